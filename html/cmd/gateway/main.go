@@ -0,0 +1,70 @@
+// Command gateway es el API gateway del sistema de inventario sin el dashboard: publica
+// el Command Service y el Query Service en un solo puerto (GATEWAY_PORT, 8090 por defecto)
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"dashboard-server/gateway"
+)
+
+func main() {
+	config := gateway.DefaultConfig()
+	port := flag.String("port", envOr("GATEWAY_PORT", "8090"), "Puerto del gateway")
+	config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	gw, err := gateway.New(config)
+	if err != nil {
+		log.Fatalf("Configuración del gateway inválida: %v", err)
+	}
+	defer gw.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	gw.Start(ctx)
+
+	// Las rutas stream (SSE, WebSocket) quitan los timeouts de lectura y escritura de su
+	// conexión y se cierran por inactividad (PROXY_STREAM_IDLE_TIMEOUT_MS)
+	server := &http.Server{
+		Addr:         ":" + *port,
+		Handler:      gw.Handler(nil),
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 60 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	fmt.Println("🚪 API Gateway iniciado")
+	fmt.Printf("🌐 URL: http://localhost:%s/api/v1/\n", *port)
+	fmt.Printf("📚 Swagger combinado: http://localhost:%s/swagger/index.html\n", *port)
+	fmt.Printf("🩺 Estado del proxy: http://localhost:%s/proxy/status\n", *port)
+	fmt.Printf("🔐 Autenticación en el borde: %s (%d rutas)\n", config.Auth, len(gw.Routes()))
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("Error al iniciar el gateway: %v", err)
+	}
+}
+
+// envOr lee una variable de entorno con valor por defecto
+func envOr(key, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,240 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// RequestIDHeader correlaciona una petición del navegador con los logs de cada servicio
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength limita el X-Request-ID que se acepta del cliente
+const maxRequestIDLength = 128
+
+// Formatos del access log (ACCESS_LOG_FORMAT)
+const (
+	accessLogText = "text"
+	accessLogJSON = "json"
+)
+
+// accessLogEntry es una línea del access log
+type accessLogEntry struct {
+	Time       time.Time `json:"time"`
+	RequestID  string    `json:"request_id"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Query      string    `json:"query,omitempty"`
+	Upstream   string    `json:"upstream,omitempty"` // Réplica que respondió; vacío si lo respondió el proxy
+	Attempts   int       `json:"attempts,omitempty"`
+	Status     int       `json:"status"`
+	LatencyMs  float64   `json:"latency_ms"`
+	Bytes      int64     `json:"bytes"`
+	RemoteAddr string    `json:"remote_addr"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+}
+
+type accessLogKey struct{}
+
+// accessLogOf devuelve la línea en curso de la petición, para que el router anote la réplica
+func accessLogOf(r *http.Request) *accessLogEntry {
+	entry, _ := r.Context().Value(accessLogKey{}).(*accessLogEntry)
+	return entry
+}
+
+// accessLogger escribe el access log, una línea por petición: en text con el formato
+// del log estándar o en JSON
+type accessLogger struct {
+	mu     sync.Mutex
+	format string
+	out    io.Writer // nil: deshabilitado
+	text   *log.Logger
+	file   *os.File // Solo si el destino es un archivo
+}
+
+// newAccessLogger abre el destino del access log: "stdout" (por defecto), "off" o la
+// ruta de un archivo, al que se agregan las líneas
+func newAccessLogger(destination, format string) (*accessLogger, error) {
+	if format != accessLogText && format != accessLogJSON {
+		return nil, fmt.Errorf("ACCESS_LOG_FORMAT inválido %q: se espera text o json", format)
+	}
+	logger := &accessLogger{format: format}
+	switch destination {
+	case "off":
+		return logger, nil
+	case "", "stdout":
+		logger.out = os.Stdout
+	default:
+		file, err := os.OpenFile(destination, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo abrir el access log: %w", err)
+		}
+		logger.out, logger.file = file, file
+	}
+	logger.text = log.New(logger.out, "", log.LstdFlags)
+	return logger, nil
+}
+
+func (l *accessLogger) write(entry *accessLogEntry) {
+	if l == nil || l.out == nil {
+		return
+	}
+	if l.format == accessLogText {
+		upstream := entry.Upstream
+		if upstream == "" {
+			upstream = "-"
+		}
+		l.text.Printf("📜 [Access] %s %s %s -> %s %d %.1fms %dB id=%s",
+			entry.RemoteAddr, entry.Method, entry.Path, upstream, entry.Status, entry.LatencyMs, entry.Bytes, entry.RequestID)
+		return
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.out.Write(append(line, '\n')); err != nil {
+		log.Printf("❌ [Access] No se pudo escribir el access log: %v", err)
+	}
+}
+
+// Close cierra el archivo del access log, si lo hay
+func (l *accessLogger) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// accessLogMiddleware asegura que cada petición tenga X-Request-ID (el del cliente si es
+// válido, si no uno nuevo), lo reenvía a los servicios, lo devuelve en la respuesta y
+// escribe la línea del access log al terminar
+func accessLogMiddleware(logger *accessLogger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			if requestID != "" {
+				log.Printf("⚠️  [Access] X-Request-ID inválido descartado (%d caracteres)", len(requestID))
+			}
+			requestID = newRequestID()
+			r.Header.Set(RequestIDHeader, requestID)
+		}
+
+		entry := &accessLogEntry{
+			RequestID:  requestID,
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Query:      r.URL.RawQuery,
+			RemoteAddr: r.RemoteAddr,
+			UserAgent:  r.UserAgent(),
+		}
+		recorder := &accessLogWriter{ResponseWriter: w, requestID: requestID}
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), accessLogKey{}, entry)))
+
+		entry.Time = start.UTC()
+		entry.Status = recorder.statusCode()
+		entry.Bytes = recorder.bytes
+		entry.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+		logger.write(entry)
+	})
+}
+
+// validRequestID acepta IDs de hasta maxRequestIDLength caracteres imprimibles sin
+// espacios, para que un header malicioso no pueda partir las líneas del log
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID genera un UUID v4, el mismo formato que generan los servicios
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%032x", time.Now().UnixNano())
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// accessLogWriter registra el status y los bytes de la respuesta, y agrega X-Request-ID
+// si el servicio no lo devolvió
+type accessLogWriter struct {
+	http.ResponseWriter
+	requestID string
+	status    int
+	bytes     int64
+}
+
+func (w *accessLogWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+		if w.Header().Get(RequestIDHeader) == "" {
+			w.Header().Set(RequestIDHeader, w.requestID)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessLogWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(data)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush mantiene el streaming (SSE, exportaciones) a través del proxy
+func (w *accessLogWriter) Flush() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack deja pasar los WebSocket; el proxy escribe el 101 directo en la conexión, así
+// que el status y X-Request-ID se registran aquí
+func (w *accessLogWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.Header().Get(RequestIDHeader) == "" {
+		w.Header().Set(RequestIDHeader, w.requestID)
+	}
+	conn, brw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, brw, err
+}
+
+// Unwrap deja que http.ResponseController llegue al writer original
+func (w *accessLogWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *accessLogWriter) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
@@ -0,0 +1,255 @@
+package gateway
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Modos de GATEWAY_AUTH
+const (
+	authJWT = "jwt" // Valida el JWT de las rutas no públicas antes de reenviarlas
+	authOff = "off" // Deja toda la autenticación a los servicios
+)
+
+// defaultTenant es el tenant de los tokens sin claim tenant_id (tenant.Default en los
+// servicios)
+const defaultTenant = "default"
+
+// apiKeyHeader es el header de las API keys de los servicios; el gateway no puede
+// validarlas (el hash vive en la base de cada servicio) y las deja pasar
+const apiKeyHeader = "X-API-Key"
+
+var (
+	errMissingCredentials = errors.New("falta el header Authorization: Bearer <token> o X-API-Key")
+	errInvalidToken       = errors.New("token inválido")
+	errExpiredToken       = errors.New("token expirado")
+	// errUnverifiedToken marca un token asimétrico sin JWT_JWKS_URL: el gateway no tiene
+	// la clave y lo deja pasar para que lo valide el servicio
+	errUnverifiedToken = errors.New("token asimétrico sin JWKS en el gateway")
+)
+
+// jwtValidator valida en el borde los JWT HS256/HS384/HS512 que emiten los servicios
+// (mismo JWT_SECRET) y, con JWT_JWKS_URL, los RS256/ES256 del IdP. Replica los chequeos
+// de los servicios salvo la lista de revocación, que sigue a cargo de cada servicio.
+type jwtValidator struct {
+	secret         []byte
+	audience       string   // aud requerido; vacío acepta cualquiera
+	trustedIssuers []string // iss aceptados; vacío acepta cualquiera
+	clockSkew      time.Duration
+	now            func() time.Time
+	jwks           *jwksKeySet // nil sin JWT_JWKS_URL
+}
+
+// edgeClaims son los claims que el gateway usa para identificar al cliente
+type edgeClaims struct {
+	Username          string          `json:"username"`
+	PreferredUsername string          `json:"preferred_username"`
+	Role              string          `json:"role"`
+	TenantID          string          `json:"tenant_id"`
+	Subject           string          `json:"sub"`
+	Issuer            string          `json:"iss"`
+	Audience          json.RawMessage `json:"aud"` // Un string o una lista
+	ExpiresAt         *float64        `json:"exp"`
+	NotBefore         *float64        `json:"nbf"`
+	IssuedAt          *float64        `json:"iat"`
+}
+
+// validate verifica la firma, los tiempos (con la tolerancia de reloj), el emisor y la
+// audiencia de token
+func (v *jwtValidator) validate(token string) (*edgeClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errInvalidToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, errInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errInvalidToken
+	}
+	var newHash func() hash.Hash
+	switch header.Alg {
+	case "HS256":
+		newHash = sha256.New
+	case "HS384":
+		newHash = sha512.New384
+	case "HS512":
+		newHash = sha512.New
+	}
+	switch {
+	case newHash != nil:
+		mac := hmac.New(newHash, v.secret)
+		mac.Write([]byte(parts[0] + "." + parts[1]))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return nil, errInvalidToken
+		}
+	case !isAsymmetric(header.Alg):
+		return nil, errInvalidToken
+	case v.jwks == nil:
+		return nil, errUnverifiedToken
+	default:
+		// Como los servicios, del JWKS solo se aceptan RS256 y ES256
+		key, err := v.jwks.key(header.Kid)
+		if err != nil || !verifyAsymmetric(header.Alg, key, parts[0]+"."+parts[1], signature) {
+			return nil, errInvalidToken
+		}
+	}
+
+	var claims edgeClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, errInvalidToken
+	}
+	now := v.now()
+	if claims.ExpiresAt != nil && now.Add(-v.clockSkew).After(unixTime(*claims.ExpiresAt)) {
+		return nil, errExpiredToken
+	}
+	if claims.NotBefore != nil && now.Add(v.clockSkew).Before(unixTime(*claims.NotBefore)) {
+		return nil, errInvalidToken
+	}
+	if claims.IssuedAt != nil && now.Add(v.clockSkew).Before(unixTime(*claims.IssuedAt)) {
+		return nil, errInvalidToken
+	}
+	if len(v.trustedIssuers) > 0 && !contains(v.trustedIssuers, claims.Issuer) {
+		return nil, errInvalidToken
+	}
+	if v.audience != "" && !contains(claims.audiences(), v.audience) {
+		return nil, errInvalidToken
+	}
+	if claims.Username == "" {
+		claims.Username = claims.PreferredUsername
+	}
+	if claims.TenantID == "" {
+		claims.TenantID = defaultTenant
+	}
+	return &claims, nil
+}
+
+// isAsymmetric indica si alg es una firma de clave pública (RSA, RSA-PSS o ECDSA)
+func isAsymmetric(alg string) bool {
+	return strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS") || strings.HasPrefix(alg, "ES")
+}
+
+// audiences devuelve aud como lista, sea un string o un arreglo
+func (c *edgeClaims) audiences() []string {
+	var single string
+	if json.Unmarshal(c.Audience, &single) == nil {
+		return []string{single}
+	}
+	var list []string
+	json.Unmarshal(c.Audience, &list)
+	return list
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIdentity identifica al cliente de una petición para el rate limit y el caché
+type clientIdentity struct {
+	key   string // Tenant y usuario del JWT, hash de la API key o IP
+	scope string // Lo que determina el contenido de una respuesta: tenant y rol, API key o credencial
+}
+
+type clientIdentityKey struct{}
+
+// identityOf devuelve la identidad que authenticate guardó en el contexto
+func identityOf(r *http.Request) clientIdentity {
+	identity, _ := r.Context().Value(clientIdentityKey{}).(clientIdentity)
+	return identity
+}
+
+// authenticate valida las credenciales de r (solo el JWT: la API key y los tokens
+// asimétricos sin JWKS los valida el servicio) y devuelve la petición con la identidad
+// del cliente en el contexto. Sin validator (GATEWAY_AUTH=off) o en rutas públicas no
+// rechaza ninguna petición.
+func authenticate(r *http.Request, validator *jwtValidator, public bool) (*http.Request, error) {
+	identity := clientIdentity{key: "ip:" + clientIP(r)}
+	bearer, hasBearer := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	apiKey := r.Header.Get(apiKeyHeader)
+
+	switch {
+	case hasBearer && validator != nil:
+		claims, err := validator.validate(strings.TrimSpace(bearer))
+		if errors.Is(err, errUnverifiedToken) {
+			identity = clientIdentity{key: "token:" + digest(bearer), scope: "token:" + digest(bearer)}
+			break
+		}
+		if err != nil {
+			if !public {
+				return r, err
+			}
+			break
+		}
+		// El rate limit es por usuario; el caché se comparte entre los usuarios del mismo
+		// rol, pero no entre tenants porque cada uno tiene su read model
+		identity = clientIdentity{
+			key:   "tenant:" + claims.TenantID + "|user:" + claims.Username,
+			scope: "tenant:" + claims.TenantID + "|role:" + claims.Role,
+		}
+	case hasBearer:
+		// Sin validación en el borde la respuesta depende de la credencial completa
+		identity = clientIdentity{key: "token:" + digest(bearer), scope: "token:" + digest(bearer)}
+	case apiKey != "":
+		identity = clientIdentity{key: "key:" + digest(apiKey), scope: "key:" + digest(apiKey)}
+	case validator != nil && !public:
+		return r, errMissingCredentials
+	}
+	return r.WithContext(context.WithValue(r.Context(), clientIdentityKey{}, identity)), nil
+}
+
+// digest es un resumen corto de una credencial, para no guardarla en memoria ni en logs
+func digest(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:8])
+}
+
+// clientIP es la IP de la conexión; X-Forwarded-For no se usa porque el cliente la controla
+func clientIP(r *http.Request) string {
+	host := r.RemoteAddr
+	if i := strings.LastIndex(host, ":"); i > 0 {
+		host = host[:i]
+	}
+	return strings.Trim(host, "[]")
+}
+
+// writeAuthError responde 401 con el envelope de error de los servicios
+func writeAuthError(w http.ResponseWriter, r *http.Request, err error) {
+	message := "Token inválido o expirado"
+	if errors.Is(err, errMissingCredentials) {
+		message = "Se requiere autenticación"
+	}
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	writeProxyError(w, r, http.StatusUnauthorized, "Unauthorized", message, err.Error())
+}
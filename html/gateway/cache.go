@@ -0,0 +1,230 @@
+package gateway
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCachedBodyBytes es el tamaño máximo de una respuesta guardada en caché
+const maxCachedBodyBytes = 1 << 20
+
+// Valores del header X-Cache
+const (
+	cacheHit    = "HIT"
+	cacheMiss   = "MISS"
+	cacheBypass = "BYPASS" // El cliente pidió Cache-Control: no-cache
+)
+
+// responseCache guarda las respuestas 200 de las rutas GET con cache_seconds. Las
+// escrituras exitosas que pasan por el gateway lo vacían; como el read model se actualiza
+// de forma asíncrona, el TTL sigue acotando cuánto puede atrasarse una lectura.
+type responseCache struct {
+	mu         sync.Mutex
+	entries    map[string]*cachedResponse
+	maxEntries int
+	now        func() time.Time
+}
+
+type cachedResponse struct {
+	status   int
+	header   http.Header
+	body     []byte
+	storedAt time.Time
+	expires  time.Time
+}
+
+func newResponseCache(maxEntries int) *responseCache {
+	return &responseCache{entries: make(map[string]*cachedResponse), maxEntries: maxEntries, now: time.Now}
+}
+
+// cacheKey separa las respuestas por recurso, por lo que determina su contenido (tenant y
+// rol, o credencial) y por los headers de negociación
+func cacheKey(r *http.Request) string {
+	return strings.Join([]string{
+		r.Method, r.URL.Path, r.URL.RawQuery, identityOf(r).scope,
+		r.Header.Get("Accept"), r.Header.Get("Accept-Encoding"),
+	}, "\x00")
+}
+
+func (c *responseCache) get(key string) (*cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry, true
+}
+
+func (c *responseCache) set(key string, entry *cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= c.maxEntries {
+		c.evictLocked()
+	}
+	if len(c.entries) < c.maxEntries {
+		c.entries[key] = entry
+	}
+}
+
+// evictLocked descarta las entradas vencidas y, si no alcanza, la que vence primero
+func (c *responseCache) evictLocked() {
+	now := c.now()
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+			continue
+		}
+		if oldestKey == "" || entry.expires.Before(oldest) {
+			oldestKey, oldest = key, entry.expires
+		}
+	}
+	if len(c.entries) >= c.maxEntries && oldestKey != "" {
+		delete(c.entries, oldestKey)
+	}
+}
+
+// purge vacía el caché
+func (c *responseCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*cachedResponse)
+}
+
+// size es la cantidad de respuestas guardadas
+func (c *responseCache) size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// serve responde r desde el caché o con next, guardando la respuesta si se puede
+func (c *responseCache) serve(w http.ResponseWriter, r *http.Request, ttl time.Duration, next http.Handler) {
+	key := cacheKey(r)
+	bypass := strings.Contains(r.Header.Get("Cache-Control"), "no-cache")
+	if !bypass {
+		if entry, ok := c.get(key); ok {
+			for name, values := range entry.header {
+				w.Header()[name] = values
+			}
+			w.Header().Set("X-Cache", cacheHit)
+			w.Header().Set("Age", strconv.Itoa(int(c.now().Sub(entry.storedAt).Seconds())))
+			w.WriteHeader(entry.status)
+			if r.Method != http.MethodHead {
+				w.Write(entry.body)
+			}
+			return
+		}
+	}
+
+	state := cacheMiss
+	if bypass {
+		state = cacheBypass
+	}
+	w.Header().Set("X-Cache", state)
+	recorder := &cacheRecorder{ResponseWriter: w}
+	next.ServeHTTP(recorder, r)
+	if recorder.cacheable() && r.Method == http.MethodGet {
+		header := recorder.Header().Clone()
+		header.Del("X-Cache")
+		header.Del(RequestIDHeader)
+		now := c.now()
+		c.set(key, &cachedResponse{
+			status:   recorder.status,
+			header:   header,
+			body:     recorder.body.Bytes(),
+			storedAt: now,
+			expires:  now.Add(ttl),
+		})
+	}
+}
+
+// cacheRecorder copia la respuesta mientras la escribe, hasta maxCachedBodyBytes
+type cacheRecorder struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *cacheRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *cacheRecorder) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if !w.overflow {
+		if w.body.Len()+len(data) > maxCachedBodyBytes {
+			w.overflow = true
+			w.body.Reset()
+		} else {
+			w.body.Write(data)
+		}
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *cacheRecorder) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *cacheRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// cacheable indica si la respuesta se puede guardar: 200 completa, sin cookies y sin
+// Cache-Control no-store o private del servicio
+func (w *cacheRecorder) cacheable() bool {
+	if w.status != http.StatusOK || w.overflow || w.Header().Get("Set-Cookie") != "" {
+		return false
+	}
+	control := w.Header().Get("Cache-Control")
+	return !strings.Contains(control, "no-store") && !strings.Contains(control, "private")
+}
+
+// statusRecorder registra el status de la respuesta
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *statusRecorder) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
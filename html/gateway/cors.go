@@ -0,0 +1,95 @@
+package gateway
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// corsAllowedMethods son los métodos que el proxy reenvía
+const corsAllowedMethods = "GET, POST, PUT, DELETE, OPTIONS, PATCH"
+
+// corsPolicy define qué orígenes pueden llamar al proxy desde un navegador
+type corsPolicy struct {
+	origins  map[string]bool
+	allowAny bool // "*": cualquier origen, sin credenciales
+	headers  string
+	maxAge   string
+}
+
+// newCORSPolicy arma la política con la lista de orígenes permitidos (CORS_ALLOWED_ORIGINS,
+// "*" para cualquiera), los headers permitidos y el max-age del preflight en segundos
+func newCORSPolicy(origins, headers string, maxAge int) (corsPolicy, error) {
+	policy := corsPolicy{
+		origins: make(map[string]bool),
+		headers: strings.Join(splitList(headers), ", "),
+		maxAge:  strconv.Itoa(maxAge),
+	}
+	for _, origin := range splitList(origins) {
+		if origin == "*" {
+			policy.allowAny = true
+			continue
+		}
+		normalized, ok := normalizeOrigin(origin)
+		if !ok {
+			return policy, fmt.Errorf("origen CORS inválido %q: se espera scheme://host[:puerto]", origin)
+		}
+		policy.origins[normalized] = true
+	}
+	return policy, nil
+}
+
+// normalizeOrigin devuelve el origen como scheme://host[:puerto] en minúsculas
+func normalizeOrigin(origin string) (string, bool) {
+	parsed, err := url.Parse(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" || parsed.Path != "" || parsed.RawQuery != "" {
+		return "", false
+	}
+	return strings.ToLower(parsed.Scheme + "://" + parsed.Host), true
+}
+
+// corsMiddleware agrega los headers CORS para los orígenes permitidos. Un origen de la
+// lista recibe su propio valor y credenciales; con "*" se responde "*" sin credenciales.
+// Los preflight de orígenes no permitidos reciben 403.
+func corsMiddleware(cors corsPolicy, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		allowed := origin == ""
+		if origin != "" {
+			w.Header().Add("Vary", "Origin")
+			normalized, ok := normalizeOrigin(origin)
+			switch {
+			case ok && cors.origins[normalized]:
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+				allowed = true
+			case cors.allowAny:
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+				allowed = true
+			}
+			if allowed {
+				w.Header().Set("Access-Control-Allow-Methods", corsAllowedMethods)
+				w.Header().Set("Access-Control-Allow-Headers", cors.headers)
+				w.Header().Set("Access-Control-Max-Age", cors.maxAge)
+				w.Header().Set("Access-Control-Expose-Headers", RequestIDHeader+", Retry-After, X-Canary-Upstream")
+			}
+		}
+
+		// Manejar preflight requests (OPTIONS)
+		if r.Method == "OPTIONS" {
+			if !allowed {
+				log.Printf("🚫 [CORS] Preflight rechazado para el origen %s", origin)
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		// Continuar con el siguiente handler
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,379 @@
+// Package gateway es el API gateway del sistema de inventario: publica el Command
+// Service y el Query Service detrás de una sola URL según una tabla de rutas, valida los
+// JWT en el borde, limita las peticiones por ruta, guarda en caché las lecturas y combina
+// la documentación Swagger de ambos servicios. Lo usan el binario cmd/gateway y el
+// servidor del dashboard.
+package gateway
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Réplicas por defecto (COMMAND_SERVICE_URLS, QUERY_SERVICE_URLS)
+const (
+	defaultCommandServiceURL = "http://localhost:8080"
+	defaultQueryServiceURL   = "http://localhost:8081"
+)
+
+// defaultJWTSecret es el JWT_SECRET por defecto de los servicios
+const defaultJWTSecret = "your-secret-key-change-in-production-min-32-chars"
+
+// Config es la configuración del gateway. DefaultConfig la lee de las variables de
+// entorno y RegisterFlags permite sobrescribirla con flags.
+type Config struct {
+	CommandURLs       string // Réplicas del Command Service, separadas por coma (url o url;timeout=5s)
+	QueryURLs         string // Réplicas del Query Service
+	CommandCanaryURLs string // Réplicas canary del Command Service (opcional)
+	QueryCanaryURLs   string // Réplicas canary del Query Service (opcional)
+	CanaryPercent     int    // Porcentaje del tráfico enviado al canary (0-100)
+	ListenerURL       string // Solo para el health check agregado
+
+	UpstreamsFile   string        // JSON de réplicas recargado en caliente; reemplaza a las URLs
+	UpstreamsReload time.Duration // Cada cuánto se revisa UpstreamsFile
+	Strategy        string        // round-robin o least-connections
+
+	StreamIdleTimeout time.Duration // Cierra un SSE o WebSocket sin tráfico; 0 sin límite
+
+	RoutesFile      string // JSON con la tabla de rutas; vacío usa la tabla por defecto
+	Auth            string // jwt u off
+	JWTSecret       string
+	JWTAudience     string
+	JWTIssuers      string // iss aceptados, separados por coma; vacío acepta cualquiera
+	JWTClockSkew    time.Duration
+	JWTJWKSURL      string        // JWKS para verificar tokens RS256/ES256; vacío los deja pasar a los servicios
+	JWTJWKSRefresh  time.Duration // Cada cuánto se vuelve a descargar el JWKS
+	CacheMaxEntries int           // 0 desactiva el caché de respuestas
+
+	CORSOrigins string
+	CORSHeaders string
+	CORSMaxAge  int
+
+	AccessLog       string // stdout, off o la ruta de un archivo
+	AccessLogFormat string // text o json
+
+	policy proxyPolicy // Timeouts, reintentos y circuit breaker (PROXY_*)
+}
+
+// DefaultConfig lee la configuración de las variables de entorno
+func DefaultConfig() Config {
+	// Sin CORS_ALLOWED_ORIGINS, en development (ENVIRONMENT, por defecto) se permite el
+	// propio dashboard en localhost:8000 y en otros entornos ningún origen externo
+	defaultOrigins := ""
+	if getEnv("ENVIRONMENT", "development") == "development" {
+		defaultOrigins = "http://localhost:8000,http://127.0.0.1:8000"
+	}
+	policy := loadProxyPolicy()
+	return Config{
+		CommandURLs:       getEnv("COMMAND_SERVICE_URLS", defaultCommandServiceURL),
+		QueryURLs:         getEnv("QUERY_SERVICE_URLS", defaultQueryServiceURL),
+		CommandCanaryURLs: os.Getenv("COMMAND_CANARY_URL"),
+		QueryCanaryURLs:   os.Getenv("QUERY_CANARY_URL"),
+		CanaryPercent:     getEnvAsInt("CANARY_PERCENT", 0),
+		ListenerURL:       getEnv("LISTENER_SERVICE_URL", defaultListenerServiceURL),
+		UpstreamsFile:     os.Getenv("PROXY_UPSTREAMS_FILE"),
+		UpstreamsReload:   time.Duration(getEnvAsInt("PROXY_UPSTREAMS_RELOAD_MS", 2000)) * time.Millisecond,
+		Strategy:          policy.Strategy,
+		StreamIdleTimeout: time.Duration(getEnvAsInt("PROXY_STREAM_IDLE_TIMEOUT_MS", 60000)) * time.Millisecond,
+		RoutesFile:        os.Getenv("GATEWAY_ROUTES_FILE"),
+		Auth:              getEnv("GATEWAY_AUTH", authJWT),
+		JWTSecret:         getEnv("JWT_SECRET", defaultJWTSecret),
+		JWTAudience:       os.Getenv("JWT_AUDIENCE"),
+		JWTIssuers:        os.Getenv("JWT_TRUSTED_ISSUERS"),
+		JWTClockSkew:      time.Duration(getEnvAsInt("JWT_CLOCK_SKEW_SECONDS", 30)) * time.Second,
+		JWTJWKSURL:        os.Getenv("JWT_JWKS_URL"),
+		JWTJWKSRefresh:    time.Duration(getEnvAsInt("JWT_JWKS_REFRESH_SECONDS", 300)) * time.Second,
+		CacheMaxEntries:   getEnvAsInt("GATEWAY_CACHE_MAX_ENTRIES", 1000),
+		CORSOrigins:       getEnv("CORS_ALLOWED_ORIGINS", defaultOrigins),
+		CORSHeaders:       getEnv("CORS_ALLOWED_HEADERS", "Content-Type, Authorization, Accept, X-Request-ID, X-Canary, If-Match, X-API-Key, Cache-Control"),
+		CORSMaxAge:        getEnvAsInt("CORS_MAX_AGE", 3600),
+		AccessLog:         getEnv("ACCESS_LOG", "stdout"),
+		AccessLogFormat:   getEnv("ACCESS_LOG_FORMAT", accessLogText),
+		policy:            policy,
+	}
+}
+
+// RegisterFlags registra en fs los flags que sobrescriben la configuración
+func (c *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.CommandURLs, "command-url", c.CommandURLs, "Réplicas del Command Service, separadas por coma (url o url;timeout=5s)")
+	fs.StringVar(&c.QueryURLs, "query-url", c.QueryURLs, "Réplicas del Query Service, separadas por coma (url o url;timeout=5s)")
+	fs.StringVar(&c.CommandCanaryURLs, "command-canary-url", c.CommandCanaryURLs, "Réplicas del Command Service canary, separadas por coma (opcional)")
+	fs.StringVar(&c.QueryCanaryURLs, "query-canary-url", c.QueryCanaryURLs, "Réplicas del Query Service canary, separadas por coma (opcional)")
+	fs.IntVar(&c.CanaryPercent, "canary-percent", c.CanaryPercent, "Porcentaje de tráfico enviado al canary (0-100)")
+	fs.StringVar(&c.ListenerURL, "listener-url", c.ListenerURL, "URL del Listener Service (solo health check)")
+	fs.StringVar(&c.UpstreamsFile, "upstreams-file", c.UpstreamsFile, "Archivo JSON con las réplicas, recargado en caliente; reemplaza a -command-url, -query-url y las URLs canary (opcional)")
+	fs.StringVar(&c.Strategy, "lb-strategy", c.Strategy, "Balanceo entre réplicas: round-robin o least-connections")
+	fs.StringVar(&c.RoutesFile, "routes-file", c.RoutesFile, "Archivo JSON con la tabla de rutas (opcional)")
+	fs.StringVar(&c.Auth, "auth", c.Auth, "Validación de JWT en el gateway: jwt u off")
+	fs.StringVar(&c.AccessLog, "access-log", c.AccessLog, "Destino del access log: stdout, off o la ruta de un archivo")
+	fs.StringVar(&c.AccessLogFormat, "access-log-format", c.AccessLogFormat, "Formato del access log: text o json")
+}
+
+// Gateway enruta las peticiones /api/v1/ a los servicios
+type Gateway struct {
+	config    Config
+	policy    proxyPolicy
+	command   *canaryRouter
+	query     *canaryRouter
+	routes    []Route
+	validator *jwtValidator // nil con GATEWAY_AUTH=off
+	limiter   *rateLimiter
+	cache     *responseCache // nil con GATEWAY_CACHE_MAX_ENTRIES=0
+	cors      corsPolicy
+	accessLog *accessLogger
+}
+
+// New valida la configuración y crea el gateway. Start inicia los health checks.
+func New(config Config) (*Gateway, error) {
+	policy := config.policy
+	if policy == (proxyPolicy{}) {
+		policy = loadProxyPolicy()
+	}
+	policy.Strategy = config.Strategy
+	if policy.Strategy != balanceRoundRobin && policy.Strategy != balanceLeastConnections {
+		return nil, fmt.Errorf("LB_STRATEGY inválida %q: se espera round-robin o least-connections", policy.Strategy)
+	}
+
+	upstreams := upstreamConfig{
+		Command:       splitList(config.CommandURLs),
+		CommandCanary: splitList(config.CommandCanaryURLs),
+		Query:         splitList(config.QueryURLs),
+		QueryCanary:   splitList(config.QueryCanaryURLs),
+	}
+	if config.UpstreamsFile != "" {
+		var err error
+		if upstreams, err = loadUpstreamsFile(config.UpstreamsFile); err != nil {
+			return nil, fmt.Errorf("archivo de réplicas: %w", err)
+		}
+	}
+	specs, err := upstreams.specs()
+	if err != nil {
+		return nil, fmt.Errorf("réplicas: %w", err)
+	}
+
+	routes := defaultRoutes
+	if config.RoutesFile != "" {
+		if routes, err = loadRoutesFile(config.RoutesFile); err != nil {
+			return nil, fmt.Errorf("tabla de rutas: %w", err)
+		}
+	}
+
+	var validator *jwtValidator
+	switch config.Auth {
+	case authJWT:
+		if config.JWTSecret == "" {
+			return nil, fmt.Errorf("JWT_SECRET es obligatorio con GATEWAY_AUTH=jwt")
+		}
+		validator = &jwtValidator{
+			secret:         []byte(config.JWTSecret),
+			audience:       config.JWTAudience,
+			trustedIssuers: splitList(config.JWTIssuers),
+			clockSkew:      config.JWTClockSkew,
+			now:            time.Now,
+		}
+		if config.JWTJWKSURL != "" {
+			validator.jwks = newJWKSKeySet(config.JWTJWKSURL, config.JWTJWKSRefresh)
+			if err := validator.jwks.fetch(); err != nil {
+				// Se reintenta con el primer token; mientras tanto los RS256/ES256 dan 401
+				log.Printf("⚠️  [Gateway] No se pudo descargar el JWKS %s: %v", config.JWTJWKSURL, err)
+			}
+		}
+	case authOff:
+	default:
+		return nil, fmt.Errorf("GATEWAY_AUTH inválido %q: se espera jwt u off", config.Auth)
+	}
+
+	cors, err := newCORSPolicy(config.CORSOrigins, config.CORSHeaders, config.CORSMaxAge)
+	if err != nil {
+		return nil, err
+	}
+	accessLog, err := newAccessLogger(config.AccessLog, config.AccessLogFormat)
+	if err != nil {
+		return nil, fmt.Errorf("access log: %w", err)
+	}
+
+	g := &Gateway{
+		config: config,
+		policy: policy,
+		// Si hay réplicas canary configuradas, parte del tráfico se envía a la nueva versión
+		command:   newCanaryRouter(serviceCommand, specs.command, specs.commandCanary, config.CanaryPercent, policy),
+		query:     newCanaryRouter(serviceQuery, specs.query, specs.queryCanary, config.CanaryPercent, policy),
+		routes:    routes,
+		validator: validator,
+		limiter:   newRateLimiter(),
+		cors:      cors,
+		accessLog: accessLog,
+	}
+	if config.CacheMaxEntries > 0 {
+		g.cache = newResponseCache(config.CacheMaxEntries)
+	}
+	return g, nil
+}
+
+// Start inicia el health checking de las réplicas, la recarga del archivo de réplicas y
+// la limpieza del rate limiter hasta que ctx termine
+func (g *Gateway) Start(ctx context.Context) {
+	// Health checking activo de cada réplica (PROXY_HEALTH_INTERVAL_MS)
+	startHealthChecks(ctx, func() []*upstream {
+		return append(g.command.upstreams(), g.query.upstreams()...)
+	}, g.policy)
+
+	// Recarga en caliente de las réplicas (cambios del archivo o SIGHUP)
+	if g.config.UpstreamsFile != "" {
+		go watchUpstreamsFile(ctx, g.config.UpstreamsFile, g.config.UpstreamsReload, g.command, g.query)
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				g.limiter.sweep()
+			}
+		}
+	}()
+}
+
+// Close cierra el access log
+func (g *Gateway) Close() error {
+	return g.accessLog.Close()
+}
+
+// Handler devuelve el handler HTTP del gateway. Las rutas que no son del gateway van a
+// fallback (p. ej. los archivos estáticos del dashboard); sin fallback responden 404.
+func (g *Gateway) Handler(fallback http.Handler) http.Handler {
+	mux := http.NewServeMux()
+
+	// Estado de salud y circuit breaker de cada réplica
+	mux.Handle("/proxy/status", newProxyStatusHandler(g.command, g.query))
+
+	// Veredicto combinado de los tres servicios (gating de despliegues)
+	mux.Handle("/api/v1/health/all", newAggregateHealthHandler(healthTargets(g.command, g.query, g.config.ListenerURL), g.policy.HealthTimeout))
+
+	// Documentación Swagger combinada de ambos servicios
+	mux.Handle("/swagger/doc.json", newOpenAPIHandler([]*canaryRouter{g.command, g.query}, g.routes, g.policy.HealthTimeout))
+	mux.HandleFunc("/swagger/index.html", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(swaggerUIPage))
+	})
+
+	// Todas las peticiones /api/v1/ según la tabla de rutas
+	mux.HandleFunc("/api/v1/", g.serveAPI)
+
+	if fallback == nil {
+		fallback = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeProxyError(w, r, http.StatusNotFound, "NotFound", "Ruta no encontrada", r.URL.Path)
+		})
+	}
+	mux.Handle("/", fallback)
+
+	// Access log con X-Request-ID (ACCESS_LOG, ACCESS_LOG_FORMAT); va primero para
+	// registrar también los preflight rechazados por CORS
+	return accessLogMiddleware(g.accessLog, corsMiddleware(g.cors, mux))
+}
+
+// Routes devuelve la tabla de rutas vigente
+func (g *Gateway) Routes() []Route {
+	return g.routes
+}
+
+// serveAPI aplica a la petición la primera ruta que coincide: autenticación, rate limit,
+// caché y reescritura, y la envía al servicio de la ruta
+func (g *Gateway) serveAPI(w http.ResponseWriter, r *http.Request) {
+	rt, ok := matchRoute(g.routes, r.Method, r.URL.Path)
+	if !ok {
+		writeProxyError(w, r, http.StatusNotFound, "NotFound", "Ruta no publicada por el gateway", r.Method+" "+r.URL.Path)
+		return
+	}
+
+	r, err := authenticate(r, g.validator, rt.Public)
+	if err != nil {
+		log.Printf("🔐 [Gateway] %s %s rechazada (ruta %s): %v", r.Method, r.URL.Path, rt.Name, err)
+		writeAuthError(w, r, err)
+		return
+	}
+
+	if rt.RateLimit > 0 {
+		if ok, wait := g.limiter.allow(rt.Name+"|"+identityOf(r).key, rt.RateLimit); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait/time.Second)+1))
+			writeProxyError(w, r, http.StatusTooManyRequests, "RateLimited",
+				"Demasiadas peticiones", fmt.Sprintf("la ruta %s admite %d peticiones por minuto", rt.Name, rt.RateLimit))
+			return
+		}
+	}
+
+	if rt.Rewrite != "" {
+		r.URL.Path = rt.Rewrite
+		r.URL.RawPath = ""
+	}
+	router := g.query
+	if rt.Service == serviceCommand {
+		router = g.command
+	}
+
+	// SSE y WebSocket: sin los timeouts del servidor y con cierre por inactividad
+	if rt.Stream || isUpgrade(r) {
+		serveStream(w, r, g.config.StreamIdleTimeout, router)
+		return
+	}
+
+	if g.cache != nil && rt.CacheSeconds > 0 && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+		g.cache.serve(w, r, time.Duration(rt.CacheSeconds)*time.Second, router)
+		return
+	}
+
+	if g.cache == nil || rt.Service != serviceCommand || safeMethod(r.Method) {
+		router.ServeHTTP(w, r)
+		return
+	}
+
+	// Una escritura exitosa invalida las lecturas guardadas
+	recorder := &statusRecorder{ResponseWriter: w}
+	router.ServeHTTP(recorder, r)
+	if recorder.status < http.StatusBadRequest {
+		g.cache.purge()
+	}
+}
+
+// safeMethod indica si method no modifica datos
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// getEnv lee una variable de entorno con valor por defecto
+func getEnv(key, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvAsInt lee una variable de entorno entera con valor por defecto
+func getEnvAsInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// splitList separa una lista separada por comas, descartando elementos vacíos
+func splitList(value string) []string {
+	var result []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
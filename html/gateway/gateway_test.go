@@ -0,0 +1,482 @@
+package gateway
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testSecret = "test-secret-key-with-at-least-32-chars"
+
+// signToken firma un JWT HS256 con los claims dados
+func signToken(t *testing.T, secret string, claims map[string]interface{}) string {
+	t.Helper()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// validClaims son los claims de un token recién emitido por los servicios
+func validClaims() map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"username": "admin", "role": "admin", "iss": "command-service",
+		"iat": now.Unix(), "nbf": now.Unix(), "exp": now.Add(10 * time.Minute).Unix(),
+	}
+}
+
+// newTestGateway crea un gateway con validación JWT apuntando a los servidores dados
+func newTestGateway(t *testing.T, commandURL, queryURL string) *Gateway {
+	t.Helper()
+	gw, err := New(Config{
+		CommandURLs:     commandURL,
+		QueryURLs:       queryURL,
+		Strategy:        balanceRoundRobin,
+		Auth:            authJWT,
+		JWTSecret:       testSecret,
+		JWTClockSkew:    30 * time.Second,
+		CacheMaxEntries: 10,
+		AccessLog:       "off",
+		AccessLogFormat: accessLogText,
+		policy:          testProxyPolicy(),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Cleanup(func() { gw.Close() })
+	return gw
+}
+
+// TestDefaultRoutes verifica que la tabla por defecto sea válida y envíe cada ruta al
+// servicio que la atiende
+func TestDefaultRoutes(t *testing.T) {
+	if err := validateRoutes(defaultRoutes); err != nil {
+		t.Fatalf("Invalid default routes: %v", err)
+	}
+
+	tests := []struct {
+		method, path string
+		route        string
+		service      string
+	}{
+		{"GET", "/api/v1/health/query", "health-query", serviceQuery},
+		{"GET", "/api/v1/health/ready", "health", serviceCommand},
+		{"POST", "/api/v1/auth/login", "login", serviceCommand},
+		{"DELETE", "/api/v1/auth/api-keys/1", "auth", serviceCommand},
+		{"GET", "/api/v1/inventory/items/1/history", "inventory-items", serviceQuery},
+		{"HEAD", "/api/v1/inventory/items", "inventory-items", serviceQuery},
+		{"POST", "/api/v1/inventory/items", "command", serviceCommand},
+		{"POST", "/api/v1/inventory/items/batch", "inventory-batch", serviceQuery},
+		{"GET", "/api/v1/stores/1/reservations", "stores-read", serviceQuery},
+		{"PUT", "/api/v1/stores/1", "command", serviceCommand},
+		{"GET", "/api/v1/audit/export", "command", serviceCommand},
+		{"GET", "/api/v1/images/items/1/photo.jpg", "images", serviceQuery},
+		{"GET", "/api/v1/suppliers", "suppliers-read", serviceQuery},
+		{"PUT", "/api/v1/suppliers/1", "command", serviceCommand},
+		{"GET", "/api/v1/purchase-orders", "purchase-orders-read", serviceQuery},
+		{"GET", "/api/v1/purchase-orders/1", "purchase-orders-read", serviceQuery},
+		{"POST", "/api/v1/purchase-orders/1/receive", "command", serviceCommand},
+		{"DELETE", "/api/v1/images/items/1/photo.jpg", "command", serviceCommand},
+	}
+	for _, tt := range tests {
+		rt, ok := matchRoute(defaultRoutes, tt.method, tt.path)
+		if !ok || rt.Name != tt.route || rt.Service != tt.service {
+			t.Errorf("%s %s: expected route %s (%s), got %s (%s)", tt.method, tt.path, tt.route, tt.service, rt.Name, rt.Service)
+		}
+	}
+}
+
+// TestLoadRoutesFile verifica la lectura y validación de GATEWAY_ROUTES_FILE
+func TestLoadRoutesFile(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "routes.json")
+	os.WriteFile(valid, []byte(`[
+		{"name": "items", "methods": ["GET"], "path": "/api/v1/inventory/items*", "service": "query", "cache_seconds": 5},
+		{"name": "rest", "path": "/api/v1/*", "service": "command", "rate_limit": 60}
+	]`), 0o644)
+	routes, err := loadRoutesFile(valid)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(routes) != 2 || routes[0].CacheSeconds != 5 || routes[1].RateLimit != 60 || routes[1].Public {
+		t.Errorf("Unexpected routes: %+v", routes)
+	}
+
+	for name, content := range map[string]string{
+		"service":  `[{"name": "a", "path": "/api/v1/*", "service": "listener"}]`,
+		"rewrite":  `[{"name": "a", "path": "/api/v1/*", "service": "query", "rewrite": "/api/v1/health"}]`,
+		"cache":    `[{"name": "a", "path": "/api/v1/*", "service": "query", "cache_seconds": 5}]`,
+		"repeated": `[{"name": "a", "path": "/a", "service": "query"}, {"name": "a", "path": "/b", "service": "query"}]`,
+		"empty":    `[]`,
+	} {
+		path := filepath.Join(dir, name+".json")
+		os.WriteFile(path, []byte(content), 0o644)
+		if _, err := loadRoutesFile(path); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+}
+
+// TestJWTValidator verifica la firma, los tiempos, el emisor y la audiencia en el borde
+func TestJWTValidator(t *testing.T) {
+	validator := &jwtValidator{secret: []byte(testSecret), clockSkew: 30 * time.Second, now: time.Now}
+	claims, err := validator.validate(signToken(t, testSecret, validClaims()))
+	if err != nil || claims.Username != "admin" || claims.Role != "admin" {
+		t.Fatalf("Expected a valid token, got %+v, %v", claims, err)
+	}
+
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	withinSkew := validClaims()
+	withinSkew["exp"] = time.Now().Add(-10 * time.Second).Unix()
+	rs256 := strings.Replace(signToken(t, testSecret, validClaims()), "eyJhbGciOiJIUzI1NiIs", "eyJhbGciOiJSUzI1NiIs", 1)
+	none := strings.Replace(signToken(t, testSecret, validClaims()), "eyJhbGciOiJIUzI1NiIs", base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none",`)), 1)
+
+	if _, err := validator.validate(signToken(t, testSecret, expired)); err != errExpiredToken {
+		t.Errorf("Expected errExpiredToken, got %v", err)
+	}
+	if _, err := validator.validate(signToken(t, testSecret, withinSkew)); err != nil {
+		t.Errorf("Expected a token within the clock skew to be valid, got %v", err)
+	}
+	if _, err := validator.validate(signToken(t, "another-secret-with-at-least-32-chars", validClaims())); err == nil {
+		t.Error("Expected an error for a token signed with another secret")
+	}
+	if _, err := validator.validate(rs256); err != errUnverifiedToken {
+		t.Errorf("Expected an RS256 token without a JWKS to be left to the services, got %v", err)
+	}
+	if _, err := validator.validate(none); err != errInvalidToken {
+		t.Errorf("Expected errInvalidToken for alg none, got %v", err)
+	}
+
+	validator.audience = "inventory"
+	validator.trustedIssuers = []string{"command-service"}
+	withAudience := validClaims()
+	withAudience["aud"] = []string{"inventory"}
+	if _, err := validator.validate(signToken(t, testSecret, withAudience)); err != nil {
+		t.Errorf("Expected a token with the audience to be valid, got %v", err)
+	}
+	if _, err := validator.validate(signToken(t, testSecret, validClaims())); err == nil {
+		t.Error("Expected an error for a token without the audience")
+	}
+	withAudience["iss"] = "someone-else"
+	if _, err := validator.validate(signToken(t, testSecret, withAudience)); err == nil {
+		t.Error("Expected an error for an untrusted issuer")
+	}
+}
+
+// TestGateway_EdgeAuth verifica que las rutas protegidas exijan un JWT válido o una API
+// key, y que las públicas pasen sin credenciales
+func TestGateway_EdgeAuth(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer backend.Close()
+	handler := newTestGateway(t, backend.URL, backend.URL).Handler(nil)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header map[string]string
+		status int
+	}{
+		{"no credentials", "GET", "/api/v1/inventory/items", nil, http.StatusUnauthorized},
+		{"invalid token", "GET", "/api/v1/inventory/items", map[string]string{"Authorization": "Bearer not-a-jwt"}, http.StatusUnauthorized},
+		{"valid token", "GET", "/api/v1/inventory/items", map[string]string{"Authorization": "Bearer " + signToken(t, testSecret, validClaims())}, http.StatusOK},
+		{"rs256 token without jwks", "GET", "/api/v1/inventory/items", map[string]string{"Authorization": "Bearer " + signRS256(t, rsaKey, "kid-1", validClaims())}, http.StatusOK},
+		{"api key", "POST", "/api/v1/inventory/items", map[string]string{apiKeyHeader: "pos-terminal-key"}, http.StatusOK},
+		{"public login", "POST", "/api/v1/auth/login", nil, http.StatusOK},
+		{"public health", "GET", "/api/v1/health/command", nil, http.StatusOK},
+		{"public image", "GET", "/api/v1/images/items/1/photo.jpg", nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		for key, value := range tt.header {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d %s", tt.name, tt.status, w.Code, w.Body.String())
+		}
+		if w.Code == http.StatusUnauthorized {
+			var body proxyError
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != "Unauthorized" {
+				t.Errorf("%s: expected the Unauthorized envelope, got %s", tt.name, w.Body.String())
+			}
+		}
+	}
+}
+
+// TestGateway_RateLimit verifica el límite por cliente de la ruta de login
+func TestGateway_RateLimit(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer backend.Close()
+	handler := newTestGateway(t, backend.URL, backend.URL).Handler(nil)
+
+	login := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/auth/login", strings.NewReader(`{}`))
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	for i := 0; i < 10; i++ {
+		if w := login("10.0.0.1:5000"); w.Code != http.StatusOK {
+			t.Fatalf("Request %d: expected status 200, got %d", i+1, w.Code)
+		}
+	}
+	w := login("10.0.0.1:5001")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 429 with Retry-After, got %d %v", w.Code, w.Header())
+	}
+	if w := login("10.0.0.2:5000"); w.Code != http.StatusOK {
+		t.Errorf("Expected another client to have its own limit, got %d", w.Code)
+	}
+}
+
+// TestGateway_RateLimitPerUser verifica que los usuarios del mismo tenant y rol tengan
+// cada uno su límite
+func TestGateway_RateLimitPerUser(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer backend.Close()
+	handler := newTestGateway(t, backend.URL, backend.URL).Handler(nil)
+
+	export := func(username string) int {
+		claims := validClaims()
+		claims["username"], claims["tenant_id"] = username, "acme"
+		req := httptest.NewRequest("GET", "/api/v1/inventory/export", nil)
+		req.Header.Set("Authorization", "Bearer "+signToken(t, testSecret, claims))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+	for i := 0; i < 30; i++ {
+		if code := export("operator-1"); code != http.StatusOK {
+			t.Fatalf("Request %d: expected status 200, got %d", i+1, code)
+		}
+	}
+	if code := export("operator-1"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 after the route limit, got %d", code)
+	}
+	if code := export("operator-2"); code != http.StatusOK {
+		t.Errorf("Expected another user with the same tenant and role to have its own limit, got %d", code)
+	}
+}
+
+// TestRateLimiter_Refill verifica que los tokens se repongan con el tiempo
+func TestRateLimiter_Refill(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := newRateLimiter()
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 60; i++ {
+		limiter.allow("route|client", 60)
+	}
+	if ok, wait := limiter.allow("route|client", 60); ok || wait != time.Second {
+		t.Errorf("Expected to wait 1s for the next token, got %v %v", ok, wait)
+	}
+	now = now.Add(time.Second)
+	if ok, _ := limiter.allow("route|client", 60); !ok {
+		t.Error("Expected a token after 1s")
+	}
+	now = now.Add(idleBucketTTL + time.Second)
+	limiter.sweep()
+	if len(limiter.buckets) != 0 {
+		t.Errorf("Expected idle buckets to be swept, got %d", len(limiter.buckets))
+	}
+}
+
+// TestGateway_Cache verifica el caché de lecturas: HIT dentro del TTL, separado por tenant y rol,
+// BYPASS con Cache-Control: no-cache y vaciado después de una escritura
+func TestGateway_Cache(t *testing.T) {
+	var reads atomic.Int64
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			reads.Add(1)
+		}
+		w.Write([]byte(`{"items":[]}`))
+	}))
+	defer backend.Close()
+	handler := newTestGateway(t, backend.URL, backend.URL).Handler(nil)
+
+	adminToken := signToken(t, testSecret, validClaims())
+	viewer := validClaims()
+	viewer["username"], viewer["role"] = "viewer", "viewer"
+	viewerToken := signToken(t, testSecret, viewer)
+	otherTenant := validClaims()
+	otherTenant["tenant_id"] = "acme"
+	otherTenantToken := signToken(t, testSecret, otherTenant)
+	explicitDefault := validClaims()
+	explicitDefault["tenant_id"] = defaultTenant
+	explicitDefaultToken := signToken(t, testSecret, explicitDefault)
+
+	request := func(method, token, cacheControl string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/inventory/items?page=1", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if cacheControl != "" {
+			req.Header.Set("Cache-Control", cacheControl)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	steps := []struct {
+		name   string
+		method string
+		token  string
+		cache  string
+		xCache string
+		reads  int64
+	}{
+		{"first read", "GET", adminToken, "", cacheMiss, 1},
+		{"second read", "GET", adminToken, "", cacheHit, 1},
+		{"other role", "GET", viewerToken, "", cacheMiss, 2},
+		{"other tenant, same role", "GET", otherTenantToken, "", cacheMiss, 3},
+		{"other tenant, second read", "GET", otherTenantToken, "", cacheHit, 3},
+		{"explicit default tenant", "GET", explicitDefaultToken, "", cacheHit, 3},
+		{"no-cache", "GET", adminToken, "no-cache", cacheBypass, 4},
+		{"write", "POST", adminToken, "", "", 4},
+		{"read after write", "GET", adminToken, "", cacheMiss, 5},
+	}
+	for _, step := range steps {
+		w := request(step.method, step.token, step.cache)
+		if w.Code != http.StatusOK || w.Header().Get("X-Cache") != step.xCache || reads.Load() != step.reads {
+			t.Errorf("%s: expected %d X-Cache=%q and %d backend reads, got %d %q and %d",
+				step.name, http.StatusOK, step.xCache, step.reads, w.Code, w.Header().Get("X-Cache"), reads.Load())
+		}
+	}
+}
+
+// TestResponseCache_Expires verifica el TTL y el límite de entradas
+func TestResponseCache_Expires(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	cache := newResponseCache(2)
+	cache.now = func() time.Time { return now }
+
+	for i, key := range []string{"a", "b", "c"} {
+		cache.set(key, &cachedResponse{status: 200, storedAt: now, expires: now.Add(time.Duration(i+1) * time.Second)})
+	}
+	if cache.size() != 2 {
+		t.Fatalf("Expected 2 entries, got %d", cache.size())
+	}
+	if _, ok := cache.get("a"); ok {
+		t.Error("Expected the entry that expires first to be evicted")
+	}
+	now = now.Add(2 * time.Second)
+	if _, ok := cache.get("b"); ok {
+		t.Error("Expected b to have expired")
+	}
+	if _, ok := cache.get("c"); !ok {
+		t.Error("Expected c to still be cached")
+	}
+}
+
+// TestMergeSwagger verifica que la documentación combinada tenga cada operación en el
+// servicio al que la envía la tabla de rutas y renombre las definiciones en conflicto
+func TestMergeSwagger(t *testing.T) {
+	var command, query swaggerDoc
+	json.Unmarshal([]byte(`{
+		"swagger": "2.0", "basePath": "/api/v1",
+		"paths": {
+			"/inventory/items": {"post": {"responses": {"201": {"schema": {"$ref": "#/definitions/models.Item"}}}}},
+			"/inventory/items/{id}": {"put": {}},
+			"/auth/login": {"post": {"responses": {"200": {"schema": {"$ref": "#/definitions/auth.LoginResponse"}}}}}
+		},
+		"definitions": {"models.Item": {"type": "object", "properties": {"version": {"type": "integer"}}}, "auth.LoginResponse": {"type": "object"}},
+		"tags": [{"name": "auth"}]
+	}`), &command)
+	json.Unmarshal([]byte(`{
+		"swagger": "2.0", "basePath": "/api/v1",
+		"paths": {
+			"/inventory/items": {"get": {"responses": {"200": {"schema": {"type": "array", "items": {"$ref": "#/definitions/models.Item"}}}}}},
+			"/inventory/items/{id}": {"get": {}},
+			"/auth/login": {"post": {}}
+		},
+		"definitions": {"models.Item": {"type": "object"}, "auth.LoginResponse": {"type": "object"}},
+		"tags": [{"name": "auth"}, {"name": "inventory"}]
+	}`), &query)
+
+	merged := mergeSwagger(map[string]swaggerDoc{serviceCommand: command, serviceQuery: query}, defaultRoutes)
+	data, _ := json.Marshal(merged)
+	var doc struct {
+		Paths       map[string]map[string]map[string]interface{} `json:"paths"`
+		Definitions map[string]interface{}                       `json:"definitions"`
+		Tags        []map[string]string                          `json:"tags"`
+	}
+	json.Unmarshal(data, &doc)
+
+	services := map[string]string{
+		"/inventory/items post":     serviceCommand,
+		"/inventory/items get":      serviceQuery,
+		"/inventory/items/{id} put": serviceCommand,
+		"/inventory/items/{id} get": serviceQuery,
+		"/auth/login post":          serviceCommand,
+	}
+	for key, service := range services {
+		parts := strings.Fields(key)
+		op, ok := doc.Paths[parts[0]][parts[1]]
+		if !ok || op["x-service"] != service {
+			t.Errorf("%s: expected x-service %s, got %v", key, service, op)
+		}
+	}
+	if _, ok := doc.Definitions["command.models.Item"]; !ok {
+		t.Errorf("Expected conflicting definitions to be renamed, got %v", doc.Definitions)
+	}
+	if _, ok := doc.Definitions["auth.LoginResponse"]; !ok {
+		t.Errorf("Expected identical definitions to be kept once, got %v", doc.Definitions)
+	}
+	if !strings.Contains(string(data), `"#/definitions/query.models.Item"`) || strings.Contains(string(data), `"#/definitions/models.Item"`) {
+		t.Errorf("Expected $ref to follow the renamed definitions: %s", data)
+	}
+	if len(doc.Tags) != 2 {
+		t.Errorf("Expected merged tags, got %v", doc.Tags)
+	}
+}
+
+// TestGateway_OpenAPI verifica GET /swagger/doc.json con un servicio caído
+func TestGateway_OpenAPI(t *testing.T) {
+	query := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/swagger/doc.json" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"swagger": "2.0", "basePath": "/api/v1", "paths": {"/activity": {"get": {}}}}`))
+	}))
+	defer query.Close()
+	handler := newTestGateway(t, closedServerURL(), query.URL).Handler(nil)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/swagger/doc.json", nil))
+	if w.Code != http.StatusOK || w.Header().Get("X-Swagger-Missing") != serviceCommand {
+		t.Fatalf("Expected 200 with the command service missing, got %d %v", w.Code, w.Header())
+	}
+	if !strings.Contains(w.Body.String(), `"/activity"`) {
+		t.Errorf("Expected the query service paths, got %s", w.Body.String())
+	}
+}
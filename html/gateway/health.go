@@ -0,0 +1,157 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultListenerServiceURL no pasa por el proxy: solo se consulta su health check
+const defaultListenerServiceURL = "http://localhost:8082"
+
+// Estados de un servicio y del veredicto agregado
+const (
+	healthOK       = "ok"
+	healthDegraded = "degraded" // responde, pero reporta un problema (p. ej. esquema del read model)
+	healthDown     = "down"     // no responde o responde con error
+)
+
+// healthTarget es un servicio cuyo health check forma parte del veredicto
+type healthTarget struct {
+	Service string
+	URL     string // URL completa del health check
+}
+
+// serviceHealth es el resultado del health check de un servicio
+type serviceHealth struct {
+	Service    string          `json:"service"`
+	URL        string          `json:"url"`
+	Status     string          `json:"status"`
+	HTTPStatus int             `json:"http_status,omitempty"`
+	LatencyMs  int64           `json:"latency_ms"`
+	Error      string          `json:"error,omitempty"`
+	Detail     json.RawMessage `json:"detail,omitempty"` // Respuesta del servicio tal cual
+}
+
+// aggregateHealth es la respuesta de GET /api/v1/health/all
+type aggregateHealth struct {
+	Status    string          `json:"status"`
+	Ready     bool            `json:"ready"`
+	CheckedAt time.Time       `json:"checked_at"`
+	Services  []serviceHealth `json:"services"`
+}
+
+// healthTargets son los health checks de los tres servicios del backend: cada réplica
+// estable de command y query (la lista vigente, con las recargas) y el listener
+func healthTargets(command, query *canaryRouter, listenerURL string) func() []healthTarget {
+	return func() []healthTarget {
+		var targets []healthTarget
+		for _, u := range command.stable.list() {
+			targets = append(targets, healthTarget{Service: "command-service", URL: u.url + "/api/v1/health"})
+		}
+		for _, u := range query.stable.list() {
+			targets = append(targets, healthTarget{Service: "query-service", URL: u.url + "/api/v1/health"})
+		}
+		return append(targets, healthTarget{Service: "listener-service", URL: listenerURL + "/api/v1/health"})
+	}
+}
+
+// newAggregateHealthHandler consulta en paralelo el health check de cada servicio y
+// responde un veredicto combinado para gating de despliegues (Docker HEALTHCHECK,
+// Terraform): 200 solo si todos responden "ok", 503 en cualquier otro caso. Cada
+// servicio tiene timeout como plazo máximo, así que la respuesta nunca tarda más.
+func newAggregateHealthHandler(targets func() []healthTarget, timeout time.Duration) http.Handler {
+	client := &http.Client{Timeout: timeout}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		targets := targets()
+		results := make([]serviceHealth, len(targets))
+		var wg sync.WaitGroup
+		for i, target := range targets {
+			wg.Add(1)
+			go func(i int, target healthTarget) {
+				defer wg.Done()
+				results[i] = checkServiceHealth(r.Context(), client, target)
+			}(i, target)
+		}
+		wg.Wait()
+
+		report := aggregateHealth{
+			Status:    healthOK,
+			CheckedAt: time.Now().UTC(),
+			Services:  results,
+		}
+		for _, result := range results {
+			switch {
+			case result.Status == healthDown:
+				report.Status = healthDown
+			case result.Status != healthOK && report.Status == healthOK:
+				report.Status = healthDegraded
+			}
+		}
+		report.Ready = report.Status == healthOK
+
+		status := http.StatusOK
+		if !report.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(report)
+	})
+}
+
+// checkServiceHealth consulta un health check. El estado es el campo "status" de la
+// respuesta ("ok", "degraded", ...); un error de red o un código distinto de 2xx es "down".
+func checkServiceHealth(ctx context.Context, client *http.Client, target healthTarget) (result serviceHealth) {
+	result = serviceHealth{Service: target.Service, URL: target.URL, Status: healthDown}
+	start := time.Now()
+	defer func() { result.LatencyMs = time.Since(start).Milliseconds() }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.URL, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	result.HTTPStatus = resp.StatusCode
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	var payload struct {
+		Status string `json:"status"`
+	}
+	if json.Unmarshal(body, &payload) == nil {
+		result.Detail = body
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		result.Error = http.StatusText(resp.StatusCode)
+		return result
+	}
+	switch payload.Status {
+	case "", healthOK:
+		result.Status = healthOK
+	default:
+		result.Status = healthDegraded
+	}
+	return result
+}
@@ -0,0 +1,164 @@
+package gateway
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// jwksMinRefresh limita las descargas que provocan los kid desconocidos, para que tokens
+// con kid inventados no saturen al IdP
+const jwksMinRefresh = 10 * time.Second
+
+// jwksKeySet cachea las claves públicas de JWT_JWKS_URL, igual que los servicios: las
+// vuelve a descargar cuando vencen o cuando un token nombra un kid desconocido
+type jwksKeySet struct {
+	url        string
+	refresh    time.Duration
+	minRefresh time.Duration
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]interface{} // kid -> *rsa.PublicKey / *ecdsa.PublicKey
+	fetchedAt time.Time
+	triedAt   time.Time
+}
+
+func newJWKSKeySet(url string, refresh time.Duration) *jwksKeySet {
+	if refresh <= 0 {
+		refresh = 5 * time.Minute
+	}
+	return &jwksKeySet{
+		url:        url,
+		refresh:    refresh,
+		minRefresh: jwksMinRefresh,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		keys:       map[string]interface{}{},
+	}
+}
+
+// fetch descarga el JWKS; al arrancar adelanta la primera descarga
+func (s *jwksKeySet) fetch() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetchLocked()
+}
+
+// key devuelve la clave con el kid; si falla la descarga sigue usando las cacheadas
+func (s *jwksKeySet) key(kid string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[kid]
+	stale := time.Since(s.fetchedAt) > s.refresh
+	if (!ok || stale) && time.Since(s.triedAt) >= s.minRefresh {
+		if err := s.fetchLocked(); err != nil {
+			log.Printf("⚠️  [Gateway] No se pudo actualizar el JWKS %s (%d claves cacheadas): %v", s.url, len(s.keys), err)
+		}
+		key, ok = s.keys[kid]
+	}
+	if !ok {
+		return nil, fmt.Errorf("kid desconocido %q", kid)
+	}
+	return key, nil
+}
+
+func (s *jwksKeySet) fetchLocked() error {
+	s.triedAt = time.Now()
+
+	resp, err := s.httpClient.Get(s.url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", s.url, resp.Status)
+	}
+
+	var doc struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return fmt.Errorf("JWKS inválido: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, errN := decodeBigInt(k.N)
+			e, errE := decodeBigInt(k.E)
+			if errN != nil || errE != nil || !e.IsInt64() || e.Int64() > 1<<31-1 {
+				continue
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: n, E: int(e.Int64())}
+		case "EC":
+			x, errX := decodeBigInt(k.X)
+			y, errY := decodeBigInt(k.Y)
+			if k.Crv != "P-256" || errX != nil || errY != nil || !elliptic.P256().IsOnCurve(x, y) {
+				continue
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		}
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("el JWKS %s no tiene claves de firma utilizables", s.url)
+	}
+
+	s.keys = keys
+	s.fetchedAt = s.triedAt
+	return nil
+}
+
+// verifyAsymmetric verifica la firma RS256 o ES256 de signingInput con key
+func verifyAsymmetric(alg string, key interface{}, signingInput string, signature []byte) bool {
+	sum := sha256.Sum256([]byte(signingInput))
+	switch alg {
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(pub, crypto.SHA256, sum[:], signature) == nil
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(pub, sum[:], r, s)
+	}
+	return false
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	if value == "" {
+		return nil, errors.New("vacío")
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
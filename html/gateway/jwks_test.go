@@ -0,0 +1,187 @@
+package gateway
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// signRS256 firma un JWT RS256 con la clave y el kid dados
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	unsigned := unsignedToken(t, "RS256", kid, claims)
+	sum := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// signES256 firma un JWT ES256 (r||s de 32 bytes cada uno) con la clave y el kid dados
+func signES256(t *testing.T, key *ecdsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	unsigned := unsignedToken(t, "ES256", kid, claims)
+	sum := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, key, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func unsignedToken(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT", "kid": kid})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// newJWKSServer publica las claves dadas como un JWKS y cuenta las descargas
+func newJWKSServer(t *testing.T, rsaKey *rsa.PublicKey, ecKey *ecdsa.PublicKey) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	encode := func(n *big.Int) string { return base64.RawURLEncoding.EncodeToString(n.Bytes()) }
+	doc := map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": encode(rsaKey.N), "e": encode(big.NewInt(int64(rsaKey.E)))},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": encode(ecKey.X), "y": encode(ecKey.Y)},
+		{"kty": "RSA", "kid": "enc-1", "use": "enc", "n": encode(rsaKey.N), "e": encode(big.NewInt(int64(rsaKey.E)))},
+	}}
+	var fetches atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		json.NewEncoder(w).Encode(doc)
+	}))
+	t.Cleanup(server.Close)
+	return server, &fetches
+}
+
+// TestJWTValidator_JWKS verifica los tokens RS256 y ES256 contra el JWKS configurado
+func TestJWTValidator_JWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, fetches := newJWKSServer(t, &rsaKey.PublicKey, &ecKey.PublicKey)
+	validator := &jwtValidator{
+		secret:    []byte(testSecret),
+		clockSkew: 30 * time.Second,
+		now:       time.Now,
+		jwks:      newJWKSKeySet(server.URL, time.Minute),
+	}
+
+	claims, err := validator.validate(signRS256(t, rsaKey, "rsa-1", validClaims()))
+	if err != nil || claims.Username != "admin" {
+		t.Fatalf("Expected a valid RS256 token, got %+v, %v", claims, err)
+	}
+	if _, err := validator.validate(signES256(t, ecKey, "ec-1", validClaims())); err != nil {
+		t.Errorf("Expected a valid ES256 token, got %v", err)
+	}
+	if _, err := validator.validate(signToken(t, testSecret, validClaims())); err != nil {
+		t.Errorf("Expected HS256 tokens to keep working with a JWKS, got %v", err)
+	}
+
+	expired := validClaims()
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	promoted := validClaims()
+	promoted["role"] = "superadmin"
+	signed := signRS256(t, rsaKey, "rsa-1", validClaims())
+	tampered := unsignedToken(t, "RS256", "rsa-1", promoted) + signed[strings.LastIndex(signed, "."):]
+	invalid := map[string]string{
+		"another key":     signRS256(t, otherKey, "rsa-1", validClaims()),
+		"unknown kid":     signRS256(t, rsaKey, "rsa-2", validClaims()),
+		"encryption key":  signRS256(t, rsaKey, "enc-1", validClaims()),
+		"ec key for rsa":  signRS256(t, rsaKey, "ec-1", validClaims()),
+		"expired RS256":   signRS256(t, rsaKey, "rsa-1", expired),
+		"tampered claims": tampered,
+	}
+	for name, token := range invalid {
+		if _, err := validator.validate(token); err == nil || err == errUnverifiedToken {
+			t.Errorf("%s: expected the token to be rejected, got %v", name, err)
+		}
+	}
+
+	// Los kid desconocidos no vuelven a descargar el JWKS dentro de jwksMinRefresh
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("Expected a single JWKS fetch, got %d", got)
+	}
+}
+
+// TestGateway_EdgeAuthJWKS verifica que con JWT_JWKS_URL el gateway valide los tokens
+// RS256 en el borde
+func TestGateway_EdgeAuthJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwksServer, _ := newJWKSServer(t, &rsaKey.PublicKey, &ecKey.PublicKey)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer backend.Close()
+
+	gw, err := New(Config{
+		CommandURLs:     backend.URL,
+		QueryURLs:       backend.URL,
+		Strategy:        balanceRoundRobin,
+		Auth:            authJWT,
+		JWTSecret:       testSecret,
+		JWTClockSkew:    30 * time.Second,
+		JWTJWKSURL:      jwksServer.URL,
+		JWTJWKSRefresh:  time.Minute,
+		AccessLog:       "off",
+		AccessLogFormat: accessLogText,
+		policy:          testProxyPolicy(),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Cleanup(func() { gw.Close() })
+	handler := gw.Handler(nil)
+
+	for token, status := range map[string]int{
+		signRS256(t, rsaKey, "rsa-1", validClaims()):   http.StatusOK,
+		signRS256(t, otherKey, "rsa-1", validClaims()): http.StatusUnauthorized,
+	} {
+		req := httptest.NewRequest("GET", "/api/v1/inventory/items", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != status {
+			t.Errorf("Expected status %d, got %d %s", status, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// gatewayBasePath es el basePath de la documentación combinada
+const gatewayBasePath = "/api/v1"
+
+// swaggerDoc es un documento Swagger 2.0 como lo sirven los servicios en /swagger/doc.json
+type swaggerDoc map[string]interface{}
+
+// fetchSwagger descarga el Swagger de la primera réplica de router que responda
+func fetchSwagger(ctx context.Context, client *http.Client, router *canaryRouter) (swaggerDoc, error) {
+	var lastErr error = fmt.Errorf("%s no tiene réplicas", router.service)
+	for _, u := range router.stable.list() {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.url+"/swagger/doc.json", nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		var doc swaggerDoc
+		err = json.NewDecoder(resp.Body).Decode(&doc)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", u.url, err)
+			continue
+		}
+		return doc, nil
+	}
+	return nil, lastErr
+}
+
+// mergeSwagger combina los Swagger de los servicios en uno solo con las rutas que publica
+// el gateway: cada operación se incluye solo si la tabla de rutas la envía al servicio que
+// la documenta, y se marca con x-service. Las definiciones con el mismo nombre y distinto
+// contenido se renombran con el prefijo del servicio.
+func mergeSwagger(docs map[string]swaggerDoc, routes []Route) swaggerDoc {
+	services := make([]string, 0, len(docs))
+	for service := range docs {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	renames := conflictingDefinitions(docs, services)
+	paths := make(map[string]interface{})
+	definitions := make(map[string]interface{})
+	securityDefinitions := make(map[string]interface{})
+	var tags []interface{}
+	seenTags := make(map[string]bool)
+
+	for _, service := range services {
+		doc := docs[service]
+		if rename := renames[service]; len(rename) > 0 {
+			doc = rewriteRefs(map[string]interface{}(doc), rename).(map[string]interface{})
+		}
+		basePath, _ := doc["basePath"].(string)
+
+		servicePaths, _ := doc["paths"].(map[string]interface{})
+		for path, item := range servicePaths {
+			operations, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			fullPath := basePath + path
+			if !strings.HasPrefix(fullPath, gatewayBasePath+"/") {
+				continue
+			}
+			for method, operation := range operations {
+				rt, ok := matchRoute(routes, strings.ToUpper(method), samplePath(fullPath))
+				if !ok || rt.Service != service || rt.Rewrite != "" {
+					continue
+				}
+				if op, ok := operation.(map[string]interface{}); ok {
+					op["x-service"] = service
+				}
+				gatewayPath := strings.TrimPrefix(fullPath, gatewayBasePath)
+				merged, _ := paths[gatewayPath].(map[string]interface{})
+				if merged == nil {
+					merged = make(map[string]interface{})
+					paths[gatewayPath] = merged
+				}
+				merged[method] = operation
+			}
+		}
+
+		serviceDefinitions, _ := doc["definitions"].(map[string]interface{})
+		for name, definition := range serviceDefinitions {
+			if newName, ok := renames[service][name]; ok {
+				name = newName
+			}
+			definitions[name] = definition
+		}
+		security, _ := doc["securityDefinitions"].(map[string]interface{})
+		for name, definition := range security {
+			securityDefinitions[name] = definition
+		}
+		serviceTags, _ := doc["tags"].([]interface{})
+		for _, tag := range serviceTags {
+			name, _ := tag.(map[string]interface{})["name"].(string)
+			if !seenTags[name] {
+				seenTags[name] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	merged := swaggerDoc{
+		"swagger": "2.0",
+		"info": map[string]interface{}{
+			"title":       "Inventory API Gateway",
+			"description": "Documentación combinada del Command Service y el Query Service, con las rutas que publica el gateway. El campo x-service de cada operación indica el servicio que la atiende.",
+			"version":     "1.0",
+		},
+		"basePath":    gatewayBasePath,
+		"paths":       paths,
+		"definitions": definitions,
+	}
+	if len(securityDefinitions) > 0 {
+		merged["securityDefinitions"] = securityDefinitions
+	}
+	if len(tags) > 0 {
+		merged["tags"] = tags
+	}
+	return merged
+}
+
+// conflictingDefinitions devuelve, por servicio, los nombres de definiciones que otro
+// servicio define distinto y el nombre con prefijo que los reemplaza
+func conflictingDefinitions(docs map[string]swaggerDoc, services []string) map[string]map[string]string {
+	owners := make(map[string][]string)
+	for _, service := range services {
+		definitions, _ := docs[service]["definitions"].(map[string]interface{})
+		for name := range definitions {
+			owners[name] = append(owners[name], service)
+		}
+	}
+	renames := make(map[string]map[string]string)
+	for name, serviceList := range owners {
+		if len(serviceList) < 2 {
+			continue
+		}
+		first := docs[serviceList[0]]["definitions"].(map[string]interface{})[name]
+		same := true
+		for _, service := range serviceList[1:] {
+			if !reflect.DeepEqual(first, docs[service]["definitions"].(map[string]interface{})[name]) {
+				same = false
+			}
+		}
+		if same {
+			continue
+		}
+		for _, service := range serviceList {
+			if renames[service] == nil {
+				renames[service] = make(map[string]string)
+			}
+			renames[service][name] = service + "." + name
+		}
+	}
+	return renames
+}
+
+// rewriteRefs reemplaza en value las referencias #/definitions/<nombre> de rename
+func rewriteRefs(value interface{}, rename map[string]string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if ref, ok := item.(string); key == "$ref" && ok {
+				if newName, ok := rename[strings.TrimPrefix(ref, "#/definitions/")]; ok {
+					item = "#/definitions/" + newName
+				}
+			}
+			out[key] = rewriteRefs(item, rename)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = rewriteRefs(item, rename)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// samplePath reemplaza los parámetros {id} de una ruta Swagger por un segmento cualquiera
+// para buscarla en la tabla de rutas
+func samplePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = "x"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// newOpenAPIHandler sirve GET /swagger/doc.json con la documentación combinada. Un
+// servicio que no responde se omite y se informa en el header X-Swagger-Missing.
+func newOpenAPIHandler(routers []*canaryRouter, routes []Route, timeout time.Duration) http.Handler {
+	client := &http.Client{Timeout: timeout}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		docs := make(map[string]swaggerDoc)
+		var missing []string
+		for _, router := range routers {
+			doc, err := fetchSwagger(r.Context(), client, router)
+			if err != nil {
+				log.Printf("⚠️  [Gateway] Swagger de %s no disponible: %v", router.service, err)
+				missing = append(missing, router.service)
+				continue
+			}
+			docs[router.service] = doc
+		}
+		if len(docs) == 0 {
+			writeProxyError(w, r, http.StatusServiceUnavailable, "ServiceUnavailable",
+				"La documentación de los servicios no está disponible", strings.Join(missing, ", "))
+			return
+		}
+		if len(missing) > 0 {
+			w.Header().Set("X-Swagger-Missing", strings.Join(missing, ","))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(mergeSwagger(docs, routes))
+	})
+}
+
+// swaggerUIPage muestra la documentación combinada con Swagger UI
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="es">
+<head>
+  <meta charset="utf-8">
+  <title>Inventory API Gateway</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>SwaggerUIBundle({ url: "/swagger/doc.json", dom_id: "#swagger-ui" });</script>
+</body>
+</html>
+`
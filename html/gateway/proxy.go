@@ -0,0 +1,140 @@
+package gateway
+
+import (
+	"log"
+	"math/rand"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+)
+
+// createProxy crea un proxy reverso para un servicio backend
+func createProxy(targetURL string) *httputil.ReverseProxy {
+	target, err := url.Parse(targetURL)
+	if err != nil {
+		log.Fatalf("Error al parsear URL del servicio: %v", err)
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+
+	// Modificar la respuesta para agregar headers CORS
+	originalDirector := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		// Preservar la ruta y query originales antes de que el director las modifique
+		originalPath := req.URL.Path
+		originalRawQuery := req.URL.RawQuery
+		originalRawPath := req.URL.RawPath
+
+		// Llamar al director original (esto configura Scheme, Host, etc.)
+		originalDirector(req)
+
+		// Restaurar la ruta original - esto es crítico para rutas con parámetros dinámicos
+		req.URL.Path = originalPath
+		req.URL.RawQuery = originalRawQuery
+		if originalRawPath != "" {
+			req.URL.RawPath = originalRawPath
+		}
+
+		// Configurar el host y scheme
+		req.Host = target.Host
+		req.URL.Scheme = target.Scheme
+		req.URL.Host = target.Host
+
+		// Log para depuración
+		log.Printf("🔗 [Proxy Director] %s %s?%s -> %s://%s%s?%s id=%s",
+			req.Method, originalPath, originalRawQuery,
+			req.URL.Scheme, req.URL.Host, req.URL.Path, req.URL.RawQuery, req.Header.Get(RequestIDHeader))
+	}
+
+	// Modificar la respuesta
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		// Los headers CORS los pone corsMiddleware con la política del proxy; los del
+		// servicio (su propia política) se descartan para no duplicarlos
+		for key := range resp.Header {
+			if strings.HasPrefix(key, "Access-Control-") {
+				resp.Header.Del(key)
+			}
+		}
+		if attempt, ok := resp.Request.Context().Value(proxyAttemptKey{}).(*proxyAttempt); ok {
+			attempt.status = resp.StatusCode
+			if attempt.retryable && upstreamFailed(resp.StatusCode) {
+				return errRetryableStatus
+			}
+		}
+		return nil
+	}
+
+	// Un error de red se devuelve al router para reintentar; fuera de él se responde en JSON
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		if attempt, ok := r.Context().Value(proxyAttemptKey{}).(*proxyAttempt); ok {
+			attempt.err = err
+			return
+		}
+		log.Printf("❌ [Proxy] %s %s -> %s: %v", r.Method, r.URL.Path, target.Host, err)
+		writeUpstreamError(w, r, target.Host, err)
+	}
+
+	return proxy
+}
+
+// CanaryHeader permite forzar el enrutamiento: "1" envía al canary, "0" a la versión estable
+const CanaryHeader = "X-Canary"
+
+// canaryRouter reparte las peticiones de un servicio entre la versión estable y la canary,
+// y dentro de cada una entre sus réplicas (LB_STRATEGY). Si la réplica elegida no está
+// sana o tiene el circuito abierto, la petición (o el reintento de un GET) va a la
+// siguiente, y a la otra versión si no queda ninguna.
+type canaryRouter struct {
+	service string
+	stable  *upstreamPool
+	canary  *upstreamPool // Vacío si no hay canary configurado
+	percent int
+	policy  proxyPolicy
+}
+
+// newCanaryRouter crea el router canary de un servicio. Sin réplicas canary, todas las
+// peticiones van a la versión estable.
+func newCanaryRouter(service string, stable, canary []upstreamSpec, percent int, policy proxyPolicy) *canaryRouter {
+	return &canaryRouter{
+		service: service,
+		stable:  newUpstreamPool(service, false, stable, policy),
+		canary:  newUpstreamPool(service+"-canary", true, canary, policy),
+		percent: percent,
+		policy:  policy,
+	}
+}
+
+// upstreams son las réplicas del servicio, las estables primero
+func (cr *canaryRouter) upstreams() []*upstream {
+	return append(cr.stable.list(), cr.canary.list()...)
+}
+
+func (cr *canaryRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	candidates := append(cr.stable.ordered(), cr.canary.ordered()...)
+	if cr.useCanary(r) {
+		candidates = append(cr.canary.ordered(), cr.stable.ordered()...)
+	}
+	serveResilient(w, r, cr.service, candidates, cr.policy, func(target *upstream) {
+		if target.canary {
+			log.Printf("🐤 [Canary] %s %s -> %s canary", r.Method, r.URL.Path, cr.service)
+			w.Header().Set("X-Canary-Upstream", cr.service)
+			return
+		}
+		w.Header().Del("X-Canary-Upstream")
+	})
+}
+
+// useCanary decide si la petición va al canary: primero por header, luego por porcentaje
+func (cr *canaryRouter) useCanary(r *http.Request) bool {
+	if len(cr.canary.list()) == 0 {
+		return false
+	}
+	switch r.Header.Get(CanaryHeader) {
+	case "1":
+		return true
+	case "0":
+		return false
+	}
+	return cr.percent > 0 && rand.Intn(100) < cr.percent
+}
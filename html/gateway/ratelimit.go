@@ -0,0 +1,64 @@
+package gateway
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimiter limita las peticiones de cada cliente por ruta con un token bucket: la
+// ráfaga es el límite por minuto y los tokens se reponen de forma continua
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket // Por ruta y cliente
+	now     func() time.Time
+}
+
+type tokenBucket struct {
+	tokens   float64
+	updated  time.Time
+	capacity float64
+}
+
+// idleBucketTTL es cuánto se conserva el bucket de un cliente que dejó de llamar
+const idleBucketTTL = 10 * time.Minute
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: make(map[string]*tokenBucket), now: time.Now}
+}
+
+// allow consume un token del bucket de key (perMinute peticiones por minuto). Si no
+// quedan, devuelve cuánto falta para el siguiente.
+func (l *rateLimiter) allow(key string, perMinute int) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	rate := float64(perMinute) / time.Minute.Seconds()
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(perMinute), updated: now, capacity: float64(perMinute)}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(bucket.capacity, bucket.tokens+now.Sub(bucket.updated).Seconds()*rate)
+	bucket.updated = now
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - bucket.tokens) / rate * float64(time.Second))
+	return false, wait
+}
+
+// sweep descarta los buckets sin uso reciente; con el bucket lleno de nuevo, olvidarlo
+// no cambia el resultado
+func (l *rateLimiter) sweep() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.updated) > idleBucketTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
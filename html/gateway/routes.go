@@ -0,0 +1,170 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Servicios a los que puede apuntar una ruta
+const (
+	serviceCommand = "command"
+	serviceQuery   = "query"
+)
+
+// Route es una entrada de la tabla de rutas del gateway. La primera ruta que coincide con
+// el método y la ruta de la petición decide a qué servicio va y qué políticas se aplican.
+type Route struct {
+	Name         string   `json:"name"`
+	Methods      []string `json:"methods,omitempty"`       // Vacío: cualquier método; GET incluye HEAD
+	Path         string   `json:"path"`                    // Exacta, o prefijo si termina en "*"
+	Service      string   `json:"service"`                 // command o query
+	Rewrite      string   `json:"rewrite,omitempty"`       // Ruta enviada al servicio (solo rutas exactas)
+	Public       bool     `json:"public,omitempty"`        // Sin validación de JWT en el gateway
+	RateLimit    int      `json:"rate_limit,omitempty"`    // Peticiones por minuto de cada cliente; 0 sin límite
+	CacheSeconds int      `json:"cache_seconds,omitempty"` // TTL del caché de respuestas GET; 0 sin caché
+	Stream       bool     `json:"stream,omitempty"`        // SSE o WebSocket: sin timeouts de escritura, con cierre por inactividad
+}
+
+// matches indica si la ruta atiende method y path
+func (rt Route) matches(method, path string) bool {
+	if prefix, ok := strings.CutSuffix(rt.Path, "*"); ok {
+		if !strings.HasPrefix(path, prefix) {
+			return false
+		}
+	} else if path != rt.Path {
+		return false
+	}
+	if len(rt.Methods) == 0 {
+		return true
+	}
+	for _, m := range rt.Methods {
+		if m == method || (m == http.MethodGet && method == http.MethodHead) {
+			return true
+		}
+	}
+	return false
+}
+
+// readOnly indica si la ruta solo acepta lecturas (GET/HEAD)
+func (rt Route) readOnly() bool {
+	if len(rt.Methods) == 0 {
+		return false
+	}
+	for _, m := range rt.Methods {
+		if m != http.MethodGet && m != http.MethodHead {
+			return false
+		}
+	}
+	return true
+}
+
+// defaultRoutes es la tabla de rutas sin GATEWAY_ROUTES_FILE: las lecturas van al Query
+// Service y todo lo demás (escrituras, auth, auditoría) al Command Service
+var defaultRoutes = []Route{
+	// Health checks: públicos, el de cada servicio con su propia ruta
+	{Name: "health-command", Methods: []string{"GET"}, Path: "/api/v1/health/command", Service: serviceCommand, Rewrite: "/api/v1/health", Public: true},
+	{Name: "health-query", Methods: []string{"GET"}, Path: "/api/v1/health/query", Service: serviceQuery, Rewrite: "/api/v1/health", Public: true},
+	{Name: "health", Methods: []string{"GET"}, Path: "/api/v1/health*", Service: serviceCommand, Public: true},
+
+	// SLOs de cada servicio (el dashboard consulta ambos)
+	{Name: "slo-command", Methods: []string{"GET"}, Path: "/api/v1/slo/command", Service: serviceCommand, Rewrite: "/api/v1/slo"},
+	{Name: "slo-query", Methods: []string{"GET"}, Path: "/api/v1/slo/query", Service: serviceQuery, Rewrite: "/api/v1/slo"},
+
+	// Autenticación: el login se limita por cliente para frenar la fuerza bruta; la
+	// gestión de usuarios y API keys la autoriza el propio servicio
+	{Name: "login", Methods: []string{"POST"}, Path: "/api/v1/auth/login", Service: serviceCommand, Public: true, RateLimit: 10},
+	{Name: "auth", Path: "/api/v1/auth/*", Service: serviceCommand, Public: true},
+
+	// Lecturas del Query Service
+	{Name: "inventory-stream", Methods: []string{"GET"}, Path: "/api/v1/inventory/stream", Service: serviceQuery, Stream: true},
+	{Name: "inventory-items", Methods: []string{"GET"}, Path: "/api/v1/inventory/items*", Service: serviceQuery, CacheSeconds: 5},
+	{Name: "inventory-valuation", Methods: []string{"GET"}, Path: "/api/v1/inventory/valuation", Service: serviceQuery, CacheSeconds: 30},
+	{Name: "inventory-stats", Methods: []string{"GET"}, Path: "/api/v1/inventory/stats", Service: serviceQuery, CacheSeconds: 10},
+	{Name: "inventory-export", Methods: []string{"GET"}, Path: "/api/v1/inventory/export", Service: serviceQuery, RateLimit: 30},
+	{Name: "inventory-waitlist", Methods: []string{"GET"}, Path: "/api/v1/inventory/waitlist/*", Service: serviceQuery},
+	{Name: "stores-read", Methods: []string{"GET"}, Path: "/api/v1/stores/*", Service: serviceQuery},
+	{Name: "activity", Methods: []string{"GET"}, Path: "/api/v1/activity", Service: serviceQuery},
+	{Name: "suppliers-read", Methods: []string{"GET"}, Path: "/api/v1/suppliers*", Service: serviceQuery},
+	{Name: "purchase-orders-read", Methods: []string{"GET"}, Path: "/api/v1/purchase-orders*", Service: serviceQuery},
+	// Imágenes de IMAGE_STORE=local: públicas porque las carga un <img> sin Authorization
+	{Name: "images", Methods: []string{"GET"}, Path: "/api/v1/images/*", Service: serviceQuery, Public: true},
+	{Name: "graphql", Methods: []string{"GET", "POST"}, Path: "/api/v1/graphql", Service: serviceQuery},
+	// Lecturas que usan POST porque la consulta viaja en el body
+	{Name: "inventory-availability", Methods: []string{"POST"}, Path: "/api/v1/inventory/availability", Service: serviceQuery},
+	{Name: "inventory-batch", Methods: []string{"POST"}, Path: "/api/v1/inventory/items/batch", Service: serviceQuery},
+	{Name: "query-cache-admin", Path: "/api/v1/admin/cache*", Service: serviceQuery},
+
+	// Todo lo demás (POST, PUT, PATCH, DELETE, comandos, auditoría) va al Command Service
+	{Name: "command", Path: "/api/v1/*", Service: serviceCommand, RateLimit: 600},
+}
+
+// validateRoutes revisa una tabla de rutas antes de usarla
+func validateRoutes(routes []Route) error {
+	if len(routes) == 0 {
+		return fmt.Errorf("la tabla de rutas está vacía")
+	}
+	names := make(map[string]bool)
+	for i, rt := range routes {
+		if rt.Name == "" {
+			return fmt.Errorf("ruta %d: falta name", i)
+		}
+		if names[rt.Name] {
+			return fmt.Errorf("ruta %s: name repetido", rt.Name)
+		}
+		names[rt.Name] = true
+		if !strings.HasPrefix(rt.Path, "/") {
+			return fmt.Errorf("ruta %s: path debe empezar con / (got %q)", rt.Name, rt.Path)
+		}
+		if rt.Service != serviceCommand && rt.Service != serviceQuery {
+			return fmt.Errorf("ruta %s: service debe ser command o query (got %q)", rt.Name, rt.Service)
+		}
+		if rt.Rewrite != "" && (strings.HasSuffix(rt.Path, "*") || !strings.HasPrefix(rt.Rewrite, "/")) {
+			return fmt.Errorf("ruta %s: rewrite solo aplica a rutas exactas y debe empezar con /", rt.Name)
+		}
+		for _, m := range rt.Methods {
+			if m != strings.ToUpper(m) || m == "" {
+				return fmt.Errorf("ruta %s: método inválido %q", rt.Name, m)
+			}
+		}
+		if rt.RateLimit < 0 || rt.CacheSeconds < 0 {
+			return fmt.Errorf("ruta %s: rate_limit y cache_seconds no pueden ser negativos", rt.Name)
+		}
+		if rt.CacheSeconds > 0 && rt.Stream {
+			return fmt.Errorf("ruta %s: una ruta stream no puede usar cache_seconds", rt.Name)
+		}
+		if rt.CacheSeconds > 0 && !rt.readOnly() {
+			return fmt.Errorf("ruta %s: cache_seconds solo aplica a rutas de solo GET", rt.Name)
+		}
+	}
+	return nil
+}
+
+// loadRoutesFile lee la tabla de rutas de un archivo JSON (un arreglo de rutas con el
+// formato de Route, en orden de prioridad)
+func loadRoutesFile(path string) ([]Route, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var routes []Route
+	if err := json.Unmarshal(data, &routes); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := validateRoutes(routes); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return routes, nil
+}
+
+// matchRoute devuelve la primera ruta que atiende method y path
+func matchRoute(routes []Route, method, path string) (Route, bool) {
+	for _, rt := range routes {
+		if rt.matches(method, path) {
+			return rt, true
+		}
+	}
+	return Route{}, false
+}
@@ -0,0 +1,126 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// isUpgrade indica si r pide cambiar de protocolo (WebSocket)
+func isUpgrade(r *http.Request) bool {
+	if r.Header.Get("Upgrade") == "" {
+		return false
+	}
+	for _, value := range r.Header.Values("Connection") {
+		for _, token := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// serveStream atiende una conexión larga (SSE o WebSocket). Quita los timeouts de lectura
+// y escritura del servidor, que cortarían el stream a los pocos segundos, y la cierra si
+// pasa idle sin tráfico en ningún sentido (0 la deja abierta mientras ambos extremos
+// sigan conectados). Los heartbeats del servicio cuentan como tráfico.
+func serveStream(w http.ResponseWriter, r *http.Request, idle time.Duration, next http.Handler) {
+	// Sin soporte (p. ej. con httptest.ResponseRecorder) no hay timeouts que quitar
+	controller := http.NewResponseController(w)
+	controller.SetReadDeadline(time.Time{})
+	controller.SetWriteDeadline(time.Time{})
+	if idle <= 0 {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	stream := &streamWriter{ResponseWriter: w}
+	stream.touch()
+	go func() {
+		ticker := time.NewTicker(idle / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if now.Sub(stream.lastActivity()) >= idle {
+					log.Printf("⏱️  [Gateway] Stream %s %s cerrado tras %s sin tráfico", r.Method, r.URL.Path, idle)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	next.ServeHTTP(stream, r.WithContext(ctx))
+}
+
+// streamWriter registra la última actividad del stream: cada escritura hacia el cliente
+// y, en un WebSocket, cada lectura o escritura de la conexión
+type streamWriter struct {
+	http.ResponseWriter
+	last atomic.Int64 // UnixNano de la última actividad
+}
+
+func (w *streamWriter) touch() {
+	w.last.Store(time.Now().UnixNano())
+}
+
+func (w *streamWriter) lastActivity() time.Time {
+	return time.Unix(0, w.last.Load())
+}
+
+func (w *streamWriter) Write(data []byte) (int, error) {
+	w.touch()
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush envía cada evento SSE en cuanto llega, sin esperar a llenar el buffer
+func (w *streamWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack entrega la conexión del cliente al proxy para el WebSocket
+func (w *streamWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err != nil {
+		return conn, brw, err
+	}
+	return &streamConn{Conn: conn, stream: w}, brw, nil
+}
+
+func (w *streamWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// streamConn es la conexión de un WebSocket; su tráfico mantiene vivo el stream
+type streamConn struct {
+	net.Conn
+	stream *streamWriter
+}
+
+func (c *streamConn) Read(data []byte) (int, error) {
+	n, err := c.Conn.Read(data)
+	if n > 0 {
+		c.stream.touch()
+	}
+	return n, err
+}
+
+func (c *streamConn) Write(data []byte) (int, error) {
+	n, err := c.Conn.Write(data)
+	if n > 0 {
+		c.stream.touch()
+	}
+	return n, err
+}
@@ -0,0 +1,142 @@
+package gateway
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newShortTimeoutServer publica el gateway con timeouts de servidor más cortos que los
+// streams de los tests
+func newShortTimeoutServer(t *testing.T, gw *Gateway) *httptest.Server {
+	server := httptest.NewUnstartedServer(gw.Handler(nil))
+	server.Config.ReadTimeout = 200 * time.Millisecond
+	server.Config.WriteTimeout = 200 * time.Millisecond
+	server.Start()
+	t.Cleanup(server.Close)
+	return server
+}
+
+// upgradeBackend acepta el WebSocket y, con echo, devuelve lo que recibe
+func upgradeBackend(t *testing.T, echo bool) *httptest.Server {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isUpgrade(r) {
+			t.Errorf("Expected an upgrade request, got %v", r.Header)
+			return
+		}
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("Hijack failed: %v", err)
+			return
+		}
+		defer conn.Close()
+		brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		brw.Flush()
+		if echo {
+			io.Copy(conn, brw)
+			return
+		}
+		io.Copy(io.Discard, brw)
+	}))
+	t.Cleanup(backend.Close)
+	return backend
+}
+
+// dialUpgrade abre un WebSocket a través del gateway y devuelve la conexión tras el 101
+func dialUpgrade(t *testing.T, gatewayURL, token string) (net.Conn, *bufio.Reader) {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(gatewayURL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	fmt.Fprintf(conn, "GET /api/v1/inventory/stream HTTP/1.1\r\nHost: gateway\r\nAuthorization: Bearer %s\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n", token)
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get(RequestIDHeader) == "" {
+		t.Fatalf("Expected 101 with X-Request-ID, got %d %v", resp.StatusCode, resp.Header)
+	}
+	return conn, reader
+}
+
+// TestStream_SSE verifica que cada evento SSE llegue en cuanto el servicio lo envía y que
+// el stream sobreviva a los timeouts de lectura y escritura del servidor
+func TestStream_SSE(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 1; i <= 3; i++ {
+			fmt.Fprintf(w, "event: heartbeat\ndata: %d\n\n", i)
+			w.(http.Flusher).Flush()
+			time.Sleep(150 * time.Millisecond)
+		}
+	}))
+	defer backend.Close()
+	server := newShortTimeoutServer(t, newTestGateway(t, backend.URL, backend.URL))
+
+	req, _ := http.NewRequest("GET", server.URL+"/api/v1/inventory/stream", nil)
+	req.Header.Set("Authorization", "Bearer "+signToken(t, testSecret, validClaims()))
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	var data []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			break
+		}
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), "data: "); ok {
+			if len(data) == 0 && time.Since(start) > 120*time.Millisecond {
+				t.Errorf("Expected the first event to be flushed right away, took %s", time.Since(start))
+			}
+			data = append(data, value)
+		}
+	}
+	if strings.Join(data, ",") != "1,2,3" {
+		t.Errorf("Expected the 3 events past the server timeouts, got %v", data)
+	}
+}
+
+// TestStream_WebSocket verifica que el WebSocket pase en ambos sentidos después de los
+// timeouts del servidor
+func TestStream_WebSocket(t *testing.T) {
+	backend := upgradeBackend(t, true)
+	server := newShortTimeoutServer(t, newTestGateway(t, backend.URL, backend.URL))
+	conn, reader := dialUpgrade(t, server.URL, signToken(t, testSecret, validClaims()))
+
+	time.Sleep(300 * time.Millisecond)
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	echo := make([]byte, 4)
+	if _, err := io.ReadFull(reader, echo); err != nil || string(echo) != "ping" {
+		t.Errorf("Expected the echo through the gateway, got %q %v", echo, err)
+	}
+}
+
+// TestStream_IdleTimeout verifica que un WebSocket sin tráfico se cierre
+func TestStream_IdleTimeout(t *testing.T) {
+	backend := upgradeBackend(t, false)
+	gw := newTestGateway(t, backend.URL, backend.URL)
+	gw.config.StreamIdleTimeout = 200 * time.Millisecond
+	server := newShortTimeoutServer(t, gw)
+	conn, reader := dialUpgrade(t, server.URL, signToken(t, testSecret, validClaims()))
+
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	if _, err := reader.ReadByte(); err != io.EOF {
+		t.Errorf("Expected the gateway to close the idle connection, got %v", err)
+	}
+}
@@ -0,0 +1,526 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// proxyPolicy configura el balanceo, el health checking, los reintentos y el circuit
+// breaker del proxy
+type proxyPolicy struct {
+	Strategy         string        // balanceRoundRobin o balanceLeastConnections
+	UpstreamTimeout  time.Duration // Espera máxima de la respuesta de una réplica sin timeout propio
+	HealthInterval   time.Duration // Cada cuánto se consulta el health check de cada réplica
+	HealthTimeout    time.Duration
+	Retries          int           // Reintentos de un GET/HEAD fallido, en otra réplica si la hay
+	RetryBackoff     time.Duration // Espera antes de cada reintento
+	BreakerThreshold int           // Fallos seguidos que abren el circuito
+	BreakerCooldown  time.Duration // Tiempo abierto antes de dejar pasar una petición de prueba
+}
+
+// loadProxyPolicy lee LB_STRATEGY, PROXY_UPSTREAM_TIMEOUT_MS, PROXY_HEALTH_INTERVAL_MS,
+// HEALTH_TIMEOUT_MS, PROXY_RETRIES, PROXY_RETRY_BACKOFF_MS, PROXY_BREAKER_THRESHOLD y
+// PROXY_BREAKER_COOLDOWN_MS
+func loadProxyPolicy() proxyPolicy {
+	return proxyPolicy{
+		Strategy:         getEnv("LB_STRATEGY", balanceRoundRobin),
+		UpstreamTimeout:  time.Duration(getEnvAsInt("PROXY_UPSTREAM_TIMEOUT_MS", 10000)) * time.Millisecond,
+		HealthInterval:   time.Duration(getEnvAsInt("PROXY_HEALTH_INTERVAL_MS", 5000)) * time.Millisecond,
+		HealthTimeout:    time.Duration(getEnvAsInt("HEALTH_TIMEOUT_MS", 2000)) * time.Millisecond,
+		Retries:          getEnvAsInt("PROXY_RETRIES", 1),
+		RetryBackoff:     time.Duration(getEnvAsInt("PROXY_RETRY_BACKOFF_MS", 250)) * time.Millisecond,
+		BreakerThreshold: getEnvAsInt("PROXY_BREAKER_THRESHOLD", 5),
+		BreakerCooldown:  time.Duration(getEnvAsInt("PROXY_BREAKER_COOLDOWN_MS", 10000)) * time.Millisecond,
+	}
+}
+
+// Estados del circuit breaker
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open" // Una petición de prueba en curso decide si se cierra
+)
+
+// circuitBreaker deja de enviar peticiones a una réplica después de threshold fallos
+// seguidos; pasado cooldown deja pasar una sola petición de prueba
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     string
+	failures  int // Fallos seguidos
+	openedAt  time.Time
+	now       func() time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, state: breakerClosed, now: time.Now}
+}
+
+// allow indica si se puede enviar una petición; con el circuito abierto y el cooldown
+// cumplido pasa a half-open y deja pasar esta, que es la de prueba
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerClosed:
+		return true
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		return true
+	default:
+		return false
+	}
+}
+
+// record registra el resultado de una petición que allow dejó pasar
+func (b *circuitBreaker) record(ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if ok {
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// retryAfter es el tiempo que falta para la próxima petición de prueba
+func (b *circuitBreaker) retryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != breakerOpen {
+		return 0
+	}
+	if remaining := b.cooldown - b.now().Sub(b.openedAt); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// breakerStatus es el estado del circuit breaker en /proxy/status
+type breakerStatus struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+}
+
+func (b *circuitBreaker) status() breakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := breakerStatus{State: b.state, ConsecutiveFailures: b.failures}
+	if b.state != breakerClosed {
+		openedAt := b.openedAt.UTC()
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+// upstream es una réplica de un servicio (estable o canary), con su health check activo
+// y su circuit breaker
+type upstream struct {
+	name    string
+	url     string
+	canary  bool
+	timeout time.Duration
+	proxy   http.Handler
+	breaker *circuitBreaker
+	active  atomic.Int64 // Peticiones en curso (least-connections)
+
+	mu        sync.Mutex
+	healthy   bool // Hasta el primer health check se asume sana
+	health    string
+	lastCheck time.Time
+	lastError string
+	requests  int64
+	failures  int64
+}
+
+func newUpstream(name string, spec upstreamSpec, canary bool, policy proxyPolicy) *upstream {
+	timeout := spec.Timeout
+	if timeout <= 0 {
+		timeout = policy.UpstreamTimeout
+	}
+	proxy := createProxy(spec.URL)
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = timeout
+	proxy.Transport = transport
+
+	return &upstream{
+		name:    name,
+		url:     spec.URL,
+		canary:  canary,
+		timeout: timeout,
+		proxy:   proxy,
+		breaker: newCircuitBreaker(policy.BreakerThreshold, policy.BreakerCooldown),
+		healthy: true,
+	}
+}
+
+// isHealthy indica si el último health check respondió; "degraded" sigue recibiendo tráfico
+func (u *upstream) isHealthy() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.healthy
+}
+
+// checkHealth consulta el health check de la réplica y actualiza su estado
+func (u *upstream) checkHealth(ctx context.Context, client *http.Client) {
+	result := checkServiceHealth(ctx, client, healthTarget{Service: u.name, URL: u.url + "/api/v1/health"})
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.healthy != (result.Status != healthDown) {
+		log.Printf("🩺 [Proxy] %s (%s) pasó a %s %s", u.name, u.url, result.Status, result.Error)
+	}
+	u.healthy = result.Status != healthDown
+	u.health = result.Status
+	u.lastCheck = time.Now().UTC()
+	u.lastError = result.Error
+}
+
+// recordResult cuenta una petición enviada a la réplica y la registra en el breaker
+func (u *upstream) recordResult(ok bool) {
+	u.mu.Lock()
+	u.requests++
+	if !ok {
+		u.failures++
+	}
+	u.mu.Unlock()
+	u.breaker.record(ok)
+}
+
+// upstreamStatus es una réplica en /proxy/status
+type upstreamStatus struct {
+	Name           string        `json:"name"`
+	URL            string        `json:"url"`
+	Canary         bool          `json:"canary,omitempty"`
+	TimeoutMs      int64         `json:"timeout_ms"`
+	Healthy        bool          `json:"healthy"`
+	Health         string        `json:"health,omitempty"` // Último estado reportado: ok, degraded o down
+	LastCheck      *time.Time    `json:"last_check,omitempty"`
+	LastError      string        `json:"last_error,omitempty"`
+	ActiveRequests int64         `json:"active_requests"`
+	Requests       int64         `json:"requests"`
+	Failures       int64         `json:"failures"`
+	Breaker        breakerStatus `json:"breaker"`
+}
+
+func (u *upstream) status() upstreamStatus {
+	u.mu.Lock()
+	status := upstreamStatus{
+		Name:           u.name,
+		URL:            u.url,
+		Canary:         u.canary,
+		TimeoutMs:      u.timeout.Milliseconds(),
+		Healthy:        u.healthy,
+		Health:         u.health,
+		LastError:      u.lastError,
+		ActiveRequests: u.active.Load(),
+		Requests:       u.requests,
+		Failures:       u.failures,
+	}
+	if !u.lastCheck.IsZero() {
+		lastCheck := u.lastCheck
+		status.LastCheck = &lastCheck
+	}
+	u.mu.Unlock()
+	status.Breaker = u.breaker.status()
+	return status
+}
+
+// startHealthChecks consulta en paralelo el health check de cada réplica de upstreams
+// cada policy.HealthInterval hasta que ctx termina; el primero se hace en el momento.
+// La lista se vuelve a pedir en cada ronda, así que incluye las réplicas recargadas.
+func startHealthChecks(ctx context.Context, upstreams func() []*upstream, policy proxyPolicy) {
+	if policy.HealthInterval <= 0 {
+		return
+	}
+	client := &http.Client{Timeout: policy.HealthTimeout}
+	go func() {
+		ticker := time.NewTicker(policy.HealthInterval)
+		defer ticker.Stop()
+		for {
+			var wg sync.WaitGroup
+			for _, u := range upstreams() {
+				wg.Add(1)
+				go func(u *upstream) {
+					defer wg.Done()
+					u.checkHealth(ctx, client)
+				}(u)
+			}
+			wg.Wait()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Estrategias de balanceo entre las réplicas de un servicio (LB_STRATEGY)
+const (
+	balanceRoundRobin       = "round-robin"
+	balanceLeastConnections = "least-connections"
+)
+
+// upstreamPool son las réplicas estables o las canary de un servicio. La lista se puede
+// reemplazar en caliente; las réplicas que siguen conservan su salud y su breaker.
+type upstreamPool struct {
+	name   string
+	canary bool
+	policy proxyPolicy
+
+	mu        sync.RWMutex
+	upstreams []*upstream
+	next      atomic.Uint64 // Turno de round-robin
+}
+
+func newUpstreamPool(name string, canary bool, specs []upstreamSpec, policy proxyPolicy) *upstreamPool {
+	pool := &upstreamPool{name: name, canary: canary, policy: policy}
+	pool.set(specs)
+	return pool
+}
+
+// set reemplaza las réplicas por las de specs y devuelve cuántas se agregaron y quitaron
+func (p *upstreamPool) set(specs []upstreamSpec) (added, removed int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	current := make(map[upstreamSpec]*upstream, len(p.upstreams))
+	for _, u := range p.upstreams {
+		current[upstreamSpec{URL: u.url, Timeout: u.timeout}] = u
+	}
+	upstreams := make([]*upstream, 0, len(specs))
+	for _, spec := range specs {
+		key := spec
+		if key.Timeout <= 0 {
+			key.Timeout = p.policy.UpstreamTimeout
+		}
+		if u, ok := current[key]; ok {
+			upstreams = append(upstreams, u)
+			delete(current, key)
+			continue
+		}
+		upstreams = append(upstreams, newUpstream(p.name, spec, p.canary, p.policy))
+		added++
+	}
+	p.upstreams = upstreams
+	return added, len(current)
+}
+
+// list devuelve las réplicas en el orden configurado
+func (p *upstreamPool) list() []*upstream {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]*upstream(nil), p.upstreams...)
+}
+
+// ordered devuelve las réplicas en el orden en que se deben intentar: rotadas por
+// turno con round-robin y, con least-connections, de menos a más peticiones en curso
+// (a igualdad, por turno)
+func (p *upstreamPool) ordered() []*upstream {
+	upstreams := p.list()
+	if len(upstreams) == 0 {
+		return nil
+	}
+	start := int((p.next.Add(1) - 1) % uint64(len(upstreams)))
+	ordered := make([]*upstream, 0, len(upstreams))
+	ordered = append(ordered, upstreams[start:]...)
+	ordered = append(ordered, upstreams[:start]...)
+	if p.policy.Strategy == balanceLeastConnections {
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return ordered[i].active.Load() < ordered[j].active.Load()
+		})
+	}
+	return ordered
+}
+
+// proxyAttempt es el resultado de enviar una petición a una réplica. Viaja en el
+// contexto para que createProxy lo complete en vez de responder el error.
+type proxyAttempt struct {
+	err       error
+	status    int
+	retryable bool // Queda otro intento: una respuesta de fallo se descarta
+}
+
+type proxyAttemptKey struct{}
+
+// upstreamFailed indica si un status significa que la réplica no está atendiendo
+func upstreamFailed(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
+
+// proxyError es la respuesta de error del propio proxy, con el envelope de los servicios
+type proxyError struct {
+	Code      string    `json:"code"`
+	Message   string    `json:"message"`
+	Details   string    `json:"details,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func writeProxyError(w http.ResponseWriter, r *http.Request, status int, code, message, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(proxyError{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: r.Header.Get("X-Request-ID"),
+		Timestamp: time.Now().UTC(),
+	})
+}
+
+// writeUpstreamError responde el error de red de la última réplica intentada
+func writeUpstreamError(w http.ResponseWriter, r *http.Request, service string, err error) {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		writeProxyError(w, r, http.StatusGatewayTimeout, "Timeout", service+" service did not answer in time", err.Error())
+		return
+	}
+	writeProxyError(w, r, http.StatusBadGateway, "ServiceUnavailable", service+" service is unreachable", err.Error())
+}
+
+// serveResilient envía la petición a la primera réplica de candidates que esté sana y
+// con el circuito cerrado. Un GET o HEAD que falla por red o con 502/503/504 se
+// reintenta hasta policy.Retries veces, pasando a la siguiente réplica si la hay; las
+// escrituras no se reintentan (podrían aplicarse dos veces). Sin réplicas disponibles
+// responde 503 de inmediato.
+func serveResilient(w http.ResponseWriter, r *http.Request, service string, candidates []*upstream, policy proxyPolicy, before func(*upstream)) {
+	attempts := 1
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		attempts += policy.Retries
+	}
+
+	var last *proxyAttempt
+	next := 0
+	for i := 0; i < attempts; i++ {
+		target := pickUpstream(candidates, &next)
+		if target == nil {
+			break
+		}
+		if i > 0 {
+			log.Printf("🔁 [Proxy] Reintento %d de %s %s -> %s (%s)", i, r.Method, r.URL.Path, target.name, target.url)
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(policy.RetryBackoff):
+			}
+		}
+
+		attempt := &proxyAttempt{retryable: i < attempts-1}
+		if entry := accessLogOf(r); entry != nil {
+			entry.Upstream = target.url
+			entry.Attempts = i + 1
+		}
+		if before != nil {
+			before(target)
+		}
+		target.active.Add(1)
+		target.proxy.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), proxyAttemptKey{}, attempt)))
+		target.active.Add(-1)
+		if r.Context().Err() != nil {
+			// El cliente se fue: no es un fallo de la réplica
+			return
+		}
+		target.recordResult(attempt.err == nil && !upstreamFailed(attempt.status))
+		if attempt.err == nil {
+			return
+		}
+		log.Printf("⚠️  [Proxy] %s %s -> %s (%s) falló: %v", r.Method, r.URL.Path, target.name, target.url, attempt.err)
+		last = attempt
+	}
+
+	if last != nil && !errors.Is(last.err, errRetryableStatus) {
+		writeUpstreamError(w, r, service, last.err)
+		return
+	}
+	retryAfter := time.Second
+	for _, candidate := range candidates {
+		if wait := candidate.breaker.retryAfter(); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	w.Header().Set("Retry-After", fmt.Sprintf("%.0f", retryAfter.Seconds()))
+	writeProxyError(w, r, http.StatusServiceUnavailable, "ServiceUnavailable", service+" service is unavailable",
+		"no healthy replica with a closed circuit breaker; see /proxy/status")
+}
+
+// errRetryableStatus marca una respuesta 502/503/504 descartada para reintentar
+var errRetryableStatus = errors.New("upstream answered with a failure status")
+
+// pickUpstream devuelve la primera réplica disponible desde candidates[*next], rotando
+// para que el siguiente intento empiece por la réplica siguiente
+func pickUpstream(candidates []*upstream, next *int) *upstream {
+	for n := 0; n < len(candidates); n++ {
+		candidate := candidates[(*next+n)%len(candidates)]
+		if candidate.isHealthy() && candidate.breaker.allow() {
+			*next = (*next + n + 1) % len(candidates)
+			return candidate
+		}
+	}
+	return nil
+}
+
+// proxyStatus es la respuesta de GET /proxy/status
+type proxyStatus struct {
+	CheckedAt time.Time            `json:"checked_at"`
+	Strategy  string               `json:"strategy"`
+	Services  []proxyServiceStatus `json:"services"`
+}
+
+type proxyServiceStatus struct {
+	Service   string           `json:"service"`
+	Available bool             `json:"available"` // Alguna réplica sana con el circuito sin abrir
+	Upstreams []upstreamStatus `json:"upstreams"`
+}
+
+// newProxyStatusHandler expone el estado de salud y del circuit breaker de cada réplica
+func newProxyStatusHandler(routers ...*canaryRouter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		report := proxyStatus{CheckedAt: time.Now().UTC()}
+		for _, router := range routers {
+			report.Strategy = router.policy.Strategy
+			service := proxyServiceStatus{Service: router.service}
+			for _, u := range router.upstreams() {
+				status := u.status()
+				if status.Healthy && status.Breaker.State != breakerOpen {
+					service.Available = true
+				}
+				service.Upstreams = append(service.Upstreams, status)
+			}
+			report.Services = append(report.Services, service)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(report)
+	})
+}
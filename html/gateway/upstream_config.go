@@ -0,0 +1,175 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// upstreamSpec es una réplica configurada: "url" o "url;timeout=5s"
+type upstreamSpec struct {
+	URL     string
+	Timeout time.Duration // 0: PROXY_UPSTREAM_TIMEOUT_MS
+}
+
+// parseUpstreamSpec lee una réplica con su timeout opcional
+func parseUpstreamSpec(entry string) (upstreamSpec, error) {
+	parts := strings.Split(entry, ";")
+	spec := upstreamSpec{URL: strings.TrimSuffix(strings.TrimSpace(parts[0]), "/")}
+	parsed, err := url.Parse(spec.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return spec, fmt.Errorf("réplica inválida %q: se espera http(s)://host[:puerto]", entry)
+	}
+	for _, option := range parts[1:] {
+		key, value, _ := strings.Cut(strings.TrimSpace(option), "=")
+		if key != "timeout" {
+			return spec, fmt.Errorf("réplica %q: opción desconocida %q (solo timeout)", entry, key)
+		}
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return spec, fmt.Errorf("réplica %q: timeout inválido %q", entry, value)
+		}
+		spec.Timeout = timeout
+	}
+	return spec, nil
+}
+
+// parseUpstreamSpecs lee una lista de réplicas
+func parseUpstreamSpecs(entries []string) ([]upstreamSpec, error) {
+	specs := make([]upstreamSpec, 0, len(entries))
+	for _, entry := range entries {
+		spec, err := parseUpstreamSpec(entry)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// upstreamConfig son las réplicas de cada servicio, de los flags o de PROXY_UPSTREAMS_FILE
+type upstreamConfig struct {
+	Command       []string `json:"command"`
+	CommandCanary []string `json:"command_canary"`
+	Query         []string `json:"query"`
+	QueryCanary   []string `json:"query_canary"`
+}
+
+// upstreamSpecs son las réplicas ya validadas de cada servicio
+type upstreamSpecs struct {
+	command, commandCanary, query, queryCanary []upstreamSpec
+}
+
+// specs valida la configuración; command y query necesitan al menos una réplica
+func (c upstreamConfig) specs() (upstreamSpecs, error) {
+	var specs upstreamSpecs
+	var err error
+	if len(c.Command) == 0 || len(c.Query) == 0 {
+		return specs, fmt.Errorf("command y query necesitan al menos una réplica")
+	}
+	if specs.command, err = parseUpstreamSpecs(c.Command); err != nil {
+		return specs, err
+	}
+	if specs.commandCanary, err = parseUpstreamSpecs(c.CommandCanary); err != nil {
+		return specs, err
+	}
+	if specs.query, err = parseUpstreamSpecs(c.Query); err != nil {
+		return specs, err
+	}
+	if specs.queryCanary, err = parseUpstreamSpecs(c.QueryCanary); err != nil {
+		return specs, err
+	}
+	return specs, nil
+}
+
+// loadUpstreamsFile lee el JSON de réplicas, p. ej.
+// {"command": ["http://10.0.0.1:8080", "http://10.0.0.2:8080;timeout=5s"], "query": ["http://10.0.0.3:8081"]}
+func loadUpstreamsFile(path string) (upstreamConfig, error) {
+	var config upstreamConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// applyUpstreams reemplaza las réplicas de los routers por las de specs
+func applyUpstreams(command, query *canaryRouter, specs upstreamSpecs) {
+	for _, change := range []struct {
+		pool  *upstreamPool
+		specs []upstreamSpec
+	}{
+		{command.stable, specs.command},
+		{command.canary, specs.commandCanary},
+		{query.stable, specs.query},
+		{query.canary, specs.queryCanary},
+	} {
+		if added, removed := change.pool.set(change.specs); added > 0 || removed > 0 {
+			log.Printf("🔄 [Proxy] Réplicas de %s: %d agregadas, %d quitadas (%d en total)",
+				change.pool.name, added, removed, len(change.specs))
+		}
+	}
+}
+
+// watchUpstreamsFile recarga las réplicas de path cuando el archivo cambia (se revisa
+// cada interval) o al recibir SIGHUP. Una versión inválida se descarta y se siguen
+// usando las réplicas anteriores.
+func watchUpstreamsFile(ctx context.Context, path string, interval time.Duration, command, query *canaryRouter) {
+	reload := func() {
+		config, err := loadUpstreamsFile(path)
+		if err == nil {
+			var specs upstreamSpecs
+			if specs, err = config.specs(); err == nil {
+				applyUpstreams(command, query, specs)
+				return
+			}
+		}
+		log.Printf("❌ [Proxy] No se recargaron las réplicas de %s: %v", path, err)
+	}
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	var ticker <-chan time.Time
+	if interval > 0 {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		ticker = t.C
+	}
+
+	lastModified := fileModTime(path)
+	for {
+		select {
+		case <-ctx.Done():
+			signal.Stop(hangup)
+			return
+		case <-hangup:
+			log.Printf("🔄 [Proxy] SIGHUP: recargando réplicas de %s", path)
+			lastModified = fileModTime(path)
+			reload()
+		case <-ticker:
+			if modified := fileModTime(path); !modified.Equal(lastModified) {
+				lastModified = modified
+				reload()
+			}
+		}
+	}
+}
+
+// fileModTime devuelve la fecha de modificación de path, o cero si no se puede leer
+func fileModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,523 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNewCORSPolicy verifica la lectura y validación de CORS_ALLOWED_ORIGINS
+func TestNewCORSPolicy(t *testing.T) {
+	policy, err := newCORSPolicy("https://dashboard.example.com, *", "Content-Type", 3600)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !policy.origins["https://dashboard.example.com"] || !policy.allowAny {
+		t.Errorf("Unexpected policy: %+v", policy)
+	}
+
+	if _, err := newCORSPolicy("dashboard.example.com", "Content-Type", 3600); err == nil {
+		t.Error("Expected an error for an origin without scheme")
+	}
+}
+
+// TestCanaryRouting_Header verifica que X-Canary: 1 envíe la petición al upstream canary
+func TestCanaryRouting_Header(t *testing.T) {
+	stableServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("stable"))
+	}))
+	defer stableServer.Close()
+
+	canaryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("canary"))
+	}))
+	defer canaryServer.Close()
+
+	router := newCanaryRouter("query", specsOf(stableServer.URL), specsOf(canaryServer.URL), 0, loadProxyPolicy())
+
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"1", "canary"},
+		{"0", "stable"},
+		{"", "stable"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/v1/inventory/items", nil)
+		if tt.header != "" {
+			req.Header.Set(CanaryHeader, tt.header)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if body := w.Body.String(); body != tt.expected {
+			t.Errorf("X-Canary=%q: expected %s upstream, got %s", tt.header, tt.expected, body)
+		}
+	}
+}
+
+// TestCanaryRouting_Percentage verifica el reparto por porcentaje y el header de opt-out
+func TestCanaryRouting_Percentage(t *testing.T) {
+	stableServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("stable"))
+	}))
+	defer stableServer.Close()
+
+	canaryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("canary"))
+	}))
+	defer canaryServer.Close()
+
+	router := newCanaryRouter("command", specsOf(stableServer.URL), specsOf(canaryServer.URL), 100, loadProxyPolicy())
+
+	req := httptest.NewRequest("POST", "/api/v1/inventory/items", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Body.String() != "canary" {
+		t.Errorf("Expected canary upstream with 100%%, got %s", w.Body.String())
+	}
+	if w.Header().Get("X-Canary-Upstream") != "command" {
+		t.Errorf("Expected X-Canary-Upstream header, got %q", w.Header().Get("X-Canary-Upstream"))
+	}
+
+	req = httptest.NewRequest("POST", "/api/v1/inventory/items", nil)
+	req.Header.Set(CanaryHeader, "0")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Body.String() != "stable" {
+		t.Errorf("Expected stable upstream with X-Canary: 0, got %s", w.Body.String())
+	}
+}
+
+// staticTargets devuelve siempre los mismos health checks
+func staticTargets(targets []healthTarget) func() []healthTarget {
+	return func() []healthTarget { return targets }
+}
+
+// TestAggregateHealth_AllOK verifica que /api/v1/health/all responda 200 y ready=true
+// cuando los tres servicios responden "ok"
+func TestAggregateHealth_AllOK(t *testing.T) {
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"ok","service":"test"}`))
+	}))
+	defer ok.Close()
+
+	handler := newAggregateHealthHandler(staticTargets([]healthTarget{
+		{Service: "command-service", URL: ok.URL},
+		{Service: "query-service", URL: ok.URL},
+		{Service: "listener-service", URL: ok.URL},
+	}), time.Second)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/health/all", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var report aggregateHealth
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if !report.Ready || report.Status != "ok" || len(report.Services) != 3 {
+		t.Errorf("Expected ready ok with 3 services, got %+v", report)
+	}
+	if string(report.Services[0].Detail) != `{"status":"ok","service":"test"}` {
+		t.Errorf("Expected service detail to be preserved, got %s", report.Services[0].Detail)
+	}
+}
+
+// TestAggregateHealth_NotReady verifica que un servicio caído, con error o degradado
+// haga responder 503 con el detalle de cada servicio
+func TestAggregateHealth_NotReady(t *testing.T) {
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer ok.Close()
+	degraded := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"degraded"}`))
+	}))
+	defer degraded.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	tests := []struct {
+		name     string
+		targets  []healthTarget
+		status   string
+		services []string
+	}{
+		{"degraded", []healthTarget{{"command-service", ok.URL}, {"query-service", degraded.URL}}, "degraded", []string{"ok", "degraded"}},
+		{"error", []healthTarget{{"command-service", failing.URL}, {"query-service", degraded.URL}}, "down", []string{"down", "degraded"}},
+		{"timeout", []healthTarget{{"command-service", ok.URL}, {"listener-service", slow.URL}}, "down", []string{"ok", "down"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newAggregateHealthHandler(staticTargets(tt.targets), 100*time.Millisecond).ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/health/all", nil))
+
+			if w.Code != http.StatusServiceUnavailable {
+				t.Fatalf("Expected status 503, got %d", w.Code)
+			}
+			var report aggregateHealth
+			if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+				t.Fatalf("Invalid JSON: %v", err)
+			}
+			if report.Ready || report.Status != tt.status {
+				t.Errorf("Expected not ready with status %s, got %+v", tt.status, report)
+			}
+			for i, expected := range tt.services {
+				if report.Services[i].Status != expected {
+					t.Errorf("Expected %s to be %s, got %s", report.Services[i].Service, expected, report.Services[i].Status)
+				}
+			}
+		})
+	}
+}
+
+// specsOf devuelve las réplicas de urls, sin timeout propio
+func specsOf(urls ...string) []upstreamSpec {
+	var specs []upstreamSpec
+	for _, u := range urls {
+		specs = append(specs, upstreamSpec{URL: u})
+	}
+	return specs
+}
+
+// testProxyPolicy reintenta sin espera y abre el circuito al segundo fallo
+func testProxyPolicy() proxyPolicy {
+	return proxyPolicy{HealthTimeout: time.Second, Retries: 1, BreakerThreshold: 2, BreakerCooldown: time.Minute}
+}
+
+// closedServerURL devuelve la URL de un servidor que ya no escucha (conexión rechazada)
+func closedServerURL() string {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	return server.URL
+}
+
+// TestResilientRouting_RetriesGETOnOtherReplica verifica que un GET que falla por red se
+// reintente en la otra réplica y que una escritura no se reintente
+func TestResilientRouting_RetriesGETOnOtherReplica(t *testing.T) {
+	canaryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("canary"))
+	}))
+	defer canaryServer.Close()
+
+	router := newCanaryRouter("query", specsOf(closedServerURL()), specsOf(canaryServer.URL), 0, testProxyPolicy())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/inventory/items", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "canary" {
+		t.Fatalf("Expected the GET to be retried on the canary, got %d %s", w.Code, w.Body.String())
+	}
+
+	req := httptest.NewRequest("POST", "/api/v1/inventory/items", strings.NewReader(`{}`))
+	req.Header.Set(CanaryHeader, "0")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("Expected 502 for a POST to a down replica, got %d", w.Code)
+	}
+	var body proxyError
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != "ServiceUnavailable" {
+		t.Errorf("Expected a JSON error with code ServiceUnavailable, got %s", w.Body.String())
+	}
+}
+
+// TestResilientRouting_RetriesFailureStatus verifica que un 503 de la réplica se descarte
+// y el GET se reintente, y que el último intento responda lo que devuelva el servicio
+func TestResilientRouting_RetriesFailureStatus(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	router := newCanaryRouter("query", specsOf(server.URL), nil, 0, testProxyPolicy())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/inventory/items", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "ok" || calls != 2 {
+		t.Errorf("Expected the second attempt to answer, got %d %s after %d calls", w.Code, w.Body.String(), calls)
+	}
+}
+
+// TestResilientRouting_CircuitBreakerFastFails verifica que con el circuito abierto se
+// responda 503 sin llamar al servicio y que /proxy/status lo muestre
+func TestResilientRouting_CircuitBreakerFastFails(t *testing.T) {
+	router := newCanaryRouter("command", specsOf(closedServerURL()), nil, 0, testProxyPolicy())
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/inventory/items", strings.NewReader(`{}`)))
+		if w.Code != http.StatusBadGateway {
+			t.Fatalf("Request %d: expected 502, got %d", i+1, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/health", nil))
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("Expected a fast 503 with Retry-After, got %d", w.Code)
+	}
+	var body proxyError
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != "ServiceUnavailable" {
+		t.Errorf("Expected a JSON error with code ServiceUnavailable, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	newProxyStatusHandler(router).ServeHTTP(w, httptest.NewRequest("GET", "/proxy/status", nil))
+	var report proxyStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	service := report.Services[0]
+	if service.Available || service.Upstreams[0].Breaker.State != breakerOpen || service.Upstreams[0].Failures != 2 {
+		t.Errorf("Expected the command replica to be unavailable with an open breaker, got %+v", service)
+	}
+}
+
+// TestCircuitBreaker_HalfOpen verifica que pasado el cooldown pase una sola petición de
+// prueba, que cierra el circuito si sale bien y lo vuelve a abrir si falla
+func TestCircuitBreaker_HalfOpen(t *testing.T) {
+	now := time.Now()
+	breaker := newCircuitBreaker(1, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	breaker.record(false)
+	if breaker.allow() {
+		t.Fatal("Expected the breaker to be open")
+	}
+
+	now = now.Add(time.Minute)
+	if !breaker.allow() || breaker.allow() {
+		t.Fatal("Expected exactly one trial request after the cooldown")
+	}
+	breaker.record(false)
+	if breaker.allow() {
+		t.Fatal("Expected a failed trial to reopen the breaker")
+	}
+
+	now = now.Add(time.Minute)
+	breaker.allow()
+	breaker.record(true)
+	if !breaker.allow() || !breaker.allow() {
+		t.Error("Expected a successful trial to close the breaker")
+	}
+}
+
+// TestUpstreamHealthCheck verifica que una réplica caída deje de recibir tráfico
+func TestUpstreamHealthCheck(t *testing.T) {
+	healthy := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/health" && !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	router := newCanaryRouter("query", specsOf(server.URL), nil, 0, testProxyPolicy())
+	client := &http.Client{Timeout: time.Second}
+
+	replica := router.stable.list()[0]
+	replica.checkHealth(context.Background(), client)
+	if !replica.isHealthy() {
+		t.Fatal("Expected the replica to be healthy")
+	}
+
+	healthy = false
+	replica.checkHealth(context.Background(), client)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/inventory/items", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected a fast 503 for an unhealthy replica, got %d", w.Code)
+	}
+}
+
+// TestUpstreamPool_Balancing verifica el reparto round-robin y least-connections
+func TestUpstreamPool_Balancing(t *testing.T) {
+	specs := specsOf("http://a:1", "http://b:1", "http://c:1")
+
+	pool := newUpstreamPool("query", false, specs, testProxyPolicy())
+	var first []string
+	for i := 0; i < 4; i++ {
+		first = append(first, pool.ordered()[0].url)
+	}
+	if strings.Join(first, " ") != "http://a:1 http://b:1 http://c:1 http://a:1" {
+		t.Errorf("Expected round-robin order, got %v", first)
+	}
+
+	policy := testProxyPolicy()
+	policy.Strategy = balanceLeastConnections
+	pool = newUpstreamPool("query", false, specs, policy)
+	replicas := pool.list()
+	replicas[0].active.Add(2)
+	replicas[2].active.Add(1)
+	if ordered := pool.ordered(); ordered[0] != replicas[1] || ordered[1] != replicas[2] || ordered[2] != replicas[0] {
+		t.Errorf("Expected the replica with fewest active requests first, got %s %s %s", ordered[0].url, ordered[1].url, ordered[2].url)
+	}
+}
+
+// TestUpstreamPool_Reload verifica que al recargar la lista las réplicas que siguen
+// conserven su estado y que el timeout propio se aplique
+func TestUpstreamPool_Reload(t *testing.T) {
+	policy := testProxyPolicy()
+	policy.UpstreamTimeout = 10 * time.Second
+	pool := newUpstreamPool("command", false, specsOf("http://a:1", "http://b:1"), policy)
+	kept := pool.list()[1]
+	kept.recordResult(false)
+
+	specs, err := upstreamConfig{Command: []string{"http://b:1", "http://c:1;timeout=2s"}, Query: []string{"http://q:1"}}.specs()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	added, removed := pool.set(specs.command)
+	if added != 1 || removed != 1 {
+		t.Errorf("Expected 1 added and 1 removed, got %d and %d", added, removed)
+	}
+	replicas := pool.list()
+	if len(replicas) != 2 || replicas[0] != kept || replicas[0].status().Failures != 1 {
+		t.Errorf("Expected http://b:1 to keep its state, got %+v", replicas[0].status())
+	}
+	if replicas[1].url != "http://c:1" || replicas[1].timeout != 2*time.Second || kept.timeout != 10*time.Second {
+		t.Errorf("Expected per-replica timeouts, got %s %s", replicas[1].timeout, kept.timeout)
+	}
+}
+
+// TestUpstreamConfig_Invalid verifica la validación de las listas de réplicas
+func TestUpstreamConfig_Invalid(t *testing.T) {
+	tests := []upstreamConfig{
+		{Command: []string{"http://a:1"}},
+		{Command: []string{"localhost:8080"}, Query: []string{"http://q:1"}},
+		{Command: []string{"http://a:1;timeout=soon"}, Query: []string{"http://q:1"}},
+		{Command: []string{"http://a:1;weight=2"}, Query: []string{"http://q:1"}},
+	}
+	for _, config := range tests {
+		if _, err := config.specs(); err == nil {
+			t.Errorf("Expected an error for %+v", config)
+		}
+	}
+}
+
+// TestUpstreamTimeout verifica que una réplica lenta responda 504 al vencer su timeout
+func TestUpstreamTimeout(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	policy := testProxyPolicy()
+	policy.Retries = 0
+	router := newCanaryRouter("query", []upstreamSpec{{URL: slow.URL, Timeout: 50 * time.Millisecond}}, nil, 0, policy)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/inventory/items", nil))
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("Expected 504, got %d", w.Code)
+	}
+}
+
+// TestAccessLog_RequestID verifica que el proxy genere X-Request-ID si falta o es
+// inválido, lo reenvíe al servicio, lo devuelva y escriba la línea JSON del access log
+func TestAccessLog_RequestID(t *testing.T) {
+	var received []string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get(RequestIDHeader))
+		w.Write([]byte(`{"items":[]}`))
+	}))
+	defer backend.Close()
+
+	path := t.TempDir() + "/access.log"
+	logger, err := newAccessLogger(path, accessLogJSON)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	router := newCanaryRouter("query", specsOf(backend.URL), nil, 0, testProxyPolicy())
+	handler := accessLogMiddleware(logger, router)
+
+	tests := []struct {
+		header   string
+		expected string // vacío: uno generado
+	}{
+		{"", ""},
+		{"dashboard-123", "dashboard-123"},
+		{"bad id\nforged line", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/v1/inventory/items?page=1", nil)
+		if tt.header != "" {
+			req.Header.Set(RequestIDHeader, tt.header)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		id := w.Header().Get(RequestIDHeader)
+		if tt.expected != "" && id != tt.expected {
+			t.Errorf("Expected request ID %s, got %s", tt.expected, id)
+		}
+		if tt.expected == "" && (len(id) != 36 || id == tt.header) {
+			t.Errorf("Expected a generated UUID, got %q", id)
+		}
+		if received[len(received)-1] != id {
+			t.Errorf("Expected the backend to receive %s, got %s", id, received[len(received)-1])
+		}
+		if values := w.Header().Values(RequestIDHeader); len(values) != 1 {
+			t.Errorf("Expected one X-Request-ID header, got %v", values)
+		}
+	}
+	logger.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 access log lines, got %d", len(lines))
+	}
+	var entry accessLogEntry
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if entry.RequestID != "dashboard-123" || entry.Method != "GET" || entry.Path != "/api/v1/inventory/items" ||
+		entry.Query != "page=1" || entry.Upstream != backend.URL || entry.Status != 200 || entry.Bytes != 12 || entry.Attempts != 1 {
+		t.Errorf("Unexpected access log entry: %+v", entry)
+	}
+}
+
+// TestAccessLog_ProxyError verifica que un error del propio proxy lleve el X-Request-ID
+// en el header y en el cuerpo
+func TestAccessLog_ProxyError(t *testing.T) {
+	logger, _ := newAccessLogger("off", accessLogText)
+	router := newCanaryRouter("command", specsOf(closedServerURL()), nil, 0, testProxyPolicy())
+	req := httptest.NewRequest("POST", "/api/v1/inventory/items", strings.NewReader(`{}`))
+	req.Header.Set(RequestIDHeader, "req-1")
+	w := httptest.NewRecorder()
+	accessLogMiddleware(logger, router).ServeHTTP(w, req)
+
+	var body proxyError
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if w.Header().Get(RequestIDHeader) != "req-1" || body.RequestID != "req-1" {
+		t.Errorf("Expected request ID req-1 in header and body, got %q and %q", w.Header().Get(RequestIDHeader), body.RequestID)
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
-	"net/http/httputil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"dashboard-server/gateway"
 )
 
 // TestProxyRouting_GET_InventoryItems verifica que las peticiones GET a /api/v1/inventory/items
@@ -31,12 +34,8 @@ func TestProxyRouting_GET_InventoryItems(t *testing.T) {
 	}))
 	defer commandServer.Close()
 
-	// Crear el proxy con los servidores mock
-	queryProxy := createProxy(queryServer.URL)
-	commandProxy := createProxy(commandServer.URL)
-
-	// Crear el handler del proxy
-	handler := createProxyHandler(queryProxy, commandProxy)
+	// Crear el gateway con los servidores mock
+	handler := newTestGateway(t, commandServer.URL, queryServer.URL)
 
 	// Crear la petición
 	req := httptest.NewRequest("GET", "/api/v1/inventory/items?page=1&page_size=100", nil)
@@ -71,9 +70,7 @@ func TestProxyRouting_GET_InventoryItemsByID(t *testing.T) {
 	}))
 	defer commandServer.Close()
 
-	queryProxy := createProxy(queryServer.URL)
-	commandProxy := createProxy(commandServer.URL)
-	handler := createProxyHandler(queryProxy, commandProxy)
+	handler := newTestGateway(t, commandServer.URL, queryServer.URL)
 
 	req := httptest.NewRequest("GET", expectedPath, nil)
 	w := httptest.NewRecorder()
@@ -105,9 +102,7 @@ func TestProxyRouting_GET_InventoryItemsBySKU(t *testing.T) {
 	}))
 	defer commandServer.Close()
 
-	queryProxy := createProxy(queryServer.URL)
-	commandProxy := createProxy(commandServer.URL)
-	handler := createProxyHandler(queryProxy, commandProxy)
+	handler := newTestGateway(t, commandServer.URL, queryServer.URL)
 
 	req := httptest.NewRequest("GET", expectedPath, nil)
 	w := httptest.NewRecorder()
@@ -144,9 +139,7 @@ func TestProxyRouting_POST_CreateItem(t *testing.T) {
 	}))
 	defer queryServer.Close()
 
-	queryProxy := createProxy(queryServer.URL)
-	commandProxy := createProxy(commandServer.URL)
-	handler := createProxyHandler(queryProxy, commandProxy)
+	handler := newTestGateway(t, commandServer.URL, queryServer.URL)
 
 	body := `{"sku":"SKU-001","name":"Test Item","quantity":100}`
 	req := httptest.NewRequest("POST", "/api/v1/inventory/items", strings.NewReader(body))
@@ -183,9 +176,7 @@ func TestProxyRouting_POST_ReserveStock(t *testing.T) {
 	}))
 	defer queryServer.Close()
 
-	queryProxy := createProxy(queryServer.URL)
-	commandProxy := createProxy(commandServer.URL)
-	handler := createProxyHandler(queryProxy, commandProxy)
+	handler := newTestGateway(t, commandServer.URL, queryServer.URL)
 
 	body := `{"quantity":2}`
 	req := httptest.NewRequest("POST", expectedPath, strings.NewReader(body))
@@ -214,9 +205,7 @@ func TestProxyRouting_POST_ReleaseStock(t *testing.T) {
 	}))
 	defer commandServer.Close()
 
-	queryProxy := createProxy("http://localhost:8081")
-	commandProxy := createProxy(commandServer.URL)
-	handler := createProxyHandler(queryProxy, commandProxy)
+	handler := newTestGateway(t, commandServer.URL, "http://localhost:8081")
 
 	body := `{"quantity":2}`
 	req := httptest.NewRequest("POST", expectedPath, strings.NewReader(body))
@@ -245,9 +234,7 @@ func TestProxyRouting_POST_AdjustStock(t *testing.T) {
 	}))
 	defer commandServer.Close()
 
-	queryProxy := createProxy("http://localhost:8081")
-	commandProxy := createProxy(commandServer.URL)
-	handler := createProxyHandler(queryProxy, commandProxy)
+	handler := newTestGateway(t, commandServer.URL, "http://localhost:8081")
 
 	body := `{"quantity":2}`
 	req := httptest.NewRequest("POST", expectedPath, strings.NewReader(body))
@@ -279,9 +266,7 @@ func TestProxyRouting_PUT_UpdateItem(t *testing.T) {
 	}))
 	defer commandServer.Close()
 
-	queryProxy := createProxy("http://localhost:8081")
-	commandProxy := createProxy(commandServer.URL)
-	handler := createProxyHandler(queryProxy, commandProxy)
+	handler := newTestGateway(t, commandServer.URL, "http://localhost:8081")
 
 	body := `{"name":"Updated Name"}`
 	req := httptest.NewRequest("PUT", expectedPath, strings.NewReader(body))
@@ -313,9 +298,7 @@ func TestProxyRouting_DELETE_DeleteItem(t *testing.T) {
 	}))
 	defer commandServer.Close()
 
-	queryProxy := createProxy("http://localhost:8081")
-	commandProxy := createProxy(commandServer.URL)
-	handler := createProxyHandler(queryProxy, commandProxy)
+	handler := newTestGateway(t, commandServer.URL, "http://localhost:8081")
 
 	req := httptest.NewRequest("DELETE", expectedPath, nil)
 	w := httptest.NewRecorder()
@@ -342,9 +325,7 @@ func TestProxyRouting_POST_Login(t *testing.T) {
 	}))
 	defer commandServer.Close()
 
-	queryProxy := createProxy("http://localhost:8081")
-	commandProxy := createProxy(commandServer.URL)
-	handler := createProxyHandler(queryProxy, commandProxy)
+	handler := newTestGateway(t, commandServer.URL, "http://localhost:8081")
 
 	body := `{"username":"admin","password":"admin123"}`
 	req := httptest.NewRequest("POST", "/api/v1/auth/login", strings.NewReader(body))
@@ -369,9 +350,7 @@ func TestProxyRouting_QueryParamsPreservation(t *testing.T) {
 	}))
 	defer queryServer.Close()
 
-	queryProxy := createProxy(queryServer.URL)
-	commandProxy := createProxy("http://localhost:8080")
-	handler := createProxyHandler(queryProxy, commandProxy)
+	handler := newTestGateway(t, "http://localhost:8080", queryServer.URL)
 
 	req := httptest.NewRequest("GET", "/api/v1/inventory/items?page=1&page_size=100", nil)
 	w := httptest.NewRecorder()
@@ -391,18 +370,20 @@ func TestCORSHeaders(t *testing.T) {
 	}))
 	defer queryServer.Close()
 
-	queryProxy := createProxy(queryServer.URL)
-	commandProxy := createProxy("http://localhost:8080")
-	handler := createProxyHandler(queryProxy, commandProxy)
+	handler := newTestGateway(t, "http://localhost:8080", queryServer.URL)
 
 	req := httptest.NewRequest("GET", "/api/v1/inventory/items", nil)
+	req.Header.Set("Origin", "http://localhost:8000")
 	w := httptest.NewRecorder()
 
 	handler.ServeHTTP(w, req)
 
-	// Verificar headers CORS
-	if w.Header().Get("Access-Control-Allow-Origin") != "*" {
-		t.Errorf("Expected Access-Control-Allow-Origin: *, got %s", w.Header().Get("Access-Control-Allow-Origin"))
+	// Verificar headers CORS: se devuelve el origen permitido, nunca "*" con credenciales
+	if got := w.Header().Values("Access-Control-Allow-Origin"); len(got) != 1 || got[0] != "http://localhost:8000" {
+		t.Errorf("Expected Access-Control-Allow-Origin: http://localhost:8000, got %v", got)
+	}
+	if w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Error("Expected Access-Control-Allow-Credentials: true")
 	}
 	if w.Header().Get("Access-Control-Allow-Methods") == "" {
 		t.Error("Expected Access-Control-Allow-Methods header")
@@ -411,9 +392,7 @@ func TestCORSHeaders(t *testing.T) {
 
 // TestCORS_PreflightRequest verifica que las peticiones OPTIONS (preflight) se manejen correctamente
 func TestCORS_PreflightRequest(t *testing.T) {
-	queryProxy := createProxy("http://localhost:8081")
-	commandProxy := createProxy("http://localhost:8080")
-	handler := createProxyHandler(queryProxy, commandProxy)
+	handler := newTestGateway(t, "http://localhost:8080", "http://localhost:8081")
 
 	req := httptest.NewRequest("OPTIONS", "/api/v1/inventory/items", nil)
 	req.Header.Set("Origin", "http://localhost:8000")
@@ -427,52 +406,97 @@ func TestCORS_PreflightRequest(t *testing.T) {
 	}
 
 	// Verificar headers CORS
-	if w.Header().Get("Access-Control-Allow-Origin") != "*" {
-		t.Errorf("Expected Access-Control-Allow-Origin: *, got %s", w.Header().Get("Access-Control-Allow-Origin"))
+	if w.Header().Get("Access-Control-Allow-Origin") != "http://localhost:8000" {
+		t.Errorf("Expected Access-Control-Allow-Origin: http://localhost:8000, got %s", w.Header().Get("Access-Control-Allow-Origin"))
 	}
 }
 
-// createProxyHandler crea un handler de prueba que simula el comportamiento del servidor
-func createProxyHandler(queryProxy, commandProxy *httputil.ReverseProxy) http.Handler {
-	mux := http.NewServeMux()
-
-	// Health checks específicos
-	mux.HandleFunc("/api/v1/health/command", func(w http.ResponseWriter, r *http.Request) {
-		r.URL.Path = "/api/v1/health"
-		commandProxy.ServeHTTP(w, r)
-	})
-
-	mux.HandleFunc("/api/v1/health/query", func(w http.ResponseWriter, r *http.Request) {
-		r.URL.Path = "/api/v1/health"
-		queryProxy.ServeHTTP(w, r)
-	})
-
-	// Proxy para todas las peticiones /api/v1/
-	mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, r *http.Request) {
-		path := r.URL.Path
-		method := r.Method
-
-		// Health check genérico
-		if path == "/api/v1/health" {
-			commandProxy.ServeHTTP(w, r)
-			return
-		}
+// TestCORS_RejectedOrigin verifica que un origen fuera de CORS_ALLOWED_ORIGINS no reciba headers CORS
+func TestCORS_RejectedOrigin(t *testing.T) {
+	handler := newTestGateway(t, "http://localhost:8080", "http://localhost:8081")
 
-		// Rutas de consulta (GET) van a Query Service
-		if method == "GET" && strings.HasPrefix(path, "/api/v1/inventory/items") {
-			queryProxy.ServeHTTP(w, r)
-			return
-		}
+	req := httptest.NewRequest("OPTIONS", "/api/v1/inventory/items", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
 
-		// Rutas de autenticación van a Command Service
-		if strings.HasPrefix(path, "/api/v1/auth/") {
-			commandProxy.ServeHTTP(w, r)
-			return
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for a disallowed origin, got %d", w.Code)
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Expected no Access-Control-Allow-Origin, got %s", w.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
+
+// TestProxyRouting_QueryServiceReads verifica que las lecturas que el proxy anterior
+// enviaba al Command Service (stream, export, GraphQL, batch) lleguen al Query Service
+func TestProxyRouting_QueryServiceReads(t *testing.T) {
+	queryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("query"))
+	}))
+	defer queryServer.Close()
+
+	commandServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Command Service should not be called for %s %s", r.Method, r.URL.Path)
+	}))
+	defer commandServer.Close()
+
+	handler := newTestGateway(t, commandServer.URL, queryServer.URL)
+
+	for _, tt := range []struct{ method, path string }{
+		{"GET", "/api/v1/inventory/stream"},
+		{"GET", "/api/v1/inventory/export?format=csv"},
+		{"POST", "/api/v1/graphql"},
+		{"POST", "/api/v1/inventory/items/batch"},
+		{"GET", "/api/v1/stores/1/calendar"},
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Body.String() != "query" {
+			t.Errorf("%s %s: expected Query Service, got %d %s", tt.method, tt.path, w.Code, w.Body.String())
 		}
+	}
+}
 
-		// Todas las demás rutas van a Command Service
-		commandProxy.ServeHTTP(w, r)
-	})
+// TestStaticFiles verifica que las rutas fuera del gateway sirvan los archivos del dashboard
+func TestStaticFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("dashboard"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config := testGatewayConfig("http://localhost:8080", "http://localhost:8081")
+	gw, err := gateway.New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gw.Close()
+
+	w := httptest.NewRecorder()
+	gw.Handler(http.FileServer(http.Dir(dir))).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "dashboard" {
+		t.Errorf("Expected the static file, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+// testGatewayConfig apunta el gateway a los servicios mock, sin validación de JWT (los
+// servicios mock no la hacen) ni access log
+func testGatewayConfig(commandURL, queryURL string) gateway.Config {
+	config := gateway.DefaultConfig()
+	config.CommandURLs = commandURL
+	config.QueryURLs = queryURL
+	config.Auth = "off"
+	config.AccessLog = "off"
+	return config
+}
 
-	return corsMiddleware(mux)
+// newTestGateway crea el handler del dashboard con el gateway apuntando a los servicios mock
+func newTestGateway(t *testing.T, commandURL, queryURL string) http.Handler {
+	gw, err := gateway.New(testGatewayConfig(commandURL, queryURL))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Cleanup(func() { gw.Close() })
+	return gw.Handler(http.NotFoundHandler())
 }
@@ -29,7 +29,7 @@ echo Iniciando servidor en http://localhost:8000
 echo Presiona Ctrl+C para detener el servidor
 echo.
 
-go run server.go
+go run .
 
 pause
 
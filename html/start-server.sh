@@ -26,5 +26,5 @@ echo "Iniciando servidor en http://localhost:8000"
 echo "Presiona Ctrl+C para detener el servidor"
 echo ""
 
-go run server.go
+go run .
 
@@ -2,29 +2,42 @@ package main
 
 import (
 	"context"
+	"flag"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"command-service/internal/audit"
 	"command-service/internal/auth"
 	"command-service/internal/config"
+	"command-service/internal/events"
+	"command-service/internal/grpcapi"
 	"command-service/internal/handlers"
+	"command-service/internal/saga"
+	"command-service/pkg/health"
 	"command-service/pkg/logger"
+	"command-service/pkg/metrics"
 	"command-service/pkg/middleware"
+	"command-service/pkg/tracing"
+	"command-service/pkg/validation"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 
 	_ "command-service/docs" // Import docs for Swagger
 )
 
 // @title           Command Service API
 // @version         1.0
-// @description     API de escritura para el sistema de inventario basado en arquitectura CQRS + EDA
+// @description     API de escritura para el sistema de inventario basado en arquitectura CQRS + EDA. Los errores responden siempre el mismo envelope (`code`, `message`, `details`, `request_id`, `timestamp`); los clientes deben decidir según `code` (ver ErrorResponse).
 // @termsOfService  http://swagger.io/terms/
 
 // @contact.name   API Support
@@ -46,10 +59,27 @@ func main() {
 	// Load configuration
 	cfg := config.Load()
 
+	checkConfig := flag.Bool("check-config", false, "Validate the configuration, probe the event bus and the SQLite paths, and exit (non-zero if anything failed)")
+	flag.Parse()
+	if *checkConfig {
+		if !cfg.Check(context.Background(), os.Stdout, time.Duration(cfg.HealthCheckTimeoutMs)*time.Millisecond) {
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Initialize logger
 	appLogger := logger.New(cfg.Environment)
 	defer appLogger.Sync()
 
+	// Refuse to start with a configuration that would only fail later
+	if err := cfg.Validate(); err != nil {
+		appLogger.Fatal("❌ Invalid configuration", zap.Error(err))
+	}
+	if err := cfg.RunStartupChecks(context.Background(), appLogger); err != nil {
+		appLogger.Fatal("❌ Startup checks failed (STARTUP_CHECK_MODE=strict)", zap.Error(err))
+	}
+
 	appLogger.Info("🚀 Starting Command Service",
 		zap.String("environment", cfg.Environment),
 		zap.String("port", cfg.Port),
@@ -57,17 +87,45 @@ func main() {
 
 	appLogger.Info("🔐 JWT Configuration",
 		zap.Int("secret_length", len(cfg.JWTSecret)),
-		zap.String("note", "Token expiration: 10 minutes"),
+		zap.Int("access_token_ttl_seconds", cfg.JWTAccessTokenTTLSeconds),
+		zap.String("issuer", cfg.JWTIssuer),
+		zap.String("audience", cfg.JWTAudience),
+		zap.String("jwks_url", cfg.JWTJWKSURL),
 	)
 
-	appLogger.Info("📡 Kafka Configuration",
-		zap.Strings("brokers", cfg.KafkaBrokers),
-		zap.String("topic_items", cfg.KafkaTopicItems),
-		zap.String("topic_stock", cfg.KafkaTopicStock),
-		zap.String("client_id", cfg.KafkaClientID),
-		zap.String("acks", cfg.KafkaAcks),
-		zap.Int("retries", cfg.KafkaRetries),
-	)
+	switch cfg.EventBus {
+	case config.EventBusNATS:
+		appLogger.Info("📡 Event bus: NATS JetStream",
+			zap.String("stream", cfg.NATSStream),
+			zap.String("subject_items", cfg.KafkaTopicItems),
+			zap.String("subject_stock", cfg.KafkaTopicStock),
+		)
+	case config.EventBusRabbitMQ:
+		appLogger.Info("📡 Event bus: RabbitMQ",
+			zap.String("exchange", cfg.RabbitMQExchange),
+			zap.String("routing_key_items", cfg.KafkaTopicItems),
+			zap.String("routing_key_stock", cfg.KafkaTopicStock),
+		)
+	default:
+		appLogger.Info("📡 Kafka Configuration",
+			zap.Strings("brokers", cfg.KafkaBrokers),
+			zap.String("topic_items", cfg.KafkaTopicItems),
+			zap.String("topic_stock", cfg.KafkaTopicStock),
+			zap.String("client_id", cfg.KafkaClientID),
+			zap.String("acks", cfg.KafkaAcks),
+			zap.Int("retries", cfg.KafkaRetries),
+		)
+	}
+
+	// Initialize tracing (OTLP export configured through the standard OTEL_* variables)
+	shutdownTracing, err := tracing.Init(context.Background(), appLogger)
+	if err != nil {
+		appLogger.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+
+	if cfg.MockDependencies {
+		appLogger.Warn("🧪 Mock mode: in-memory write store, users, tokens and event broker; no Kafka or Redis, state is lost on exit")
+	}
 
 	// Set Gin mode
 	if cfg.Environment == "production" {
@@ -78,78 +136,325 @@ func main() {
 	router := gin.New()
 	
 	// CORS middleware (must be first to handle preflight requests)
-	router.Use(middleware.CORSMiddleware())
+	corsConfig, err := middleware.NewCORSConfig(cfg.CORSAllowedOrigins, cfg.CORSAllowedHeaders, cfg.CORSMaxAgeSeconds)
+	if err != nil {
+		appLogger.Fatal("Invalid CORS configuration", zap.Error(err))
+	}
+	router.Use(middleware.CORSMiddleware(corsConfig))
+
+	// SLO tracking (outside the recovery handler so recovered panics count as 5xx)
+	sloTracker := middleware.NewSLOTracker(middleware.SLOConfig{
+		Service:            "command-service",
+		AvailabilityTarget: cfg.SLOAvailabilityTarget,
+		LatencyThreshold:   time.Duration(cfg.SLOLatencyThresholdMs) * time.Millisecond,
+		LatencyTarget:      cfg.SLOLatencyTarget,
+		ExcludePrefixes:    []string{"/api/v1/health", "/api/v1/slo", "/swagger", "/metrics"},
+	})
+	router.Use(sloTracker.Middleware())
+
+	// Prometheus request metrics (also outside the recovery handler)
+	router.Use(metrics.GinMiddleware())
+
+	// Server span per request; its context reaches the Kafka headers of the published events
+	router.Use(otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(func(r *http.Request) bool {
+		return r.URL.Path != "/metrics" && !strings.HasPrefix(r.URL.Path, "/api/v1/health")
+	})))
 	
 	router.Use(middleware.RecoveryHandler(appLogger))
 	router.Use(logger.GinMiddleware(appLogger))
 	
 	// Request ID middleware (must be early in the chain)
 	router.Use(middleware.RequestIDMiddleware(appLogger))
+
+	// Handler timeouts (HANDLER_TIMEOUT_SECONDS, HANDLER_ROUTE_TIMEOUTS), before any middleware
+	// that wraps the response writer so route timeouts can move the connection deadlines
+	routeTimeouts, err := middleware.ParseRouteTimeouts(cfg.HandlerRouteTimeouts)
+	if err != nil {
+		appLogger.Fatal("Invalid HANDLER_ROUTE_TIMEOUTS", zap.Error(err))
+	}
+	router.Use(middleware.RequestTimeout(middleware.RequestTimeoutConfig{
+		Default: time.Duration(cfg.HandlerTimeoutSeconds) * time.Second,
+		Routes:  routeTimeouts,
+	}, appLogger))
+
+	// Optional {data, meta} response envelope (RESPONSE_ENVELOPE or Accept: ...; envelope=true)
+	router.Use(middleware.ResponseEnvelope(cfg.ResponseEnvelope))
+
+	// Request body size limit (MAX_REQUEST_BODY_BYTES) and field validation rules
+	// Image uploads get IMAGE_MAX_BYTES plus room for the multipart framing
+	router.Use(middleware.BodyLimitRoutes(int64(cfg.MaxRequestBodyBytes), map[string]int64{
+		"POST /api/v1/inventory/items/:id/images": int64(cfg.ImageMaxBytes) + 64<<10,
+	}))
+	validation.Register()
 	
 	// Initialize request ID store for idempotency
 	appLogger.Info("🔧 Initializing request ID store for idempotency...")
 	requestIDStore := middleware.NewInMemoryRequestIDStore()
 	appLogger.Info("✅ Request ID store initialized successfully")
 	
-	// Idempotency middleware (for write operations)
-	router.Use(middleware.IdempotencyMiddleware(requestIDStore, appLogger, 5*time.Minute))
-	
 	// Error handler middleware
 	router.Use(middleware.ErrorHandler(appLogger))
-	
-	// Store response middleware (for idempotency)
-	router.Use(middleware.StoreResponseMiddleware(requestIDStore, appLogger, 5*time.Minute))
 
 	// Swagger documentation
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Prometheus metrics (HTTP latency/status, Kafka publishes)
+	router.GET("/metrics", metrics.Handler())
+
 	// Initialize JWT manager
 	appLogger.Info("🔧 Initializing JWT manager...")
-	jwtManager := auth.NewJWTManager(cfg.JWTSecret, appLogger)
+	jwtManager := auth.NewJWTManager(cfg.JWTSecret, appLogger).
+		WithClockSkew(time.Duration(cfg.JWTClockSkewSeconds)*time.Second).
+		WithAccessTokenTTL(time.Duration(cfg.JWTAccessTokenTTLSeconds)*time.Second).
+		WithIssuer(cfg.JWTIssuer, cfg.JWTTrustedIssuers).
+		WithAudience(cfg.JWTAudience)
+	if cfg.JWTJWKSURL != "" {
+		jwks := auth.NewJWKSKeySet(cfg.JWTJWKSURL, time.Duration(cfg.JWTJWKSRefreshSeconds)*time.Second, appLogger)
+		// Fetch the keys now so a wrong URL shows at startup; they are fetched again on use
+		jwksCtx, cancelJWKS := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := jwks.Refresh(jwksCtx); err != nil {
+			appLogger.Warn("⚠️  Failed to fetch JWKS, RS256/ES256 tokens will fail until it is reachable",
+				zap.String("url", cfg.JWTJWKSURL),
+				zap.Error(err),
+			)
+		}
+		cancelJWKS()
+		jwtManager.WithJWKS(jwks)
+	}
 	appLogger.Info("✅ JWT manager initialized successfully")
 
+	// Initialize role-based authorization
+	rbac, err := auth.NewRBAC(cfg.RBACRolePermissions)
+	if err != nil {
+		appLogger.Fatal("Invalid RBAC_ROLE_PERMISSIONS", zap.Error(err))
+	}
+	appLogger.Info("✅ RBAC initialized", zap.Strings("roles", rbac.Roles()))
+
+	// Initialize token store (refresh tokens + revocation list)
+	tokenStore := auth.NewTokenStore(cfg.TokenStore, auth.RedisOptions{
+		Addr:     cfg.RedisHost + ":" + cfg.RedisPort,
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	}, appLogger)
+
+	// Initialize user store (seeded with the default users when empty)
+	appLogger.Info("🔧 Initializing user store...", zap.String("kind", cfg.UserStore), zap.String("path", cfg.UserStorePath))
+	userStore, err := auth.NewUserStore(cfg.UserStore, cfg.UserStorePath, appLogger)
+	if err != nil {
+		appLogger.Fatal("Failed to initialize user store", zap.Error(err))
+	}
+	appLogger.Info("✅ User store initialized successfully")
+
+	// Initialize API key store (X-API-Key credentials of machine clients)
+	apiKeyStore, err := auth.NewSQLiteAPIKeyStore(cfg.APIKeyStorePath)
+	if err != nil {
+		appLogger.Fatal("Failed to initialize API key store", zap.Error(err))
+	}
+	defer apiKeyStore.Close()
+
 	// Initialize auth handler
 	appLogger.Info("🔧 Initializing auth handler...")
-	authHandler := auth.NewAuthHandler(jwtManager, appLogger)
+	authHandler := auth.NewAuthHandler(jwtManager, userStore, tokenStore, time.Duration(cfg.RefreshTokenTTLMinutes)*time.Minute, appLogger)
+	apiKeyHandler := auth.NewAPIKeyHandler(apiKeyStore, appLogger)
 	appLogger.Info("✅ Auth handler initialized successfully")
 
 	// Initialize handlers
 	appLogger.Info("🔧 Initializing handlers...")
 	inventoryHandler := handlers.NewInventoryHandler(appLogger, cfg)
+	// Command statuses: accepted, published, then confirmed or rejected by the listener
+	commandStatuses := saga.NewStore(time.Duration(cfg.CommandStatusTTLMinutes) * time.Minute)
+	inventoryHandler.TrackCommands(commandStatuses)
+	storeHandler := handlers.NewStoreHandler(appLogger, inventoryHandler.GetStoreRepository(), inventoryHandler.GetEventBus())
+	purchasingHandler := handlers.NewPurchasingHandler(appLogger, inventoryHandler)
+	commandStatusHandler := handlers.NewCommandStatusHandler(appLogger, commandStatuses)
 	appLogger.Info("✅ Handlers initialized successfully")
 
+	// Audit log of the write commands (AUDIT_SINKS)
+	auditLog, err := audit.Open(context.Background(), cfg, appLogger)
+	if err != nil {
+		appLogger.Fatal("Failed to open audit log", zap.Error(err))
+	}
+	defer auditLog.Close()
+	if auditLog != nil {
+		appLogger.Info("✅ Audit log opened", zap.Strings("sinks", cfg.AuditSinks))
+	}
+	auditHandler := handlers.NewAuditHandler(appLogger, auditLog)
+	idempotencyHandler := handlers.NewIdempotencyHandler(appLogger, requestIDStore)
+
+	// Confirmations and rejections published by the Listener Service (the mock broker is not shared with it)
+	statusCtx, stopStatusConsumer := context.WithCancel(context.Background())
+	defer stopStatusConsumer()
+	if cfg.MockDependencies {
+		appLogger.Warn("🧪 Mock mode: confirmations and rejections are not consumed, commands stay published")
+	} else {
+		statusTopics := append([]string{cfg.KafkaTopicRejections}, cfg.EventTopics()...)
+		go saga.NewConsumer(cfg, statusTopics, commandStatuses, appLogger).Run(statusCtx)
+	}
+
+	// Initialize priority queue for write requests
+	var writeQueue *middleware.PriorityQueue
+	if cfg.QueueEnabled {
+		appLogger.Info("🔧 Initializing write priority queue...",
+			zap.Int("capacity", cfg.QueueCapacity),
+			zap.Int("low_max_queued", cfg.QueueLowMaxQueued),
+		)
+		writeQueue = middleware.NewPriorityQueue(middleware.PriorityQueueConfig{
+			Capacity: cfg.QueueCapacity,
+			Lanes: [3]middleware.LaneConfig{
+				middleware.LaneHigh:   {MaxConcurrent: cfg.QueueHighConcurrency, MaxQueued: cfg.QueueHighMaxQueued},
+				middleware.LaneNormal: {MaxConcurrent: cfg.QueueNormalConcurrency, MaxQueued: cfg.QueueNormalMaxQueued},
+				middleware.LaneLow:    {MaxConcurrent: cfg.QueueLowConcurrency, MaxQueued: cfg.QueueLowMaxQueued},
+			},
+			MaxWait: time.Duration(cfg.QueueMaxWaitMs) * time.Millisecond,
+		})
+		appLogger.Info("✅ Write priority queue initialized successfully")
+	}
+
+	// Initialize write rate limiter (token buckets per client IP and per JWT subject)
+	var rateLimiter gin.HandlerFunc
+	var rateLimitStore middleware.RateLimitStore
+	if cfg.RateLimitEnabled {
+		rateLimitStore = middleware.NewRateLimitStore(cfg.RateLimitStore, auth.RedisOptions{
+			Addr:     cfg.RedisHost + ":" + cfg.RedisPort,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		}, appLogger)
+		rateLimiter = middleware.RateLimitMiddleware(middleware.RateLimitConfig{
+			PerIP:   middleware.RateLimit{PerMinute: cfg.RateLimitIPPerMinute, Burst: cfg.RateLimitIPBurst},
+			PerUser: middleware.RateLimit{PerMinute: cfg.RateLimitUserPerMinute, Burst: cfg.RateLimitUserBurst},
+		}, rateLimitStore, appLogger)
+		appLogger.Info("✅ Write rate limiter initialized",
+			zap.String("store", cfg.RateLimitStore),
+			zap.Int("ip_per_minute", cfg.RateLimitIPPerMinute),
+			zap.Int("user_per_minute", cfg.RateLimitUserPerMinute),
+		)
+	}
+
+	// Readiness probe: write store, Kafka and Redis
+	healthChecker := newHealthChecker(cfg, inventoryHandler, tokenStore, rateLimitStore)
+
+	// JWT or API key authentication
+	authenticate := middleware.AuthMiddleware(jwtManager, rbac, tokenStore, apiKeyStore, appLogger)
+	// Permission check for user management (the auth route group below shadows the auth package)
+	manageUsers := middleware.RequirePermission(rbac, auth.PermissionManageUsers, appLogger)
+	overrideStock := middleware.RequirePermission(rbac, auth.PermissionOverrideStock, appLogger)
+	// Restoring undoes a delete, so it needs the delete permission rather than write
+	restoreItems := middleware.RequirePermission(rbac, auth.PermissionDelete, appLogger)
+	readAudit := middleware.RequirePermission(rbac, auth.PermissionReadAudit, appLogger)
+	manageIdempotency := middleware.RequirePermission(rbac, auth.PermissionManageIdempotency, appLogger)
+
 	// API routes
 	v1 := router.Group("/api/v1")
 	{
-		// Health check endpoint (public)
+		// Health check endpoints (public)
 		v1.GET("/health", healthCheck)
+		v1.GET("/health/live", healthChecker.Live)
+		v1.GET("/health/ready", healthChecker.Ready)
 
 		// Auth endpoints (public)
 		auth := v1.Group("/auth")
 		{
 			auth.POST("/login", authHandler.Login)
+			auth.POST("/refresh", authHandler.Refresh)
+			auth.POST("/logout", authHandler.Logout)
+			// User management (admin only)
+			auth.POST("/users", authenticate, manageUsers, authHandler.CreateUser)
+			// API keys for machine clients (admin only)
+			auth.POST("/api-keys", authenticate, manageUsers, apiKeyHandler.CreateAPIKey)
+			auth.GET("/api-keys", authenticate, manageUsers, apiKeyHandler.ListAPIKeys)
+			auth.DELETE("/api-keys/:id", authenticate, manageUsers, apiKeyHandler.RevokeAPIKey)
 		}
 
-		// Protected endpoints (require JWT authentication)
+		// Protected endpoints (require a JWT or an API key)
 		protected := v1.Group("")
-		protected.Use(middleware.AuthMiddleware(jwtManager, appLogger))
+		protected.Use(authenticate)
+		// Idempotency for write operations, after authentication: the store is keyed by
+		// tenant, caller and X-Request-ID
+		protected.Use(middleware.IdempotencyMiddleware(requestIDStore, appLogger, 5*time.Minute))
+		protected.Use(middleware.StoreResponseMiddleware(requestIDStore, appLogger, 5*time.Minute))
+		protected.GET("/slo", middleware.SLOReportHandler(sloTracker))
+		protected.GET("/commands/:request_id", commandStatusHandler.GetCommand)
+		protected.GET("/commands/:request_id/status", commandStatusHandler.GetCommandStatus)
+		protected.GET("/audit/export", readAudit, auditHandler.ExportAuditLog)
+		// Every write below is recorded in the audit log, rejected ones included
+		protected.Use(audit.Middleware(auditLog, appLogger))
+		if rateLimiter != nil {
+			// Before the write queue: a rejected request must not take a slot
+			protected.Use(rateLimiter)
+		}
+		if writeQueue != nil {
+			protected.Use(middleware.PriorityQueueMiddleware(writeQueue,
+				middleware.NewLaneClassifier(cfg.QueueLowPriorityUsers), appLogger))
+			protected.GET("/queue/stats", middleware.QueueStatsHandler(writeQueue))
+		}
 		{
 			inventory := protected.Group("/inventory")
 			{
 				inventory.POST("/items", inventoryHandler.CreateItem)
 				inventory.PUT("/items/:id", inventoryHandler.UpdateItem)
+				inventory.PATCH("/items/:id", inventoryHandler.PatchItem)
 				inventory.DELETE("/items/:id", inventoryHandler.DeleteItem)
+				inventory.POST("/items/:id/restore", restoreItems, inventoryHandler.RestoreItem)
 				inventory.POST("/items/:id/adjust", inventoryHandler.AdjustStock)
 				inventory.POST("/items/:id/reserve", inventoryHandler.ReserveStock)
 				inventory.POST("/items/:id/release", inventoryHandler.ReleaseStock)
+				inventory.POST("/items/:id/commit", inventoryHandler.CommitStock)
+				inventory.POST("/items/:id/locations/:loc/adjust", inventoryHandler.AdjustLocationStock)
+				inventory.POST("/reconciliation", inventoryHandler.ReconcileStock)
+				inventory.POST("/items/:id/related", inventoryHandler.AddItemRelation)
+				inventory.DELETE("/items/:id/related/:related_id", inventoryHandler.RemoveItemRelation)
+				inventory.POST("/items/:id/images", inventoryHandler.AddItemImage)
+				inventory.DELETE("/items/:id/images/:image_id", inventoryHandler.RemoveItemImage)
+				inventory.POST("/items/:id/identifiers", inventoryHandler.AddItemIdentifier)
+				inventory.DELETE("/items/:id/identifiers/:code", inventoryHandler.RemoveItemIdentifier)
+			}
+
+			stores := protected.Group("/stores")
+			{
+				stores.POST("", storeHandler.CreateStore)
+				stores.PUT("/:id", storeHandler.UpdateStore)
+				stores.DELETE("/:id", storeHandler.DeleteStore)
+				stores.PUT("/:id/calendar", storeHandler.SetStoreCalendar)
+				stores.DELETE("/:id/calendar", storeHandler.DeleteStoreCalendar)
+			}
+
+			suppliers := protected.Group("/suppliers")
+			{
+				suppliers.POST("", purchasingHandler.CreateSupplier)
+				suppliers.PUT("/:id", purchasingHandler.UpdateSupplier)
+				suppliers.DELETE("/:id", purchasingHandler.DeleteSupplier)
+			}
+
+			purchaseOrders := protected.Group("/purchase-orders")
+			{
+				purchaseOrders.POST("", purchasingHandler.CreatePurchaseOrder)
+				purchaseOrders.POST("/:id/receive", purchasingHandler.ReceivePurchaseOrder)
+				purchaseOrders.POST("/:id/cancel", purchasingHandler.CancelPurchaseOrder)
+			}
+
+			// Administrative corrections (inventory:override, admin only by default)
+			admin := protected.Group("/admin", overrideStock)
+			{
+				admin.POST("/items/:id/force-set-stock", inventoryHandler.ForceSetStock)
+			}
+
+			// Idempotency store introspection (idempotency:manage, admin only by default)
+			idempotency := protected.Group("/admin/idempotency", manageIdempotency)
+			{
+				idempotency.GET("", idempotencyHandler.ListIdempotencyEntries)
+				idempotency.GET("/:key", idempotencyHandler.GetIdempotencyEntry)
+				idempotency.DELETE("/:key", idempotencyHandler.DeleteIdempotencyEntry)
 			}
 		}
 	}
 
 	// Start server
 	srv := &http.Server{
-		Addr:    ":" + cfg.Port,
-		Handler: router,
+		Addr:         ":" + cfg.Port,
+		Handler:      router,
+		ReadTimeout:  time.Duration(cfg.HTTPReadTimeoutSeconds) * time.Second,
+		WriteTimeout: time.Duration(cfg.HTTPWriteTimeoutSeconds) * time.Second,
+		IdleTimeout:  time.Duration(cfg.HTTPIdleTimeoutSeconds) * time.Second,
 	}
 
 	// Start server in a goroutine
@@ -163,19 +468,58 @@ func main() {
 		}
 	}()
 
+	// The gRPC command API runs the same handlers on its own port
+	var grpcServer *grpc.Server
+	if cfg.GRPCPort != "" {
+		listener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+		if err != nil {
+			appLogger.Fatal("Failed to listen for gRPC", zap.String("port", cfg.GRPCPort), zap.Error(err))
+		}
+		grpcServer = grpcapi.NewServer(appLogger, inventoryHandler, jwtManager, rbac, tokenStore, auditLog)
+		go func() {
+			appLogger.Info("Starting gRPC command API", zap.String("port", cfg.GRPCPort))
+			if err := grpcServer.Serve(listener); err != nil {
+				appLogger.Fatal("Failed to start gRPC server", zap.Error(err))
+			}
+		}()
+	}
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	appLogger.Info("Shutting down server...")
+	stopStatusConsumer()
 
-	// Graceful shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// Graceful shutdown with timeout (SHUTDOWN_TIMEOUT_SECONDS)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeoutSeconds)*time.Second)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		appLogger.Fatal("Server forced to shutdown", zap.Error(err))
+		appLogger.Error("Server forced to shutdown", zap.Error(err))
+	}
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
+
+	// The last requests may still be retrying their event publishes: wait for them before
+	// closing the producer. Events left behind are republished from the journal at the
+	// next start.
+	eventBus := inventoryHandler.GetEventBus()
+	if tracked, ok := eventBus.(*saga.TrackingPublisher); ok {
+		eventBus = tracked.Unwrap()
+	}
+	if drainer, ok := eventBus.(events.Drainer); ok {
+		if err := drainer.Shutdown(ctx); err != nil {
+			appLogger.Error("Event publisher left open, pending events may be lost", zap.Error(err))
+		} else {
+			appLogger.Info("Event publisher drained and closed", zap.String("event_bus", cfg.EventBus))
+		}
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		appLogger.Warn("Failed to flush traces", zap.Error(err))
 	}
 
 	appLogger.Info("Server exited")
@@ -201,3 +545,53 @@ func healthCheck(c *gin.Context) {
 		"service": "command-service",
 	})
 }
+
+// newHealthChecker registers the dependencies probed by /health/ready. A store that fell
+// back to memory at startup is reported with the reason. The write store and the event
+// bus (kafka, nats or rabbitmq) are critical: writes would be lost or never reach the
+// read model. The Redis token store is critical too, because revocation checks fail
+// closed and every authenticated request gets a 503 while it is down; the Redis rate
+// limiter only degrades the service. Mock mode uses in-process fakes and registers none
+// of them.
+func newHealthChecker(cfg *config.Config, inventoryHandler *handlers.InventoryHandler, tokenStore auth.TokenStore, rateLimitStore middleware.RateLimitStore) *health.Checker {
+	checker := health.NewChecker(health.Config{
+		Service:          "command-service",
+		Timeout:          time.Duration(cfg.HealthCheckTimeoutMs) * time.Millisecond,
+		FailureThreshold: cfg.HealthFailureThreshold,
+	})
+
+	if pinger, ok := inventoryHandler.GetRepository().(health.Pinger); ok {
+		checker.Register(health.Dependency{Name: "write_store", Critical: true, Check: pinger.Ping})
+	} else if cfg.WriteStore != "memory" {
+		checker.Register(health.Dependency{Name: "write_store", Critical: true,
+			Check: health.Unavailable("SQLite write store could not be opened, using in-memory fallback")})
+	}
+
+	eventBus := inventoryHandler.GetEventBus()
+	if tracked, ok := eventBus.(*saga.TrackingPublisher); ok {
+		eventBus = tracked.Unwrap()
+	}
+	if pinger, ok := eventBus.(health.Pinger); ok {
+		checker.Register(health.Dependency{Name: cfg.EventBus, Critical: true, Check: pinger.Ping})
+	} else if !cfg.MockDependencies {
+		checker.Register(health.Dependency{Name: cfg.EventBus, Critical: true,
+			Check: health.Unavailable("Event publisher could not be created, events are kept in memory")})
+	}
+
+	if pinger, ok := tokenStore.(health.Pinger); ok {
+		checker.Register(health.Dependency{Name: "redis_tokens", Critical: true, Check: pinger.Ping})
+	} else if cfg.TokenStore == "redis" {
+		checker.Register(health.Dependency{Name: "redis_tokens",
+			Check: health.Unavailable("Redis unreachable at startup, using in-memory token store")})
+	}
+
+	// The rate limiter lets requests through when Redis fails
+	if pinger, ok := rateLimitStore.(health.Pinger); ok {
+		checker.Register(health.Dependency{Name: "redis_rate_limit", Check: pinger.Ping})
+	} else if cfg.RateLimitEnabled && cfg.RateLimitStore == "redis" {
+		checker.Register(health.Dependency{Name: "redis_rate_limit",
+			Check: health.Unavailable("Redis unreachable at startup, rate limits are per replica")})
+	}
+
+	return checker
+}
@@ -0,0 +1,100 @@
+// Command seed generates demo data (stores, items and their stock movements) and
+// writes it through the Command Service HTTP API, so the Listener, the read model and
+// the Query Service caches see the same events real clients would produce.
+//
+// Development only: it refuses non-local targets unless -force is given.
+//
+//	go run ./cmd/seed -items 200 -stores 5 -movements 10
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"command-service/internal/seed"
+	"command-service/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+func main() {
+	url := flag.String("url", getEnv("SEED_COMMAND_URL", "http://localhost:8080"), "Command Service base URL")
+	username := flag.String("username", getEnv("SEED_USERNAME", "admin"), "user to log in with (needs inventory and store write permissions)")
+	password := flag.String("password", getEnv("SEED_PASSWORD", "admin123"), "password of -username")
+	items := flag.Int("items", 50, "items to create")
+	stores := flag.Int("stores", 3, "stores to create")
+	movements := flag.Int("movements", 5, "stock movements per item")
+	prefix := flag.String("prefix", "", "prefix for SKUs and store codes (default SEED-<unix time>-)")
+	randomSeed := flag.Int64("seed", 0, "random seed; the same seed and sizes produce the same data (default: current time)")
+	force := flag.Bool("force", false, "allow a non-local Command Service URL")
+	flag.Parse()
+
+	log := logger.New("development")
+	defer log.Sync()
+
+	if *items < 0 || *stores < 0 || *movements < 0 {
+		log.Fatal("-items, -stores and -movements cannot be negative")
+	}
+	if err := seed.CheckTarget(*url); err != nil {
+		if !errors.Is(err, seed.ErrRemoteTarget) || !*force {
+			log.Fatal("Seed target rejected (use -force only for disposable environments)", zap.Error(err))
+		}
+	}
+
+	now := time.Now()
+	if *prefix == "" {
+		*prefix = "SEED-" + strconv.FormatInt(now.Unix(), 36) + "-"
+	}
+	if *randomSeed == 0 {
+		*randomSeed = now.UnixNano()
+	}
+
+	plan := seed.NewPlan(seed.PlanOptions{
+		Items:            *items,
+		Stores:           *stores,
+		MovementsPerItem: *movements,
+		Prefix:           *prefix,
+		Seed:             *randomSeed,
+	})
+	log.Info("Seeding Command Service",
+		zap.String("url", *url),
+		zap.String("prefix", *prefix),
+		zap.Int64("seed", *randomSeed),
+		zap.Int("stores", len(plan.Stores)),
+		zap.Int("items", len(plan.Items)),
+		zap.Int("movements", len(plan.Movements)),
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	summary, err := seed.NewRunner(*url, *username, *password, log).Run(ctx, plan)
+	fields := []zap.Field{
+		zap.Int("stores", summary.Stores),
+		zap.Int("items", summary.Items),
+		zap.Int("movements", summary.Movements),
+		zap.Int("rejected", summary.Failed),
+		zap.Int("throttled", summary.Throttled),
+		zap.Duration("elapsed", time.Since(now).Round(time.Millisecond)),
+	}
+	if err != nil {
+		log.Fatal("Seed run failed", append(fields, zap.Error(err))...)
+	}
+	log.Info("Seed run completed", fields...)
+	fmt.Printf("Seeded %d stores, %d items and %d movements (prefix %s)\n",
+		summary.Stores, summary.Items, summary.Movements, *prefix)
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,252 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"command-service/internal/config"
+	"command-service/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+// Resources whose state is recorded in the changes of an entry
+const (
+	ResourceItem          = "item"
+	ResourceStore         = "store"
+	ResourceSupplier      = "supplier"
+	ResourcePurchaseOrder = "purchase_order"
+)
+
+// Entry is one write command: who sent it, from where, its outcome and the state of the
+// resources it changed. Hash covers every other field, PrevHash included, so changing or
+// removing an entry breaks the chain from that entry on.
+type Entry struct {
+	Seq       int64     `json:"seq"`
+	At        time.Time `json:"at"`
+	Actor     string    `json:"actor"`
+	RequestID string    `json:"request_id"`
+	IP        string    `json:"ip"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status"`
+	Changes   []Change  `json:"changes,omitempty"`
+	PrevHash  string    `json:"prev_hash"` // Hash of the previous entry; empty for the first one
+	Hash      string    `json:"hash"`
+}
+
+// Change is the state of a resource before and after a command saved it
+type Change struct {
+	Resource string          `json:"resource"`
+	ID       string          `json:"id"`
+	Before   json.RawMessage `json:"before,omitempty"` // absent for a creation
+	After    json.RawMessage `json:"after,omitempty"`  // absent for a removal
+}
+
+// sum is the SHA-256 of the entry encoded without its hash
+func (e Entry) sum() string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Query selects the entries of an export, in sequence order
+type Query struct {
+	AfterSeq int64     // Entries with a greater sequence number
+	From     time.Time // Recorded at or after From (zero: no bound)
+	To       time.Time // Recorded before To (zero: no bound)
+	Limit    int
+}
+
+// Sink receives every entry, in sequence order
+type Sink interface {
+	Append(ctx context.Context, entry Entry) error
+	Close() error
+}
+
+// Store is a sink the entries can be read back from: the chain continues from its last
+// entry and exports read it
+type Store interface {
+	Sink
+	// Last returns the entry with the highest sequence number, nil when there is none
+	Last(ctx context.Context) (*Entry, error)
+	List(ctx context.Context, query Query) ([]Entry, error)
+}
+
+// Log chains the entries and writes them to the store and the other sinks. An entry
+// only counts once the store has it; a sink that fails misses that entry (logged and
+// counted in audit_records_total).
+//
+// A nil *Log is valid and records nothing.
+type Log struct {
+	mu        sync.Mutex
+	store     Store
+	storeName string
+	sinks     map[string]Sink // by AUDIT_SINKS name, besides the store
+	seq       int64
+	head      string // Hash of the last entry
+	logger    *zap.Logger
+}
+
+// NewLog continues the chain of store, copying every entry to sinks
+func NewLog(ctx context.Context, store Store, sinks map[string]Sink, logger *zap.Logger) (*Log, error) {
+	last, err := store.Last(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the last audit entry: %w", err)
+	}
+	l := &Log{store: store, storeName: "store", sinks: sinks, logger: logger}
+	if last != nil {
+		l.seq = last.Seq
+		l.head = last.Hash
+	}
+	return l, nil
+}
+
+// Open builds the log of AUDIT_SINKS: the sqlite sink is the store if configured, the
+// file sink otherwise. It returns nil when AUDIT_ENABLED=false.
+func Open(ctx context.Context, cfg *config.Config, logger *zap.Logger) (*Log, error) {
+	if !cfg.AuditEnabled {
+		return nil, nil
+	}
+
+	sinks := make(map[string]Sink)
+	closeAll := func() {
+		for _, sink := range sinks {
+			sink.Close()
+		}
+	}
+	for _, name := range cfg.AuditSinks {
+		var sink Sink
+		var err error
+		switch name {
+		case config.AuditSinkSQLite:
+			sink, err = NewSQLiteStore(cfg.AuditSQLitePath)
+		case config.AuditSinkFile:
+			sink, err = NewFileStore(cfg.AuditFilePath, logger)
+		case config.AuditSinkKafka:
+			if cfg.MockDependencies {
+				logger.Warn("🧪 Mock mode: the kafka audit sink is disabled")
+				continue
+			}
+			sink, err = NewKafkaSink(cfg)
+		}
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("failed to open the %s audit sink: %w", name, err)
+		}
+		sinks[name] = sink
+	}
+
+	var store Store
+	var storeName string
+	for _, name := range []string{config.AuditSinkSQLite, config.AuditSinkFile} {
+		if sink, ok := sinks[name]; ok {
+			store, storeName = sink.(Store), name
+			delete(sinks, name)
+			break
+		}
+	}
+	if store == nil {
+		closeAll()
+		return nil, fmt.Errorf("AUDIT_SINKS must include sqlite or file")
+	}
+
+	l, err := NewLog(ctx, store, sinks, logger)
+	if err != nil {
+		store.Close()
+		closeAll()
+		return nil, err
+	}
+	l.storeName = storeName
+	return l, nil
+}
+
+// Record chains entry after the last one and writes it. Seq, PrevHash and Hash are set
+// here; At too when it is zero.
+func (l *Log) Record(ctx context.Context, entry Entry) (Entry, error) {
+	if l == nil {
+		return entry, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry.Seq = l.seq + 1
+	if entry.At.IsZero() {
+		entry.At = time.Now().UTC()
+	}
+	entry.PrevHash = l.head
+	entry.Hash = entry.sum()
+
+	if err := l.store.Append(ctx, entry); err != nil {
+		metrics.AuditRecords.WithLabelValues(l.storeName, "error").Inc()
+		return entry, err
+	}
+	metrics.AuditRecords.WithLabelValues(l.storeName, "success").Inc()
+	l.seq = entry.Seq
+	l.head = entry.Hash
+
+	for name, sink := range l.sinks {
+		if err := sink.Append(ctx, entry); err != nil {
+			metrics.AuditRecords.WithLabelValues(name, "error").Inc()
+			l.logger.Error("Failed to write audit entry to sink",
+				zap.String("sink", name),
+				zap.Int64("seq", entry.Seq),
+				zap.Error(err),
+			)
+			continue
+		}
+		metrics.AuditRecords.WithLabelValues(name, "success").Inc()
+	}
+	return entry, nil
+}
+
+// Export reads the entries of query from the store
+func (l *Log) Export(ctx context.Context, query Query) ([]Entry, error) {
+	return l.store.List(ctx, query)
+}
+
+// Close closes the store and the sinks
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	err := l.store.Close()
+	for _, sink := range l.sinks {
+		sink.Close()
+	}
+	return err
+}
+
+// Verify checks that every entry matches its hash and links to the one before it.
+// entries must be consecutive; the first one is only checked against its own hash
+// unless it is the first of the chain.
+func Verify(entries []Entry) error {
+	for i, entry := range entries {
+		if entry.sum() != entry.Hash {
+			return fmt.Errorf("entry %d does not match its hash", entry.Seq)
+		}
+		if i == 0 {
+			if entry.Seq == 1 && entry.PrevHash != "" {
+				return fmt.Errorf("entry 1 links to a previous entry")
+			}
+			continue
+		}
+		previous := entries[i-1]
+		if entry.Seq != previous.Seq+1 {
+			return fmt.Errorf("entries %d to %d are missing", previous.Seq+1, entry.Seq-1)
+		}
+		if entry.PrevHash != previous.Hash {
+			return fmt.Errorf("entry %d does not link to entry %d", entry.Seq, previous.Seq)
+		}
+	}
+	return nil
+}
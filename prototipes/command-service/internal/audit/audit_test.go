@@ -0,0 +1,131 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newSQLiteLog(t *testing.T, path string) (*Log, *SQLiteStore) {
+	store, err := NewSQLiteStore(path)
+	require.NoError(t, err)
+	log, err := NewLog(context.Background(), store, nil, zap.NewNop())
+	require.NoError(t, err)
+	return log, store
+}
+
+func TestLog_ChainsEntriesAndVerifies(t *testing.T) {
+	ctx := context.Background()
+	log, _ := newSQLiteLog(t, filepath.Join(t.TempDir(), "audit.db"))
+	defer log.Close()
+
+	for _, path := range []string{"/api/v1/inventory/items", "/api/v1/stores", "/api/v1/inventory/items"} {
+		_, err := log.Record(ctx, Entry{Actor: "admin", Method: http.MethodPost, Path: path, Status: http.StatusCreated})
+		require.NoError(t, err)
+	}
+
+	entries, err := log.Export(ctx, Query{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, int64(1), entries[0].Seq)
+	assert.Empty(t, entries[0].PrevHash)
+	assert.Equal(t, entries[0].Hash, entries[1].PrevHash)
+	assert.Equal(t, entries[1].Hash, entries[2].PrevHash)
+	assert.NoError(t, Verify(entries))
+
+	page, err := log.Export(ctx, Query{AfterSeq: 1, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, int64(2), page[0].Seq)
+
+	tampered := append([]Entry(nil), entries...)
+	tampered[1].Actor = "someone-else"
+	assert.Error(t, Verify(tampered))
+
+	assert.Error(t, Verify([]Entry{entries[0], entries[2]}), "a removed entry breaks the chain")
+}
+
+func TestLog_ContinuesChainAfterReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	store, err := NewFileStore(path, zap.NewNop())
+	require.NoError(t, err)
+	log, err := NewLog(ctx, store, nil, zap.NewNop())
+	require.NoError(t, err)
+	first, err := log.Record(ctx, Entry{Actor: "admin", Method: http.MethodDelete, Path: "/api/v1/stores/1"})
+	require.NoError(t, err)
+	require.NoError(t, log.Close())
+
+	store, err = NewFileStore(path, zap.NewNop())
+	require.NoError(t, err)
+	log, err = NewLog(ctx, store, nil, zap.NewNop())
+	require.NoError(t, err)
+	defer log.Close()
+	second, err := log.Record(ctx, Entry{Actor: "admin", Method: http.MethodPut, Path: "/api/v1/stores/2"})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2), second.Seq)
+	assert.Equal(t, first.Hash, second.PrevHash)
+	entries, err := log.Export(ctx, Query{Limit: 10})
+	require.NoError(t, err)
+	assert.NoError(t, Verify(entries))
+}
+
+func TestSQLiteStore_IsAppendOnly(t *testing.T) {
+	ctx := context.Background()
+	log, store := newSQLiteLog(t, filepath.Join(t.TempDir(), "audit.db"))
+	defer log.Close()
+	_, err := log.Record(ctx, Entry{Actor: "admin", Method: http.MethodPost, Path: "/api/v1/inventory/items"})
+	require.NoError(t, err)
+
+	_, err = store.db.ExecContext(ctx, `UPDATE audit_log SET actor = 'someone-else'`)
+	assert.Error(t, err)
+	_, err = store.db.ExecContext(ctx, `DELETE FROM audit_log`)
+	assert.Error(t, err)
+
+	last, err := store.Last(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, last)
+	assert.Equal(t, "admin", last.Actor)
+}
+
+func TestMiddleware_RecordsSavedChanges(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log, _ := newSQLiteLog(t, filepath.Join(t.TempDir(), "audit.db"))
+	defer log.Close()
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("username", "admin") }, Middleware(log, zap.NewNop()))
+	router.PUT("/items/:id", func(c *gin.Context) {
+		Before(c, ResourceItem, "1", map[string]int{"quantity": 10})
+		Before(c, ResourceItem, "2", map[string]int{"quantity": 5}) // never saved
+		After(c, ResourceItem, "1", map[string]int{"quantity": 7})
+		c.Status(http.StatusOK)
+	})
+	router.GET("/items/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, method := range []string{http.MethodPut, http.MethodGet} {
+		req := httptest.NewRequest(method, "/items/1", nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	entries, err := log.Export(context.Background(), Query{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "reads are not audited")
+	entry := entries[0]
+	assert.Equal(t, "admin", entry.Actor)
+	assert.Equal(t, http.MethodPut, entry.Method)
+	assert.Equal(t, http.StatusOK, entry.Status)
+	require.Len(t, entry.Changes, 1)
+	assert.Equal(t, "1", entry.Changes[0].ID)
+	assert.JSONEq(t, `{"quantity":10}`, string(entry.Changes[0].Before))
+	assert.JSONEq(t, `{"quantity":7}`, string(entry.Changes[0].After))
+}
@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// FileStore appends the entries to a file (AUDIT_FILE_PATH), one JSON object per line,
+// syncing each one to disk
+type FileStore struct {
+	mu     sync.Mutex
+	path   string
+	file   *os.File
+	logger *zap.Logger
+}
+
+// NewFileStore opens (or creates) the audit file at path
+func NewFileStore(path string, logger *zap.Logger) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	return &FileStore{path: path, file: file, logger: logger}, nil
+}
+
+// Append writes entry as a line and syncs it
+func (s *FileStore) Append(ctx context.Context, entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit file: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync audit file: %w", err)
+	}
+	return nil
+}
+
+// Last returns the last entry of the file
+func (s *FileStore) Last(ctx context.Context) (*Entry, error) {
+	var last *Entry
+	err := s.scan(func(entry Entry) bool {
+		last = &entry
+		return true
+	})
+	return last, err
+}
+
+// List returns the entries of query in file order
+func (s *FileStore) List(ctx context.Context, query Query) ([]Entry, error) {
+	var entries []Entry
+	err := s.scan(func(entry Entry) bool {
+		if entry.Seq <= query.AfterSeq ||
+			(!query.From.IsZero() && entry.At.Before(query.From)) ||
+			(!query.To.IsZero() && !entry.At.Before(query.To)) {
+			return true
+		}
+		entries = append(entries, entry)
+		return len(entries) < query.Limit
+	})
+	return entries, err
+}
+
+// scan reads the file from the start, calling fn for each entry until it returns false
+func (s *FileStore) scan(fn func(Entry) bool) error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read audit file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// A torn last line is what a crash in the middle of a write leaves behind
+			s.logger.Warn("Skipping unreadable audit line",
+				zap.String("path", s.path),
+				zap.Int("line", line),
+				zap.Error(err),
+			)
+			continue
+		}
+		if !fn(entry) {
+			break
+		}
+	}
+	return scanner.Err()
+}
+
+// Close closes the file
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
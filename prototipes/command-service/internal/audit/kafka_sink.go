@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"command-service/internal/config"
+	"command-service/internal/events"
+
+	"github.com/IBM/sarama"
+)
+
+// kafkaKey keys every entry, so they all go to one partition in sequence order
+const kafkaKey = "audit"
+
+// KafkaSink publishes the entries to KAFKA_TOPIC_AUDIT, as JSON
+type KafkaSink struct {
+	producer sarama.SyncProducer
+	topic    string
+}
+
+// NewKafkaSink connects to KAFKA_BROKERS with the security settings of the event publisher
+func NewKafkaSink(cfg *config.Config) (*KafkaSink, error) {
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.ClientID = cfg.KafkaClientID
+	saramaConfig.Producer.Return.Successes = true
+	saramaConfig.Producer.RequiredAcks = sarama.WaitForAll
+	saramaConfig.Producer.Retry.Max = cfg.KafkaRetries
+	saramaConfig.Producer.Idempotent = true
+	saramaConfig.Net.MaxOpenRequests = 1
+	if err := events.ConfigureKafkaSecurity(saramaConfig, cfg); err != nil {
+		return nil, fmt.Errorf("invalid Kafka security config: %w", err)
+	}
+
+	producer, err := sarama.NewSyncProducer(cfg.KafkaBrokers, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
+	}
+	return &KafkaSink{producer: producer, topic: cfg.KafkaTopicAudit}, nil
+}
+
+// Append publishes entry and waits for the brokers to acknowledge it
+func (s *KafkaSink) Append(ctx context.Context, entry Entry) error {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	_, _, err = s.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: s.topic,
+		Key:   sarama.StringEncoder(kafkaKey),
+		Value: sarama.ByteEncoder(value),
+		Headers: []sarama.RecordHeader{
+			{Key: []byte("seq"), Value: []byte(strconv.FormatInt(entry.Seq, 10))},
+			{Key: []byte(events.RequestIDHeader), Value: []byte(entry.RequestID)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish audit entry: %w", err)
+	}
+	return nil
+}
+
+// Close closes the producer
+func (s *KafkaSink) Close() error {
+	return s.producer.Close()
+}
@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"command-service/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// changesKey is the gin key of the changes recorded by Before and After
+const changesKey = "audit_changes"
+
+type pendingChange struct {
+	Change
+	saved bool
+}
+
+type changeSet struct {
+	changes []*pendingChange
+}
+
+// Middleware records an entry for every write request (POST, PUT, PATCH, DELETE) once
+// its handler returns, rejected ones included. It goes after the authentication, which
+// sets the actor. An entry the log fails to record is only logged: the command already
+// ran.
+func Middleware(log *Log, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if log == nil || c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		set := &changeSet{}
+		c.Set(changesKey, set)
+		c.Next()
+
+		entry := Entry{
+			Actor:     c.GetString("username"),
+			RequestID: c.GetString(middleware.RequestIDContextKey),
+			IP:        c.ClientIP(),
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Status:    c.Writer.Status(),
+		}
+		for _, change := range set.changes {
+			if change.saved {
+				entry.Changes = append(entry.Changes, change.Change)
+			}
+		}
+		// Not the request context: a client that disconnects must not drop the entry
+		if _, err := log.Record(context.Background(), entry); err != nil {
+			logger.Error("Failed to record audit entry",
+				zap.String("request_id", entry.RequestID),
+				zap.String("actor", entry.Actor),
+				zap.String("path", entry.Path),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// Before records the state of a resource as loaded, before the command changes it.
+// It is dropped unless After follows for the same resource.
+func Before(c *gin.Context, resource, id string, state interface{}) {
+	set := changesOf(c)
+	if set == nil {
+		return
+	}
+	set.changes = append(set.changes, &pendingChange{Change: Change{Resource: resource, ID: id, Before: encode(state)}})
+}
+
+// After records the state of a resource once the command saved it; nil for a removal.
+// Without a Before the resource was created.
+func After(c *gin.Context, resource, id string, state interface{}) {
+	set := changesOf(c)
+	if set == nil {
+		return
+	}
+	var after json.RawMessage
+	if state != nil {
+		after = encode(state)
+	}
+	for i := len(set.changes) - 1; i >= 0; i-- {
+		change := set.changes[i]
+		if !change.saved && change.Resource == resource && change.ID == id {
+			change.After = after
+			change.saved = true
+			return
+		}
+	}
+	set.changes = append(set.changes, &pendingChange{Change: Change{Resource: resource, ID: id, After: after}, saved: true})
+}
+
+// changesOf returns the changes of the request, nil when it is not audited
+func changesOf(c *gin.Context) *changeSet {
+	value, ok := c.Get(changesKey)
+	if !ok {
+		return nil
+	}
+	return value.(*changeSet)
+}
+
+// encode takes the state now: the handler keeps changing the same value
+func encode(state interface{}) json.RawMessage {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil
+	}
+	return data
+}
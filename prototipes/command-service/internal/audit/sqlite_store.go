@@ -0,0 +1,153 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// timeFormat is fixed-width so that the stored times sort as strings
+const timeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+// auditSchema creates the audit table; the triggers make it append-only
+const auditSchema = `
+	CREATE TABLE IF NOT EXISTS audit_log (
+		seq INTEGER PRIMARY KEY,
+		at TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		request_id TEXT NOT NULL,
+		ip TEXT NOT NULL,
+		method TEXT NOT NULL,
+		path TEXT NOT NULL,
+		status INTEGER NOT NULL,
+		changes TEXT,
+		prev_hash TEXT NOT NULL,
+		hash TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_at ON audit_log(at);
+	CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+	BEGIN
+		SELECT RAISE(ABORT, 'audit_log is append-only');
+	END;
+	CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+	BEGIN
+		SELECT RAISE(ABORT, 'audit_log is append-only');
+	END;
+`
+
+const auditColumns = `seq, at, actor, request_id, ip, method, path, status, changes, prev_hash, hash`
+
+// SQLiteStore keeps the audit log in the audit_log table of its own SQLite database
+// (AUDIT_SQLITE_PATH)
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (or creates) the audit database at path
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite3", path+"?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit database: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(auditSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create audit_log: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// Append inserts entry
+func (s *SQLiteStore) Append(ctx context.Context, entry Entry) error {
+	var changes interface{}
+	if len(entry.Changes) > 0 {
+		data, err := json.Marshal(entry.Changes)
+		if err != nil {
+			return fmt.Errorf("failed to encode audit changes: %w", err)
+		}
+		changes = string(data)
+	}
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO audit_log (`+auditColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.Seq, entry.At.UTC().Format(timeFormat), entry.Actor, entry.RequestID, entry.IP,
+		entry.Method, entry.Path, entry.Status, changes, entry.PrevHash, entry.Hash,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// Last returns the entry with the highest sequence number
+func (s *SQLiteStore) Last(ctx context.Context) (*Entry, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+auditColumns+` FROM audit_log ORDER BY seq DESC LIMIT 1`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	entries, err := scanEntries(rows)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// List returns the entries of query in sequence order
+func (s *SQLiteStore) List(ctx context.Context, query Query) ([]Entry, error) {
+	var from, to string
+	if !query.From.IsZero() {
+		from = query.From.UTC().Format(timeFormat)
+	}
+	if !query.To.IsZero() {
+		to = query.To.UTC().Format(timeFormat)
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+auditColumns+`
+		FROM audit_log
+		WHERE seq > ? AND (? = '' OR at >= ?) AND (? = '' OR at < ?)
+		ORDER BY seq
+		LIMIT ?
+	`, query.AfterSeq, from, from, to, to, query.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return scanEntries(rows)
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+func scanEntries(rows *sql.Rows) ([]Entry, error) {
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var entry Entry
+		var at string
+		var changes sql.NullString
+		if err := rows.Scan(
+			&entry.Seq, &at, &entry.Actor, &entry.RequestID, &entry.IP,
+			&entry.Method, &entry.Path, &entry.Status, &changes, &entry.PrevHash, &entry.Hash,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		parsed, err := time.Parse(timeFormat, at)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time of audit entry %d: %w", entry.Seq, err)
+		}
+		entry.At = parsed
+		if changes.Valid {
+			if err := json.Unmarshal([]byte(changes.String), &entry.Changes); err != nil {
+				return nil, fmt.Errorf("invalid changes of audit entry %d: %w", entry.Seq, err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,335 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"command-service/internal/tenant"
+
+	"github.com/google/uuid"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrAPIKeyRevoked  = errors.New("api key revoked")
+	ErrAPIKeyExpired  = errors.New("api key expired")
+)
+
+// APIKeyPrefix starts every API key, so leaked keys are easy to recognize
+const APIKeyPrefix = "crk_"
+
+// APIKeyActorPrefix is prepended to the key name to form the actor of a request made
+// with an API key (username in the request context and the actor header of events)
+const APIKeyActorPrefix = "apikey:"
+
+// apiKeyTouchInterval bounds how often last_used_at is written for a busy key
+const apiKeyTouchInterval = time.Minute
+
+// APIKeyScopes are the permissions that can be granted to an API key. Managing users
+// and keys needs an interactive login.
+var APIKeyScopes = []string{PermissionRead, PermissionWrite, PermissionDelete, PermissionOverrideStock}
+
+// APIKey is a long-lived credential for machine clients (POS terminals, batch jobs).
+// Only the SHA-256 hash of the key is stored: the key itself is shown once, on creation.
+type APIKey struct {
+	ID         string
+	Name       string
+	Prefix     string // First characters of the key, to tell keys apart in listings
+	Hash       string
+	Scopes     []string
+	TenantID   string // Tenant of the admin that created the key; requests made with it act for it
+	CreatedBy  string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+// Actor is the username requests made with the key are attributed to
+func (k *APIKey) Actor() string {
+	return APIKeyActorPrefix + k.Name
+}
+
+// HasScope reports whether the key is granted permission
+func (k *APIKey) HasScope(permission string) bool {
+	for _, scope := range k.Scopes {
+		if scope == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns ErrAPIKeyRevoked or ErrAPIKeyExpired when the key can no longer be used
+func (k *APIKey) Check(now time.Time) error {
+	if k.RevokedAt != nil {
+		return ErrAPIKeyRevoked
+	}
+	if k.ExpiresAt != nil && !now.Before(*k.ExpiresAt) {
+		return ErrAPIKeyExpired
+	}
+	return nil
+}
+
+// ValidateAPIKeyScopes rejects empty, unknown or repeated scopes
+func ValidateAPIKeyScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	seen := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		if !isAPIKeyScope(scope) {
+			return fmt.Errorf("unknown scope %q (allowed: %s)", scope, strings.Join(APIKeyScopes, ", "))
+		}
+		if seen[scope] {
+			return fmt.Errorf("scope %q given more than once", scope)
+		}
+		seen[scope] = true
+	}
+	return nil
+}
+
+func isAPIKeyScope(scope string) bool {
+	for _, allowed := range APIKeyScopes {
+		if scope == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// NewAPIKey generates a key for a client of tenantID and returns it with its record; the
+// key must be handed to the client now, as only its hash is kept
+func NewAPIKey(name string, scopes []string, tenantID, createdBy string, expiresAt *time.Time) (string, *APIKey, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	key := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	return key, &APIKey{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Prefix:    key[:len(APIKeyPrefix)+8],
+		Hash:      hashToken(key),
+		Scopes:    scopes,
+		TenantID:  tenantID,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// APIKeyStore keeps API keys. Keys are looked up by the hash of the presented key.
+// Listing and revoking only see the keys of the tenant of ctx, except for the default
+// tenant, which sees them all.
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, key *APIKey) error
+	FindAPIKeyByHash(ctx context.Context, hash string) (*APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]*APIKey, error)
+	// RevokeAPIKey marks the key revoked; revoked keys stay listed for auditing
+	RevokeAPIKey(ctx context.Context, id string, at time.Time) (*APIKey, error)
+	// TouchAPIKey records that the key was used at the given time
+	TouchAPIKey(ctx context.Context, id string, at time.Time) error
+}
+
+// Authenticate returns the usable key matching the presented one. Unknown keys give
+// ErrAPIKeyNotFound; revoked or expired ones ErrAPIKeyRevoked or ErrAPIKeyExpired.
+func Authenticate(ctx context.Context, store APIKeyStore, presented string) (*APIKey, error) {
+	if !strings.HasPrefix(presented, APIKeyPrefix) {
+		return nil, ErrAPIKeyNotFound
+	}
+	key, err := store.FindAPIKeyByHash(ctx, hashToken(presented))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if err := key.Check(now); err != nil {
+		return nil, err
+	}
+	// Usage tracking is best effort: a failed write must not reject the request
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		_ = store.TouchAPIKey(ctx, key.ID, now)
+	}
+	return key, nil
+}
+
+// SQLiteAPIKeyStore keeps API keys in a SQLite database. Pointing both services at
+// the same path shares the keys.
+type SQLiteAPIKeyStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteAPIKeyStore opens (or creates) the API key database at path
+func NewSQLiteAPIKeyStore(path string) (*SQLiteAPIKeyStore, error) {
+	// path may already carry DSN parameters (e.g. an in-memory database in mock mode)
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	db, err := sql.Open("sqlite3", path+sep+"_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open api key store: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS api_keys (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			prefix TEXT NOT NULL,
+			key_hash TEXT NOT NULL UNIQUE,
+			scopes TEXT NOT NULL,
+			created_by TEXT NOT NULL,
+			created_at TEXT NOT NULL,
+			expires_at TEXT,
+			last_used_at TEXT,
+			revoked_at TEXT
+		)
+	`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create api_keys table: %w", err)
+	}
+	// Keys created before multi-tenancy belong to the default tenant
+	if err := addColumnIfMissing(db, "api_keys", "tenant_id", "TEXT NOT NULL DEFAULT '"+tenant.Default+"'"); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteAPIKeyStore{db: db}, nil
+}
+
+const apiKeyColumns = `id, name, prefix, key_hash, scopes, tenant_id, created_by, created_at, expires_at, last_used_at, revoked_at`
+
+// tenantKeys restricts a query on api_keys to the keys the tenant of ctx may manage;
+// its arguments are the tenant twice
+const tenantKeys = `(? = '` + tenant.Default + `' OR tenant_id = ?)`
+
+// CreateAPIKey inserts a new key
+func (s *SQLiteAPIKeyStore) CreateAPIKey(ctx context.Context, key *APIKey) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO api_keys (`+apiKeyColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		key.ID, key.Name, key.Prefix, key.Hash, strings.Join(key.Scopes, ","), tenant.OrDefault(key.TenantID), key.CreatedBy,
+		key.CreatedAt.UTC().Format(time.RFC3339), formatOptionalTime(key.ExpiresAt),
+		formatOptionalTime(key.LastUsedAt), formatOptionalTime(key.RevokedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+// FindAPIKeyByHash returns the key with the given hash
+func (s *SQLiteAPIKeyStore) FindAPIKeyByHash(ctx context.Context, hash string) (*APIKey, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = ?`, hash)
+	key, err := scanAPIKey(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+// ListAPIKeys returns the keys of the tenant of ctx, newest first
+func (s *SQLiteAPIKeyStore) ListAPIKeys(ctx context.Context) ([]*APIKey, error) {
+	tenantID := tenant.FromContext(ctx)
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE `+tenantKeys+` ORDER BY created_at DESC, name`,
+		tenantID, tenantID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]*APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey marks a key of the tenant of ctx revoked; revoking it again keeps the
+// first revocation time
+func (s *SQLiteAPIKeyStore) RevokeAPIKey(ctx context.Context, id string, at time.Time) (*APIKey, error) {
+	tenantID := tenant.FromContext(ctx)
+	if _, err := s.db.ExecContext(ctx,
+		`UPDATE api_keys SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ? AND `+tenantKeys,
+		at.UTC().Format(time.RFC3339), id, tenantID, tenantID,
+	); err != nil {
+		return nil, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	key, err := scanAPIKey(s.db.QueryRowContext(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE id = ? AND `+tenantKeys, id, tenantID, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+// TouchAPIKey records the last use of a key
+func (s *SQLiteAPIKeyStore) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+	if _, err := s.db.ExecContext(ctx,
+		`UPDATE api_keys SET last_used_at = ? WHERE id = ?`, at.UTC().Format(time.RFC3339), id,
+	); err != nil {
+		return fmt.Errorf("failed to record api key use: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying database
+func (s *SQLiteAPIKeyStore) Close() error {
+	return s.db.Close()
+}
+
+func scanAPIKey(row interface{ Scan(...interface{}) error }) (*APIKey, error) {
+	var key APIKey
+	var scopes, createdAt string
+	var expiresAt, lastUsedAt, revokedAt sql.NullString
+	if err := row.Scan(
+		&key.ID, &key.Name, &key.Prefix, &key.Hash, &scopes, &key.TenantID, &key.CreatedBy,
+		&createdAt, &expiresAt, &lastUsedAt, &revokedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan api key: %w", err)
+	}
+	key.Scopes = strings.Split(scopes, ",")
+	key.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	key.ExpiresAt = parseOptionalTime(expiresAt)
+	key.LastUsedAt = parseOptionalTime(lastUsedAt)
+	key.RevokedAt = parseOptionalTime(revokedAt)
+	return &key, nil
+}
+
+func formatOptionalTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func parseOptionalTime(s sql.NullString) *time.Time {
+	if !s.Valid {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s.String)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
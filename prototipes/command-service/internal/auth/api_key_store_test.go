@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"command-service/internal/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyStore_AuthenticateStoresOnlyTheHash(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteAPIKeyStore(filepath.Join(t.TempDir(), "api_keys.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	key, record, err := NewAPIKey("pos-store-12", []string{PermissionRead, PermissionWrite}, "brand-a", "admin", nil)
+	require.NoError(t, err)
+	require.NoError(t, store.CreateAPIKey(ctx, record))
+	assert.True(t, strings.HasPrefix(key, APIKeyPrefix))
+	assert.True(t, strings.HasPrefix(key, record.Prefix))
+	assert.NotContains(t, record.Hash, key)
+
+	found, err := Authenticate(ctx, store, key)
+	require.NoError(t, err)
+	assert.Equal(t, record.ID, found.ID)
+	assert.Equal(t, "apikey:pos-store-12", found.Actor())
+	assert.True(t, found.HasScope(PermissionWrite))
+	assert.False(t, found.HasScope(PermissionDelete))
+
+	// The first use is recorded
+	keys, err := store.ListAPIKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.NotNil(t, keys[0].LastUsedAt)
+
+	for _, presented := range []string{"", "not-a-key", key + "x", APIKeyPrefix + "unknown"} {
+		_, err := Authenticate(ctx, store, presented)
+		assert.Equal(t, ErrAPIKeyNotFound, err, presented)
+	}
+}
+
+func TestAPIKeyStore_RevokedAndExpiredKeys(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteAPIKeyStore(filepath.Join(t.TempDir(), "api_keys.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	revokedKey, revoked, err := NewAPIKey("batch-job", []string{PermissionRead}, "default", "admin", nil)
+	require.NoError(t, err)
+	require.NoError(t, store.CreateAPIKey(ctx, revoked))
+	first, err := store.RevokeAPIKey(ctx, revoked.ID, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	again, err := store.RevokeAPIKey(ctx, revoked.ID, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, first.RevokedAt, again.RevokedAt)
+	_, err = Authenticate(ctx, store, revokedKey)
+	assert.Equal(t, ErrAPIKeyRevoked, err)
+
+	past := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	expiredKey, expired, err := NewAPIKey("old-terminal", []string{PermissionRead}, "default", "admin", &past)
+	require.NoError(t, err)
+	require.NoError(t, store.CreateAPIKey(ctx, expired))
+	_, err = Authenticate(ctx, store, expiredKey)
+	assert.Equal(t, ErrAPIKeyExpired, err)
+
+	_, err = store.RevokeAPIKey(ctx, "missing", time.Now())
+	assert.Equal(t, ErrAPIKeyNotFound, err)
+}
+
+func TestAPIKeyStore_ScopedByTenant(t *testing.T) {
+	store, err := NewSQLiteAPIKeyStore(filepath.Join(t.TempDir(), "api_keys.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	_, brandA, err := NewAPIKey("pos-a", []string{PermissionRead}, "brand-a", "admin-a", nil)
+	require.NoError(t, err)
+	_, brandB, err := NewAPIKey("pos-b", []string{PermissionRead}, "brand-b", "admin-b", nil)
+	require.NoError(t, err)
+	require.NoError(t, store.CreateAPIKey(context.Background(), brandA))
+	require.NoError(t, store.CreateAPIKey(context.Background(), brandB))
+
+	ctxA := tenant.WithTenant(context.Background(), "brand-a")
+	keys, err := store.ListAPIKeys(ctxA)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "brand-a", keys[0].TenantID)
+
+	// Another tenant's key cannot be revoked
+	_, err = store.RevokeAPIKey(ctxA, brandB.ID, time.Now())
+	assert.Equal(t, ErrAPIKeyNotFound, err)
+
+	// The default tenant manages every key
+	keys, err = store.ListAPIKeys(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+	revoked, err := store.RevokeAPIKey(context.Background(), brandB.ID, time.Now())
+	require.NoError(t, err)
+	assert.NotNil(t, revoked.RevokedAt)
+}
+
+func TestValidateAPIKeyScopes(t *testing.T) {
+	assert.NoError(t, ValidateAPIKeyScopes([]string{PermissionRead, PermissionOverrideStock}))
+	assert.Error(t, ValidateAPIKeyScopes(nil))
+	assert.Error(t, ValidateAPIKeyScopes([]string{PermissionManageUsers}))
+	assert.Error(t, ValidateAPIKeyScopes([]string{PermissionRead, PermissionRead}))
+}
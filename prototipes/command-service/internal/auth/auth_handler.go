@@ -1,9 +1,12 @@
 package auth
 
 import (
+	stderrors "errors"
 	"net/http"
+	"strings"
 	"time"
 
+	"command-service/internal/tenant"
 	"command-service/pkg/errors"
 
 	"github.com/gin-gonic/gin"
@@ -13,13 +16,19 @@ import (
 // AuthHandler handles authentication requests
 type AuthHandler struct {
 	jwtManager *JWTManager
+	userStore  UserStore
+	tokenStore TokenStore
+	refreshTTL time.Duration
 	logger     *zap.Logger
 }
 
-// NewAuthHandler creates a new auth handler
-func NewAuthHandler(jwtManager *JWTManager, logger *zap.Logger) *AuthHandler {
+// NewAuthHandler creates a new auth handler. Refresh tokens live for refreshTTL.
+func NewAuthHandler(jwtManager *JWTManager, userStore UserStore, tokenStore TokenStore, refreshTTL time.Duration, logger *zap.Logger) *AuthHandler {
 	return &AuthHandler{
 		jwtManager: jwtManager,
+		userStore:  userStore,
+		tokenStore: tokenStore,
+		refreshTTL: refreshTTL,
 		logger:     logger,
 	}
 }
@@ -32,22 +41,54 @@ type LoginRequest struct {
 
 // LoginResponse represents the login response
 type LoginResponse struct {
-	Token     string    `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
-	Type      string    `json:"type" example:"Bearer"`
-	ExpiresIn int       `json:"expires_in" example:"600"` // 10 minutes in seconds
-	ExpiresAt time.Time `json:"expires_at" example:"2024-01-15T12:00:00Z"`
+	Token            string    `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	Type             string    `json:"type" example:"Bearer"`
+	Role             string    `json:"role" example:"admin"`
+	TenantID         string    `json:"tenant_id" example:"default"`
+	ExpiresIn        int       `json:"expires_in" example:"600"` // Access token lifetime in seconds (JWT_ACCESS_TOKEN_TTL_SECONDS)
+	ExpiresAt        time.Time `json:"expires_at" example:"2024-01-15T12:00:00Z"`
+	RefreshToken     string    `json:"refresh_token" example:"q0dW3Wc5m6Yx..."`
+	RefreshExpiresIn int       `json:"refresh_expires_in" example:"86400"`
+}
+
+// CreateUserRequest represents the create user request
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required,max=64" example:"jdoe"`
+	Password string `json:"password" binding:"required,min=8" example:"s3cret-pass"`
+	Role     string `json:"role" binding:"required,oneof=admin operator viewer" example:"operator"`
+	// TenantID defaults to the tenant of the admin creating the user
+	TenantID string `json:"tenant_id,omitempty" example:"brand-a"`
+}
+
+// UserResponse represents a user (without the password hash)
+type UserResponse struct {
+	Username  string    `json:"username" example:"jdoe"`
+	Role      string    `json:"role" example:"operator"`
+	TenantID  string    `json:"tenant_id" example:"brand-a"`
+	CreatedAt time.Time `json:"created_at" example:"2024-01-15T12:00:00Z"`
+}
+
+// RefreshRequest represents the refresh request
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required" example:"q0dW3Wc5m6Yx..."`
+}
+
+// LogoutRequest represents the logout request. The access token is taken from the
+// Authorization header; the refresh token is optional.
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" example:"q0dW3Wc5m6Yx..."`
 }
 
 // Login handles POST /api/v1/auth/login
 // @Summary      Login and get JWT token
-// @Description  Autentica un usuario y retorna un token JWT válido por 10 minutos. Usuarios disponibles: admin/admin123, user/user123, operator/operator123
+// @Description  Autentica un usuario contra el user store (SQLite o archivo, contraseñas con bcrypt) y retorna un token JWT válido por `JWT_ACCESS_TOKEN_TTL_SECONDS` (10 minutos por defecto, en `expires_in`) y un refresh token para renovarlo (`POST /auth/refresh`). Un store vacío se inicializa con admin/admin123 (rol admin), operator/operator123 (rol operator) y user/user123 (rol viewer). El rol y el tenant (`tenant_id`) del usuario viajan en el token: el rol determina los permisos y el tenant limita los ítems y tiendas visibles
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        request  body      LoginRequest  true  "Login credentials"
 // @Success      200      {object}  LoginResponse  "Token generado exitosamente"
-// @Failure      400      {object}  map[string]string  "Request inválido - credenciales faltantes"
-// @Failure      401      {object}  map[string]string  "Credenciales inválidas"
+// @Failure      400      {object}  errors.StandardError  "Request inválido - credenciales faltantes"
+// @Failure      401      {object}  errors.StandardError  "Credenciales inválidas"
 // @Router       /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
@@ -59,20 +100,24 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// Simple authentication (for prototype)
-	// In production, this should validate against a user database
-	if !h.validateCredentials(req.Username, req.Password) {
+	user, err := h.userStore.FindByUsername(c.Request.Context(), req.Username)
+	if err != nil && err != ErrUserNotFound {
+		h.logger.Error("Failed to look up user", zap.Error(err))
+		c.Error(errors.NewInternalError("failed to authenticate", err))
+		c.Abort()
+		return
+	}
+	if user == nil || !CheckPassword(user.PasswordHash, req.Password) {
 		h.logger.Warn("Invalid credentials",
 			zap.String("username", req.Username),
 		)
-		c.Error(errors.NewStandardError("Unauthorized", "invalid credentials", "username or password incorrect"))
+		c.Error(errors.NewUnauthorized("invalid credentials", "username or password incorrect"))
 		c.Abort()
-		c.JSON(http.StatusUnauthorized, errors.NewStandardError("Unauthorized", "invalid credentials", "username or password incorrect"))
 		return
 	}
 
-	// Generate JWT token
-	token, err := h.jwtManager.GenerateToken(req.Username)
+	role := user.Role
+	response, err := h.issueTokens(c, user.Username, role, tenant.OrDefault(user.TenantID))
 	if err != nil {
 		h.logger.Error("Failed to generate token", zap.Error(err))
 		c.Error(errors.NewInternalError("failed to generate token", err))
@@ -80,38 +125,228 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	expiresAt := time.Now().Add(10 * time.Minute)
-	response := LoginResponse{
-		Token:     token,
-		Type:      "Bearer",
-		ExpiresIn: 600, // 10 minutes in seconds
-		ExpiresAt: expiresAt,
-	}
-
 	h.logger.Info("User logged in successfully",
 		zap.String("username", req.Username),
-		zap.Time("expires_at", expiresAt),
+		zap.String("role", role),
+		zap.String("tenant_id", response.TenantID),
+		zap.Time("expires_at", response.ExpiresAt),
 	)
 
 	c.JSON(http.StatusOK, response)
 }
 
-// validateCredentials validates user credentials
-// For prototype: simple hardcoded validation
-// In production: validate against user database
-func (h *AuthHandler) validateCredentials(username, password string) bool {
-	// Simple validation for prototype
-	// In production, this should query a user database
-	validUsers := map[string]string{
-		"admin":    "admin123",
-		"user":     "user123",
-		"operator": "operator123",
+// Refresh handles POST /api/v1/auth/refresh
+// @Summary      Refresh the JWT token
+// @Description  Intercambia un refresh token por un nuevo token JWT y un nuevo refresh token. Cada refresh token se puede usar una sola vez (rotación): reutilizarlo retorna 401.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      RefreshRequest  true  "Refresh token"
+// @Success      200      {object}  LoginResponse  "Tokens renovados"
+// @Failure      400      {object}  errors.StandardError  "Request inválido - refresh token faltante"
+// @Failure      401      {object}  errors.StandardError  "Refresh token inválido, expirado o ya usado"
+// @Router       /auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid refresh request", zap.Error(err))
+		c.Error(errors.NewValidationError("invalid request", "refresh_token"))
+		c.Abort()
+		return
 	}
 
-	expectedPassword, exists := validUsers[username]
-	if !exists {
-		return false
+	session, err := h.tokenStore.ConsumeRefreshToken(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		if err != ErrInvalidRefreshToken {
+			h.logger.Error("Failed to read refresh token", zap.Error(err))
+			c.Error(errors.NewInternalError("failed to refresh token", err))
+			c.Abort()
+			return
+		}
+		h.logger.Warn("Invalid refresh token")
+		c.Error(errors.NewUnauthorized("invalid refresh token", "refresh token is unknown, expired or already used"))
+		c.Abort()
+		return
+	}
+
+	response, err := h.issueTokens(c, session.Username, session.Role, tenant.OrDefault(session.TenantID))
+	if err != nil {
+		h.logger.Error("Failed to generate token", zap.Error(err))
+		c.Error(errors.NewInternalError("failed to generate token", err))
+		c.Abort()
+		return
+	}
+
+	h.logger.Info("Token refreshed",
+		zap.String("username", session.Username),
+		zap.Time("expires_at", response.ExpiresAt),
+	)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Logout handles POST /api/v1/auth/logout
+// @Summary      Logout (revoke tokens)
+// @Description  Revoca el token JWT del header `Authorization` (queda en la lista de revocación hasta que expira) y, si se envía, el refresh token. Se debe enviar al menos uno de los dos.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        Authorization  header    string         false  "Bearer <token> a revocar"
+// @Param        request        body      LogoutRequest  false  "Refresh token a revocar"
+// @Success      200            {object}  map[string]string  "Sesión cerrada"
+// @Failure      400            {object}  errors.StandardError  "Request inválido - no hay tokens para revocar"
+// @Router       /auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req LogoutRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.logger.Warn("Invalid logout request", zap.Error(err))
+			c.Error(errors.NewValidationError("invalid request", "refresh_token"))
+			c.Abort()
+			return
+		}
 	}
 
-	return password == expectedPassword
+	ctx := c.Request.Context()
+	revoked := false
+
+	// Revoke the access token for the rest of its lifetime; expired or invalid tokens are already unusable
+	if parts := strings.Split(c.GetHeader("Authorization"), " "); len(parts) == 2 && parts[0] == "Bearer" {
+		if claims, err := h.jwtManager.ValidateToken(parts[1]); err == nil && claims.ID != "" {
+			if err := h.tokenStore.RevokeAccessToken(ctx, claims.ID, time.Until(claims.ExpiresAt.Time)); err != nil {
+				h.logger.Error("Failed to revoke access token", zap.Error(err))
+				c.Error(errors.NewInternalError("failed to revoke token", err))
+				c.Abort()
+				return
+			}
+			revoked = true
+		}
+	}
+
+	if req.RefreshToken != "" {
+		if err := h.tokenStore.DeleteRefreshToken(ctx, req.RefreshToken); err != nil {
+			h.logger.Error("Failed to revoke refresh token", zap.Error(err))
+			c.Error(errors.NewInternalError("failed to revoke token", err))
+			c.Abort()
+			return
+		}
+		revoked = true
+	}
+
+	if !revoked {
+		c.Error(errors.NewInvalidRequest("nothing to revoke", "send a valid Bearer token and/or refresh_token"))
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// issueTokens generates an access token and a refresh token for the user
+func (h *AuthHandler) issueTokens(c *gin.Context, username, role, tenantID string) (*LoginResponse, error) {
+	token, err := h.jwtManager.GenerateToken(username, role, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := NewRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := h.tokenStore.SaveRefreshToken(c.Request.Context(), refreshToken, RefreshSession{Username: username, Role: role, TenantID: tenantID}, h.refreshTTL); err != nil {
+		return nil, err
+	}
+
+	return &LoginResponse{
+		Token:            token,
+		Type:             "Bearer",
+		Role:             role,
+		TenantID:         tenantID,
+		ExpiresIn:        int(h.jwtManager.AccessTokenTTL().Seconds()),
+		ExpiresAt:        time.Now().Add(h.jwtManager.AccessTokenTTL()),
+		RefreshToken:     refreshToken,
+		RefreshExpiresIn: int(h.refreshTTL.Seconds()),
+	}, nil
+}
+
+// CreateUser handles POST /api/v1/auth/users
+// @Summary      Create a user
+// @Description  Crea un usuario en el user store con la contraseña hasheada con bcrypt. Requiere un token con el permiso `users:manage` (rol admin por defecto). El usuario pertenece al tenant del admin que lo crea salvo que se indique `tenant_id`; solo los admins del tenant `default` pueden crear usuarios de otros tenants.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      CreateUserRequest  true  "Usuario a crear"
+// @Success      201      {object}  UserResponse  "Usuario creado"
+// @Failure      400      {object}  errors.StandardError  "Request inválido"
+// @Failure      401      {object}  errors.StandardError  "No autenticado"
+// @Failure      403      {object}  errors.StandardError  "Sin permiso users:manage o tenant ajeno"
+// @Failure      409      {object}  errors.StandardError  "El usuario ya existe"
+// @Router       /auth/users [post]
+func (h *AuthHandler) CreateUser(c *gin.Context) {
+	var req CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid create user request", zap.Error(err))
+		c.Error(errors.NewValidationError("invalid request", "username, password (min 8 chars) or role (admin, operator, viewer)"))
+		c.Abort()
+		return
+	}
+
+	creatorTenant := tenant.FromContext(c.Request.Context())
+	userTenant := creatorTenant
+	if req.TenantID != "" {
+		if !tenant.Valid(req.TenantID) {
+			c.Error(errors.NewValidationError("invalid tenant_id", "lowercase letters, digits, '-' and '_', up to 64 characters"))
+			c.Abort()
+			return
+		}
+		// Only the operators of the deployment create users for other brands
+		if req.TenantID != creatorTenant && creatorTenant != tenant.Default {
+			c.Error(errors.NewForbidden("cannot create users of another tenant", "Tenant: "+req.TenantID))
+			c.Abort()
+			return
+		}
+		userTenant = req.TenantID
+	}
+
+	hash, err := HashPassword(req.Password)
+	if err != nil {
+		h.logger.Error("Failed to hash password", zap.Error(err))
+		c.Error(errors.NewInternalError("failed to create user", err))
+		c.Abort()
+		return
+	}
+
+	user := &User{
+		Username:     req.Username,
+		PasswordHash: hash,
+		Role:         req.Role,
+		TenantID:     userTenant,
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err := h.userStore.CreateUser(c.Request.Context(), user); err != nil {
+		if stderrors.Is(err, ErrUserExists) {
+			c.Error(errors.NewConflict("user already exists", "Username: "+req.Username))
+			c.Abort()
+			return
+		}
+		h.logger.Error("Failed to create user", zap.Error(err))
+		c.Error(errors.NewInternalError("failed to create user", err))
+		c.Abort()
+		return
+	}
+
+	h.logger.Info("User created",
+		zap.String("username", user.Username),
+		zap.String("role", user.Role),
+		zap.String("tenant_id", user.TenantID),
+		zap.String("created_by", c.GetString("username")),
+	)
+
+	c.JSON(http.StatusCreated, UserResponse{
+		Username:  user.Username,
+		Role:      user.Role,
+		TenantID:  user.TenantID,
+		CreatedAt: user.CreatedAt,
+	})
 }
@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrUnknownKey is returned by JWKSKeySet.Key when the JWKS has no key with the kid
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// DefaultJWKSRefreshInterval is how long the keys of a JWKS are cached before fetching it again
+const DefaultJWKSRefreshInterval = 5 * time.Minute
+
+// jwksMinRefreshInterval throttles the refetches triggered by unknown kids, so tokens with
+// made-up kids cannot hammer the IdP
+const jwksMinRefreshInterval = 10 * time.Second
+
+// jwk is a key of a JWKS (RFC 7517); only the RSA and EC members are read
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// JWKSKeySet caches the public keys published at a JWKS URL (e.g. the certs endpoint of a
+// Keycloak realm) and refetches them when they get stale or a token names an unknown kid,
+// which picks up key rotations
+type JWKSKeySet struct {
+	url        string
+	refresh    time.Duration
+	minRefresh time.Duration
+	httpClient *http.Client
+	logger     *zap.Logger
+
+	mu        sync.Mutex
+	keys      map[string]interface{} // kid -> *rsa.PublicKey / *ecdsa.PublicKey
+	fetchedAt time.Time
+	triedAt   time.Time
+}
+
+// NewJWKSKeySet creates a key set for url; the keys are fetched on first use
+func NewJWKSKeySet(url string, refresh time.Duration, logger *zap.Logger) *JWKSKeySet {
+	if refresh <= 0 {
+		refresh = DefaultJWKSRefreshInterval
+	}
+	return &JWKSKeySet{
+		url:        url,
+		refresh:    refresh,
+		minRefresh: jwksMinRefreshInterval,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		logger:     logger,
+		keys:       map[string]interface{}{},
+	}
+}
+
+// Refresh fetches the JWKS and replaces the cached keys
+func (s *JWKSKeySet) Refresh(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetchLocked(ctx)
+}
+
+// Key returns the public key with the kid, refetching the JWKS when the cache is stale or
+// has no such kid. A failed refetch keeps serving the cached keys.
+func (s *JWKSKeySet) Key(ctx context.Context, kid string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[kid]
+	stale := time.Since(s.fetchedAt) > s.refresh
+	if (!ok || stale) && time.Since(s.triedAt) >= s.minRefresh {
+		if err := s.fetchLocked(ctx); err != nil {
+			s.logger.Warn("Failed to refresh JWKS, using cached keys",
+				zap.String("url", s.url),
+				zap.Int("cached_keys", len(s.keys)),
+				zap.Error(err),
+			)
+		}
+		key, ok = s.keys[kid]
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: kid %q", ErrUnknownKey, kid)
+	}
+	return key, nil
+}
+
+func (s *JWKSKeySet) fetchLocked(ctx context.Context) error {
+	s.triedAt = time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", s.url, resp.Status)
+	}
+
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return fmt.Errorf("decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			s.logger.Warn("Skipping JWKS key", zap.String("kid", k.Kid), zap.Error(err))
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("JWKS at %s has no usable signing keys", s.url)
+	}
+
+	s.keys = keys
+	s.fetchedAt = s.triedAt
+	s.logger.Info("JWKS refreshed", zap.String("url", s.url), zap.Int("keys", len(keys)))
+	return nil
+}
+
+// publicKey decodes an RSA or P-256 EC key
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("n: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("e: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("e: too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, errors.New("point not on P-256")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	if value == "" {
+		return nil, errors.New("missing")
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeIdP serves a JWKS whose keys the test can rotate
+type fakeIdP struct {
+	mu      sync.Mutex
+	keys    []jwk
+	fetches int
+}
+
+func (f *fakeIdP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fetches++
+	json.NewEncoder(w).Encode(map[string]interface{}{"keys": f.keys})
+}
+
+func (f *fakeIdP) publish(keys ...jwk) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys = keys
+}
+
+func b64(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func rsaJWK(t *testing.T, kid string) (*rsa.PrivateKey, jwk) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key, jwk{Kty: "RSA", Kid: kid, Use: "sig", Alg: "RS256", N: b64(key.N), E: b64(big.NewInt(int64(key.E)))}
+}
+
+func ecJWK(t *testing.T, kid string) (*ecdsa.PrivateKey, jwk) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return key, jwk{Kty: "EC", Kid: kid, Use: "sig", Alg: "ES256", Crv: "P-256", X: b64(key.X), Y: b64(key.Y)}
+}
+
+// idpToken signs a Keycloak-like token: preferred_username instead of username
+func idpToken(t *testing.T, method jwt.SigningMethod, kid string, key interface{}) string {
+	now := time.Now()
+	token := jwt.NewWithClaims(method, jwt.MapClaims{
+		"preferred_username": "jdoe",
+		"role":               RoleOperator,
+		"iss":                "https://keycloak.example.com/realms/retail",
+		"sub":                "f3c1e6f2",
+		"iat":                now.Unix(),
+		"exp":                now.Add(5 * time.Minute).Unix(),
+	})
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestJWTManager_ValidatesJWKSTokens(t *testing.T) {
+	rsaKey, rsaPub := rsaJWK(t, "rsa-1")
+	ecKey, ecPub := ecJWK(t, "ec-1")
+	idp := &fakeIdP{}
+	idp.publish(rsaPub, ecPub)
+	server := httptest.NewServer(idp)
+	defer server.Close()
+
+	manager := NewJWTManager(testSecret, zap.NewNop()).WithJWKS(NewJWKSKeySet(server.URL, time.Minute, zap.NewNop()))
+
+	claims, err := manager.ValidateToken(idpToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey))
+	require.NoError(t, err)
+	assert.Equal(t, "jdoe", claims.Username)
+	assert.Equal(t, RoleOperator, claims.Role)
+
+	_, err = manager.ValidateToken(idpToken(t, jwt.SigningMethodES256, "ec-1", ecKey))
+	assert.NoError(t, err)
+
+	// Local HS256 tokens keep working
+	local, err := manager.GenerateToken("admin", RoleAdmin, "")
+	require.NoError(t, err)
+	_, err = manager.ValidateToken(local)
+	assert.NoError(t, err)
+
+	// The keys are cached
+	assert.Equal(t, 1, idp.fetches)
+}
+
+func TestJWTManager_RejectsUntrustedAsymmetricTokens(t *testing.T) {
+	rsaKey, rsaPub := rsaJWK(t, "rsa-1")
+	otherKey, _ := rsaJWK(t, "rsa-1")
+	idp := &fakeIdP{}
+	idp.publish(rsaPub)
+	server := httptest.NewServer(idp)
+	defer server.Close()
+
+	// Without a JWKS only HS256 is accepted
+	_, err := NewJWTManager(testSecret, zap.NewNop()).ValidateToken(idpToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	manager := NewJWTManager(testSecret, zap.NewNop()).WithJWKS(NewJWKSKeySet(server.URL, time.Minute, zap.NewNop()))
+
+	// Signed by a key the IdP does not publish
+	_, err = manager.ValidateToken(idpToken(t, jwt.SigningMethodRS256, "rsa-1", otherKey))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Unknown kid
+	_, err = manager.ValidateToken(idpToken(t, jwt.SigningMethodRS256, "rsa-2", rsaKey))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Only RS256 and ES256 are accepted
+	_, err = manager.ValidateToken(idpToken(t, jwt.SigningMethodRS512, "rsa-1", rsaKey))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestJWKSKeySet_PicksUpRotatedKeys(t *testing.T) {
+	oldKey, oldPub := rsaJWK(t, "old")
+	newKey, newPub := rsaJWK(t, "new")
+	idp := &fakeIdP{}
+	idp.publish(oldPub)
+	server := httptest.NewServer(idp)
+	defer server.Close()
+
+	keys := NewJWKSKeySet(server.URL, time.Hour, zap.NewNop())
+	keys.minRefresh = 0
+	manager := NewJWTManager(testSecret, zap.NewNop()).WithJWKS(keys)
+
+	_, err := manager.ValidateToken(idpToken(t, jwt.SigningMethodRS256, "old", oldKey))
+	require.NoError(t, err)
+
+	// The IdP rotates its key: the unknown kid triggers a refetch
+	idp.publish(newPub)
+	_, err = manager.ValidateToken(idpToken(t, jwt.SigningMethodRS256, "new", newKey))
+	require.NoError(t, err)
+	assert.Equal(t, 2, idp.fetches)
+
+	// If the IdP goes down the stale keys are still served
+	server.Close()
+	keys.refresh = 0
+	_, err = manager.ValidateToken(idpToken(t, jwt.SigningMethodRS256, "new", newKey))
+	assert.NoError(t, err)
+}
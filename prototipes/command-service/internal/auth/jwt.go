@@ -1,10 +1,12 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -13,41 +15,120 @@ var (
 	ErrExpiredToken = errors.New("token expired")
 )
 
+// DefaultAccessTokenTTL is the lifetime of access tokens unless WithAccessTokenTTL changes
+// it; use refresh tokens to get new ones
+const DefaultAccessTokenTTL = 10 * time.Minute
+
+// DefaultClockSkew is how far apart the clocks of the host that issued a token and the
+// host that validates it may be before exp, nbf and iat are enforced
+const DefaultClockSkew = 30 * time.Second
+
 // JWTClaims represents the JWT claims
 type JWTClaims struct {
 	Username string `json:"username"`
+	Role     string `json:"role"`
+	// PreferredUsername is the username claim of OIDC IdPs such as Keycloak; ValidateToken
+	// copies it to Username when the token has no username
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	// TenantID is the retail brand the user works for; tokens without it act for
+	// tenant.Default
+	TenantID string `json:"tenant_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // JWTManager handles JWT token generation and validation
 type JWTManager struct {
-	secretKey []byte
-	logger    *zap.Logger
+	secretKey      []byte
+	clockSkew      time.Duration
+	accessTokenTTL time.Duration
+	issuer         string      // iss of the generated tokens
+	trustedIssuers []string    // iss accepted by ValidateToken; empty accepts any
+	audience       string      // aud of the generated tokens, required by ValidateToken; empty for none
+	jwks           *JWKSKeySet // verifies RS256/ES256 tokens; nil accepts HS256 only
+	logger         *zap.Logger
 }
 
 // NewJWTManager creates a new JWT manager
 func NewJWTManager(secretKey string, logger *zap.Logger) *JWTManager {
 	return &JWTManager{
-		secretKey: []byte(secretKey),
-		logger:    logger,
+		secretKey:      []byte(secretKey),
+		clockSkew:      DefaultClockSkew,
+		accessTokenTTL: DefaultAccessTokenTTL,
+		issuer:         "command-service",
+		logger:         logger,
+	}
+}
+
+// WithClockSkew sets the clock skew tolerated when validating exp, nbf and iat
+func (j *JWTManager) WithClockSkew(skew time.Duration) *JWTManager {
+	if skew < 0 {
+		skew = 0
+	}
+	j.clockSkew = skew
+	return j
+}
+
+// WithAccessTokenTTL sets the lifetime of the generated tokens; non-positive values keep
+// the current one
+func (j *JWTManager) WithAccessTokenTTL(ttl time.Duration) *JWTManager {
+	if ttl > 0 {
+		j.accessTokenTTL = ttl
+	}
+	return j
+}
+
+// WithIssuer sets the iss of the generated tokens and the issuers ValidateToken accepts
+// (any when trusted is empty)
+func (j *JWTManager) WithIssuer(issuer string, trusted []string) *JWTManager {
+	if issuer != "" {
+		j.issuer = issuer
 	}
+	j.trustedIssuers = trusted
+	return j
 }
 
-// GenerateToken generates a new JWT token with 10 minutes expiration
-func (j *JWTManager) GenerateToken(username string) (string, error) {
+// WithAudience sets the aud of the generated tokens, which ValidateToken then requires.
+// Empty generates tokens without aud and accepts any.
+func (j *JWTManager) WithAudience(audience string) *JWTManager {
+	j.audience = audience
+	return j
+}
+
+// WithJWKS makes ValidateToken also accept RS256 and ES256 tokens signed by a key of the
+// JWKS (e.g. issued by Keycloak). HS256 tokens signed with the secret are still accepted;
+// the tokens this manager generates stay HS256.
+func (j *JWTManager) WithJWKS(keys *JWKSKeySet) *JWTManager {
+	j.jwks = keys
+	return j
+}
+
+// AccessTokenTTL returns the lifetime of the generated tokens (expires_in of the login)
+func (j *JWTManager) AccessTokenTTL() time.Duration {
+	return j.accessTokenTTL
+}
+
+// GenerateToken generates a new JWT token for a user of tenantID that expires after the
+// access token TTL
+func (j *JWTManager) GenerateToken(username, role, tenantID string) (string, error) {
 	now := time.Now()
-	expiresAt := now.Add(10 * time.Minute) // 10 minutes expiration
+	expiresAt := now.Add(j.accessTokenTTL)
 
 	claims := JWTClaims{
 		Username: username,
+		Role:     role,
+		TenantID: tenantID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
-			Issuer:    "command-service",
+			Issuer:    j.issuer,
 			Subject:   username,
+			ID:        uuid.New().String(), // jti, used by the revocation list
 		},
 	}
+	if j.audience != "" {
+		claims.Audience = jwt.ClaimStrings{j.audience}
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString(j.secretKey)
@@ -58,6 +139,8 @@ func (j *JWTManager) GenerateToken(username string) (string, error) {
 
 	j.logger.Info("Token generated",
 		zap.String("username", username),
+		zap.String("role", role),
+		zap.String("tenant_id", tenantID),
 		zap.Time("expires_at", expiresAt),
 	)
 
@@ -66,19 +149,11 @@ func (j *JWTManager) GenerateToken(username string) (string, error) {
 
 // ValidateToken validates a JWT token and returns the claims
 func (j *JWTManager) ValidateToken(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidToken
-		}
-		return j.secretKey, nil
-	})
+	// The time claims are checked below with the skew tolerance
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+	token, err := parser.ParseWithClaims(tokenString, &JWTClaims{}, j.verificationKey)
 
 	if err != nil {
-		if errors.Is(err, jwt.ErrTokenExpired) {
-			j.logger.Warn("Token expired", zap.Error(err))
-			return nil, ErrExpiredToken
-		}
 		j.logger.Warn("Invalid token", zap.Error(err))
 		return nil, ErrInvalidToken
 	}
@@ -88,7 +163,89 @@ func (j *JWTManager) ValidateToken(tokenString string) (*JWTClaims, error) {
 		j.logger.Warn("Invalid token claims")
 		return nil, ErrInvalidToken
 	}
+	if err := j.validateTimes(claims, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := j.validateIssuerAndAudience(claims); err != nil {
+		return nil, err
+	}
+	if claims.Username == "" {
+		claims.Username = claims.PreferredUsername
+	}
 
 	return claims, nil
 }
 
+// verificationKey picks the key that verifies token by its alg: the secret for HMAC, the
+// JWKS key named by kid for RS256 and ES256
+func (j *JWTManager) verificationKey(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		return j.secretKey, nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		alg := token.Method.Alg()
+		if j.jwks == nil || (alg != jwt.SigningMethodRS256.Alg() && alg != jwt.SigningMethodES256.Alg()) {
+			return nil, ErrInvalidToken
+		}
+		kid, _ := token.Header["kid"].(string)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return j.jwks.Key(ctx, kid)
+	default:
+		return nil, ErrInvalidToken
+	}
+}
+
+// validateIssuerAndAudience checks iss against the trusted issuers and aud against the
+// configured audience, when they are set
+func (j *JWTManager) validateIssuerAndAudience(claims *JWTClaims) error {
+	if len(j.trustedIssuers) > 0 {
+		trusted := false
+		for _, issuer := range j.trustedIssuers {
+			if claims.Issuer == issuer {
+				trusted = true
+				break
+			}
+		}
+		if !trusted {
+			j.logger.Warn("Token from an untrusted issuer", zap.String("issuer", claims.Issuer))
+			return ErrInvalidToken
+		}
+	}
+	if j.audience != "" && !claims.VerifyAudience(j.audience, true) {
+		j.logger.Warn("Token for another audience",
+			zap.Strings("audience", claims.Audience),
+			zap.String("expected", j.audience),
+		)
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// validateTimes checks exp, nbf and iat allowing for clockSkew between the host that
+// issued the token and this one
+func (j *JWTManager) validateTimes(claims *JWTClaims, now time.Time) error {
+	if !claims.VerifyExpiresAt(now.Add(-j.clockSkew), false) {
+		j.logger.Warn("Token expired",
+			zap.Time("expires_at", claims.ExpiresAt.Time),
+			zap.Duration("clock_skew", j.clockSkew),
+		)
+		return ErrExpiredToken
+	}
+	if !claims.VerifyNotBefore(now.Add(j.clockSkew), false) {
+		j.logger.Warn("Token not valid yet",
+			zap.Time("not_before", claims.NotBefore.Time),
+			zap.Duration("clock_skew", j.clockSkew),
+		)
+		return ErrInvalidToken
+	}
+	if !claims.VerifyIssuedAt(now.Add(j.clockSkew), false) {
+		j.logger.Warn("Token issued in the future",
+			zap.Time("issued_at", claims.IssuedAt.Time),
+			zap.Duration("clock_skew", j.clockSkew),
+		)
+		return ErrInvalidToken
+	}
+	return nil
+}
+
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const testSecret = "test-secret-key-min-32-chars-for-testing"
+
+// signToken signs claims whose times are shifted by offset, as a host with a skewed clock would
+func signToken(t *testing.T, offset, ttl time.Duration) string {
+	issued := time.Now().Add(offset)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, JWTClaims{
+		Username: "admin",
+		Role:     RoleAdmin,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(issued.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(issued),
+			NotBefore: jwt.NewNumericDate(issued),
+			Subject:   "admin",
+		},
+	})
+	signed, err := token.SignedString([]byte(testSecret))
+	require.NoError(t, err)
+	return signed
+}
+
+func TestJWTManager_ToleratesClockSkew(t *testing.T) {
+	manager := NewJWTManager(testSecret, zap.NewNop()).WithClockSkew(30 * time.Second)
+
+	// Issued by a host 10s ahead: nbf/iat are in the future here
+	_, err := manager.ValidateToken(signToken(t, 10*time.Second, DefaultAccessTokenTTL))
+	assert.NoError(t, err)
+
+	// Expired 10s ago by this clock, still within the tolerance
+	_, err = manager.ValidateToken(signToken(t, -DefaultAccessTokenTTL-10*time.Second, DefaultAccessTokenTTL))
+	assert.NoError(t, err)
+}
+
+func TestJWTManager_RejectsBeyondClockSkew(t *testing.T) {
+	manager := NewJWTManager(testSecret, zap.NewNop()).WithClockSkew(30 * time.Second)
+
+	_, err := manager.ValidateToken(signToken(t, 2*time.Minute, DefaultAccessTokenTTL))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = manager.ValidateToken(signToken(t, -DefaultAccessTokenTTL-time.Minute, DefaultAccessTokenTTL))
+	assert.ErrorIs(t, err, ErrExpiredToken)
+
+	// Without tolerance a token from a host 10s ahead is not valid yet
+	_, err = NewJWTManager(testSecret, zap.NewNop()).WithClockSkew(0).
+		ValidateToken(signToken(t, 10*time.Second, DefaultAccessTokenTTL))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestJWTManager_AccessTokenTTL(t *testing.T) {
+	manager := NewJWTManager(testSecret, zap.NewNop()).WithAccessTokenTTL(time.Hour)
+	assert.Equal(t, time.Hour, manager.AccessTokenTTL())
+
+	token, err := manager.GenerateToken("admin", RoleAdmin, "")
+	require.NoError(t, err)
+	claims, err := manager.ValidateToken(token)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), claims.ExpiresAt.Time, 5*time.Second)
+
+	// Non-positive values keep the current TTL
+	assert.Equal(t, time.Hour, manager.WithAccessTokenTTL(0).AccessTokenTTL())
+}
+
+func TestJWTManager_IssuerAndAudience(t *testing.T) {
+	issuer := NewJWTManager(testSecret, zap.NewNop()).WithIssuer("query-service", nil).WithAudience("inventory")
+	token, err := issuer.GenerateToken("admin", RoleAdmin, "")
+	require.NoError(t, err)
+
+	claims, err := NewJWTManager(testSecret, zap.NewNop()).
+		WithIssuer("command-service", []string{"command-service", "query-service"}).
+		WithAudience("inventory").
+		ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "query-service", claims.Issuer)
+
+	// Issuer not in the trusted list
+	_, err = NewJWTManager(testSecret, zap.NewNop()).
+		WithIssuer("command-service", []string{"command-service"}).
+		ValidateToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Token for another audience
+	_, err = NewJWTManager(testSecret, zap.NewNop()).WithAudience("billing").ValidateToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// A configured audience requires aud in the token
+	_, err = NewJWTManager(testSecret, zap.NewNop()).WithAudience("inventory").
+		ValidateToken(signToken(t, 0, DefaultAccessTokenTTL))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRBAC_DefaultCommandPermissions(t *testing.T) {
+	rbac, err := NewRBAC("")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		role    string
+		method  string
+		allowed bool
+	}{
+		{RoleAdmin, http.MethodPost, true},
+		{RoleAdmin, http.MethodDelete, true},
+		{RoleOperator, http.MethodPut, true},
+		{RoleOperator, http.MethodDelete, false},
+		{RoleViewer, http.MethodGet, false},
+		{RoleViewer, http.MethodPost, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.role+" "+tc.method, func(t *testing.T) {
+			assert.Equal(t, tc.allowed, rbac.HasPermission(tc.role, RequiredPermission(tc.method)))
+		})
+	}
+}
+
+func TestNewRBAC_InvalidMapping(t *testing.T) {
+	_, err := NewRBAC("admin;operator=inventory:write")
+	assert.Error(t, err)
+}
@@ -0,0 +1,228 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidRefreshToken is returned for unknown, expired or already used refresh tokens
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// RefreshSession is what a refresh token stands for
+type RefreshSession struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	TenantID string `json:"tenant_id,omitempty"`
+}
+
+// TokenStore keeps refresh tokens and the list of revoked access tokens (by jti).
+// Refresh tokens are single use: ConsumeRefreshToken removes the token it returns.
+type TokenStore interface {
+	SaveRefreshToken(ctx context.Context, token string, session RefreshSession, ttl time.Duration) error
+	ConsumeRefreshToken(ctx context.Context, token string) (*RefreshSession, error)
+	DeleteRefreshToken(ctx context.Context, token string) error
+	// RevokeAccessToken keeps jti revoked for ttl (the token's remaining lifetime)
+	RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// NewRefreshToken returns a random opaque refresh token
+func NewRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken is the storage key of a refresh token, so a dump of the store cannot be replayed
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// RedisOptions configures the Redis token store
+type RedisOptions struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+// NewTokenStore creates the token store: "redis" shares tokens between instances
+// (and between the Command and Query services), "memory" is per process.
+// If Redis is not reachable it falls back to memory.
+func NewTokenStore(kind string, opts RedisOptions, logger *zap.Logger) TokenStore {
+	if kind != "redis" {
+		return NewInMemoryTokenStore()
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     opts.Addr,
+		Password: opts.Password,
+		DB:       opts.DB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		logger.Warn("Failed to connect to Redis, using in-memory token store",
+			zap.String("addr", opts.Addr),
+			zap.Error(err),
+		)
+		client.Close()
+		return NewInMemoryTokenStore()
+	}
+
+	logger.Info("Redis token store initialized", zap.String("addr", opts.Addr))
+	return &RedisTokenStore{client: client}
+}
+
+// InMemoryTokenStore is a per-process TokenStore
+type InMemoryTokenStore struct {
+	mu       sync.Mutex
+	sessions map[string]refreshEntry
+	revoked  map[string]time.Time // jti -> expiry
+}
+
+type refreshEntry struct {
+	session   RefreshSession
+	expiresAt time.Time
+}
+
+// NewInMemoryTokenStore creates an empty in-memory token store
+func NewInMemoryTokenStore() *InMemoryTokenStore {
+	return &InMemoryTokenStore{
+		sessions: make(map[string]refreshEntry),
+		revoked:  make(map[string]time.Time),
+	}
+}
+
+// SaveRefreshToken stores a refresh token
+func (s *InMemoryTokenStore) SaveRefreshToken(ctx context.Context, token string, session RefreshSession, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purge(time.Now())
+	s.sessions[hashToken(token)] = refreshEntry{session: session, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+// ConsumeRefreshToken returns and removes a refresh token
+func (s *InMemoryTokenStore) ConsumeRefreshToken(ctx context.Context, token string) (*RefreshSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := hashToken(token)
+	entry, ok := s.sessions[key]
+	delete(s.sessions, key)
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+	return &entry.session, nil
+}
+
+// DeleteRefreshToken removes a refresh token
+func (s *InMemoryTokenStore) DeleteRefreshToken(ctx context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, hashToken(token))
+	return nil
+}
+
+// RevokeAccessToken adds jti to the revocation list
+func (s *InMemoryTokenStore) RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purge(time.Now())
+	s.revoked[jti] = time.Now().Add(ttl)
+	return nil
+}
+
+// IsAccessTokenRevoked reports whether jti is in the revocation list
+func (s *InMemoryTokenStore) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expiresAt, ok := s.revoked[jti]
+	return ok && time.Now().Before(expiresAt), nil
+}
+
+// purge drops expired entries so the maps don't grow forever. Callers hold s.mu.
+func (s *InMemoryTokenStore) purge(now time.Time) {
+	for key, entry := range s.sessions {
+		if now.After(entry.expiresAt) {
+			delete(s.sessions, key)
+		}
+	}
+	for jti, expiresAt := range s.revoked {
+		if now.After(expiresAt) {
+			delete(s.revoked, jti)
+		}
+	}
+}
+
+// RedisTokenStore is a TokenStore shared through Redis; entries expire with Redis TTLs
+type RedisTokenStore struct {
+	client *redis.Client
+}
+
+// Ping checks that Redis answers (readiness probe)
+func (s *RedisTokenStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+func refreshKey(token string) string { return "auth:refresh:" + hashToken(token) }
+func revokedKey(jti string) string   { return "auth:revoked:" + jti }
+
+// SaveRefreshToken stores a refresh token
+func (s *RedisTokenStore) SaveRefreshToken(ctx context.Context, token string, session RefreshSession, ttl time.Duration) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to marshal refresh session: %w", err)
+	}
+	return s.client.Set(ctx, refreshKey(token), data, ttl).Err()
+}
+
+// ConsumeRefreshToken atomically returns and removes a refresh token
+func (s *RedisTokenStore) ConsumeRefreshToken(ctx context.Context, token string) (*RefreshSession, error) {
+	data, err := s.client.GetDel(ctx, refreshKey(token)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read refresh token: %w", err)
+	}
+
+	var session RefreshSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal refresh session: %w", err)
+	}
+	return &session, nil
+}
+
+// DeleteRefreshToken removes a refresh token
+func (s *RedisTokenStore) DeleteRefreshToken(ctx context.Context, token string) error {
+	return s.client.Del(ctx, refreshKey(token)).Err()
+}
+
+// RevokeAccessToken adds jti to the revocation list
+func (s *RedisTokenStore) RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error {
+	return s.client.Set(ctx, revokedKey(jti), "1", ttl).Err()
+}
+
+// IsAccessTokenRevoked reports whether jti is in the revocation list
+func (s *RedisTokenStore) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := s.client.Exists(ctx, revokedKey(jti)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check revocation list: %w", err)
+	}
+	return n > 0, nil
+}
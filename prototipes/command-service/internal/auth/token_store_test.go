@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryTokenStore_RefreshTokenIsSingleUse(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryTokenStore()
+
+	token, err := NewRefreshToken()
+	require.NoError(t, err)
+	require.NoError(t, store.SaveRefreshToken(ctx, token, RefreshSession{Username: "admin", Role: RoleAdmin}, time.Hour))
+
+	session, err := store.ConsumeRefreshToken(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, RefreshSession{Username: "admin", Role: RoleAdmin}, *session)
+
+	_, err = store.ConsumeRefreshToken(ctx, token)
+	assert.Equal(t, ErrInvalidRefreshToken, err)
+}
+
+func TestInMemoryTokenStore_ExpiredEntries(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryTokenStore()
+
+	require.NoError(t, store.SaveRefreshToken(ctx, "expired", RefreshSession{Username: "admin"}, -time.Second))
+	_, err := store.ConsumeRefreshToken(ctx, "expired")
+	assert.Equal(t, ErrInvalidRefreshToken, err)
+
+	require.NoError(t, store.RevokeAccessToken(ctx, "jti-1", time.Minute))
+	require.NoError(t, store.RevokeAccessToken(ctx, "jti-2", -time.Second))
+
+	revoked, err := store.IsAccessTokenRevoked(ctx, "jti-1")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = store.IsAccessTokenRevoked(ctx, "jti-2")
+	require.NoError(t, err)
+	assert.False(t, revoked, "revocations expire with the token")
+}
@@ -0,0 +1,297 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"command-service/internal/tenant"
+
+	_ "github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserExists   = errors.New("user already exists")
+)
+
+// User is an account that can log in
+type User struct {
+	Username     string
+	PasswordHash string // bcrypt
+	Role         string
+	TenantID     string // tenant.Default for users created before multi-tenancy
+	CreatedAt    time.Time
+}
+
+// UserStore looks up and creates users
+type UserStore interface {
+	FindByUsername(ctx context.Context, username string) (*User, error)
+	CreateUser(ctx context.Context, user *User) error
+}
+
+// defaultUsers are seeded into an empty store so a fresh install can log in.
+// Change their passwords (or replace the store) outside of local development.
+var defaultUsers = []struct {
+	username string
+	password string
+	role     string
+}{
+	{"admin", "admin123", RoleAdmin},
+	{"user", "user123", RoleViewer},
+	{"operator", "operator123", RoleOperator},
+}
+
+// HashPassword hashes a password with bcrypt
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches the bcrypt hash
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NewUserStore creates the user store: "file" reads users from path
+// (one "username:bcrypt_hash:role[:tenant_id]" per line), anything else uses SQLite at
+// path. Empty stores are seeded with the default users, in the default tenant.
+func NewUserStore(kind, path string, logger *zap.Logger) (UserStore, error) {
+	var store interface {
+		UserStore
+		count(ctx context.Context) (int, error)
+	}
+	var err error
+
+	if kind == "file" {
+		store, err = NewFileUserStore(path)
+	} else {
+		store, err = NewSQLiteUserStore(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	n, err := store.count(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		for _, u := range defaultUsers {
+			hash, err := HashPassword(u.password)
+			if err != nil {
+				return nil, err
+			}
+			if err := store.CreateUser(ctx, &User{Username: u.username, PasswordHash: hash, Role: u.role, TenantID: tenant.Default, CreatedAt: time.Now().UTC()}); err != nil {
+				return nil, fmt.Errorf("failed to seed user %s: %w", u.username, err)
+			}
+		}
+		logger.Warn("User store was empty, seeded default users (change their passwords)",
+			zap.String("kind", kind),
+			zap.String("path", path),
+		)
+	}
+
+	return store, nil
+}
+
+// SQLiteUserStore keeps users in a SQLite database
+type SQLiteUserStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteUserStore opens (or creates) the users database at path
+func NewSQLiteUserStore(path string) (*SQLiteUserStore, error) {
+	// path may already carry DSN parameters (e.g. an in-memory database in mock mode)
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	db, err := sql.Open("sqlite3", path+sep+"_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open user store: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS users (
+			username TEXT PRIMARY KEY,
+			password_hash TEXT NOT NULL,
+			role TEXT NOT NULL,
+			created_at TEXT NOT NULL
+		)
+	`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create users table: %w", err)
+	}
+	// Users created before multi-tenancy belong to the default tenant
+	if err := addColumnIfMissing(db, "users", "tenant_id", "TEXT NOT NULL DEFAULT '"+tenant.Default+"'"); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteUserStore{db: db}, nil
+}
+
+// FindByUsername returns the user with the given username
+func (s *SQLiteUserStore) FindByUsername(ctx context.Context, username string) (*User, error) {
+	var user User
+	var createdAt string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT username, password_hash, role, tenant_id, created_at FROM users WHERE username = ?`, username,
+	).Scan(&user.Username, &user.PasswordHash, &user.Role, &user.TenantID, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	user.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	return &user, nil
+}
+
+// CreateUser inserts a new user
+func (s *SQLiteUserStore) CreateUser(ctx context.Context, user *User) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO users (username, password_hash, role, tenant_id, created_at) VALUES (?, ?, ?, ?, ?)`,
+		user.Username, user.PasswordHash, user.Role, tenant.OrDefault(user.TenantID), user.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return ErrUserExists
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	return nil
+}
+
+// Close closes the underlying database
+func (s *SQLiteUserStore) Close() error {
+	return s.db.Close()
+}
+
+// addColumnIfMissing adds a column to a table created by an older version
+func addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	_, err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition))
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+func (s *SQLiteUserStore) count(ctx context.Context) (int, error) {
+	var n int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+	return n, nil
+}
+
+// FileUserStore keeps users in a text file, one "username:bcrypt_hash:role[:tenant_id]"
+// per line (blank lines and lines starting with # are ignored); users without tenant
+// belong to the default one. New users are appended.
+type FileUserStore struct {
+	path  string
+	mu    sync.RWMutex
+	users map[string]*User
+}
+
+// NewFileUserStore loads the users file at path; a missing file is created on first write
+func NewFileUserStore(path string) (*FileUserStore, error) {
+	store := &FileUserStore{path: path, users: make(map[string]*User)}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open users file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// bcrypt hashes contain no ':' so the line splits cleanly
+		parts := strings.Split(line, ":")
+		if len(parts) < 3 || len(parts) > 4 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid users file line %d: expected username:bcrypt_hash:role[:tenant_id]", lineNo)
+		}
+		user := &User{Username: parts[0], PasswordHash: parts[1], Role: parts[2], TenantID: tenant.Default}
+		if len(parts) == 4 {
+			if !tenant.Valid(parts[3]) {
+				return nil, fmt.Errorf("invalid users file line %d: invalid tenant_id %q", lineNo, parts[3])
+			}
+			user.TenantID = parts[3]
+		}
+		store.users[parts[0]] = user
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read users file: %w", err)
+	}
+
+	return store, nil
+}
+
+// FindByUsername returns the user with the given username
+func (s *FileUserStore) FindByUsername(ctx context.Context, username string) (*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	user, ok := s.users[username]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+// CreateUser appends a new user to the file
+func (s *FileUserStore) CreateUser(ctx context.Context, user *User) error {
+	if strings.ContainsAny(user.Username+user.Role, ":\n") {
+		return fmt.Errorf("username and role cannot contain ':' or newlines")
+	}
+	user.TenantID = tenant.OrDefault(user.TenantID)
+	if !tenant.Valid(user.TenantID) {
+		return fmt.Errorf("invalid tenant_id %q", user.TenantID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.users[user.Username]; exists {
+		return ErrUserExists
+	}
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open users file: %w", err)
+	}
+	defer file.Close()
+	if _, err := fmt.Fprintf(file, "%s:%s:%s:%s\n", user.Username, user.PasswordHash, user.Role, user.TenantID); err != nil {
+		return fmt.Errorf("failed to write users file: %w", err)
+	}
+
+	copied := *user
+	s.users[user.Username] = &copied
+	return nil
+}
+
+func (s *FileUserStore) count(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.users), nil
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNewUserStore_SeedsDefaultUsers(t *testing.T) {
+	for _, kind := range []string{"sqlite", "file"} {
+		t.Run(kind, func(t *testing.T) {
+			store, err := NewUserStore(kind, filepath.Join(t.TempDir(), "users"), zap.NewNop())
+			require.NoError(t, err)
+
+			user, err := store.FindByUsername(context.Background(), "operator")
+			require.NoError(t, err)
+			assert.Equal(t, RoleOperator, user.Role)
+			assert.NotEqual(t, "operator123", user.PasswordHash)
+			assert.True(t, CheckPassword(user.PasswordHash, "operator123"))
+			assert.False(t, CheckPassword(user.PasswordHash, "wrong"))
+
+			_, err = store.FindByUsername(context.Background(), "nobody")
+			assert.Equal(t, ErrUserNotFound, err)
+		})
+	}
+}
+
+func TestUserStore_CreateUserPersists(t *testing.T) {
+	ctx := context.Background()
+	hash, err := HashPassword("s3cret-pass")
+	require.NoError(t, err)
+
+	for _, kind := range []string{"sqlite", "file"} {
+		t.Run(kind, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "users")
+			store, err := NewUserStore(kind, path, zap.NewNop())
+			require.NoError(t, err)
+
+			user := &User{Username: "jdoe", PasswordHash: hash, Role: RoleViewer, TenantID: "brand-a", CreatedAt: time.Now().UTC()}
+			require.NoError(t, store.CreateUser(ctx, user))
+			assert.Equal(t, ErrUserExists, store.CreateUser(ctx, user))
+
+			// A new store over the same path sees the user and is not re-seeded
+			if closer, ok := store.(interface{ Close() error }); ok {
+				require.NoError(t, closer.Close())
+			}
+			reopened, err := NewUserStore(kind, path, zap.NewNop())
+			require.NoError(t, err)
+			found, err := reopened.FindByUsername(ctx, "jdoe")
+			require.NoError(t, err)
+			assert.Equal(t, RoleViewer, found.Role)
+			assert.Equal(t, "brand-a", found.TenantID)
+			assert.True(t, CheckPassword(found.PasswordHash, "s3cret-pass"))
+
+			admin, err := reopened.FindByUsername(ctx, "admin")
+			require.NoError(t, err)
+			assert.Equal(t, "default", admin.TenantID)
+		})
+	}
+}
+
+func TestNewFileUserStore_InvalidLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users")
+	require.NoError(t, os.WriteFile(path, []byte("# comment\nadmin:$2a$10$hash:admin\nbroken-line\n"), 0600))
+
+	_, err := NewFileUserStore(path)
+	assert.ErrorContains(t, err, "line 3")
+}
+
+func TestNewFileUserStore_LinesWithoutTenant(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users")
+	require.NoError(t, os.WriteFile(path, []byte("admin:$2a$10$hash:admin\njdoe:$2a$10$hash:viewer:brand-a\n"), 0600))
+
+	store, err := NewFileUserStore(path)
+	require.NoError(t, err)
+	admin, err := store.FindByUsername(context.Background(), "admin")
+	require.NoError(t, err)
+	assert.Equal(t, "default", admin.TenantID)
+	jdoe, err := store.FindByUsername(context.Background(), "jdoe")
+	require.NoError(t, err)
+	assert.Equal(t, "brand-a", jdoe.TenantID)
+}
+
+func TestNewSQLiteUserStore_AddsTenantColumn(t *testing.T) {
+	// A users table created before multi-tenancy
+	path := filepath.Join(t.TempDir(), "users.db")
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE users (username TEXT PRIMARY KEY, password_hash TEXT NOT NULL, role TEXT NOT NULL, created_at TEXT NOT NULL);
+		INSERT INTO users VALUES ('legacy', 'hash', 'viewer', '2024-01-15T12:00:00Z');`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	store, err := NewSQLiteUserStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+	user, err := store.FindByUsername(context.Background(), "legacy")
+	require.NoError(t, err)
+	assert.Equal(t, "default", user.TenantID)
+}
@@ -10,6 +10,7 @@ type CreateItemCommand struct {
 	Name        string
 	Description string
 	Quantity    int
+	UnitCost    *float64 // Optional cost of the initial stock
 }
 
 // UpdateItemCommand represents a command to update an inventory item
@@ -23,18 +24,23 @@ type UpdateItemCommand struct {
 type AdjustStockCommand struct {
 	ID       uuid.UUID
 	Quantity int
+	UnitCost *float64 // Optional cost of received stock (positive adjustments)
 }
 
 // ReserveStockCommand represents a command to reserve stock
+// StoreID is optional; when set the reservation is attributed to the store
 type ReserveStockCommand struct {
 	ID       uuid.UUID
 	Quantity int
+	StoreID  *uuid.UUID
 }
 
 // ReleaseStockCommand represents a command to release reserved stock
+// StoreID is optional; when set the store's reservation is released
 type ReleaseStockCommand struct {
 	ID       uuid.UUID
 	Quantity int
+	StoreID  *uuid.UUID
 }
 
 // DeleteItemCommand represents a command to delete an inventory item
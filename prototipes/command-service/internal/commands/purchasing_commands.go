@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateSupplierCommand represents a command to create a new supplier
+type CreateSupplierCommand struct {
+	Code  string
+	Name  string
+	Email string
+	Phone string
+}
+
+// UpdateSupplierCommand represents a command to update a supplier
+type UpdateSupplierCommand struct {
+	ID     uuid.UUID
+	Name   string
+	Email  string
+	Phone  string
+	Active bool
+}
+
+// CreatePurchaseOrderCommand represents a command to create a purchase order
+// Number is optional; it is generated when empty
+type CreatePurchaseOrderCommand struct {
+	SupplierID uuid.UUID
+	Number     string
+	ExpectedAt *time.Time
+	Notes      string
+	Lines      []PurchaseOrderLine
+}
+
+// PurchaseOrderLine is the quantity of an item ordered or received
+type PurchaseOrderLine struct {
+	ItemID   uuid.UUID
+	Quantity int
+	UnitCost *float64 // Optional cost of each unit (ordered lines only)
+}
+
+// ReceivePurchaseOrderCommand represents a command to receive stock for a purchase order
+type ReceivePurchaseOrderCommand struct {
+	ID    uuid.UUID
+	Lines []PurchaseOrderLine
+}
@@ -0,0 +1,25 @@
+package commands
+
+import (
+	"github.com/google/uuid"
+)
+
+// CreateStoreCommand represents a command to create a new store
+type CreateStoreCommand struct {
+	Code     string
+	Name     string
+	Location string
+}
+
+// UpdateStoreCommand represents a command to update a store
+type UpdateStoreCommand struct {
+	ID       uuid.UUID
+	Name     string
+	Location string
+	Active   bool
+}
+
+// DeleteStoreCommand represents a command to delete a store
+type DeleteStoreCommand struct {
+	ID uuid.UUID
+}
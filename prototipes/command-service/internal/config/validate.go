@@ -0,0 +1,522 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// MinJWTSecretLength is the shortest JWT_SECRET accepted: HS256 needs at least 256 bits
+const MinJWTSecretLength = 32
+
+// defaultJWTSecret is the placeholder JWT_SECRET of Load; it is refused in production
+const defaultJWTSecret = "your-secret-key-change-in-production-min-32-chars"
+
+// Startup check modes (STARTUP_CHECK_MODE): what a failed self-check does at startup
+const (
+	StartupCheckStrict = "strict" // refuse to start
+	StartupCheckWarn   = "warn"   // log it and start anyway
+	StartupCheckOff    = "off"    // skip the self-check
+)
+
+// ValidationError lists every problem found by Validate, so a single run shows them all
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks the values that would otherwise only fail later (on the first
+// request, the first event or a reconnect). It does not touch the network or the disk;
+// see SelfCheck.
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if err := validatePort(c.Port); err != nil {
+		add("PORT: %v", err)
+	}
+	if c.GRPCPort != "" {
+		if err := validatePort(c.GRPCPort); err != nil {
+			add("GRPC_PORT: %v", err)
+		}
+	}
+	if c.JWTSecret == "" {
+		add("JWT_SECRET is required")
+	} else if len(c.JWTSecret) < MinJWTSecretLength {
+		add("JWT_SECRET must be at least %d characters (got %d)", MinJWTSecretLength, len(c.JWTSecret))
+	} else if c.Environment == "production" && c.JWTSecret == defaultJWTSecret {
+		add("JWT_SECRET still has the default value; set a secret of your own in production")
+	}
+
+	switch c.WriteStore {
+	case "memory":
+	case "sqlite":
+		if c.WriteStorePath == "" {
+			add("WRITE_STORE_PATH is required with WRITE_STORE=sqlite")
+		}
+	default:
+		add("WRITE_STORE must be sqlite or memory (got %q)", c.WriteStore)
+	}
+	switch c.UserStore {
+	case "sqlite", "file":
+		if c.UserStorePath == "" {
+			add("USER_STORE_PATH is required")
+		}
+	default:
+		add("USER_STORE must be sqlite or file (got %q)", c.UserStore)
+	}
+	if c.APIKeyStorePath == "" {
+		add("API_KEY_STORE_PATH is required")
+	}
+	if c.AuditEnabled {
+		c.validateAudit(add)
+	}
+	c.validateImages(add)
+	if c.TokenStore != "memory" && c.TokenStore != "redis" {
+		add("TOKEN_STORE must be memory or redis (got %q)", c.TokenStore)
+	}
+	if c.RateLimitStore != "memory" && c.RateLimitStore != "redis" {
+		add("RATE_LIMIT_STORE must be memory or redis (got %q)", c.RateLimitStore)
+	}
+	if c.RefreshTokenTTLMinutes <= 0 {
+		add("REFRESH_TOKEN_TTL_MINUTES must be positive")
+	}
+	if c.JWTAccessTokenTTLSeconds <= 0 {
+		add("JWT_ACCESS_TOKEN_TTL_SECONDS must be positive")
+	}
+	if c.JWTClockSkewSeconds < 0 {
+		add("JWT_CLOCK_SKEW_SECONDS must not be negative")
+	}
+	if c.JWTIssuer == "" {
+		add("JWT_ISSUER is required")
+	} else if len(c.JWTTrustedIssuers) > 0 && !contains(c.JWTTrustedIssuers, c.JWTIssuer) {
+		// The service would reject its own tokens
+		add("JWT_TRUSTED_ISSUERS must include JWT_ISSUER (%s)", c.JWTIssuer)
+	}
+	if c.JWTJWKSURL != "" {
+		if parsed, err := url.Parse(c.JWTJWKSURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			add("JWT_JWKS_URL: not an http:// or https:// URL")
+		}
+		if c.JWTJWKSRefreshSeconds <= 0 {
+			add("JWT_JWKS_REFRESH_SECONDS must be positive")
+		}
+	}
+
+	switch c.EventBus {
+	case EventBusKafka:
+		if !c.MockDependencies {
+			for _, err := range validateBrokers(c.KafkaBrokers) {
+				add("KAFKA_BROKERS: %v", err)
+			}
+		}
+	case EventBusNATS:
+		if _, err := natsAddresses(c.NATSURL); err != nil {
+			add("NATS_URL: %v", err)
+		}
+		if c.NATSStream == "" {
+			add("NATS_STREAM is required with EVENT_BUS=nats")
+		}
+	case EventBusRabbitMQ:
+		if _, err := rabbitMQAddress(c.RabbitMQURL); err != nil {
+			add("RABBITMQ_URL: %v", err)
+		}
+		if c.RabbitMQExchange == "" {
+			add("RABBITMQ_EXCHANGE is required with EVENT_BUS=rabbitmq")
+		}
+	default:
+		add("EVENT_BUS must be kafka, nats or rabbitmq (got %q)", c.EventBus)
+	}
+	for _, topic := range [][2]string{
+		{"KAFKA_TOPIC_ITEMS", c.KafkaTopicItems},
+		{"KAFKA_TOPIC_STOCK", c.KafkaTopicStock},
+		{"KAFKA_TOPIC_STORES", c.KafkaTopicStores},
+		{"KAFKA_TOPIC_REJECTIONS", c.KafkaTopicRejections},
+	} {
+		if topic[1] == "" {
+			add("%s is required", topic[0])
+		}
+	}
+	c.validateTopics(add)
+	switch c.KafkaAcks {
+	case "0", "1", "all":
+	default:
+		add("KAFKA_ACKS must be 0, 1 or all (got %q)", c.KafkaAcks)
+	}
+	switch c.KafkaPublishMode {
+	case KafkaPublishSync:
+	case KafkaPublishAsync:
+		if c.KafkaAsyncQueueSize <= 0 {
+			add("KAFKA_ASYNC_QUEUE_SIZE must be positive")
+		}
+	default:
+		add("KAFKA_PUBLISH_MODE must be sync or async (got %q)", c.KafkaPublishMode)
+	}
+	switch c.KafkaSASLMechanism {
+	case "":
+	case "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512":
+		if c.KafkaSASLUsername == "" || c.KafkaSASLPassword == "" {
+			add("KAFKA_SASL_USERNAME and KAFKA_SASL_PASSWORD are required with KAFKA_SASL_MECHANISM=%s", c.KafkaSASLMechanism)
+		}
+	default:
+		add("KAFKA_SASL_MECHANISM must be PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512 (got %q)", c.KafkaSASLMechanism)
+	}
+	if (c.KafkaTLSCertFile == "") != (c.KafkaTLSKeyFile == "") {
+		add("KAFKA_TLS_CERT_FILE and KAFKA_TLS_KEY_FILE must be set together")
+	}
+	if !c.KafkaTLSEnabled && (c.KafkaTLSCAFile != "" || c.KafkaTLSCertFile != "") {
+		add("KAFKA_TLS_CA_FILE and KAFKA_TLS_CERT_FILE need KAFKA_TLS_ENABLED=true")
+	}
+
+	if c.MaxRequestBodyBytes <= 0 {
+		add("MAX_REQUEST_BODY_BYTES must be positive")
+	}
+	if c.HTTPReadTimeoutSeconds < 0 || c.HTTPWriteTimeoutSeconds < 0 || c.HTTPIdleTimeoutSeconds < 0 || c.HandlerTimeoutSeconds < 0 {
+		add("HTTP_READ_TIMEOUT_SECONDS, HTTP_WRITE_TIMEOUT_SECONDS, HTTP_IDLE_TIMEOUT_SECONDS and HANDLER_TIMEOUT_SECONDS cannot be negative")
+	} else if c.HTTPWriteTimeoutSeconds > 0 && (c.HandlerTimeoutSeconds == 0 || c.HandlerTimeoutSeconds >= c.HTTPWriteTimeoutSeconds) {
+		// Otherwise the connection is cut before the handler can answer 504
+		add("HANDLER_TIMEOUT_SECONDS must be shorter than HTTP_WRITE_TIMEOUT_SECONDS (got %d and %d)", c.HandlerTimeoutSeconds, c.HTTPWriteTimeoutSeconds)
+	}
+	if c.ShutdownTimeoutSeconds <= 0 {
+		add("SHUTDOWN_TIMEOUT_SECONDS must be positive")
+	}
+	if c.HealthCheckTimeoutMs <= 0 {
+		add("HEALTH_CHECK_TIMEOUT_MS must be positive")
+	}
+	if c.HealthFailureThreshold < 1 {
+		add("HEALTH_FAILURE_THRESHOLD must be at least 1")
+	}
+	if c.SLOAvailabilityTarget <= 0 || c.SLOAvailabilityTarget >= 1 {
+		add("SLO_AVAILABILITY_TARGET must be between 0 and 1 (got %v)", c.SLOAvailabilityTarget)
+	}
+	if c.SLOLatencyTarget <= 0 || c.SLOLatencyTarget >= 1 {
+		add("SLO_LATENCY_TARGET must be between 0 and 1 (got %v)", c.SLOLatencyTarget)
+	}
+	switch c.StartupCheckMode {
+	case StartupCheckStrict, StartupCheckWarn, StartupCheckOff:
+	default:
+		add("STARTUP_CHECK_MODE must be strict, warn or off (got %q)", c.StartupCheckMode)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// validateImages checks IMAGE_STORE and the settings of the store
+func (c *Config) validateImages(add func(format string, args ...interface{})) {
+	switch c.ImageStore {
+	case ImageStoreLocal:
+		if c.ImageLocalDir == "" {
+			add("IMAGE_LOCAL_DIR is required with IMAGE_STORE=local")
+		}
+	case ImageStoreS3:
+		if endpoint, err := url.Parse(c.S3Endpoint); err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+			add("S3_ENDPOINT must be an http(s) URL with IMAGE_STORE=s3 (got %q)", c.S3Endpoint)
+		}
+		if c.S3Bucket == "" || c.S3AccessKeyID == "" || c.S3SecretAccessKey == "" {
+			add("S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required with IMAGE_STORE=s3")
+		}
+	default:
+		add("IMAGE_STORE must be local or s3 (got %q)", c.ImageStore)
+	}
+	if c.ImageMaxBytes <= 0 {
+		add("IMAGE_MAX_BYTES must be positive")
+	}
+}
+
+// validateAudit checks AUDIT_SINKS and the settings of each sink
+func (c *Config) validateAudit(add func(format string, args ...interface{})) {
+	readable := false
+	seen := make(map[string]bool, len(c.AuditSinks))
+	for _, sink := range c.AuditSinks {
+		if seen[sink] {
+			add("AUDIT_SINKS lists %s more than once", sink)
+			continue
+		}
+		seen[sink] = true
+		switch sink {
+		case AuditSinkSQLite:
+			readable = true
+			if c.AuditSQLitePath == "" {
+				add("AUDIT_SQLITE_PATH is required with the sqlite audit sink")
+			}
+		case AuditSinkFile:
+			readable = true
+			if c.AuditFilePath == "" {
+				add("AUDIT_FILE_PATH is required with the file audit sink")
+			}
+		case AuditSinkKafka:
+			if c.KafkaTopicAudit == "" {
+				add("KAFKA_TOPIC_AUDIT is required with the kafka audit sink")
+			}
+			if c.EventBus != EventBusKafka && !c.MockDependencies {
+				for _, err := range validateBrokers(c.KafkaBrokers) {
+					add("KAFKA_BROKERS: %v", err)
+				}
+			}
+		default:
+			add("AUDIT_SINKS must list sqlite, file or kafka (got %q)", sink)
+		}
+	}
+	if !readable {
+		add("AUDIT_SINKS must include sqlite or file (the audit log is read back from one of them)")
+	}
+}
+
+// CheckResult is the outcome of one self-check probe
+type CheckResult struct {
+	Name string
+	Err  error
+}
+
+// SelfCheck probes what the configuration points at: that a Kafka broker (or the NATS
+// or RabbitMQ server of EVENT_BUS) accepts connections, that the Kafka TLS files can be
+// read and that the SQLite files can be created or written. Mock mode has none of them.
+func (c *Config) SelfCheck(ctx context.Context, timeout time.Duration) []CheckResult {
+	if c.MockDependencies {
+		return nil
+	}
+	results := []CheckResult{c.probeEventBus(ctx, timeout)}
+	if c.EventBus == EventBusKafka && c.KafkaTLSEnabled {
+		for _, file := range []string{c.KafkaTLSCAFile, c.KafkaTLSCertFile, c.KafkaTLSKeyFile} {
+			if file != "" {
+				_, err := os.ReadFile(file)
+				results = append(results, CheckResult{Name: "kafka tls file " + file, Err: err})
+			}
+		}
+	}
+	if c.WriteStore == "sqlite" {
+		results = append(results, CheckResult{Name: "write store " + c.WriteStorePath, Err: probeWritable(c.WriteStorePath)})
+	}
+	if c.JournalPath != "" {
+		results = append(results, CheckResult{Name: "journal " + c.JournalPath, Err: probeWritable(c.JournalPath)})
+	}
+	if c.UserStore == "sqlite" { // a "file" user store is only read
+		results = append(results, CheckResult{Name: "user store " + c.UserStorePath, Err: probeWritable(c.UserStorePath)})
+	}
+	results = append(results, CheckResult{Name: "api key store " + c.APIKeyStorePath, Err: probeWritable(c.APIKeyStorePath)})
+	if c.AuditEnabled {
+		if contains(c.AuditSinks, AuditSinkSQLite) {
+			results = append(results, CheckResult{Name: "audit database " + c.AuditSQLitePath, Err: probeWritable(c.AuditSQLitePath)})
+		}
+		if contains(c.AuditSinks, AuditSinkFile) {
+			results = append(results, CheckResult{Name: "audit file " + c.AuditFilePath, Err: probeWritable(c.AuditFilePath)})
+		}
+	}
+	return results
+}
+
+// RunStartupChecks runs SelfCheck as set by STARTUP_CHECK_MODE, logging each failed
+// probe. In strict mode it returns an error if any failed.
+func (c *Config) RunStartupChecks(ctx context.Context, logger *zap.Logger) error {
+	if c.StartupCheckMode == StartupCheckOff {
+		return nil
+	}
+	var failed []string
+	for _, result := range c.SelfCheck(ctx, time.Duration(c.HealthCheckTimeoutMs)*time.Millisecond) {
+		if result.Err == nil {
+			continue
+		}
+		failed = append(failed, result.Name)
+		if c.StartupCheckMode == StartupCheckStrict {
+			logger.Error("Startup check failed", zap.String("check", result.Name), zap.Error(result.Err))
+		} else {
+			logger.Warn("Startup check failed, starting anyway (STARTUP_CHECK_MODE=warn)", zap.String("check", result.Name), zap.Error(result.Err))
+		}
+	}
+	if len(failed) > 0 && c.StartupCheckMode == StartupCheckStrict {
+		return fmt.Errorf("startup checks failed: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// Check runs Validate and SelfCheck for --check-config, writing one line per check to
+// w. It returns false if anything failed.
+func (c *Config) Check(ctx context.Context, w io.Writer, timeout time.Duration) bool {
+	if err := c.Validate(); err != nil {
+		fmt.Fprintln(w, "FAIL configuration")
+		for _, problem := range err.(*ValidationError).Problems {
+			fmt.Fprintln(w, "     - "+problem)
+		}
+		return false
+	}
+	fmt.Fprintln(w, "OK   configuration")
+
+	ok := true
+	for _, result := range c.SelfCheck(ctx, timeout) {
+		if result.Err != nil {
+			fmt.Fprintf(w, "FAIL %s: %v\n", result.Name, result.Err)
+			ok = false
+			continue
+		}
+		fmt.Fprintf(w, "OK   %s\n", result.Name)
+	}
+	return ok
+}
+
+func validatePort(port string) error {
+	value, err := strconv.Atoi(port)
+	if err != nil || value < 1 || value > 65535 {
+		return fmt.Errorf("%q is not a port number", port)
+	}
+	return nil
+}
+
+// contains reports whether values has value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func validateBrokers(brokers []string) []error {
+	var errs []error
+	count := 0
+	for _, broker := range brokers {
+		if broker == "" {
+			continue
+		}
+		count++
+		_, port, err := net.SplitHostPort(broker)
+		if err == nil {
+			err = validatePort(port)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%q is not host:port", broker))
+		}
+	}
+	if count == 0 {
+		errs = append(errs, fmt.Errorf("at least one broker is required"))
+	}
+	return errs
+}
+
+// probeBrokers succeeds if any broker accepts a TCP connection: the client only needs
+// one to bootstrap
+func probeBrokers(ctx context.Context, brokers []string, timeout time.Duration) error {
+	dialer := net.Dialer{Timeout: timeout}
+	var failures []string
+	for _, broker := range brokers {
+		conn, err := dialer.DialContext(ctx, "tcp", broker)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		failures = append(failures, err.Error())
+	}
+	return fmt.Errorf("no broker reachable: %s", strings.Join(failures, "; "))
+}
+
+// probeEventBus checks that a server of the event bus accepts a TCP connection
+func (c *Config) probeEventBus(ctx context.Context, timeout time.Duration) CheckResult {
+	switch c.EventBus {
+	case EventBusNATS:
+		addresses, err := natsAddresses(c.NATSURL)
+		if err == nil {
+			err = probeBrokers(ctx, addresses, timeout)
+		}
+		return CheckResult{Name: "nats server", Err: err}
+	case EventBusRabbitMQ:
+		address, err := rabbitMQAddress(c.RabbitMQURL)
+		if err == nil {
+			err = probeBrokers(ctx, []string{address}, timeout)
+		}
+		return CheckResult{Name: "rabbitmq server", Err: err}
+	}
+	return CheckResult{Name: "kafka brokers", Err: probeBrokers(ctx, c.KafkaBrokers, timeout)}
+}
+
+// natsAddresses returns the host:port of every server of a NATS_URL
+// ("nats://host:4222,nats://other:4222"; the port defaults to 4222)
+func natsAddresses(natsURL string) ([]string, error) {
+	var addresses []string
+	for _, server := range strings.Split(natsURL, ",") {
+		server = strings.TrimSpace(server)
+		if server == "" {
+			continue
+		}
+		if !strings.Contains(server, "://") {
+			server = "nats://" + server
+		}
+		parsed, err := url.Parse(server)
+		if err != nil || parsed.Hostname() == "" {
+			return nil, fmt.Errorf("not a NATS server URL") // the URL may hold credentials
+		}
+		port := parsed.Port()
+		if port == "" {
+			port = "4222"
+		}
+		addresses = append(addresses, net.JoinHostPort(parsed.Hostname(), port))
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("at least one server is required")
+	}
+	return addresses, nil
+}
+
+// rabbitMQAddress returns the host:port of an amqp:// or amqps:// RABBITMQ_URL
+func rabbitMQAddress(rabbitURL string) (string, error) {
+	parsed, err := url.Parse(rabbitURL)
+	if err != nil || parsed.Hostname() == "" {
+		return "", fmt.Errorf("not an AMQP URL") // the URL may hold credentials
+	}
+	port := parsed.Port()
+	switch {
+	case parsed.Scheme == "amqp" && port == "":
+		port = "5672"
+	case parsed.Scheme == "amqps" && port == "":
+		port = "5671"
+	case parsed.Scheme != "amqp" && parsed.Scheme != "amqps":
+		return "", fmt.Errorf("not an amqp:// or amqps:// URL")
+	}
+	return net.JoinHostPort(parsed.Hostname(), port), nil
+}
+
+// probeWritable checks that path (a SQLite file, or a DSN like "file:x?mode=memory")
+// can be written: an existing file is opened for writing, otherwise a temporary file is
+// created in its directory, where SQLite also keeps its journal
+func probeWritable(path string) error {
+	if strings.HasPrefix(path, "file:") && strings.Contains(path, "mode=memory") {
+		return nil
+	}
+	path = strings.TrimPrefix(path, "file:")
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory", path)
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return fmt.Errorf("not writable: %w", err)
+		}
+		return f.Close()
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".check-config-*")
+	if err != nil {
+		return fmt.Errorf("directory not writable: %w", err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
@@ -9,6 +9,7 @@ import (
 // InventoryItem represents the aggregate root for inventory
 type InventoryItem struct {
 	ID          uuid.UUID
+	TenantID    string // Retail brand the item belongs to; the SKU is unique per tenant
 	SKU         string
 	Name        string
 	Description string
@@ -17,6 +18,16 @@ type InventoryItem struct {
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	Version     int // For optimistic locking
+	// Stock per location; nil when the item is not tracked per location. Quantity and
+	// Reserved include it, the rest is the unlocated stock (see UnlocatedQuantity).
+	Locations map[string]*LocationStock
+	// Images of the item, in the order they were added
+	Images []ItemImage
+	// Barcodes and other alternate identifiers, in the order they were added
+	Identifiers []ItemIdentifier
+	// Set while the item is soft-deleted; the repository hides deleted items from
+	// FindByID so no other operation reaches them until they are restored
+	DeletedAt *time.Time
 }
 
 // NewInventoryItem creates a new inventory item
@@ -28,8 +39,8 @@ func NewInventoryItem(sku, name, description string, initialQuantity int) *Inven
 		Description: description,
 		Quantity:    initialQuantity,
 		Reserved:    0,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
 		Version:     1,
 	}
 }
@@ -39,48 +50,118 @@ func (i *InventoryItem) AvailableQuantity() int {
 	return i.Quantity - i.Reserved
 }
 
+// UpdateDetails changes the name and description
+func (i *InventoryItem) UpdateDetails(name, description string) {
+	i.Name = name
+	i.Description = description
+	i.UpdatedAt = time.Now().UTC()
+	i.Version++
+}
+
+// PatchDetails changes the name and description that are not nil and returns the names
+// of the fields that actually changed. The version only advances when one did.
+func (i *InventoryItem) PatchDetails(name, description *string) []string {
+	var changed []string
+	if name != nil && *name != i.Name {
+		i.Name = *name
+		changed = append(changed, "name")
+	}
+	if description != nil && *description != i.Description {
+		i.Description = *description
+		changed = append(changed, "description")
+	}
+	if len(changed) > 0 {
+		i.UpdatedAt = time.Now().UTC()
+		i.Version++
+	}
+	return changed
+}
+
 // AdjustStock adjusts the stock quantity
 func (i *InventoryItem) AdjustStock(quantity int) error {
 	newQuantity := i.Quantity + quantity
-	if newQuantity < 0 {
+	// Stock held at a location is only removed through AdjustLocationStock
+	if newQuantity < 0 || i.UnlocatedQuantity()+quantity < 0 {
 		return ErrInsufficientStock
 	}
 	i.Quantity = newQuantity
-	i.UpdatedAt = time.Now()
+	i.UpdatedAt = time.Now().UTC()
 	i.Version++
 	return nil
 }
 
-// ReserveStock reserves stock
+// ReserveStock reserves stock not assigned to a location
 func (i *InventoryItem) ReserveStock(quantity int) error {
-	if i.AvailableQuantity() < quantity {
+	if i.unlocatedAvailable() < quantity {
 		return ErrInsufficientStock
 	}
 	i.Reserved += quantity
-	i.UpdatedAt = time.Now()
+	i.UpdatedAt = time.Now().UTC()
 	i.Version++
 	return nil
 }
 
-// ReleaseStock releases reserved stock
+// ReleaseStock releases reserved stock not assigned to a location
 func (i *InventoryItem) ReleaseStock(quantity int) error {
-	if i.Reserved < quantity {
+	if i.UnlocatedReserved() < quantity {
 		return ErrInvalidReleaseQuantity
 	}
 	i.Reserved -= quantity
-	i.UpdatedAt = time.Now()
+	i.UpdatedAt = time.Now().UTC()
 	i.Version++
 	return nil
 }
 
 // FulfillReservation fulfills a reservation and reduces stock
 func (i *InventoryItem) FulfillReservation(quantity int) error {
-	if i.Reserved < quantity {
+	if i.UnlocatedReserved() < quantity {
 		return ErrInvalidReleaseQuantity
 	}
 	i.Reserved -= quantity
 	i.Quantity -= quantity
-	i.UpdatedAt = time.Now()
+	i.UpdatedAt = time.Now().UTC()
+	i.Version++
+	return nil
+}
+
+// ForceSetStock overwrites the stock counters with explicit values. It is an
+// administrative correction for counters that drifted (e.g. a corrupted reserved
+// count), so it skips the delta rules of the regular operations but still keeps
+// the aggregate consistent: the totals cannot drop below the stock held at locations.
+func (i *InventoryItem) ForceSetStock(quantity, reserved int) error {
+	if quantity < 0 || reserved < 0 || reserved > quantity {
+		return ErrInvalidStockOverride
+	}
+	locatedQuantity, locatedReserved := i.locatedTotals()
+	if quantity-locatedQuantity < reserved-locatedReserved || reserved < locatedReserved {
+		return ErrInvalidStockOverride
+	}
+	i.Quantity = quantity
+	i.Reserved = reserved
+	i.UpdatedAt = time.Now().UTC()
+	i.Version++
+	return nil
+}
+
+// Delete soft-deletes the item: it keeps its stock and history and can be restored
+func (i *InventoryItem) Delete() error {
+	if i.DeletedAt != nil {
+		return ErrItemDeleted
+	}
+	now := time.Now().UTC()
+	i.DeletedAt = &now
+	i.UpdatedAt = now
+	i.Version++
+	return nil
+}
+
+// Restore undoes Delete
+func (i *InventoryItem) Restore() error {
+	if i.DeletedAt == nil {
+		return ErrItemNotDeleted
+	}
+	i.DeletedAt = nil
+	i.UpdatedAt = time.Now().UTC()
 	i.Version++
 	return nil
 }
@@ -90,6 +171,11 @@ var (
 	ErrInsufficientStock      = &DomainError{Message: "insufficient stock available"}
 	ErrInvalidReleaseQuantity = &DomainError{Message: "invalid release quantity"}
 	ErrItemNotFound           = &DomainError{Message: "item not found"}
+	ErrDuplicateSKU           = &DomainError{Message: "an item with this SKU already exists"}
+	ErrInvalidStockOverride   = &DomainError{Message: "quantity and reserved must be non-negative and reserved cannot exceed quantity"}
+	ErrVersionConflict        = &DomainError{Message: "item was modified by another request"}
+	ErrItemDeleted            = &DomainError{Message: "item is already deleted"}
+	ErrItemNotDeleted         = &DomainError{Message: "item is not deleted"}
 )
 
 // DomainError represents a domain-level error
@@ -146,3 +146,43 @@ func TestFulfillReservation_Error_InvalidQuantity(t *testing.T) {
 	assert.Equal(t, originalVersion, item.Version)
 }
 
+func TestForceSetStock_Success(t *testing.T) {
+	item := NewInventoryItem("SKU-001", "Test Item", "Description", 100)
+	item.Reserved = 250 // corrupted counter, above the quantity
+	originalVersion := item.Version
+
+	err := item.ForceSetStock(80, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 80, item.Quantity)
+	assert.Equal(t, 0, item.Reserved)
+	assert.Equal(t, originalVersion+1, item.Version)
+}
+
+func TestForceSetStock_Error_Inconsistent(t *testing.T) {
+	item := NewInventoryItem("SKU-001", "Test Item", "Description", 100)
+	originalVersion := item.Version
+
+	assert.Equal(t, ErrInvalidStockOverride, item.ForceSetStock(-1, 0))
+	assert.Equal(t, ErrInvalidStockOverride, item.ForceSetStock(10, -1))
+	assert.Equal(t, ErrInvalidStockOverride, item.ForceSetStock(10, 11))
+	assert.Equal(t, 100, item.Quantity)
+	assert.Equal(t, originalVersion, item.Version)
+}
+
+func TestDeleteAndRestore(t *testing.T) {
+	item := NewInventoryItem("SKU-001", "Test Item", "Description", 100)
+
+	assert.Equal(t, ErrItemNotDeleted, item.Restore())
+	assert.Equal(t, 1, item.Version)
+
+	assert.NoError(t, item.Delete())
+	assert.NotNil(t, item.DeletedAt)
+	assert.Equal(t, 2, item.Version)
+	assert.Equal(t, ErrItemDeleted, item.Delete())
+
+	assert.NoError(t, item.Restore())
+	assert.Nil(t, item.DeletedAt)
+	assert.Equal(t, 3, item.Version)
+	assert.Equal(t, 100, item.Quantity)
+}
@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Identifier types. The GS1 barcodes (EAN-13, EAN-8, UPC-A and GTIN-14) are looked up
+// by their GTIN-14, so a UPC-A scanned as the EAN-13 with a leading zero still matches.
+const (
+	IdentifierEAN13  = "ean13"
+	IdentifierEAN8   = "ean8"
+	IdentifierUPC    = "upc"    // UPC-A, 12 digits
+	IdentifierGTIN14 = "gtin14" // case and pallet codes
+	IdentifierAlias  = "alias"  // any other code: supplier reference, legacy SKU
+)
+
+// MaxItemIdentifiers bounds the identifiers of an item
+const MaxItemIdentifiers = 20
+
+// maxAliasLength bounds alias values, which end up in URLs and cache keys
+const maxAliasLength = 64
+
+// ItemIdentifier is an alternate identifier of an item. Code is what a lookup matches:
+// the GTIN-14 of a barcode or the alias as given. It is unique per tenant.
+type ItemIdentifier struct {
+	Type      string
+	Value     string // As sent, e.g. the 12 digits of a UPC-A
+	Code      string
+	CreatedAt time.Time
+}
+
+// Item identifier errors
+var (
+	ErrInvalidIdentifier   = &DomainError{Message: "invalid item identifier"}
+	ErrDuplicateIdentifier = &DomainError{Message: "the identifier is already assigned to an item"}
+	ErrTooManyIdentifiers  = &DomainError{Message: "the item already has the maximum number of identifiers"}
+	ErrIdentifierNotFound  = &DomainError{Message: "identifier not found"}
+)
+
+// barcodeLengths are the digits of each GS1 identifier type
+var barcodeLengths = map[string]int{
+	IdentifierEAN13:  13,
+	IdentifierEAN8:   8,
+	IdentifierUPC:    12,
+	IdentifierGTIN14: 14,
+}
+
+// NewItemIdentifier validates an identifier of the given type ("ean13", case-insensitive).
+// Barcodes must have the digits of their type and a valid check digit; aliases 1 to 64
+// letters, digits, '-', '_' or '.', and not be a valid barcode themselves.
+func NewItemIdentifier(kind, value string) (ItemIdentifier, error) {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	value = strings.TrimSpace(value)
+	identifier := ItemIdentifier{Type: kind, Value: value, CreatedAt: time.Now().UTC()}
+
+	if kind == IdentifierAlias {
+		if !validAlias(value) {
+			return ItemIdentifier{}, fmt.Errorf("%w: alias %q must have 1 to %d letters, digits, '-', '_' or '.'", ErrInvalidIdentifier, value, maxAliasLength)
+		}
+		// Otherwise a lookup of the value would match the barcode, never the alias
+		if LookupCode(value) != value {
+			return ItemIdentifier{}, fmt.Errorf("%w: alias %q is a GS1 barcode, add it with its barcode type", ErrInvalidIdentifier, value)
+		}
+		identifier.Code = value
+		return identifier, nil
+	}
+	length, ok := barcodeLengths[kind]
+	if !ok {
+		return ItemIdentifier{}, fmt.Errorf("%w: type %q is not ean13, ean8, upc, gtin14 or alias", ErrInvalidIdentifier, kind)
+	}
+	if len(value) != length || !allDigits(value) {
+		return ItemIdentifier{}, fmt.Errorf("%w: %s %q must have %d digits", ErrInvalidIdentifier, kind, value, length)
+	}
+	if !validCheckDigit(value) {
+		return ItemIdentifier{}, fmt.Errorf("%w: %s %q has an invalid check digit", ErrInvalidIdentifier, kind, value)
+	}
+	identifier.Code = strings.Repeat("0", 14-length) + value
+	return identifier, nil
+}
+
+// AddIdentifier adds an identifier to the item. Its uniqueness among the items of the
+// tenant is checked by the repository on save (ErrDuplicateIdentifier).
+func (i *InventoryItem) AddIdentifier(identifier ItemIdentifier) error {
+	for _, existing := range i.Identifiers {
+		if existing.Code == identifier.Code {
+			return ErrDuplicateIdentifier
+		}
+	}
+	if len(i.Identifiers) >= MaxItemIdentifiers {
+		return ErrTooManyIdentifiers
+	}
+	i.Identifiers = append(i.Identifiers, identifier)
+	i.touch()
+	return nil
+}
+
+// RemoveIdentifier removes the identifier with the given code and returns it
+func (i *InventoryItem) RemoveIdentifier(code string) (ItemIdentifier, error) {
+	for n, identifier := range i.Identifiers {
+		if identifier.Code == code {
+			i.Identifiers = append(i.Identifiers[:n:n], i.Identifiers[n+1:]...)
+			i.touch()
+			return identifier, nil
+		}
+	}
+	return ItemIdentifier{}, ErrIdentifierNotFound
+}
+
+// LookupCode returns the code a scanned value is looked up by: its GTIN-14 when it is a
+// valid GS1 barcode, the value itself otherwise (an alias)
+func LookupCode(value string) string {
+	value = strings.TrimSpace(value)
+	switch len(value) {
+	case 8, 12, 13, 14:
+		if allDigits(value) && validCheckDigit(value) {
+			return strings.Repeat("0", 14-len(value)) + value
+		}
+	}
+	return value
+}
+
+func validAlias(value string) bool {
+	if value == "" || len(value) > maxAliasLength {
+		return false
+	}
+	for _, r := range value {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func allDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// validCheckDigit checks the GS1 mod-10 check digit (the last digit of code): from the
+// right, the other digits are weighted 3, 1, 3, ...
+func validCheckDigit(code string) bool {
+	sum := 0
+	for n := len(code) - 2; n >= 0; n-- {
+		digit := int(code[n] - '0')
+		if (len(code)-2-n)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return (10-sum%10)%10 == int(code[len(code)-1]-'0')
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewItemIdentifier(t *testing.T) {
+	tests := []struct {
+		kind, value string
+		code        string
+	}{
+		{"ean13", "4006381333931", "04006381333931"},
+		{"EAN8", "96385074", "00000096385074"},
+		{"upc", "036000291452", "00036000291452"},
+		{"gtin14", "10036000291459", "10036000291459"},
+		{"alias", "SUP-4411.b", "SUP-4411.b"},
+	}
+	for _, tt := range tests {
+		identifier, err := NewItemIdentifier(tt.kind, tt.value)
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.code, identifier.Code)
+		assert.Equal(t, tt.value, identifier.Value)
+	}
+}
+
+func TestNewItemIdentifier_Error(t *testing.T) {
+	tests := []struct{ kind, value string }{
+		{"ean13", "4006381333932"}, // check digit
+		{"ean13", "400638133393"},  // length
+		{"upc", "03600029145A"},    // not digits
+		{"isbn", "9780306406157"},  // type
+		{"alias", "SUP 4411"},      // space
+		{"alias", ""},              // empty
+		{"alias", "4006381333931"}, // a valid EAN-13
+	}
+	for _, tt := range tests {
+		_, err := NewItemIdentifier(tt.kind, tt.value)
+		assert.ErrorIs(t, err, ErrInvalidIdentifier, tt.kind+" "+tt.value)
+	}
+}
+
+func TestLookupCode(t *testing.T) {
+	// The UPC-A, the same code as an EAN-13 and its GTIN-14 are the same item
+	assert.Equal(t, "00036000291452", LookupCode("036000291452"))
+	assert.Equal(t, "00036000291452", LookupCode("0036000291452"))
+	assert.Equal(t, "00036000291452", LookupCode("00036000291452"))
+	// Anything else is an alias
+	assert.Equal(t, "036000291453", LookupCode("036000291453"))
+	assert.Equal(t, "SUP-4411", LookupCode(" SUP-4411 "))
+}
+
+func TestItemIdentifiers(t *testing.T) {
+	item := NewInventoryItem("SKU-001", "Test Item", "Description", 10)
+	ean, err := NewItemIdentifier(IdentifierEAN13, "0036000291452")
+	require.NoError(t, err)
+	upc, err := NewItemIdentifier(IdentifierUPC, "036000291452")
+	require.NoError(t, err)
+
+	require.NoError(t, item.AddIdentifier(ean))
+	assert.Equal(t, 2, item.Version)
+	assert.Equal(t, ErrDuplicateIdentifier, item.AddIdentifier(upc), "same GTIN")
+
+	removed, err := item.RemoveIdentifier(upc.Code)
+	require.NoError(t, err)
+	assert.Equal(t, ean, removed)
+	assert.Empty(t, item.Identifiers)
+	assert.Equal(t, 3, item.Version)
+
+	_, err = item.RemoveIdentifier(upc.Code)
+	assert.Equal(t, ErrIdentifierNotFound, err)
+}
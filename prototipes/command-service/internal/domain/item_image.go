@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxItemImages bounds the images of an item
+const MaxItemImages = 20
+
+// ItemImage is an image of an item. The file is kept by the image store under Key; the
+// item only holds its metadata.
+type ItemImage struct {
+	ID          uuid.UUID
+	Key         string // Key of the file in the image store
+	URL         string // Public URL of the file
+	ContentType string
+	Size        int64
+	CreatedAt   time.Time
+}
+
+// Item image errors
+var (
+	ErrTooManyImages = &DomainError{Message: "the item already has the maximum number of images"}
+	ErrImageNotFound = &DomainError{Message: "image not found"}
+)
+
+// AddImage appends an image to the item
+func (i *InventoryItem) AddImage(image ItemImage) error {
+	if len(i.Images) >= MaxItemImages {
+		return ErrTooManyImages
+	}
+	i.Images = append(i.Images, image)
+	i.touch()
+	return nil
+}
+
+// RemoveImage removes the image with the given ID and returns it, so its file can be
+// deleted once the change is saved
+func (i *InventoryItem) RemoveImage(id uuid.UUID) (ItemImage, error) {
+	for n, image := range i.Images {
+		if image.ID == id {
+			i.Images = append(i.Images[:n:n], i.Images[n+1:]...)
+			i.touch()
+			return image, nil
+		}
+	}
+	return ItemImage{}, ErrImageNotFound
+}
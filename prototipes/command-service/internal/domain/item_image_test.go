@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemImages(t *testing.T) {
+	item := NewInventoryItem("SKU-001", "Test Item", "Description", 10)
+	first := ItemImage{ID: uuid.New(), Key: "default/item/first.png"}
+	second := ItemImage{ID: uuid.New(), Key: "default/item/second.png"}
+
+	require.NoError(t, item.AddImage(first))
+	require.NoError(t, item.AddImage(second))
+	assert.Equal(t, 3, item.Version)
+
+	removed, err := item.RemoveImage(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, first, removed)
+	assert.Equal(t, []ItemImage{second}, item.Images)
+	assert.Equal(t, 4, item.Version)
+
+	_, err = item.RemoveImage(first.ID)
+	assert.Equal(t, ErrImageNotFound, err)
+	assert.Equal(t, 4, item.Version)
+}
+
+func TestAddImage_Error_TooMany(t *testing.T) {
+	item := NewInventoryItem("SKU-001", "Test Item", "Description", 10)
+	for n := 0; n < MaxItemImages; n++ {
+		require.NoError(t, item.AddImage(ItemImage{ID: uuid.New()}))
+	}
+
+	assert.Equal(t, ErrTooManyImages, item.AddImage(ItemImage{ID: uuid.New()}))
+	assert.Len(t, item.Images, MaxItemImages)
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Relations between items. A relation is directed: it hangs from the item it is
+// defined on and points to the related item.
+const (
+	RelationSubstitute = "substitute" // the related item can be sold instead of the item
+	RelationAccessory  = "accessory"  // the related item is sold with the item
+)
+
+// Item relation errors
+var (
+	ErrInvalidRelation = &DomainError{Message: "invalid item relation"}
+	ErrSelfRelation    = &DomainError{Message: "an item cannot be related to itself"}
+)
+
+// ParseRelation parses a relation name ("substitute", case-insensitive)
+func ParseRelation(name string) (string, error) {
+	switch relation := strings.ToLower(strings.TrimSpace(name)); relation {
+	case RelationSubstitute, RelationAccessory:
+		return relation, nil
+	default:
+		return "", fmt.Errorf("%w: %q is not substitute or accessory", ErrInvalidRelation, name)
+	}
+}
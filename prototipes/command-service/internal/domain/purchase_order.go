@@ -0,0 +1,184 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Purchase order statuses. An order takes receipts while it is open or partially
+// received; it is received once every line is, or cancelled.
+const (
+	PurchaseOrderOpen              = "open"
+	PurchaseOrderPartiallyReceived = "partially_received"
+	PurchaseOrderReceived          = "received"
+	PurchaseOrderCancelled         = "cancelled"
+)
+
+// MaxPurchaseOrderLines bounds the lines of a purchase order
+const MaxPurchaseOrderLines = 200
+
+// PurchaseOrder is stock ordered from a supplier. Receiving its lines adds the stock to
+// the items.
+type PurchaseOrder struct {
+	ID         uuid.UUID
+	TenantID   string
+	Number     string // Unique per tenant
+	SupplierID uuid.UUID
+	Status     string
+	Lines      []PurchaseOrderLine
+	ExpectedAt *time.Time // Expected delivery date, nil when unknown
+	Notes      string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Version    int
+}
+
+// PurchaseOrderLine is the quantity of an item ordered, and how much of it arrived
+type PurchaseOrderLine struct {
+	ItemID   uuid.UUID
+	SKU      string
+	Ordered  int
+	Received int
+	UnitCost *float64 // Cost of each unit, recorded with the received stock; nil when unknown
+}
+
+// Remaining is the quantity of the line still to be received
+func (l PurchaseOrderLine) Remaining() int {
+	return l.Ordered - l.Received
+}
+
+// PurchaseOrderReceipt is a quantity of an item received for a purchase order
+type PurchaseOrderReceipt struct {
+	ItemID   uuid.UUID
+	Quantity int
+}
+
+// Purchase order errors
+var (
+	ErrPurchaseOrderNotFound     = &DomainError{Message: "purchase order not found"}
+	ErrDuplicatePurchaseOrder    = &DomainError{Message: "purchase order number already exists"}
+	ErrInvalidPurchaseOrder      = &DomainError{Message: "invalid purchase order"}
+	ErrPurchaseOrderClosed       = &DomainError{Message: "purchase order is already received or cancelled"}
+	ErrPurchaseOrderLineNotFound = &DomainError{Message: "item is not on the purchase order"}
+	ErrInvalidReceipt            = &DomainError{Message: "received quantity must be positive and at most the quantity still to be received"}
+)
+
+// NewPurchaseOrder creates an open purchase order. Every line must order a positive
+// quantity of a different item, at a non-negative cost when given. An empty number is
+// generated from the ID.
+func NewPurchaseOrder(number string, supplierID uuid.UUID, lines []PurchaseOrderLine, expectedAt *time.Time, notes string) (*PurchaseOrder, error) {
+	if len(lines) == 0 || len(lines) > MaxPurchaseOrderLines {
+		return nil, fmt.Errorf("%w: it must have 1 to %d lines", ErrInvalidPurchaseOrder, MaxPurchaseOrderLines)
+	}
+	seen := make(map[uuid.UUID]bool, len(lines))
+	for _, line := range lines {
+		if line.Ordered <= 0 {
+			return nil, fmt.Errorf("%w: the quantity of item %s must be positive", ErrInvalidPurchaseOrder, line.ItemID)
+		}
+		if line.UnitCost != nil && *line.UnitCost < 0 {
+			return nil, fmt.Errorf("%w: the unit cost of item %s cannot be negative", ErrInvalidPurchaseOrder, line.ItemID)
+		}
+		if seen[line.ItemID] {
+			return nil, fmt.Errorf("%w: item %s is ordered on more than one line", ErrInvalidPurchaseOrder, line.ItemID)
+		}
+		seen[line.ItemID] = true
+	}
+
+	now := time.Now().UTC()
+	order := &PurchaseOrder{
+		ID:         uuid.New(),
+		Number:     strings.TrimSpace(number),
+		SupplierID: supplierID,
+		Status:     PurchaseOrderOpen,
+		Lines:      make([]PurchaseOrderLine, len(lines)),
+		ExpectedAt: expectedAt,
+		Notes:      notes,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Version:    1,
+	}
+	for i, line := range lines {
+		line.Received = 0
+		order.Lines[i] = line
+	}
+	if order.Number == "" {
+		order.Number = "PO-" + strings.ToUpper(order.ID.String()[:8])
+	}
+	return order, nil
+}
+
+// Line returns the line of an item
+func (o *PurchaseOrder) Line(itemID uuid.UUID) (PurchaseOrderLine, bool) {
+	for _, line := range o.Lines {
+		if line.ItemID == itemID {
+			return line, true
+		}
+	}
+	return PurchaseOrderLine{}, false
+}
+
+// Open reports whether the order still takes receipts
+func (o *PurchaseOrder) Open() bool {
+	return o.Status == PurchaseOrderOpen || o.Status == PurchaseOrderPartiallyReceived
+}
+
+// CheckReceipt checks that a quantity of an item can be received for the order
+func (o *PurchaseOrder) CheckReceipt(receipt PurchaseOrderReceipt) error {
+	if !o.Open() {
+		return ErrPurchaseOrderClosed
+	}
+	line, ok := o.Line(receipt.ItemID)
+	if !ok {
+		return ErrPurchaseOrderLineNotFound
+	}
+	if receipt.Quantity <= 0 || receipt.Quantity > line.Remaining() {
+		return ErrInvalidReceipt
+	}
+	return nil
+}
+
+// Receive records the receipts on the lines and moves the status. If any receipt does
+// not fit (see CheckReceipt, summing the receipts of the same item) nothing is recorded.
+func (o *PurchaseOrder) Receive(receipts []PurchaseOrderReceipt) error {
+	received := make(map[uuid.UUID]int, len(receipts))
+	for _, receipt := range receipts {
+		if receipt.Quantity <= 0 {
+			return ErrInvalidReceipt
+		}
+		if err := o.CheckReceipt(PurchaseOrderReceipt{ItemID: receipt.ItemID, Quantity: received[receipt.ItemID] + receipt.Quantity}); err != nil {
+			return err
+		}
+		received[receipt.ItemID] += receipt.Quantity
+	}
+	for i := range o.Lines {
+		o.Lines[i].Received += received[o.Lines[i].ItemID]
+	}
+
+	o.Status = PurchaseOrderReceived
+	for _, line := range o.Lines {
+		if line.Remaining() > 0 {
+			o.Status = PurchaseOrderPartiallyReceived
+			break
+		}
+	}
+	o.touch()
+	return nil
+}
+
+// Cancel closes an order that is not fully received; what was received stays in stock
+func (o *PurchaseOrder) Cancel() error {
+	if !o.Open() {
+		return ErrPurchaseOrderClosed
+	}
+	o.Status = PurchaseOrderCancelled
+	o.touch()
+	return nil
+}
+
+func (o *PurchaseOrder) touch() {
+	o.UpdatedAt = time.Now().UTC()
+	o.Version++
+}
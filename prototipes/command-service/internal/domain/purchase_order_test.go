@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPurchaseOrder(t *testing.T) {
+	itemA, itemB := uuid.New(), uuid.New()
+	cost := 2.5
+	order, err := NewPurchaseOrder("", uuid.New(), []PurchaseOrderLine{
+		{ItemID: itemA, SKU: "SKU-A", Ordered: 10, UnitCost: &cost},
+		{ItemID: itemB, SKU: "SKU-B", Ordered: 5, Received: 3},
+	}, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, PurchaseOrderOpen, order.Status)
+	assert.Equal(t, 1, order.Version)
+	assert.Regexp(t, `^PO-[0-9A-F]{8}$`, order.Number)
+	assert.Equal(t, 0, order.Lines[1].Received)
+
+	negative := -1.0
+	for _, lines := range [][]PurchaseOrderLine{
+		nil,
+		{{ItemID: itemA, Ordered: 0}},
+		{{ItemID: itemA, Ordered: 1}, {ItemID: itemA, Ordered: 2}},
+		{{ItemID: itemA, Ordered: 1, UnitCost: &cost}, {ItemID: itemB, Ordered: 1, UnitCost: &negative}},
+	} {
+		_, err := NewPurchaseOrder("PO-1", uuid.New(), lines, nil, "")
+		assert.ErrorIs(t, err, ErrInvalidPurchaseOrder)
+	}
+}
+
+func TestPurchaseOrder_Receive(t *testing.T) {
+	itemA, itemB := uuid.New(), uuid.New()
+	order, err := NewPurchaseOrder("PO-1", uuid.New(), []PurchaseOrderLine{
+		{ItemID: itemA, Ordered: 10},
+		{ItemID: itemB, Ordered: 5},
+	}, nil, "")
+	require.NoError(t, err)
+
+	// Partial receipt
+	require.NoError(t, order.Receive([]PurchaseOrderReceipt{{ItemID: itemA, Quantity: 4}}))
+	assert.Equal(t, PurchaseOrderPartiallyReceived, order.Status)
+	assert.Equal(t, 4, order.Lines[0].Received)
+	assert.Equal(t, 2, order.Version)
+
+	// Nothing is recorded when one receipt does not fit, also summing the same item
+	assert.Equal(t, ErrInvalidReceipt, order.Receive([]PurchaseOrderReceipt{{ItemID: itemB, Quantity: 5}, {ItemID: itemA, Quantity: 7}}))
+	assert.Equal(t, ErrInvalidReceipt, order.Receive([]PurchaseOrderReceipt{{ItemID: itemA, Quantity: 4}, {ItemID: itemA, Quantity: 3}}))
+	assert.Equal(t, ErrPurchaseOrderLineNotFound, order.Receive([]PurchaseOrderReceipt{{ItemID: uuid.New(), Quantity: 1}}))
+	assert.Equal(t, 0, order.Lines[1].Received)
+
+	// The rest
+	require.NoError(t, order.Receive([]PurchaseOrderReceipt{{ItemID: itemA, Quantity: 6}, {ItemID: itemB, Quantity: 5}}))
+	assert.Equal(t, PurchaseOrderReceived, order.Status)
+	assert.Equal(t, ErrPurchaseOrderClosed, order.CheckReceipt(PurchaseOrderReceipt{ItemID: itemA, Quantity: 1}))
+	assert.Equal(t, ErrPurchaseOrderClosed, order.Cancel())
+}
+
+func TestPurchaseOrder_Cancel(t *testing.T) {
+	itemA := uuid.New()
+	order, err := NewPurchaseOrder("PO-1", uuid.New(), []PurchaseOrderLine{{ItemID: itemA, Ordered: 10}}, nil, "")
+	require.NoError(t, err)
+	require.NoError(t, order.Receive([]PurchaseOrderReceipt{{ItemID: itemA, Quantity: 2}}))
+
+	require.NoError(t, order.Cancel())
+	assert.Equal(t, PurchaseOrderCancelled, order.Status)
+	assert.Equal(t, 2, order.Lines[0].Received)
+	assert.Equal(t, ErrPurchaseOrderClosed, order.Receive([]PurchaseOrderReceipt{{ItemID: itemA, Quantity: 1}}))
+}
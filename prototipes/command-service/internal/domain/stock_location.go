@@ -0,0 +1,164 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// LocationStock is the part of an item's stock kept at one location (a warehouse or a
+// store backroom). The item's Quantity and Reserved stay the totals across locations.
+type LocationStock struct {
+	Quantity int
+	Reserved int
+}
+
+// Available returns the stock of the location that can still be reserved
+func (l *LocationStock) Available() int {
+	return l.Quantity - l.Reserved
+}
+
+// Stock location errors
+var (
+	ErrInvalidLocation  = &DomainError{Message: "invalid stock location"}
+	ErrLocationNotFound = &DomainError{Message: "the item has no stock at this location"}
+)
+
+// maxLocationLength bounds location codes, which end up in URLs and event payloads
+const maxLocationLength = 64
+
+// ParseLocation validates a location code ("WH-MAD-01"): 1 to 64 letters, digits,
+// '-', '_' or '.'
+func ParseLocation(code string) (string, error) {
+	if code == "" || len(code) > maxLocationLength {
+		return "", fmt.Errorf("%w: %q must have 1 to %d characters", ErrInvalidLocation, code, maxLocationLength)
+	}
+	for _, r := range code {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return "", fmt.Errorf("%w: %q may only contain letters, digits, '-', '_' and '.'", ErrInvalidLocation, code)
+		}
+	}
+	return code, nil
+}
+
+// LocationCodes returns the locations the item has stock rows for, sorted
+func (i *InventoryItem) LocationCodes() []string {
+	codes := make([]string, 0, len(i.Locations))
+	for code := range i.Locations {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Location returns the stock of the item at location, or nil if it has none
+func (i *InventoryItem) Location(location string) *LocationStock {
+	return i.Locations[location]
+}
+
+// locatedTotals returns the quantity and the reserved stock assigned to locations
+func (i *InventoryItem) locatedTotals() (quantity, reserved int) {
+	for _, stock := range i.Locations {
+		quantity += stock.Quantity
+		reserved += stock.Reserved
+	}
+	return quantity, reserved
+}
+
+// UnlocatedQuantity returns the stock not assigned to any location. Items created
+// before stock was tracked per location keep all their stock here; the item-level
+// operations (AdjustStock, ReserveStock, ...) work on this pool.
+func (i *InventoryItem) UnlocatedQuantity() int {
+	quantity, _ := i.locatedTotals()
+	return i.Quantity - quantity
+}
+
+// UnlocatedReserved returns the reserved stock not assigned to any location
+func (i *InventoryItem) UnlocatedReserved() int {
+	_, reserved := i.locatedTotals()
+	return i.Reserved - reserved
+}
+
+// unlocatedAvailable returns the unlocated stock that can still be reserved
+func (i *InventoryItem) unlocatedAvailable() int {
+	return i.UnlocatedQuantity() - i.UnlocatedReserved()
+}
+
+// AdjustLocationStock adds quantity (negative to remove) to the stock at location,
+// creating the location on its first receipt. The item totals move by the same amount.
+func (i *InventoryItem) AdjustLocationStock(location string, quantity int) error {
+	stock := i.Locations[location]
+	if stock == nil {
+		if quantity < 0 {
+			return ErrInsufficientStock
+		}
+		stock = &LocationStock{}
+	}
+	if stock.Quantity+quantity < stock.Reserved {
+		return ErrInsufficientStock
+	}
+
+	if i.Locations == nil {
+		i.Locations = make(map[string]*LocationStock)
+	}
+	i.Locations[location] = stock
+	stock.Quantity += quantity
+	i.Quantity += quantity
+	i.touch()
+	return nil
+}
+
+// ReserveAtLocation reserves stock available at location
+func (i *InventoryItem) ReserveAtLocation(location string, quantity int) error {
+	stock := i.Locations[location]
+	if stock == nil {
+		return ErrLocationNotFound
+	}
+	if stock.Available() < quantity {
+		return ErrInsufficientStock
+	}
+	stock.Reserved += quantity
+	i.Reserved += quantity
+	i.touch()
+	return nil
+}
+
+// ReleaseAtLocation releases stock reserved at location
+func (i *InventoryItem) ReleaseAtLocation(location string, quantity int) error {
+	stock := i.Locations[location]
+	if stock == nil {
+		return ErrLocationNotFound
+	}
+	if stock.Reserved < quantity {
+		return ErrInvalidReleaseQuantity
+	}
+	stock.Reserved -= quantity
+	i.Reserved -= quantity
+	i.touch()
+	return nil
+}
+
+// FulfillAtLocation fulfills a reservation at location: the stock leaves the location
+func (i *InventoryItem) FulfillAtLocation(location string, quantity int) error {
+	stock := i.Locations[location]
+	if stock == nil {
+		return ErrLocationNotFound
+	}
+	if stock.Reserved < quantity {
+		return ErrInvalidReleaseQuantity
+	}
+	stock.Reserved -= quantity
+	stock.Quantity -= quantity
+	i.Reserved -= quantity
+	i.Quantity -= quantity
+	i.touch()
+	return nil
+}
+
+// touch records a change to the aggregate
+func (i *InventoryItem) touch() {
+	i.UpdatedAt = time.Now().UTC()
+	i.Version++
+}
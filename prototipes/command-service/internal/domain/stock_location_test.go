@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLocation(t *testing.T) {
+	for _, code := range []string{"WH-MAD-01", "store_12", "a", "bcn.backroom", strings.Repeat("x", 64)} {
+		parsed, err := ParseLocation(code)
+		assert.NoError(t, err, code)
+		assert.Equal(t, code, parsed)
+	}
+	for _, code := range []string{"", "WH MAD", "wh/01", "almacén", strings.Repeat("x", 65)} {
+		_, err := ParseLocation(code)
+		assert.True(t, errors.Is(err, ErrInvalidLocation), code)
+	}
+}
+
+func TestAdjustLocationStock(t *testing.T) {
+	item := NewInventoryItem("SKU-001", "Test Item", "Description", 10)
+	originalVersion := item.Version
+
+	require.NoError(t, item.AdjustLocationStock("WH-1", 30))
+	require.NoError(t, item.AdjustLocationStock("WH-2", 5))
+	require.NoError(t, item.AdjustLocationStock("WH-1", -10))
+
+	assert.Equal(t, 35, item.Quantity)
+	assert.Equal(t, 20, item.Location("WH-1").Quantity)
+	assert.Equal(t, 5, item.Location("WH-2").Quantity)
+	assert.Equal(t, 10, item.UnlocatedQuantity())
+	assert.Equal(t, []string{"WH-1", "WH-2"}, item.LocationCodes())
+	assert.Equal(t, originalVersion+3, item.Version)
+}
+
+func TestAdjustLocationStock_Error_BelowReserved(t *testing.T) {
+	item := NewInventoryItem("SKU-001", "Test Item", "Description", 0)
+	require.NoError(t, item.AdjustLocationStock("WH-1", 10))
+	require.NoError(t, item.ReserveAtLocation("WH-1", 8))
+	version := item.Version
+
+	assert.Equal(t, ErrInsufficientStock, item.AdjustLocationStock("WH-1", -3))
+	assert.Equal(t, ErrInsufficientStock, item.AdjustLocationStock("WH-9", -1))
+	assert.Equal(t, 10, item.Quantity)
+	assert.Nil(t, item.Location("WH-9"))
+	assert.Equal(t, version, item.Version)
+}
+
+func TestReserveReleaseFulfillAtLocation(t *testing.T) {
+	item := NewInventoryItem("SKU-001", "Test Item", "Description", 0)
+	require.NoError(t, item.AdjustLocationStock("WH-1", 10))
+	require.NoError(t, item.AdjustLocationStock("WH-2", 4))
+
+	assert.Equal(t, ErrInsufficientStock, item.ReserveAtLocation("WH-2", 5))
+	assert.Equal(t, ErrLocationNotFound, item.ReserveAtLocation("WH-3", 1))
+	require.NoError(t, item.ReserveAtLocation("WH-1", 6))
+	require.NoError(t, item.ReleaseAtLocation("WH-1", 2))
+	assert.Equal(t, ErrInvalidReleaseQuantity, item.ReleaseAtLocation("WH-2", 1))
+	require.NoError(t, item.FulfillAtLocation("WH-1", 3))
+
+	assert.Equal(t, 11, item.Quantity)
+	assert.Equal(t, 1, item.Reserved)
+	assert.Equal(t, LocationStock{Quantity: 7, Reserved: 1}, *item.Location("WH-1"))
+	assert.Equal(t, LocationStock{Quantity: 4, Reserved: 0}, *item.Location("WH-2"))
+}
+
+func TestItemOperations_UseUnlocatedStock(t *testing.T) {
+	item := NewInventoryItem("SKU-001", "Test Item", "Description", 5)
+	require.NoError(t, item.AdjustLocationStock("WH-1", 20))
+	require.NoError(t, item.ReserveAtLocation("WH-1", 4))
+
+	// Only the 5 unlocated units can be reserved or removed at item level
+	assert.Equal(t, ErrInsufficientStock, item.ReserveStock(6))
+	assert.Equal(t, ErrInsufficientStock, item.AdjustStock(-6))
+	require.NoError(t, item.ReserveStock(5))
+	assert.Equal(t, ErrInvalidReleaseQuantity, item.ReleaseStock(6))
+	assert.Equal(t, ErrInvalidReleaseQuantity, item.FulfillReservation(6))
+	require.NoError(t, item.FulfillReservation(5))
+
+	assert.Equal(t, 20, item.Quantity)
+	assert.Equal(t, 4, item.Reserved)
+	assert.Equal(t, 0, item.UnlocatedQuantity())
+	assert.Equal(t, 0, item.UnlocatedReserved())
+}
+
+func TestForceSetStock_Error_BelowLocatedStock(t *testing.T) {
+	item := NewInventoryItem("SKU-001", "Test Item", "Description", 5)
+	require.NoError(t, item.AdjustLocationStock("WH-1", 20))
+	require.NoError(t, item.ReserveAtLocation("WH-1", 4))
+
+	assert.Equal(t, ErrInvalidStockOverride, item.ForceSetStock(19, 4))
+	assert.Equal(t, ErrInvalidStockOverride, item.ForceSetStock(30, 3))
+	assert.NoError(t, item.ForceSetStock(20, 4))
+}
@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Store represents a physical store that reserves inventory
+type Store struct {
+	ID        uuid.UUID
+	TenantID  string // Retail brand of the store; the code is unique per tenant
+	Code      string
+	Name      string
+	Location  string
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Version   int // For optimistic locking
+
+	// Reservations tracks the quantity currently reserved by the store per item
+	Reservations map[uuid.UUID]int
+
+	// Calendar holds the opening hours and holidays; nil when none were set
+	Calendar *StoreCalendar
+}
+
+// NewStore creates a new active store
+func NewStore(code, name, location string) *Store {
+	now := time.Now().UTC()
+	return &Store{
+		ID:           uuid.New(),
+		Code:         code,
+		Name:         name,
+		Location:     location,
+		Active:       true,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		Version:      1,
+		Reservations: make(map[uuid.UUID]int),
+	}
+}
+
+// Update updates the store information
+func (s *Store) Update(name, location string, active bool) {
+	s.Name = name
+	s.Location = location
+	s.Active = active
+	s.UpdatedAt = time.Now().UTC()
+	s.Version++
+}
+
+// SetCalendar replaces the store's calendar; nil removes it
+func (s *Store) SetCalendar(calendar *StoreCalendar) {
+	s.Calendar = calendar
+	s.UpdatedAt = time.Now().UTC()
+	s.Version++
+}
+
+// ReservedQuantity returns the quantity of an item reserved by the store
+func (s *Store) ReservedQuantity(itemID uuid.UUID) int {
+	return s.Reservations[itemID]
+}
+
+// HasActiveReservations reports whether the store still holds reserved stock
+func (s *Store) HasActiveReservations() bool {
+	for _, quantity := range s.Reservations {
+		if quantity > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Reserve attributes a reservation of an item to the store
+func (s *Store) Reserve(itemID uuid.UUID, quantity int) error {
+	if !s.Active {
+		return ErrStoreInactive
+	}
+	if s.Reservations == nil {
+		s.Reservations = make(map[uuid.UUID]int)
+	}
+	s.Reservations[itemID] += quantity
+	s.UpdatedAt = time.Now().UTC()
+	s.Version++
+	return nil
+}
+
+// Release releases stock previously reserved by the store
+func (s *Store) Release(itemID uuid.UUID, quantity int) error {
+	if s.Reservations[itemID] < quantity {
+		return ErrInvalidReleaseQuantity
+	}
+	s.Reservations[itemID] -= quantity
+	if s.Reservations[itemID] == 0 {
+		delete(s.Reservations, itemID)
+	}
+	s.UpdatedAt = time.Now().UTC()
+	s.Version++
+	return nil
+}
+
+// Store domain errors
+var (
+	ErrStoreNotFound        = &DomainError{Message: "store not found"}
+	ErrStoreInactive        = &DomainError{Message: "store is not active"}
+	ErrStoreHasReservations = &DomainError{Message: "store has active reservations"}
+	ErrDuplicateStoreCode   = &DomainError{Message: "store code already exists"}
+)
@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OpeningHours is one opening period of a store on a day of the week, in the store's
+// local time. A day may have several periods (e.g. closed at lunch).
+type OpeningHours struct {
+	Day    time.Weekday
+	Opens  string // "HH:MM"
+	Closes string // "HH:MM", after Opens; "24:00" closes at midnight
+}
+
+// Holiday is a date on which the store is closed all day
+type Holiday struct {
+	Date string // "YYYY-MM-DD", in the store's local time
+	Name string
+}
+
+// StoreCalendar holds the opening hours and holidays of a store
+type StoreCalendar struct {
+	Timezone string // IANA name, e.g. "America/Bogota"
+	Hours    []OpeningHours
+	Holidays []Holiday
+}
+
+// Store calendar errors
+var (
+	ErrInvalidCalendar = &DomainError{Message: "invalid store calendar"}
+	ErrStoreClosed     = &DomainError{Message: "store is closed"}
+)
+
+// weekdays maps the day names accepted by the API to time.Weekday
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// ParseWeekday parses a day name ("monday", case-insensitive)
+func ParseWeekday(name string) (time.Weekday, error) {
+	day, ok := weekdays[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown day %q", ErrInvalidCalendar, name)
+	}
+	return day, nil
+}
+
+// Validate checks the timezone, the periods (HH:MM, closing after opening, no overlap on
+// the same day) and the holiday dates
+func (c *StoreCalendar) Validate() error {
+	if c.Timezone == "" {
+		return fmt.Errorf("%w: timezone is required", ErrInvalidCalendar)
+	}
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidCalendar, c.Timezone)
+	}
+	for i, hours := range c.Hours {
+		opens, err := parseClock(hours.Opens)
+		if err != nil {
+			return err
+		}
+		closes, err := parseClock(hours.Closes)
+		if err != nil {
+			return err
+		}
+		if closes <= opens {
+			return fmt.Errorf("%w: %s closes (%s) before it opens (%s)", ErrInvalidCalendar, hours.Day, hours.Closes, hours.Opens)
+		}
+		for _, other := range c.Hours[:i] {
+			if other.Day != hours.Day {
+				continue
+			}
+			otherOpens, _ := parseClock(other.Opens)
+			otherCloses, _ := parseClock(other.Closes)
+			if opens < otherCloses && otherOpens < closes {
+				return fmt.Errorf("%w: overlapping hours on %s", ErrInvalidCalendar, hours.Day)
+			}
+		}
+	}
+	for _, holiday := range c.Holidays {
+		if _, err := time.Parse("2006-01-02", holiday.Date); err != nil {
+			return fmt.Errorf("%w: holiday date %q is not YYYY-MM-DD", ErrInvalidCalendar, holiday.Date)
+		}
+	}
+	return nil
+}
+
+// IsOpen reports whether the store is open at t. A store without a calendar is always
+// open. The calendar is assumed valid.
+func (c *StoreCalendar) IsOpen(t time.Time) bool {
+	if c == nil {
+		return true
+	}
+	location, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return true
+	}
+	local := t.In(location)
+
+	date := local.Format("2006-01-02")
+	for _, holiday := range c.Holidays {
+		if holiday.Date == date {
+			return false
+		}
+	}
+
+	minute := local.Hour()*60 + local.Minute()
+	for _, hours := range c.Hours {
+		if hours.Day != local.Weekday() {
+			continue
+		}
+		opens, _ := parseClock(hours.Opens)
+		closes, _ := parseClock(hours.Closes)
+		if minute >= opens && minute < closes {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock returns the minutes since midnight of an "HH:MM" time ("24:00" included)
+func parseClock(clock string) (int, error) {
+	var hour, minute int
+	if len(clock) != 5 || clock[2] != ':' {
+		return 0, fmt.Errorf("%w: time %q is not HH:MM", ErrInvalidCalendar, clock)
+	}
+	if _, err := fmt.Sscanf(clock, "%02d:%02d", &hour, &minute); err != nil {
+		return 0, fmt.Errorf("%w: time %q is not HH:MM", ErrInvalidCalendar, clock)
+	}
+	if hour < 0 || minute < 0 || hour > 24 || minute > 59 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("%w: time %q is out of range", ErrInvalidCalendar, clock)
+	}
+	return hour*60 + minute, nil
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreCalendar_Validate(t *testing.T) {
+	valid := StoreCalendar{
+		Timezone: "America/Bogota",
+		Hours: []OpeningHours{
+			{Day: time.Monday, Opens: "09:00", Closes: "13:00"},
+			{Day: time.Monday, Opens: "14:00", Closes: "19:00"},
+			{Day: time.Saturday, Opens: "10:00", Closes: "24:00"},
+		},
+		Holidays: []Holiday{{Date: "2024-12-25", Name: "Navidad"}},
+	}
+	assert.NoError(t, valid.Validate())
+
+	for name, calendar := range map[string]StoreCalendar{
+		"missing timezone": {},
+		"unknown timezone": {Timezone: "Mars/Olympus"},
+		"bad clock":        {Timezone: "UTC", Hours: []OpeningHours{{Day: time.Monday, Opens: "9:00", Closes: "18:00"}}},
+		"out of range":     {Timezone: "UTC", Hours: []OpeningHours{{Day: time.Monday, Opens: "09:00", Closes: "24:30"}}},
+		"closes first":     {Timezone: "UTC", Hours: []OpeningHours{{Day: time.Monday, Opens: "18:00", Closes: "09:00"}}},
+		"overlap": {Timezone: "UTC", Hours: []OpeningHours{
+			{Day: time.Monday, Opens: "09:00", Closes: "13:00"},
+			{Day: time.Monday, Opens: "12:00", Closes: "18:00"},
+		}},
+		"bad holiday": {Timezone: "UTC", Holidays: []Holiday{{Date: "25/12/2024"}}},
+	} {
+		err := calendar.Validate()
+		assert.True(t, errors.Is(err, ErrInvalidCalendar), "%s: %v", name, err)
+	}
+}
+
+func TestStoreCalendar_IsOpen(t *testing.T) {
+	calendar := &StoreCalendar{
+		Timezone: "America/Bogota", // UTC-5, no DST
+		Hours: []OpeningHours{
+			{Day: time.Monday, Opens: "09:00", Closes: "13:00"},
+			{Day: time.Monday, Opens: "14:00", Closes: "19:00"},
+		},
+		Holidays: []Holiday{{Date: "2024-12-23"}},
+	}
+
+	// Monday 2024-12-16 in Bogota
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 12, 16, hour+5, minute, 0, 0, time.UTC)
+	}
+	assert.False(t, calendar.IsOpen(at(8, 59)))
+	assert.True(t, calendar.IsOpen(at(9, 0)))
+	assert.False(t, calendar.IsOpen(at(13, 30)), "closed at lunch")
+	assert.True(t, calendar.IsOpen(at(18, 59)))
+	assert.False(t, calendar.IsOpen(at(19, 0)))
+	assert.False(t, calendar.IsOpen(at(12, 0).AddDate(0, 0, 1)), "no hours on Tuesday")
+	assert.False(t, calendar.IsOpen(at(12, 0).AddDate(0, 0, 7)), "holiday")
+
+	var none *StoreCalendar
+	assert.True(t, none.IsOpen(time.Now()), "a store without calendar is always open")
+}
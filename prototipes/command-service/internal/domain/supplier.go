@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Supplier is a vendor the purchase orders are placed with
+type Supplier struct {
+	ID        uuid.UUID
+	TenantID  string // The code is unique per tenant
+	Code      string
+	Name      string
+	Email     string
+	Phone     string
+	Active    bool // Inactive suppliers take no new purchase orders
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Version   int
+}
+
+// NewSupplier creates a new active supplier
+func NewSupplier(code, name, email, phone string) *Supplier {
+	now := time.Now().UTC()
+	return &Supplier{
+		ID:        uuid.New(),
+		Code:      code,
+		Name:      name,
+		Email:     email,
+		Phone:     phone,
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Version:   1,
+	}
+}
+
+// Update updates the supplier information
+func (s *Supplier) Update(name, email, phone string, active bool) {
+	s.Name = name
+	s.Email = email
+	s.Phone = phone
+	s.Active = active
+	s.UpdatedAt = time.Now().UTC()
+	s.Version++
+}
+
+// Supplier domain errors
+var (
+	ErrSupplierNotFound      = &DomainError{Message: "supplier not found"}
+	ErrSupplierInactive      = &DomainError{Message: "supplier is not active"}
+	ErrSupplierHasOpenOrders = &DomainError{Message: "supplier has open purchase orders"}
+	ErrDuplicateSupplierCode = &DomainError{Message: "supplier code already exists"}
+)
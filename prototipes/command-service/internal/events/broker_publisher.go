@@ -0,0 +1,69 @@
+package events
+
+import (
+	"context"
+	"fmt"
+
+	"command-service/internal/config"
+
+	"testsupport"
+
+	"go.uber.org/zap"
+)
+
+// BrokerEventPublisher publishes events to an in-memory broker (MOCK_DEPENDENCIES mode).
+// Messages carry the same topic, headers, key and (encrypted) payload as on Kafka.
+type BrokerEventPublisher struct {
+	messages *KafkaEventPublisher // builds the messages; its producer is never used
+	broker   *testsupport.Broker
+	logger   *zap.Logger
+}
+
+// NewBrokerEventPublisher creates a publisher backed by broker
+func NewBrokerEventPublisher(cfg *config.Config, broker *testsupport.Broker, logger *zap.Logger) (*BrokerEventPublisher, error) {
+	payloadCipher, err := NewPayloadCipher(cfg.EventEncryptionKeys, cfg.EventEncryptionActiveKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event encryption config: %w", err)
+	}
+
+	return &BrokerEventPublisher{
+		messages: &KafkaEventPublisher{cipher: payloadCipher, logger: logger, config: cfg},
+		broker:   broker,
+		logger:   logger,
+	}, nil
+}
+
+// Publish appends the event to its topic in the broker
+func (p *BrokerEventPublisher) Publish(ctx context.Context, event interface{}) (err error) {
+	ctx, span := startPublishSpan(ctx, p.messages.getEventType(event))
+	defer func() { endSpan(span, err) }()
+
+	message, err := p.messages.buildMessage(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	msg := testsupport.Message{
+		Topic:   message.Topic,
+		Headers: make(map[string]string, len(message.Headers)),
+	}
+	if msg.Value, err = message.Value.Encode(); err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	if message.Key != nil {
+		if msg.Key, err = message.Key.Encode(); err != nil {
+			return fmt.Errorf("failed to encode partition key: %w", err)
+		}
+	}
+	for _, header := range message.Headers {
+		msg.Headers[string(header.Key)] = string(header.Value)
+	}
+
+	published := p.broker.Publish(msg)
+	p.logger.Info("Event published to in-memory broker",
+		zap.String("topic", published.Topic),
+		zap.Int64("offset", published.Offset),
+		zap.String("event-type", msg.Headers["event-type"]),
+	)
+	return nil
+}
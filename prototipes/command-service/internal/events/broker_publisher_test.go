@@ -0,0 +1,60 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"command-service/internal/config"
+
+	"testsupport"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestBrokerEventPublisher_Publish(t *testing.T) {
+	cfg := &config.Config{
+		KafkaTopicItems: "inventory.items",
+		KafkaTopicStock: "inventory.stock",
+	}
+	broker := testsupport.NewBroker()
+	publisher, err := NewBrokerEventPublisher(cfg, broker, zap.NewNop())
+	require.NoError(t, err)
+
+	itemID := uuid.New()
+	ctx := context.WithValue(context.Background(), actorContextKey, "alice")
+	err = publisher.Publish(ctx, StockAdjustedEvent{
+		ItemID:     itemID,
+		SKU:        "SKU-001",
+		Quantity:   5,
+		NewTotal:   15,
+		OccurredAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	messages := broker.Messages("inventory.stock")
+	require.Len(t, messages, 1)
+	msg := messages[0]
+	assert.Equal(t, itemID.String(), string(msg.Key))
+	assert.Equal(t, "StockAdjusted", msg.Headers["event-type"])
+	assert.Equal(t, "alice", msg.Headers[ActorHeader])
+	assert.NotEmpty(t, msg.Headers["event-id"])
+
+	assert.Equal(t, "2", msg.Headers[SchemaVersionHeader])
+
+	var envelope Envelope
+	require.NoError(t, json.Unmarshal(msg.Value, &envelope))
+	assert.Equal(t, msg.Headers["event-id"], envelope.EventID)
+	assert.Equal(t, "StockAdjusted", envelope.EventType)
+	assert.Equal(t, SchemaVersion, envelope.SchemaVersion)
+
+	var payload StockAdjustedEvent
+	require.NoError(t, json.Unmarshal(envelope.Payload, &payload))
+	assert.Equal(t, 15, payload.NewTotal)
+	assert.Contains(t, string(envelope.Payload), `"newTotal":15`)
+	assert.Empty(t, broker.Messages("inventory.items"))
+}
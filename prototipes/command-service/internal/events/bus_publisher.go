@@ -0,0 +1,125 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"command-service/internal/config"
+	"command-service/pkg/metrics"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.uber.org/zap"
+)
+
+// busPublishAttempts is how many times a publish to NATS or RabbitMQ is tried
+const busPublishAttempts = 3
+
+// BusEventPublisher publishes events to NATS JetStream or RabbitMQ (EVENT_BUS).
+// Messages carry the same topic, headers, key and (encrypted) payload as on Kafka.
+type BusEventPublisher struct {
+	messages *KafkaEventPublisher // builds the messages; its producer is never used
+	bus      messageBus
+	system   string // EVENT_BUS, the messaging system of the spans
+	logger   *zap.Logger
+	inflight inFlight // publishes Shutdown waits for
+}
+
+// NewBusEventPublisher connects to the NATS or RabbitMQ server of cfg
+func NewBusEventPublisher(cfg *config.Config, logger *zap.Logger) (*BusEventPublisher, error) {
+	payloadCipher, err := NewPayloadCipher(cfg.EventEncryptionKeys, cfg.EventEncryptionActiveKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event encryption config: %w", err)
+	}
+	bus, err := dialBus(cfg, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &BusEventPublisher{
+		messages: &KafkaEventPublisher{cipher: payloadCipher, logger: logger, config: cfg},
+		bus:      bus,
+		system:   cfg.EventBus,
+		logger:   logger,
+	}, nil
+}
+
+// Publish sends the event to its topic, retrying with exponential backoff
+func (p *BusEventPublisher) Publish(ctx context.Context, event interface{}) (err error) {
+	if err := p.inflight.begin(); err != nil {
+		return err
+	}
+	defer p.inflight.end()
+
+	eventType := p.messages.getEventType(event)
+	ctx, span := startPublishSpan(ctx, eventType)
+	defer func() { endSpan(span, err) }()
+	span.SetAttributes(semconv.MessagingSystem(p.system))
+
+	message, err := p.messages.buildMessage(ctx, event)
+	if err != nil {
+		return err
+	}
+	topic := message.Topic
+	span.SetAttributes(semconv.MessagingDestinationName(topic))
+
+	start := time.Now()
+	defer func() {
+		outcome := "success"
+		if err != nil {
+			outcome = "error"
+		}
+		metrics.KafkaMessagesPublished.WithLabelValues(topic, eventType, outcome).Inc()
+		metrics.KafkaPublishDuration.WithLabelValues(topic).Observe(time.Since(start).Seconds())
+	}()
+
+	delay := 100 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		offset, sendErr := p.bus.Send(ctx, message)
+		if sendErr == nil {
+			p.logger.Info("Event published",
+				zap.String("bus", p.system),
+				zap.String("topic", topic),
+				zap.Int64("offset", offset),
+				zap.String("event-type", eventType),
+				zap.Int("attempt", attempt),
+			)
+			return nil
+		}
+		if attempt == busPublishAttempts {
+			return fmt.Errorf("failed to publish event to %s after %d attempts: %w", p.system, attempt, sendErr)
+		}
+		p.logger.Warn("Failed to publish event, retrying",
+			zap.String("bus", p.system),
+			zap.String("topic", topic),
+			zap.Error(sendErr),
+			zap.Int("attempt", attempt),
+		)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("context cancelled during backoff: %w", ctx.Err())
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// Ping fails when the bus does not answer. Used by the readiness probe.
+func (p *BusEventPublisher) Ping(ctx context.Context) error {
+	return p.bus.Ping(ctx)
+}
+
+// Shutdown stops accepting events, waits for the publishes in flight (retries included)
+// and closes the connection. If ctx ends first the connection is left open for the
+// publishes still retrying.
+func (p *BusEventPublisher) Shutdown(ctx context.Context) error {
+	if err := p.inflight.drain(ctx); err != nil {
+		return err
+	}
+	return p.Close()
+}
+
+// Close closes the connection to the bus
+func (p *BusEventPublisher) Close() error {
+	return p.bus.Close()
+}
@@ -0,0 +1,75 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/nats-io/nats.go"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func busTestMessage() *sarama.ProducerMessage {
+	return &sarama.ProducerMessage{
+		Topic: "inventory.stock",
+		Key:   sarama.StringEncoder("item-1"),
+		Value: sarama.ByteEncoder(`{"eventType":"StockAdjusted"}`),
+		Headers: []sarama.RecordHeader{
+			{Key: []byte("event-type"), Value: []byte("StockAdjusted")},
+			{Key: []byte("event-id"), Value: []byte("event-1")},
+			{Key: []byte(RequestIDHeader), Value: []byte("request-1")},
+		},
+	}
+}
+
+func TestNATSMessage_RoundTrip(t *testing.T) {
+	msg, err := natsMessage(busTestMessage())
+	require.NoError(t, err)
+	assert.Equal(t, "inventory.stock", msg.Subject)
+	assert.Equal(t, "event-1", msg.Header.Get(nats.MsgIdHdr), "the event ID deduplicates retries")
+
+	message := consumerMessageFromNATS(msg)
+	assert.Equal(t, "inventory.stock", message.Topic)
+	assert.Equal(t, []byte("item-1"), message.Key)
+	assert.JSONEq(t, `{"eventType":"StockAdjusted"}`, string(message.Value))
+	assert.Len(t, message.Headers, 3, "the key and the JetStream message ID are not event headers")
+	assert.Equal(t, "StockAdjusted", header(message.Headers, "event-type"))
+	assert.Equal(t, "request-1", header(message.Headers, RequestIDHeader))
+}
+
+func TestAMQPPublishing_RoundTrip(t *testing.T) {
+	publishing, err := amqpPublishing(busTestMessage())
+	require.NoError(t, err)
+	assert.Equal(t, amqp.Persistent, publishing.DeliveryMode)
+	assert.Equal(t, "event-1", publishing.MessageId)
+
+	message := consumerMessageFromAMQP(amqp.Delivery{
+		RoutingKey:  "inventory.stock",
+		DeliveryTag: 7,
+		Headers:     publishing.Headers,
+		Timestamp:   publishing.Timestamp,
+		Body:        publishing.Body,
+	})
+	assert.Equal(t, "inventory.stock", message.Topic)
+	assert.Equal(t, int64(7), message.Offset)
+	assert.Equal(t, []byte("item-1"), message.Key)
+	assert.JSONEq(t, `{"eventType":"StockAdjusted"}`, string(message.Value))
+	assert.Len(t, message.Headers, 3)
+	assert.Equal(t, "event-1", header(message.Headers, "event-id"))
+	assert.WithinDuration(t, time.Now(), message.Timestamp, time.Minute)
+}
+
+func TestNATSDurableName(t *testing.T) {
+	assert.Equal(t, "listener-service_inventory_items", natsDurableName("listener-service", "inventory.items"))
+}
+
+func header(headers []*sarama.RecordHeader, key string) string {
+	for _, h := range headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,212 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// TypeOf returns the type name of an event as sent in the event-type header
+func TypeOf(event interface{}) string {
+	switch event.(type) {
+	case InventoryItemCreatedEvent:
+		return "InventoryItemCreated"
+	case InventoryItemUpdatedEvent:
+		return "InventoryItemUpdated"
+	case InventoryItemPatchedEvent:
+		return "InventoryItemPatched"
+	case InventoryItemDeletedEvent:
+		return "InventoryItemDeleted"
+	case InventoryItemRestoredEvent:
+		return "InventoryItemRestored"
+	case ItemRelationAddedEvent:
+		return "ItemRelationAdded"
+	case ItemRelationRemovedEvent:
+		return "ItemRelationRemoved"
+	case ItemImageAddedEvent:
+		return "ItemImageAdded"
+	case ItemImageRemovedEvent:
+		return "ItemImageRemoved"
+	case ItemIdentifierAddedEvent:
+		return "ItemIdentifierAdded"
+	case ItemIdentifierRemovedEvent:
+		return "ItemIdentifierRemoved"
+	case StockAdjustedEvent:
+		return "StockAdjusted"
+	case StockReservedEvent:
+		return "StockReserved"
+	case StockReleasedEvent:
+		return "StockReleased"
+	case StockCommittedEvent:
+		return "StockCommitted"
+	case StoreCreatedEvent:
+		return "StoreCreated"
+	case StoreUpdatedEvent:
+		return "StoreUpdated"
+	case StoreDeletedEvent:
+		return "StoreDeleted"
+	case StoreCalendarUpdatedEvent:
+		return "StoreCalendarUpdated"
+	case SupplierCreatedEvent:
+		return "SupplierCreated"
+	case SupplierUpdatedEvent:
+		return "SupplierUpdated"
+	case SupplierDeletedEvent:
+		return "SupplierDeleted"
+	case PurchaseOrderCreatedEvent:
+		return "PurchaseOrderCreated"
+	case PurchaseOrderReceivedEvent:
+		return "PurchaseOrderReceived"
+	case PurchaseOrderCancelledEvent:
+		return "PurchaseOrderCancelled"
+	case StoreReservationCreatedEvent:
+		return "StoreReservationCreated"
+	case StoreReservationReleasedEvent:
+		return "StoreReservationReleased"
+	case ReservationWaitlistedEvent:
+		return "ReservationWaitlisted"
+	case ManualCorrectionEvent:
+		return "ManualCorrection"
+	default:
+		return "Unknown"
+	}
+}
+
+// Decode rebuilds an event from its type name and JSON encoding, so an event that was
+// stored (e.g. in the write-ahead journal) can be handed back to a publisher
+func Decode(eventType string, payload []byte) (interface{}, error) {
+	var event interface{}
+	switch eventType {
+	case "InventoryItemCreated":
+		event = &InventoryItemCreatedEvent{}
+	case "InventoryItemUpdated":
+		event = &InventoryItemUpdatedEvent{}
+	case "InventoryItemPatched":
+		event = &InventoryItemPatchedEvent{}
+	case "InventoryItemDeleted":
+		event = &InventoryItemDeletedEvent{}
+	case "InventoryItemRestored":
+		event = &InventoryItemRestoredEvent{}
+	case "ItemRelationAdded":
+		event = &ItemRelationAddedEvent{}
+	case "ItemRelationRemoved":
+		event = &ItemRelationRemovedEvent{}
+	case "ItemImageAdded":
+		event = &ItemImageAddedEvent{}
+	case "ItemImageRemoved":
+		event = &ItemImageRemovedEvent{}
+	case "ItemIdentifierAdded":
+		event = &ItemIdentifierAddedEvent{}
+	case "ItemIdentifierRemoved":
+		event = &ItemIdentifierRemovedEvent{}
+	case "StockAdjusted":
+		event = &StockAdjustedEvent{}
+	case "StockReserved":
+		event = &StockReservedEvent{}
+	case "StockReleased":
+		event = &StockReleasedEvent{}
+	case "StockCommitted":
+		event = &StockCommittedEvent{}
+	case "StoreCreated":
+		event = &StoreCreatedEvent{}
+	case "StoreUpdated":
+		event = &StoreUpdatedEvent{}
+	case "StoreDeleted":
+		event = &StoreDeletedEvent{}
+	case "StoreCalendarUpdated":
+		event = &StoreCalendarUpdatedEvent{}
+	case "SupplierCreated":
+		event = &SupplierCreatedEvent{}
+	case "SupplierUpdated":
+		event = &SupplierUpdatedEvent{}
+	case "SupplierDeleted":
+		event = &SupplierDeletedEvent{}
+	case "PurchaseOrderCreated":
+		event = &PurchaseOrderCreatedEvent{}
+	case "PurchaseOrderReceived":
+		event = &PurchaseOrderReceivedEvent{}
+	case "PurchaseOrderCancelled":
+		event = &PurchaseOrderCancelledEvent{}
+	case "StoreReservationCreated":
+		event = &StoreReservationCreatedEvent{}
+	case "StoreReservationReleased":
+		event = &StoreReservationReleasedEvent{}
+	case "ReservationWaitlisted":
+		event = &ReservationWaitlistedEvent{}
+	case "ManualCorrection":
+		event = &ManualCorrectionEvent{}
+	default:
+		return nil, fmt.Errorf("unknown event type %q", eventType)
+	}
+
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, fmt.Errorf("failed to decode %s event: %w", eventType, err)
+	}
+	return derefEvent(event), nil
+}
+
+// derefEvent returns the event struct behind the pointer Decode filled in; publishers
+// switch on the value types
+func derefEvent(event interface{}) interface{} {
+	switch e := event.(type) {
+	case *InventoryItemCreatedEvent:
+		return *e
+	case *InventoryItemUpdatedEvent:
+		return *e
+	case *InventoryItemPatchedEvent:
+		return *e
+	case *InventoryItemDeletedEvent:
+		return *e
+	case *InventoryItemRestoredEvent:
+		return *e
+	case *ItemRelationAddedEvent:
+		return *e
+	case *ItemRelationRemovedEvent:
+		return *e
+	case *ItemImageAddedEvent:
+		return *e
+	case *ItemImageRemovedEvent:
+		return *e
+	case *ItemIdentifierAddedEvent:
+		return *e
+	case *ItemIdentifierRemovedEvent:
+		return *e
+	case *StockAdjustedEvent:
+		return *e
+	case *StockReservedEvent:
+		return *e
+	case *StockReleasedEvent:
+		return *e
+	case *StockCommittedEvent:
+		return *e
+	case *StoreCreatedEvent:
+		return *e
+	case *StoreUpdatedEvent:
+		return *e
+	case *StoreDeletedEvent:
+		return *e
+	case *StoreCalendarUpdatedEvent:
+		return *e
+	case *SupplierCreatedEvent:
+		return *e
+	case *SupplierUpdatedEvent:
+		return *e
+	case *SupplierDeletedEvent:
+		return *e
+	case *PurchaseOrderCreatedEvent:
+		return *e
+	case *PurchaseOrderReceivedEvent:
+		return *e
+	case *PurchaseOrderCancelledEvent:
+		return *e
+	case *StoreReservationCreatedEvent:
+		return *e
+	case *StoreReservationReleasedEvent:
+		return *e
+	case *ReservationWaitlistedEvent:
+		return *e
+	case *ManualCorrectionEvent:
+		return *e
+	default:
+		return event
+	}
+}
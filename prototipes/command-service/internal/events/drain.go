@@ -0,0 +1,93 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"command-service/pkg/metrics"
+)
+
+// ErrPublisherClosed is returned by Publish once the publisher started shutting down
+var ErrPublisherClosed = errors.New("event publisher is shutting down")
+
+// Drainer is implemented by publishers that can wait for their in-flight publishes
+// (retries included) before closing their connection
+type Drainer interface {
+	// Shutdown stops accepting publishes, waits until the in-flight ones finish or ctx
+	// is done, and closes the publisher
+	Shutdown(ctx context.Context) error
+}
+
+// inFlight counts the publishes a publisher is working on so shutdown can wait for
+// them. The zero value is ready to use.
+type inFlight struct {
+	mu      sync.Mutex
+	closing bool
+	count   int
+	idle    chan struct{} // closed when count drops to 0 while closing
+}
+
+// begin registers a publish; it fails once drain was called
+func (f *inFlight) begin() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closing {
+		return ErrPublisherClosed
+	}
+	f.count++
+	metrics.EventPublishesInFlight.Inc()
+	return nil
+}
+
+// join registers work started by a publish already in flight (a send that outlives its
+// attempt); unlike begin it is accepted while draining
+func (f *inFlight) join() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.count++
+	metrics.EventPublishesInFlight.Inc()
+}
+
+// end unregisters a publish started with begin or join
+func (f *inFlight) end() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.count--
+	metrics.EventPublishesInFlight.Dec()
+	if f.closing && f.count == 0 && f.idle != nil {
+		close(f.idle)
+		f.idle = nil
+	}
+}
+
+// drain rejects new publishes and waits for the in-flight ones. When ctx is done first
+// it returns an error with the number of publishes (and sends) left behind.
+func (f *inFlight) drain(ctx context.Context) error {
+	f.mu.Lock()
+	f.closing = true
+	if f.count == 0 {
+		f.mu.Unlock()
+		return nil
+	}
+	if f.idle == nil {
+		f.idle = make(chan struct{})
+	}
+	idle := f.idle
+	f.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("event publisher not drained, %d publishes and sends in flight: %w", f.pending(), ctx.Err())
+	}
+}
+
+// pending returns the number of publishes in flight
+func (f *inFlight) pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.count
+}
@@ -0,0 +1,95 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"command-service/internal/config"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// blockedPublisher returns a Kafka publisher whose first send waits for release
+func blockedPublisher(t *testing.T, release <-chan struct{}) (*KafkaEventPublisher, *mocks.SyncProducer) {
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(*sarama.ProducerMessage) error {
+		<-release
+		return nil
+	})
+	return &KafkaEventPublisher{
+		producer: producer,
+		logger:   zap.NewNop(),
+		config:   &config.Config{KafkaTopicStock: "inventory.stock"},
+	}, producer
+}
+
+// publishAsync publishes a stock event in the background and waits until it is in flight
+func publishAsync(t *testing.T, publisher *KafkaEventPublisher) <-chan error {
+	result := make(chan error, 1)
+	go func() {
+		result <- publisher.Publish(context.Background(), StockAdjustedEvent{ItemID: uuid.New().String(), SKU: "SKU-001", Quantity: 5})
+	}()
+	require.Eventually(t, func() bool { return publisher.inflight.pending() > 0 }, time.Second, 5*time.Millisecond)
+	return result
+}
+
+func TestKafkaEventPublisher_Shutdown_WaitsForInFlightPublish(t *testing.T) {
+	release := make(chan struct{})
+	publisher, _ := blockedPublisher(t, release)
+	published := publishAsync(t, publisher)
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- publisher.Shutdown(context.Background()) }()
+
+	// New events are rejected while the pending one is still being sent
+	require.Eventually(t, func() bool {
+		publisher.inflight.mu.Lock()
+		defer publisher.inflight.mu.Unlock()
+		return publisher.inflight.closing
+	}, time.Second, 5*time.Millisecond)
+	assert.ErrorIs(t, publisher.Publish(context.Background(), StockAdjustedEvent{ItemID: uuid.New().String()}), ErrPublisherClosed)
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown returned before the publish finished: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	assert.NoError(t, <-published)
+	// Shutdown closes the mock producer, which fails if the expected send did not happen
+	assert.NoError(t, <-shutdown)
+	assert.Zero(t, publisher.inflight.pending())
+}
+
+func TestKafkaEventPublisher_Shutdown_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	publisher, producer := blockedPublisher(t, release)
+	published := publishAsync(t, publisher)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := publisher.Shutdown(ctx)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	// The publish and its send
+	assert.Contains(t, err.Error(), "2 publishes and sends in flight")
+
+	// The producer was left open for the pending send
+	close(release)
+	assert.NoError(t, <-published)
+	assert.NoError(t, producer.Close())
+}
+
+func TestKafkaEventPublisher_Shutdown_Idle(t *testing.T) {
+	producer := mocks.NewSyncProducer(t, nil)
+	publisher := &KafkaEventPublisher{producer: producer, logger: zap.NewNop()}
+
+	assert.NoError(t, publisher.Shutdown(context.Background()))
+	assert.ErrorIs(t, publisher.Publish(context.Background(), StockAdjustedEvent{}), ErrPublisherClosed)
+}
@@ -0,0 +1,50 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SchemaVersion is the version of the event format published. Version 1 was the bare
+// event struct (PascalCase fields) with its ID, type and time only in the headers;
+// version 2 wraps the camelCase payload in an Envelope.
+const SchemaVersion = 2
+
+// SchemaVersionHeader carries the schema version so consumers can route a message
+// without parsing it
+const SchemaVersionHeader = "schema-version"
+
+// Envelope is the message body of every published event
+type Envelope struct {
+	EventID       string          `json:"event_id"`
+	EventType     string          `json:"event_type"`
+	SchemaVersion int             `json:"schema_version"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	TenantID      string          `json:"tenant_id,omitempty"` // Empty before multi-tenancy: the default tenant
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// NewEnvelope wraps event in an envelope. The occurrence time is taken from the
+// event's OccurredAt, or now if it has none.
+func NewEnvelope(eventID string, event interface{}) (*Envelope, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	var timing struct {
+		OccurredAt time.Time `json:"occurredAt"`
+	}
+	if json.Unmarshal(payload, &timing) != nil || timing.OccurredAt.IsZero() {
+		timing.OccurredAt = time.Now()
+	}
+
+	return &Envelope{
+		EventID:       eventID,
+		EventType:     TypeOf(event),
+		SchemaVersion: SchemaVersion,
+		OccurredAt:    timing.OccurredAt.UTC(),
+		Payload:       payload,
+	}, nil
+}
@@ -0,0 +1,43 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEnvelope(t *testing.T) {
+	itemID := uuid.New()
+	occurredAt := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	envelope, err := NewEnvelope("event-1", StockReservedEvent{
+		ItemID:     itemID,
+		SKU:        "SKU-001",
+		Quantity:   3,
+		OccurredAt: occurredAt,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "event-1", envelope.EventID)
+	assert.Equal(t, "StockReserved", envelope.EventType)
+	assert.Equal(t, SchemaVersion, envelope.SchemaVersion)
+	assert.True(t, occurredAt.Equal(envelope.OccurredAt))
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(envelope.Payload, &payload))
+	assert.Equal(t, itemID.String(), payload["itemId"])
+	assert.Equal(t, "SKU-001", payload["sku"])
+	assert.EqualValues(t, 3, payload["quantity"])
+}
+
+func TestNewEnvelope_DefaultsOccurredAtToNow(t *testing.T) {
+	before := time.Now().Add(-time.Second)
+
+	envelope, err := NewEnvelope("event-1", StoreDeletedEvent{StoreID: uuid.New(), Code: "S-1"})
+	require.NoError(t, err)
+	assert.True(t, envelope.OccurredAt.After(before))
+}
@@ -0,0 +1,199 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"command-service/internal/config"
+
+	"github.com/IBM/sarama"
+)
+
+// EventConsumer reads the messages of a set of topics from the event bus (EVENT_BUS).
+// Whatever the bus, messages are handed over in the shape of Kafka messages.
+type EventConsumer interface {
+	// Consume calls handle for each message of topics, one at a time, until ctx is
+	// cancelled (it then returns nil) or the consumer fails. A message is acknowledged
+	// once handle returns. On Kafka, a topic with config.TenantPlaceholder stands for
+	// every topic of the cluster it matches, looked up again every
+	// KAFKA_TOPIC_REFRESH_SECONDS.
+	Consume(ctx context.Context, topics []string, handle func(*sarama.ConsumerMessage)) error
+	// Ping fails when the bus does not answer. Used by the readiness probe.
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// NewEventConsumer creates a consumer of the event bus of cfg that reads every message
+// from the newest one, without a consumer group: each replica gets all of them
+func NewEventConsumer(cfg *config.Config) (EventConsumer, error) {
+	if cfg.EventBus != config.EventBusKafka {
+		return dialBus(cfg, "")
+	}
+
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Consumer.Return.Errors = false
+	if err := ConfigureKafkaSecurity(saramaConfig, cfg); err != nil {
+		return nil, err
+	}
+	client, err := sarama.NewClient(cfg.KafkaBrokers, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer: %w", err)
+	}
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to create consumer: %w", err)
+	}
+	return &kafkaEventConsumer{client: client, consumer: consumer, refresh: time.Duration(cfg.KafkaTopicRefreshSeconds) * time.Second}, nil
+}
+
+// kafkaEventConsumer reads every partition of the topics from the newest offset
+type kafkaEventConsumer struct {
+	client   sarama.Client // owns the broker connections of consumer
+	consumer sarama.Consumer
+	refresh  time.Duration // how often the topics of templates are looked up
+}
+
+// Consume reads every partition of topics until parent is cancelled or a partition
+// fails. When topics have templates, it starts over whenever the topics they match change
+// (a tenant got its own topics).
+func (c *kafkaEventConsumer) Consume(parent context.Context, topics []string, handle func(*sarama.ConsumerMessage)) error {
+	var templates, fixed []string
+	for _, topic := range topics {
+		if strings.Contains(topic, config.TenantPlaceholder) {
+			templates = append(templates, topic)
+		} else {
+			fixed = append(fixed, topic)
+		}
+	}
+	if len(templates) == 0 {
+		return c.consumeTopics(parent, topics, handle)
+	}
+	for {
+		matched, err := c.matchTopics(templates)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithCancel(parent)
+		go c.watchTopics(ctx, templates, matched, cancel)
+		err = c.consumeTopics(ctx, append(append([]string(nil), fixed...), matched...), handle)
+		cancel()
+		if err != nil || parent.Err() != nil {
+			return err
+		}
+	}
+}
+
+// matchTopics returns the topics of the cluster that templates match, sorted
+func (c *kafkaEventConsumer) matchTopics(templates []string) ([]string, error) {
+	if err := c.client.RefreshMetadata(); err != nil {
+		return nil, fmt.Errorf("failed to refresh the topic list: %w", err)
+	}
+	available, err := c.client.Topics()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list topics: %w", err)
+	}
+	return config.MatchTopics(available, templates...), nil
+}
+
+// watchTopics looks the topics of templates up every refresh interval and calls changed
+// once they differ from current. It returns then or when ctx is done.
+func (c *kafkaEventConsumer) watchTopics(ctx context.Context, templates, current []string, changed func()) {
+	ticker := time.NewTicker(c.refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		// A failed lookup keeps the current topics until the next one
+		if topics, err := c.matchTopics(templates); err == nil && !sameTopics(topics, current) {
+			changed()
+			return
+		}
+	}
+}
+
+// sameTopics compares two sorted topic lists
+func sameTopics(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// consumeTopics reads every partition of topics until parent is cancelled (it then
+// returns nil) or a partition fails
+func (c *kafkaEventConsumer) consumeTopics(parent context.Context, topics []string, handle func(*sarama.ConsumerMessage)) error {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	var wg sync.WaitGroup
+	var mu sync.Mutex // handle is called one message at a time
+	stop := func(err error) error {
+		cancel()
+		wg.Wait()
+		return err
+	}
+	for _, topic := range topics {
+		partitions, err := c.consumer.Partitions(topic)
+		if err != nil {
+			return stop(fmt.Errorf("failed to list partitions of %s: %w", topic, err))
+		}
+		for _, partition := range partitions {
+			pc, err := c.consumer.ConsumePartition(topic, partition, sarama.OffsetNewest)
+			if err != nil {
+				return stop(fmt.Errorf("failed to consume partition %d of %s: %w", partition, topic, err))
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer pc.Close()
+				for {
+					select {
+					case message, ok := <-pc.Messages():
+						if !ok {
+							cancel()
+							return
+						}
+						mu.Lock()
+						handle(message)
+						mu.Unlock()
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+		}
+	}
+
+	wg.Wait()
+	if parent.Err() != nil {
+		return nil
+	}
+	return fmt.Errorf("partition consumer closed")
+}
+
+// Ping refreshes the metadata of the cluster, which fails when no broker answers
+func (c *kafkaEventConsumer) Ping(ctx context.Context) error {
+	if err := c.client.RefreshMetadata(); err != nil {
+		return fmt.Errorf("kafka metadata refresh failed: %w", err)
+	}
+	return nil
+}
+
+// Close closes the consumer and its broker connections
+func (c *kafkaEventConsumer) Close() error {
+	if err := c.consumer.Close(); err != nil {
+		return err
+	}
+	return c.client.Close()
+}
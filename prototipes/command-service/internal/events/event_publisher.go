@@ -17,53 +17,359 @@ type Event struct {
 	OccurredAt interface{}
 }
 
+// Event payloads use camelCase field names and are published inside an Envelope
+
 // Inventory domain events
 type InventoryItemCreatedEvent struct {
-	ItemID      interface{}
-	SKU         string
-	Name        string
-	Description string
-	Quantity    int
-	OccurredAt  interface{}
+	ItemID      interface{} `json:"itemId"`
+	SKU         string      `json:"sku"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Quantity    int         `json:"quantity"`
+	UnitCost    *float64    `json:"unitCost"` // Cost of the initial stock, nil when unknown
+	OccurredAt  interface{} `json:"occurredAt"`
 }
 
 type InventoryItemUpdatedEvent struct {
-	ItemID      interface{}
-	Name        string
-	Description string
-	OccurredAt  interface{}
+	ItemID          interface{} `json:"itemId"`
+	Name            string      `json:"name"`
+	Description     string      `json:"description"`
+	ExpectedVersion int         `json:"expectedVersion"` // Item version the change was made on (the listener's optimistic lock)
+	OccurredAt      interface{} `json:"occurredAt"`
+}
+
+// InventoryItemPatchedEvent carries only the details a partial update changed: a nil
+// field keeps its current value
+type InventoryItemPatchedEvent struct {
+	ItemID          interface{} `json:"itemId"`
+	Name            *string     `json:"name,omitempty"`
+	Description     *string     `json:"description,omitempty"`
+	ExpectedVersion int         `json:"expectedVersion"` // Item version the change was made on
+	OccurredAt      interface{} `json:"occurredAt"`
 }
 
+// InventoryItemDeletedEvent soft-deletes an item: it leaves the listings but keeps its
+// stock and history until an InventoryItemRestoredEvent
 type InventoryItemDeletedEvent struct {
-	ItemID     interface{}
-	SKU        string
-	OccurredAt interface{}
+	ItemID          interface{} `json:"itemId"`
+	SKU             string      `json:"sku"`
+	ExpectedVersion int         `json:"expectedVersion"` // Item version the delete was made on (0 in older events)
+	OccurredAt      interface{} `json:"occurredAt"`
+}
+
+// InventoryItemRestoredEvent undoes an InventoryItemDeletedEvent
+type InventoryItemRestoredEvent struct {
+	ItemID          interface{} `json:"itemId"`
+	SKU             string      `json:"sku"`
+	ExpectedVersion int         `json:"expectedVersion"` // Item version the restore was made on
+	OccurredAt      interface{} `json:"occurredAt"`
+}
+
+// ItemRelationAddedEvent links RelatedItemID to ItemID (Relation: substitute, accessory)
+type ItemRelationAddedEvent struct {
+	ItemID        interface{} `json:"itemId"`
+	RelatedItemID interface{} `json:"relatedItemId"`
+	Relation      string      `json:"relation"`
+	OccurredAt    interface{} `json:"occurredAt"`
+}
+
+// ItemRelationRemovedEvent removes a link added by ItemRelationAddedEvent
+type ItemRelationRemovedEvent struct {
+	ItemID        interface{} `json:"itemId"`
+	RelatedItemID interface{} `json:"relatedItemId"`
+	Relation      string      `json:"relation"`
+	OccurredAt    interface{} `json:"occurredAt"`
+}
+
+// ItemImageAddedEvent adds an image to an item. The file is already in the image store
+// under Key; URL is its public URL.
+type ItemImageAddedEvent struct {
+	ItemID          interface{} `json:"itemId"`
+	ImageID         interface{} `json:"imageId"`
+	Key             string      `json:"key"`
+	URL             string      `json:"url"`
+	ContentType     string      `json:"contentType"`
+	Size            int64       `json:"size"`
+	ExpectedVersion int         `json:"expectedVersion"` // Item version the image was added on
+	OccurredAt      interface{} `json:"occurredAt"`
+}
+
+// ItemImageRemovedEvent removes an image added by ItemImageAddedEvent; its file is
+// deleted from the image store
+type ItemImageRemovedEvent struct {
+	ItemID          interface{} `json:"itemId"`
+	ImageID         interface{} `json:"imageId"`
+	ExpectedVersion int         `json:"expectedVersion"` // Item version the image was removed on
+	OccurredAt      interface{} `json:"occurredAt"`
+}
+
+// ItemIdentifierAddedEvent adds a barcode or alias to an item. Code is what a lookup
+// matches (the GTIN-14 of a barcode); the write store keeps it unique per tenant.
+type ItemIdentifierAddedEvent struct {
+	ItemID          interface{} `json:"itemId"`
+	Type            string      `json:"type"`
+	Value           string      `json:"value"`
+	Code            string      `json:"code"`
+	ExpectedVersion int         `json:"expectedVersion"` // Item version the identifier was added on
+	OccurredAt      interface{} `json:"occurredAt"`
+}
+
+// ItemIdentifierRemovedEvent removes an identifier added by ItemIdentifierAddedEvent
+type ItemIdentifierRemovedEvent struct {
+	ItemID          interface{} `json:"itemId"`
+	Type            string      `json:"type"`
+	Value           string      `json:"value"`
+	Code            string      `json:"code"`
+	ExpectedVersion int         `json:"expectedVersion"` // Item version the identifier was removed on
+	OccurredAt      interface{} `json:"occurredAt"`
 }
 
 type StockAdjustedEvent struct {
-	ItemID     interface{}
-	SKU        string
-	Quantity   int
-	NewTotal   int
-	OccurredAt interface{}
+	ItemID          interface{} `json:"itemId"`
+	SKU             string      `json:"sku"`
+	Quantity        int         `json:"quantity"`
+	NewTotal        int         `json:"newTotal"`
+	UnitCost        *float64    `json:"unitCost"`            // Cost of received stock, nil when unknown
+	ExpectedVersion int         `json:"expectedVersion"`     // Item version the change was made on (the listener's optimistic lock)
+	Location        string      `json:"location,omitempty"`  // Stock location adjusted; empty for the unlocated stock
+	Reason          string      `json:"reason,omitempty"`    // Reason code given with the command (ReasonStockCount for reconciliations)
+	Reference       string      `json:"reference,omitempty"` // External reference given with the command (e.g. an order ID)
+	Actor           string      `json:"actor,omitempty"`     // User that issued the command (also in the actor header)
+	OccurredAt      interface{} `json:"occurredAt"`
 }
 
+// ReasonStockCount tags the adjustments that bring the stock in line with a physical count
+const ReasonStockCount = "stock_count"
+
+// ReasonPOReceipt tags the adjustments that add the stock received for a purchase order;
+// their Reference is the order number
+const ReasonPOReceipt = "po_receipt"
+
 type StockReservedEvent struct {
-	ItemID     interface{}
-	SKU        string
-	Quantity   int
-	Reserved   int
-	Available  int
-	OccurredAt interface{}
+	ItemID     interface{} `json:"itemId"`
+	SKU        string      `json:"sku"`
+	Quantity   int         `json:"quantity"`
+	Reserved   int         `json:"reserved"`
+	Available  int         `json:"available"`
+	Location   string      `json:"location,omitempty"`  // Stock location; empty for the unlocated stock
+	Reason     string      `json:"reason,omitempty"`    // Reason code given with the command
+	Reference  string      `json:"reference,omitempty"` // External reference given with the command (e.g. an order ID)
+	Actor      string      `json:"actor,omitempty"`     // User that issued the command (also in the actor header)
+	OccurredAt interface{} `json:"occurredAt"`
 }
 
 type StockReleasedEvent struct {
-	ItemID     interface{}
-	SKU        string
-	Quantity   int
-	Reserved   int
-	Available  int
-	OccurredAt interface{}
+	ItemID     interface{} `json:"itemId"`
+	SKU        string      `json:"sku"`
+	Quantity   int         `json:"quantity"`
+	Reserved   int         `json:"reserved"`
+	Available  int         `json:"available"`
+	Location   string      `json:"location,omitempty"`  // Stock location; empty for the unlocated stock
+	Reason     string      `json:"reason,omitempty"`    // Reason code given with the command
+	Reference  string      `json:"reference,omitempty"` // External reference given with the command (e.g. an order ID)
+	Actor      string      `json:"actor,omitempty"`     // User that issued the command (also in the actor header)
+	OccurredAt interface{} `json:"occurredAt"`
+}
+
+// StockCommittedEvent is published when reserved stock is sold: both the reserved and
+// the total quantity drop by Quantity
+type StockCommittedEvent struct {
+	ItemID     interface{} `json:"itemId"`
+	SKU        string      `json:"sku"`
+	Quantity   int         `json:"quantity"`
+	NewTotal   int         `json:"newTotal"`
+	Reserved   int         `json:"reserved"`
+	Available  int         `json:"available"`
+	Location   string      `json:"location,omitempty"`  // Stock location the units left from
+	Reason     string      `json:"reason,omitempty"`    // Reason code given with the command
+	Reference  string      `json:"reference,omitempty"` // External reference given with the command (e.g. an order ID)
+	Actor      string      `json:"actor,omitempty"`     // User that issued the command (also in the actor header)
+	OccurredAt interface{} `json:"occurredAt"`
+}
+
+// Store domain events
+type StoreCreatedEvent struct {
+	StoreID    interface{} `json:"storeId"`
+	Code       string      `json:"code"`
+	Name       string      `json:"name"`
+	Location   string      `json:"location"`
+	Active     bool        `json:"active"`
+	OccurredAt interface{} `json:"occurredAt"`
+}
+
+type StoreUpdatedEvent struct {
+	StoreID    interface{} `json:"storeId"`
+	Code       string      `json:"code"`
+	Name       string      `json:"name"`
+	Location   string      `json:"location"`
+	Active     bool        `json:"active"`
+	OccurredAt interface{} `json:"occurredAt"`
+}
+
+type StoreDeletedEvent struct {
+	StoreID    interface{} `json:"storeId"`
+	Code       string      `json:"code"`
+	OccurredAt interface{} `json:"occurredAt"`
+}
+
+// StoreCalendarUpdatedEvent carries the whole calendar of a store after it is set or
+// removed (Calendar is nil then)
+type StoreCalendarUpdatedEvent struct {
+	StoreID    interface{}    `json:"storeId"`
+	Code       string         `json:"code"`
+	Calendar   *StoreCalendar `json:"calendar"`
+	OccurredAt interface{}    `json:"occurredAt"`
+}
+
+// StoreCalendar is the opening hours and holidays of a store, in its local time
+type StoreCalendar struct {
+	Timezone string         `json:"timezone"`
+	Hours    []OpeningHours `json:"hours"`
+	Holidays []Holiday      `json:"holidays"`
+}
+
+// OpeningHours is one opening period of a day of the week ("monday", "09:00", "18:00")
+type OpeningHours struct {
+	Day    string `json:"day"`
+	Opens  string `json:"opens"`
+	Closes string `json:"closes"`
+}
+
+// Holiday is a date ("2024-12-25") on which the store is closed
+type Holiday struct {
+	Date string `json:"date"`
+	Name string `json:"name,omitempty"`
+}
+
+// StoreReservationCreatedEvent is published instead of StockReservedEvent when
+// the reservation is attributed to a store
+type StoreReservationCreatedEvent struct {
+	ReservationID interface{} `json:"reservationId"`
+	StoreID       interface{} `json:"storeId"`
+	ItemID        interface{} `json:"itemId"`
+	SKU           string      `json:"sku"`
+	Quantity      int         `json:"quantity"`
+	Reserved      int         `json:"reserved"`
+	Available     int         `json:"available"`
+	Reason        string      `json:"reason,omitempty"`    // Reason code given with the command
+	Reference     string      `json:"reference,omitempty"` // External reference given with the command (e.g. an order ID)
+	Actor         string      `json:"actor,omitempty"`     // User that issued the command (also in the actor header)
+	OccurredAt    interface{} `json:"occurredAt"`
+}
+
+// StoreReservationReleasedEvent is published instead of StockReleasedEvent when
+// the released stock belonged to a store reservation
+type StoreReservationReleasedEvent struct {
+	StoreID    interface{} `json:"storeId"`
+	ItemID     interface{} `json:"itemId"`
+	SKU        string      `json:"sku"`
+	Quantity   int         `json:"quantity"`
+	Reserved   int         `json:"reserved"`
+	Available  int         `json:"available"`
+	Reason     string      `json:"reason,omitempty"`    // Reason code given with the command
+	Reference  string      `json:"reference,omitempty"` // External reference given with the command (e.g. an order ID)
+	Actor      string      `json:"actor,omitempty"`     // User that issued the command (also in the actor header)
+	OccurredAt interface{} `json:"occurredAt"`
+}
+
+// ReservationWaitlistedEvent is published instead of StockReservedEvent when the
+// reservation could not be satisfied and the client asked to wait for stock.
+// The listener fulfills waitlisted reservations in FIFO order as stock frees up.
+type ReservationWaitlistedEvent struct {
+	WaitlistID interface{} `json:"waitlistId"`
+	ItemID     interface{} `json:"itemId"`
+	SKU        string      `json:"sku"`
+	Quantity   int         `json:"quantity"`
+	Available  int         `json:"available"`
+	OccurredAt interface{} `json:"occurredAt"`
+}
+
+// ManualCorrectionEvent is published when an administrator overwrites an item's stock
+// counters (POST /admin/items/:id/force-set-stock). It carries absolute values, not
+// deltas, together with the values they replaced and the reason for the correction.
+type ManualCorrectionEvent struct {
+	ItemID           interface{} `json:"itemId"`
+	SKU              string      `json:"sku"`
+	Quantity         int         `json:"quantity"`
+	Reserved         int         `json:"reserved"`
+	Available        int         `json:"available"`
+	PreviousQuantity int         `json:"previousQuantity"`
+	PreviousReserved int         `json:"previousReserved"`
+	Reason           string      `json:"reason"`
+	OccurredAt       interface{} `json:"occurredAt"`
+}
+
+type SupplierCreatedEvent struct {
+	SupplierID interface{} `json:"supplierId"`
+	Code       string      `json:"code"`
+	Name       string      `json:"name"`
+	Email      string      `json:"email"`
+	Phone      string      `json:"phone"`
+	Active     bool        `json:"active"`
+	OccurredAt interface{} `json:"occurredAt"`
+}
+
+type SupplierUpdatedEvent struct {
+	SupplierID interface{} `json:"supplierId"`
+	Code       string      `json:"code"`
+	Name       string      `json:"name"`
+	Email      string      `json:"email"`
+	Phone      string      `json:"phone"`
+	Active     bool        `json:"active"`
+	OccurredAt interface{} `json:"occurredAt"`
+}
+
+type SupplierDeletedEvent struct {
+	SupplierID interface{} `json:"supplierId"`
+	Code       string      `json:"code"`
+	OccurredAt interface{} `json:"occurredAt"`
+}
+
+// PurchaseOrderCreatedEvent carries a new purchase order with all its lines
+type PurchaseOrderCreatedEvent struct {
+	PurchaseOrderID interface{}         `json:"purchaseOrderId"`
+	Number          string              `json:"number"`
+	SupplierID      interface{}         `json:"supplierId"`
+	Status          string              `json:"status"`
+	ExpectedAt      interface{}         `json:"expectedAt"` // nil when unknown
+	Notes           string              `json:"notes,omitempty"`
+	Lines           []PurchaseOrderLine `json:"lines"`
+	OccurredAt      interface{}         `json:"occurredAt"`
+}
+
+// PurchaseOrderLine is an item ordered on a purchase order
+type PurchaseOrderLine struct {
+	ItemID   interface{} `json:"itemId"`
+	SKU      string      `json:"sku"`
+	Quantity int         `json:"quantity"`
+	UnitCost *float64    `json:"unitCost"` // nil when unknown
+}
+
+// PurchaseOrderReceivedEvent is published after the stock of a receipt is adjusted
+// (StockAdjustedEvent with ReasonPOReceipt, one per line). Its lines carry the
+// cumulative received quantity, so applying the event twice changes nothing.
+type PurchaseOrderReceivedEvent struct {
+	PurchaseOrderID interface{}            `json:"purchaseOrderId"`
+	Number          string                 `json:"number"`
+	Status          string                 `json:"status"`
+	Lines           []PurchaseOrderReceipt `json:"lines"`
+	Actor           string                 `json:"actor,omitempty"` // User that issued the command (also in the actor header)
+	OccurredAt      interface{}            `json:"occurredAt"`
+}
+
+// PurchaseOrderReceipt is the quantity of an item received by a receipt, and the total
+// received for its line so far
+type PurchaseOrderReceipt struct {
+	ItemID   interface{} `json:"itemId"`
+	Quantity int         `json:"quantity"`
+	Received int         `json:"received"`
+}
+
+type PurchaseOrderCancelledEvent struct {
+	PurchaseOrderID interface{} `json:"purchaseOrderId"`
+	Number          string      `json:"number"`
+	Status          string      `json:"status"`
+	OccurredAt      interface{} `json:"occurredAt"`
 }
 
 // InMemoryEventPublisher is a placeholder implementation
@@ -86,4 +392,3 @@ func (p *InMemoryEventPublisher) Publish(ctx context.Context, event interface{})
 	p.logger.Info("Event published (in-memory)", zap.Any("event", event))
 	return nil
 }
-
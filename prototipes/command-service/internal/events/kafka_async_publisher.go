@@ -0,0 +1,251 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"command-service/internal/config"
+	"command-service/pkg/metrics"
+
+	"github.com/IBM/sarama"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.uber.org/zap"
+)
+
+// asyncPublishAttempts is how many times the async publisher hands an event to the
+// producer before giving up (each one with the producer's own KAFKA_RETRIES)
+const asyncPublishAttempts = 5
+
+// asyncMessage is an event waiting in the local queue or in the producer
+type asyncMessage struct {
+	message   *sarama.ProducerMessage
+	eventType string
+	attempt   int
+	queuedAt  time.Time
+}
+
+// AsyncKafkaEventPublisher publishes events to Kafka without blocking the request
+// (KAFKA_PUBLISH_MODE=async). Publish returns once the event is in a bounded local
+// queue; a sarama.AsyncProducer sends it in the background and failed sends go back to
+// the queue with exponential backoff. Events still queued when the process dies are
+// lost, so the journal cannot republish them.
+type AsyncKafkaEventPublisher struct {
+	messages   *KafkaEventPublisher // builds the messages and answers Ping; its sync producer is never used
+	producer   sarama.AsyncProducer
+	queue      chan *asyncMessage
+	retryDelay time.Duration // backoff before the second attempt, doubled on each retry
+	logger     *zap.Logger
+	inflight   inFlight      // queued events, until they are acknowledged or dropped
+	stop       chan struct{} // closed by Close to stop the dispatcher
+	dispatched chan struct{} // closed when the dispatcher returned
+	collectors sync.WaitGroup
+	closeOnce  sync.Once
+}
+
+// NewAsyncKafkaEventPublisher creates an async Kafka publisher with a local queue of
+// KAFKA_ASYNC_QUEUE_SIZE events
+func NewAsyncKafkaEventPublisher(cfg *config.Config, logger *zap.Logger) (*AsyncKafkaEventPublisher, error) {
+	config, err := newProducerConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// Batches are only worth it when nobody waits for each send
+	config.Producer.Flush.Bytes = cfg.KafkaBatchSize
+	config.Producer.Flush.Frequency = time.Duration(cfg.KafkaLingerMs) * time.Millisecond
+	config.Producer.Return.Errors = true
+
+	payloadCipher, err := NewPayloadCipher(cfg.EventEncryptionKeys, cfg.EventEncryptionActiveKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event encryption config: %w", err)
+	}
+	logEncryption(logger, payloadCipher)
+
+	client, err := sarama.NewClient(cfg.KafkaBrokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
+	}
+	producer, err := sarama.NewAsyncProducerFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
+	}
+
+	logger.Info("Kafka async publishing enabled", zap.Int("queue_size", cfg.KafkaAsyncQueueSize))
+	messages := &KafkaEventPublisher{client: client, cipher: payloadCipher, logger: logger, config: cfg}
+	return newAsyncKafkaEventPublisher(messages, producer, cfg.KafkaAsyncQueueSize, logger), nil
+}
+
+// newAsyncKafkaEventPublisher starts the workers that feed producer and collect its results
+func newAsyncKafkaEventPublisher(messages *KafkaEventPublisher, producer sarama.AsyncProducer, queueSize int, logger *zap.Logger) *AsyncKafkaEventPublisher {
+	p := &AsyncKafkaEventPublisher{
+		messages:   messages,
+		producer:   producer,
+		queue:      make(chan *asyncMessage, queueSize),
+		retryDelay: 100 * time.Millisecond,
+		logger:     logger,
+		stop:       make(chan struct{}),
+		dispatched: make(chan struct{}),
+	}
+	p.collectors.Add(2)
+	go p.dispatch()
+	go p.collectSuccesses()
+	go p.collectErrors()
+	return p
+}
+
+// Publish queues the event and returns without waiting for Kafka. It fails when the
+// queue is full, so the caller sees the same error as a failed sync publish.
+func (p *AsyncKafkaEventPublisher) Publish(ctx context.Context, event interface{}) (err error) {
+	if err := p.inflight.begin(); err != nil {
+		return err
+	}
+
+	eventType := p.messages.getEventType(event)
+	ctx, span := startPublishSpan(ctx, eventType)
+	defer func() { endSpan(span, err) }()
+
+	message, err := p.messages.buildMessage(ctx, event)
+	if err != nil {
+		p.inflight.end()
+		return err
+	}
+	span.SetAttributes(semconv.MessagingDestinationName(message.Topic))
+
+	if !p.enqueue(&asyncMessage{message: message, eventType: eventType, attempt: 1, queuedAt: time.Now()}) {
+		p.inflight.end()
+		metrics.KafkaAsyncEvents.WithLabelValues("rejected").Inc()
+		metrics.KafkaMessagesPublished.WithLabelValues(message.Topic, eventType, "error").Inc()
+		return fmt.Errorf("async publish queue is full (%d events)", cap(p.queue))
+	}
+	metrics.KafkaAsyncEvents.WithLabelValues("queued").Inc()
+	return nil
+}
+
+// enqueue adds m to the local queue unless it is full
+func (p *AsyncKafkaEventPublisher) enqueue(m *asyncMessage) bool {
+	select {
+	case p.queue <- m:
+		metrics.KafkaAsyncQueueLength.Inc()
+		return true
+	default:
+		return false
+	}
+}
+
+// dispatch hands the queued events to the producer
+func (p *AsyncKafkaEventPublisher) dispatch() {
+	defer close(p.dispatched)
+	for {
+		select {
+		case m := <-p.queue:
+			metrics.KafkaAsyncQueueLength.Dec()
+			// A fresh message: sarama keeps retry state in the one it returned
+			p.producer.Input() <- &sarama.ProducerMessage{
+				Topic:    m.message.Topic,
+				Key:      m.message.Key,
+				Value:    m.message.Value,
+				Headers:  m.message.Headers,
+				Metadata: m,
+			}
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// collectSuccesses records the events acknowledged by Kafka
+func (p *AsyncKafkaEventPublisher) collectSuccesses() {
+	defer p.collectors.Done()
+	for msg := range p.producer.Successes() {
+		m := msg.Metadata.(*asyncMessage)
+		p.logger.Info("Event published to Kafka",
+			zap.String("topic", msg.Topic),
+			zap.Int32("partition", msg.Partition),
+			zap.Int64("offset", msg.Offset),
+			zap.String("event-type", m.eventType),
+			zap.Int("attempt", m.attempt),
+		)
+		p.finish(m, "success")
+	}
+}
+
+// collectErrors queues failed events again with backoff, or drops them after
+// asyncPublishAttempts
+func (p *AsyncKafkaEventPublisher) collectErrors() {
+	defer p.collectors.Done()
+	for perr := range p.producer.Errors() {
+		m := perr.Msg.Metadata.(*asyncMessage)
+		if m.attempt >= asyncPublishAttempts {
+			p.logger.Error("Failed to publish event to Kafka, event dropped",
+				zap.String("topic", perr.Msg.Topic),
+				zap.String("event-type", m.eventType),
+				zap.Int("attempts", m.attempt),
+				zap.Error(perr.Err),
+			)
+			metrics.KafkaAsyncEvents.WithLabelValues("failed").Inc()
+			p.finish(m, "error")
+			continue
+		}
+
+		p.logger.Warn("Failed to publish event to Kafka, retrying",
+			zap.String("topic", perr.Msg.Topic),
+			zap.String("event-type", m.eventType),
+			zap.Int("attempt", m.attempt),
+			zap.Error(perr.Err),
+		)
+		metrics.KafkaAsyncEvents.WithLabelValues("retried").Inc()
+		delay := p.retryDelay * time.Duration(1<<uint(m.attempt-1))
+		m.attempt++
+		time.AfterFunc(delay, func() {
+			if !p.enqueue(m) {
+				p.logger.Error("Async publish queue is full, event dropped",
+					zap.String("topic", m.message.Topic),
+					zap.String("event-type", m.eventType),
+				)
+				metrics.KafkaAsyncEvents.WithLabelValues("failed").Inc()
+				p.finish(m, "error")
+			}
+		})
+	}
+}
+
+// finish records the outcome of an event that left the publisher
+func (p *AsyncKafkaEventPublisher) finish(m *asyncMessage, outcome string) {
+	metrics.KafkaMessagesPublished.WithLabelValues(m.message.Topic, m.eventType, outcome).Inc()
+	metrics.KafkaPublishDuration.WithLabelValues(m.message.Topic).Observe(time.Since(m.queuedAt).Seconds())
+	p.inflight.end()
+}
+
+// Shutdown stops accepting events, waits until every queued event is acknowledged or
+// dropped, and closes the producer. If ctx ends first the producer is left open and the
+// events still queued are lost.
+func (p *AsyncKafkaEventPublisher) Shutdown(ctx context.Context) error {
+	if err := p.inflight.drain(ctx); err != nil {
+		return err
+	}
+	return p.Close()
+}
+
+// Close stops the workers and closes the producer; queued events are not waited for
+func (p *AsyncKafkaEventPublisher) Close() (err error) {
+	p.closeOnce.Do(func() {
+		// The dispatcher must be out of Input() before the producer closes it
+		close(p.stop)
+		<-p.dispatched
+		err = p.producer.Close()
+		p.collectors.Wait()
+		if p.messages.client != nil {
+			if closeErr := p.messages.client.Close(); err == nil {
+				err = closeErr
+			}
+		}
+	})
+	return err
+}
+
+// Ping refreshes the metadata of the event topics. Used by the readiness probe.
+func (p *AsyncKafkaEventPublisher) Ping(ctx context.Context) error {
+	return p.messages.Ping(ctx)
+}
@@ -0,0 +1,106 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"command-service/internal/config"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newTestAsyncPublisher returns an async publisher over a mock producer with short retries
+func newTestAsyncPublisher(t *testing.T, queueSize int) (*AsyncKafkaEventPublisher, *mocks.AsyncProducer) {
+	producerConfig := mocks.NewTestConfig()
+	producerConfig.Producer.Return.Successes = true
+	producer := mocks.NewAsyncProducer(t, producerConfig)
+	messages := &KafkaEventPublisher{logger: zap.NewNop(), config: &config.Config{KafkaTopicStock: "inventory.stock"}}
+	publisher := newAsyncKafkaEventPublisher(messages, producer, queueSize, zap.NewNop())
+	publisher.retryDelay = time.Millisecond
+	return publisher, producer
+}
+
+func stockAdjusted() StockAdjustedEvent {
+	return StockAdjustedEvent{ItemID: uuid.New().String(), SKU: "SKU-001", Quantity: 5}
+}
+
+func TestAsyncKafkaEventPublisher_PublishDoesNotWait(t *testing.T) {
+	publisher, producer := newTestAsyncPublisher(t, 10)
+	release := make(chan struct{})
+	var sent *sarama.ProducerMessage
+	producer.ExpectInputWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		<-release
+		sent = msg
+		return nil
+	})
+
+	// Publish returns while Kafka has not answered
+	ctx := context.WithValue(context.Background(), "request_id", "req-123")
+	done := make(chan error, 1)
+	go func() { done <- publisher.Publish(ctx, stockAdjusted()) }()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Publish waited for Kafka")
+	}
+	assert.Equal(t, 1, publisher.inflight.pending())
+
+	// Shutdown waits for the acknowledgement and closes the producer
+	close(release)
+	require.NoError(t, publisher.Shutdown(context.Background()))
+	assert.Equal(t, "inventory.stock", sent.Topic)
+	headers := map[string]string{}
+	for _, h := range sent.Headers {
+		headers[string(h.Key)] = string(h.Value)
+	}
+	assert.Equal(t, "req-123", headers[RequestIDHeader])
+	assert.ErrorIs(t, publisher.Publish(context.Background(), stockAdjusted()), ErrPublisherClosed)
+}
+
+func TestAsyncKafkaEventPublisher_RetriesFailedSends(t *testing.T) {
+	publisher, producer := newTestAsyncPublisher(t, 10)
+	producer.ExpectInputAndFail(sarama.ErrNotLeaderForPartition)
+	producer.ExpectInputAndFail(sarama.ErrNotLeaderForPartition)
+	producer.ExpectInputAndSucceed()
+
+	require.NoError(t, publisher.Publish(context.Background(), stockAdjusted()))
+
+	// The mock producer fails on Close if an expected send did not happen
+	require.NoError(t, publisher.Shutdown(context.Background()))
+}
+
+func TestAsyncKafkaEventPublisher_DropsAfterAttempts(t *testing.T) {
+	publisher, producer := newTestAsyncPublisher(t, 10)
+	for i := 0; i < asyncPublishAttempts; i++ {
+		producer.ExpectInputAndFail(errors.New("broker unavailable"))
+	}
+
+	require.NoError(t, publisher.Publish(context.Background(), stockAdjusted()))
+
+	// A dropped event no longer holds the shutdown back
+	require.NoError(t, publisher.Shutdown(context.Background()))
+	assert.Zero(t, publisher.inflight.pending())
+}
+
+func TestAsyncKafkaEventPublisher_QueueFull(t *testing.T) {
+	// Without workers nothing leaves the queue
+	publisher := &AsyncKafkaEventPublisher{
+		messages: &KafkaEventPublisher{logger: zap.NewNop(), config: &config.Config{KafkaTopicStock: "inventory.stock"}},
+		queue:    make(chan *asyncMessage, 1),
+		logger:   zap.NewNop(),
+	}
+
+	require.NoError(t, publisher.Publish(context.Background(), stockAdjusted()))
+	err := publisher.Publish(context.Background(), stockAdjusted())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "async publish queue is full (1 events)")
+	assert.Equal(t, 1, publisher.inflight.pending(), "a rejected event is not in flight")
+}
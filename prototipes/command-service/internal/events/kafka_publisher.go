@@ -4,24 +4,92 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"command-service/internal/config"
+	"command-service/internal/tenant"
+	"command-service/pkg/metrics"
+	"command-service/pkg/tracing"
 
 	"github.com/IBM/sarama"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+// Kafka headers that attribute an event to the user and request that caused it
+const (
+	ActorHeader     = "actor"
+	RequestIDHeader = "request-id"
+)
+
+// TenantHeader carries the tenant of the event, also in the envelope, so consumers can
+// filter messages without parsing them
+const TenantHeader = "tenant-id"
+
+// Request context keys copied into the attribution headers. They are set by
+// middleware.AuthMiddleware and middleware.RequestIDMiddleware.
+const (
+	actorContextKey     = "username"
+	requestIDContextKey = "request_id"
+)
+
+// RequestIDFromContext returns the ID of the request an event published with ctx is
+// attributed to, or "" outside a request
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey).(string)
+	return requestID
+}
+
 // KafkaEventPublisher implements EventPublisher using Kafka
 type KafkaEventPublisher struct {
+	client   sarama.Client // owns the broker connections of producer
 	producer sarama.SyncProducer
+	cipher   *PayloadCipher // nil when payload encryption is disabled
 	logger   *zap.Logger
 	config   *config.Config
+	inflight inFlight // publishes and sends Shutdown waits for
 }
 
 // NewKafkaEventPublisher creates a new Kafka event publisher
 func NewKafkaEventPublisher(cfg *config.Config, logger *zap.Logger) (EventPublisher, error) {
+	config, err := newProducerConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	payloadCipher, err := NewPayloadCipher(cfg.EventEncryptionKeys, cfg.EventEncryptionActiveKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event encryption config: %w", err)
+	}
+	logEncryption(logger, payloadCipher)
+
+	client, err := sarama.NewClient(cfg.KafkaBrokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
+	}
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
+	}
+
+	return &KafkaEventPublisher{
+		client:   client,
+		producer: producer,
+		cipher:   payloadCipher,
+		logger:   logger,
+		config:   cfg,
+	}, nil
+}
+
+// newProducerConfig returns the producer settings of cfg (acks, retries, idempotence,
+// TLS and SASL), shared by the sync and async publishers
+func newProducerConfig(cfg *config.Config) (*sarama.Config, error) {
 	config := sarama.NewConfig()
 	config.Producer.Return.Successes = true
 	config.Producer.RequiredAcks = sarama.WaitForAll
@@ -41,56 +109,48 @@ func NewKafkaEventPublisher(cfg *config.Config, logger *zap.Logger) (EventPublis
 		config.Producer.RequiredAcks = sarama.WaitForAll
 	}
 
-	producer, err := sarama.NewSyncProducer(cfg.KafkaBrokers, config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
+	if err := ConfigureKafkaSecurity(config, cfg); err != nil {
+		return nil, fmt.Errorf("invalid Kafka security config: %w", err)
 	}
+	return config, nil
+}
 
-	return &KafkaEventPublisher{
-		producer: producer,
-		logger:   logger,
-		config:   cfg,
-	}, nil
+// logEncryption logs the active key when payload encryption is enabled
+func logEncryption(logger *zap.Logger, payloadCipher *PayloadCipher) {
+	if payloadCipher != nil {
+		logger.Info("Event payload encryption enabled",
+			zap.String("algorithm", EncryptionAlgorithm),
+			zap.String("active_key_id", payloadCipher.ActiveKeyID()),
+		)
+	}
 }
 
 // Publish publishes an event to Kafka with retries and exponential backoff
-func (p *KafkaEventPublisher) Publish(ctx context.Context, event interface{}) error {
-	// Determine topic based on event type
-	topic, err := p.getTopicForEvent(event)
-	if err != nil {
-		return fmt.Errorf("failed to determine topic: %w", err)
+func (p *KafkaEventPublisher) Publish(ctx context.Context, event interface{}) (err error) {
+	if err := p.inflight.begin(); err != nil {
+		return err
 	}
+	defer p.inflight.end()
 
-	// Serialize event to JSON
-	eventJSON, err := json.Marshal(event)
-	if err != nil {
-		return fmt.Errorf("failed to marshal event: %w", err)
-	}
+	ctx, span := startPublishSpan(ctx, p.getEventType(event))
+	defer func() { endSpan(span, err) }()
 
-	// Create Kafka message
-	message := &sarama.ProducerMessage{
-		Topic: topic,
-		Value: sarama.ByteEncoder(eventJSON),
-		Headers: []sarama.RecordHeader{
-			{
-				Key:   []byte("event-type"),
-				Value: []byte(p.getEventType(event)),
-			},
-			{
-				Key:   []byte("event-id"),
-				Value: []byte(uuid.New().String()),
-			},
-			{
-				Key:   []byte("timestamp"),
-				Value: []byte(time.Now().UTC().Format(time.RFC3339)),
-			},
-		},
+	message, err := p.buildMessage(ctx, event)
+	if err != nil {
+		return err
 	}
+	topic := message.Topic
+	span.SetAttributes(semconv.MessagingDestinationName(topic))
 
-	// Set partition key if available
-	if partitionKey := p.getPartitionKey(event); partitionKey != "" {
-		message.Key = sarama.StringEncoder(partitionKey)
-	}
+	start := time.Now()
+	defer func() {
+		outcome := "success"
+		if err != nil {
+			outcome = "error"
+		}
+		metrics.KafkaMessagesPublished.WithLabelValues(topic, p.getEventType(event), outcome).Inc()
+		metrics.KafkaPublishDuration.WithLabelValues(topic).Observe(time.Since(start).Seconds())
+	}()
 
 	// Retry with exponential backoff
 	maxRetries := 3
@@ -108,7 +168,10 @@ func (p *KafkaEventPublisher) Publish(ctx context.Context, event interface{}) er
 		sendCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 		done := make(chan error, 1)
 		
+		// A send that times out keeps running; shutdown waits for it too
+		p.inflight.join()
 		go func() {
+			defer p.inflight.end()
 			partition, offset, err := p.producer.SendMessage(message)
 			if err != nil {
 				done <- err
@@ -163,10 +226,146 @@ func (p *KafkaEventPublisher) Publish(ctx context.Context, event interface{}) er
 	return fmt.Errorf("failed to publish event to Kafka after %d attempts", maxRetries)
 }
 
+// buildMessage serializes (and, if enabled, encrypts) an event into a Kafka message
+// with its topic, headers and partition key
+func (p *KafkaEventPublisher) buildMessage(ctx context.Context, event interface{}) (*sarama.ProducerMessage, error) {
+	// Determine topic based on event type (resolved for the tenant below)
+	topic, err := p.getTopicForEvent(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine topic: %w", err)
+	}
+
+	// Serialize the event inside its envelope
+	envelope, err := NewEnvelope(uuid.New().String(), event)
+	if err != nil {
+		return nil, err
+	}
+	envelope.TenantID = tenant.FromContext(ctx)
+	topic = p.config.TopicFor(topic, envelope.TenantID)
+	eventJSON, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event envelope: %w", err)
+	}
+
+	eventType := envelope.EventType
+	headers := []sarama.RecordHeader{
+		{
+			Key:   []byte("event-type"),
+			Value: []byte(eventType),
+		},
+		{
+			Key:   []byte("event-id"),
+			Value: []byte(envelope.EventID),
+		},
+		{
+			Key:   []byte("timestamp"),
+			Value: []byte(envelope.OccurredAt.Format(time.RFC3339)), // Same instant as the envelope, in UTC
+		},
+		{
+			Key:   []byte(SchemaVersionHeader),
+			Value: []byte(strconv.Itoa(envelope.SchemaVersion)),
+		},
+		{
+			Key:   []byte(TenantHeader),
+			Value: []byte(envelope.TenantID),
+		},
+	}
+
+	// Attribute the event to the request that caused it (shown in the activity feed)
+	if actor, ok := ctx.Value(actorContextKey).(string); ok && actor != "" {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(ActorHeader), Value: []byte(actor)})
+	}
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(RequestIDHeader), Value: []byte(requestID)})
+	}
+
+	// Continue the request's trace in the consumers
+	tracing.InjectKafka(ctx, &headers)
+
+	// Encrypt the payload; the event type is bound as associated data
+	if p.cipher != nil {
+		keyID, ciphertext, err := p.cipher.Encrypt(eventJSON, []byte(eventType))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt event: %w", err)
+		}
+		eventJSON = ciphertext
+		headers = append(headers,
+			sarama.RecordHeader{Key: []byte(EncryptionHeader), Value: []byte(EncryptionAlgorithm)},
+			sarama.RecordHeader{Key: []byte(EncryptionKeyIDHeader), Value: []byte(keyID)},
+		)
+	}
+
+	// Create Kafka message
+	message := &sarama.ProducerMessage{
+		Topic:   topic,
+		Value:   sarama.ByteEncoder(eventJSON),
+		Headers: headers,
+	}
+
+	// Set partition key if available
+	if partitionKey := p.getPartitionKey(event); partitionKey != "" {
+		message.Key = sarama.StringEncoder(tenantPartitionKey(envelope.TenantID, partitionKey))
+	}
+
+	return message, nil
+}
+
+// startPublishSpan starts the producer span of an event publish
+func startPublishSpan(ctx context.Context, eventType string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, "publish "+eventType,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystem("kafka"),
+			attribute.String("event.type", eventType),
+		),
+	)
+}
+
+// endSpan records err (if any) on span and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Shutdown stops accepting events, waits for the publishes in flight (retries included)
+// and closes the producer. If ctx ends first the producer is left open: a send still in
+// progress would hit its closed input channel, and the process is about to exit anyway.
+func (p *KafkaEventPublisher) Shutdown(ctx context.Context) error {
+	if err := p.inflight.drain(ctx); err != nil {
+		return err
+	}
+	return p.Close()
+}
+
 // Close closes the Kafka producer
 func (p *KafkaEventPublisher) Close() error {
 	if p.producer != nil {
-		return p.producer.Close()
+		if err := p.producer.Close(); err != nil {
+			return err
+		}
+	}
+	if p.client != nil {
+		return p.client.Close()
+	}
+	return nil
+}
+
+// Ping refreshes the metadata of the event topics (of the whole cluster when the topics
+// of the tenants are not listed), which fails when no broker answers. Used by the
+// readiness probe.
+func (p *KafkaEventPublisher) Ping(ctx context.Context) error {
+	if p.client == nil {
+		return fmt.Errorf("kafka client not initialized")
+	}
+	var topics []string
+	if p.config.KafkaTopicSubscription != config.TopicSubscriptionRegex {
+		topics = p.config.ListedTopics(p.config.KafkaTopicItems, p.config.KafkaTopicStock)
+	}
+	if err := p.client.RefreshMetadata(topics...); err != nil {
+		return fmt.Errorf("kafka metadata refresh failed: %w", err)
 	}
 	return nil
 }
@@ -174,10 +373,23 @@ func (p *KafkaEventPublisher) Close() error {
 // getTopicForEvent determines the Kafka topic based on event type
 func (p *KafkaEventPublisher) getTopicForEvent(event interface{}) (string, error) {
 	switch event.(type) {
-	case InventoryItemCreatedEvent, InventoryItemUpdatedEvent, InventoryItemDeletedEvent:
+	case InventoryItemCreatedEvent, InventoryItemUpdatedEvent, InventoryItemPatchedEvent, InventoryItemDeletedEvent, InventoryItemRestoredEvent,
+		ItemRelationAddedEvent, ItemRelationRemovedEvent, ItemImageAddedEvent, ItemImageRemovedEvent,
+		ItemIdentifierAddedEvent, ItemIdentifierRemovedEvent:
 		return p.config.KafkaTopicItems, nil
-	case StockAdjustedEvent, StockReservedEvent, StockReleasedEvent:
+	case StockAdjustedEvent, StockReservedEvent, StockReleasedEvent, StockCommittedEvent,
+		StoreReservationCreatedEvent, StoreReservationReleasedEvent, ReservationWaitlistedEvent,
+		ManualCorrectionEvent:
+		// Store reservations share the stock topic (keyed by item) so they are
+		// ordered with the rest of the item's stock events
 		return p.config.KafkaTopicStock, nil
+	case StoreCreatedEvent, StoreUpdatedEvent, StoreDeletedEvent, StoreCalendarUpdatedEvent:
+		return p.config.KafkaTopicStores, nil
+	case SupplierCreatedEvent, SupplierUpdatedEvent, SupplierDeletedEvent,
+		PurchaseOrderCreatedEvent, PurchaseOrderReceivedEvent, PurchaseOrderCancelledEvent:
+		// Purchasing is low volume master data: it shares the stores topic. The stock
+		// received for an order is published on the stock topic as StockAdjusted events.
+		return p.config.KafkaTopicStores, nil
 	default:
 		return "", fmt.Errorf("unknown event type: %T", event)
 	}
@@ -185,22 +397,7 @@ func (p *KafkaEventPublisher) getTopicForEvent(event interface{}) (string, error
 
 // getEventType returns the event type as string
 func (p *KafkaEventPublisher) getEventType(event interface{}) string {
-	switch event.(type) {
-	case InventoryItemCreatedEvent:
-		return "InventoryItemCreated"
-	case InventoryItemUpdatedEvent:
-		return "InventoryItemUpdated"
-	case InventoryItemDeletedEvent:
-		return "InventoryItemDeleted"
-	case StockAdjustedEvent:
-		return "StockAdjusted"
-	case StockReservedEvent:
-		return "StockReserved"
-	case StockReleasedEvent:
-		return "StockReleased"
-	default:
-		return "Unknown"
-	}
+	return TypeOf(event)
 }
 
 // getPartitionKey returns the partition key for the event (usually the item ID)
@@ -248,6 +445,73 @@ func (p *KafkaEventPublisher) getPartitionKey(event interface{}) string {
 		if id, ok := e.ItemID.(uuid.UUID); ok {
 			return id.String()
 		}
+	case StoreCreatedEvent:
+		return idToString(e.StoreID)
+	case StoreUpdatedEvent:
+		return idToString(e.StoreID)
+	case StoreDeletedEvent:
+		return idToString(e.StoreID)
+	case StoreCalendarUpdatedEvent:
+		return idToString(e.StoreID)
+	case SupplierCreatedEvent:
+		return idToString(e.SupplierID)
+	case SupplierUpdatedEvent:
+		return idToString(e.SupplierID)
+	case SupplierDeletedEvent:
+		return idToString(e.SupplierID)
+	case PurchaseOrderCreatedEvent:
+		return idToString(e.PurchaseOrderID)
+	case PurchaseOrderReceivedEvent:
+		return idToString(e.PurchaseOrderID)
+	case PurchaseOrderCancelledEvent:
+		return idToString(e.PurchaseOrderID)
+	case InventoryItemRestoredEvent:
+		return idToString(e.ItemID)
+	case InventoryItemPatchedEvent:
+		return idToString(e.ItemID)
+	case ItemRelationAddedEvent:
+		return idToString(e.ItemID)
+	case ItemRelationRemovedEvent:
+		return idToString(e.ItemID)
+	case ItemImageAddedEvent:
+		return idToString(e.ItemID)
+	case ItemImageRemovedEvent:
+		return idToString(e.ItemID)
+	case ItemIdentifierAddedEvent:
+		return idToString(e.ItemID)
+	case ItemIdentifierRemovedEvent:
+		return idToString(e.ItemID)
+	case StoreReservationCreatedEvent:
+		return idToString(e.ItemID)
+	case StoreReservationReleasedEvent:
+		return idToString(e.ItemID)
+	case ReservationWaitlistedEvent:
+		return idToString(e.ItemID)
+	case ManualCorrectionEvent:
+		return idToString(e.ItemID)
+	case StockCommittedEvent:
+		return idToString(e.ItemID)
+	}
+	return ""
+}
+
+// tenantPartitionKey prefixes the partition key with the tenant, so aggregates of
+// different tenants with the same ID never share a key. The default tenant keeps the bare
+// key: its events stay on the partitions they were published to before multi-tenancy.
+func tenantPartitionKey(tenantID, key string) string {
+	if tenantID == tenant.Default {
+		return key
+	}
+	return tenantID + "/" + key
+}
+
+// idToString converts an aggregate ID (string or uuid.UUID) to its string form
+func idToString(id interface{}) string {
+	switch v := id.(type) {
+	case string:
+		return v
+	case uuid.UUID:
+		return v.String()
 	}
 	return ""
 }
@@ -2,13 +2,22 @@ package events
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
 	"command-service/internal/config"
+	"command-service/internal/tenant"
 
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.uber.org/zap"
 )
 
@@ -98,10 +107,15 @@ func TestKafkaEventPublisher_GetEventType_AllTypes(t *testing.T) {
 	}{
 		{"InventoryItemCreated", InventoryItemCreatedEvent{}, "InventoryItemCreated"},
 		{"InventoryItemUpdated", InventoryItemUpdatedEvent{}, "InventoryItemUpdated"},
+		{"InventoryItemPatched", InventoryItemPatchedEvent{}, "InventoryItemPatched"},
 		{"InventoryItemDeleted", InventoryItemDeletedEvent{}, "InventoryItemDeleted"},
+		{"InventoryItemRestored", InventoryItemRestoredEvent{}, "InventoryItemRestored"},
 		{"StockAdjusted", StockAdjustedEvent{}, "StockAdjusted"},
 		{"StockReserved", StockReservedEvent{}, "StockReserved"},
 		{"StockReleased", StockReleasedEvent{}, "StockReleased"},
+		{"ReservationWaitlisted", ReservationWaitlistedEvent{}, "ReservationWaitlisted"},
+		{"ManualCorrection", ManualCorrectionEvent{}, "ManualCorrection"},
+		{"StockCommitted", StockCommittedEvent{}, "StockCommitted"},
 		{"Unknown", "unknown", "Unknown"},
 	}
 
@@ -116,8 +130,9 @@ func TestKafkaEventPublisher_GetEventType_AllTypes(t *testing.T) {
 func TestKafkaEventPublisher_GetTopicForEvent_AllTypes(t *testing.T) {
 	logger := zap.NewNop()
 	cfg := &config.Config{
-		KafkaTopicItems: "inventory.items",
-		KafkaTopicStock: "inventory.stock",
+		KafkaTopicItems:  "inventory.items",
+		KafkaTopicStock:  "inventory.stock",
+		KafkaTopicStores: "inventory.stores",
 	}
 
 	publisher := &KafkaEventPublisher{
@@ -133,10 +148,23 @@ func TestKafkaEventPublisher_GetTopicForEvent_AllTypes(t *testing.T) {
 	}{
 		{"InventoryItemCreated", InventoryItemCreatedEvent{}, "inventory.items", false},
 		{"InventoryItemUpdated", InventoryItemUpdatedEvent{}, "inventory.items", false},
+		{"InventoryItemPatched", InventoryItemPatchedEvent{}, "inventory.items", false},
 		{"InventoryItemDeleted", InventoryItemDeletedEvent{}, "inventory.items", false},
+		{"InventoryItemRestored", InventoryItemRestoredEvent{}, "inventory.items", false},
+		{"ItemImageAdded", ItemImageAddedEvent{}, "inventory.items", false},
+		{"ItemImageRemoved", ItemImageRemovedEvent{}, "inventory.items", false},
+		{"ItemIdentifierAdded", ItemIdentifierAddedEvent{}, "inventory.items", false},
+		{"ItemIdentifierRemoved", ItemIdentifierRemovedEvent{}, "inventory.items", false},
 		{"StockAdjusted", StockAdjustedEvent{}, "inventory.stock", false},
 		{"StockReserved", StockReservedEvent{}, "inventory.stock", false},
 		{"StockReleased", StockReleasedEvent{}, "inventory.stock", false},
+		{"ReservationWaitlisted", ReservationWaitlistedEvent{}, "inventory.stock", false},
+		{"ManualCorrection", ManualCorrectionEvent{}, "inventory.stock", false},
+		{"StockCommitted", StockCommittedEvent{}, "inventory.stock", false},
+		{"SupplierCreated", SupplierCreatedEvent{}, "inventory.stores", false},
+		{"PurchaseOrderCreated", PurchaseOrderCreatedEvent{}, "inventory.stores", false},
+		{"PurchaseOrderReceived", PurchaseOrderReceivedEvent{}, "inventory.stores", false},
+		{"PurchaseOrderCancelled", PurchaseOrderCancelledEvent{}, "inventory.stores", false},
 		{"Unknown", "unknown", "", true},
 	}
 
@@ -213,3 +241,139 @@ func TestInMemoryEventPublisher_Publish(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+
+func TestKafkaEventPublisher_Publish_AttributionHeaders(t *testing.T) {
+	// Setup: a mock producer that captures the sent message
+	producer := mocks.NewSyncProducer(t, nil)
+	var sent *sarama.ProducerMessage
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		sent = msg
+		return nil
+	})
+	publisher := &KafkaEventPublisher{
+		producer: producer,
+		logger:   zap.NewNop(),
+		config:   &config.Config{KafkaTopicStock: "inventory.stock"},
+	}
+
+	ctx := context.WithValue(context.Background(), "username", "operator")
+	ctx = context.WithValue(ctx, "request_id", "req-123")
+
+	// Execute
+	err := publisher.Publish(ctx, StockAdjustedEvent{ItemID: uuid.New().String(), SKU: "SKU-001", Quantity: 5})
+
+	// Assert
+	assert.NoError(t, err)
+	headers := map[string]string{}
+	for _, h := range sent.Headers {
+		headers[string(h.Key)] = string(h.Value)
+	}
+	assert.Equal(t, "operator", headers[ActorHeader])
+	assert.Equal(t, "req-123", headers[RequestIDHeader])
+	assert.NoError(t, producer.Close())
+}
+
+func TestKafkaEventPublisher_Publish_TraceContextHeader(t *testing.T) {
+	// Setup: record spans in memory and propagate W3C trace context
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator()) })
+
+	producer := mocks.NewSyncProducer(t, nil)
+	var sent *sarama.ProducerMessage
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		sent = msg
+		return nil
+	})
+	publisher := &KafkaEventPublisher{
+		producer: producer,
+		logger:   zap.NewNop(),
+		config:   &config.Config{KafkaTopicStock: "inventory.stock"},
+	}
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "POST /api/v1/inventory/items/:id/adjust")
+
+	// Execute
+	err := publisher.Publish(ctx, StockAdjustedEvent{ItemID: uuid.New().String(), SKU: "SKU-001", Quantity: 5})
+	parent.End()
+
+	// Assert: the header carries the trace of the request and the publish span is its child
+	assert.NoError(t, err)
+	var traceparent string
+	for _, h := range sent.Headers {
+		if string(h.Key) == "traceparent" {
+			traceparent = string(h.Value)
+		}
+	}
+	assert.Contains(t, traceparent, parent.SpanContext().TraceID().String())
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "publish StockAdjusted", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Contains(t, traceparent, spans[0].SpanContext().SpanID().String())
+	assert.NoError(t, producer.Close())
+}
+
+func TestKafkaEventPublisher_Publish_Tenant(t *testing.T) {
+	itemID := uuid.New().String()
+	for _, tc := range []struct {
+		tenant string
+		key    string
+	}{
+		{tenant.Default, itemID},
+		{"brand-a", "brand-a/" + itemID},
+	} {
+		producer := mocks.NewSyncProducer(t, nil)
+		var sent *sarama.ProducerMessage
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+			sent = msg
+			return nil
+		})
+		publisher := &KafkaEventPublisher{
+			producer: producer,
+			logger:   zap.NewNop(),
+			config:   &config.Config{KafkaTopicStock: "inventory.stock"},
+		}
+
+		ctx := tenant.WithTenant(context.Background(), tc.tenant)
+		require.NoError(t, publisher.Publish(ctx, StockAdjustedEvent{ItemID: itemID, SKU: "SKU-001", Quantity: 5}))
+
+		// The tenant travels in a header, in the envelope and in the partition key
+		for _, h := range sent.Headers {
+			if string(h.Key) == TenantHeader {
+				assert.Equal(t, tc.tenant, string(h.Value))
+			}
+		}
+		key, err := sent.Key.Encode()
+		require.NoError(t, err)
+		assert.Equal(t, tc.key, string(key))
+		value, err := sent.Value.Encode()
+		require.NoError(t, err)
+		var envelope Envelope
+		require.NoError(t, json.Unmarshal(value, &envelope))
+		assert.Equal(t, tc.tenant, envelope.TenantID)
+		assert.NoError(t, producer.Close())
+	}
+}
+
+func TestKafkaEventPublisher_Publish_TenantTopic(t *testing.T) {
+	cfg := &config.Config{KafkaTopicStock: "inventory.stock.{tenant}", KafkaDedicatedTenants: "brand-a"}
+	for tenantID, topic := range map[string]string{
+		"brand-a":      "inventory.stock.brand-a",
+		"brand-b":      "inventory.stock.shared",
+		tenant.Default: "inventory.stock.shared",
+	} {
+		producer := mocks.NewSyncProducer(t, nil)
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+			assert.Equal(t, topic, msg.Topic, tenantID)
+			return nil
+		})
+		publisher := &KafkaEventPublisher{producer: producer, logger: zap.NewNop(), config: cfg}
+
+		ctx := tenant.WithTenant(context.Background(), tenantID)
+		require.NoError(t, publisher.Publish(ctx, StockAdjustedEvent{ItemID: uuid.New().String(), SKU: "SKU-001", Quantity: 5}))
+		assert.NoError(t, producer.Close())
+	}
+}
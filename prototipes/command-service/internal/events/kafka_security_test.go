@@ -0,0 +1,111 @@
+package events
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"command-service/internal/config"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCertificate writes a self-signed certificate and its key as PEM files
+func writeTestCertificate(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "kafka-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "client.pem")
+	keyFile = filepath.Join(dir, "client.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestConfigureKafkaSecurity_PlaintextByDefault(t *testing.T) {
+	saramaConfig := sarama.NewConfig()
+
+	require.NoError(t, ConfigureKafkaSecurity(saramaConfig, &config.Config{}))
+	assert.False(t, saramaConfig.Net.TLS.Enable)
+	assert.False(t, saramaConfig.Net.SASL.Enable)
+}
+
+func TestConfigureKafkaSecurity_MutualTLS(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t, t.TempDir())
+	saramaConfig := sarama.NewConfig()
+
+	err := ConfigureKafkaSecurity(saramaConfig, &config.Config{
+		KafkaTLSEnabled:  true,
+		KafkaTLSCAFile:   certFile,
+		KafkaTLSCertFile: certFile,
+		KafkaTLSKeyFile:  keyFile,
+	})
+	require.NoError(t, err)
+	assert.True(t, saramaConfig.Net.TLS.Enable)
+	require.NotNil(t, saramaConfig.Net.TLS.Config)
+	assert.NotNil(t, saramaConfig.Net.TLS.Config.RootCAs)
+	assert.Len(t, saramaConfig.Net.TLS.Config.Certificates, 1)
+	assert.NoError(t, saramaConfig.Validate())
+}
+
+func TestConfigureKafkaSecurity_BadTLSFiles(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0o600))
+
+	for _, cfg := range []*config.Config{
+		{KafkaTLSEnabled: true, KafkaTLSCAFile: filepath.Join(dir, "missing.pem")},
+		{KafkaTLSEnabled: true, KafkaTLSCAFile: notPEM},
+		{KafkaTLSEnabled: true, KafkaTLSCertFile: notPEM, KafkaTLSKeyFile: notPEM},
+	} {
+		assert.Error(t, ConfigureKafkaSecurity(sarama.NewConfig(), cfg))
+	}
+}
+
+func TestConfigureKafkaSecurity_SASL(t *testing.T) {
+	for _, mechanism := range []string{sarama.SASLTypePlaintext, sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512} {
+		saramaConfig := sarama.NewConfig()
+		err := ConfigureKafkaSecurity(saramaConfig, &config.Config{
+			KafkaSASLMechanism: mechanism,
+			KafkaSASLUsername:  "inventory",
+			KafkaSASLPassword:  "secret",
+		})
+		require.NoError(t, err, mechanism)
+		assert.True(t, saramaConfig.Net.SASL.Enable)
+		assert.Equal(t, sarama.SASLMechanism(mechanism), saramaConfig.Net.SASL.Mechanism)
+		assert.Equal(t, "inventory", saramaConfig.Net.SASL.User)
+		assert.NoError(t, saramaConfig.Validate(), mechanism)
+
+		if mechanism != sarama.SASLTypePlaintext {
+			client := saramaConfig.Net.SASL.SCRAMClientGeneratorFunc()
+			require.NoError(t, client.Begin("inventory", "secret", ""))
+			first, err := client.Step("")
+			require.NoError(t, err)
+			assert.Contains(t, first, "n=inventory")
+			assert.False(t, client.Done())
+		}
+	}
+
+	assert.Error(t, ConfigureKafkaSecurity(sarama.NewConfig(), &config.Config{KafkaSASLMechanism: "GSSAPI"}))
+}
@@ -0,0 +1,119 @@
+package events
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kafka headers that mark an encrypted payload. The key id lets consumers pick the
+// right key while keys are being rotated.
+const (
+	EncryptionHeader      = "encryption"
+	EncryptionKeyIDHeader = "encryption-key-id"
+	EncryptionAlgorithm   = "AES-GCM"
+)
+
+// ErrUnknownEncryptionKey is returned when a payload was encrypted with a key that is not configured
+var ErrUnknownEncryptionKey = errors.New("unknown encryption key id")
+
+// PayloadCipher encrypts and decrypts event payloads with AES-GCM.
+// Several keys can be configured so old events stay readable after a rotation;
+// only the active key is used to encrypt.
+type PayloadCipher struct {
+	keys        map[string]cipher.AEAD
+	activeKeyID string
+}
+
+// NewPayloadCipher parses keySpec ("id:base64key,id:base64key", 16/24/32 byte keys).
+// activeKeyID selects the encryption key; when empty the first key is used.
+// It returns nil, nil when keySpec is empty (encryption disabled).
+func NewPayloadCipher(keySpec, activeKeyID string) (*PayloadCipher, error) {
+	if strings.TrimSpace(keySpec) == "" {
+		return nil, nil
+	}
+
+	pc := &PayloadCipher{keys: make(map[string]cipher.AEAD)}
+	for _, entry := range strings.Split(keySpec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid encryption key %q: expected id:base64key", entry)
+		}
+		keyID := parts[0]
+
+		key, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %q: %w", keyID, err)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %q: %w", keyID, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %q: %w", keyID, err)
+		}
+
+		if _, exists := pc.keys[keyID]; exists {
+			return nil, fmt.Errorf("duplicate encryption key id %q", keyID)
+		}
+		pc.keys[keyID] = aead
+		if pc.activeKeyID == "" {
+			pc.activeKeyID = keyID
+		}
+	}
+
+	if activeKeyID != "" {
+		if _, ok := pc.keys[activeKeyID]; !ok {
+			return nil, fmt.Errorf("active encryption key %q is not configured", activeKeyID)
+		}
+		pc.activeKeyID = activeKeyID
+	}
+	if pc.activeKeyID == "" {
+		return nil, fmt.Errorf("no encryption keys configured")
+	}
+
+	return pc, nil
+}
+
+// ActiveKeyID returns the id of the key used to encrypt
+func (pc *PayloadCipher) ActiveKeyID() string {
+	return pc.activeKeyID
+}
+
+// Encrypt seals plaintext with the active key. The output is nonce||ciphertext.
+// aad is authenticated but not encrypted (the event type, so a payload cannot be
+// replayed under another type).
+func (pc *PayloadCipher) Encrypt(plaintext, aad []byte) (string, []byte, error) {
+	aead := pc.keys[pc.activeKeyID]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return pc.activeKeyID, aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// Decrypt opens a payload produced by Encrypt with the key it was encrypted with
+func (pc *PayloadCipher) Decrypt(keyID string, ciphertext, aad []byte) ([]byte, error) {
+	aead, ok := pc.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownEncryptionKey, keyID)
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted payload too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, aad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt payload: %w", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,83 @@
+package events
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+func TestPayloadCipher_RoundTrip(t *testing.T) {
+	pc, err := NewPayloadCipher("k1:"+testKey(1), "")
+	require.NoError(t, err)
+	require.NotNil(t, pc)
+
+	plaintext := []byte(`{"ItemID":"abc","Quantity":5}`)
+	keyID, ciphertext, err := pc.Encrypt(plaintext, []byte("StockAdjusted"))
+	require.NoError(t, err)
+	assert.Equal(t, "k1", keyID)
+	assert.NotContains(t, string(ciphertext), "ItemID")
+
+	decrypted, err := pc.Decrypt(keyID, ciphertext, []byte("StockAdjusted"))
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	// The event type is authenticated: the payload cannot be replayed as another type
+	_, err = pc.Decrypt(keyID, ciphertext, []byte("StockReserved"))
+	assert.Error(t, err)
+}
+
+func TestPayloadCipher_Rotation(t *testing.T) {
+	oldCipher, err := NewPayloadCipher("k1:"+testKey(1), "")
+	require.NoError(t, err)
+	_, oldPayload, err := oldCipher.Encrypt([]byte("old"), nil)
+	require.NoError(t, err)
+
+	// After rotating to k2, k1 is kept so events already on the topic stay readable
+	rotated, err := NewPayloadCipher("k1:"+testKey(1)+",k2:"+testKey(2), "k2")
+	require.NoError(t, err)
+	assert.Equal(t, "k2", rotated.ActiveKeyID())
+
+	decrypted, err := rotated.Decrypt("k1", oldPayload, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("old"), decrypted)
+
+	keyID, _, err := rotated.Encrypt([]byte("new"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "k2", keyID)
+
+	_, err = oldCipher.Decrypt("k2", oldPayload, nil)
+	assert.True(t, errors.Is(err, ErrUnknownEncryptionKey))
+}
+
+func TestNewPayloadCipher_Config(t *testing.T) {
+	pc, err := NewPayloadCipher("", "")
+	assert.NoError(t, err)
+	assert.Nil(t, pc, "empty key spec disables encryption")
+
+	invalid := []struct {
+		name      string
+		keySpec   string
+		activeKey string
+	}{
+		{"missing id", ":" + testKey(1), ""},
+		{"not base64", "k1:not-base64!", ""},
+		{"wrong key size", "k1:" + base64.StdEncoding.EncodeToString([]byte("short")), ""},
+		{"duplicate id", "k1:" + testKey(1) + ",k1:" + testKey(2), ""},
+		{"unknown active key", "k1:" + testKey(1), "k2"},
+	}
+
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewPayloadCipher(tc.keySpec, tc.activeKey)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"command-service/internal/config"
+
+	"github.com/IBM/sarama"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// rabbitMQBus carries the events on a RabbitMQ topic exchange (RABBITMQ_EXCHANGE); the
+// topic is the routing key. RabbitMQ only keeps a message in the queues bound when it
+// is published: a group's queue must exist (its consumer started once) before the
+// events it should get are published.
+type rabbitMQBus struct {
+	url      string
+	exchange string
+	group    string
+
+	mu      sync.Mutex // guards conn and channel, which are replaced on reconnect
+	conn    *amqp.Connection
+	channel *amqp.Channel // publishing channel, in confirm mode
+}
+
+// dialRabbitMQ connects to RABBITMQ_URL and declares the exchange
+func dialRabbitMQ(cfg *config.Config, group string) (*rabbitMQBus, error) {
+	bus := &rabbitMQBus{url: cfg.RabbitMQURL, exchange: cfg.RabbitMQExchange, group: group}
+	if err := bus.connect(); err != nil {
+		return nil, err
+	}
+	return bus, nil
+}
+
+// connect opens the connection and the publishing channel; callers hold mu
+func (b *rabbitMQBus) connect() error {
+	conn, err := amqp.Dial(b.url)
+	if err != nil {
+		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	channel, err := conn.Channel()
+	if err == nil {
+		err = channel.ExchangeDeclare(b.exchange, amqp.ExchangeTopic, true, false, false, false, nil)
+	}
+	if err == nil {
+		err = channel.Confirm(false)
+	}
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to set up RabbitMQ exchange %s: %w", b.exchange, err)
+	}
+	b.conn, b.channel = conn, channel
+	return nil
+}
+
+// connection returns the connection and publishing channel, reconnecting if either
+// was closed (the client does not reconnect by itself)
+func (b *rabbitMQBus) connection() (*amqp.Connection, *amqp.Channel, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.conn.IsClosed() || b.channel.IsClosed() {
+		b.conn.Close()
+		if err := b.connect(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return b.conn, b.channel, nil
+}
+
+// Send publishes message with its topic as routing key and waits for the broker to
+// confirm it
+func (b *rabbitMQBus) Send(ctx context.Context, message *sarama.ProducerMessage) (int64, error) {
+	publishing, err := amqpPublishing(message)
+	if err != nil {
+		return 0, err
+	}
+	_, channel, err := b.connection()
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := withSendTimeout(ctx)
+	defer cancel()
+	confirmation, err := channel.PublishWithDeferredConfirmWithContext(ctx, b.exchange, message.Topic, false, false, publishing)
+	if err != nil {
+		return 0, fmt.Errorf("failed to publish to RabbitMQ: %w", err)
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("RabbitMQ did not confirm the message: %w", err)
+	}
+	if !acked {
+		return 0, fmt.Errorf("RabbitMQ rejected the message")
+	}
+	return int64(confirmation.DeliveryTag), nil
+}
+
+// Consume binds a queue to every topic and handles its messages. A group shares one
+// durable queue named after it; without a group the queue is exclusive and deleted
+// when the consumer stops.
+func (b *rabbitMQBus) Consume(ctx context.Context, topics []string, handle func(*sarama.ConsumerMessage)) error {
+	conn, _, err := b.connection()
+	if err != nil {
+		return err
+	}
+	channel, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open RabbitMQ channel: %w", err)
+	}
+	defer channel.Close()
+
+	if err := channel.Qos(busPrefetch, 0, false); err != nil {
+		return fmt.Errorf("failed to set RabbitMQ prefetch: %w", err)
+	}
+	durable := b.group != ""
+	queue, err := channel.QueueDeclare(b.group, durable, !durable, !durable, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to declare RabbitMQ queue %q: %w", b.group, err)
+	}
+	for _, topic := range topics {
+		if err := channel.QueueBind(queue.Name, topic, b.exchange, false, nil); err != nil {
+			return fmt.Errorf("failed to bind %s to queue %s: %w", topic, queue.Name, err)
+		}
+	}
+	deliveries, err := channel.Consume(queue.Name, "", false, !durable, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to consume queue %s: %w", queue.Name, err)
+	}
+
+	for {
+		select {
+		case delivery, ok := <-deliveries:
+			if !ok {
+				return fmt.Errorf("RabbitMQ channel closed")
+			}
+			handle(consumerMessageFromAMQP(delivery))
+			// An unacknowledged message is delivered again when the channel closes
+			delivery.Ack(false)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Ping opens and closes a channel, which fails when the broker does not answer. Used
+// by the readiness probe.
+func (b *rabbitMQBus) Ping(ctx context.Context) error {
+	conn, _, err := b.connection()
+	if err != nil {
+		return err
+	}
+	channel, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("rabbitmq channel failed: %w", err)
+	}
+	return channel.Close()
+}
+
+// Close closes the connection
+func (b *rabbitMQBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.conn.Close()
+}
+
+// amqpPublishing converts a message to a persistent AMQP message, with its key and
+// headers as AMQP headers and its event ID as message ID
+func amqpPublishing(message *sarama.ProducerMessage) (amqp.Publishing, error) {
+	value, key, err := encodeMessage(message)
+	if err != nil {
+		return amqp.Publishing{}, err
+	}
+	headers := amqp.Table{}
+	if key != nil {
+		headers[busKeyHeader] = string(key)
+	}
+	for _, h := range message.Headers {
+		headers[string(h.Key)] = string(h.Value)
+	}
+	return amqp.Publishing{
+		Headers:      headers,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    messageHeader(message, "event-id"),
+		Timestamp:    time.Now().UTC(),
+		Body:         value,
+	}, nil
+}
+
+// consumerMessageFromAMQP converts a delivery to the shape the Kafka path works with;
+// the delivery tag is its offset
+func consumerMessageFromAMQP(delivery amqp.Delivery) *sarama.ConsumerMessage {
+	message := &sarama.ConsumerMessage{
+		Topic:     delivery.RoutingKey,
+		Value:     delivery.Body,
+		Offset:    int64(delivery.DeliveryTag),
+		Timestamp: delivery.Timestamp,
+	}
+	if message.Timestamp.IsZero() {
+		message.Timestamp = time.Now()
+	}
+	for key, value := range delivery.Headers {
+		var text string
+		switch v := value.(type) {
+		case string:
+			text = v
+		case []byte:
+			text = string(v)
+		default:
+			text = fmt.Sprint(v)
+		}
+		if key == busKeyHeader {
+			message.Key = []byte(text)
+			continue
+		}
+		message.Headers = append(message.Headers, &sarama.RecordHeader{Key: []byte(key), Value: []byte(text)})
+	}
+	return message
+}
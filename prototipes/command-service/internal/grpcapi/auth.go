@@ -0,0 +1,137 @@
+package grpcapi
+
+import (
+	"context"
+	"strings"
+
+	"command-service/internal/auth"
+	"command-service/internal/tenant"
+	"command-service/pkg/middleware"
+	inventoryv1 "command-service/proto/inventory/v1"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys read by the interceptor (gRPC metadata keys are lowercase)
+const (
+	authorizationMetadata = "authorization"
+	requestIDMetadata     = "x-request-id"
+)
+
+// methodPermissions is the permission each RPC needs, mirroring auth.RequiredPermission
+// for the HTTP method of its REST endpoint
+var methodPermissions = map[string]string{
+	inventoryv1.InventoryCommandService_CreateItem_FullMethodName:   auth.PermissionWrite,
+	inventoryv1.InventoryCommandService_UpdateItem_FullMethodName:   auth.PermissionWrite,
+	inventoryv1.InventoryCommandService_DeleteItem_FullMethodName:   auth.PermissionDelete,
+	inventoryv1.InventoryCommandService_AdjustStock_FullMethodName:  auth.PermissionWrite,
+	inventoryv1.InventoryCommandService_ReserveStock_FullMethodName: auth.PermissionWrite,
+	inventoryv1.InventoryCommandService_ReleaseStock_FullMethodName: auth.PermissionWrite,
+	inventoryv1.InventoryCommandService_CommitStock_FullMethodName:  auth.PermissionWrite,
+}
+
+// principalKey is the context key of the authenticated caller
+type principalKey struct{}
+
+// principal is the caller of an RPC, as read from its JWT
+type principal struct {
+	username string
+	userID   string
+	role     string
+	tenantID string
+}
+
+// UnaryAuthInterceptor is the gRPC counterpart of middleware.AuthMiddleware: it reads
+// the JWT from the "authorization: Bearer <token>" metadata, rejects expired and revoked
+// tokens and checks the RPC's permission. The request ID comes from "x-request-id" (or
+// is generated) and, with the username, attributes the published events like over REST.
+func UnaryAuthInterceptor(jwtManager *auth.JWTManager, rbac *auth.RBAC, tokenStore auth.TokenStore, logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		token, ok := bearerToken(md)
+		if !ok {
+			logger.Warn("Missing or malformed authorization metadata", zap.String("method", info.FullMethod))
+			return nil, status.Error(codes.Unauthenticated, "missing authorization metadata, expected: Bearer <token>")
+		}
+
+		claims, err := jwtManager.ValidateToken(token)
+		if err != nil {
+			if err == auth.ErrExpiredToken {
+				return nil, status.Error(codes.Unauthenticated, "token expired")
+			}
+			logger.Warn("Invalid token", zap.String("method", info.FullMethod), zap.Error(err))
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+
+		if claims.ID != "" {
+			revoked, err := tokenStore.IsAccessTokenRevoked(ctx, claims.ID)
+			if err != nil {
+				logger.Error("Failed to check token revocation", zap.Error(err))
+				return nil, status.Error(codes.Unavailable, "failed to check token revocation")
+			}
+			if revoked {
+				return nil, status.Error(codes.Unauthenticated, "token revoked")
+			}
+		}
+
+		tenantID := tenant.OrDefault(claims.TenantID)
+		if !tenant.Valid(tenantID) {
+			logger.Warn("Invalid tenant claim", zap.String("method", info.FullMethod), zap.String("tenant_id", tenantID))
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+
+		role := claims.Role
+		if role == "" {
+			role = auth.RoleViewer
+		}
+		permission, ok := methodPermissions[info.FullMethod]
+		if !ok {
+			permission = auth.PermissionWrite
+		}
+		if !rbac.HasPermission(role, permission) {
+			logger.Warn("Insufficient permissions",
+				zap.String("username", claims.Username),
+				zap.String("role", role),
+				zap.String("permission", permission),
+				zap.String("method", info.FullMethod),
+			)
+			return nil, status.Errorf(codes.PermissionDenied, "role %s lacks permission %s", role, permission)
+		}
+
+		requestID := firstValue(md, requestIDMetadata)
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+
+		ctx = context.WithValue(ctx, principalKey{}, principal{username: claims.Username, userID: claims.Subject, role: role, tenantID: tenantID})
+		// Same context keys the event publisher and the repositories read over REST
+		ctx = context.WithValue(ctx, "username", claims.Username)
+		ctx = tenant.WithTenant(ctx, tenantID)
+		ctx = context.WithValue(ctx, middleware.RequestIDContextKey, requestID)
+		grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, requestID))
+
+		return handler(ctx, req)
+	}
+}
+
+// bearerToken extracts the token of an "authorization: Bearer <token>" metadata entry
+func bearerToken(md metadata.MD) (string, bool) {
+	parts := strings.Split(firstValue(md, authorizationMetadata), " ")
+	if len(parts) != 2 || parts[0] != "Bearer" || parts[1] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
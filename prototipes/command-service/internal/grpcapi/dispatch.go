@@ -0,0 +1,148 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"command-service/internal/audit"
+	"command-service/internal/handlers"
+	"command-service/internal/tenant"
+	"command-service/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// dispatcher runs an RPC through the same InventoryHandler method as its REST endpoint,
+// in process: the request never touches the network, but it is validated, journaled,
+// saved and published exactly like the REST one. Authentication already happened in
+// UnaryAuthInterceptor, so the routes have no auth, rate limit or idempotency middleware.
+type dispatcher struct {
+	router *gin.Engine
+}
+
+func newDispatcher(inventory *handlers.InventoryHandler, auditLog *audit.Log, logger *zap.Logger) *dispatcher {
+	router := gin.New()
+	router.Use(gin.Recovery(), withPrincipal, audit.Middleware(auditLog, logger))
+
+	router.POST("/items", inventory.CreateItem)
+	router.PUT("/items/:id", inventory.UpdateItem)
+	router.DELETE("/items/:id", inventory.DeleteItem)
+	router.POST("/items/:id/adjust", inventory.AdjustStock)
+	router.POST("/items/:id/reserve", inventory.ReserveStock)
+	router.POST("/items/:id/release", inventory.ReleaseStock)
+	router.POST("/items/:id/commit", inventory.CommitStock)
+
+	return &dispatcher{router: router}
+}
+
+// withPrincipal sets the gin keys middleware.AuthMiddleware would have set
+func withPrincipal(c *gin.Context) {
+	if p, ok := c.Request.Context().Value(principalKey{}).(principal); ok {
+		c.Set("username", p.username)
+		c.Set("user_id", p.userID)
+		c.Set("role", p.role)
+		c.Set(tenant.ContextKey, p.tenantID)
+	}
+	if requestID, ok := c.Request.Context().Value(middleware.RequestIDContextKey).(string); ok {
+		c.Set(middleware.RequestIDContextKey, requestID)
+	}
+	c.Next()
+}
+
+// response is the status and JSON body written by a handler
+type response struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *response) Header() http.Header { return r.header }
+
+func (r *response) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+func (r *response) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+// call runs method path with body as JSON and decodes a successful response into out.
+// An error response becomes a gRPC status; conflict is the code of a 409 other than a
+// version conflict, which is always Aborted.
+func (d *dispatcher) call(ctx context.Context, method, path string, body, out interface{}, conflict codes.Code) (int, error) {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return 0, status.Errorf(codes.Internal, "failed to encode request: %v", err)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, path, &payload)
+	if err != nil {
+		return 0, status.Errorf(codes.Internal, "failed to build request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// The audit log records the address of the gRPC client
+	if client, ok := peer.FromContext(ctx); ok {
+		req.RemoteAddr = client.Addr.String()
+	}
+
+	resp := &response{header: make(http.Header)}
+	d.router.ServeHTTP(resp, req)
+
+	if resp.status >= http.StatusBadRequest {
+		return resp.status, statusError(resp.status, resp.body.Bytes(), conflict)
+	}
+	if out != nil {
+		if err := json.Unmarshal(resp.body.Bytes(), out); err != nil {
+			return resp.status, status.Errorf(codes.Internal, "failed to decode response: %v", err)
+		}
+	}
+	return resp.status, nil
+}
+
+// statusError converts an error response of a handler into a gRPC status
+func statusError(httpStatus int, body []byte, conflict codes.Code) error {
+	var payload struct {
+		Message        string `json:"message"`
+		CurrentVersion *int   `json:"current_version"`
+	}
+	message := http.StatusText(httpStatus)
+	if err := json.Unmarshal(body, &payload); err == nil && payload.Message != "" {
+		message = payload.Message
+	}
+	if payload.CurrentVersion != nil {
+		message = fmt.Sprintf("%s (current version %d)", message, *payload.CurrentVersion)
+	}
+
+	code := codes.Internal
+	switch httpStatus {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = conflict
+		if payload.CurrentVersion != nil {
+			code = codes.Aborted
+		}
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	case http.StatusGatewayTimeout:
+		code = codes.DeadlineExceeded
+	}
+	return status.Error(code, message)
+}
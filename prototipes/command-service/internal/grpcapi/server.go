@@ -0,0 +1,225 @@
+// Package grpcapi serves the inventory commands over gRPC (proto/inventory/v1) for
+// internal services, next to the REST API and on its own port.
+package grpcapi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"command-service/internal/audit"
+	"command-service/internal/auth"
+	"command-service/internal/handlers"
+	inventoryv1 "command-service/proto/inventory/v1"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server implements inventoryv1.InventoryCommandServiceServer on top of the REST
+// command handlers
+type Server struct {
+	inventoryv1.UnimplementedInventoryCommandServiceServer
+	dispatch *dispatcher
+}
+
+// NewServer builds the gRPC server: the inventory command service behind the JWT
+// interceptor. It shares the handler, and so the repositories and the event publisher,
+// with the REST API.
+func NewServer(logger *zap.Logger, inventory *handlers.InventoryHandler, jwtManager *auth.JWTManager, rbac *auth.RBAC, tokenStore auth.TokenStore, auditLog *audit.Log) *grpc.Server {
+	server := grpc.NewServer(grpc.UnaryInterceptor(UnaryAuthInterceptor(jwtManager, rbac, tokenStore, logger)))
+	inventoryv1.RegisterInventoryCommandServiceServer(server, &Server{dispatch: newDispatcher(inventory, auditLog, logger)})
+	return server
+}
+
+// JSON bodies written by the handlers
+type (
+	itemBody struct {
+		ID          string    `json:"id"`
+		SKU         string    `json:"sku"`
+		Name        string    `json:"name"`
+		Description string    `json:"description"`
+		Quantity    int32     `json:"quantity"`
+		Version     int32     `json:"version"`
+		CreatedAt   time.Time `json:"created_at"`
+		UpdatedAt   time.Time `json:"updated_at"`
+	}
+
+	stockBody struct {
+		ID            string    `json:"id"`
+		Quantity      int32     `json:"quantity"`
+		Reserved      int32     `json:"reserved"`
+		Available     int32     `json:"available"`
+		Version       int32     `json:"version"`
+		UpdatedAt     time.Time `json:"updated_at"`
+		StoreID       string    `json:"store_id"`
+		StoreReserved int32     `json:"store_reserved"`
+		ReservationID string    `json:"reservation_id"`
+		WaitlistID    string    `json:"waitlist_id"`
+	}
+)
+
+func (b itemBody) proto() *inventoryv1.Item {
+	return &inventoryv1.Item{
+		Id:          b.ID,
+		Sku:         b.SKU,
+		Name:        b.Name,
+		Description: b.Description,
+		Quantity:    b.Quantity,
+		Version:     b.Version,
+		CreatedAt:   timestamp(b.CreatedAt),
+		UpdatedAt:   timestamp(b.UpdatedAt),
+	}
+}
+
+func (b stockBody) proto() *inventoryv1.StockLevel {
+	return &inventoryv1.StockLevel{
+		Id:            b.ID,
+		Quantity:      b.Quantity,
+		Reserved:      b.Reserved,
+		Available:     b.Available,
+		Version:       b.Version,
+		UpdatedAt:     timestamp(b.UpdatedAt),
+		StoreId:       b.StoreID,
+		StoreReserved: b.StoreReserved,
+	}
+}
+
+// timestamp converts a time the handler left unset to a nil timestamp
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// itemPath is the route of an item, escaped so a malformed ID reaches the handler's
+// own validation instead of another route
+func itemPath(id string, action string) string {
+	path := "/items/" + url.PathEscape(id)
+	if action != "" {
+		path += "/" + action
+	}
+	return path
+}
+
+// withStore appends the optional store_id query parameter
+func withStore(path, storeID string) string {
+	if storeID == "" {
+		return path
+	}
+	return path + "?store_id=" + url.QueryEscape(storeID)
+}
+
+// CreateItem creates an item. A duplicate SKU is AlreadyExists; a retry of a create the
+// caller already made returns the original item with deduplicated set.
+func (s *Server) CreateItem(ctx context.Context, req *inventoryv1.CreateItemRequest) (*inventoryv1.Item, error) {
+	body := map[string]interface{}{
+		"sku":         req.GetSku(),
+		"name":        req.GetName(),
+		"description": req.GetDescription(),
+		"quantity":    req.GetQuantity(),
+	}
+	if req.UnitCost != nil {
+		body["unit_cost"] = req.GetUnitCost()
+	}
+
+	var item itemBody
+	code, err := s.dispatch.call(ctx, http.MethodPost, "/items", body, &item, codes.AlreadyExists)
+	if err != nil {
+		return nil, err
+	}
+	response := item.proto()
+	response.Deduplicated = code == http.StatusOK
+	return response, nil
+}
+
+// UpdateItem updates the name and description of an item
+func (s *Server) UpdateItem(ctx context.Context, req *inventoryv1.UpdateItemRequest) (*inventoryv1.Item, error) {
+	body := map[string]interface{}{
+		"name":        req.GetName(),
+		"description": req.GetDescription(),
+	}
+	if req.ExpectedVersion != nil {
+		body["version"] = req.GetExpectedVersion()
+	}
+
+	var item itemBody
+	if _, err := s.dispatch.call(ctx, http.MethodPut, itemPath(req.GetId(), ""), body, &item, codes.Aborted); err != nil {
+		return nil, err
+	}
+	return item.proto(), nil
+}
+
+// DeleteItem deletes an item
+func (s *Server) DeleteItem(ctx context.Context, req *inventoryv1.DeleteItemRequest) (*inventoryv1.DeleteItemResponse, error) {
+	if _, err := s.dispatch.call(ctx, http.MethodDelete, itemPath(req.GetId(), ""), nil, nil, codes.Aborted); err != nil {
+		return nil, err
+	}
+	return &inventoryv1.DeleteItemResponse{}, nil
+}
+
+// AdjustStock adds (or removes, when negative) stock
+func (s *Server) AdjustStock(ctx context.Context, req *inventoryv1.AdjustStockRequest) (*inventoryv1.StockLevel, error) {
+	if req.GetQuantity() == 0 {
+		// The handler requires a quantity; zero is how proto3 encodes a missing one
+		return nil, status.Error(codes.InvalidArgument, "quantity is required")
+	}
+	body := map[string]interface{}{"quantity": req.GetQuantity()}
+	if req.UnitCost != nil {
+		body["unit_cost"] = req.GetUnitCost()
+	}
+	if req.ExpectedVersion != nil {
+		body["version"] = req.GetExpectedVersion()
+	}
+
+	var stock stockBody
+	if _, err := s.dispatch.call(ctx, http.MethodPost, itemPath(req.GetId(), "adjust"), body, &stock, codes.Aborted); err != nil {
+		return nil, err
+	}
+	return stock.proto(), nil
+}
+
+// ReserveStock reserves stock, optionally for a store or on the waitlist. A store
+// outside its opening hours (ENFORCE_STORE_HOURS) is FailedPrecondition.
+func (s *Server) ReserveStock(ctx context.Context, req *inventoryv1.ReserveStockRequest) (*inventoryv1.ReserveStockResponse, error) {
+	body := map[string]interface{}{"quantity": req.GetQuantity(), "waitlist": req.GetWaitlist()}
+
+	var stock stockBody
+	path := withStore(itemPath(req.GetId(), "reserve"), req.GetStoreId())
+	code, err := s.dispatch.call(ctx, http.MethodPost, path, body, &stock, codes.FailedPrecondition)
+	if err != nil {
+		return nil, err
+	}
+	if code == http.StatusAccepted {
+		return &inventoryv1.ReserveStockResponse{WaitlistId: stock.WaitlistID}, nil
+	}
+	return &inventoryv1.ReserveStockResponse{Stock: stock.proto(), ReservationId: stock.ReservationID}, nil
+}
+
+// ReleaseStock releases reserved stock, optionally a store's reservation
+func (s *Server) ReleaseStock(ctx context.Context, req *inventoryv1.ReleaseStockRequest) (*inventoryv1.StockLevel, error) {
+	body := map[string]interface{}{"quantity": req.GetQuantity()}
+
+	var stock stockBody
+	path := withStore(itemPath(req.GetId(), "release"), req.GetStoreId())
+	if _, err := s.dispatch.call(ctx, http.MethodPost, path, body, &stock, codes.Aborted); err != nil {
+		return nil, err
+	}
+	return stock.proto(), nil
+}
+
+// CommitStock turns reserved stock into a sale
+func (s *Server) CommitStock(ctx context.Context, req *inventoryv1.CommitStockRequest) (*inventoryv1.StockLevel, error) {
+	body := map[string]interface{}{"quantity": req.GetQuantity()}
+
+	var stock stockBody
+	if _, err := s.dispatch.call(ctx, http.MethodPost, itemPath(req.GetId(), "commit"), body, &stock, codes.Aborted); err != nil {
+		return nil, err
+	}
+	return stock.proto(), nil
+}
@@ -0,0 +1,144 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"command-service/internal/auth"
+	"command-service/internal/config"
+	"command-service/internal/handlers"
+	"command-service/internal/tenant"
+	inventoryv1 "command-service/proto/inventory/v1"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testSecret = "grpc-test-secret"
+
+// setupClient serves the API over an in-memory listener and returns a client and the
+// manager that signs its tokens
+func setupClient(t *testing.T) (inventoryv1.InventoryCommandServiceClient, *auth.JWTManager, auth.TokenStore) {
+	gin.SetMode(gin.TestMode)
+	logger := zap.NewNop()
+	cfg := &config.Config{WriteStore: "memory", MockDependencies: true}
+	jwtManager := auth.NewJWTManager(testSecret, logger)
+	rbac, err := auth.NewRBAC("")
+	require.NoError(t, err)
+	tokenStore := auth.NewInMemoryTokenStore()
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(logger, handlers.NewInventoryHandler(logger, cfg), jwtManager, rbac, tokenStore, nil)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return inventoryv1.NewInventoryCommandServiceClient(conn), jwtManager, tokenStore
+}
+
+func withToken(t *testing.T, jwtManager *auth.JWTManager, username, role string) context.Context {
+	token, err := jwtManager.GenerateToken(username, role, tenant.Default)
+	require.NoError(t, err)
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestServer_Authentication(t *testing.T) {
+	client, jwtManager, _ := setupClient(t)
+	req := &inventoryv1.CreateItemRequest{Sku: "SKU-AUTH", Name: "Item", Quantity: 1}
+
+	_, err := client.CreateItem(context.Background(), req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	bad := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer not-a-token")
+	_, err = client.CreateItem(bad, req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.CreateItem(withToken(t, jwtManager, "viewer", auth.RoleViewer), req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestServer_RevokedToken(t *testing.T) {
+	client, jwtManager, tokenStore := setupClient(t)
+
+	token, err := jwtManager.GenerateToken("alice", auth.RoleAdmin, tenant.Default)
+	require.NoError(t, err)
+	claims, err := jwtManager.ValidateToken(token)
+	require.NoError(t, err)
+	require.NoError(t, tokenStore.RevokeAccessToken(context.Background(), claims.ID, time.Hour))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	_, err = client.CreateItem(ctx, &inventoryv1.CreateItemRequest{Sku: "SKU-REVOKED", Name: "Item", Quantity: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestServer_CommandsRoundTrip(t *testing.T) {
+	client, jwtManager, _ := setupClient(t)
+	ctx := withToken(t, jwtManager, "alice", auth.RoleAdmin)
+
+	var header metadata.MD
+	created, err := client.CreateItem(metadata.AppendToOutgoingContext(ctx, "x-request-id", "req-1"),
+		&inventoryv1.CreateItemRequest{Sku: "SKU-GRPC", Name: "Item", Quantity: 10}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, "SKU-GRPC", created.GetSku())
+	assert.Equal(t, int32(10), created.GetQuantity())
+	assert.False(t, created.GetDeduplicated())
+	assert.NotNil(t, created.GetCreatedAt())
+	assert.Equal(t, []string{"req-1"}, header.Get("x-request-id"))
+
+	reserved, err := client.ReserveStock(ctx, &inventoryv1.ReserveStockRequest{Id: created.GetId(), Quantity: 4})
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), reserved.GetStock().GetReserved())
+	assert.Equal(t, int32(6), reserved.GetStock().GetAvailable())
+
+	committed, err := client.CommitStock(ctx, &inventoryv1.CommitStockRequest{Id: created.GetId(), Quantity: 4})
+	require.NoError(t, err)
+	assert.Equal(t, int32(6), committed.GetQuantity())
+	assert.Equal(t, int32(0), committed.GetReserved())
+
+	adjusted, err := client.AdjustStock(ctx, &inventoryv1.AdjustStockRequest{Id: created.GetId(), Quantity: 5})
+	require.NoError(t, err)
+	assert.Equal(t, int32(11), adjusted.GetQuantity())
+
+	stale := int32(1)
+	_, err = client.UpdateItem(ctx, &inventoryv1.UpdateItemRequest{Id: created.GetId(), Name: "Renamed", ExpectedVersion: &stale})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	_, err = client.DeleteItem(ctx, &inventoryv1.DeleteItemRequest{Id: created.GetId()})
+	require.NoError(t, err)
+}
+
+func TestServer_ErrorCodes(t *testing.T) {
+	client, jwtManager, _ := setupClient(t)
+	ctx := withToken(t, jwtManager, "alice", auth.RoleAdmin)
+
+	_, err := client.ReserveStock(ctx, &inventoryv1.ReserveStockRequest{Id: uuid.New().String(), Quantity: 1})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.ReserveStock(ctx, &inventoryv1.ReserveStockRequest{Id: "not-a-uuid", Quantity: 1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.AdjustStock(ctx, &inventoryv1.AdjustStockRequest{Id: uuid.New().String()})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.CreateItem(ctx, &inventoryv1.CreateItemRequest{Sku: "SKU-DUP", Name: "Item", Quantity: 1})
+	require.NoError(t, err)
+	other := withToken(t, jwtManager, "bob", auth.RoleOperator)
+	_, err = client.CreateItem(other, &inventoryv1.CreateItemRequest{Sku: "SKU-DUP", Name: "Item", Quantity: 1})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"command-service/internal/audit"
+	"command-service/internal/domain"
+	"command-service/internal/tenant"
+	"command-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Entries per page of GET /audit/export
+const (
+	defaultAuditLimit = 1000
+	maxAuditLimit     = 10000
+)
+
+type AuditHandler struct {
+	logger *zap.Logger
+	log    *audit.Log // nil when AUDIT_ENABLED=false
+}
+
+// NewAuditHandler creates the handler of the audit log export
+func NewAuditHandler(logger *zap.Logger, log *audit.Log) *AuditHandler {
+	return &AuditHandler{logger: logger, log: log}
+}
+
+// ExportAuditLog handles GET /api/v1/audit/export
+// @Summary      Export the audit log
+// @Description  Exporta el audit log de los comandos de escritura: por cada request POST, PUT, PATCH o DELETE (también los rechazados) el actor, el `request_id`, la IP, el método, la ruta, el status y el estado anterior y posterior de cada item, tienda, proveedor u orden de compra que modificó. Requiere el permiso `audit:read` (rol admin por defecto). El log es uno solo para todos los tenants: solo lo exportan los usuarios del tenant `default`.
+//
+// **Encadenamiento:**
+// - Cada entrada lleva el `hash` SHA-256 de su contenido, que incluye el `prev_hash` de la anterior: modificar o quitar una entrada rompe la cadena desde ella
+// - La respuesta verifica la cadena de la página (`chain_verified`); para encadenar páginas, el `prev_hash` de la primera entrada debe ser el `hash` de la última de la página anterior
+// - La tabla SQLite es append-only (triggers que rechazan UPDATE y DELETE)
+//
+// **Paginación:** las entradas salen en orden de `seq`; la siguiente página se pide con `after_seq=next_after_seq`.
+//
+// **Ejemplos válidos:**
+// - `GET /api/v1/audit/export`
+// - `GET /api/v1/audit/export?from=2024-01-15T00:00:00Z&to=2024-01-16T00:00:00Z`
+// - `GET /api/v1/audit/export?after_seq=1000&limit=500`
+//
+// **Ejemplos inválidos:**
+// - Fecha sin zona: `GET /api/v1/audit/export?from=2024-01-15`
+// - limit fuera de rango: `GET /api/v1/audit/export?limit=0`
+//
+// @Tags         audit
+// @Produce      json
+// @Security     BearerAuth
+// @Param        after_seq  query     int     false  "Entries after this sequence number (default: 0)"
+// @Param        from       query     string  false  "Recorded at or after (RFC3339)"
+// @Param        to         query     string  false  "Recorded before (RFC3339)"
+// @Param        limit      query     int     false  "Entries per page (default: 1000, max: 10000)"
+// @Success      200        {object}  AuditExportResponse  "Entradas del audit log"
+// @Failure      400        {object}  ErrorResponse        "Request inválido - parámetros fuera de rango o fechas inválidas"
+// @Failure      401        {object}  ErrorResponse        "No autorizado - token JWT inválido o faltante"
+// @Failure      403        {object}  ErrorResponse        "Sin permiso audit:read o usuario de un tenant distinto de default"
+// @Failure      500        {object}  ErrorResponse        "Error interno del servidor - error de lectura del audit log"
+// @Failure      503        {object}  ErrorResponse        "Audit log deshabilitado (AUDIT_ENABLED=false)"
+// @Router       /audit/export [get]
+func (h *AuditHandler) ExportAuditLog(c *gin.Context) {
+	// The chain covers every tenant, so it cannot be filtered without breaking it
+	if tenantID := tenant.FromContext(c.Request.Context()); tenantID != tenant.Default {
+		errors.Respond(c, errors.NewForbidden("audit log export is restricted to the default tenant", "Tenant: "+tenantID))
+		return
+	}
+	if h.log == nil {
+		errors.Respond(c, errors.NewServiceUnavailable("audit log is disabled", "AUDIT_ENABLED=false"))
+		return
+	}
+
+	query := audit.Query{Limit: defaultAuditLimit}
+	var err error
+	if raw := c.Query("after_seq"); raw != "" {
+		if query.AfterSeq, err = strconv.ParseInt(raw, 10, 64); err != nil || query.AfterSeq < 0 {
+			errors.Respond(c, errors.NewInvalidRequest("after_seq must be a non-negative integer", ""))
+			return
+		}
+	}
+	if raw := c.Query("limit"); raw != "" {
+		if query.Limit, err = strconv.Atoi(raw); err != nil || query.Limit < 1 || query.Limit > maxAuditLimit {
+			errors.Respond(c, errors.NewInvalidRequest("limit must be between 1 and "+strconv.Itoa(maxAuditLimit), ""))
+			return
+		}
+	}
+	for _, bound := range []struct {
+		name string
+		into *time.Time
+	}{{"from", &query.From}, {"to", &query.To}} {
+		if raw := c.Query(bound.name); raw != "" {
+			if *bound.into, err = time.Parse(time.RFC3339, raw); err != nil {
+				errors.Respond(c, errors.NewInvalidRequest(bound.name+" must be an RFC3339 time", raw))
+				return
+			}
+		}
+	}
+
+	entries, err := h.log.Export(c.Request.Context(), query)
+	if err != nil {
+		h.logger.Error("Failed to export audit log", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to export audit log", nil))
+		return
+	}
+
+	response := AuditExportResponse{
+		Entries:       entries,
+		Count:         len(entries),
+		ChainVerified: true,
+	}
+	if response.Entries == nil {
+		response.Entries = []audit.Entry{}
+	}
+	if len(entries) == query.Limit {
+		next := entries[len(entries)-1].Seq
+		response.NextAfterSeq = &next
+	}
+	if err := audit.Verify(entries); err != nil {
+		h.logger.Error("Audit log chain is broken", zap.Error(err))
+		response.ChainVerified = false
+		response.ChainError = err.Error()
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// itemAuditState is the state of an item recorded in the audit log
+func itemAuditState(item *domain.InventoryItem) gin.H {
+	state := gin.H{
+		"id":          item.ID,
+		"sku":         item.SKU,
+		"name":        item.Name,
+		"description": item.Description,
+		"quantity":    item.Quantity,
+		"reserved":    item.Reserved,
+		"version":     item.Version,
+		"updated_at":  item.UpdatedAt,
+	}
+	if item.Locations != nil {
+		locations := make(map[string]gin.H, len(item.Locations))
+		for name, stock := range item.Locations {
+			locations[name] = gin.H{"quantity": stock.Quantity, "reserved": stock.Reserved}
+		}
+		state["locations"] = locations
+	}
+	if len(item.Images) > 0 {
+		keys := make([]string, len(item.Images))
+		for n, image := range item.Images {
+			keys[n] = image.Key
+		}
+		state["images"] = keys
+	}
+	if len(item.Identifiers) > 0 {
+		codes := make([]string, len(item.Identifiers))
+		for n, identifier := range item.Identifiers {
+			codes[n] = identifier.Type + ":" + identifier.Value
+		}
+		state["identifiers"] = codes
+	}
+	if item.DeletedAt != nil {
+		state["deleted_at"] = item.DeletedAt
+	}
+	return state
+}
+
+// auditItemBefore records the item as loaded, before the command changes it
+func auditItemBefore(c *gin.Context, item *domain.InventoryItem) {
+	audit.Before(c, audit.ResourceItem, item.ID.String(), itemAuditState(item))
+}
+
+// auditItemAfter records the item once the command saved it
+func auditItemAfter(c *gin.Context, item *domain.InventoryItem) {
+	audit.After(c, audit.ResourceItem, item.ID.String(), itemAuditState(item))
+}
+
+// auditStoreBefore records the store as loaded, before the command changes it
+func auditStoreBefore(c *gin.Context, store *domain.Store) {
+	audit.Before(c, audit.ResourceStore, store.ID.String(), storeResponse(store))
+}
+
+// auditStoreAfter records the store once the command saved it
+func auditStoreAfter(c *gin.Context, store *domain.Store) {
+	audit.After(c, audit.ResourceStore, store.ID.String(), storeResponse(store))
+}
+
+// auditStoreRemoved records that the command removed the store
+func auditStoreRemoved(c *gin.Context, id uuid.UUID) {
+	audit.After(c, audit.ResourceStore, id.String(), nil)
+}
+
+// auditSupplierBefore records the supplier as loaded, before the command changes it
+func auditSupplierBefore(c *gin.Context, supplier *domain.Supplier) {
+	audit.Before(c, audit.ResourceSupplier, supplier.ID.String(), supplierResponse(supplier))
+}
+
+// auditSupplierAfter records the supplier once the command saved it
+func auditSupplierAfter(c *gin.Context, supplier *domain.Supplier) {
+	audit.After(c, audit.ResourceSupplier, supplier.ID.String(), supplierResponse(supplier))
+}
+
+// auditSupplierRemoved records that the command removed the supplier
+func auditSupplierRemoved(c *gin.Context, id uuid.UUID) {
+	audit.After(c, audit.ResourceSupplier, id.String(), nil)
+}
+
+// auditPurchaseOrderBefore records the purchase order as loaded, before the command changes it
+func auditPurchaseOrderBefore(c *gin.Context, order *domain.PurchaseOrder) {
+	audit.Before(c, audit.ResourcePurchaseOrder, order.ID.String(), purchaseOrderResponse(order))
+}
+
+// auditPurchaseOrderAfter records the purchase order once the command saved it
+func auditPurchaseOrderAfter(c *gin.Context, order *domain.PurchaseOrder) {
+	audit.After(c, audit.ResourcePurchaseOrder, order.ID.String(), purchaseOrderResponse(order))
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"command-service/internal/saga"
+	"command-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type CommandStatusHandler struct {
+	logger   *zap.Logger
+	statuses *saga.Store
+}
+
+// NewCommandStatusHandler creates the handler of the command status endpoints. statuses
+// is the store filled by the tracked event bus and the command status consumer.
+func NewCommandStatusHandler(logger *zap.Logger, statuses *saga.Store) *CommandStatusHandler {
+	return &CommandStatusHandler{logger: logger, statuses: statuses}
+}
+
+// GetCommand handles GET /api/v1/commands/:request_id
+// @Summary      Track a command through the pipeline
+// @Description  Retorna la etapa de un request de escritura a partir de su `X-Request-ID`: `accepted` (comando aceptado, eventos aún no publicados), `published` (Kafka confirmó los eventos), `confirmed` (el Listener Service aplicó los eventos al read model y emitió sus confirmaciones: el cambio ya es visible en el Query Service) o `failed` (el Listener Service rechazó alguno de los eventos, ver `rejections`). La etapa del request es la del evento menos avanzado; cada evento trae su etapa y los instantes `published_at`, `applied_at` y `confirmed_at`.
+//
+// El estado se guarda en memoria en cada réplica durante `COMMAND_STATUS_TTL_MINUTES` desde su último cambio. Las confirmaciones y los rechazos llegan a todas las réplicas; las etapas `accepted` y `published` solo las conoce la réplica que atendió el request.
+//
+// @Tags         commands
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request_id  path      string           true  "Request ID (X-Request-ID) del comando"
+// @Success      200         {object}  CommandResponse  "Etapa del comando"
+// @Failure      401         {object}  ErrorResponse    "No autorizado - token JWT inválido o faltante"
+// @Failure      404         {object}  ErrorResponse    "Request desconocido o expirado"
+// @Router       /commands/{request_id} [get]
+func (h *CommandStatusHandler) GetCommand(c *gin.Context) {
+	status, ok := h.find(c)
+	if !ok {
+		return
+	}
+
+	response := CommandResponse{
+		RequestID:  status.RequestID,
+		Stage:      status.Stage,
+		AcceptedAt: formatTime(status.AcceptedAt),
+		UpdatedAt:  status.UpdatedAt.Format(time.RFC3339),
+		Events:     make([]CommandEventResponse, 0, len(status.Events)),
+		Rejections: rejectionResponses(status.Rejections),
+	}
+	for _, event := range status.Events {
+		response.Events = append(response.Events, CommandEventResponse{
+			Type:        event.Type,
+			Stage:       event.Stage,
+			PublishedAt: formatTime(event.PublishedAt),
+			AppliedAt:   formatTime(event.AppliedAt),
+			ConfirmedAt: formatTime(event.ConfirmedAt),
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetCommandStatus handles GET /api/v1/commands/:request_id/status
+// @Summary      Get the status of a command
+// @Description  Retorna el estado de un request de escritura a partir de su `X-Request-ID`. Un comando `accepted` publicó sus eventos y ninguno fue rechazado hasta ahora; pasa a `failed` cuando el Listener Service no puede aplicar alguno de sus eventos (por ejemplo, una reserva sin stock suficiente en el read model) y publica un evento `EventRejected`, que se lista en `rejections`. Para saber cuándo el cambio es visible en las consultas usar `GET /commands/{request_id}`.
+//
+// El estado se guarda en memoria en cada réplica durante `COMMAND_STATUS_TTL_MINUTES` desde su último cambio. Los rechazos llegan a todas las réplicas; los eventos publicados (`events`, `accepted_at`) solo los conoce la réplica que atendió el request.
+//
+// @Tags         commands
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request_id  path      string                 true  "Request ID (X-Request-ID) del comando"
+// @Success      200         {object}  CommandStatusResponse  "Estado del comando"
+// @Failure      401         {object}  ErrorResponse          "No autorizado - token JWT inválido o faltante"
+// @Failure      404         {object}  ErrorResponse          "Request desconocido o expirado"
+// @Router       /commands/{request_id}/status [get]
+func (h *CommandStatusHandler) GetCommandStatus(c *gin.Context) {
+	status, ok := h.find(c)
+	if !ok {
+		return
+	}
+
+	response := CommandStatusResponse{
+		RequestID:  status.RequestID,
+		Status:     status.Status,
+		Events:     make([]string, 0, len(status.Events)),
+		AcceptedAt: formatTime(status.AcceptedAt),
+		UpdatedAt:  status.UpdatedAt.Format(time.RFC3339),
+		Rejections: rejectionResponses(status.Rejections),
+	}
+	for _, event := range status.Events {
+		response.Events = append(response.Events, event.Type)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// find loads the status of the request_id parameter, responding 404 when it is unknown
+func (h *CommandStatusHandler) find(c *gin.Context) (saga.CommandStatus, bool) {
+	requestID := c.Param("request_id")
+	status, ok := h.statuses.Get(requestID)
+	if !ok {
+		errors.Respond(c, errors.NewNotFound("command status not found", "Request ID: "+requestID))
+		return saga.CommandStatus{}, false
+	}
+	return status, true
+}
+
+func rejectionResponses(rejections []saga.Rejection) []RejectedEventResponse {
+	responses := make([]RejectedEventResponse, 0, len(rejections))
+	for _, rejection := range rejections {
+		responses = append(responses, RejectedEventResponse{
+			EventID:    rejection.EventID,
+			EventType:  rejection.EventType,
+			Reason:     rejection.Reason,
+			RejectedAt: rejection.RejectedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	return responses
+}
+
+// formatTime formats t as RFC 3339, or returns "" when it is not set
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
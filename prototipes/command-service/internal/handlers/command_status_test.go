@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"command-service/internal/saga"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupCommandStatusRouter(statuses *saga.Store) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewCommandStatusHandler(zap.NewNop(), statuses)
+	router.GET("/api/v1/commands/:request_id", handler.GetCommand)
+	router.GET("/api/v1/commands/:request_id/status", handler.GetCommandStatus)
+	return router
+}
+
+func TestGetCommandStatus_Failed(t *testing.T) {
+	statuses := saga.NewStore(time.Hour)
+	statuses.Published("req-1", statuses.Accept("req-1", "StockReserved"))
+	statuses.Reject(saga.Rejection{
+		EventID:    "evt-1",
+		EventType:  "StockReserved",
+		RequestID:  "req-1",
+		Reason:     "insufficient stock",
+		RejectedAt: time.Now(),
+	})
+	router := setupCommandStatusRouter(statuses)
+
+	req, _ := http.NewRequest("GET", "/api/v1/commands/req-1/status", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response CommandStatusResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "req-1", response.RequestID)
+	assert.Equal(t, saga.StatusFailed, response.Status)
+	assert.Equal(t, []string{"StockReserved"}, response.Events)
+	assert.NotEmpty(t, response.AcceptedAt)
+	require.Len(t, response.Rejections, 1)
+	assert.Equal(t, "evt-1", response.Rejections[0].EventID)
+	assert.Equal(t, "insufficient stock", response.Rejections[0].Reason)
+}
+
+func TestGetCommandStatus_Accepted(t *testing.T) {
+	statuses := saga.NewStore(time.Hour)
+	statuses.Accept("req-1", "ItemCreated")
+	router := setupCommandStatusRouter(statuses)
+
+	req, _ := http.NewRequest("GET", "/api/v1/commands/req-1/status", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, saga.StatusAccepted, response["status"])
+	assert.Equal(t, []interface{}{}, response["rejections"])
+}
+
+func TestGetCommandStatus_NotFound(t *testing.T) {
+	router := setupCommandStatusRouter(saga.NewStore(time.Hour))
+
+	req, _ := http.NewRequest("GET", "/api/v1/commands/unknown/status", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "ResourceNotFound", response["code"])
+}
+
+func TestGetCommand_Stages(t *testing.T) {
+	statuses := saga.NewStore(time.Hour)
+	statuses.Published("req-1", statuses.Accept("req-1", "StockAdjusted"))
+	router := setupCommandStatusRouter(statuses)
+
+	get := func() CommandResponse {
+		req, _ := http.NewRequest("GET", "/api/v1/commands/req-1", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		var response CommandResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	response := get()
+	assert.Equal(t, saga.StagePublished, response.Stage)
+	require.Len(t, response.Events, 1)
+	assert.NotEmpty(t, response.Events[0].PublishedAt)
+	assert.Empty(t, response.Events[0].ConfirmedAt)
+
+	now := time.Now()
+	statuses.Confirm(saga.Confirmation{RequestID: "req-1", EventType: "StockAdjusted", AppliedAt: now, ConfirmedAt: now})
+	response = get()
+	assert.Equal(t, saga.StageConfirmed, response.Stage)
+	assert.Equal(t, saga.StageConfirmed, response.Events[0].Stage)
+	assert.NotEmpty(t, response.Events[0].AppliedAt)
+	assert.NotEmpty(t, response.Events[0].ConfirmedAt)
+	assert.Empty(t, response.Rejections)
+}
+
+func TestGetCommand_NotFound(t *testing.T) {
+	router := setupCommandStatusRouter(saga.NewStore(time.Hour))
+
+	req, _ := http.NewRequest("GET", "/api/v1/commands/unknown", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
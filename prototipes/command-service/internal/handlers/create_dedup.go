@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// createDedup remembers which item each actor created for a SKU, so a create retried
+// without X-Request-ID returns the original item instead of a 409 or a duplicate.
+// A nil *createDedup disables deduplication.
+type createDedup struct {
+	window  time.Duration
+	mu      sync.Mutex
+	entries map[createDedupKey]createDedupEntry
+}
+
+type createDedupKey struct {
+	tenant string // API key names, hence their actors, are only unique per tenant
+	sku    string
+	actor  string
+}
+
+type createDedupEntry struct {
+	itemID    uuid.UUID
+	createdAt time.Time
+}
+
+// newCreateDedup returns nil (disabled) when window is not positive
+func newCreateDedup(window time.Duration) *createDedup {
+	if window <= 0 {
+		return nil
+	}
+	return &createDedup{
+		window:  window,
+		entries: make(map[createDedupKey]createDedupEntry),
+	}
+}
+
+// lookup returns the item the actor of tenantID created for sku within the window
+func (d *createDedup) lookup(tenantID, sku, actor string) (uuid.UUID, bool) {
+	if d == nil {
+		return uuid.Nil, false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry, ok := d.entries[createDedupKey{tenant: tenantID, sku: sku, actor: actor}]
+	if !ok || time.Since(entry.createdAt) > d.window {
+		return uuid.Nil, false
+	}
+	return entry.itemID, true
+}
+
+// remember records a successful create and drops expired entries
+func (d *createDedup) remember(tenantID, sku, actor string, itemID uuid.UUID) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	for key, entry := range d.entries {
+		if now.Sub(entry.createdAt) > d.window {
+			delete(d.entries, key)
+		}
+	}
+	d.entries[createDedupKey{tenant: tenantID, sku: sku, actor: actor}] = createDedupEntry{itemID: itemID, createdAt: now}
+}
@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"command-service/internal/domain"
+	"command-service/pkg/errors"
+)
+
+// domainError turns a stock operation the item (or store) refused into a 400 response:
+// InsufficientStock when there is not enough available stock, InvalidOperation otherwise
+func domainError(err error) *errors.StandardError {
+	if err == domain.ErrInsufficientStock {
+		return errors.NewStandardError(errors.CodeInsufficientStock, err.Error(), "")
+	}
+	return errors.NewInvalidOperation(err.Error(), "")
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"strconv"
+
+	"command-service/pkg/errors"
+	"command-service/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Entries per page of GET /admin/idempotency
+const (
+	defaultIdempotencyPageSize = 50
+	maxIdempotencyPageSize     = 500
+)
+
+type IdempotencyHandler struct {
+	logger *zap.Logger
+	store  middleware.IdempotencyInspector
+}
+
+// NewIdempotencyHandler creates the handler of the idempotency store admin endpoints
+func NewIdempotencyHandler(logger *zap.Logger, store middleware.IdempotencyInspector) *IdempotencyHandler {
+	return &IdempotencyHandler{logger: logger, store: store}
+}
+
+// ListIdempotencyEntries handles GET /api/v1/admin/idempotency
+// @Summary      List the idempotency store
+// @Description  Lista las respuestas guardadas por `X-Request-ID` que se devuelven a las peticiones de escritura duplicadas del mismo tenant y usuario, de la más reciente a la más antigua, con la clave de la entrada (`key`), el tenant, el usuario (`subject`), el método, la ruta y el status de la petición original, cuándo se guardó, el TTL restante y cuántas veces se devolvió (`hits`). Incluye las estadísticas del store desde el arranque: peticiones de escritura revisadas (`checks`), respondidas con la respuesta guardada (`hits`) y la tasa de duplicados (`hit_rate`). Requiere el permiso `idempotency:manage` (rol admin por defecto).
+//
+// El store está en memoria en cada réplica: solo lista las entradas de la réplica que atiende la petición.
+//
+// **Ejemplos válidos:**
+// - `GET /api/v1/admin/idempotency`
+// - `GET /api/v1/admin/idempotency?page=2&page_size=100`
+//
+// **Ejemplos inválidos:**
+// - page_size fuera de rango: `GET /api/v1/admin/idempotency?page_size=1000`
+//
+// @Tags         idempotency
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page       query     int  false  "Page number (default: 1, min: 1)"
+// @Param        page_size  query     int  false  "Entries per page (default: 50, min: 1, max: 500)"
+// @Success      200        {object}  IdempotencyListResponse  "Página de entradas y estadísticas"
+// @Failure      400        {object}  ErrorResponse            "Request inválido - page o page_size fuera de rango"
+// @Failure      401        {object}  ErrorResponse            "No autorizado - token JWT inválido o faltante"
+// @Failure      403        {object}  ErrorResponse            "Sin permiso idempotency:manage"
+// @Router       /admin/idempotency [get]
+func (h *IdempotencyHandler) ListIdempotencyEntries(c *gin.Context) {
+	page, pageSize := 1, defaultIdempotencyPageSize
+	var err error
+	if raw := c.Query("page"); raw != "" {
+		if page, err = strconv.Atoi(raw); err != nil || page < 1 {
+			errors.Respond(c, errors.NewInvalidRequest("page must be a positive integer", ""))
+			return
+		}
+	}
+	if raw := c.Query("page_size"); raw != "" {
+		if pageSize, err = strconv.Atoi(raw); err != nil || pageSize < 1 || pageSize > maxIdempotencyPageSize {
+			errors.Respond(c, errors.NewInvalidRequest("page_size must be between 1 and "+strconv.Itoa(maxIdempotencyPageSize), ""))
+			return
+		}
+	}
+
+	entries, total, err := h.store.List(c.Request.Context(), (page-1)*pageSize, pageSize)
+	if err != nil {
+		h.logger.Error("Failed to list idempotency entries", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to list idempotency entries", err))
+		return
+	}
+	c.JSON(http.StatusOK, IdempotencyListResponse{
+		Entries:    entries,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+		Stats:      h.store.Stats(),
+	})
+}
+
+// GetIdempotencyEntry handles GET /api/v1/admin/idempotency/:key
+// @Summary      Inspect an idempotency entry
+// @Description  Retorna la entrada guardada para un `X-Request-ID` de un tenant y usuario, por su `key` (ver `GET /admin/idempotency`), y la respuesta que reciben sus duplicados (`response` si es JSON, `response_text` si no). Requiere el permiso `idempotency:manage`.
+// @Tags         idempotency
+// @Produce      json
+// @Security     BearerAuth
+// @Param        key  path      string                    true  "Clave de la entrada (key del listado)"
+// @Success      200  {object}  IdempotencyEntryResponse  "Entrada y respuesta guardada"
+// @Failure      401  {object}  ErrorResponse             "No autorizado - token JWT inválido o faltante"
+// @Failure      403  {object}  ErrorResponse             "Sin permiso idempotency:manage"
+// @Failure      404  {object}  ErrorResponse             "Clave desconocida o expirada"
+// @Router       /admin/idempotency/{key} [get]
+func (h *IdempotencyHandler) GetIdempotencyEntry(c *gin.Context) {
+	key := c.Param("key")
+	entry, body, err := h.store.Lookup(c.Request.Context(), key)
+	if err != nil {
+		h.respondLookupError(c, key, err)
+		return
+	}
+
+	response := IdempotencyEntryResponse{IdempotencyEntry: entry}
+	if json.Valid(body) {
+		response.Response = body
+	} else {
+		response.ResponseText = string(body)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteIdempotencyEntry handles DELETE /api/v1/admin/idempotency/:key
+// @Summary      Delete an idempotency entry
+// @Description  Borra la respuesta guardada para un `X-Request-ID` de un tenant y usuario, por su `key`: la próxima petición de ese usuario con ese ID se procesa de nuevo en lugar de recibir la respuesta guardada. Requiere el permiso `idempotency:manage`.
+// @Tags         idempotency
+// @Security     BearerAuth
+// @Param        key  path  string  true  "Clave de la entrada (key del listado)"
+// @Success      204  "Entrada borrada"
+// @Failure      401  {object}  ErrorResponse  "No autorizado - token JWT inválido o faltante"
+// @Failure      403  {object}  ErrorResponse  "Sin permiso idempotency:manage"
+// @Failure      404  {object}  ErrorResponse  "Clave desconocida o expirada"
+// @Router       /admin/idempotency/{key} [delete]
+func (h *IdempotencyHandler) DeleteIdempotencyEntry(c *gin.Context) {
+	key := c.Param("key")
+	if err := h.store.Delete(c.Request.Context(), key); err != nil {
+		h.respondLookupError(c, key, err)
+		return
+	}
+	h.logger.Info("Idempotency entry deleted", zap.String("key", key))
+	c.Status(http.StatusNoContent)
+}
+
+func (h *IdempotencyHandler) respondLookupError(c *gin.Context, key string, err error) {
+	if stderrors.Is(err, middleware.ErrRequestIDNotFound) {
+		errors.Respond(c, errors.NewNotFound("idempotency entry not found", "Key: "+key))
+		return
+	}
+	h.logger.Error("Failed to read idempotency entry", zap.String("key", key), zap.Error(err))
+	errors.Respond(c, errors.NewInternalError("failed to read idempotency entry", err))
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"command-service/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupIdempotencyRouter(store middleware.IdempotencyInspector) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewIdempotencyHandler(zap.NewNop(), store)
+	router.GET("/api/v1/admin/idempotency", handler.ListIdempotencyEntries)
+	router.GET("/api/v1/admin/idempotency/:key", handler.GetIdempotencyEntry)
+	router.DELETE("/api/v1/admin/idempotency/:key", handler.DeleteIdempotencyEntry)
+	return router
+}
+
+func TestListIdempotencyEntries(t *testing.T) {
+	store := middleware.NewInMemoryRequestIDStore()
+	ctx := context.Background()
+	require.NoError(t, store.Record(ctx, middleware.IdempotencyEntry{RequestID: "req-1", Method: "POST", Path: "/api/v1/inventory/items", Status: 201}, []byte(`{"id":"1"}`), time.Minute))
+	require.NoError(t, store.Record(ctx, middleware.IdempotencyEntry{RequestID: "req-2", Method: "PUT", Path: "/api/v1/inventory/items/1", Status: 200}, []byte(`{"id":"1"}`), time.Minute))
+	store.Exists(ctx, "req-1")
+	store.Get(ctx, "req-1")
+	store.Exists(ctx, "req-3")
+	router := setupIdempotencyRouter(store)
+
+	req, _ := http.NewRequest("GET", "/api/v1/admin/idempotency?page_size=1&page=2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response IdempotencyListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 2, response.Total)
+	assert.Equal(t, 2, response.TotalPages)
+	require.Len(t, response.Entries, 1)
+	assert.Equal(t, "req-1", response.Entries[0].RequestID)
+	assert.Equal(t, 201, response.Entries[0].Status)
+	assert.Equal(t, 1, response.Entries[0].Hits)
+	assert.Equal(t, int64(2), response.Stats.Checks)
+	assert.Equal(t, 0.5, response.Stats.HitRate)
+}
+
+func TestListIdempotencyEntries_InvalidPageSize(t *testing.T) {
+	router := setupIdempotencyRouter(middleware.NewInMemoryRequestIDStore())
+
+	req, _ := http.NewRequest("GET", "/api/v1/admin/idempotency?page_size=1000", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetAndDeleteIdempotencyEntry(t *testing.T) {
+	store := middleware.NewInMemoryRequestIDStore()
+	key := middleware.IdempotencyKey("acme", "user-1", "req-1")
+	require.NoError(t, store.Record(context.Background(), middleware.IdempotencyEntry{Key: key, RequestID: "req-1", TenantID: "acme", Subject: "user-1", Method: "POST", Status: 202}, []byte(`{"message":"accepted"}`), time.Minute))
+	router := setupIdempotencyRouter(store)
+
+	req, _ := http.NewRequest("GET", "/api/v1/admin/idempotency/"+key, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var response IdempotencyEntryResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "req-1", response.RequestID)
+	assert.Equal(t, "acme", response.TenantID)
+	assert.JSONEq(t, `{"message":"accepted"}`, string(response.Response))
+
+	req, _ = http.NewRequest("DELETE", "/api/v1/admin/idempotency/"+key, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api/v1/admin/idempotency/"+key, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
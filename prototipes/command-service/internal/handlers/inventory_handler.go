@@ -1,13 +1,24 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
+	"strings"
+	"time"
 
 	"command-service/internal/commands"
 	"command-service/internal/config"
 	"command-service/internal/domain"
 	"command-service/internal/events"
+	"command-service/internal/images"
+	"command-service/internal/journal"
 	"command-service/internal/repository"
+	"command-service/internal/saga"
+	"command-service/internal/tenant"
+	"command-service/pkg/errors"
+	"command-service/pkg/validation"
+
+	"testsupport"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -17,41 +28,186 @@ import (
 type InventoryHandler struct {
 	logger     *zap.Logger
 	repository repository.InventoryRepository
+	stores     repository.StoreRepository
 	eventBus   events.EventPublisher
+	dedup      *createDedup     // nil disables create deduplication
+	journal    *journal.Journal // nil disables the write-ahead journal
+	// Reject store reservations outside the store's opening hours
+	enforceStoreHours bool
+	// Item image files (nil when IMAGE_STORE cannot be opened) and their size limit
+	images        images.Store
+	imageMaxBytes int64
 }
 
 func NewInventoryHandler(logger *zap.Logger, cfg *config.Config) *InventoryHandler {
-	// TODO: Initialize repository with actual implementations
-	repo := repository.NewInventoryRepository() // Placeholder
+	repo := newWriteStore(logger, cfg)
 
-	// Initialize Kafka event publisher
-	eventBus, err := events.NewKafkaEventPublisher(cfg, logger)
+	// Initialize the event publisher of EVENT_BUS
+	var eventBus events.EventPublisher
+	var err error
+	switch {
+	case cfg.MockDependencies:
+		eventBus, err = events.NewBrokerEventPublisher(cfg, testsupport.NewBroker(), logger)
+	case cfg.EventBus == config.EventBusKafka && cfg.KafkaPublishMode == config.KafkaPublishAsync:
+		eventBus, err = events.NewAsyncKafkaEventPublisher(cfg, logger)
+	case cfg.EventBus == config.EventBusKafka:
+		eventBus, err = events.NewKafkaEventPublisher(cfg, logger)
+	default:
+		eventBus, err = events.NewBusEventPublisher(cfg, logger)
+	}
 	if err != nil {
-		logger.Warn("Failed to initialize Kafka publisher, using in-memory fallback", zap.Error(err))
+		logger.Warn("Failed to initialize event publisher, using in-memory fallback", zap.String("event_bus", cfg.EventBus), zap.Error(err))
 		eventBus = events.NewEventPublisher() // Fallback to in-memory
 	}
 
+	// Publish the events of changes saved before a crash, before taking new writes
+	wal := openJournal(logger, cfg)
+	wal.Recover(context.Background(), repo, eventBus)
+
 	return &InventoryHandler{
 		logger:     logger,
 		repository: repo,
+		stores:     repository.NewStoreRepository(),
 		eventBus:   eventBus,
+		dedup:      newCreateDedup(time.Duration(cfg.CreateDedupWindowSeconds) * time.Second),
+		journal:    wal,
+
+		enforceStoreHours: cfg.EnforceStoreHours,
+		images:            newImageStore(logger, cfg),
+		imageMaxBytes:     int64(cfg.ImageMaxBytes),
+	}
+}
+
+// newImageStore opens the image store of IMAGE_STORE; image uploads answer 503 when
+// it cannot be opened
+func newImageStore(logger *zap.Logger, cfg *config.Config) images.Store {
+	if cfg.MockDependencies {
+		return images.NewMemoryStore()
 	}
+	store, err := images.New(cfg)
+	if err != nil {
+		logger.Error("Failed to open image store", zap.String("image_store", cfg.ImageStore), zap.Error(err))
+		return nil
+	}
+	return store
+}
+
+// openJournal opens the write-ahead journal. It is only useful with a durable write
+// store; without one (or if it cannot be opened) writes are not journaled.
+func openJournal(logger *zap.Logger, cfg *config.Config) *journal.Journal {
+	if cfg.JournalPath == "" || cfg.WriteStore == "memory" {
+		return nil
+	}
+
+	wal, err := journal.Open(cfg.JournalPath, logger)
+	if err != nil {
+		logger.Error("Failed to open write-ahead journal, writes will not be journaled",
+			zap.String("path", cfg.JournalPath),
+			zap.Error(err),
+		)
+		return nil
+	}
+
+	logger.Info("Write-ahead journal opened",
+		zap.String("path", cfg.JournalPath),
+		zap.Int("pending", len(wal.Pending())),
+	)
+	return wal
+}
+
+// newWriteStore opens the configured write store, falling back to memory if it cannot be opened
+func newWriteStore(logger *zap.Logger, cfg *config.Config) repository.InventoryRepository {
+	if cfg.WriteStore == "memory" {
+		logger.Warn("Using in-memory write store, state will be lost on restart")
+		return repository.NewInventoryRepository()
+	}
+
+	repo, err := repository.NewSQLiteInventoryRepository(cfg.WriteStorePath, logger)
+	if err != nil {
+		logger.Error("Failed to open SQLite write store, using in-memory fallback",
+			zap.String("path", cfg.WriteStorePath),
+			zap.Error(err),
+		)
+		return repository.NewInventoryRepository()
+	}
+
+	logger.Info("SQLite write store opened", zap.String("path", cfg.WriteStorePath))
+	return repo
+}
+
+// GetRepository returns the write store (probed by the readiness check)
+func (h *InventoryHandler) GetRepository() repository.InventoryRepository {
+	return h.repository
+}
+
+// GetStoreRepository returns the store repository (shared with the store handler)
+func (h *InventoryHandler) GetStoreRepository() repository.StoreRepository {
+	return h.stores
+}
+
+// GetEventBus returns the event publisher (shared with the store handler)
+func (h *InventoryHandler) GetEventBus() events.EventPublisher {
+	return h.eventBus
+}
+
+// TrackCommands records in statuses the requests whose events are published, for
+// GET /commands/:request_id/status; call it before sharing the event bus
+func (h *InventoryHandler) TrackCommands(statuses *saga.Store) {
+	h.eventBus = saga.NewTrackingPublisher(h.eventBus, statuses)
+}
+
+// findStoreParam loads the store referenced by the optional store_id query parameter.
+// It returns (nil, true) when no store_id was given and writes the error response
+// and returns false when the store cannot be used.
+func (h *InventoryHandler) findStoreParam(c *gin.Context) (*domain.Store, bool) {
+	storeIDParam := c.Query("store_id")
+	if storeIDParam == "" {
+		return nil, true
+	}
+
+	storeID, err := uuid.Parse(storeIDParam)
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid store id", ""))
+		return nil, false
+	}
+
+	if h.stores == nil {
+		errors.Respond(c, errors.NewInvalidRequest("store-scoped operations are not enabled", ""))
+		return nil, false
+	}
+
+	store, err := h.stores.FindByID(c.Request.Context(), storeID)
+	if err != nil {
+		if err == domain.ErrStoreNotFound {
+			errors.Respond(c, errors.NewNotFound("store not found", "Store ID: "+storeID.String()))
+			return nil, false
+		}
+		h.logger.Error("Failed to find store", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to find store", nil))
+		return nil, false
+	}
+
+	return store, true
 }
 
 // CreateItem handles POST /api/v1/inventory/items
 // @Summary      Create a new inventory item
 // @Description  Crea un nuevo item en el inventario. El SKU debe ser único y la cantidad inicial debe ser >= 0.
 // @Description  **Idempotencia**: Incluye X-Request-ID en el header para evitar duplicados. Si se envía el mismo X-Request-ID, se retornará la respuesta cacheada (válida por 5 minutos).
+// @Description  **Deduplicación**: Sin X-Request-ID, si el mismo usuario ya creó un item con ese SKU dentro de la ventana (`CREATE_DEDUP_WINDOW_SECONDS`), se retorna el item original con 200 y el header `X-Deduplicated: true`, sin publicar un nuevo evento.
 //
 // **Ejemplos válidos:**
 // - Request completo con todos los campos
 // - Request con descripción opcional vacía
 // - Request con cantidad inicial 0
+// - Request con costo unitario de la existencia inicial (`unit_cost`)
+// - Reintento del mismo usuario con el mismo SKU dentro de la ventana de deduplicación (200, item original)
 //
 // **Ejemplos inválidos:**
 // - Campos requeridos faltantes (sku, name, quantity)
 // - Cantidad negativa
 // - SKU vacío
+// - SKU ya existente creado por otro usuario o fuera de la ventana de deduplicación (409)
 //
 // @Tags         inventory
 // @Accept       json
@@ -60,7 +216,7 @@ func NewInventoryHandler(logger *zap.Logger, cfg *config.Config) *InventoryHandl
 // @Param        X-Request-ID  header    string  false  "Request ID for idempotency (UUID). If not provided, a new one will be generated."
 // @Param        request      body      CreateItemRequest  true  "Item creation request"
 // @Success      201          {object}  CreateItemResponse  "Item creado exitosamente"
-// @Success      200          {object}  CreateItemResponse  "Request duplicado - respuesta cacheada (idempotencia)"
+// @Success      200          {object}  CreateItemResponse  "Request duplicado - respuesta cacheada (idempotencia) o item original (deduplicación por SKU)"
 // @Failure      400          {object}  ErrorResponse       "Request inválido - campos requeridos faltantes o valores inválidos"
 // @Failure      401          {object}  ErrorResponse       "No autorizado - token JWT inválido o faltante"
 // @Failure      409          {object}  ErrorResponse       "Conflicto - SKU duplicado"
@@ -69,15 +225,16 @@ func NewInventoryHandler(logger *zap.Logger, cfg *config.Config) *InventoryHandl
 // @Router       /inventory/items [post]
 func (h *InventoryHandler) CreateItem(c *gin.Context) {
 	var req struct {
-		SKU         string `json:"sku" binding:"required"`
-		Name        string `json:"name" binding:"required"`
-		Description string `json:"description"`
-		Quantity    int    `json:"quantity" binding:"required,min=0"`
+		SKU         string   `json:"sku" binding:"required,sku"`
+		Name        string   `json:"name" binding:"required,itemname"`
+		Description string   `json:"description" binding:"description"`
+		Quantity    int      `json:"quantity" binding:"required,min=0,quantity"`
+		UnitCost    *float64 `json:"unit_cost" binding:"omitempty,min=0"`
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Warn("Invalid request", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if bindErr := validation.BindJSON(c, &req); bindErr != nil {
+		h.logger.Warn("Invalid request", zap.Error(bindErr))
+		errors.Respond(c, bindErr)
 		return
 	}
 
@@ -87,28 +244,52 @@ func (h *InventoryHandler) CreateItem(c *gin.Context) {
 		Name:        req.Name,
 		Description: req.Description,
 		Quantity:    req.Quantity,
+		UnitCost:    req.UnitCost,
 	}
 
-	// Execute command
-	item := domain.NewInventoryItem(cmd.SKU, cmd.Name, cmd.Description, cmd.Quantity)
-
-	// Save to repository
-	if err := h.repository.Save(c.Request.Context(), item); err != nil {
-		h.logger.Error("Failed to save item", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create item"})
+	// A retry of a create this user already made returns the original item
+	actor := c.GetString("username")
+	if h.respondDeduplicated(c, cmd.SKU, actor) {
 		return
 	}
 
-	// Publish event
+	// Execute command
+	item := domain.NewInventoryItem(cmd.SKU, cmd.Name, cmd.Description, cmd.Quantity)
+	item.TenantID = tenant.FromContext(c.Request.Context())
 	event := events.InventoryItemCreatedEvent{
 		ItemID:      item.ID,
 		SKU:         item.SKU,
 		Name:        item.Name,
 		Description: item.Description,
 		Quantity:    item.Quantity,
+		UnitCost:    cmd.UnitCost,
 		OccurredAt:  item.CreatedAt,
 	}
-	if err := h.eventBus.Publish(c.Request.Context(), event); err != nil {
+	journalID, ok := h.beginWrite(c, item, false, event, "failed to create item")
+	if !ok {
+		return
+	}
+
+	// Save to repository
+	if err := h.repository.Save(c.Request.Context(), item); err != nil {
+		h.journal.Aborted(journalID)
+		if err == domain.ErrDuplicateSKU {
+			// A concurrent retry may have won the race
+			if h.respondDeduplicated(c, cmd.SKU, actor) {
+				return
+			}
+			errors.Respond(c, errors.NewDuplicateSKU(cmd.SKU))
+			return
+		}
+		h.logger.Error("Failed to save item", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to create item", nil))
+		return
+	}
+	auditItemAfter(c, item)
+	h.dedup.remember(item.TenantID, cmd.SKU, actor, item.ID)
+
+	// Publish event
+	if err := h.publish(c, journalID, event); err != nil {
 		h.logger.Error("Failed to publish event", zap.Error(err))
 		// Note: In production, you might want to handle this differently
 	}
@@ -124,24 +305,58 @@ func (h *InventoryHandler) CreateItem(c *gin.Context) {
 	})
 }
 
+// respondDeduplicated writes the item the actor created for sku within the dedup
+// window, if any, and reports whether it did. No event is published again.
+func (h *InventoryHandler) respondDeduplicated(c *gin.Context, sku, actor string) bool {
+	itemID, ok := h.dedup.lookup(tenant.FromContext(c.Request.Context()), sku, actor)
+	if !ok {
+		return false
+	}
+	item, err := h.repository.FindByID(c.Request.Context(), itemID)
+	if err != nil || item.SKU != sku {
+		// Deleted (or replaced) since it was created: handle as a new create
+		return false
+	}
+
+	h.logger.Info("Duplicate create deduplicated",
+		zap.String("item_id", item.ID.String()),
+		zap.String("sku", sku),
+		zap.String("actor", actor),
+	)
+	c.Header("X-Deduplicated", "true")
+	c.JSON(http.StatusOK, gin.H{
+		"id":          item.ID,
+		"sku":         item.SKU,
+		"name":        item.Name,
+		"description": item.Description,
+		"quantity":    item.Quantity,
+		"created_at":  item.CreatedAt,
+	})
+	return true
+}
+
 // UpdateItem handles PUT /api/v1/inventory/items/:id
 // @Summary      Update an inventory item
 // @Description  Actualiza un item existente en el inventario. Solo se pueden actualizar el nombre y la descripción.
+// @Description  Con `If-Match: "<version>"` (o `version` en el body) la actualización solo se aplica si el item sigue en esa versión; si no, responde 409 con `current_version`. La respuesta trae la nueva versión en `version` y en el header `ETag`.
 //
 // **Ejemplos válidos:**
 // - Actualizar nombre y descripción
 // - Actualizar solo el nombre (descripción opcional)
+// - Actualizar solo si no cambió: `If-Match: "3"` o `{"name": "Laptop", "version": 3}`
 //
 // **Ejemplos inválidos:**
 // - Nombre faltante (campo requerido)
 // - ID inválido (UUID malformado)
 // - Item no encontrado (ID válido pero no existe)
+// - Versión desactualizada (409)
 //
 // @Tags         inventory
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
 // @Param        X-Request-ID  header    string  false  "Request ID for idempotency (UUID). If not provided, a new one will be generated."
+// @Param        If-Match      header    string  false  "Versión esperada del item (ETag), p. ej. \"3\""
 // @Param        id            path      string  true   "Item ID (UUID)" example(550e8400-e29b-41d4-a716-446655440000)
 // @Param        request       body      UpdateItemRequest  true  "Item update request"
 // @Success      200           {object}  UpdateItemResponse  "Item actualizado exitosamente"
@@ -149,23 +364,25 @@ func (h *InventoryHandler) CreateItem(c *gin.Context) {
 // @Failure      400           {object}  ErrorResponse      "Request inválido - ID inválido o campos requeridos faltantes"
 // @Failure      401           {object}  ErrorResponse      "No autorizado - token JWT inválido o faltante"
 // @Failure      404           {object}  ErrorResponse      "Item no encontrado"
+// @Failure      409           {object}  VersionConflictResponse  "Conflicto - el item cambió desde la versión esperada"
 // @Failure      500           {object}  ErrorResponse      "Error interno del servidor - error de persistencia o conexión a base de datos"
 // @Failure      503           {object}  ErrorResponse      "Servicio no disponible - error de conexión al event broker"
 // @Router       /inventory/items/{id} [put]
 func (h *InventoryHandler) UpdateItem(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item id"})
+		errors.Respond(c, errors.NewInvalidRequest("invalid item id", ""))
 		return
 	}
 
 	var req struct {
-		Name        string `json:"name" binding:"required"`
-		Description string `json:"description"`
+		Name        string `json:"name" binding:"required,itemname"`
+		Description string `json:"description" binding:"description"`
+		Version     *int   `json:"version" binding:"omitempty,min=1"`
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if bindErr := validation.BindJSON(c, &req); bindErr != nil {
+		errors.Respond(c, bindErr)
 		return
 	}
 
@@ -173,56 +390,73 @@ func (h *InventoryHandler) UpdateItem(c *gin.Context) {
 	item, err := h.repository.FindByID(c.Request.Context(), id)
 	if err != nil {
 		if err == domain.ErrItemNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+			errors.Respond(c, errors.NewItemNotFound(id.String()))
 			return
 		}
 		h.logger.Error("Failed to find item", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update item"})
+		errors.Respond(c, errors.NewInternalError("failed to update item", nil))
 		return
 	}
+	auditItemBefore(c, item)
+	if !h.checkVersion(c, item, req.Version) {
+		return
+	}
+	expected := item.Version
 
 	// Update item
-	item.Name = req.Name
-	item.Description = req.Description
+	item.UpdateDetails(req.Name, req.Description)
+	event := events.InventoryItemUpdatedEvent{
+		ItemID:          item.ID,
+		Name:            item.Name,
+		Description:     item.Description,
+		ExpectedVersion: expected,
+		OccurredAt:      item.UpdatedAt,
+	}
+	journalID, ok := h.beginWrite(c, item, false, event, "failed to update item")
+	if !ok {
+		return
+	}
 
 	// Save changes
 	if err := h.repository.Save(c.Request.Context(), item); err != nil {
+		h.journal.Aborted(journalID)
+		if err == domain.ErrVersionConflict {
+			h.respondVersionConflict(c, item.ID)
+			return
+		}
 		h.logger.Error("Failed to save item", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update item"})
+		errors.Respond(c, errors.NewInternalError("failed to update item", nil))
 		return
 	}
+	auditItemAfter(c, item)
 
 	// Publish event
-	event := events.InventoryItemUpdatedEvent{
-		ItemID:      item.ID,
-		Name:        item.Name,
-		Description: item.Description,
-		OccurredAt:  item.UpdatedAt,
-	}
-	if err := h.eventBus.Publish(c.Request.Context(), event); err != nil {
+	if err := h.publish(c, journalID, event); err != nil {
 		h.logger.Error("Failed to publish event", zap.Error(err))
 	}
 
+	setETag(c, item)
 	c.JSON(http.StatusOK, gin.H{
 		"id":          item.ID,
 		"sku":         item.SKU,
 		"name":        item.Name,
 		"description": item.Description,
 		"quantity":    item.Quantity,
+		"version":     item.Version,
 		"updated_at":  item.UpdatedAt,
 	})
 }
 
 // DeleteItem handles DELETE /api/v1/inventory/items/:id
 // @Summary      Delete an inventory item
-// @Description  Elimina un item del inventario. Esta operación no se puede deshacer.
+// @Description  Elimina un item del inventario (soft delete). El item conserva su stock, su historial y las reservas que lo referencian, deja de aparecer en los listados y no admite operaciones hasta restaurarlo con `POST /inventory/items/{id}/restore`. Su SKU sigue ocupado mientras tanto.
 //
 // **Ejemplos válidos:**
 // - DELETE con ID válido existente
 //
 // **Ejemplos inválidos:**
 // - ID inválido (UUID malformado)
-// - Item no encontrado (ID válido pero no existe)
+// - Item no encontrado (ID válido pero no existe o ya está eliminado)
 //
 // @Tags         inventory
 // @Accept       json
@@ -234,49 +468,157 @@ func (h *InventoryHandler) UpdateItem(c *gin.Context) {
 // @Success      200          {object}  SuccessResponse  "Request duplicado - respuesta cacheada (idempotencia)"
 // @Failure      400          {object}  ErrorResponse    "ID inválido"
 // @Failure      401          {object}  ErrorResponse    "No autorizado - token JWT inválido o faltante"
+// @Failure      403          {object}  ErrorResponse    "Prohibido - el rol del token no tiene permiso inventory:delete"
 // @Failure      404          {object}  ErrorResponse    "Item no encontrado"
+// @Failure      409          {object}  VersionConflictResponse  "Conflicto - el item cambió mientras se eliminaba"
 // @Failure      500          {object}  ErrorResponse    "Error interno del servidor - error de persistencia o conexión a base de datos"
 // @Failure      503          {object}  ErrorResponse    "Servicio no disponible - error de conexión al event broker"
 // @Router       /inventory/items/{id} [delete]
 func (h *InventoryHandler) DeleteItem(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item id"})
+		errors.Respond(c, errors.NewInvalidRequest("invalid item id", ""))
 		return
 	}
 
-	// Get item to verify it exists
+	// Get item to verify it exists (deleted items are not found)
 	item, err := h.repository.FindByID(c.Request.Context(), id)
 	if err != nil {
 		if err == domain.ErrItemNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+			errors.Respond(c, errors.NewItemNotFound(id.String()))
 			return
 		}
 		h.logger.Error("Failed to find item", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete item"})
+		errors.Respond(c, errors.NewInternalError("failed to delete item", nil))
+		return
+	}
+	auditItemBefore(c, item)
+	expected := item.Version
+
+	if err := item.Delete(); err != nil {
+		errors.Respond(c, errors.NewItemNotFound(id.String()))
+		return
+	}
+	event := events.InventoryItemDeletedEvent{
+		ItemID:          item.ID,
+		SKU:             item.SKU,
+		ExpectedVersion: expected,
+		OccurredAt:      item.UpdatedAt,
+	}
+	journalID, ok := h.beginWrite(c, item, false, event, "failed to delete item")
+	if !ok {
 		return
 	}
 
-	// Delete from repository
-	if err := h.repository.Delete(c.Request.Context(), id); err != nil {
+	// Soft delete: the row stays with deleted_at set
+	if err := h.repository.Save(c.Request.Context(), item); err != nil {
+		h.journal.Aborted(journalID)
+		if err == domain.ErrVersionConflict {
+			h.respondVersionConflict(c, item.ID)
+			return
+		}
 		h.logger.Error("Failed to delete item", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete item"})
+		errors.Respond(c, errors.NewInternalError("failed to delete item", nil))
 		return
 	}
+	auditItemAfter(c, item)
 
 	// Publish event
-	event := events.InventoryItemDeletedEvent{
-		ItemID:     item.ID,
-		SKU:        item.SKU,
-		OccurredAt: item.UpdatedAt,
-	}
-	if err := h.eventBus.Publish(c.Request.Context(), event); err != nil {
+	if err := h.publish(c, journalID, event); err != nil {
 		h.logger.Error("Failed to publish event", zap.Error(err))
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "item deleted successfully"})
 }
 
+// RestoreItem handles POST /api/v1/inventory/items/:id/restore
+// @Summary      Restore a deleted inventory item
+// @Description  Restaura un item eliminado con `DELETE /inventory/items/{id}`: vuelve a los listados con el stock que tenía y admite operaciones de nuevo. Publica un evento InventoryItemRestored. Requiere el permiso `inventory:delete`.
+//
+// **Ejemplos válidos:**
+// - `POST /inventory/items/{id}/restore` sobre un item eliminado
+//
+// **Ejemplos inválidos:**
+// - ID inválido (UUID malformado)
+// - Item no encontrado
+// - Item que no está eliminado (409)
+//
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string         true  "Item ID (UUID)" example(550e8400-e29b-41d4-a716-446655440000)
+// @Success      200  {object}  UpdateItemResponse  "Item restaurado exitosamente"
+// @Failure      400  {object}  ErrorResponse  "ID inválido"
+// @Failure      401  {object}  ErrorResponse  "No autorizado - token JWT inválido o faltante"
+// @Failure      403  {object}  ErrorResponse  "Prohibido - el rol del token no tiene permiso inventory:delete"
+// @Failure      404  {object}  ErrorResponse  "Item no encontrado"
+// @Failure      409  {object}  ErrorResponse  "Conflicto - el item no está eliminado o cambió mientras se restauraba"
+// @Failure      500  {object}  ErrorResponse  "Error interno del servidor - error de persistencia o conexión a base de datos"
+// @Router       /inventory/items/{id}/restore [post]
+func (h *InventoryHandler) RestoreItem(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid item id", ""))
+		return
+	}
+
+	item, err := h.repository.FindIncludingDeleted(c.Request.Context(), id)
+	if err != nil {
+		if err == domain.ErrItemNotFound {
+			errors.Respond(c, errors.NewItemNotFound(id.String()))
+			return
+		}
+		h.logger.Error("Failed to find item", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to restore item", nil))
+		return
+	}
+	auditItemBefore(c, item)
+	expected := item.Version
+
+	if err := item.Restore(); err != nil {
+		errors.Respond(c, errors.NewConflict(err.Error(), "Item ID: "+id.String()))
+		return
+	}
+	event := events.InventoryItemRestoredEvent{
+		ItemID:          item.ID,
+		SKU:             item.SKU,
+		ExpectedVersion: expected,
+		OccurredAt:      item.UpdatedAt,
+	}
+	journalID, ok := h.beginWrite(c, item, false, event, "failed to restore item")
+	if !ok {
+		return
+	}
+
+	if err := h.repository.Save(c.Request.Context(), item); err != nil {
+		h.journal.Aborted(journalID)
+		if err == domain.ErrVersionConflict {
+			h.respondVersionConflict(c, item.ID)
+			return
+		}
+		h.logger.Error("Failed to restore item", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to restore item", nil))
+		return
+	}
+	auditItemAfter(c, item)
+
+	if err := h.publish(c, journalID, event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+
+	setETag(c, item)
+	c.JSON(http.StatusOK, gin.H{
+		"id":          item.ID,
+		"sku":         item.SKU,
+		"name":        item.Name,
+		"description": item.Description,
+		"quantity":    item.Quantity,
+		"version":     item.Version,
+		"updated_at":  item.UpdatedAt,
+	})
+}
+
 // AdjustStock handles POST /api/v1/inventory/items/:id/adjust
 // @Summary      Adjust stock quantity
 // @Description  Ajusta la cantidad de stock de un item. Valores positivos aumentan el stock, valores negativos lo disminuyen. No se puede ajustar a un valor negativo total.
@@ -284,38 +626,56 @@ func (h *InventoryHandler) DeleteItem(c *gin.Context) {
 // **Ejemplos válidos:**
 // - Aumentar stock: `{"quantity": 10}`
 // - Disminuir stock: `{"quantity": -5}` (siempre que el resultado sea >= 0)
+// - Recepción con costo unitario: `{"quantity": 10, "unit_cost": 12.5}` (crea una capa de costo para la valorización)
+// - Ajuste condicionado a la versión: `If-Match: "3"` o `{"quantity": -5, "version": 3}`
 //
 // **Ejemplos inválidos:**
 // - Cantidad faltante
 // - Ajuste que resultaría en stock negativo
+// - `unit_cost` negativo o enviado junto con un ajuste negativo
 // - ID inválido o item no encontrado
+// - Versión desactualizada (409 con `current_version`)
 //
 // @Tags         inventory
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
+// @Param        If-Match header    string              false "Versión esperada del item (ETag), p. ej. \"3\""
 // @Param        id       path      string              true  "Item ID (UUID)" example(550e8400-e29b-41d4-a716-446655440000)
 // @Param        request  body      AdjustStockRequest  true  "Stock adjustment request"
 // @Success      200      {object}  StockResponse       "Stock ajustado exitosamente"
 // @Failure      400      {object}  ErrorResponse       "Request inválido - ID inválido, cantidad faltante o stock insuficiente"
 // @Failure      401      {object}  ErrorResponse       "No autorizado - token JWT inválido o faltante"
 // @Failure      404      {object}  ErrorResponse       "Item no encontrado"
+// @Failure      409      {object}  VersionConflictResponse  "Conflicto - el item cambió desde la versión esperada"
 // @Failure      500      {object}  ErrorResponse       "Error interno del servidor - error de persistencia o conexión a base de datos"
 // @Failure      503      {object}  ErrorResponse       "Servicio no disponible - error de conexión al event broker"
 // @Router       /inventory/items/{id}/adjust [post]
 func (h *InventoryHandler) AdjustStock(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item id"})
+		errors.Respond(c, errors.NewInvalidRequest("invalid item id", ""))
 		return
 	}
 
 	var req struct {
-		Quantity int `json:"quantity" binding:"required"`
+		Quantity int      `json:"quantity" binding:"required,quantity"`
+		UnitCost *float64 `json:"unit_cost" binding:"omitempty,min=0"`
+		Version  *int     `json:"version" binding:"omitempty,min=1"`
+		StockNote
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if bindErr := validation.BindJSON(c, &req); bindErr != nil {
+		errors.Respond(c, bindErr)
+		return
+	}
+	if !checkStockNote(c, req.StockNote) {
+		return
+	}
+
+	// Unit cost describes a stock receipt; outgoing stock is valued from existing cost layers
+	if req.UnitCost != nil && req.Quantity < 0 {
+		errors.Respond(c, errors.NewInvalidRequest("unit_cost is only allowed for positive adjustments", ""))
 		return
 	}
 
@@ -323,46 +683,70 @@ func (h *InventoryHandler) AdjustStock(c *gin.Context) {
 	item, err := h.repository.FindByID(c.Request.Context(), id)
 	if err != nil {
 		if err == domain.ErrItemNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+			errors.Respond(c, errors.NewItemNotFound(id.String()))
 			return
 		}
 		h.logger.Error("Failed to find item", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to adjust stock"})
+		errors.Respond(c, errors.NewInternalError("failed to adjust stock", nil))
+		return
+	}
+	auditItemBefore(c, item)
+	if !h.checkVersion(c, item, req.Version) {
 		return
 	}
+	expected := item.Version
 
 	// Adjust stock
 	if err := item.AdjustStock(req.Quantity); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		errors.Respond(c, domainError(err))
+		return
+	}
+	event := events.StockAdjustedEvent{
+		ItemID:          item.ID,
+		SKU:             item.SKU,
+		Quantity:        req.Quantity,
+		NewTotal:        item.Quantity,
+		UnitCost:        req.UnitCost,
+		ExpectedVersion: expected,
+		Reason:          req.Reason,
+		Reference:       req.Reference,
+		Actor:           requestActor(c),
+		OccurredAt:      item.UpdatedAt,
+	}
+	journalID, ok := h.beginWrite(c, item, false, event, "failed to adjust stock")
+	if !ok {
 		return
 	}
 
 	// Save changes
 	if err := h.repository.Save(c.Request.Context(), item); err != nil {
+		h.journal.Aborted(journalID)
+		if err == domain.ErrVersionConflict {
+			h.respondVersionConflict(c, item.ID)
+			return
+		}
 		h.logger.Error("Failed to save item", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to adjust stock"})
+		errors.Respond(c, errors.NewInternalError("failed to adjust stock", nil))
 		return
 	}
+	auditItemAfter(c, item)
 
 	// Publish event
-	event := events.StockAdjustedEvent{
-		ItemID:     item.ID,
-		SKU:        item.SKU,
-		Quantity:   req.Quantity,
-		NewTotal:   item.Quantity,
-		OccurredAt: item.UpdatedAt,
-	}
-	if err := h.eventBus.Publish(c.Request.Context(), event); err != nil {
+	if err := h.publish(c, journalID, event); err != nil {
 		h.logger.Error("Failed to publish event", zap.Error(err))
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"id":         item.ID,
 		"quantity":   item.Quantity,
 		"available":  item.AvailableQuantity(),
 		"reserved":   item.Reserved,
+		"version":    item.Version,
 		"updated_at": item.UpdatedAt,
-	})
+	}
+	addStockNote(response, req.StockNote)
+	setETag(c, item)
+	c.JSON(http.StatusOK, response)
 }
 
 // ReserveStock handles POST /api/v1/inventory/items/:id/reserve
@@ -371,39 +755,67 @@ func (h *InventoryHandler) AdjustStock(c *gin.Context) {
 //
 // **Ejemplos válidos:**
 // - Reservar cantidad disponible: `{"quantity": 5}`
+// - Reservar para una tienda: `?store_id=<uuid>` con `{"quantity": 5}` (evento StoreReservationCreated)
+// - Encolar si no hay stock: `{"quantity": 20, "waitlist": true}` (202, evento ReservationWaitlisted; el listener la atiende en orden FIFO cuando se libera o ingresa stock)
+// - Reservar en una ubicación: `?location=WH-MAD-01` con `{"quantity": 5}` (sin `location` se reserva del stock no asignado a ninguna ubicación)
 //
 // **Ejemplos inválidos:**
 // - Cantidad faltante
 // - Cantidad menor a 1
-// - Stock insuficiente (cantidad > disponible)
+// - Stock insuficiente (cantidad > disponible) sin `waitlist`
+// - `waitlist` junto con `store_id` (la lista de espera es solo para reservas de item)
+// - `location` junto con `store_id` o `waitlist`, o una ubicación sin stock del item
+// - Tienda inactiva o inexistente
+// - Tienda cerrada según su horario (`PUT /stores/{id}/calendar`), solo con `ENFORCE_STORE_HOURS=true`
 // - ID inválido o item no encontrado
 //
 // @Tags         inventory
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
-// @Param        id       path      string               true  "Item ID (UUID)" example(550e8400-e29b-41d4-a716-446655440000)
-// @Param        request  body      ReserveStockRequest  true  "Stock reservation request"
+// @Param        id        path      string               true   "Item ID (UUID)" example(550e8400-e29b-41d4-a716-446655440000)
+// @Param        store_id  query     string               false  "Store ID (UUID) al que se atribuye la reserva"
+// @Param        location  query     string               false  "Ubicación de la que se reserva" example(WH-MAD-01)
+// @Param        request   body      ReserveStockRequest  true   "Stock reservation request"
 // @Success      200      {object}  StockResponse       "Stock reservado exitosamente"
-// @Failure      400      {object}  ErrorResponse       "Request inválido - ID inválido, cantidad inválida o stock insuficiente"
+// @Success      202      {object}  WaitlistResponse    "Stock insuficiente - reserva encolada en la lista de espera"
+// @Failure      400      {object}  ErrorResponse       "Request inválido - ID inválido, cantidad inválida, stock insuficiente o tienda inactiva"
 // @Failure      401      {object}  ErrorResponse       "No autorizado - token JWT inválido o faltante"
-// @Failure      404      {object}  ErrorResponse       "Item no encontrado"
+// @Failure      404      {object}  ErrorResponse       "Item o tienda no encontrado"
+// @Failure      409      {object}  ErrorResponse       "Tienda cerrada (fuera de su horario)"
 // @Failure      500      {object}  ErrorResponse       "Error interno del servidor - error de persistencia o conexión a base de datos"
 // @Failure      503      {object}  ErrorResponse       "Servicio no disponible - error de conexión al event broker"
 // @Router       /inventory/items/{id}/reserve [post]
 func (h *InventoryHandler) ReserveStock(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item id"})
+		errors.Respond(c, errors.NewInvalidRequest("invalid item id", ""))
 		return
 	}
 
 	var req struct {
-		Quantity int `json:"quantity" binding:"required,min=1"`
+		Quantity int  `json:"quantity" binding:"required,min=1,quantity"`
+		Waitlist bool `json:"waitlist"`
+		StockNote
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if bindErr := validation.BindJSON(c, &req); bindErr != nil {
+		errors.Respond(c, bindErr)
+		return
+	}
+	if !checkStockNote(c, req.StockNote) {
+		return
+	}
+	if req.Waitlist && c.Query("store_id") != "" {
+		errors.Respond(c, errors.NewInvalidRequest("waitlist is not supported for store reservations", ""))
+		return
+	}
+	location, ok := locationParam(c)
+	if !ok {
+		return
+	}
+	if location != "" && (req.Waitlist || c.Query("store_id") != "") {
+		errors.Respond(c, errors.NewInvalidRequest("location cannot be combined with store_id or waitlist", ""))
 		return
 	}
 
@@ -411,46 +823,154 @@ func (h *InventoryHandler) ReserveStock(c *gin.Context) {
 	item, err := h.repository.FindByID(c.Request.Context(), id)
 	if err != nil {
 		if err == domain.ErrItemNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+			errors.Respond(c, errors.NewItemNotFound(id.String()))
 			return
 		}
 		h.logger.Error("Failed to find item", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reserve stock"})
+		errors.Respond(c, errors.NewInternalError("failed to reserve stock", nil))
 		return
 	}
+	auditItemBefore(c, item)
 
-	// Reserve stock
-	if err := item.ReserveStock(req.Quantity); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	// Optional store attribution
+	store, ok := h.findStoreParam(c)
+	if !ok {
+		return
+	}
+	if store != nil && !store.Active {
+		errors.Respond(c, errors.NewInvalidOperation(domain.ErrStoreInactive.Error(), ""))
+		return
+	}
+	if store != nil && h.enforceStoreHours && !store.Calendar.IsOpen(time.Now()) {
+		errors.Respond(c, errors.NewConflict(domain.ErrStoreClosed.Error(), ""))
 		return
 	}
 
-	// Save changes
-	if err := h.repository.Save(c.Request.Context(), item); err != nil {
-		h.logger.Error("Failed to save item", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reserve stock"})
+	// Reserve stock
+	reserve := item.ReserveStock
+	if location != "" {
+		reserve = func(quantity int) error { return item.ReserveAtLocation(location, quantity) }
+	}
+	if err := reserve(req.Quantity); err != nil {
+		if err == domain.ErrInsufficientStock && req.Waitlist {
+			h.waitlistReservation(c, item, req.Quantity)
+			return
+		}
+		errors.Respond(c, domainError(err))
 		return
 	}
 
-	// Publish event
-	event := events.StockReservedEvent{
+	var event interface{} = events.StockReservedEvent{
 		ItemID:     item.ID,
 		SKU:        item.SKU,
 		Quantity:   req.Quantity,
 		Reserved:   item.Reserved,
 		Available:  item.AvailableQuantity(),
+		Location:   location,
+		Reason:     req.Reason,
+		Reference:  req.Reference,
+		Actor:      requestActor(c),
 		OccurredAt: item.UpdatedAt,
 	}
-	if err := h.eventBus.Publish(c.Request.Context(), event); err != nil {
-		h.logger.Error("Failed to publish event", zap.Error(err))
+	reservationID := uuid.New()
+	if store != nil {
+		event = events.StoreReservationCreatedEvent{
+			ReservationID: reservationID,
+			StoreID:       store.ID,
+			ItemID:        item.ID,
+			SKU:           item.SKU,
+			Quantity:      req.Quantity,
+			Reserved:      item.Reserved,
+			Available:     item.AvailableQuantity(),
+			Reason:        req.Reason,
+			Reference:     req.Reference,
+			Actor:         requestActor(c),
+			OccurredAt:    item.UpdatedAt,
+		}
+	}
+	journalID, ok := h.beginWrite(c, item, false, event, "failed to reserve stock")
+	if !ok {
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	// Save changes
+	if err := h.repository.Save(c.Request.Context(), item); err != nil {
+		h.journal.Aborted(journalID)
+		if err == domain.ErrVersionConflict {
+			h.respondVersionConflict(c, item.ID)
+			return
+		}
+		h.logger.Error("Failed to save item", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to reserve stock", nil))
+		return
+	}
+	auditItemAfter(c, item)
+
+	response := gin.H{
 		"id":         item.ID,
 		"quantity":   item.Quantity,
 		"available":  item.AvailableQuantity(),
 		"reserved":   item.Reserved,
 		"updated_at": item.UpdatedAt,
+	}
+	addLocation(response, item, location)
+	addStockNote(response, req.StockNote)
+
+	if store != nil {
+		if err := store.Reserve(item.ID, req.Quantity); err != nil {
+			errors.Respond(c, domainError(err))
+			return
+		}
+		if err := h.stores.Save(c.Request.Context(), store); err != nil {
+			h.logger.Error("Failed to save store", zap.Error(err))
+			errors.Respond(c, errors.NewInternalError("failed to reserve stock", nil))
+			return
+		}
+
+		response["store_id"] = store.ID
+		response["reservation_id"] = reservationID
+		response["store_reserved"] = store.ReservedQuantity(item.ID)
+	}
+
+	// Publish event
+	if err := h.publish(c, journalID, event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// waitlistReservation queues a reservation that cannot be satisfied yet. The item
+// is not modified here: the listener reserves the stock when it becomes available.
+func (h *InventoryHandler) waitlistReservation(c *gin.Context, item *domain.InventoryItem, quantity int) {
+	waitlistID := uuid.New()
+	event := events.ReservationWaitlistedEvent{
+		WaitlistID: waitlistID,
+		ItemID:     item.ID,
+		SKU:        item.SKU,
+		Quantity:   quantity,
+		Available:  item.AvailableQuantity(),
+		OccurredAt: time.Now().UTC(),
+	}
+	// Unlike other writes, nothing is persisted before publishing: without the event
+	// the reservation would be lost, so a publish failure is reported to the client
+	if err := h.eventBus.Publish(c.Request.Context(), event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+		errors.Respond(c, errors.NewServiceUnavailable("failed to enqueue reservation", ""))
+		return
+	}
+
+	h.logger.Info("Reservation waitlisted",
+		zap.String("item_id", item.ID.String()),
+		zap.String("waitlist_id", waitlistID.String()),
+		zap.Int("quantity", quantity),
+	)
+	c.JSON(http.StatusAccepted, WaitlistResponse{
+		WaitlistID: waitlistID.String(),
+		ID:         item.ID.String(),
+		Status:     "waiting",
+		Quantity:   quantity,
+		Available:  item.AvailableQuantity(),
 	})
 }
 
@@ -460,19 +980,24 @@ func (h *InventoryHandler) ReserveStock(c *gin.Context) {
 //
 // **Ejemplos válidos:**
 // - Liberar cantidad reservada: `{"quantity": 5}`
+// - Liberar la reserva de una tienda: `?store_id=<uuid>` con `{"quantity": 5}`
+// - Liberar lo reservado en una ubicación: `?location=WH-MAD-01` con `{"quantity": 5}`
 //
 // **Ejemplos inválidos:**
 // - Cantidad faltante
 // - Cantidad menor a 1
-// - Cantidad excede lo reservado
+// - Cantidad excede lo reservado (o lo reservado por la tienda o en la ubicación)
+// - `location` junto con `store_id`
 // - ID inválido o item no encontrado
 //
 // @Tags         inventory
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
-// @Param        id       path      string               true  "Item ID (UUID)" example(550e8400-e29b-41d4-a716-446655440000)
-// @Param        request  body      ReleaseStockRequest  true  "Stock release request"
+// @Param        id        path      string               true   "Item ID (UUID)" example(550e8400-e29b-41d4-a716-446655440000)
+// @Param        store_id  query     string               false  "Store ID (UUID) cuya reserva se libera"
+// @Param        location  query     string               false  "Ubicación cuya reserva se libera" example(WH-MAD-01)
+// @Param        request   body      ReleaseStockRequest  true   "Stock release request"
 // @Success      200      {object}  StockResponse       "Stock liberado exitosamente"
 // @Failure      400      {object}  ErrorResponse       "Request inválido - ID inválido, cantidad inválida o cantidad a liberar excede lo reservado"
 // @Failure      401      {object}  ErrorResponse       "No autorizado - token JWT inválido o faltante"
@@ -483,16 +1008,28 @@ func (h *InventoryHandler) ReserveStock(c *gin.Context) {
 func (h *InventoryHandler) ReleaseStock(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item id"})
+		errors.Respond(c, errors.NewInvalidRequest("invalid item id", ""))
 		return
 	}
 
 	var req struct {
-		Quantity int `json:"quantity" binding:"required,min=1"`
+		Quantity int `json:"quantity" binding:"required,min=1,quantity"`
+		StockNote
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if bindErr := validation.BindJSON(c, &req); bindErr != nil {
+		errors.Respond(c, bindErr)
+		return
+	}
+	if !checkStockNote(c, req.StockNote) {
+		return
+	}
+	location, ok := locationParam(c)
+	if !ok {
+		return
+	}
+	if location != "" && c.Query("store_id") != "" {
+		errors.Respond(c, errors.NewInvalidRequest("location cannot be combined with store_id", ""))
 		return
 	}
 
@@ -500,45 +1037,337 @@ func (h *InventoryHandler) ReleaseStock(c *gin.Context) {
 	item, err := h.repository.FindByID(c.Request.Context(), id)
 	if err != nil {
 		if err == domain.ErrItemNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+			errors.Respond(c, errors.NewItemNotFound(id.String()))
 			return
 		}
 		h.logger.Error("Failed to find item", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to release stock"})
+		errors.Respond(c, errors.NewInternalError("failed to release stock", nil))
+		return
+	}
+	auditItemBefore(c, item)
+
+	// Optional store attribution: the store must hold enough reserved stock
+	store, ok := h.findStoreParam(c)
+	if !ok {
+		return
+	}
+	if store != nil && store.ReservedQuantity(item.ID) < req.Quantity {
+		errors.Respond(c, errors.NewInvalidOperation(domain.ErrInvalidReleaseQuantity.Error(), ""))
 		return
 	}
 
 	// Release stock
-	if err := item.ReleaseStock(req.Quantity); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	release := item.ReleaseStock
+	if location != "" {
+		release = func(quantity int) error { return item.ReleaseAtLocation(location, quantity) }
+	}
+	if err := release(req.Quantity); err != nil {
+		errors.Respond(c, domainError(err))
+		return
+	}
+
+	var event interface{} = events.StockReleasedEvent{
+		ItemID:     item.ID,
+		SKU:        item.SKU,
+		Quantity:   req.Quantity,
+		Reserved:   item.Reserved,
+		Available:  item.AvailableQuantity(),
+		Location:   location,
+		Reason:     req.Reason,
+		Reference:  req.Reference,
+		Actor:      requestActor(c),
+		OccurredAt: item.UpdatedAt,
+	}
+	if store != nil {
+		event = events.StoreReservationReleasedEvent{
+			StoreID:    store.ID,
+			ItemID:     item.ID,
+			SKU:        item.SKU,
+			Quantity:   req.Quantity,
+			Reserved:   item.Reserved,
+			Available:  item.AvailableQuantity(),
+			Reason:     req.Reason,
+			Reference:  req.Reference,
+			Actor:      requestActor(c),
+			OccurredAt: item.UpdatedAt,
+		}
+	}
+	journalID, ok := h.beginWrite(c, item, false, event, "failed to release stock")
+	if !ok {
 		return
 	}
 
 	// Save changes
 	if err := h.repository.Save(c.Request.Context(), item); err != nil {
+		h.journal.Aborted(journalID)
+		if err == domain.ErrVersionConflict {
+			h.respondVersionConflict(c, item.ID)
+			return
+		}
 		h.logger.Error("Failed to save item", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to release stock"})
+		errors.Respond(c, errors.NewInternalError("failed to release stock", nil))
 		return
 	}
+	auditItemAfter(c, item)
+
+	response := gin.H{
+		"id":         item.ID,
+		"quantity":   item.Quantity,
+		"available":  item.AvailableQuantity(),
+		"reserved":   item.Reserved,
+		"updated_at": item.UpdatedAt,
+	}
+	addLocation(response, item, location)
+	addStockNote(response, req.StockNote)
+
+	if store != nil {
+		if err := store.Release(item.ID, req.Quantity); err != nil {
+			errors.Respond(c, domainError(err))
+			return
+		}
+		if err := h.stores.Save(c.Request.Context(), store); err != nil {
+			h.logger.Error("Failed to save store", zap.Error(err))
+			errors.Respond(c, errors.NewInternalError("failed to release stock", nil))
+			return
+		}
+
+		response["store_id"] = store.ID
+		response["store_reserved"] = store.ReservedQuantity(item.ID)
+	}
 
 	// Publish event
-	event := events.StockReleasedEvent{
+	if err := h.publish(c, journalID, event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CommitStock handles POST /api/v1/inventory/items/:id/commit
+// @Summary      Commit reserved stock
+// @Description  Convierte stock reservado en una venta: descuenta la cantidad de lo reservado y del stock total en una sola operación, por lo que el disponible no cambia. Publica un evento StockCommitted.
+//
+// **Ejemplos válidos:**
+// - Confirmar la venta de una reserva: `{"quantity": 5}` (con al menos 5 unidades reservadas)
+// - Vender desde una ubicación: `?location=store-12` con `{"quantity": 1}` (las unidades salen de esa ubicación)
+//
+// **Ejemplos inválidos:**
+// - Cantidad faltante o menor a 1
+// - Cantidad mayor a lo reservado (o a lo reservado en la ubicación)
+// - ID inválido o item no encontrado
+//
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string              true  "Item ID (UUID)" example(550e8400-e29b-41d4-a716-446655440000)
+// @Param        location query     string              false "Ubicación de la que sale el stock" example(store-12)
+// @Param        request  body      CommitStockRequest  true  "Stock commit request"
+// @Success      200      {object}  StockResponse       "Stock comprometido exitosamente"
+// @Failure      400      {object}  ErrorResponse       "Request inválido - ID inválido, cantidad inválida o mayor a lo reservado"
+// @Failure      401      {object}  ErrorResponse       "No autorizado - token JWT inválido o faltante"
+// @Failure      404      {object}  ErrorResponse       "Item no encontrado"
+// @Failure      500      {object}  ErrorResponse       "Error interno del servidor - error de persistencia o conexión a base de datos"
+// @Router       /inventory/items/{id}/commit [post]
+func (h *InventoryHandler) CommitStock(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid item id", ""))
+		return
+	}
+
+	var req CommitStockRequest
+	if bindErr := validation.BindJSON(c, &req); bindErr != nil {
+		errors.Respond(c, bindErr)
+		return
+	}
+	if !checkStockNote(c, req.StockNote) {
+		return
+	}
+	location, ok := locationParam(c)
+	if !ok {
+		return
+	}
+
+	item, err := h.repository.FindByID(c.Request.Context(), id)
+	if err != nil {
+		if err == domain.ErrItemNotFound {
+			errors.Respond(c, errors.NewItemNotFound(id.String()))
+			return
+		}
+		h.logger.Error("Failed to find item", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to commit stock", nil))
+		return
+	}
+	auditItemBefore(c, item)
+
+	// Reserved and total quantity drop together
+	fulfill := item.FulfillReservation
+	if location != "" {
+		fulfill = func(quantity int) error { return item.FulfillAtLocation(location, quantity) }
+	}
+	if err := fulfill(req.Quantity); err != nil {
+		errors.Respond(c, domainError(err))
+		return
+	}
+	event := events.StockCommittedEvent{
 		ItemID:     item.ID,
 		SKU:        item.SKU,
 		Quantity:   req.Quantity,
+		NewTotal:   item.Quantity,
 		Reserved:   item.Reserved,
 		Available:  item.AvailableQuantity(),
+		Location:   location,
+		Reason:     req.Reason,
+		Reference:  req.Reference,
+		Actor:      requestActor(c),
 		OccurredAt: item.UpdatedAt,
 	}
-	if err := h.eventBus.Publish(c.Request.Context(), event); err != nil {
+	journalID, ok := h.beginWrite(c, item, false, event, "failed to commit stock")
+	if !ok {
+		return
+	}
+
+	if err := h.repository.Save(c.Request.Context(), item); err != nil {
+		h.journal.Aborted(journalID)
+		if err == domain.ErrVersionConflict {
+			h.respondVersionConflict(c, item.ID)
+			return
+		}
+		h.logger.Error("Failed to save item", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to commit stock", nil))
+		return
+	}
+	auditItemAfter(c, item)
+
+	if err := h.publish(c, journalID, event); err != nil {
 		h.logger.Error("Failed to publish event", zap.Error(err))
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"id":         item.ID,
 		"quantity":   item.Quantity,
 		"available":  item.AvailableQuantity(),
 		"reserved":   item.Reserved,
 		"updated_at": item.UpdatedAt,
+	}
+	addLocation(response, item, location)
+	addStockNote(response, req.StockNote)
+	c.JSON(http.StatusOK, response)
+}
+
+// ForceSetStock handles POST /api/v1/admin/items/:id/force-set-stock
+// @Summary      Force-set stock counters (admin)
+// @Description  Sobrescribe la cantidad y lo reservado de un item con valores explícitos, para que soporte corrija contadores corruptos (p. ej. un `reserved` que quedó trabado). Publica un evento ManualCorrection con los valores anteriores, los nuevos y el motivo; el listener lo registra en el historial de movimientos con el actor y el motivo. Las reservas por tienda no se modifican. Requiere el permiso `inventory:override` (rol admin por defecto).
+//
+// **Ejemplos válidos:**
+// - Liberar un reservado trabado: `{"quantity": 40, "reserved": 0, "reason": "reserva huérfana"}`
+// - Corregir tras un conteo físico: `{"quantity": 35, "reserved": 5, "reason": "conteo cíclico 2024-01-15"}`
+//
+// **Ejemplos inválidos:**
+// - `quantity`, `reserved` o `reason` faltantes
+// - Valores negativos
+// - `reserved` mayor que `quantity`
+// - ID inválido o item no encontrado
+//
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                    true  "Item ID (UUID)" example(550e8400-e29b-41d4-a716-446655440000)
+// @Param        request  body      ForceSetStockRequest      true  "Stock correction"
+// @Success      200      {object}  ManualCorrectionResponse  "Contadores corregidos"
+// @Failure      400      {object}  ErrorResponse             "Request inválido - ID inválido, campos faltantes o contadores inconsistentes"
+// @Failure      401      {object}  ErrorResponse             "No autorizado - token JWT inválido o faltante"
+// @Failure      403      {object}  ErrorResponse             "Sin permiso inventory:override"
+// @Failure      404      {object}  ErrorResponse             "Item no encontrado"
+// @Failure      500      {object}  ErrorResponse             "Error interno del servidor - error de persistencia"
+// @Router       /admin/items/{id}/force-set-stock [post]
+func (h *InventoryHandler) ForceSetStock(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid item id", ""))
+		return
+	}
+
+	var req ForceSetStockRequest
+	if bindErr := validation.BindJSON(c, &req); bindErr != nil {
+		errors.Respond(c, bindErr)
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		errors.Respond(c, errors.NewInvalidRequest("reason is required", ""))
+		return
+	}
+
+	item, err := h.repository.FindByID(c.Request.Context(), id)
+	if err != nil {
+		if err == domain.ErrItemNotFound {
+			errors.Respond(c, errors.NewItemNotFound(id.String()))
+			return
+		}
+		h.logger.Error("Failed to find item", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to correct stock", nil))
+		return
+	}
+	auditItemBefore(c, item)
+
+	previousQuantity, previousReserved := item.Quantity, item.Reserved
+	if err := item.ForceSetStock(*req.Quantity, *req.Reserved); err != nil {
+		errors.Respond(c, domainError(err))
+		return
+	}
+	event := events.ManualCorrectionEvent{
+		ItemID:           item.ID,
+		SKU:              item.SKU,
+		Quantity:         item.Quantity,
+		Reserved:         item.Reserved,
+		Available:        item.AvailableQuantity(),
+		PreviousQuantity: previousQuantity,
+		PreviousReserved: previousReserved,
+		Reason:           req.Reason,
+		OccurredAt:       item.UpdatedAt,
+	}
+	journalID, ok := h.beginWrite(c, item, false, event, "failed to correct stock")
+	if !ok {
+		return
+	}
+
+	if err := h.repository.Save(c.Request.Context(), item); err != nil {
+		h.journal.Aborted(journalID)
+		if err == domain.ErrVersionConflict {
+			h.respondVersionConflict(c, item.ID)
+			return
+		}
+		h.logger.Error("Failed to save item", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to correct stock", nil))
+		return
+	}
+	auditItemAfter(c, item)
+
+	h.logger.Warn("Manual stock correction",
+		zap.String("item_id", item.ID.String()),
+		zap.String("sku", item.SKU),
+		zap.String("actor", c.GetString("username")),
+		zap.Int("previous_quantity", previousQuantity),
+		zap.Int("quantity", item.Quantity),
+		zap.Int("previous_reserved", previousReserved),
+		zap.Int("reserved", item.Reserved),
+		zap.String("reason", req.Reason),
+	)
+
+	if err := h.publish(c, journalID, event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":                item.ID,
+		"quantity":          item.Quantity,
+		"available":         item.AvailableQuantity(),
+		"reserved":          item.Reserved,
+		"updated_at":        item.UpdatedAt,
+		"previous_quantity": previousQuantity,
+		"previous_reserved": previousReserved,
+		"reason":            req.Reason,
 	})
 }
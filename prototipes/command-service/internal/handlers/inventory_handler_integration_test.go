@@ -379,14 +379,13 @@ func TestStockOperations_Sequence_VerifiesMultipleEvents(t *testing.T) {
 	adjustEventJSON, _ := json.Marshal(events[0])
 	var adjustEvent map[string]interface{}
 	json.Unmarshal(adjustEventJSON, &adjustEvent)
-	// Note: JSON serialization uses field names, not JSON tags for structs
-	// The event struct fields are: Quantity, NewTotal (PascalCase)
-	assert.NotNil(t, adjustEvent["Quantity"], "AdjustStock event should have Quantity field")
-	assert.NotNil(t, adjustEvent["NewTotal"], "AdjustStock event should have NewTotal field")
-	if quantity, ok := adjustEvent["Quantity"].(float64); ok {
+	// Event payloads are serialized in camelCase, as the consumers read them
+	assert.NotNil(t, adjustEvent["quantity"], "AdjustStock event should have quantity field")
+	assert.NotNil(t, adjustEvent["newTotal"], "AdjustStock event should have newTotal field")
+	if quantity, ok := adjustEvent["quantity"].(float64); ok {
 		assert.Equal(t, float64(50), quantity)  // Ajuste
 	}
-	if newTotal, ok := adjustEvent["NewTotal"].(float64); ok {
+	if newTotal, ok := adjustEvent["newTotal"].(float64); ok {
 		assert.Equal(t, float64(150), newTotal) // Nueva cantidad
 	}
 
@@ -394,17 +393,17 @@ func TestStockOperations_Sequence_VerifiesMultipleEvents(t *testing.T) {
 	reserveEventJSON, _ := json.Marshal(events[1])
 	var reserveEvent map[string]interface{}
 	json.Unmarshal(reserveEventJSON, &reserveEvent)
-	assert.NotNil(t, reserveEvent["Quantity"], "ReserveStock event should have Quantity field")
-	assert.NotNil(t, reserveEvent["Reserved"], "ReserveStock event should have Reserved field")
-	assert.NotNil(t, reserveEvent["Available"], "ReserveStock event should have Available field")
+	assert.NotNil(t, reserveEvent["quantity"], "ReserveStock event should have quantity field")
+	assert.NotNil(t, reserveEvent["reserved"], "ReserveStock event should have reserved field")
+	assert.NotNil(t, reserveEvent["available"], "ReserveStock event should have available field")
 
 	// Verify third event (ReleaseStock)
 	releaseEventJSON, _ := json.Marshal(events[2])
 	var releaseEvent map[string]interface{}
 	json.Unmarshal(releaseEventJSON, &releaseEvent)
-	assert.NotNil(t, releaseEvent["Quantity"], "ReleaseStock event should have Quantity field")
-	assert.NotNil(t, releaseEvent["Reserved"], "ReleaseStock event should have Reserved field")
-	assert.NotNil(t, releaseEvent["Available"], "ReleaseStock event should have Available field")
+	assert.NotNil(t, releaseEvent["quantity"], "ReleaseStock event should have quantity field")
+	assert.NotNil(t, releaseEvent["reserved"], "ReleaseStock event should have reserved field")
+	assert.NotNil(t, releaseEvent["available"], "ReleaseStock event should have available field")
 
 	mockRepo.AssertExpectations(t)
 }
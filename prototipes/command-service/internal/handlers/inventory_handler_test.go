@@ -7,13 +7,18 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"command-service/internal/domain"
+	"command-service/internal/events"
+	"command-service/internal/repository"
+	"command-service/pkg/errors"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -43,6 +48,14 @@ func (m *MockInventoryRepository) FindBySKU(ctx context.Context, sku string) (*d
 	return args.Get(0).(*domain.InventoryItem), args.Error(1)
 }
 
+func (m *MockInventoryRepository) FindIncludingDeleted(ctx context.Context, id uuid.UUID) (*domain.InventoryItem, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.InventoryItem), args.Error(1)
+}
+
 func (m *MockInventoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -70,10 +83,13 @@ func setupTestRouter(handler *InventoryHandler) *gin.Engine {
 			inventory.POST("/items", handler.CreateItem)
 			inventory.PUT("/items/:id", handler.UpdateItem)
 			inventory.DELETE("/items/:id", handler.DeleteItem)
+			inventory.POST("/items/:id/restore", handler.RestoreItem)
 			inventory.POST("/items/:id/adjust", handler.AdjustStock)
 			inventory.POST("/items/:id/reserve", handler.ReserveStock)
 			inventory.POST("/items/:id/release", handler.ReleaseStock)
+			inventory.POST("/items/:id/commit", handler.CommitStock)
 		}
+		v1.POST("/admin/items/:id/force-set-stock", handler.ForceSetStock)
 	}
 
 	return router
@@ -155,9 +171,13 @@ func TestCreateItem_InvalidRequest_MissingFields(t *testing.T) {
 
 	// Assert
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	var response map[string]interface{}
-	json.Unmarshal(w.Body.Bytes(), &response)
-	assert.Contains(t, response["error"].(string), "required")
+	var response errors.StandardError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, errors.CodeValidationError, response.Code)
+	assert.ElementsMatch(t, []errors.FieldError{
+		{Field: "sku", Rule: "required", Message: "is required"},
+		{Field: "quantity", Rule: "required", Message: "is required"},
+	}, response.Fields)
 
 	mockRepo.AssertNotCalled(t, "Save")
 	mockEventBus.AssertNotCalled(t, "Publish")
@@ -194,14 +214,81 @@ func TestCreateItem_InvalidRequest_NegativeQuantity(t *testing.T) {
 
 	// Assert
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	var response map[string]interface{}
-	json.Unmarshal(w.Body.Bytes(), &response)
-	assert.Contains(t, response["error"].(string), "min")
+	var response errors.StandardError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, errors.CodeValidationError, response.Code)
+	require.Len(t, response.Fields, 1)
+	assert.Equal(t, errors.FieldError{Field: "quantity", Rule: "min", Message: "must be at least 0"}, response.Fields[0])
+
+	mockRepo.AssertNotCalled(t, "Save")
+	mockEventBus.AssertNotCalled(t, "Publish")
+}
+
+func TestCreateItem_InvalidFields(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	router := setupTestRouter(&InventoryHandler{logger: zap.NewNop(), repository: mockRepo, eventBus: mockEventBus})
+
+	longText := func(n int) string { return string(bytes.Repeat([]byte("a"), n)) }
+	for name, tc := range map[string]struct {
+		body  map[string]interface{}
+		field string
+		rule  string
+	}{
+		"sku with spaces":     {map[string]interface{}{"sku": "SKU 001", "name": "Item", "quantity": 1}, "sku", "sku"},
+		"sku starting with -": {map[string]interface{}{"sku": "-SKU", "name": "Item", "quantity": 1}, "sku", "sku"},
+		"sku too long":        {map[string]interface{}{"sku": longText(65), "name": "Item", "quantity": 1}, "sku", "sku"},
+		"blank name":          {map[string]interface{}{"sku": "SKU-001", "name": "   ", "quantity": 1}, "name", "itemname"},
+		"name too long":       {map[string]interface{}{"sku": "SKU-001", "name": longText(201), "quantity": 1}, "name", "itemname"},
+		"description too long": {map[string]interface{}{"sku": "SKU-001", "name": "Item", "description": longText(2001), "quantity": 1},
+			"description", "description"},
+		"quantity too large": {map[string]interface{}{"sku": "SKU-001", "name": "Item", "quantity": 1000001}, "quantity", "quantity"},
+		"quantity as string": {map[string]interface{}{"sku": "SKU-001", "name": "Item", "quantity": "ten"}, "quantity", "type"},
+	} {
+		body, _ := json.Marshal(tc.body)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/inventory/items", bytes.NewBuffer(body)))
+
+		require.Equal(t, http.StatusBadRequest, w.Code, name)
+		var response errors.StandardError
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), name)
+		assert.Equal(t, errors.CodeValidationError, response.Code, name)
+		require.Len(t, response.Fields, 1, name)
+		assert.Equal(t, tc.field, response.Fields[0].Field, name)
+		assert.Equal(t, tc.rule, response.Fields[0].Rule, name)
+		assert.NotEmpty(t, response.Fields[0].Message, name)
+	}
+
+	// Malformed JSON is not a field error
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/inventory/items", bytes.NewBufferString(`{"sku":`)))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var response errors.StandardError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, errors.CodeInvalidRequest, response.Code)
+	assert.Empty(t, response.Fields)
 
 	mockRepo.AssertNotCalled(t, "Save")
 	mockEventBus.AssertNotCalled(t, "Publish")
 }
 
+func TestAdjustStock_InvalidFields(t *testing.T) {
+	router := setupTestRouter(&InventoryHandler{logger: zap.NewNop(), repository: new(MockInventoryRepository), eventBus: new(MockEventPublisher)})
+
+	// Fields of the embedded stock note are reported by their JSON name
+	body, _ := json.Marshal(map[string]interface{}{"quantity": -2000000, "reason": string(bytes.Repeat([]byte("r"), 65))})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/inventory/items/"+uuid.New().String()+"/adjust", bytes.NewBuffer(body)))
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var response errors.StandardError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.ElementsMatch(t, []errors.FieldError{
+		{Field: "quantity", Rule: "quantity", Message: "must be between -1000000 and 1000000"},
+		{Field: "reason", Rule: "max", Message: "must be at most 64 characters"},
+	}, response.Fields)
+}
+
 func TestCreateItem_RepositoryError(t *testing.T) {
 	// Setup
 	logger := zap.NewNop()
@@ -238,7 +325,7 @@ func TestCreateItem_RepositoryError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 	var response map[string]interface{}
 	json.Unmarshal(w.Body.Bytes(), &response)
-	assert.Contains(t, response["error"].(string), "failed to create item")
+	assert.Contains(t, response["message"].(string), "failed to create item")
 
 	mockRepo.AssertExpectations(t)
 	mockEventBus.AssertNotCalled(t, "Publish")
@@ -328,7 +415,7 @@ func TestUpdateItem_NotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	var response map[string]interface{}
 	json.Unmarshal(w.Body.Bytes(), &response)
-	assert.Contains(t, response["error"].(string), "not found")
+	assert.Contains(t, response["message"].(string), "not found")
 
 	mockRepo.AssertExpectations(t)
 	mockEventBus.AssertNotCalled(t, "Publish")
@@ -418,12 +505,102 @@ func TestAdjustStock_InsufficientStock(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	var response map[string]interface{}
 	json.Unmarshal(w.Body.Bytes(), &response)
-	assert.Contains(t, response["error"].(string), "insufficient")
+	assert.Contains(t, response["message"].(string), "insufficient")
+	assert.Equal(t, "InsufficientStock", response["code"])
 
 	mockRepo.AssertExpectations(t)
 	mockEventBus.AssertNotCalled(t, "Publish")
 }
 
+func TestUpdateItem_IfMatchStaleVersion(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	router := setupTestRouter(&InventoryHandler{logger: zap.NewNop(), repository: mockRepo, eventBus: mockEventBus})
+
+	itemID := uuid.New()
+	existingItem := domain.NewInventoryItem("TEST-001", "Old Name", "", 100)
+	existingItem.ID = itemID
+	existingItem.Version = 5
+
+	body, _ := json.Marshal(map[string]interface{}{"name": "New Name"})
+	req, _ := http.NewRequest("PUT", "/api/v1/inventory/items/"+itemID.String(), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"4"`)
+	w := httptest.NewRecorder()
+
+	mockRepo.On("FindByID", mock.Anything, itemID).Return(existingItem, nil)
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, float64(5), response["current_version"])
+	assert.Equal(t, `"5"`, w.Header().Get("ETag"))
+	mockRepo.AssertNotCalled(t, "Save")
+	mockEventBus.AssertNotCalled(t, "Publish")
+}
+
+func TestAdjustStock_MatchingVersionCarriedInEvent(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	router := setupTestRouter(&InventoryHandler{logger: zap.NewNop(), repository: mockRepo, eventBus: mockEventBus})
+
+	itemID := uuid.New()
+	existingItem := domain.NewInventoryItem("TEST-001", "Test Item", "", 100)
+	existingItem.ID = itemID
+
+	body, _ := json.Marshal(map[string]interface{}{"quantity": -5, "version": 1})
+	req, _ := http.NewRequest("POST", "/api/v1/inventory/items/"+itemID.String()+"/adjust", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	mockRepo.On("FindByID", mock.Anything, itemID).Return(existingItem, nil)
+	mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.InventoryItem")).Return(nil)
+	mockEventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.StockAdjustedEvent) bool {
+		return e.ExpectedVersion == 1 && e.NewTotal == 95
+	})).Return(nil)
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, float64(2), response["version"])
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	mockEventBus.AssertExpectations(t)
+}
+
+func TestAdjustStock_ConcurrentSaveConflict(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	router := setupTestRouter(&InventoryHandler{logger: zap.NewNop(), repository: mockRepo, eventBus: mockEventBus})
+
+	itemID := uuid.New()
+	loaded := domain.NewInventoryItem("TEST-001", "Test Item", "", 100)
+	loaded.ID = itemID
+	current := *loaded
+	current.Version = 3
+
+	body, _ := json.Marshal(map[string]interface{}{"quantity": 10})
+	req, _ := http.NewRequest("POST", "/api/v1/inventory/items/"+itemID.String()+"/adjust", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Another writer saves between our read and our write
+	mockRepo.On("FindByID", mock.Anything, itemID).Return(loaded, nil).Once()
+	mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.InventoryItem")).Return(domain.ErrVersionConflict)
+	mockRepo.On("FindByID", mock.Anything, itemID).Return(&current, nil).Once()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, float64(3), response["current_version"])
+	mockEventBus.AssertNotCalled(t, "Publish")
+}
+
 func TestReserveStock_Success(t *testing.T) {
 	// Setup
 	logger := zap.NewNop()
@@ -509,12 +686,63 @@ func TestReserveStock_InsufficientStock(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	var response map[string]interface{}
 	json.Unmarshal(w.Body.Bytes(), &response)
-	assert.Contains(t, response["error"].(string), "insufficient")
+	assert.Contains(t, response["message"].(string), "insufficient")
 
 	mockRepo.AssertExpectations(t)
 	mockEventBus.AssertNotCalled(t, "Publish")
 }
 
+func TestReserveStock_InsufficientStock_Waitlist(t *testing.T) {
+	// Setup
+	logger := zap.NewNop()
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+
+	handler := &InventoryHandler{
+		logger:     logger,
+		repository: mockRepo,
+		eventBus:   mockEventBus,
+	}
+
+	router := setupTestRouter(handler)
+
+	// Test data
+	itemID := uuid.New()
+	existingItem := domain.NewInventoryItem("TEST-001", "Test Item", "Description", 10)
+	existingItem.ID = itemID
+
+	reqBody := map[string]interface{}{
+		"quantity": 20,
+		"waitlist": true,
+	}
+
+	body, _ := json.Marshal(reqBody)
+	req, _ := http.NewRequest("POST", "/api/v1/inventory/items/"+itemID.String()+"/reserve", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Mock expectations
+	mockRepo.On("FindByID", mock.Anything, itemID).Return(existingItem, nil)
+	mockEventBus.On("Publish", mock.Anything, mock.MatchedBy(func(event events.ReservationWaitlistedEvent) bool {
+		return event.ItemID == itemID && event.Quantity == 20
+	})).Return(nil)
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert: queued, item untouched
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	var response WaitlistResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, "waiting", response.Status)
+	assert.NotEmpty(t, response.WaitlistID)
+	assert.Equal(t, 10, response.Available)
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Save")
+	mockEventBus.AssertExpectations(t)
+}
+
 func TestReserveStock_ItemNotFound(t *testing.T) {
 	// Setup
 	logger := zap.NewNop()
@@ -551,7 +779,8 @@ func TestReserveStock_ItemNotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	var response map[string]interface{}
 	json.Unmarshal(w.Body.Bytes(), &response)
-	assert.Equal(t, "item not found", response["error"])
+	assert.Equal(t, "item not found", response["message"])
+	assert.Equal(t, "ItemNotFound", response["code"])
 
 	mockRepo.AssertExpectations(t)
 	mockEventBus.AssertNotCalled(t, "Publish")
@@ -588,7 +817,7 @@ func TestReserveStock_InvalidID(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	var response map[string]interface{}
 	json.Unmarshal(w.Body.Bytes(), &response)
-	assert.Equal(t, "invalid item id", response["error"])
+	assert.Equal(t, "invalid item id", response["message"])
 
 	mockRepo.AssertNotCalled(t, "FindByID")
 	mockEventBus.AssertNotCalled(t, "Publish")
@@ -707,7 +936,7 @@ func TestReserveStock_RepositoryError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 	var response map[string]interface{}
 	json.Unmarshal(w.Body.Bytes(), &response)
-	assert.Equal(t, "failed to reserve stock", response["error"])
+	assert.Equal(t, "failed to reserve stock", response["message"])
 
 	mockRepo.AssertExpectations(t)
 	mockEventBus.AssertNotCalled(t, "Publish")
@@ -896,9 +1125,81 @@ func TestReleaseStock_InvalidQuantity(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	var response map[string]interface{}
 	json.Unmarshal(w.Body.Bytes(), &response)
-	assert.Contains(t, response["error"].(string), "invalid")
+	assert.Contains(t, response["message"].(string), "invalid")
+
+	mockRepo.AssertExpectations(t)
+	mockEventBus.AssertNotCalled(t, "Publish")
+}
+
+func TestCommitStock_Success(t *testing.T) {
+	// Setup
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	handler := &InventoryHandler{
+		logger:     zap.NewNop(),
+		repository: mockRepo,
+		eventBus:   mockEventBus,
+	}
+	router := setupTestRouter(handler)
+
+	itemID := uuid.New()
+	existingItem := domain.NewInventoryItem("TEST-001", "Test Item", "Description", 100)
+	existingItem.ID = itemID
+	existingItem.Reserved = 20
+
+	req, _ := http.NewRequest("POST", "/api/v1/inventory/items/"+itemID.String()+"/commit", bytes.NewBufferString(`{"quantity": 5}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	mockRepo.On("FindByID", mock.Anything, itemID).Return(existingItem, nil)
+	mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.InventoryItem")).Return(nil)
+	mockEventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.StockCommittedEvent) bool {
+		return e.Quantity == 5 && e.NewTotal == 95 && e.Reserved == 15
+	})).Return(nil)
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert: reserved and quantity both drop, available is unchanged
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, float64(95), response["quantity"])
+	assert.Equal(t, float64(15), response["reserved"])
+	assert.Equal(t, float64(80), response["available"])
 
 	mockRepo.AssertExpectations(t)
+	mockEventBus.AssertExpectations(t)
+}
+
+func TestCommitStock_ExceedsReserved(t *testing.T) {
+	// Setup
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	handler := &InventoryHandler{
+		logger:     zap.NewNop(),
+		repository: mockRepo,
+		eventBus:   mockEventBus,
+	}
+	router := setupTestRouter(handler)
+
+	itemID := uuid.New()
+	existingItem := domain.NewInventoryItem("TEST-001", "Test Item", "Description", 100)
+	existingItem.ID = itemID
+	existingItem.Reserved = 3
+
+	req, _ := http.NewRequest("POST", "/api/v1/inventory/items/"+itemID.String()+"/commit", bytes.NewBufferString(`{"quantity": 5}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	mockRepo.On("FindByID", mock.Anything, itemID).Return(existingItem, nil)
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
 	mockEventBus.AssertNotCalled(t, "Publish")
 }
 
@@ -926,8 +1227,10 @@ func TestDeleteItem_Success(t *testing.T) {
 
 	// Mock expectations
 	mockRepo.On("FindByID", mock.Anything, itemID).Return(existingItem, nil)
-	mockRepo.On("Delete", mock.Anything, itemID).Return(nil)
-	mockEventBus.On("Publish", mock.Anything, mock.AnythingOfType("events.InventoryItemDeletedEvent")).Return(nil)
+	mockRepo.On("Save", mock.Anything, existingItem).Return(nil)
+	mockEventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.InventoryItemDeletedEvent) bool {
+		return e.ExpectedVersion == 1
+	})).Return(nil)
 
 	// Execute
 	router.ServeHTTP(w, req)
@@ -940,6 +1243,11 @@ func TestDeleteItem_Success(t *testing.T) {
 
 	mockRepo.AssertExpectations(t)
 	mockEventBus.AssertExpectations(t)
+
+	// Soft delete: the item is saved with deleted_at instead of being removed
+	assert.NotNil(t, existingItem.DeletedAt)
+	assert.Equal(t, 2, existingItem.Version)
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
 }
 
 func TestDeleteItem_NotFound(t *testing.T) {
@@ -972,9 +1280,194 @@ func TestDeleteItem_NotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	var response map[string]interface{}
 	json.Unmarshal(w.Body.Bytes(), &response)
-	assert.Contains(t, response["error"].(string), "not found")
+	assert.Contains(t, response["message"].(string), "not found")
 
 	mockRepo.AssertExpectations(t)
 	mockEventBus.AssertNotCalled(t, "Publish")
 }
 
+func TestRestoreItem(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	router := setupTestRouter(&InventoryHandler{logger: zap.NewNop(), repository: mockRepo, eventBus: mockEventBus})
+
+	item := domain.NewInventoryItem("TEST-001", "Test Item", "Description", 100)
+	require.NoError(t, item.Delete())
+	mockRepo.On("FindIncludingDeleted", mock.Anything, item.ID).Return(item, nil)
+	mockRepo.On("Save", mock.Anything, item).Return(nil)
+	mockEventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.InventoryItemRestoredEvent) bool {
+		return e.ExpectedVersion == 2 && e.SKU == "TEST-001"
+	})).Return(nil)
+
+	req, _ := http.NewRequest("POST", "/api/v1/inventory/items/"+item.ID.String()+"/restore", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, item.DeletedAt)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+	mockEventBus.AssertExpectations(t)
+
+	// Restoring an item that is not deleted is a conflict
+	req, _ = http.NewRequest("POST", "/api/v1/inventory/items/"+item.ID.String()+"/restore", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockRepo.AssertNumberOfCalls(t, "Save", 1)
+}
+
+
+func TestCreateItem_DeduplicatesRetryBySKUAndActor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := repository.NewInventoryRepository()
+	mockEventBus := new(MockEventPublisher)
+	mockEventBus.On("Publish", mock.Anything, mock.AnythingOfType("events.InventoryItemCreatedEvent")).Return(nil).Once()
+
+	handler := &InventoryHandler{
+		logger:     zap.NewNop(),
+		repository: repo,
+		eventBus:   mockEventBus,
+		dedup:      newCreateDedup(time.Minute),
+	}
+
+	router := gin.New()
+	router.POST("/items", func(c *gin.Context) {
+		c.Set("username", c.GetHeader("X-Test-User"))
+	}, handler.CreateItem)
+
+	create := func(user string) *httptest.ResponseRecorder {
+		body := []byte(`{"sku":"DEDUP-001","name":"Item","quantity":5}`)
+		req, _ := http.NewRequest("POST", "/items", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", user)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := create("alice")
+	assert.Equal(t, http.StatusCreated, first.Code)
+
+	retry := create("alice")
+	assert.Equal(t, http.StatusOK, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get("X-Deduplicated"))
+
+	var created, deduplicated map[string]interface{}
+	json.Unmarshal(first.Body.Bytes(), &created)
+	json.Unmarshal(retry.Body.Bytes(), &deduplicated)
+	assert.Equal(t, created["id"], deduplicated["id"])
+
+	// Another user creating the same SKU is a real conflict
+	assert.Equal(t, http.StatusConflict, create("bob").Code)
+
+	// The event is only published for the original create
+	mockEventBus.AssertNumberOfCalls(t, "Publish", 1)
+}
+
+func TestCreateItem_DedupSkipsDeletedItem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := repository.NewInventoryRepository()
+	mockEventBus := new(MockEventPublisher)
+	mockEventBus.On("Publish", mock.Anything, mock.Anything).Return(nil)
+
+	handler := &InventoryHandler{
+		logger:     zap.NewNop(),
+		repository: repo,
+		eventBus:   mockEventBus,
+		dedup:      newCreateDedup(time.Minute),
+	}
+	router := setupTestRouter(handler)
+
+	create := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/v1/inventory/items", bytes.NewBufferString(`{"sku":"DEDUP-002","name":"Item","quantity":1}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := create()
+	assert.Equal(t, http.StatusCreated, first.Code)
+	var created map[string]interface{}
+	json.Unmarshal(first.Body.Bytes(), &created)
+	assert.NoError(t, repo.Delete(context.Background(), uuid.MustParse(created["id"].(string))))
+
+	assert.Equal(t, http.StatusCreated, create().Code)
+}
+
+func TestForceSetStock_OverwritesCountersAndPublishesCorrection(t *testing.T) {
+	// Setup
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	handler := &InventoryHandler{
+		logger:     zap.NewNop(),
+		repository: mockRepo,
+		eventBus:   mockEventBus,
+	}
+	router := setupTestRouter(handler)
+
+	itemID := uuid.New()
+	existingItem := domain.NewInventoryItem("TEST-001", "Test Item", "Description", 40)
+	existingItem.ID = itemID
+	existingItem.Reserved = 57 // corrupted: more reserved than in stock
+
+	body := `{"quantity": 40, "reserved": 0, "reason": "orphaned reservation"}`
+	req, _ := http.NewRequest("POST", "/api/v1/admin/items/"+itemID.String()+"/force-set-stock", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	mockRepo.On("FindByID", mock.Anything, itemID).Return(existingItem, nil)
+	mockRepo.On("Save", mock.Anything, mock.AnythingOfType("*domain.InventoryItem")).Return(nil)
+	mockEventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.ManualCorrectionEvent) bool {
+		return e.Quantity == 40 && e.Reserved == 0 && e.PreviousReserved == 57 && e.Reason == "orphaned reservation"
+	})).Return(nil)
+
+	// Execute
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, float64(0), response["reserved"])
+	assert.Equal(t, float64(40), response["available"])
+	assert.Equal(t, float64(57), response["previous_reserved"])
+
+	mockRepo.AssertExpectations(t)
+	mockEventBus.AssertExpectations(t)
+}
+
+func TestForceSetStock_InvalidRequest(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	handler := &InventoryHandler{
+		logger:     zap.NewNop(),
+		repository: mockRepo,
+		eventBus:   mockEventBus,
+	}
+	router := setupTestRouter(handler)
+
+	itemID := uuid.New()
+	existingItem := domain.NewInventoryItem("TEST-001", "Test Item", "Description", 10)
+	existingItem.ID = itemID
+	mockRepo.On("FindByID", mock.Anything, itemID).Return(existingItem, nil)
+
+	for _, body := range []string{
+		`{"quantity": 10, "reason": "missing reserved"}`,
+		`{"quantity": 10, "reserved": 0}`,
+		`{"quantity": 10, "reserved": 0, "reason": "   "}`,
+		`{"quantity": -1, "reserved": 0, "reason": "negative"}`,
+		`{"quantity": 5, "reserved": 6, "reason": "reserved above quantity"}`,
+	} {
+		req, _ := http.NewRequest("POST", "/api/v1/admin/items/"+itemID.String()+"/force-set-stock", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	assert.Equal(t, 10, existingItem.Quantity)
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	mockEventBus.AssertNotCalled(t, "Publish")
+}
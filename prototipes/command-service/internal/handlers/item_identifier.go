@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"net/http"
+
+	"command-service/internal/domain"
+	"command-service/internal/events"
+	"command-service/pkg/errors"
+	"command-service/pkg/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// AddItemIdentifier handles POST /api/v1/inventory/items/:id/identifiers
+// @Summary      Add a barcode or alias to an item
+// @Description  Agrega un identificador alternativo a un item para buscarlo por él en el Query Service (`GET /inventory/items/barcode/{code}`): un código de barras `ean13`, `ean8`, `upc` (UPC-A) o `gtin14`, con su dígito verificador, o un `alias` libre (referencia del proveedor, SKU anterior) de letras, dígitos, `-`, `_` o `.`.
+// @Description  Los códigos de barras se comparan como GTIN-14: el UPC-A `036000291452` y el EAN-13 `0036000291452` son el mismo código. Un código pertenece a un solo item del tenant, también si ese item está eliminado. Un item tiene como máximo 20 identificadores. Publica un evento ItemIdentifierAdded.
+//
+// **Ejemplos válidos:**
+// - `{"type": "ean13", "value": "4006381333931"}`
+// - `{"type": "upc", "value": "036000291452", "version": 3}`
+// - `{"type": "alias", "value": "PROV-4411"}`
+//
+// **Ejemplos inválidos:**
+// - Dígito verificador incorrecto: `{"type": "ean13", "value": "4006381333932"}`
+// - Tipo desconocido: `{"type": "isbn", "value": "9780306406157"}`
+// - Alias que es un código de barras válido: `{"type": "alias", "value": "4006381333931"}`
+//
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        If-Match  header    string                    false  "Versión esperada del item (ETag), p. ej. \"3\""
+// @Param        id        path      string                    true   "Item ID (UUID)" example(550e8400-e29b-41d4-a716-446655440000)
+// @Param        request   body      AddItemIdentifierRequest  true   "Identificador"
+// @Success      201       {object}  ItemIdentifierResponse    "Identificador agregado"
+// @Failure      400       {object}  ErrorResponse             "Request inválido - ID, tipo o valor inválidos"
+// @Failure      401       {object}  ErrorResponse             "No autorizado - token JWT inválido o faltante"
+// @Failure      404       {object}  ErrorResponse             "Item no encontrado"
+// @Failure      409       {object}  VersionConflictResponse   "Conflicto - el código ya pertenece a un item, el item ya tiene 20 identificadores o cambió desde la versión esperada"
+// @Failure      500       {object}  ErrorResponse             "Error interno del servidor - error de persistencia"
+// @Router       /inventory/items/{id}/identifiers [post]
+func (h *InventoryHandler) AddItemIdentifier(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid item id", ""))
+		return
+	}
+
+	var req AddItemIdentifierRequest
+	if bindErr := validation.BindJSON(c, &req); bindErr != nil {
+		errors.Respond(c, bindErr)
+		return
+	}
+	identifier, err := domain.NewItemIdentifier(req.Type, req.Value)
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest(err.Error(), ""))
+		return
+	}
+
+	item, err := h.repository.FindByID(c.Request.Context(), id)
+	if err != nil {
+		if err == domain.ErrItemNotFound {
+			errors.Respond(c, errors.NewItemNotFound(id.String()))
+			return
+		}
+		h.logger.Error("Failed to find item", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to add item identifier", nil))
+		return
+	}
+	auditItemBefore(c, item)
+	if !h.checkVersion(c, item, req.Version) {
+		return
+	}
+	expected := item.Version
+
+	if err := item.AddIdentifier(identifier); err != nil {
+		errors.Respond(c, errors.NewConflict(err.Error(), "Code: "+identifier.Code))
+		return
+	}
+	event := events.ItemIdentifierAddedEvent{
+		ItemID:          item.ID,
+		Type:            identifier.Type,
+		Value:           identifier.Value,
+		Code:            identifier.Code,
+		ExpectedVersion: expected,
+		OccurredAt:      item.UpdatedAt,
+	}
+	journalID, ok := h.beginWrite(c, item, false, event, "failed to add item identifier")
+	if !ok {
+		return
+	}
+
+	if err := h.repository.Save(c.Request.Context(), item); err != nil {
+		h.journal.Aborted(journalID)
+		switch err {
+		case domain.ErrDuplicateIdentifier:
+			errors.Respond(c, errors.NewConflict(err.Error(), "Code: "+identifier.Code))
+		case domain.ErrVersionConflict:
+			h.respondVersionConflict(c, item.ID)
+		default:
+			h.logger.Error("Failed to save item", zap.Error(err))
+			errors.Respond(c, errors.NewInternalError("failed to add item identifier", nil))
+		}
+		return
+	}
+	auditItemAfter(c, item)
+
+	if err := h.publish(c, journalID, event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+
+	h.logger.Info("Item identifier added",
+		zap.String("item_id", item.ID.String()),
+		zap.String("type", identifier.Type),
+		zap.String("code", identifier.Code),
+	)
+	setETag(c, item)
+	c.JSON(http.StatusCreated, itemIdentifierResponse(item, identifier))
+}
+
+// RemoveItemIdentifier handles DELETE /api/v1/inventory/items/:id/identifiers/:code
+// @Summary      Remove a barcode or alias from an item
+// @Description  Quita un identificador de un item. `code` es el valor del código de barras en cualquiera de sus formas (UPC-A, EAN-13 o GTIN-14) o el alias. Publica un evento ItemIdentifierRemoved; el código queda libre para otro item.
+// @Tags         inventory
+// @Produce      json
+// @Security     BearerAuth
+// @Param        If-Match  header    string  false  "Versión esperada del item (ETag), p. ej. \"3\""
+// @Param        id        path      string  true   "Item ID (UUID)"
+// @Param        code      path      string  true   "Código de barras o alias" example(4006381333931)
+// @Success      200       {object}  ItemIdentifierResponse   "Identificador quitado"
+// @Failure      400       {object}  ErrorResponse            "ID inválido"
+// @Failure      401       {object}  ErrorResponse            "No autorizado - token JWT inválido o faltante"
+// @Failure      403       {object}  ErrorResponse            "Prohibido - el rol del token no tiene permiso inventory:delete"
+// @Failure      404       {object}  ErrorResponse            "Item o identificador no encontrados"
+// @Failure      409       {object}  VersionConflictResponse  "Conflicto - el item cambió desde la versión esperada"
+// @Failure      500       {object}  ErrorResponse            "Error interno del servidor - error de persistencia"
+// @Router       /inventory/items/{id}/identifiers/{code} [delete]
+func (h *InventoryHandler) RemoveItemIdentifier(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid item id", ""))
+		return
+	}
+	code := domain.LookupCode(c.Param("code"))
+
+	item, err := h.repository.FindByID(c.Request.Context(), id)
+	if err != nil {
+		if err == domain.ErrItemNotFound {
+			errors.Respond(c, errors.NewItemNotFound(id.String()))
+			return
+		}
+		h.logger.Error("Failed to find item", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to remove item identifier", nil))
+		return
+	}
+	auditItemBefore(c, item)
+	if !h.checkVersion(c, item, nil) {
+		return
+	}
+	expected := item.Version
+
+	identifier, err := item.RemoveIdentifier(code)
+	if err != nil {
+		errors.Respond(c, errors.NewNotFound(err.Error(), "Code: "+code))
+		return
+	}
+	event := events.ItemIdentifierRemovedEvent{
+		ItemID:          item.ID,
+		Type:            identifier.Type,
+		Value:           identifier.Value,
+		Code:            identifier.Code,
+		ExpectedVersion: expected,
+		OccurredAt:      item.UpdatedAt,
+	}
+	journalID, ok := h.beginWrite(c, item, false, event, "failed to remove item identifier")
+	if !ok {
+		return
+	}
+
+	if err := h.repository.Save(c.Request.Context(), item); err != nil {
+		h.journal.Aborted(journalID)
+		if err == domain.ErrVersionConflict {
+			h.respondVersionConflict(c, item.ID)
+			return
+		}
+		h.logger.Error("Failed to save item", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to remove item identifier", nil))
+		return
+	}
+	auditItemAfter(c, item)
+
+	if err := h.publish(c, journalID, event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+
+	h.logger.Info("Item identifier removed",
+		zap.String("item_id", item.ID.String()),
+		zap.String("code", identifier.Code),
+	)
+	setETag(c, item)
+	c.JSON(http.StatusOK, itemIdentifierResponse(item, identifier))
+}
+
+// itemIdentifierResponse describes identifier of item
+func itemIdentifierResponse(item *domain.InventoryItem, identifier domain.ItemIdentifier) ItemIdentifierResponse {
+	return ItemIdentifierResponse{
+		ItemID:    item.ID.String(),
+		Type:      identifier.Type,
+		Value:     identifier.Value,
+		Code:      identifier.Code,
+		CreatedAt: identifier.CreatedAt,
+		Version:   item.Version,
+	}
+}
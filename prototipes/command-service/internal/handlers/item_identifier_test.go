@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"command-service/internal/domain"
+	"command-service/internal/events"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupIdentifierTestRouter(repo *MockInventoryRepository, eventBus *MockEventPublisher) *gin.Engine {
+	handler := &InventoryHandler{logger: zap.NewNop(), repository: repo, eventBus: eventBus}
+	router := setupTestRouter(handler)
+	router.POST("/api/v1/inventory/items/:id/identifiers", handler.AddItemIdentifier)
+	router.DELETE("/api/v1/inventory/items/:id/identifiers/:code", handler.RemoveItemIdentifier)
+	return router
+}
+
+func postIdentifier(router *gin.Engine, itemID uuid.UUID, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/api/v1/inventory/items/"+itemID.String()+"/identifiers", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAddItemIdentifier_Success(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	router := setupIdentifierTestRouter(mockRepo, mockEventBus)
+
+	item := domain.NewInventoryItem("SKU-001", "Item", "", 10)
+	mockRepo.On("FindByID", mock.Anything, item.ID).Return(item, nil)
+	mockRepo.On("Save", mock.Anything, item).Return(nil)
+	mockEventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.ItemIdentifierAddedEvent) bool {
+		return e.ItemID == item.ID && e.Type == "upc" && e.Code == "00036000291452" && e.ExpectedVersion == 1
+	})).Return(nil).Once()
+
+	w := postIdentifier(router, item.ID, `{"type": "UPC", "value": "036000291452"}`)
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var response ItemIdentifierResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "036000291452", response.Value)
+	assert.Equal(t, "00036000291452", response.Code)
+	assert.Equal(t, 2, response.Version)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	mockEventBus.AssertExpectations(t)
+}
+
+func TestAddItemIdentifier_Invalid(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	router := setupIdentifierTestRouter(mockRepo, mockEventBus)
+
+	for _, body := range []string{
+		`{"type": "ean13", "value": "4006381333932"}`,
+		`{"type": "isbn", "value": "9780306406157"}`,
+		`{"type": "alias"}`,
+	} {
+		w := postIdentifier(router, uuid.New(), body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+	mockRepo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+	mockEventBus.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+}
+
+func TestAddItemIdentifier_Duplicate(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	router := setupIdentifierTestRouter(mockRepo, mockEventBus)
+
+	// Assigned to another item: the write store rejects it
+	item := domain.NewInventoryItem("SKU-001", "Item", "", 10)
+	mockRepo.On("FindByID", mock.Anything, item.ID).Return(item, nil)
+	mockRepo.On("Save", mock.Anything, item).Return(domain.ErrDuplicateIdentifier)
+
+	w := postIdentifier(router, item.ID, `{"type": "ean13", "value": "4006381333931"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Already on the item, as the same GTIN in another form
+	owner := domain.NewInventoryItem("SKU-002", "Item", "", 10)
+	upc, err := domain.NewItemIdentifier(domain.IdentifierUPC, "036000291452")
+	require.NoError(t, err)
+	require.NoError(t, owner.AddIdentifier(upc))
+	mockRepo.On("FindByID", mock.Anything, owner.ID).Return(owner, nil)
+
+	w = postIdentifier(router, owner.ID, `{"type": "gtin14", "value": "00036000291452"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockEventBus.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+}
+
+func TestRemoveItemIdentifier(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	router := setupIdentifierTestRouter(mockRepo, mockEventBus)
+
+	item := domain.NewInventoryItem("SKU-001", "Item", "", 10)
+	upc, err := domain.NewItemIdentifier(domain.IdentifierUPC, "036000291452")
+	require.NoError(t, err)
+	require.NoError(t, item.AddIdentifier(upc))
+	mockRepo.On("FindByID", mock.Anything, item.ID).Return(item, nil)
+	mockRepo.On("Save", mock.Anything, item).Return(nil)
+	mockEventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.ItemIdentifierRemovedEvent) bool {
+		return e.ItemID == item.ID && e.Code == upc.Code && e.Value == upc.Value
+	})).Return(nil).Once()
+
+	// Any form of the barcode finds it
+	path := "/api/v1/inventory/items/" + item.ID.String() + "/identifiers/"
+	req, _ := http.NewRequest("DELETE", path+"0036000291452", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Empty(t, item.Identifiers)
+
+	// Already removed
+	req, _ = http.NewRequest("DELETE", path+"036000291452", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockEventBus.AssertExpectations(t)
+}
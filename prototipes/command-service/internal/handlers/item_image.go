@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"command-service/internal/domain"
+	"command-service/internal/events"
+	"command-service/internal/images"
+	"command-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// AddItemImage handles POST /api/v1/inventory/items/:id/images
+// @Summary      Upload an item image
+// @Description  Sube una imagen de un item (`multipart/form-data`, campo `file`): JPEG, PNG, GIF o WebP de hasta `IMAGE_MAX_BYTES` (5 MiB por defecto). El formato se detecta por el contenido, no por el `Content-Type` enviado. El archivo se guarda en el store de `IMAGE_STORE` (directorio local o bucket S3/MinIO) y sus metadatos en el item; publica un evento ItemImageAdded con la URL pública. Un item tiene como máximo 20 imágenes.
+// @Description  El Query Service devuelve las imágenes en `images` de las respuestas del item, con URLs firmadas si `IMAGE_SIGNED_URLS=true`.
+//
+// **Ejemplos válidos:**
+// - `curl -F file=@front.png /api/v1/inventory/items/{id}/images`
+// - Condicionado a la versión: `If-Match: "3"` o el campo `version=3`
+//
+// **Ejemplos inválidos:**
+// - Sin campo `file`, o un archivo que no es una imagen soportada (SVG, PDF)
+// - Archivo mayor que `IMAGE_MAX_BYTES` (413)
+// - Item con 20 imágenes (409)
+//
+// @Tags         inventory
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        If-Match  header    string  false  "Versión esperada del item (ETag), p. ej. \"3\""
+// @Param        id        path      string  true   "Item ID (UUID)" example(550e8400-e29b-41d4-a716-446655440000)
+// @Param        file      formData  file    true   "Imagen (JPEG, PNG, GIF o WebP)"
+// @Param        version   formData  int     false  "Versión esperada del item"
+// @Success      201       {object}  ItemImageResponse        "Imagen guardada"
+// @Failure      400       {object}  ErrorResponse            "Request inválido - ID inválido, sin archivo o formato no soportado"
+// @Failure      401       {object}  ErrorResponse            "No autorizado - token JWT inválido o faltante"
+// @Failure      404       {object}  ErrorResponse            "Item no encontrado"
+// @Failure      409       {object}  VersionConflictResponse  "Conflicto - el item cambió desde la versión esperada o ya tiene 20 imágenes"
+// @Failure      413       {object}  ErrorResponse            "Archivo mayor que IMAGE_MAX_BYTES"
+// @Failure      500       {object}  ErrorResponse            "Error interno del servidor - error de persistencia"
+// @Failure      503       {object}  ErrorResponse            "Servicio no disponible - el store de imágenes no responde"
+// @Router       /inventory/items/{id}/images [post]
+func (h *InventoryHandler) AddItemImage(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid item id", ""))
+		return
+	}
+	if h.images == nil {
+		errors.Respond(c, errors.NewServiceUnavailable("image store is not available", ""))
+		return
+	}
+
+	data, ok := h.readImageFile(c)
+	if !ok {
+		return
+	}
+	contentType, err := images.DetectContentType(data)
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest(err.Error(), ""))
+		return
+	}
+	var version *int
+	if raw := c.PostForm("version"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			errors.Respond(c, errors.NewInvalidRequest("version must be a positive integer", raw))
+			return
+		}
+		version = &parsed
+	}
+
+	item, err := h.repository.FindByID(c.Request.Context(), id)
+	if err != nil {
+		if err == domain.ErrItemNotFound {
+			errors.Respond(c, errors.NewItemNotFound(id.String()))
+			return
+		}
+		h.logger.Error("Failed to find item", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to add item image", nil))
+		return
+	}
+	auditItemBefore(c, item)
+	if !h.checkVersion(c, item, version) {
+		return
+	}
+	expected := item.Version
+
+	image := domain.ItemImage{
+		ID:          uuid.New(),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		CreatedAt:   time.Now().UTC(),
+	}
+	image.Key = images.Key(item.TenantID, item.ID.String(), image.ID.String(), contentType)
+	image.URL = h.images.URL(image.Key)
+	if err := item.AddImage(image); err != nil {
+		errors.Respond(c, errors.NewConflict(err.Error(), "Item ID: "+id.String()))
+		return
+	}
+
+	// The file goes first: an event never points to a file that is not there
+	if err := h.images.Put(c.Request.Context(), image.Key, contentType, data); err != nil {
+		h.logger.Error("Failed to store image", zap.String("key", image.Key), zap.Error(err))
+		errors.Respond(c, errors.NewServiceUnavailable("failed to store image", ""))
+		return
+	}
+	event := events.ItemImageAddedEvent{
+		ItemID:          item.ID,
+		ImageID:         image.ID,
+		Key:             image.Key,
+		URL:             image.URL,
+		ContentType:     image.ContentType,
+		Size:            image.Size,
+		ExpectedVersion: expected,
+		OccurredAt:      item.UpdatedAt,
+	}
+	journalID, ok := h.beginWrite(c, item, false, event, "failed to add item image")
+	if !ok {
+		h.deleteImageFile(c, image.Key)
+		return
+	}
+
+	if err := h.repository.Save(c.Request.Context(), item); err != nil {
+		h.journal.Aborted(journalID)
+		h.deleteImageFile(c, image.Key)
+		if err == domain.ErrVersionConflict {
+			h.respondVersionConflict(c, item.ID)
+			return
+		}
+		h.logger.Error("Failed to save item", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to add item image", nil))
+		return
+	}
+	auditItemAfter(c, item)
+
+	if err := h.publish(c, journalID, event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+
+	h.logger.Info("Item image added",
+		zap.String("item_id", item.ID.String()),
+		zap.String("image_id", image.ID.String()),
+		zap.String("content_type", image.ContentType),
+		zap.Int64("size", image.Size),
+	)
+	setETag(c, item)
+	c.JSON(http.StatusCreated, itemImageResponse(item, image))
+}
+
+// RemoveItemImage handles DELETE /api/v1/inventory/items/:id/images/:image_id
+// @Summary      Remove an item image
+// @Description  Quita una imagen de un item y borra su archivo del store de imágenes. Publica un evento ItemImageRemoved.
+// @Tags         inventory
+// @Produce      json
+// @Security     BearerAuth
+// @Param        If-Match  header    string  false  "Versión esperada del item (ETag), p. ej. \"3\""
+// @Param        id        path      string  true   "Item ID (UUID)"
+// @Param        image_id  path      string  true   "Image ID (UUID)"
+// @Success      200       {object}  ItemImageResponse        "Imagen quitada"
+// @Failure      400       {object}  ErrorResponse            "ID inválido"
+// @Failure      401       {object}  ErrorResponse            "No autorizado - token JWT inválido o faltante"
+// @Failure      403       {object}  ErrorResponse            "Prohibido - el rol del token no tiene permiso inventory:delete"
+// @Failure      404       {object}  ErrorResponse            "Item o imagen no encontrados"
+// @Failure      409       {object}  VersionConflictResponse  "Conflicto - el item cambió desde la versión esperada"
+// @Failure      500       {object}  ErrorResponse            "Error interno del servidor - error de persistencia"
+// @Router       /inventory/items/{id}/images/{image_id} [delete]
+func (h *InventoryHandler) RemoveItemImage(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid item id", ""))
+		return
+	}
+	imageID, err := uuid.Parse(c.Param("image_id"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid image id", ""))
+		return
+	}
+
+	item, err := h.repository.FindByID(c.Request.Context(), id)
+	if err != nil {
+		if err == domain.ErrItemNotFound {
+			errors.Respond(c, errors.NewItemNotFound(id.String()))
+			return
+		}
+		h.logger.Error("Failed to find item", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to remove item image", nil))
+		return
+	}
+	auditItemBefore(c, item)
+	if !h.checkVersion(c, item, nil) {
+		return
+	}
+	expected := item.Version
+
+	image, err := item.RemoveImage(imageID)
+	if err != nil {
+		errors.Respond(c, errors.NewNotFound(err.Error(), "Image ID: "+imageID.String()))
+		return
+	}
+	event := events.ItemImageRemovedEvent{
+		ItemID:          item.ID,
+		ImageID:         image.ID,
+		ExpectedVersion: expected,
+		OccurredAt:      item.UpdatedAt,
+	}
+	journalID, ok := h.beginWrite(c, item, false, event, "failed to remove item image")
+	if !ok {
+		return
+	}
+
+	if err := h.repository.Save(c.Request.Context(), item); err != nil {
+		h.journal.Aborted(journalID)
+		if err == domain.ErrVersionConflict {
+			h.respondVersionConflict(c, item.ID)
+			return
+		}
+		h.logger.Error("Failed to save item", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to remove item image", nil))
+		return
+	}
+	auditItemAfter(c, item)
+
+	if err := h.publish(c, journalID, event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+	// Once the item no longer points to it; a file left behind is only wasted space
+	h.deleteImageFile(c, image.Key)
+
+	h.logger.Info("Item image removed",
+		zap.String("item_id", item.ID.String()),
+		zap.String("image_id", image.ID.String()),
+	)
+	setETag(c, item)
+	c.JSON(http.StatusOK, itemImageResponse(item, image))
+}
+
+// readImageFile reads the file field of the multipart request, writing a 400 or 413
+// response when it is missing or too large
+func (h *InventoryHandler) readImageFile(c *gin.Context) ([]byte, bool) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if stderrors.As(err, &tooLarge) {
+			errors.Respond(c, errors.NewPayloadTooLarge(h.imageMaxBytes))
+			return nil, false
+		}
+		errors.Respond(c, errors.NewInvalidRequest("the image must be sent in the file field of a multipart/form-data body", ""))
+		return nil, false
+	}
+	if header.Size > h.imageMaxBytes {
+		errors.Respond(c, errors.NewPayloadTooLarge(h.imageMaxBytes))
+		return nil, false
+	}
+	file, err := header.Open()
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("failed to read the image", ""))
+		return nil, false
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil || len(data) == 0 {
+		errors.Respond(c, errors.NewInvalidRequest("failed to read the image", ""))
+		return nil, false
+	}
+	return data, true
+}
+
+// deleteImageFile removes a file from the image store, logging a failure
+func (h *InventoryHandler) deleteImageFile(c *gin.Context, key string) {
+	if err := h.images.Delete(c.Request.Context(), key); err != nil {
+		h.logger.Warn("Failed to delete image file", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// itemImageResponse describes image of item
+func itemImageResponse(item *domain.InventoryItem, image domain.ItemImage) ItemImageResponse {
+	return ItemImageResponse{
+		ID:          image.ID.String(),
+		ItemID:      item.ID.String(),
+		URL:         image.URL,
+		ContentType: image.ContentType,
+		Size:        image.Size,
+		CreatedAt:   image.CreatedAt,
+		Version:     item.Version,
+	}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"command-service/internal/domain"
+	"command-service/internal/events"
+	"command-service/internal/images"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// pngHeader is enough of a PNG for content sniffing
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x02\x00\x00\x00")
+
+func setupImageTestRouter(repo *MockInventoryRepository, eventBus *MockEventPublisher, store images.Store) *gin.Engine {
+	handler := &InventoryHandler{logger: zap.NewNop(), repository: repo, eventBus: eventBus, images: store, imageMaxBytes: 1024}
+	router := setupTestRouter(handler)
+	router.POST("/api/v1/inventory/items/:id/images", handler.AddItemImage)
+	router.DELETE("/api/v1/inventory/items/:id/images/:image_id", handler.RemoveItemImage)
+	return router
+}
+
+func postImage(router *gin.Engine, itemID uuid.UUID, data []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "front.png")
+	part.Write(data)
+	form.Close()
+	req, _ := http.NewRequest("POST", "/api/v1/inventory/items/"+itemID.String()+"/images", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAddItemImage_Success(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	store := images.NewMemoryStore()
+	router := setupImageTestRouter(mockRepo, mockEventBus, store)
+
+	item := domain.NewInventoryItem("SKU-001", "Item", "", 10)
+	mockRepo.On("FindByID", mock.Anything, item.ID).Return(item, nil)
+	mockRepo.On("Save", mock.Anything, item).Return(nil)
+	mockEventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.ItemImageAddedEvent) bool {
+		return e.ItemID == item.ID && e.ContentType == "image/png" && e.ExpectedVersion == 1
+	})).Return(nil).Once()
+
+	w := postImage(router, item.ID, pngHeader)
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var response ItemImageResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "image/png", response.ContentType)
+	assert.Equal(t, int64(len(pngHeader)), response.Size)
+	assert.Equal(t, 2, response.Version)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	require.Len(t, item.Images, 1)
+	stored, ok := store.Get(item.Images[0].Key)
+	require.True(t, ok)
+	assert.Equal(t, pngHeader, stored)
+	mockEventBus.AssertExpectations(t)
+}
+
+func TestAddItemImage_Invalid(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	router := setupImageTestRouter(mockRepo, mockEventBus, images.NewMemoryStore())
+
+	item := domain.NewInventoryItem("SKU-001", "Item", "", 10)
+	mockRepo.On("FindByID", mock.Anything, item.ID).Return(item, nil)
+
+	// Not an image
+	w := postImage(router, item.ID, []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"/>"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Over the handler's 1 KiB limit
+	w = postImage(router, item.ID, append(append([]byte{}, pngHeader...), make([]byte, 2048)...))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	// No file field
+	req, _ := http.NewRequest("POST", "/api/v1/inventory/items/"+item.ID.String()+"/images", bytes.NewBufferString("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockEventBus.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+}
+
+func TestAddItemImage_TooMany(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	store := images.NewMemoryStore()
+	router := setupImageTestRouter(mockRepo, mockEventBus, store)
+
+	item := domain.NewInventoryItem("SKU-001", "Item", "", 10)
+	for n := 0; n < domain.MaxItemImages; n++ {
+		require.NoError(t, item.AddImage(domain.ItemImage{ID: uuid.New(), Key: uuid.NewString()}))
+	}
+	mockRepo.On("FindByID", mock.Anything, item.ID).Return(item, nil)
+
+	w := postImage(router, item.ID, pngHeader)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockEventBus.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+}
+
+func TestAddItemImage_NoStore(t *testing.T) {
+	router := setupImageTestRouter(new(MockInventoryRepository), new(MockEventPublisher), nil)
+
+	w := postImage(router, uuid.New(), pngHeader)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestRemoveItemImage(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	store := images.NewMemoryStore()
+	router := setupImageTestRouter(mockRepo, mockEventBus, store)
+
+	item := domain.NewInventoryItem("SKU-001", "Item", "", 10)
+	image := domain.ItemImage{ID: uuid.New(), Key: "default/item/front.png", ContentType: "image/png"}
+	require.NoError(t, item.AddImage(image))
+	require.NoError(t, store.Put(context.Background(), image.Key, image.ContentType, pngHeader))
+	mockRepo.On("FindByID", mock.Anything, item.ID).Return(item, nil)
+	mockRepo.On("Save", mock.Anything, item).Return(nil)
+	mockEventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.ItemImageRemovedEvent) bool {
+		return e.ItemID == item.ID && e.ImageID == image.ID
+	})).Return(nil).Once()
+
+	path := "/api/v1/inventory/items/" + item.ID.String() + "/images/"
+	req, _ := http.NewRequest("DELETE", path+image.ID.String(), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Empty(t, item.Images)
+	_, ok := store.Get(image.Key)
+	assert.False(t, ok)
+
+	// Already removed
+	req, _ = http.NewRequest("DELETE", path+image.ID.String(), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockEventBus.AssertExpectations(t)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+
+	"command-service/internal/domain"
+	"command-service/internal/events"
+	"command-service/pkg/errors"
+	"command-service/pkg/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// PatchItem handles PATCH /api/v1/inventory/items/:id
+// @Summary      Partially update an inventory item
+// @Description  Cambia solo los campos enviados: un campo omitido (o `null`) conserva su valor, a diferencia de `PUT`, que exige el nombre y borra la descripción omitida. `"description": ""` borra la descripción.
+// @Description  Publica un evento InventoryItemPatched con los campos que cambiaron; si ningún campo cambia responde 200 sin publicar ni avanzar la versión. Admite `If-Match: "<version>"` (o `version` en el body) igual que `PUT`.
+//
+// **Ejemplos válidos:**
+// - Cambiar solo la descripción: `{"description": "Nueva descripción"}`
+// - Cambiar solo el nombre: `{"name": "Laptop Dell XPS 15"}`
+// - Borrar la descripción: `{"description": ""}`
+// - Condicionado a la versión: `If-Match: "3"` o `{"name": "Laptop", "version": 3}`
+//
+// **Ejemplos inválidos:**
+// - Body sin ningún campo a cambiar: `{}`
+// - Nombre vacío: `{"name": ""}`
+// - ID inválido o item no encontrado
+// - Versión desactualizada (409 con `current_version`)
+//
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        X-Request-ID  header    string  false  "Request ID for idempotency (UUID). If not provided, a new one will be generated."
+// @Param        If-Match      header    string  false  "Versión esperada del item (ETag), p. ej. \"3\""
+// @Param        id            path      string  true   "Item ID (UUID)" example(550e8400-e29b-41d4-a716-446655440000)
+// @Param        request       body      PatchItemRequest   true  "Fields to change"
+// @Success      200           {object}  PatchItemResponse  "Item actualizado (o sin cambios)"
+// @Failure      400           {object}  ErrorResponse      "Request inválido - ID inválido, ningún campo a cambiar o campos inválidos"
+// @Failure      401           {object}  ErrorResponse      "No autorizado - token JWT inválido o faltante"
+// @Failure      404           {object}  ErrorResponse      "Item no encontrado"
+// @Failure      409           {object}  VersionConflictResponse  "Conflicto - el item cambió desde la versión esperada"
+// @Failure      500           {object}  ErrorResponse      "Error interno del servidor - error de persistencia o conexión a base de datos"
+// @Failure      503           {object}  ErrorResponse      "Servicio no disponible - error de conexión al event broker"
+// @Router       /inventory/items/{id} [patch]
+func (h *InventoryHandler) PatchItem(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid item id", ""))
+		return
+	}
+
+	var req PatchItemRequest
+	if bindErr := validation.BindJSON(c, &req); bindErr != nil {
+		errors.Respond(c, bindErr)
+		return
+	}
+	if req.Name == nil && req.Description == nil {
+		errors.Respond(c, errors.NewInvalidRequest("no fields to update", "send name and/or description"))
+		return
+	}
+
+	item, err := h.repository.FindByID(c.Request.Context(), id)
+	if err != nil {
+		if err == domain.ErrItemNotFound {
+			errors.Respond(c, errors.NewItemNotFound(id.String()))
+			return
+		}
+		h.logger.Error("Failed to find item", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to update item", nil))
+		return
+	}
+	auditItemBefore(c, item)
+	if !h.checkVersion(c, item, req.Version) {
+		return
+	}
+	expected := item.Version
+
+	changed := item.PatchDetails(req.Name, req.Description)
+	if len(changed) == 0 {
+		h.respondPatched(c, item, changed)
+		return
+	}
+
+	// The event only carries the fields that changed
+	event := events.InventoryItemPatchedEvent{
+		ItemID:          item.ID,
+		ExpectedVersion: expected,
+		OccurredAt:      item.UpdatedAt,
+	}
+	for _, field := range changed {
+		switch field {
+		case "name":
+			event.Name = &item.Name
+		case "description":
+			event.Description = &item.Description
+		}
+	}
+	journalID, ok := h.beginWrite(c, item, false, event, "failed to update item")
+	if !ok {
+		return
+	}
+
+	if err := h.repository.Save(c.Request.Context(), item); err != nil {
+		h.journal.Aborted(journalID)
+		if err == domain.ErrVersionConflict {
+			h.respondVersionConflict(c, item.ID)
+			return
+		}
+		h.logger.Error("Failed to save item", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to update item", nil))
+		return
+	}
+	auditItemAfter(c, item)
+
+	if err := h.publish(c, journalID, event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+
+	h.respondPatched(c, item, changed)
+}
+
+func (h *InventoryHandler) respondPatched(c *gin.Context, item *domain.InventoryItem, changed []string) {
+	if changed == nil {
+		changed = []string{}
+	}
+	setETag(c, item)
+	c.JSON(http.StatusOK, gin.H{
+		"id":          item.ID,
+		"sku":         item.SKU,
+		"name":        item.Name,
+		"description": item.Description,
+		"quantity":    item.Quantity,
+		"version":     item.Version,
+		"updated_at":  item.UpdatedAt,
+		"changed":     changed,
+	})
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"command-service/internal/domain"
+	"command-service/internal/events"
+	"command-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupPatchItemTest(t *testing.T) (*gin.Engine, *domain.InventoryItem, repository.InventoryRepository, *MockEventPublisher) {
+	repo := repository.NewInventoryRepository()
+	eventBus := new(MockEventPublisher)
+	handler := &InventoryHandler{logger: zap.NewNop(), repository: repo, eventBus: eventBus}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.PATCH("/items/:id", handler.PatchItem)
+
+	item := domain.NewInventoryItem("SKU-001", "Laptop", "15 inch", 20)
+	require.NoError(t, repo.Save(context.Background(), item))
+	return router, item, repo, eventBus
+}
+
+func patchItem(router *gin.Engine, path, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	req, _ := http.NewRequest("PATCH", path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return w, response
+}
+
+func TestPatchItem_ChangesOnlySentFields(t *testing.T) {
+	router, item, repo, eventBus := setupPatchItemTest(t)
+	eventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.InventoryItemPatchedEvent) bool {
+		return e.Name == nil && e.Description != nil && *e.Description == "17 inch" && e.ExpectedVersion == 1
+	})).Return(nil).Once()
+
+	w, response := patchItem(router, "/items/"+item.ID.String(), `{"description": "17 inch"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Laptop", response["name"])
+	assert.Equal(t, "17 inch", response["description"])
+	assert.Equal(t, []interface{}{"description"}, response["changed"])
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+	stored, err := repo.FindByID(context.Background(), item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Laptop", stored.Name)
+	assert.Equal(t, 2, stored.Version)
+	eventBus.AssertExpectations(t)
+}
+
+func TestPatchItem_UnchangedFieldsPublishNothing(t *testing.T) {
+	router, item, repo, eventBus := setupPatchItemTest(t)
+
+	w, response := patchItem(router, "/items/"+item.ID.String(), `{"name": "Laptop", "description": null}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []interface{}{}, response["changed"])
+
+	stored, err := repo.FindByID(context.Background(), item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.Version)
+	eventBus.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+}
+
+func TestPatchItem_ClearsDescription(t *testing.T) {
+	router, item, _, eventBus := setupPatchItemTest(t)
+	eventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.InventoryItemPatchedEvent) bool {
+		return e.Name == nil && e.Description != nil && *e.Description == ""
+	})).Return(nil).Once()
+
+	w, response := patchItem(router, "/items/"+item.ID.String(), `{"description": ""}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "", response["description"])
+	eventBus.AssertExpectations(t)
+}
+
+func TestPatchItem_InvalidRequests(t *testing.T) {
+	router, item, _, eventBus := setupPatchItemTest(t)
+	path := "/items/" + item.ID.String()
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+	}{
+		{"no fields", path, `{}`, http.StatusBadRequest},
+		{"blank name", path, `{"name": "  "}`, http.StatusBadRequest},
+		{"invalid id", "/items/not-a-uuid", `{"name": "Laptop"}`, http.StatusBadRequest},
+		{"stale version", path, `{"name": "Laptop 2", "version": 5}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, _ := patchItem(router, tt.path, tt.body)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+	eventBus.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"command-service/internal/domain"
+	"command-service/internal/events"
+	"command-service/pkg/errors"
+	"command-service/pkg/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// AddItemRelation handles POST /api/v1/inventory/items/:id/related
+// @Summary      Link a related item
+// @Description  Vincula un item a otro: `substitute` (se puede vender en su lugar, p. ej. cuando no hay stock) o `accessory` (se vende junto con él). El vínculo es dirigido; para que dos items sean sustitutos entre sí se vinculan en ambos sentidos.
+// @Description  El Query Service los expone con su disponibilidad en `GET /inventory/items/{id}/related` y los sugiere en las líneas sin stock de `POST /inventory/availability`. Volver a vincular el mismo par no es un error.
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                  true  "Item ID (UUID)"
+// @Param        request  body      AddItemRelationRequest  true  "Related item"
+// @Success      201      {object}  ItemRelationResponse    "Vínculo publicado"
+// @Failure      400      {object}  ErrorResponse           "Request inválido - ID o relación inválidos, o el item vinculado a sí mismo"
+// @Failure      401      {object}  ErrorResponse           "No autorizado - token JWT inválido o faltante"
+// @Failure      404      {object}  ErrorResponse           "Item o item relacionado no encontrado"
+// @Failure      500      {object}  ErrorResponse           "Error interno del servidor"
+// @Failure      503      {object}  ErrorResponse           "Servicio no disponible - error de conexión al event broker"
+// @Router       /inventory/items/{id}/related [post]
+func (h *InventoryHandler) AddItemRelation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid item id", ""))
+		return
+	}
+
+	var req AddItemRelationRequest
+	if bindErr := validation.BindJSON(c, &req); bindErr != nil {
+		errors.Respond(c, bindErr)
+		return
+	}
+	relatedID, err := uuid.Parse(req.RelatedID)
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid related item id", ""))
+		return
+	}
+	relation, err := domain.ParseRelation(req.Relation)
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest(err.Error(), ""))
+		return
+	}
+	if relatedID == id {
+		errors.Respond(c, errors.NewInvalidRequest(domain.ErrSelfRelation.Error(), ""))
+		return
+	}
+
+	// Both ends must exist now; the listener checks again when it applies the link
+	for _, itemID := range []uuid.UUID{id, relatedID} {
+		if _, err := h.repository.FindByID(c.Request.Context(), itemID); err != nil {
+			if err == domain.ErrItemNotFound {
+				errors.Respond(c, errors.NewItemNotFound(itemID.String()))
+				return
+			}
+			h.logger.Error("Failed to find item", zap.Error(err))
+			errors.Respond(c, errors.NewInternalError("failed to add item relation", nil))
+			return
+		}
+	}
+
+	event := events.ItemRelationAddedEvent{
+		ItemID:        id,
+		RelatedItemID: relatedID,
+		Relation:      relation,
+		OccurredAt:    time.Now().UTC(),
+	}
+	if !h.publishRelation(c, event, "failed to add item relation") {
+		return
+	}
+
+	h.logger.Info("Item relation added",
+		zap.String("item_id", id.String()),
+		zap.String("related_item_id", relatedID.String()),
+		zap.String("relation", relation),
+	)
+	c.JSON(http.StatusCreated, ItemRelationResponse{ItemID: id.String(), RelatedItemID: relatedID.String(), Relation: relation})
+}
+
+// RemoveItemRelation handles DELETE /api/v1/inventory/items/:id/related/:related_id
+// @Summary      Unlink a related item
+// @Description  Elimina el vínculo `relation` (query, obligatorio) entre un item y otro. Eliminar un vínculo que no existe no es un error; los vínculos de un item eliminado desaparecen con él.
+// @Tags         inventory
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id          path      string  true  "Item ID (UUID)"
+// @Param        related_id  path      string  true  "Related item ID (UUID)"
+// @Param        relation    query     string  true  "substitute or accessory"
+// @Success      200         {object}  ItemRelationResponse  "Vínculo eliminado"
+// @Failure      400         {object}  ErrorResponse         "ID o relación inválidos"
+// @Failure      401         {object}  ErrorResponse         "No autorizado - token JWT inválido o faltante"
+// @Failure      403         {object}  ErrorResponse         "Prohibido - el rol del token no tiene permiso inventory:delete"
+// @Failure      503         {object}  ErrorResponse         "Servicio no disponible - error de conexión al event broker"
+// @Router       /inventory/items/{id}/related/{related_id} [delete]
+func (h *InventoryHandler) RemoveItemRelation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid item id", ""))
+		return
+	}
+	relatedID, err := uuid.Parse(c.Param("related_id"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid related item id", ""))
+		return
+	}
+	relation, err := domain.ParseRelation(c.Query("relation"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest(err.Error(), ""))
+		return
+	}
+
+	event := events.ItemRelationRemovedEvent{
+		ItemID:        id,
+		RelatedItemID: relatedID,
+		Relation:      relation,
+		OccurredAt:    time.Now().UTC(),
+	}
+	if !h.publishRelation(c, event, "failed to remove item relation") {
+		return
+	}
+
+	h.logger.Info("Item relation removed",
+		zap.String("item_id", id.String()),
+		zap.String("related_item_id", relatedID.String()),
+		zap.String("relation", relation),
+	)
+	c.JSON(http.StatusOK, ItemRelationResponse{ItemID: id.String(), RelatedItemID: relatedID.String(), Relation: relation})
+}
+
+// publishRelation publishes a relation event. Relations live only in the read model, so
+// the event is the whole change: a publish failure is reported to the client.
+func (h *InventoryHandler) publishRelation(c *gin.Context, event interface{}, failure string) bool {
+	if err := h.eventBus.Publish(c.Request.Context(), event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+		errors.Respond(c, errors.NewServiceUnavailable(failure, ""))
+		return false
+	}
+	return true
+}
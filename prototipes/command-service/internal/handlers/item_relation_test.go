@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"command-service/internal/domain"
+	"command-service/internal/events"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func setupRelationTestRouter(repo *MockInventoryRepository, eventBus *MockEventPublisher) *gin.Engine {
+	handler := &InventoryHandler{logger: zap.NewNop(), repository: repo, eventBus: eventBus}
+	router := setupTestRouter(handler)
+	router.POST("/api/v1/inventory/items/:id/related", handler.AddItemRelation)
+	router.DELETE("/api/v1/inventory/items/:id/related/:related_id", handler.RemoveItemRelation)
+	return router
+}
+
+func postRelation(router *gin.Engine, itemID uuid.UUID, body map[string]interface{}) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", "/api/v1/inventory/items/"+itemID.String()+"/related", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAddItemRelation_Success(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	router := setupRelationTestRouter(mockRepo, mockEventBus)
+
+	item := domain.NewInventoryItem("SKU-001", "Item", "", 0)
+	substitute := domain.NewInventoryItem("SKU-002", "Substitute", "", 10)
+	mockRepo.On("FindByID", mock.Anything, item.ID).Return(item, nil)
+	mockRepo.On("FindByID", mock.Anything, substitute.ID).Return(substitute, nil)
+	mockEventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.ItemRelationAddedEvent) bool {
+		return e.ItemID == item.ID && e.RelatedItemID == substitute.ID && e.Relation == domain.RelationSubstitute
+	})).Return(nil).Once()
+
+	w := postRelation(router, item.ID, map[string]interface{}{"related_id": substitute.ID.String(), "relation": "Substitute"})
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response ItemRelationResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, substitute.ID.String(), response.RelatedItemID)
+	assert.Equal(t, "substitute", response.Relation)
+	mockEventBus.AssertExpectations(t)
+}
+
+func TestAddItemRelation_Invalid(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	router := setupRelationTestRouter(mockRepo, mockEventBus)
+
+	item := domain.NewInventoryItem("SKU-001", "Item", "", 0)
+	missing := uuid.New()
+	mockRepo.On("FindByID", mock.Anything, item.ID).Return(item, nil)
+	mockRepo.On("FindByID", mock.Anything, missing).Return(nil, domain.ErrItemNotFound)
+
+	w := postRelation(router, item.ID, map[string]interface{}{"related_id": uuid.New().String(), "relation": "bundle"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postRelation(router, item.ID, map[string]interface{}{"related_id": item.ID.String(), "relation": "accessory"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = postRelation(router, item.ID, map[string]interface{}{"related_id": missing.String(), "relation": "accessory"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockEventBus.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+}
+
+func TestRemoveItemRelation(t *testing.T) {
+	mockEventBus := new(MockEventPublisher)
+	router := setupRelationTestRouter(new(MockInventoryRepository), mockEventBus)
+	itemID, relatedID := uuid.New(), uuid.New()
+	path := "/api/v1/inventory/items/" + itemID.String() + "/related/" + relatedID.String()
+
+	// The relation is required
+	req, _ := http.NewRequest("DELETE", path, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Nothing but the event records the change, so a publish failure is reported
+	mockEventBus.On("Publish", mock.Anything, mock.AnythingOfType("events.ItemRelationRemovedEvent")).Return(errors.New("broker down")).Once()
+	req, _ = http.NewRequest("DELETE", path+"?relation=accessory", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	mockEventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.ItemRelationRemovedEvent) bool {
+		return e.ItemID == itemID && e.RelatedItemID == relatedID && e.Relation == domain.RelationAccessory
+	})).Return(nil).Once()
+	req, _ = http.NewRequest("DELETE", path+"?relation=accessory", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockEventBus.AssertExpectations(t)
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"command-service/internal/domain"
+	"command-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// beginWrite records in the write-ahead journal the change about to be saved and the
+// event it will publish. If the journal cannot be written it responds with a 500 using
+// failure as the message and returns false; nothing has been saved at that point.
+func (h *InventoryHandler) beginWrite(c *gin.Context, item *domain.InventoryItem, deleted bool, event interface{}, failure string) (string, bool) {
+	journalID, err := h.journal.Begin(item, deleted, event)
+	if err != nil {
+		h.logger.Error("Failed to write journal", zap.String("item_id", item.ID.String()), zap.Error(err))
+		errors.Respond(c, errors.NewInternalError(failure, nil))
+		return "", false
+	}
+	return journalID, true
+}
+
+// publish sends the event of a saved change and closes its journal entry. An event that
+// fails to publish keeps its entry pending and is published on the next start.
+func (h *InventoryHandler) publish(c *gin.Context, journalID string, event interface{}) error {
+	if err := h.eventBus.Publish(c.Request.Context(), event); err != nil {
+		return err
+	}
+	h.journal.Published(journalID)
+	return nil
+}
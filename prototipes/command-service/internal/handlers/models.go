@@ -1,13 +1,18 @@
 package handlers
 
-// ErrorResponse represents an error response
-// @Description Error response with error message
+import (
+	"encoding/json"
+	"time"
+
+	"command-service/internal/audit"
+	"command-service/pkg/errors"
+	"command-service/pkg/middleware"
+)
+
+// ErrorResponse is the error envelope returned by every endpoint (errors.StandardError)
+// @Description Error envelope: machine-readable code, message, details, request ID and timestamp
 type ErrorResponse struct {
-	// Error message describing what went wrong
-	// @Example "invalid request: sku is required"
-	// @Example "item not found"
-	// @Example "insufficient stock available"
-	Error string `json:"error" example:"invalid request: sku is required"`
+	errors.StandardError
 }
 
 // SuccessResponse represents a success response
@@ -21,26 +26,34 @@ type SuccessResponse struct {
 // CreateItemRequest represents the request body for creating an item
 // @Description Request to create a new inventory item
 type CreateItemRequest struct {
-	// SKU (Stock Keeping Unit) - unique identifier for the product
+	// SKU (Stock Keeping Unit) - unique identifier for the product; up to 64 letters, digits,
+	// '.', '_' or '-', starting with a letter or digit
 	// @Example "SKU-001"
 	// @Example "PROD-2024-ABC"
-	SKU string `json:"sku" binding:"required" example:"SKU-001"`
+	SKU string `json:"sku" binding:"required,sku" maxLength:"64" example:"SKU-001"`
 	
-	// Product name
+	// Product name (up to 200 characters)
 	// @Example "Laptop Dell XPS 15"
 	// @Example "iPhone 15 Pro Max"
-	Name string `json:"name" binding:"required" example:"Laptop Dell XPS 15"`
+	Name string `json:"name" binding:"required,itemname" maxLength:"200" example:"Laptop Dell XPS 15"`
 	
-	// Product description (optional)
+	// Product description (optional, up to 2000 characters)
 	// @Example "High-performance laptop with 16GB RAM and 512GB SSD"
 	// @Example ""
-	Description string `json:"description" example:"High-performance laptop with 16GB RAM and 512GB SSD"`
+	Description string `json:"description" binding:"description" maxLength:"2000" example:"High-performance laptop with 16GB RAM and 512GB SSD"`
 	
-	// Initial stock quantity (must be >= 0)
+	// Initial stock quantity (0 to 1000000)
 	// @Example 100
 	// @Example 0
 	// @Example 500
-	Quantity int `json:"quantity" binding:"required,min=0" example:"100"`
+	Quantity int `json:"quantity" binding:"required,min=0,quantity" maximum:"1000000" example:"100"`
+	
+	// Unit cost of the initial stock (optional, used for inventory valuation)
+	// @Example 12.5
+	UnitCost *float64 `json:"unit_cost,omitempty" binding:"omitempty,min=0" example:"12.5"`
+
+	// Expected item version (optional, same as If-Match); 409 if the item changed since
+	Version *int `json:"version,omitempty" binding:"omitempty,min=1" example:"3"`
 }
 
 // CreateItemResponse represents the response after creating an item
@@ -68,13 +81,16 @@ type CreateItemResponse struct {
 // UpdateItemRequest represents the request body for updating an item
 // @Description Request to update an existing inventory item
 type UpdateItemRequest struct {
-	// Updated product name
+	// Updated product name (up to 200 characters)
 	// @Example "Laptop Dell XPS 15 - Updated"
-	Name string `json:"name" binding:"required" example:"Laptop Dell XPS 15 - Updated"`
+	Name string `json:"name" binding:"required,itemname" maxLength:"200" example:"Laptop Dell XPS 15 - Updated"`
 	
-	// Updated product description (optional)
+	// Updated product description (optional, up to 2000 characters)
 	// @Example "High-performance laptop with 32GB RAM and 1TB SSD"
-	Description string `json:"description" example:"High-performance laptop with 32GB RAM and 1TB SSD"`
+	Description string `json:"description" binding:"description" maxLength:"2000" example:"High-performance laptop with 32GB RAM and 1TB SSD"`
+
+	// Expected item version (optional, same as If-Match); 409 if the item changed since
+	Version *int `json:"version,omitempty" binding:"omitempty,min=1" example:"3"`
 }
 
 // UpdateItemResponse represents the response after updating an item
@@ -94,19 +110,60 @@ type UpdateItemResponse struct {
 	
 	// Current stock quantity
 	Quantity int `json:"quantity" example:"100"`
+
+	// Item version after the update (also in the ETag header)
+	Version int `json:"version" example:"4"`
 	
 	// Last update timestamp (ISO 8601 format)
 	UpdatedAt string `json:"updated_at" example:"2024-01-15T11:45:00Z"`
 }
 
+// PatchItemRequest represents the request body for a partial update of an item
+// @Description Fields to change; omitted (or null) fields keep their current value
+type PatchItemRequest struct {
+	// New product name (up to 200 characters)
+	Name *string `json:"name,omitempty" binding:"omitempty,itemname" maxLength:"200" example:"Laptop Dell XPS 15 - Updated"`
+
+	// New product description (up to 2000 characters); an empty string clears it
+	Description *string `json:"description,omitempty" binding:"omitempty,description" maxLength:"2000" example:"High-performance laptop with 32GB RAM and 1TB SSD"`
+
+	// Expected item version (optional, same as If-Match); 409 if the item changed since
+	Version *int `json:"version,omitempty" binding:"omitempty,min=1" example:"3"`
+}
+
+// PatchItemResponse represents the item after a partial update
+// @Description Item after the partial update and the fields that changed
+type PatchItemResponse struct {
+	UpdateItemResponse
+
+	// Fields whose value changed; empty when the request matched the item (no event published)
+	Changed []string `json:"changed" example:"description"`
+}
+
+// StockNote is the optional attribution of a stock operation. It is published with the
+// event, together with the user that issued the command, and kept in the stock history.
+type StockNote struct {
+	// Reason code: lowercase letters, digits and underscores (up to 64 characters)
+	Reason string `json:"reason,omitempty" binding:"omitempty,max=64" example:"customer_return"`
+
+	// External reference of the operation, e.g. an order ID (up to 128 characters)
+	Reference string `json:"reference,omitempty" binding:"omitempty,max=128" example:"ORD-2024-000123"`
+}
+
 // AdjustStockRequest represents the request body for adjusting stock
 // @Description Request to adjust stock quantity (can be positive or negative)
 type AdjustStockRequest struct {
-	// Quantity adjustment (positive to add, negative to subtract)
+	// Quantity adjustment (positive to add, negative to subtract; at most 1000000 either way)
 	// @Example 10
 	// @Example -5
 	// @Example 50
-	Quantity int `json:"quantity" binding:"required" example:"10"`
+	Quantity int `json:"quantity" binding:"required,quantity" minimum:"-1000000" maximum:"1000000" example:"10"`
+	
+	// Unit cost of the received stock (optional, positive adjustments only)
+	// @Example 12.5
+	UnitCost *float64 `json:"unit_cost,omitempty" binding:"omitempty,min=0" example:"12.5"`
+
+	StockNote
 }
 
 // StockResponse represents the response for stock operations
@@ -123,28 +180,571 @@ type StockResponse struct {
 	
 	// Reserved stock quantity
 	Reserved int `json:"reserved" example:"20"`
+
+	// Item version after the change (adjust only; also in the ETag header)
+	Version int `json:"version,omitempty" example:"4"`
 	
 	// Last update timestamp (ISO 8601 format)
 	UpdatedAt string `json:"updated_at" example:"2024-01-15T12:00:00Z"`
+
+	// Store the reservation is attributed to (only with ?store_id=)
+	StoreID string `json:"store_id,omitempty" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+
+	// Reservation identifier (only for store-scoped reservations)
+	ReservationID string `json:"reservation_id,omitempty" example:"9b2f1c4e-8d7a-4f3b-a1e2-3c4d5e6f7a8b"`
+
+	// Quantity of the item currently reserved by the store (only with ?store_id=)
+	StoreReserved int `json:"store_reserved,omitempty" example:"5"`
+
+	// Reason code given with the operation
+	Reason string `json:"reason,omitempty" example:"customer_return"`
+
+	// External reference given with the operation
+	Reference string `json:"reference,omitempty" example:"ORD-2024-000123"`
 }
 
 // ReserveStockRequest represents the request body for reserving stock
 // @Description Request to reserve stock for an order
 type ReserveStockRequest struct {
-	// Quantity to reserve (must be >= 1)
+	// Quantity to reserve (1 to 1000000)
 	// @Example 5
 	// @Example 10
 	// @Example 1
-	Quantity int `json:"quantity" binding:"required,min=1" example:"5"`
+	Quantity int `json:"quantity" binding:"required,min=1,quantity" maximum:"1000000" example:"5"`
+
+	// Queue the reservation when stock is insufficient instead of failing
+	Waitlist bool `json:"waitlist" example:"false"`
+
+	StockNote
+}
+
+// WaitlistResponse represents a reservation queued in the waitlist
+// @Description Reservation accepted into the waitlist
+type WaitlistResponse struct {
+	WaitlistID string `json:"waitlist_id" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	ID         string `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Status     string `json:"status" example:"waiting"`
+	Quantity   int    `json:"quantity" example:"20"`
+	Available  int    `json:"available" example:"10"`
 }
 
 // ReleaseStockRequest represents the request body for releasing stock
 // @Description Request to release previously reserved stock
 type ReleaseStockRequest struct {
-	// Quantity to release (must be >= 1)
+	// Quantity to release (1 to 1000000)
 	// @Example 5
 	// @Example 10
 	// @Example 1
-	Quantity int `json:"quantity" binding:"required,min=1" example:"5"`
+	Quantity int `json:"quantity" binding:"required,min=1,quantity" maximum:"1000000" example:"5"`
+
+	StockNote
+}
+
+// LocationStockResponse is the response of a stock operation on one location
+// @Description Item totals plus the stock at the location
+type LocationStockResponse struct {
+	StockResponse
+
+	// Location code
+	Location string `json:"location" example:"WH-MAD-01"`
+
+	// Stock at the location
+	LocationQuantity int `json:"location_quantity" example:"50"`
+
+	// Reserved at the location
+	LocationReserved int `json:"location_reserved" example:"5"`
+
+	// Available at the location (quantity - reserved)
+	LocationAvailable int `json:"location_available" example:"45"`
+}
+
+// CommitStockRequest represents the request body for committing reserved stock
+// @Description Request to turn reserved stock into a sale
+type CommitStockRequest struct {
+	// Reserved quantity to commit (1 to 1000000, and <= reserved)
+	// @Example 5
+	Quantity int `json:"quantity" binding:"required,min=1,quantity" maximum:"1000000" example:"5"`
+
+	StockNote
+}
+
+// ForceSetStockRequest represents the request body for an administrative stock correction
+// @Description Explicit stock counters that replace the current ones
+type ForceSetStockRequest struct {
+	// New total stock quantity (>= 0)
+	Quantity *int `json:"quantity" binding:"required,min=0,quantity" maximum:"1000000" example:"40"`
+
+	// New reserved quantity (>= 0, cannot exceed quantity)
+	Reserved *int `json:"reserved" binding:"required,min=0,quantity" maximum:"1000000" example:"0"`
+
+	// Why the counters are being overwritten (recorded in the stock history)
+	Reason string `json:"reason" binding:"required" example:"reserved counter corrupted after failed release"`
+}
+
+// AddItemRelationRequest represents the request body for linking two items
+// @Description Item to link and how it relates to the item in the path
+type AddItemRelationRequest struct {
+	// ID of the related item (UUID)
+	RelatedID string `json:"related_id" binding:"required" example:"6ba7b810-9dad-11d1-80b4-00c04fd430c8"`
+
+	// substitute (can be sold instead of the item) or accessory (sold with it)
+	Relation string `json:"relation" binding:"required" example:"substitute"`
+}
+
+// ItemRelationResponse represents a link between two items
+// @Description Item, related item and relation
+type ItemRelationResponse struct {
+	ItemID        string `json:"item_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	RelatedItemID string `json:"related_item_id" example:"6ba7b810-9dad-11d1-80b4-00c04fd430c8"`
+	Relation      string `json:"relation" example:"substitute"`
+}
+
+// ItemImageResponse represents an image of an item
+// @Description Image stored for an item and the item version after the change
+type ItemImageResponse struct {
+	ID          string    `json:"id" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	ItemID      string    `json:"item_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	URL         string    `json:"url" example:"http://localhost:8081/api/v1/images/default/550e8400-e29b-41d4-a716-446655440000/7c9e6679-7425-40de-944b-e07fc1f90ae7.png"`
+	ContentType string    `json:"content_type" example:"image/png"`
+	Size        int64     `json:"size" example:"48213"`
+	CreatedAt   time.Time `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	Version     int       `json:"version" example:"4"`
+}
+
+// AddItemIdentifierRequest represents the request body for adding a barcode or alias to an item
+// @Description Identifier type and value
+type AddItemIdentifierRequest struct {
+	// ean13, ean8, upc (UPC-A), gtin14 or alias
+	Type string `json:"type" binding:"required" example:"ean13"`
+
+	// Digits of the barcode (with its check digit) or the alias
+	Value string `json:"value" binding:"required" example:"4006381333931"`
+
+	// Expected item version (optional, same as If-Match); 409 if the item changed since
+	Version *int `json:"version,omitempty" binding:"omitempty,min=1" example:"3"`
+}
+
+// ItemIdentifierResponse represents a barcode or alias of an item
+// @Description Identifier of an item and the item version after the change
+type ItemIdentifierResponse struct {
+	ItemID string `json:"item_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Type   string `json:"type" example:"ean13"`
+	Value  string `json:"value" example:"4006381333931"`
+	// What GET /inventory/items/barcode/{code} matches: the GTIN-14 of a barcode, the alias as given
+	Code      string    `json:"code" example:"04006381333931"`
+	CreatedAt time.Time `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	Version   int       `json:"version" example:"4"`
+}
+
+// ManualCorrectionResponse represents the result of an administrative stock correction
+// @Description Stock counters after the correction and the values they replaced
+type ManualCorrectionResponse struct {
+	StockResponse
+
+	// Quantity before the correction
+	PreviousQuantity int `json:"previous_quantity" example:"40"`
+
+	// Reserved quantity before the correction
+	PreviousReserved int `json:"previous_reserved" example:"57"`
+
+	// Reason given for the correction
+	Reason string `json:"reason" example:"reserved counter corrupted after failed release"`
+}
+
+// StockCountRequest represents the counted quantities of a reconciliation
+// @Description Physical stock counts, one per SKU
+type StockCountRequest struct {
+	Counts []StockCountLine `json:"counts" binding:"required,min=1,dive"`
+}
+
+// StockCountLine is the counted quantity of one SKU
+type StockCountLine struct {
+	// SKU of the counted item
+	SKU string `json:"sku" binding:"required" example:"SKU-001"`
+
+	// Units found in the physical count (>= 0)
+	Counted *int `json:"counted" binding:"required,min=0,quantity" maximum:"1000000" example:"42"`
+}
+
+// ReconciliationReport represents the corrections applied by a reconciliation
+// @Description Result of every counted SKU and totals per outcome
+type ReconciliationReport struct {
+	// Counted SKUs in the request
+	Counted int `json:"counted" example:"3"`
+
+	// SKUs whose stock was adjusted to the count
+	Adjusted int `json:"adjusted" example:"1"`
+
+	// SKUs whose stock already matched the count
+	Unchanged int `json:"unchanged" example:"1"`
+
+	// SKUs that could not be reconciled (see the line status)
+	Failed int `json:"failed" example:"1"`
+
+	// Net change of all adjustments
+	NetDelta int `json:"net_delta" example:"-3"`
+
+	Lines []ReconciliationLine `json:"lines"`
+}
+
+// ReconciliationLine represents the outcome of one counted SKU
+type ReconciliationLine struct {
+	SKU    string `json:"sku" example:"SKU-001"`
+	ItemID string `json:"item_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+
+	// Quantity in the write store before the count (absent if the SKU was not found)
+	PreviousQuantity *int `json:"previous_quantity,omitempty" example:"45"`
+
+	Counted int `json:"counted" example:"42"`
+
+	// Adjustment applied (counted - previous)
+	Delta int `json:"delta" example:"-3"`
+
+	// adjusted, unchanged, not_found, duplicate, rejected (count below the stock held at
+	// locations), conflict (item changed during the reconciliation) or failed
+	Status string `json:"status" example:"adjusted"`
+
+	Error string `json:"error,omitempty" example:""`
+
+	// Item version after the adjustment
+	Version int `json:"version,omitempty" example:"5"`
+}
+
+// CreateStoreRequest represents the request body for creating a store
+// @Description Request to create a new physical store
+type CreateStoreRequest struct {
+	// Unique store code
+	Code string `json:"code" binding:"required" example:"STORE-001"`
+
+	// Store name
+	Name string `json:"name" binding:"required" example:"Tienda Centro"`
+
+	// Store location (optional)
+	Location string `json:"location" example:"Av. Principal 123"`
+}
+
+// UpdateStoreRequest represents the request body for updating a store
+// @Description Request to update an existing store
+type UpdateStoreRequest struct {
+	// Store name
+	Name string `json:"name" binding:"required" example:"Tienda Centro"`
+
+	// Store location (optional)
+	Location string `json:"location" example:"Av. Principal 123"`
+
+	// Whether the store accepts new reservations (optional, unchanged if omitted)
+	Active *bool `json:"active" example:"true"`
 }
 
+// SetStoreCalendarRequest represents the request body for setting a store's opening hours
+// @Description Opening hours and holidays of a store, in its local time
+type SetStoreCalendarRequest struct {
+	// IANA timezone of the store
+	Timezone string `json:"timezone" binding:"required" example:"America/Bogota"`
+
+	// Opening periods; a day without periods is closed
+	Hours []OpeningHoursRequest `json:"hours" binding:"dive"`
+
+	// Dates on which the store is closed all day
+	Holidays []HolidayRequest `json:"holidays" binding:"dive"`
+}
+
+// OpeningHoursRequest is one opening period of a day of the week
+type OpeningHoursRequest struct {
+	// Day of the week (sunday ... saturday)
+	Day string `json:"day" binding:"required" example:"monday"`
+
+	// Opening time (HH:MM)
+	Opens string `json:"opens" binding:"required" example:"09:00"`
+
+	// Closing time (HH:MM, "24:00" for midnight)
+	Closes string `json:"closes" binding:"required" example:"18:00"`
+}
+
+// HolidayRequest is a date on which the store is closed
+type HolidayRequest struct {
+	// Date (YYYY-MM-DD)
+	Date string `json:"date" binding:"required" example:"2024-12-25"`
+
+	// Holiday name (optional)
+	Name string `json:"name" example:"Navidad"`
+}
+
+// StoreResponse represents a store in API responses
+// @Description Store information
+type StoreResponse struct {
+	ID        string `json:"id" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	Code      string `json:"code" example:"STORE-001"`
+	Name      string `json:"name" example:"Tienda Centro"`
+	Location  string `json:"location" example:"Av. Principal 123"`
+	Active    bool   `json:"active" example:"true"`
+	CreatedAt string `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt string `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+
+	// Opening hours and holidays (only when set)
+	Calendar *SetStoreCalendarRequest `json:"calendar,omitempty"`
+}
+
+// VersionConflictResponse is returned when the item is no longer at the expected version
+// @Description Optimistic concurrency conflict (If-Match / version): the error envelope with code VersionConflict plus the versions
+type VersionConflictResponse struct {
+	*errors.StandardError
+
+	// Version sent by the client (If-Match or version)
+	ExpectedVersion int `json:"expected_version,omitempty" example:"3"`
+
+	// Version the item is at now; reload the item and retry with it
+	CurrentVersion int `json:"current_version,omitempty" example:"5"`
+}
+
+// CommandStatusResponse is the outcome of a request whose events were published
+// @Description Command status: accepted until the Listener Service rejects one of its events, then failed
+type CommandStatusResponse struct {
+	RequestID string `json:"request_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+
+	// accepted or failed
+	Status string `json:"status" example:"failed" enums:"accepted,failed"`
+
+	// Events published by the request, in order (empty when this replica did not serve it)
+	Events []string `json:"events" example:"StockReserved"`
+
+	// When the events were published (omitted when this replica did not serve the request)
+	AcceptedAt string `json:"accepted_at,omitempty" example:"2024-01-15T10:30:00Z"`
+
+	UpdatedAt string `json:"updated_at" example:"2024-01-15T10:30:02Z"`
+
+	// Events the Listener Service could not apply
+	Rejections []RejectedEventResponse `json:"rejections"`
+}
+
+// RejectedEventResponse is an event of the request that the Listener Service rejected
+type RejectedEventResponse struct {
+	EventID    string `json:"event_id" example:"3f2b8c1e-9d4a-4e7b-8c6d-1a2b3c4d5e6f"`
+	EventType  string `json:"event_type" example:"StockReserved"`
+	Reason     string `json:"reason" example:"insufficient stock: available 2, requested 5"`
+	RejectedAt string `json:"rejected_at" example:"2024-01-15T10:30:02Z"`
+}
+
+// CommandResponse is the progress of a request through the pipeline
+// @Description Command tracking: accepted → published → confirmed (visible to queries), or failed
+type CommandResponse struct {
+	RequestID string `json:"request_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+
+	// Stage of the least advanced event of the request
+	Stage string `json:"stage" example:"confirmed" enums:"accepted,published,confirmed,failed"`
+
+	// When the command was accepted (omitted when this replica did not serve the request)
+	AcceptedAt string `json:"accepted_at,omitempty" example:"2024-01-15T10:30:00Z"`
+
+	UpdatedAt string `json:"updated_at" example:"2024-01-15T10:30:01Z"`
+
+	// Events of the request, in the order they were published
+	Events []CommandEventResponse `json:"events"`
+
+	// Events the Listener Service could not apply
+	Rejections []RejectedEventResponse `json:"rejections"`
+}
+
+// CommandEventResponse is the progress of one event of a request
+type CommandEventResponse struct {
+	Type  string `json:"type" example:"StockReserved"`
+	Stage string `json:"stage" example:"confirmed" enums:"accepted,published,confirmed"`
+
+	// When Kafka acknowledged the event
+	PublishedAt string `json:"published_at,omitempty" example:"2024-01-15T10:30:00Z"`
+
+	// When the Listener Service applied it to the read model
+	AppliedAt string `json:"applied_at,omitempty" example:"2024-01-15T10:30:01Z"`
+
+	// When its confirmation reached the Command Service
+	ConfirmedAt string `json:"confirmed_at,omitempty" example:"2024-01-15T10:30:01Z"`
+}
+
+// AuditExportResponse is a page of the audit log and the outcome of verifying its chain
+// @Description Audit log export: consecutive entries, each with the hash of the previous one
+type AuditExportResponse struct {
+	Entries []audit.Entry `json:"entries"`
+	Count   int           `json:"count" example:"2"`
+
+	// after_seq of the next page; omitted on the last one
+	NextAfterSeq *int64 `json:"next_after_seq,omitempty" example:"1002"`
+
+	// Whether every entry matches its hash and links to the previous one
+	ChainVerified bool `json:"chain_verified" example:"true"`
+
+	// First problem found when chain_verified is false
+	ChainError string `json:"chain_error,omitempty" example:"entry 17 does not match its hash"`
+}
+
+// IdempotencyListResponse is a page of the idempotency store
+// @Description Stored responses by X-Request-ID, most recent first, and the duplicate-hit statistics
+type IdempotencyListResponse struct {
+	Entries    []middleware.IdempotencyEntry `json:"entries"`
+	Total      int                           `json:"total" example:"120"`
+	Page       int                           `json:"page" example:"1"`
+	PageSize   int                           `json:"page_size" example:"50"`
+	TotalPages int                           `json:"total_pages" example:"3"`
+
+	// Checks and duplicate hits of this replica since startup
+	Stats middleware.IdempotencyStats `json:"stats"`
+}
+
+// IdempotencyEntryResponse is an idempotency entry with the response its duplicates receive
+type IdempotencyEntryResponse struct {
+	middleware.IdempotencyEntry
+
+	// Stored response, when it is JSON
+	Response json.RawMessage `json:"response,omitempty" swaggertype:"object"`
+
+	// Stored response, when it is not JSON
+	ResponseText string `json:"response_text,omitempty"`
+}
+
+// CreateSupplierRequest represents the request body for creating a supplier
+// @Description Request to create a new supplier
+type CreateSupplierRequest struct {
+	// Unique supplier code
+	Code string `json:"code" binding:"required,max=64" example:"SUP-001"`
+
+	// Supplier name
+	Name string `json:"name" binding:"required,max=255" example:"Distribuidora Norte"`
+
+	// Contact email (optional)
+	Email string `json:"email" binding:"omitempty,email" example:"compras@norte.com"`
+
+	// Contact phone (optional)
+	Phone string `json:"phone" binding:"max=32" example:"+57 300 123 4567"`
+}
+
+// UpdateSupplierRequest represents the request body for updating a supplier
+// @Description Request to update an existing supplier
+type UpdateSupplierRequest struct {
+	// Supplier name
+	Name string `json:"name" binding:"required,max=255" example:"Distribuidora Norte"`
+
+	// Contact email (optional)
+	Email string `json:"email" binding:"omitempty,email" example:"compras@norte.com"`
+
+	// Contact phone (optional)
+	Phone string `json:"phone" binding:"max=32" example:"+57 300 123 4567"`
+
+	// Whether the supplier takes new purchase orders (optional, unchanged if omitted)
+	Active *bool `json:"active" example:"true"`
+}
+
+// SupplierResponse represents a supplier in API responses
+// @Description Supplier information
+type SupplierResponse struct {
+	ID        string `json:"id" example:"3f2b8c1e-5d4a-4e6b-9c7d-8e9f0a1b2c3d"`
+	Code      string `json:"code" example:"SUP-001"`
+	Name      string `json:"name" example:"Distribuidora Norte"`
+	Email     string `json:"email" example:"compras@norte.com"`
+	Phone     string `json:"phone" example:"+57 300 123 4567"`
+	Active    bool   `json:"active" example:"true"`
+	CreatedAt string `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt string `json:"updated_at" example:"2024-01-15T10:30:00Z"`
+}
+
+// CreatePurchaseOrderRequest represents the request body for creating a purchase order
+// @Description Items ordered from a supplier
+type CreatePurchaseOrderRequest struct {
+	// Supplier the order is placed with (UUID)
+	SupplierID string `json:"supplier_id" binding:"required" example:"3f2b8c1e-5d4a-4e6b-9c7d-8e9f0a1b2c3d"`
+
+	// Order number, unique per tenant (optional, generated from the ID if omitted)
+	Number string `json:"number" binding:"max=64" example:"PO-2024-0001"`
+
+	// Expected delivery date (optional, RFC3339)
+	ExpectedAt *time.Time `json:"expected_at" example:"2024-02-01T00:00:00Z"`
+
+	// Free text notes (optional)
+	Notes string `json:"notes" binding:"max=1000" example:"Entregar en bodega central"`
+
+	// Items ordered, one line per item (1 to 200)
+	Lines []PurchaseOrderLineRequest `json:"lines" binding:"required,min=1,max=200,dive"`
+}
+
+// PurchaseOrderLineRequest is the quantity of an item ordered
+type PurchaseOrderLineRequest struct {
+	// Item ordered (UUID)
+	ItemID string `json:"item_id" binding:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
+
+	// Units ordered
+	Quantity int `json:"quantity" binding:"required,min=1,quantity" minimum:"1" maximum:"1000000" example:"100"`
+
+	// Cost of each unit (optional), recorded with the received stock
+	UnitCost *float64 `json:"unit_cost,omitempty" binding:"omitempty,min=0" example:"12.5"`
+}
+
+// ReceivePurchaseOrderRequest represents the request body for receiving a purchase order
+// @Description Quantities that arrived, one line per item of the order
+type ReceivePurchaseOrderRequest struct {
+	Lines []PurchaseOrderReceiptRequest `json:"lines" binding:"required,min=1,max=200,dive"`
+}
+
+// PurchaseOrderReceiptRequest is the quantity of an item that arrived
+type PurchaseOrderReceiptRequest struct {
+	// Item received (UUID), one of the lines of the order
+	ItemID string `json:"item_id" binding:"required" example:"550e8400-e29b-41d4-a716-446655440000"`
+
+	// Units received, at most the units of the line still to be received
+	Quantity int `json:"quantity" binding:"required,min=1,quantity" minimum:"1" maximum:"1000000" example:"40"`
+}
+
+// PurchaseOrderResponse represents a purchase order in API responses
+// @Description Purchase order with the ordered and received quantity of each line
+type PurchaseOrderResponse struct {
+	ID         string `json:"id" example:"9b1deb4d-3b7d-4bad-9bdd-2b0d7b3dcb6d"`
+	Number     string `json:"number" example:"PO-2024-0001"`
+	SupplierID string `json:"supplier_id" example:"3f2b8c1e-5d4a-4e6b-9c7d-8e9f0a1b2c3d"`
+
+	// open, partially_received, received or cancelled
+	Status string `json:"status" example:"partially_received"`
+
+	ExpectedAt string                      `json:"expected_at,omitempty" example:"2024-02-01T00:00:00Z"`
+	Notes      string                      `json:"notes,omitempty" example:"Entregar en bodega central"`
+	Lines      []PurchaseOrderLineResponse `json:"lines"`
+	Version    int                         `json:"version" example:"2"`
+	CreatedAt  string                      `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt  string                      `json:"updated_at" example:"2024-01-20T08:00:00Z"`
+}
+
+// PurchaseOrderLineResponse is an item of a purchase order
+type PurchaseOrderLineResponse struct {
+	ItemID    string   `json:"item_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	SKU       string   `json:"sku" example:"SKU-001"`
+	Ordered   int      `json:"ordered" example:"100"`
+	Received  int      `json:"received" example:"40"`
+	Remaining int      `json:"remaining" example:"60"`
+	UnitCost  *float64 `json:"unit_cost,omitempty" example:"12.5"`
+}
+
+// PurchaseOrderReceiptReport represents the outcome of a receipt
+// @Description The purchase order after the receipt and the result of every line
+type PurchaseOrderReceiptReport struct {
+	PurchaseOrder PurchaseOrderResponse `json:"purchase_order"`
+
+	// Lines whose stock was added
+	Received int `json:"received" example:"2"`
+
+	// Lines that could not be received (see the line status); they stay pending on the order
+	Failed int `json:"failed" example:"0"`
+
+	Lines []PurchaseOrderReceiptLine `json:"lines"`
+}
+
+// PurchaseOrderReceiptLine represents the outcome of one received item
+type PurchaseOrderReceiptLine struct {
+	ItemID   string `json:"item_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	SKU      string `json:"sku" example:"SKU-001"`
+	Quantity int    `json:"quantity" example:"40"`
+
+	// received, not_found (item deleted), conflict (item changed during the receipt) or failed
+	Status string `json:"status" example:"received"`
+
+	Error string `json:"error,omitempty" example:""`
+
+	// Item quantity and version after the adjustment
+	NewTotal int `json:"new_total,omitempty" example:"140"`
+	Version  int `json:"version,omitempty" example:"5"`
+}
@@ -0,0 +1,469 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"command-service/internal/commands"
+	"command-service/internal/domain"
+	"command-service/internal/events"
+	"command-service/pkg/errors"
+	"command-service/pkg/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Outcomes of a received line in the receipt report
+const (
+	receiptReceived = "received"
+	receiptNotFound = "not_found"
+	receiptConflict = "conflict"
+	receiptFailed   = "failed"
+)
+
+// CreatePurchaseOrder handles POST /api/v1/purchase-orders
+// @Summary      Create a purchase order
+// @Description  Crea una orden de compra abierta a un proveedor activo, con una línea por item pedido. El stock no cambia al crear la orden: se agrega al recibirla (`POST /purchase-orders/{id}/receive`).
+//
+// **Características:**
+// - El número de orden es único por tenant; si se omite se genera a partir del ID (`PO-XXXXXXXX`)
+// - Cada item aparece en una sola línea; el SKU se copia del item al crear la orden
+// - Hasta 200 líneas por orden
+//
+// **Ejemplos válidos:**
+// - `{"supplier_id": "3f2b8c1e-5d4a-4e6b-9c7d-8e9f0a1b2c3d", "number": "PO-2024-0001", "lines": [{"item_id": "550e8400-e29b-41d4-a716-446655440000", "quantity": 100, "unit_cost": 12.5}]}`
+// - Con fecha de entrega: `{"supplier_id": "...", "expected_at": "2024-02-01T00:00:00Z", "lines": [...]}`
+//
+// **Ejemplos inválidos:**
+// - Sin líneas, cantidad no positiva o costo negativo
+// - El mismo item en dos líneas
+// - Proveedor inactivo (409) o inexistente (404)
+// - Número de orden duplicado (409)
+//
+// @Tags         purchasing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        X-Request-ID  header    string                      false  "Request ID for idempotency (UUID). If not provided, a new one will be generated."
+// @Param        request       body      CreatePurchaseOrderRequest  true   "Purchase order"
+// @Success      201           {object}  PurchaseOrderResponse       "Orden de compra creada"
+// @Failure      400           {object}  ErrorResponse               "Request inválido - líneas vacías, cantidades o costos inválidos, item repetido"
+// @Failure      401           {object}  ErrorResponse               "No autorizado - token JWT inválido o faltante"
+// @Failure      404           {object}  ErrorResponse               "Proveedor o item no encontrado"
+// @Failure      409           {object}  ErrorResponse               "Proveedor inactivo o número de orden duplicado"
+// @Failure      500           {object}  ErrorResponse               "Error interno del servidor"
+// @Router       /purchase-orders [post]
+func (h *PurchasingHandler) CreatePurchaseOrder(c *gin.Context) {
+	var req CreatePurchaseOrderRequest
+	if bindErr := validation.BindJSON(c, &req); bindErr != nil {
+		errors.Respond(c, bindErr)
+		return
+	}
+
+	supplierID, err := uuid.Parse(req.SupplierID)
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid supplier id", ""))
+		return
+	}
+	cmd := commands.CreatePurchaseOrderCommand{
+		SupplierID: supplierID,
+		Number:     req.Number,
+		Notes:      req.Notes,
+		Lines:      make([]commands.PurchaseOrderLine, 0, len(req.Lines)),
+	}
+	if req.ExpectedAt != nil {
+		expectedAt := req.ExpectedAt.UTC()
+		cmd.ExpectedAt = &expectedAt
+	}
+	for _, line := range req.Lines {
+		itemID, err := uuid.Parse(line.ItemID)
+		if err != nil {
+			errors.Respond(c, errors.NewInvalidRequest("invalid item id", "Item ID: "+line.ItemID))
+			return
+		}
+		cmd.Lines = append(cmd.Lines, commands.PurchaseOrderLine{ItemID: itemID, Quantity: line.Quantity, UnitCost: line.UnitCost})
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	supplier, ok := h.findSupplier(c, cmd.SupplierID, "failed to create purchase order")
+	if !ok {
+		return
+	}
+	if !supplier.Active {
+		errors.Respond(c, errors.NewConflict(domain.ErrSupplierInactive.Error(), "Supplier ID: "+supplier.ID.String()))
+		return
+	}
+
+	lines := make([]domain.PurchaseOrderLine, 0, len(cmd.Lines))
+	for _, line := range cmd.Lines {
+		item, err := h.inventory.repository.FindByID(c.Request.Context(), line.ItemID)
+		if err != nil {
+			if err == domain.ErrItemNotFound {
+				errors.Respond(c, errors.NewNotFound("item not found", "Item ID: "+line.ItemID.String()))
+				return
+			}
+			h.logger.Error("Failed to find item", zap.Error(err))
+			errors.Respond(c, errors.NewInternalError("failed to create purchase order", nil))
+			return
+		}
+		lines = append(lines, domain.PurchaseOrderLine{ItemID: item.ID, SKU: item.SKU, Ordered: line.Quantity, UnitCost: line.UnitCost})
+	}
+
+	order, err := domain.NewPurchaseOrder(cmd.Number, supplier.ID, lines, cmd.ExpectedAt, cmd.Notes)
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest(err.Error(), ""))
+		return
+	}
+
+	if err := h.orders.Save(c.Request.Context(), order); err != nil {
+		if err == domain.ErrDuplicatePurchaseOrder {
+			errors.Respond(c, errors.NewConflict(err.Error(), "Number: "+order.Number))
+			return
+		}
+		h.logger.Error("Failed to save purchase order", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to create purchase order", nil))
+		return
+	}
+	auditPurchaseOrderAfter(c, order)
+
+	event := events.PurchaseOrderCreatedEvent{
+		PurchaseOrderID: order.ID,
+		Number:          order.Number,
+		SupplierID:      order.SupplierID,
+		Status:          order.Status,
+		Notes:           order.Notes,
+		Lines:           make([]events.PurchaseOrderLine, 0, len(order.Lines)),
+		OccurredAt:      order.CreatedAt,
+	}
+	if order.ExpectedAt != nil {
+		event.ExpectedAt = *order.ExpectedAt
+	}
+	for _, line := range order.Lines {
+		event.Lines = append(event.Lines, events.PurchaseOrderLine{ItemID: line.ItemID, SKU: line.SKU, Quantity: line.Ordered, UnitCost: line.UnitCost})
+	}
+	if err := h.inventory.eventBus.Publish(c.Request.Context(), event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+
+	h.logger.Info("Purchase order created",
+		zap.String("purchase_order_id", order.ID.String()),
+		zap.String("number", order.Number),
+		zap.String("supplier_id", supplier.ID.String()),
+		zap.Int("lines", len(order.Lines)),
+	)
+	c.JSON(http.StatusCreated, purchaseOrderResponse(order))
+}
+
+// ReceivePurchaseOrder handles POST /api/v1/purchase-orders/:id/receive
+// @Summary      Receive stock for a purchase order
+// @Description  Registra la mercadería recibida de una orden de compra: cada línea ajusta el stock del item publicando un `StockAdjusted` con `reason: "po_receipt"`, `reference` igual al número de orden y el costo unitario de la línea. Luego se publica un `PurchaseOrderReceived` con lo recibido.
+//
+// **Características:**
+// - Se puede recibir en varias entregas; la orden queda `partially_received` hasta recibir todo (`received`)
+// - Antes de ajustar stock se valida todo el request: si una línea no es de la orden o supera lo pendiente, no se recibe nada (400)
+// - Cada item se ajusta por separado: un item que falla (`not_found`, `conflict`, `failed`) queda pendiente en la orden y se puede volver a recibir, sin impedir los demás
+//
+// **Ejemplos válidos:**
+// - `{"lines": [{"item_id": "550e8400-e29b-41d4-a716-446655440000", "quantity": 40}]}`
+//
+// **Ejemplos inválidos:**
+// - Item que no está en la orden, o repetido en el request
+// - Cantidad mayor a la pendiente de la línea
+// - Orden recibida o cancelada (409)
+//
+// @Tags         purchasing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                       true  "Purchase order ID (UUID)"
+// @Param        request  body      ReceivePurchaseOrderRequest  true  "Received quantities"
+// @Success      200      {object}  PurchaseOrderReceiptReport   "Resultado de la recepción (incluye las líneas que no se pudieron recibir)"
+// @Failure      400      {object}  ErrorResponse                "Request inválido - item fuera de la orden, repetido o cantidad mayor a la pendiente"
+// @Failure      401      {object}  ErrorResponse                "No autorizado - token JWT inválido o faltante"
+// @Failure      404      {object}  ErrorResponse                "Orden de compra no encontrada"
+// @Failure      409      {object}  ErrorResponse                "La orden ya fue recibida o cancelada"
+// @Failure      500      {object}  ErrorResponse                "Error interno del servidor"
+// @Router       /purchase-orders/{id}/receive [post]
+func (h *PurchasingHandler) ReceivePurchaseOrder(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid purchase order id", ""))
+		return
+	}
+
+	var req ReceivePurchaseOrderRequest
+	if bindErr := validation.BindJSON(c, &req); bindErr != nil {
+		errors.Respond(c, bindErr)
+		return
+	}
+	cmd := commands.ReceivePurchaseOrderCommand{ID: id, Lines: make([]commands.PurchaseOrderLine, 0, len(req.Lines))}
+	seen := make(map[uuid.UUID]bool, len(req.Lines))
+	for _, line := range req.Lines {
+		itemID, err := uuid.Parse(line.ItemID)
+		if err != nil {
+			errors.Respond(c, errors.NewInvalidRequest("invalid item id", "Item ID: "+line.ItemID))
+			return
+		}
+		if seen[itemID] {
+			errors.Respond(c, errors.NewInvalidRequest("item received more than once", "Item ID: "+line.ItemID))
+			return
+		}
+		seen[itemID] = true
+		cmd.Lines = append(cmd.Lines, commands.PurchaseOrderLine{ItemID: itemID, Quantity: line.Quantity})
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	order, ok := h.findPurchaseOrder(c, cmd.ID, "failed to receive purchase order")
+	if !ok {
+		return
+	}
+	auditPurchaseOrderBefore(c, order)
+
+	// Reject the whole receipt before any stock moves
+	for _, line := range cmd.Lines {
+		err := order.CheckReceipt(domain.PurchaseOrderReceipt{ItemID: line.ItemID, Quantity: line.Quantity})
+		switch err {
+		case nil:
+		case domain.ErrPurchaseOrderClosed:
+			errors.Respond(c, errors.NewConflict(err.Error(), "Status: "+order.Status))
+			return
+		default:
+			errors.Respond(c, errors.NewInvalidRequest(err.Error(), "Item ID: "+line.ItemID.String()))
+			return
+		}
+	}
+
+	report := PurchaseOrderReceiptReport{Lines: make([]PurchaseOrderReceiptLine, 0, len(cmd.Lines))}
+	receipts := make([]domain.PurchaseOrderReceipt, 0, len(cmd.Lines))
+	for _, receipt := range cmd.Lines {
+		orderLine, _ := order.Line(receipt.ItemID)
+		line := PurchaseOrderReceiptLine{ItemID: receipt.ItemID.String(), SKU: orderLine.SKU, Quantity: receipt.Quantity}
+		h.receiveLine(c, order, orderLine, &line)
+		if line.Status == receiptReceived {
+			report.Received++
+			receipts = append(receipts, domain.PurchaseOrderReceipt{ItemID: receipt.ItemID, Quantity: receipt.Quantity})
+		} else {
+			report.Failed++
+		}
+		report.Lines = append(report.Lines, line)
+	}
+
+	if len(receipts) > 0 {
+		if err := h.recordReceipts(c, order, receipts); err != nil {
+			h.logger.Error("Failed to save purchase order",
+				zap.String("purchase_order_id", order.ID.String()), zap.Error(err))
+			errors.Respond(c, errors.NewInternalError("stock was received but the purchase order could not be updated", nil))
+			return
+		}
+	}
+
+	h.logger.Info("Purchase order received",
+		zap.String("purchase_order_id", order.ID.String()),
+		zap.String("number", order.Number),
+		zap.String("status", order.Status),
+		zap.Int("received", report.Received),
+		zap.Int("failed", report.Failed),
+	)
+	report.PurchaseOrder = purchaseOrderResponse(order)
+	c.JSON(http.StatusOK, report)
+}
+
+// receiveLine adds the received quantity to the item's stock and publishes the
+// adjustment, recording the outcome in line
+func (h *PurchasingHandler) receiveLine(c *gin.Context, order *domain.PurchaseOrder, orderLine domain.PurchaseOrderLine, line *PurchaseOrderReceiptLine) {
+	ctx := c.Request.Context()
+	inventory := h.inventory
+	item, err := inventory.repository.FindByID(ctx, orderLine.ItemID)
+	if err != nil {
+		if err == domain.ErrItemNotFound {
+			line.Status = receiptNotFound
+			line.Error = "item not found"
+			return
+		}
+		h.logger.Error("Failed to find item", zap.String("item_id", line.ItemID), zap.Error(err))
+		line.Status = receiptFailed
+		line.Error = "failed to read item"
+		return
+	}
+	auditItemBefore(c, item)
+
+	expected := item.Version
+	if err := item.AdjustStock(line.Quantity); err != nil {
+		line.Status = receiptFailed
+		line.Error = err.Error()
+		return
+	}
+	event := events.StockAdjustedEvent{
+		ItemID:          item.ID,
+		SKU:             item.SKU,
+		Quantity:        line.Quantity,
+		NewTotal:        item.Quantity,
+		UnitCost:        orderLine.UnitCost,
+		ExpectedVersion: expected,
+		Reason:          events.ReasonPOReceipt,
+		Reference:       order.Number,
+		Actor:           requestActor(c),
+		OccurredAt:      item.UpdatedAt,
+	}
+	journalID, err := inventory.journal.Begin(item, false, event)
+	if err != nil {
+		h.logger.Error("Failed to write journal", zap.String("item_id", line.ItemID), zap.Error(err))
+		line.Status = receiptFailed
+		line.Error = "failed to adjust stock"
+		return
+	}
+
+	if err := inventory.repository.Save(ctx, item); err != nil {
+		inventory.journal.Aborted(journalID)
+		if err == domain.ErrVersionConflict {
+			line.Status = receiptConflict
+			line.Error = "item changed during the receipt, receive it again"
+			return
+		}
+		h.logger.Error("Failed to save item", zap.String("item_id", line.ItemID), zap.Error(err))
+		line.Status = receiptFailed
+		line.Error = "failed to adjust stock"
+		return
+	}
+	auditItemAfter(c, item)
+
+	if err := inventory.publish(c, journalID, event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+	line.Status = receiptReceived
+	line.NewTotal = item.Quantity
+	line.Version = item.Version
+}
+
+// recordReceipts records the received lines on the order, saves it and publishes them
+func (h *PurchasingHandler) recordReceipts(c *gin.Context, order *domain.PurchaseOrder, receipts []domain.PurchaseOrderReceipt) error {
+	if err := order.Receive(receipts); err != nil {
+		return err
+	}
+	if err := h.orders.Save(c.Request.Context(), order); err != nil {
+		return err
+	}
+	auditPurchaseOrderAfter(c, order)
+
+	event := events.PurchaseOrderReceivedEvent{
+		PurchaseOrderID: order.ID,
+		Number:          order.Number,
+		Status:          order.Status,
+		Lines:           make([]events.PurchaseOrderReceipt, 0, len(receipts)),
+		Actor:           requestActor(c),
+		OccurredAt:      order.UpdatedAt,
+	}
+	for _, receipt := range receipts {
+		line, _ := order.Line(receipt.ItemID)
+		event.Lines = append(event.Lines, events.PurchaseOrderReceipt{ItemID: receipt.ItemID, Quantity: receipt.Quantity, Received: line.Received})
+	}
+	if err := h.inventory.eventBus.Publish(c.Request.Context(), event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+	return nil
+}
+
+// CancelPurchaseOrder handles POST /api/v1/purchase-orders/:id/cancel
+// @Summary      Cancel a purchase order
+// @Description  Cancela una orden de compra abierta o parcialmente recibida: lo pendiente ya no se puede recibir. El stock ya recibido no se modifica.
+// @Tags         purchasing
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Purchase order ID (UUID)"
+// @Success      200  {object}  PurchaseOrderResponse  "Orden de compra cancelada"
+// @Failure      400  {object}  ErrorResponse          "ID inválido"
+// @Failure      401  {object}  ErrorResponse          "No autorizado - token JWT inválido o faltante"
+// @Failure      404  {object}  ErrorResponse          "Orden de compra no encontrada"
+// @Failure      409  {object}  ErrorResponse          "La orden ya fue recibida o cancelada"
+// @Failure      500  {object}  ErrorResponse          "Error interno del servidor"
+// @Router       /purchase-orders/{id}/cancel [post]
+func (h *PurchasingHandler) CancelPurchaseOrder(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid purchase order id", ""))
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	order, ok := h.findPurchaseOrder(c, id, "failed to cancel purchase order")
+	if !ok {
+		return
+	}
+	auditPurchaseOrderBefore(c, order)
+
+	if err := order.Cancel(); err != nil {
+		errors.Respond(c, errors.NewConflict(err.Error(), "Status: "+order.Status))
+		return
+	}
+
+	if err := h.orders.Save(c.Request.Context(), order); err != nil {
+		h.logger.Error("Failed to save purchase order", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to cancel purchase order", nil))
+		return
+	}
+	auditPurchaseOrderAfter(c, order)
+
+	event := events.PurchaseOrderCancelledEvent{
+		PurchaseOrderID: order.ID,
+		Number:          order.Number,
+		Status:          order.Status,
+		OccurredAt:      order.UpdatedAt,
+	}
+	if err := h.inventory.eventBus.Publish(c.Request.Context(), event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+
+	h.logger.Info("Purchase order cancelled", zap.String("purchase_order_id", order.ID.String()), zap.String("number", order.Number))
+	c.JSON(http.StatusOK, purchaseOrderResponse(order))
+}
+
+// findPurchaseOrder loads a purchase order, responding 404 (or 500 with failure) when it cannot
+func (h *PurchasingHandler) findPurchaseOrder(c *gin.Context, id uuid.UUID, failure string) (*domain.PurchaseOrder, bool) {
+	order, err := h.orders.FindByID(c.Request.Context(), id)
+	if err != nil {
+		if err == domain.ErrPurchaseOrderNotFound {
+			errors.Respond(c, errors.NewNotFound("purchase order not found", "Purchase order ID: "+id.String()))
+			return nil, false
+		}
+		h.logger.Error("Failed to find purchase order", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError(failure, nil))
+		return nil, false
+	}
+	return order, true
+}
+
+func purchaseOrderResponse(order *domain.PurchaseOrder) PurchaseOrderResponse {
+	response := PurchaseOrderResponse{
+		ID:         order.ID.String(),
+		Number:     order.Number,
+		SupplierID: order.SupplierID.String(),
+		Status:     order.Status,
+		Notes:      order.Notes,
+		Lines:      make([]PurchaseOrderLineResponse, 0, len(order.Lines)),
+		Version:    order.Version,
+		CreatedAt:  order.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  order.UpdatedAt.Format(time.RFC3339),
+	}
+	if order.ExpectedAt != nil {
+		response.ExpectedAt = order.ExpectedAt.Format(time.RFC3339)
+	}
+	for _, line := range order.Lines {
+		response.Lines = append(response.Lines, PurchaseOrderLineResponse{
+			ItemID:    line.ItemID.String(),
+			SKU:       line.SKU,
+			Ordered:   line.Ordered,
+			Received:  line.Received,
+			Remaining: line.Remaining(),
+			UnitCost:  line.UnitCost,
+		})
+	}
+	return response
+}
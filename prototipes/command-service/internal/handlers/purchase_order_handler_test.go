@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"command-service/internal/domain"
+	"command-service/internal/events"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePurchaseOrder(t *testing.T) {
+	router, handler, repo, eventBus := setupPurchasingTest(t)
+	supplier := domain.NewSupplier("SUP-001", "Norte", "", "")
+	require.NoError(t, handler.suppliers.Save(context.Background(), supplier))
+	item := domain.NewInventoryItem("SKU-001", "Item", "", 3)
+	require.NoError(t, repo.Save(context.Background(), item))
+	eventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.PurchaseOrderCreatedEvent) bool {
+		return e.Number == "PO-2024-0001" && e.Status == domain.PurchaseOrderOpen &&
+			len(e.Lines) == 1 && e.Lines[0].SKU == "SKU-001" && e.Lines[0].Quantity == 10 && *e.Lines[0].UnitCost == 2.5
+	})).Return(nil).Once()
+
+	body := map[string]interface{}{
+		"supplier_id": supplier.ID.String(),
+		"number":      "PO-2024-0001",
+		"expected_at": "2024-02-01T00:00:00Z",
+		"lines":       []map[string]interface{}{{"item_id": item.ID.String(), "quantity": 10, "unit_cost": 2.5}},
+	}
+	w := sendPurchasing(router, "POST", "/api/v1/purchase-orders", body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var response PurchaseOrderResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "open", response.Status)
+	assert.Equal(t, "2024-02-01T00:00:00Z", response.ExpectedAt)
+	require.Len(t, response.Lines, 1)
+	assert.Equal(t, 10, response.Lines[0].Remaining)
+
+	// Creating the order does not move stock
+	assert.Equal(t, 3, item.Quantity)
+
+	// Duplicate number
+	w = sendPurchasing(router, "POST", "/api/v1/purchase-orders", body)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	eventBus.AssertExpectations(t)
+}
+
+func TestCreatePurchaseOrder_Invalid(t *testing.T) {
+	router, handler, repo, _ := setupPurchasingTest(t)
+	supplier := domain.NewSupplier("SUP-001", "Norte", "", "")
+	inactive := domain.NewSupplier("SUP-002", "Sur", "", "")
+	inactive.Update("Sur", "", "", false)
+	require.NoError(t, handler.suppliers.Save(context.Background(), supplier))
+	require.NoError(t, handler.suppliers.Save(context.Background(), inactive))
+	item := domain.NewInventoryItem("SKU-001", "Item", "", 0)
+	require.NoError(t, repo.Save(context.Background(), item))
+	line := map[string]interface{}{"item_id": item.ID.String(), "quantity": 1}
+
+	testCases := []struct {
+		name     string
+		body     map[string]interface{}
+		expected int
+	}{
+		{"no lines", map[string]interface{}{"supplier_id": supplier.ID.String(), "lines": []interface{}{}}, http.StatusBadRequest},
+		{"zero quantity", map[string]interface{}{"supplier_id": supplier.ID.String(), "lines": []interface{}{map[string]interface{}{"item_id": item.ID.String(), "quantity": 0}}}, http.StatusBadRequest},
+		{"repeated item", map[string]interface{}{"supplier_id": supplier.ID.String(), "lines": []interface{}{line, line}}, http.StatusBadRequest},
+		{"unknown item", map[string]interface{}{"supplier_id": supplier.ID.String(), "lines": []interface{}{map[string]interface{}{"item_id": domain.NewSupplier("X", "X", "", "").ID.String(), "quantity": 1}}}, http.StatusNotFound},
+		{"unknown supplier", map[string]interface{}{"supplier_id": domain.NewSupplier("X", "X", "", "").ID.String(), "lines": []interface{}{line}}, http.StatusNotFound},
+		{"inactive supplier", map[string]interface{}{"supplier_id": inactive.ID.String(), "lines": []interface{}{line}}, http.StatusConflict},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := sendPurchasing(router, "POST", "/api/v1/purchase-orders", tc.body)
+			assert.Equal(t, tc.expected, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestReceivePurchaseOrder(t *testing.T) {
+	router, handler, repo, eventBus := setupPurchasingTest(t)
+	itemA := domain.NewInventoryItem("SKU-A", "A", "", 5)
+	itemB := domain.NewInventoryItem("SKU-B", "B", "", 0)
+	require.NoError(t, repo.Save(context.Background(), itemA))
+	require.NoError(t, repo.Save(context.Background(), itemB))
+	cost := 4.0
+	order, err := domain.NewPurchaseOrder("PO-1", domain.NewSupplier("SUP-001", "Norte", "", "").ID, []domain.PurchaseOrderLine{
+		{ItemID: itemA.ID, SKU: "SKU-A", Ordered: 10, UnitCost: &cost},
+		{ItemID: itemB.ID, SKU: "SKU-B", Ordered: 2},
+	}, nil, "")
+	require.NoError(t, err)
+	require.NoError(t, handler.orders.Save(context.Background(), order))
+	path := "/api/v1/purchase-orders/" + order.ID.String()
+
+	// Over-receipt and items off the order are rejected before any stock moves
+	w := sendPurchasing(router, "POST", path+"/receive", map[string]interface{}{"lines": []map[string]interface{}{
+		{"item_id": itemA.ID.String(), "quantity": 4}, {"item_id": itemB.ID.String(), "quantity": 3},
+	}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = sendPurchasing(router, "POST", path+"/receive", map[string]interface{}{"lines": []map[string]interface{}{
+		{"item_id": domain.NewInventoryItem("SKU-X", "X", "", 0).ID.String(), "quantity": 1},
+	}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, 5, itemA.Quantity)
+	eventBus.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+
+	eventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.StockAdjustedEvent) bool {
+		return e.SKU == "SKU-A" && e.Quantity == 4 && e.NewTotal == 9 && e.Reason == events.ReasonPOReceipt &&
+			e.Reference == "PO-1" && e.UnitCost != nil && *e.UnitCost == 4.0
+	})).Return(nil).Once()
+	eventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.PurchaseOrderReceivedEvent) bool {
+		return e.Status == domain.PurchaseOrderPartiallyReceived && len(e.Lines) == 1 && e.Lines[0].Received == 4
+	})).Return(nil).Once()
+
+	w = sendPurchasing(router, "POST", path+"/receive", map[string]interface{}{"lines": []map[string]interface{}{
+		{"item_id": itemA.ID.String(), "quantity": 4},
+	}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var report PurchaseOrderReceiptReport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, 1, report.Received)
+	assert.Equal(t, "partially_received", report.PurchaseOrder.Status)
+	assert.Equal(t, 9, report.Lines[0].NewTotal)
+	saved, err := repo.FindByID(context.Background(), itemA.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 9, saved.Quantity)
+
+	// The rest closes the order
+	eventBus.On("Publish", mock.Anything, mock.AnythingOfType("events.StockAdjustedEvent")).Return(nil).Twice()
+	eventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.PurchaseOrderReceivedEvent) bool {
+		return e.Status == domain.PurchaseOrderReceived && len(e.Lines) == 2
+	})).Return(nil).Once()
+	w = sendPurchasing(router, "POST", path+"/receive", map[string]interface{}{"lines": []map[string]interface{}{
+		{"item_id": itemA.ID.String(), "quantity": 6}, {"item_id": itemB.ID.String(), "quantity": 2},
+	}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, domain.PurchaseOrderReceived, order.Status)
+	assert.Equal(t, 15, itemA.Quantity)
+	assert.Equal(t, 2, itemB.Quantity)
+	eventBus.AssertExpectations(t)
+
+	w = sendPurchasing(router, "POST", path+"/receive", map[string]interface{}{"lines": []map[string]interface{}{
+		{"item_id": itemA.ID.String(), "quantity": 1},
+	}})
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestReceivePurchaseOrder_DeletedItem(t *testing.T) {
+	router, handler, repo, eventBus := setupPurchasingTest(t)
+	item := domain.NewInventoryItem("SKU-A", "A", "", 0)
+	require.NoError(t, repo.Save(context.Background(), item))
+	order, err := domain.NewPurchaseOrder("PO-1", domain.NewSupplier("SUP-001", "Norte", "", "").ID, []domain.PurchaseOrderLine{
+		{ItemID: item.ID, SKU: "SKU-A", Ordered: 10},
+	}, nil, "")
+	require.NoError(t, err)
+	require.NoError(t, handler.orders.Save(context.Background(), order))
+	require.NoError(t, repo.Delete(context.Background(), item.ID))
+
+	w := sendPurchasing(router, "POST", "/api/v1/purchase-orders/"+order.ID.String()+"/receive", map[string]interface{}{"lines": []map[string]interface{}{
+		{"item_id": item.ID.String(), "quantity": 4},
+	}})
+	require.Equal(t, http.StatusOK, w.Code)
+	var report PurchaseOrderReceiptReport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, receiptNotFound, report.Lines[0].Status)
+
+	// The line stays pending
+	assert.Equal(t, domain.PurchaseOrderOpen, order.Status)
+	assert.Equal(t, 10, order.Lines[0].Remaining())
+	eventBus.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+}
+
+func TestCancelPurchaseOrder(t *testing.T) {
+	router, handler, _, eventBus := setupPurchasingTest(t)
+	order, err := domain.NewPurchaseOrder("PO-1", domain.NewSupplier("SUP-001", "Norte", "", "").ID, []domain.PurchaseOrderLine{
+		{ItemID: domain.NewInventoryItem("SKU-A", "A", "", 0).ID, Ordered: 10},
+	}, nil, "")
+	require.NoError(t, err)
+	require.NoError(t, handler.orders.Save(context.Background(), order))
+	eventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.PurchaseOrderCancelledEvent) bool {
+		return e.Number == "PO-1" && e.Status == domain.PurchaseOrderCancelled
+	})).Return(nil).Once()
+
+	w := sendPurchasing(router, "POST", "/api/v1/purchase-orders/"+order.ID.String()+"/cancel", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = sendPurchasing(router, "POST", "/api/v1/purchase-orders/"+order.ID.String()+"/cancel", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	eventBus.AssertExpectations(t)
+}
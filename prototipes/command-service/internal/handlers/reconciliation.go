@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"encoding/csv"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"command-service/internal/domain"
+	"command-service/internal/events"
+	"command-service/pkg/errors"
+	"command-service/pkg/validation"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxStockCountLines bounds the SKUs of one reconciliation, which is applied line by line
+const maxStockCountLines = 5000
+
+// Outcomes of a counted SKU in the reconciliation report
+const (
+	countAdjusted  = "adjusted"
+	countUnchanged = "unchanged"
+	countNotFound  = "not_found"
+	countDuplicate = "duplicate"
+	countRejected  = "rejected"
+	countConflict  = "conflict"
+	countFailed    = "failed"
+)
+
+// ReconcileStock handles POST /api/v1/inventory/reconciliation
+// @Summary      Reconcile stock with a physical count
+// @Description  Recibe las cantidades contadas por SKU (JSON o CSV), calcula la diferencia con el stock actual y ajusta cada item al conteo publicando un `StockAdjusted` con `reason: "stock_count"`. Responde con el reporte de las correcciones aplicadas.
+//
+// **Características:**
+// - JSON: `{"counts": [{"sku": "SKU-001", "counted": 42}]}`
+// - CSV (`Content-Type: text/csv`): fila de encabezado con las columnas `sku` y `counted` (las demás columnas se ignoran)
+// - Cada SKU se ajusta por separado: un SKU que falla no impide corregir los demás, y el reporte indica el resultado de cada uno
+// - El conteo es del stock total del item; no puede quedar por debajo del stock asignado a ubicaciones (`rejected`)
+// - Hasta 5000 SKUs por conteo
+//
+// **Ejemplos válidos:**
+// - `{"counts": [{"sku": "SKU-001", "counted": 42}, {"sku": "SKU-002", "counted": 0}]}`
+// - CSV: `sku,counted\nSKU-001,42\nSKU-002,0`
+//
+// **Ejemplos inválidos:**
+// - Lista vacía o sin `counts`
+// - `counted` negativo o faltante, o SKU vacío
+// - CSV sin las columnas `sku` y `counted`, o con cantidades no numéricas
+//
+// @Tags         inventory
+// @Accept       json,text/csv
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      StockCountRequest     true  "Counted quantities"
+// @Success      200      {object}  ReconciliationReport  "Reporte de la conciliación (incluye los SKUs que no se pudieron corregir)"
+// @Failure      400      {object}  ErrorResponse         "Request inválido - conteo vacío, cantidades inválidas o CSV mal formado"
+// @Failure      401      {object}  ErrorResponse         "No autorizado - token JWT inválido o faltante"
+// @Router       /inventory/reconciliation [post]
+func (h *InventoryHandler) ReconcileStock(c *gin.Context) {
+	var counts []StockCountLine
+	if c.ContentType() == "text/csv" {
+		parsed, err := parseStockCountCSV(c.Request.Body)
+		var tooLarge *http.MaxBytesError
+		if stderrors.As(err, &tooLarge) {
+			errors.Respond(c, errors.NewPayloadTooLarge(tooLarge.Limit))
+			return
+		}
+		if err != nil {
+			errors.Respond(c, errors.NewInvalidRequest(err.Error(), ""))
+			return
+		}
+		counts = parsed
+	} else {
+		var req StockCountRequest
+		if bindErr := validation.BindJSON(c, &req); bindErr != nil {
+			errors.Respond(c, bindErr)
+			return
+		}
+		counts = req.Counts
+	}
+	if len(counts) == 0 {
+		errors.Respond(c, errors.NewInvalidRequest("no stock counts given", ""))
+		return
+	}
+	if len(counts) > maxStockCountLines {
+		errors.Respond(c, errors.NewInvalidRequest(fmt.Sprintf("at most %d stock counts per reconciliation", maxStockCountLines), ""))
+		return
+	}
+
+	report := ReconciliationReport{Counted: len(counts), Lines: make([]ReconciliationLine, 0, len(counts))}
+	seen := make(map[string]bool, len(counts))
+	for _, count := range counts {
+		line := ReconciliationLine{SKU: count.SKU, Counted: *count.Counted}
+		if seen[count.SKU] {
+			line.Status = countDuplicate
+			line.Error = "sku counted more than once"
+		} else {
+			seen[count.SKU] = true
+			h.reconcileLine(c, &line)
+		}
+
+		switch line.Status {
+		case countAdjusted:
+			report.Adjusted++
+			report.NetDelta += line.Delta
+		case countUnchanged:
+			report.Unchanged++
+		default:
+			report.Failed++
+		}
+		report.Lines = append(report.Lines, line)
+	}
+
+	h.logger.Info("Stock reconciled",
+		zap.Int("counted", report.Counted),
+		zap.Int("adjusted", report.Adjusted),
+		zap.Int("failed", report.Failed),
+		zap.Int("net_delta", report.NetDelta),
+	)
+	c.JSON(http.StatusOK, report)
+}
+
+// reconcileLine adjusts the stock of one SKU to its counted quantity and publishes the
+// adjustment, recording the outcome in line
+func (h *InventoryHandler) reconcileLine(c *gin.Context, line *ReconciliationLine) {
+	ctx := c.Request.Context()
+	item, err := h.repository.FindBySKU(ctx, line.SKU)
+	if err != nil {
+		if err == domain.ErrItemNotFound {
+			line.Status = countNotFound
+			line.Error = "item not found"
+			return
+		}
+		h.logger.Error("Failed to find item", zap.String("sku", line.SKU), zap.Error(err))
+		line.Status = countFailed
+		line.Error = "failed to read item"
+		return
+	}
+	auditItemBefore(c, item)
+
+	previous := item.Quantity
+	line.ItemID = item.ID.String()
+	line.PreviousQuantity = &previous
+	line.Delta = line.Counted - previous
+	if line.Delta == 0 {
+		line.Status = countUnchanged
+		line.Version = item.Version
+		return
+	}
+
+	expected := item.Version
+	if err := item.AdjustStock(line.Delta); err != nil {
+		line.Status = countRejected
+		line.Error = "count is below the stock held at locations"
+		return
+	}
+	event := events.StockAdjustedEvent{
+		ItemID:          item.ID,
+		SKU:             item.SKU,
+		Quantity:        line.Delta,
+		NewTotal:        item.Quantity,
+		ExpectedVersion: expected,
+		Reason:          events.ReasonStockCount,
+		Actor:           requestActor(c),
+		OccurredAt:      item.UpdatedAt,
+	}
+	journalID, err := h.journal.Begin(item, false, event)
+	if err != nil {
+		h.logger.Error("Failed to write journal", zap.String("item_id", line.ItemID), zap.Error(err))
+		line.Status = countFailed
+		line.Error = "failed to adjust stock"
+		return
+	}
+
+	if err := h.repository.Save(ctx, item); err != nil {
+		h.journal.Aborted(journalID)
+		if err == domain.ErrVersionConflict {
+			line.Status = countConflict
+			line.Error = "item changed during the reconciliation, count it again"
+			return
+		}
+		h.logger.Error("Failed to save item", zap.String("item_id", line.ItemID), zap.Error(err))
+		line.Status = countFailed
+		line.Error = "failed to adjust stock"
+		return
+	}
+	auditItemAfter(c, item)
+
+	if err := h.publish(c, journalID, event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+	line.Status = countAdjusted
+	line.Version = item.Version
+}
+
+// parseStockCountCSV reads the counts of a CSV with a header row naming the sku and
+// counted columns
+func parseStockCountCSV(body io.Reader) ([]StockCountLine, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if stderrors.Is(err, io.EOF) {
+			return nil, stderrors.New("empty CSV")
+		}
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	skuColumn, countedColumn := -1, -1
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))) {
+		case "sku":
+			skuColumn = i
+		case "counted":
+			countedColumn = i
+		}
+	}
+	if skuColumn < 0 || countedColumn < 0 {
+		return nil, stderrors.New("CSV header must name the sku and counted columns")
+	}
+
+	counts := make([]StockCountLine, 0)
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if stderrors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		if skuColumn >= len(record) || countedColumn >= len(record) {
+			return nil, fmt.Errorf("row %d: missing sku or counted", row)
+		}
+		sku := strings.TrimSpace(record[skuColumn])
+		counted, err := strconv.Atoi(strings.TrimSpace(record[countedColumn]))
+		if sku == "" || err != nil || counted < 0 || counted > validation.MaxQuantity {
+			return nil, fmt.Errorf("row %d: sku is required and counted must be a whole number from 0 to %d", row, validation.MaxQuantity)
+		}
+		counts = append(counts, StockCountLine{SKU: sku, Counted: &counted})
+		if len(counts) > maxStockCountLines {
+			break
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"command-service/internal/domain"
+	"command-service/internal/events"
+	"command-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupReconciliationTest(t *testing.T) (*gin.Engine, repository.InventoryRepository, *MockEventPublisher) {
+	repo := repository.NewInventoryRepository()
+	eventBus := new(MockEventPublisher)
+	handler := &InventoryHandler{logger: zap.NewNop(), repository: repo, eventBus: eventBus}
+	router := setupTestRouter(handler)
+	router.POST("/api/v1/inventory/reconciliation", handler.ReconcileStock)
+	return router, repo, eventBus
+}
+
+func postReconciliation(router *gin.Engine, contentType, body string) (*httptest.ResponseRecorder, ReconciliationReport) {
+	req, _ := http.NewRequest("POST", "/api/v1/inventory/reconciliation", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var report ReconciliationReport
+	json.Unmarshal(w.Body.Bytes(), &report)
+	return w, report
+}
+
+func TestReconcileStock_JSON(t *testing.T) {
+	router, repo, eventBus := setupReconciliationTest(t)
+	short := domain.NewInventoryItem("SKU-SHORT", "Short", "", 45)
+	exact := domain.NewInventoryItem("SKU-EXACT", "Exact", "", 10)
+	require.NoError(t, repo.Save(context.Background(), short))
+	require.NoError(t, repo.Save(context.Background(), exact))
+	eventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.StockAdjustedEvent) bool {
+		return e.SKU == "SKU-SHORT" && e.Quantity == -3 && e.NewTotal == 42 && e.Reason == events.ReasonStockCount
+	})).Return(nil).Once()
+
+	w, report := postReconciliation(router, "application/json", `{"counts": [
+		{"sku": "SKU-SHORT", "counted": 42},
+		{"sku": "SKU-EXACT", "counted": 10},
+		{"sku": "SKU-MISSING", "counted": 1},
+		{"sku": "SKU-SHORT", "counted": 40}
+	]}`)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 4, report.Counted)
+	assert.Equal(t, 1, report.Adjusted)
+	assert.Equal(t, 1, report.Unchanged)
+	assert.Equal(t, 2, report.Failed)
+	assert.Equal(t, -3, report.NetDelta)
+	statuses := make([]string, 0, len(report.Lines))
+	for _, line := range report.Lines {
+		statuses = append(statuses, line.Status)
+	}
+	assert.Equal(t, []string{"adjusted", "unchanged", "not_found", "duplicate"}, statuses)
+	require.NotNil(t, report.Lines[0].PreviousQuantity)
+	assert.Equal(t, 45, *report.Lines[0].PreviousQuantity)
+	assert.Equal(t, 2, report.Lines[0].Version)
+
+	saved, err := repo.FindBySKU(context.Background(), "SKU-SHORT")
+	require.NoError(t, err)
+	assert.Equal(t, 42, saved.Quantity)
+	eventBus.AssertExpectations(t)
+}
+
+func TestReconcileStock_CSV(t *testing.T) {
+	router, repo, eventBus := setupReconciliationTest(t)
+	item := domain.NewInventoryItem("SKU-001", "Item", "", 5)
+	require.NoError(t, repo.Save(context.Background(), item))
+	eventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.StockAdjustedEvent) bool {
+		return e.Quantity == 7 && e.Reason == events.ReasonStockCount
+	})).Return(nil).Once()
+
+	w, report := postReconciliation(router, "text/csv", "location,SKU,Counted\nWH-1,SKU-001,12\n")
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, report.Adjusted)
+	assert.Equal(t, 7, report.Lines[0].Delta)
+	eventBus.AssertExpectations(t)
+}
+
+func TestReconcileStock_RejectsCountBelowLocatedStock(t *testing.T) {
+	router, repo, eventBus := setupReconciliationTest(t)
+	item := domain.NewInventoryItem("SKU-001", "Item", "", 10)
+	require.NoError(t, item.AdjustLocationStock("WH-1", 8))
+	require.NoError(t, repo.Save(context.Background(), item))
+
+	w, report := postReconciliation(router, "application/json", `{"counts": [{"sku": "SKU-001", "counted": 5}]}`)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "rejected", report.Lines[0].Status)
+	eventBus.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+}
+
+func TestReconcileStock_InvalidRequests(t *testing.T) {
+	router, _, _ := setupReconciliationTest(t)
+
+	for name, tc := range map[string]struct{ contentType, body string }{
+		"empty counts":     {"application/json", `{"counts": []}`},
+		"negative count":   {"application/json", `{"counts": [{"sku": "SKU-001", "counted": -1}]}`},
+		"missing count":    {"application/json", `{"counts": [{"sku": "SKU-001"}]}`},
+		"csv header":       {"text/csv", "sku,quantity\nSKU-001,4\n"},
+		"csv non numeric":  {"text/csv", "sku,counted\nSKU-001,four\n"},
+		"csv without rows": {"text/csv", "sku,counted\n"},
+		"too many counts":  {"text/csv", "sku,counted\n" + strings.Repeat("SKU-001,1\n", maxStockCountLines+1)},
+	} {
+		w, _ := postReconciliation(router, tc.contentType, tc.body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, name)
+	}
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"net/http"
+
+	"command-service/internal/domain"
+	"command-service/internal/events"
+	"command-service/pkg/errors"
+	"command-service/pkg/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// locationParam parses the optional ?location= of the stock operations. It returns ""
+// for the unlocated stock and writes a 400 response when the code is invalid.
+func locationParam(c *gin.Context) (string, bool) {
+	code, ok := c.GetQuery("location")
+	if !ok {
+		return "", true
+	}
+	location, err := domain.ParseLocation(code)
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest(err.Error(), ""))
+		return "", false
+	}
+	return location, true
+}
+
+// addLocation adds the stock at location to a stock response
+func addLocation(response gin.H, item *domain.InventoryItem, location string) {
+	if location == "" {
+		return
+	}
+	stock := item.Location(location)
+	response["location"] = location
+	response["location_quantity"] = stock.Quantity
+	response["location_reserved"] = stock.Reserved
+	response["location_available"] = stock.Available()
+}
+
+// AdjustLocationStock handles POST /api/v1/inventory/items/:id/locations/:loc/adjust
+// @Summary      Adjust stock at a location
+// @Description  Ajusta el stock de un item en una ubicación (almacén o tienda). El total del item cambia en la misma cantidad; la primera entrada crea la ubicación. El stock en una ubicación no puede quedar por debajo de lo reservado en ella. Publica un evento StockAdjusted con `location`.
+// @Description  El stock que no está asignado a ninguna ubicación (el de los items anteriores a esta función) sigue gestionándose con `POST /inventory/items/{id}/adjust`; reservas, liberaciones y ventas de una ubicación usan `?location=` en `reserve`, `release` y `commit`.
+//
+// **Ejemplos válidos:**
+// - Recepción en un almacén: `POST /inventory/items/{id}/locations/WH-MAD-01/adjust` con `{"quantity": 50, "unit_cost": 12.5}`
+// - Merma en una tienda: `POST /inventory/items/{id}/locations/store-12/adjust` con `{"quantity": -2}`
+// - Ajuste condicionado a la versión: `If-Match: "3"` o `{"quantity": -5, "version": 3}`
+//
+// **Ejemplos inválidos:**
+// - Código de ubicación con espacios, `/` o más de 64 caracteres
+// - Retirar más de lo disponible en la ubicación, o retirar de una ubicación sin stock
+// - `unit_cost` negativo o enviado junto con un ajuste negativo
+// - ID inválido o item no encontrado
+//
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        If-Match header    string                 false "Versión esperada del item (ETag), p. ej. \"3\""
+// @Param        id       path      string                 true  "Item ID (UUID)" example(550e8400-e29b-41d4-a716-446655440000)
+// @Param        loc      path      string                 true  "Código de ubicación" example(WH-MAD-01)
+// @Param        request  body      AdjustStockRequest     true  "Stock adjustment request"
+// @Success      200      {object}  LocationStockResponse  "Stock ajustado en la ubicación"
+// @Failure      400      {object}  ErrorResponse          "Request inválido - ID o ubicación inválidos, cantidad faltante o stock insuficiente en la ubicación"
+// @Failure      401      {object}  ErrorResponse          "No autorizado - token JWT inválido o faltante"
+// @Failure      404      {object}  ErrorResponse          "Item no encontrado"
+// @Failure      409      {object}  VersionConflictResponse  "Conflicto - el item cambió desde la versión esperada"
+// @Failure      500      {object}  ErrorResponse          "Error interno del servidor - error de persistencia o conexión a base de datos"
+// @Router       /inventory/items/{id}/locations/{loc}/adjust [post]
+func (h *InventoryHandler) AdjustLocationStock(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid item id", ""))
+		return
+	}
+	location, err := domain.ParseLocation(c.Param("loc"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest(err.Error(), ""))
+		return
+	}
+
+	var req struct {
+		Quantity int      `json:"quantity" binding:"required,quantity"`
+		UnitCost *float64 `json:"unit_cost" binding:"omitempty,min=0"`
+		Version  *int     `json:"version" binding:"omitempty,min=1"`
+		StockNote
+	}
+	if bindErr := validation.BindJSON(c, &req); bindErr != nil {
+		errors.Respond(c, bindErr)
+		return
+	}
+	if !checkStockNote(c, req.StockNote) {
+		return
+	}
+	if req.UnitCost != nil && req.Quantity < 0 {
+		errors.Respond(c, errors.NewInvalidRequest("unit_cost is only allowed for positive adjustments", ""))
+		return
+	}
+
+	item, err := h.repository.FindByID(c.Request.Context(), id)
+	if err != nil {
+		if err == domain.ErrItemNotFound {
+			errors.Respond(c, errors.NewItemNotFound(id.String()))
+			return
+		}
+		h.logger.Error("Failed to find item", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to adjust stock", nil))
+		return
+	}
+	auditItemBefore(c, item)
+	if !h.checkVersion(c, item, req.Version) {
+		return
+	}
+	expected := item.Version
+
+	if err := item.AdjustLocationStock(location, req.Quantity); err != nil {
+		errors.Respond(c, domainError(err))
+		return
+	}
+	event := events.StockAdjustedEvent{
+		ItemID:          item.ID,
+		SKU:             item.SKU,
+		Quantity:        req.Quantity,
+		NewTotal:        item.Quantity,
+		UnitCost:        req.UnitCost,
+		ExpectedVersion: expected,
+		Location:        location,
+		Reason:          req.Reason,
+		Reference:       req.Reference,
+		Actor:           requestActor(c),
+		OccurredAt:      item.UpdatedAt,
+	}
+	journalID, ok := h.beginWrite(c, item, false, event, "failed to adjust stock")
+	if !ok {
+		return
+	}
+
+	if err := h.repository.Save(c.Request.Context(), item); err != nil {
+		h.journal.Aborted(journalID)
+		if err == domain.ErrVersionConflict {
+			h.respondVersionConflict(c, item.ID)
+			return
+		}
+		h.logger.Error("Failed to save item", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to adjust stock", nil))
+		return
+	}
+	auditItemAfter(c, item)
+
+	if err := h.publish(c, journalID, event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+
+	response := gin.H{
+		"id":         item.ID,
+		"quantity":   item.Quantity,
+		"available":  item.AvailableQuantity(),
+		"reserved":   item.Reserved,
+		"version":    item.Version,
+		"updated_at": item.UpdatedAt,
+	}
+	addLocation(response, item, location)
+	addStockNote(response, req.StockNote)
+	setETag(c, item)
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"command-service/internal/domain"
+	"command-service/internal/events"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupLocationTestRouter(repo *MockInventoryRepository, eventBus *MockEventPublisher) *gin.Engine {
+	handler := &InventoryHandler{logger: zap.NewNop(), repository: repo, eventBus: eventBus}
+	router := setupTestRouter(handler)
+	router.POST("/api/v1/inventory/items/:id/locations/:loc/adjust", handler.AdjustLocationStock)
+	return router
+}
+
+func postStock(router *gin.Engine, path string, body map[string]interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+	payload, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", path, bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return w, response
+}
+
+func TestAdjustLocationStock_Success(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	router := setupLocationTestRouter(mockRepo, mockEventBus)
+
+	item := domain.NewInventoryItem("TEST-001", "Test Item", "", 10)
+	mockRepo.On("FindByID", mock.Anything, item.ID).Return(item, nil)
+	mockRepo.On("Save", mock.Anything, item).Return(nil)
+	mockEventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.StockAdjustedEvent) bool {
+		return e.Location == "WH-1" && e.Quantity == 30 && e.NewTotal == 40 && e.ExpectedVersion == 1
+	})).Return(nil)
+
+	w, response := postStock(router, "/api/v1/inventory/items/"+item.ID.String()+"/locations/WH-1/adjust",
+		map[string]interface{}{"quantity": 30})
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(40), response["quantity"])
+	assert.Equal(t, "WH-1", response["location"])
+	assert.Equal(t, float64(30), response["location_quantity"])
+	assert.Equal(t, float64(30), response["location_available"])
+	mockEventBus.AssertExpectations(t)
+}
+
+func TestAdjustLocationStock_InvalidLocationOrInsufficientStock(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	router := setupLocationTestRouter(mockRepo, mockEventBus)
+
+	item := domain.NewInventoryItem("TEST-001", "Test Item", "", 10)
+	mockRepo.On("FindByID", mock.Anything, item.ID).Return(item, nil)
+
+	w, _ := postStock(router, "/api/v1/inventory/items/"+item.ID.String()+"/locations/WH%201/adjust",
+		map[string]interface{}{"quantity": 5})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// The 10 units of the item are not at WH-1
+	w, _ = postStock(router, "/api/v1/inventory/items/"+item.ID.String()+"/locations/WH-1/adjust",
+		map[string]interface{}{"quantity": -5})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+	mockEventBus.AssertNotCalled(t, "Publish")
+}
+
+func TestReserveReleaseCommit_AtLocation(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	router := setupLocationTestRouter(mockRepo, mockEventBus)
+
+	item := domain.NewInventoryItem("TEST-001", "Test Item", "", 100)
+	require.NoError(t, item.AdjustLocationStock("store-12", 8))
+	mockRepo.On("FindByID", mock.Anything, item.ID).Return(item, nil)
+	mockRepo.On("Save", mock.Anything, item).Return(nil)
+	mockEventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.StockReservedEvent) bool {
+		return e.Location == "store-12" && e.Quantity == 6
+	})).Return(nil)
+	mockEventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.StockReleasedEvent) bool {
+		return e.Location == "store-12" && e.Quantity == 2
+	})).Return(nil)
+	mockEventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.StockCommittedEvent) bool {
+		return e.Location == "store-12" && e.Quantity == 3 && e.NewTotal == 105
+	})).Return(nil)
+	base := "/api/v1/inventory/items/" + item.ID.String()
+
+	// Only 8 units are at the store, even if the item has 108
+	w, _ := postStock(router, base+"/reserve?location=store-12", map[string]interface{}{"quantity": 9})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, response := postStock(router, base+"/reserve?location=store-12", map[string]interface{}{"quantity": 6})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(6), response["location_reserved"])
+	assert.Equal(t, float64(2), response["location_available"])
+
+	w, _ = postStock(router, base+"/release?location=store-12", map[string]interface{}{"quantity": 2})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w, response = postStock(router, base+"/commit?location=store-12", map[string]interface{}{"quantity": 3})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(105), response["quantity"])
+	assert.Equal(t, float64(5), response["location_quantity"])
+	assert.Equal(t, float64(1), response["location_reserved"])
+
+	mockEventBus.AssertExpectations(t)
+}
+
+func TestReserveStock_LocationWithStoreOrWaitlist(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	router := setupLocationTestRouter(mockRepo, mockEventBus)
+	base := "/api/v1/inventory/items/" + uuid.New().String()
+
+	w, _ := postStock(router, base+"/reserve?location=WH-1", map[string]interface{}{"quantity": 1, "waitlist": true})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w, _ = postStock(router, base+"/reserve?location=WH-1&store_id="+uuid.New().String(), map[string]interface{}{"quantity": 1})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w, _ = postStock(router, base+"/release?location=bad/code", map[string]interface{}{"quantity": 1})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockRepo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"regexp"
+
+	"command-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reasonCodePattern is the shape of a reason code, so reasons can be grouped in reports
+var reasonCodePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// checkStockNote responds with 400 and returns false when the reason is not a reason code
+func checkStockNote(c *gin.Context, note StockNote) bool {
+	if note.Reason != "" && !reasonCodePattern.MatchString(note.Reason) {
+		errors.Respond(c, errors.NewInvalidRequest("reason must be a code of lowercase letters, digits and underscores", ""))
+		return false
+	}
+	return true
+}
+
+// requestActor is the user that issued the request, as set by the auth middleware
+func requestActor(c *gin.Context) string {
+	return c.GetString("username")
+}
+
+// addStockNote echoes the reason and reference of a stock operation in its response
+func addStockNote(response gin.H, note StockNote) {
+	if note.Reason != "" {
+		response["reason"] = note.Reason
+	}
+	if note.Reference != "" {
+		response["reference"] = note.Reference
+	}
+}
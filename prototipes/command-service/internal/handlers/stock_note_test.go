@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"command-service/internal/domain"
+	"command-service/internal/events"
+	"command-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupStockNoteTest(t *testing.T) (*gin.Engine, *domain.InventoryItem, *MockEventPublisher) {
+	repo := repository.NewInventoryRepository()
+	eventBus := new(MockEventPublisher)
+	handler := &InventoryHandler{logger: zap.NewNop(), repository: repo, eventBus: eventBus}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("username", "operator")
+		c.Next()
+	})
+	router.POST("/items/:id/adjust", handler.AdjustStock)
+	router.POST("/items/:id/reserve", handler.ReserveStock)
+	router.POST("/items/:id/commit", handler.CommitStock)
+
+	item := domain.NewInventoryItem("SKU-001", "Item", "", 20)
+	require.NoError(t, repo.Save(context.Background(), item))
+	return router, item, eventBus
+}
+
+func postStockNote(router *gin.Engine, path, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return w, response
+}
+
+func TestStockNote_FlowsIntoEvents(t *testing.T) {
+	router, item, eventBus := setupStockNoteTest(t)
+	eventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.StockAdjustedEvent) bool {
+		return e.Reason == "damaged" && e.Reference == "RMA-7" && e.Actor == "operator"
+	})).Return(nil).Once()
+	eventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.StockReservedEvent) bool {
+		return e.Reason == "order" && e.Reference == "ORD-1" && e.Actor == "operator"
+	})).Return(nil).Once()
+	eventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.StockCommittedEvent) bool {
+		// Reason and reference are optional; the actor is always captured
+		return e.Reason == "" && e.Reference == "" && e.Actor == "operator"
+	})).Return(nil).Once()
+
+	w, response := postStockNote(router, "/items/"+item.ID.String()+"/adjust", `{"quantity": -2, "reason": "damaged", "reference": "RMA-7"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "damaged", response["reason"])
+	assert.Equal(t, "RMA-7", response["reference"])
+
+	w, response = postStockNote(router, "/items/"+item.ID.String()+"/reserve", `{"quantity": 3, "reason": "order", "reference": "ORD-1"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ORD-1", response["reference"])
+
+	w, response = postStockNote(router, "/items/"+item.ID.String()+"/commit", `{"quantity": 3}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, response, "reason")
+	eventBus.AssertExpectations(t)
+}
+
+func TestStockNote_InvalidNotes(t *testing.T) {
+	router, item, eventBus := setupStockNoteTest(t)
+
+	for name, body := range map[string]string{
+		"free text reason":   `{"quantity": 1, "reason": "Broken in transit"}`,
+		"uppercase reason":   `{"quantity": 1, "reason": "DAMAGED"}`,
+		"too long reason":    `{"quantity": 1, "reason": "` + strings.Repeat("a", 65) + `"}`,
+		"too long reference": `{"quantity": 1, "reference": "` + strings.Repeat("r", 129) + `"}`,
+	} {
+		w, _ := postStockNote(router, "/items/"+item.ID.String()+"/adjust", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, name)
+	}
+	eventBus.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+}
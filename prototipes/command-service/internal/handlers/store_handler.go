@@ -0,0 +1,430 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"command-service/internal/commands"
+	"command-service/internal/domain"
+	"command-service/internal/events"
+	"command-service/internal/repository"
+	"command-service/pkg/errors"
+	"command-service/pkg/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type StoreHandler struct {
+	logger     *zap.Logger
+	repository repository.StoreRepository
+	eventBus   events.EventPublisher
+}
+
+// NewStoreHandler creates a store handler. The repository and event bus are
+// shared with the inventory handler so store-scoped reservations see the same stores.
+func NewStoreHandler(logger *zap.Logger, repo repository.StoreRepository, eventBus events.EventPublisher) *StoreHandler {
+	return &StoreHandler{
+		logger:     logger,
+		repository: repo,
+		eventBus:   eventBus,
+	}
+}
+
+// CreateStore handles POST /api/v1/stores
+// @Summary      Create a new store
+// @Description  Crea una nueva tienda física. El código de la tienda debe ser único. La tienda se crea activa.
+//
+// **Ejemplos válidos:**
+// - `{"code": "STORE-001", "name": "Tienda Centro", "location": "Av. Principal 123"}`
+// - Request sin ubicación (campo opcional)
+//
+// **Ejemplos inválidos:**
+// - Campos requeridos faltantes (code, name)
+// - Código de tienda duplicado
+//
+// @Tags         stores
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        X-Request-ID  header    string              false  "Request ID for idempotency (UUID). If not provided, a new one will be generated."
+// @Param        request       body      CreateStoreRequest  true   "Store creation request"
+// @Success      201           {object}  StoreResponse       "Tienda creada exitosamente"
+// @Failure      400           {object}  ErrorResponse       "Request inválido - campos requeridos faltantes"
+// @Failure      401           {object}  ErrorResponse       "No autorizado - token JWT inválido o faltante"
+// @Failure      409           {object}  ErrorResponse       "Conflicto - código de tienda duplicado"
+// @Failure      500           {object}  ErrorResponse       "Error interno del servidor"
+// @Router       /stores [post]
+func (h *StoreHandler) CreateStore(c *gin.Context) {
+	var req struct {
+		Code     string `json:"code" binding:"required"`
+		Name     string `json:"name" binding:"required"`
+		Location string `json:"location"`
+	}
+
+	if bindErr := validation.BindJSON(c, &req); bindErr != nil {
+		h.logger.Warn("Invalid request", zap.Error(bindErr))
+		errors.Respond(c, bindErr)
+		return
+	}
+
+	cmd := commands.CreateStoreCommand{
+		Code:     req.Code,
+		Name:     req.Name,
+		Location: req.Location,
+	}
+
+	// Store codes are unique per tenant
+	if _, err := h.repository.FindByCode(c.Request.Context(), cmd.Code); err == nil {
+		errors.Respond(c, errors.NewConflict(domain.ErrDuplicateStoreCode.Error(), ""))
+		return
+	} else if err != domain.ErrStoreNotFound {
+		h.logger.Error("Failed to check store code", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to create store", nil))
+		return
+	}
+
+	store := domain.NewStore(cmd.Code, cmd.Name, cmd.Location)
+
+	if err := h.repository.Save(c.Request.Context(), store); err != nil {
+		h.logger.Error("Failed to save store", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to create store", nil))
+		return
+	}
+	auditStoreAfter(c, store)
+
+	event := events.StoreCreatedEvent{
+		StoreID:    store.ID,
+		Code:       store.Code,
+		Name:       store.Name,
+		Location:   store.Location,
+		Active:     store.Active,
+		OccurredAt: store.CreatedAt,
+	}
+	if err := h.eventBus.Publish(c.Request.Context(), event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+
+	h.logger.Info("Store created", zap.String("store_id", store.ID.String()), zap.String("code", store.Code))
+	c.JSON(http.StatusCreated, storeResponse(store))
+}
+
+// UpdateStore handles PUT /api/v1/stores/:id
+// @Summary      Update a store
+// @Description  Actualiza nombre, ubicación y estado (activa/inactiva) de una tienda. El código no se puede modificar.
+//
+// **Ejemplos válidos:**
+// - `{"name": "Tienda Centro", "location": "Av. Principal 123", "active": true}`
+// - Desactivar tienda: `{"name": "Tienda Centro", "active": false}`
+//
+// **Ejemplos inválidos:**
+// - Nombre faltante
+// - ID inválido o tienda no encontrada
+//
+// @Tags         stores
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string              true  "Store ID (UUID)"
+// @Param        request  body      UpdateStoreRequest  true  "Store update request"
+// @Success      200      {object}  StoreResponse       "Tienda actualizada exitosamente"
+// @Failure      400      {object}  ErrorResponse       "Request inválido"
+// @Failure      401      {object}  ErrorResponse       "No autorizado - token JWT inválido o faltante"
+// @Failure      404      {object}  ErrorResponse       "Tienda no encontrada"
+// @Failure      500      {object}  ErrorResponse       "Error interno del servidor"
+// @Router       /stores/{id} [put]
+func (h *StoreHandler) UpdateStore(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid store id", ""))
+		return
+	}
+
+	var req struct {
+		Name     string `json:"name" binding:"required"`
+		Location string `json:"location"`
+		Active   *bool  `json:"active"`
+	}
+
+	if bindErr := validation.BindJSON(c, &req); bindErr != nil {
+		errors.Respond(c, bindErr)
+		return
+	}
+
+	store, err := h.repository.FindByID(c.Request.Context(), id)
+	if err != nil {
+		if err == domain.ErrStoreNotFound {
+			errors.Respond(c, errors.NewNotFound("store not found", "Store ID: "+id.String()))
+			return
+		}
+		h.logger.Error("Failed to find store", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to update store", nil))
+		return
+	}
+	auditStoreBefore(c, store)
+
+	cmd := commands.UpdateStoreCommand{
+		ID:       id,
+		Name:     req.Name,
+		Location: req.Location,
+		Active:   store.Active,
+	}
+	if req.Active != nil {
+		cmd.Active = *req.Active
+	}
+
+	store.Update(cmd.Name, cmd.Location, cmd.Active)
+
+	if err := h.repository.Save(c.Request.Context(), store); err != nil {
+		h.logger.Error("Failed to save store", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to update store", nil))
+		return
+	}
+	auditStoreAfter(c, store)
+
+	event := events.StoreUpdatedEvent{
+		StoreID:    store.ID,
+		Code:       store.Code,
+		Name:       store.Name,
+		Location:   store.Location,
+		Active:     store.Active,
+		OccurredAt: store.UpdatedAt,
+	}
+	if err := h.eventBus.Publish(c.Request.Context(), event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+
+	c.JSON(http.StatusOK, storeResponse(store))
+}
+
+// DeleteStore handles DELETE /api/v1/stores/:id
+// @Summary      Delete a store
+// @Description  Elimina una tienda. No se puede eliminar una tienda con reservas activas; libérelas primero.
+//
+// **Ejemplos válidos:**
+// - DELETE con ID válido de una tienda sin reservas activas
+//
+// **Ejemplos inválidos:**
+// - ID inválido o tienda no encontrada
+// - Tienda con reservas activas
+//
+// @Tags         stores
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Store ID (UUID)"
+// @Success      200  {object}  SuccessResponse  "Tienda eliminada exitosamente"
+// @Failure      400  {object}  ErrorResponse    "ID inválido"
+// @Failure      401  {object}  ErrorResponse    "No autorizado - token JWT inválido o faltante"
+// @Failure      403  {object}  ErrorResponse    "Prohibido - el rol del token no tiene permiso inventory:delete"
+// @Failure      404  {object}  ErrorResponse    "Tienda no encontrada"
+// @Failure      409  {object}  ErrorResponse    "La tienda tiene reservas activas"
+// @Failure      500  {object}  ErrorResponse    "Error interno del servidor"
+// @Router       /stores/{id} [delete]
+func (h *StoreHandler) DeleteStore(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid store id", ""))
+		return
+	}
+
+	store, err := h.repository.FindByID(c.Request.Context(), id)
+	if err != nil {
+		if err == domain.ErrStoreNotFound {
+			errors.Respond(c, errors.NewNotFound("store not found", "Store ID: "+id.String()))
+			return
+		}
+		h.logger.Error("Failed to find store", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to delete store", nil))
+		return
+	}
+	auditStoreBefore(c, store)
+
+	if store.HasActiveReservations() {
+		errors.Respond(c, errors.NewConflict(domain.ErrStoreHasReservations.Error(), ""))
+		return
+	}
+
+	if err := h.repository.Delete(c.Request.Context(), id); err != nil {
+		h.logger.Error("Failed to delete store", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to delete store", nil))
+		return
+	}
+	auditStoreRemoved(c, id)
+
+	event := events.StoreDeletedEvent{
+		StoreID:    store.ID,
+		Code:       store.Code,
+		OccurredAt: store.UpdatedAt,
+	}
+	if err := h.eventBus.Publish(c.Request.Context(), event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "store deleted successfully"})
+}
+
+// SetStoreCalendar handles PUT /api/v1/stores/:id/calendar
+// @Summary      Set the opening hours of a store
+// @Description  Reemplaza el horario de apertura y los feriados de una tienda. Los horarios son en la hora local de la tienda (`timezone`, nombre IANA); un día puede tener varios tramos y un día sin tramos es un día cerrado. Los feriados cierran la tienda todo el día.
+//
+// Con `ENFORCE_STORE_HOURS=true` las reservas para la tienda (`?store_id=`) se rechazan fuera de horario.
+//
+// **Ejemplos válidos:**
+// - `{"timezone": "America/Bogota", "hours": [{"day": "monday", "opens": "09:00", "closes": "18:00"}], "holidays": [{"date": "2024-12-25", "name": "Navidad"}]}`
+// - Cierre al mediodía: dos tramos para el mismo día (`09:00`-`13:00` y `14:00`-`19:00`)
+//
+// **Ejemplos inválidos:**
+// - Zona horaria desconocida o faltante
+// - `closes` anterior a `opens`, tramos superpuestos o día desconocido
+// - Fecha de feriado que no es `YYYY-MM-DD`
+//
+// @Tags         stores
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                    true  "Store ID (UUID)"
+// @Param        request  body      SetStoreCalendarRequest   true  "Store calendar"
+// @Success      200      {object}  StoreResponse             "Horario actualizado"
+// @Failure      400      {object}  ErrorResponse             "Calendario inválido"
+// @Failure      401      {object}  ErrorResponse             "No autorizado - token JWT inválido o faltante"
+// @Failure      404      {object}  ErrorResponse             "Tienda no encontrada"
+// @Failure      500      {object}  ErrorResponse             "Error interno del servidor"
+// @Router       /stores/{id}/calendar [put]
+func (h *StoreHandler) SetStoreCalendar(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid store id", ""))
+		return
+	}
+
+	var req SetStoreCalendarRequest
+	if bindErr := validation.BindJSON(c, &req); bindErr != nil {
+		errors.Respond(c, bindErr)
+		return
+	}
+	calendar, err := req.toDomain()
+	if err == nil {
+		err = calendar.Validate()
+	}
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest(err.Error(), ""))
+		return
+	}
+
+	h.saveCalendar(c, id, calendar)
+}
+
+// DeleteStoreCalendar handles DELETE /api/v1/stores/:id/calendar
+// @Summary      Remove the opening hours of a store
+// @Description  Elimina el horario de una tienda: sin calendario la tienda se considera siempre abierta.
+// @Tags         stores
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Store ID (UUID)"
+// @Success      200  {object}  StoreResponse  "Horario eliminado"
+// @Failure      400  {object}  ErrorResponse  "ID inválido"
+// @Failure      401  {object}  ErrorResponse  "No autorizado - token JWT inválido o faltante"
+// @Failure      404  {object}  ErrorResponse  "Tienda no encontrada"
+// @Failure      500  {object}  ErrorResponse  "Error interno del servidor"
+// @Router       /stores/{id}/calendar [delete]
+func (h *StoreHandler) DeleteStoreCalendar(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid store id", ""))
+		return
+	}
+	h.saveCalendar(c, id, nil)
+}
+
+// saveCalendar sets (or, when nil, removes) the calendar of a store and publishes it
+func (h *StoreHandler) saveCalendar(c *gin.Context, id uuid.UUID, calendar *domain.StoreCalendar) {
+	store, err := h.repository.FindByID(c.Request.Context(), id)
+	if err != nil {
+		if err == domain.ErrStoreNotFound {
+			errors.Respond(c, errors.NewNotFound("store not found", "Store ID: "+id.String()))
+			return
+		}
+		h.logger.Error("Failed to find store", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to update store calendar", nil))
+		return
+	}
+	auditStoreBefore(c, store)
+
+	store.SetCalendar(calendar)
+
+	if err := h.repository.Save(c.Request.Context(), store); err != nil {
+		h.logger.Error("Failed to save store", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to update store calendar", nil))
+		return
+	}
+	auditStoreAfter(c, store)
+
+	event := events.StoreCalendarUpdatedEvent{
+		StoreID:    store.ID,
+		Code:       store.Code,
+		Calendar:   calendarEvent(calendar),
+		OccurredAt: store.UpdatedAt,
+	}
+	if err := h.eventBus.Publish(c.Request.Context(), event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+
+	h.logger.Info("Store calendar updated", zap.String("store_id", store.ID.String()), zap.Bool("removed", calendar == nil))
+	c.JSON(http.StatusOK, storeResponse(store))
+}
+
+// toDomain converts the request to a domain calendar (not yet validated)
+func (r SetStoreCalendarRequest) toDomain() (*domain.StoreCalendar, error) {
+	calendar := &domain.StoreCalendar{Timezone: r.Timezone}
+	for _, hours := range r.Hours {
+		day, err := domain.ParseWeekday(hours.Day)
+		if err != nil {
+			return nil, err
+		}
+		calendar.Hours = append(calendar.Hours, domain.OpeningHours{Day: day, Opens: hours.Opens, Closes: hours.Closes})
+	}
+	for _, holiday := range r.Holidays {
+		calendar.Holidays = append(calendar.Holidays, domain.Holiday{Date: holiday.Date, Name: holiday.Name})
+	}
+	return calendar, nil
+}
+
+// calendarEvent converts a calendar to its event payload (nil stays nil)
+func calendarEvent(calendar *domain.StoreCalendar) *events.StoreCalendar {
+	if calendar == nil {
+		return nil
+	}
+	payload := &events.StoreCalendar{
+		Timezone: calendar.Timezone,
+		Hours:    make([]events.OpeningHours, 0, len(calendar.Hours)),
+		Holidays: make([]events.Holiday, 0, len(calendar.Holidays)),
+	}
+	for _, hours := range calendar.Hours {
+		payload.Hours = append(payload.Hours, events.OpeningHours{
+			Day:    strings.ToLower(hours.Day.String()),
+			Opens:  hours.Opens,
+			Closes: hours.Closes,
+		})
+	}
+	for _, holiday := range calendar.Holidays {
+		payload.Holidays = append(payload.Holidays, events.Holiday{Date: holiday.Date, Name: holiday.Name})
+	}
+	return payload
+}
+
+func storeResponse(store *domain.Store) gin.H {
+	response := gin.H{
+		"id":         store.ID,
+		"code":       store.Code,
+		"name":       store.Name,
+		"location":   store.Location,
+		"active":     store.Active,
+		"created_at": store.CreatedAt,
+		"updated_at": store.UpdatedAt,
+	}
+	if store.Calendar != nil {
+		response["calendar"] = calendarEvent(store.Calendar)
+	}
+	return response
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"command-service/internal/domain"
+	"command-service/internal/events"
+	"command-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupStoreTestRouter(storeHandler *StoreHandler, inventoryHandler *InventoryHandler) *gin.Engine {
+	router := setupTestRouter(inventoryHandler)
+
+	stores := router.Group("/api/v1/stores")
+	{
+		stores.POST("", storeHandler.CreateStore)
+		stores.PUT("/:id", storeHandler.UpdateStore)
+		stores.DELETE("/:id", storeHandler.DeleteStore)
+	}
+
+	return router
+}
+
+func newStoreTestHandlers(mockRepo *MockInventoryRepository, mockEventBus *MockEventPublisher) (*StoreHandler, *InventoryHandler, repository.StoreRepository) {
+	logger := zap.NewNop()
+	stores := repository.NewStoreRepository()
+	inventoryHandler := &InventoryHandler{
+		logger:     logger,
+		repository: mockRepo,
+		stores:     stores,
+		eventBus:   mockEventBus,
+	}
+	return NewStoreHandler(logger, stores, mockEventBus), inventoryHandler, stores
+}
+
+func TestCreateStore_Success(t *testing.T) {
+	mockEventBus := new(MockEventPublisher)
+	storeHandler, inventoryHandler, _ := newStoreTestHandlers(new(MockInventoryRepository), mockEventBus)
+	router := setupStoreTestRouter(storeHandler, inventoryHandler)
+
+	mockEventBus.On("Publish", mock.Anything, mock.AnythingOfType("events.StoreCreatedEvent")).Return(nil)
+
+	body, _ := json.Marshal(map[string]interface{}{"code": "STORE-001", "name": "Centro", "location": "Main St"})
+	req, _ := http.NewRequest("POST", "/api/v1/stores", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, "STORE-001", response["code"])
+	assert.Equal(t, true, response["active"])
+	mockEventBus.AssertExpectations(t)
+}
+
+func TestCreateStore_DuplicateCode(t *testing.T) {
+	mockEventBus := new(MockEventPublisher)
+	storeHandler, inventoryHandler, stores := newStoreTestHandlers(new(MockInventoryRepository), mockEventBus)
+	router := setupStoreTestRouter(storeHandler, inventoryHandler)
+	require.NoError(t, stores.Save(context.Background(), domain.NewStore("STORE-001", "Centro", "")))
+
+	body, _ := json.Marshal(map[string]interface{}{"code": "STORE-001", "name": "Otra"})
+	req, _ := http.NewRequest("POST", "/api/v1/stores", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockEventBus.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+}
+
+func TestReserveStock_StoreScoped(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	storeHandler, inventoryHandler, stores := newStoreTestHandlers(mockRepo, mockEventBus)
+	router := setupStoreTestRouter(storeHandler, inventoryHandler)
+
+	store := domain.NewStore("STORE-001", "Centro", "")
+	require.NoError(t, stores.Save(context.Background(), store))
+	item := domain.NewInventoryItem("SKU-001", "Item", "", 10)
+
+	mockRepo.On("FindByID", mock.Anything, item.ID).Return(item, nil)
+	mockRepo.On("Save", mock.Anything, item).Return(nil)
+	mockEventBus.On("Publish", mock.Anything, mock.AnythingOfType("events.StoreReservationCreatedEvent")).Return(nil)
+
+	body, _ := json.Marshal(map[string]interface{}{"quantity": 4})
+	req, _ := http.NewRequest("POST", "/api/v1/inventory/items/"+item.ID.String()+"/reserve?store_id="+store.ID.String(), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, store.ID.String(), response["store_id"])
+	assert.NotEmpty(t, response["reservation_id"])
+	assert.Equal(t, 4, store.ReservedQuantity(item.ID))
+	mockEventBus.AssertExpectations(t)
+
+	// A store with active reservations cannot be deleted
+	req, _ = http.NewRequest("DELETE", "/api/v1/stores/"+store.ID.String(), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestReleaseStock_StoreScoped_ExceedsStoreReservation(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	mockEventBus := new(MockEventPublisher)
+	storeHandler, inventoryHandler, stores := newStoreTestHandlers(mockRepo, mockEventBus)
+	router := setupStoreTestRouter(storeHandler, inventoryHandler)
+
+	store := domain.NewStore("STORE-001", "Centro", "")
+	require.NoError(t, stores.Save(context.Background(), store))
+	item := domain.NewInventoryItem("SKU-001", "Item", "", 10)
+	item.Reserved = 5 // reserved without store attribution
+
+	mockRepo.On("FindByID", mock.Anything, item.ID).Return(item, nil)
+
+	body, _ := json.Marshal(map[string]interface{}{"quantity": 2})
+	req, _ := http.NewRequest("POST", "/api/v1/inventory/items/"+item.ID.String()+"/release?store_id="+store.ID.String(), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, 5, item.Reserved)
+	mockEventBus.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+}
+
+func TestReserveStock_StoreNotFound(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	storeHandler, inventoryHandler, _ := newStoreTestHandlers(mockRepo, new(MockEventPublisher))
+	router := setupStoreTestRouter(storeHandler, inventoryHandler)
+
+	item := domain.NewInventoryItem("SKU-001", "Item", "", 10)
+	mockRepo.On("FindByID", mock.Anything, item.ID).Return(item, nil)
+
+	body, _ := json.Marshal(map[string]interface{}{"quantity": 1})
+	req, _ := http.NewRequest("POST", "/api/v1/inventory/items/"+item.ID.String()+"/reserve?store_id="+domain.NewStore("X", "X", "").ID.String(), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, 0, item.Reserved)
+}
+
+func TestSetStoreCalendar(t *testing.T) {
+	mockEventBus := new(MockEventPublisher)
+	storeHandler, inventoryHandler, stores := newStoreTestHandlers(new(MockInventoryRepository), mockEventBus)
+	router := setupStoreTestRouter(storeHandler, inventoryHandler)
+	router.PUT("/api/v1/stores/:id/calendar", storeHandler.SetStoreCalendar)
+	router.DELETE("/api/v1/stores/:id/calendar", storeHandler.DeleteStoreCalendar)
+
+	store := domain.NewStore("STORE-001", "Centro", "")
+	require.NoError(t, stores.Save(context.Background(), store))
+
+	mockEventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.StoreCalendarUpdatedEvent) bool {
+		return e.StoreID == store.ID && e.Calendar != nil && e.Calendar.Hours[0].Day == "monday"
+	})).Return(nil).Once()
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"timezone": "America/Bogota",
+		"hours":    []map[string]string{{"day": "Monday", "opens": "09:00", "closes": "18:00"}},
+		"holidays": []map[string]string{{"date": "2024-12-25", "name": "Navidad"}},
+	})
+	req, _ := http.NewRequest("PUT", "/api/v1/stores/"+store.ID.String()+"/calendar", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, store.Calendar)
+	assert.Equal(t, time.Monday, store.Calendar.Hours[0].Day)
+	assert.Contains(t, w.Body.String(), `"calendar"`)
+
+	// Removing the calendar publishes a null calendar
+	mockEventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.StoreCalendarUpdatedEvent) bool {
+		return e.StoreID == store.ID && e.Calendar == nil
+	})).Return(nil).Once()
+
+	req, _ = http.NewRequest("DELETE", "/api/v1/stores/"+store.ID.String()+"/calendar", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, store.Calendar)
+	mockEventBus.AssertExpectations(t)
+}
+
+func TestSetStoreCalendar_Invalid(t *testing.T) {
+	mockEventBus := new(MockEventPublisher)
+	storeHandler, inventoryHandler, stores := newStoreTestHandlers(new(MockInventoryRepository), mockEventBus)
+	router := setupStoreTestRouter(storeHandler, inventoryHandler)
+	router.PUT("/api/v1/stores/:id/calendar", storeHandler.SetStoreCalendar)
+
+	store := domain.NewStore("STORE-001", "Centro", "")
+	require.NoError(t, stores.Save(context.Background(), store))
+
+	for name, calendar := range map[string]map[string]interface{}{
+		"unknown timezone": {"timezone": "Mars/Olympus"},
+		"unknown day":      {"timezone": "UTC", "hours": []map[string]string{{"day": "funday", "opens": "09:00", "closes": "18:00"}}},
+		"closes first":     {"timezone": "UTC", "hours": []map[string]string{{"day": "monday", "opens": "18:00", "closes": "09:00"}}},
+		"bad holiday":      {"timezone": "UTC", "holidays": []map[string]string{{"date": "25/12/2024"}}},
+	} {
+		body, _ := json.Marshal(calendar)
+		req, _ := http.NewRequest("PUT", "/api/v1/stores/"+store.ID.String()+"/calendar", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, name)
+	}
+	assert.Nil(t, store.Calendar)
+	mockEventBus.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+}
+
+func TestReserveStock_StoreClosed(t *testing.T) {
+	mockRepo := new(MockInventoryRepository)
+	storeHandler, inventoryHandler, stores := newStoreTestHandlers(mockRepo, new(MockEventPublisher))
+	inventoryHandler.enforceStoreHours = true
+	router := setupStoreTestRouter(storeHandler, inventoryHandler)
+
+	// A calendar without opening periods: the store is never open
+	store := domain.NewStore("STORE-001", "Centro", "")
+	store.SetCalendar(&domain.StoreCalendar{Timezone: "UTC"})
+	require.NoError(t, stores.Save(context.Background(), store))
+	item := domain.NewInventoryItem("SKU-001", "Item", "", 10)
+	mockRepo.On("FindByID", mock.Anything, item.ID).Return(item, nil)
+
+	body, _ := json.Marshal(map[string]interface{}{"quantity": 1})
+	req, _ := http.NewRequest("POST", "/api/v1/inventory/items/"+item.ID.String()+"/reserve?store_id="+store.ID.String(), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), domain.ErrStoreClosed.Error())
+	assert.Equal(t, 0, item.Reserved)
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+
+	"command-service/internal/commands"
+	"command-service/internal/domain"
+	"command-service/internal/events"
+	"command-service/internal/repository"
+	"command-service/pkg/errors"
+	"command-service/pkg/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// PurchasingHandler handles suppliers and purchase orders. Received stock is adjusted
+// through the inventory handler's repository, journal and event bus, like any other
+// stock adjustment.
+type PurchasingHandler struct {
+	logger    *zap.Logger
+	suppliers repository.SupplierRepository
+	orders    repository.PurchaseOrderRepository
+	inventory *InventoryHandler
+
+	// mu serializes the purchasing commands: two receipts of the same order cannot
+	// over-receive a line, and a supplier cannot be deleted while an order is placed
+	mu sync.Mutex
+}
+
+// NewPurchasingHandler creates the purchasing handler on top of the inventory handler
+func NewPurchasingHandler(logger *zap.Logger, inventory *InventoryHandler) *PurchasingHandler {
+	return &PurchasingHandler{
+		logger:    logger,
+		suppliers: repository.NewSupplierRepository(),
+		orders:    repository.NewPurchaseOrderRepository(),
+		inventory: inventory,
+	}
+}
+
+// CreateSupplier handles POST /api/v1/suppliers
+// @Summary      Create a new supplier
+// @Description  Crea un proveedor al que se le emiten órdenes de compra. El código del proveedor debe ser único. El proveedor se crea activo.
+//
+// **Ejemplos válidos:**
+// - `{"code": "SUP-001", "name": "Distribuidora Norte", "email": "compras@norte.com", "phone": "+57 300 123 4567"}`
+// - Request sin email ni teléfono (campos opcionales)
+//
+// **Ejemplos inválidos:**
+// - Campos requeridos faltantes (code, name)
+// - Email mal formado
+// - Código de proveedor duplicado
+//
+// @Tags         purchasing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        X-Request-ID  header    string                 false  "Request ID for idempotency (UUID). If not provided, a new one will be generated."
+// @Param        request       body      CreateSupplierRequest  true   "Supplier creation request"
+// @Success      201           {object}  SupplierResponse       "Proveedor creado exitosamente"
+// @Failure      400           {object}  ErrorResponse          "Request inválido - campos requeridos faltantes o email inválido"
+// @Failure      401           {object}  ErrorResponse          "No autorizado - token JWT inválido o faltante"
+// @Failure      409           {object}  ErrorResponse          "Conflicto - código de proveedor duplicado"
+// @Failure      500           {object}  ErrorResponse          "Error interno del servidor"
+// @Router       /suppliers [post]
+func (h *PurchasingHandler) CreateSupplier(c *gin.Context) {
+	var req CreateSupplierRequest
+	if bindErr := validation.BindJSON(c, &req); bindErr != nil {
+		h.logger.Warn("Invalid request", zap.Error(bindErr))
+		errors.Respond(c, bindErr)
+		return
+	}
+
+	cmd := commands.CreateSupplierCommand{
+		Code:  req.Code,
+		Name:  req.Name,
+		Email: req.Email,
+		Phone: req.Phone,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Supplier codes are unique per tenant
+	if _, err := h.suppliers.FindByCode(c.Request.Context(), cmd.Code); err == nil {
+		errors.Respond(c, errors.NewConflict(domain.ErrDuplicateSupplierCode.Error(), ""))
+		return
+	} else if err != domain.ErrSupplierNotFound {
+		h.logger.Error("Failed to check supplier code", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to create supplier", nil))
+		return
+	}
+
+	supplier := domain.NewSupplier(cmd.Code, cmd.Name, cmd.Email, cmd.Phone)
+
+	if err := h.suppliers.Save(c.Request.Context(), supplier); err != nil {
+		h.logger.Error("Failed to save supplier", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to create supplier", nil))
+		return
+	}
+	auditSupplierAfter(c, supplier)
+
+	event := events.SupplierCreatedEvent{
+		SupplierID: supplier.ID,
+		Code:       supplier.Code,
+		Name:       supplier.Name,
+		Email:      supplier.Email,
+		Phone:      supplier.Phone,
+		Active:     supplier.Active,
+		OccurredAt: supplier.CreatedAt,
+	}
+	if err := h.inventory.eventBus.Publish(c.Request.Context(), event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+
+	h.logger.Info("Supplier created", zap.String("supplier_id", supplier.ID.String()), zap.String("code", supplier.Code))
+	c.JSON(http.StatusCreated, supplierResponse(supplier))
+}
+
+// UpdateSupplier handles PUT /api/v1/suppliers/:id
+// @Summary      Update a supplier
+// @Description  Actualiza nombre, datos de contacto y estado (activo/inactivo) de un proveedor. El código no se puede modificar. Un proveedor inactivo no recibe nuevas órdenes de compra; las órdenes abiertas se pueden seguir recibiendo.
+//
+// **Ejemplos válidos:**
+// - `{"name": "Distribuidora Norte", "email": "compras@norte.com", "active": true}`
+// - Desactivar proveedor: `{"name": "Distribuidora Norte", "active": false}`
+//
+// **Ejemplos inválidos:**
+// - Nombre faltante
+// - ID inválido o proveedor no encontrado
+//
+// @Tags         purchasing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                 true  "Supplier ID (UUID)"
+// @Param        request  body      UpdateSupplierRequest  true  "Supplier update request"
+// @Success      200      {object}  SupplierResponse       "Proveedor actualizado exitosamente"
+// @Failure      400      {object}  ErrorResponse          "Request inválido"
+// @Failure      401      {object}  ErrorResponse          "No autorizado - token JWT inválido o faltante"
+// @Failure      404      {object}  ErrorResponse          "Proveedor no encontrado"
+// @Failure      500      {object}  ErrorResponse          "Error interno del servidor"
+// @Router       /suppliers/{id} [put]
+func (h *PurchasingHandler) UpdateSupplier(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid supplier id", ""))
+		return
+	}
+
+	var req UpdateSupplierRequest
+	if bindErr := validation.BindJSON(c, &req); bindErr != nil {
+		errors.Respond(c, bindErr)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	supplier, ok := h.findSupplier(c, id, "failed to update supplier")
+	if !ok {
+		return
+	}
+	auditSupplierBefore(c, supplier)
+
+	cmd := commands.UpdateSupplierCommand{
+		ID:     id,
+		Name:   req.Name,
+		Email:  req.Email,
+		Phone:  req.Phone,
+		Active: supplier.Active,
+	}
+	if req.Active != nil {
+		cmd.Active = *req.Active
+	}
+
+	supplier.Update(cmd.Name, cmd.Email, cmd.Phone, cmd.Active)
+
+	if err := h.suppliers.Save(c.Request.Context(), supplier); err != nil {
+		h.logger.Error("Failed to save supplier", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to update supplier", nil))
+		return
+	}
+	auditSupplierAfter(c, supplier)
+
+	event := events.SupplierUpdatedEvent{
+		SupplierID: supplier.ID,
+		Code:       supplier.Code,
+		Name:       supplier.Name,
+		Email:      supplier.Email,
+		Phone:      supplier.Phone,
+		Active:     supplier.Active,
+		OccurredAt: supplier.UpdatedAt,
+	}
+	if err := h.inventory.eventBus.Publish(c.Request.Context(), event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+
+	c.JSON(http.StatusOK, supplierResponse(supplier))
+}
+
+// DeleteSupplier handles DELETE /api/v1/suppliers/:id
+// @Summary      Delete a supplier
+// @Description  Elimina un proveedor. No se puede eliminar un proveedor con órdenes de compra abiertas o parcialmente recibidas; recíbalas o cancélelas primero. Las órdenes cerradas conservan el ID del proveedor.
+//
+// **Ejemplos válidos:**
+// - DELETE con ID válido de un proveedor sin órdenes abiertas
+//
+// **Ejemplos inválidos:**
+// - ID inválido o proveedor no encontrado
+// - Proveedor con órdenes de compra abiertas
+//
+// @Tags         purchasing
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Supplier ID (UUID)"
+// @Success      200  {object}  SuccessResponse  "Proveedor eliminado exitosamente"
+// @Failure      400  {object}  ErrorResponse    "ID inválido"
+// @Failure      401  {object}  ErrorResponse    "No autorizado - token JWT inválido o faltante"
+// @Failure      403  {object}  ErrorResponse    "Prohibido - el rol del token no tiene permiso inventory:delete"
+// @Failure      404  {object}  ErrorResponse    "Proveedor no encontrado"
+// @Failure      409  {object}  ErrorResponse    "El proveedor tiene órdenes de compra abiertas"
+// @Failure      500  {object}  ErrorResponse    "Error interno del servidor"
+// @Router       /suppliers/{id} [delete]
+func (h *PurchasingHandler) DeleteSupplier(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest("invalid supplier id", ""))
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	supplier, ok := h.findSupplier(c, id, "failed to delete supplier")
+	if !ok {
+		return
+	}
+	auditSupplierBefore(c, supplier)
+
+	open, err := h.orders.HasOpenOrders(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to check purchase orders", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to delete supplier", nil))
+		return
+	}
+	if open {
+		errors.Respond(c, errors.NewConflict(domain.ErrSupplierHasOpenOrders.Error(), ""))
+		return
+	}
+
+	if err := h.suppliers.Delete(c.Request.Context(), id); err != nil {
+		h.logger.Error("Failed to delete supplier", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError("failed to delete supplier", nil))
+		return
+	}
+	auditSupplierRemoved(c, id)
+
+	event := events.SupplierDeletedEvent{
+		SupplierID: supplier.ID,
+		Code:       supplier.Code,
+		OccurredAt: supplier.UpdatedAt,
+	}
+	if err := h.inventory.eventBus.Publish(c.Request.Context(), event); err != nil {
+		h.logger.Error("Failed to publish event", zap.Error(err))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "supplier deleted successfully"})
+}
+
+// findSupplier loads a supplier, responding 404 (or 500 with failure) when it cannot
+func (h *PurchasingHandler) findSupplier(c *gin.Context, id uuid.UUID, failure string) (*domain.Supplier, bool) {
+	supplier, err := h.suppliers.FindByID(c.Request.Context(), id)
+	if err != nil {
+		if err == domain.ErrSupplierNotFound {
+			errors.Respond(c, errors.NewNotFound("supplier not found", "Supplier ID: "+id.String()))
+			return nil, false
+		}
+		h.logger.Error("Failed to find supplier", zap.Error(err))
+		errors.Respond(c, errors.NewInternalError(failure, nil))
+		return nil, false
+	}
+	return supplier, true
+}
+
+func supplierResponse(supplier *domain.Supplier) gin.H {
+	return gin.H{
+		"id":         supplier.ID,
+		"code":       supplier.Code,
+		"name":       supplier.Name,
+		"email":      supplier.Email,
+		"phone":      supplier.Phone,
+		"active":     supplier.Active,
+		"created_at": supplier.CreatedAt,
+		"updated_at": supplier.UpdatedAt,
+	}
+}
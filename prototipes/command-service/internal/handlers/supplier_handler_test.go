@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"command-service/internal/domain"
+	"command-service/internal/events"
+	"command-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupPurchasingTest(t *testing.T) (*gin.Engine, *PurchasingHandler, repository.InventoryRepository, *MockEventPublisher) {
+	repo := repository.NewInventoryRepository()
+	eventBus := new(MockEventPublisher)
+	handler := NewPurchasingHandler(zap.NewNop(), &InventoryHandler{logger: zap.NewNop(), repository: repo, eventBus: eventBus})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	suppliers := router.Group("/api/v1/suppliers")
+	{
+		suppliers.POST("", handler.CreateSupplier)
+		suppliers.PUT("/:id", handler.UpdateSupplier)
+		suppliers.DELETE("/:id", handler.DeleteSupplier)
+	}
+	orders := router.Group("/api/v1/purchase-orders")
+	{
+		orders.POST("", handler.CreatePurchaseOrder)
+		orders.POST("/:id/receive", handler.ReceivePurchaseOrder)
+		orders.POST("/:id/cancel", handler.CancelPurchaseOrder)
+	}
+	return router, handler, repo, eventBus
+}
+
+func sendPurchasing(router *gin.Engine, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateSupplier(t *testing.T) {
+	router, handler, _, eventBus := setupPurchasingTest(t)
+	eventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.SupplierCreatedEvent) bool {
+		return e.Code == "SUP-001" && e.Email == "compras@norte.com" && e.Active
+	})).Return(nil).Once()
+
+	w := sendPurchasing(router, "POST", "/api/v1/suppliers", map[string]interface{}{"code": "SUP-001", "name": "Norte", "email": "compras@norte.com"})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, "SUP-001", response["code"])
+	assert.Equal(t, true, response["active"])
+
+	saved, err := handler.suppliers.FindByCode(context.Background(), "SUP-001")
+	require.NoError(t, err)
+	assert.Equal(t, "Norte", saved.Name)
+
+	// Duplicate code and malformed email
+	w = sendPurchasing(router, "POST", "/api/v1/suppliers", map[string]interface{}{"code": "SUP-001", "name": "Otro"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = sendPurchasing(router, "POST", "/api/v1/suppliers", map[string]interface{}{"code": "SUP-002", "name": "Otro", "email": "no-email"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	eventBus.AssertExpectations(t)
+}
+
+func TestUpdateSupplier(t *testing.T) {
+	router, handler, _, eventBus := setupPurchasingTest(t)
+	supplier := domain.NewSupplier("SUP-001", "Norte", "", "")
+	require.NoError(t, handler.suppliers.Save(context.Background(), supplier))
+	eventBus.On("Publish", mock.Anything, mock.MatchedBy(func(e events.SupplierUpdatedEvent) bool {
+		return e.Name == "Norte SA" && !e.Active
+	})).Return(nil).Once()
+
+	w := sendPurchasing(router, "PUT", "/api/v1/suppliers/"+supplier.ID.String(), map[string]interface{}{"name": "Norte SA", "active": false})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, supplier.Active)
+	assert.Equal(t, 2, supplier.Version)
+
+	w = sendPurchasing(router, "PUT", "/api/v1/suppliers/"+domain.NewSupplier("X", "X", "", "").ID.String(), map[string]interface{}{"name": "X"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	eventBus.AssertExpectations(t)
+}
+
+func TestDeleteSupplier_OpenOrders(t *testing.T) {
+	router, handler, repo, eventBus := setupPurchasingTest(t)
+	supplier := domain.NewSupplier("SUP-001", "Norte", "", "")
+	require.NoError(t, handler.suppliers.Save(context.Background(), supplier))
+	item := domain.NewInventoryItem("SKU-001", "Item", "", 0)
+	require.NoError(t, repo.Save(context.Background(), item))
+	order, err := domain.NewPurchaseOrder("PO-1", supplier.ID, []domain.PurchaseOrderLine{{ItemID: item.ID, Ordered: 5}}, nil, "")
+	require.NoError(t, err)
+	require.NoError(t, handler.orders.Save(context.Background(), order))
+
+	w := sendPurchasing(router, "DELETE", "/api/v1/suppliers/"+supplier.ID.String(), nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Once the order is closed the supplier can go
+	require.NoError(t, order.Cancel())
+	eventBus.On("Publish", mock.Anything, mock.AnythingOfType("events.SupplierDeletedEvent")).Return(nil).Once()
+	w = sendPurchasing(router, "DELETE", "/api/v1/suppliers/"+supplier.ID.String(), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	_, err = handler.suppliers.FindByID(context.Background(), supplier.ID)
+	assert.Equal(t, domain.ErrSupplierNotFound, err)
+	eventBus.AssertExpectations(t)
+}
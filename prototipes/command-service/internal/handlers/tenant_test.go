@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"command-service/internal/repository"
+	"command-service/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// asTenant sets the tenant like middleware.AuthMiddleware does for the token's claim
+func asTenant(tenantID string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(tenant.ContextKey, tenantID)
+		c.Request = c.Request.WithContext(tenant.WithTenant(c.Request.Context(), tenantID))
+		c.Next()
+	}
+}
+
+func TestInventoryHandler_TenantIsolation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := &InventoryHandler{
+		logger:     zap.NewNop(),
+		repository: repository.NewInventoryRepository(),
+		eventBus:   NewIntegrationTestEventPublisher(zap.NewNop()),
+	}
+	routers := map[string]*gin.Engine{}
+	for _, tenantID := range []string{"brand-a", "brand-b"} {
+		router := gin.New()
+		router.Use(asTenant(tenantID))
+		router.POST("/items", handler.CreateItem)
+		router.POST("/items/:id/adjust", handler.AdjustStock)
+		routers[tenantID] = router
+	}
+	send := func(tenantID, path string, body interface{}) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		routers[tenantID].ServeHTTP(w, req)
+		return w
+	}
+
+	created := send("brand-a", "/items", map[string]interface{}{"sku": "SKU-001", "name": "Laptop", "quantity": 10})
+	require.Equal(t, http.StatusCreated, created.Code)
+	var item struct {
+		ID string `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal(created.Body.Bytes(), &item))
+
+	// The other brand neither sees the item nor collides with its SKU
+	assert.Equal(t, http.StatusNotFound, send("brand-b", "/items/"+item.ID+"/adjust", map[string]int{"quantity": 5}).Code)
+	assert.Equal(t, http.StatusCreated, send("brand-b", "/items", map[string]interface{}{"sku": "SKU-001", "name": "Laptop", "quantity": 1}).Code)
+	assert.Equal(t, http.StatusOK, send("brand-a", "/items/"+item.ID+"/adjust", map[string]int{"quantity": 5}).Code)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"command-service/internal/domain"
+	"command-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// expectedVersion returns the item version the client based its change on, taken from
+// the If-Match header ("3", W/"3" or 3) or the body's version field. ok is false when
+// the client sent neither (or If-Match: *), i.e. the change applies to any version.
+func expectedVersion(c *gin.Context, bodyVersion *int) (version int, ok bool, err error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header != "" && header != "*" {
+		tag := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+		version, err = strconv.Atoi(tag)
+		if err != nil || version < 1 {
+			return 0, false, fmt.Errorf("If-Match must be an item version, e.g. \"3\"")
+		}
+		if bodyVersion != nil && *bodyVersion != version {
+			return 0, false, fmt.Errorf("If-Match and version disagree")
+		}
+		return version, true, nil
+	}
+	if bodyVersion != nil {
+		return *bodyVersion, true, nil
+	}
+	return 0, false, nil
+}
+
+// checkVersion writes 400 for a malformed If-Match/version and 409 when the item is no
+// longer at the version the client expected. It reports whether the change may proceed.
+func (h *InventoryHandler) checkVersion(c *gin.Context, item *domain.InventoryItem, bodyVersion *int) bool {
+	expected, ok, err := expectedVersion(c, bodyVersion)
+	if err != nil {
+		errors.Respond(c, errors.NewInvalidRequest(err.Error(), ""))
+		return false
+	}
+	if ok && expected != item.Version {
+		setETag(c, item)
+		respondVersionMismatch(c, item.ID, "version mismatch", expected, item.Version)
+		return false
+	}
+	return true
+}
+
+// respondVersionConflict answers a save that lost the race against another writer,
+// with the version that writer left
+func (h *InventoryHandler) respondVersionConflict(c *gin.Context, id uuid.UUID) {
+	current := 0
+	if item, err := h.repository.FindByID(c.Request.Context(), id); err == nil {
+		setETag(c, item)
+		current = item.Version
+	} else {
+		h.logger.Warn("Failed to reload item after version conflict", zap.Error(err))
+	}
+	respondVersionMismatch(c, id, domain.ErrVersionConflict.Error(), 0, current)
+}
+
+// respondVersionMismatch writes a VersionConflict error carrying the versions, so the
+// client can reload the item and retry. Zero versions are left out.
+func respondVersionMismatch(c *gin.Context, id uuid.UUID, message string, expected, current int) {
+	stdErr := errors.NewStandardError(errors.CodeVersionConflict, message, "Item ID: "+id.String())
+	c.AbortWithStatusJSON(stdErr.HTTPStatus(), VersionConflictResponse{
+		StandardError:   stdErr.ForRequest(c),
+		ExpectedVersion: expected,
+		CurrentVersion:  current,
+	})
+}
+
+// setETag exposes the item version so the client can send it back in If-Match
+func setETag(c *gin.Context, item *domain.InventoryItem) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(item.Version)))
+}
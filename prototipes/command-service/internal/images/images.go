@@ -0,0 +1,82 @@
+// Package images stores the files of the item images, in a local directory (served by
+// the Query Service from the same directory) or in an S3 or MinIO bucket. Their metadata
+// is part of the item in the write model (domain.ItemImage).
+package images
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"command-service/internal/config"
+)
+
+// Store keeps the image files by key ("<tenant>/<item id>/<image id>.png")
+type Store interface {
+	// Put stores data under key
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	// Delete removes the file of key; a missing file is not an error
+	Delete(ctx context.Context, key string) error
+	// URL is the public URL of the file of key, carried by the events
+	URL(key string) string
+}
+
+// contentTypes are the image formats accepted, with the extension of their files
+var contentTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// DetectContentType returns the content type of an image file from its first bytes, or
+// an error if it is not JPEG, PNG, GIF or WebP. The Content-Type the client sent is not
+// trusted.
+func DetectContentType(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if _, ok := contentTypes[contentType]; !ok {
+		return "", fmt.Errorf("unsupported image type %q: expected JPEG, PNG, GIF or WebP", contentType)
+	}
+	return contentType, nil
+}
+
+// Key returns the key of an image file: the tenant and item group the files, so a
+// tenant's images can be listed or removed by prefix
+func Key(tenantID, itemID, imageID, contentType string) string {
+	return tenantID + "/" + itemID + "/" + imageID + contentTypes[contentType]
+}
+
+// New returns the store of IMAGE_STORE
+func New(cfg *config.Config) (Store, error) {
+	switch cfg.ImageStore {
+	case config.ImageStoreLocal:
+		return NewLocalStore(cfg.ImageLocalDir, publicBaseURL(cfg)), nil
+	case config.ImageStoreS3:
+		return NewS3Store(S3Options{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			PathStyle:       cfg.S3PathStyle,
+			PublicBaseURL:   cfg.ImagePublicBaseURL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown IMAGE_STORE %q", cfg.ImageStore)
+	}
+}
+
+// publicBaseURL is IMAGE_PUBLIC_BASE_URL, by default the image route of a local Query
+// Service
+func publicBaseURL(cfg *config.Config) string {
+	if cfg.ImagePublicBaseURL != "" {
+		return cfg.ImagePublicBaseURL
+	}
+	return "http://localhost:8081/api/v1/images"
+}
+
+// joinURL appends key to base
+func joinURL(base, key string) string {
+	return strings.TrimSuffix(base, "/") + "/" + key
+}
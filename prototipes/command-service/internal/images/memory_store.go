@@ -0,0 +1,46 @@
+package images
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore keeps the files in memory. Used in mock mode and in tests.
+type MemoryStore struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{files: make(map[string][]byte)}
+}
+
+// Put stores a copy of data
+func (s *MemoryStore) Put(ctx context.Context, key, contentType string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[key] = append([]byte(nil), data...)
+	return nil
+}
+
+// Delete removes the file of key
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.files, key)
+	return nil
+}
+
+// URL returns a memory:// URL
+func (s *MemoryStore) URL(key string) string {
+	return "memory://" + key
+}
+
+// Get returns the file of key
+func (s *MemoryStore) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.files[key]
+	return data, ok
+}
@@ -0,0 +1,190 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Options configure an S3Store
+type S3Options struct {
+	Endpoint        string // https://s3.eu-west-1.amazonaws.com or http://minio:9000
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	PathStyle       bool   // http://minio:9000/bucket/key instead of http://bucket.minio:9000/key
+	PublicBaseURL   string // base of the public URLs; empty uses the URL of the bucket
+	Client          *http.Client
+}
+
+// S3Store keeps the files in a bucket of S3 or of an S3-compatible server such as MinIO.
+// Requests are signed with AWS Signature Version 4.
+type S3Store struct {
+	options S3Options
+	bucket  *url.URL // URL of the bucket, the keys go after its path
+	client  *http.Client
+}
+
+// NewS3Store creates a store of the files of a bucket
+func NewS3Store(options S3Options) (*S3Store, error) {
+	endpoint, err := url.Parse(options.Endpoint)
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", options.Endpoint)
+	}
+	bucket := *endpoint
+	if options.PathStyle {
+		bucket.Path = strings.TrimSuffix(endpoint.Path, "/") + "/" + options.Bucket
+	} else {
+		bucket.Host = options.Bucket + "." + endpoint.Host
+	}
+	client := options.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &S3Store{options: options, bucket: &bucket, client: client}, nil
+}
+
+// Put uploads the file with PUT Object
+func (s *S3Store) Put(ctx context.Context, key, contentType string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to upload image: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	return s.do(req, data, "upload")
+}
+
+// Delete removes the file with DELETE Object, which succeeds for a missing key
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key), nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete image: %w", err)
+	}
+	return s.do(req, nil, "delete")
+}
+
+// URL returns the URL of the object, or of key under IMAGE_PUBLIC_BASE_URL (a CDN)
+func (s *S3Store) URL(key string) string {
+	if s.options.PublicBaseURL != "" {
+		return joinURL(s.options.PublicBaseURL, key)
+	}
+	return s.objectURL(key)
+}
+
+func (s *S3Store) objectURL(key string) string {
+	return joinURL(s.bucket.String(), key)
+}
+
+// do signs and sends req, whose body is payload
+func (s *S3Store) do(req *http.Request, payload []byte, action string) error {
+	signRequest(req, payload, s.options.AccessKeyID, s.options.SecretAccessKey, s.options.Region, time.Now())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to %s image: %w", action, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to %s image: S3 answered %s: %s", action, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// signRequest adds the AWS Signature Version 4 headers of req (service s3), signing the
+// host and every header already set on it
+func signRequest(req *http.Request, payload []byte, accessKeyID, secretAccessKey, region string, now time.Time) {
+	payloadHash := sha256Hex(payload)
+	amzDate := now.UTC().Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath(req.URL),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := amzDate[:8] + "/" + region + "/s3/aws4_request"
+	signature := signature(secretAccessKey, amzDate, region, scope, canonicalRequest)
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKeyID, scope, signedHeaders, signature))
+}
+
+// signature signs the canonical request with the key derived for the date and region
+func signature(secretAccessKey, amzDate, region, scope, canonicalRequest string) string {
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), amzDate[:8])
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// canonicalPath is the URI-encoded path; S3 does not normalize it
+func canonicalPath(u *url.URL) string {
+	if path := u.EscapedPath(); path != "" {
+		return path
+	}
+	return "/"
+}
+
+// canonicalQuery sorts the query by name and URI-encodes it
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var pairs []string
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(name)+"="+uriEncode(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode encodes everything but the unreserved characters, as SigV4 requires
+func uriEncode(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
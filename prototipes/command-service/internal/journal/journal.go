@@ -0,0 +1,368 @@
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"command-service/internal/domain"
+	"command-service/internal/events"
+	"command-service/internal/tenant"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// compactAfter is the number of records after which the file is truncated, once
+// nothing is pending
+const compactAfter = 1000
+
+const (
+	recordIntent    = "intent"
+	recordPublished = "published"
+	recordAborted   = "aborted"
+)
+
+// Entry is a change that is about to be saved, with the event it must publish
+type Entry struct {
+	ID        string          `json:"id"`
+	ItemID    uuid.UUID       `json:"item_id"`
+	TenantID  string          `json:"tenant_id,omitempty"` // Empty for entries written before multi-tenancy
+	Version   int             `json:"version"`             // Item version once the change is saved
+	Deleted   bool            `json:"deleted,omitempty"`
+	EventType string          `json:"event_type"`
+	Event     json.RawMessage `json:"event"`
+	At        time.Time       `json:"at"`
+}
+
+type record struct {
+	Kind  string `json:"kind"`
+	ID    string `json:"id"`
+	Entry *Entry `json:"entry,omitempty"`
+}
+
+// ItemFinder reads the write store during recovery. Soft-deleted items are included:
+// a soft delete is a versioned change like any other.
+type ItemFinder interface {
+	FindIncludingDeleted(ctx context.Context, id uuid.UUID) (*domain.InventoryItem, error)
+}
+
+// Journal is an append-only write-ahead log of item changes. The intent (item version
+// and event) is written and synced before the repository Save, and marked once the
+// event has been published, so a crash between the two can be detected and the event
+// published on the next start.
+//
+// A nil *Journal is valid and records nothing.
+type Journal struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	pending map[string]*Entry
+	order   []string // pending IDs in the order they were written
+	records int
+	logger  *zap.Logger
+}
+
+// Open opens (or creates) the journal at path and loads the entries still pending
+func Open(path string, logger *zap.Logger) (*Journal, error) {
+	j := &Journal{
+		path:    path,
+		pending: make(map[string]*Entry),
+		logger:  logger,
+	}
+	if err := j.load(); err != nil {
+		return nil, err
+	}
+
+	// Rewrite the file with only what is still pending
+	if err := j.rewrite(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func (j *Journal) load() error {
+	data, err := os.ReadFile(j.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var rec record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// A torn last line is what a crash in the middle of a write leaves behind;
+			// its Save never started
+			j.logger.Warn("Skipping unreadable journal record",
+				zap.String("path", j.path),
+				zap.Int("line", line),
+				zap.Error(err),
+			)
+			continue
+		}
+		switch rec.Kind {
+		case recordIntent:
+			if rec.Entry != nil {
+				j.addPending(rec.Entry)
+			}
+		case recordPublished, recordAborted:
+			j.removePending(rec.ID)
+		}
+	}
+	return scanner.Err()
+}
+
+// rewrite replaces the file with the pending intents
+func (j *Journal) rewrite() error {
+	tmpPath := j.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create journal: %w", err)
+	}
+	writer := bufio.NewWriter(tmp)
+	for _, id := range j.order {
+		line, err := json.Marshal(record{Kind: recordIntent, ID: id, Entry: j.pending[id]})
+		if err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to encode journal entry: %w", err)
+		}
+		writer.Write(append(line, '\n'))
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+	tmp.Close()
+	if err := os.Rename(tmpPath, j.path); err != nil {
+		return fmt.Errorf("failed to replace journal: %w", err)
+	}
+
+	if j.file != nil {
+		j.file.Close()
+	}
+	j.file, err = os.OpenFile(j.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+	j.records = len(j.order)
+	return nil
+}
+
+// Begin records that item is about to be saved at its current version and that event
+// must be published afterwards. deleted marks a removal of the row (soft deletes are
+// regular saves and pass false). It returns the
+// entry ID to pass to Published or Aborted.
+func (j *Journal) Begin(item *domain.InventoryItem, deleted bool, event interface{}) (string, error) {
+	if j == nil {
+		return "", nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode event: %w", err)
+	}
+	entry := &Entry{
+		ID:        uuid.New().String(),
+		ItemID:    item.ID,
+		TenantID:  item.TenantID,
+		Version:   item.Version,
+		Deleted:   deleted,
+		EventType: events.TypeOf(event),
+		Event:     payload,
+		At:        time.Now().UTC(),
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.append(record{Kind: recordIntent, ID: entry.ID, Entry: entry}); err != nil {
+		return "", err
+	}
+	j.addPending(entry)
+	return entry.ID, nil
+}
+
+// Published marks the entry done: its change was saved and its event published
+func (j *Journal) Published(id string) {
+	j.finish(id, recordPublished)
+}
+
+// Aborted marks the entry done without publishing: its change was not saved
+func (j *Journal) Aborted(id string) {
+	j.finish(id, recordAborted)
+}
+
+func (j *Journal) finish(id, kind string) {
+	if j == nil || id == "" {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, ok := j.pending[id]; !ok {
+		return
+	}
+	if err := j.append(record{Kind: kind, ID: id}); err != nil {
+		// The entry is looked at again on the next start
+		j.logger.Error("Failed to write journal record", zap.String("id", id), zap.Error(err))
+		return
+	}
+	j.removePending(id)
+
+	if len(j.order) == 0 && j.records >= compactAfter {
+		if err := j.rewrite(); err != nil {
+			j.logger.Warn("Failed to compact journal", zap.Error(err))
+		}
+	}
+}
+
+// Pending returns the entries whose event has not been published, oldest first
+func (j *Journal) Pending() []Entry {
+	if j == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entries := make([]Entry, 0, len(j.order))
+	for _, id := range j.order {
+		entries = append(entries, *j.pending[id])
+	}
+	return entries
+}
+
+// Recover reconciles the pending entries against the write store, publishing the
+// events of changes that were saved. Per entry:
+//   - the item is at the journaled version (or gone, for a removal): the change was saved
+//     and its event is published
+//   - the item is missing or at an older version (or still there, for a removal): the
+//     change was never saved and the entry is dropped
+//   - the item is at a newer version: later changes were published after this one; the
+//     event is logged for manual reconciliation and dropped rather than sent out of order
+//
+// Entries whose event fails to publish stay pending for the next start.
+func (j *Journal) Recover(ctx context.Context, items ItemFinder, publisher events.EventPublisher) {
+	if j == nil {
+		return
+	}
+
+	for _, entry := range j.Pending() {
+		fields := []zap.Field{
+			zap.String("journal_id", entry.ID),
+			zap.String("item_id", entry.ItemID.String()),
+			zap.Int("version", entry.Version),
+			zap.String("event_type", entry.EventType),
+		}
+
+		// The write store only finds the item in its tenant
+		ctx := tenant.WithTenant(ctx, tenant.OrDefault(entry.TenantID))
+		item, err := items.FindIncludingDeleted(ctx, entry.ItemID)
+		if err != nil && err != domain.ErrItemNotFound {
+			j.logger.Error("Failed to check journaled change, keeping it for the next start", append(fields, zap.Error(err))...)
+			continue
+		}
+		found := err == nil
+
+		saved := false
+		switch {
+		case entry.Deleted:
+			saved = !found
+		case !found || item.Version < entry.Version:
+			saved = false
+		case item.Version > entry.Version:
+			j.logger.Error("Journaled event was superseded by later changes, reconcile manually",
+				append(fields, zap.Int("current_version", item.Version), zap.ByteString("event", entry.Event))...)
+			j.Aborted(entry.ID)
+			continue
+		default:
+			saved = true
+		}
+
+		if !saved {
+			j.logger.Info("Journaled change was not saved, dropping it", fields...)
+			j.Aborted(entry.ID)
+			continue
+		}
+
+		event, err := events.Decode(entry.EventType, entry.Event)
+		if err != nil {
+			j.logger.Error("Failed to decode journaled event, reconcile manually",
+				append(fields, zap.ByteString("event", entry.Event), zap.Error(err))...)
+			j.Aborted(entry.ID)
+			continue
+		}
+		if err := publisher.Publish(ctx, event); err != nil {
+			j.logger.Error("Failed to publish journaled event, keeping it for the next start", append(fields, zap.Error(err))...)
+			continue
+		}
+		j.logger.Info("Published event of a change saved before a crash", fields...)
+		j.Published(entry.ID)
+	}
+}
+
+// Close closes the journal file
+func (j *Journal) Close() error {
+	if j == nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+// append writes rec and syncs it to disk. The caller holds mu.
+func (j *Journal) append(rec record) error {
+	if j.file == nil {
+		return fmt.Errorf("journal is closed")
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal record: %w", err)
+	}
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+	j.records++
+	return nil
+}
+
+func (j *Journal) addPending(entry *Entry) {
+	if _, exists := j.pending[entry.ID]; !exists {
+		j.order = append(j.order, entry.ID)
+	}
+	j.pending[entry.ID] = entry
+}
+
+func (j *Journal) removePending(id string) {
+	if _, exists := j.pending[id]; !exists {
+		return
+	}
+	delete(j.pending, id)
+	for i, pendingID := range j.order {
+		if pendingID == id {
+			j.order = append(j.order[:i], j.order[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package journal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"command-service/internal/domain"
+	"command-service/internal/events"
+	"command-service/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type recordingPublisher struct {
+	events []interface{}
+	err    error
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event interface{}) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.events = append(p.events, event)
+	return nil
+}
+
+func TestJournal_RecoverPublishesSavedButUnpublishedChange(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "journal.log")
+	repo := repository.NewInventoryRepository()
+
+	wal, err := Open(path, zap.NewNop())
+	require.NoError(t, err)
+
+	// Saved and published: nothing to recover
+	done := domain.NewInventoryItem("SKU-001", "Laptop", "", 10)
+	id, err := wal.Begin(done, false, events.InventoryItemCreatedEvent{ItemID: done.ID, SKU: done.SKU})
+	require.NoError(t, err)
+	require.NoError(t, repo.Save(ctx, done))
+	wal.Published(id)
+
+	// Saved, then the process "crashed" before publishing
+	crashed := domain.NewInventoryItem("SKU-002", "Mouse", "", 5)
+	_, err = wal.Begin(crashed, false, events.InventoryItemCreatedEvent{ItemID: crashed.ID, SKU: crashed.SKU, Quantity: 5})
+	require.NoError(t, err)
+	require.NoError(t, repo.Save(ctx, crashed))
+
+	// Crashed before the save: must not be published
+	unsaved := domain.NewInventoryItem("SKU-003", "Keyboard", "", 1)
+	_, err = wal.Begin(unsaved, false, events.InventoryItemCreatedEvent{ItemID: unsaved.ID, SKU: unsaved.SKU})
+	require.NoError(t, err)
+	require.NoError(t, wal.Close())
+
+	wal, err = Open(path, zap.NewNop())
+	require.NoError(t, err)
+	defer wal.Close()
+	assert.Len(t, wal.Pending(), 2)
+
+	publisher := &recordingPublisher{}
+	wal.Recover(ctx, repo, publisher)
+
+	require.Len(t, publisher.events, 1)
+	event, ok := publisher.events[0].(events.InventoryItemCreatedEvent)
+	require.True(t, ok)
+	assert.Equal(t, crashed.ID.String(), event.ItemID)
+	assert.Equal(t, "SKU-002", event.SKU)
+	assert.Equal(t, 5, event.Quantity)
+	assert.Empty(t, wal.Pending())
+}
+
+func TestJournal_RecoverChecksVersionAndDeletes(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInventoryRepository()
+	wal, err := Open(filepath.Join(t.TempDir(), "journal.log"), zap.NewNop())
+	require.NoError(t, err)
+	defer wal.Close()
+
+	item := domain.NewInventoryItem("SKU-001", "Laptop", "", 10)
+	require.NoError(t, repo.Save(ctx, item))
+
+	// An adjustment that never reached the store (the store is still at version 1)
+	adjusted := *item
+	require.NoError(t, adjusted.AdjustStock(5))
+	_, err = wal.Begin(&adjusted, false, events.StockAdjustedEvent{ItemID: item.ID, Quantity: 5})
+	require.NoError(t, err)
+
+	// A delete that did happen
+	deleted := domain.NewInventoryItem("SKU-002", "Mouse", "", 1)
+	_, err = wal.Begin(deleted, true, events.InventoryItemDeletedEvent{ItemID: deleted.ID, SKU: deleted.SKU})
+	require.NoError(t, err)
+
+	publisher := &recordingPublisher{}
+	wal.Recover(ctx, repo, publisher)
+
+	require.Len(t, publisher.events, 1)
+	assert.IsType(t, events.InventoryItemDeletedEvent{}, publisher.events[0])
+	assert.Empty(t, wal.Pending())
+}
+
+func TestJournal_FailedPublishStaysPending(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInventoryRepository()
+	wal, err := Open(filepath.Join(t.TempDir(), "journal.log"), zap.NewNop())
+	require.NoError(t, err)
+	defer wal.Close()
+
+	item := domain.NewInventoryItem("SKU-001", "Laptop", "", 10)
+	_, err = wal.Begin(item, false, events.InventoryItemCreatedEvent{ItemID: item.ID})
+	require.NoError(t, err)
+	require.NoError(t, repo.Save(ctx, item))
+
+	wal.Recover(ctx, repo, &recordingPublisher{err: errors.New("broker down")})
+	assert.Len(t, wal.Pending(), 1)
+}
+
+func TestJournal_SkipsTornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.log")
+	wal, err := Open(path, zap.NewNop())
+	require.NoError(t, err)
+	item := domain.NewInventoryItem("SKU-001", "Laptop", "", 10)
+	_, err = wal.Begin(item, false, events.InventoryItemCreatedEvent{ItemID: item.ID})
+	require.NoError(t, err)
+	require.NoError(t, wal.Close())
+
+	// A crash in the middle of a write leaves half a line
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"kind":"intent","id":"abc","entry":{"item_`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	wal, err = Open(path, zap.NewNop())
+	require.NoError(t, err)
+	defer wal.Close()
+	assert.Len(t, wal.Pending(), 1)
+}
+
+func TestJournal_NilIsDisabled(t *testing.T) {
+	var wal *Journal
+	id, err := wal.Begin(domain.NewInventoryItem("SKU-001", "Laptop", "", 1), false, events.InventoryItemCreatedEvent{})
+	require.NoError(t, err)
+	assert.Empty(t, id)
+	wal.Published(id)
+	wal.Aborted(id)
+	assert.Nil(t, wal.Pending())
+	assert.NoError(t, wal.Close())
+}
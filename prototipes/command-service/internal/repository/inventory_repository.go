@@ -4,20 +4,28 @@ import (
 	"context"
 
 	"command-service/internal/domain"
+	"command-service/internal/tenant"
 
 	"github.com/google/uuid"
 )
 
-// InventoryRepository defines the interface for inventory persistence
+// InventoryRepository defines the interface for inventory persistence. Every method only
+// sees the items of the tenant of ctx (tenant.FromContext): the items of other tenants
+// are not found.
 type InventoryRepository interface {
+	// Save stores a new item or one change to a stored item: the stored copy must be at
+	// item.Version-1, otherwise domain.ErrVersionConflict is returned
 	Save(ctx context.Context, item *domain.InventoryItem) error
+	// FindByID and FindBySKU do not return soft-deleted items (domain.ErrItemNotFound)
 	FindByID(ctx context.Context, id uuid.UUID) (*domain.InventoryItem, error)
 	FindBySKU(ctx context.Context, sku string) (*domain.InventoryItem, error)
+	// FindIncludingDeleted returns the item whether or not it is soft-deleted
+	FindIncludingDeleted(ctx context.Context, id uuid.UUID) (*domain.InventoryItem, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
-// InMemoryInventoryRepository is a placeholder implementation
-// TODO: Replace with actual database implementation (PostgreSQL, etc.)
+// InMemoryInventoryRepository keeps items in memory only (state is lost on restart).
+// It is used when WRITE_STORE=memory; the default write store is SQLiteInventoryRepository.
 type InMemoryInventoryRepository struct {
 	items map[uuid.UUID]*domain.InventoryItem
 }
@@ -29,21 +37,53 @@ func NewInventoryRepository() InventoryRepository {
 }
 
 func (r *InMemoryInventoryRepository) Save(ctx context.Context, item *domain.InventoryItem) error {
+	if item.TenantID == "" {
+		item.TenantID = tenant.FromContext(ctx)
+	}
+	// FindByID hands out the stored pointer, so only a different copy can be stale
+	if existing, exists := r.items[item.ID]; exists && existing != item &&
+		(existing.Version != item.Version-1 || existing.TenantID != item.TenantID) {
+		return domain.ErrVersionConflict
+	}
+	for id, existing := range r.items {
+		if id == item.ID || existing.TenantID != item.TenantID {
+			continue
+		}
+		if existing.SKU == item.SKU {
+			return domain.ErrDuplicateSKU
+		}
+		for _, identifier := range item.Identifiers {
+			for _, taken := range existing.Identifiers {
+				if taken.Code == identifier.Code {
+					return domain.ErrDuplicateIdentifier
+				}
+			}
+		}
+	}
 	r.items[item.ID] = item
 	return nil
 }
 
 func (r *InMemoryInventoryRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.InventoryItem, error) {
+	item, err := r.FindIncludingDeleted(ctx, id)
+	if err != nil || item.DeletedAt != nil {
+		return nil, domain.ErrItemNotFound
+	}
+	return item, nil
+}
+
+func (r *InMemoryInventoryRepository) FindIncludingDeleted(ctx context.Context, id uuid.UUID) (*domain.InventoryItem, error) {
 	item, exists := r.items[id]
-	if !exists {
+	if !exists || item.TenantID != tenant.FromContext(ctx) {
 		return nil, domain.ErrItemNotFound
 	}
 	return item, nil
 }
 
 func (r *InMemoryInventoryRepository) FindBySKU(ctx context.Context, sku string) (*domain.InventoryItem, error) {
+	tenantID := tenant.FromContext(ctx)
 	for _, item := range r.items {
-		if item.SKU == sku {
+		if item.TenantID == tenantID && item.SKU == sku && item.DeletedAt == nil {
 			return item, nil
 		}
 	}
@@ -51,8 +91,8 @@ func (r *InMemoryInventoryRepository) FindBySKU(ctx context.Context, sku string)
 }
 
 func (r *InMemoryInventoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	if _, exists := r.items[id]; !exists {
-		return domain.ErrItemNotFound
+	if _, err := r.FindIncludingDeleted(ctx, id); err != nil {
+		return err
 	}
 	delete(r.items, id)
 	return nil
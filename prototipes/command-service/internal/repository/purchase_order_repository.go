@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"command-service/internal/domain"
+	"command-service/internal/tenant"
+
+	"github.com/google/uuid"
+)
+
+// PurchaseOrderRepository defines the interface for purchase order persistence. It
+// only sees the purchase orders of the tenant of ctx.
+type PurchaseOrderRepository interface {
+	// Save stores a purchase order; its number must be unique among the orders of the
+	// tenant (domain.ErrDuplicatePurchaseOrder)
+	Save(ctx context.Context, order *domain.PurchaseOrder) error
+	FindByID(ctx context.Context, id uuid.UUID) (*domain.PurchaseOrder, error)
+	// HasOpenOrders reports whether a supplier has orders that still take receipts
+	HasOpenOrders(ctx context.Context, supplierID uuid.UUID) (bool, error)
+}
+
+// InMemoryPurchaseOrderRepository is an in-memory implementation of PurchaseOrderRepository
+type InMemoryPurchaseOrderRepository struct {
+	mu     sync.RWMutex
+	orders map[uuid.UUID]*domain.PurchaseOrder
+}
+
+func NewPurchaseOrderRepository() PurchaseOrderRepository {
+	return &InMemoryPurchaseOrderRepository{
+		orders: make(map[uuid.UUID]*domain.PurchaseOrder),
+	}
+}
+
+func (r *InMemoryPurchaseOrderRepository) Save(ctx context.Context, order *domain.PurchaseOrder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if order.TenantID == "" {
+		order.TenantID = tenant.FromContext(ctx)
+	}
+	for id, existing := range r.orders {
+		if id != order.ID && existing.TenantID == order.TenantID && existing.Number == order.Number {
+			return domain.ErrDuplicatePurchaseOrder
+		}
+	}
+	r.orders[order.ID] = order
+	return nil
+}
+
+func (r *InMemoryPurchaseOrderRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.PurchaseOrder, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	order, exists := r.orders[id]
+	if !exists || order.TenantID != tenant.FromContext(ctx) {
+		return nil, domain.ErrPurchaseOrderNotFound
+	}
+	return order, nil
+}
+
+func (r *InMemoryPurchaseOrderRepository) HasOpenOrders(ctx context.Context, supplierID uuid.UUID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID := tenant.FromContext(ctx)
+	for _, order := range r.orders {
+		if order.TenantID == tenantID && order.SupplierID == supplierID && order.Open() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
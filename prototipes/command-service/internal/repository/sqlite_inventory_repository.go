@@ -0,0 +1,463 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"command-service/internal/domain"
+	"command-service/internal/tenant"
+
+	"github.com/google/uuid"
+	_ "github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
+)
+
+// writeMigrations are applied in order and recorded in schema_migrations.
+// Never edit an applied migration; append a new one instead.
+var writeMigrations = []string{
+	// 1: write model for inventory items
+	`CREATE TABLE IF NOT EXISTS inventory_items (
+		id TEXT PRIMARY KEY,
+		sku TEXT UNIQUE NOT NULL,
+		name TEXT NOT NULL,
+		description TEXT,
+		quantity INTEGER NOT NULL DEFAULT 0,
+		reserved INTEGER NOT NULL DEFAULT 0,
+		version INTEGER NOT NULL DEFAULT 1,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL,
+		CHECK(quantity >= 0),
+		CHECK(reserved >= 0),
+		CHECK(reserved <= quantity)
+	);`,
+	// 2: stock of an item per location; the item row keeps the totals
+	`CREATE TABLE IF NOT EXISTS stock_locations (
+		item_id TEXT NOT NULL,
+		location TEXT NOT NULL,
+		quantity INTEGER NOT NULL DEFAULT 0,
+		reserved INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (item_id, location),
+		CHECK(quantity >= 0),
+		CHECK(reserved >= 0),
+		CHECK(reserved <= quantity)
+	);`,
+	// 3: soft delete; a deleted item keeps its row (and its SKU) until restored
+	`ALTER TABLE inventory_items ADD COLUMN deleted_at TEXT;`,
+	// 4: items belong to a tenant and the SKU is unique per tenant. SQLite cannot drop
+	// the old UNIQUE(sku), so the table is rebuilt; existing items go to the default tenant.
+	`CREATE TABLE inventory_items_v4 (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL DEFAULT '` + tenant.Default + `',
+		sku TEXT NOT NULL,
+		name TEXT NOT NULL,
+		description TEXT,
+		quantity INTEGER NOT NULL DEFAULT 0,
+		reserved INTEGER NOT NULL DEFAULT 0,
+		version INTEGER NOT NULL DEFAULT 1,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL,
+		deleted_at TEXT,
+		UNIQUE(tenant_id, sku),
+		CHECK(quantity >= 0),
+		CHECK(reserved >= 0),
+		CHECK(reserved <= quantity)
+	);
+	INSERT INTO inventory_items_v4 (id, sku, name, description, quantity, reserved, version, created_at, updated_at, deleted_at)
+		SELECT id, sku, name, description, quantity, reserved, version, created_at, updated_at, deleted_at FROM inventory_items;
+	DROP TABLE inventory_items;
+	ALTER TABLE inventory_items_v4 RENAME TO inventory_items;`,
+	// 5: metadata of the item images; the files are in the image store
+	`CREATE TABLE IF NOT EXISTS item_images (
+		id TEXT PRIMARY KEY,
+		item_id TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		storage_key TEXT NOT NULL,
+		url TEXT NOT NULL,
+		content_type TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		created_at TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_item_images_item ON item_images(item_id, position);`,
+	// 6: barcodes and alternate identifiers; a code belongs to one item of the tenant,
+	// deleted items included (like the SKU)
+	`CREATE TABLE IF NOT EXISTS item_identifiers (
+		item_id TEXT NOT NULL,
+		tenant_id TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		type TEXT NOT NULL,
+		value TEXT NOT NULL,
+		code TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		PRIMARY KEY (item_id, code),
+		UNIQUE(tenant_id, code)
+	);`,
+}
+
+// SQLiteInventoryRepository is the durable write store of the Command Service.
+// It is independent from the Listener Service database (the read model).
+type SQLiteInventoryRepository struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+// NewSQLiteInventoryRepository opens (or creates) the write store at path and applies pending migrations
+func NewSQLiteInventoryRepository(path string, logger *zap.Logger) (*SQLiteInventoryRepository, error) {
+	db, err := sql.Open("sqlite3", path+"?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open write store: %w", err)
+	}
+
+	// SQLite allows a single writer; serialize access through one connection
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(time.Hour)
+
+	repo := &SQLiteInventoryRepository{
+		db:     db,
+		logger: logger,
+	}
+
+	if err := repo.migrate(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+// migrate applies the migrations that are not yet recorded in schema_migrations
+func (r *SQLiteInventoryRepository) migrate(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			applied_at TEXT NOT NULL
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := r.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	for i := current; i < len(writeMigrations); i++ {
+		version := i + 1
+		tx, err := r.db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin migration %d: %w", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, writeMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply migration %d: %w", version, err)
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`,
+			version, time.Now().UTC().Format(time.RFC3339),
+		); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d: %w", version, err)
+		}
+		r.logger.Info("Applied write store migration", zap.Int("version", version))
+	}
+
+	return nil
+}
+
+// Close closes the underlying database
+func (r *SQLiteInventoryRepository) Close() error {
+	return r.db.Close()
+}
+
+// Ping checks that the write store can be reached (readiness probe)
+func (r *SQLiteInventoryRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// Save inserts the item or overwrites the stored copy. The overwrite only happens if
+// the stored copy is the version the change was made on (optimistic locking), so two
+// concurrent writers cannot silently overwrite each other. The stock per location is
+// written in the same transaction. New items without tenant get the one of ctx; the
+// tenant of a stored item never changes.
+func (r *SQLiteInventoryRepository) Save(ctx context.Context, item *domain.InventoryItem) error {
+	if item.TenantID == "" {
+		item.TenantID = tenant.FromContext(ctx)
+	}
+	query := `
+		INSERT INTO inventory_items (id, tenant_id, sku, name, description, quantity, reserved, version, created_at, updated_at, deleted_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			sku = excluded.sku,
+			name = excluded.name,
+			description = excluded.description,
+			quantity = excluded.quantity,
+			reserved = excluded.reserved,
+			version = excluded.version,
+			updated_at = excluded.updated_at,
+			deleted_at = excluded.deleted_at
+		WHERE inventory_items.version = excluded.version - 1
+			AND inventory_items.tenant_id = excluded.tenant_id
+	`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to save item: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query,
+		item.ID.String(), item.TenantID, item.SKU, item.Name, item.Description,
+		item.Quantity, item.Reserved, item.Version,
+		item.CreatedAt.UTC().Format(time.RFC3339Nano), item.UpdatedAt.UTC().Format(time.RFC3339Nano),
+		formatDeletedAt(item.DeletedAt),
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") && strings.Contains(err.Error(), "inventory_items.sku") {
+			return domain.ErrDuplicateSKU
+		}
+		return fmt.Errorf("failed to save item: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to save item: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrVersionConflict
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM stock_locations WHERE item_id = ?`, item.ID.String()); err != nil {
+		return fmt.Errorf("failed to save item locations: %w", err)
+	}
+	for _, location := range item.LocationCodes() {
+		stock := item.Locations[location]
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO stock_locations (item_id, location, quantity, reserved) VALUES (?, ?, ?, ?)`,
+			item.ID.String(), location, stock.Quantity, stock.Reserved,
+		); err != nil {
+			return fmt.Errorf("failed to save item location %s: %w", location, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM item_images WHERE item_id = ?`, item.ID.String()); err != nil {
+		return fmt.Errorf("failed to save item images: %w", err)
+	}
+	for position, image := range item.Images {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO item_images (id, item_id, position, storage_key, url, content_type, size, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			image.ID.String(), item.ID.String(), position, image.Key, image.URL, image.ContentType, image.Size,
+			image.CreatedAt.UTC().Format(time.RFC3339Nano),
+		); err != nil {
+			return fmt.Errorf("failed to save item image %s: %w", image.ID, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM item_identifiers WHERE item_id = ?`, item.ID.String()); err != nil {
+		return fmt.Errorf("failed to save item identifiers: %w", err)
+	}
+	for position, identifier := range item.Identifiers {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO item_identifiers (item_id, tenant_id, position, type, value, code, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			item.ID.String(), item.TenantID, position, identifier.Type, identifier.Value, identifier.Code,
+			identifier.CreatedAt.UTC().Format(time.RFC3339Nano),
+		); err != nil {
+			if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+				return domain.ErrDuplicateIdentifier
+			}
+			return fmt.Errorf("failed to save item identifier %s: %w", identifier.Code, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save item: %w", err)
+	}
+	return nil
+}
+
+// FindByID returns the item of the tenant of ctx with the given ID unless it is soft-deleted
+func (r *SQLiteInventoryRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.InventoryItem, error) {
+	return r.findOne(ctx, `AND id = ? AND deleted_at IS NULL`, id.String())
+}
+
+// FindIncludingDeleted returns the item of the tenant of ctx with the given ID, soft-deleted or not
+func (r *SQLiteInventoryRepository) FindIncludingDeleted(ctx context.Context, id uuid.UUID) (*domain.InventoryItem, error) {
+	return r.findOne(ctx, `AND id = ?`, id.String())
+}
+
+// FindBySKU returns the item of the tenant of ctx with the given SKU unless it is soft-deleted
+func (r *SQLiteInventoryRepository) FindBySKU(ctx context.Context, sku string) (*domain.InventoryItem, error) {
+	return r.findOne(ctx, `AND sku = ? AND deleted_at IS NULL`, sku)
+}
+
+// findOne returns the item of the tenant of ctx matching the condition
+func (r *SQLiteInventoryRepository) findOne(ctx context.Context, condition string, arg interface{}) (*domain.InventoryItem, error) {
+	query := `
+		SELECT id, tenant_id, sku, name, description, quantity, reserved, version, created_at, updated_at, deleted_at
+		FROM inventory_items
+		WHERE tenant_id = ? ` + condition
+
+	var item domain.InventoryItem
+	var id, createdAt, updatedAt string
+	var description, deletedAt sql.NullString
+
+	err := r.db.QueryRowContext(ctx, query, tenant.FromContext(ctx), arg).Scan(
+		&id, &item.TenantID, &item.SKU, &item.Name, &description,
+		&item.Quantity, &item.Reserved, &item.Version,
+		&createdAt, &updatedAt, &deletedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrItemNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find item: %w", err)
+	}
+
+	if item.ID, err = uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid item id %q: %w", id, err)
+	}
+	item.Description = description.String
+	item.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+	item.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updatedAt)
+	if deletedAt.Valid {
+		at, _ := time.Parse(time.RFC3339Nano, deletedAt.String)
+		item.DeletedAt = &at
+	}
+
+	if item.Locations, err = r.findLocations(ctx, id); err != nil {
+		return nil, err
+	}
+	if item.Images, err = r.findImages(ctx, id); err != nil {
+		return nil, err
+	}
+	if item.Identifiers, err = r.findIdentifiers(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+// findLocations returns the stock of the item per location, nil if it has none
+func (r *SQLiteInventoryRepository) findLocations(ctx context.Context, itemID string) (map[string]*domain.LocationStock, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT location, quantity, reserved FROM stock_locations WHERE item_id = ?`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find item locations: %w", err)
+	}
+	defer rows.Close()
+
+	var locations map[string]*domain.LocationStock
+	for rows.Next() {
+		var location string
+		var stock domain.LocationStock
+		if err := rows.Scan(&location, &stock.Quantity, &stock.Reserved); err != nil {
+			return nil, fmt.Errorf("failed to scan item location: %w", err)
+		}
+		if locations == nil {
+			locations = make(map[string]*domain.LocationStock)
+		}
+		locations[location] = &stock
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find item locations: %w", err)
+	}
+	return locations, nil
+}
+
+// findImages returns the images of the item in order, nil if it has none
+func (r *SQLiteInventoryRepository) findImages(ctx context.Context, itemID string) ([]domain.ItemImage, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, storage_key, url, content_type, size, created_at FROM item_images WHERE item_id = ? ORDER BY position`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find item images: %w", err)
+	}
+	defer rows.Close()
+
+	var images []domain.ItemImage
+	for rows.Next() {
+		var image domain.ItemImage
+		var id, createdAt string
+		if err := rows.Scan(&id, &image.Key, &image.URL, &image.ContentType, &image.Size, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan item image: %w", err)
+		}
+		if image.ID, err = uuid.Parse(id); err != nil {
+			return nil, fmt.Errorf("invalid image id %q: %w", id, err)
+		}
+		image.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+		images = append(images, image)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find item images: %w", err)
+	}
+	return images, nil
+}
+
+// findIdentifiers returns the identifiers of the item in order, nil if it has none
+func (r *SQLiteInventoryRepository) findIdentifiers(ctx context.Context, itemID string) ([]domain.ItemIdentifier, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT type, value, code, created_at FROM item_identifiers WHERE item_id = ? ORDER BY position`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find item identifiers: %w", err)
+	}
+	defer rows.Close()
+
+	var identifiers []domain.ItemIdentifier
+	for rows.Next() {
+		var identifier domain.ItemIdentifier
+		var createdAt string
+		if err := rows.Scan(&identifier.Type, &identifier.Value, &identifier.Code, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan item identifier: %w", err)
+		}
+		identifier.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+		identifiers = append(identifiers, identifier)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find item identifiers: %w", err)
+	}
+	return identifiers, nil
+}
+
+// formatDeletedAt stores the soft-delete time, NULL for a live item
+func formatDeletedAt(deletedAt *time.Time) interface{} {
+	if deletedAt == nil {
+		return nil
+	}
+	return deletedAt.UTC().Format(time.RFC3339Nano)
+}
+
+// Delete removes the item of the tenant of ctx with the given ID, its stock per location,
+// its image metadata and its identifiers for good. The API soft-deletes items
+// (domain.InventoryItem.Delete followed by Save) instead.
+func (r *SQLiteInventoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete item: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM inventory_items WHERE id = ? AND tenant_id = ?`, id.String(), tenant.FromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to delete item: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete item: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrItemNotFound
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM stock_locations WHERE item_id = ?`, id.String()); err != nil {
+		return fmt.Errorf("failed to delete item locations: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM item_images WHERE item_id = ?`, id.String()); err != nil {
+		return fmt.Errorf("failed to delete item images: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM item_identifiers WHERE item_id = ?`, id.String()); err != nil {
+		return fmt.Errorf("failed to delete item identifiers: %w", err)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,318 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"command-service/internal/domain"
+	"command-service/internal/tenant"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSQLiteInventoryRepository_PersistsAcrossReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "command.db")
+
+	repo, err := NewSQLiteInventoryRepository(path, zap.NewNop())
+	require.NoError(t, err)
+
+	item := domain.NewInventoryItem("SKU-001", "Laptop", "Gaming laptop", 10)
+	require.NoError(t, item.ReserveStock(3))
+	require.NoError(t, repo.Save(ctx, item))
+	require.NoError(t, repo.Close())
+
+	// Reopening applies no migration twice and sees the saved state
+	repo, err = NewSQLiteInventoryRepository(path, zap.NewNop())
+	require.NoError(t, err)
+	defer repo.Close()
+
+	found, err := repo.FindByID(ctx, item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "SKU-001", found.SKU)
+	assert.Equal(t, 10, found.Quantity)
+	assert.Equal(t, 3, found.Reserved)
+	assert.Equal(t, item.Version, found.Version)
+
+	bySKU, err := repo.FindBySKU(ctx, "SKU-001")
+	require.NoError(t, err)
+	assert.Equal(t, item.ID, bySKU.ID)
+}
+
+func TestSQLiteInventoryRepository_SaveUpdatesAndDeletes(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewSQLiteInventoryRepository(filepath.Join(t.TempDir(), "command.db"), zap.NewNop())
+	require.NoError(t, err)
+	defer repo.Close()
+
+	item := domain.NewInventoryItem("SKU-001", "Laptop", "", 10)
+	require.NoError(t, repo.Save(ctx, item))
+
+	require.NoError(t, item.AdjustStock(5))
+	require.NoError(t, repo.Save(ctx, item))
+
+	found, err := repo.FindByID(ctx, item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 15, found.Quantity)
+
+	duplicate := domain.NewInventoryItem("SKU-001", "Other", "", 1)
+	assert.Equal(t, domain.ErrDuplicateSKU, repo.Save(ctx, duplicate))
+
+	require.NoError(t, repo.Delete(ctx, item.ID))
+	_, err = repo.FindByID(ctx, item.ID)
+	assert.Equal(t, domain.ErrItemNotFound, err)
+	assert.Equal(t, domain.ErrItemNotFound, repo.Delete(ctx, item.ID))
+}
+
+func TestSQLiteInventoryRepository_SaveRejectsStaleVersion(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewSQLiteInventoryRepository(filepath.Join(t.TempDir(), "command.db"), zap.NewNop())
+	require.NoError(t, err)
+	defer repo.Close()
+
+	item := domain.NewInventoryItem("SKU-001", "Laptop", "", 10)
+	require.NoError(t, repo.Save(ctx, item))
+
+	// Two writers load version 1; the second save would overwrite the first
+	first, err := repo.FindByID(ctx, item.ID)
+	require.NoError(t, err)
+	second, err := repo.FindByID(ctx, item.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, first.AdjustStock(5))
+	require.NoError(t, repo.Save(ctx, first))
+
+	require.NoError(t, second.AdjustStock(-2))
+	assert.Equal(t, domain.ErrVersionConflict, repo.Save(ctx, second))
+
+	found, err := repo.FindByID(ctx, item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 15, found.Quantity)
+	assert.Equal(t, 2, found.Version)
+}
+
+func TestSQLiteInventoryRepository_SavesLocations(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewSQLiteInventoryRepository(filepath.Join(t.TempDir(), "command.db"), zap.NewNop())
+	require.NoError(t, err)
+	defer repo.Close()
+
+	item := domain.NewInventoryItem("SKU-001", "Laptop", "", 2)
+	require.NoError(t, repo.Save(ctx, item))
+	// Each change is saved on its own, as the handlers do
+	for _, change := range []func() error{
+		func() error { return item.AdjustLocationStock("WH-1", 10) },
+		func() error { return item.ReserveAtLocation("WH-1", 4) },
+		func() error { return item.AdjustLocationStock("WH-2", 3) },
+	} {
+		require.NoError(t, change())
+		require.NoError(t, repo.Save(ctx, item))
+	}
+
+	found, err := repo.FindByID(ctx, item.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 15, found.Quantity)
+	assert.Equal(t, 4, found.Reserved)
+	assert.Equal(t, domain.LocationStock{Quantity: 10, Reserved: 4}, *found.Location("WH-1"))
+	assert.Equal(t, domain.LocationStock{Quantity: 3}, *found.Location("WH-2"))
+	assert.Equal(t, 2, found.UnlocatedQuantity())
+
+	// A stale writer changes neither the item nor its locations
+	stale := domain.NewInventoryItem("SKU-001", "Laptop", "", 0)
+	stale.ID = item.ID
+	require.NoError(t, stale.AdjustLocationStock("WH-1", 99))
+	assert.Equal(t, domain.ErrVersionConflict, repo.Save(ctx, stale))
+
+	require.NoError(t, repo.Delete(ctx, item.ID))
+	again := domain.NewInventoryItem("SKU-002", "Laptop", "", 0)
+	again.ID = item.ID
+	require.NoError(t, repo.Save(ctx, again))
+	found, err = repo.FindByID(ctx, item.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found.Locations)
+}
+
+func TestSQLiteInventoryRepository_SavesImages(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewSQLiteInventoryRepository(filepath.Join(t.TempDir(), "command.db"), zap.NewNop())
+	require.NoError(t, err)
+	defer repo.Close()
+
+	item := domain.NewInventoryItem("SKU-001", "Laptop", "", 2)
+	require.NoError(t, repo.Save(ctx, item))
+	var images []domain.ItemImage
+	for _, name := range []string{"front", "back", "side"} {
+		image := domain.ItemImage{
+			ID:          uuid.New(),
+			Key:         "default/" + item.ID.String() + "/" + name + ".png",
+			URL:         "http://localhost:8081/api/v1/images/" + name + ".png",
+			ContentType: "image/png",
+			Size:        1024,
+			CreatedAt:   time.Now().UTC(),
+		}
+		require.NoError(t, item.AddImage(image))
+		require.NoError(t, repo.Save(ctx, item))
+		images = append(images, image)
+	}
+	_, err = item.RemoveImage(images[1].ID)
+	require.NoError(t, err)
+	require.NoError(t, repo.Save(ctx, item))
+
+	found, err := repo.FindByID(ctx, item.ID)
+	require.NoError(t, err)
+	require.Len(t, found.Images, 2)
+	for n, image := range []domain.ItemImage{images[0], images[2]} {
+		assert.Equal(t, image.ID, found.Images[n].ID, "in the order they were added")
+		assert.Equal(t, image.Key, found.Images[n].Key)
+		assert.Equal(t, image.URL, found.Images[n].URL)
+		assert.Equal(t, image.ContentType, found.Images[n].ContentType)
+		assert.Equal(t, image.Size, found.Images[n].Size)
+		assert.True(t, image.CreatedAt.Equal(found.Images[n].CreatedAt))
+	}
+
+	require.NoError(t, repo.Delete(ctx, item.ID))
+	again := domain.NewInventoryItem("SKU-002", "Laptop", "", 0)
+	again.ID = item.ID
+	require.NoError(t, repo.Save(ctx, again))
+	found, err = repo.FindByID(ctx, item.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found.Images)
+}
+
+func TestSQLiteInventoryRepository_SavesIdentifiers(t *testing.T) {
+	repo, err := NewSQLiteInventoryRepository(filepath.Join(t.TempDir(), "command.db"), zap.NewNop())
+	require.NoError(t, err)
+	defer repo.Close()
+	ctx := tenant.WithTenant(context.Background(), "brand-a")
+
+	item := domain.NewInventoryItem("SKU-001", "Laptop", "", 2)
+	require.NoError(t, repo.Save(ctx, item))
+	ean, err := domain.NewItemIdentifier(domain.IdentifierEAN13, "4006381333931")
+	require.NoError(t, err)
+	alias, err := domain.NewItemIdentifier(domain.IdentifierAlias, "SUP-4411")
+	require.NoError(t, err)
+	for _, identifier := range []domain.ItemIdentifier{ean, alias} {
+		require.NoError(t, item.AddIdentifier(identifier))
+		require.NoError(t, repo.Save(ctx, item))
+	}
+
+	found, err := repo.FindByID(ctx, item.ID)
+	require.NoError(t, err)
+	require.Len(t, found.Identifiers, 2)
+	for n, identifier := range []domain.ItemIdentifier{ean, alias} {
+		assert.Equal(t, identifier.Type, found.Identifiers[n].Type, "in the order they were added")
+		assert.Equal(t, identifier.Value, found.Identifiers[n].Value)
+		assert.Equal(t, identifier.Code, found.Identifiers[n].Code)
+	}
+
+	// A code belongs to one item of the tenant; other tenants can use it
+	other := domain.NewInventoryItem("SKU-002", "Other laptop", "", 1)
+	require.NoError(t, other.AddIdentifier(ean))
+	assert.Equal(t, domain.ErrDuplicateIdentifier, repo.Save(ctx, other))
+	otherBrand := domain.NewInventoryItem("SKU-002", "Other brand laptop", "", 1)
+	require.NoError(t, otherBrand.AddIdentifier(ean))
+	require.NoError(t, repo.Save(tenant.WithTenant(context.Background(), "brand-b"), otherBrand))
+
+	_, err = found.RemoveIdentifier(ean.Code)
+	require.NoError(t, err)
+	require.NoError(t, repo.Save(ctx, found))
+	again := domain.NewInventoryItem("SKU-003", "Another laptop", "", 1)
+	require.NoError(t, again.AddIdentifier(ean))
+	require.NoError(t, repo.Save(ctx, again), "the code is free once removed")
+}
+
+func TestSQLiteInventoryRepository_SoftDelete(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewSQLiteInventoryRepository(filepath.Join(t.TempDir(), "command.db"), zap.NewNop())
+	require.NoError(t, err)
+	defer repo.Close()
+
+	item := domain.NewInventoryItem("SKU-001", "Laptop", "", 5)
+	require.NoError(t, repo.Save(ctx, item))
+	require.NoError(t, item.Delete())
+	require.NoError(t, repo.Save(ctx, item))
+
+	_, err = repo.FindByID(ctx, item.ID)
+	assert.Equal(t, domain.ErrItemNotFound, err)
+	_, err = repo.FindBySKU(ctx, "SKU-001")
+	assert.Equal(t, domain.ErrItemNotFound, err)
+
+	// The deleted item keeps its SKU
+	assert.Equal(t, domain.ErrDuplicateSKU, repo.Save(ctx, domain.NewInventoryItem("SKU-001", "Other", "", 0)))
+
+	deleted, err := repo.FindIncludingDeleted(ctx, item.ID)
+	require.NoError(t, err)
+	require.NotNil(t, deleted.DeletedAt)
+	assert.Equal(t, 5, deleted.Quantity)
+
+	require.NoError(t, deleted.Restore())
+	require.NoError(t, repo.Save(ctx, deleted))
+	found, err := repo.FindByID(ctx, item.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found.DeletedAt)
+	assert.Equal(t, 3, found.Version)
+}
+
+func TestSQLiteInventoryRepository_ScopedByTenant(t *testing.T) {
+	repo, err := NewSQLiteInventoryRepository(filepath.Join(t.TempDir(), "command.db"), zap.NewNop())
+	require.NoError(t, err)
+	defer repo.Close()
+
+	brandA := tenant.WithTenant(context.Background(), "brand-a")
+	brandB := tenant.WithTenant(context.Background(), "brand-b")
+
+	item := domain.NewInventoryItem("SKU-001", "Laptop", "", 10)
+	require.NoError(t, repo.Save(brandA, item))
+	assert.Equal(t, "brand-a", item.TenantID)
+
+	// The SKU is unique per tenant only
+	other := domain.NewInventoryItem("SKU-001", "Other brand laptop", "", 1)
+	require.NoError(t, repo.Save(brandB, other))
+	assert.Equal(t, domain.ErrDuplicateSKU, repo.Save(brandA, domain.NewInventoryItem("SKU-001", "Again", "", 1)))
+
+	found, err := repo.FindBySKU(brandB, "SKU-001")
+	require.NoError(t, err)
+	assert.Equal(t, other.ID, found.ID)
+	assert.Equal(t, "brand-b", found.TenantID)
+
+	// Items of another tenant are not found, and cannot be deleted
+	_, err = repo.FindByID(brandB, item.ID)
+	assert.Equal(t, domain.ErrItemNotFound, err)
+	_, err = repo.FindIncludingDeleted(context.Background(), item.ID)
+	assert.Equal(t, domain.ErrItemNotFound, err)
+	assert.Equal(t, domain.ErrItemNotFound, repo.Delete(brandB, item.ID))
+}
+
+func TestSQLiteInventoryRepository_MigratesItemsToDefaultTenant(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "command.db")
+	repo, err := NewSQLiteInventoryRepository(path, zap.NewNop())
+	require.NoError(t, err)
+
+	// A write store from before multi-tenancy, with one item
+	_, err = repo.db.Exec(`DROP TABLE inventory_items;
+		DELETE FROM schema_migrations WHERE version >= 4;
+		CREATE TABLE inventory_items (
+			id TEXT PRIMARY KEY, sku TEXT UNIQUE NOT NULL, name TEXT NOT NULL, description TEXT,
+			quantity INTEGER NOT NULL DEFAULT 0, reserved INTEGER NOT NULL DEFAULT 0,
+			version INTEGER NOT NULL DEFAULT 1, created_at TEXT NOT NULL, updated_at TEXT NOT NULL, deleted_at TEXT
+		);
+		INSERT INTO inventory_items (id, sku, name, quantity, created_at, updated_at)
+			VALUES ('7c9e6679-7425-40de-944b-e07fc1f90ae7', 'SKU-OLD', 'Legacy', 4, '2024-01-15T12:00:00Z', '2024-01-15T12:00:00Z');`)
+	require.NoError(t, err)
+	require.NoError(t, repo.Close())
+
+	repo, err = NewSQLiteInventoryRepository(path, zap.NewNop())
+	require.NoError(t, err)
+	defer repo.Close()
+
+	found, err := repo.FindBySKU(context.Background(), "SKU-OLD")
+	require.NoError(t, err)
+	assert.Equal(t, tenant.Default, found.TenantID)
+	assert.Equal(t, 4, found.Quantity)
+	require.NoError(t, repo.Save(tenant.WithTenant(context.Background(), "brand-a"), domain.NewInventoryItem("SKU-OLD", "Other", "", 1)))
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"command-service/internal/domain"
+	"command-service/internal/tenant"
+
+	"github.com/google/uuid"
+)
+
+// StoreRepository defines the interface for store persistence. Like
+// InventoryRepository, it only sees the stores of the tenant of ctx.
+type StoreRepository interface {
+	Save(ctx context.Context, store *domain.Store) error
+	FindByID(ctx context.Context, id uuid.UUID) (*domain.Store, error)
+	FindByCode(ctx context.Context, code string) (*domain.Store, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// InMemoryStoreRepository is an in-memory implementation of StoreRepository
+type InMemoryStoreRepository struct {
+	mu     sync.RWMutex
+	stores map[uuid.UUID]*domain.Store
+}
+
+func NewStoreRepository() StoreRepository {
+	return &InMemoryStoreRepository{
+		stores: make(map[uuid.UUID]*domain.Store),
+	}
+}
+
+func (r *InMemoryStoreRepository) Save(ctx context.Context, store *domain.Store) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if store.TenantID == "" {
+		store.TenantID = tenant.FromContext(ctx)
+	}
+	r.stores[store.ID] = store
+	return nil
+}
+
+func (r *InMemoryStoreRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Store, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	store, exists := r.stores[id]
+	if !exists || store.TenantID != tenant.FromContext(ctx) {
+		return nil, domain.ErrStoreNotFound
+	}
+	return store, nil
+}
+
+func (r *InMemoryStoreRepository) FindByCode(ctx context.Context, code string) (*domain.Store, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID := tenant.FromContext(ctx)
+	for _, store := range r.stores {
+		if store.TenantID == tenantID && store.Code == code {
+			return store, nil
+		}
+	}
+	return nil, domain.ErrStoreNotFound
+}
+
+func (r *InMemoryStoreRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if store, exists := r.stores[id]; !exists || store.TenantID != tenant.FromContext(ctx) {
+		return domain.ErrStoreNotFound
+	}
+	delete(r.stores, id)
+	return nil
+}
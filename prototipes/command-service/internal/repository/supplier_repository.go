@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"command-service/internal/domain"
+	"command-service/internal/tenant"
+
+	"github.com/google/uuid"
+)
+
+// SupplierRepository defines the interface for supplier persistence. Like
+// StoreRepository, it only sees the suppliers of the tenant of ctx.
+type SupplierRepository interface {
+	Save(ctx context.Context, supplier *domain.Supplier) error
+	FindByID(ctx context.Context, id uuid.UUID) (*domain.Supplier, error)
+	FindByCode(ctx context.Context, code string) (*domain.Supplier, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// InMemorySupplierRepository is an in-memory implementation of SupplierRepository
+type InMemorySupplierRepository struct {
+	mu        sync.RWMutex
+	suppliers map[uuid.UUID]*domain.Supplier
+}
+
+func NewSupplierRepository() SupplierRepository {
+	return &InMemorySupplierRepository{
+		suppliers: make(map[uuid.UUID]*domain.Supplier),
+	}
+}
+
+func (r *InMemorySupplierRepository) Save(ctx context.Context, supplier *domain.Supplier) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if supplier.TenantID == "" {
+		supplier.TenantID = tenant.FromContext(ctx)
+	}
+	r.suppliers[supplier.ID] = supplier
+	return nil
+}
+
+func (r *InMemorySupplierRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Supplier, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	supplier, exists := r.suppliers[id]
+	if !exists || supplier.TenantID != tenant.FromContext(ctx) {
+		return nil, domain.ErrSupplierNotFound
+	}
+	return supplier, nil
+}
+
+func (r *InMemorySupplierRepository) FindByCode(ctx context.Context, code string) (*domain.Supplier, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenantID := tenant.FromContext(ctx)
+	for _, supplier := range r.suppliers {
+		if supplier.TenantID == tenantID && supplier.Code == code {
+			return supplier, nil
+		}
+	}
+	return nil, domain.ErrSupplierNotFound
+}
+
+func (r *InMemorySupplierRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if supplier, exists := r.suppliers[id]; !exists || supplier.TenantID != tenant.FromContext(ctx) {
+		return domain.ErrSupplierNotFound
+	}
+	delete(r.suppliers, id)
+	return nil
+}
@@ -0,0 +1,158 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"command-service/internal/config"
+	"command-service/internal/events"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// RejectionEventType is the event type of the messages on the rejections topic
+const RejectionEventType = "EventRejected"
+
+// confirmationSuffix ends the event type of the Listener Service confirmations
+const confirmationSuffix = "Confirmed"
+
+// reconnectDelay is how long the consumer waits before retrying to open the topics
+const reconnectDelay = 30 * time.Second
+
+// Consumer reads what the Listener Service reports about the events of the requests:
+// the EventRejected events of the rejections topic and the <Type>Confirmed events
+// published on the event topics. It reads every message without a consumer group, from
+// the newest one: each replica keeps its own Store and needs every report, and statuses
+// older than the replica are not kept anyway.
+type Consumer struct {
+	config   *config.Config // event bus settings
+	topics   []string
+	statuses *Store
+	logger   *zap.Logger
+}
+
+// NewConsumer creates a consumer of topics (the rejections topic and the event topics,
+// which may be templates with config.TenantPlaceholder)
+func NewConsumer(cfg *config.Config, topics []string, statuses *Store, logger *zap.Logger) *Consumer {
+	return &Consumer{config: cfg, topics: topics, statuses: statuses, logger: logger}
+}
+
+// Run consumes the reports until ctx is cancelled. While the event bus or a topic is
+// not available it retries every 30 seconds.
+func (c *Consumer) Run(ctx context.Context) {
+	for {
+		err := c.consume(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		c.logger.Warn("Command status consumer stopped, retrying",
+			zap.Strings("topics", c.topics),
+			zap.Duration("delay", reconnectDelay),
+			zap.Error(err),
+		)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// consume reads the topics until ctx is cancelled or the consumer fails
+func (c *Consumer) consume(ctx context.Context) error {
+	consumer, err := events.NewEventConsumer(c.config)
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+
+	// The Kafka consumer follows the topics the templates match itself
+	topics := c.topics
+	if c.config.KafkaTopicSubscription != config.TopicSubscriptionRegex {
+		topics = c.config.ListedTopics(c.topics...)
+	}
+	c.logger.Info("Command status consumer started", zap.Strings("topics", topics), zap.String("event_bus", c.config.EventBus))
+	return consumer.Consume(ctx, topics, c.handle)
+}
+
+// handle records one message. Only rejections and the confirmations of events
+// published by a request are decoded; the rest (the events themselves, possibly
+// encrypted) are skipped by their event-type header.
+func (c *Consumer) handle(message *sarama.ConsumerMessage) {
+	eventType := header(message.Headers, "event-type")
+	switch {
+	case eventType == RejectionEventType:
+		rejection, ok, err := DecodeRejection(message.Value)
+		if err != nil {
+			c.logger.Warn("Invalid message on the rejections topic", zap.Error(err))
+			return
+		}
+		if !ok {
+			return
+		}
+		c.statuses.Reject(rejection)
+		c.logger.Warn("Event rejected by the Listener Service",
+			zap.String("request_id", rejection.RequestID),
+			zap.String("event_type", rejection.EventType),
+			zap.String("event_id", rejection.EventID),
+			zap.String("reason", rejection.Reason),
+		)
+	case strings.HasSuffix(eventType, confirmationSuffix):
+		requestID := header(message.Headers, events.RequestIDHeader)
+		if requestID == "" {
+			return
+		}
+		confirmation, err := DecodeConfirmation(message.Value, requestID)
+		if err != nil {
+			c.logger.Warn("Invalid confirmation event", zap.String("event_type", eventType), zap.Error(err))
+			return
+		}
+		c.statuses.Confirm(confirmation)
+	}
+}
+
+// DecodeRejection decodes a message of the rejections topic. ok is false when the
+// message is not an EventRejected event.
+func DecodeRejection(value []byte) (rejection Rejection, ok bool, err error) {
+	var envelope events.Envelope
+	if err := json.Unmarshal(value, &envelope); err != nil {
+		return Rejection{}, false, fmt.Errorf("failed to decode envelope: %w", err)
+	}
+	if envelope.EventType != RejectionEventType {
+		return Rejection{}, false, nil
+	}
+	if err := json.Unmarshal(envelope.Payload, &rejection); err != nil {
+		return Rejection{}, false, fmt.Errorf("failed to decode rejection: %w", err)
+	}
+	return rejection, true, nil
+}
+
+// DecodeConfirmation decodes the envelope of a <Type>Confirmed event of requestID
+func DecodeConfirmation(value []byte, requestID string) (Confirmation, error) {
+	var envelope events.Envelope
+	if err := json.Unmarshal(value, &envelope); err != nil {
+		return Confirmation{}, fmt.Errorf("failed to decode envelope: %w", err)
+	}
+	if !strings.HasSuffix(envelope.EventType, confirmationSuffix) {
+		return Confirmation{}, fmt.Errorf("unexpected event type %q", envelope.EventType)
+	}
+	return Confirmation{
+		RequestID:   requestID,
+		EventType:   strings.TrimSuffix(envelope.EventType, confirmationSuffix),
+		AppliedAt:   envelope.OccurredAt.UTC(),
+		ConfirmedAt: time.Now().UTC(),
+	}, nil
+}
+
+func header(headers []*sarama.RecordHeader, key string) string {
+	for _, h := range headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
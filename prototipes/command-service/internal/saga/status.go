@@ -0,0 +1,249 @@
+package saga
+
+import (
+	"sync"
+	"time"
+)
+
+// Status of a request whose events were published
+const (
+	// StatusAccepted: the events were published and none has been rejected so far
+	StatusAccepted = "accepted"
+	// StatusFailed: the Listener Service could not apply at least one of the events
+	StatusFailed = "failed"
+)
+
+// Stage of a request (or of one of its events) in the pipeline, in order
+const (
+	// StageAccepted: the command was accepted; its events are not published yet
+	StageAccepted = "accepted"
+	// StagePublished: Kafka acknowledged the events
+	StagePublished = "published"
+	// StageConfirmed: the Listener Service applied the events to the read model and
+	// emitted their confirmations, so the change is visible to queries
+	StageConfirmed = "confirmed"
+	// StageFailed: the Listener Service rejected one of the events
+	StageFailed = "failed"
+)
+
+// stageOrder ranks the stages of an event; a request is at the stage of its least
+// advanced event
+var stageOrder = map[string]int{StageAccepted: 0, StagePublished: 1, StageConfirmed: 2}
+
+// Rejection is the payload of an EventRejected event published by the Listener Service
+// for an event it could not apply (after its retries)
+type Rejection struct {
+	EventID    string    `json:"eventId"`
+	EventType  string    `json:"eventType"`
+	RequestID  string    `json:"requestId,omitempty"`
+	Actor      string    `json:"actor,omitempty"`
+	Key        string    `json:"key,omitempty"` // Partition key of the event (item or store ID)
+	Topic      string    `json:"topic"`
+	Partition  int32     `json:"partition"`
+	Offset     int64     `json:"offset"`
+	Reason     string    `json:"reason"`
+	RejectedAt time.Time `json:"rejectedAt"`
+}
+
+// Confirmation is a <Type>Confirmed event of the Listener Service for an event of a request
+type Confirmation struct {
+	RequestID   string
+	EventType   string    // Type of the confirmed event (without the Confirmed suffix)
+	AppliedAt   time.Time // When the listener published the confirmation, right after committing
+	ConfirmedAt time.Time // When this replica received it
+}
+
+// EventStatus is the progress of one event of a request
+type EventStatus struct {
+	Type        string
+	Stage       string
+	PublishedAt time.Time
+	AppliedAt   time.Time
+	ConfirmedAt time.Time
+}
+
+// CommandStatus is what is known about the outcome of a request
+type CommandStatus struct {
+	RequestID  string
+	Status     string
+	Stage      string
+	Events     []EventStatus // Events of the request, in the order they were published
+	Rejections []Rejection
+	AcceptedAt time.Time // Zero when the request was not seen by this replica
+	UpdatedAt  time.Time
+}
+
+// Store keeps the status of recent requests in memory, for ttl after their last change.
+// Every replica keeps its own: it knows the requests it published and every rejection.
+type Store struct {
+	mu       sync.Mutex
+	statuses map[string]*CommandStatus
+	ttl      time.Duration
+	now      func() time.Time
+	purged   time.Time // last purge of the expired statuses
+}
+
+// NewStore creates a store that forgets a request ttl after its last change
+func NewStore(ttl time.Duration) *Store {
+	return &Store{
+		statuses: make(map[string]*CommandStatus),
+		ttl:      ttl,
+		now:      time.Now,
+	}
+}
+
+// Accept records that requestID is about to publish an event of eventType. It returns
+// the index of the event, to be passed to Published once Kafka acknowledges it.
+func (s *Store) Accept(requestID, eventType string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now().UTC()
+	s.purge(now)
+	status := s.get(requestID, now)
+	if status.AcceptedAt.IsZero() {
+		status.AcceptedAt = now
+	}
+	status.Events = append(status.Events, EventStatus{Type: eventType, Stage: StageAccepted})
+	status.UpdatedAt = now
+	s.updateStage(status)
+	return len(status.Events) - 1
+}
+
+// Published records that the event at index of requestID was published
+func (s *Store) Published(requestID string, index int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now().UTC()
+	status, ok := s.statuses[requestID]
+	if !ok || index >= len(status.Events) {
+		// Expired while publishing
+		return
+	}
+	event := &status.Events[index]
+	if event.Stage == StageAccepted {
+		// The confirmation may arrive before the publisher returns
+		event.Stage = StagePublished
+	}
+	event.PublishedAt = now
+	status.UpdatedAt = now
+	s.updateStage(status)
+}
+
+// Confirm records a confirmation of the Listener Service. It confirms the first event of
+// the request of that type not confirmed yet; requests served by another replica only
+// get the confirmed events. Confirmations of events published outside a request are
+// ignored.
+func (s *Store) Confirm(confirmation Confirmation) {
+	if confirmation.RequestID == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now().UTC()
+	s.purge(now)
+	status := s.get(confirmation.RequestID, now)
+	var event *EventStatus
+	for i := range status.Events {
+		if status.Events[i].Type == confirmation.EventType && status.Events[i].ConfirmedAt.IsZero() {
+			event = &status.Events[i]
+			break
+		}
+	}
+	if event == nil {
+		status.Events = append(status.Events, EventStatus{Type: confirmation.EventType})
+		event = &status.Events[len(status.Events)-1]
+	}
+	event.Stage = StageConfirmed
+	event.AppliedAt = confirmation.AppliedAt
+	event.ConfirmedAt = confirmation.ConfirmedAt
+	status.UpdatedAt = now
+	s.updateStage(status)
+}
+
+// Reject marks the request of rejection as failed. Rejections of events published
+// outside a request are ignored.
+func (s *Store) Reject(rejection Rejection) {
+	if rejection.RequestID == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now().UTC()
+	s.purge(now)
+	status := s.get(rejection.RequestID, now)
+	for _, known := range status.Rejections {
+		if known.EventID != "" && known.EventID == rejection.EventID {
+			// Redelivered rejection
+			return
+		}
+	}
+	status.Status = StatusFailed
+	status.Rejections = append(status.Rejections, rejection)
+	status.UpdatedAt = now
+	s.updateStage(status)
+}
+
+// Get returns a copy of the status of requestID
+func (s *Store) Get(requestID string) (CommandStatus, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status, ok := s.statuses[requestID]
+	if !ok || s.expired(status, s.now()) {
+		return CommandStatus{}, false
+	}
+	copied := *status
+	copied.Events = append([]EventStatus(nil), status.Events...)
+	copied.Rejections = append([]Rejection(nil), status.Rejections...)
+	return copied, true
+}
+
+// get returns the status of requestID, creating it if needed. Callers hold mu.
+func (s *Store) get(requestID string, now time.Time) *CommandStatus {
+	status, ok := s.statuses[requestID]
+	if !ok || s.expired(status, now) {
+		status = &CommandStatus{RequestID: requestID, Status: StatusAccepted, Stage: StageAccepted, UpdatedAt: now}
+		s.statuses[requestID] = status
+	}
+	return status
+}
+
+// updateStage sets the stage of the request from those of its events. Callers hold mu.
+func (s *Store) updateStage(status *CommandStatus) {
+	if status.Status == StatusFailed {
+		status.Stage = StageFailed
+		return
+	}
+	if len(status.Events) == 0 {
+		status.Stage = StageAccepted
+		return
+	}
+	stage := StageConfirmed
+	for _, event := range status.Events {
+		if stageOrder[event.Stage] < stageOrder[stage] {
+			stage = event.Stage
+		}
+	}
+	status.Stage = stage
+}
+
+func (s *Store) expired(status *CommandStatus, now time.Time) bool {
+	return now.Sub(status.UpdatedAt) > s.ttl
+}
+
+// purge drops the expired statuses, at most once a minute. Callers hold mu.
+func (s *Store) purge(now time.Time) {
+	if now.Sub(s.purged) < time.Minute {
+		return
+	}
+	s.purged = now
+	for requestID, status := range s.statuses {
+		if s.expired(status, now) {
+			delete(s.statuses, requestID)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"command-service/internal/events"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_AcceptAndReject(t *testing.T) {
+	store := NewStore(time.Hour)
+	store.Published("req-1", store.Accept("req-1", "StockReserved"))
+
+	status, ok := store.Get("req-1")
+	require.True(t, ok)
+	assert.Equal(t, StatusAccepted, status.Status)
+	assert.Equal(t, StagePublished, status.Stage)
+	require.Len(t, status.Events, 1)
+	assert.Equal(t, "StockReserved", status.Events[0].Type)
+	assert.False(t, status.AcceptedAt.IsZero())
+
+	rejection := Rejection{EventID: "evt-1", EventType: "StockReserved", RequestID: "req-1", Reason: "insufficient stock"}
+	store.Reject(rejection)
+	store.Reject(rejection) // redelivered
+
+	status, ok = store.Get("req-1")
+	require.True(t, ok)
+	assert.Equal(t, StatusFailed, status.Status)
+	assert.Equal(t, StageFailed, status.Stage)
+	assert.Len(t, status.Rejections, 1)
+
+	_, ok = store.Get("req-2")
+	assert.False(t, ok)
+}
+
+func TestStore_Stages(t *testing.T) {
+	store := NewStore(time.Hour)
+	first := store.Accept("req-1", "StockAdjusted")
+	second := store.Accept("req-1", "StockAdjusted")
+
+	status, _ := store.Get("req-1")
+	assert.Equal(t, StageAccepted, status.Stage)
+
+	store.Published("req-1", first)
+	store.Published("req-1", second)
+	status, _ = store.Get("req-1")
+	assert.Equal(t, StagePublished, status.Stage)
+
+	appliedAt := time.Now().UTC()
+	store.Confirm(Confirmation{RequestID: "req-1", EventType: "StockAdjusted", AppliedAt: appliedAt, ConfirmedAt: appliedAt})
+	status, _ = store.Get("req-1")
+	assert.Equal(t, StagePublished, status.Stage)
+	assert.Equal(t, StageConfirmed, status.Events[0].Stage)
+	assert.Equal(t, appliedAt, status.Events[0].AppliedAt)
+
+	store.Confirm(Confirmation{RequestID: "req-1", EventType: "StockAdjusted", AppliedAt: appliedAt, ConfirmedAt: appliedAt})
+	status, _ = store.Get("req-1")
+	assert.Equal(t, StageConfirmed, status.Stage)
+	assert.Len(t, status.Events, 2)
+}
+
+func TestStore_ConfirmationBeforePublished(t *testing.T) {
+	store := NewStore(time.Hour)
+	index := store.Accept("req-1", "ItemCreated")
+	store.Confirm(Confirmation{RequestID: "req-1", EventType: "InventoryItemCreated"})
+	store.Confirm(Confirmation{RequestID: "req-1", EventType: "ItemCreated"})
+	store.Published("req-1", index)
+
+	status, _ := store.Get("req-1")
+	assert.Equal(t, StageConfirmed, status.Events[0].Stage)
+	assert.False(t, status.Events[0].PublishedAt.IsZero())
+	// The confirmation of an event this replica did not publish is kept as well
+	assert.Len(t, status.Events, 2)
+	assert.Equal(t, StageConfirmed, status.Stage)
+}
+
+func TestStore_RejectionWithoutRequest(t *testing.T) {
+	store := NewStore(time.Hour)
+	store.Reject(Rejection{EventID: "evt-1", EventType: "StockReserved"})
+	store.Confirm(Confirmation{EventType: "StockReserved"})
+	assert.Empty(t, store.statuses)
+}
+
+func TestStore_Expiry(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	store := NewStore(time.Hour)
+	store.now = func() time.Time { return now }
+	store.Accept("req-1", "ItemCreated")
+
+	now = now.Add(2 * time.Hour)
+	_, ok := store.Get("req-1")
+	assert.False(t, ok)
+
+	store.Accept("req-2", "ItemCreated")
+	assert.NotContains(t, store.statuses, "req-1")
+}
+
+func TestTrackingPublisher(t *testing.T) {
+	store := NewStore(time.Hour)
+	publisher := NewTrackingPublisher(events.NewEventPublisher(), store)
+
+	ctx := context.WithValue(context.Background(), "request_id", "req-1")
+	require.NoError(t, publisher.Publish(ctx, events.StockReservedEvent{SKU: "SKU-001"}))
+	require.NoError(t, publisher.Publish(context.Background(), events.StockReservedEvent{SKU: "SKU-001"}))
+
+	status, ok := store.Get("req-1")
+	require.True(t, ok)
+	require.Len(t, status.Events, 1)
+	assert.Equal(t, "StockReserved", status.Events[0].Type)
+	assert.Equal(t, StagePublished, status.Stage)
+	assert.Len(t, store.statuses, 1)
+}
+
+func TestDecodeRejection(t *testing.T) {
+	payload, _ := json.Marshal(Rejection{EventID: "evt-1", EventType: "StockReserved", RequestID: "req-1", Reason: "insufficient stock"})
+	value, _ := json.Marshal(events.Envelope{EventID: "rej-1", EventType: RejectionEventType, SchemaVersion: events.SchemaVersion, Payload: payload})
+
+	rejection, ok, err := DecodeRejection(value)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "req-1", rejection.RequestID)
+	assert.Equal(t, "insufficient stock", rejection.Reason)
+
+	other, _ := json.Marshal(events.Envelope{EventID: "evt-2", EventType: "ItemCreated", Payload: json.RawMessage(`{}`)})
+	_, ok, err = DecodeRejection(other)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, _, err = DecodeRejection([]byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestDecodeConfirmation(t *testing.T) {
+	occurredAt := time.Date(2024, 1, 15, 10, 30, 1, 0, time.UTC)
+	value, _ := json.Marshal(events.Envelope{EventID: "conf-1", EventType: "StockReservedConfirmed", OccurredAt: occurredAt, Payload: json.RawMessage(`{}`)})
+
+	confirmation, err := DecodeConfirmation(value, "req-1")
+	require.NoError(t, err)
+	assert.Equal(t, "req-1", confirmation.RequestID)
+	assert.Equal(t, "StockReserved", confirmation.EventType)
+	assert.Equal(t, occurredAt, confirmation.AppliedAt)
+
+	other, _ := json.Marshal(events.Envelope{EventID: "evt-1", EventType: "StockReserved", Payload: json.RawMessage(`{}`)})
+	_, err = DecodeConfirmation(other, "req-1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,41 @@
+package saga
+
+import (
+	"context"
+
+	"command-service/internal/events"
+)
+
+// TrackingPublisher records in a Store the events of each request as they are accepted
+// and published, so their progress can be queried until the Listener Service confirms
+// or rejects them
+type TrackingPublisher struct {
+	next     events.EventPublisher
+	statuses *Store
+}
+
+// NewTrackingPublisher wraps next
+func NewTrackingPublisher(next events.EventPublisher, statuses *Store) *TrackingPublisher {
+	return &TrackingPublisher{next: next, statuses: statuses}
+}
+
+// Publish implements events.EventPublisher. Events published outside a request
+// (journal recovery) are not tracked.
+func (p *TrackingPublisher) Publish(ctx context.Context, event interface{}) error {
+	requestID := events.RequestIDFromContext(ctx)
+	if requestID == "" {
+		return p.next.Publish(ctx, event)
+	}
+	index := p.statuses.Accept(requestID, events.TypeOf(event))
+	if err := p.next.Publish(ctx, event); err != nil {
+		// Stays accepted; the journal republishes it at the next start
+		return err
+	}
+	p.statuses.Published(requestID, index)
+	return nil
+}
+
+// Unwrap returns the wrapped publisher (probed by the readiness check)
+func (p *TrackingPublisher) Unwrap() events.EventPublisher {
+	return p.next
+}
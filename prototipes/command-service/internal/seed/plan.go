@@ -0,0 +1,201 @@
+package seed
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Movement kinds, one per Command Service stock endpoint
+const (
+	MovementReceive = "receive" // positive adjust with unit cost
+	MovementShrink  = "shrink"  // negative adjust (damage, loss)
+	MovementReserve = "reserve"
+	MovementRelease = "release"
+	MovementCommit  = "commit"
+)
+
+// StoreSpec is a store to create
+type StoreSpec struct {
+	Code     string
+	Name     string
+	Location string
+}
+
+// ItemSpec is an item to create with its initial stock
+type ItemSpec struct {
+	SKU         string
+	Name        string
+	Description string
+	Quantity    int
+	UnitCost    float64
+}
+
+// MovementSpec is a stock command against a seeded item. Store is the index of the
+// seeded store the reservation belongs to, or -1 for an item-level reservation.
+type MovementSpec struct {
+	Item     int
+	Kind     string
+	Quantity int
+	UnitCost float64
+	Store    int
+}
+
+// Plan is everything a seed run writes, in order
+type Plan struct {
+	Stores    []StoreSpec
+	Items     []ItemSpec
+	Movements []MovementSpec
+}
+
+// PlanOptions sizes a plan
+type PlanOptions struct {
+	Items            int
+	Stores           int
+	MovementsPerItem int
+	Prefix           string // Prepended to SKUs and store codes so runs do not collide
+	Seed             int64  // Same seed, same plan
+}
+
+var (
+	categories = []string{"Laptop", "Monitor", "Teclado", "Mouse", "Auriculares", "Smartphone", "Tablet", "Impresora", "Router", "Disco SSD"}
+	brands     = []string{"Dell", "Lenovo", "HP", "Samsung", "Logitech", "Apple", "Asus", "Acer", "Sony", "Kingston"}
+	variants   = []string{"Pro", "Max", "Lite", "Plus", "Air", "Ultra", "Mini", "X", "S", "Studio"}
+	cities     = []string{"Centro", "Norte", "Sur", "Aeropuerto", "Puerto", "Plaza Mayor", "Outlet", "Estación", "Campus", "Mall"}
+	streets    = []string{"Av. Principal", "Calle Comercio", "Av. Libertador", "Calle Real", "Av. del Puerto"}
+)
+
+// NewPlan builds a plan whose movements are valid when applied in order: quantities
+// never go negative and only reserved stock is released or committed. Movements of
+// different items are interleaved so the event stream looks like real traffic.
+func NewPlan(opts PlanOptions) Plan {
+	rng := rand.New(rand.NewSource(opts.Seed))
+	plan := Plan{}
+
+	for i := 0; i < opts.Stores; i++ {
+		city := cities[i%len(cities)]
+		if i >= len(cities) {
+			city = fmt.Sprintf("%s %d", city, i/len(cities)+1)
+		}
+		plan.Stores = append(plan.Stores, StoreSpec{
+			Code:     fmt.Sprintf("%sSTORE-%03d", opts.Prefix, i+1),
+			Name:     "Tienda " + city,
+			Location: fmt.Sprintf("%s %d", streets[rng.Intn(len(streets))], 100+rng.Intn(900)),
+		})
+	}
+
+	stock := make([]*stockState, opts.Items)
+	for i := 0; i < opts.Items; i++ {
+		category := categories[rng.Intn(len(categories))]
+		brand := brands[rng.Intn(len(brands))]
+		variant := variants[rng.Intn(len(variants))]
+		item := ItemSpec{
+			SKU:         fmt.Sprintf("%s%04d", opts.Prefix, i+1),
+			Name:        fmt.Sprintf("%s %s %s", category, brand, variant),
+			Description: fmt.Sprintf("%s %s de la línea %s (datos de prueba)", category, brand, variant),
+			Quantity:    10 + rng.Intn(190),
+			UnitCost:    roundCents(5 + rng.Float64()*995),
+		}
+		plan.Items = append(plan.Items, item)
+		stock[i] = &stockState{quantity: item.Quantity, unitCost: item.UnitCost, stores: map[int]int{}}
+	}
+
+	for round := 0; round < opts.MovementsPerItem; round++ {
+		for i, state := range stock {
+			plan.Movements = append(plan.Movements, state.next(rng, i, opts.Stores))
+		}
+	}
+	return plan
+}
+
+// stockState tracks an item's counters while its movements are generated
+type stockState struct {
+	quantity int
+	reserved int         // item-level reservations, committable
+	stores   map[int]int // reservations per store, releasable
+	unitCost float64
+}
+
+func (s *stockState) available() int {
+	total := s.reserved
+	for _, reserved := range s.stores {
+		total += reserved
+	}
+	return s.quantity - total
+}
+
+// next picks a movement that is valid for the current counters and applies it
+func (s *stockState) next(rng *rand.Rand, item, stores int) MovementSpec {
+	var kinds []string
+	if s.available() > 0 {
+		kinds = append(kinds, MovementReserve, MovementReserve, MovementShrink)
+	}
+	if s.reserved > 0 {
+		kinds = append(kinds, MovementCommit, MovementCommit, MovementRelease)
+	}
+	if len(s.stores) > 0 {
+		kinds = append(kinds, MovementRelease)
+	}
+	kinds = append(kinds, MovementReceive)
+
+	switch kind := kinds[rng.Intn(len(kinds))]; kind {
+	case MovementReserve:
+		quantity := 1 + rng.Intn(min(5, s.available()))
+		store := -1
+		if stores > 0 && rng.Intn(2) == 0 {
+			store = rng.Intn(stores)
+			s.stores[store] += quantity
+		} else {
+			s.reserved += quantity
+		}
+		return MovementSpec{Item: item, Kind: kind, Quantity: quantity, Store: store}
+	case MovementShrink:
+		quantity := 1 + rng.Intn(min(3, s.available()))
+		s.quantity -= quantity
+		return MovementSpec{Item: item, Kind: kind, Quantity: quantity, Store: -1}
+	case MovementCommit:
+		quantity := 1 + rng.Intn(s.reserved)
+		s.reserved -= quantity
+		s.quantity -= quantity
+		return MovementSpec{Item: item, Kind: kind, Quantity: quantity, Store: -1}
+	case MovementRelease:
+		// Prefer a store reservation when there is one (lowest store first, so the
+		// plan does not depend on map order)
+		if store := s.firstStore(stores); store >= 0 {
+			quantity := 1 + rng.Intn(s.stores[store])
+			if s.stores[store] -= quantity; s.stores[store] == 0 {
+				delete(s.stores, store)
+			}
+			return MovementSpec{Item: item, Kind: kind, Quantity: quantity, Store: store}
+		}
+		quantity := 1 + rng.Intn(s.reserved)
+		s.reserved -= quantity
+		return MovementSpec{Item: item, Kind: kind, Quantity: quantity, Store: -1}
+	default:
+		quantity := 5 + rng.Intn(50)
+		// Purchase prices drift a little between receptions
+		cost := roundCents(s.unitCost * (0.9 + rng.Float64()*0.2))
+		s.quantity += quantity
+		return MovementSpec{Item: item, Kind: MovementReceive, Quantity: quantity, UnitCost: cost, Store: -1}
+	}
+}
+
+// firstStore returns the lowest store index holding a reservation, or -1
+func (s *stockState) firstStore(stores int) int {
+	for store := 0; store < stores; store++ {
+		if s.stores[store] > 0 {
+			return store
+		}
+	}
+	return -1
+}
+
+func roundCents(value float64) float64 {
+	return float64(int(value*100+0.5)) / 100
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,250 @@
+package seed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ErrRemoteTarget is returned when the Command Service URL is not a local or
+// compose-internal host and the run was not forced
+var ErrRemoteTarget = errors.New("refusing to seed a non-local Command Service")
+
+// maxRetries bounds how many times a write throttled with 429/503 is retried
+const maxRetries = 5
+
+// Summary counts what a run wrote
+type Summary struct {
+	Stores    int
+	Items     int
+	Movements int
+	Failed    int // Writes rejected by the service (logged, the run goes on)
+	Throttled int // 429/503 responses waited out
+}
+
+// Runner writes a plan through the Command Service HTTP API, so every write goes
+// through authentication, validation, rate limiting and event publishing exactly
+// like a client's would.
+type Runner struct {
+	baseURL  string
+	username string
+	password string
+	client   *http.Client
+	logger   *zap.Logger
+
+	token    string
+	storeIDs []string
+	itemIDs  []string
+}
+
+// NewRunner creates a runner against the Command Service at baseURL
+func NewRunner(baseURL, username, password string, logger *zap.Logger) *Runner {
+	return &Runner{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		username: username,
+		password: password,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		logger:   logger,
+	}
+}
+
+// CheckTarget refuses hosts that could be shared environments: only localhost,
+// loopback addresses and single-label names (docker compose services) are allowed
+func CheckTarget(baseURL string) error {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Host == "" {
+		return fmt.Errorf("invalid Command Service URL %q", baseURL)
+	}
+	host := parsed.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.IsLoopback() {
+			return nil
+		}
+	} else if host == "localhost" || !strings.Contains(host, ".") {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrRemoteTarget, host)
+}
+
+// Run logs in and writes the stores, the items and then the movements
+func (r *Runner) Run(ctx context.Context, plan Plan) (Summary, error) {
+	var summary Summary
+	if err := r.login(ctx); err != nil {
+		return summary, err
+	}
+
+	for _, store := range plan.Stores {
+		var created struct {
+			ID string `json:"id"`
+		}
+		err := r.post(ctx, "/api/v1/stores", map[string]string{
+			"code":     store.Code,
+			"name":     store.Name,
+			"location": store.Location,
+		}, &created, &summary)
+		if err != nil {
+			return summary, fmt.Errorf("failed to create store %s: %w", store.Code, err)
+		}
+		r.storeIDs = append(r.storeIDs, created.ID)
+		summary.Stores++
+	}
+
+	for _, item := range plan.Items {
+		var created struct {
+			ID string `json:"id"`
+		}
+		err := r.post(ctx, "/api/v1/inventory/items", map[string]interface{}{
+			"sku":         item.SKU,
+			"name":        item.Name,
+			"description": item.Description,
+			"quantity":    item.Quantity,
+			"unit_cost":   item.UnitCost,
+		}, &created, &summary)
+		if err != nil {
+			return summary, fmt.Errorf("failed to create item %s: %w", item.SKU, err)
+		}
+		r.itemIDs = append(r.itemIDs, created.ID)
+		summary.Items++
+	}
+
+	for _, movement := range plan.Movements {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+		if err := r.apply(ctx, movement, &summary); err != nil {
+			// Another client may have moved the same stock: skip and go on
+			summary.Failed++
+			r.logger.Warn("Seed movement rejected",
+				zap.String("item_id", r.itemIDs[movement.Item]),
+				zap.String("kind", movement.Kind),
+				zap.Int("quantity", movement.Quantity),
+				zap.Error(err),
+			)
+			continue
+		}
+		summary.Movements++
+	}
+	return summary, nil
+}
+
+// apply sends the stock command of a movement
+func (r *Runner) apply(ctx context.Context, movement MovementSpec, summary *Summary) error {
+	path := "/api/v1/inventory/items/" + r.itemIDs[movement.Item]
+	body := map[string]interface{}{"quantity": movement.Quantity}
+	switch movement.Kind {
+	case MovementReceive:
+		path += "/adjust"
+		body["unit_cost"] = movement.UnitCost
+	case MovementShrink:
+		path += "/adjust"
+		body["quantity"] = -movement.Quantity
+	case MovementReserve, MovementRelease, MovementCommit:
+		path += "/" + movement.Kind
+	default:
+		return fmt.Errorf("unknown movement kind %q", movement.Kind)
+	}
+	if movement.Store >= 0 {
+		path += "?store_id=" + url.QueryEscape(r.storeIDs[movement.Store])
+	}
+	return r.post(ctx, path, body, nil, summary)
+}
+
+func (r *Runner) login(ctx context.Context) error {
+	var response struct {
+		Token string `json:"token"`
+	}
+	err := r.post(ctx, "/api/v1/auth/login", map[string]string{
+		"username": r.username,
+		"password": r.password,
+	}, &response, &Summary{})
+	if err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+	r.token = response.Token
+	return nil
+}
+
+// post sends a JSON command, waiting out 429/503 responses as their Retry-After asks,
+// and decodes a 2xx response into out (if not nil)
+func (r *Runner) post(ctx context.Context, path string, body, out interface{}, summary *Summary) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal seed command: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.baseURL+path, bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("failed to build seed request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json; envelope=false") // decoded below, even with RESPONSE_ENVELOPE=true
+		req.Header.Set("X-Request-ID", uuid.New().String())
+		if r.token != "" {
+			req.Header.Set("Authorization", "Bearer "+r.token)
+		}
+
+		resp, err := r.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("seed write failed: %w", err)
+		}
+
+		throttled := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+		if throttled && attempt < maxRetries {
+			wait := retryAfter(resp.Header.Get("Retry-After"))
+			resp.Body.Close()
+			summary.Throttled++
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+			continue
+		}
+
+		err = decodeResponse(resp, path, out)
+		resp.Body.Close()
+		return err
+	}
+}
+
+func decodeResponse(resp *http.Response, path string, out interface{}) error {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		detail := apiErr.Code
+		if apiErr.Message != "" {
+			detail += ": " + apiErr.Message
+		}
+		return fmt.Errorf("%s returned %d: %s", path, resp.StatusCode, detail)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", path, err)
+	}
+	return nil
+}
+
+// retryAfter reads a Retry-After in seconds, defaulting to one second if missing
+func retryAfter(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return time.Second
+}
@@ -0,0 +1,121 @@
+package seed
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNewPlan_MovementsAreValidInOrder(t *testing.T) {
+	opts := PlanOptions{Items: 20, Stores: 3, MovementsPerItem: 30, Prefix: "T-", Seed: 42}
+	plan := NewPlan(opts)
+	require.Len(t, plan.Stores, 3)
+	require.Len(t, plan.Items, 20)
+	require.Len(t, plan.Movements, 600)
+	assert.Equal(t, NewPlan(opts), plan, "same seed must give the same plan")
+	assert.Equal(t, "T-0001", plan.Items[0].SKU)
+	assert.Equal(t, "T-STORE-001", plan.Stores[0].Code)
+
+	// Replay the movements as the Command Service would
+	quantity := make([]int, len(plan.Items))
+	reserved := make([]int, len(plan.Items))
+	storeReserved := make([]map[int]int, len(plan.Items))
+	for i, item := range plan.Items {
+		quantity[i] = item.Quantity
+		storeReserved[i] = map[int]int{}
+	}
+	for _, m := range plan.Movements {
+		require.Greater(t, m.Quantity, 0)
+		switch m.Kind {
+		case MovementReceive:
+			quantity[m.Item] += m.Quantity
+		case MovementShrink:
+			quantity[m.Item] -= m.Quantity
+		case MovementReserve:
+			if m.Store >= 0 {
+				storeReserved[m.Item][m.Store] += m.Quantity
+			} else {
+				reserved[m.Item] += m.Quantity
+			}
+		case MovementRelease:
+			if m.Store >= 0 {
+				storeReserved[m.Item][m.Store] -= m.Quantity
+				require.GreaterOrEqual(t, storeReserved[m.Item][m.Store], 0)
+			} else {
+				reserved[m.Item] -= m.Quantity
+			}
+		case MovementCommit:
+			reserved[m.Item] -= m.Quantity
+			quantity[m.Item] -= m.Quantity
+		}
+		total := reserved[m.Item]
+		for _, r := range storeReserved[m.Item] {
+			total += r
+		}
+		require.GreaterOrEqual(t, reserved[m.Item], 0, "%+v", m)
+		require.GreaterOrEqual(t, quantity[m.Item], total, "%+v", m)
+	}
+}
+
+func TestRunner_WritesThroughTheAPI(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	throttled := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path != "/api/v1/auth/login" {
+			assert.Equal(t, "Bearer token-1", r.Header.Get("Authorization"))
+		}
+		switch {
+		case r.URL.Path == "/api/v1/auth/login":
+			json.NewEncoder(w).Encode(map[string]string{"token": "token-1"})
+			return
+		case strings.HasSuffix(r.URL.Path, "/reserve") && !throttled:
+			// The rate limiter rejects once; the runner waits and retries
+			throttled = true
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		paths = append(paths, r.URL.RequestURI())
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"id": "id-" + string(rune('a'+len(paths)))})
+	}))
+	defer server.Close()
+
+	plan := Plan{
+		Stores: []StoreSpec{{Code: "S-1", Name: "Tienda Centro"}},
+		Items:  []ItemSpec{{SKU: "SKU-1", Name: "Mouse", Quantity: 10}},
+		Movements: []MovementSpec{
+			{Item: 0, Kind: MovementReserve, Quantity: 2, Store: 0},
+			{Item: 0, Kind: MovementShrink, Quantity: 1, Store: -1},
+		},
+	}
+	summary, err := NewRunner(server.URL+"/", "admin", "admin123", zap.NewNop()).Run(context.Background(), plan)
+	require.NoError(t, err)
+	assert.Equal(t, Summary{Stores: 1, Items: 1, Movements: 2, Throttled: 1}, summary)
+	assert.Equal(t, []string{
+		"/api/v1/stores",
+		"/api/v1/inventory/items",
+		"/api/v1/inventory/items/id-c/reserve?store_id=id-b",
+		"/api/v1/inventory/items/id-c/adjust",
+	}, paths)
+}
+
+func TestCheckTarget(t *testing.T) {
+	for _, target := range []string{"http://localhost:8080", "http://127.0.0.1:8080", "http://command-service:8080", "http://[::1]:8080"} {
+		assert.NoError(t, CheckTarget(target), target)
+	}
+	assert.ErrorIs(t, CheckTarget("https://api.example.com"), ErrRemoteTarget)
+	assert.ErrorIs(t, CheckTarget("http://10.0.0.5:8080"), ErrRemoteTarget)
+	assert.Error(t, CheckTarget("localhost"))
+}
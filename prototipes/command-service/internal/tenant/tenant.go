@@ -0,0 +1,46 @@
+// Package tenant carries the tenant (the retail brand) a request acts for. Items, stores
+// and their events belong to one tenant, and repositories only see the tenant of the
+// context they are called with.
+package tenant
+
+import (
+	"context"
+	"regexp"
+)
+
+// Default is the tenant of tokens, users and rows created before multi-tenancy. Its
+// users administer every tenant.
+const Default = "default"
+
+// ContextKey is the key of the tenant in the gin context and the request context, like
+// "username"
+const ContextKey = "tenant_id"
+
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Valid reports whether id can be used as a tenant: lowercase letters, digits, '-' and
+// '_', up to 64 characters. Tenants end up in Kafka keys and cache keys.
+func Valid(id string) bool {
+	return validID.MatchString(id)
+}
+
+// WithTenant returns a copy of ctx that acts for tenant id
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ContextKey, id)
+}
+
+// FromContext returns the tenant ctx acts for, Default when none was set
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(ContextKey).(string); ok && id != "" {
+		return id
+	}
+	return Default
+}
+
+// OrDefault returns id, or Default when it is empty (tokens and rows without tenant)
+func OrDefault(id string) string {
+	if id == "" {
+		return Default
+	}
+	return id
+}
@@ -0,0 +1,23 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, Default, FromContext(context.Background()))
+	assert.Equal(t, Default, FromContext(WithTenant(context.Background(), "")))
+	assert.Equal(t, "brand-a", FromContext(WithTenant(context.Background(), "brand-a")))
+}
+
+func TestValid(t *testing.T) {
+	for _, id := range []string{"default", "brand-a", "brand_b", "b2"} {
+		assert.True(t, Valid(id), id)
+	}
+	for _, id := range []string{"", "Brand", "-brand", "brand a", "brand/a", string(make([]byte, 65))} {
+		assert.False(t, Valid(id), id)
+	}
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// HTTP metrics
+var (
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method, route and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// Kafka metrics
+var (
+	// KafkaMessagesPublished counts events by outcome (success, error); a publish that
+	// succeeds after retries counts once
+	KafkaMessagesPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_messages_published_total",
+		Help: "Kafka messages published by topic, event type and outcome.",
+	}, []string{"topic", "event_type", "outcome"})
+
+	// KafkaPublishDuration covers the whole publish, retries and backoff included
+	KafkaPublishDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kafka_publish_duration_seconds",
+		Help:    "Time to publish an event to Kafka, retries included.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	// EventPublishesInFlight is the number of publishes (retries included) not finished
+	// yet, plus Kafka sends that outlived their attempt; shutdown waits for it to drop to
+	// zero before closing the producer
+	EventPublishesInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "event_publishes_in_flight",
+		Help: "Event publishes in progress, retries included.",
+	})
+
+	// KafkaAsyncQueueLength is the number of events waiting in the local queue of the
+	// async publisher (new events and retries), not yet handed to the producer
+	KafkaAsyncQueueLength = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "kafka_async_queue_length",
+		Help: "Events waiting in the local queue of the async Kafka publisher.",
+	})
+
+	// KafkaAsyncEvents counts what the async publisher did with each event: queued,
+	// rejected (queue full, the request fails), retried or failed (dropped after retries)
+	KafkaAsyncEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_async_events_total",
+		Help: "Events handled by the async Kafka publisher by result.",
+	}, []string{"result"})
+)
+
+// Audit metrics
+var (
+	// AuditRecords counts the audit entries written to each sink by outcome (success, error)
+	AuditRecords = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "audit_records_total",
+		Help: "Audit log entries written by sink and outcome.",
+	}, []string{"sink", "outcome"})
+)
+
+// Idempotency metrics
+var (
+	// IdempotencyChecks counts the write requests checked against the idempotency store by
+	// result: hit (answered with the stored response), miss (processed) or mismatch (422:
+	// the ID was stored for a different request)
+	IdempotencyChecks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "idempotency_checks_total",
+		Help: "Write requests checked against the idempotency store by result.",
+	}, []string{"result"})
+)
+
+// GinMiddleware records the latency and status of every request. Requests that
+// match no route are grouped under "unmatched" to keep label cardinality bounded.
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequestDuration.WithLabelValues(
+			c.Request.Method, route, strconv.Itoa(c.Writer.Status()),
+		).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
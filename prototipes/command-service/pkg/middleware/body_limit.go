@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+
+	"command-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultMaxBodyBytes is the request body limit used when none is configured (1 MiB)
+const DefaultMaxBodyBytes int64 = 1 << 20
+
+// BodyLimit caps request bodies at maxBytes. Requests that declare a larger Content-Length are
+// rejected with 413 before the body is read; streamed bodies fail when the limit is crossed,
+// which validation.BindJSON reports as PayloadTooLarge.
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	return BodyLimitRoutes(maxBytes, nil)
+}
+
+// BodyLimitRoutes is BodyLimit with per-route limits, keyed by "METHOD /route/:param"
+// (e.g. "POST /api/v1/inventory/items/:id/images"), for routes that take uploads
+func BodyLimitRoutes(maxBytes int64, routes map[string]int64) gin.HandlerFunc {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBodyBytes
+	}
+	return func(c *gin.Context) {
+		limit := maxBytes
+		if routeLimit, ok := routes[c.Request.Method+" "+c.FullPath()]; ok {
+			limit = routeLimit
+		}
+		if c.Request.ContentLength > limit {
+			errors.Respond(c, errors.NewPayloadTooLarge(limit))
+			return
+		}
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+		c.Next()
+	}
+}
@@ -1,30 +1,118 @@
 package middleware
 
 import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"command-service/pkg/errors"
+
 	"github.com/gin-gonic/gin"
 )
 
-// CORSMiddleware configura los headers CORS para permitir todas las peticiones
-// desde cualquier origen. Esto es necesario cuando el dashboard HTML se sirve
-// desde un puerto diferente (8000) y necesita hacer peticiones directas al
-// Command Service (8080).
-func CORSMiddleware() gin.HandlerFunc {
+// corsAllowedMethods son los métodos que expone la API
+const corsAllowedMethods = "GET, POST, PUT, DELETE, OPTIONS, PATCH"
+
+// CORSConfig define qué orígenes pueden llamar a la API desde un navegador
+type CORSConfig struct {
+	AllowedOrigins []string // Orígenes exactos ("http://localhost:8000"); "*" permite cualquiera sin credenciales
+	AllowedHeaders []string
+	MaxAgeSeconds  int // Tiempo que el navegador cachea el preflight
+}
+
+// NewCORSConfig valida la configuración CORS: cada origen debe ser "*" o
+// scheme://host[:port], sin path.
+func NewCORSConfig(origins, headers []string, maxAgeSeconds int) (CORSConfig, error) {
+	cfg := CORSConfig{
+		AllowedHeaders: headers,
+		MaxAgeSeconds:  maxAgeSeconds,
+	}
+	for _, origin := range origins {
+		normalized, err := normalizeOrigin(origin)
+		if err != nil {
+			return CORSConfig{}, err
+		}
+		cfg.AllowedOrigins = append(cfg.AllowedOrigins, normalized)
+	}
+	if maxAgeSeconds < 0 {
+		return CORSConfig{}, fmt.Errorf("invalid CORS max age %d: must not be negative", maxAgeSeconds)
+	}
+	return cfg, nil
+}
+
+func normalizeOrigin(origin string) (string, error) {
+	origin = strings.TrimSpace(origin)
+	if origin == "*" {
+		return origin, nil
+	}
+	parsed, err := url.Parse(strings.TrimSuffix(origin, "/"))
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" || parsed.Path != "" || parsed.RawQuery != "" {
+		return "", fmt.Errorf("invalid CORS origin %q: expected scheme://host[:port]", origin)
+	}
+	return strings.ToLower(parsed.Scheme + "://" + parsed.Host), nil
+}
+
+// CORSMiddleware responde a los navegadores según los orígenes configurados. Un origen
+// permitido recibe su propio valor en Access-Control-Allow-Origin junto con
+// Access-Control-Allow-Credentials; con "*" se responde "*" sin credenciales (los
+// navegadores rechazan la combinación). Los preflight de orígenes no permitidos
+// reciben 403. Las peticiones sin Origin (curl, otros servicios) no se ven afectadas.
+func CORSMiddleware(cfg CORSConfig) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(cfg.AllowedOrigins))
+	allowAny := false
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			allowAny = true
+			continue
+		}
+		allowed[origin] = true
+	}
+	allowedHeaders := strings.Join(cfg.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(cfg.MaxAgeSeconds)
+
 	return func(c *gin.Context) {
-		// Configurar headers CORS
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, Accept, X-Request-ID")
-		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Max-Age", "3600")
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions
+
+		if origin == "" {
+			if preflight {
+				c.AbortWithStatus(http.StatusNoContent)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		// La respuesta depende del Origin: los caches no deben compartirla entre orígenes
+		c.Writer.Header().Add("Vary", "Origin")
+
+		normalized, err := normalizeOrigin(origin)
+		switch {
+		case err == nil && allowed[normalized]:
+			c.Header("Access-Control-Allow-Origin", origin)
+			c.Header("Access-Control-Allow-Credentials", "true")
+		case allowAny:
+			c.Header("Access-Control-Allow-Origin", "*")
+		default:
+			if preflight {
+				errors.Respond(c, errors.NewForbidden("origin not allowed", "Origin: "+origin))
+				return
+			}
+			c.Next()
+			return
+		}
+		c.Header("Access-Control-Allow-Methods", corsAllowedMethods)
+		c.Header("Access-Control-Allow-Headers", allowedHeaders)
+		c.Header("Access-Control-Max-Age", maxAge)
 
 		// Manejar preflight requests (OPTIONS)
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204) // No Content
+		if preflight {
+			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
 
-		// Continuar con el siguiente handler
 		c.Next()
 	}
 }
-
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupEnvelopeRouter(enabledByDefault bool, annotators ...EnvelopeAnnotator) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestIDMiddleware(zap.NewNop()))
+	router.Use(ResponseEnvelope(enabledByDefault, annotators...))
+	router.GET("/items", func(c *gin.Context) {
+		AddWarning(c, "quantity rounded down")
+		c.JSON(http.StatusOK, gin.H{"total": 1})
+	})
+	router.GET("/missing", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+	})
+	router.GET("/text", func(c *gin.Context) {
+		c.String(http.StatusOK, "plain")
+	})
+	return router
+}
+
+func TestResponseEnvelope_AcceptParameter(t *testing.T) {
+	router := setupEnvelopeRouter(false, func(ctx context.Context) (context.Context, func(*EnvelopeMeta)) {
+		return ctx, func(meta *EnvelopeMeta) { meta.Cache = "hit" }
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set("Accept", "application/json; envelope=true")
+	req.Header.Set(RequestIDHeader, "req-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var envelope struct {
+		Data map[string]interface{} `json:"data"`
+		Meta EnvelopeMeta           `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &envelope))
+	assert.Equal(t, float64(1), envelope.Data["total"])
+	assert.Equal(t, "req-123", envelope.Meta.RequestID)
+	assert.Equal(t, "hit", envelope.Meta.Cache)
+	assert.Equal(t, []string{"quantity rounded down"}, envelope.Meta.Warnings)
+	assert.GreaterOrEqual(t, envelope.Meta.DurationMs, float64(0))
+}
+
+func TestResponseEnvelope_DisabledByDefault(t *testing.T) {
+	router := setupEnvelopeRouter(false)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+	assert.JSONEq(t, `{"total":1}`, w.Body.String())
+}
+
+func TestResponseEnvelope_EnabledByConfig(t *testing.T) {
+	router := setupEnvelopeRouter(true)
+
+	// Errors keep their status code and are wrapped too
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	var envelope Envelope
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &envelope))
+	assert.JSONEq(t, `{"error":"item not found"}`, string(envelope.Data))
+	assert.Empty(t, envelope.Meta.Cache)
+	assert.NotNil(t, envelope.Meta.Warnings)
+
+	// Clients can opt out per request
+	req := httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set("Accept", "application/json; envelope=false")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.JSONEq(t, `{"total":1}`, w.Body.String())
+}
+
+func TestResponseEnvelope_PassesNonJSONThrough(t *testing.T) {
+	router := setupEnvelopeRouter(true)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/text", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "plain", w.Body.String())
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"command-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestErrorEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RecoveryHandler(zap.NewNop()), RequestIDMiddleware(zap.NewNop()), ErrorHandler(zap.NewNop()))
+	router.GET("/respond", func(c *gin.Context) {
+		errors.Respond(c, errors.NewItemNotFound("42"))
+	})
+	router.GET("/context-error", func(c *gin.Context) {
+		c.Error(errors.NewValidationError("invalid request", "sku"))
+		c.Abort()
+	})
+	router.GET("/plain-error", func(c *gin.Context) {
+		c.Error(assert.AnError)
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	// Handlers, middleware errors, unknown errors and panics all answer with the same envelope
+	for path, want := range map[string]struct {
+		status int
+		code   string
+	}{
+		"/respond":       {http.StatusNotFound, errors.CodeItemNotFound},
+		"/context-error": {http.StatusBadRequest, errors.CodeValidationError},
+		"/plain-error":   {http.StatusInternalServerError, errors.CodeInternalError},
+		"/panic":         {http.StatusInternalServerError, errors.CodeInternalError},
+	} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set(RequestIDHeader, "req-"+path)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, want.status, w.Code, path)
+		var body errors.StandardError
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), path)
+		assert.Equal(t, want.code, body.Code, path)
+		assert.NotEmpty(t, body.Message, path)
+		assert.Equal(t, "req-"+path, body.RequestID, path)
+		assert.WithinDuration(t, time.Now(), body.Timestamp, time.Minute, path)
+	}
+}
@@ -0,0 +1,339 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"command-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Lane identifies a priority lane of the write queue. Lower values are served first.
+type Lane int
+
+const (
+	// LaneHigh is used for latency sensitive operations (reserve/release)
+	LaneHigh Lane = iota
+	// LaneNormal is used for regular write operations
+	LaneNormal
+	// LaneLow is used for bulk operations (imports, batch jobs)
+	LaneLow
+
+	laneCount = 3
+)
+
+// String returns the lane name used in logs and metrics
+func (l Lane) String() string {
+	switch l {
+	case LaneHigh:
+		return "high"
+	case LaneNormal:
+		return "normal"
+	case LaneLow:
+		return "low"
+	default:
+		return "unknown"
+	}
+}
+
+// LaneConfig configures the concurrency and queue depth of a lane
+type LaneConfig struct {
+	MaxConcurrent int // Maximum number of requests of this lane executing at once
+	MaxQueued     int // Maximum number of requests of this lane waiting for a slot
+}
+
+// PriorityQueueConfig configures the prioritized work queue
+type PriorityQueueConfig struct {
+	Capacity int                   // Total execution slots shared by all lanes
+	Lanes    [laneCount]LaneConfig // Per-lane limits, indexed by Lane
+	MaxWait  time.Duration         // Maximum time a request may wait for a slot
+}
+
+// LaneStats is a snapshot of a lane's queue-depth metrics
+type LaneStats struct {
+	Lane          string `json:"lane"`
+	Running       int    `json:"running"`
+	Queued        int    `json:"queued"`
+	MaxConcurrent int    `json:"max_concurrent"`
+	MaxQueued     int    `json:"max_queued"`
+	Admitted      uint64 `json:"admitted"`
+	Rejected      uint64 `json:"rejected"`
+	TimedOut      uint64 `json:"timed_out"`
+}
+
+// QueueStats is a snapshot of the whole priority queue
+type QueueStats struct {
+	Capacity int         `json:"capacity"`
+	Running  int         `json:"running"`
+	Lanes    []LaneStats `json:"lanes"`
+}
+
+var (
+	// ErrQueueFull is returned when the lane's wait queue is saturated
+	ErrQueueFull = &QueueError{Message: "priority lane is saturated"}
+	// ErrQueueTimeout is returned when a request waited longer than MaxWait
+	ErrQueueTimeout = &QueueError{Message: "timed out waiting for an execution slot"}
+)
+
+// QueueError is returned when a request cannot be admitted by the priority queue
+type QueueError struct {
+	Message string
+}
+
+func (e *QueueError) Error() string {
+	return e.Message
+}
+
+type laneState struct {
+	cfg      LaneConfig
+	running  int
+	waiting  []chan struct{}
+	admitted uint64
+	rejected uint64
+	timedOut uint64
+}
+
+// PriorityQueue admits requests into a bounded pool of execution slots.
+// When a slot is freed it is handed to the highest priority lane that has
+// waiters, so reserve/release traffic preempts bulk work during load peaks.
+type PriorityQueue struct {
+	mu       sync.Mutex
+	capacity int
+	running  int
+	maxWait  time.Duration
+	lanes    [laneCount]*laneState
+}
+
+// NewPriorityQueue creates a new prioritized work queue
+func NewPriorityQueue(cfg PriorityQueueConfig) *PriorityQueue {
+	q := &PriorityQueue{
+		capacity: cfg.Capacity,
+		maxWait:  cfg.MaxWait,
+	}
+	if q.capacity <= 0 {
+		q.capacity = 1
+	}
+	for i := range q.lanes {
+		laneCfg := cfg.Lanes[i]
+		if laneCfg.MaxConcurrent <= 0 || laneCfg.MaxConcurrent > q.capacity {
+			laneCfg.MaxConcurrent = q.capacity
+		}
+		q.lanes[i] = &laneState{cfg: laneCfg}
+	}
+	return q
+}
+
+// Acquire blocks until the request obtains an execution slot in the given lane.
+// It returns ErrQueueFull when the lane's queue is saturated and ErrQueueTimeout
+// when the slot was not granted within MaxWait.
+func (q *PriorityQueue) Acquire(ctx context.Context, lane Lane) error {
+	q.mu.Lock()
+	l := q.lanes[lane]
+
+	if q.canRun(lane) {
+		l.running++
+		q.running++
+		l.admitted++
+		q.mu.Unlock()
+		return nil
+	}
+
+	if len(l.waiting) >= l.cfg.MaxQueued {
+		l.rejected++
+		q.mu.Unlock()
+		return ErrQueueFull
+	}
+
+	ready := make(chan struct{})
+	l.waiting = append(l.waiting, ready)
+	q.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if q.maxWait > 0 {
+		timer := time.NewTimer(q.maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		return q.abandon(lane, ready, ctx.Err())
+	case <-timeout:
+		return q.abandon(lane, ready, ErrQueueTimeout)
+	}
+}
+
+// Release frees the slot held by a request of the given lane
+func (q *PriorityQueue) Release(lane Lane) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.lanes[lane].running--
+	q.running--
+	q.dispatch()
+}
+
+// Stats returns a snapshot of the queue-depth metrics
+func (q *PriorityQueue) Stats() QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := QueueStats{
+		Capacity: q.capacity,
+		Running:  q.running,
+		Lanes:    make([]LaneStats, 0, laneCount),
+	}
+	for i, l := range q.lanes {
+		stats.Lanes = append(stats.Lanes, LaneStats{
+			Lane:          Lane(i).String(),
+			Running:       l.running,
+			Queued:        len(l.waiting),
+			MaxConcurrent: l.cfg.MaxConcurrent,
+			MaxQueued:     l.cfg.MaxQueued,
+			Admitted:      l.admitted,
+			Rejected:      l.rejected,
+			TimedOut:      l.timedOut,
+		})
+	}
+	return stats
+}
+
+// canRun reports whether a new request of the lane may start right away.
+// Must be called with q.mu held.
+func (q *PriorityQueue) canRun(lane Lane) bool {
+	if q.running >= q.capacity || q.lanes[lane].running >= q.lanes[lane].cfg.MaxConcurrent {
+		return false
+	}
+	// Do not jump ahead of requests already waiting in this lane, or in a
+	// higher priority lane that is only blocked by the shared capacity
+	for i := LaneHigh; i <= lane; i++ {
+		l := q.lanes[i]
+		if len(l.waiting) > 0 && (i == lane || l.running < l.cfg.MaxConcurrent) {
+			return false
+		}
+	}
+	return true
+}
+
+// dispatch hands free slots to waiters, highest priority first.
+// Must be called with q.mu held.
+func (q *PriorityQueue) dispatch() {
+	for _, l := range q.lanes {
+		for len(l.waiting) > 0 && q.running < q.capacity && l.running < l.cfg.MaxConcurrent {
+			ready := l.waiting[0]
+			l.waiting = l.waiting[1:]
+			l.running++
+			l.admitted++
+			q.running++
+			close(ready)
+		}
+	}
+}
+
+// abandon removes a waiter that gave up. If the slot was granted concurrently
+// it is released again so that it is not leaked.
+func (q *PriorityQueue) abandon(lane Lane, ready chan struct{}, cause error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	l := q.lanes[lane]
+	for i, w := range l.waiting {
+		if w == ready {
+			l.waiting = append(l.waiting[:i], l.waiting[i+1:]...)
+			l.timedOut++
+			return cause
+		}
+	}
+
+	// The slot was granted between the timeout firing and acquiring the lock
+	l.running--
+	q.running--
+	q.dispatch()
+	l.timedOut++
+	return cause
+}
+
+// LaneClassifier decides which lane a request belongs to
+type LaneClassifier func(c *gin.Context) Lane
+
+// lowPriorityRoutes are the bulk write routes: a reconciliation adjusts up to 5000
+// items in one request
+var lowPriorityRoutes = map[string]bool{
+	"/api/v1/inventory/reconciliation": true,
+}
+
+// NewLaneClassifier returns the default classifier: reserve/release routes go to
+// the high lane, bulk routes and requests from low priority users go to the low
+// lane, and everything else to the normal lane.
+func NewLaneClassifier(lowPriorityUsers []string) LaneClassifier {
+	lowUsers := make(map[string]bool, len(lowPriorityUsers))
+	for _, u := range lowPriorityUsers {
+		if u = strings.TrimSpace(u); u != "" {
+			lowUsers[u] = true
+		}
+	}
+
+	return func(c *gin.Context) Lane {
+		path := c.FullPath()
+		if path == "" {
+			path = c.Request.URL.Path
+		}
+
+		switch {
+		case strings.HasSuffix(path, "/reserve"), strings.HasSuffix(path, "/release"):
+			return LaneHigh
+		case lowPriorityRoutes[path]:
+			return LaneLow
+		}
+
+		if lowUsers[c.GetString("username")] {
+			return LaneLow
+		}
+		return LaneNormal
+	}
+}
+
+// PriorityQueueMiddleware admits write requests through the priority queue and
+// rejects them with 503 when their lane is saturated
+func PriorityQueueMiddleware(queue *PriorityQueue, classify LaneClassifier, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == "GET" || c.Request.Method == "HEAD" || c.Request.Method == "OPTIONS" {
+			c.Next()
+			return
+		}
+
+		lane := classify(c)
+		if err := queue.Acquire(c.Request.Context(), lane); err != nil {
+			logger.Warn("Request rejected by priority queue",
+				zap.String("lane", lane.String()),
+				zap.String("path", c.Request.URL.Path),
+				zap.String("method", c.Request.Method),
+				zap.String("request_id", GetRequestID(c)),
+				zap.Error(err),
+			)
+			c.Header("Retry-After", strconv.Itoa(1))
+			errors.Respond(c, errors.NewServiceUnavailable("service is busy, please retry later",
+				"Lane: "+lane.String()))
+			return
+		}
+		defer queue.Release(lane)
+
+		c.Set("queue_lane", lane.String())
+		c.Next()
+	}
+}
+
+// QueueStatsHandler exposes the queue-depth metrics of the priority queue
+func QueueStatsHandler(queue *PriorityQueue) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, queue.Stats())
+	}
+}
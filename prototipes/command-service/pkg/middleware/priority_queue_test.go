@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestQueue(capacity, lowMaxQueued int) *PriorityQueue {
+	return NewPriorityQueue(PriorityQueueConfig{
+		Capacity: capacity,
+		Lanes: [laneCount]LaneConfig{
+			LaneHigh:   {MaxConcurrent: capacity, MaxQueued: 10},
+			LaneNormal: {MaxConcurrent: capacity, MaxQueued: 10},
+			LaneLow:    {MaxConcurrent: capacity, MaxQueued: lowMaxQueued},
+		},
+		MaxWait: time.Second,
+	})
+}
+
+func TestPriorityQueue_HighLanePreemptsLow(t *testing.T) {
+	queue := newTestQueue(1, 10)
+	ctx := context.Background()
+
+	// Occupy the only slot
+	require.NoError(t, queue.Acquire(ctx, LaneNormal))
+
+	order := make(chan Lane, 2)
+	lowQueued := make(chan struct{})
+	go func() {
+		close(lowQueued)
+		if queue.Acquire(ctx, LaneLow) == nil {
+			order <- LaneLow
+			queue.Release(LaneLow)
+		}
+	}()
+	<-lowQueued
+	assert.Eventually(t, func() bool { return queue.Stats().Lanes[LaneLow].Queued == 1 }, time.Second, time.Millisecond)
+
+	go func() {
+		if queue.Acquire(ctx, LaneHigh) == nil {
+			order <- LaneHigh
+			queue.Release(LaneHigh)
+		}
+	}()
+	assert.Eventually(t, func() bool { return queue.Stats().Lanes[LaneHigh].Queued == 1 }, time.Second, time.Millisecond)
+
+	queue.Release(LaneNormal)
+
+	assert.Equal(t, LaneHigh, <-order)
+	assert.Equal(t, LaneLow, <-order)
+}
+
+func TestPriorityQueue_RejectsWhenLowLaneSaturated(t *testing.T) {
+	queue := newTestQueue(1, 0)
+	ctx := context.Background()
+
+	require.NoError(t, queue.Acquire(ctx, LaneNormal))
+	err := queue.Acquire(ctx, LaneLow)
+
+	assert.Equal(t, ErrQueueFull, err)
+	assert.Equal(t, uint64(1), queue.Stats().Lanes[LaneLow].Rejected)
+}
+
+func TestPriorityQueueMiddleware_Returns503WhenSaturated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	queue := newTestQueue(1, 0)
+	require.NoError(t, queue.Acquire(context.Background(), LaneHigh))
+
+	router := gin.New()
+	router.Use(PriorityQueueMiddleware(queue, NewLaneClassifier(nil), zap.NewNop()))
+	router.POST("/api/v1/inventory/reconciliation", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest("POST", "/api/v1/inventory/reconciliation", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+}
+
+func TestLaneClassifier(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	classify := NewLaneClassifier([]string{"batch-importer"})
+
+	tests := []struct {
+		name     string
+		path     string
+		username string
+		expected Lane
+	}{
+		{"reserve is high priority", "/api/v1/inventory/items/1/reserve", "admin", LaneHigh},
+		{"release is high priority", "/api/v1/inventory/items/1/release", "admin", LaneHigh},
+		{"reconciliation is low priority", "/api/v1/inventory/reconciliation", "admin", LaneLow},
+		{"low priority user", "/api/v1/inventory/items", "batch-importer", LaneLow},
+		{"regular write", "/api/v1/inventory/items", "admin", LaneNormal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("POST", tt.path, nil)
+			c.Set("username", tt.username)
+			assert.Equal(t, tt.expected, classify(c))
+		})
+	}
+}
+
+func TestLaneClassifier_RegisteredRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	queue := newTestQueue(4, 10)
+
+	router := gin.New()
+	router.Use(PriorityQueueMiddleware(queue, NewLaneClassifier(nil), zap.NewNop()))
+	lane := func(c *gin.Context) { c.String(http.StatusOK, c.GetString("queue_lane")) }
+	router.POST("/api/v1/inventory/reconciliation", lane)
+	router.POST("/api/v1/inventory/items/:id/reserve", lane)
+	router.POST("/api/v1/inventory/items/:id/adjust", lane)
+
+	for path, expected := range map[string]Lane{
+		"/api/v1/inventory/reconciliation":   LaneLow,
+		"/api/v1/inventory/items/42/reserve": LaneHigh,
+		"/api/v1/inventory/items/42/adjust":  LaneNormal,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", path, nil))
+		assert.Equal(t, expected.String(), w.Body.String(), path)
+	}
+}
@@ -0,0 +1,277 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"command-service/internal/auth"
+	"command-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// RateLimit is a token bucket: Burst requests at once, refilled at PerMinute requests
+// per minute. A zero PerMinute disables the limit.
+type RateLimit struct {
+	PerMinute int
+	Burst     int
+}
+
+func (l RateLimit) enabled() bool {
+	return l.PerMinute > 0
+}
+
+// perSecond is the refill rate of the bucket
+func (l RateLimit) perSecond() float64 {
+	return float64(l.PerMinute) / 60
+}
+
+// burst is the bucket size; at least one request
+func (l RateLimit) burst() int {
+	if l.Burst < 1 {
+		return 1
+	}
+	return l.Burst
+}
+
+// RateLimitConfig configures the write rate limiter. Each request takes a token from
+// the bucket of its client IP and, once authenticated, from the bucket of its JWT subject.
+type RateLimitConfig struct {
+	PerIP   RateLimit
+	PerUser RateLimit
+}
+
+// RateLimitStore keeps the token buckets
+type RateLimitStore interface {
+	// Take removes a token from the bucket of key. When the bucket is empty it returns
+	// false and how long until a token is available.
+	Take(ctx context.Context, key string, limit RateLimit) (bool, time.Duration, error)
+}
+
+// NewRateLimitStore creates the bucket store: "redis" shares the limits between
+// replicas, "memory" is per process. If Redis is not reachable it falls back to memory.
+func NewRateLimitStore(kind string, opts auth.RedisOptions, logger *zap.Logger) RateLimitStore {
+	if kind != "redis" {
+		return NewInMemoryRateLimitStore()
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     opts.Addr,
+		Password: opts.Password,
+		DB:       opts.DB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		logger.Warn("Failed to connect to Redis, using in-memory rate limit store (limits are per replica)",
+			zap.String("addr", opts.Addr),
+			zap.Error(err),
+		)
+		client.Close()
+		return NewInMemoryRateLimitStore()
+	}
+
+	logger.Info("Redis rate limit store initialized", zap.String("addr", opts.Addr))
+	return NewRedisRateLimitStore(client)
+}
+
+// maxIdleBuckets is the number of buckets after which the in-memory store drops
+// the full ones (clients that have been idle long enough to refill)
+const maxIdleBuckets = 10000
+
+// InMemoryRateLimitStore is a per-process RateLimitStore
+type InMemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	now     func() time.Time
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	limit   RateLimit
+}
+
+// NewInMemoryRateLimitStore creates an empty in-memory store
+func NewInMemoryRateLimitStore() *InMemoryRateLimitStore {
+	return &InMemoryRateLimitStore{
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// Take implements RateLimitStore
+func (s *InMemoryRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if len(s.buckets) >= maxIdleBuckets {
+		s.purge(now)
+	}
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(limit.burst()), updated: now}
+		s.buckets[key] = bucket
+	}
+	bucket.refill(now, limit)
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0, nil
+	}
+	wait := time.Duration((1 - bucket.tokens) / limit.perSecond() * float64(time.Second))
+	return false, wait, nil
+}
+
+func (b *tokenBucket) refill(now time.Time, limit RateLimit) {
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(limit.burst()), b.tokens+elapsed*limit.perSecond())
+		b.updated = now
+	}
+	b.limit = limit
+}
+
+// purge drops the buckets that are full again. The caller holds mu.
+func (s *InMemoryRateLimitStore) purge(now time.Time) {
+	for key, bucket := range s.buckets {
+		bucket.refill(now, bucket.limit)
+		if bucket.tokens >= float64(bucket.limit.burst()) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// takeTokenScript refills and takes from a bucket atomically. It uses the Redis clock
+// so that replicas with skewed clocks share the same buckets consistently.
+// Returns {allowed, milliseconds until the next token}.
+var takeTokenScript = redis.NewScript(`
+redis.replicate_commands()
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1]) or burst
+local updated = tonumber(state[2]) or now
+if now > updated then
+  tokens = math.min(burst, tokens + (now - updated) * rate)
+end
+
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  wait = math.ceil((1 - tokens) / rate * 1000)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, wait}
+`)
+
+// RedisRateLimitStore keeps the buckets in Redis, shared by every replica
+type RedisRateLimitStore struct {
+	client *redis.Client
+}
+
+// NewRedisRateLimitStore creates a store on an existing Redis client
+func NewRedisRateLimitStore(client *redis.Client) *RedisRateLimitStore {
+	return &RedisRateLimitStore{client: client}
+}
+
+// Ping checks that Redis answers (readiness probe)
+func (s *RedisRateLimitStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+// Take implements RateLimitStore
+func (s *RedisRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (bool, time.Duration, error) {
+	result, err := takeTokenScript.Run(ctx, s.client, []string{"ratelimit:" + key},
+		strconv.FormatFloat(limit.perSecond(), 'f', -1, 64), limit.burst()).Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("unexpected rate limit script result: %v", result)
+	}
+	allowed, _ := result[0].(int64)
+	waitMs, _ := result[1].(int64)
+	return allowed == 1, time.Duration(waitMs) * time.Millisecond, nil
+}
+
+type rateLimitCheck struct {
+	scope string // "ip" or "user"
+	key   string
+	limit RateLimit
+}
+
+// RateLimitMiddleware limits write requests (POST, PUT, PATCH, DELETE) per client IP and
+// per JWT subject, rejecting them with 429 and Retry-After when a bucket is empty. It
+// must run after AuthMiddleware to see the subject. If the store fails the request is
+// let through: the limiter protects the service, it must not take it down.
+func RateLimitMiddleware(cfg RateLimitConfig, store RateLimitStore, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+
+		checks := make([]rateLimitCheck, 0, 2)
+		if cfg.PerIP.enabled() {
+			checks = append(checks, rateLimitCheck{scope: "ip", key: "ip:" + c.ClientIP(), limit: cfg.PerIP})
+		}
+		if subject := c.GetString("user_id"); subject != "" && cfg.PerUser.enabled() {
+			checks = append(checks, rateLimitCheck{scope: "user", key: "user:" + subject, limit: cfg.PerUser})
+		}
+
+		for _, check := range checks {
+			allowed, wait, err := store.Take(c.Request.Context(), check.key, check.limit)
+			if err != nil {
+				logger.Warn("Rate limit store unavailable, request not limited",
+					zap.String("scope", check.scope),
+					zap.String("request_id", GetRequestID(c)),
+					zap.Error(err),
+				)
+				continue
+			}
+			if allowed {
+				continue
+			}
+
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			logger.Warn("Request rejected by rate limiter",
+				zap.String("scope", check.scope),
+				zap.String("client_ip", c.ClientIP()),
+				zap.String("username", c.GetString("username")),
+				zap.String("path", c.Request.URL.Path),
+				zap.String("method", c.Request.Method),
+				zap.String("request_id", GetRequestID(c)),
+			)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			errors.Respond(c, errors.NewRateLimited("too many requests, please retry later",
+				fmt.Sprintf("Limit: %d/min per %s", check.limit.PerMinute, check.scope)))
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newRateLimitedRouter(cfg RateLimitConfig, store RateLimitStore) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-Test-User"); user != "" {
+			c.Set("user_id", user)
+		}
+		c.Next()
+	})
+	router.Use(RateLimitMiddleware(cfg, store, zap.NewNop()))
+	router.POST("/items", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	router.GET("/items", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func doRateLimited(router *gin.Engine, method, ip, user string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/items", nil)
+	req.RemoteAddr = ip + ":12345"
+	if user != "" {
+		req.Header.Set("X-Test-User", user)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit_PerIPReturns429WithRetryAfter(t *testing.T) {
+	router := newRateLimitedRouter(RateLimitConfig{PerIP: RateLimit{PerMinute: 60, Burst: 2}}, NewInMemoryRateLimitStore())
+
+	assert.Equal(t, http.StatusCreated, doRateLimited(router, http.MethodPost, "10.0.0.1", "").Code)
+	assert.Equal(t, http.StatusCreated, doRateLimited(router, http.MethodPost, "10.0.0.1", "").Code)
+
+	w := doRateLimited(router, http.MethodPost, "10.0.0.1", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "RateLimited")
+
+	// Other clients and reads are not affected
+	assert.Equal(t, http.StatusCreated, doRateLimited(router, http.MethodPost, "10.0.0.2", "").Code)
+	assert.Equal(t, http.StatusOK, doRateLimited(router, http.MethodGet, "10.0.0.1", "").Code)
+}
+
+func TestRateLimit_PerUserAcrossIPs(t *testing.T) {
+	router := newRateLimitedRouter(RateLimitConfig{PerUser: RateLimit{PerMinute: 6, Burst: 1}}, NewInMemoryRateLimitStore())
+
+	assert.Equal(t, http.StatusCreated, doRateLimited(router, http.MethodPost, "10.0.0.1", "alice").Code)
+	w := doRateLimited(router, http.MethodPost, "10.0.0.2", "alice")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "10", w.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusCreated, doRateLimited(router, http.MethodPost, "10.0.0.2", "bob").Code)
+}
+
+func TestInMemoryRateLimitStore_Refills(t *testing.T) {
+	store := NewInMemoryRateLimitStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	limit := RateLimit{PerMinute: 60, Burst: 1}
+	ctx := context.Background()
+
+	allowed, _, err := store.Take(ctx, "k", limit)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, wait, err := store.Take(ctx, "k", limit)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, time.Second, wait)
+
+	now = now.Add(time.Second)
+	allowed, _, err = store.Take(ctx, "k", limit)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (bool, time.Duration, error) {
+	return false, 0, errors.New("redis down")
+}
+
+func TestRateLimit_StoreErrorLetsRequestThrough(t *testing.T) {
+	router := newRateLimitedRouter(RateLimitConfig{PerIP: RateLimit{PerMinute: 1, Burst: 1}}, failingRateLimitStore{})
+
+	assert.Equal(t, http.StatusCreated, doRateLimited(router, http.MethodPost, "10.0.0.1", "").Code)
+}
@@ -1,10 +1,21 @@
 package middleware
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"command-service/internal/tenant"
+	"command-service/pkg/errors"
+	"command-service/pkg/metrics"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -15,34 +26,92 @@ const (
 	RequestIDHeader = "X-Request-ID"
 	// RequestIDContextKey is the context key for request ID
 	RequestIDContextKey = "request_id"
+	// IdempotentReplayHeader marks a response replayed from the idempotency store
+	IdempotentReplayHeader = "X-Idempotent-Replay"
+	// idempotencyFingerprintKey is the context key for the fingerprint of a write request
+	idempotencyFingerprintKey = "idempotency_fingerprint"
 )
 
-// RequestIDStore stores processed request IDs for idempotency
+// RequestIDStore stores processed request IDs for idempotency, by the key IdempotencyKey
+// builds from the request ID and the caller
 type RequestIDStore interface {
-	// Store stores a request ID with its response
-	Store(ctx context.Context, requestID string, response []byte, ttl time.Duration) error
-	// Get retrieves a stored response by request ID
-	Get(ctx context.Context, requestID string) ([]byte, error)
-	// Exists checks if a request ID exists
-	Exists(ctx context.Context, requestID string) (bool, error)
+	// Store stores a key with its response
+	Store(ctx context.Context, key string, response []byte, ttl time.Duration) error
+	// Get retrieves a stored response by key
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Exists checks if a key exists
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// IdempotencyKey is the store key of a request ID sent by subject in tenantID: the same
+// X-Request-ID from another tenant or caller is another request
+func IdempotencyKey(tenantID, subject, requestID string) string {
+	sum := sha256.Sum256([]byte(tenantID + "\x00" + subject + "\x00" + requestID))
+	return hex.EncodeToString(sum[:16])
+}
+
+// IdempotencyEntry describes a stored response, without its body
+type IdempotencyEntry struct {
+	// Store key (IdempotencyKey), used by the admin endpoints
+	Key          string    `json:"key" example:"3f2a9c0d5b7e41a8c6d2e9f0a1b2c3d4"`
+	RequestID    string    `json:"request_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	TenantID     string    `json:"tenant_id,omitempty" example:"default"`
+	Subject      string    `json:"subject,omitempty" example:"550e8400-e29b-41d4-a716-446655440001"`
+	Method       string    `json:"method,omitempty" example:"POST"`
+	Path         string    `json:"path,omitempty" example:"/api/v1/inventory/items"`
+	Status       int       `json:"status,omitempty" example:"201"`
+	StoredAt     time.Time `json:"stored_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	TTLRemaining float64   `json:"ttl_remaining_seconds" example:"212.5"`
+	Size         int       `json:"size_bytes" example:"245"`
+	// SHA-256 of the tenant, subject, method, path and body of the original request
+	Fingerprint string `json:"fingerprint,omitempty" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	// Duplicates answered with the stored response
+	Hits int `json:"hits" example:"3"`
+}
+
+// IdempotencyStats counts the idempotency checks of write requests since startup
+type IdempotencyStats struct {
+	Entries int   `json:"entries" example:"120"`
+	Checks  int64 `json:"checks" example:"5000"`
+	Hits    int64 `json:"hits" example:"25"`
+	// Hits / Checks
+	HitRate float64 `json:"hit_rate" example:"0.005"`
+}
+
+// IdempotencyInspector is a RequestIDStore that keeps the request behind each stored
+// response, for the admin endpoints
+type IdempotencyInspector interface {
+	RequestIDStore
+	// Record stores a response along with the request that produced it
+	Record(ctx context.Context, entry IdempotencyEntry, response []byte, ttl time.Duration) error
+	// List returns a page of the live entries, most recent first, and the total
+	List(ctx context.Context, offset, limit int) ([]IdempotencyEntry, int, error)
+	// Lookup returns an entry and its stored response
+	Lookup(ctx context.Context, key string) (IdempotencyEntry, []byte, error)
+	// Delete removes an entry so the next request with its ID is processed again
+	Delete(ctx context.Context, key string) error
+	Stats() IdempotencyStats
 }
 
 // InMemoryRequestIDStore is an in-memory implementation of RequestIDStore
 type InMemoryRequestIDStore struct {
 	mu      sync.RWMutex
-	store   map[string]requestIDEntry
+	store   map[string]*requestIDEntry
 	cleanup *time.Ticker
+	checks  atomic.Int64
+	hits    atomic.Int64
 }
 
 type requestIDEntry struct {
-	response  []byte
-	expiresAt time.Time
+	IdempotencyEntry
+	response []byte
 }
 
 // NewInMemoryRequestIDStore creates a new in-memory request ID store
 func NewInMemoryRequestIDStore() *InMemoryRequestIDStore {
 	store := &InMemoryRequestIDStore{
-		store:   make(map[string]requestIDEntry),
+		store:   make(map[string]*requestIDEntry),
 		cleanup: time.NewTicker(1 * time.Minute), // Cleanup every minute
 	}
 
@@ -52,52 +121,131 @@ func NewInMemoryRequestIDStore() *InMemoryRequestIDStore {
 	return store
 }
 
-func (s *InMemoryRequestIDStore) Store(ctx context.Context, requestID string, response []byte, ttl time.Duration) error {
+func (s *InMemoryRequestIDStore) Store(ctx context.Context, key string, response []byte, ttl time.Duration) error {
+	return s.Record(ctx, IdempotencyEntry{Key: key, RequestID: key}, response, ttl)
+}
+
+// Record stores entry under entry.Key, or entry.RequestID when the key is empty
+func (s *InMemoryRequestIDStore) Record(ctx context.Context, entry IdempotencyEntry, response []byte, ttl time.Duration) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.store[requestID] = requestIDEntry{
-		response:  response,
-		expiresAt: time.Now().Add(ttl),
+	entry.StoredAt = time.Now()
+	entry.ExpiresAt = entry.StoredAt.Add(ttl)
+	entry.Size = len(response)
+	entry.Hits = 0
+	if entry.Key == "" {
+		entry.Key = entry.RequestID
 	}
+	s.store[entry.Key] = &requestIDEntry{IdempotencyEntry: entry, response: response}
 
 	return nil
 }
 
-func (s *InMemoryRequestIDStore) Get(ctx context.Context, requestID string) ([]byte, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// Get counts a duplicate hit: the idempotency middleware calls it to replay a response
+func (s *InMemoryRequestIDStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	entry, exists := s.store[requestID]
-	if !exists {
+	entry := s.liveLocked(key)
+	if entry == nil {
 		return nil, ErrRequestIDNotFound
 	}
+	entry.Hits++
+	s.hits.Add(1)
 
-	// Check if expired
-	if time.Now().After(entry.expiresAt) {
-		delete(s.store, requestID)
-		return nil, ErrRequestIDNotFound
+	return entry.response, nil
+}
+
+// Exists counts an idempotency check: the middleware calls it once per write request
+func (s *InMemoryRequestIDStore) Exists(ctx context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checks.Add(1)
+	return s.liveLocked(key) != nil, nil
+}
+
+func (s *InMemoryRequestIDStore) List(ctx context.Context, offset, limit int) ([]IdempotencyEntry, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	entries := make([]IdempotencyEntry, 0, len(s.store))
+	for key := range s.store {
+		if entry := s.liveLocked(key); entry != nil {
+			entries = append(entries, entry.describe(now))
+		}
 	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].StoredAt.Equal(entries[j].StoredAt) {
+			return entries[i].StoredAt.After(entries[j].StoredAt)
+		}
+		return entries[i].Key < entries[j].Key
+	})
 
-	return entry.response, nil
+	total := len(entries)
+	if offset > total {
+		offset = total
+	}
+	if end := offset + limit; end < total {
+		entries = entries[:end]
+	}
+	return entries[offset:], total, nil
+}
+
+func (s *InMemoryRequestIDStore) Lookup(ctx context.Context, key string) (IdempotencyEntry, []byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.liveLocked(key)
+	if entry == nil {
+		return IdempotencyEntry{}, nil, ErrRequestIDNotFound
+	}
+	return entry.describe(time.Now()), entry.response, nil
+}
+
+func (s *InMemoryRequestIDStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.liveLocked(key) == nil {
+		return ErrRequestIDNotFound
+	}
+	delete(s.store, key)
+	return nil
 }
 
-func (s *InMemoryRequestIDStore) Exists(ctx context.Context, requestID string) (bool, error) {
+func (s *InMemoryRequestIDStore) Stats() IdempotencyStats {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
+	entries := len(s.store)
+	s.mu.RUnlock()
 
-	entry, exists := s.store[requestID]
-	if !exists {
-		return false, nil
+	stats := IdempotencyStats{Entries: entries, Checks: s.checks.Load(), Hits: s.hits.Load()}
+	if stats.Checks > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(stats.Checks)
 	}
+	return stats
+}
 
-	// Check if expired
-	if time.Now().After(entry.expiresAt) {
-		delete(s.store, requestID)
-		return false, nil
+// liveLocked returns the entry of key, dropping it if it expired. Callers hold the write lock.
+func (s *InMemoryRequestIDStore) liveLocked(key string) *requestIDEntry {
+	entry, exists := s.store[key]
+	if !exists {
+		return nil
 	}
+	if time.Now().After(entry.ExpiresAt) {
+		delete(s.store, key)
+		return nil
+	}
+	return entry
+}
 
-	return true, nil
+// describe fills in the remaining TTL of the entry
+func (e *requestIDEntry) describe(now time.Time) IdempotencyEntry {
+	entry := e.IdempotencyEntry
+	entry.TTLRemaining = entry.ExpiresAt.Sub(now).Seconds()
+	return entry
 }
 
 func (s *InMemoryRequestIDStore) cleanupExpired() {
@@ -105,7 +253,7 @@ func (s *InMemoryRequestIDStore) cleanupExpired() {
 		s.mu.Lock()
 		now := time.Now()
 		for id, entry := range s.store {
-			if now.After(entry.expiresAt) {
+			if now.After(entry.ExpiresAt) {
 				delete(s.store, id)
 			}
 		}
@@ -168,7 +316,8 @@ func GetRequestID(c *gin.Context) string {
 	return ""
 }
 
-// IdempotencyMiddleware checks for duplicate requests based on X-Request-ID
+// IdempotencyMiddleware checks for duplicate requests based on X-Request-ID. It runs after
+// authentication: a request ID is only a duplicate from the same tenant and caller.
 func IdempotencyMiddleware(store RequestIDStore, logger *zap.Logger, ttl time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Only apply idempotency to write operations (POST, PUT, DELETE, PATCH)
@@ -183,9 +332,26 @@ func IdempotencyMiddleware(store RequestIDStore, logger *zap.Logger, ttl time.Du
 			c.Next()
 			return
 		}
+		tenantID, subject := idempotencyCaller(c)
+		key := IdempotencyKey(tenantID, subject, requestID)
+
+		// Fingerprint the request so a reused ID with another method, path or body is not
+		// answered with the response of a different request
+		inspector, inspectable := store.(IdempotencyInspector)
+		fingerprint := ""
+		if inspectable {
+			body, err := readBody(c)
+			if err != nil {
+				// The handler gets the same error (e.g. body over the size limit)
+				c.Next()
+				return
+			}
+			fingerprint = requestFingerprint(tenantID, subject, c.Request.Method, c.Request.URL.Path, body)
+			c.Set(idempotencyFingerprintKey, fingerprint)
+		}
 
 		// Check if request ID already exists
-		exists, err := store.Exists(c.Request.Context(), requestID)
+		exists, err := store.Exists(c.Request.Context(), key)
 		if err != nil {
 			logger.Warn("Error checking request ID existence",
 				zap.String("request_id", requestID),
@@ -196,23 +362,46 @@ func IdempotencyMiddleware(store RequestIDStore, logger *zap.Logger, ttl time.Du
 			return
 		}
 
+		// The original status, when the store recorded it
+		status := http.StatusOK
+		if exists && inspectable {
+			if entry, _, err := inspector.Lookup(c.Request.Context(), key); err == nil {
+				if entry.Fingerprint != "" && entry.Fingerprint != fingerprint {
+					logger.Warn("Request ID reused for a different request",
+						zap.String("request_id", requestID),
+						zap.String("path", c.Request.URL.Path),
+						zap.String("method", c.Request.Method),
+						zap.String("original_method", entry.Method),
+						zap.String("original_path", entry.Path),
+					)
+					metrics.IdempotencyChecks.WithLabelValues("mismatch").Inc()
+					errors.Respond(c, errors.NewIdempotencyMismatch(requestID))
+					return
+				}
+				if entry.Status != 0 {
+					status = entry.Status
+				}
+			}
+		}
+
 		if exists {
 			// Request ID exists, retrieve cached response
-			cachedResponse, err := store.Get(c.Request.Context(), requestID)
+			cachedResponse, err := store.Get(c.Request.Context(), key)
 			if err == nil && len(cachedResponse) > 0 {
 				logger.Info("Duplicate request detected, returning cached response",
 					zap.String("request_id", requestID),
 					zap.String("path", c.Request.URL.Path),
 					zap.String("method", c.Request.Method),
 				)
+				metrics.IdempotencyChecks.WithLabelValues("hit").Inc()
 
-				// Set content type and return cached response
-				c.Header("Content-Type", "application/json")
-				c.Data(200, "application/json", cachedResponse)
+				c.Header(IdempotentReplayHeader, "true")
+				c.Data(status, "application/json", cachedResponse)
 				c.Abort()
 				return
 			}
 		}
+		metrics.IdempotencyChecks.WithLabelValues("miss").Inc()
 
 		// Request ID doesn't exist, continue processing
 		// We'll store the response after processing
@@ -234,6 +423,8 @@ func StoreResponseMiddleware(store RequestIDStore, logger *zap.Logger, ttl time.
 			c.Next()
 			return
 		}
+		tenantID, subject := idempotencyCaller(c)
+		key := IdempotencyKey(tenantID, subject, requestID)
 
 		// Capture response
 		writer := &responseWriter{
@@ -247,8 +438,23 @@ func StoreResponseMiddleware(store RequestIDStore, logger *zap.Logger, ttl time.
 		// Only store successful responses (2xx)
 		if c.Writer.Status() >= 200 && c.Writer.Status() < 300 {
 			if len(writer.body) > 0 {
-				// Store response for idempotency
-				if err := store.Store(c.Request.Context(), requestID, writer.body, ttl); err != nil {
+				// Store response for idempotency, with its request when the store keeps it
+				var err error
+				if inspector, ok := store.(IdempotencyInspector); ok {
+					err = inspector.Record(c.Request.Context(), IdempotencyEntry{
+						Key:         key,
+						RequestID:   requestID,
+						TenantID:    tenantID,
+						Subject:     subject,
+						Method:      c.Request.Method,
+						Path:        c.Request.URL.Path,
+						Status:      c.Writer.Status(),
+						Fingerprint: c.GetString(idempotencyFingerprintKey),
+					}, writer.body, ttl)
+				} else {
+					err = store.Store(c.Request.Context(), key, writer.body, ttl)
+				}
+				if err != nil {
 					logger.Warn("Failed to store response for idempotency",
 						zap.String("request_id", requestID),
 						zap.Error(err),
@@ -266,6 +472,41 @@ func StoreResponseMiddleware(store RequestIDStore, logger *zap.Logger, ttl time.
 	}
 }
 
+// idempotencyCaller returns the tenant and the subject (user ID, API key ID or, failing
+// those, username) set by the auth middleware
+func idempotencyCaller(c *gin.Context) (string, string) {
+	subject := c.GetString("user_id")
+	if subject == "" {
+		subject = c.GetString("username")
+	}
+	return tenant.FromContext(c.Request.Context()), subject
+}
+
+// requestFingerprint hashes what makes two writes the same request: tenant, subject,
+// method, path and body
+func requestFingerprint(tenantID, subject, method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(tenantID + "\x00" + subject + "\n"))
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// readBody reads the request body and puts it back for the handler, which on a read
+// error gets the bytes read followed by the same error
+func readBody(c *gin.Context) ([]byte, error) {
+	if c.Request.Body == nil {
+		return nil, nil
+	}
+	original := c.Request.Body
+	body, err := io.ReadAll(original)
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), original), original}
+	return body, err
+}
+
 // responseWriter captures the response body
 type responseWriter struct {
 	gin.ResponseWriter
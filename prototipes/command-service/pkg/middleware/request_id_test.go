@@ -2,11 +2,15 @@ package middleware
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"command-service/internal/tenant"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -77,7 +81,7 @@ func TestIdempotencyMiddleware_DuplicateRequest(t *testing.T) {
 
 	// Store a response for the request ID
 	response := []byte(`{"message":"success"}`)
-	err := store.Store(context.Background(), requestID, response, 5*time.Minute)
+	err := store.Store(context.Background(), IdempotencyKey(tenant.Default, "", requestID), response, 5*time.Minute)
 	assert.NoError(t, err)
 
 	router.Use(RequestIDMiddleware(logger))
@@ -183,3 +187,187 @@ func TestInMemoryRequestIDStore_Expiration(t *testing.T) {
 	assert.False(t, exists)
 }
 
+
+func TestIdempotencyMiddleware_ReplaysRecordedStatus(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	logger := zap.NewNop()
+	store := NewInMemoryRequestIDStore()
+	calls := 0
+
+	router.Use(RequestIDMiddleware(logger))
+	router.Use(IdempotencyMiddleware(store, logger, 5*time.Minute))
+	router.Use(StoreResponseMiddleware(store, logger, 5*time.Minute))
+	router.POST("/items", func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusAccepted, gin.H{"message": "accepted"})
+	})
+
+	requestID := uuid.New().String()
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("POST", "/items", nil)
+		req.Header.Set(RequestIDHeader, requestID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// Assert - duplicates get the original status, marked as replays
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Equal(t, i > 0, w.Header().Get(IdempotentReplayHeader) == "true")
+	}
+	assert.Equal(t, 1, calls)
+
+	entry, body, err := store.Lookup(context.Background(), IdempotencyKey(tenant.Default, "", requestID))
+	assert.NoError(t, err)
+	assert.Equal(t, "POST", entry.Method)
+	assert.Equal(t, "/items", entry.Path)
+	assert.Equal(t, http.StatusAccepted, entry.Status)
+	assert.Equal(t, 2, entry.Hits)
+	assert.Equal(t, len(body), entry.Size)
+	assert.InDelta(t, (5 * time.Minute).Seconds(), entry.TTLRemaining, 5)
+
+	stats := store.Stats()
+	assert.Equal(t, IdempotencyStats{Entries: 1, Checks: 3, Hits: 2, HitRate: 2.0 / 3.0}, stats)
+}
+
+func TestInMemoryRequestIDStore_ListAndDelete(t *testing.T) {
+	// Setup
+	store := NewInMemoryRequestIDStore()
+	ctx := context.Background()
+	for _, id := range []string{"req-1", "req-2", "req-3"} {
+		assert.NoError(t, store.Record(ctx, IdempotencyEntry{RequestID: id, Method: "POST"}, []byte(`{}`), time.Minute))
+		time.Sleep(time.Millisecond)
+	}
+	assert.NoError(t, store.Store(ctx, "expired", []byte(`{}`), time.Nanosecond))
+	time.Sleep(time.Millisecond)
+
+	// Most recent first, expired entries left out
+	entries, total, err := store.List(ctx, 0, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "req-3", entries[0].RequestID)
+		assert.Equal(t, "req-2", entries[1].RequestID)
+	}
+	entries, _, _ = store.List(ctx, 2, 2)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "req-1", entries[0].RequestID)
+	}
+	entries, _, _ = store.List(ctx, 10, 2)
+	assert.Empty(t, entries)
+
+	// Delete lets the request ID be processed again
+	assert.NoError(t, store.Delete(ctx, "req-2"))
+	assert.ErrorIs(t, store.Delete(ctx, "req-2"), ErrRequestIDNotFound)
+	exists, _ := store.Exists(ctx, "req-2")
+	assert.False(t, exists)
+	assert.Equal(t, 2, store.Stats().Entries)
+}
+
+func TestIdempotencyMiddleware_FingerprintMismatch(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	logger := zap.NewNop()
+	store := NewInMemoryRequestIDStore()
+	var bodies []string
+
+	router.Use(RequestIDMiddleware(logger))
+	router.Use(IdempotencyMiddleware(store, logger, 5*time.Minute))
+	router.Use(StoreResponseMiddleware(store, logger, 5*time.Minute))
+	handler := func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		bodies = append(bodies, string(body))
+		c.JSON(http.StatusCreated, gin.H{"received": string(body)})
+	}
+	router.POST("/items", handler)
+	router.POST("/stores", handler)
+
+	requestID := uuid.New().String()
+	send := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set(RequestIDHeader, requestID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// The handler still reads the body the middleware fingerprinted
+	first := send("/items", `{"sku":"SKU-001"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, []string{`{"sku":"SKU-001"}`}, bodies)
+
+	// Same request: replayed
+	replay := send("/items", `{"sku":"SKU-001"}`)
+	assert.Equal(t, http.StatusCreated, replay.Code)
+	assert.Equal(t, first.Body.String(), replay.Body.String())
+
+	// Same ID with another body or path: 422, not processed
+	for _, w := range []*httptest.ResponseRecorder{send("/items", `{"sku":"SKU-002"}`), send("/stores", `{"sku":"SKU-001"}`)} {
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"IdempotencyMismatch"`)
+	}
+	assert.Len(t, bodies, 1)
+
+	entry, _, err := store.Lookup(context.Background(), IdempotencyKey(tenant.Default, "", requestID))
+	assert.NoError(t, err)
+	assert.Equal(t, requestFingerprint(tenant.Default, "", "POST", "/items", []byte(`{"sku":"SKU-001"}`)), entry.Fingerprint)
+	assert.Equal(t, 1, entry.Hits)
+}
+
+func TestIdempotencyMiddleware_PerTenantAndCaller(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	logger := zap.NewNop()
+	store := NewInMemoryRequestIDStore()
+	calls := 0
+
+	router.Use(RequestIDMiddleware(logger))
+	// Stands in for the auth middleware, which sets the caller and its tenant
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-Test-User"))
+		c.Request = c.Request.WithContext(tenant.WithTenant(c.Request.Context(), c.GetHeader("X-Test-Tenant")))
+		c.Next()
+	})
+	router.Use(IdempotencyMiddleware(store, logger, 5*time.Minute))
+	router.Use(StoreResponseMiddleware(store, logger, 5*time.Minute))
+	router.POST("/items", func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"tenant": tenant.FromContext(c.Request.Context()), "call": calls})
+	})
+
+	requestID := uuid.New().String()
+	send := func(tenantID, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/items", strings.NewReader(`{"sku":"SKU-001"}`))
+		req.Header.Set(RequestIDHeader, requestID)
+		req.Header.Set("X-Test-Tenant", tenantID)
+		req.Header.Set("X-Test-User", user)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Same X-Request-ID and body from another tenant or caller: processed, not replayed
+	first := send("acme", "user-1")
+	otherTenant := send("globex", "user-1")
+	otherUser := send("acme", "user-2")
+	for _, w := range []*httptest.ResponseRecorder{first, otherTenant, otherUser} {
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get(IdempotentReplayHeader))
+	}
+	assert.Equal(t, 3, calls)
+	assert.Contains(t, otherTenant.Body.String(), `"tenant":"globex"`)
+
+	// Same tenant and caller: replayed
+	replay := send("acme", "user-1")
+	assert.Equal(t, "true", replay.Header().Get(IdempotentReplayHeader))
+	assert.Equal(t, first.Body.String(), replay.Body.String())
+	assert.Equal(t, 3, calls)
+
+	entry, _, err := store.Lookup(context.Background(), IdempotencyKey("globex", "user-1", requestID))
+	assert.NoError(t, err)
+	assert.Equal(t, requestID, entry.RequestID)
+	assert.Equal(t, "globex", entry.TenantID)
+	assert.Equal(t, "user-1", entry.Subject)
+}
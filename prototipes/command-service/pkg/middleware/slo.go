@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sloRetention is how much history the SLO tracker keeps, in one-minute buckets
+const sloRetention = 24 * 60
+
+// SLOWindows are the rolling windows reported by the SLO endpoint. Short windows
+// catch fast burns (pages), long windows catch slow burns (tickets).
+var SLOWindows = []time.Duration{5 * time.Minute, time.Hour, 6 * time.Hour, 24 * time.Hour}
+
+// SLOConfig configures the service level objectives
+type SLOConfig struct {
+	Service            string        // Service name reported in the SLO report
+	AvailabilityTarget float64       // Fraction of requests that must not fail with 5xx (e.g. 0.999)
+	LatencyThreshold   time.Duration // A request slower than this is "slow"
+	LatencyTarget      float64       // Fraction of requests that must be faster than LatencyThreshold (e.g. 0.99)
+	ExcludePrefixes    []string      // Paths not counted (health checks, swagger, the SLO endpoint itself)
+}
+
+// SLIWindow holds the SLIs and burn rates of one rolling window
+type SLIWindow struct {
+	Window               string  `json:"window"`
+	Requests             int64   `json:"requests"`
+	Errors               int64   `json:"errors"`
+	SlowRequests         int64   `json:"slow_requests"`
+	Availability         float64 `json:"availability"`
+	LatencySLI           float64 `json:"latency_sli"`
+	AvailabilityBurnRate float64 `json:"availability_burn_rate"`
+	LatencyBurnRate      float64 `json:"latency_burn_rate"`
+}
+
+// SLOReport is the response of the SLO endpoint
+type SLOReport struct {
+	Service            string      `json:"service"`
+	AvailabilityTarget float64     `json:"availability_target"`
+	LatencyTarget      float64     `json:"latency_target"`
+	LatencyThresholdMs int64       `json:"latency_threshold_ms"`
+	Windows            []SLIWindow `json:"windows"`
+	GeneratedAt        time.Time   `json:"generated_at"`
+}
+
+type sloBucket struct {
+	minute int64 // Unix minute the counters belong to
+	total  int64
+	errors int64
+	slow   int64
+}
+
+// SLOTracker counts requests per minute and computes availability and latency
+// SLIs over rolling windows, so basic SLO monitoring needs no external system
+type SLOTracker struct {
+	config  SLOConfig
+	mu      sync.Mutex
+	buckets [sloRetention]sloBucket
+	now     func() time.Time
+}
+
+// NewSLOTracker creates a new SLO tracker
+func NewSLOTracker(config SLOConfig) *SLOTracker {
+	return &SLOTracker{
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Record counts a finished request
+func (t *SLOTracker) Record(status int, latency time.Duration) {
+	minute := t.now().Unix() / 60
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	bucket := &t.buckets[minute%sloRetention]
+	if bucket.minute != minute {
+		*bucket = sloBucket{minute: minute}
+	}
+	bucket.total++
+	if status >= http.StatusInternalServerError {
+		bucket.errors++
+	}
+	if latency > t.config.LatencyThreshold {
+		bucket.slow++
+	}
+}
+
+// Report computes the SLIs of every window in SLOWindows
+func (t *SLOTracker) Report() SLOReport {
+	now := t.now()
+	current := now.Unix() / 60
+
+	report := SLOReport{
+		Service:            t.config.Service,
+		AvailabilityTarget: t.config.AvailabilityTarget,
+		LatencyTarget:      t.config.LatencyTarget,
+		LatencyThresholdMs: t.config.LatencyThreshold.Milliseconds(),
+		Windows:            make([]SLIWindow, 0, len(SLOWindows)),
+		GeneratedAt:        now.UTC(),
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, window := range SLOWindows {
+		minutes := int64(window / time.Minute)
+		sli := SLIWindow{Window: window.String()}
+		for _, bucket := range t.buckets {
+			if bucket.minute > current-minutes && bucket.minute <= current {
+				sli.Requests += bucket.total
+				sli.Errors += bucket.errors
+				sli.SlowRequests += bucket.slow
+			}
+		}
+
+		sli.Availability, sli.AvailabilityBurnRate = sliAndBurnRate(sli.Requests, sli.Errors, t.config.AvailabilityTarget)
+		sli.LatencySLI, sli.LatencyBurnRate = sliAndBurnRate(sli.Requests, sli.SlowRequests, t.config.LatencyTarget)
+		report.Windows = append(report.Windows, sli)
+	}
+
+	return report
+}
+
+// sliAndBurnRate returns the fraction of good events and how fast the error budget
+// is being spent (1 = exactly on budget, 14.4 over 1h = a 30 day budget gone in ~2 days)
+func sliAndBurnRate(total, bad int64, target float64) (float64, float64) {
+	if total == 0 {
+		return 1, 0
+	}
+	badRatio := float64(bad) / float64(total)
+	budget := 1 - target
+	if budget <= 0 {
+		return 1 - badRatio, 0
+	}
+	return 1 - badRatio, badRatio / budget
+}
+
+// Middleware records the status and latency of every request not excluded by the config
+func (t *SLOTracker) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, prefix := range t.config.ExcludePrefixes {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		start := time.Now()
+		c.Next()
+		t.Record(c.Writer.Status(), time.Since(start))
+	}
+}
+
+// SLOReportHandler serves the SLO report
+func SLOReportHandler(tracker *SLOTracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, tracker.Report())
+	}
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSLOTracker(now *time.Time) *SLOTracker {
+	tracker := NewSLOTracker(SLOConfig{
+		Service:            "test",
+		AvailabilityTarget: 0.99,
+		LatencyThreshold:   100 * time.Millisecond,
+		LatencyTarget:      0.9,
+		ExcludePrefixes:    []string{"/api/v1/health"},
+	})
+	tracker.now = func() time.Time { return *now }
+	return tracker
+}
+
+func TestSLOTracker_ReportWindows(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newTestSLOTracker(&now)
+
+	// Two hours ago: only visible in the 6h and 24h windows
+	now = now.Add(-2 * time.Hour)
+	tracker.Record(http.StatusInternalServerError, time.Millisecond)
+	now = now.Add(2 * time.Hour)
+
+	// Last minute: 98 fast successes, 1 error, 1 slow success
+	for i := 0; i < 98; i++ {
+		tracker.Record(http.StatusOK, time.Millisecond)
+	}
+	tracker.Record(http.StatusServiceUnavailable, time.Millisecond)
+	tracker.Record(http.StatusOK, 200*time.Millisecond)
+
+	report := tracker.Report()
+	require.Len(t, report.Windows, len(SLOWindows))
+
+	fiveMinutes := report.Windows[0]
+	assert.Equal(t, int64(100), fiveMinutes.Requests)
+	assert.Equal(t, int64(1), fiveMinutes.Errors)
+	assert.Equal(t, int64(1), fiveMinutes.SlowRequests)
+	assert.InDelta(t, 0.99, fiveMinutes.Availability, 1e-9)
+	assert.InDelta(t, 1.0, fiveMinutes.AvailabilityBurnRate, 1e-9)
+	assert.InDelta(t, 0.1, fiveMinutes.LatencyBurnRate, 1e-9)
+
+	sixHours := report.Windows[2]
+	assert.Equal(t, int64(101), sixHours.Requests)
+	assert.Equal(t, int64(2), sixHours.Errors)
+}
+
+func TestSLOTracker_EmptyWindowIsHealthy(t *testing.T) {
+	now := time.Now()
+	report := newTestSLOTracker(&now).Report()
+
+	for _, window := range report.Windows {
+		assert.Equal(t, 1.0, window.Availability)
+		assert.Equal(t, 0.0, window.AvailabilityBurnRate)
+	}
+}
+
+func TestSLOTracker_MiddlewareSkipsExcludedPaths(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Now()
+	tracker := newTestSLOTracker(&now)
+
+	router := gin.New()
+	router.Use(tracker.Middleware())
+	router.GET("/api/v1/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/api/v1/items", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	for _, path := range []string{"/api/v1/health", "/api/v1/items"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	window := tracker.Report().Windows[0]
+	assert.Equal(t, int64(1), window.Requests)
+	assert.Equal(t, int64(1), window.Errors)
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"command-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupTimeoutRouter(config RequestTimeoutConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestTimeout(config, zap.NewNop()))
+	// Waits for the request context, like a database call would
+	router.GET("/slow", func(c *gin.Context) {
+		select {
+		case <-c.Request.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+			c.JSON(http.StatusOK, gin.H{"status": "done"})
+		}
+	})
+	// Answers with its own error once the context expires
+	router.GET("/answers", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "gave up"})
+	})
+	return router
+}
+
+func TestRequestTimeout(t *testing.T) {
+	router := setupTimeoutRouter(RequestTimeoutConfig{Default: 20 * time.Millisecond})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+	require.Equal(t, http.StatusGatewayTimeout, w.Code)
+	var body errors.StandardError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, errors.CodeTimeout, body.Code)
+	assert.Equal(t, "Limit: 20ms", body.Details)
+
+	// A response the handler already wrote is kept
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/answers", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestRequestTimeout_RouteOverrides(t *testing.T) {
+	router := setupTimeoutRouter(RequestTimeoutConfig{
+		Default: 20 * time.Millisecond,
+		Routes:  map[string]time.Duration{"GET /slow": 0},
+	})
+
+	// Unbounded route: the handler finishes
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRequestTimeout_RouteOutlivesServerWriteTimeout(t *testing.T) {
+	router := setupTimeoutRouter(RequestTimeoutConfig{
+		Default: 20 * time.Millisecond,
+		Routes:  map[string]time.Duration{"GET /slow": time.Second},
+	})
+	server := httptest.NewUnstartedServer(router)
+	server.Config.WriteTimeout = 50 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	// The route timeout moves the write deadline past the server WriteTimeout
+	resp, err := http.Get(server.URL + "/slow")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"status":"done"}`, string(body))
+}
+
+func TestParseRouteTimeouts(t *testing.T) {
+	routes, err := ParseRouteTimeouts(" POST /api/v1/inventory/reconciliation=60s, GET /api/v1/audit/export=2m,GET /stream=0s")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{
+		"POST /api/v1/inventory/reconciliation": time.Minute,
+		"GET /api/v1/audit/export":              2 * time.Minute,
+		"GET /stream":                           0,
+	}, routes)
+
+	routes, err = ParseRouteTimeouts("")
+	require.NoError(t, err)
+	assert.Empty(t, routes)
+
+	for _, spec := range []string{"/api/v1/items=10s", "post /items=10s", "GET items=10s", "GET /items", "GET /items=soon", "GET /items=-1s"} {
+		_, err := ParseRouteTimeouts(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,188 @@
+package validation
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"command-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Limits enforced on request fields
+const (
+	SKUMaxLength         = 64
+	NameMaxLength        = 200
+	DescriptionMaxLength = 2000
+	// MaxQuantity bounds any quantity sent in a request, positive or negative
+	MaxQuantity = 1000000
+)
+
+// skuPattern accepts letters, digits, '.', '_' and '-', starting with a letter or digit
+var skuPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+var registerOnce sync.Once
+
+// Register adds the custom tags (sku, itemname, description, quantity) to gin's validator
+// and makes field errors report JSON field names. It is safe to call more than once.
+func Register() {
+	registerOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		v.RegisterTagNameFunc(jsonFieldName)
+		v.RegisterValidation("sku", func(fl validator.FieldLevel) bool {
+			return ValidSKU(fl.Field().String())
+		})
+		v.RegisterValidation("itemname", func(fl validator.FieldLevel) bool {
+			return validText(fl.Field().String(), NameMaxLength)
+		})
+		v.RegisterValidation("description", func(fl validator.FieldLevel) bool {
+			return utf8.RuneCountInString(fl.Field().String()) <= DescriptionMaxLength
+		})
+		v.RegisterValidation("quantity", func(fl validator.FieldLevel) bool {
+			q := fl.Field().Int()
+			return q >= -MaxQuantity && q <= MaxQuantity
+		})
+	})
+}
+
+// ValidSKU reports whether sku has the accepted format and length
+func ValidSKU(sku string) bool {
+	return len(sku) <= SKUMaxLength && skuPattern.MatchString(sku)
+}
+
+// validText accepts a non-blank string of at most max characters without control characters
+func validText(s string, max int) bool {
+	if strings.TrimSpace(s) == "" || utf8.RuneCountInString(s) > max {
+		return false
+	}
+	for _, r := range s {
+		if unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonFieldName names struct fields after their JSON key; embedded structs keep the Go name
+func jsonFieldName(fld reflect.StructField) string {
+	name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return fld.Name
+	}
+	return name
+}
+
+// BindJSON decodes the request body into obj and validates it.
+// It returns nil on success, or the error to respond with.
+func BindJSON(c *gin.Context, obj interface{}) *errors.StandardError {
+	Register()
+	if err := c.ShouldBindJSON(obj); err != nil {
+		return Error(err)
+	}
+	return nil
+}
+
+// Error translates a binding error into the error envelope: field-level errors for failed
+// validation, PayloadTooLarge for an oversized body and InvalidRequest for malformed JSON
+func Error(err error) *errors.StandardError {
+	var validationErrs validator.ValidationErrors
+	if stderrors.As(err, &validationErrs) {
+		fields := make([]errors.FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, errors.FieldError{
+				Field:   fieldPath(fe),
+				Rule:    fe.Tag(),
+				Message: message(fe),
+			})
+		}
+		return errors.NewFieldErrors(fields)
+	}
+
+	var tooLarge *http.MaxBytesError
+	if stderrors.As(err, &tooLarge) {
+		return errors.NewPayloadTooLarge(tooLarge.Limit)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if stderrors.As(err, &typeErr) {
+		return errors.NewFieldErrors([]errors.FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: fmt.Sprintf("must be a %s", typeErr.Type.Kind()),
+		}})
+	}
+
+	if stderrors.Is(err, io.EOF) {
+		return errors.NewInvalidRequest("request body is required", "")
+	}
+	return errors.NewInvalidRequest("malformed JSON body", err.Error())
+}
+
+// fieldPath turns a validator namespace (CreateItemRequest.StockNote.reason) into the JSON
+// path of the field (reason). Struct and embedded struct names are the segments that are
+// the same in the JSON namespace and in the Go one (CreateItemRequest.StockNote.Reason).
+func fieldPath(fe validator.FieldError) string {
+	segments := strings.Split(fe.Namespace(), ".")
+	goSegments := strings.Split(fe.StructNamespace(), ".")
+	path := make([]string, 0, len(segments))
+	for i, segment := range segments {
+		if i < len(goSegments) && goSegments[i] == segment {
+			continue
+		}
+		path = append(path, segment)
+	}
+	return strings.Join(path, ".")
+}
+
+// message describes the rule a field broke
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		if fe.Kind() == reflect.Slice {
+			return fmt.Sprintf("must have at least %s elements", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		if fe.Kind() == reflect.Slice {
+			return fmt.Sprintf("must have at most %s elements", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", fe.Param())
+	case "sku":
+		return fmt.Sprintf("must be at most %d characters, start with a letter or digit and contain only letters, digits, '.', '_' or '-'", SKUMaxLength)
+	case "itemname":
+		return fmt.Sprintf("must not be blank, be at most %d characters and contain no control characters", NameMaxLength)
+	case "description":
+		return fmt.Sprintf("must be at most %d characters", DescriptionMaxLength)
+	case "quantity":
+		return fmt.Sprintf("must be between -%d and %d", MaxQuantity, MaxQuantity)
+	default:
+		return fmt.Sprintf("failed the '%s' rule", fe.Tag())
+	}
+}
@@ -0,0 +1,26 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidSKU(t *testing.T) {
+	for _, sku := range []string{"SKU-001", "PROD-2024-ABC", "a", "9.5_kg", strings.Repeat("A", SKUMaxLength)} {
+		assert.True(t, ValidSKU(sku), sku)
+	}
+	for _, sku := range []string{"", "-SKU", ".SKU", "SKU 001", "SKU/001", "SKÚ-001", "SKU-001\n", strings.Repeat("A", SKUMaxLength+1)} {
+		assert.False(t, ValidSKU(sku), sku)
+	}
+}
+
+func TestValidText(t *testing.T) {
+	assert.True(t, validText("Laptop Dell XPS 15", NameMaxLength))
+	assert.True(t, validText(strings.Repeat("ñ", NameMaxLength), NameMaxLength))
+	assert.False(t, validText("", NameMaxLength))
+	assert.False(t, validText(" \t", NameMaxLength))
+	assert.False(t, validText("Laptop\x00", NameMaxLength))
+	assert.False(t, validText(strings.Repeat("a", NameMaxLength+1), NameMaxLength))
+}
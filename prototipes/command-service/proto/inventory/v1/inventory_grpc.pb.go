@@ -0,0 +1,350 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: inventory/v1/inventory.proto
+
+// Inventory commands over gRPC, for internal services. Each RPC runs the same command
+// handler as its REST endpoint (see the comments), so validation, persistence and the
+// published events are identical.
+
+package inventoryv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	InventoryCommandService_CreateItem_FullMethodName   = "/inventory.v1.InventoryCommandService/CreateItem"
+	InventoryCommandService_UpdateItem_FullMethodName   = "/inventory.v1.InventoryCommandService/UpdateItem"
+	InventoryCommandService_DeleteItem_FullMethodName   = "/inventory.v1.InventoryCommandService/DeleteItem"
+	InventoryCommandService_AdjustStock_FullMethodName  = "/inventory.v1.InventoryCommandService/AdjustStock"
+	InventoryCommandService_ReserveStock_FullMethodName = "/inventory.v1.InventoryCommandService/ReserveStock"
+	InventoryCommandService_ReleaseStock_FullMethodName = "/inventory.v1.InventoryCommandService/ReleaseStock"
+	InventoryCommandService_CommitStock_FullMethodName  = "/inventory.v1.InventoryCommandService/CommitStock"
+)
+
+// InventoryCommandServiceClient is the client API for InventoryCommandService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type InventoryCommandServiceClient interface {
+	// POST /api/v1/inventory/items
+	CreateItem(ctx context.Context, in *CreateItemRequest, opts ...grpc.CallOption) (*Item, error)
+	// PUT /api/v1/inventory/items/{id}
+	UpdateItem(ctx context.Context, in *UpdateItemRequest, opts ...grpc.CallOption) (*Item, error)
+	// DELETE /api/v1/inventory/items/{id} (requires inventory:delete)
+	DeleteItem(ctx context.Context, in *DeleteItemRequest, opts ...grpc.CallOption) (*DeleteItemResponse, error)
+	// POST /api/v1/inventory/items/{id}/adjust
+	AdjustStock(ctx context.Context, in *AdjustStockRequest, opts ...grpc.CallOption) (*StockLevel, error)
+	// POST /api/v1/inventory/items/{id}/reserve
+	ReserveStock(ctx context.Context, in *ReserveStockRequest, opts ...grpc.CallOption) (*ReserveStockResponse, error)
+	// POST /api/v1/inventory/items/{id}/release
+	ReleaseStock(ctx context.Context, in *ReleaseStockRequest, opts ...grpc.CallOption) (*StockLevel, error)
+	// POST /api/v1/inventory/items/{id}/commit
+	CommitStock(ctx context.Context, in *CommitStockRequest, opts ...grpc.CallOption) (*StockLevel, error)
+}
+
+type inventoryCommandServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewInventoryCommandServiceClient(cc grpc.ClientConnInterface) InventoryCommandServiceClient {
+	return &inventoryCommandServiceClient{cc}
+}
+
+func (c *inventoryCommandServiceClient) CreateItem(ctx context.Context, in *CreateItemRequest, opts ...grpc.CallOption) (*Item, error) {
+	out := new(Item)
+	err := c.cc.Invoke(ctx, InventoryCommandService_CreateItem_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryCommandServiceClient) UpdateItem(ctx context.Context, in *UpdateItemRequest, opts ...grpc.CallOption) (*Item, error) {
+	out := new(Item)
+	err := c.cc.Invoke(ctx, InventoryCommandService_UpdateItem_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryCommandServiceClient) DeleteItem(ctx context.Context, in *DeleteItemRequest, opts ...grpc.CallOption) (*DeleteItemResponse, error) {
+	out := new(DeleteItemResponse)
+	err := c.cc.Invoke(ctx, InventoryCommandService_DeleteItem_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryCommandServiceClient) AdjustStock(ctx context.Context, in *AdjustStockRequest, opts ...grpc.CallOption) (*StockLevel, error) {
+	out := new(StockLevel)
+	err := c.cc.Invoke(ctx, InventoryCommandService_AdjustStock_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryCommandServiceClient) ReserveStock(ctx context.Context, in *ReserveStockRequest, opts ...grpc.CallOption) (*ReserveStockResponse, error) {
+	out := new(ReserveStockResponse)
+	err := c.cc.Invoke(ctx, InventoryCommandService_ReserveStock_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryCommandServiceClient) ReleaseStock(ctx context.Context, in *ReleaseStockRequest, opts ...grpc.CallOption) (*StockLevel, error) {
+	out := new(StockLevel)
+	err := c.cc.Invoke(ctx, InventoryCommandService_ReleaseStock_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryCommandServiceClient) CommitStock(ctx context.Context, in *CommitStockRequest, opts ...grpc.CallOption) (*StockLevel, error) {
+	out := new(StockLevel)
+	err := c.cc.Invoke(ctx, InventoryCommandService_CommitStock_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// InventoryCommandServiceServer is the server API for InventoryCommandService service.
+// All implementations must embed UnimplementedInventoryCommandServiceServer
+// for forward compatibility
+type InventoryCommandServiceServer interface {
+	// POST /api/v1/inventory/items
+	CreateItem(context.Context, *CreateItemRequest) (*Item, error)
+	// PUT /api/v1/inventory/items/{id}
+	UpdateItem(context.Context, *UpdateItemRequest) (*Item, error)
+	// DELETE /api/v1/inventory/items/{id} (requires inventory:delete)
+	DeleteItem(context.Context, *DeleteItemRequest) (*DeleteItemResponse, error)
+	// POST /api/v1/inventory/items/{id}/adjust
+	AdjustStock(context.Context, *AdjustStockRequest) (*StockLevel, error)
+	// POST /api/v1/inventory/items/{id}/reserve
+	ReserveStock(context.Context, *ReserveStockRequest) (*ReserveStockResponse, error)
+	// POST /api/v1/inventory/items/{id}/release
+	ReleaseStock(context.Context, *ReleaseStockRequest) (*StockLevel, error)
+	// POST /api/v1/inventory/items/{id}/commit
+	CommitStock(context.Context, *CommitStockRequest) (*StockLevel, error)
+	mustEmbedUnimplementedInventoryCommandServiceServer()
+}
+
+// UnimplementedInventoryCommandServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedInventoryCommandServiceServer struct {
+}
+
+func (UnimplementedInventoryCommandServiceServer) CreateItem(context.Context, *CreateItemRequest) (*Item, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateItem not implemented")
+}
+func (UnimplementedInventoryCommandServiceServer) UpdateItem(context.Context, *UpdateItemRequest) (*Item, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateItem not implemented")
+}
+func (UnimplementedInventoryCommandServiceServer) DeleteItem(context.Context, *DeleteItemRequest) (*DeleteItemResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteItem not implemented")
+}
+func (UnimplementedInventoryCommandServiceServer) AdjustStock(context.Context, *AdjustStockRequest) (*StockLevel, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdjustStock not implemented")
+}
+func (UnimplementedInventoryCommandServiceServer) ReserveStock(context.Context, *ReserveStockRequest) (*ReserveStockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReserveStock not implemented")
+}
+func (UnimplementedInventoryCommandServiceServer) ReleaseStock(context.Context, *ReleaseStockRequest) (*StockLevel, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseStock not implemented")
+}
+func (UnimplementedInventoryCommandServiceServer) CommitStock(context.Context, *CommitStockRequest) (*StockLevel, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CommitStock not implemented")
+}
+func (UnimplementedInventoryCommandServiceServer) mustEmbedUnimplementedInventoryCommandServiceServer() {
+}
+
+// UnsafeInventoryCommandServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to InventoryCommandServiceServer will
+// result in compilation errors.
+type UnsafeInventoryCommandServiceServer interface {
+	mustEmbedUnimplementedInventoryCommandServiceServer()
+}
+
+func RegisterInventoryCommandServiceServer(s grpc.ServiceRegistrar, srv InventoryCommandServiceServer) {
+	s.RegisterService(&InventoryCommandService_ServiceDesc, srv)
+}
+
+func _InventoryCommandService_CreateItem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateItemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryCommandServiceServer).CreateItem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryCommandService_CreateItem_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryCommandServiceServer).CreateItem(ctx, req.(*CreateItemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryCommandService_UpdateItem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateItemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryCommandServiceServer).UpdateItem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryCommandService_UpdateItem_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryCommandServiceServer).UpdateItem(ctx, req.(*UpdateItemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryCommandService_DeleteItem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteItemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryCommandServiceServer).DeleteItem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryCommandService_DeleteItem_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryCommandServiceServer).DeleteItem(ctx, req.(*DeleteItemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryCommandService_AdjustStock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdjustStockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryCommandServiceServer).AdjustStock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryCommandService_AdjustStock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryCommandServiceServer).AdjustStock(ctx, req.(*AdjustStockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryCommandService_ReserveStock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReserveStockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryCommandServiceServer).ReserveStock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryCommandService_ReserveStock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryCommandServiceServer).ReserveStock(ctx, req.(*ReserveStockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryCommandService_ReleaseStock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseStockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryCommandServiceServer).ReleaseStock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryCommandService_ReleaseStock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryCommandServiceServer).ReleaseStock(ctx, req.(*ReleaseStockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryCommandService_CommitStock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommitStockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryCommandServiceServer).CommitStock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryCommandService_CommitStock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryCommandServiceServer).CommitStock(ctx, req.(*CommitStockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// InventoryCommandService_ServiceDesc is the grpc.ServiceDesc for InventoryCommandService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var InventoryCommandService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "inventory.v1.InventoryCommandService",
+	HandlerType: (*InventoryCommandServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateItem",
+			Handler:    _InventoryCommandService_CreateItem_Handler,
+		},
+		{
+			MethodName: "UpdateItem",
+			Handler:    _InventoryCommandService_UpdateItem_Handler,
+		},
+		{
+			MethodName: "DeleteItem",
+			Handler:    _InventoryCommandService_DeleteItem_Handler,
+		},
+		{
+			MethodName: "AdjustStock",
+			Handler:    _InventoryCommandService_AdjustStock_Handler,
+		},
+		{
+			MethodName: "ReserveStock",
+			Handler:    _InventoryCommandService_ReserveStock_Handler,
+		},
+		{
+			MethodName: "ReleaseStock",
+			Handler:    _InventoryCommandService_ReleaseStock_Handler,
+		},
+		{
+			MethodName: "CommitStock",
+			Handler:    _InventoryCommandService_CommitStock_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "inventory/v1/inventory.proto",
+}
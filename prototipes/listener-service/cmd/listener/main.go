@@ -2,15 +2,23 @@ package main
 
 import (
 	"context"
+	"flag"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"listener-service/internal/archive"
+	"listener-service/internal/backup"
 	"listener-service/internal/config"
 	"listener-service/internal/database"
 	"listener-service/internal/events"
 	"listener-service/internal/kafka"
+	"listener-service/internal/replication"
 	"listener-service/pkg/logger"
+	"listener-service/pkg/tracing"
+
+	"testsupport"
 
 	"go.uber.org/zap"
 )
@@ -19,55 +27,197 @@ func main() {
 	// Load configuration
 	cfg := config.Load()
 
+	dryRun := flag.Bool("dry-run", cfg.DryRun, "Log what each event would do without writing to SQLite or publishing confirmations")
+	checkConfig := flag.Bool("check-config", false, "Validate the configuration, probe the event bus and the SQLite path, and exit (non-zero if anything failed)")
+	flag.Parse()
+	cfg.DryRun = *dryRun
+	if *checkConfig {
+		if !cfg.Check(context.Background(), os.Stdout, time.Duration(cfg.HealthCheckTimeoutMs)*time.Millisecond) {
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Initialize logger
 	appLogger := logger.New(cfg.Environment)
 	defer appLogger.Sync()
 
+	// Refuse to start with a configuration that would only fail later
+	if err := cfg.Validate(); err != nil {
+		appLogger.Fatal("❌ Invalid configuration", zap.Error(err))
+	}
+	if err := cfg.RunStartupChecks(context.Background(), appLogger); err != nil {
+		appLogger.Fatal("❌ Startup checks failed (STARTUP_CHECK_MODE=strict)", zap.Error(err))
+	}
+
 	appLogger.Info("🚀 Starting Listener Service (Legacy Mode)",
 		zap.String("environment", cfg.Environment),
+		zap.String("driver", cfg.DBDriver),
 		zap.String("sqlite_path", cfg.SQLitePath),
 		zap.Strings("kafka_brokers", cfg.KafkaBrokers),
 		zap.String("kafka_group_id", cfg.KafkaGroupID),
 	)
 
 	appLogger.Info("📡 Kafka Configuration",
+		zap.String("event_bus", cfg.EventBus),
 		zap.Strings("brokers", cfg.KafkaBrokers),
 		zap.String("topic_items", cfg.KafkaTopicItems),
 		zap.String("topic_stock", cfg.KafkaTopicStock),
+		zap.String("topic_subscription", cfg.KafkaTopicSubscription),
 		zap.String("group_id", cfg.KafkaGroupID),
 		zap.Bool("auto_commit", cfg.KafkaAutoCommit),
 	)
 
-	// Initialize database (Single Writer)
-	appLogger.Info("🔧 Initializing database...")
-	db, err := database.NewSingleWriterDB(cfg, appLogger)
+	var db database.WriterDB
+	var processor kafka.EventHandler
+	var activity kafka.ActivityRecorder     // stays nil in dry-run (read-only database)
+	var rejections kafka.RejectionPublisher // stays nil without a producer (dry-run, mock mode)
+	var forwarder kafka.MessageForwarder    // stays nil without a producer too
+	var replicationState *replication.State
+	var err error
+
+	// Initialize tracing; event processing continues the traces started by the Command Service
+	shutdownTracing, err := tracing.Init(context.Background(), appLogger)
 	if err != nil {
-		appLogger.Fatal("Failed to initialize database", zap.Error(err))
+		appLogger.Fatal("Failed to initialize tracing", zap.Error(err))
 	}
-	defer db.Close()
-	appLogger.Info("✅ Database initialized successfully")
 
-	// Initialize Kafka producer for confirmation events
-	appLogger.Info("🔧 Initializing Kafka producer for confirmation events...")
-	producer, err := kafka.NewProducer(cfg, appLogger)
-	if err != nil {
-		appLogger.Fatal("Failed to initialize Kafka producer", zap.Error(err))
+	if cfg.MockDependencies {
+		if *dryRun {
+			appLogger.Fatal("Dry-run mode needs an existing database and cannot be combined with MOCK_DEPENDENCIES")
+		}
+		appLogger.Warn("🧪 Mock mode: in-memory SQLite and event broker, no Kafka; state is lost on exit")
 	}
-	defer producer.Close()
-	appLogger.Info("✅ Kafka producer initialized successfully")
 
-	// Initialize event processor
-	appLogger.Info("🔧 Initializing event processor...")
-	processor := events.NewEventProcessor(db, producer, appLogger)
-	appLogger.Info("✅ Event processor initialized successfully")
+	if *dryRun {
+		// Dry-run: read-only database, no producer, separate consumer group
+		cfg.KafkaGroupID = cfg.DryRunGroupID
+		appLogger.Warn("🧪 Dry-run mode: events will be evaluated and logged, nothing will be written or published",
+			zap.String("group_id", cfg.KafkaGroupID),
+		)
+
+		appLogger.Info("🔧 Opening database in read-only mode...")
+		db, err = database.OpenReadOnly(cfg, appLogger)
+		if err != nil {
+			appLogger.Fatal("Failed to open database", zap.Error(err))
+		}
+		defer db.Close()
+		appLogger.Info("✅ Database opened successfully")
+
+		processor = events.NewDryRunProcessor(db, appLogger)
+		replicationState = replication.NewState(cfg.ReplicationRole, cfg.Region, nil, appLogger)
+	} else {
+		// Initialize database (Single Writer)
+		appLogger.Info("🔧 Initializing database...")
+		db, err = database.Open(cfg, appLogger)
+		if err != nil {
+			appLogger.Fatal("Failed to initialize database", zap.Error(err))
+		}
+		defer db.Close()
+		appLogger.Info("✅ Database initialized successfully", zap.String("driver", db.Driver()))
+
+		// Replication role: a secondary region applies events but does not confirm them
+		replicationState = replication.NewState(cfg.ReplicationRole, cfg.Region, db, appLogger)
+		if err := replicationState.Restore(context.Background()); err != nil {
+			appLogger.Fatal("Failed to restore replication role", zap.Error(err))
+		}
+		appLogger.Info("🌍 Replication role",
+			zap.String("role", replicationState.Role()),
+			zap.String("region", cfg.Region),
+			zap.String("group_id", cfg.KafkaGroupID),
+		)
+
+		// Initialize Kafka producer for confirmation events (none in mock mode)
+		var publisher events.EventPublisher
+		if !cfg.MockDependencies {
+			appLogger.Info("🔧 Initializing Kafka producer for confirmation events...")
+			producer, err := kafka.NewProducer(cfg, appLogger)
+			if err != nil {
+				appLogger.Fatal("Failed to initialize Kafka producer", zap.Error(err))
+			}
+			defer producer.Close()
+			gated := replication.NewPublisher(replicationState, producer, appLogger)
+			publisher, rejections = gated, gated
+			forwarder = producer
+			appLogger.Info("✅ Kafka producer initialized successfully")
+		}
+
+		// Initialize event processor
+		appLogger.Info("🔧 Initializing event processor...")
+		processor = events.NewEventProcessor(db, publisher, appLogger)
+		activity = db
+		appLogger.Info("✅ Event processor initialized successfully")
+	}
 
 	// Initialize Kafka consumer
 	appLogger.Info("🔧 Initializing Kafka consumer...")
-	consumer, err := kafka.NewConsumer(cfg, processor, appLogger)
+	var consumer *kafka.Consumer
+	if cfg.MockDependencies {
+		consumer, err = kafka.NewMockConsumer(cfg, testsupport.NewBroker(), processor, activity, appLogger)
+	} else {
+		consumer, err = kafka.NewConsumer(cfg, processor, activity, appLogger)
+	}
 	if err != nil {
 		appLogger.Fatal("Failed to initialize Kafka consumer", zap.Error(err))
 	}
 	defer consumer.Close()
+	consumer.SetProgressObserver(replicationState)
+	if rejections != nil {
+		// Events that cannot be applied are reported back to the Command Service
+		consumer.SetRejectionPublisher(rejections)
+	}
+	if cfg.BatchSize > 1 && !*dryRun {
+		consumer.SetBatchWriter(db)
+		appLogger.Info("📦 Batched writes enabled",
+			zap.Int("batch_size", cfg.BatchSize),
+			zap.Int("batch_window_ms", cfg.BatchWindowMs),
+		)
+	}
+	if cfg.EventDedupEnabled && !*dryRun {
+		// Events redelivered after they were applied (crash before the offset commit) are skipped
+		consumer.SetDeduplicator(db)
+	}
+	if forwarder != nil && cfg.DeadLetterQueue {
+		// Events that still fail after their retries are kept in DLQ_TOPIC
+		consumer.SetDeadLetterQueue(forwarder)
+	}
+	if forwarder != nil && cfg.RetryTopics != "" {
+		// Events still failing after MAX_RETRIES wait in retry topics instead of holding their partition
+		delays, _ := cfg.RetryTopicDelays() // validated at startup
+		consumer.SetRetryTopics(forwarder, delays)
+		appLogger.Info("🔁 Retry topics enabled", zap.String("retry_topics", cfg.RetryTopics))
+	}
+	if cfg.ArchiveEnabled && !*dryRun {
+		// Archive every handled event to object storage, beyond the retention of the topics
+		archiver, err := archive.New(cfg, appLogger)
+		if err != nil {
+			appLogger.Fatal("Failed to initialize event archive", zap.Error(err))
+		}
+		archiver.Start()
+		defer archiver.Close()
+		consumer.SetArchiver(archiver)
+		appLogger.Info("🗄️ Event archive enabled",
+			zap.String("endpoint", cfg.ArchiveEndpoint),
+			zap.String("bucket", cfg.ArchiveBucket),
+			zap.Int("batch_size", cfg.ArchiveBatchSize),
+			zap.Int("retention_days", cfg.ArchiveRetentionDays),
+		)
+	}
+	if cfg.BackupEnabled && !*dryRun {
+		// Periodic online backups of the SQLite read model
+		backups, err := backup.New(cfg, db, consumer, appLogger)
+		if err != nil {
+			appLogger.Fatal("Failed to initialize backups", zap.Error(err))
+		}
+		backups.Start()
+		defer backups.Close()
+		appLogger.Info("💾 Backups enabled",
+			zap.String("dir", cfg.BackupDir),
+			zap.String("s3_bucket", cfg.BackupS3Bucket),
+			zap.Int("interval_minutes", cfg.BackupIntervalMinutes),
+			zap.Int("keep", cfg.BackupKeep),
+		)
+	}
 	appLogger.Info("✅ Kafka consumer initialized successfully",
 		zap.Strings("topics", []string{cfg.KafkaTopicItems, cfg.KafkaTopicStock}),
 	)
@@ -97,6 +247,10 @@ func main() {
 		cancel()
 	}
 
+	if err := shutdownTracing(context.Background()); err != nil {
+		appLogger.Warn("Failed to flush traces", zap.Error(err))
+	}
+
 	appLogger.Info("Listener service exited")
 }
 
@@ -0,0 +1,353 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"listener-service/internal/config"
+	"listener-service/pkg/metrics"
+
+	"github.com/IBM/sarama"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxPendingBatches bounds the buffer while the storage is down, in files
+	// (ARCHIVE_BATCH_SIZE events each); the oldest events are dropped beyond it
+	maxPendingBatches = 10
+	// retentionInterval is how often files older than ARCHIVE_RETENTION_DAYS are deleted
+	retentionInterval = time.Hour
+	// storageTimeout bounds every call to the object storage
+	storageTimeout = 30 * time.Second
+)
+
+// Record is one line of an archive file: a handled message, its outcome and its payload
+// as published
+type Record struct {
+	EventID     string            `json:"event_id,omitempty"`
+	EventType   string            `json:"event_type"`
+	Outcome     string            `json:"outcome"` // applied, failed or skipped
+	Topic       string            `json:"topic"`
+	Partition   int32             `json:"partition"`
+	Offset      int64             `json:"offset"`
+	Key         string            `json:"key,omitempty"`
+	Timestamp   time.Time         `json:"timestamp"` // when the event was published
+	ProcessedAt time.Time         `json:"processed_at"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	// The message value when it is JSON; encrypted payloads are kept encrypted, in
+	// PayloadBase64, and can be decrypted with the key named by their headers
+	Payload       json.RawMessage `json:"payload,omitempty"`
+	PayloadBase64 string          `json:"payload_base64,omitempty"`
+}
+
+// Archiver writes the handled events to S3-compatible storage (MinIO) as gzipped JSONL
+// files, partitioned by topic and by hour of publication, so they outlive the
+// retention of the topics. Events are buffered in memory and written every
+// ARCHIVE_BATCH_SIZE events or ARCHIVE_FLUSH_INTERVAL_SECONDS: a crash loses the
+// buffered ones.
+type Archiver struct {
+	client        *minio.Client
+	bucket        string
+	prefix        string
+	batchSize     int
+	flushInterval time.Duration
+	retention     time.Duration // 0 keeps files forever
+	logger        *zap.Logger
+
+	mu      sync.Mutex
+	pending []Record
+	full    chan struct{} // signalled when a batch is ready
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewStorageClient connects to the S3-compatible storage of cfg (ARCHIVE_ENDPOINT and
+// credentials) and creates bucket if it does not exist. Shared with the backups.
+func NewStorageClient(cfg *config.Config, bucket string, logger *zap.Logger) (*minio.Client, error) {
+	client, err := minio.New(cfg.ArchiveEndpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.ArchiveAccessKey, cfg.ArchiveSecretKey, ""),
+		Secure: cfg.ArchiveUseSSL,
+		Region: cfg.ArchiveRegion,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+	defer cancel()
+	exists, err := client.BucketExists(ctx, bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check bucket %s: %w", bucket, err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{Region: cfg.ArchiveRegion}); err != nil {
+			return nil, fmt.Errorf("failed to create bucket %s: %w", bucket, err)
+		}
+		logger.Info("🗄️ Bucket created", zap.String("bucket", bucket))
+	}
+	return client, nil
+}
+
+// New connects to the storage of cfg and creates the bucket if it does not exist
+func New(cfg *config.Config, logger *zap.Logger) (*Archiver, error) {
+	client, err := NewStorageClient(cfg, cfg.ArchiveBucket, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive storage: %w", err)
+	}
+
+	return &Archiver{
+		client:        client,
+		bucket:        cfg.ArchiveBucket,
+		prefix:        cfg.ArchivePrefix,
+		batchSize:     cfg.ArchiveBatchSize,
+		flushInterval: time.Duration(cfg.ArchiveFlushIntervalSeconds) * time.Second,
+		retention:     time.Duration(cfg.ArchiveRetentionDays) * 24 * time.Hour,
+		logger:        logger,
+		full:          make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}, nil
+}
+
+// Start writes the buffered events in the background until Close
+func (a *Archiver) Start() {
+	go a.run()
+}
+
+func (a *Archiver) run() {
+	defer close(a.done)
+	flushTicker := time.NewTicker(a.flushInterval)
+	defer flushTicker.Stop()
+	retentionTicker := time.NewTicker(retentionInterval)
+	defer retentionTicker.Stop()
+	a.expire()
+
+	for {
+		select {
+		case <-a.full:
+			a.flush()
+		case <-flushTicker.C:
+			a.flush()
+		case <-retentionTicker.C:
+			a.expire()
+		case <-a.stop:
+			a.flush()
+			return
+		}
+	}
+}
+
+// Archive buffers a handled message. It never blocks event processing: when the
+// storage has been down for maxPendingBatches files, the oldest events are dropped.
+func (a *Archiver) Archive(message *sarama.ConsumerMessage, eventType, outcome string) {
+	record := newRecord(message, eventType, outcome)
+
+	a.mu.Lock()
+	a.pending = append(a.pending, record)
+	if overflow := len(a.pending) - a.batchSize*maxPendingBatches; overflow > 0 {
+		a.pending = a.pending[overflow:]
+		metrics.EventsArchived.WithLabelValues("dropped").Add(float64(overflow))
+		a.logger.Error("Archive buffer full, dropping oldest events", zap.Int("dropped", overflow))
+	}
+	ready := len(a.pending) >= a.batchSize
+	metrics.ArchivePending.Set(float64(len(a.pending)))
+	a.mu.Unlock()
+
+	if ready {
+		select {
+		case a.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// flush writes the buffered events, one file per topic, partition and hour. Files that
+// cannot be written are put back in the buffer for the next flush.
+func (a *Archiver) flush() {
+	a.mu.Lock()
+	records := a.pending
+	a.pending = nil
+	a.mu.Unlock()
+	if len(records) == 0 {
+		return
+	}
+
+	var failed []Record
+	for _, group := range groupRecords(records) {
+		if err := a.upload(group); err != nil {
+			metrics.ArchiveUploads.WithLabelValues("error").Inc()
+			a.logger.Warn("Failed to write archive file, will retry",
+				zap.String("topic", group[0].Topic),
+				zap.Int("events", len(group)),
+				zap.Error(err),
+			)
+			failed = append(failed, group...)
+			continue
+		}
+		metrics.ArchiveUploads.WithLabelValues("success").Inc()
+		metrics.EventsArchived.WithLabelValues("uploaded").Add(float64(len(group)))
+	}
+
+	a.mu.Lock()
+	a.pending = append(failed, a.pending...)
+	metrics.ArchivePending.Set(float64(len(a.pending)))
+	a.mu.Unlock()
+}
+
+// upload writes the records of one topic, partition and hour as a gzipped JSONL file
+func (a *Archiver) upload(records []Record) error {
+	var body bytes.Buffer
+	gz := gzip.NewWriter(&body)
+	encoder := json.NewEncoder(gz)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to encode record: %w", err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to compress archive file: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storageTimeout)
+	defer cancel()
+	key := a.objectKey(records)
+	_, err := a.client.PutObject(ctx, a.bucket, key, &body, int64(body.Len()), minio.PutObjectOptions{
+		ContentType:     "application/x-ndjson",
+		ContentEncoding: "gzip",
+	})
+	if err != nil {
+		return err
+	}
+	a.logger.Debug("Archive file written", zap.String("key", key), zap.Int("events", len(records)))
+	return nil
+}
+
+// objectKey names the file of records, which share topic, partition and hour:
+// <prefix>/topic=<topic>/date=<YYYY-MM-DD>/hour=<HH>/<topic>-<partition>-<first offset>-<last offset>.jsonl.gz.
+// A message handled twice (redelivered after a rebalance) rewrites the same file.
+func (a *Archiver) objectKey(records []Record) string {
+	first, last := records[0], records[len(records)-1]
+	at := archiveTime(first)
+	key := fmt.Sprintf("topic=%s/date=%s/hour=%02d/%s-%d-%d-%d.jsonl.gz",
+		first.Topic, at.Format("2006-01-02"), at.Hour(), first.Topic, first.Partition, first.Offset, last.Offset)
+	if a.prefix != "" {
+		key = a.prefix + "/" + key
+	}
+	return key
+}
+
+// expire deletes the files written more than ARCHIVE_RETENTION_DAYS ago
+func (a *Archiver) expire() {
+	if a.retention <= 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), retentionInterval/2)
+	defer cancel()
+
+	cutoff := time.Now().Add(-a.retention)
+	deleted := 0
+	prefix := a.prefix
+	if prefix != "" {
+		prefix += "/"
+	}
+	for object := range a.client.ListObjects(ctx, a.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if object.Err != nil {
+			a.logger.Warn("Failed to list archive files", zap.Error(object.Err))
+			return
+		}
+		if !object.LastModified.Before(cutoff) {
+			continue
+		}
+		if err := a.client.RemoveObject(ctx, a.bucket, object.Key, minio.RemoveObjectOptions{}); err != nil {
+			a.logger.Warn("Failed to delete expired archive file", zap.String("key", object.Key), zap.Error(err))
+			continue
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		a.logger.Info("🗄️ Expired archive files deleted", zap.Int("files", deleted), zap.Time("older_than", cutoff))
+	}
+}
+
+// Ping checks that the bucket can be reached. Used by the readiness probe.
+func (a *Archiver) Ping(ctx context.Context) error {
+	if _, err := a.client.BucketExists(ctx, a.bucket); err != nil {
+		return fmt.Errorf("archive storage unreachable: %w", err)
+	}
+	return nil
+}
+
+// Close writes the buffered events and stops the archiver
+func (a *Archiver) Close() error {
+	close(a.stop)
+	<-a.done
+	return nil
+}
+
+// newRecord copies what the archive keeps of a message
+func newRecord(message *sarama.ConsumerMessage, eventType, outcome string) Record {
+	record := Record{
+		EventType:   eventType,
+		Outcome:     outcome,
+		Topic:       message.Topic,
+		Partition:   message.Partition,
+		Offset:      message.Offset,
+		Key:         string(message.Key),
+		Timestamp:   message.Timestamp.UTC(),
+		ProcessedAt: time.Now().UTC(),
+	}
+	if len(message.Headers) > 0 {
+		record.Headers = make(map[string]string, len(message.Headers))
+		for _, header := range message.Headers {
+			record.Headers[string(header.Key)] = string(header.Value)
+		}
+		record.EventID = record.Headers["event-id"]
+	}
+	if json.Valid(message.Value) {
+		record.Payload = append(json.RawMessage(nil), message.Value...)
+	} else if len(message.Value) > 0 {
+		record.PayloadBase64 = base64.StdEncoding.EncodeToString(message.Value)
+	}
+	return record
+}
+
+// groupRecords splits records by topic, partition and hour, each group in offset order
+func groupRecords(records []Record) [][]Record {
+	groups := make(map[string][]Record)
+	var keys []string
+	for _, record := range records {
+		key := record.Topic + "/" + strconv.Itoa(int(record.Partition)) + "/" + archiveTime(record).Format("2006-01-02T15")
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], record)
+	}
+	sort.Strings(keys)
+
+	result := make([][]Record, 0, len(keys))
+	for _, key := range keys {
+		group := groups[key]
+		sort.SliceStable(group, func(i, j int) bool { return group[i].Offset < group[j].Offset })
+		result = append(result, group)
+	}
+	return result
+}
+
+// archiveTime is the hour a record is filed under: when it was published, or when it
+// was handled for messages without a timestamp
+func archiveTime(record Record) time.Time {
+	if record.Timestamp.IsZero() || record.Timestamp.Unix() <= 0 {
+		return record.ProcessedAt
+	}
+	return record.Timestamp
+}
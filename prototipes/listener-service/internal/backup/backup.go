@@ -0,0 +1,409 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"listener-service/internal/archive"
+	"listener-service/internal/config"
+	"listener-service/internal/database"
+	"listener-service/internal/kafka"
+	"listener-service/internal/rebuild"
+	"listener-service/pkg/metrics"
+
+	"github.com/minio/minio-go/v7"
+	"go.uber.org/zap"
+)
+
+const (
+	// namePrefix and timeLayout name every backup after when it was taken, so names
+	// sort in time order: inventory-20240115T130000Z. The SQLite copy is <name>.db and
+	// its manifest <name>.json.
+	namePrefix = "inventory-"
+	timeLayout = "20060102T150405Z"
+	// storageTimeout bounds every transfer of a backup to or from the object storage
+	storageTimeout = 10 * time.Minute
+)
+
+var (
+	// ErrNotFound is returned for a backup that does not exist
+	ErrNotFound = errors.New("backup not found")
+	// ErrInvalidName is returned for a name that is not one of a backup
+	ErrInvalidName = errors.New("invalid backup name")
+)
+
+// Info describes a backup, as listed by the monitoring API
+type Info struct {
+	Name      string    `json:"name" example:"inventory-20240115T130000Z"`
+	CreatedAt time.Time `json:"created_at" example:"2024-01-15T13:00:00Z"`
+	SizeBytes int64     `json:"size_bytes" example:"1048576"`
+	Location  string    `json:"location" example:"backups/inventory-20240115T130000Z.db"` // file, or s3://bucket/key
+}
+
+// manifest is written next to every backup: the consumer positions it matches, from
+// which a restore replays the events that came after it
+type manifest struct {
+	Name          string           `json:"name"`
+	CreatedAt     time.Time        `json:"created_at"`
+	SchemaVersion int              `json:"schema_version"`
+	Positions     []kafka.Position `json:"positions,omitempty"` // none with NATS or RabbitMQ
+}
+
+// Consumer is paused while a backup is taken, so its positions match what the backup
+// holds. It is implemented by kafka.Consumer.
+type Consumer interface {
+	Pause() (resume func(replayed []kafka.ReplayPartition), err error)
+	Positions() ([]kafka.Position, error)
+}
+
+// Manager takes online backups of the SQLite read model every BACKUP_INTERVAL_MINUTES,
+// into BACKUP_DIR or to the object storage (BACKUP_S3_BUCKET), keeping the newest
+// BACKUP_KEEP, and fetches them back for a restore
+type Manager struct {
+	db       database.WriterDB
+	consumer Consumer
+	dir      string
+	keep     int
+	interval time.Duration
+	s3       *minio.Client // nil keeps the backups in dir
+	bucket   string
+	prefix   string
+	logger   *zap.Logger
+
+	mu   sync.Mutex // one backup at a time
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New creates the backup directory and, with BACKUP_S3_BUCKET, connects to the object
+// storage and creates the bucket if it does not exist
+func New(cfg *config.Config, db database.WriterDB, consumer Consumer, logger *zap.Logger) (*Manager, error) {
+	if err := os.MkdirAll(cfg.BackupDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory %s: %w", cfg.BackupDir, err)
+	}
+	m := &Manager{
+		db:       db,
+		consumer: consumer,
+		dir:      cfg.BackupDir,
+		keep:     cfg.BackupKeep,
+		interval: time.Duration(cfg.BackupIntervalMinutes) * time.Minute,
+		bucket:   cfg.BackupS3Bucket,
+		prefix:   cfg.BackupS3Prefix,
+		logger:   logger,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if cfg.BackupS3Bucket != "" {
+		client, err := archive.NewStorageClient(cfg, cfg.BackupS3Bucket, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open backup storage: %w", err)
+		}
+		m.s3 = client
+	}
+	return m, nil
+}
+
+// Start takes a backup every interval in the background until Close
+func (m *Manager) Start() {
+	go m.run()
+}
+
+func (m *Manager) run() {
+	defer close(m.done)
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			// Failures are logged and counted by Backup; the next tick tries again
+			_, _ = m.Backup(context.Background())
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// Close stops the periodic backups, waiting for one in progress
+func (m *Manager) Close() error {
+	close(m.stop)
+	<-m.done
+	return nil
+}
+
+// Backup takes a backup now, then deletes those beyond BACKUP_KEEP
+func (m *Manager) Backup(ctx context.Context) (Info, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	start := time.Now()
+	info, err := m.take(ctx)
+	if err != nil {
+		metrics.BackupsTaken.WithLabelValues("error").Inc()
+		m.logger.Error("❌ Backup failed", zap.Error(err))
+		return Info{}, err
+	}
+	metrics.BackupsTaken.WithLabelValues("success").Inc()
+	metrics.BackupLastSuccess.Set(float64(info.CreatedAt.Unix()))
+	metrics.BackupSize.Set(float64(info.SizeBytes))
+	m.logger.Info("💾 Backup taken",
+		zap.String("name", info.Name),
+		zap.String("location", info.Location),
+		zap.Int64("size_bytes", info.SizeBytes),
+		zap.Duration("duration", time.Since(start)),
+	)
+
+	m.prune(ctx)
+	return info, nil
+}
+
+// take writes the SQLite copy and its manifest, and uploads both with S3
+func (m *Manager) take(ctx context.Context) (Info, error) {
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	name := namePrefix + createdAt.Format(timeLayout)
+	path := filepath.Join(m.dir, name+".db")
+	if _, err := os.Stat(path); err == nil {
+		return Info{}, fmt.Errorf("backup %s already exists", name)
+	}
+
+	positions, err := m.copy(ctx, path)
+	if err != nil {
+		os.Remove(path)
+		return Info{}, err
+	}
+	body, err := json.MarshalIndent(manifest{
+		Name:          name,
+		CreatedAt:     createdAt,
+		SchemaVersion: database.SchemaVersion,
+		Positions:     positions,
+	}, "", "  ")
+	if err != nil {
+		os.Remove(path)
+		return Info{}, fmt.Errorf("failed to encode backup manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(m.dir, name+".json"), body, 0o644); err != nil {
+		os.Remove(path)
+		return Info{}, fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return Info{}, err
+	}
+	info := Info{Name: name, CreatedAt: createdAt, SizeBytes: stat.Size(), Location: path}
+	if m.s3 == nil {
+		return info, nil
+	}
+
+	// The local files were only staged for the upload
+	defer m.removeLocal(name)
+	for _, ext := range []string{".db", ".json"} {
+		if err := m.upload(ctx, name+ext); err != nil {
+			m.removeRemote(ctx, name)
+			return Info{}, err
+		}
+	}
+	info.Location = "s3://" + m.bucket + "/" + m.objectKey(name+".db")
+	return info, nil
+}
+
+// copy backs the read model up to path with the consumer paused, and returns the
+// positions the copy matches. The NATS and RabbitMQ consumers cannot be paused nor
+// report positions: their backups are taken while events keep being applied.
+func (m *Manager) copy(ctx context.Context, path string) ([]kafka.Position, error) {
+	resume, err := m.consumer.Pause()
+	if err != nil {
+		return nil, m.db.Backup(ctx, path)
+	}
+	defer resume(nil)
+
+	positions, err := m.consumer.Positions()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.db.Backup(ctx, path); err != nil {
+		return nil, err
+	}
+	return positions, nil
+}
+
+// List returns the backups, newest first
+func (m *Manager) List(ctx context.Context) ([]Info, error) {
+	var backups []Info
+	if m.s3 != nil {
+		listCtx, cancel := context.WithTimeout(ctx, storageTimeout)
+		defer cancel()
+		for object := range m.s3.ListObjects(listCtx, m.bucket, minio.ListObjectsOptions{Prefix: m.objectKey(namePrefix)}) {
+			if object.Err != nil {
+				return nil, fmt.Errorf("failed to list backups: %w", object.Err)
+			}
+			name := strings.TrimSuffix(filepath.Base(object.Key), ".db")
+			if createdAt, ok := parseName(name); ok && strings.HasSuffix(object.Key, ".db") {
+				backups = append(backups, Info{Name: name, CreatedAt: createdAt, SizeBytes: object.Size, Location: "s3://" + m.bucket + "/" + object.Key})
+			}
+		}
+	} else {
+		entries, err := os.ReadDir(m.dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list backups: %w", err)
+		}
+		for _, entry := range entries {
+			name := strings.TrimSuffix(entry.Name(), ".db")
+			createdAt, ok := parseName(name)
+			if !ok || !strings.HasSuffix(entry.Name(), ".db") {
+				continue
+			}
+			stat, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			backups = append(backups, Info{Name: name, CreatedAt: createdAt, SizeBytes: stat.Size(), Location: filepath.Join(m.dir, entry.Name())})
+		}
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Name > backups[j].Name })
+	return backups, nil
+}
+
+// Fetch gets a backup ready to be restored: the newest one when name is empty. Backups
+// in the object storage are downloaded to BACKUP_DIR and deleted by the Cleanup of the
+// result.
+func (m *Manager) Fetch(ctx context.Context, name string) (rebuild.Backup, error) {
+	if name == "" {
+		backups, err := m.List(ctx)
+		if err != nil {
+			return rebuild.Backup{}, err
+		}
+		if len(backups) == 0 {
+			return rebuild.Backup{}, fmt.Errorf("%w: no backup has been taken", ErrNotFound)
+		}
+		name = backups[0].Name
+	}
+	if _, ok := parseName(name); !ok {
+		return rebuild.Backup{}, fmt.Errorf("%w: %q (expected %s<YYYYMMDDTHHMMSSZ>)", ErrInvalidName, name, namePrefix)
+	}
+
+	backup := rebuild.Backup{Name: name, Path: filepath.Join(m.dir, name+".db")}
+	manifestPath := filepath.Join(m.dir, name+".json")
+	if m.s3 != nil {
+		backup.Path = filepath.Join(m.dir, name+".restore.db")
+		manifestPath = filepath.Join(m.dir, name+".restore.json")
+		backup.Cleanup = func() {
+			os.Remove(backup.Path)
+			os.Remove(manifestPath)
+		}
+		if err := m.download(ctx, name+".db", backup.Path); err != nil {
+			backup.Cleanup()
+			return rebuild.Backup{}, err
+		}
+		if err := m.download(ctx, name+".json", manifestPath); err != nil {
+			backup.Cleanup()
+			return rebuild.Backup{}, err
+		}
+	} else if _, err := os.Stat(backup.Path); os.IsNotExist(err) {
+		return rebuild.Backup{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	body, err := os.ReadFile(manifestPath)
+	if err == nil {
+		var content manifest
+		if err = json.Unmarshal(body, &content); err == nil {
+			backup.Positions = content.Positions
+		}
+	}
+	if err != nil {
+		if backup.Cleanup != nil {
+			backup.Cleanup()
+		}
+		return rebuild.Backup{}, fmt.Errorf("failed to read manifest of backup %s: %w", name, err)
+	}
+	return backup, nil
+}
+
+// prune deletes the backups beyond the newest BACKUP_KEEP
+func (m *Manager) prune(ctx context.Context) {
+	backups, err := m.List(ctx)
+	if err != nil {
+		m.logger.Warn("Failed to list backups to prune", zap.Error(err))
+		return
+	}
+	if len(backups) <= m.keep {
+		return
+	}
+	for _, old := range backups[m.keep:] {
+		if m.s3 != nil {
+			m.removeRemote(ctx, old.Name)
+		} else {
+			m.removeLocal(old.Name)
+		}
+		m.logger.Info("🗑️ Old backup deleted", zap.String("name", old.Name))
+	}
+}
+
+// upload copies a staged file of BACKUP_DIR to the bucket
+func (m *Manager) upload(ctx context.Context, file string) error {
+	ctx, cancel := context.WithTimeout(ctx, storageTimeout)
+	defer cancel()
+	if _, err := m.s3.FPutObject(ctx, m.bucket, m.objectKey(file), filepath.Join(m.dir, file), minio.PutObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to upload backup file %s: %w", file, err)
+	}
+	return nil
+}
+
+// download copies a file of the bucket to path
+func (m *Manager) download(ctx context.Context, file, path string) error {
+	ctx, cancel := context.WithTimeout(ctx, storageTimeout)
+	defer cancel()
+	if err := m.s3.FGetObject(ctx, m.bucket, m.objectKey(file), path, minio.GetObjectOptions{}); err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return fmt.Errorf("%w: %s", ErrNotFound, file)
+		}
+		return fmt.Errorf("failed to download backup file %s: %w", file, err)
+	}
+	return nil
+}
+
+// removeLocal deletes the files of a backup in BACKUP_DIR
+func (m *Manager) removeLocal(name string) {
+	for _, ext := range []string{".db", ".json"} {
+		if err := os.Remove(filepath.Join(m.dir, name+ext)); err != nil && !os.IsNotExist(err) {
+			m.logger.Warn("Failed to delete backup file", zap.String("file", name+ext), zap.Error(err))
+		}
+	}
+}
+
+// removeRemote deletes the objects of a backup in the bucket
+func (m *Manager) removeRemote(ctx context.Context, name string) {
+	for _, ext := range []string{".db", ".json"} {
+		if err := m.s3.RemoveObject(ctx, m.bucket, m.objectKey(name+ext), minio.RemoveObjectOptions{}); err != nil {
+			m.logger.Warn("Failed to delete backup object", zap.String("file", name+ext), zap.Error(err))
+		}
+	}
+}
+
+// objectKey is where a backup file is kept in the bucket: <prefix>/<file>
+func (m *Manager) objectKey(file string) string {
+	if m.prefix == "" {
+		return file
+	}
+	return m.prefix + "/" + file
+}
+
+// parseName returns when a backup was taken from its name; false for other names
+func parseName(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, namePrefix) {
+		return time.Time{}, false
+	}
+	createdAt, err := time.Parse(timeLayout, strings.TrimPrefix(name, namePrefix))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return createdAt, true
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TenantPlaceholder in KAFKA_TOPIC_ITEMS, KAFKA_TOPIC_STOCK or KAFKA_TOPIC_STORES is
+// replaced by the tenant of each event: inventory.items.{tenant}
+const TenantPlaceholder = "{tenant}"
+
+// SharedTopicTenant replaces TenantPlaceholder for the tenants that are not listed in
+// KAFKA_DEDICATED_TENANTS: their events share inventory.items.shared
+const SharedTopicTenant = "shared"
+
+// How the consumers find the event topics (KAFKA_TOPIC_SUBSCRIPTION)
+const (
+	// TopicSubscriptionList consumes the topics of the shared tenant and of every
+	// dedicated tenant
+	TopicSubscriptionList = "list"
+	// TopicSubscriptionRegex consumes every topic of the cluster a template matches,
+	// refreshed every KAFKA_TOPIC_REFRESH_SECONDS (Kafka only)
+	TopicSubscriptionRegex = "regex"
+)
+
+var topicTenantID = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// EventTopics are the topics (or templates) of the events
+func (c *Config) EventTopics() []string {
+	return []string{c.KafkaTopicItems, c.KafkaTopicStock, c.KafkaTopicStores}
+}
+
+// TenantTopics reports whether an event topic has a TenantPlaceholder
+func (c *Config) TenantTopics() bool {
+	for _, topic := range c.EventTopics() {
+		if strings.Contains(topic, TenantPlaceholder) {
+			return true
+		}
+	}
+	return false
+}
+
+// DedicatedTenants parses KAFKA_DEDICATED_TENANTS; nil when it is empty, and then every
+// tenant has its own topics
+func (c *Config) DedicatedTenants() ([]string, error) {
+	if strings.TrimSpace(c.KafkaDedicatedTenants) == "" {
+		return nil, nil
+	}
+	var tenants []string
+	for _, tenantID := range strings.Split(c.KafkaDedicatedTenants, ",") {
+		tenantID = strings.TrimSpace(tenantID)
+		if !topicTenantID.MatchString(tenantID) || tenantID == SharedTopicTenant {
+			return nil, fmt.Errorf("%q is not a valid tenant", tenantID)
+		}
+		tenants = append(tenants, tenantID)
+	}
+	return tenants, nil
+}
+
+// TopicFor resolves the topic template for the events of tenantID
+func (c *Config) TopicFor(template, tenantID string) string {
+	if !strings.Contains(template, TenantPlaceholder) {
+		return template
+	}
+	dedicated, _ := c.DedicatedTenants()
+	name := tenantID
+	if len(dedicated) > 0 && !contains(dedicated, tenantID) {
+		name = SharedTopicTenant
+	}
+	return strings.ReplaceAll(template, TenantPlaceholder, name)
+}
+
+// ListedTopics returns the topics of templates for the shared tenant and for every
+// dedicated tenant: the topics of a list subscription
+func (c *Config) ListedTopics(templates ...string) []string {
+	dedicated, _ := c.DedicatedTenants()
+	var topics []string
+	for _, template := range templates {
+		if !strings.Contains(template, TenantPlaceholder) {
+			topics = append(topics, template)
+			continue
+		}
+		for _, tenantID := range append([]string{SharedTopicTenant}, dedicated...) {
+			topics = append(topics, strings.ReplaceAll(template, TenantPlaceholder, tenantID))
+		}
+	}
+	return topics
+}
+
+// MatchTopics returns the topics of available that one of templates names for some
+// tenant, sorted: the topics of a regex subscription
+func MatchTopics(available []string, templates ...string) []string {
+	patterns := make([]*regexp.Regexp, len(templates))
+	for i, template := range templates {
+		quoted := strings.ReplaceAll(regexp.QuoteMeta(template), regexp.QuoteMeta(TenantPlaceholder), `[a-z0-9][a-z0-9_-]*`)
+		patterns[i] = regexp.MustCompile("^" + quoted + "$")
+	}
+	var topics []string
+	for _, topic := range available {
+		for _, pattern := range patterns {
+			if pattern.MatchString(topic) {
+				topics = append(topics, topic)
+				break
+			}
+		}
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// validateTopics checks the topic routing settings
+func (c *Config) validateTopics(add func(format string, args ...interface{})) {
+	if strings.Contains(c.KafkaTopicRejections, TenantPlaceholder) {
+		add("KAFKA_TOPIC_REJECTIONS cannot have %s", TenantPlaceholder)
+	}
+	if strings.Contains(c.DLQTopic, TenantPlaceholder) {
+		add("DLQ_TOPIC cannot have %s", TenantPlaceholder)
+	}
+	dedicated, err := c.DedicatedTenants()
+	if err != nil {
+		add("KAFKA_DEDICATED_TENANTS: %v", err)
+	}
+	switch c.KafkaTopicSubscription {
+	case TopicSubscriptionList:
+		if c.TenantTopics() && err == nil && len(dedicated) == 0 {
+			add("KAFKA_TOPIC_SUBSCRIPTION=regex is required when every tenant has its own topics (%s without KAFKA_DEDICATED_TENANTS)", TenantPlaceholder)
+		}
+	case TopicSubscriptionRegex:
+		if c.EventBus != EventBusKafka {
+			add("KAFKA_TOPIC_SUBSCRIPTION=regex requires EVENT_BUS=kafka")
+		}
+		if c.KafkaTopicRefreshSeconds < 1 {
+			add("KAFKA_TOPIC_REFRESH_SECONDS must be at least 1")
+		}
+	default:
+		add("KAFKA_TOPIC_SUBSCRIPTION must be list or regex (got %q)", c.KafkaTopicSubscription)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,455 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Startup check modes (STARTUP_CHECK_MODE): what a failed self-check does at startup
+const (
+	StartupCheckStrict = "strict" // refuse to start
+	StartupCheckWarn   = "warn"   // log it and start anyway
+	StartupCheckOff    = "off"    // skip the self-check
+)
+
+// ValidationError lists every problem found by Validate, so a single run shows them all
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks the values that would otherwise only fail later (on the first event,
+// a retry or a reconnect). It does not touch the network or the disk; see SelfCheck.
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if err := validatePort(c.Port); err != nil {
+		add("PORT: %v", err)
+	}
+
+	switch c.EventBus {
+	case EventBusKafka:
+		if !c.MockDependencies {
+			for _, err := range validateBrokers(c.KafkaBrokers) {
+				add("KAFKA_BROKERS: %v", err)
+			}
+		}
+	case EventBusNATS:
+		if _, err := natsAddresses(c.NATSURL); err != nil {
+			add("NATS_URL: %v", err)
+		}
+		if c.NATSStream == "" {
+			add("NATS_STREAM is required with EVENT_BUS=nats")
+		}
+	case EventBusRabbitMQ:
+		if _, err := rabbitMQAddress(c.RabbitMQURL); err != nil {
+			add("RABBITMQ_URL: %v", err)
+		}
+		if c.RabbitMQExchange == "" {
+			add("RABBITMQ_EXCHANGE is required with EVENT_BUS=rabbitmq")
+		}
+	default:
+		add("EVENT_BUS must be kafka, nats or rabbitmq (got %q)", c.EventBus)
+	}
+	for _, setting := range [][2]string{
+		{"KAFKA_TOPIC_ITEMS", c.KafkaTopicItems},
+		{"KAFKA_TOPIC_STOCK", c.KafkaTopicStock},
+		{"KAFKA_TOPIC_STORES", c.KafkaTopicStores},
+		{"KAFKA_TOPIC_REJECTIONS", c.KafkaTopicRejections},
+		{"KAFKA_GROUP_ID", c.KafkaGroupID},
+	} {
+		if setting[1] == "" {
+			add("%s is required", setting[0])
+		}
+	}
+	c.validateTopics(add)
+	switch c.KafkaSASLMechanism {
+	case "":
+	case "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512":
+		if c.KafkaSASLUsername == "" || c.KafkaSASLPassword == "" {
+			add("KAFKA_SASL_USERNAME and KAFKA_SASL_PASSWORD are required with KAFKA_SASL_MECHANISM=%s", c.KafkaSASLMechanism)
+		}
+	default:
+		add("KAFKA_SASL_MECHANISM must be PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512 (got %q)", c.KafkaSASLMechanism)
+	}
+	if (c.KafkaTLSCertFile == "") != (c.KafkaTLSKeyFile == "") {
+		add("KAFKA_TLS_CERT_FILE and KAFKA_TLS_KEY_FILE must be set together")
+	}
+	if !c.KafkaTLSEnabled && (c.KafkaTLSCAFile != "" || c.KafkaTLSCertFile != "") {
+		add("KAFKA_TLS_CA_FILE and KAFKA_TLS_CERT_FILE need KAFKA_TLS_ENABLED=true")
+	}
+
+	switch c.DBDriver {
+	case "sqlite":
+		if c.SQLitePath == "" {
+			add("SQLITE_PATH is required with DB_DRIVER=sqlite")
+		}
+	case "postgres":
+		if c.PostgresDSN == "" {
+			add("POSTGRES_DSN is required with DB_DRIVER=postgres")
+		}
+		if c.PostgresMaxConns < 1 {
+			add("POSTGRES_MAX_CONNS must be at least 1")
+		}
+	default:
+		add("DB_DRIVER must be sqlite or postgres (got %q)", c.DBDriver)
+	}
+
+	if c.MaxRetries < 0 {
+		add("MAX_RETRIES must not be negative")
+	}
+	if c.RetryDelayMs < 0 {
+		add("RETRY_DELAY_MS must not be negative")
+	}
+	if c.RetryMaxDelayMs < c.RetryDelayMs {
+		add("RETRY_MAX_DELAY_MS must not be less than RETRY_DELAY_MS")
+	}
+	if c.RetryJitterPercent < 0 || c.RetryJitterPercent > 100 {
+		add("RETRY_JITTER_PERCENT must be between 0 and 100 (got %d)", c.RetryJitterPercent)
+	}
+	if c.MaxProcessingMs < 0 {
+		add("MAX_PROCESSING_MS must not be negative")
+	}
+	if _, err := c.RetryTopicDelays(); err != nil {
+		add("RETRY_TOPICS: %v", err)
+	} else if c.RetryTopics != "" && c.EventBus != EventBusKafka {
+		add("RETRY_TOPICS requires EVENT_BUS=kafka")
+	}
+	if c.DeadLetterQueue && c.DLQTopic == "" {
+		add("DLQ_TOPIC is required with DEAD_LETTER_QUEUE=true")
+	}
+	if c.BatchSize < 1 {
+		add("BATCH_SIZE must be at least 1")
+	}
+	if c.BatchWindowMs < 0 {
+		add("BATCH_WINDOW_MS must not be negative")
+	}
+	if c.ConsumerWorkers < 1 || c.ConsumerWorkerQueue < 1 {
+		add("CONSUMER_WORKERS and CONSUMER_WORKER_QUEUE must be at least 1")
+	}
+	if c.ConsumerWorkers > 1 && c.BatchSize > 1 {
+		add("CONSUMER_WORKERS > 1 cannot be combined with BATCH_SIZE > 1")
+	}
+	if c.EventDedupRetentionHours < 0 {
+		add("EVENT_DEDUP_RETENTION_HOURS must not be negative")
+	}
+	if c.MaxEventFutureSkewSeconds < 0 {
+		add("MAX_EVENT_FUTURE_SKEW_SECONDS must not be negative")
+	}
+	if c.usesObjectStorage() {
+		if _, port, err := net.SplitHostPort(c.ArchiveEndpoint); err != nil || validatePort(port) != nil {
+			add("ARCHIVE_ENDPOINT: %q is not host:port", c.ArchiveEndpoint)
+		}
+		if c.ArchiveAccessKey == "" || c.ArchiveSecretKey == "" {
+			add("ARCHIVE_ACCESS_KEY and ARCHIVE_SECRET_KEY are required with ARCHIVE_ENABLED=true or BACKUP_S3_BUCKET")
+		}
+	}
+	if c.ArchiveEnabled {
+		if c.ArchiveBucket == "" {
+			add("ARCHIVE_BUCKET is required with ARCHIVE_ENABLED=true")
+		}
+		if c.ArchiveBatchSize < 1 {
+			add("ARCHIVE_BATCH_SIZE must be at least 1")
+		}
+		if c.ArchiveFlushIntervalSeconds < 1 {
+			add("ARCHIVE_FLUSH_INTERVAL_SECONDS must be at least 1")
+		}
+		if c.ArchiveRetentionDays < 0 {
+			add("ARCHIVE_RETENTION_DAYS must not be negative")
+		}
+	}
+	if c.BackupEnabled {
+		if c.DBDriver != "sqlite" {
+			add("BACKUP_ENABLED needs DB_DRIVER=sqlite (back up PostgreSQL with its own tools)")
+		}
+		if c.BackupIntervalMinutes < 1 {
+			add("BACKUP_INTERVAL_MINUTES must be at least 1")
+		}
+		if c.BackupDir == "" {
+			add("BACKUP_DIR is required with BACKUP_ENABLED=true")
+		}
+		if c.BackupKeep < 1 {
+			add("BACKUP_KEEP must be at least 1")
+		}
+	}
+	if c.ReplicationRole != "primary" && c.ReplicationRole != "secondary" {
+		add("REPLICATION_ROLE must be primary or secondary (got %q)", c.ReplicationRole)
+	}
+	if c.Region == "" {
+		add("REGION is required")
+	}
+
+	if c.HealthCheckTimeoutMs <= 0 {
+		add("HEALTH_CHECK_TIMEOUT_MS must be positive")
+	}
+	if c.HealthFailureThreshold < 1 {
+		add("HEALTH_FAILURE_THRESHOLD must be at least 1")
+	}
+	switch c.StartupCheckMode {
+	case StartupCheckStrict, StartupCheckWarn, StartupCheckOff:
+	default:
+		add("STARTUP_CHECK_MODE must be strict, warn or off (got %q)", c.StartupCheckMode)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// usesObjectStorage reports whether the S3-compatible storage of ARCHIVE_ENDPOINT is
+// used, by the event archive or by the backups
+func (c *Config) usesObjectStorage() bool {
+	return c.ArchiveEnabled || (c.BackupEnabled && c.BackupS3Bucket != "")
+}
+
+// SelfCheck probes what the configuration points at: that a Kafka broker (or the NATS
+// or RabbitMQ server of EVENT_BUS) and the archive and backup storage accept connections, that the
+// Kafka TLS files can be read and that the SQLite database can be written (only read in
+// dry-run). Mock mode has none of them.
+func (c *Config) SelfCheck(ctx context.Context, timeout time.Duration) []CheckResult {
+	if c.MockDependencies {
+		return nil
+	}
+	results := []CheckResult{c.probeEventBus(ctx, timeout)}
+	if c.usesObjectStorage() {
+		results = append(results, CheckResult{Name: "object storage", Err: probeBrokers(ctx, []string{c.ArchiveEndpoint}, timeout)})
+	}
+	if c.EventBus == EventBusKafka && c.KafkaTLSEnabled {
+		for _, file := range []string{c.KafkaTLSCAFile, c.KafkaTLSCertFile, c.KafkaTLSKeyFile} {
+			if file != "" {
+				_, err := os.ReadFile(file)
+				results = append(results, CheckResult{Name: "kafka tls file " + file, Err: err})
+			}
+		}
+	}
+	if c.DBDriver == "sqlite" {
+		probe := probeWritable
+		if c.DryRun {
+			probe = probeReadable
+		}
+		results = append(results, CheckResult{Name: "database " + c.SQLitePath, Err: probe(c.SQLitePath)})
+	}
+	return results
+}
+
+// CheckResult is the outcome of one self-check probe
+type CheckResult struct {
+	Name string
+	Err  error
+}
+
+// RunStartupChecks runs SelfCheck as set by STARTUP_CHECK_MODE, logging each failed
+// probe. In strict mode it returns an error if any failed.
+func (c *Config) RunStartupChecks(ctx context.Context, logger *zap.Logger) error {
+	if c.StartupCheckMode == StartupCheckOff {
+		return nil
+	}
+	var failed []string
+	for _, result := range c.SelfCheck(ctx, time.Duration(c.HealthCheckTimeoutMs)*time.Millisecond) {
+		if result.Err == nil {
+			continue
+		}
+		failed = append(failed, result.Name)
+		if c.StartupCheckMode == StartupCheckStrict {
+			logger.Error("Startup check failed", zap.String("check", result.Name), zap.Error(result.Err))
+		} else {
+			logger.Warn("Startup check failed, starting anyway (STARTUP_CHECK_MODE=warn)", zap.String("check", result.Name), zap.Error(result.Err))
+		}
+	}
+	if len(failed) > 0 && c.StartupCheckMode == StartupCheckStrict {
+		return fmt.Errorf("startup checks failed: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// Check runs Validate and SelfCheck for --check-config, writing one line per check to
+// w. It returns false if anything failed.
+func (c *Config) Check(ctx context.Context, w io.Writer, timeout time.Duration) bool {
+	if err := c.Validate(); err != nil {
+		fmt.Fprintln(w, "FAIL configuration")
+		for _, problem := range err.(*ValidationError).Problems {
+			fmt.Fprintln(w, "     - "+problem)
+		}
+		return false
+	}
+	fmt.Fprintln(w, "OK   configuration")
+
+	ok := true
+	for _, result := range c.SelfCheck(ctx, timeout) {
+		if result.Err != nil {
+			fmt.Fprintf(w, "FAIL %s: %v\n", result.Name, result.Err)
+			ok = false
+			continue
+		}
+		fmt.Fprintf(w, "OK   %s\n", result.Name)
+	}
+	return ok
+}
+
+func validatePort(port string) error {
+	value, err := strconv.Atoi(port)
+	if err != nil || value < 1 || value > 65535 {
+		return fmt.Errorf("%q is not a port number", port)
+	}
+	return nil
+}
+
+func validateBrokers(brokers []string) []error {
+	var errs []error
+	count := 0
+	for _, broker := range brokers {
+		if broker == "" {
+			continue
+		}
+		count++
+		_, port, err := net.SplitHostPort(broker)
+		if err == nil {
+			err = validatePort(port)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%q is not host:port", broker))
+		}
+	}
+	if count == 0 {
+		errs = append(errs, fmt.Errorf("at least one broker is required"))
+	}
+	return errs
+}
+
+// probeBrokers succeeds if any broker accepts a TCP connection: the client only needs
+// one to bootstrap
+func probeBrokers(ctx context.Context, brokers []string, timeout time.Duration) error {
+	dialer := net.Dialer{Timeout: timeout}
+	var failures []string
+	for _, broker := range brokers {
+		conn, err := dialer.DialContext(ctx, "tcp", broker)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		failures = append(failures, err.Error())
+	}
+	return fmt.Errorf("no broker reachable: %s", strings.Join(failures, "; "))
+}
+
+// probeEventBus checks that a server of the event bus accepts a TCP connection
+func (c *Config) probeEventBus(ctx context.Context, timeout time.Duration) CheckResult {
+	switch c.EventBus {
+	case EventBusNATS:
+		addresses, err := natsAddresses(c.NATSURL)
+		if err == nil {
+			err = probeBrokers(ctx, addresses, timeout)
+		}
+		return CheckResult{Name: "nats server", Err: err}
+	case EventBusRabbitMQ:
+		address, err := rabbitMQAddress(c.RabbitMQURL)
+		if err == nil {
+			err = probeBrokers(ctx, []string{address}, timeout)
+		}
+		return CheckResult{Name: "rabbitmq server", Err: err}
+	}
+	return CheckResult{Name: "kafka brokers", Err: probeBrokers(ctx, c.KafkaBrokers, timeout)}
+}
+
+// natsAddresses returns the host:port of every server of a NATS_URL
+// ("nats://host:4222,nats://other:4222"; the port defaults to 4222)
+func natsAddresses(natsURL string) ([]string, error) {
+	var addresses []string
+	for _, server := range strings.Split(natsURL, ",") {
+		server = strings.TrimSpace(server)
+		if server == "" {
+			continue
+		}
+		if !strings.Contains(server, "://") {
+			server = "nats://" + server
+		}
+		parsed, err := url.Parse(server)
+		if err != nil || parsed.Hostname() == "" {
+			return nil, fmt.Errorf("not a NATS server URL") // the URL may hold credentials
+		}
+		port := parsed.Port()
+		if port == "" {
+			port = "4222"
+		}
+		addresses = append(addresses, net.JoinHostPort(parsed.Hostname(), port))
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("at least one server is required")
+	}
+	return addresses, nil
+}
+
+// rabbitMQAddress returns the host:port of an amqp:// or amqps:// RABBITMQ_URL
+func rabbitMQAddress(rabbitURL string) (string, error) {
+	parsed, err := url.Parse(rabbitURL)
+	if err != nil || parsed.Hostname() == "" {
+		return "", fmt.Errorf("not an AMQP URL") // the URL may hold credentials
+	}
+	port := parsed.Port()
+	switch {
+	case parsed.Scheme == "amqp" && port == "":
+		port = "5672"
+	case parsed.Scheme == "amqps" && port == "":
+		port = "5671"
+	case parsed.Scheme != "amqp" && parsed.Scheme != "amqps":
+		return "", fmt.Errorf("not an amqp:// or amqps:// URL")
+	}
+	return net.JoinHostPort(parsed.Hostname(), port), nil
+}
+
+// probeWritable checks that path (a SQLite file, or a DSN like "file:x?mode=memory")
+// can be written: an existing file is opened for writing, otherwise a temporary file is
+// created in its directory, where SQLite also keeps its journal
+func probeWritable(path string) error {
+	if strings.HasPrefix(path, "file:") && strings.Contains(path, "mode=memory") {
+		return nil
+	}
+	path = strings.TrimPrefix(path, "file:")
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory", path)
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return fmt.Errorf("not writable: %w", err)
+		}
+		return f.Close()
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".check-config-*")
+	if err != nil {
+		return fmt.Errorf("directory not writable: %w", err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// probeReadable checks that the database exists and can be read, as dry-run needs
+func probeReadable(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("not readable: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,29 @@
+package database
+
+import "context"
+
+// attributionKey is the context key for the user and request behind an event
+type attributionKey struct{}
+
+type attribution struct {
+	actor     string
+	requestID string
+}
+
+// WithAttribution returns a context carrying the user and request that caused the
+// event being processed (taken from the Kafka headers), so rows written while
+// processing it can be attributed
+func WithAttribution(ctx context.Context, actor, requestID string) context.Context {
+	if actor == "" && requestID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, attributionKey{}, attribution{actor: actor, requestID: requestID})
+}
+
+// AttributionFromContext returns the actor and request ID set by WithAttribution
+func AttributionFromContext(ctx context.Context) (actor, requestID string) {
+	if a, ok := ctx.Value(attributionKey{}).(attribution); ok {
+		return a.actor, a.requestID
+	}
+	return "", ""
+}
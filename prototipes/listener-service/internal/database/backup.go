@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Backup writes a consistent copy of the read model to path with VACUUM INTO, without
+// blocking readers. Only SQLite read models are backed up.
+func (swdb *SingleWriterDB) Backup(ctx context.Context, path string) error {
+	if swdb.dialect != DriverSQLite {
+		return fmt.Errorf("backups need DB_DRIVER=sqlite (got %s)", swdb.dialect)
+	}
+	if _, err := swdb.db.ExecContext(ctx, `VACUUM INTO ?`, path); err != nil {
+		return fmt.Errorf("failed to back up database to %s: %w", path, err)
+	}
+	return nil
+}
+
+// Restore replaces the whole read model with the backup at path through the SQLite
+// backup API, then migrates it to the current schema. Writes wait until it is done.
+func (swdb *SingleWriterDB) Restore(ctx context.Context, path string) error {
+	if swdb.dialect != DriverSQLite {
+		return fmt.Errorf("restoring a backup needs DB_DRIVER=sqlite (got %s)", swdb.dialect)
+	}
+	defer swdb.lockWriter(ctx, "restore")()
+
+	source, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("failed to open backup %s: %w", path, err)
+	}
+	defer source.Close()
+	if err := checkBackup(ctx, source); err != nil {
+		return fmt.Errorf("backup %s cannot be restored: %w", path, err)
+	}
+
+	if err := copyDatabase(ctx, swdb.db.DB, source); err != nil {
+		return fmt.Errorf("failed to restore backup %s: %w", path, err)
+	}
+	// A backup taken by an older release lacks the later columns and tables
+	if err := swdb.initSchema(); err != nil {
+		return fmt.Errorf("failed to migrate restored backup: %w", err)
+	}
+	swdb.logger.Warn("♻️ Read model restored from backup")
+	return nil
+}
+
+// checkBackup fails unless the backup is intact and has a schema this release can read
+func checkBackup(ctx context.Context, backup *sql.DB) error {
+	var result string
+	if err := backup.QueryRowContext(ctx, `PRAGMA integrity_check`).Scan(&result); err != nil {
+		return fmt.Errorf("integrity check failed: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+
+	var version sql.NullInt64
+	if err := backup.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return fmt.Errorf("not a read model backup: %w", err)
+	}
+	if version.Int64 > SchemaVersion {
+		return fmt.Errorf("schema version %d is newer than this release (%d)", version.Int64, SchemaVersion)
+	}
+	return nil
+}
+
+// copyDatabase overwrites every page of the main database of dst with those of src
+func copyDatabase(ctx context.Context, dst, src *sql.DB) error {
+	dstConn, err := dst.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer dstConn.Close()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return dstConn.Raw(func(dstDriver interface{}) error {
+		return srcConn.Raw(func(srcDriver interface{}) error {
+			backup, err := dstDriver.(*sqlite3.SQLiteConn).Backup("main", srcDriver.(*sqlite3.SQLiteConn), "main")
+			if err != nil {
+				return err
+			}
+			if _, err := backup.Step(-1); err != nil {
+				backup.Finish()
+				return err
+			}
+			return backup.Finish()
+		})
+	})
+}
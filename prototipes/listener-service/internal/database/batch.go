@@ -0,0 +1,184 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"listener-service/pkg/metrics"
+)
+
+// batchKey is the context key of the batch transaction started by Batch
+type batchKey struct{}
+
+// batch is a write transaction shared by every write made with its context
+type batch struct {
+	tx          *sqlTx
+	savepoints  int
+	afterCommit []func()
+}
+
+func batchFrom(ctx context.Context) *batch {
+	b, _ := ctx.Value(batchKey{}).(*batch)
+	return b
+}
+
+// executor runs statements on the database or on the transaction of a batch
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn returns where the statements of ctx run: the batch transaction if there is one.
+// Reads go there too, SQLite's single connection is held by the batch.
+func (swdb *SingleWriterDB) conn(ctx context.Context) executor {
+	if b := batchFrom(ctx); b != nil {
+		return b.tx
+	}
+	return swdb.db
+}
+
+// Batch runs fn in a single write transaction: every write made with the context fn
+// receives joins it, and is committed (or rolled back, if fn fails) at once. The
+// writer lock is held for the whole batch, so one commit and one lock acquisition
+// cover many events.
+func (swdb *SingleWriterDB) Batch(ctx context.Context, fn func(ctx context.Context) error) error {
+	if batchFrom(ctx) != nil {
+		return fn(ctx)
+	}
+
+	hooks, err := swdb.runBatch(ctx, fn)
+	if err != nil {
+		return err
+	}
+	// Run once the lock is released: hooks publish, they do not write
+	for _, hook := range hooks {
+		hook()
+	}
+	return nil
+}
+
+func (swdb *SingleWriterDB) runBatch(ctx context.Context, fn func(ctx context.Context) error) ([]func(), error) {
+	defer swdb.lockWriter(ctx, "batch")()
+
+	tx, err := swdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin batch: %w", err)
+	}
+	defer tx.Rollback()
+
+	b := &batch{tx: tx}
+	if err := fn(context.WithValue(ctx, batchKey{}, b)); err != nil {
+		return nil, err
+	}
+	if err := commitTx(&writeTx{sqlTx: tx}, "batch"); err != nil {
+		return nil, fmt.Errorf("failed to commit batch: %w", err)
+	}
+	return b.afterCommit, nil
+}
+
+// Savepoint runs fn so that, inside a batch, its writes are undone if it fails while
+// the rest of the batch goes on. Outside a batch it just runs fn.
+func (swdb *SingleWriterDB) Savepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	b := batchFrom(ctx)
+	if b == nil {
+		return fn(ctx)
+	}
+
+	tx, err := swdb.begin(ctx)
+	if err != nil {
+		return err
+	}
+	hooks := len(b.afterCommit)
+	err = fn(ctx)
+	if err == nil {
+		// On PostgreSQL a statement that failed in fn, even if fn went on, leaves the
+		// transaction aborted and the release fails: the savepoint is rolled back then
+		err = tx.Commit()
+	}
+	if err != nil {
+		tx.Rollback()
+		// Confirmations of undone writes must not go out
+		b.afterCommit = b.afterCommit[:hooks]
+		return err
+	}
+	return nil
+}
+
+// AfterCommit runs fn once the writes of ctx are committed: at the end of its batch,
+// or right away outside a batch. Hooks of a failed batch or savepoint never run.
+func AfterCommit(ctx context.Context, fn func()) {
+	if b := batchFrom(ctx); b != nil {
+		b.afterCommit = append(b.afterCommit, fn)
+		return
+	}
+	fn()
+}
+
+// writeTx is a write transaction, or a savepoint when it is opened inside a batch
+type writeTx struct {
+	*sqlTx
+	savepoint string
+	done      bool
+}
+
+// begin opens a write transaction, nested as a savepoint in the batch of ctx if any
+func (swdb *SingleWriterDB) begin(ctx context.Context) (*writeTx, error) {
+	b := batchFrom(ctx)
+	if b == nil {
+		tx, err := swdb.db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &writeTx{sqlTx: tx}, nil
+	}
+
+	b.savepoints++
+	name := fmt.Sprintf("sp_%d", b.savepoints)
+	if _, err := b.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return nil, fmt.Errorf("failed to open savepoint: %w", err)
+	}
+	return &writeTx{sqlTx: b.tx, savepoint: name}, nil
+}
+
+// Commit commits the transaction or releases the savepoint into its batch
+func (tx *writeTx) Commit() error {
+	if tx.savepoint == "" {
+		return tx.sqlTx.Commit()
+	}
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	if _, err := tx.sqlTx.ExecContext(context.Background(), "RELEASE SAVEPOINT "+tx.savepoint); err != nil {
+		return err // still open: Rollback undoes it
+	}
+	tx.done = true
+	return nil
+}
+
+// Rollback undoes the transaction or the savepoint. After Commit it does nothing, so it
+// can be deferred: on PostgreSQL a failed statement would abort the whole batch.
+func (tx *writeTx) Rollback() error {
+	if tx.savepoint == "" {
+		return tx.sqlTx.Rollback()
+	}
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	tx.done = true
+	if _, err := tx.sqlTx.ExecContext(context.Background(), "ROLLBACK TO SAVEPOINT "+tx.savepoint); err != nil {
+		return err
+	}
+	_, err := tx.sqlTx.ExecContext(context.Background(), "RELEASE SAVEPOINT "+tx.savepoint)
+	return err
+}
+
+// commitTx commits a write transaction and records the commit duration
+func commitTx(tx *writeTx, operation string) error {
+	start := time.Now()
+	err := tx.Commit()
+	metrics.SQLiteCommitDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	return err
+}
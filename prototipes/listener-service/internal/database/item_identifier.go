@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ItemIdentifier is a barcode or alias an item is looked up by. Code is the GTIN-14 of
+// a barcode or the alias, as computed by the Command Service.
+type ItemIdentifier struct {
+	ItemID    string
+	Type      string
+	Value     string
+	Code      string
+	CreatedAt time.Time
+}
+
+// AddItemIdentifier adds an identifier to an item as a versioned change of the item, in
+// a single transaction with optimistic locking. The identifier takes the tenant of the
+// item. A code held by another item of the tenant moves to this one: the Command
+// Service only assigns it once it was freed, and the removal may arrive later, in the
+// partition of the other item.
+func (swdb *SingleWriterDB) AddItemIdentifier(ctx context.Context, identifier *ItemIdentifier, expectedVersion int) error {
+	defer swdb.lockWriter(ctx, "add_item_identifier")()
+
+	tx, err := swdb.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := bumpItemVersion(ctx, tx, identifier.ItemID, expectedVersion); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO item_identifiers (tenant_id, code, item_id, type, value, created_at)
+		SELECT tenant_id, ?, id, ?, ?, ? FROM inventory_items WHERE id = ?
+		ON CONFLICT (tenant_id, code) DO UPDATE SET
+			item_id = excluded.item_id,
+			type = excluded.type,
+			value = excluded.value,
+			created_at = excluded.created_at
+	`, identifier.Code, identifier.Type, identifier.Value,
+		identifier.CreatedAt.UTC().Format(time.RFC3339), identifier.ItemID,
+	); err != nil {
+		return fmt.Errorf("failed to save item identifier: %w", err)
+	}
+
+	if err := commitTx(tx, "add_item_identifier"); err != nil {
+		return fmt.Errorf("failed to commit item identifier: %w", err)
+	}
+	return nil
+}
+
+// RemoveItemIdentifier removes an identifier of an item as a versioned change of the
+// item. Removing an identifier the item does not hold (any more) only moves the version.
+func (swdb *SingleWriterDB) RemoveItemIdentifier(ctx context.Context, itemID, code string, expectedVersion int) error {
+	defer swdb.lockWriter(ctx, "remove_item_identifier")()
+
+	tx, err := swdb.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := bumpItemVersion(ctx, tx, itemID, expectedVersion); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM item_identifiers WHERE item_id = ? AND code = ?`, itemID, code); err != nil {
+		return fmt.Errorf("failed to delete item identifier: %w", err)
+	}
+
+	if err := commitTx(tx, "remove_item_identifier"); err != nil {
+		return fmt.Errorf("failed to commit item identifier removal: %w", err)
+	}
+	return nil
+}
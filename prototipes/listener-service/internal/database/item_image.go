@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ItemImage is an image of an item. The file lives in the image store of the Command
+// Service; the read model keeps where to find it.
+type ItemImage struct {
+	ID          string
+	ItemID      string
+	StorageKey  string // Key of the file in the image store
+	URL         string // Public URL of the file, as published by the Command Service
+	ContentType string
+	Size        int64
+	CreatedAt   time.Time
+}
+
+// AddItemImage adds an image to an item as a versioned change of the item, in a single
+// transaction with optimistic locking. Adding an image that is already there only
+// moves the version.
+func (swdb *SingleWriterDB) AddItemImage(ctx context.Context, image *ItemImage, expectedVersion int) error {
+	defer swdb.lockWriter(ctx, "add_item_image")()
+
+	tx, err := swdb.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := bumpItemVersion(ctx, tx, image.ItemID, expectedVersion); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO item_images (id, item_id, storage_key, url, content_type, size, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING
+	`, image.ID, image.ItemID, image.StorageKey, image.URL, image.ContentType, image.Size,
+		image.CreatedAt.UTC().Format(time.RFC3339),
+	); err != nil {
+		return fmt.Errorf("failed to save item image: %w", err)
+	}
+
+	if err := commitTx(tx, "add_item_image"); err != nil {
+		return fmt.Errorf("failed to commit item image: %w", err)
+	}
+	return nil
+}
+
+// RemoveItemImage removes an image of an item as a versioned change of the item.
+// Removing an image that is not there only moves the version.
+func (swdb *SingleWriterDB) RemoveItemImage(ctx context.Context, itemID, imageID string, expectedVersion int) error {
+	defer swdb.lockWriter(ctx, "remove_item_image")()
+
+	tx, err := swdb.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := bumpItemVersion(ctx, tx, itemID, expectedVersion); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM item_images WHERE id = ? AND item_id = ?`, imageID, itemID); err != nil {
+		return fmt.Errorf("failed to delete item image: %w", err)
+	}
+
+	if err := commitTx(tx, "remove_item_image"); err != nil {
+		return fmt.Errorf("failed to commit item image removal: %w", err)
+	}
+	return nil
+}
+
+// bumpItemVersion moves the version of an item changed by a write to one of its
+// child tables
+func bumpItemVersion(ctx context.Context, tx *writeTx, itemID string, expectedVersion int) error {
+	result, err := tx.ExecContext(ctx, `
+		UPDATE inventory_items
+		SET version = version + 1,
+		    updated_at = ?
+		WHERE id = ? AND version = ?
+	`, time.Now().UTC().Format(time.RFC3339), itemID, expectedVersion)
+	if err != nil {
+		return fmt.Errorf("failed to update item version: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrOptimisticLockFailed
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Item relation types
+const (
+	RelationSubstitute = "substitute" // the related item can be sold instead of the item
+	RelationAccessory  = "accessory"  // the related item is sold with the item
+)
+
+// SaveItemRelation links relatedItemID to itemID. Both items must exist; linking them
+// again is not an error.
+func (swdb *SingleWriterDB) SaveItemRelation(ctx context.Context, itemID, relatedItemID, relationType string) error {
+	defer swdb.lockWriter(ctx, "save_item_relation")()
+
+	for _, id := range []string{itemID, relatedItemID} {
+		var exists int
+		err := swdb.conn(ctx).QueryRowContext(ctx, `SELECT 1 FROM inventory_items WHERE id = ?`, id).Scan(&exists)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrItemNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get item: %w", err)
+		}
+	}
+
+	_, err := swdb.conn(ctx).ExecContext(ctx, `
+		INSERT INTO item_relations (item_id, related_item_id, relation_type, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (item_id, related_item_id, relation_type) DO NOTHING
+	`, itemID, relatedItemID, relationType, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("failed to save item relation: %w", err)
+	}
+	return nil
+}
+
+// DeleteItemRelation removes a link. Removing a link that does not exist is not an
+// error; links of a deleted item go away with it.
+func (swdb *SingleWriterDB) DeleteItemRelation(ctx context.Context, itemID, relatedItemID, relationType string) error {
+	defer swdb.lockWriter(ctx, "delete_item_relation")()
+
+	_, err := swdb.conn(ctx).ExecContext(ctx,
+		`DELETE FROM item_relations WHERE item_id = ? AND related_item_id = ? AND relation_type = ?`,
+		itemID, relatedItemID, relationType,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete item relation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,397 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"listener-service/internal/config"
+
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// NewPostgresDB opens the read model on PostgreSQL (DB_DRIVER=postgres) and creates or
+// migrates its schema. Unlike SQLite, several listeners can write to it at once.
+func NewPostgresDB(cfg *config.Config, logger *zap.Logger) (*SingleWriterDB, error) {
+	db, err := openPostgres(cfg.PostgresDSN, cfg.PostgresMaxConns)
+	if err != nil {
+		return nil, err
+	}
+
+	swdb := &SingleWriterDB{
+		db:      &sqlDB{DB: db, dialect: DriverPostgres},
+		dialect: DriverPostgres,
+		logger:  logger,
+	}
+	if err := swdb.initSchema(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize schema: %w", err)
+	}
+	return swdb, nil
+}
+
+// NewReadOnlyPostgresDB opens an existing PostgreSQL read model in read-only
+// transactions, the PostgreSQL counterpart of NewReadOnlyDB for dry-run mode
+func NewReadOnlyPostgresDB(cfg *config.Config, logger *zap.Logger) (*SingleWriterDB, error) {
+	dsn, err := withPostgresParam(cfg.PostgresDSN, "default_transaction_read_only", "on")
+	if err != nil {
+		return nil, err
+	}
+	db, err := openPostgres(dsn, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &SingleWriterDB{
+		db:      &sqlDB{DB: db, dialect: DriverPostgres},
+		dialect: DriverPostgres,
+		logger:  logger,
+	}, nil
+}
+
+func openPostgres(dsn string, maxConns int) (*sql.DB, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("POSTGRES_DSN is required with DB_DRIVER=postgres")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if maxConns < 1 {
+		maxConns = 1
+	}
+	db.SetMaxOpenConns(maxConns)
+	db.SetMaxIdleConns(maxConns)
+	db.SetConnMaxLifetime(time.Hour)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return db, nil
+}
+
+// withPostgresParam adds a run-time parameter to a URL ("postgres://...") or
+// key/value ("host=... dbname=...") DSN; lib/pq sends unknown keys to the server
+func withPostgresParam(dsn, key, value string) (string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		parsed, err := url.Parse(dsn)
+		if err != nil {
+			return "", fmt.Errorf("invalid POSTGRES_DSN: %w", err)
+		}
+		query := parsed.Query()
+		query.Set(key, value)
+		parsed.RawQuery = query.Encode()
+		return parsed.String(), nil
+	}
+	return strings.TrimSpace(dsn + " " + key + "=" + value), nil
+}
+
+// postgresIndexes run after the migrations, which add some of the indexed columns.
+// SKUs and store codes are unique per tenant.
+const postgresIndexes = `
+	CREATE INDEX IF NOT EXISTS idx_inventory_items_version ON inventory_items(version);
+	CREATE INDEX IF NOT EXISTS idx_inventory_items_created ON inventory_items(created_at DESC, id);
+	CREATE INDEX IF NOT EXISTS idx_inventory_items_tenant_created ON inventory_items(tenant_id, created_at DESC, id);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_inventory_items_tenant_sku ON inventory_items(tenant_id, sku);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_stores_tenant_code ON stores(tenant_id, code);
+	CREATE INDEX IF NOT EXISTS idx_stores_active ON stores(active);
+	CREATE INDEX IF NOT EXISTS idx_store_reservations_store_id ON store_reservations(store_id);
+	CREATE INDEX IF NOT EXISTS idx_store_reservations_item_id ON store_reservations(item_id);
+	CREATE INDEX IF NOT EXISTS idx_store_reservations_status ON store_reservations(status);
+	CREATE INDEX IF NOT EXISTS idx_store_reservations_store_item ON store_reservations(store_id, item_id);
+	CREATE INDEX IF NOT EXISTS idx_item_relations_related ON item_relations(related_item_id);
+	CREATE INDEX IF NOT EXISTS idx_item_images_item ON item_images(item_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_item_identifiers_item ON item_identifiers(item_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_suppliers_tenant_code ON suppliers(tenant_id, code);
+	CREATE INDEX IF NOT EXISTS idx_purchase_orders_tenant_created ON purchase_orders(tenant_id, created_at DESC, id);
+	CREATE INDEX IF NOT EXISTS idx_purchase_orders_supplier ON purchase_orders(supplier_id, status);
+	CREATE INDEX IF NOT EXISTS idx_stock_locations_location ON stock_locations(location);
+	CREATE INDEX IF NOT EXISTS idx_cost_layers_item_received ON cost_layers(item_id, received_at);
+	CREATE INDEX IF NOT EXISTS idx_stock_movements_item_occurred ON stock_movements(item_id, occurred_at);
+	CREATE INDEX IF NOT EXISTS idx_stock_movements_actor ON stock_movements(actor, occurred_at);
+	CREATE INDEX IF NOT EXISTS idx_reservation_waitlist_item_status ON reservation_waitlist(item_id, status, requested_at);
+	CREATE INDEX IF NOT EXISTS idx_activity_log_processed ON activity_log(processed_at);
+	CREATE INDEX IF NOT EXISTS idx_activity_log_actor ON activity_log(actor, processed_at);
+	CREATE INDEX IF NOT EXISTS idx_activity_log_item ON activity_log(item_id, processed_at);
+	CREATE INDEX IF NOT EXISTS idx_activity_log_tenant ON activity_log(tenant_id, processed_at);
+	CREATE INDEX IF NOT EXISTS idx_processed_events_processed ON processed_events(processed_at);
+`
+
+// initPostgresSchema creates the same tables as the SQLite schema. Timestamps are
+// TIMESTAMPTZ instead of RFC3339 text: the listener still reads and writes them as
+// RFC3339 strings, and the Query Service scans them as times.
+func (swdb *SingleWriterDB) initPostgresSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS stores (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL DEFAULT 'default',
+		name TEXT NOT NULL,
+		location TEXT,
+		code TEXT NOT NULL,
+		active INTEGER NOT NULL DEFAULT 1,
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL,
+		CHECK(active IN (0, 1))
+	);
+
+	CREATE TABLE IF NOT EXISTS store_calendars (
+		store_id TEXT PRIMARY KEY REFERENCES stores(id) ON DELETE CASCADE,
+		timezone TEXT NOT NULL,
+		hours TEXT NOT NULL DEFAULT '[]',
+		holidays TEXT NOT NULL DEFAULT '[]',
+		updated_at TIMESTAMPTZ NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS inventory_items (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL DEFAULT 'default',
+		sku TEXT NOT NULL,
+		name TEXT NOT NULL,
+		description TEXT,
+		quantity INTEGER NOT NULL DEFAULT 0,
+		reserved INTEGER NOT NULL DEFAULT 0,
+		available INTEGER NOT NULL DEFAULT 0,
+		version INTEGER NOT NULL DEFAULT 1,
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL,
+		deleted_at TIMESTAMPTZ,
+		CHECK(quantity >= 0),
+		CHECK(reserved >= 0),
+		CHECK(available >= 0),
+		CHECK(reserved <= quantity),
+		CHECK(available = quantity - reserved)
+	);
+
+	CREATE TABLE IF NOT EXISTS store_reservations (
+		id TEXT PRIMARY KEY,
+		store_id TEXT NOT NULL REFERENCES stores(id) ON DELETE CASCADE,
+		item_id TEXT NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+		quantity INTEGER NOT NULL DEFAULT 0,
+		status TEXT NOT NULL DEFAULT 'active',
+		reserved_at TIMESTAMPTZ NOT NULL,
+		released_at TIMESTAMPTZ,
+		expires_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL,
+		CHECK(quantity > 0),
+		CHECK(status IN ('active', 'released', 'expired', 'fulfilled'))
+	);
+
+	CREATE TABLE IF NOT EXISTS item_relations (
+		item_id TEXT NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+		related_item_id TEXT NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+		relation_type TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (item_id, related_item_id, relation_type),
+		CHECK(item_id <> related_item_id),
+		CHECK(relation_type IN ('substitute', 'accessory'))
+	);
+
+	CREATE TABLE IF NOT EXISTS item_images (
+		id TEXT PRIMARY KEY,
+		item_id TEXT NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+		storage_key TEXT NOT NULL,
+		url TEXT NOT NULL,
+		content_type TEXT NOT NULL,
+		size BIGINT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL,
+		CHECK(size > 0)
+	);
+
+	CREATE TABLE IF NOT EXISTS item_identifiers (
+		tenant_id TEXT NOT NULL DEFAULT 'default',
+		code TEXT NOT NULL,
+		item_id TEXT NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+		type TEXT NOT NULL,
+		value TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (tenant_id, code)
+	);
+
+	CREATE TABLE IF NOT EXISTS suppliers (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL DEFAULT 'default',
+		code TEXT NOT NULL,
+		name TEXT NOT NULL,
+		email TEXT,
+		phone TEXT,
+		active INTEGER NOT NULL DEFAULT 1,
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL,
+		CHECK(active IN (0, 1))
+	);
+
+	CREATE TABLE IF NOT EXISTS purchase_orders (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL DEFAULT 'default',
+		number TEXT NOT NULL,
+		supplier_id TEXT NOT NULL,
+		status TEXT NOT NULL,
+		expected_at TIMESTAMPTZ,
+		notes TEXT,
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL,
+		CHECK(status IN ('open', 'partially_received', 'received', 'cancelled'))
+	);
+
+	CREATE TABLE IF NOT EXISTS purchase_order_lines (
+		purchase_order_id TEXT NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+		item_id TEXT NOT NULL,
+		sku TEXT NOT NULL,
+		ordered INTEGER NOT NULL,
+		received INTEGER NOT NULL DEFAULT 0,
+		unit_cost DOUBLE PRECISION,
+		PRIMARY KEY (purchase_order_id, item_id),
+		CHECK(ordered > 0),
+		CHECK(received >= 0),
+		CHECK(received <= ordered),
+		CHECK(unit_cost IS NULL OR unit_cost >= 0)
+	);
+
+	CREATE TABLE IF NOT EXISTS stock_locations (
+		item_id TEXT NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+		location TEXT NOT NULL,
+		quantity INTEGER NOT NULL DEFAULT 0,
+		reserved INTEGER NOT NULL DEFAULT 0,
+		updated_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (item_id, location),
+		CHECK(quantity >= 0),
+		CHECK(reserved >= 0),
+		CHECK(reserved <= quantity)
+	);
+
+	CREATE TABLE IF NOT EXISTS cost_layers (
+		id TEXT PRIMARY KEY,
+		item_id TEXT NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+		quantity_received INTEGER NOT NULL,
+		quantity_remaining INTEGER NOT NULL,
+		unit_cost DOUBLE PRECISION,
+		received_at TIMESTAMPTZ NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL,
+		CHECK(quantity_received > 0),
+		CHECK(quantity_remaining >= 0),
+		CHECK(quantity_remaining <= quantity_received),
+		CHECK(unit_cost IS NULL OR unit_cost >= 0)
+	);
+
+	CREATE TABLE IF NOT EXISTS stock_movements (
+		id TEXT PRIMARY KEY,
+		item_id TEXT NOT NULL,
+		store_id TEXT,
+		movement_type TEXT NOT NULL,
+		quantity_change INTEGER NOT NULL DEFAULT 0,
+		reserved_change INTEGER NOT NULL DEFAULT 0,
+		quantity_after INTEGER NOT NULL,
+		reserved_after INTEGER NOT NULL,
+		available_after INTEGER NOT NULL,
+		occurred_at TIMESTAMPTZ NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS reservation_waitlist (
+		id TEXT PRIMARY KEY,
+		item_id TEXT NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+		quantity INTEGER NOT NULL,
+		status TEXT NOT NULL DEFAULT 'waiting',
+		requested_at TIMESTAMPTZ NOT NULL,
+		fulfilled_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL,
+		CHECK(quantity > 0),
+		CHECK(status IN ('waiting', 'fulfilled', 'cancelled'))
+	);
+
+	CREATE TABLE IF NOT EXISTS activity_log (
+		id TEXT PRIMARY KEY,
+		event_id TEXT,
+		event_type TEXT NOT NULL,
+		item_id TEXT,
+		store_id TEXT,
+		sku TEXT,
+		quantity INTEGER,
+		actor TEXT,
+		request_id TEXT,
+		tenant_id TEXT NOT NULL DEFAULT 'default',
+		outcome TEXT NOT NULL,
+		error TEXT,
+		occurred_at TIMESTAMPTZ NOT NULL,
+		processed_at TIMESTAMPTZ NOT NULL,
+		CHECK(outcome IN ('applied', 'failed'))
+	);
+
+	CREATE TABLE IF NOT EXISTS processed_events (
+		event_id TEXT PRIMARY KEY,
+		event_type TEXT NOT NULL,
+		processed_at TIMESTAMPTZ NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS replication_state (
+		id INTEGER PRIMARY KEY CHECK(id = 1),
+		role TEXT NOT NULL,
+		region TEXT NOT NULL,
+		changed_at TIMESTAMPTZ NOT NULL,
+		CHECK(role IN ('primary', 'secondary'))
+	);
+
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL
+	);
+	`
+
+	if err := swdb.execStatements(schema); err != nil {
+		return err
+	}
+
+	// Same column migrations as SQLite; PostgreSQL checks for the column itself
+	for _, column := range []struct{ table, name, definition string }{
+		{"stock_movements", "actor", "TEXT"},
+		{"stock_movements", "request_id", "TEXT"},
+		{"stock_movements", "reason", "TEXT"},
+		{"stock_movements", "reference", "TEXT"},
+		{"inventory_items", "deleted_at", "TIMESTAMPTZ"},
+		{"inventory_items", "tenant_id", "TEXT NOT NULL DEFAULT 'default'"},
+		{"stores", "tenant_id", "TEXT NOT NULL DEFAULT 'default'"},
+		{"activity_log", "tenant_id", "TEXT NOT NULL DEFAULT 'default'"},
+	} {
+		if _, err := swdb.db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s`,
+			column.table, column.name, column.definition)); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", column.table, column.name, err)
+		}
+	}
+
+	// Schema 8 made SKUs and store codes unique per tenant instead of globally: the
+	// per-tenant unique indexes replace these constraints
+	for _, statement := range []string{
+		`ALTER TABLE inventory_items DROP CONSTRAINT IF EXISTS inventory_items_sku_key`,
+		`ALTER TABLE stores DROP CONSTRAINT IF EXISTS stores_code_key`,
+	} {
+		if _, err := swdb.db.Exec(statement); err != nil {
+			return err
+		}
+	}
+
+	if err := swdb.execStatements(postgresIndexes); err != nil {
+		return err
+	}
+
+	return swdb.recordSchemaVersion()
+}
+
+// execStatements runs a single statement per Exec, which keeps the errors pointing at
+// the failing statement
+func (swdb *SingleWriterDB) execStatements(statements string) error {
+	for _, statement := range strings.Split(statements, ";") {
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+		if _, err := swdb.db.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
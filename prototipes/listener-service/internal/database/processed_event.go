@@ -0,0 +1,44 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// MarkEventProcessed records the event-id of an event being applied. It returns false,
+// without an error, when the event was recorded before: it was applied and committed,
+// but its offset was not marked (a crash or a rebalance in between). Call it with the
+// context of the batch applying the event, so the record is committed or rolled back
+// with its writes.
+func (swdb *SingleWriterDB) MarkEventProcessed(ctx context.Context, eventID, eventType string) (bool, error) {
+	defer swdb.lockWriter(ctx, "mark_event_processed")()
+
+	result, err := swdb.conn(ctx).ExecContext(ctx, `
+		INSERT INTO processed_events (event_id, event_type, processed_at) VALUES (?, ?, ?)
+		ON CONFLICT (event_id) DO NOTHING
+	`, eventID, eventType, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return false, fmt.Errorf("failed to record processed event: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record processed event: %w", err)
+	}
+	return rows == 1, nil
+}
+
+// PruneProcessedEvents deletes the records of the events processed before before and
+// returns how many. A redelivery older than that is applied again.
+func (swdb *SingleWriterDB) PruneProcessedEvents(ctx context.Context, before time.Time) (int64, error) {
+	defer swdb.lockWriter(ctx, "prune_processed_events")()
+
+	result, err := swdb.conn(ctx).ExecContext(ctx,
+		`DELETE FROM processed_events WHERE processed_at < ?`,
+		before.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune processed events: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,202 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrPurchaseOrderNotFound is returned by the writes to a purchase order that does not exist
+var ErrPurchaseOrderNotFound = errors.New("purchase order not found")
+
+// Supplier is a vendor the purchase orders are placed with
+type Supplier struct {
+	ID        string
+	TenantID  string // Tenant of the event that created it
+	Code      string
+	Name      string
+	Email     string
+	Phone     string
+	Active    bool
+	UpdatedAt time.Time // When the supplier was created or last changed
+}
+
+// PurchaseOrder is stock ordered from a supplier. Its lines are only written when it is
+// created; receipts update the received quantity of each line.
+type PurchaseOrder struct {
+	ID         string
+	TenantID   string
+	Number     string
+	SupplierID string
+	Status     string
+	ExpectedAt *time.Time
+	Notes      string
+	Lines      []PurchaseOrderLine
+	CreatedAt  time.Time
+}
+
+// PurchaseOrderLine is the quantity of an item ordered and received on a purchase order
+type PurchaseOrderLine struct {
+	ItemID   string
+	SKU      string
+	Ordered  int
+	Received int // Total received so far
+	UnitCost *float64
+}
+
+// SaveSupplier creates a supplier or replaces its data. The tenant and creation time of
+// an existing supplier are kept.
+func (swdb *SingleWriterDB) SaveSupplier(ctx context.Context, supplier *Supplier) error {
+	defer swdb.lockWriter(ctx, "save_supplier")()
+
+	if supplier.TenantID == "" {
+		supplier.TenantID = TenantFromContext(ctx)
+	}
+	active := 0
+	if supplier.Active {
+		active = 1
+	}
+	at := supplier.UpdatedAt.UTC().Format(time.RFC3339)
+
+	_, err := swdb.conn(ctx).ExecContext(ctx, `
+		INSERT INTO suppliers (id, tenant_id, code, name, email, phone, active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			name = excluded.name,
+			email = excluded.email,
+			phone = excluded.phone,
+			active = excluded.active,
+			updated_at = excluded.updated_at
+	`, supplier.ID, supplier.TenantID, supplier.Code, supplier.Name, supplier.Email, supplier.Phone, active, at, at)
+	if err != nil {
+		return fmt.Errorf("failed to save supplier: %w", err)
+	}
+	return nil
+}
+
+// DeleteSupplier removes a supplier; its purchase orders keep the supplier ID. Removing
+// a supplier that does not exist is not an error.
+func (swdb *SingleWriterDB) DeleteSupplier(ctx context.Context, supplierID string) error {
+	defer swdb.lockWriter(ctx, "delete_supplier")()
+
+	if _, err := swdb.conn(ctx).ExecContext(ctx, `DELETE FROM suppliers WHERE id = ?`, supplierID); err != nil {
+		return fmt.Errorf("failed to delete supplier: %w", err)
+	}
+	return nil
+}
+
+// CreatePurchaseOrder inserts a purchase order with its lines in a single transaction.
+// An order that already exists is left as it is.
+func (swdb *SingleWriterDB) CreatePurchaseOrder(ctx context.Context, order *PurchaseOrder) error {
+	defer swdb.lockWriter(ctx, "create_purchase_order")()
+
+	if order.TenantID == "" {
+		order.TenantID = TenantFromContext(ctx)
+	}
+	var expectedAt sql.NullString
+	if order.ExpectedAt != nil {
+		expectedAt = sql.NullString{String: order.ExpectedAt.UTC().Format(time.RFC3339), Valid: true}
+	}
+	createdAt := order.CreatedAt.UTC().Format(time.RFC3339)
+
+	tx, err := swdb.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO purchase_orders (id, tenant_id, number, supplier_id, status, expected_at, notes, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING
+	`, order.ID, order.TenantID, order.Number, order.SupplierID, order.Status, expectedAt, order.Notes, createdAt, createdAt)
+	if err != nil {
+		return fmt.Errorf("failed to create purchase order: %w", err)
+	}
+	if inserted, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if inserted == 0 {
+		return nil
+	}
+
+	for _, line := range order.Lines {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO purchase_order_lines (purchase_order_id, item_id, sku, ordered, received, unit_cost)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, order.ID, line.ItemID, line.SKU, line.Ordered, line.Received, line.UnitCost); err != nil {
+			return fmt.Errorf("failed to create purchase order line: %w", err)
+		}
+	}
+
+	if err := commitTx(tx, "create_purchase_order"); err != nil {
+		return fmt.Errorf("failed to commit purchase order: %w", err)
+	}
+	return nil
+}
+
+// ReceivePurchaseOrder records the total received of the given lines (Received) and the
+// status of the order after a receipt. Totals only move forward, so applying the same
+// receipt twice changes nothing.
+func (swdb *SingleWriterDB) ReceivePurchaseOrder(ctx context.Context, orderID, status string, lines []PurchaseOrderLine, receivedAt time.Time) error {
+	defer swdb.lockWriter(ctx, "receive_purchase_order")()
+
+	tx, err := swdb.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := setPurchaseOrderStatus(ctx, tx, orderID, status, receivedAt); err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE purchase_order_lines SET received = ?
+			WHERE purchase_order_id = ? AND item_id = ? AND received < ?
+		`, line.Received, orderID, line.ItemID, line.Received); err != nil {
+			return fmt.Errorf("failed to update purchase order line: %w", err)
+		}
+	}
+
+	if err := commitTx(tx, "receive_purchase_order"); err != nil {
+		return fmt.Errorf("failed to commit purchase order receipt: %w", err)
+	}
+	return nil
+}
+
+// SetPurchaseOrderStatus changes the status of a purchase order (e.g. when it is cancelled)
+func (swdb *SingleWriterDB) SetPurchaseOrderStatus(ctx context.Context, orderID, status string, at time.Time) error {
+	defer swdb.lockWriter(ctx, "set_purchase_order_status")()
+	return setPurchaseOrderStatus(ctx, swdb.conn(ctx), orderID, status, at)
+}
+
+func setPurchaseOrderStatus(ctx context.Context, conn executor, orderID, status string, at time.Time) error {
+	result, err := conn.ExecContext(ctx, `UPDATE purchase_orders SET status = ?, updated_at = ? WHERE id = ?`,
+		status, at.UTC().Format(time.RFC3339), orderID)
+	if err != nil {
+		return fmt.Errorf("failed to update purchase order: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrPurchaseOrderNotFound
+	}
+	return nil
+}
+
+// GetPurchaseOrderStatus returns the status of a purchase order
+func (swdb *SingleWriterDB) GetPurchaseOrderStatus(ctx context.Context, orderID string) (string, error) {
+	var status string
+	err := swdb.conn(ctx).QueryRowContext(ctx, `SELECT status FROM purchase_orders WHERE id = ?`, orderID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrPurchaseOrderNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get purchase order: %w", err)
+	}
+	return status, nil
+}
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// readModelTables are the tables rebuilt from the events, children first. The
+// replication role and the schema version are not derived from events and are kept.
+var readModelTables = []string{
+	"store_calendars",
+	"store_reservations",
+	"item_relations",
+	"item_images",
+	"item_identifiers",
+	"purchase_order_lines",
+	"purchase_orders",
+	"suppliers",
+	"stock_locations",
+	"cost_layers",
+	"stock_movements",
+	"reservation_waitlist",
+	"activity_log",
+	"processed_events",
+	"inventory_items",
+	"stores",
+}
+
+// Truncate deletes every row the events wrote, in one transaction, so the read model
+// can be rebuilt by replaying them
+func (swdb *SingleWriterDB) Truncate(ctx context.Context) error {
+	defer swdb.lockWriter(ctx, "truncate")()
+
+	tx, err := swdb.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range readModelTables {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+			return fmt.Errorf("failed to truncate %s: %w", table, err)
+		}
+	}
+	if err := commitTx(tx, "truncate"); err != nil {
+		return fmt.Errorf("failed to commit truncation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// GetReplicationRole returns the role recorded by the last promote/demote, or an
+// empty role if the region never changed role
+func (swdb *SingleWriterDB) GetReplicationRole(ctx context.Context) (string, time.Time, error) {
+	var role, changedAt string
+	err := swdb.conn(ctx).QueryRowContext(ctx, `SELECT role, changed_at FROM replication_state WHERE id = 1`).
+		Scan(&role, &changedAt)
+	if err == sql.ErrNoRows {
+		return "", time.Time{}, nil
+	}
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to get replication role: %w", err)
+	}
+
+	at, err := time.Parse(time.RFC3339, changedAt)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid replication role timestamp %q: %w", changedAt, err)
+	}
+	return role, at, nil
+}
+
+// SaveReplicationRole records a role change of this region
+func (swdb *SingleWriterDB) SaveReplicationRole(ctx context.Context, role, region string, changedAt time.Time) error {
+	defer swdb.lockWriter(ctx, "save_replication_role")()
+
+	_, err := swdb.conn(ctx).ExecContext(ctx, `
+		INSERT INTO replication_state (id, role, region, changed_at) VALUES (1, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET role = excluded.role, region = excluded.region, changed_at = excluded.changed_at
+	`, role, region, changedAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("failed to save replication role: %w", err)
+	}
+	return nil
+}
@@ -5,21 +5,41 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"listener-service/internal/config"
+	"listener-service/pkg/metrics"
 
+	"github.com/google/uuid"
 	_ "github.com/mattn/go-sqlite3"
 	"go.uber.org/zap"
 )
 
+// SchemaVersion is the version of the read model schema created by initSchema. It is
+// recorded in schema_migrations so the Query Service, which reads this database, can
+// refuse a schema it was not written for. Bump it with every change to the schema.
+const SchemaVersion = 11
+
 // SingleWriterDB implements Single Writer Principle for SQLite
-// Only one writer can access the database at a time
+// Only one writer can access the database at a time.
+//
+// The same queries also run on PostgreSQL (see NewPostgresDB). There the database
+// serializes conflicting writes itself, through the version checks of optimistic
+// locking and row locks in transactions, so the in-process lock is not taken and
+// several listeners can write to the read model.
 type SingleWriterDB struct {
-	db     *sql.DB
-	logger *zap.Logger
-	mu     sync.Mutex // Mutex to ensure single writer
+	db        *sqlDB
+	dialect   dialect
+	serialize bool // Take mu around every write (SQLite)
+	logger    *zap.Logger
+	mu        sync.Mutex // Mutex to ensure single writer
+}
+
+// Driver returns the database driver ("sqlite" or "postgres")
+func (swdb *SingleWriterDB) Driver() string {
+	return string(swdb.dialect)
 }
 
 // QueryRow executes a query that returns a single row
@@ -34,7 +54,7 @@ func (swdb *SingleWriterDB) Ping() error {
 
 // NewSingleWriterDB creates a new database connection with single writer principle
 func NewSingleWriterDB(cfg *config.Config, logger *zap.Logger) (*SingleWriterDB, error) {
-	db, err := sql.Open("sqlite3", cfg.SQLitePath+"?_journal_mode=WAL&_foreign_keys=1")
+	db, err := sql.Open("sqlite3", withParams(cfg.SQLitePath, "_journal_mode=WAL&_foreign_keys=1"))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -42,11 +62,16 @@ func NewSingleWriterDB(cfg *config.Config, logger *zap.Logger) (*SingleWriterDB,
 	// Set connection pool settings
 	db.SetMaxOpenConns(1) // Single writer
 	db.SetMaxIdleConns(1)
-	db.SetConnMaxLifetime(time.Hour)
+	if !strings.Contains(cfg.SQLitePath, "mode=memory") {
+		// An in-memory database is dropped with its last connection, so it is never recycled
+		db.SetConnMaxLifetime(time.Hour)
+	}
 
 	swdb := &SingleWriterDB{
-		db:     db,
-		logger: logger,
+		db:        &sqlDB{DB: db, dialect: DriverSQLite},
+		dialect:   DriverSQLite,
+		serialize: true,
+		logger:    logger,
 	}
 
 	// Initialize schema
@@ -58,25 +83,60 @@ func NewSingleWriterDB(cfg *config.Config, logger *zap.Logger) (*SingleWriterDB,
 	return swdb, nil
 }
 
-// initSchema creates the database schema
-func (swdb *SingleWriterDB) initSchema() error {
-	schema := `
-	-- Stores table: Information about physical stores
-	CREATE TABLE IF NOT EXISTS stores (
+// withParams appends SQLite DSN parameters to path, which may already have some
+// (e.g. the in-memory DSN used by MOCK_DEPENDENCIES)
+func withParams(path, params string) string {
+	if strings.Contains(path, "?") {
+		return path + "&" + params
+	}
+	return path + "?" + params
+}
+
+// NewReadOnlyDB opens an existing database without creating or migrating the schema.
+// Writes fail at the SQLite level, which is what dry-run mode relies on.
+func NewReadOnlyDB(cfg *config.Config, logger *zap.Logger) (*SingleWriterDB, error) {
+	db, err := sql.Open("sqlite3", "file:"+cfg.SQLitePath+"?mode=ro&_foreign_keys=1")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database read-only (it must already exist): %w", err)
+	}
+
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(time.Hour)
+
+	return &SingleWriterDB{
+		db:        &sqlDB{DB: db, dialect: DriverSQLite},
+		dialect:   DriverSQLite,
+		serialize: true,
+		logger:    logger,
+	}, nil
+}
+
+// SQLite tables keyed by tenant, as templates taking the table name so an older
+// table can be rebuilt under another name (see rebuildWithTenant). SKUs and store
+// codes are unique per tenant.
+const (
+	sqliteStoresTable = `CREATE TABLE IF NOT EXISTS %s (
 		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL DEFAULT 'default',
 		name TEXT NOT NULL,
 		location TEXT,
-		code TEXT UNIQUE NOT NULL,
+		code TEXT NOT NULL,
 		active INTEGER NOT NULL DEFAULT 1,
 		created_at TEXT NOT NULL,
 		updated_at TEXT NOT NULL,
+		UNIQUE(tenant_id, code),
 		CHECK(active IN (0, 1))
-	);
+	)`
 
-	-- Inventory items table: Centralized inventory (single source of truth)
-	CREATE TABLE IF NOT EXISTS inventory_items (
+	sqliteItemsTable = `CREATE TABLE IF NOT EXISTS %s (
 		id TEXT PRIMARY KEY,
-		sku TEXT UNIQUE NOT NULL,
+		tenant_id TEXT NOT NULL DEFAULT 'default',
+		sku TEXT NOT NULL,
 		name TEXT NOT NULL,
 		description TEXT,
 		quantity INTEGER NOT NULL DEFAULT 0,
@@ -85,13 +145,70 @@ func (swdb *SingleWriterDB) initSchema() error {
 		version INTEGER NOT NULL DEFAULT 1,
 		created_at TEXT NOT NULL,
 		updated_at TEXT NOT NULL,
+		deleted_at TEXT,
+		UNIQUE(tenant_id, sku),
 		CHECK(quantity >= 0),
 		CHECK(reserved >= 0),
 		CHECK(available >= 0),
 		CHECK(reserved <= quantity),
 		CHECK(available = quantity - reserved)
+	)`
+)
+
+// sqliteIndexes run after the migrations, which may rebuild the tables they index
+const sqliteIndexes = `
+	CREATE INDEX IF NOT EXISTS idx_inventory_items_sku ON inventory_items(sku);
+	CREATE INDEX IF NOT EXISTS idx_inventory_items_version ON inventory_items(version);
+	CREATE INDEX IF NOT EXISTS idx_inventory_items_created ON inventory_items(created_at DESC, id);
+	CREATE INDEX IF NOT EXISTS idx_inventory_items_tenant_created ON inventory_items(tenant_id, created_at DESC, id);
+	CREATE INDEX IF NOT EXISTS idx_stores_code ON stores(code);
+	CREATE INDEX IF NOT EXISTS idx_stores_active ON stores(active);
+	CREATE INDEX IF NOT EXISTS idx_store_reservations_store_id ON store_reservations(store_id);
+	CREATE INDEX IF NOT EXISTS idx_store_reservations_item_id ON store_reservations(item_id);
+	CREATE INDEX IF NOT EXISTS idx_store_reservations_status ON store_reservations(status);
+	CREATE INDEX IF NOT EXISTS idx_store_reservations_store_item ON store_reservations(store_id, item_id);
+	CREATE INDEX IF NOT EXISTS idx_item_relations_related ON item_relations(related_item_id);
+	CREATE INDEX IF NOT EXISTS idx_item_images_item ON item_images(item_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_item_identifiers_item ON item_identifiers(item_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_suppliers_tenant_code ON suppliers(tenant_id, code);
+	CREATE INDEX IF NOT EXISTS idx_purchase_orders_tenant_created ON purchase_orders(tenant_id, created_at DESC, id);
+	CREATE INDEX IF NOT EXISTS idx_purchase_orders_supplier ON purchase_orders(supplier_id, status);
+	CREATE INDEX IF NOT EXISTS idx_stock_locations_location ON stock_locations(location);
+	CREATE INDEX IF NOT EXISTS idx_cost_layers_item_received ON cost_layers(item_id, received_at);
+	CREATE INDEX IF NOT EXISTS idx_stock_movements_item_occurred ON stock_movements(item_id, occurred_at);
+	CREATE INDEX IF NOT EXISTS idx_stock_movements_actor ON stock_movements(actor, occurred_at);
+	CREATE INDEX IF NOT EXISTS idx_reservation_waitlist_item_status ON reservation_waitlist(item_id, status, requested_at);
+	CREATE INDEX IF NOT EXISTS idx_activity_log_processed ON activity_log(processed_at);
+	CREATE INDEX IF NOT EXISTS idx_activity_log_actor ON activity_log(actor, processed_at);
+	CREATE INDEX IF NOT EXISTS idx_activity_log_item ON activity_log(item_id, processed_at);
+	CREATE INDEX IF NOT EXISTS idx_activity_log_tenant ON activity_log(tenant_id, processed_at);
+	CREATE INDEX IF NOT EXISTS idx_processed_events_processed ON processed_events(processed_at);
+`
+
+// initSchema creates the database schema
+func (swdb *SingleWriterDB) initSchema() error {
+	if swdb.dialect == DriverPostgres {
+		return swdb.initPostgresSchema()
+	}
+
+	schema := fmt.Sprintf(`
+	-- Stores table: Information about physical stores
+	%s;
+
+	-- Store calendars table: Opening hours and holidays of a store (none = always open)
+	-- hours and holidays are JSON arrays, in the store's local time (timezone)
+	CREATE TABLE IF NOT EXISTS store_calendars (
+		store_id TEXT PRIMARY KEY,
+		timezone TEXT NOT NULL,
+		hours TEXT NOT NULL DEFAULT '[]',
+		holidays TEXT NOT NULL DEFAULT '[]',
+		updated_at TEXT NOT NULL,
+		FOREIGN KEY (store_id) REFERENCES stores(id) ON DELETE CASCADE
 	);
 
+	-- Inventory items table: Centralized inventory (single source of truth)
+	%s;
+
 	-- Store reservations table: Track reservations by store
 	-- This allows us to know which store has reserved which items
 	CREATE TABLE IF NOT EXISTS store_reservations (
@@ -111,19 +228,362 @@ func (swdb *SingleWriterDB) initSchema() error {
 		CHECK(status IN ('active', 'released', 'expired', 'fulfilled'))
 	);
 
-	-- Indexes for performance
-	CREATE INDEX IF NOT EXISTS idx_inventory_items_sku ON inventory_items(sku);
-	CREATE INDEX IF NOT EXISTS idx_inventory_items_version ON inventory_items(version);
-	CREATE INDEX IF NOT EXISTS idx_stores_code ON stores(code);
-	CREATE INDEX IF NOT EXISTS idx_stores_active ON stores(active);
-	CREATE INDEX IF NOT EXISTS idx_store_reservations_store_id ON store_reservations(store_id);
-	CREATE INDEX IF NOT EXISTS idx_store_reservations_item_id ON store_reservations(item_id);
-	CREATE INDEX IF NOT EXISTS idx_store_reservations_status ON store_reservations(status);
-	CREATE INDEX IF NOT EXISTS idx_store_reservations_store_item ON store_reservations(store_id, item_id);
-	`
+	-- Item relations table: Directed links between items
+	-- related_item_id is a substitute for item_id, or an accessory of it
+	CREATE TABLE IF NOT EXISTS item_relations (
+		item_id TEXT NOT NULL,
+		related_item_id TEXT NOT NULL,
+		relation_type TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		PRIMARY KEY (item_id, related_item_id, relation_type),
+		FOREIGN KEY (item_id) REFERENCES inventory_items(id) ON DELETE CASCADE,
+		FOREIGN KEY (related_item_id) REFERENCES inventory_items(id) ON DELETE CASCADE,
+		CHECK(item_id <> related_item_id),
+		CHECK(relation_type IN ('substitute', 'accessory'))
+	);
+
+	-- Item images table: Where the image files of an item are
+	-- The files live in the image store of the Command Service (IMAGE_STORE)
+	CREATE TABLE IF NOT EXISTS item_images (
+		id TEXT PRIMARY KEY,
+		item_id TEXT NOT NULL,
+		storage_key TEXT NOT NULL,
+		url TEXT NOT NULL,
+		content_type TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		created_at TEXT NOT NULL,
+		FOREIGN KEY (item_id) REFERENCES inventory_items(id) ON DELETE CASCADE,
+		CHECK(size > 0)
+	);
+
+	-- Item identifiers table: Barcodes and aliases an item is looked up by
+	-- code is the GTIN-14 of a barcode or the alias, unique among the items of a tenant
+	CREATE TABLE IF NOT EXISTS item_identifiers (
+		tenant_id TEXT NOT NULL DEFAULT 'default',
+		code TEXT NOT NULL,
+		item_id TEXT NOT NULL,
+		type TEXT NOT NULL,
+		value TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		PRIMARY KEY (tenant_id, code),
+		FOREIGN KEY (item_id) REFERENCES inventory_items(id) ON DELETE CASCADE
+	);
+
+	-- Suppliers table: Vendors the purchase orders are placed with
+	-- The code is unique per tenant in the Command Service; not enforced here because a
+	-- supplier recreated with a freed code may arrive before the removal of the old one
+	CREATE TABLE IF NOT EXISTS suppliers (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL DEFAULT 'default',
+		code TEXT NOT NULL,
+		name TEXT NOT NULL,
+		email TEXT,
+		phone TEXT,
+		active INTEGER NOT NULL DEFAULT 1,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL,
+		CHECK(active IN (0, 1))
+	);
+
+	-- Purchase orders table: Stock ordered from a supplier
+	-- No foreign key on supplier_id: closed orders outlive their supplier
+	CREATE TABLE IF NOT EXISTS purchase_orders (
+		id TEXT PRIMARY KEY,
+		tenant_id TEXT NOT NULL DEFAULT 'default',
+		number TEXT NOT NULL,
+		supplier_id TEXT NOT NULL,
+		status TEXT NOT NULL,
+		expected_at TEXT,
+		notes TEXT,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL,
+		CHECK(status IN ('open', 'partially_received', 'received', 'cancelled'))
+	);
+
+	-- Purchase order lines table: Quantity ordered and received of each item of an order
+	-- No foreign key on item_id: the item events come on another topic and may lag
+	CREATE TABLE IF NOT EXISTS purchase_order_lines (
+		purchase_order_id TEXT NOT NULL,
+		item_id TEXT NOT NULL,
+		sku TEXT NOT NULL,
+		ordered INTEGER NOT NULL,
+		received INTEGER NOT NULL DEFAULT 0,
+		unit_cost REAL,
+		PRIMARY KEY (purchase_order_id, item_id),
+		FOREIGN KEY (purchase_order_id) REFERENCES purchase_orders(id) ON DELETE CASCADE,
+		CHECK(ordered > 0),
+		CHECK(received >= 0),
+		CHECK(received <= ordered),
+		CHECK(unit_cost IS NULL OR unit_cost >= 0)
+	);
+
+	-- Stock locations table: Stock of an item per warehouse/store location
+	-- inventory_items keeps the totals; the part not held at any location is the difference
+	CREATE TABLE IF NOT EXISTS stock_locations (
+		item_id TEXT NOT NULL,
+		location TEXT NOT NULL,
+		quantity INTEGER NOT NULL DEFAULT 0,
+		reserved INTEGER NOT NULL DEFAULT 0,
+		updated_at TEXT NOT NULL,
+		PRIMARY KEY (item_id, location),
+		FOREIGN KEY (item_id) REFERENCES inventory_items(id) ON DELETE CASCADE,
+		CHECK(quantity >= 0),
+		CHECK(reserved >= 0),
+		CHECK(reserved <= quantity)
+	);
+
+	-- Cost layers table: One layer per stock receipt, used for inventory valuation
+	-- quantity_remaining is consumed oldest-first (FIFO) when stock leaves the inventory
+	-- unit_cost is NULL when the receipt was recorded without a cost
+	CREATE TABLE IF NOT EXISTS cost_layers (
+		id TEXT PRIMARY KEY,
+		item_id TEXT NOT NULL,
+		quantity_received INTEGER NOT NULL,
+		quantity_remaining INTEGER NOT NULL,
+		unit_cost REAL,
+		received_at TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL,
+		FOREIGN KEY (item_id) REFERENCES inventory_items(id) ON DELETE CASCADE,
+		CHECK(quantity_received > 0),
+		CHECK(quantity_remaining >= 0),
+		CHECK(quantity_remaining <= quantity_received),
+		CHECK(unit_cost IS NULL OR unit_cost >= 0)
+	);
+
+	-- Stock movements table: One row per stock change applied to an item
+	-- quantity_change/reserved_change are signed deltas, *_after are the resulting totals
+	-- No foreign key on item_id so the history survives item deletion
+	CREATE TABLE IF NOT EXISTS stock_movements (
+		id TEXT PRIMARY KEY,
+		item_id TEXT NOT NULL,
+		store_id TEXT,
+		movement_type TEXT NOT NULL,
+		quantity_change INTEGER NOT NULL DEFAULT 0,
+		reserved_change INTEGER NOT NULL DEFAULT 0,
+		quantity_after INTEGER NOT NULL,
+		reserved_after INTEGER NOT NULL,
+		available_after INTEGER NOT NULL,
+		occurred_at TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		actor TEXT,
+		request_id TEXT,
+		reason TEXT,
+		reference TEXT
+	);
+
+	-- Reservation waitlist: reservations that could not be satisfied when requested
+	-- Waiting entries are fulfilled in FIFO order (requested_at) when stock frees up
+	CREATE TABLE IF NOT EXISTS reservation_waitlist (
+		id TEXT PRIMARY KEY,
+		item_id TEXT NOT NULL,
+		quantity INTEGER NOT NULL,
+		status TEXT NOT NULL DEFAULT 'waiting',
+		requested_at TEXT NOT NULL,
+		fulfilled_at TEXT,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL,
+		FOREIGN KEY (item_id) REFERENCES inventory_items(id) ON DELETE CASCADE,
+		CHECK(quantity > 0),
+		CHECK(status IN ('waiting', 'fulfilled', 'cancelled'))
+	);
+
+	-- Activity log: every consumed command event and its outcome, for the activity feed
+	CREATE TABLE IF NOT EXISTS activity_log (
+		id TEXT PRIMARY KEY,
+		event_id TEXT,
+		event_type TEXT NOT NULL,
+		item_id TEXT,
+		store_id TEXT,
+		sku TEXT,
+		quantity INTEGER,
+		actor TEXT,
+		request_id TEXT,
+		tenant_id TEXT NOT NULL DEFAULT 'default',
+		outcome TEXT NOT NULL,
+		error TEXT,
+		occurred_at TEXT NOT NULL,
+		processed_at TEXT NOT NULL,
+		CHECK(outcome IN ('applied', 'failed'))
+	);
+
+	-- Event ids already applied, recorded in the transaction of their writes so a
+	-- redelivered event is not applied twice
+	CREATE TABLE IF NOT EXISTS processed_events (
+		event_id TEXT PRIMARY KEY,
+		event_type TEXT NOT NULL,
+		processed_at TEXT NOT NULL
+	);
+
+	-- Replication role set by promote/demote (at most one row)
+	CREATE TABLE IF NOT EXISTS replication_state (
+		id INTEGER PRIMARY KEY CHECK(id = 1),
+		role TEXT NOT NULL,
+		region TEXT NOT NULL,
+		changed_at TEXT NOT NULL,
+		CHECK(role IN ('primary', 'secondary'))
+	);
+
+	-- Schema versions applied to this database (read by the Query Service at startup)
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	);
+	`, fmt.Sprintf(sqliteStoresTable, "stores"), fmt.Sprintf(sqliteItemsTable, "inventory_items"))
+
+	if _, err := swdb.db.Exec(schema); err != nil {
+		return err
+	}
+
+	// Columns added after the table was first released; CREATE TABLE IF NOT EXISTS
+	// does not add them to existing databases
+	for _, column := range []struct{ table, name, definition string }{
+		{"stock_movements", "actor", "TEXT"},
+		{"stock_movements", "request_id", "TEXT"},
+		{"stock_movements", "reason", "TEXT"},
+		{"stock_movements", "reference", "TEXT"},
+		{"inventory_items", "deleted_at", "TEXT"},
+		{"activity_log", "tenant_id", "TEXT NOT NULL DEFAULT 'default'"},
+	} {
+		if err := swdb.addColumnIfMissing(column.table, column.name, column.definition); err != nil {
+			return err
+		}
+	}
+
+	// Schema 8 made SKUs and store codes unique per tenant instead of globally
+	if err := swdb.rebuildWithTenant("stores", sqliteStoresTable); err != nil {
+		return err
+	}
+	if err := swdb.rebuildWithTenant("inventory_items", sqliteItemsTable); err != nil {
+		return err
+	}
 
-	_, err := swdb.db.Exec(schema)
-	return err
+	if _, err := swdb.db.Exec(sqliteIndexes); err != nil {
+		return err
+	}
+
+	return swdb.recordSchemaVersion()
+}
+
+// recordSchemaVersion runs last, once every change to the schema is in place
+func (swdb *SingleWriterDB) recordSchemaVersion() error {
+	if _, err := swdb.db.Exec(
+		`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?) ON CONFLICT (version) DO NOTHING`,
+		SchemaVersion, time.Now().UTC().Format(time.RFC3339),
+	); err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+	return nil
+}
+
+// addColumnIfMissing adds a column to an existing table unless it is already there
+func (swdb *SingleWriterDB) addColumnIfMissing(table, column, definition string) error {
+	columns, err := swdb.tableColumns(table)
+	if err != nil {
+		return err
+	}
+	for _, name := range columns {
+		if name == column {
+			return nil
+		}
+	}
+
+	if _, err := swdb.db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+	swdb.logger.Info("Schema migrated: column added", zap.String("table", table), zap.String("column", column))
+	return nil
+}
+
+// tableColumns returns the column names of a table, in order
+func (swdb *SingleWriterDB) tableColumns(table string) ([]string, error) {
+	rows, err := swdb.db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to inspect table %s: %w", table, err)
+		}
+		columns = append(columns, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	return columns, nil
+}
+
+// rebuildWithTenant recreates a table of a schema older than 8 from its template, so
+// it gets the tenant_id column and the per-tenant unique constraint; SQLite cannot
+// change a constraint in place. Existing rows go to the default tenant. Foreign keys
+// are off during the copy so dropping the old table does not cascade to the rows
+// referencing it, which point at the new table once it takes the old name.
+func (swdb *SingleWriterDB) rebuildWithTenant(table, template string) error {
+	columns, err := swdb.tableColumns(table)
+	if err != nil {
+		return err
+	}
+	for _, name := range columns {
+		if name == "tenant_id" {
+			return nil
+		}
+	}
+
+	ctx := context.Background()
+	conn, err := swdb.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild %s: %w", table, err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+		return fmt.Errorf("failed to rebuild %s: %w", table, err)
+	}
+	defer conn.ExecContext(ctx, `PRAGMA foreign_keys = ON`)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild %s: %w", table, err)
+	}
+	defer tx.Rollback()
+
+	rebuilt := table + "_v8"
+	list := strings.Join(columns, ", ")
+	for _, statement := range []string{
+		fmt.Sprintf(template, rebuilt),
+		fmt.Sprintf(`INSERT INTO %s (%s) SELECT %s FROM %s`, rebuilt, list, list, table),
+		fmt.Sprintf(`DROP TABLE %s`, table),
+		fmt.Sprintf(`ALTER TABLE %s RENAME TO %s`, rebuilt, table),
+	} {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to rebuild %s: %w", table, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to rebuild %s: %w", table, err)
+	}
+	swdb.logger.Info("Schema migrated: table rebuilt with tenant_id", zap.String("table", table))
+	return nil
+}
+
+// lockWriter takes the single-writer lock and returns the function that releases it,
+// recording how long the operation held the lock (or just ran, on PostgreSQL). Writes
+// inside a batch do not take it again: the batch holds it.
+func (swdb *SingleWriterDB) lockWriter(ctx context.Context, operation string) func() {
+	lock := swdb.serialize && batchFrom(ctx) == nil
+	if lock {
+		swdb.mu.Lock()
+	}
+	start := time.Now()
+	return func() {
+		metrics.SQLiteWriteDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+		if lock {
+			swdb.mu.Unlock()
+		}
+	}
 }
 
 // Close closes the database connection
@@ -134,6 +594,7 @@ func (swdb *SingleWriterDB) Close() error {
 // Store represents a physical store
 type Store struct {
 	ID        string
+	TenantID  string // Tenant of the event that created it; the code is unique per tenant
 	Name      string
 	Location  string
 	Code      string
@@ -145,6 +606,7 @@ type Store struct {
 // InventoryItem represents an inventory item in the database
 type InventoryItem struct {
 	ID          string
+	TenantID    string // Tenant of the event that created it; the SKU is unique per tenant
 	SKU         string
 	Name        string
 	Description string
@@ -154,6 +616,7 @@ type InventoryItem struct {
 	Version     int
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	DeletedAt   *time.Time // Set while the item is soft-deleted
 }
 
 // StoreReservation represents a reservation of inventory by a store
@@ -170,20 +633,75 @@ type StoreReservation struct {
 	UpdatedAt  time.Time
 }
 
+// StockMovement represents a stock change applied to an item
+type StockMovement struct {
+	ID             string
+	ItemID         string
+	StoreID        string // Empty when the movement is not attributed to a store
+	MovementType   string // Event type that caused the movement
+	QuantityChange int
+	ReservedChange int
+	QuantityAfter  int
+	ReservedAfter  int
+	AvailableAfter int
+	OccurredAt     time.Time
+	CreatedAt      time.Time
+	Actor          string // User that issued the command (actor header); empty if unknown
+	RequestID      string
+	Reason         string // Reason code or justification given for the movement
+	Reference      string // External reference given with the command, e.g. an order ID
+}
+
+// Activity outcomes
+const (
+	ActivityApplied = "applied"
+	ActivityFailed  = "failed"
+)
+
+// ActivityEntry records a consumed command event and whether it was applied
+type ActivityEntry struct {
+	ID          string
+	EventID     string // "event-id" header; empty for events published without it
+	EventType   string
+	ItemID      string
+	StoreID     string
+	SKU         string
+	Quantity    *int   // nil for events without a quantity
+	Actor       string // "actor" header (username of the command's JWT)
+	RequestID   string // "request-id" header
+	TenantID    string // "tenant-id" header; DefaultTenant when missing
+	Outcome     string // ActivityApplied or ActivityFailed
+	Error       string // Why the event failed
+	OccurredAt  time.Time
+	ProcessedAt time.Time
+}
+
+// WaitlistEntry represents a reservation waiting for stock
+type WaitlistEntry struct {
+	ID          string
+	ItemID      string
+	Quantity    int
+	Status      string // waiting, fulfilled, cancelled
+	RequestedAt time.Time
+	FulfilledAt *time.Time
+}
+
 // CreateItem creates a new inventory item (Single Writer)
 func (swdb *SingleWriterDB) CreateItem(ctx context.Context, item *InventoryItem) error {
-	swdb.mu.Lock()
-	defer swdb.mu.Unlock()
+	defer swdb.lockWriter(ctx, "create_item")()
 
 	query := `
-		INSERT INTO inventory_items (id, sku, name, description, quantity, reserved, available, version, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, 1, ?, ?)
+		INSERT INTO inventory_items (id, tenant_id, sku, name, description, quantity, reserved, available, version, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, 1, ?, ?)
 	`
 
+	if item.TenantID == "" {
+		item.TenantID = TenantFromContext(ctx)
+	}
 	now := time.Now().UTC()
 	available := item.Quantity - item.Reserved
-	_, err := swdb.db.ExecContext(ctx, query,
-		item.ID, item.SKU, item.Name, item.Description,
+	_, err := swdb.conn(ctx).ExecContext(ctx, query,
+		item.ID, item.TenantID, item.SKU, item.Name, item.Description,
 		item.Quantity, item.Reserved, available,
 		now.Format(time.RFC3339), now.Format(time.RFC3339),
 	)
@@ -197,8 +715,7 @@ func (swdb *SingleWriterDB) CreateItem(ctx context.Context, item *InventoryItem)
 
 // UpdateItem updates an inventory item with optimistic locking
 func (swdb *SingleWriterDB) UpdateItem(ctx context.Context, item *InventoryItem) error {
-	swdb.mu.Lock()
-	defer swdb.mu.Unlock()
+	defer swdb.lockWriter(ctx, "update_item")()
 
 	query := `
 		UPDATE inventory_items
@@ -206,7 +723,7 @@ func (swdb *SingleWriterDB) UpdateItem(ctx context.Context, item *InventoryItem)
 		WHERE id = ? AND version = ?
 	`
 
-	result, err := swdb.db.ExecContext(ctx, query,
+	result, err := swdb.conn(ctx).ExecContext(ctx, query,
 		item.Name, item.Description,
 		time.Now().UTC().Format(time.RFC3339),
 		item.ID, item.Version,
@@ -228,10 +745,39 @@ func (swdb *SingleWriterDB) UpdateItem(ctx context.Context, item *InventoryItem)
 	return nil
 }
 
+// PatchItem changes the name and description that are not nil, with optimistic locking
+func (swdb *SingleWriterDB) PatchItem(ctx context.Context, itemID string, name, description *string, expectedVersion int) error {
+	defer swdb.lockWriter(ctx, "patch_item")()
+
+	query := `
+		UPDATE inventory_items
+		SET name = COALESCE(?, name), description = COALESCE(?, description), version = version + 1, updated_at = ?
+		WHERE id = ? AND version = ?
+	`
+
+	result, err := swdb.conn(ctx).ExecContext(ctx, query,
+		name, description,
+		time.Now().UTC().Format(time.RFC3339),
+		itemID, expectedVersion,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to patch item: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrOptimisticLockFailed
+	}
+
+	return nil
+}
+
 // AdjustStock adjusts stock with optimistic locking
 func (swdb *SingleWriterDB) AdjustStock(ctx context.Context, itemID string, adjustment int, expectedVersion int) error {
-	swdb.mu.Lock()
-	defer swdb.mu.Unlock()
+	defer swdb.lockWriter(ctx, "adjust_stock")()
 
 	query := `
 		UPDATE inventory_items
@@ -239,7 +785,7 @@ func (swdb *SingleWriterDB) AdjustStock(ctx context.Context, itemID string, adju
 		WHERE id = ? AND version = ? AND (quantity + ?) >= 0
 	`
 
-	result, err := swdb.db.ExecContext(ctx, query,
+	result, err := swdb.conn(ctx).ExecContext(ctx, query,
 		adjustment,
 		time.Now().UTC().Format(time.RFC3339),
 		itemID, expectedVersion,
@@ -262,10 +808,41 @@ func (swdb *SingleWriterDB) AdjustStock(ctx context.Context, itemID string, adju
 	return nil
 }
 
+// ForceSetStock overwrites an item's quantity and reserved counters (manual correction)
+// with optimistic locking. Store reservations are left untouched.
+func (swdb *SingleWriterDB) ForceSetStock(ctx context.Context, itemID string, quantity, reserved int, expectedVersion int) error {
+	defer swdb.lockWriter(ctx, "force_set_stock")()
+
+	query := `
+		UPDATE inventory_items
+		SET quantity = ?, reserved = ?, available = ?, version = version + 1, updated_at = ?
+		WHERE id = ? AND version = ?
+	`
+
+	result, err := swdb.conn(ctx).ExecContext(ctx, query,
+		quantity, reserved, quantity-reserved,
+		time.Now().UTC().Format(time.RFC3339),
+		itemID, expectedVersion,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to force-set stock: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrOptimisticLockFailed
+	}
+
+	return nil
+}
+
 // ReserveStock reserves stock with optimistic locking
 func (swdb *SingleWriterDB) ReserveStock(ctx context.Context, itemID string, quantity int, expectedVersion int) error {
-	swdb.mu.Lock()
-	defer swdb.mu.Unlock()
+	defer swdb.lockWriter(ctx, "reserve_stock")()
 
 	query := `
 		UPDATE inventory_items
@@ -276,7 +853,7 @@ func (swdb *SingleWriterDB) ReserveStock(ctx context.Context, itemID string, qua
 		WHERE id = ? AND version = ? AND (quantity - reserved - ?) >= 0
 	`
 
-	result, err := swdb.db.ExecContext(ctx, query,
+	result, err := swdb.conn(ctx).ExecContext(ctx, query,
 		quantity,
 		quantity,
 		time.Now().UTC().Format(time.RFC3339),
@@ -302,8 +879,7 @@ func (swdb *SingleWriterDB) ReserveStock(ctx context.Context, itemID string, qua
 
 // ReleaseStock releases reserved stock with optimistic locking
 func (swdb *SingleWriterDB) ReleaseStock(ctx context.Context, itemID string, quantity int, expectedVersion int) error {
-	swdb.mu.Lock()
-	defer swdb.mu.Unlock()
+	defer swdb.lockWriter(ctx, "release_stock")()
 
 	query := `
 		UPDATE inventory_items
@@ -314,7 +890,7 @@ func (swdb *SingleWriterDB) ReleaseStock(ctx context.Context, itemID string, qua
 		WHERE id = ? AND version = ? AND reserved >= ?
 	`
 
-	result, err := swdb.db.ExecContext(ctx, query,
+	result, err := swdb.conn(ctx).ExecContext(ctx, query,
 		quantity,
 		quantity,
 		time.Now().UTC().Format(time.RFC3339),
@@ -338,36 +914,99 @@ func (swdb *SingleWriterDB) ReleaseStock(ctx context.Context, itemID string, qua
 	return nil
 }
 
-// DeleteItem deletes an inventory item
-func (swdb *SingleWriterDB) DeleteItem(ctx context.Context, itemID string) error {
-	swdb.mu.Lock()
-	defer swdb.mu.Unlock()
+// CommitStock turns reserved stock into a sale with optimistic locking: reserved and
+// quantity drop by the same amount in one statement, so available is unchanged
+func (swdb *SingleWriterDB) CommitStock(ctx context.Context, itemID string, quantity int, expectedVersion int) error {
+	defer swdb.lockWriter(ctx, "commit_stock")()
+
+	query := `
+		UPDATE inventory_items
+		SET quantity = quantity - ?,
+		    reserved = reserved - ?,
+		    version = version + 1,
+		    updated_at = ?
+		WHERE id = ? AND version = ? AND reserved >= ?
+	`
 
-	query := `DELETE FROM inventory_items WHERE id = ?`
+	result, err := swdb.conn(ctx).ExecContext(ctx, query,
+		quantity,
+		quantity,
+		time.Now().UTC().Format(time.RFC3339),
+		itemID, expectedVersion,
+		quantity,
+	)
 
-	_, err := swdb.db.ExecContext(ctx, query, itemID)
 	if err != nil {
-		return fmt.Errorf("failed to delete item: %w", err)
+		return fmt.Errorf("failed to commit stock: %w", err)
 	}
 
-	return nil
-}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
 
-// GetItem retrieves an item by ID (read-only, no lock needed)
-func (swdb *SingleWriterDB) GetItem(ctx context.Context, itemID string) (*InventoryItem, error) {
+	if rowsAffected == 0 {
+		return ErrOptimisticLockFailed
+	}
+
+	return nil
+}
+
+// DeleteItem soft-deletes an inventory item: the row keeps its stock, reservations and
+// history, and deleted_at hides it from the Query Service listings until RestoreItem
+func (swdb *SingleWriterDB) DeleteItem(ctx context.Context, itemID string, expectedVersion int) error {
+	defer swdb.lockWriter(ctx, "delete_item")()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	return swdb.setItemDeletedAt(ctx, itemID, expectedVersion, now, now)
+}
+
+// RestoreItem undoes DeleteItem
+func (swdb *SingleWriterDB) RestoreItem(ctx context.Context, itemID string, expectedVersion int) error {
+	defer swdb.lockWriter(ctx, "restore_item")()
+
+	return swdb.setItemDeletedAt(ctx, itemID, expectedVersion, nil, time.Now().UTC().Format(time.RFC3339))
+}
+
+// setItemDeletedAt sets deleted_at (nil to clear it) as a versioned change of the item.
+// The caller holds the writer lock.
+func (swdb *SingleWriterDB) setItemDeletedAt(ctx context.Context, itemID string, expectedVersion int, deletedAt interface{}, now string) error {
+	result, err := swdb.conn(ctx).ExecContext(ctx, `
+		UPDATE inventory_items
+		SET deleted_at = ?,
+		    version = version + 1,
+		    updated_at = ?
+		WHERE id = ? AND version = ?
+	`, deletedAt, now, itemID, expectedVersion)
+	if err != nil {
+		return fmt.Errorf("failed to update item deletion: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrOptimisticLockFailed
+	}
+	return nil
+}
+
+// GetItem retrieves an item by ID (read-only, no lock needed)
+func (swdb *SingleWriterDB) GetItem(ctx context.Context, itemID string) (*InventoryItem, error) {
 	query := `
-		SELECT id, sku, name, description, quantity, reserved, available, version, created_at, updated_at
+		SELECT id, tenant_id, sku, name, description, quantity, reserved, available, version, created_at, updated_at, deleted_at
 		FROM inventory_items
 		WHERE id = ?
 	`
 
 	var item InventoryItem
 	var createdAtStr, updatedAtStr string
+	var deletedAt sql.NullString
 
-	err := swdb.db.QueryRowContext(ctx, query, itemID).Scan(
-		&item.ID, &item.SKU, &item.Name, &item.Description,
+	err := swdb.conn(ctx).QueryRowContext(ctx, query, itemID).Scan(
+		&item.ID, &item.TenantID, &item.SKU, &item.Name, &item.Description,
 		&item.Quantity, &item.Reserved, &item.Available, &item.Version,
-		&createdAtStr, &updatedAtStr,
+		&createdAtStr, &updatedAtStr, &deletedAt,
 	)
 
 	if err != nil {
@@ -379,28 +1018,75 @@ func (swdb *SingleWriterDB) GetItem(ctx context.Context, itemID string) (*Invent
 
 	item.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
 	item.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAtStr)
+	if deletedAt.Valid {
+		at, _ := time.Parse(time.RFC3339, deletedAt.String)
+		item.DeletedAt = &at
+	}
 
 	return &item, nil
 }
 
+// FindItemIDBySKU returns the ID of the item of the ctx tenant with the given SKU (read-only)
+func (swdb *SingleWriterDB) FindItemIDBySKU(ctx context.Context, sku string) (string, error) {
+	var id string
+	err := swdb.conn(ctx).QueryRowContext(ctx, `SELECT id FROM inventory_items WHERE tenant_id = ? AND sku = ?`,
+		TenantFromContext(ctx), sku).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrItemNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to find item by SKU: %w", err)
+	}
+	return id, nil
+}
+
+// FindStoreIDByCode returns the ID of the store of the ctx tenant with the given code (read-only)
+func (swdb *SingleWriterDB) FindStoreIDByCode(ctx context.Context, code string) (string, error) {
+	var id string
+	err := swdb.conn(ctx).QueryRowContext(ctx, `SELECT id FROM stores WHERE tenant_id = ? AND code = ?`,
+		TenantFromContext(ctx), code).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrStoreNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to find store by code: %w", err)
+	}
+	return id, nil
+}
+
+// GetActiveStoreReservedQuantity returns the stock a store holds in active reservations of an item (read-only)
+func (swdb *SingleWriterDB) GetActiveStoreReservedQuantity(ctx context.Context, storeID, itemID string) (int, error) {
+	var reserved int
+	err := swdb.conn(ctx).QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(quantity), 0) FROM store_reservations
+		WHERE store_id = ? AND item_id = ? AND status = 'active'
+	`, storeID, itemID).Scan(&reserved)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum store reservations: %w", err)
+	}
+	return reserved, nil
+}
+
 // CreateStore creates a new store
 func (swdb *SingleWriterDB) CreateStore(ctx context.Context, store *Store) error {
-	swdb.mu.Lock()
-	defer swdb.mu.Unlock()
+	defer swdb.lockWriter(ctx, "create_store")()
 
 	query := `
-		INSERT INTO stores (id, name, location, code, active, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO stores (id, tenant_id, name, location, code, active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
+	if store.TenantID == "" {
+		store.TenantID = TenantFromContext(ctx)
+	}
 	now := time.Now().UTC()
 	active := 0
 	if store.Active {
 		active = 1
 	}
 
-	_, err := swdb.db.ExecContext(ctx, query,
-		store.ID, store.Name, store.Location, store.Code, active,
+	_, err := swdb.conn(ctx).ExecContext(ctx, query,
+		store.ID, store.TenantID, store.Name, store.Location, store.Code, active,
 		now.Format(time.RFC3339), now.Format(time.RFC3339),
 	)
 
@@ -414,7 +1100,7 @@ func (swdb *SingleWriterDB) CreateStore(ctx context.Context, store *Store) error
 // GetStore retrieves a store by ID
 func (swdb *SingleWriterDB) GetStore(ctx context.Context, storeID string) (*Store, error) {
 	query := `
-		SELECT id, name, location, code, active, created_at, updated_at
+		SELECT id, tenant_id, name, location, code, active, created_at, updated_at
 		FROM stores
 		WHERE id = ?
 	`
@@ -423,8 +1109,8 @@ func (swdb *SingleWriterDB) GetStore(ctx context.Context, storeID string) (*Stor
 	var createdAtStr, updatedAtStr string
 	var active int
 
-	err := swdb.db.QueryRowContext(ctx, query, storeID).Scan(
-		&store.ID, &store.Name, &store.Location, &store.Code, &active,
+	err := swdb.conn(ctx).QueryRowContext(ctx, query, storeID).Scan(
+		&store.ID, &store.TenantID, &store.Name, &store.Location, &store.Code, &active,
 		&createdAtStr, &updatedAtStr,
 	)
 
@@ -444,8 +1130,7 @@ func (swdb *SingleWriterDB) GetStore(ctx context.Context, storeID string) (*Stor
 
 // CreateStoreReservation creates a reservation of inventory by a store
 func (swdb *SingleWriterDB) CreateStoreReservation(ctx context.Context, reservation *StoreReservation) error {
-	swdb.mu.Lock()
-	defer swdb.mu.Unlock()
+	defer swdb.lockWriter(ctx, "create_store_reservation")()
 
 	query := `
 		INSERT INTO store_reservations (id, store_id, item_id, quantity, status, reserved_at, expires_at, created_at, updated_at)
@@ -453,12 +1138,12 @@ func (swdb *SingleWriterDB) CreateStoreReservation(ctx context.Context, reservat
 	`
 
 	now := time.Now().UTC()
-	var expiresAtStr string
+	var expiresAtStr sql.NullString
 	if reservation.ExpiresAt != nil {
-		expiresAtStr = reservation.ExpiresAt.Format(time.RFC3339)
+		expiresAtStr = sql.NullString{String: reservation.ExpiresAt.Format(time.RFC3339), Valid: true}
 	}
 
-	_, err := swdb.db.ExecContext(ctx, query,
+	_, err := swdb.conn(ctx).ExecContext(ctx, query,
 		reservation.ID, reservation.StoreID, reservation.ItemID, reservation.Quantity,
 		reservation.Status, reservation.ReservedAt.Format(time.RFC3339),
 		expiresAtStr, now.Format(time.RFC3339), now.Format(time.RFC3339),
@@ -480,7 +1165,7 @@ func (swdb *SingleWriterDB) GetStoreReservations(ctx context.Context, storeID st
 		ORDER BY reserved_at DESC
 	`
 
-	rows, err := swdb.db.QueryContext(ctx, query, storeID)
+	rows, err := swdb.conn(ctx).QueryContext(ctx, query, storeID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get store reservations: %w", err)
 	}
@@ -521,8 +1206,516 @@ func (swdb *SingleWriterDB) GetStoreReservations(ctx context.Context, storeID st
 	return reservations, nil
 }
 
+// UpdateStore updates a store's name, location and active flag
+func (swdb *SingleWriterDB) UpdateStore(ctx context.Context, store *Store) error {
+	defer swdb.lockWriter(ctx, "update_store")()
+
+	query := `
+		UPDATE stores
+		SET name = ?, location = ?, active = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	active := 0
+	if store.Active {
+		active = 1
+	}
+
+	result, err := swdb.conn(ctx).ExecContext(ctx, query,
+		store.Name, store.Location, active,
+		time.Now().UTC().Format(time.RFC3339),
+		store.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update store: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrStoreNotFound
+	}
+
+	return nil
+}
+
+// DeleteStore deletes a store (its reservations are removed by ON DELETE CASCADE)
+func (swdb *SingleWriterDB) DeleteStore(ctx context.Context, storeID string) error {
+	defer swdb.lockWriter(ctx, "delete_store")()
+
+	query := `DELETE FROM stores WHERE id = ?`
+
+	_, err := swdb.conn(ctx).ExecContext(ctx, query, storeID)
+	if err != nil {
+		return fmt.Errorf("failed to delete store: %w", err)
+	}
+
+	return nil
+}
+
+// ReserveStockForStore reserves stock for an item and records the store
+// reservation in a single transaction, so item totals and per-store
+// reservations never diverge
+func (swdb *SingleWriterDB) ReserveStockForStore(ctx context.Context, reservation *StoreReservation, expectedVersion int) error {
+	defer swdb.lockWriter(ctx, "reserve_stock_for_store")()
+
+	tx, err := swdb.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE inventory_items
+		SET reserved = reserved + ?,
+		    available = quantity - (reserved + ?),
+		    version = version + 1,
+		    updated_at = ?
+		WHERE id = ? AND version = ? AND (quantity - reserved - ?) >= 0
+	`,
+		reservation.Quantity,
+		reservation.Quantity,
+		now,
+		reservation.ItemID, expectedVersion,
+		reservation.Quantity,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to reserve stock: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrOptimisticLockFailed
+	}
+
+	var expiresAtStr sql.NullString
+	if reservation.ExpiresAt != nil {
+		expiresAtStr = sql.NullString{String: reservation.ExpiresAt.Format(time.RFC3339), Valid: true}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO store_reservations (id, store_id, item_id, quantity, status, reserved_at, expires_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, 'active', ?, ?, ?, ?)
+	`,
+		reservation.ID, reservation.StoreID, reservation.ItemID, reservation.Quantity,
+		reservation.ReservedAt.UTC().Format(time.RFC3339), expiresAtStr, now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create store reservation: %w", err)
+	}
+
+	if err := commitTx(tx, "reserve_stock_for_store"); err != nil {
+		return fmt.Errorf("failed to commit store reservation: %w", err)
+	}
+
+	return nil
+}
+
+// ReleaseStockForStore releases stock reserved by a store in a single transaction.
+// Active reservations for the store/item are released oldest first; when the
+// quantity only covers part of a reservation, that reservation is reduced and
+// the released part is recorded as a separate 'released' row.
+func (swdb *SingleWriterDB) ReleaseStockForStore(ctx context.Context, storeID, itemID string, quantity int, expectedVersion int) error {
+	defer swdb.lockWriter(ctx, "release_stock_for_store")()
+
+	tx, err := swdb.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, quantity, reserved_at
+		FROM store_reservations
+		WHERE store_id = ? AND item_id = ? AND status = 'active'
+		ORDER BY reserved_at ASC, created_at ASC`+swdb.dialect.forUpdate(), storeID, itemID)
+	if err != nil {
+		return fmt.Errorf("failed to get store reservations: %w", err)
+	}
+
+	type activeReservation struct {
+		id         string
+		quantity   int
+		reservedAt string
+	}
+	var active []activeReservation
+	reservedByStore := 0
+	for rows.Next() {
+		var res activeReservation
+		if err := rows.Scan(&res.id, &res.quantity, &res.reservedAt); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan reservation: %w", err)
+		}
+		active = append(active, res)
+		reservedByStore += res.quantity
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating reservations: %w", err)
+	}
+
+	if reservedByStore < quantity {
+		return ErrInsufficientStoreReservation
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE inventory_items
+		SET reserved = reserved - ?,
+		    available = quantity - (reserved - ?),
+		    version = version + 1,
+		    updated_at = ?
+		WHERE id = ? AND version = ? AND reserved >= ?
+	`,
+		quantity,
+		quantity,
+		now,
+		itemID, expectedVersion,
+		quantity,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release stock: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrOptimisticLockFailed
+	}
+
+	remaining := quantity
+	for _, res := range active {
+		if remaining == 0 {
+			break
+		}
+
+		if res.quantity <= remaining {
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE store_reservations
+				SET status = 'released', released_at = ?, updated_at = ?
+				WHERE id = ?
+			`, now, now, res.id); err != nil {
+				return fmt.Errorf("failed to release store reservation: %w", err)
+			}
+			remaining -= res.quantity
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE store_reservations
+			SET quantity = quantity - ?, updated_at = ?
+			WHERE id = ?
+		`, remaining, now, res.id); err != nil {
+			return fmt.Errorf("failed to reduce store reservation: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO store_reservations (id, store_id, item_id, quantity, status, reserved_at, released_at, created_at, updated_at)
+			VALUES (?, ?, ?, ?, 'released', ?, ?, ?, ?)
+		`, uuid.New().String(), storeID, itemID, remaining, res.reservedAt, now, now, now); err != nil {
+			return fmt.Errorf("failed to record partial release: %w", err)
+		}
+		remaining = 0
+	}
+
+	if err := commitTx(tx, "release_stock_for_store"); err != nil {
+		return fmt.Errorf("failed to commit store release: %w", err)
+	}
+
+	return nil
+}
+
+// RecordStockReceipt adds a cost layer for stock received into the inventory.
+// unitCost may be nil when the receipt has no known cost.
+func (swdb *SingleWriterDB) RecordStockReceipt(ctx context.Context, itemID string, quantity int, unitCost *float64, receivedAt time.Time) error {
+	defer swdb.lockWriter(ctx, "record_stock_receipt")()
+
+	query := `
+		INSERT INTO cost_layers (id, item_id, quantity_received, quantity_remaining, unit_cost, received_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	var cost sql.NullFloat64
+	if unitCost != nil {
+		cost = sql.NullFloat64{Float64: *unitCost, Valid: true}
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err := swdb.conn(ctx).ExecContext(ctx, query,
+		uuid.New().String(), itemID, quantity, quantity, cost,
+		receivedAt.UTC().Format(time.RFC3339), now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record stock receipt: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeCostLayers removes quantity from the item's cost layers, oldest first.
+// Stock without layers (recorded before cost tracking existed) is not tracked,
+// so consuming more than the layers hold simply empties them.
+func (swdb *SingleWriterDB) ConsumeCostLayers(ctx context.Context, itemID string, quantity int) error {
+	defer swdb.lockWriter(ctx, "consume_cost_layers")()
+
+	tx, err := swdb.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, quantity_remaining
+		FROM cost_layers
+		WHERE item_id = ? AND quantity_remaining > 0
+		ORDER BY received_at ASC, created_at ASC`+swdb.dialect.forUpdate(), itemID)
+	if err != nil {
+		return fmt.Errorf("failed to get cost layers: %w", err)
+	}
+
+	type openLayer struct {
+		id        string
+		remaining int
+	}
+	var layers []openLayer
+	for rows.Next() {
+		var layer openLayer
+		if err := rows.Scan(&layer.id, &layer.remaining); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan cost layer: %w", err)
+		}
+		layers = append(layers, layer)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating cost layers: %w", err)
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	remaining := quantity
+	for _, layer := range layers {
+		if remaining == 0 {
+			break
+		}
+		consumed := layer.remaining
+		if consumed > remaining {
+			consumed = remaining
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE cost_layers
+			SET quantity_remaining = quantity_remaining - ?, updated_at = ?
+			WHERE id = ?
+		`, consumed, now, layer.id); err != nil {
+			return fmt.Errorf("failed to consume cost layer: %w", err)
+		}
+		remaining -= consumed
+	}
+
+	if err := commitTx(tx, "consume_cost_layers"); err != nil {
+		return fmt.Errorf("failed to commit cost layer consumption: %w", err)
+	}
+
+	return nil
+}
+
+// RecordStockMovement appends a stock movement to the item's history
+func (swdb *SingleWriterDB) RecordStockMovement(ctx context.Context, movement *StockMovement) error {
+	defer swdb.lockWriter(ctx, "record_stock_movement")()
+
+	query := `
+		INSERT INTO stock_movements (id, item_id, store_id, movement_type, quantity_change, reserved_change,
+			quantity_after, reserved_after, available_after, occurred_at, created_at, actor, request_id, reason, reference)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	if movement.ID == "" {
+		movement.ID = uuid.New().String()
+	}
+	var storeID sql.NullString
+	if movement.StoreID != "" {
+		storeID = sql.NullString{String: movement.StoreID, Valid: true}
+	}
+
+	_, err := swdb.conn(ctx).ExecContext(ctx, query,
+		movement.ID, movement.ItemID, storeID, movement.MovementType,
+		movement.QuantityChange, movement.ReservedChange,
+		movement.QuantityAfter, movement.ReservedAfter, movement.AvailableAfter,
+		movement.OccurredAt.UTC().Format(time.RFC3339), time.Now().UTC().Format(time.RFC3339),
+		nullString(movement.Actor), nullString(movement.RequestID), nullString(movement.Reason),
+		nullString(movement.Reference),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record stock movement: %w", err)
+	}
+
+	return nil
+}
+
+// RecordActivity appends an entry to the activity log
+func (swdb *SingleWriterDB) RecordActivity(ctx context.Context, entry *ActivityEntry) error {
+	defer swdb.lockWriter(ctx, "record_activity")()
+
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	if entry.ProcessedAt.IsZero() {
+		entry.ProcessedAt = time.Now()
+	}
+	if entry.OccurredAt.IsZero() {
+		entry.OccurredAt = entry.ProcessedAt
+	}
+	if entry.TenantID == "" {
+		entry.TenantID = TenantFromContext(ctx)
+	}
+
+	_, err := swdb.conn(ctx).ExecContext(ctx, `
+		INSERT INTO activity_log (id, event_id, event_type, item_id, store_id, sku, quantity,
+			actor, request_id, tenant_id, outcome, error, occurred_at, processed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		entry.ID, nullString(entry.EventID), entry.EventType, nullString(entry.ItemID), nullString(entry.StoreID),
+		nullString(entry.SKU), entry.Quantity, nullString(entry.Actor), nullString(entry.RequestID),
+		entry.TenantID, entry.Outcome, nullString(entry.Error),
+		entry.OccurredAt.UTC().Format(time.RFC3339), entry.ProcessedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record activity: %w", err)
+	}
+
+	return nil
+}
+
+// nullString stores empty strings as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// EnqueueWaitlist adds a reservation to the item's waitlist.
+// Enqueuing an entry that already exists is a no-op, so redelivered events are safe.
+func (swdb *SingleWriterDB) EnqueueWaitlist(ctx context.Context, entry *WaitlistEntry) error {
+	defer swdb.lockWriter(ctx, "enqueue_waitlist")()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err := swdb.conn(ctx).ExecContext(ctx, `
+		INSERT INTO reservation_waitlist (id, item_id, quantity, status, requested_at, created_at, updated_at)
+		VALUES (?, ?, ?, 'waiting', ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING
+	`,
+		entry.ID, entry.ItemID, entry.Quantity,
+		entry.RequestedAt.UTC().Format(time.RFC3339), now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue waitlist entry: %w", err)
+	}
+
+	return nil
+}
+
+// FulfillWaitlist reserves stock for the item's waiting entries in FIFO order, in a
+// single transaction. It stops at the first entry that does not fit in the available
+// stock so later (possibly smaller) requests never jump the queue. The fulfilled
+// entries are returned in the order they were reserved.
+func (swdb *SingleWriterDB) FulfillWaitlist(ctx context.Context, itemID string) ([]WaitlistEntry, error) {
+	defer swdb.lockWriter(ctx, "fulfill_waitlist")()
+
+	tx, err := swdb.begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var available int
+	err = tx.QueryRowContext(ctx, `SELECT quantity - reserved FROM inventory_items WHERE id = ?`+swdb.dialect.forUpdate(), itemID).Scan(&available)
+	if err == sql.ErrNoRows {
+		return nil, ErrItemNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, quantity, requested_at
+		FROM reservation_waitlist
+		WHERE item_id = ? AND status = 'waiting'
+		ORDER BY requested_at ASC, created_at ASC
+	`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list waitlist: %w", err)
+	}
+
+	var fulfilled []WaitlistEntry
+	reserved := 0
+	for rows.Next() {
+		var entry WaitlistEntry
+		var requestedAtStr string
+		if err := rows.Scan(&entry.ID, &entry.Quantity, &requestedAtStr); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan waitlist entry: %w", err)
+		}
+		if entry.Quantity > available-reserved {
+			break
+		}
+		entry.ItemID = itemID
+		entry.Status = "fulfilled"
+		entry.RequestedAt, _ = time.Parse(time.RFC3339, requestedAtStr)
+		reserved += entry.Quantity
+		fulfilled = append(fulfilled, entry)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating waitlist: %w", err)
+	}
+
+	if len(fulfilled) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+	nowStr := now.Format(time.RFC3339)
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE inventory_items
+		SET reserved = reserved + ?,
+		    available = quantity - (reserved + ?),
+		    version = version + 1,
+		    updated_at = ?
+		WHERE id = ?
+	`, reserved, reserved, nowStr, itemID); err != nil {
+		return nil, fmt.Errorf("failed to reserve waitlisted stock: %w", err)
+	}
+
+	for i := range fulfilled {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE reservation_waitlist
+			SET status = 'fulfilled', fulfilled_at = ?, updated_at = ?
+			WHERE id = ?
+		`, nowStr, nowStr, fulfilled[i].ID); err != nil {
+			return nil, fmt.Errorf("failed to update waitlist entry: %w", err)
+		}
+		fulfilled[i].FulfilledAt = &now
+	}
+
+	if err := commitTx(tx, "fulfill_waitlist"); err != nil {
+		return nil, fmt.Errorf("failed to commit waitlist fulfillment: %w", err)
+	}
+
+	return fulfilled, nil
+}
+
 var (
-	ErrItemNotFound         = errors.New("item not found")
-	ErrStoreNotFound        = errors.New("store not found")
-	ErrOptimisticLockFailed = errors.New("optimistic lock failed - version mismatch or constraint violation")
+	ErrItemNotFound                 = errors.New("item not found")
+	ErrStoreNotFound                = errors.New("store not found")
+	ErrOptimisticLockFailed         = errors.New("optimistic lock failed - version mismatch or constraint violation")
+	ErrInsufficientStoreReservation = errors.New("store has not reserved enough stock for this item")
 )
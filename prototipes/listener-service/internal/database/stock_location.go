@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInsufficientLocationStock is returned when a location change would leave the
+// location with negative stock or with more reserved than it holds
+var ErrInsufficientLocationStock = errors.New("not enough stock at this location")
+
+// StockLocation is the stock of an item at one location (warehouse or store). The
+// item row keeps the totals; the stock of the item not held at any location is the
+// difference.
+type StockLocation struct {
+	ItemID    string
+	Location  string
+	Quantity  int
+	Reserved  int
+	UpdatedAt time.Time
+}
+
+// GetStockLocation returns the stock of the item at location; a location the item has
+// never had stock at is returned empty (read-only, no lock needed)
+func (swdb *SingleWriterDB) GetStockLocation(ctx context.Context, itemID, location string) (*StockLocation, error) {
+	stock := &StockLocation{ItemID: itemID, Location: location}
+	var updatedAt string
+	err := swdb.conn(ctx).QueryRowContext(ctx,
+		`SELECT quantity, reserved, updated_at FROM stock_locations WHERE item_id = ? AND location = ?`,
+		itemID, location,
+	).Scan(&stock.Quantity, &stock.Reserved, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return stock, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock location: %w", err)
+	}
+	stock.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	return stock, nil
+}
+
+// ChangeLocationStock moves the quantity and the reserved stock of an item at location
+// by quantityDelta and reservedDelta, and the item totals by the same amounts, in a
+// single transaction with optimistic locking on the item. The location row is created
+// on its first receipt.
+func (swdb *SingleWriterDB) ChangeLocationStock(ctx context.Context, itemID, location string, quantityDelta, reservedDelta int, expectedVersion int) error {
+	defer swdb.lockWriter(ctx, "change_location_stock")()
+
+	tx, err := swdb.begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var quantity, reserved int
+	err = tx.QueryRowContext(ctx,
+		`SELECT quantity, reserved FROM stock_locations WHERE item_id = ? AND location = ?`+swdb.dialect.forUpdate(),
+		itemID, location,
+	).Scan(&quantity, &reserved)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get stock location: %w", err)
+	}
+	quantity += quantityDelta
+	reserved += reservedDelta
+	if quantity < 0 || reserved < 0 || reserved > quantity {
+		return fmt.Errorf("%w: %s would hold %d with %d reserved", ErrInsufficientLocationStock, location, quantity, reserved)
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	result, err := tx.ExecContext(ctx, `
+		UPDATE inventory_items
+		SET quantity = quantity + ?,
+		    reserved = reserved + ?,
+		    available = (quantity + ?) - (reserved + ?),
+		    version = version + 1,
+		    updated_at = ?
+		WHERE id = ? AND version = ?
+		  AND (quantity + ?) >= (reserved + ?) AND (reserved + ?) >= 0
+	`,
+		quantityDelta, reservedDelta,
+		quantityDelta, reservedDelta,
+		now,
+		itemID, expectedVersion,
+		quantityDelta, reservedDelta, reservedDelta,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to change item stock: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrOptimisticLockFailed
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO stock_locations (item_id, location, quantity, reserved, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (item_id, location) DO UPDATE SET
+			quantity = excluded.quantity,
+			reserved = excluded.reserved,
+			updated_at = excluded.updated_at
+	`, itemID, location, quantity, reserved, now); err != nil {
+		return fmt.Errorf("failed to save stock location: %w", err)
+	}
+
+	if err := commitTx(tx, "change_location_stock"); err != nil {
+		return fmt.Errorf("failed to commit location stock change: %w", err)
+	}
+	return nil
+}
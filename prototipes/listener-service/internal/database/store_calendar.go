@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// StoreCalendar is the opening hours and holidays of a store. Hours and Holidays are
+// kept as the JSON arrays of the StoreCalendarUpdated event, which the Query Service
+// decodes when it serves the calendar.
+type StoreCalendar struct {
+	StoreID   string
+	Timezone  string
+	Hours     string // [{"day":"monday","opens":"09:00","closes":"18:00"}, ...]
+	Holidays  string // [{"date":"2024-12-25","name":"Navidad"}, ...]
+	UpdatedAt time.Time
+}
+
+// SaveStoreCalendar sets the calendar of a store, replacing the previous one
+func (swdb *SingleWriterDB) SaveStoreCalendar(ctx context.Context, calendar *StoreCalendar) error {
+	defer swdb.lockWriter(ctx, "save_store_calendar")()
+
+	var exists int
+	err := swdb.conn(ctx).QueryRowContext(ctx, `SELECT 1 FROM stores WHERE id = ?`, calendar.StoreID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrStoreNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get store: %w", err)
+	}
+
+	_, err = swdb.conn(ctx).ExecContext(ctx, `
+		INSERT INTO store_calendars (store_id, timezone, hours, holidays, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(store_id) DO UPDATE SET timezone = excluded.timezone, hours = excluded.hours,
+			holidays = excluded.holidays, updated_at = excluded.updated_at
+	`, calendar.StoreID, calendar.Timezone, calendar.Hours, calendar.Holidays, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("failed to save store calendar: %w", err)
+	}
+	return nil
+}
+
+// DeleteStoreCalendar removes the calendar of a store; a store without one is always
+// open. Removing a calendar that does not exist is not an error.
+func (swdb *SingleWriterDB) DeleteStoreCalendar(ctx context.Context, storeID string) error {
+	defer swdb.lockWriter(ctx, "delete_store_calendar")()
+
+	if _, err := swdb.conn(ctx).ExecContext(ctx, `DELETE FROM store_calendars WHERE store_id = ?`, storeID); err != nil {
+		return fmt.Errorf("failed to delete store calendar: %w", err)
+	}
+	return nil
+}
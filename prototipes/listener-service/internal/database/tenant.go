@@ -0,0 +1,27 @@
+package database
+
+import "context"
+
+// DefaultTenant owns the rows of events published without a tenant, including every
+// row written before the read model was multi-tenant
+const DefaultTenant = "default"
+
+// tenantKey is the context key for the tenant (retail brand) of an event
+type tenantKey struct{}
+
+// WithTenant returns a context carrying the tenant of the event being processed
+// (taken from the Kafka headers), so the rows it creates belong to that tenant
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	if tenantID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant set by WithTenant, or DefaultTenant
+func TenantFromContext(ctx context.Context) string {
+	if tenantID, ok := ctx.Value(tenantKey{}).(string); ok {
+		return tenantID
+	}
+	return DefaultTenant
+}
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"listener-service/internal/config"
+
+	"go.uber.org/zap"
+)
+
+// WriterDB is the read model as the listener uses it: the writes applied by the event
+// processor plus the lookups they depend on. SingleWriterDB implements it on SQLite
+// and on PostgreSQL; Open picks the backend from DB_DRIVER.
+type WriterDB interface {
+	// Items
+	CreateItem(ctx context.Context, item *InventoryItem) error
+	UpdateItem(ctx context.Context, item *InventoryItem) error
+	// PatchItem keeps the current value of the nil fields
+	PatchItem(ctx context.Context, itemID string, name, description *string, expectedVersion int) error
+	DeleteItem(ctx context.Context, itemID string, expectedVersion int) error // Soft delete
+	RestoreItem(ctx context.Context, itemID string, expectedVersion int) error
+	GetItem(ctx context.Context, itemID string) (*InventoryItem, error)
+	FindItemIDBySKU(ctx context.Context, sku string) (string, error)
+
+	// Stock; expectedVersion is checked against the item's version (optimistic locking)
+	AdjustStock(ctx context.Context, itemID string, adjustment int, expectedVersion int) error
+	ForceSetStock(ctx context.Context, itemID string, quantity, reserved int, expectedVersion int) error
+	ReserveStock(ctx context.Context, itemID string, quantity int, expectedVersion int) error
+	ReleaseStock(ctx context.Context, itemID string, quantity int, expectedVersion int) error
+	CommitStock(ctx context.Context, itemID string, quantity int, expectedVersion int) error
+	ChangeLocationStock(ctx context.Context, itemID, location string, quantityDelta, reservedDelta int, expectedVersion int) error
+	GetStockLocation(ctx context.Context, itemID, location string) (*StockLocation, error)
+	RecordStockReceipt(ctx context.Context, itemID string, quantity int, unitCost *float64, receivedAt time.Time) error
+	ConsumeCostLayers(ctx context.Context, itemID string, quantity int) error
+	RecordStockMovement(ctx context.Context, movement *StockMovement) error
+	EnqueueWaitlist(ctx context.Context, entry *WaitlistEntry) error
+	FulfillWaitlist(ctx context.Context, itemID string) ([]WaitlistEntry, error)
+	SaveItemRelation(ctx context.Context, itemID, relatedItemID, relationType string) error
+	DeleteItemRelation(ctx context.Context, itemID, relatedItemID, relationType string) error
+	AddItemImage(ctx context.Context, image *ItemImage, expectedVersion int) error
+	RemoveItemImage(ctx context.Context, itemID, imageID string, expectedVersion int) error
+	AddItemIdentifier(ctx context.Context, identifier *ItemIdentifier, expectedVersion int) error
+	RemoveItemIdentifier(ctx context.Context, itemID, code string, expectedVersion int) error
+
+	// Stores and store reservations
+	CreateStore(ctx context.Context, store *Store) error
+	UpdateStore(ctx context.Context, store *Store) error
+	DeleteStore(ctx context.Context, storeID string) error
+	GetStore(ctx context.Context, storeID string) (*Store, error)
+	FindStoreIDByCode(ctx context.Context, code string) (string, error)
+	ReserveStockForStore(ctx context.Context, reservation *StoreReservation, expectedVersion int) error
+	ReleaseStockForStore(ctx context.Context, storeID, itemID string, quantity int, expectedVersion int) error
+	GetActiveStoreReservedQuantity(ctx context.Context, storeID, itemID string) (int, error)
+	GetStoreReservations(ctx context.Context, storeID string) ([]*StoreReservation, error)
+	SaveStoreCalendar(ctx context.Context, calendar *StoreCalendar) error
+	DeleteStoreCalendar(ctx context.Context, storeID string) error
+
+	// Suppliers and purchase orders
+	SaveSupplier(ctx context.Context, supplier *Supplier) error
+	DeleteSupplier(ctx context.Context, supplierID string) error
+	CreatePurchaseOrder(ctx context.Context, order *PurchaseOrder) error
+	ReceivePurchaseOrder(ctx context.Context, orderID, status string, lines []PurchaseOrderLine, receivedAt time.Time) error
+	SetPurchaseOrderStatus(ctx context.Context, orderID, status string, at time.Time) error
+	GetPurchaseOrderStatus(ctx context.Context, orderID string) (string, error)
+
+	// Activity log and replication role
+	RecordActivity(ctx context.Context, entry *ActivityEntry) error
+	GetReplicationRole(ctx context.Context) (string, time.Time, error)
+	SaveReplicationRole(ctx context.Context, role, region string, changedAt time.Time) error
+
+	// Deduplication: event ids already applied, so redelivered events are skipped
+	MarkEventProcessed(ctx context.Context, eventID, eventType string) (bool, error)
+	PruneProcessedEvents(ctx context.Context, before time.Time) (int64, error)
+
+	// Batching: Batch shares one transaction between the writes of many events,
+	// Savepoint isolates the writes of one of them
+	Batch(ctx context.Context, fn func(ctx context.Context) error) error
+	Savepoint(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// Rebuild: deletes everything the events wrote, before replaying them
+	Truncate(ctx context.Context) error
+
+	// Backups: online copy of the read model to a file, and restore of one (SQLite only)
+	Backup(ctx context.Context, path string) error
+	Restore(ctx context.Context, path string) error
+
+	// Monitoring
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Ping() error
+	Driver() string
+	Close() error
+}
+
+var _ WriterDB = (*SingleWriterDB)(nil)
+
+// Open opens the read model for writing on the backend selected by DB_DRIVER
+func Open(cfg *config.Config, logger *zap.Logger) (WriterDB, error) {
+	switch cfg.DBDriver {
+	case DriverSQLite, "":
+		return NewSingleWriterDB(cfg, logger)
+	case DriverPostgres:
+		return NewPostgresDB(cfg, logger)
+	default:
+		return nil, fmt.Errorf("unsupported DB_DRIVER %q (expected %s or %s)", cfg.DBDriver, DriverSQLite, DriverPostgres)
+	}
+}
+
+// OpenReadOnly opens an existing read model without write access, for dry-run mode
+func OpenReadOnly(cfg *config.Config, logger *zap.Logger) (WriterDB, error) {
+	switch cfg.DBDriver {
+	case DriverSQLite, "":
+		return NewReadOnlyDB(cfg, logger)
+	case DriverPostgres:
+		return NewReadOnlyPostgresDB(cfg, logger)
+	default:
+		return nil, fmt.Errorf("unsupported DB_DRIVER %q (expected %s or %s)", cfg.DBDriver, DriverSQLite, DriverPostgres)
+	}
+}
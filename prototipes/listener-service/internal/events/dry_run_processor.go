@@ -0,0 +1,405 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"listener-service/internal/database"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// DryRunProcessor evaluates events against the current database state and logs
+// what the EventProcessor would do, without writing to SQLite or publishing
+// confirmations. It is used to validate a topic before pointing a real listener at it.
+//
+// The database is never modified, so consecutive events for the same aggregate are
+// each evaluated against the state before the run (e.g. two reservations that only
+// fit together are both reported as applicable).
+type DryRunProcessor struct {
+	db     database.WriterDB
+	logger *zap.Logger
+}
+
+// NewDryRunProcessor creates a new dry-run processor
+func NewDryRunProcessor(db database.WriterDB, logger *zap.Logger) *DryRunProcessor {
+	return &DryRunProcessor{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// dryRunEvent holds the fields used by any of the supported events
+type dryRunEvent struct {
+	ItemID        string `json:"itemId"`
+	RelatedItemID string `json:"relatedItemId"`
+	Relation      string `json:"relation"`
+	StoreID       string `json:"storeId"`
+	SupplierID    string `json:"supplierId"`
+	OrderID       string `json:"purchaseOrderId"`
+	ReservationID string `json:"reservationId"`
+	WaitlistID    string `json:"waitlistId"`
+	SKU           string `json:"sku"`
+	Code          string `json:"code"`
+	Quantity      int    `json:"quantity"`
+	Reserved      int    `json:"reserved"` // ManualCorrection only: absolute reserved value
+	Location      string `json:"location"` // Stock events on one location
+}
+
+// dryRunOutcome is what the EventProcessor would do with an event
+type dryRunOutcome struct {
+	action string
+	err    error // Why the event would fail; nil when it would be applied
+	fields []zap.Field
+}
+
+// ProcessEvent logs the outcome of an event. It always returns nil: a failing event
+// is the result being reported, so it must not be retried or sent to the DLQ.
+func (p *DryRunProcessor) ProcessEvent(ctx context.Context, eventType string, eventData []byte) error {
+	var event dryRunEvent
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		p.logger.Warn("[dry-run] Event would be rejected",
+			zap.String("event_type", eventType),
+			zap.Error(fmt.Errorf("failed to unmarshal event: %w", err)),
+		)
+		return nil
+	}
+
+	outcome := p.evaluate(ctx, eventType, event)
+	fields := append([]zap.Field{
+		zap.String("event_type", eventType),
+		zap.String("action", outcome.action),
+	}, outcome.fields...)
+
+	if outcome.err != nil {
+		p.logger.Warn("[dry-run] Event would fail", append(fields, zap.Error(outcome.err))...)
+		return nil
+	}
+	p.logger.Info("[dry-run] Event would be applied", fields...)
+	return nil
+}
+
+func (p *DryRunProcessor) evaluate(ctx context.Context, eventType string, event dryRunEvent) dryRunOutcome {
+	switch eventType {
+	case "InventoryItemCreated":
+		return p.evaluateItemCreated(ctx, event)
+	case "InventoryItemUpdated":
+		return p.evaluateItemChange(ctx, "update item", event)
+	case "InventoryItemPatched":
+		return p.evaluateItemChange(ctx, "patch item", event)
+	case "InventoryItemDeleted":
+		return p.evaluateItemChange(ctx, "delete item", event)
+	case "InventoryItemRestored":
+		return p.evaluateItemChange(ctx, "restore item", event)
+	case "ItemImageAdded":
+		return p.evaluateItemChange(ctx, "add item image", event)
+	case "ItemImageRemoved":
+		return p.evaluateItemChange(ctx, "remove item image (no-op when missing)", event)
+	case "ItemIdentifierAdded":
+		return p.evaluateItemChange(ctx, "add item identifier", event)
+	case "ItemIdentifierRemoved":
+		return p.evaluateItemChange(ctx, "remove item identifier (no-op when missing)", event)
+	case "ItemRelationAdded":
+		return p.evaluateItemRelation(ctx, "add item relation", event)
+	case "ItemRelationRemoved":
+		return dryRunOutcome{action: "remove item relation (no-op when missing)", fields: []zap.Field{
+			zap.String("item_id", event.ItemID),
+			zap.String("related_item_id", event.RelatedItemID),
+			zap.String("relation", event.Relation),
+		}}
+	case "StockAdjusted":
+		return p.evaluateStock(ctx, "adjust stock", event, func(item *database.InventoryItem) (int, int, error) {
+			quantity := item.Quantity + event.Quantity
+			if quantity < 0 || quantity < item.Reserved {
+				return 0, 0, fmt.Errorf("adjustment %d leaves quantity %d below reserved %d", event.Quantity, quantity, item.Reserved)
+			}
+			return quantity, item.Reserved, nil
+		})
+	case "StockReserved":
+		return p.evaluateStock(ctx, "reserve stock", event, func(item *database.InventoryItem) (int, int, error) {
+			if item.Quantity-item.Reserved < event.Quantity {
+				return 0, 0, fmt.Errorf("insufficient stock: available %d, requested %d", item.Quantity-item.Reserved, event.Quantity)
+			}
+			return item.Quantity, item.Reserved + event.Quantity, nil
+		})
+	case "StockReleased":
+		return p.evaluateStock(ctx, "release stock", event, func(item *database.InventoryItem) (int, int, error) {
+			if item.Reserved < event.Quantity {
+				return 0, 0, fmt.Errorf("cannot release %d, only %d reserved", event.Quantity, item.Reserved)
+			}
+			return item.Quantity, item.Reserved - event.Quantity, nil
+		})
+	case "StockCommitted":
+		return p.evaluateStock(ctx, "commit reserved stock", event, func(item *database.InventoryItem) (int, int, error) {
+			if item.Reserved < event.Quantity {
+				return 0, 0, fmt.Errorf("cannot commit %d, only %d reserved", event.Quantity, item.Reserved)
+			}
+			return item.Quantity - event.Quantity, item.Reserved - event.Quantity, nil
+		})
+	case "ManualCorrection":
+		return p.evaluateStock(ctx, "force-set stock (manual correction)", event, func(item *database.InventoryItem) (int, int, error) {
+			if event.Quantity < 0 || event.Reserved < 0 || event.Reserved > event.Quantity {
+				return 0, 0, fmt.Errorf("invalid manual correction: quantity %d, reserved %d", event.Quantity, event.Reserved)
+			}
+			return event.Quantity, event.Reserved, nil
+		})
+	case "StoreCreated":
+		return p.evaluateStoreCreated(ctx, event)
+	case "StoreUpdated":
+		return p.evaluateStoreChange(ctx, "update store", event)
+	case "StoreDeleted":
+		return p.evaluateStoreChange(ctx, "delete store (cascades to its reservations)", event)
+	case "StoreCalendarUpdated":
+		return p.evaluateStoreChange(ctx, "set store calendar", event)
+	case "StoreReservationCreated":
+		if outcome, ok := p.checkStore(ctx, "reserve stock for store", event); !ok {
+			return outcome
+		}
+		if _, err := uuid.Parse(event.ReservationID); err != nil {
+			return dryRunOutcome{action: "reserve stock for store", err: fmt.Errorf("invalid reservation ID: %w", err)}
+		}
+		return p.evaluateStock(ctx, "reserve stock for store", event, func(item *database.InventoryItem) (int, int, error) {
+			if item.Quantity-item.Reserved < event.Quantity {
+				return 0, 0, fmt.Errorf("insufficient stock: available %d, requested %d", item.Quantity-item.Reserved, event.Quantity)
+			}
+			return item.Quantity, item.Reserved + event.Quantity, nil
+		})
+	case "StoreReservationReleased":
+		if outcome, ok := p.checkStore(ctx, "release store reservation", event); !ok {
+			return outcome
+		}
+		held, err := p.db.GetActiveStoreReservedQuantity(ctx, event.StoreID, event.ItemID)
+		if err != nil {
+			return dryRunOutcome{action: "release store reservation", err: err}
+		}
+		if held < event.Quantity {
+			return dryRunOutcome{action: "release store reservation", err: fmt.Errorf("%w: holds %d, releasing %d", database.ErrInsufficientStoreReservation, held, event.Quantity)}
+		}
+		return p.evaluateStock(ctx, "release store reservation", event, func(item *database.InventoryItem) (int, int, error) {
+			return item.Quantity, item.Reserved - event.Quantity, nil
+		})
+	case "SupplierCreated", "SupplierUpdated":
+		return p.evaluateSupplier(ctx, "save supplier", event)
+	case "SupplierDeleted":
+		return p.evaluateSupplier(ctx, "delete supplier (no-op when missing)", event)
+	case "PurchaseOrderCreated":
+		return p.evaluatePurchaseOrderCreated(ctx, event)
+	case "PurchaseOrderReceived":
+		return p.evaluatePurchaseOrderChange(ctx, "record purchase order receipt", event)
+	case "PurchaseOrderCancelled":
+		return p.evaluatePurchaseOrderChange(ctx, "cancel purchase order", event)
+	case "ReservationWaitlisted":
+		if _, err := uuid.Parse(event.WaitlistID); err != nil {
+			return dryRunOutcome{action: "enqueue waitlisted reservation", err: fmt.Errorf("invalid waitlist ID: %w", err)}
+		}
+		return p.evaluateItemChange(ctx, "enqueue waitlisted reservation", event)
+	default:
+		return dryRunOutcome{action: "none", err: fmt.Errorf("unknown event type: %s", eventType)}
+	}
+}
+
+func (p *DryRunProcessor) evaluateItemCreated(ctx context.Context, event dryRunEvent) dryRunOutcome {
+	action := "create item"
+	itemID, err := uuid.Parse(event.ItemID)
+	if err != nil {
+		return dryRunOutcome{action: action, err: fmt.Errorf("invalid item ID: %w", err)}
+	}
+	fields := []zap.Field{zap.String("item_id", itemID.String()), zap.String("sku", event.SKU), zap.Int("quantity", event.Quantity)}
+
+	if _, err := p.db.GetItem(ctx, itemID.String()); err == nil {
+		return dryRunOutcome{action: action, err: fmt.Errorf("item already exists"), fields: fields}
+	} else if err != database.ErrItemNotFound {
+		return dryRunOutcome{action: action, err: err, fields: fields}
+	}
+	if existingID, err := p.db.FindItemIDBySKU(ctx, event.SKU); err == nil {
+		return dryRunOutcome{action: action, err: fmt.Errorf("SKU already used by item %s", existingID), fields: fields}
+	} else if err != database.ErrItemNotFound {
+		return dryRunOutcome{action: action, err: err, fields: fields}
+	}
+	if event.Quantity < 0 {
+		return dryRunOutcome{action: action, err: fmt.Errorf("negative initial quantity"), fields: fields}
+	}
+
+	return dryRunOutcome{action: action, fields: fields}
+}
+
+// evaluateItemChange checks that the item an event refers to exists
+func (p *DryRunProcessor) evaluateItemChange(ctx context.Context, action string, event dryRunEvent) dryRunOutcome {
+	itemID, err := uuid.Parse(event.ItemID)
+	if err != nil {
+		return dryRunOutcome{action: action, err: fmt.Errorf("invalid item ID: %w", err)}
+	}
+	item, err := p.db.GetItem(ctx, itemID.String())
+	if err != nil {
+		return dryRunOutcome{action: action, err: err, fields: []zap.Field{zap.String("item_id", itemID.String())}}
+	}
+	return dryRunOutcome{action: action, fields: []zap.Field{
+		zap.String("item_id", item.ID),
+		zap.String("sku", item.SKU),
+		zap.Int("expected_version", item.Version),
+	}}
+}
+
+// evaluateItemRelation checks that both items of a relation exist
+func (p *DryRunProcessor) evaluateItemRelation(ctx context.Context, action string, event dryRunEvent) dryRunOutcome {
+	fields := []zap.Field{
+		zap.String("item_id", event.ItemID),
+		zap.String("related_item_id", event.RelatedItemID),
+		zap.String("relation", event.Relation),
+	}
+	if event.Relation != database.RelationSubstitute && event.Relation != database.RelationAccessory {
+		return dryRunOutcome{action: action, err: fmt.Errorf("unknown item relation %q", event.Relation), fields: fields}
+	}
+	for _, id := range []string{event.ItemID, event.RelatedItemID} {
+		itemID, err := uuid.Parse(id)
+		if err != nil {
+			return dryRunOutcome{action: action, err: fmt.Errorf("invalid item ID: %w", err), fields: fields}
+		}
+		if _, err := p.db.GetItem(ctx, itemID.String()); err != nil {
+			return dryRunOutcome{action: action, err: err, fields: fields}
+		}
+	}
+	return dryRunOutcome{action: action, fields: fields}
+}
+
+// evaluateStock applies change to the current item totals and checks the result
+// against the same constraints the database enforces. For an event on a location the
+// change is checked against the stock at the location and carried to the totals.
+func (p *DryRunProcessor) evaluateStock(ctx context.Context, action string, event dryRunEvent, change func(item *database.InventoryItem) (int, int, error)) dryRunOutcome {
+	itemID, err := uuid.Parse(event.ItemID)
+	if err != nil {
+		return dryRunOutcome{action: action, err: fmt.Errorf("invalid item ID: %w", err)}
+	}
+	fields := []zap.Field{zap.String("item_id", itemID.String()), zap.Int("quantity", event.Quantity)}
+	if event.StoreID != "" {
+		fields = append(fields, zap.String("store_id", event.StoreID))
+	}
+
+	item, err := p.db.GetItem(ctx, itemID.String())
+	if err != nil {
+		return dryRunOutcome{action: action, err: err, fields: fields}
+	}
+	fields = append(fields, zap.Int("expected_version", item.Version))
+
+	if event.Location != "" {
+		return p.evaluateLocationStock(ctx, action, event, item, fields, change)
+	}
+
+	quantity, reserved, err := change(item)
+	if err != nil {
+		return dryRunOutcome{action: action, err: err, fields: fields}
+	}
+
+	fields = append(fields,
+		zap.String("quantity_change", fmt.Sprintf("%d -> %d", item.Quantity, quantity)),
+		zap.String("reserved_change", fmt.Sprintf("%d -> %d", item.Reserved, reserved)),
+		zap.Int("available_after", quantity-reserved),
+	)
+	return dryRunOutcome{action: action, fields: fields}
+}
+
+// evaluateLocationStock runs change on the stock of the item at the event's location
+func (p *DryRunProcessor) evaluateLocationStock(ctx context.Context, action string, event dryRunEvent, item *database.InventoryItem, fields []zap.Field, change func(item *database.InventoryItem) (int, int, error)) dryRunOutcome {
+	fields = append(fields, zap.String("location", event.Location))
+	stock, err := p.db.GetStockLocation(ctx, item.ID, event.Location)
+	if err != nil {
+		return dryRunOutcome{action: action, err: err, fields: fields}
+	}
+
+	quantity, reserved, err := change(&database.InventoryItem{ID: item.ID, Quantity: stock.Quantity, Reserved: stock.Reserved})
+	if err != nil {
+		return dryRunOutcome{action: action, err: fmt.Errorf("location %s: %w", event.Location, err), fields: fields}
+	}
+
+	fields = append(fields,
+		zap.String("location_quantity_change", fmt.Sprintf("%d -> %d", stock.Quantity, quantity)),
+		zap.String("location_reserved_change", fmt.Sprintf("%d -> %d", stock.Reserved, reserved)),
+		zap.String("quantity_change", fmt.Sprintf("%d -> %d", item.Quantity, item.Quantity+quantity-stock.Quantity)),
+		zap.String("reserved_change", fmt.Sprintf("%d -> %d", item.Reserved, item.Reserved+reserved-stock.Reserved)),
+	)
+	return dryRunOutcome{action: action, fields: fields}
+}
+
+func (p *DryRunProcessor) evaluateStoreCreated(ctx context.Context, event dryRunEvent) dryRunOutcome {
+	action := "create store"
+	storeID, err := uuid.Parse(event.StoreID)
+	if err != nil {
+		return dryRunOutcome{action: action, err: fmt.Errorf("invalid store ID: %w", err)}
+	}
+	fields := []zap.Field{zap.String("store_id", storeID.String()), zap.String("code", event.Code)}
+
+	if _, err := p.db.GetStore(ctx, storeID.String()); err == nil {
+		return dryRunOutcome{action: action, err: fmt.Errorf("store already exists"), fields: fields}
+	} else if err != database.ErrStoreNotFound {
+		return dryRunOutcome{action: action, err: err, fields: fields}
+	}
+	if existingID, err := p.db.FindStoreIDByCode(ctx, event.Code); err == nil {
+		return dryRunOutcome{action: action, err: fmt.Errorf("code already used by store %s", existingID), fields: fields}
+	} else if err != database.ErrStoreNotFound {
+		return dryRunOutcome{action: action, err: err, fields: fields}
+	}
+
+	return dryRunOutcome{action: action, fields: fields}
+}
+
+// evaluateSupplier checks the supplier ID of an event; supplier writes do not depend on
+// the current state
+func (p *DryRunProcessor) evaluateSupplier(ctx context.Context, action string, event dryRunEvent) dryRunOutcome {
+	supplierID, err := uuid.Parse(event.SupplierID)
+	if err != nil {
+		return dryRunOutcome{action: action, err: fmt.Errorf("invalid supplier ID: %w", err)}
+	}
+	return dryRunOutcome{action: action, fields: []zap.Field{zap.String("supplier_id", supplierID.String()), zap.String("code", event.Code)}}
+}
+
+func (p *DryRunProcessor) evaluatePurchaseOrderCreated(ctx context.Context, event dryRunEvent) dryRunOutcome {
+	action := "create purchase order"
+	orderID, err := uuid.Parse(event.OrderID)
+	if err != nil {
+		return dryRunOutcome{action: action, err: fmt.Errorf("invalid purchase order ID: %w", err)}
+	}
+	fields := []zap.Field{zap.String("purchase_order_id", orderID.String())}
+
+	if _, err := p.db.GetPurchaseOrderStatus(ctx, orderID.String()); err == nil {
+		return dryRunOutcome{action: "create purchase order (no-op, it already exists)", fields: fields}
+	} else if err != database.ErrPurchaseOrderNotFound {
+		return dryRunOutcome{action: action, err: err, fields: fields}
+	}
+	return dryRunOutcome{action: action, fields: fields}
+}
+
+// evaluatePurchaseOrderChange checks that the purchase order an event refers to exists
+func (p *DryRunProcessor) evaluatePurchaseOrderChange(ctx context.Context, action string, event dryRunEvent) dryRunOutcome {
+	orderID, err := uuid.Parse(event.OrderID)
+	if err != nil {
+		return dryRunOutcome{action: action, err: fmt.Errorf("invalid purchase order ID: %w", err)}
+	}
+	fields := []zap.Field{zap.String("purchase_order_id", orderID.String())}
+	status, err := p.db.GetPurchaseOrderStatus(ctx, orderID.String())
+	if err != nil {
+		return dryRunOutcome{action: action, err: err, fields: fields}
+	}
+	return dryRunOutcome{action: action, fields: append(fields, zap.String("status", status))}
+}
+
+// evaluateStoreChange checks that the store an event refers to exists
+func (p *DryRunProcessor) evaluateStoreChange(ctx context.Context, action string, event dryRunEvent) dryRunOutcome {
+	outcome, _ := p.checkStore(ctx, action, event)
+	return outcome
+}
+
+// checkStore returns false with a failed outcome when the event's store is invalid or missing
+func (p *DryRunProcessor) checkStore(ctx context.Context, action string, event dryRunEvent) (dryRunOutcome, bool) {
+	storeID, err := uuid.Parse(event.StoreID)
+	if err != nil {
+		return dryRunOutcome{action: action, err: fmt.Errorf("invalid store ID: %w", err)}, false
+	}
+	fields := []zap.Field{zap.String("store_id", storeID.String())}
+	store, err := p.db.GetStore(ctx, storeID.String())
+	if err != nil {
+		return dryRunOutcome{action: action, err: err, fields: fields}, false
+	}
+	return dryRunOutcome{action: action, fields: append(fields, zap.String("code", store.Code))}, true
+}
@@ -3,6 +3,7 @@ package events
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -17,15 +18,35 @@ type EventPublisher interface {
 	PublishConfirmationEvent(ctx context.Context, eventType string, itemID, sku string, data interface{}) error
 }
 
+// committedPublisher holds a confirmation back until the writes it confirms are
+// committed. Outside a batch that is right away; inside one, confirmations of events
+// rolled back with their savepoint are never published.
+type committedPublisher struct {
+	next   EventPublisher
+	logger *zap.Logger
+}
+
+func (c *committedPublisher) PublishConfirmationEvent(ctx context.Context, eventType string, itemID, sku string, data interface{}) error {
+	database.AfterCommit(ctx, func() {
+		if err := c.next.PublishConfirmationEvent(ctx, eventType, itemID, sku, data); err != nil {
+			c.logger.Warn("Failed to publish confirmation event", zap.String("event_type", eventType), zap.Error(err))
+		}
+	})
+	return nil
+}
+
 // EventProcessor processes domain events and updates the database
 type EventProcessor struct {
-	db       *database.SingleWriterDB
+	db       database.WriterDB
 	producer EventPublisher
 	logger   *zap.Logger
 }
 
 // NewEventProcessor creates a new event processor
-func NewEventProcessor(db *database.SingleWriterDB, producer EventPublisher, logger *zap.Logger) *EventProcessor {
+func NewEventProcessor(db database.WriterDB, producer EventPublisher, logger *zap.Logger) *EventProcessor {
+	if producer != nil {
+		producer = &committedPublisher{next: producer, logger: logger}
+	}
 	return &EventProcessor{
 		db:       db,
 		producer: producer,
@@ -40,14 +61,60 @@ func (p *EventProcessor) ProcessEvent(ctx context.Context, eventType string, eve
 		return p.processItemCreated(ctx, eventData)
 	case "InventoryItemUpdated":
 		return p.processItemUpdated(ctx, eventData)
+	case "InventoryItemPatched":
+		return p.processItemPatched(ctx, eventData)
 	case "InventoryItemDeleted":
 		return p.processItemDeleted(ctx, eventData)
+	case "InventoryItemRestored":
+		return p.processItemRestored(ctx, eventData)
+	case "ItemRelationAdded":
+		return p.processItemRelationAdded(ctx, eventData)
+	case "ItemRelationRemoved":
+		return p.processItemRelationRemoved(ctx, eventData)
+	case "ItemImageAdded":
+		return p.processItemImageAdded(ctx, eventData)
+	case "ItemImageRemoved":
+		return p.processItemImageRemoved(ctx, eventData)
+	case "ItemIdentifierAdded":
+		return p.processItemIdentifierAdded(ctx, eventData)
+	case "ItemIdentifierRemoved":
+		return p.processItemIdentifierRemoved(ctx, eventData)
 	case "StockAdjusted":
 		return p.processStockAdjusted(ctx, eventData)
 	case "StockReserved":
 		return p.processStockReserved(ctx, eventData)
 	case "StockReleased":
 		return p.processStockReleased(ctx, eventData)
+	case "StockCommitted":
+		return p.processStockCommitted(ctx, eventData)
+	case "StoreCreated":
+		return p.processStoreCreated(ctx, eventData)
+	case "StoreUpdated":
+		return p.processStoreUpdated(ctx, eventData)
+	case "StoreDeleted":
+		return p.processStoreDeleted(ctx, eventData)
+	case "StoreCalendarUpdated":
+		return p.processStoreCalendarUpdated(ctx, eventData)
+	case "StoreReservationCreated":
+		return p.processStoreReservationCreated(ctx, eventData)
+	case "StoreReservationReleased":
+		return p.processStoreReservationReleased(ctx, eventData)
+	case "SupplierCreated":
+		return p.processSupplierSaved(ctx, "SupplierCreated", eventData)
+	case "SupplierUpdated":
+		return p.processSupplierSaved(ctx, "SupplierUpdated", eventData)
+	case "SupplierDeleted":
+		return p.processSupplierDeleted(ctx, eventData)
+	case "PurchaseOrderCreated":
+		return p.processPurchaseOrderCreated(ctx, eventData)
+	case "PurchaseOrderReceived":
+		return p.processPurchaseOrderReceived(ctx, eventData)
+	case "PurchaseOrderCancelled":
+		return p.processPurchaseOrderCancelled(ctx, eventData)
+	case "ReservationWaitlisted":
+		return p.processReservationWaitlisted(ctx, eventData)
+	case "ManualCorrection":
+		return p.processManualCorrection(ctx, eventData)
 	default:
 		return fmt.Errorf("unknown event type: %s", eventType)
 	}
@@ -56,11 +123,13 @@ func (p *EventProcessor) ProcessEvent(ctx context.Context, eventType string, eve
 // processItemCreated processes InventoryItemCreated event
 func (p *EventProcessor) processItemCreated(ctx context.Context, eventData []byte) error {
 	var event struct {
-		ItemID      string `json:"itemId"`
-		SKU         string `json:"sku"`
-		Name        string `json:"name"`
-		Description string `json:"description"`
-		Quantity    int    `json:"quantity"`
+		ItemID      string    `json:"itemId"`
+		SKU         string    `json:"sku"`
+		Name        string    `json:"name"`
+		Description string    `json:"description"`
+		Quantity    int       `json:"quantity"`
+		UnitCost    *float64  `json:"unitCost"`
+		OccurredAt  time.Time `json:"occurredAt"`
 	}
 
 	if err := json.Unmarshal(eventData, &event); err != nil {
@@ -90,6 +159,12 @@ func (p *EventProcessor) processItemCreated(ctx context.Context, eventData []byt
 
 	p.logger.Info("Item created", zap.String("item_id", itemID.String()), zap.String("sku", event.SKU))
 
+	// Initial stock is the item's first receipt
+	if event.Quantity > 0 {
+		p.recordCostLayers(ctx, itemID.String(), event.Quantity, event.UnitCost)
+	}
+	p.recordMovement(ctx, "InventoryItemCreated", dbItem, "", event.Quantity, 0, event.OccurredAt)
+
 	// Publish confirmation event to update Redis in query-service
 	if p.producer != nil {
 		confirmationData := map[string]interface{}{
@@ -113,9 +188,10 @@ func (p *EventProcessor) processItemCreated(ctx context.Context, eventData []byt
 // processItemUpdated processes InventoryItemUpdated event
 func (p *EventProcessor) processItemUpdated(ctx context.Context, eventData []byte) error {
 	var event struct {
-		ItemID      string `json:"itemId"`
-		Name        string `json:"name"`
-		Description string `json:"description"`
+		ItemID          string `json:"itemId"`
+		Name            string `json:"name"`
+		Description     string `json:"description"`
+		ExpectedVersion int    `json:"expectedVersion"` // Version the Command Service changed (0 in older events)
 	}
 
 	if err := json.Unmarshal(eventData, &event); err != nil {
@@ -127,20 +203,15 @@ func (p *EventProcessor) processItemUpdated(ctx context.Context, eventData []byt
 		return fmt.Errorf("invalid item ID: %w", err)
 	}
 
-	// Get current item to get version
-	currentItem, err := p.db.GetItem(ctx, itemID.String())
+	err = p.applyWithVersion(ctx, itemID.String(), event.ExpectedVersion, func(version int) error {
+		return p.db.UpdateItem(ctx, &database.InventoryItem{
+			ID:          itemID.String(),
+			Name:        event.Name,
+			Description: event.Description,
+			Version:     version,
+		})
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get item for update: %w", err)
-	}
-
-	dbItem := &database.InventoryItem{
-		ID:          itemID.String(),
-		Name:        event.Name,
-		Description: event.Description,
-		Version:     currentItem.Version,
-	}
-
-	if err := p.db.UpdateItem(ctx, dbItem); err != nil {
 		return fmt.Errorf("failed to update item: %w", err)
 	}
 
@@ -166,10 +237,14 @@ func (p *EventProcessor) processItemUpdated(ctx context.Context, eventData []byt
 	return nil
 }
 
-// processItemDeleted processes InventoryItemDeleted event
-func (p *EventProcessor) processItemDeleted(ctx context.Context, eventData []byte) error {
+// processItemPatched processes InventoryItemPatched event: only the fields in the event
+// change, the others keep the value in the read model
+func (p *EventProcessor) processItemPatched(ctx context.Context, eventData []byte) error {
 	var event struct {
-		ItemID string `json:"itemId"`
+		ItemID          string  `json:"itemId"`
+		Name            *string `json:"name"`
+		Description     *string `json:"description"`
+		ExpectedVersion int     `json:"expectedVersion"`
 	}
 
 	if err := json.Unmarshal(eventData, &event); err != nil {
@@ -181,26 +256,32 @@ func (p *EventProcessor) processItemDeleted(ctx context.Context, eventData []byt
 		return fmt.Errorf("invalid item ID: %w", err)
 	}
 
-	// Get item before deletion to publish confirmation event
-	var sku string
-	itemToDelete, err := p.db.GetItem(ctx, itemID.String())
-	if err == nil {
-		sku = itemToDelete.SKU
-	}
-
-	if err := p.db.DeleteItem(ctx, itemID.String()); err != nil {
-		return fmt.Errorf("failed to delete item: %w", err)
+	err = p.applyWithVersion(ctx, itemID.String(), event.ExpectedVersion, func(version int) error {
+		return p.db.PatchItem(ctx, itemID.String(), event.Name, event.Description, version)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to patch item: %w", err)
 	}
 
-	p.logger.Info("Item deleted", zap.String("item_id", itemID.String()))
+	p.logger.Info("Item patched",
+		zap.String("item_id", itemID.String()),
+		zap.Bool("name", event.Name != nil),
+		zap.Bool("description", event.Description != nil),
+	)
 
-	// Publish confirmation event
-	if p.producer != nil {
+	// The confirmation carries the whole item, like InventoryItemUpdated's
+	updatedItem, err := p.db.GetItem(ctx, itemID.String())
+	if err == nil && p.producer != nil {
 		confirmationData := map[string]interface{}{
-			"itemId": itemID.String(),
-			"sku":    sku,
+			"itemId":      itemID.String(),
+			"sku":         updatedItem.SKU,
+			"name":        updatedItem.Name,
+			"description": updatedItem.Description,
+			"quantity":    updatedItem.Quantity,
+			"reserved":    updatedItem.Reserved,
+			"available":   updatedItem.Available,
 		}
-		if err := p.producer.PublishConfirmationEvent(ctx, "InventoryItemDeleted", itemID.String(), sku, confirmationData); err != nil {
+		if err := p.producer.PublishConfirmationEvent(ctx, "InventoryItemPatched", itemID.String(), updatedItem.SKU, confirmationData); err != nil {
 			p.logger.Warn("Failed to publish confirmation event", zap.Error(err))
 		}
 	}
@@ -208,12 +289,13 @@ func (p *EventProcessor) processItemDeleted(ctx context.Context, eventData []byt
 	return nil
 }
 
-// processStockAdjusted processes StockAdjusted event
-func (p *EventProcessor) processStockAdjusted(ctx context.Context, eventData []byte) error {
+// processItemDeleted processes InventoryItemDeleted event: the item is soft-deleted, so
+// reservations and history that reference it stay valid
+func (p *EventProcessor) processItemDeleted(ctx context.Context, eventData []byte) error {
 	var event struct {
-		ItemID   string `json:"itemId"`
-		Quantity int    `json:"quantity"` // This is the adjustment (difference), not the new total
-		NewTotal int    `json:"newTotal"`  // This is the new total quantity after adjustment
+		ItemID string `json:"itemId"`
+		// Version the Command Service deleted (0 in older events)
+		ExpectedVersion int `json:"expectedVersion"`
 	}
 
 	if err := json.Unmarshal(eventData, &event); err != nil {
@@ -225,45 +307,489 @@ func (p *EventProcessor) processStockAdjusted(ctx context.Context, eventData []b
 		return fmt.Errorf("invalid item ID: %w", err)
 	}
 
-	// Get current item to get version
-	currentItem, err := p.db.GetItem(ctx, itemID.String())
+	err = p.applyWithVersion(ctx, itemID.String(), event.ExpectedVersion, func(version int) error {
+		return p.db.DeleteItem(ctx, itemID.String(), version)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete item: %w", err)
+	}
+
+	p.logger.Info("Item deleted", zap.String("item_id", itemID.String()))
+
+	p.publishItemDeletionConfirmation(ctx, "InventoryItemDeleted", itemID.String())
+	return nil
+}
+
+// processItemRestored processes InventoryItemRestored event: undoes a soft delete
+func (p *EventProcessor) processItemRestored(ctx context.Context, eventData []byte) error {
+	var event struct {
+		ItemID          string `json:"itemId"`
+		ExpectedVersion int    `json:"expectedVersion"`
+	}
+
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	itemID, err := uuid.Parse(event.ItemID)
+	if err != nil {
+		return fmt.Errorf("invalid item ID: %w", err)
+	}
+
+	err = p.applyWithVersion(ctx, itemID.String(), event.ExpectedVersion, func(version int) error {
+		return p.db.RestoreItem(ctx, itemID.String(), version)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore item: %w", err)
+	}
+
+	p.logger.Info("Item restored", zap.String("item_id", itemID.String()))
+
+	p.publishItemDeletionConfirmation(ctx, "InventoryItemRestored", itemID.String())
+	return nil
+}
+
+// publishItemDeletionConfirmation confirms a soft delete or a restore
+func (p *EventProcessor) publishItemDeletionConfirmation(ctx context.Context, eventType, itemID string) {
+	if p.producer == nil {
+		return
+	}
+	item, err := p.db.GetItem(ctx, itemID)
+	if err != nil {
+		p.logger.Warn("Failed to get item for confirmation event", zap.String("item_id", itemID), zap.Error(err))
+		return
+	}
+	confirmationData := map[string]interface{}{
+		"itemId":    itemID,
+		"sku":       item.SKU,
+		"deletedAt": item.DeletedAt,
+	}
+	if err := p.producer.PublishConfirmationEvent(ctx, eventType, itemID, item.SKU, confirmationData); err != nil {
+		p.logger.Warn("Failed to publish confirmation event", zap.Error(err))
+	}
+}
+
+// itemRelationEvent is the payload of ItemRelationAdded and ItemRelationRemoved
+type itemRelationEvent struct {
+	ItemID        string `json:"itemId"`
+	RelatedItemID string `json:"relatedItemId"`
+	Relation      string `json:"relation"`
+}
+
+// parseItemRelationEvent decodes and validates an item relation event
+func parseItemRelationEvent(eventData []byte) (*itemRelationEvent, error) {
+	var event itemRelationEvent
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+	if _, err := uuid.Parse(event.ItemID); err != nil {
+		return nil, fmt.Errorf("invalid item ID: %w", err)
+	}
+	if _, err := uuid.Parse(event.RelatedItemID); err != nil {
+		return nil, fmt.Errorf("invalid related item ID: %w", err)
+	}
+	if event.Relation != database.RelationSubstitute && event.Relation != database.RelationAccessory {
+		return nil, fmt.Errorf("unknown item relation %q", event.Relation)
+	}
+	return &event, nil
+}
+
+// processItemRelationAdded processes ItemRelationAdded event
+func (p *EventProcessor) processItemRelationAdded(ctx context.Context, eventData []byte) error {
+	event, err := parseItemRelationEvent(eventData)
+	if err != nil {
+		return err
+	}
+
+	if err := p.db.SaveItemRelation(ctx, event.ItemID, event.RelatedItemID, event.Relation); err != nil {
+		return fmt.Errorf("failed to save item relation: %w", err)
+	}
+
+	p.logger.Info("Item relation added",
+		zap.String("item_id", event.ItemID),
+		zap.String("related_item_id", event.RelatedItemID),
+		zap.String("relation", event.Relation),
+	)
+	p.publishItemRelationConfirmation(ctx, "ItemRelationAdded", event)
+	return nil
+}
+
+// processItemRelationRemoved processes ItemRelationRemoved event
+func (p *EventProcessor) processItemRelationRemoved(ctx context.Context, eventData []byte) error {
+	event, err := parseItemRelationEvent(eventData)
+	if err != nil {
+		return err
+	}
+
+	if err := p.db.DeleteItemRelation(ctx, event.ItemID, event.RelatedItemID, event.Relation); err != nil {
+		return fmt.Errorf("failed to delete item relation: %w", err)
+	}
+
+	p.logger.Info("Item relation removed",
+		zap.String("item_id", event.ItemID),
+		zap.String("related_item_id", event.RelatedItemID),
+		zap.String("relation", event.Relation),
+	)
+	p.publishItemRelationConfirmation(ctx, "ItemRelationRemoved", event)
+	return nil
+}
+
+// processItemImageAdded processes ItemImageAdded event: the file is already in the
+// image store, the read model records where it is
+func (p *EventProcessor) processItemImageAdded(ctx context.Context, eventData []byte) error {
+	var event struct {
+		ItemID          string    `json:"itemId"`
+		ImageID         string    `json:"imageId"`
+		Key             string    `json:"key"`
+		URL             string    `json:"url"`
+		ContentType     string    `json:"contentType"`
+		Size            int64     `json:"size"`
+		ExpectedVersion int       `json:"expectedVersion"`
+		OccurredAt      time.Time `json:"occurredAt"`
+	}
+
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	itemID, err := uuid.Parse(event.ItemID)
+	if err != nil {
+		return fmt.Errorf("invalid item ID: %w", err)
+	}
+	imageID, err := uuid.Parse(event.ImageID)
+	if err != nil {
+		return fmt.Errorf("invalid image ID: %w", err)
+	}
+	if event.Key == "" || event.Size <= 0 {
+		return fmt.Errorf("invalid item image: key %q, size %d", event.Key, event.Size)
+	}
+
+	image := &database.ItemImage{
+		ID:          imageID.String(),
+		ItemID:      itemID.String(),
+		StorageKey:  event.Key,
+		URL:         event.URL,
+		ContentType: event.ContentType,
+		Size:        event.Size,
+		CreatedAt:   event.OccurredAt,
+	}
+	if image.CreatedAt.IsZero() {
+		image.CreatedAt = time.Now().UTC()
+	}
+	err = p.applyWithVersion(ctx, image.ItemID, event.ExpectedVersion, func(version int) error {
+		return p.db.AddItemImage(ctx, image, version)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add item image: %w", err)
+	}
+
+	p.logger.Info("Item image added",
+		zap.String("item_id", image.ItemID),
+		zap.String("image_id", image.ID),
+		zap.String("key", image.StorageKey),
+	)
+	p.publishItemImageConfirmation(ctx, "ItemImageAdded", image.ItemID, image.ID)
+	return nil
+}
+
+// processItemImageRemoved processes ItemImageRemoved event
+func (p *EventProcessor) processItemImageRemoved(ctx context.Context, eventData []byte) error {
+	var event struct {
+		ItemID          string `json:"itemId"`
+		ImageID         string `json:"imageId"`
+		ExpectedVersion int    `json:"expectedVersion"`
+	}
+
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	itemID, err := uuid.Parse(event.ItemID)
+	if err != nil {
+		return fmt.Errorf("invalid item ID: %w", err)
+	}
+	imageID, err := uuid.Parse(event.ImageID)
+	if err != nil {
+		return fmt.Errorf("invalid image ID: %w", err)
+	}
+
+	err = p.applyWithVersion(ctx, itemID.String(), event.ExpectedVersion, func(version int) error {
+		return p.db.RemoveItemImage(ctx, itemID.String(), imageID.String(), version)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove item image: %w", err)
+	}
+
+	p.logger.Info("Item image removed",
+		zap.String("item_id", itemID.String()),
+		zap.String("image_id", imageID.String()),
+	)
+	p.publishItemImageConfirmation(ctx, "ItemImageRemoved", itemID.String(), imageID.String())
+	return nil
+}
+
+// processItemIdentifierAdded processes ItemIdentifierAdded event
+func (p *EventProcessor) processItemIdentifierAdded(ctx context.Context, eventData []byte) error {
+	var event struct {
+		ItemID          string    `json:"itemId"`
+		Type            string    `json:"type"`
+		Value           string    `json:"value"`
+		Code            string    `json:"code"`
+		ExpectedVersion int       `json:"expectedVersion"`
+		OccurredAt      time.Time `json:"occurredAt"`
+	}
+
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	itemID, err := uuid.Parse(event.ItemID)
+	if err != nil {
+		return fmt.Errorf("invalid item ID: %w", err)
+	}
+	if event.Code == "" || event.Type == "" {
+		return fmt.Errorf("invalid item identifier: type %q, code %q", event.Type, event.Code)
+	}
+
+	identifier := &database.ItemIdentifier{
+		ItemID:    itemID.String(),
+		Type:      event.Type,
+		Value:     event.Value,
+		Code:      event.Code,
+		CreatedAt: event.OccurredAt,
+	}
+	if identifier.CreatedAt.IsZero() {
+		identifier.CreatedAt = time.Now().UTC()
+	}
+	err = p.applyWithVersion(ctx, identifier.ItemID, event.ExpectedVersion, func(version int) error {
+		return p.db.AddItemIdentifier(ctx, identifier, version)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add item identifier: %w", err)
+	}
+
+	p.logger.Info("Item identifier added",
+		zap.String("item_id", identifier.ItemID),
+		zap.String("type", identifier.Type),
+		zap.String("code", identifier.Code),
+	)
+	p.publishItemIdentifierConfirmation(ctx, "ItemIdentifierAdded", identifier.ItemID, identifier.Code)
+	return nil
+}
+
+// processItemIdentifierRemoved processes ItemIdentifierRemoved event
+func (p *EventProcessor) processItemIdentifierRemoved(ctx context.Context, eventData []byte) error {
+	var event struct {
+		ItemID          string `json:"itemId"`
+		Code            string `json:"code"`
+		ExpectedVersion int    `json:"expectedVersion"`
+	}
+
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	itemID, err := uuid.Parse(event.ItemID)
+	if err != nil {
+		return fmt.Errorf("invalid item ID: %w", err)
+	}
+	if event.Code == "" {
+		return fmt.Errorf("invalid item identifier: empty code")
+	}
+
+	err = p.applyWithVersion(ctx, itemID.String(), event.ExpectedVersion, func(version int) error {
+		return p.db.RemoveItemIdentifier(ctx, itemID.String(), event.Code, version)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove item identifier: %w", err)
+	}
+
+	p.logger.Info("Item identifier removed",
+		zap.String("item_id", itemID.String()),
+		zap.String("code", event.Code),
+	)
+	p.publishItemIdentifierConfirmation(ctx, "ItemIdentifierRemoved", itemID.String(), event.Code)
+	return nil
+}
+
+// processStockAdjusted processes StockAdjusted event
+func (p *EventProcessor) processStockAdjusted(ctx context.Context, eventData []byte) error {
+	var event struct {
+		ItemID     string    `json:"itemId"`
+		Quantity   int       `json:"quantity"` // This is the adjustment (difference), not the new total
+		NewTotal   int       `json:"newTotal"` // This is the new total quantity after adjustment
+		UnitCost   *float64  `json:"unitCost"` // Cost of received stock (positive adjustments only)
+		OccurredAt time.Time `json:"occurredAt"`
+		// Version the Command Service adjusted (0 in older events)
+		ExpectedVersion int `json:"expectedVersion"`
+		// Location whose stock was adjusted; empty for the stock not held at a location
+		Location string `json:"location"`
+		// Reason code (stock_count for reconciliations), reference and actor of the command
+		stockNote
+	}
+
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	itemID, err := uuid.Parse(event.ItemID)
 	if err != nil {
-		return fmt.Errorf("failed to get item for stock adjustment: %w", err)
+		return fmt.Errorf("invalid item ID: %w", err)
 	}
 
 	// The event.Quantity field contains the adjustment (difference), not the new total
 	// For example: if stock was 100 and we adjust by +25, event.Quantity = 25
 	adjustment := event.Quantity
 
-	if err := p.db.AdjustStock(ctx, itemID.String(), adjustment, currentItem.Version); err != nil {
+	err = p.applyWithVersion(ctx, itemID.String(), event.ExpectedVersion, func(version int) error {
+		if event.Location != "" {
+			return p.db.ChangeLocationStock(ctx, itemID.String(), event.Location, adjustment, 0, version)
+		}
+		return p.db.AdjustStock(ctx, itemID.String(), adjustment, version)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to adjust stock: %w", err)
 	}
 
-	p.logger.Info("Stock adjusted", zap.String("item_id", itemID.String()), zap.Int("adjustment", adjustment))
+	p.logger.Info("Stock adjusted",
+		zap.String("item_id", itemID.String()),
+		zap.String("location", event.Location),
+		zap.Int("adjustment", adjustment),
+		zap.String("reason", event.Reason),
+	)
+
+	p.recordCostLayers(ctx, itemID.String(), adjustment, event.UnitCost)
 
 	// Get updated item to publish confirmation event
 	updatedItem, err := p.db.GetItem(ctx, itemID.String())
+	if err == nil {
+		p.recordMovementWithNote(ctx, "StockAdjusted", updatedItem, "", adjustment, 0, event.OccurredAt, event.stockNote)
+	}
 	if err == nil && p.producer != nil {
 		confirmationData := map[string]interface{}{
-			"itemId":   itemID.String(),
-			"sku":      updatedItem.SKU,
-			"quantity": updatedItem.Quantity,
-			"reserved": updatedItem.Reserved,
+			"itemId":    itemID.String(),
+			"sku":       updatedItem.SKU,
+			"quantity":  updatedItem.Quantity,
+			"reserved":  updatedItem.Reserved,
 			"available": updatedItem.Available,
 		}
+		p.addLocationStock(ctx, confirmationData, itemID.String(), event.Location)
 		if err := p.producer.PublishConfirmationEvent(ctx, "StockAdjusted", itemID.String(), updatedItem.SKU, confirmationData); err != nil {
 			p.logger.Warn("Failed to publish confirmation event", zap.Error(err))
 		}
 	}
 
+	// Waitlisted reservations are served from the stock not held at a location
+	if adjustment > 0 && event.Location == "" {
+		p.fulfillWaitlist(ctx, itemID.String())
+	}
+
+	return nil
+}
+
+// applyWithVersion runs apply under the optimistic lock. Events carry the version the
+// Command Service changed; while the read model is in step with the write store that
+// version is used as is, without reading the item first. Events without it, and items
+// whose read-model version moved on its own (a waitlist fulfilled here), fall back to
+// the version currently stored.
+func (p *EventProcessor) applyWithVersion(ctx context.Context, itemID string, expectedVersion int, apply func(version int) error) error {
+	if expectedVersion > 0 {
+		err := apply(expectedVersion)
+		if !errors.Is(err, database.ErrOptimisticLockFailed) {
+			return err
+		}
+		p.logger.Debug("Read model is not at the event's version, using the stored version",
+			zap.String("item_id", itemID),
+			zap.Int("expected_version", expectedVersion),
+		)
+	}
+
+	current, err := p.db.GetItem(ctx, itemID)
+	if err != nil {
+		return fmt.Errorf("failed to get item: %w", err)
+	}
+	return apply(current.Version)
+}
+
+// processManualCorrection processes ManualCorrection event: an administrator overwrote
+// the item's counters, so the absolute values replace whatever the read model holds
+func (p *EventProcessor) processManualCorrection(ctx context.Context, eventData []byte) error {
+	var event struct {
+		ItemID     string    `json:"itemId"`
+		Quantity   int       `json:"quantity"`
+		Reserved   int       `json:"reserved"`
+		Reason     string    `json:"reason"`
+		OccurredAt time.Time `json:"occurredAt"`
+	}
+
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	itemID, err := uuid.Parse(event.ItemID)
+	if err != nil {
+		return fmt.Errorf("invalid item ID: %w", err)
+	}
+	if event.Quantity < 0 || event.Reserved < 0 || event.Reserved > event.Quantity {
+		return fmt.Errorf("invalid manual correction: quantity %d, reserved %d", event.Quantity, event.Reserved)
+	}
+
+	currentItem, err := p.db.GetItem(ctx, itemID.String())
+	if err != nil {
+		return fmt.Errorf("failed to get item for manual correction: %w", err)
+	}
+
+	if err := p.db.ForceSetStock(ctx, itemID.String(), event.Quantity, event.Reserved, currentItem.Version); err != nil {
+		return fmt.Errorf("failed to apply manual correction: %w", err)
+	}
+
+	// Deltas are against the read model, which is what the correction actually changed
+	quantityChange := event.Quantity - currentItem.Quantity
+	reservedChange := event.Reserved - currentItem.Reserved
+	actor, _ := database.AttributionFromContext(ctx)
+	p.logger.Warn("Manual stock correction applied",
+		zap.String("item_id", itemID.String()),
+		zap.String("sku", currentItem.SKU),
+		zap.String("actor", actor),
+		zap.String("quantity", fmt.Sprintf("%d -> %d", currentItem.Quantity, event.Quantity)),
+		zap.String("reserved", fmt.Sprintf("%d -> %d", currentItem.Reserved, event.Reserved)),
+		zap.String("reason", event.Reason),
+	)
+
+	updatedItem, err := p.db.GetItem(ctx, itemID.String())
+	if err == nil {
+		p.recordMovementWithNote(ctx, "ManualCorrection", updatedItem, "", quantityChange, reservedChange, event.OccurredAt, stockNote{Reason: event.Reason})
+	}
+	if err == nil && p.producer != nil {
+		confirmationData := map[string]interface{}{
+			"itemId":    itemID.String(),
+			"sku":       updatedItem.SKU,
+			"quantity":  updatedItem.Quantity,
+			"reserved":  updatedItem.Reserved,
+			"available": updatedItem.Available,
+			"reason":    event.Reason,
+		}
+		if err := p.producer.PublishConfirmationEvent(ctx, "ManualCorrection", itemID.String(), updatedItem.SKU, confirmationData); err != nil {
+			p.logger.Warn("Failed to publish confirmation event", zap.Error(err))
+		}
+	}
+
+	// Freed availability may satisfy waiting reservations
+	if event.Quantity-event.Reserved > currentItem.Quantity-currentItem.Reserved {
+		p.fulfillWaitlist(ctx, itemID.String())
+	}
+
 	return nil
 }
 
 // processStockReserved processes StockReserved event
 func (p *EventProcessor) processStockReserved(ctx context.Context, eventData []byte) error {
 	var event struct {
-		ItemID   string `json:"itemId"`
-		Quantity int    `json:"quantity"`
+		ItemID     string    `json:"itemId"`
+		Quantity   int       `json:"quantity"`
+		Location   string    `json:"location"` // Empty for the stock not held at a location
+		OccurredAt time.Time `json:"occurredAt"`
+		stockNote
 	}
 
 	if err := json.Unmarshal(eventData, &event); err != nil {
@@ -281,22 +807,35 @@ func (p *EventProcessor) processStockReserved(ctx context.Context, eventData []b
 		return fmt.Errorf("failed to get item for stock reservation: %w", err)
 	}
 
-	if err := p.db.ReserveStock(ctx, itemID.String(), event.Quantity, currentItem.Version); err != nil {
+	if event.Location != "" {
+		err = p.db.ChangeLocationStock(ctx, itemID.String(), event.Location, 0, event.Quantity, currentItem.Version)
+	} else {
+		err = p.db.ReserveStock(ctx, itemID.String(), event.Quantity, currentItem.Version)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to reserve stock: %w", err)
 	}
 
-	p.logger.Info("Stock reserved", zap.String("item_id", itemID.String()), zap.Int("quantity", event.Quantity))
+	p.logger.Info("Stock reserved",
+		zap.String("item_id", itemID.String()),
+		zap.String("location", event.Location),
+		zap.Int("quantity", event.Quantity),
+	)
 
 	// Get updated item to publish confirmation event
 	updatedItem, err := p.db.GetItem(ctx, itemID.String())
+	if err == nil {
+		p.recordMovementWithNote(ctx, "StockReserved", updatedItem, "", 0, event.Quantity, event.OccurredAt, event.stockNote)
+	}
 	if err == nil && p.producer != nil {
 		confirmationData := map[string]interface{}{
-			"itemId":   itemID.String(),
-			"sku":      updatedItem.SKU,
-			"quantity": updatedItem.Quantity,
-			"reserved": updatedItem.Reserved,
+			"itemId":    itemID.String(),
+			"sku":       updatedItem.SKU,
+			"quantity":  updatedItem.Quantity,
+			"reserved":  updatedItem.Reserved,
 			"available": updatedItem.Available,
 		}
+		p.addLocationStock(ctx, confirmationData, itemID.String(), event.Location)
 		if err := p.producer.PublishConfirmationEvent(ctx, "StockReserved", itemID.String(), updatedItem.SKU, confirmationData); err != nil {
 			p.logger.Warn("Failed to publish confirmation event", zap.Error(err))
 		}
@@ -308,8 +847,11 @@ func (p *EventProcessor) processStockReserved(ctx context.Context, eventData []b
 // processStockReleased processes StockReleased event
 func (p *EventProcessor) processStockReleased(ctx context.Context, eventData []byte) error {
 	var event struct {
-		ItemID   string `json:"itemId"`
-		Quantity int    `json:"quantity"`
+		ItemID     string    `json:"itemId"`
+		Quantity   int       `json:"quantity"`
+		Location   string    `json:"location"` // Empty for the stock not held at a location
+		OccurredAt time.Time `json:"occurredAt"`
+		stockNote
 	}
 
 	if err := json.Unmarshal(eventData, &event); err != nil {
@@ -327,27 +869,922 @@ func (p *EventProcessor) processStockReleased(ctx context.Context, eventData []b
 		return fmt.Errorf("failed to get item for stock release: %w", err)
 	}
 
-	if err := p.db.ReleaseStock(ctx, itemID.String(), event.Quantity, currentItem.Version); err != nil {
+	if event.Location != "" {
+		err = p.db.ChangeLocationStock(ctx, itemID.String(), event.Location, 0, -event.Quantity, currentItem.Version)
+	} else {
+		err = p.db.ReleaseStock(ctx, itemID.String(), event.Quantity, currentItem.Version)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to release stock: %w", err)
 	}
 
-	p.logger.Info("Stock released", zap.String("item_id", itemID.String()), zap.Int("quantity", event.Quantity))
+	p.logger.Info("Stock released",
+		zap.String("item_id", itemID.String()),
+		zap.String("location", event.Location),
+		zap.Int("quantity", event.Quantity),
+	)
 
 	// Get updated item to publish confirmation event
 	updatedItem, err := p.db.GetItem(ctx, itemID.String())
+	if err == nil {
+		p.recordMovementWithNote(ctx, "StockReleased", updatedItem, "", 0, -event.Quantity, event.OccurredAt, event.stockNote)
+	}
 	if err == nil && p.producer != nil {
 		confirmationData := map[string]interface{}{
-			"itemId":   itemID.String(),
-			"sku":      updatedItem.SKU,
-			"quantity": updatedItem.Quantity,
-			"reserved": updatedItem.Reserved,
+			"itemId":    itemID.String(),
+			"sku":       updatedItem.SKU,
+			"quantity":  updatedItem.Quantity,
+			"reserved":  updatedItem.Reserved,
 			"available": updatedItem.Available,
 		}
+		p.addLocationStock(ctx, confirmationData, itemID.String(), event.Location)
 		if err := p.producer.PublishConfirmationEvent(ctx, "StockReleased", itemID.String(), updatedItem.SKU, confirmationData); err != nil {
 			p.logger.Warn("Failed to publish confirmation event", zap.Error(err))
 		}
 	}
 
-	return nil
+	if event.Location == "" {
+		p.fulfillWaitlist(ctx, itemID.String())
+	}
+
+	return nil
+}
+
+// processStockCommitted processes StockCommitted event: reserved stock was sold, so it
+// leaves both the reserved counter and the total quantity
+func (p *EventProcessor) processStockCommitted(ctx context.Context, eventData []byte) error {
+	var event struct {
+		ItemID     string    `json:"itemId"`
+		Quantity   int       `json:"quantity"`
+		Location   string    `json:"location"` // Empty for the stock not held at a location
+		OccurredAt time.Time `json:"occurredAt"`
+		stockNote
+	}
+
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	itemID, err := uuid.Parse(event.ItemID)
+	if err != nil {
+		return fmt.Errorf("invalid item ID: %w", err)
+	}
+
+	// Get current item to get version
+	currentItem, err := p.db.GetItem(ctx, itemID.String())
+	if err != nil {
+		return fmt.Errorf("failed to get item for stock commit: %w", err)
+	}
+
+	if event.Location != "" {
+		err = p.db.ChangeLocationStock(ctx, itemID.String(), event.Location, -event.Quantity, -event.Quantity, currentItem.Version)
+	} else {
+		err = p.db.CommitStock(ctx, itemID.String(), event.Quantity, currentItem.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to commit stock: %w", err)
+	}
+
+	p.logger.Info("Stock committed",
+		zap.String("item_id", itemID.String()),
+		zap.String("location", event.Location),
+		zap.Int("quantity", event.Quantity),
+	)
+
+	// The sold units leave inventory at their FIFO cost
+	p.recordCostLayers(ctx, itemID.String(), -event.Quantity, nil)
+
+	// Get updated item to publish confirmation event
+	updatedItem, err := p.db.GetItem(ctx, itemID.String())
+	if err == nil {
+		p.recordMovementWithNote(ctx, "StockCommitted", updatedItem, "", -event.Quantity, -event.Quantity, event.OccurredAt, event.stockNote)
+	}
+	if err == nil && p.producer != nil {
+		confirmationData := map[string]interface{}{
+			"itemId":    itemID.String(),
+			"sku":       updatedItem.SKU,
+			"quantity":  updatedItem.Quantity,
+			"reserved":  updatedItem.Reserved,
+			"available": updatedItem.Available,
+		}
+		p.addLocationStock(ctx, confirmationData, itemID.String(), event.Location)
+		if err := p.producer.PublishConfirmationEvent(ctx, "StockCommitted", itemID.String(), updatedItem.SKU, confirmationData); err != nil {
+			p.logger.Warn("Failed to publish confirmation event", zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// storeEvent is the payload shared by StoreCreated and StoreUpdated events
+type storeEvent struct {
+	StoreID  string `json:"storeId"`
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	Active   bool   `json:"active"`
+}
+
+// processStoreCreated processes StoreCreated event
+func (p *EventProcessor) processStoreCreated(ctx context.Context, eventData []byte) error {
+	var event storeEvent
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	storeID, err := uuid.Parse(event.StoreID)
+	if err != nil {
+		return fmt.Errorf("invalid store ID: %w", err)
+	}
+
+	dbStore := &database.Store{
+		ID:       storeID.String(),
+		Code:     event.Code,
+		Name:     event.Name,
+		Location: event.Location,
+		Active:   event.Active,
+	}
+
+	if err := p.db.CreateStore(ctx, dbStore); err != nil {
+		return fmt.Errorf("failed to create store: %w", err)
+	}
+
+	p.logger.Info("Store created", zap.String("store_id", storeID.String()), zap.String("code", event.Code))
+
+	p.publishStoreConfirmation(ctx, "StoreCreated", storeID.String(), map[string]interface{}{
+		"storeId":  storeID.String(),
+		"code":     event.Code,
+		"name":     event.Name,
+		"location": event.Location,
+		"active":   event.Active,
+	})
+
+	return nil
+}
+
+// processStoreUpdated processes StoreUpdated event
+func (p *EventProcessor) processStoreUpdated(ctx context.Context, eventData []byte) error {
+	var event storeEvent
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	storeID, err := uuid.Parse(event.StoreID)
+	if err != nil {
+		return fmt.Errorf("invalid store ID: %w", err)
+	}
+
+	dbStore := &database.Store{
+		ID:       storeID.String(),
+		Name:     event.Name,
+		Location: event.Location,
+		Active:   event.Active,
+	}
+
+	if err := p.db.UpdateStore(ctx, dbStore); err != nil {
+		return fmt.Errorf("failed to update store: %w", err)
+	}
+
+	p.logger.Info("Store updated", zap.String("store_id", storeID.String()))
+
+	p.publishStoreConfirmation(ctx, "StoreUpdated", storeID.String(), map[string]interface{}{
+		"storeId":  storeID.String(),
+		"code":     event.Code,
+		"name":     event.Name,
+		"location": event.Location,
+		"active":   event.Active,
+	})
+
+	return nil
+}
+
+// processStoreDeleted processes StoreDeleted event
+func (p *EventProcessor) processStoreDeleted(ctx context.Context, eventData []byte) error {
+	var event struct {
+		StoreID string `json:"storeId"`
+		Code    string `json:"code"`
+	}
+
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	storeID, err := uuid.Parse(event.StoreID)
+	if err != nil {
+		return fmt.Errorf("invalid store ID: %w", err)
+	}
+
+	if err := p.db.DeleteStore(ctx, storeID.String()); err != nil {
+		return fmt.Errorf("failed to delete store: %w", err)
+	}
+
+	p.logger.Info("Store deleted", zap.String("store_id", storeID.String()))
+
+	p.publishStoreConfirmation(ctx, "StoreDeleted", storeID.String(), map[string]interface{}{
+		"storeId": storeID.String(),
+		"code":    event.Code,
+	})
+
+	return nil
+}
+
+// processStoreCalendarUpdated processes StoreCalendarUpdated event. A null calendar
+// removes the store's calendar.
+func (p *EventProcessor) processStoreCalendarUpdated(ctx context.Context, eventData []byte) error {
+	var event struct {
+		StoreID  string `json:"storeId"`
+		Code     string `json:"code"`
+		Calendar *struct {
+			Timezone string          `json:"timezone"`
+			Hours    json.RawMessage `json:"hours"`
+			Holidays json.RawMessage `json:"holidays"`
+		} `json:"calendar"`
+	}
+
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	storeID, err := uuid.Parse(event.StoreID)
+	if err != nil {
+		return fmt.Errorf("invalid store ID: %w", err)
+	}
+
+	if event.Calendar == nil {
+		if err := p.db.DeleteStoreCalendar(ctx, storeID.String()); err != nil {
+			return fmt.Errorf("failed to delete store calendar: %w", err)
+		}
+	} else {
+		calendar := &database.StoreCalendar{
+			StoreID:  storeID.String(),
+			Timezone: event.Calendar.Timezone,
+			Hours:    jsonArray(event.Calendar.Hours),
+			Holidays: jsonArray(event.Calendar.Holidays),
+		}
+		if err := p.db.SaveStoreCalendar(ctx, calendar); err != nil {
+			return fmt.Errorf("failed to save store calendar: %w", err)
+		}
+	}
+
+	p.logger.Info("Store calendar updated", zap.String("store_id", storeID.String()), zap.Bool("removed", event.Calendar == nil))
+
+	p.publishStoreConfirmation(ctx, "StoreCalendarUpdated", storeID.String(), map[string]interface{}{
+		"storeId": storeID.String(),
+		"code":    event.Code,
+		"removed": event.Calendar == nil,
+	})
+
+	return nil
+}
+
+// processSupplierSaved processes SupplierCreated and SupplierUpdated events, which carry
+// the whole supplier
+func (p *EventProcessor) processSupplierSaved(ctx context.Context, eventType string, eventData []byte) error {
+	var event struct {
+		SupplierID string    `json:"supplierId"`
+		Code       string    `json:"code"`
+		Name       string    `json:"name"`
+		Email      string    `json:"email"`
+		Phone      string    `json:"phone"`
+		Active     bool      `json:"active"`
+		OccurredAt time.Time `json:"occurredAt"`
+	}
+
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	supplierID, err := uuid.Parse(event.SupplierID)
+	if err != nil {
+		return fmt.Errorf("invalid supplier ID: %w", err)
+	}
+
+	supplier := &database.Supplier{
+		ID:        supplierID.String(),
+		Code:      event.Code,
+		Name:      event.Name,
+		Email:     event.Email,
+		Phone:     event.Phone,
+		Active:    event.Active,
+		UpdatedAt: occurredAtOrNow(event.OccurredAt),
+	}
+	if err := p.db.SaveSupplier(ctx, supplier); err != nil {
+		return fmt.Errorf("failed to save supplier: %w", err)
+	}
+
+	p.logger.Info("Supplier saved", zap.String("supplier_id", supplier.ID), zap.String("code", event.Code))
+
+	p.publishStoreConfirmation(ctx, eventType, supplier.ID, map[string]interface{}{
+		"supplierId": supplier.ID,
+		"code":       event.Code,
+		"name":       event.Name,
+		"active":     event.Active,
+	})
+
+	return nil
+}
+
+// processSupplierDeleted processes SupplierDeleted event
+func (p *EventProcessor) processSupplierDeleted(ctx context.Context, eventData []byte) error {
+	var event struct {
+		SupplierID string `json:"supplierId"`
+		Code       string `json:"code"`
+	}
+
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	supplierID, err := uuid.Parse(event.SupplierID)
+	if err != nil {
+		return fmt.Errorf("invalid supplier ID: %w", err)
+	}
+
+	if err := p.db.DeleteSupplier(ctx, supplierID.String()); err != nil {
+		return fmt.Errorf("failed to delete supplier: %w", err)
+	}
+
+	p.logger.Info("Supplier deleted", zap.String("supplier_id", supplierID.String()))
+
+	p.publishStoreConfirmation(ctx, "SupplierDeleted", supplierID.String(), map[string]interface{}{
+		"supplierId": supplierID.String(),
+		"code":       event.Code,
+	})
+
+	return nil
+}
+
+// processPurchaseOrderCreated processes PurchaseOrderCreated event
+func (p *EventProcessor) processPurchaseOrderCreated(ctx context.Context, eventData []byte) error {
+	var event struct {
+		PurchaseOrderID string     `json:"purchaseOrderId"`
+		Number          string     `json:"number"`
+		SupplierID      string     `json:"supplierId"`
+		Status          string     `json:"status"`
+		ExpectedAt      *time.Time `json:"expectedAt"`
+		Notes           string     `json:"notes"`
+		Lines           []struct {
+			ItemID   string   `json:"itemId"`
+			SKU      string   `json:"sku"`
+			Quantity int      `json:"quantity"`
+			UnitCost *float64 `json:"unitCost"`
+		} `json:"lines"`
+		OccurredAt time.Time `json:"occurredAt"`
+	}
+
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	orderID, err := uuid.Parse(event.PurchaseOrderID)
+	if err != nil {
+		return fmt.Errorf("invalid purchase order ID: %w", err)
+	}
+	supplierID, err := uuid.Parse(event.SupplierID)
+	if err != nil {
+		return fmt.Errorf("invalid supplier ID: %w", err)
+	}
+
+	order := &database.PurchaseOrder{
+		ID:         orderID.String(),
+		Number:     event.Number,
+		SupplierID: supplierID.String(),
+		Status:     event.Status,
+		ExpectedAt: event.ExpectedAt,
+		Notes:      event.Notes,
+		Lines:      make([]database.PurchaseOrderLine, 0, len(event.Lines)),
+		CreatedAt:  occurredAtOrNow(event.OccurredAt),
+	}
+	for _, line := range event.Lines {
+		itemID, err := uuid.Parse(line.ItemID)
+		if err != nil {
+			return fmt.Errorf("invalid item ID: %w", err)
+		}
+		order.Lines = append(order.Lines, database.PurchaseOrderLine{
+			ItemID:   itemID.String(),
+			SKU:      line.SKU,
+			Ordered:  line.Quantity,
+			UnitCost: line.UnitCost,
+		})
+	}
+	if err := p.db.CreatePurchaseOrder(ctx, order); err != nil {
+		return fmt.Errorf("failed to create purchase order: %w", err)
+	}
+
+	p.logger.Info("Purchase order created",
+		zap.String("purchase_order_id", order.ID),
+		zap.String("number", order.Number),
+		zap.Int("lines", len(order.Lines)),
+	)
+
+	p.publishStoreConfirmation(ctx, "PurchaseOrderCreated", order.ID, map[string]interface{}{
+		"purchaseOrderId": order.ID,
+		"number":          order.Number,
+		"supplierId":      order.SupplierID,
+		"status":          order.Status,
+	})
+
+	return nil
+}
+
+// processPurchaseOrderReceived processes PurchaseOrderReceived event. The stock itself
+// arrives as StockAdjusted events (reason po_receipt); this records the received totals
+// and the status of the order.
+func (p *EventProcessor) processPurchaseOrderReceived(ctx context.Context, eventData []byte) error {
+	var event struct {
+		PurchaseOrderID string `json:"purchaseOrderId"`
+		Number          string `json:"number"`
+		Status          string `json:"status"`
+		Lines           []struct {
+			ItemID   string `json:"itemId"`
+			Quantity int    `json:"quantity"`
+			Received int    `json:"received"`
+		} `json:"lines"`
+		OccurredAt time.Time `json:"occurredAt"`
+	}
+
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	orderID, err := uuid.Parse(event.PurchaseOrderID)
+	if err != nil {
+		return fmt.Errorf("invalid purchase order ID: %w", err)
+	}
+
+	lines := make([]database.PurchaseOrderLine, 0, len(event.Lines))
+	for _, line := range event.Lines {
+		itemID, err := uuid.Parse(line.ItemID)
+		if err != nil {
+			return fmt.Errorf("invalid item ID: %w", err)
+		}
+		lines = append(lines, database.PurchaseOrderLine{ItemID: itemID.String(), Received: line.Received})
+	}
+	if err := p.db.ReceivePurchaseOrder(ctx, orderID.String(), event.Status, lines, occurredAtOrNow(event.OccurredAt)); err != nil {
+		return fmt.Errorf("failed to receive purchase order: %w", err)
+	}
+
+	p.logger.Info("Purchase order received",
+		zap.String("purchase_order_id", orderID.String()),
+		zap.String("status", event.Status),
+		zap.Int("lines", len(lines)),
+	)
+
+	p.publishStoreConfirmation(ctx, "PurchaseOrderReceived", orderID.String(), map[string]interface{}{
+		"purchaseOrderId": orderID.String(),
+		"number":          event.Number,
+		"status":          event.Status,
+	})
+
+	return nil
 }
 
+// processPurchaseOrderCancelled processes PurchaseOrderCancelled event
+func (p *EventProcessor) processPurchaseOrderCancelled(ctx context.Context, eventData []byte) error {
+	var event struct {
+		PurchaseOrderID string    `json:"purchaseOrderId"`
+		Number          string    `json:"number"`
+		Status          string    `json:"status"`
+		OccurredAt      time.Time `json:"occurredAt"`
+	}
+
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	orderID, err := uuid.Parse(event.PurchaseOrderID)
+	if err != nil {
+		return fmt.Errorf("invalid purchase order ID: %w", err)
+	}
+
+	if err := p.db.SetPurchaseOrderStatus(ctx, orderID.String(), event.Status, occurredAtOrNow(event.OccurredAt)); err != nil {
+		return fmt.Errorf("failed to cancel purchase order: %w", err)
+	}
+
+	p.logger.Info("Purchase order cancelled", zap.String("purchase_order_id", orderID.String()))
+
+	p.publishStoreConfirmation(ctx, "PurchaseOrderCancelled", orderID.String(), map[string]interface{}{
+		"purchaseOrderId": orderID.String(),
+		"number":          event.Number,
+		"status":          event.Status,
+	})
+
+	return nil
+}
+
+// occurredAtOrNow returns the time an event occurred, or now for events without one
+func occurredAtOrNow(occurredAt time.Time) time.Time {
+	if occurredAt.IsZero() {
+		return time.Now().UTC()
+	}
+	return occurredAt
+}
+
+// jsonArray returns a JSON array of the event as text, "[]" when it is missing or null
+func jsonArray(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "[]"
+	}
+	return string(raw)
+}
+
+// processStoreReservationCreated processes StoreReservationCreated event.
+// The item's reserved stock and the store reservation row are written together.
+func (p *EventProcessor) processStoreReservationCreated(ctx context.Context, eventData []byte) error {
+	var event struct {
+		ReservationID string    `json:"reservationId"`
+		StoreID       string    `json:"storeId"`
+		ItemID        string    `json:"itemId"`
+		Quantity      int       `json:"quantity"`
+		OccurredAt    time.Time `json:"occurredAt"`
+		stockNote
+	}
+
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	reservationID, err := uuid.Parse(event.ReservationID)
+	if err != nil {
+		return fmt.Errorf("invalid reservation ID: %w", err)
+	}
+	storeID, err := uuid.Parse(event.StoreID)
+	if err != nil {
+		return fmt.Errorf("invalid store ID: %w", err)
+	}
+	itemID, err := uuid.Parse(event.ItemID)
+	if err != nil {
+		return fmt.Errorf("invalid item ID: %w", err)
+	}
+
+	// Get current item to get version
+	currentItem, err := p.db.GetItem(ctx, itemID.String())
+	if err != nil {
+		return fmt.Errorf("failed to get item for store reservation: %w", err)
+	}
+
+	reservedAt := event.OccurredAt
+	if reservedAt.IsZero() {
+		reservedAt = time.Now()
+	}
+
+	reservation := &database.StoreReservation{
+		ID:         reservationID.String(),
+		StoreID:    storeID.String(),
+		ItemID:     itemID.String(),
+		Quantity:   event.Quantity,
+		Status:     "active",
+		ReservedAt: reservedAt,
+	}
+
+	if err := p.db.ReserveStockForStore(ctx, reservation, currentItem.Version); err != nil {
+		return fmt.Errorf("failed to reserve stock for store: %w", err)
+	}
+
+	p.logger.Info("Store reservation created",
+		zap.String("reservation_id", reservationID.String()),
+		zap.String("store_id", storeID.String()),
+		zap.String("item_id", itemID.String()),
+		zap.Int("quantity", event.Quantity),
+	)
+
+	// Get updated item to publish confirmation event
+	updatedItem, err := p.db.GetItem(ctx, itemID.String())
+	if err == nil {
+		p.recordMovementWithNote(ctx, "StoreReservationCreated", updatedItem, storeID.String(), 0, event.Quantity, reservedAt, event.stockNote)
+	}
+	if err == nil && p.producer != nil {
+		confirmationData := map[string]interface{}{
+			"reservationId": reservationID.String(),
+			"storeId":       storeID.String(),
+			"itemId":        itemID.String(),
+			"sku":           updatedItem.SKU,
+			"quantity":      updatedItem.Quantity,
+			"reserved":      updatedItem.Reserved,
+			"available":     updatedItem.Available,
+		}
+		if err := p.producer.PublishConfirmationEvent(ctx, "StoreReservationCreated", itemID.String(), updatedItem.SKU, confirmationData); err != nil {
+			p.logger.Warn("Failed to publish confirmation event", zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// processStoreReservationReleased processes StoreReservationReleased event
+func (p *EventProcessor) processStoreReservationReleased(ctx context.Context, eventData []byte) error {
+	var event struct {
+		StoreID    string    `json:"storeId"`
+		ItemID     string    `json:"itemId"`
+		Quantity   int       `json:"quantity"`
+		OccurredAt time.Time `json:"occurredAt"`
+		stockNote
+	}
+
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	storeID, err := uuid.Parse(event.StoreID)
+	if err != nil {
+		return fmt.Errorf("invalid store ID: %w", err)
+	}
+	itemID, err := uuid.Parse(event.ItemID)
+	if err != nil {
+		return fmt.Errorf("invalid item ID: %w", err)
+	}
+
+	// Get current item to get version
+	currentItem, err := p.db.GetItem(ctx, itemID.String())
+	if err != nil {
+		return fmt.Errorf("failed to get item for store release: %w", err)
+	}
+
+	if err := p.db.ReleaseStockForStore(ctx, storeID.String(), itemID.String(), event.Quantity, currentItem.Version); err != nil {
+		return fmt.Errorf("failed to release stock for store: %w", err)
+	}
+
+	p.logger.Info("Store reservation released",
+		zap.String("store_id", storeID.String()),
+		zap.String("item_id", itemID.String()),
+		zap.Int("quantity", event.Quantity),
+	)
+
+	// Get updated item to publish confirmation event
+	updatedItem, err := p.db.GetItem(ctx, itemID.String())
+	if err == nil {
+		p.recordMovementWithNote(ctx, "StoreReservationReleased", updatedItem, storeID.String(), 0, -event.Quantity, event.OccurredAt, event.stockNote)
+	}
+	if err == nil && p.producer != nil {
+		confirmationData := map[string]interface{}{
+			"storeId":   storeID.String(),
+			"itemId":    itemID.String(),
+			"sku":       updatedItem.SKU,
+			"quantity":  updatedItem.Quantity,
+			"reserved":  updatedItem.Reserved,
+			"available": updatedItem.Available,
+		}
+		if err := p.producer.PublishConfirmationEvent(ctx, "StoreReservationReleased", itemID.String(), updatedItem.SKU, confirmationData); err != nil {
+			p.logger.Warn("Failed to publish confirmation event", zap.Error(err))
+		}
+	}
+
+	p.fulfillWaitlist(ctx, itemID.String())
+
+	return nil
+}
+
+// processReservationWaitlisted processes ReservationWaitlisted event.
+// The entry is queued and fulfilled right away if stock was freed in the meantime.
+func (p *EventProcessor) processReservationWaitlisted(ctx context.Context, eventData []byte) error {
+	var event struct {
+		WaitlistID string    `json:"waitlistId"`
+		ItemID     string    `json:"itemId"`
+		Quantity   int       `json:"quantity"`
+		OccurredAt time.Time `json:"occurredAt"`
+	}
+
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal event: %w", err)
+	}
+
+	waitlistID, err := uuid.Parse(event.WaitlistID)
+	if err != nil {
+		return fmt.Errorf("invalid waitlist ID: %w", err)
+	}
+	itemID, err := uuid.Parse(event.ItemID)
+	if err != nil {
+		return fmt.Errorf("invalid item ID: %w", err)
+	}
+
+	requestedAt := event.OccurredAt
+	if requestedAt.IsZero() {
+		requestedAt = time.Now()
+	}
+
+	entry := &database.WaitlistEntry{
+		ID:          waitlistID.String(),
+		ItemID:      itemID.String(),
+		Quantity:    event.Quantity,
+		RequestedAt: requestedAt,
+	}
+	if err := p.db.EnqueueWaitlist(ctx, entry); err != nil {
+		return fmt.Errorf("failed to enqueue reservation: %w", err)
+	}
+
+	p.logger.Info("Reservation waitlisted",
+		zap.String("waitlist_id", waitlistID.String()),
+		zap.String("item_id", itemID.String()),
+		zap.Int("quantity", event.Quantity),
+	)
+
+	if p.producer != nil {
+		confirmationData := map[string]interface{}{
+			"waitlistId":        waitlistID.String(),
+			"itemId":            itemID.String(),
+			"requestedQuantity": event.Quantity,
+		}
+		if err := p.producer.PublishConfirmationEvent(ctx, "ReservationWaitlisted", itemID.String(), "", confirmationData); err != nil {
+			p.logger.Warn("Failed to publish confirmation event", zap.Error(err))
+		}
+	}
+
+	p.fulfillWaitlist(ctx, itemID.String())
+
+	return nil
+}
+
+// fulfillWaitlist reserves stock for waitlisted reservations of the item (FIFO) and
+// notifies each fulfillment with a ReservationWaitlistFulfilled confirmation.
+// The stock event that freed the stock is already applied, so failures are only logged.
+func (p *EventProcessor) fulfillWaitlist(ctx context.Context, itemID string) {
+	fulfilled, err := p.db.FulfillWaitlist(ctx, itemID)
+	if err != nil {
+		p.logger.Warn("Failed to fulfill waitlist", zap.String("item_id", itemID), zap.Error(err))
+		return
+	}
+	if len(fulfilled) == 0 {
+		return
+	}
+
+	item, err := p.db.GetItem(ctx, itemID)
+	if err != nil {
+		p.logger.Warn("Failed to get item after waitlist fulfillment", zap.String("item_id", itemID), zap.Error(err))
+		return
+	}
+
+	// Rebuild the totals after each entry so the history shows one movement per reservation
+	snapshot := *item
+	for _, entry := range fulfilled {
+		snapshot.Reserved -= entry.Quantity
+	}
+	for _, entry := range fulfilled {
+		snapshot.Reserved += entry.Quantity
+		snapshot.Available = snapshot.Quantity - snapshot.Reserved
+		p.recordMovement(ctx, "ReservationWaitlistFulfilled", &snapshot, "", 0, entry.Quantity, *entry.FulfilledAt)
+
+		p.logger.Info("Waitlisted reservation fulfilled",
+			zap.String("waitlist_id", entry.ID),
+			zap.String("item_id", itemID),
+			zap.Int("quantity", entry.Quantity),
+		)
+
+		if p.producer != nil {
+			confirmationData := map[string]interface{}{
+				"waitlistId":        entry.ID,
+				"itemId":            itemID,
+				"sku":               item.SKU,
+				"requestedQuantity": entry.Quantity,
+				"quantity":          snapshot.Quantity,
+				"reserved":          snapshot.Reserved,
+				"available":         snapshot.Available,
+			}
+			if err := p.producer.PublishConfirmationEvent(ctx, "ReservationWaitlistFulfilled", itemID, item.SKU, confirmationData); err != nil {
+				p.logger.Warn("Failed to publish confirmation event", zap.Error(err))
+			}
+		}
+	}
+}
+
+// publishStoreConfirmation publishes a confirmation for a store event.
+// Store events carry no item, so the store ID is used as the message key.
+func (p *EventProcessor) publishStoreConfirmation(ctx context.Context, eventType, storeID string, data map[string]interface{}) {
+	if p.producer == nil {
+		return
+	}
+	if err := p.producer.PublishConfirmationEvent(ctx, eventType, storeID, "", data); err != nil {
+		p.logger.Warn("Failed to publish confirmation event", zap.Error(err))
+	}
+}
+
+// publishItemRelationConfirmation publishes a confirmation for an item relation event,
+// keyed by the item the relation hangs from
+func (p *EventProcessor) publishItemRelationConfirmation(ctx context.Context, eventType string, event *itemRelationEvent) {
+	if p.producer == nil {
+		return
+	}
+	data := map[string]interface{}{
+		"itemId":        event.ItemID,
+		"relatedItemId": event.RelatedItemID,
+		"relation":      event.Relation,
+	}
+	if err := p.producer.PublishConfirmationEvent(ctx, eventType, event.ItemID, "", data); err != nil {
+		p.logger.Warn("Failed to publish confirmation event", zap.Error(err))
+	}
+}
+
+// publishItemImageConfirmation publishes a confirmation for an item image event
+func (p *EventProcessor) publishItemImageConfirmation(ctx context.Context, eventType, itemID, imageID string) {
+	if p.producer == nil {
+		return
+	}
+	data := map[string]interface{}{
+		"itemId":  itemID,
+		"imageId": imageID,
+	}
+	if err := p.producer.PublishConfirmationEvent(ctx, eventType, itemID, "", data); err != nil {
+		p.logger.Warn("Failed to publish confirmation event", zap.Error(err))
+	}
+}
+
+// publishItemIdentifierConfirmation publishes a confirmation for an item identifier
+// event. The Query Service drops its cached lookup of the code.
+func (p *EventProcessor) publishItemIdentifierConfirmation(ctx context.Context, eventType, itemID, code string) {
+	if p.producer == nil {
+		return
+	}
+	data := map[string]interface{}{
+		"itemId": itemID,
+		"code":   code,
+	}
+	if err := p.producer.PublishConfirmationEvent(ctx, eventType, itemID, "", data); err != nil {
+		p.logger.Warn("Failed to publish confirmation event", zap.Error(err))
+	}
+}
+
+// addLocationStock adds the stock left at location to a confirmation, so consumers of
+// the confirmations see both the item totals and the location that changed
+func (p *EventProcessor) addLocationStock(ctx context.Context, data map[string]interface{}, itemID, location string) {
+	if location == "" {
+		return
+	}
+	data["location"] = location
+	stock, err := p.db.GetStockLocation(ctx, itemID, location)
+	if err != nil {
+		p.logger.Warn("Failed to read location stock for confirmation", zap.String("location", location), zap.Error(err))
+		return
+	}
+	data["locationQuantity"] = stock.Quantity
+	data["locationReserved"] = stock.Reserved
+}
+
+// recordCostLayers keeps the valuation cost layers in line with a stock change:
+// receipts add a layer, outgoing stock consumes layers oldest first.
+// Valuation is secondary to stock, so failures are logged and not returned.
+func (p *EventProcessor) recordCostLayers(ctx context.Context, itemID string, quantity int, unitCost *float64) {
+	var err error
+	switch {
+	case quantity > 0:
+		err = p.db.RecordStockReceipt(ctx, itemID, quantity, unitCost, time.Now())
+	case quantity < 0:
+		err = p.db.ConsumeCostLayers(ctx, itemID, -quantity)
+	}
+	if err != nil {
+		p.logger.Warn("Failed to update cost layers",
+			zap.String("item_id", itemID),
+			zap.Int("quantity", quantity),
+			zap.Error(err),
+		)
+	}
+}
+
+// recordMovement appends a stock movement for an applied event. item holds the
+// totals after the change. Like cost layers, history failures are only logged.
+func (p *EventProcessor) recordMovement(ctx context.Context, movementType string, item *database.InventoryItem, storeID string, quantityChange, reservedChange int, occurredAt time.Time) {
+	p.recordMovementWithNote(ctx, movementType, item, storeID, quantityChange, reservedChange, occurredAt, stockNote{})
+}
+
+// stockNote is the optional attribution the Command Service adds to stock events
+type stockNote struct {
+	Reason    string `json:"reason"`    // Reason code of the operation
+	Reference string `json:"reference"` // External reference, e.g. an order ID
+	Actor     string `json:"actor"`     // User that issued the command
+}
+
+// recordMovementWithNote is recordMovement for movements that carry a reason, a
+// reference or the actor in the event itself
+func (p *EventProcessor) recordMovementWithNote(ctx context.Context, movementType string, item *database.InventoryItem, storeID string, quantityChange, reservedChange int, occurredAt time.Time, note stockNote) {
+	// A producer whose clock runs ahead would date the movement after ones applied later;
+	// within the tolerance checked by the consumer, such times are clamped to now
+	now := time.Now().UTC()
+	if occurredAt.IsZero() || occurredAt.After(now) {
+		occurredAt = now
+	}
+	occurredAt = occurredAt.UTC()
+
+	movement := &database.StockMovement{
+		ItemID:         item.ID,
+		StoreID:        storeID,
+		MovementType:   movementType,
+		QuantityChange: quantityChange,
+		ReservedChange: reservedChange,
+		QuantityAfter:  item.Quantity,
+		ReservedAfter:  item.Reserved,
+		AvailableAfter: item.Quantity - item.Reserved,
+		OccurredAt:     occurredAt,
+		Reason:         note.Reason,
+		Reference:      note.Reference,
+	}
+	movement.Actor, movement.RequestID = database.AttributionFromContext(ctx)
+	// Events republished from the Command Service journal have no actor header
+	if movement.Actor == "" {
+		movement.Actor = note.Actor
+	}
+	if err := p.db.RecordStockMovement(ctx, movement); err != nil {
+		p.logger.Warn("Failed to record stock movement",
+			zap.String("item_id", item.ID),
+			zap.String("movement_type", movementType),
+			zap.Error(err),
+		)
+	}
+}
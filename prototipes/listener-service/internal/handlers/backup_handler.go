@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"listener-service/internal/backup"
+	"listener-service/internal/rebuild"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// BackupHandler lists, takes and restores backups of the read model
+type BackupHandler struct {
+	backups   *backup.Manager // nil when BACKUP_ENABLED=false
+	rebuilder *rebuild.Rebuilder
+	logger    *zap.Logger
+}
+
+func NewBackupHandler(backups *backup.Manager, rebuilder *rebuild.Rebuilder, logger *zap.Logger) *BackupHandler {
+	return &BackupHandler{
+		backups:   backups,
+		rebuilder: rebuilder,
+		logger:    logger,
+	}
+}
+
+// ListBackups godoc
+// @Summary      List read model backups
+// @Description  Lista los backups del read model SQLite, del más reciente al más antiguo, con su fecha, tamaño y ubicación (archivo en `BACKUP_DIR` o `s3://bucket/clave`).
+// @Tags         monitoring
+// @Produce      json
+// @Success      200  {object}  BackupListResponse  "Backups disponibles"
+// @Failure      409  {object}  ErrorResponse       "Backups deshabilitados (BACKUP_ENABLED=false)"
+// @Failure      500  {object}  ErrorResponse       "Error al listar los backups"
+// @Router       /monitoring/backups [get]
+func (h *BackupHandler) ListBackups(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+	backups, err := h.backups.List(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list backups", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list backups"})
+		return
+	}
+	if backups == nil {
+		backups = []backup.Info{}
+	}
+	c.JSON(http.StatusOK, BackupListResponse{Backups: backups, Count: len(backups)})
+}
+
+// CreateBackup godoc
+// @Summary      Take a read model backup now
+// @Description  Toma un backup del read model sin esperar al siguiente programado (`BACKUP_INTERVAL_MINUTES`). El consumer se pausa mientras se copia la base de datos, para que el backup registre hasta qué offset llega cada partición.
+// @Tags         admin
+// @Produce      json
+// @Success      201  {object}  backup.Info    "Backup tomado"
+// @Failure      409  {object}  ErrorResponse  "Backups deshabilitados (BACKUP_ENABLED=false)"
+// @Failure      500  {object}  ErrorResponse  "Error al tomar el backup"
+// @Router       /admin/backups [post]
+func (h *BackupHandler) CreateBackup(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+	info, err := h.backups.Backup(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, info)
+}
+
+// Restore godoc
+// @Summary      Restore the read model from a backup
+// @Description  Reemplaza el read model por un backup y reproduce desde Kafka los eventos publicados después de tomarlo, para volver a un estado sano sin reproducir toda la historia. Corre en segundo plano como una reconstrucción `mode: "restore"`: el progreso se consulta con `GET /admin/rebuild`.
+// @Description
+// @Description  El consumer queda pausado hasta terminar y luego sigue después de lo reproducido. El rol de replicación actual se conserva. Requiere `EVENT_BUS=kafka` y `DB_DRIVER=sqlite`.
+// @Description
+// @Description  **Ejemplos válidos:**
+// @Description  - `{}` (backup más reciente)
+// @Description  - `{"backup": "inventory-20240115T130000Z"}`
+// @Description
+// @Description  **Ejemplos inválidos:**
+// @Description  - `{"backup": "../inventory.db"}` (no es un nombre de backup)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body      RestoreRequest    false  "Backup a restaurar"
+// @Success      202      {object}  rebuild.Progress  "Restauración iniciada"
+// @Failure      400      {object}  ErrorResponse     "Body o nombre de backup inválido"
+// @Failure      404      {object}  ErrorResponse     "El backup no existe"
+// @Failure      409      {object}  ErrorResponse     "Backups deshabilitados, reconstrucción en curso, o el listener no puede restaurar (bus distinto de Kafka, mock o dry-run)"
+// @Failure      500      {object}  ErrorResponse     "Error al obtener el backup"
+// @Router       /admin/restore [post]
+func (h *BackupHandler) Restore(c *gin.Context) {
+	var req RestoreRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if !h.enabled(c) {
+		return
+	}
+
+	source, err := h.backups.Fetch(c.Request.Context(), req.Backup)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, backup.ErrInvalidName):
+			status = http.StatusBadRequest
+		case errors.Is(err, backup.ErrNotFound):
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	progress, err := h.rebuilder.StartRestore(source)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	h.logger.Warn("Read model restore requested",
+		zap.String("backup", source.Name),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusAccepted, progress)
+}
+
+// enabled answers 409 when backups are disabled
+func (h *BackupHandler) enabled(c *gin.Context) bool {
+	if h.backups == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "backups are disabled (BACKUP_ENABLED=false)"})
+		return false
+	}
+	return true
+}
@@ -1,9 +1,20 @@
 package handlers
 
+import (
+	"listener-service/internal/backup"
+	"listener-service/internal/kafka"
+)
+
 // StatsResponse represents statistics response
 type StatsResponse struct {
 	Status string                 `json:"status" example:"ok"`
-	Stats  map[string]interface{} `json:"stats"`
+	Stats  map[string]interface{} `json:"stats"` // read model counts
+	// Consumer since startup
+	UptimeSeconds float64                `json:"uptime_seconds" example:"3600"`
+	Processed     int64                  `json:"processed" example:"832"`
+	DeadLettered  int64                  `json:"dead_lettered" example:"2"`
+	DLQDepth      *int64                 `json:"dlq_depth,omitempty" example:"2"` // messages in DLQ_TOPIC, when Kafka can be read
+	EventTypes    []kafka.EventTypeStats `json:"event_types"`
 }
 
 // DatabaseStatusResponse represents database status response
@@ -20,4 +31,18 @@ type ErrorResponse struct {
 	Error string `json:"error" example:"error message"`
 }
 
+// RoleChangeRequest names the region being promoted or demoted
+type RoleChangeRequest struct {
+	Region string `json:"region" binding:"required" example:"eu-west-1"`
+}
 
+// BackupListResponse lists the backups of the read model, newest first
+type BackupListResponse struct {
+	Backups []backup.Info `json:"backups"`
+	Count   int           `json:"count" example:"24"`
+}
+
+// RestoreRequest names the backup to restore; the newest one when empty
+type RestoreRequest struct {
+	Backup string `json:"backup" example:"inventory-20240115T130000Z"`
+}
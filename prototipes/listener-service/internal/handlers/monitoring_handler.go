@@ -4,26 +4,33 @@ import (
 	"net/http"
 
 	"listener-service/internal/database"
+	"listener-service/internal/kafka"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 type MonitoringHandler struct {
-	db     *database.SingleWriterDB
-	logger *zap.Logger
+	db       database.WriterDB
+	consumer *kafka.Consumer
+	logger   *zap.Logger
 }
 
-func NewMonitoringHandler(db *database.SingleWriterDB, logger *zap.Logger) *MonitoringHandler {
+func NewMonitoringHandler(db database.WriterDB, consumer *kafka.Consumer, logger *zap.Logger) *MonitoringHandler {
 	return &MonitoringHandler{
-		db:     db,
-		logger: logger,
+		db:       db,
+		consumer: consumer,
+		logger:   logger,
 	}
 }
 
 // GetStats godoc
 // @Summary      Get service statistics
-// @Description  Obtiene estadísticas del servicio incluyendo conteo de items, tiendas y reservas
+// @Description  Obtiene estadísticas del servicio: conteo de items, tiendas y reservas del read model, y del consumer desde el arranque por tipo de evento los aplicados, fallidos, omitidos, reintentos y enviados a la DLQ, la latencia de procesamiento p50/p95 y el último error con su fecha.
+// @Description
+// @Description  - Las latencias se calculan sobre los últimos 1000 eventos de cada tipo, reintentos incluidos
+// @Description  - `dlq_depth`: mensajes en `DLQ_TOPIC`, leídos de Kafka; se omite si el topic no existe o el bus no es Kafka
+// @Description  - Con `STATS_PROMETHEUS_EXPORT=true` las latencias, el último error, `dlq_depth` y `uptime_seconds` también se exportan en `/metrics`
 // @Tags         monitoring
 // @Accept       json
 // @Produce      json
@@ -63,15 +70,21 @@ func (h *MonitoringHandler) GetStats(c *gin.Context) {
 	}
 	stats["active_reservations"] = reservationsCount
 
-	c.JSON(http.StatusOK, gin.H{
-		"status": "ok",
-		"stats":  stats,
+	consumer := h.consumer.Stats()
+	c.JSON(http.StatusOK, StatsResponse{
+		Status:        "ok",
+		Stats:         stats,
+		UptimeSeconds: consumer.UptimeSeconds,
+		Processed:     consumer.Processed,
+		DeadLettered:  consumer.DeadLettered,
+		DLQDepth:      consumer.DLQDepth,
+		EventTypes:    consumer.EventTypes,
 	})
 }
 
 // GetDatabaseStatus godoc
 // @Summary      Get database status
-// @Description  Obtiene el estado de la base de datos SQLite
+// @Description  Obtiene el estado de la base de datos (SQLite o PostgreSQL)
 // @Tags         monitoring
 // @Accept       json
 // @Produce      json
@@ -93,8 +106,22 @@ func (h *MonitoringHandler) GetDatabaseStatus(c *gin.Context) {
 		Status: "ok",
 	}
 	response.Database.Connected = true
-	response.Database.Type = "sqlite"
+	response.Database.Type = h.db.Driver()
 
 	c.JSON(http.StatusOK, response)
 }
 
+// GetConsumer godoc
+// @Summary      Get Kafka consumer progress
+// @Description  Retorna el progreso del consumer desde el arranque: por partición el último offset procesado, el high-water mark, el lag y el retraso entre la escritura en el Command Service y su aplicación en el read model; por tipo de evento los aplicados, fallidos, omitidos, reintentos y enviados a la DLQ, la latencia p50/p95 y el último error.
+// @Description
+// @Description  - `total_lag`: mensajes pendientes sumando todas las particiones
+// @Description  - `delay_seconds`: antigüedad del último evento de la partición al aplicarlo
+// @Description  - `dead_letter_failures`: eventos fallidos que no se pudieron enviar a la DLQ
+// @Tags         monitoring
+// @Produce      json
+// @Success      200  {object}  kafka.ConsumerSnapshot  "Progreso del consumer"
+// @Router       /monitoring/consumer [get]
+func (h *MonitoringHandler) GetConsumer(c *gin.Context) {
+	c.JSON(http.StatusOK, h.consumer.Stats())
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"listener-service/internal/rebuild"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RebuildHandler starts read model rebuilds and reports their progress
+type RebuildHandler struct {
+	rebuilder *rebuild.Rebuilder
+	logger    *zap.Logger
+}
+
+func NewRebuildHandler(rebuilder *rebuild.Rebuilder, logger *zap.Logger) *RebuildHandler {
+	return &RebuildHandler{
+		rebuilder: rebuilder,
+		logger:    logger,
+	}
+}
+
+// StartRebuild godoc
+// @Summary      Rebuild the read model from Kafka
+// @Description  Reconstruye el read model reproduciendo los eventos de Kafka desde un offset o una fecha, para recuperarse de un read model corrupto. Corre en segundo plano: el progreso se consulta con `GET /admin/rebuild`.
+// @Description
+// @Description  - `mode: "shadow"` (default): escribe una copia SQLite nueva en `REBUILD_SHADOW_PATH` mientras el consumer sigue actualizando el read model; la copia llega hasta el final de los topics al iniciar
+// @Description  - `mode: "truncate"`: pausa el consumer, vacía el read model y lo reconstruye en su lugar; al terminar el consumer sigue después de lo reproducido. Las consultas ven un read model incompleto mientras dura
+// @Description  - `from_offset`: offset inicial en cada partición; `from_timestamp`: primer evento en o después de esa fecha; sin ninguno, desde el evento más antiguo que Kafka conserva
+// @Description
+// @Description  Los eventos reproducidos no publican confirmaciones ni rechazos, y los que fallan no se reintentan.
+// @Description
+// @Description  **Ejemplos válidos:**
+// @Description  - `{}` (copia shadow desde el inicio)
+// @Description  - `{"mode": "truncate", "from_timestamp": "2024-01-15T00:00:00Z"}`
+// @Description
+// @Description  **Ejemplos inválidos:**
+// @Description  - `{"mode": "merge"}` (modo desconocido)
+// @Description  - `{"from_offset": 10, "from_timestamp": "2024-01-15T00:00:00Z"}` (solo uno de los dos)
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body      rebuild.Request   false  "Modo y punto de partida"
+// @Success      202      {object}  rebuild.Progress  "Reconstrucción iniciada"
+// @Failure      400      {object}  ErrorResponse     "Body o modo inválido"
+// @Failure      409      {object}  ErrorResponse     "Ya hay una reconstrucción en curso, o el listener no puede reconstruir (bus distinto de Kafka, mock o dry-run)"
+// @Router       /admin/rebuild [post]
+func (h *RebuildHandler) StartRebuild(c *gin.Context) {
+	var req rebuild.Request
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	progress, err := h.rebuilder.Start(req)
+	if err != nil {
+		status := http.StatusConflict
+		if errors.Is(err, rebuild.ErrInvalidRequest) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	h.logger.Warn("Read model rebuild requested",
+		zap.String("mode", progress.Mode),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	c.JSON(http.StatusAccepted, progress)
+}
+
+// GetRebuild godoc
+// @Summary      Get read model rebuild progress
+// @Description  Retorna el estado de la última reconstrucción (`running`, `completed` o `failed`): mensajes reproducidos sobre el total, cuántos eventos se aplicaron o fallaron, y hasta qué offset llegó cada partición.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  rebuild.Progress  "Progreso de la reconstrucción"
+// @Failure      404  {object}  ErrorResponse     "No se inició ninguna reconstrucción"
+// @Router       /admin/rebuild [get]
+func (h *RebuildHandler) GetRebuild(c *gin.Context) {
+	progress, ok := h.rebuilder.Status()
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no rebuild has been started"})
+		return
+	}
+	c.JSON(http.StatusOK, progress)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"listener-service/internal/replication"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ReplicationHandler serves the replication lag and the promotion procedure
+type ReplicationHandler struct {
+	state  *replication.State
+	logger *zap.Logger
+}
+
+func NewReplicationHandler(state *replication.State, logger *zap.Logger) *ReplicationHandler {
+	return &ReplicationHandler{
+		state:  state,
+		logger: logger,
+	}
+}
+
+// GetStatus godoc
+// @Summary      Get replication status
+// @Description  Retorna el rol de esta región (primary/secondary) y cuánto va atrasado su read model respecto de los topics.
+// @Description
+// @Description  - `offset_lag`: mensajes pendientes sumando todas las particiones (detalle en `partitions`)
+// @Description  - `lag_seconds`: antigüedad del último evento aplicado mientras quedan mensajes pendientes; `0` al estar al día
+// @Tags         replication
+// @Produce      json
+// @Success      200  {object}  replication.Status  "Estado de replicación"
+// @Router       /replication/status [get]
+func (h *ReplicationHandler) GetStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.state.Status())
+}
+
+// Promote godoc
+// @Summary      Promote this region to primary
+// @Description  Convierte la región secundaria en primaria: desde ese momento publica los eventos de confirmación. El rol se guarda en la base de datos y sobrevive a reinicios.
+// @Description
+// @Description  El body debe nombrar la región de este listener, para evitar promover una instancia equivocada.
+// @Description
+// @Description  **Ejemplos válidos:**
+// @Description  - `{"region": "eu-west-1"}` (con `REGION=eu-west-1`)
+// @Description
+// @Description  **Ejemplos inválidos:**
+// @Description  - `{}` (región requerida)
+// @Description  - `{"region": "us-east-1"}` en el listener de `eu-west-1` (409)
+// @Tags         replication
+// @Accept       json
+// @Produce      json
+// @Param        request  body      RoleChangeRequest   true  "Región a promover"
+// @Success      200      {object}  replication.Status  "Región promovida (o ya era primaria)"
+// @Failure      400      {object}  ErrorResponse       "Body inválido"
+// @Failure      409      {object}  ErrorResponse       "Región distinta o base de datos de solo lectura"
+// @Failure      500      {object}  ErrorResponse       "Error al guardar el rol"
+// @Router       /replication/promote [post]
+func (h *ReplicationHandler) Promote(c *gin.Context) {
+	h.setRole(c, replication.RolePrimary)
+}
+
+// Demote godoc
+// @Summary      Demote this region to secondary
+// @Description  Devuelve la región a secundaria (p. ej. la antigua primaria al volver tras un failover). Deja de publicar eventos de confirmación pero sigue aplicando los eventos a su read model.
+// @Tags         replication
+// @Accept       json
+// @Produce      json
+// @Param        request  body      RoleChangeRequest   true  "Región a degradar"
+// @Success      200      {object}  replication.Status  "Región degradada (o ya era secundaria)"
+// @Failure      400      {object}  ErrorResponse       "Body inválido"
+// @Failure      409      {object}  ErrorResponse       "Región distinta o base de datos de solo lectura"
+// @Failure      500      {object}  ErrorResponse       "Error al guardar el rol"
+// @Router       /replication/demote [post]
+func (h *ReplicationHandler) Demote(c *gin.Context) {
+	h.setRole(c, replication.RoleSecondary)
+}
+
+func (h *ReplicationHandler) setRole(c *gin.Context, role string) {
+	var req RoleChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Region != h.state.Region() {
+		c.JSON(http.StatusConflict, gin.H{"error": "this listener serves region " + h.state.Region()})
+		return
+	}
+
+	if _, err := h.state.SetRole(c.Request.Context(), role); err != nil {
+		if errors.Is(err, replication.ErrReadOnly) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("Failed to change replication role", zap.String("role", role), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change replication role"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.state.Status())
+}
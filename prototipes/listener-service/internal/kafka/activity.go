@@ -0,0 +1,87 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"listener-service/internal/database"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// Headers set by the Command Service to attribute an event to the request that caused it
+const (
+	ActorHeader     = "actor"
+	RequestIDHeader = "request-id"
+	TenantHeader    = "tenant-id" // Tenant (retail brand) of the request; none means the default tenant
+)
+
+// ActivityRecorder stores the outcome of every consumed event for the activity feed.
+// It is implemented by database.WriterDB.
+type ActivityRecorder interface {
+	RecordActivity(ctx context.Context, entry *database.ActivityEntry) error
+}
+
+// activitySubject holds the fields of an event payload that identify what it acted on.
+// Version 1 payloads are PascalCase and version 2 payloads camelCase; encoding/json
+// matches both case-insensitively.
+type activitySubject struct {
+	ItemID   string
+	StoreID  string
+	SKU      string
+	Quantity *int
+}
+
+// recordActivity logs the outcome of a message in the activity log. Failures to record are
+// only logged: the activity feed must never block event processing. Inside a batch, ctx
+// makes the entry part of the batch transaction.
+func (h *consumerGroupHandler) recordActivity(ctx context.Context, message *sarama.ConsumerMessage, eventType string, eventData []byte, processErr error) {
+	if h.activity == nil {
+		return
+	}
+
+	entry := &database.ActivityEntry{
+		EventID:     headerValue(message.Headers, "event-id"),
+		EventType:   eventType,
+		Actor:       headerValue(message.Headers, ActorHeader),
+		RequestID:   headerValue(message.Headers, RequestIDHeader),
+		TenantID:    headerValue(message.Headers, TenantHeader),
+		Outcome:     database.ActivityApplied,
+		ProcessedAt: time.Now(),
+	}
+	if ts, err := time.Parse(time.RFC3339, headerValue(message.Headers, "timestamp")); err == nil {
+		entry.OccurredAt = ts.UTC()
+	}
+	if processErr != nil {
+		entry.Outcome = database.ActivityFailed
+		entry.Error = processErr.Error()
+	}
+
+	// Encrypted payloads that could not be decrypted are recorded without a subject
+	var subject activitySubject
+	if eventData != nil && json.Unmarshal(eventData, &subject) == nil {
+		entry.ItemID = subject.ItemID
+		entry.StoreID = subject.StoreID
+		entry.SKU = subject.SKU
+		entry.Quantity = subject.Quantity
+	}
+
+	if err := h.activity.RecordActivity(ctx, entry); err != nil {
+		h.logger.Warn("Failed to record activity",
+			zap.String("event_type", eventType),
+			zap.Error(err),
+		)
+	}
+}
+
+// headerValue returns the value of a Kafka header, or "" if it is not set
+func headerValue(headers []*sarama.RecordHeader, key string) string {
+	for _, header := range headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
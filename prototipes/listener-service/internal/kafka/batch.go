@@ -0,0 +1,180 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"listener-service/pkg/metrics"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+// BatchWriter applies many events in one write transaction. It is implemented by
+// database.WriterDB.
+type BatchWriter interface {
+	// Batch runs fn in a single transaction shared by every write made with its context
+	Batch(ctx context.Context, fn func(ctx context.Context) error) error
+	// Savepoint undoes the writes of fn, and only those, if it fails inside a batch
+	Savepoint(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// batchedEvent is a read message waiting for its batch to be flushed
+type batchedEvent struct {
+	message   *sarama.ConsumerMessage
+	eventType string
+	eventData []byte
+	applied   bool
+	duplicate bool // already applied before: skipped, not deferred
+}
+
+// consumeBatches collects the messages of a claim for up to BATCH_WINDOW_MS after the
+// first one, or until BATCH_SIZE are pending, and applies them in one transaction.
+// Offsets are marked only once their batch is flushed, so a crash never skips events
+// that were not committed to the read model.
+func (h *consumerGroupHandler) consumeBatches(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	window := time.Duration(h.config.BatchWindowMs) * time.Millisecond
+	pending := make([]*sarama.ConsumerMessage, 0, h.config.BatchSize)
+	timer := time.NewTimer(window)
+	timer.Stop()
+
+	flush := func() {
+		timer.Stop()
+		if len(pending) == 0 {
+			return
+		}
+		release := h.gate.hold()
+		h.flushBatch(h.gate.unreplayed(pending))
+		// Inside the gate, so a paused consumer reports positions matching the read model
+		h.observeProgress(claim, pending[len(pending)-1])
+		release()
+		for _, message := range pending {
+			session.MarkMessage(message, "")
+		}
+		pending = pending[:0]
+	}
+
+	for {
+		select {
+		case message := <-claim.Messages():
+			if message == nil {
+				flush()
+				return nil
+			}
+			if len(pending) == 0 {
+				timer.Reset(window)
+			}
+			pending = append(pending, message)
+			if len(pending) >= h.config.BatchSize {
+				flush()
+			}
+
+		case <-timer.C:
+			flush()
+
+		case <-session.Context().Done():
+			// Pending messages are not marked: the next owner of the partition gets them
+			timer.Stop()
+			return nil
+		}
+	}
+}
+
+// flushBatch applies a batch in a single transaction, in offset order. Each event runs
+// in its own savepoint: an event that fails is undone and, with every later event of
+// the same key (the item the Command Service keyed it by), processed again one by one
+// after the batch, with the usual retries and DLQ. If the batch itself cannot be
+// committed, all its events are processed one by one.
+func (h *consumerGroupHandler) flushBatch(messages []*sarama.ConsumerMessage) {
+	events := make([]*batchedEvent, 0, len(messages))
+	for _, message := range messages {
+		if eventType, eventData, ok := h.readMessage(message); ok {
+			events = append(events, &batchedEvent{message: message, eventType: eventType, eventData: eventData})
+		}
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	start := time.Now()
+	err := h.batch.Batch(context.Background(), func(batchCtx context.Context) error {
+		failedKeys := make(map[string]bool)
+		for _, event := range events {
+			key := string(event.message.Key)
+			if key != "" && failedKeys[key] {
+				continue
+			}
+			event.applied = h.applyBatched(batchCtx, event)
+			if !event.applied && key != "" {
+				failedKeys[key] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		h.logger.Error("Failed to commit event batch, processing its events one by one",
+			zap.Int("events", len(events)),
+			zap.Error(err),
+		)
+		for _, event := range events {
+			event.applied, event.duplicate = false, false
+		}
+	}
+
+	applied := 0
+	for _, event := range events {
+		if event.duplicate {
+			h.skipDuplicate(event.message, event.eventType)
+			continue
+		}
+		if event.applied {
+			applied++
+			h.recordOutcome(event.message, event.eventType, OutcomeApplied)
+			continue
+		}
+		metrics.EventBatchDeferred.Inc()
+		h.processMessage(event.message, event.eventType, event.eventData)
+	}
+	metrics.EventBatchSize.Observe(float64(applied))
+	h.logger.Debug("Event batch flushed",
+		zap.Int("events", len(events)),
+		zap.Int("applied", applied),
+		zap.Int("deferred", len(events)-applied),
+		zap.Duration("duration", time.Since(start)),
+	)
+}
+
+// applyBatched applies one event inside the batch of batchCtx and records it in the
+// activity log there too. Failures are not logged as such: the event is retried alone.
+// An event applied before is marked duplicate and counts as handled.
+func (h *consumerGroupHandler) applyBatched(batchCtx context.Context, event *batchedEvent) bool {
+	ctx, span := startEvent(batchCtx, event.message, event.eventType)
+	defer span.End()
+
+	start := time.Now()
+	err := h.batch.Savepoint(ctx, func(ctx context.Context) error {
+		if err := h.applyEvent(ctx, event.message, event.eventType, event.eventData); err != nil {
+			return err
+		}
+		h.recordActivity(ctx, event.message, event.eventType, event.eventData, nil)
+		return nil
+	})
+	h.observeProcessing(event.eventType, time.Since(start))
+	if errors.Is(err, errAlreadyApplied) {
+		event.duplicate = true
+		return true
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		h.logger.Debug("Batched event failed, deferring it and the rest of its key",
+			zap.String("event_type", event.eventType),
+			zap.String("key", string(event.message.Key)),
+			zap.Int64("offset", event.message.Offset),
+			zap.Error(err),
+		)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,228 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	fakeTxKey    struct{}
+	fakeBatchKey struct{}
+)
+
+// fakeTx holds the writes of a transaction until it commits
+type fakeTx struct {
+	applied   []int64
+	processed []string
+}
+
+// fakeReadModel is a transactional read model that records the offsets of the events
+// applied to it. It implements BatchWriter and EventDeduplicator.
+type fakeReadModel struct {
+	mu        sync.Mutex
+	applied   []int64 // committed, in order
+	processed map[string]bool
+	attempts  []string       // "batch:<offset>" or "alone:<offset>", every time an event is applied
+	checks    map[string]int // MarkEventProcessed calls by event-id
+	failOnce  map[int64]bool
+	commitErr error
+	onCommit  func() // called before a batch commits
+}
+
+func newFakeReadModel() *fakeReadModel {
+	return &fakeReadModel{processed: map[string]bool{}, checks: map[string]int{}, failOnce: map[int64]bool{}}
+}
+
+func (m *fakeReadModel) Batch(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, nested := ctx.Value(fakeTxKey{}).(*fakeTx); nested {
+		return fn(ctx)
+	}
+	tx := &fakeTx{}
+	if err := fn(context.WithValue(ctx, fakeTxKey{}, tx)); err != nil {
+		return err
+	}
+	if m.onCommit != nil {
+		m.onCommit()
+	}
+	if m.commitErr != nil {
+		err := m.commitErr
+		m.commitErr = nil
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.applied = append(m.applied, tx.applied...)
+	for _, eventID := range tx.processed {
+		m.processed[eventID] = true
+	}
+	return nil
+}
+
+func (m *fakeReadModel) Savepoint(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx := ctx.Value(fakeTxKey{}).(*fakeTx)
+	applied, processed := len(tx.applied), len(tx.processed)
+	err := fn(ctx)
+	if err != nil {
+		tx.applied, tx.processed = tx.applied[:applied], tx.processed[:processed]
+	}
+	return err
+}
+
+func (m *fakeReadModel) MarkEventProcessed(ctx context.Context, eventID, _ string) (bool, error) {
+	tx := ctx.Value(fakeTxKey{}).(*fakeTx)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checks[eventID]++
+	if m.processed[eventID] {
+		return false, nil
+	}
+	for _, id := range tx.processed {
+		if id == eventID {
+			return false, nil
+		}
+	}
+	tx.processed = append(tx.processed, eventID)
+	return true, nil
+}
+
+func (m *fakeReadModel) PruneProcessedEvents(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+// ProcessEvent applies an event whose payload is "<key>/<offset>"; the offsets in
+// failOnce fail the first time
+func (m *fakeReadModel) ProcessEvent(ctx context.Context, _ string, eventData []byte) error {
+	_, value, _ := strings.Cut(string(eventData), "/")
+	offset, _ := strconv.ParseInt(value, 10, 64)
+	tx, inTx := ctx.Value(fakeTxKey{}).(*fakeTx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	phase := "alone:"
+	if _, batched := ctx.Value(fakeBatchKey{}).(bool); batched {
+		phase = "batch:"
+	}
+	m.attempts = append(m.attempts, phase+value)
+	if m.failOnce[offset] {
+		delete(m.failOnce, offset)
+		return errors.New("optimistic lock failed")
+	}
+	if inTx {
+		tx.applied = append(tx.applied, offset)
+	} else {
+		m.applied = append(m.applied, offset)
+	}
+	return nil
+}
+
+// batchWriter marks the context of the batches of m, so the attempts made in one can
+// be told from those made alone
+type batchWriter struct{ *fakeReadModel }
+
+func (w batchWriter) Batch(ctx context.Context, fn func(ctx context.Context) error) error {
+	return w.fakeReadModel.Batch(context.WithValue(ctx, fakeBatchKey{}, true), fn)
+}
+
+func newBatchHandler(model *fakeReadModel) *consumerGroupHandler {
+	h := newTestHandler(model)
+	h.batch = batchWriter{model}
+	h.gate = &pauseGate{}
+	h.config.DeadLetterQueue = false
+	return h
+}
+
+func batchMessage(offset int64, key string) *sarama.ConsumerMessage {
+	message := testMessage("inventory.stock", offset, key, "StockAdjusted")
+	message.Value = []byte(key + "/" + strconv.FormatInt(offset, 10))
+	message.Headers[1].Value = []byte("event-" + strconv.FormatInt(offset, 10))
+	return message
+}
+
+func TestFlushBatch_DefersTheKeyOfAFailedEvent(t *testing.T) {
+	model := newFakeReadModel()
+	model.failOnce[1] = true
+	h := newBatchHandler(model)
+
+	h.flushBatch([]*sarama.ConsumerMessage{
+		batchMessage(0, "item-a"),
+		batchMessage(1, "item-a"), // fails in the batch
+		batchMessage(2, "item-b"),
+		batchMessage(3, "item-a"), // deferred behind 1
+		batchMessage(4, "item-b"),
+	})
+
+	// item-b is committed with the batch; 1 and the rest of item-a follow alone, in order
+	assert.Equal(t, []string{"batch:0", "batch:1", "batch:2", "batch:4", "alone:1", "alone:3"}, model.attempts)
+	assert.Equal(t, []int64{0, 2, 4, 1, 3}, model.applied)
+	snapshot := h.stats.Snapshot()
+	require.Len(t, snapshot.EventTypes, 1)
+	assert.Equal(t, int64(5), snapshot.EventTypes[0].Applied)
+	assert.Equal(t, int64(0), snapshot.EventTypes[0].Failed)
+}
+
+func TestFlushBatch_CommitFailureProcessesEveryEventAlone(t *testing.T) {
+	model := newFakeReadModel()
+	model.processed["event-1"] = true // Applied before a crash: a duplicate in the batch
+	model.commitErr = errors.New("database is locked")
+	h := newBatchHandler(model)
+	h.dedup = model
+
+	h.flushBatch([]*sarama.ConsumerMessage{
+		batchMessage(0, "item-a"),
+		batchMessage(1, "item-a"),
+		batchMessage(2, "item-b"),
+	})
+
+	// Nothing of the batch was kept: every event is applied, or found duplicate, again
+	assert.Equal(t, []string{"batch:0", "batch:2", "alone:0", "alone:2"}, model.attempts)
+	assert.Equal(t, []int64{0, 2}, model.applied)
+	assert.Equal(t, map[string]int{"event-0": 2, "event-1": 2, "event-2": 2}, model.checks)
+	snapshot := h.stats.Snapshot()
+	require.Len(t, snapshot.EventTypes, 1)
+	assert.Equal(t, int64(2), snapshot.EventTypes[0].Applied)
+	assert.Equal(t, int64(1), snapshot.EventTypes[0].Skipped)
+}
+
+func TestConsumeBatches_MarksOffsetsAfterTheFlush(t *testing.T) {
+	model := newFakeReadModel()
+	h := newBatchHandler(model)
+	h.config.BatchSize = 3
+	h.config.BatchWindowMs = int(time.Minute / time.Millisecond)
+
+	session := &fakeSession{ctx: context.Background()}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage)}
+	model.onCommit = func() {
+		assert.Equal(t, int64(-1), session.lastMarked(), "offsets marked before the batch committed")
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.consumeBatches(session, claim)
+	}()
+
+	claim.messages <- batchMessage(0, "item-a")
+	claim.messages <- batchMessage(1, "item-b")
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int64(-1), session.lastMarked())
+	assert.Empty(t, model.attempts)
+
+	// The third message fills the batch
+	claim.messages <- batchMessage(2, "item-a")
+	require.Eventually(t, func() bool { return session.lastMarked() == 2 }, time.Second, time.Millisecond)
+	close(claim.messages)
+	<-done
+
+	assert.Equal(t, []int64{0, 1, 2}, model.applied)
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	assert.Equal(t, []int64{0, 1, 2}, session.marked)
+}
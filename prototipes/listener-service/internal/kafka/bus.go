@@ -0,0 +1,80 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"listener-service/internal/config"
+
+	"github.com/IBM/sarama"
+)
+
+// busKeyHeader carries the partition key of a message on NATS and RabbitMQ, which
+// have no message key
+const busKeyHeader = "message-key"
+
+// busPrefetch is how many messages a bus delivers ahead of the one being handled
+const busPrefetch = 64
+
+// busSendTimeout bounds a publish (and its acknowledgement) on NATS and RabbitMQ
+const busSendTimeout = 5 * time.Second
+
+// messageBus is a connection to the NATS or RabbitMQ server of EVENT_BUS. It carries the
+// same messages as Kafka (topic, key, headers and value), so the publishers and
+// consumers build and read them the same way whatever the bus.
+type messageBus interface {
+	EventConsumer
+	// Send publishes a message to its topic and waits for the server to store it. It
+	// returns the position of the message in the bus, if the bus has one.
+	Send(ctx context.Context, message *sarama.ProducerMessage) (int64, error)
+}
+
+// dialBus connects to the NATS or RabbitMQ server of cfg. Messages are consumed in
+// group: a group shares the messages of its topics, kept while its consumers are down;
+// without a group every consumer gets every message, from the newest one.
+func dialBus(cfg *config.Config, group string) (messageBus, error) {
+	switch cfg.EventBus {
+	case config.EventBusNATS:
+		return dialNATS(cfg, group)
+	case config.EventBusRabbitMQ:
+		return dialRabbitMQ(cfg, group)
+	}
+	return nil, fmt.Errorf("EVENT_BUS %q is not a NATS or RabbitMQ bus", cfg.EventBus)
+}
+
+// busTopics are the topics the service consumes or publishes (those of the shared and
+// the dedicated tenants)
+func busTopics(cfg *config.Config) []string {
+	return append(cfg.ListedTopics(cfg.EventTopics()...), cfg.KafkaTopicRejections)
+}
+
+// encodeMessage returns the value and the key (nil when unset) of a message
+func encodeMessage(message *sarama.ProducerMessage) (value, key []byte, err error) {
+	if message.Value != nil {
+		if value, err = message.Value.Encode(); err != nil {
+			return nil, nil, fmt.Errorf("failed to encode event: %w", err)
+		}
+	}
+	if message.Key != nil {
+		if key, err = message.Key.Encode(); err != nil {
+			return nil, nil, fmt.Errorf("failed to encode partition key: %w", err)
+		}
+	}
+	return value, key, nil
+}
+
+// messageHeader returns the value of a header of a message being published
+func messageHeader(message *sarama.ProducerMessage, key string) string {
+	for _, h := range message.Headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// withSendTimeout bounds ctx by busSendTimeout; the NATS client needs a deadline
+func withSendTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, busSendTimeout)
+}
@@ -2,28 +2,110 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
 	"listener-service/internal/config"
-	"listener-service/internal/events"
+	"listener-service/internal/database"
+	"listener-service/pkg/metrics"
+	"listener-service/pkg/tracing"
 
 	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+// EventHandler processes a single event. It is implemented by events.EventProcessor
+// and, in dry-run mode, by events.DryRunProcessor.
+type EventHandler interface {
+	ProcessEvent(ctx context.Context, eventType string, eventData []byte) error
+}
+
+// ProgressObserver is told about every handled message and how many messages remain
+// in its partition (replication lag)
+type ProgressObserver interface {
+	Observe(topic string, partition int32, timestamp time.Time, lag int64)
+}
+
+// EventArchiver keeps a copy of every handled message and its outcome for audit. It is
+// implemented by archive.Archiver and must not block.
+type EventArchiver interface {
+	Archive(message *sarama.ConsumerMessage, eventType, outcome string)
+}
+
+// Headers added to the messages sent to the DLQ
+const (
+	// DLQErrorHeader is why the last attempt failed
+	DLQErrorHeader = "dlq-error"
+	// DLQTopicHeader is the topic the message was consumed from (a retry topic after
+	// RETRY_TOPICS)
+	DLQTopicHeader = "dlq-topic"
+	// DLQGroupHeader is the consumer group that gave up on the message
+	DLQGroupHeader = "dlq-group"
+)
+
+// errNoDeadLetterProducer is returned by sendToDLQ without a producer (dry-run, mock mode)
+var errNoDeadLetterProducer = errors.New("no DLQ producer")
+
 // Consumer represents a Kafka consumer
 type Consumer struct {
+	client        sarama.Client // owns the broker connections of consumerGroup
 	consumerGroup sarama.ConsumerGroup
-	processor     *events.EventProcessor
+	bus           EventConsumer // set instead of consumerGroup on NATS, RabbitMQ and in mock mode
+	processor     EventHandler
+	activity      ActivityRecorder   // nil disables the activity log (dry-run)
+	cipher        *PayloadCipher     // nil when payload decryption is disabled
+	progress      ProgressObserver   // optional
+	batch         BatchWriter        // nil applies events one by one
+	rejections    RejectionPublisher // nil does not report failed events
+	archive       EventArchiver      // nil does not archive events
+	dedup         EventDeduplicator  // nil applies redelivered events again
+	forwarder     MessageForwarder   // nil sends failed events straight to the DLQ
+	deadLetters   MessageForwarder   // nil cannot send failed events to the DLQ
+	gate          pauseGate          // closed while the read model is rebuilt (Pause)
+	stats         *ConsumerStats
 	logger        *zap.Logger
 	config        *config.Config
+	topicsMu      sync.RWMutex // topics change with KAFKA_TOPIC_SUBSCRIPTION=regex
 	topics        []string
+	retryTopics   []string // consumed along with topics
+	retryDelays   []config.RetryTopicDelay
 }
 
-// NewConsumer creates a new Kafka consumer
-func NewConsumer(cfg *config.Config, processor *events.EventProcessor, logger *zap.Logger) (*Consumer, error) {
+// NewConsumer creates a new Kafka consumer, or a consumer of the NATS or RabbitMQ bus
+// of EVENT_BUS. The outcome of every event is recorded through activity; pass nil to
+// disable the activity log.
+func NewConsumer(cfg *config.Config, processor EventHandler, activity ActivityRecorder, logger *zap.Logger) (*Consumer, error) {
+	payloadCipher, err := NewPayloadCipher(cfg.EventEncryptionKeys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event encryption config: %w", err)
+	}
+	topics := cfg.ListedTopics(cfg.EventTopics()...)
+
+	if cfg.EventBus != config.EventBusKafka {
+		bus, err := NewEventConsumer(cfg, cfg.KafkaGroupID)
+		if err != nil {
+			logger.Error("❌ Failed to create event consumer", zap.String("event_bus", cfg.EventBus), zap.Error(err))
+			return nil, fmt.Errorf("failed to create consumer: %w", err)
+		}
+		return &Consumer{
+			bus:       bus,
+			processor: processor,
+			activity:  activity,
+			cipher:    payloadCipher,
+			stats:     NewConsumerStats(cfg.KafkaGroupID, topics),
+			logger:    logger,
+			config:    cfg,
+			topics:    topics,
+		}, nil
+	}
+
 	logger.Info("🔌 Creating Kafka consumer",
 		zap.Strings("brokers", cfg.KafkaBrokers),
 		zap.String("group_id", cfg.KafkaGroupID),
@@ -52,7 +134,18 @@ func NewConsumer(cfg *config.Config, processor *events.EventProcessor, logger *z
 	saramaConfig.Metadata.Retry.Max = 3
 	saramaConfig.Metadata.Retry.Backoff = 250 * time.Millisecond
 
-	consumerGroup, err := sarama.NewConsumerGroup(cfg.KafkaBrokers, cfg.KafkaGroupID, saramaConfig)
+	// TLS and SASL for managed clusters
+	if err := configureSecurity(saramaConfig, cfg); err != nil {
+		return nil, fmt.Errorf("invalid Kafka security config: %w", err)
+	}
+
+	client, err := sarama.NewClient(cfg.KafkaBrokers, saramaConfig)
+	var consumerGroup sarama.ConsumerGroup
+	if err == nil {
+		if consumerGroup, err = sarama.NewConsumerGroupFromClient(cfg.KafkaGroupID, client); err != nil {
+			client.Close()
+		}
+	}
 	if err != nil {
 		logger.Error("❌ Failed to create Kafka consumer group",
 			zap.Strings("brokers", cfg.KafkaBrokers),
@@ -60,29 +153,128 @@ func NewConsumer(cfg *config.Config, processor *events.EventProcessor, logger *z
 		)
 		return nil, fmt.Errorf("failed to create consumer group: %w", err)
 	}
+	if topics, err = eventTopics(client, cfg); err != nil {
+		consumerGroup.Close()
+		client.Close()
+		return nil, fmt.Errorf("failed to resolve event topics: %w", err)
+	}
 
 	logger.Info("✅ Kafka consumer group created successfully",
 		zap.Strings("brokers", cfg.KafkaBrokers),
 		zap.String("group_id", cfg.KafkaGroupID),
+		zap.String("topic_subscription", cfg.KafkaTopicSubscription),
 	)
 
-	topics := []string{cfg.KafkaTopicItems, cfg.KafkaTopicStock}
-
 	return &Consumer{
+		client:        client,
 		consumerGroup: consumerGroup,
 		processor:     processor,
+		activity:      activity,
+		cipher:        payloadCipher,
+		stats:         NewConsumerStats(cfg.KafkaGroupID, topics),
 		logger:        logger,
 		config:        cfg,
 		topics:        topics,
 	}, nil
 }
 
+// SetProgressObserver reports the consumer's progress to observer; call it before Start
+func (c *Consumer) SetProgressObserver(observer ProgressObserver) {
+	c.progress = observer
+}
+
+// Stats returns the offsets, lag, event counters and latencies of the consumer since
+// startup, and the depth of the DLQ
+func (c *Consumer) Stats() ConsumerSnapshot {
+	snapshot := c.stats.Snapshot()
+	if depth, err := c.dlqDepth(); err == nil {
+		snapshot.DLQDepth = &depth
+	} else {
+		c.logger.Debug("DLQ depth unavailable", zap.Error(err))
+	}
+	return snapshot
+}
+
+// dlqDepth sums the messages retained in the partitions of DLQ_TOPIC. A missing topic is
+// an error rather than being looked up, which would create it.
+func (c *Consumer) dlqDepth() (int64, error) {
+	if c.client == nil || !c.config.DeadLetterQueue {
+		return 0, errors.New("no Kafka DLQ")
+	}
+	topics, err := c.client.Topics()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list topics: %w", err)
+	}
+	found := false
+	for _, topic := range topics {
+		found = found || topic == c.config.DLQTopic
+	}
+	if !found {
+		return 0, fmt.Errorf("topic %s does not exist", c.config.DLQTopic)
+	}
+	partitions, err := c.client.Partitions(c.config.DLQTopic)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list partitions of %s: %w", c.config.DLQTopic, err)
+	}
+	var depth int64
+	for _, partition := range partitions {
+		oldest, err := c.client.GetOffset(c.config.DLQTopic, partition, sarama.OffsetOldest)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read oldest offset of %s/%d: %w", c.config.DLQTopic, partition, err)
+		}
+		newest, err := c.client.GetOffset(c.config.DLQTopic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read newest offset of %s/%d: %w", c.config.DLQTopic, partition, err)
+		}
+		depth += newest - oldest
+	}
+	return depth, nil
+}
+
+// SetBatchWriter applies events in batches of up to BATCH_SIZE per transaction of
+// writer; call it before Start. Only the Kafka consumer group is batched.
+func (c *Consumer) SetBatchWriter(writer BatchWriter) {
+	c.batch = writer
+}
+
+// SetDeadLetterQueue sends the events that still fail after their retries to DLQ_TOPIC
+// through forwarder; call it before Start
+func (c *Consumer) SetDeadLetterQueue(forwarder MessageForwarder) {
+	c.deadLetters = forwarder
+}
+
+// SetArchiver archives every handled message through archiver; call it before Start
+func (c *Consumer) SetArchiver(archiver EventArchiver) {
+	c.archive = archiver
+}
+
 // Start starts consuming messages
 func (c *Consumer) Start(ctx context.Context) error {
 	handler := &consumerGroupHandler{
-		processor: c.processor,
-		logger:    c.logger,
-		config:    c.config,
+		processor:   c.processor,
+		activity:    c.activity,
+		cipher:      c.cipher,
+		progress:    c.progress,
+		batch:       c.batch,
+		rejections:  c.rejections,
+		archive:     c.archive,
+		dedup:       c.dedup,
+		forwarder:   c.forwarder,
+		deadLetters: c.deadLetters,
+		gate:        &c.gate,
+		stats:       c.stats,
+		logger:      c.logger,
+		config:      c.config,
+		// Retry topics, in order
+		retryDelays: c.retryDelays,
+	}
+
+	if c.dedup != nil && c.config.EventDedupRetentionHours > 0 {
+		go c.pruneProcessedEvents(ctx)
+	}
+
+	if c.bus != nil {
+		return c.consumeBus(ctx, handler)
 	}
 
 	wg := &sync.WaitGroup{}
@@ -91,7 +283,25 @@ func (c *Consumer) Start(ctx context.Context) error {
 	go func() {
 		defer wg.Done()
 		for {
-			if err := c.consumerGroup.Consume(ctx, c.topics, handler); err != nil {
+			topics, retryTopics := c.subscription()
+			// With a regex subscription, a change of the topics ends the session so the
+			// group rejoins with the new ones
+			session, endSession := context.WithCancel(ctx)
+			if c.config.KafkaTopicSubscription == config.TopicSubscriptionRegex {
+				go watchTopics(session, c.client, c.config, topics, c.logger, func(topics []string) {
+					c.setTopics(topics)
+					endSession()
+				})
+			}
+			var err error
+			if len(topics) == 0 {
+				c.logger.Warn("No event topic matches the topic templates yet", zap.Strings("templates", c.config.EventTopics()))
+				<-session.Done()
+			} else {
+				err = c.consumerGroup.Consume(session, append(topics, retryTopics...), handler)
+			}
+			endSession()
+			if err != nil {
 				c.logger.Error("Error from consumer",
 					zap.Error(err),
 					zap.String("error_type", fmt.Sprintf("%T", err)),
@@ -117,8 +327,9 @@ func (c *Consumer) Start(ctx context.Context) error {
 		}
 	}()
 
+	topics, _ := c.subscription()
 	c.logger.Info("Kafka consumer started",
-		zap.Strings("topics", c.topics),
+		zap.Strings("topics", topics),
 		zap.String("group_id", c.config.KafkaGroupID),
 	)
 
@@ -126,16 +337,65 @@ func (c *Consumer) Start(ctx context.Context) error {
 	return nil
 }
 
+// subscription returns the event topics and their retry topics
+func (c *Consumer) subscription() (topics, retryTopics []string) {
+	c.topicsMu.RLock()
+	defer c.topicsMu.RUnlock()
+	return c.topics, c.retryTopics
+}
+
+// setTopics replaces the event topics, and their retry topics, consumed from the next
+// session on
+func (c *Consumer) setTopics(topics []string) {
+	c.topicsMu.Lock()
+	defer c.topicsMu.Unlock()
+	c.topics = topics
+	c.retryTopics = retryTopicsOf(topics, c.retryDelays)
+	c.stats.setTopics(topics)
+}
+
 // Close closes the consumer
 func (c *Consumer) Close() error {
-	return c.consumerGroup.Close()
+	if c.bus != nil {
+		return c.bus.Close()
+	}
+	if err := c.consumerGroup.Close(); err != nil {
+		return err
+	}
+	return c.client.Close()
+}
+
+// Ping refreshes the metadata of the consumed topics, which fails when no broker
+// answers; the other buses are pinged themselves. Used by the readiness probe.
+func (c *Consumer) Ping(ctx context.Context) error {
+	if c.bus != nil {
+		return c.bus.Ping(ctx)
+	}
+	topics, _ := c.subscription()
+	if err := c.client.RefreshMetadata(topics...); err != nil {
+		return fmt.Errorf("kafka metadata refresh failed: %w", err)
+	}
+	return nil
 }
 
 // consumerGroupHandler handles Kafka consumer group messages
 type consumerGroupHandler struct {
-	processor *events.EventProcessor
-	logger    *zap.Logger
-	config    *config.Config
+	processor   EventHandler
+	activity    ActivityRecorder
+	cipher      *PayloadCipher
+	progress    ProgressObserver
+	batch       BatchWriter
+	rejections  RejectionPublisher
+	archive     EventArchiver
+	dedup       EventDeduplicator
+	forwarder   MessageForwarder
+	deadLetters MessageForwarder
+	gate        *pauseGate // nil when the handler cannot be paused (replays)
+	stats       *ConsumerStats
+	logger      *zap.Logger
+	config      *config.Config
+	// Retry topics, in order
+	retryDelays []config.RetryTopicDelay
 }
 
 // Setup is run at the beginning of a new session, before ConsumeClaim
@@ -150,70 +410,218 @@ func (h *consumerGroupHandler) Cleanup(sarama.ConsumerGroupSession) error {
 
 // ConsumeClaim must start a consumer loop of ConsumerGroupClaim's Messages()
 func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	if tier, _ := h.retryTier(claim.Topic()); tier >= 0 {
+		return h.consumeRetries(session, claim, tier)
+	}
+	if h.batch != nil && h.config.BatchSize > 1 {
+		return h.consumeBatches(session, claim)
+	}
+	if h.config.ConsumerWorkers > 1 {
+		return h.consumeParallel(session, claim)
+	}
 	for {
 		select {
 		case message := <-claim.Messages():
 			if message == nil {
 				return nil
 			}
-
-			// Extract event type from headers
-			eventType := h.extractEventType(message.Headers)
-			if eventType == "" {
-				h.logger.Warn("Message without event type, skipping",
-					zap.String("topic", message.Topic),
-					zap.Int("partition", int(message.Partition)),
-					zap.Int64("offset", message.Offset),
-				)
-				session.MarkMessage(message, "")
-				continue
+			release := h.gate.hold()
+			if !h.gate.skips(message) {
+				h.handleMessage(message)
 			}
+			h.observeProgress(claim, message)
+			release()
+			// Failed messages are marked too (to avoid an infinite loop);
+			// in production, you might want to handle this differently
+			session.MarkMessage(message, "")
 
-			// Process event with retry logic
-			if err := h.processWithRetry(context.Background(), eventType, message.Value, message); err != nil {
-				h.logger.Error("Failed to process event after retries",
-					zap.String("event_type", eventType),
-					zap.String("topic", message.Topic),
-					zap.Error(err),
-				)
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
 
-				// Send to Dead Letter Queue if enabled
-				if h.config.DeadLetterQueue {
-					if err := h.sendToDLQ(message, err); err != nil {
-						h.logger.Error("Failed to send to DLQ", zap.Error(err))
-					}
-				}
+// observeProgress reports the lag left behind message
+func (h *consumerGroupHandler) observeProgress(claim sarama.ConsumerGroupClaim, message *sarama.ConsumerMessage) {
+	lag := claim.HighWaterMarkOffset() - message.Offset - 1
+	metrics.KafkaConsumerLag.WithLabelValues(message.Topic, strconv.Itoa(int(message.Partition))).
+		Set(float64(lag))
+	h.stats.observePartition(message.Topic, message.Partition, message.Offset, claim.HighWaterMarkOffset(), message.Timestamp)
+	if h.progress != nil {
+		h.progress.Observe(message.Topic, message.Partition, message.Timestamp, lag)
+	}
+}
 
-				// Mark message as processed even if failed (to avoid infinite loop)
-				// In production, you might want to handle this differently
-				session.MarkMessage(message, "")
-				continue
-			}
+// handleMessage decrypts, processes and records a single message. Errors are logged,
+// recorded in the activity log, sent to the DLQ and reported to the Command Service as
+// an EventRejected event; the caller always moves on.
+func (h *consumerGroupHandler) handleMessage(message *sarama.ConsumerMessage) {
+	eventType, eventData, ok := h.readMessage(message)
+	if !ok {
+		return
+	}
+	h.processMessage(message, eventType, eventData)
+}
 
-			// Mark message as processed
-			session.MarkMessage(message, "")
+// readMessage extracts the event type and payload of a message: it decrypts the message
+// if the publisher encrypted it, unwraps the payload from its envelope and checks its
+// timestamp. Messages that cannot be read (none of it is retryable) are skipped or
+// sent to the DLQ here, and ok is false.
+func (h *consumerGroupHandler) readMessage(message *sarama.ConsumerMessage) (eventType string, eventData []byte, ok bool) {
+	// Extract event type from headers
+	eventType = h.extractEventType(message.Headers)
+	if eventType == "" {
+		h.logger.Warn("Message without event type, skipping",
+			zap.String("topic", message.Topic),
+			zap.Int("partition", int(message.Partition)),
+			zap.Int64("offset", message.Offset),
+		)
+		h.recordOutcome(message, "unknown", OutcomeSkipped)
+		return "", nil, false
+	}
 
-		case <-session.Context().Done():
-			return nil
+	eventData, err := decryptMessage(h.cipher, message, eventType)
+	var envelope Envelope
+	if err == nil {
+		envelope, eventData, err = decodeEnvelope(eventData)
+	}
+	if err == nil {
+		err = checkEventTime(eventTime(envelope, eventData, message), time.Now().UTC(),
+			time.Duration(h.config.MaxEventFutureSkewSeconds)*time.Second)
+	}
+	if err != nil {
+		h.logger.Error("Failed to read event",
+			zap.String("event_type", eventType),
+			zap.String("topic", message.Topic),
+			zap.Int64("offset", message.Offset),
+			zap.Error(err),
+		)
+		h.recordActivity(context.Background(), message, eventType, nil, err)
+		h.recordOutcome(message, eventType, OutcomeFailed)
+		h.stats.recordError(eventType, err)
+		h.deadLetter(message, eventType, err)
+		h.reject(context.Background(), message, eventType, err)
+		return eventType, nil, false
+	}
+	return eventType, eventData, true
+}
+
+// startEvent returns the context an event is processed with: the attribution and
+// tenant headers and the trace started by the Command Service travel in it
+func startEvent(parent context.Context, message *sarama.ConsumerMessage, eventType string) (context.Context, trace.Span) {
+	ctx := database.WithAttribution(tracing.ExtractKafka(parent, message.Headers),
+		headerValue(message.Headers, ActorHeader),
+		headerValue(message.Headers, RequestIDHeader),
+	)
+	ctx = database.WithTenant(ctx, headerValue(message.Headers, TenantHeader))
+	return tracing.Tracer().Start(ctx, "process "+eventType,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String("kafka"),
+			semconv.MessagingDestinationName(message.Topic),
+			semconv.MessagingKafkaDestinationPartition(int(message.Partition)),
+			semconv.MessagingKafkaMessageOffset(int(message.Offset)),
+			attribute.String("event.type", eventType),
+		),
+	)
+}
+
+// processMessage applies a read event with retry logic and records the outcome
+func (h *consumerGroupHandler) processMessage(message *sarama.ConsumerMessage, eventType string, eventData []byte) {
+	ctx, span := startEvent(context.Background(), message, eventType)
+	defer span.End()
+	start := time.Now()
+	err := h.processWithRetry(ctx, eventType, eventData, message)
+	h.observeProcessing(eventType, time.Since(start))
+	if errors.Is(err, errAlreadyApplied) {
+		h.skipDuplicate(message, eventType)
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		h.stats.recordError(eventType, err)
+		if h.scheduleRetry(ctx, message, eventType, err) {
+			return
 		}
+		h.logger.Error("Failed to process event after retries",
+			zap.String("event_type", eventType),
+			zap.String("topic", message.Topic),
+			zap.Error(err),
+		)
+		h.recordActivity(ctx, message, eventType, eventData, err)
+		h.recordOutcome(message, eventType, OutcomeFailed)
+		h.deadLetter(message, eventType, err)
+		h.reject(ctx, message, eventType, err)
+		return
 	}
+
+	h.recordActivity(ctx, message, eventType, eventData, nil)
+	h.recordOutcome(message, eventType, OutcomeApplied)
 }
 
-// processWithRetry processes an event with retry logic
+// recordOutcome counts a consumed event in the metrics and the consumer stats, and
+// archives it
+func (h *consumerGroupHandler) recordOutcome(message *sarama.ConsumerMessage, eventType, outcome string) {
+	metrics.KafkaMessagesConsumed.WithLabelValues(message.Topic, eventType, outcome).Inc()
+	h.stats.recordOutcome(eventType, outcome)
+	if h.archive != nil {
+		h.archive.Archive(message, eventType, outcome)
+	}
+}
+
+// observeProcessing records how long an event took, retries included, in the metrics
+// and the consumer stats
+func (h *consumerGroupHandler) observeProcessing(eventType string, d time.Duration) {
+	metrics.EventProcessingDuration.WithLabelValues(eventType).Observe(d.Seconds())
+	h.stats.recordLatency(eventType, d)
+}
+
+// deadLetter sends a failed event to the Dead Letter Queue if it is enabled
+func (h *consumerGroupHandler) deadLetter(message *sarama.ConsumerMessage, eventType string, cause error) {
+	if !h.config.DeadLetterQueue {
+		return
+	}
+	if err := h.sendToDLQ(message, cause); err != nil {
+		h.logger.Error("Failed to send to DLQ", zap.Error(err))
+		metrics.EventsDeadLettered.WithLabelValues(eventType, "error").Inc()
+		h.stats.recordDeadLetter(eventType, false)
+		return
+	}
+	metrics.EventsDeadLettered.WithLabelValues(eventType, "success").Inc()
+	h.stats.recordDeadLetter(eventType, true)
+}
+
+// processWithRetry processes an event with retry logic: up to MAX_RETRIES more attempts
+// with exponential backoff, within MAX_PROCESSING_MS
 func (h *consumerGroupHandler) processWithRetry(ctx context.Context, eventType string, eventData []byte, message *sarama.ConsumerMessage) error {
+	if h.config.MaxProcessingMs > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(h.config.MaxProcessingMs)*time.Millisecond)
+		defer cancel()
+	}
+
 	var lastErr error
 	for attempt := 0; attempt <= h.config.MaxRetries; attempt++ {
 		if attempt > 0 {
-			delay := time.Duration(h.config.RetryDelayMs*attempt) * time.Millisecond
+			delay := retryDelay(h.config, attempt)
+			if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+				return errProcessingTimeout(h.config, attempt, lastErr)
+			}
 			h.logger.Info("Retrying event processing",
 				zap.String("event_type", eventType),
 				zap.Int("attempt", attempt),
 				zap.Duration("delay", delay),
 			)
-			time.Sleep(delay)
+			h.waitUntil(ctx, time.Now().Add(delay))
+			metrics.EventRetries.WithLabelValues(eventType).Inc()
+			h.stats.recordRetry(eventType)
 		}
 
-		err := h.processor.ProcessEvent(ctx, eventType, eventData)
+		err := h.applyEvent(ctx, message, eventType, eventData)
+		if errors.Is(err, errAlreadyApplied) {
+			return err
+		}
 		if err == nil {
 			if attempt > 0 {
 				h.logger.Info("Event processed successfully after retry",
@@ -259,12 +667,22 @@ func (h *consumerGroupHandler) extractEventType(headers []*sarama.RecordHeader)
 	return ""
 }
 
-// sendToDLQ sends a failed message to Dead Letter Queue
+// sendToDLQ forwards a failed message to DLQ_TOPIC as it was read, with its headers
+// plus why it failed and where it was consumed from
 func (h *consumerGroupHandler) sendToDLQ(message *sarama.ConsumerMessage, err error) error {
-	// TODO: Implement DLQ producer
-	// For now, just log the error
-	h.logger.Error("Message sent to DLQ",
+	if h.deadLetters == nil {
+		return errNoDeadLetterProducer
+	}
+	if sendErr := h.deadLetters.Forward(context.Background(), h.config.DLQTopic, message,
+		sarama.RecordHeader{Key: []byte(DLQErrorHeader), Value: []byte(err.Error())},
+		sarama.RecordHeader{Key: []byte(DLQTopicHeader), Value: []byte(message.Topic)},
+		sarama.RecordHeader{Key: []byte(DLQGroupHeader), Value: []byte(h.config.KafkaGroupID)},
+	); sendErr != nil {
+		return sendErr
+	}
+	h.logger.Warn("Message sent to DLQ",
 		zap.String("topic", message.Topic),
+		zap.Int64("offset", message.Offset),
 		zap.String("dlq_topic", h.config.DLQTopic),
 		zap.Error(err),
 	)
@@ -0,0 +1,28 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadLetter_CountsUnsentEvents(t *testing.T) {
+	fail := eventFunc(func(context.Context, string, []byte) error { return errors.New("boom") })
+
+	// Without a producer nothing reaches the DLQ
+	h := newTestHandler(fail)
+	h.handleMessage(testMessage("inventory.stock", 1, "item-1", "StockAdjusted"))
+	snapshot := h.stats.Snapshot()
+	assert.Equal(t, int64(0), snapshot.DeadLettered)
+	assert.Equal(t, int64(1), snapshot.DeadLetterFailures)
+
+	// Nor when the producer fails
+	h = newTestHandler(fail)
+	h.deadLetters = &recordingForwarder{err: errors.New("broker down")}
+	h.handleMessage(testMessage("inventory.stock", 2, "item-1", "StockAdjusted"))
+	snapshot = h.stats.Snapshot()
+	assert.Equal(t, int64(0), snapshot.DeadLettered)
+	assert.Equal(t, int64(1), snapshot.DeadLetterFailures)
+}
@@ -0,0 +1,88 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"listener-service/pkg/metrics"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// dedupPruneInterval is how often the records of old processed events are deleted
+const dedupPruneInterval = time.Hour
+
+// errAlreadyApplied is returned for an event whose event-id was already recorded
+var errAlreadyApplied = errors.New("event already applied")
+
+// EventDeduplicator records the event-id of every applied event in the read model, in
+// the transaction of its writes, so an event redelivered after a crash between the
+// write and the offset commit is recognized. It is implemented by database.WriterDB.
+type EventDeduplicator interface {
+	Batch(ctx context.Context, fn func(ctx context.Context) error) error
+	MarkEventProcessed(ctx context.Context, eventID, eventType string) (bool, error)
+	PruneProcessedEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+// SetDeduplicator skips the events already recorded in dedup; call it before Start
+func (c *Consumer) SetDeduplicator(dedup EventDeduplicator) {
+	c.dedup = dedup
+}
+
+// applyEvent runs the processor on an event. With a deduplicator its event-id is
+// recorded in the same transaction as its writes, and errAlreadyApplied is returned,
+// without applying it, when it was recorded before. Events without an event-id header
+// are always applied.
+func (h *consumerGroupHandler) applyEvent(ctx context.Context, message *sarama.ConsumerMessage, eventType string, eventData []byte) error {
+	eventID := headerValue(message.Headers, "event-id")
+	if h.dedup == nil || eventID == "" {
+		return h.processor.ProcessEvent(ctx, eventType, eventData)
+	}
+	return h.dedup.Batch(ctx, func(ctx context.Context) error {
+		first, err := h.dedup.MarkEventProcessed(ctx, eventID, eventType)
+		if err != nil {
+			return err
+		}
+		if !first {
+			return errAlreadyApplied
+		}
+		return h.processor.ProcessEvent(ctx, eventType, eventData)
+	})
+}
+
+// skipDuplicate records a redelivered event that was not applied again
+func (h *consumerGroupHandler) skipDuplicate(message *sarama.ConsumerMessage, eventType string) {
+	h.logger.Info("Event already applied, skipping redelivery",
+		zap.String("event_type", eventType),
+		zap.String("event_id", headerValue(message.Headers, "event-id")),
+		zap.String("topic", message.Topic),
+		zap.Int64("offset", message.Offset),
+	)
+	metrics.EventsDeduplicated.WithLabelValues(eventType).Inc()
+	h.recordOutcome(message, eventType, OutcomeSkipped)
+}
+
+// pruneProcessedEvents deletes the records older than EVENT_DEDUP_RETENTION_HOURS every
+// dedupPruneInterval until ctx is done
+func (c *Consumer) pruneProcessedEvents(ctx context.Context) {
+	retention := time.Duration(c.config.EventDedupRetentionHours) * time.Hour
+	ticker := time.NewTicker(dedupPruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pruned, err := c.dedup.PruneProcessedEvents(ctx, time.Now().Add(-retention))
+			if err != nil {
+				c.logger.Warn("Failed to prune processed events", zap.Error(err))
+				continue
+			}
+			if pruned > 0 {
+				c.logger.Debug("Processed events pruned", zap.Int64("count", pruned))
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"listener-service/internal/config"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// busRetryDelay is how long the consumer waits before consuming the bus again after it
+// failed
+const busRetryDelay = 5 * time.Second
+
+// EventConsumer reads the messages of a set of topics from the NATS or RabbitMQ bus of
+// EVENT_BUS or, in mock mode, from the in-memory broker. Messages are handed over in the
+// shape of Kafka messages, so they go through the same path as those of the Kafka
+// consumer group.
+type EventConsumer interface {
+	// Consume calls handle for each message of topics, one at a time, until ctx is
+	// cancelled (it then returns nil) or the consumer fails. A message is acknowledged
+	// once handle returns.
+	Consume(ctx context.Context, topics []string, handle func(*sarama.ConsumerMessage)) error
+	// Ping fails when the bus does not answer. Used by the readiness probe.
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// NewEventConsumer connects to the NATS or RabbitMQ bus of cfg. The consumers of a
+// group share its messages, which are kept while they are down (like a Kafka consumer
+// group); without a group each consumer gets every message from the newest.
+func NewEventConsumer(cfg *config.Config, group string) (EventConsumer, error) {
+	return dialBus(cfg, group)
+}
+
+// consumeBus processes the messages of the bus until ctx is cancelled, consuming it
+// again after busRetryDelay when it fails. Events are applied one by one.
+func (c *Consumer) consumeBus(ctx context.Context, handler *consumerGroupHandler) error {
+	c.logger.Info("Event consumer started",
+		zap.String("event_bus", c.config.EventBus),
+		zap.Strings("topics", c.topics),
+		zap.String("group_id", c.config.KafkaGroupID),
+	)
+
+	handle := func(message *sarama.ConsumerMessage) {
+		handler.handleMessage(message)
+		handler.stats.observePartition(message.Topic, message.Partition, message.Offset, message.Offset+1, message.Timestamp)
+		if handler.progress != nil {
+			// The buses have no high-water mark to measure the lag against
+			handler.progress.Observe(message.Topic, message.Partition, message.Timestamp, 0)
+		}
+	}
+	for {
+		err := c.bus.Consume(ctx, c.topics, handle)
+		if ctx.Err() != nil {
+			return nil
+		}
+		c.logger.Warn("Event consumer stopped, retrying",
+			zap.String("event_bus", c.config.EventBus),
+			zap.Duration("delay", busRetryDelay),
+			zap.Error(err),
+		)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(busRetryDelay):
+		}
+	}
+}
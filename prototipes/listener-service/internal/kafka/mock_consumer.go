@@ -0,0 +1,78 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"listener-service/internal/config"
+
+	"testsupport"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// NewMockConsumer creates a consumer that reads from an in-memory broker instead of
+// Kafka (MOCK_DEPENDENCIES mode). Messages go through the same decryption, retry and
+// activity log path as the Kafka consumer.
+func NewMockConsumer(cfg *config.Config, broker *testsupport.Broker, processor EventHandler, activity ActivityRecorder, logger *zap.Logger) (*Consumer, error) {
+	payloadCipher, err := NewPayloadCipher(cfg.EventEncryptionKeys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event encryption config: %w", err)
+	}
+
+	topics := cfg.ListedTopics(cfg.EventTopics()...)
+	return &Consumer{
+		bus:       brokerConsumer{broker: broker},
+		processor: processor,
+		activity:  activity,
+		cipher:    payloadCipher,
+		stats:     NewConsumerStats(cfg.KafkaGroupID, topics),
+		logger:    logger,
+		config:    cfg,
+		topics:    topics,
+	}, nil
+}
+
+// brokerConsumer is the EventConsumer of the in-memory broker
+type brokerConsumer struct {
+	broker *testsupport.Broker
+}
+
+// Consume processes broker messages until ctx is cancelled
+func (b brokerConsumer) Consume(ctx context.Context, topics []string, handle func(*sarama.ConsumerMessage)) error {
+	sub := b.broker.Subscribe(topics...)
+	for {
+		msg, err := sub.Next(ctx)
+		if err != nil {
+			// Context cancelled: normal shutdown
+			return nil
+		}
+		handle(toConsumerMessage(msg))
+	}
+}
+
+// Ping succeeds: the in-memory broker is always reachable
+func (b brokerConsumer) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (b brokerConsumer) Close() error {
+	return nil
+}
+
+// toConsumerMessage converts a broker message to the shape the Kafka path works with
+func toConsumerMessage(msg testsupport.Message) *sarama.ConsumerMessage {
+	headers := make([]*sarama.RecordHeader, 0, len(msg.Headers))
+	for key, value := range msg.Headers {
+		headers = append(headers, &sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+	}
+	return &sarama.ConsumerMessage{
+		Topic:     msg.Topic,
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Headers:   headers,
+		Offset:    msg.Offset,
+		Timestamp: msg.Timestamp,
+	}
+}
@@ -4,24 +4,45 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"listener-service/internal/config"
+	"listener-service/internal/database"
+	"listener-service/pkg/metrics"
+	"listener-service/pkg/tracing"
 
 	"github.com/IBM/sarama"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
-// Producer publishes confirmation events to Kafka
+// Producer publishes confirmation events to Kafka, or to the NATS or RabbitMQ bus of
+// EVENT_BUS
 type Producer struct {
+	client   sarama.Client // owns the broker connections of producer
 	producer sarama.SyncProducer
+	bus      messageBus // set instead of client and producer on NATS and RabbitMQ
 	logger   *zap.Logger
 	config   *config.Config
 }
 
-// NewProducer creates a new Kafka producer
+// NewProducer creates a new Kafka producer, or connects to the bus of EVENT_BUS
 func NewProducer(cfg *config.Config, logger *zap.Logger) (*Producer, error) {
+	if cfg.EventBus != config.EventBusKafka {
+		bus, err := dialBus(cfg, "")
+		if err != nil {
+			logger.Error("❌ Failed to create event producer", zap.String("event_bus", cfg.EventBus), zap.Error(err))
+			return nil, fmt.Errorf("failed to create producer: %w", err)
+		}
+		logger.Info("✅ Event producer connected", zap.String("event_bus", cfg.EventBus))
+		return &Producer{bus: bus, logger: logger, config: cfg}, nil
+	}
+
 	logger.Info("🔌 Creating Kafka producer",
 		zap.Strings("brokers", cfg.KafkaBrokers),
 	)
@@ -39,7 +60,18 @@ func NewProducer(cfg *config.Config, logger *zap.Logger) (*Producer, error) {
 	saramaConfig.Net.ReadTimeout = 10 * time.Second
 	saramaConfig.Net.WriteTimeout = 10 * time.Second
 
-	producer, err := sarama.NewSyncProducer(cfg.KafkaBrokers, saramaConfig)
+	// TLS and SASL for managed clusters
+	if err := configureSecurity(saramaConfig, cfg); err != nil {
+		return nil, fmt.Errorf("invalid Kafka security config: %w", err)
+	}
+
+	client, err := sarama.NewClient(cfg.KafkaBrokers, saramaConfig)
+	var producer sarama.SyncProducer
+	if err == nil {
+		if producer, err = sarama.NewSyncProducerFromClient(client); err != nil {
+			client.Close()
+		}
+	}
 	if err != nil {
 		logger.Error("❌ Failed to create Kafka producer",
 			zap.Strings("brokers", cfg.KafkaBrokers),
@@ -53,6 +85,7 @@ func NewProducer(cfg *config.Config, logger *zap.Logger) (*Producer, error) {
 	)
 
 	return &Producer{
+		client:   client,
 		producer: producer,
 		logger:   logger,
 		config:   cfg,
@@ -61,37 +94,81 @@ func NewProducer(cfg *config.Config, logger *zap.Logger) (*Producer, error) {
 
 // Close closes the producer
 func (p *Producer) Close() error {
-	return p.producer.Close()
+	if p.bus != nil {
+		return p.bus.Close()
+	}
+	if err := p.producer.Close(); err != nil {
+		return err
+	}
+	return p.client.Close()
 }
 
-// PublishConfirmationEvent publishes a confirmation event after processing
-func (p *Producer) PublishConfirmationEvent(ctx context.Context, eventType string, itemID, sku string, data interface{}) error {
-	// Create confirmation event
-	confirmationEvent := map[string]interface{}{
-		"eventType":   eventType + "Confirmed",
-		"eventId":     uuid.New().String(),
-		"aggregateId": itemID,
-		"occurredAt":  time.Now().UTC().Format(time.RFC3339),
-		"version":     1,
-		"data":        data,
+// Ping refreshes the metadata of the confirmation topics (of the whole cluster when the
+// topics of the tenants are not listed), which fails when no broker answers (on NATS and
+// RabbitMQ, it round-trips to the server). Used by the readiness probe.
+func (p *Producer) Ping(ctx context.Context) error {
+	if p.bus != nil {
+		return p.bus.Ping(ctx)
 	}
-
-	// Add SKU if available
-	if sku != "" {
-		confirmationEvent["sku"] = sku
+	var topics []string
+	if p.config.KafkaTopicSubscription != config.TopicSubscriptionRegex {
+		topics = p.config.ListedTopics(p.config.EventTopics()...)
 	}
+	if err := p.client.RefreshMetadata(topics...); err != nil {
+		return fmt.Errorf("kafka metadata refresh failed: %w", err)
+	}
+	return nil
+}
+
+// PublishConfirmationEvent publishes a confirmation event after processing. The event
+// continues the trace of the event being processed (carried in ctx).
+func (p *Producer) PublishConfirmationEvent(ctx context.Context, eventType string, itemID, sku string, data interface{}) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "publish "+eventType+"Confirmed",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String("kafka"),
+			attribute.String("event.type", eventType+"Confirmed"),
+		),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
 
-	// Serialize event
+	// Serialize the confirmation in the same envelope as the Command Service events
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal confirmation event: %w", err)
+	}
+	confirmationEvent := Envelope{
+		EventID:       uuid.New().String(),
+		EventType:     eventType + "Confirmed",
+		SchemaVersion: SchemaVersion,
+		OccurredAt:    time.Now().UTC(),
+		TenantID:      database.TenantFromContext(ctx),
+		Payload:       payload,
+	}
 	eventData, err := json.Marshal(confirmationEvent)
 	if err != nil {
 		return fmt.Errorf("failed to marshal confirmation event: %w", err)
 	}
 
-	// Determine topic based on event type
+	// Determine topic based on event type, then resolve it for the tenant of the event
 	topic := p.config.KafkaTopicStock
-	if eventType == "InventoryItemCreated" || eventType == "InventoryItemUpdated" || eventType == "InventoryItemDeleted" {
+	if eventType == "InventoryItemCreated" || eventType == "InventoryItemUpdated" || eventType == "InventoryItemPatched" || eventType == "InventoryItemDeleted" ||
+		eventType == "InventoryItemRestored" || eventType == "ItemRelationAdded" || eventType == "ItemRelationRemoved" ||
+		eventType == "ItemImageAdded" || eventType == "ItemImageRemoved" || eventType == "ItemIdentifierAdded" || eventType == "ItemIdentifierRemoved" {
 		topic = p.config.KafkaTopicItems
 	}
+	if eventType == "StoreCreated" || eventType == "StoreUpdated" || eventType == "StoreDeleted" || eventType == "StoreCalendarUpdated" ||
+		eventType == "SupplierCreated" || eventType == "SupplierUpdated" || eventType == "SupplierDeleted" ||
+		eventType == "PurchaseOrderCreated" || eventType == "PurchaseOrderReceived" || eventType == "PurchaseOrderCancelled" {
+		topic = p.config.KafkaTopicStores
+	}
+	topic = p.config.TopicFor(topic, confirmationEvent.TenantID)
 
 	// Create message
 	message := &sarama.ProducerMessage{
@@ -103,19 +180,39 @@ func (p *Producer) PublishConfirmationEvent(ctx context.Context, eventType strin
 				Key:   []byte("event-type"),
 				Value: []byte(eventType + "Confirmed"),
 			},
+			{
+				Key:   []byte("event-id"),
+				Value: []byte(confirmationEvent.EventID),
+			},
+			{
+				Key:   []byte(SchemaVersionHeader),
+				Value: []byte(strconv.Itoa(SchemaVersion)),
+			},
+			{
+				Key:   []byte(TenantHeader),
+				Value: []byte(confirmationEvent.TenantID),
+			},
 		},
 	}
+	// The request of the confirmed event, so the Command Service can track the command
+	if _, requestID := database.AttributionFromContext(ctx); requestID != "" {
+		message.Headers = append(message.Headers, sarama.RecordHeader{Key: []byte(RequestIDHeader), Value: []byte(requestID)})
+	}
+	tracing.InjectKafka(ctx, &message.Headers)
+	span.SetAttributes(semconv.MessagingDestinationName(topic))
 
 	// Publish message
-	partition, offset, err := p.producer.SendMessage(message)
+	partition, offset, err := p.send(ctx, message)
 	if err != nil {
 		p.logger.Error("Failed to publish confirmation event",
 			zap.String("event_type", eventType+"Confirmed"),
 			zap.String("topic", topic),
 			zap.Error(err),
 		)
+		metrics.KafkaMessagesPublished.WithLabelValues(topic, eventType+"Confirmed", "error").Inc()
 		return fmt.Errorf("failed to publish confirmation event: %w", err)
 	}
+	metrics.KafkaMessagesPublished.WithLabelValues(topic, eventType+"Confirmed", "success").Inc()
 
 	p.logger.Info("Confirmation event published",
 		zap.String("event_type", eventType+"Confirmed"),
@@ -129,3 +226,42 @@ func (p *Producer) PublishConfirmationEvent(ctx context.Context, eventType strin
 	return nil
 }
 
+// send publishes message to Kafka or to the bus
+func (p *Producer) send(ctx context.Context, message *sarama.ProducerMessage) (partition int32, offset int64, err error) {
+	if p.bus != nil {
+		offset, err = p.bus.Send(ctx, message)
+		return 0, offset, err
+	}
+	return p.producer.SendMessage(message)
+}
+
+// Forward republishes a consumed message to topic as it was read (an encrypted payload
+// stays encrypted), with the same key and headers plus headers, which replace those of
+// the same name. Used to move failed events to the retry topics.
+func (p *Producer) Forward(ctx context.Context, topic string, message *sarama.ConsumerMessage, headers ...sarama.RecordHeader) error {
+	replaced := make(map[string]bool, len(headers))
+	for _, header := range headers {
+		replaced[string(header.Key)] = true
+	}
+	forwarded := &sarama.ProducerMessage{
+		Topic: topic,
+		Value: sarama.ByteEncoder(message.Value),
+	}
+	if len(message.Key) > 0 {
+		forwarded.Key = sarama.ByteEncoder(message.Key)
+	}
+	for _, header := range message.Headers {
+		if !replaced[string(header.Key)] {
+			forwarded.Headers = append(forwarded.Headers, *header)
+		}
+	}
+	forwarded.Headers = append(forwarded.Headers, headers...)
+
+	eventType := headerValue(message.Headers, "event-type")
+	if _, _, err := p.send(ctx, forwarded); err != nil {
+		metrics.KafkaMessagesPublished.WithLabelValues(topic, eventType, "error").Inc()
+		return fmt.Errorf("failed to forward event to %s: %w", topic, err)
+	}
+	metrics.KafkaMessagesPublished.WithLabelValues(topic, eventType, "success").Inc()
+	return nil
+}
@@ -0,0 +1,135 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"listener-service/pkg/metrics"
+
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// RejectionEventType is the event type of the messages published to the rejections topic
+const RejectionEventType = "EventRejected"
+
+// Rejection is the payload of an EventRejected event: an event of the Command Service
+// that the listener could not apply, with the request that caused it so the Command
+// Service can mark that request as failed
+type Rejection struct {
+	EventID    string    `json:"eventId"`
+	EventType  string    `json:"eventType"`
+	RequestID  string    `json:"requestId,omitempty"`
+	Actor      string    `json:"actor,omitempty"`
+	TenantID   string    `json:"tenantId,omitempty"`
+	Key        string    `json:"key,omitempty"` // Partition key of the event (item or store ID)
+	Topic      string    `json:"topic"`
+	Partition  int32     `json:"partition"`
+	Offset     int64     `json:"offset"`
+	Reason     string    `json:"reason"`
+	RejectedAt time.Time `json:"rejectedAt"`
+}
+
+// RejectionPublisher publishes the rejection of an event. It is implemented by Producer
+// and, gated on the replication role, by replication.Publisher.
+type RejectionPublisher interface {
+	PublishRejection(ctx context.Context, rejection Rejection) error
+}
+
+// SetRejectionPublisher publishes an EventRejected event for every event that fails
+// (after its retries) through publisher; call it before Start
+func (c *Consumer) SetRejectionPublisher(publisher RejectionPublisher) {
+	c.rejections = publisher
+}
+
+// reject reports a failed event to the Command Service. Failures to publish are only
+// logged and counted, like those of the DLQ.
+func (h *consumerGroupHandler) reject(ctx context.Context, message *sarama.ConsumerMessage, eventType string, cause error) {
+	if h.rejections == nil {
+		return
+	}
+	rejection := Rejection{
+		EventID:    headerValue(message.Headers, "event-id"),
+		EventType:  eventType,
+		RequestID:  headerValue(message.Headers, RequestIDHeader),
+		Actor:      headerValue(message.Headers, ActorHeader),
+		TenantID:   headerValue(message.Headers, TenantHeader),
+		Key:        string(message.Key),
+		Topic:      message.Topic,
+		Partition:  message.Partition,
+		Offset:     message.Offset,
+		Reason:     cause.Error(),
+		RejectedAt: time.Now().UTC(),
+	}
+	if err := h.rejections.PublishRejection(ctx, rejection); err != nil {
+		h.logger.Error("Failed to publish event rejection",
+			zap.String("event_type", eventType),
+			zap.String("request_id", rejection.RequestID),
+			zap.Error(err),
+		)
+		metrics.EventsRejected.WithLabelValues(eventType, "error").Inc()
+		return
+	}
+	metrics.EventsRejected.WithLabelValues(eventType, "success").Inc()
+}
+
+// PublishRejection publishes an EventRejected event to the rejections topic, keyed by
+// the request that caused the rejected event (or the event itself when it has none)
+func (p *Producer) PublishRejection(ctx context.Context, rejection Rejection) error {
+	payload, err := json.Marshal(rejection)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rejection: %w", err)
+	}
+	envelope := Envelope{
+		EventID:       uuid.New().String(),
+		EventType:     RejectionEventType,
+		SchemaVersion: SchemaVersion,
+		OccurredAt:    rejection.RejectedAt,
+		TenantID:      rejection.TenantID,
+		Payload:       payload,
+	}
+	eventData, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rejection: %w", err)
+	}
+
+	key := rejection.RequestID
+	if key == "" {
+		key = rejection.EventID
+	}
+	headers := []sarama.RecordHeader{
+		{Key: []byte("event-type"), Value: []byte(RejectionEventType)},
+		{Key: []byte("event-id"), Value: []byte(envelope.EventID)},
+		{Key: []byte(SchemaVersionHeader), Value: []byte(strconv.Itoa(SchemaVersion))},
+	}
+	if rejection.RequestID != "" {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(RequestIDHeader), Value: []byte(rejection.RequestID)})
+	}
+	if rejection.TenantID != "" {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(TenantHeader), Value: []byte(rejection.TenantID)})
+	}
+	message := &sarama.ProducerMessage{
+		Topic:   p.config.KafkaTopicRejections,
+		Key:     sarama.StringEncoder(key),
+		Value:   sarama.ByteEncoder(eventData),
+		Headers: headers,
+	}
+
+	if _, _, err := p.send(ctx, message); err != nil {
+		metrics.KafkaMessagesPublished.WithLabelValues(p.config.KafkaTopicRejections, RejectionEventType, "error").Inc()
+		return fmt.Errorf("failed to publish rejection: %w", err)
+	}
+	metrics.KafkaMessagesPublished.WithLabelValues(p.config.KafkaTopicRejections, RejectionEventType, "success").Inc()
+
+	p.logger.Warn("Event rejection published",
+		zap.String("rejected_event_type", rejection.EventType),
+		zap.String("rejected_event_id", rejection.EventID),
+		zap.String("request_id", rejection.RequestID),
+		zap.String("reason", rejection.Reason),
+	)
+	return nil
+}
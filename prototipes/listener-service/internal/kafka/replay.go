@@ -0,0 +1,354 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"listener-service/internal/config"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// ReplayPartition is the range of a partition a replay reads, from Start up to End
+// (excluded)
+type ReplayPartition struct {
+	Topic     string `json:"topic" example:"inventory.stock"`
+	Partition int32  `json:"partition" example:"0"`
+	Start     int64  `json:"start_offset" example:"0"`
+	End       int64  `json:"end_offset" example:"1054"`
+}
+
+// Replayer reads the event topics over a range of offsets outside the consumer group,
+// to rebuild the read model. Only Kafka keeps the events to replay them.
+type Replayer struct {
+	client   sarama.Client
+	consumer sarama.Consumer
+	cipher   *PayloadCipher
+	topics   []string
+	config   *config.Config
+	logger   *zap.Logger
+}
+
+// NewReplayer connects to the Kafka brokers of cfg
+func NewReplayer(cfg *config.Config, logger *zap.Logger) (*Replayer, error) {
+	if cfg.EventBus != config.EventBusKafka {
+		return nil, fmt.Errorf("replaying events needs Kafka (EVENT_BUS=%s does not keep them)", cfg.EventBus)
+	}
+	payloadCipher, err := NewPayloadCipher(cfg.EventEncryptionKeys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event encryption config: %w", err)
+	}
+
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Version = sarama.V2_8_0_0
+	if err := configureSecurity(saramaConfig, cfg); err != nil {
+		return nil, fmt.Errorf("invalid Kafka security config: %w", err)
+	}
+	client, err := sarama.NewClient(cfg.KafkaBrokers, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create replay client: %w", err)
+	}
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to create replay consumer: %w", err)
+	}
+	// The topics of every tenant at the time of the replay
+	topics, err := eventTopics(client, cfg)
+	if err != nil {
+		consumer.Close()
+		client.Close()
+		return nil, fmt.Errorf("failed to resolve event topics: %w", err)
+	}
+
+	return &Replayer{
+		client:   client,
+		consumer: consumer,
+		cipher:   payloadCipher,
+		topics:   topics,
+		config:   cfg,
+		logger:   logger,
+	}, nil
+}
+
+// Position is the next offset the consumer will handle in a partition
+type Position struct {
+	Topic     string `json:"topic" example:"inventory.stock"`
+	Partition int32  `json:"partition" example:"0"`
+	Offset    int64  `json:"offset" example:"1042"`
+}
+
+// Plan returns the range of every partition to replay: up to the current end of the
+// partition, from fromOffset (clamped to what the partition still keeps), from the first
+// message at or after fromTime, or from the oldest message kept when both are nil
+func (r *Replayer) Plan(fromOffset *int64, fromTime *time.Time) ([]ReplayPartition, error) {
+	return r.plan(func(topic string, partition int32, oldest, end int64) (int64, error) {
+		switch {
+		case fromOffset != nil:
+			return *fromOffset, nil
+		case fromTime != nil:
+			// -1 when no message is that recent
+			start, err := r.client.GetOffset(topic, partition, fromTime.UnixMilli())
+			if err != nil {
+				return 0, fmt.Errorf("failed to find offset of %s/%d at %s: %w", topic, partition, fromTime.Format(time.RFC3339), err)
+			}
+			if start < 0 {
+				return end, nil
+			}
+			return start, nil
+		}
+		return oldest, nil
+	})
+}
+
+// PlanFrom returns the range of every partition to replay from positions up to its
+// current end. Partitions without a position are replayed from the oldest message kept.
+func (r *Replayer) PlanFrom(positions []Position) ([]ReplayPartition, error) {
+	starts := make(map[string]int64, len(positions))
+	for _, position := range positions {
+		starts[position.Topic+"/"+strconv.Itoa(int(position.Partition))] = position.Offset
+	}
+	return r.plan(func(topic string, partition int32, oldest, end int64) (int64, error) {
+		if start, ok := starts[topic+"/"+strconv.Itoa(int(partition))]; ok {
+			return start, nil
+		}
+		return oldest, nil
+	})
+}
+
+// plan builds the range of every partition, from the start chosen by startOf clamped to
+// the messages the partition keeps
+func (r *Replayer) plan(startOf func(topic string, partition int32, oldest, end int64) (int64, error)) ([]ReplayPartition, error) {
+	var plan []ReplayPartition
+	for _, topic := range r.topics {
+		partitions, err := r.client.Partitions(topic)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list partitions of %s: %w", topic, err)
+		}
+		for _, partition := range partitions {
+			oldest, err := r.client.GetOffset(topic, partition, sarama.OffsetOldest)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read oldest offset of %s/%d: %w", topic, partition, err)
+			}
+			end, err := r.client.GetOffset(topic, partition, sarama.OffsetNewest)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read newest offset of %s/%d: %w", topic, partition, err)
+			}
+
+			start, err := startOf(topic, partition, oldest, end)
+			if err != nil {
+				return nil, err
+			}
+			if start < oldest {
+				start = oldest
+			}
+			if start > end {
+				start = end
+			}
+			plan = append(plan, ReplayPartition{Topic: topic, Partition: partition, Start: start, End: end})
+		}
+	}
+	return plan, nil
+}
+
+// Replay applies the messages of plan with processor, recording them through activity,
+// in timestamp order across partitions so events of different topics are applied in
+// the order they were published. Failed events are logged and recorded like in the
+// consumer, but are not retried, dead-lettered or rejected: they failed the first time
+// too. With dedup the replayed event ids are recorded as in the consumer, and events
+// repeated in the topics are applied once. handled is called after every message.
+func (r *Replayer) Replay(ctx context.Context, plan []ReplayPartition, processor EventHandler, activity ActivityRecorder, dedup EventDeduplicator, handled func(message *sarama.ConsumerMessage)) error {
+	replayConfig := *r.config
+	replayConfig.MaxRetries = 0
+	replayConfig.DeadLetterQueue = false
+	handler := &consumerGroupHandler{
+		processor: processor,
+		activity:  activity,
+		dedup:     dedup,
+		cipher:    r.cipher,
+		stats:     NewConsumerStats("replay", r.topics),
+		logger:    r.logger,
+		config:    &replayConfig,
+	}
+
+	var sources []*replaySource
+	defer func() {
+		for _, source := range sources {
+			source.consumer.AsyncClose()
+		}
+	}()
+	for _, partition := range plan {
+		if partition.Start >= partition.End {
+			continue
+		}
+		pc, err := r.consumer.ConsumePartition(partition.Topic, partition.Partition, partition.Start)
+		if err != nil {
+			return fmt.Errorf("failed to consume %s/%d: %w", partition.Topic, partition.Partition, err)
+		}
+		sources = append(sources, &replaySource{consumer: pc, end: partition.End})
+	}
+
+	for {
+		// Every partition with messages left must have its next one read before the
+		// oldest of them can be picked
+		var next *replaySource
+		for _, source := range sources {
+			if source.done {
+				continue
+			}
+			if source.head == nil {
+				select {
+				case message, ok := <-source.consumer.Messages():
+					if !ok {
+						return fmt.Errorf("replay of a partition stopped before its end offset")
+					}
+					if message.Offset >= source.end {
+						source.done = true
+						continue
+					}
+					source.head = message
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			if next == nil || source.head.Timestamp.Before(next.head.Timestamp) {
+				next = source
+			}
+		}
+		if next == nil {
+			return nil
+		}
+
+		message := next.head
+		next.head = nil
+		if message.Offset+1 >= next.end {
+			next.done = true
+		}
+		handler.handleMessage(message)
+		handled(message)
+	}
+}
+
+// Close closes the replay consumer and its broker connections
+func (r *Replayer) Close() error {
+	if err := r.consumer.Close(); err != nil {
+		return err
+	}
+	return r.client.Close()
+}
+
+// replaySource is a partition being replayed and its next message, if already read
+type replaySource struct {
+	consumer sarama.PartitionConsumer
+	end      int64
+	head     *sarama.ConsumerMessage
+	done     bool
+}
+
+// pauseGate lets the read model be rebuilt under a running consumer: while it is
+// closed no message is handled, and the messages that were replayed meanwhile are
+// skipped once it reopens
+type pauseGate struct {
+	mu       sync.RWMutex // held for writing while paused, for reading while handling
+	skipMu   sync.Mutex
+	replayed map[string]int64 // "topic/partition" -> offset the replay ended at; nil until a replay
+}
+
+// hold waits while the gate is closed and keeps it open until the returned release is
+// called
+func (g *pauseGate) hold() (release func()) {
+	g.mu.RLock()
+	return g.mu.RUnlock
+}
+
+// skips reports whether message was already applied by a replay
+func (g *pauseGate) skips(message *sarama.ConsumerMessage) bool {
+	g.skipMu.Lock()
+	defer g.skipMu.Unlock()
+	end, ok := g.replayed[message.Topic+"/"+strconv.Itoa(int(message.Partition))]
+	return ok && message.Offset < end
+}
+
+// unreplayed returns the messages that were not applied by a replay
+func (g *pauseGate) unreplayed(messages []*sarama.ConsumerMessage) []*sarama.ConsumerMessage {
+	kept := make([]*sarama.ConsumerMessage, 0, len(messages))
+	for _, message := range messages {
+		if !g.skips(message) {
+			kept = append(kept, message)
+		}
+	}
+	return kept
+}
+
+// Positions returns the next offset to handle of every partition of the topics: after
+// the last handled message, or the committed offset of the group for the partitions not
+// handled since startup. Call it while paused for a consistent cut. The other buses have
+// no positions: it returns nil.
+func (c *Consumer) Positions() ([]Position, error) {
+	if c.bus != nil {
+		return nil, nil
+	}
+	handled := make(map[string]int64)
+	for _, partition := range c.stats.Snapshot().Partitions {
+		handled[partition.Topic+"/"+strconv.Itoa(int(partition.Partition))] = partition.Offset + 1
+	}
+
+	offsets, err := sarama.NewOffsetManagerFromClient(c.config.KafkaGroupID, c.client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read committed offsets: %w", err)
+	}
+	defer offsets.Close()
+	var positions []Position
+	topics, _ := c.subscription()
+	for _, topic := range topics {
+		partitions, err := c.client.Partitions(topic)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list partitions of %s: %w", topic, err)
+		}
+		for _, partition := range partitions {
+			offset, ok := handled[topic+"/"+strconv.Itoa(int(partition))]
+			if !ok {
+				pom, err := offsets.ManagePartition(topic, partition)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read committed offset of %s/%d: %w", topic, partition, err)
+				}
+				// OffsetOldest when the group never committed: replayed from the start
+				offset, _ = pom.NextOffset()
+				pom.AsyncClose()
+			}
+			positions = append(positions, Position{Topic: topic, Partition: partition, Offset: offset})
+		}
+	}
+	return positions, nil
+}
+
+// Pause stops the consumer from handling messages, once the messages being handled are
+// done, until resume is called with what was replayed meanwhile: messages of the
+// replayed partitions below their end offset are then skipped. Only the Kafka consumer
+// group can be paused.
+func (c *Consumer) Pause() (resume func(replayed []ReplayPartition), err error) {
+	if c.bus != nil {
+		return nil, fmt.Errorf("only the Kafka consumer can be paused")
+	}
+	c.gate.mu.Lock()
+	c.logger.Warn("⏸️ Consumer paused")
+	return func(replayed []ReplayPartition) {
+		c.gate.skipMu.Lock()
+		if c.gate.replayed == nil {
+			c.gate.replayed = make(map[string]int64)
+		}
+		for _, partition := range replayed {
+			key := partition.Topic + "/" + strconv.Itoa(int(partition.Partition))
+			if partition.End > c.gate.replayed[key] {
+				c.gate.replayed[key] = partition.End
+			}
+		}
+		c.gate.skipMu.Unlock()
+		c.gate.mu.Unlock()
+		c.logger.Info("▶️ Consumer resumed", zap.Int("replayed_partitions", len(replayed)))
+	}, nil
+}
@@ -0,0 +1,173 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"listener-service/internal/config"
+	"listener-service/pkg/metrics"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// retryTopicSeparator joins a topic and the delay of one of its retry topics:
+// inventory.stock.retry.5m
+const retryTopicSeparator = ".retry."
+
+// Headers added to the messages forwarded to a retry topic
+const (
+	// RetryGroupHeader is the consumer group that forwarded the message: the groups of
+	// other regions sharing the retry topics ignore it
+	RetryGroupHeader = "retry-group"
+	// RetryErrorHeader is why the last attempt failed
+	RetryErrorHeader = "retry-error"
+)
+
+// MessageForwarder republishes a consumed message to another topic. It is implemented
+// by Producer.
+type MessageForwarder interface {
+	Forward(ctx context.Context, topic string, message *sarama.ConsumerMessage, headers ...sarama.RecordHeader) error
+}
+
+// SetRetryTopics sends the events that still fail after MAX_RETRIES to the retry topics
+// of delays, in order, through forwarder, and consumes those topics; the DLQ only gets
+// them after the last one. Call it before Start. Only the Kafka consumer group has
+// retry topics.
+func (c *Consumer) SetRetryTopics(forwarder MessageForwarder, delays []config.RetryTopicDelay) {
+	c.topicsMu.Lock()
+	defer c.topicsMu.Unlock()
+	c.forwarder = forwarder
+	c.retryDelays = delays
+	c.retryTopics = retryTopicsOf(c.topics, delays)
+}
+
+// retryTopicsOf returns the retry topics of every topic, in the order of delays
+func retryTopicsOf(topics []string, delays []config.RetryTopicDelay) []string {
+	var retryTopics []string
+	for _, topic := range topics {
+		for _, delay := range delays {
+			retryTopics = append(retryTopics, topic+retryTopicSeparator+delay.Name)
+		}
+	}
+	return retryTopics
+}
+
+// retryDelay is the wait before the attempt-th retry of an event: RETRY_DELAY_MS doubled
+// per attempt up to RETRY_MAX_DELAY_MS, shortened by a random part of up to
+// RETRY_JITTER_PERCENT so events that failed together do not retry in lockstep
+func retryDelay(cfg *config.Config, attempt int) time.Duration {
+	delay := time.Duration(cfg.RetryDelayMs) * time.Millisecond
+	limit := time.Duration(cfg.RetryMaxDelayMs) * time.Millisecond
+	for i := 1; i < attempt && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+	if cfg.RetryJitterPercent > 0 && delay > 0 {
+		delay -= time.Duration(rand.Int63n(int64(delay)*int64(cfg.RetryJitterPercent)/100 + 1))
+	}
+	return delay
+}
+
+// retryTier returns the position of topic in the retry topics (-1 for a consumed topic)
+// and the topic it retries
+func (h *consumerGroupHandler) retryTier(topic string) (int, string) {
+	for i, delay := range h.retryDelays {
+		if base := strings.TrimSuffix(topic, retryTopicSeparator+delay.Name); base != topic {
+			return i, base
+		}
+	}
+	return -1, topic
+}
+
+// scheduleRetry forwards a failed event to the next retry topic of its topic. It returns
+// false when there is none left, or the forward failed, and the event is dead-lettered.
+func (h *consumerGroupHandler) scheduleRetry(ctx context.Context, message *sarama.ConsumerMessage, eventType string, cause error) bool {
+	if h.forwarder == nil {
+		return false
+	}
+	tier, base := h.retryTier(message.Topic)
+	if tier+1 >= len(h.retryDelays) {
+		return false
+	}
+	topic := base + retryTopicSeparator + h.retryDelays[tier+1].Name
+
+	err := h.forwarder.Forward(ctx, topic, message,
+		sarama.RecordHeader{Key: []byte(RetryGroupHeader), Value: []byte(h.config.KafkaGroupID)},
+		sarama.RecordHeader{Key: []byte(RetryErrorHeader), Value: []byte(cause.Error())},
+	)
+	if err != nil {
+		h.logger.Error("Failed to send event to retry topic, dead-lettering it",
+			zap.String("event_type", eventType),
+			zap.String("retry_topic", topic),
+			zap.Error(err),
+		)
+		return false
+	}
+	h.logger.Warn("Event sent to retry topic",
+		zap.String("event_type", eventType),
+		zap.String("topic", message.Topic),
+		zap.Int64("offset", message.Offset),
+		zap.String("retry_topic", topic),
+		zap.Duration("delay", h.retryDelays[tier+1].Delay),
+		zap.Error(cause),
+	)
+	metrics.EventsRetryScheduled.WithLabelValues(eventType, topic).Inc()
+	h.recordOutcome(message, eventType, OutcomeRetried)
+	return true
+}
+
+// consumeRetries handles the messages of a retry topic one by one, each once the delay
+// of the topic has passed since it was forwarded. Waiting only holds this partition of
+// the retry topic, never the consumed topics.
+func (h *consumerGroupHandler) consumeRetries(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim, tier int) error {
+	delay := h.retryDelays[tier].Delay
+	for {
+		select {
+		case message := <-claim.Messages():
+			if message == nil {
+				return nil
+			}
+			if headerValue(message.Headers, RetryGroupHeader) == h.config.KafkaGroupID {
+				if !h.waitUntil(session.Context(), message.Timestamp.Add(delay)) {
+					// Not marked: the next owner of the partition waits for it
+					return nil
+				}
+				release := h.gate.hold()
+				h.handleMessage(message)
+				h.observeProgress(claim, message)
+				release()
+			}
+			session.MarkMessage(message, "")
+
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
+
+// waitUntil sleeps until due; it returns false if ctx is done first
+func (h *consumerGroupHandler) waitUntil(ctx context.Context, due time.Time) bool {
+	wait := time.Until(due)
+	if wait <= 0 {
+		return true
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// errProcessingTimeout is returned when an event is still failing at MAX_PROCESSING_MS
+func errProcessingTimeout(cfg *config.Config, attempts int, cause error) error {
+	return fmt.Errorf("gave up after %d attempts, MAX_PROCESSING_MS (%dms) reached: %w", attempts, cfg.MaxProcessingMs, cause)
+}
@@ -0,0 +1,125 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"listener-service/internal/config"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// recordingForwarder keeps the messages it forwards as they would be consumed from
+// their new topic, with the headers merged like Producer.Forward does
+type recordingForwarder struct {
+	mu        sync.Mutex
+	forwarded []*sarama.ConsumerMessage
+	err       error
+}
+
+func (f *recordingForwarder) Forward(_ context.Context, topic string, message *sarama.ConsumerMessage, headers ...sarama.RecordHeader) error {
+	if f.err != nil {
+		return f.err
+	}
+	replaced := make(map[string]bool, len(headers))
+	for _, header := range headers {
+		replaced[string(header.Key)] = true
+	}
+	forwarded := &sarama.ConsumerMessage{Topic: topic, Key: message.Key, Value: message.Value, Timestamp: time.Now()}
+	for _, header := range message.Headers {
+		if !replaced[string(header.Key)] {
+			forwarded.Headers = append(forwarded.Headers, header)
+		}
+	}
+	for i := range headers {
+		forwarded.Headers = append(forwarded.Headers, &headers[i])
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.forwarded = append(f.forwarded, forwarded)
+	return nil
+}
+
+func (f *recordingForwarder) messages() []*sarama.ConsumerMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*sarama.ConsumerMessage(nil), f.forwarded...)
+}
+
+// eventFunc adapts a function to EventHandler
+type eventFunc func(ctx context.Context, eventType string, eventData []byte) error
+
+func (f eventFunc) ProcessEvent(ctx context.Context, eventType string, eventData []byte) error {
+	return f(ctx, eventType, eventData)
+}
+
+// newTestHandler returns a handler without retries in place, the DLQ enabled and
+// processor applying the events
+func newTestHandler(processor EventHandler) *consumerGroupHandler {
+	return &consumerGroupHandler{
+		processor: processor,
+		stats:     NewConsumerStats("listener", []string{"inventory.stock"}),
+		logger:    zap.NewNop(),
+		config: &config.Config{
+			KafkaGroupID:    "listener",
+			DeadLetterQueue: true,
+			DLQTopic:        "inventory.dlq",
+		},
+	}
+}
+
+func testMessage(topic string, offset int64, key, eventType string) *sarama.ConsumerMessage {
+	return &sarama.ConsumerMessage{
+		Topic:     topic,
+		Offset:    offset,
+		Key:       []byte(key),
+		Value:     []byte(`{"item_id":"` + key + `"}`),
+		Timestamp: time.Now(),
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte("event-type"), Value: []byte(eventType)},
+			{Key: []byte("event-id"), Value: []byte(key + "-" + eventType)},
+		},
+	}
+}
+
+func TestRetryTopics_ExhaustedEventGoesToDLQ(t *testing.T) {
+	cause := errors.New("item not found")
+	forwarder := &recordingForwarder{}
+	h := newTestHandler(eventFunc(func(context.Context, string, []byte) error { return cause }))
+	h.forwarder = forwarder
+	h.deadLetters = forwarder
+	h.retryDelays = []config.RetryTopicDelay{{Name: "5m", Delay: 5 * time.Minute}, {Name: "1h", Delay: time.Hour}}
+
+	// The event fails on its topic and then on each retry topic it is forwarded to
+	h.handleMessage(testMessage("inventory.stock", 7, "item-1", "StockAdjusted"))
+	require.Len(t, forwarder.messages(), 1)
+	h.handleMessage(forwarder.messages()[0])
+	require.Len(t, forwarder.messages(), 2)
+	h.handleMessage(forwarder.messages()[1])
+
+	forwarded := forwarder.messages()
+	require.Len(t, forwarded, 3)
+	assert.Equal(t, "inventory.stock.retry.5m", forwarded[0].Topic)
+	assert.Equal(t, "inventory.stock.retry.1h", forwarded[1].Topic)
+
+	dead := forwarded[2]
+	assert.Equal(t, "inventory.dlq", dead.Topic)
+	assert.Equal(t, []byte("item-1"), dead.Key)
+	assert.Equal(t, []byte(`{"item_id":"item-1"}`), dead.Value)
+	assert.Equal(t, "StockAdjusted", headerValue(dead.Headers, "event-type"))
+	assert.Equal(t, "item-1-StockAdjusted", headerValue(dead.Headers, "event-id"))
+	assert.Contains(t, headerValue(dead.Headers, DLQErrorHeader), cause.Error())
+	assert.Equal(t, "inventory.stock.retry.1h", headerValue(dead.Headers, DLQTopicHeader))
+	assert.Equal(t, "listener", headerValue(dead.Headers, DLQGroupHeader))
+
+	snapshot := h.stats.Snapshot()
+	assert.Equal(t, int64(1), snapshot.DeadLettered)
+	assert.Equal(t, int64(0), snapshot.DeadLetterFailures)
+}
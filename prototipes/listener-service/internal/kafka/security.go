@@ -0,0 +1,100 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"listener-service/internal/config"
+
+	"github.com/IBM/sarama"
+	"github.com/xdg-go/scram"
+)
+
+// configureSecurity applies the TLS (KAFKA_TLS_*) and SASL (KAFKA_SASL_*) settings of
+// cfg to a sarama configuration, as managed clusters (MSK, Confluent Cloud) require.
+// With neither enabled the connection stays plaintext.
+func configureSecurity(saramaConfig *sarama.Config, cfg *config.Config) error {
+	if cfg.KafkaTLSEnabled {
+		tlsConfig, err := kafkaTLSConfig(cfg)
+		if err != nil {
+			return err
+		}
+		saramaConfig.Net.TLS.Enable = true
+		saramaConfig.Net.TLS.Config = tlsConfig
+	}
+
+	switch cfg.KafkaSASLMechanism {
+	case "":
+		return nil
+	case sarama.SASLTypePlaintext:
+	case sarama.SASLTypeSCRAMSHA256:
+		saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{hash: scram.SHA256}
+		}
+	case sarama.SASLTypeSCRAMSHA512:
+		saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{hash: scram.SHA512}
+		}
+	default:
+		return fmt.Errorf("unsupported KAFKA_SASL_MECHANISM %q", cfg.KafkaSASLMechanism)
+	}
+	saramaConfig.Net.SASL.Enable = true
+	saramaConfig.Net.SASL.Mechanism = sarama.SASLMechanism(cfg.KafkaSASLMechanism)
+	saramaConfig.Net.SASL.User = cfg.KafkaSASLUsername
+	saramaConfig.Net.SASL.Password = cfg.KafkaSASLPassword
+	saramaConfig.Net.SASL.Handshake = true
+	return nil
+}
+
+// kafkaTLSConfig verifies the brokers against KAFKA_TLS_CA_FILE (the system pool when
+// empty) and presents the client certificate when one is configured (mutual TLS)
+func kafkaTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.KafkaTLSInsecureSkipVerify,
+	}
+	if cfg.KafkaTLSCAFile != "" {
+		ca, err := os.ReadFile(cfg.KafkaTLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read KAFKA_TLS_CA_FILE: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("KAFKA_TLS_CA_FILE %s has no PEM certificate", cfg.KafkaTLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.KafkaTLSCertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(cfg.KafkaTLSCertFile, cfg.KafkaTLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the Kafka client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	return tlsConfig, nil
+}
+
+// scramClient implements sarama.SCRAMClient on xdg-go/scram
+type scramClient struct {
+	hash         scram.HashGeneratorFcn
+	conversation *scram.ClientConversation
+}
+
+func (c *scramClient) Begin(userName, password, authzID string) error {
+	client, err := c.hash.NewClient(userName, password, authzID)
+	if err != nil {
+		return err
+	}
+	c.conversation = client.NewConversation()
+	return nil
+}
+
+func (c *scramClient) Step(challenge string) (string, error) {
+	return c.conversation.Step(challenge)
+}
+
+func (c *scramClient) Done() bool {
+	return c.conversation.Done()
+}
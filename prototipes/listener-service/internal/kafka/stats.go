@@ -0,0 +1,272 @@
+package kafka
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Outcomes of a consumed event, as labelled in kafka_messages_consumed_total
+const (
+	OutcomeApplied = "applied"
+	OutcomeFailed  = "failed"
+	OutcomeSkipped = "skipped"
+	OutcomeRetried = "retried" // sent to a retry topic, handled again later
+)
+
+// PartitionStats is the progress of the consumer on one partition
+type PartitionStats struct {
+	Topic         string     `json:"topic" example:"inventory.stock"`
+	Partition     int32      `json:"partition" example:"0"`
+	Offset        int64      `json:"offset" example:"1041"`          // last offset handled
+	HighWaterMark int64      `json:"high_water_mark" example:"1054"` // offset of the next message to be produced
+	Lag           int64      `json:"lag" example:"12"`
+	LastEventAt   *time.Time `json:"last_event_at,omitempty"`
+	LastHandledAt time.Time  `json:"last_handled_at"`
+	// Time between the write in the Command Service and its application here, for the
+	// last handled event
+	DelaySeconds float64 `json:"delay_seconds" example:"0.35"`
+}
+
+// latencySamples is how many of the last processing times of each event type the
+// latency percentiles are computed over
+const latencySamples = 1000
+
+// EventTypeStats counts the events of one type since startup
+type EventTypeStats struct {
+	EventType    string `json:"event_type" example:"StockAdjusted"`
+	Applied      int64  `json:"applied" example:"830"`
+	Failed       int64  `json:"failed" example:"2"`
+	Skipped      int64  `json:"skipped" example:"0"`
+	Retries      int64  `json:"retries" example:"5"` // extra attempts, whatever their result
+	Retried      int64  `json:"retried" example:"1"` // sent to a retry topic
+	DeadLettered int64  `json:"dead_lettered" example:"2"`
+	// Processing time, retries included, over the last latencySamples events
+	LatencyP50Ms float64     `json:"latency_p50_ms" example:"1.8"`
+	LatencyP95Ms float64     `json:"latency_p95_ms" example:"12.4"`
+	LastError    *EventError `json:"last_error,omitempty"`
+}
+
+// EventError is the last failure of an event type
+type EventError struct {
+	Message string    `json:"message" example:"insufficient stock"`
+	At      time.Time `json:"at"`
+}
+
+// ConsumerSnapshot is the state of the consumer reported by the monitoring API
+type ConsumerSnapshot struct {
+	GroupID            string           `json:"group_id" example:"listener-service"`
+	Topics             []string         `json:"topics"`
+	StartedAt          time.Time        `json:"started_at"`
+	UptimeSeconds      float64          `json:"uptime_seconds" example:"3600"`
+	TotalLag           int64            `json:"total_lag" example:"12"`
+	Processed          int64            `json:"processed" example:"832"` // applied + failed + skipped + retried
+	Retries            int64            `json:"retries" example:"5"`
+	DeadLettered       int64            `json:"dead_lettered" example:"2"`
+	DeadLetterFailures int64            `json:"dead_letter_failures" example:"0"` // failed events that could not be sent to the DLQ
+	Partitions         []PartitionStats `json:"partitions"`
+	EventTypes         []EventTypeStats `json:"event_types"`
+	// Messages in DLQ_TOPIC, read from Kafka; nil without a Kafka client or when the
+	// topic cannot be read (it does not exist until something is dead-lettered)
+	DLQDepth *int64 `json:"dlq_depth,omitempty" example:"2"`
+}
+
+// ConsumerStats accumulates the progress and the outcomes of the consumer since
+// startup. The same counters are exported to Prometheus; this is the view of a single
+// listener without a metrics backend.
+type ConsumerStats struct {
+	mu                 sync.Mutex
+	groupID            string
+	topics             []string
+	startedAt          time.Time
+	partitions         map[string]*PartitionStats
+	eventTypes         map[string]*EventTypeStats
+	latencies          map[string]*latencyWindow
+	deadLetterFailures int64
+}
+
+// latencyWindow keeps the last latencySamples processing times of an event type
+type latencyWindow struct {
+	samples []time.Duration
+	next    int // where the next sample goes once samples is full
+}
+
+// add records a processing time, replacing the oldest one when the window is full
+func (w *latencyWindow) add(d time.Duration) {
+	if len(w.samples) < latencySamples {
+		w.samples = append(w.samples, d)
+		return
+	}
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % latencySamples
+}
+
+// percentiles returns the p50 and p95 of the window in milliseconds
+func (w *latencyWindow) percentiles() (p50, p95 float64) {
+	if len(w.samples) == 0 {
+		return 0, 0
+	}
+	sorted := append([]time.Duration(nil), w.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	at := func(p float64) float64 {
+		return float64(sorted[int(p*float64(len(sorted)-1))]) / float64(time.Millisecond)
+	}
+	return at(0.50), at(0.95)
+}
+
+// NewConsumerStats creates empty stats for the consumer of groupID
+func NewConsumerStats(groupID string, topics []string) *ConsumerStats {
+	return &ConsumerStats{
+		groupID:    groupID,
+		topics:     topics,
+		startedAt:  time.Now().UTC(),
+		partitions: make(map[string]*PartitionStats),
+		eventTypes: make(map[string]*EventTypeStats),
+		latencies:  make(map[string]*latencyWindow),
+	}
+}
+
+// setTopics replaces the consumed topics (KAFKA_TOPIC_SUBSCRIPTION=regex)
+func (s *ConsumerStats) setTopics(topics []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.topics = topics
+}
+
+// observePartition records the last handled message of a partition
+func (s *ConsumerStats) observePartition(topic string, partition int32, offset, highWaterMark int64, timestamp time.Time) {
+	now := time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := topic + "/" + strconv.Itoa(int(partition))
+	stats, ok := s.partitions[key]
+	if !ok {
+		stats = &PartitionStats{Topic: topic, Partition: partition}
+		s.partitions[key] = stats
+	}
+	stats.Offset = offset
+	stats.HighWaterMark = highWaterMark
+	stats.Lag = highWaterMark - offset - 1
+	if stats.Lag < 0 {
+		stats.Lag = 0
+	}
+	stats.LastHandledAt = now
+	stats.DelaySeconds = 0
+	if !timestamp.IsZero() {
+		eventAt := timestamp.UTC()
+		stats.LastEventAt = &eventAt
+		stats.DelaySeconds = now.Sub(eventAt).Seconds()
+	}
+}
+
+// recordOutcome counts an event by outcome (OutcomeApplied, OutcomeFailed, OutcomeSkipped,
+// OutcomeRetried)
+func (s *ConsumerStats) recordOutcome(eventType, outcome string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.eventType(eventType)
+	switch outcome {
+	case OutcomeApplied:
+		stats.Applied++
+	case OutcomeFailed:
+		stats.Failed++
+	case OutcomeSkipped:
+		stats.Skipped++
+	case OutcomeRetried:
+		stats.Retried++
+	}
+}
+
+// recordRetry counts an extra processing attempt
+func (s *ConsumerStats) recordRetry(eventType string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.eventType(eventType).Retries++
+}
+
+// recordLatency records how long an event took to process, retries included
+func (s *ConsumerStats) recordLatency(eventType string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	window, ok := s.latencies[eventType]
+	if !ok {
+		window = &latencyWindow{}
+		s.latencies[eventType] = window
+	}
+	window.add(d)
+}
+
+// recordError keeps err as the last failure of eventType
+func (s *ConsumerStats) recordError(eventType string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.eventType(eventType).LastError = &EventError{Message: err.Error(), At: time.Now().UTC()}
+}
+
+// recordDeadLetter counts a failed event sent to the DLQ, or that could not be sent
+func (s *ConsumerStats) recordDeadLetter(eventType string, sent bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !sent {
+		s.deadLetterFailures++
+		return
+	}
+	s.eventType(eventType).DeadLettered++
+}
+
+// eventType returns the counters of eventType; s.mu must be held
+func (s *ConsumerStats) eventType(eventType string) *EventTypeStats {
+	stats, ok := s.eventTypes[eventType]
+	if !ok {
+		stats = &EventTypeStats{EventType: eventType}
+		s.eventTypes[eventType] = stats
+	}
+	return stats
+}
+
+// Snapshot returns a copy of the stats, partitions and event types sorted by name
+func (s *ConsumerStats) Snapshot() ConsumerSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := ConsumerSnapshot{
+		GroupID:            s.groupID,
+		Topics:             append([]string(nil), s.topics...),
+		StartedAt:          s.startedAt,
+		UptimeSeconds:      time.Since(s.startedAt).Seconds(),
+		DeadLetterFailures: s.deadLetterFailures,
+		Partitions:         make([]PartitionStats, 0, len(s.partitions)),
+		EventTypes:         make([]EventTypeStats, 0, len(s.eventTypes)),
+	}
+	for _, partition := range s.partitions {
+		snapshot.Partitions = append(snapshot.Partitions, *partition)
+		snapshot.TotalLag += partition.Lag
+	}
+	for name, eventType := range s.eventTypes {
+		stats := *eventType
+		if window, ok := s.latencies[name]; ok {
+			stats.LatencyP50Ms, stats.LatencyP95Ms = window.percentiles()
+		}
+		if stats.LastError != nil {
+			lastError := *stats.LastError
+			stats.LastError = &lastError
+		}
+		snapshot.EventTypes = append(snapshot.EventTypes, stats)
+		snapshot.Processed += eventType.Applied + eventType.Failed + eventType.Skipped + eventType.Retried
+		snapshot.Retries += eventType.Retries
+		snapshot.DeadLettered += eventType.DeadLettered
+	}
+	sort.Slice(snapshot.Partitions, func(i, j int) bool {
+		a, b := snapshot.Partitions[i], snapshot.Partitions[j]
+		if a.Topic != b.Topic {
+			return a.Topic < b.Topic
+		}
+		return a.Partition < b.Partition
+	})
+	sort.Slice(snapshot.EventTypes, func(i, j int) bool {
+		return snapshot.EventTypes[i].EventType < snapshot.EventTypes[j].EventType
+	})
+	return snapshot
+}
@@ -0,0 +1,60 @@
+package kafka
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// statsCollector exports the figures of the consumer stats that Prometheus does not
+// compute by itself, read at every scrape
+type statsCollector struct {
+	consumer  *Consumer
+	latency   *prometheus.Desc
+	lastError *prometheus.Desc
+	dlqDepth  *prometheus.Desc
+	uptime    *prometheus.Desc
+}
+
+// NewStatsCollector exports the latency percentiles and last error of each event type,
+// the DLQ depth and the uptime of consumer (STATS_PROMETHEUS_EXPORT). Register it once.
+func NewStatsCollector(consumer *Consumer) prometheus.Collector {
+	return &statsCollector{
+		consumer: consumer,
+		latency: prometheus.NewDesc("event_processing_latency_ms",
+			"Processing time of the last events of a type, retries included, by quantile (0.5, 0.95).",
+			[]string{"event_type", "quantile"}, nil),
+		lastError: prometheus.NewDesc("event_last_error_timestamp_seconds",
+			"When an event of a type last failed.",
+			[]string{"event_type"}, nil),
+		dlqDepth: prometheus.NewDesc("dlq_depth_messages",
+			"Messages in the DLQ topic.",
+			nil, nil),
+		uptime: prometheus.NewDesc("consumer_uptime_seconds",
+			"Time since the consumer started.",
+			nil, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.latency
+	ch <- c.lastError
+	ch <- c.dlqDepth
+	ch <- c.uptime
+}
+
+// Collect implements prometheus.Collector
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	snapshot := c.consumer.Stats()
+	for _, eventType := range snapshot.EventTypes {
+		ch <- prometheus.MustNewConstMetric(c.latency, prometheus.GaugeValue, eventType.LatencyP50Ms, eventType.EventType, "0.5")
+		ch <- prometheus.MustNewConstMetric(c.latency, prometheus.GaugeValue, eventType.LatencyP95Ms, eventType.EventType, "0.95")
+		if eventType.LastError != nil {
+			ch <- prometheus.MustNewConstMetric(c.lastError, prometheus.GaugeValue,
+				float64(eventType.LastError.At.UnixMilli())/1000, eventType.EventType)
+		}
+	}
+	if snapshot.DLQDepth != nil {
+		ch <- prometheus.MustNewConstMetric(c.dlqDepth, prometheus.GaugeValue, float64(*snapshot.DLQDepth))
+	}
+	ch <- prometheus.MustNewConstMetric(c.uptime, prometheus.GaugeValue, snapshot.UptimeSeconds)
+}
@@ -0,0 +1,42 @@
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// eventTime returns when the event occurred, in UTC: the envelope's occurred_at, else
+// the payload's occurredAt (version 1 events), else the timestamp header. Zero if none.
+func eventTime(envelope Envelope, payload []byte, message *sarama.ConsumerMessage) time.Time {
+	if !envelope.OccurredAt.IsZero() {
+		return envelope.OccurredAt.UTC()
+	}
+	var timing struct {
+		OccurredAt time.Time `json:"occurredAt"`
+	}
+	if json.Unmarshal(payload, &timing) == nil && !timing.OccurredAt.IsZero() {
+		return timing.OccurredAt.UTC()
+	}
+	if ts, err := time.Parse(time.RFC3339, headerValue(message.Headers, "timestamp")); err == nil {
+		return ts.UTC()
+	}
+	return time.Time{}
+}
+
+// checkEventTime rejects events that claim to have occurred further in the future than
+// maxFutureSkew: no clock is that far off, so the timestamp is corrupt and applying the
+// event would put it out of order in the stock history. A zero maxFutureSkew disables
+// the check.
+func checkEventTime(occurredAt, now time.Time, maxFutureSkew time.Duration) error {
+	if maxFutureSkew <= 0 || occurredAt.IsZero() {
+		return nil
+	}
+	if ahead := occurredAt.Sub(now); ahead > maxFutureSkew {
+		return fmt.Errorf("event occurredAt %s is %s in the future (tolerance %s)",
+			occurredAt.Format(time.RFC3339), ahead.Round(time.Second), maxFutureSkew)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package kafka
+
+import (
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"listener-service/pkg/metrics"
+
+	"github.com/IBM/sarama"
+)
+
+// trackedMessage is a message handed to a worker, in the offset order of its claim
+type trackedMessage struct {
+	message *sarama.ConsumerMessage
+	done    bool
+}
+
+// offsetTracker marks the offsets of a claim handled out of order by its workers: an
+// offset is marked only once it and every offset before it are done, so a crash or a
+// rebalance never skips a message that was still queued behind a slower key
+type offsetTracker struct {
+	mu      sync.Mutex
+	pending []*trackedMessage // dispatched and not marked yet, in offset order
+}
+
+// track registers message as dispatched
+func (t *offsetTracker) track(message *sarama.ConsumerMessage) *trackedMessage {
+	tracked := &trackedMessage{message: message}
+	t.mu.Lock()
+	t.pending = append(t.pending, tracked)
+	t.mu.Unlock()
+	return tracked
+}
+
+// complete records tracked as done and calls advance with the last message of the done
+// prefix, if it grew. advance runs under the tracker's lock, so it sees offsets in order.
+func (t *offsetTracker) complete(tracked *trackedMessage, advance func(*sarama.ConsumerMessage)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tracked.done = true
+	var last *sarama.ConsumerMessage
+	for len(t.pending) > 0 && t.pending[0].done {
+		last = t.pending[0].message
+		t.pending[0] = nil
+		t.pending = t.pending[1:]
+	}
+	if last != nil {
+		advance(last)
+	}
+}
+
+// inFlight is the number of dispatched messages whose offset is not marked yet
+func (t *offsetTracker) inFlight() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.pending)
+}
+
+// workerIndex picks the worker of a message key: the same key (the item the Command
+// Service keyed the event by) always goes to the same worker, which keeps its events in
+// order. Messages without a key all go to the first worker.
+func workerIndex(key []byte, workers int) int {
+	if len(key) == 0 {
+		return 0
+	}
+	hash := fnv.New32a()
+	hash.Write(key)
+	return int(hash.Sum32() % uint32(workers))
+}
+
+// consumeParallel hands the messages of a claim to CONSUMER_WORKERS workers by key:
+// events of the same item are applied in offset order, events of different items
+// concurrently. Each worker queues up to CONSUMER_WORKER_QUEUE messages; when the queue
+// of a key is full the claim stops reading until it drains (backpressure).
+func (h *consumerGroupHandler) consumeParallel(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	tracker := &offsetTracker{}
+	inFlight := metrics.KafkaConsumerInFlight.WithLabelValues(claim.Topic(), strconv.Itoa(int(claim.Partition())))
+	defer inFlight.Set(0)
+
+	// Set when the claim ends: queued messages are then left unmarked for the next owner
+	// of the partition instead of being applied
+	var stopping atomic.Bool
+	queues := make([]chan *trackedMessage, h.config.ConsumerWorkers)
+	wg := &sync.WaitGroup{}
+	for i := range queues {
+		queues[i] = make(chan *trackedMessage, h.config.ConsumerWorkerQueue)
+		wg.Add(1)
+		go func(queue <-chan *trackedMessage) {
+			defer wg.Done()
+			for tracked := range queue {
+				if stopping.Load() {
+					continue
+				}
+				release := h.gate.hold()
+				if !h.gate.skips(tracked.message) {
+					h.handleMessage(tracked.message)
+				}
+				// Inside the gate, so a paused consumer reports positions matching the read model
+				tracker.complete(tracked, func(last *sarama.ConsumerMessage) {
+					h.observeProgress(claim, last)
+					session.MarkMessage(last, "")
+				})
+				release()
+				inFlight.Set(float64(tracker.inFlight()))
+			}
+		}(queues[i])
+	}
+	stop := func() {
+		stopping.Store(true)
+		for _, queue := range queues {
+			close(queue)
+		}
+		// The messages being applied are finished before the partition is released
+		wg.Wait()
+	}
+
+	for {
+		select {
+		case message := <-claim.Messages():
+			if message == nil {
+				stop()
+				return nil
+			}
+			tracked := tracker.track(message)
+			inFlight.Set(float64(tracker.inFlight()))
+			select {
+			case queues[workerIndex(message.Key, len(queues))] <- tracked:
+			case <-session.Context().Done():
+				stop()
+				return nil
+			}
+
+		case <-session.Context().Done():
+			stop()
+			return nil
+		}
+	}
+}
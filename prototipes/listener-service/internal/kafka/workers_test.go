@@ -0,0 +1,175 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSession records the offsets marked by a handler
+type fakeSession struct {
+	sarama.ConsumerGroupSession
+	ctx    context.Context
+	mu     sync.Mutex
+	marked []int64
+}
+
+func (s *fakeSession) Context() context.Context { return s.ctx }
+
+func (s *fakeSession) MarkMessage(message *sarama.ConsumerMessage, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.marked = append(s.marked, message.Offset)
+}
+
+// lastMarked is the last marked offset, -1 before the first
+func (s *fakeSession) lastMarked() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.marked) == 0 {
+		return -1
+	}
+	return s.marked[len(s.marked)-1]
+}
+
+// fakeClaim is a claim of partition 0 of inventory.stock fed through messages
+type fakeClaim struct {
+	sarama.ConsumerGroupClaim
+	messages chan *sarama.ConsumerMessage
+}
+
+func (c *fakeClaim) Topic() string                            { return "inventory.stock" }
+func (c *fakeClaim) Partition() int32                         { return 0 }
+func (c *fakeClaim) HighWaterMarkOffset() int64               { return 100 }
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+// startParallel runs consumeParallel with workers workers until the returned stop is
+// called
+func startParallel(t *testing.T, h *consumerGroupHandler, workers int) (*fakeSession, *fakeClaim, func()) {
+	t.Helper()
+	h.gate = &pauseGate{}
+	h.config.ConsumerWorkers = workers
+	h.config.ConsumerWorkerQueue = 4
+
+	session := &fakeSession{ctx: context.Background()}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage)}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.consumeParallel(session, claim)
+	}()
+	return session, claim, func() {
+		close(claim.messages)
+		<-done
+	}
+}
+
+func TestOffsetTracker_MarksOnlyTheDonePrefix(t *testing.T) {
+	tracker := &offsetTracker{}
+	var marked []int64
+	advance := func(last *sarama.ConsumerMessage) { marked = append(marked, last.Offset) }
+
+	first := tracker.track(&sarama.ConsumerMessage{Offset: 1})
+	second := tracker.track(&sarama.ConsumerMessage{Offset: 2})
+	third := tracker.track(&sarama.ConsumerMessage{Offset: 3})
+
+	tracker.complete(first, advance)
+	assert.Equal(t, []int64{1}, marked)
+
+	// 3 finishes before 2: nothing past 1 can be marked yet
+	tracker.complete(third, advance)
+	assert.Equal(t, []int64{1}, marked)
+	assert.Equal(t, 2, tracker.inFlight())
+
+	tracker.complete(second, advance)
+	assert.Equal(t, []int64{1, 3}, marked)
+	assert.Equal(t, 0, tracker.inFlight())
+}
+
+func TestConsumeParallel_MarksOffsetsInOrder(t *testing.T) {
+	// Two keys handled by different workers
+	slowKey, fastKey := "item-0", ""
+	for i := 1; fastKey == ""; i++ {
+		if key := "item-" + strconv.Itoa(i); workerIndex([]byte(key), 2) != workerIndex([]byte(slowKey), 2) {
+			fastKey = key
+		}
+	}
+
+	unblock := make(chan struct{})
+	applied := make(chan string, 3)
+	h := newTestHandler(eventFunc(func(_ context.Context, _ string, eventData []byte) error {
+		if string(eventData) == `{"item_id":"`+slowKey+`"}` {
+			<-unblock
+		}
+		applied <- string(eventData)
+		return nil
+	}))
+	session, claim, stop := startParallel(t, h, 2)
+	defer stop()
+
+	claim.messages <- testMessage("inventory.stock", 1, fastKey, "StockAdjusted")
+	claim.messages <- testMessage("inventory.stock", 2, slowKey, "StockAdjusted")
+	claim.messages <- testMessage("inventory.stock", 3, fastKey, "StockAdjusted")
+	<-applied
+	<-applied
+	require.Eventually(t, func() bool { return session.lastMarked() == 1 }, time.Second, time.Millisecond)
+
+	// 3 is applied, but 2 is still being applied: the group must not commit past 1
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int64(1), session.lastMarked())
+
+	close(unblock)
+	<-applied
+	require.Eventually(t, func() bool { return session.lastMarked() == 3 }, time.Second, time.Millisecond)
+}
+
+func TestConsumeParallel_KeepsKeyOrder(t *testing.T) {
+	const keys, perKey = 8, 25
+
+	var mu sync.Mutex
+	order := make(map[string][]int)
+	h := newTestHandler(eventFunc(func(_ context.Context, _ string, eventData []byte) error {
+		key, value, _ := strings.Cut(string(eventData), "/")
+		seq, _ := strconv.Atoi(value)
+		if seq%3 == 0 {
+			time.Sleep(time.Millisecond) // Uneven work, so the workers interleave
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		order[key] = append(order[key], seq)
+		return nil
+	}))
+	session, claim, stop := startParallel(t, h, 4)
+	defer stop()
+
+	offset := int64(0)
+	for seq := 0; seq < perKey; seq++ {
+		for k := 0; k < keys; k++ {
+			message := testMessage("inventory.stock", offset, "item-"+strconv.Itoa(k), "StockAdjusted")
+			message.Value = []byte("item-" + strconv.Itoa(k) + "/" + strconv.Itoa(seq))
+			claim.messages <- message
+			offset++
+		}
+	}
+	require.Eventually(t, func() bool { return session.lastMarked() == offset-1 }, 5*time.Second, time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, order, keys)
+	for key, seqs := range order {
+		require.Len(t, seqs, perKey, key)
+		for i, seq := range seqs {
+			assert.Equal(t, i, seq, "%s applied out of order: %v", key, seqs)
+		}
+	}
+	for i := 1; i < len(session.marked); i++ {
+		assert.Greater(t, session.marked[i], session.marked[i-1])
+	}
+}
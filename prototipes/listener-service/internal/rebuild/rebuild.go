@@ -0,0 +1,499 @@
+package rebuild
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"listener-service/internal/config"
+	"listener-service/internal/database"
+	"listener-service/internal/events"
+	"listener-service/internal/kafka"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// Rebuild modes. Shadow writes a new SQLite file next to the read model while the
+// consumer keeps updating the live one; truncate pauses the consumer, empties the read
+// model and replays into it; restore pauses the consumer, replaces the read model with
+// a backup and replays what came after it.
+const (
+	ModeShadow   = "shadow"
+	ModeTruncate = "truncate"
+	ModeRestore  = "restore"
+)
+
+// States of a rebuild
+const (
+	StateRunning   = "running"
+	StateCompleted = "completed"
+	StateFailed    = "failed"
+)
+
+// progressLogInterval is how many replayed messages apart progress is logged
+const progressLogInterval = 1000
+
+var (
+	// ErrInvalidRequest is returned for a request that can never be run
+	ErrInvalidRequest = errors.New("invalid rebuild request")
+	// ErrUnavailable is returned when this listener cannot rebuild its read model
+	ErrUnavailable = errors.New("rebuild unavailable")
+	// ErrRunning is returned while another rebuild is running
+	ErrRunning = errors.New("a rebuild is already running")
+)
+
+// Pauser stops the live consumer while the read model is truncated and replayed. It is
+// implemented by kafka.Consumer.
+type Pauser interface {
+	Pause() (resume func(replayed []kafka.ReplayPartition), err error)
+}
+
+// Request starts a rebuild. Events are replayed from FromOffset on every partition, or
+// from FromTimestamp, or from the oldest event Kafka keeps when both are unset.
+type Request struct {
+	Mode          string     `json:"mode" example:"shadow"` // shadow (default) or truncate
+	FromOffset    *int64     `json:"from_offset,omitempty" example:"0"`
+	FromTimestamp *time.Time `json:"from_timestamp,omitempty" example:"2024-01-15T00:00:00Z"`
+}
+
+// Backup is a backup of the read model to restore, in a local SQLite file
+type Backup struct {
+	Name      string
+	Path      string
+	Positions []kafka.Position // where the consumer was when it was taken
+	Cleanup   func()           // deletes Path when it was downloaded; nil otherwise
+}
+
+// PartitionProgress is how far the replay of a partition got
+type PartitionProgress struct {
+	kafka.ReplayPartition
+	Offset int64 `json:"offset" example:"1020"` // next offset to replay
+}
+
+// Progress is the state of the last rebuild, served by the rebuild endpoint
+type Progress struct {
+	State         string              `json:"state" example:"running"`
+	Mode          string              `json:"mode" example:"shadow"`
+	Target        string              `json:"target" example:"./inventory.db.rebuild"` // database being rebuilt
+	FromOffset    *int64              `json:"from_offset,omitempty"`
+	FromTimestamp *time.Time          `json:"from_timestamp,omitempty"`
+	StartedAt     time.Time           `json:"started_at"`
+	FinishedAt    *time.Time          `json:"finished_at,omitempty"`
+	Total         int64               `json:"total" example:"1054"`    // messages to replay
+	Replayed      int64               `json:"replayed" example:"1020"` // applied + failed + skipped
+	Applied       int64               `json:"applied" example:"1012"`
+	Failed        int64               `json:"failed" example:"8"`
+	Skipped       int64               `json:"skipped" example:"0"` // messages that could not be read
+	Percent       float64             `json:"percent" example:"96.8"`
+	Partitions    []PartitionProgress `json:"partitions"`
+	Error         string              `json:"error,omitempty"`
+}
+
+// Rebuilder rebuilds the read model by replaying the events from Kafka, one rebuild at
+// a time
+type Rebuilder struct {
+	mu       sync.Mutex
+	progress *Progress // nil until the first rebuild
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	cfg      *config.Config
+	db       database.WriterDB
+	consumer Pauser
+	logger   *zap.Logger
+}
+
+// NewRebuilder creates the rebuilder of db, the live read model; consumer is paused by
+// truncate rebuilds
+func NewRebuilder(cfg *config.Config, db database.WriterDB, consumer Pauser, logger *zap.Logger) *Rebuilder {
+	return &Rebuilder{cfg: cfg, db: db, consumer: consumer, logger: logger}
+}
+
+// Start checks req and runs the rebuild in the background; Status reports its progress
+func (r *Rebuilder) Start(req Request) (Progress, error) {
+	if req.Mode == "" {
+		req.Mode = ModeShadow
+	}
+	if err := r.check(req); err != nil {
+		return Progress{}, err
+	}
+
+	target := r.cfg.RebuildShadowPath
+	if req.Mode == ModeTruncate {
+		target = "read model (" + r.db.Driver() + ")"
+	}
+	progress, err := r.launch(Progress{
+		Mode:          req.Mode,
+		Target:        target,
+		FromOffset:    req.FromOffset,
+		FromTimestamp: req.FromTimestamp,
+	}, func(ctx context.Context) error {
+		if req.Mode == ModeTruncate {
+			return r.rebuildInPlace(ctx, req)
+		}
+		return r.rebuildShadow(ctx, req)
+	})
+	if err == nil {
+		r.logger.Warn("🔁 Read model rebuild started",
+			zap.String("mode", req.Mode),
+			zap.Any("from_offset", req.FromOffset),
+			zap.Any("from_timestamp", req.FromTimestamp),
+		)
+	}
+	return progress, err
+}
+
+// StartRestore replaces the read model with backup and replays the events published
+// after it, in the background; Status reports its progress. backup.Cleanup is called
+// once it is no longer needed, also when the restore cannot start.
+func (r *Rebuilder) StartRestore(backup Backup) (Progress, error) {
+	started := false
+	defer func() {
+		if !started && backup.Cleanup != nil {
+			backup.Cleanup()
+		}
+	}()
+	if err := r.checkAvailable(); err != nil {
+		return Progress{}, err
+	}
+	if r.db.Driver() != database.DriverSQLite {
+		return Progress{}, fmt.Errorf("%w: backups are restored into SQLite (DB_DRIVER=%s)", ErrUnavailable, r.db.Driver())
+	}
+
+	progress, err := r.launch(Progress{Mode: ModeRestore, Target: backup.Name}, func(ctx context.Context) error {
+		if backup.Cleanup != nil {
+			defer backup.Cleanup()
+		}
+		return r.restore(ctx, backup)
+	})
+	if err == nil {
+		started = true
+		r.logger.Warn("♻️ Read model restore started", zap.String("backup", backup.Name))
+	}
+	return progress, err
+}
+
+// launch runs work in the background under progress, unless a rebuild is running
+func (r *Rebuilder) launch(progress Progress, work func(ctx context.Context) error) (Progress, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.progress != nil && r.progress.State == StateRunning {
+		return Progress{}, ErrRunning
+	}
+
+	progress.State = StateRunning
+	progress.StartedAt = time.Now().UTC()
+	progress.Partitions = []PartitionProgress{}
+	r.progress = &progress
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+	go r.run(ctx, progress.Mode, work, r.done)
+
+	return r.snapshot(), nil
+}
+
+// checkAvailable rejects rebuilds where there is nothing to replay from or to write to
+func (r *Rebuilder) checkAvailable() error {
+	switch {
+	case r.cfg.EventBus != config.EventBusKafka:
+		return fmt.Errorf("%w: replaying events needs Kafka (EVENT_BUS=%s)", ErrUnavailable, r.cfg.EventBus)
+	case r.cfg.MockDependencies:
+		return fmt.Errorf("%w: mock mode has no Kafka to replay from", ErrUnavailable)
+	case r.cfg.DryRun:
+		return fmt.Errorf("%w: the read model is read-only in dry-run mode", ErrUnavailable)
+	}
+	return nil
+}
+
+// check rejects what cannot be rebuilt
+func (r *Rebuilder) check(req Request) error {
+	if err := r.checkAvailable(); err != nil {
+		return err
+	}
+
+	switch req.Mode {
+	case ModeShadow:
+		if r.db.Driver() != database.DriverSQLite {
+			return fmt.Errorf("%w: shadow mode writes a SQLite copy, use truncate with DB_DRIVER=%s", ErrInvalidRequest, r.db.Driver())
+		}
+	case ModeTruncate:
+	default:
+		return fmt.Errorf("%w: mode must be %s or %s (got %q)", ErrInvalidRequest, ModeShadow, ModeTruncate, req.Mode)
+	}
+	if req.FromOffset != nil && req.FromTimestamp != nil {
+		return fmt.Errorf("%w: set from_offset or from_timestamp, not both", ErrInvalidRequest)
+	}
+	if req.FromOffset != nil && *req.FromOffset < 0 {
+		return fmt.Errorf("%w: from_offset must not be negative", ErrInvalidRequest)
+	}
+	return nil
+}
+
+// Status returns the progress of the last rebuild; false before the first one
+func (r *Rebuilder) Status() (Progress, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.progress == nil {
+		return Progress{}, false
+	}
+	return r.snapshot(), true
+}
+
+// Stop cancels a running rebuild and waits for it to end
+func (r *Rebuilder) Stop() {
+	r.mu.Lock()
+	cancel, done := r.cancel, r.done
+	r.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// run performs a rebuild and records how it ended
+func (r *Rebuilder) run(ctx context.Context, mode string, work func(ctx context.Context) error, done chan struct{}) {
+	defer close(done)
+	err := work(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	finishedAt := time.Now().UTC()
+	r.progress.FinishedAt = &finishedAt
+	if err != nil {
+		r.progress.State = StateFailed
+		r.progress.Error = err.Error()
+		r.logger.Error("❌ Read model rebuild failed", zap.String("mode", mode), zap.Error(err))
+		return
+	}
+	r.progress.State = StateCompleted
+	r.progress.Percent = 100
+	r.logger.Info("✅ Read model rebuild completed",
+		zap.String("mode", mode),
+		zap.String("target", r.progress.Target),
+		zap.Int64("replayed", r.progress.Replayed),
+		zap.Int64("failed", r.progress.Failed),
+		zap.Duration("duration", finishedAt.Sub(r.progress.StartedAt)),
+	)
+}
+
+// rebuildShadow replays into a new SQLite file at REBUILD_SHADOW_PATH, up to the end
+// of the topics when it starts. The live read model is not touched.
+func (r *Rebuilder) rebuildShadow(ctx context.Context, req Request) error {
+	replayer, err := kafka.NewReplayer(r.cfg, r.logger)
+	if err != nil {
+		return err
+	}
+	defer replayer.Close()
+
+	path := r.cfg.RebuildShadowPath
+	for _, file := range []string{path, path + "-wal", path + "-shm"} {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove previous shadow copy: %w", err)
+		}
+	}
+	shadowCfg := *r.cfg
+	shadowCfg.DBDriver = database.DriverSQLite
+	shadowCfg.SQLitePath = path
+	shadow, err := database.Open(&shadowCfg, r.logger)
+	if err != nil {
+		return fmt.Errorf("failed to create shadow copy: %w", err)
+	}
+	defer shadow.Close()
+
+	// The replication role is not an event: carry it over so the copy can replace the
+	// read model
+	role, changedAt, err := r.db.GetReplicationRole(ctx)
+	if err == nil && role != "" {
+		err = shadow.SaveReplicationRole(ctx, role, r.cfg.Region, changedAt)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to copy replication role: %w", err)
+	}
+
+	plan, err := replayer.Plan(req.FromOffset, req.FromTimestamp)
+	if err != nil {
+		return err
+	}
+	r.setPlan(plan)
+	return r.replay(ctx, replayer, plan, shadow)
+}
+
+// rebuildInPlace pauses the consumer, empties the read model and replays into it up to
+// the end of the topics. The consumer then resumes after what was replayed.
+func (r *Rebuilder) rebuildInPlace(ctx context.Context, req Request) error {
+	replayer, err := kafka.NewReplayer(r.cfg, r.logger)
+	if err != nil {
+		return err
+	}
+	defer replayer.Close()
+
+	resume, err := r.consumer.Pause()
+	if err != nil {
+		return err
+	}
+	replayed := []kafka.ReplayPartition(nil)
+	defer func() { resume(replayed) }()
+
+	plan, err := replayer.Plan(req.FromOffset, req.FromTimestamp)
+	if err != nil {
+		return err
+	}
+	r.setPlan(plan)
+	if err := r.db.Truncate(ctx); err != nil {
+		return err
+	}
+	// From here on the read model only has what was replayed: skip it when resuming
+	// even if the replay stops halfway
+	defer func() { replayed = r.reached() }()
+	return r.replay(ctx, replayer, plan, r.db)
+}
+
+// restore pauses the consumer, replaces the read model with the backup and replays the
+// events from where the consumer was when the backup was taken up to the end of the
+// topics. The consumer then resumes after what was replayed.
+func (r *Rebuilder) restore(ctx context.Context, backup Backup) error {
+	replayer, err := kafka.NewReplayer(r.cfg, r.logger)
+	if err != nil {
+		return err
+	}
+	defer replayer.Close()
+
+	resume, err := r.consumer.Pause()
+	if err != nil {
+		return err
+	}
+	replayed := []kafka.ReplayPartition(nil)
+	defer func() { resume(replayed) }()
+
+	plan, err := replayer.PlanFrom(backup.Positions)
+	if err != nil {
+		return err
+	}
+	r.setPlan(plan)
+
+	// The replication role is not part of what is restored: keep the current one
+	role, changedAt, err := r.db.GetReplicationRole(ctx)
+	if err != nil {
+		return err
+	}
+	if err := r.db.Restore(ctx, backup.Path); err != nil {
+		return err
+	}
+	if role != "" {
+		if err := r.db.SaveReplicationRole(ctx, role, r.cfg.Region, changedAt); err != nil {
+			return fmt.Errorf("failed to keep replication role: %w", err)
+		}
+	}
+	defer func() { replayed = r.reached() }()
+	return r.replay(ctx, replayer, plan, r.db)
+}
+
+// replay applies the plan to db without publishing confirmations, recording progress
+func (r *Rebuilder) replay(ctx context.Context, replayer *kafka.Replayer, plan []kafka.ReplayPartition, db database.WriterDB) error {
+	processor := &countingProcessor{next: events.NewEventProcessor(db, nil, r.logger)}
+	var dedup kafka.EventDeduplicator
+	if r.cfg.EventDedupEnabled {
+		dedup = db
+	}
+	return replayer.Replay(ctx, plan, processor, db, dedup, func(message *sarama.ConsumerMessage) {
+		processed, err := processor.take()
+		r.handled(message, processed, err)
+	})
+}
+
+// setPlan records the partitions being replayed
+func (r *Rebuilder) setPlan(plan []kafka.ReplayPartition) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.progress.Partitions = make([]PartitionProgress, 0, len(plan))
+	r.progress.Total = 0
+	for _, partition := range plan {
+		r.progress.Partitions = append(r.progress.Partitions, PartitionProgress{ReplayPartition: partition, Offset: partition.Start})
+		r.progress.Total += partition.End - partition.Start
+	}
+	r.logger.Info("🔁 Replay planned",
+		zap.Int("partitions", len(plan)),
+		zap.Int64("messages", r.progress.Total),
+	)
+}
+
+// handled records a replayed message and the outcome of its event; processed is false
+// when the message could not be read
+func (r *Rebuilder) handled(message *sarama.ConsumerMessage, processed bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p := r.progress
+	for i := range p.Partitions {
+		if p.Partitions[i].Topic == message.Topic && p.Partitions[i].Partition == message.Partition {
+			p.Partitions[i].Offset = message.Offset + 1
+			break
+		}
+	}
+	p.Replayed++
+	switch {
+	case !processed:
+		p.Skipped++
+	case err != nil:
+		p.Failed++
+	default:
+		p.Applied++
+	}
+	if p.Total > 0 {
+		p.Percent = float64(p.Replayed) * 100 / float64(p.Total)
+	}
+	if p.Replayed%progressLogInterval == 0 {
+		r.logger.Info("🔁 Rebuild progress",
+			zap.Int64("replayed", p.Replayed),
+			zap.Int64("total", p.Total),
+			zap.String("percent", strconv.FormatFloat(p.Percent, 'f', 1, 64)),
+		)
+	}
+}
+
+// reached returns the ranges replayed so far, ending at the next offset to replay
+func (r *Rebuilder) reached() []kafka.ReplayPartition {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reached := make([]kafka.ReplayPartition, 0, len(r.progress.Partitions))
+	for _, partition := range r.progress.Partitions {
+		replayed := partition.ReplayPartition
+		replayed.End = partition.Offset
+		reached = append(reached, replayed)
+	}
+	return reached
+}
+
+// snapshot copies the progress; r.mu must be held
+func (r *Rebuilder) snapshot() Progress {
+	progress := *r.progress
+	progress.Partitions = append([]PartitionProgress(nil), r.progress.Partitions...)
+	return progress
+}
+
+// countingProcessor remembers the outcome of the last event it processed, so each
+// replayed message can be counted as applied or failed
+type countingProcessor struct {
+	next      kafka.EventHandler
+	processed bool
+	err       error
+}
+
+func (p *countingProcessor) ProcessEvent(ctx context.Context, eventType string, eventData []byte) error {
+	p.err = p.next.ProcessEvent(ctx, eventType, eventData)
+	p.processed = true
+	return p.err
+}
+
+// take returns the outcome of the last event and forgets it; processed is false when
+// no event was processed since the last call
+func (p *countingProcessor) take() (processed bool, err error) {
+	processed, err = p.processed, p.err
+	p.processed, p.err = false, nil
+	return processed, err
+}
@@ -0,0 +1,248 @@
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"listener-service/internal/events"
+	"listener-service/internal/kafka"
+	"listener-service/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+// Replication roles. Every region consumes the same topics into its own read model;
+// only the primary publishes confirmation events.
+const (
+	RolePrimary   = "primary"
+	RoleSecondary = "secondary"
+)
+
+// ErrReadOnly is returned when the role cannot be persisted (dry-run database)
+var ErrReadOnly = errors.New("role changes need a writable database")
+
+// Store persists role changes so a promoted region stays primary after a restart
+type Store interface {
+	GetReplicationRole(ctx context.Context) (role string, changedAt time.Time, err error)
+	SaveReplicationRole(ctx context.Context, role, region string, changedAt time.Time) error
+}
+
+// State is the replication role of this region and how far its read model is behind
+// the topics
+type State struct {
+	mu            sync.RWMutex
+	role          string
+	region        string
+	changedAt     time.Time
+	lastEventAt   time.Time // Kafka timestamp of the last handled event
+	lastHandledAt time.Time
+	partitionLag  map[string]int64 // "topic/partition" -> messages behind the high-water mark
+	store         Store            // nil in dry-run
+	logger        *zap.Logger
+}
+
+// Status is a snapshot of the state, served by the replication-lag endpoint
+type Status struct {
+	Role          string           `json:"role" example:"secondary"`
+	Region        string           `json:"region" example:"eu-west-1"`
+	RoleChangedAt *time.Time       `json:"role_changed_at,omitempty"`
+	LastEventAt   *time.Time       `json:"last_event_at,omitempty"`
+	LastHandledAt *time.Time       `json:"last_handled_at,omitempty"`
+	LagSeconds    float64          `json:"lag_seconds" example:"0.8"`
+	OffsetLag     int64            `json:"offset_lag" example:"12"`
+	Partitions    map[string]int64 `json:"partitions"`
+}
+
+// NewState starts in the configured role; Restore applies a persisted promotion
+func NewState(role, region string, store Store, logger *zap.Logger) *State {
+	s := &State{
+		role:         role,
+		region:       region,
+		partitionLag: make(map[string]int64),
+		store:        store,
+		logger:       logger,
+	}
+	s.updateRoleMetric()
+	return s
+}
+
+// Restore applies the role recorded by the last promote/demote, which takes precedence
+// over REPLICATION_ROLE so a promoted region does not fall back to secondary on restart
+func (s *State) Restore(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+	role, changedAt, err := s.store.GetReplicationRole(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load replication role: %w", err)
+	}
+	if role == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if role != s.role {
+		s.logger.Warn("Persisted replication role overrides REPLICATION_ROLE",
+			zap.String("configured_role", s.role),
+			zap.String("persisted_role", role),
+			zap.Time("changed_at", changedAt),
+		)
+	}
+	s.role = role
+	s.changedAt = changedAt
+	s.updateRoleMetric()
+	return nil
+}
+
+// Role returns the current role
+func (s *State) Role() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.role
+}
+
+// Region returns the region this listener serves
+func (s *State) Region() string {
+	return s.region
+}
+
+// IsPrimary reports whether this region publishes confirmation events
+func (s *State) IsPrimary() bool {
+	return s.Role() == RolePrimary
+}
+
+// SetRole persists and applies a new role. It returns false when the region already
+// had that role.
+func (s *State) SetRole(ctx context.Context, role string) (bool, error) {
+	if role != RolePrimary && role != RoleSecondary {
+		return false, fmt.Errorf("unknown replication role %q", role)
+	}
+	if s.store == nil {
+		return false, ErrReadOnly
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.role == role {
+		return false, nil
+	}
+
+	now := time.Now().UTC()
+	if err := s.store.SaveReplicationRole(ctx, role, s.region, now); err != nil {
+		return false, fmt.Errorf("failed to persist replication role: %w", err)
+	}
+	s.logger.Warn("Replication role changed",
+		zap.String("region", s.region),
+		zap.String("from", s.role),
+		zap.String("to", role),
+	)
+	s.role = role
+	s.changedAt = now
+	s.updateRoleMetric()
+	return true, nil
+}
+
+// Observe records a handled message and how many messages are still behind it in its
+// partition; it implements kafka.ProgressObserver
+func (s *State) Observe(topic string, partition int32, timestamp time.Time, lag int64) {
+	now := time.Now().UTC()
+
+	s.mu.Lock()
+	if !timestamp.IsZero() {
+		s.lastEventAt = timestamp.UTC()
+	}
+	s.lastHandledAt = now
+	s.partitionLag[topic+"/"+strconv.Itoa(int(partition))] = lag
+	s.mu.Unlock()
+
+	metrics.ReplicationLag.Set(s.Status().LagSeconds)
+}
+
+// Status returns the role and the lag. The lag in seconds is the age of the last
+// handled event while messages are still pending, and zero once caught up.
+func (s *State) Status() Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := Status{
+		Role:       s.role,
+		Region:     s.region,
+		Partitions: make(map[string]int64, len(s.partitionLag)),
+	}
+	for partition, lag := range s.partitionLag {
+		status.Partitions[partition] = lag
+		status.OffsetLag += lag
+	}
+	if !s.changedAt.IsZero() {
+		changedAt := s.changedAt
+		status.RoleChangedAt = &changedAt
+	}
+	if !s.lastHandledAt.IsZero() {
+		lastHandledAt := s.lastHandledAt
+		status.LastHandledAt = &lastHandledAt
+	}
+	if !s.lastEventAt.IsZero() {
+		lastEventAt := s.lastEventAt
+		status.LastEventAt = &lastEventAt
+		if status.OffsetLag > 0 {
+			status.LagSeconds = time.Since(lastEventAt).Seconds()
+		}
+	}
+	return status
+}
+
+func (s *State) updateRoleMetric() {
+	primary := 0.0
+	if s.role == RolePrimary {
+		primary = 1
+	}
+	metrics.ReplicationPrimary.Set(primary)
+}
+
+// Publisher forwards confirmation events only while the region is primary. The
+// secondary applies the same events to its read model but must not confirm them a
+// second time.
+type Publisher struct {
+	state  *State
+	next   events.EventPublisher
+	logger *zap.Logger
+}
+
+// NewPublisher gates next on the replication role
+func NewPublisher(state *State, next events.EventPublisher, logger *zap.Logger) *Publisher {
+	return &Publisher{state: state, next: next, logger: logger}
+}
+
+// PublishConfirmationEvent implements events.EventPublisher
+func (p *Publisher) PublishConfirmationEvent(ctx context.Context, eventType string, itemID, sku string, data interface{}) error {
+	if !p.state.IsPrimary() {
+		p.logger.Debug("Secondary region: confirmation event not published",
+			zap.String("event_type", eventType+"Confirmed"),
+			zap.String("item_id", itemID),
+		)
+		return nil
+	}
+	return p.next.PublishConfirmationEvent(ctx, eventType, itemID, sku, data)
+}
+
+// PublishRejection implements kafka.RejectionPublisher. Like confirmations, only the
+// primary reports events it could not apply; next must also publish rejections.
+func (p *Publisher) PublishRejection(ctx context.Context, rejection kafka.Rejection) error {
+	if !p.state.IsPrimary() {
+		p.logger.Debug("Secondary region: event rejection not published",
+			zap.String("event_type", rejection.EventType),
+			zap.String("request_id", rejection.RequestID),
+		)
+		return nil
+	}
+	rejections, ok := p.next.(kafka.RejectionPublisher)
+	if !ok {
+		return nil
+	}
+	return rejections.PublishRejection(ctx, rejection)
+}
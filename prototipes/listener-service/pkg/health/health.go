@@ -0,0 +1,189 @@
+// Package health implements the liveness and readiness probes. /health/live only says
+// the process is serving HTTP; /health/ready probes every dependency the service needs
+// (database, Kafka, Redis) and reports the status and latency of each one.
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Dependency statuses
+const (
+	StatusUp      = "up"
+	StatusFailing = "failing" // failed, fewer consecutive times than the failure threshold
+	StatusDown    = "down"
+)
+
+// Readiness statuses
+const (
+	StatusReady    = "ready"
+	StatusDegraded = "degraded"  // ready, but a dependency is failing or a non-critical one is down
+	StatusNotReady = "not_ready" // a critical dependency is down: answered with 503
+)
+
+// Pinger is implemented by the clients that can probe their backend
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Dependency is something the service needs to do its job
+type Dependency struct {
+	Name     string
+	Critical bool // down makes the service not ready; a non-critical one only degrades it
+	Check    func(ctx context.Context) error
+}
+
+// Unavailable is the check of a dependency that could not be set up at startup (e.g. a
+// client replaced by an in-memory fallback): it always fails with reason
+func Unavailable(reason string) func(ctx context.Context) error {
+	err := errors.New(reason)
+	return func(ctx context.Context) error { return err }
+}
+
+// Config configures the readiness probe
+type Config struct {
+	Service          string
+	Timeout          time.Duration // of each dependency check
+	FailureThreshold int           // consecutive failed checks before a dependency is down
+}
+
+// DependencyReport is the outcome of one dependency check
+type DependencyReport struct {
+	Name                string  `json:"name"`
+	Status              string  `json:"status"`
+	Critical            bool    `json:"critical"`
+	LatencyMs           float64 `json:"latency_ms"`
+	ConsecutiveFailures int     `json:"consecutive_failures,omitempty"`
+	Error               string  `json:"error,omitempty"`
+}
+
+// Report is the readiness of the service
+type Report struct {
+	Status       string             `json:"status"`
+	Service      string             `json:"service"`
+	CheckedAt    time.Time          `json:"checked_at"`
+	Dependencies []DependencyReport `json:"dependencies"`
+}
+
+// Checker runs the dependency checks and remembers consecutive failures, so a single
+// slow ping does not take the replica out of rotation unless the threshold is 1
+type Checker struct {
+	config       Config
+	started      time.Time
+	mu           sync.Mutex
+	dependencies []Dependency
+	failures     map[string]int
+}
+
+// NewChecker creates a checker without dependencies
+func NewChecker(cfg Config) *Checker {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = time.Second
+	}
+	if cfg.FailureThreshold < 1 {
+		cfg.FailureThreshold = 1
+	}
+	return &Checker{config: cfg, started: time.Now(), failures: map[string]int{}}
+}
+
+// Register adds a dependency to the readiness probe
+func (h *Checker) Register(dependency Dependency) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.dependencies = append(h.dependencies, dependency)
+}
+
+// Check probes every dependency concurrently, each within the configured timeout
+func (h *Checker) Check(ctx context.Context) Report {
+	h.mu.Lock()
+	dependencies := append([]Dependency(nil), h.dependencies...)
+	h.mu.Unlock()
+
+	reports := make([]DependencyReport, len(dependencies))
+	errs := make([]error, len(dependencies))
+	var wg sync.WaitGroup
+	for i, dependency := range dependencies {
+		wg.Add(1)
+		go func(i int, dependency Dependency) {
+			defer wg.Done()
+			start := time.Now()
+			errs[i] = h.run(ctx, dependency)
+			reports[i] = DependencyReport{
+				Name:      dependency.Name,
+				Critical:  dependency.Critical,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+		}(i, dependency)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusReady, Service: h.config.Service, CheckedAt: time.Now().UTC(), Dependencies: reports}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := range reports {
+		dependency := &reports[i]
+		if errs[i] == nil {
+			h.failures[dependency.Name] = 0
+			dependency.Status = StatusUp
+			continue
+		}
+
+		h.failures[dependency.Name]++
+		dependency.ConsecutiveFailures = h.failures[dependency.Name]
+		dependency.Error = errs[i].Error()
+		dependency.Status = StatusFailing
+		if dependency.ConsecutiveFailures >= h.config.FailureThreshold {
+			dependency.Status = StatusDown
+		}
+
+		switch {
+		case dependency.Status == StatusDown && dependency.Critical:
+			report.Status = StatusNotReady
+		case report.Status == StatusReady:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// run calls the check and gives up after the timeout even if the check ignores ctx
+func (h *Checker) run(ctx context.Context, dependency Dependency) error {
+	ctx, cancel := context.WithTimeout(ctx, h.config.Timeout)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() { result <- dependency.Check(ctx) }()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Live handles GET /health/live: the process is up and serving requests. It checks no
+// dependency, so an orchestrator does not restart the service because Kafka is down.
+func (h *Checker) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":         "alive",
+		"service":        h.config.Service,
+		"uptime_seconds": int64(time.Since(h.started).Seconds()),
+	})
+}
+
+// Ready handles GET /health/ready: 200 while ready or degraded, 503 when a critical
+// dependency is down
+func (h *Checker) Ready(c *gin.Context) {
+	report := h.Check(c.Request.Context())
+	status := http.StatusOK
+	if report.Status == StatusNotReady {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
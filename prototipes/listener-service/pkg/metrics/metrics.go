@@ -0,0 +1,194 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// HTTP metrics
+var (
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method, route and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// Kafka metrics
+var (
+	// KafkaMessagesConsumed counts consumed events by outcome (applied, failed, skipped)
+	KafkaMessagesConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_messages_consumed_total",
+		Help: "Kafka messages consumed by topic, event type and outcome.",
+	}, []string{"topic", "event_type", "outcome"})
+
+	// KafkaConsumerLag is the number of messages behind the partition's high-water mark
+	KafkaConsumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kafka_consumer_lag",
+		Help: "Messages between the last consumed offset and the partition high-water mark.",
+	}, []string{"topic", "partition"})
+
+	// KafkaMessagesPublished counts confirmation events by outcome (success, error)
+	KafkaMessagesPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_messages_published_total",
+		Help: "Kafka messages published by topic, event type and outcome.",
+	}, []string{"topic", "event_type", "outcome"})
+
+	// KafkaConsumerInFlight is the number of messages of a partition handed to its workers
+	// and not marked yet (CONSUMER_WORKERS > 1)
+	KafkaConsumerInFlight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kafka_consumer_in_flight_messages",
+		Help: "Messages of a partition dispatched to the consumer workers whose offset is not committed yet.",
+	}, []string{"topic", "partition"})
+
+	// EventProcessingDuration covers the whole processing of an event, retries included
+	EventProcessingDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "event_processing_duration_seconds",
+		Help:    "Time to apply a consumed event to the read model, retries included.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"event_type"})
+
+	// EventRetries counts the extra attempts to apply an event after a failure
+	EventRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "event_retries_total",
+		Help: "Extra attempts to apply a consumed event after a failed one.",
+	}, []string{"event_type"})
+
+	// EventsDeadLettered counts failed events sent to the DLQ by outcome (success, error)
+	EventsDeadLettered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "events_dead_lettered_total",
+		Help: "Failed events sent to the dead letter queue by event type and outcome.",
+	}, []string{"event_type", "outcome"})
+
+	// EventsRejected counts EventRejected events published for failed events by outcome (success, error)
+	EventsRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "events_rejected_total",
+		Help: "EventRejected events published for events that could not be applied, by event type and outcome.",
+	}, []string{"event_type", "outcome"})
+
+	// EventsRetryScheduled counts failed events sent to a retry topic instead of the DLQ
+	EventsRetryScheduled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "events_retry_scheduled_total",
+		Help: "Failed events sent to a retry topic to be processed again later, by event type and retry topic.",
+	}, []string{"event_type", "retry_topic"})
+
+	// EventsDeduplicated counts redelivered events skipped because they were already applied
+	EventsDeduplicated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "events_deduplicated_total",
+		Help: "Redelivered events skipped because their event-id was already applied to the read model, by event type.",
+	}, []string{"event_type"})
+
+	// EventBatchSize is the number of events applied per batch transaction (BATCH_SIZE > 1)
+	EventBatchSize = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "event_batch_size",
+		Help:    "Events applied to the read model in a single batch transaction.",
+		Buckets: []float64{1, 2, 5, 10, 25, 50, 100, 250, 500},
+	})
+
+	// EventBatchDeferred counts batched events applied again one by one after failing in their batch
+	EventBatchDeferred = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "event_batch_deferred_total",
+		Help: "Batched events that failed in their batch (or followed a failed event of the same key) and were processed individually.",
+	})
+)
+
+// SQLite metrics
+var (
+	// SQLiteWriteDuration is the time a write holds the single-writer lock
+	SQLiteWriteDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "sqlite_write_duration_seconds",
+		Help:    "Duration of SQLite write operations while holding the single-writer lock.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation"})
+
+	// SQLiteCommitDuration is the time spent in tx.Commit for transactional writes
+	SQLiteCommitDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "sqlite_commit_duration_seconds",
+		Help:    "Duration of SQLite transaction commits.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation"})
+)
+
+// Replication metrics
+var (
+	// ReplicationPrimary is 1 while this region publishes confirmations, 0 as secondary
+	ReplicationPrimary = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "replication_primary",
+		Help: "1 if this listener is the primary region, 0 if it is a secondary replica.",
+	})
+
+	// ReplicationLag is the age of the last handled event while messages are pending
+	ReplicationLag = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "replication_lag_seconds",
+		Help: "Age of the last applied event while the consumer is behind the topics; 0 when caught up.",
+	})
+)
+
+// Archive metrics
+var (
+	// EventsArchived counts handled events by archive outcome (uploaded, dropped)
+	EventsArchived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "events_archived_total",
+		Help: "Handled events written to the archive storage (uploaded) or discarded because the buffer was full (dropped).",
+	}, []string{"outcome"})
+
+	// ArchiveUploads counts archive files written by outcome (success, error)
+	ArchiveUploads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "archive_uploads_total",
+		Help: "Archive files written to the object storage by outcome.",
+	}, []string{"outcome"})
+
+	// ArchivePending is the number of events waiting in memory for their archive file
+	ArchivePending = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "archive_pending_events",
+		Help: "Handled events buffered in memory until their archive file is written.",
+	})
+)
+
+// Backup metrics
+var (
+	// BackupsTaken counts read model backups by outcome (success, error)
+	BackupsTaken = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "backups_taken_total",
+		Help: "Backups of the SQLite read model by outcome.",
+	}, []string{"outcome"})
+
+	// BackupLastSuccess is the Unix time of the last successful backup
+	BackupLastSuccess = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "backup_last_success_timestamp_seconds",
+		Help: "Unix time of the last successful backup of the read model.",
+	})
+
+	// BackupSize is the size of the last backup
+	BackupSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "backup_size_bytes",
+		Help: "Size of the last backup of the read model.",
+	})
+)
+
+// GinMiddleware records the latency and status of every request. Requests that
+// match no route are grouped under "unmatched" to keep label cardinality bounded.
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequestDuration.WithLabelValues(
+			c.Request.Method, route, strconv.Itoa(c.Writer.Status()),
+		).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
@@ -0,0 +1,135 @@
+package tracing
+
+import (
+	"context"
+	"os"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// ServiceName is the default service.name of the spans (OTEL_SERVICE_NAME overrides it)
+const ServiceName = "listener-service"
+
+// Init installs the global tracer provider and the W3C trace-context propagator.
+//
+// Spans are exported over OTLP/HTTP when OTEL_EXPORTER_OTLP_ENDPOINT (or
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT) is set, configured by the standard OTEL_*
+// variables. Without an endpoint no spans are recorded, but the trace context read
+// from consumed events is still copied onto the confirmation events. The returned
+// function flushes pending spans on shutdown.
+func Init(ctx context.Context, logger *zap.Logger) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" && os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		logger.Info("Tracing export disabled (OTEL_EXPORTER_OTLP_ENDPOINT not set)")
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(ServiceName)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES take precedence over the default name
+	if fromEnv, err := resource.New(ctx, resource.WithFromEnv()); err == nil {
+		if merged, err := resource.Merge(res, fromEnv); err == nil {
+			res = merged
+		}
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	logger.Info("Tracing enabled (OTLP/HTTP)")
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the service's tracer from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(ServiceName)
+}
+
+// InjectKafka writes the trace context of ctx into Kafka record headers
+func InjectKafka(ctx context.Context, headers *[]sarama.RecordHeader) {
+	otel.GetTextMapPropagator().Inject(ctx, producerHeaders{headers: headers})
+}
+
+// ExtractKafka returns ctx carrying the trace context found in consumed Kafka headers
+func ExtractKafka(ctx context.Context, headers []*sarama.RecordHeader) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, consumerHeaders(headers))
+}
+
+// producerHeaders adapts outgoing Kafka headers to propagation.TextMapCarrier
+type producerHeaders struct {
+	headers *[]sarama.RecordHeader
+}
+
+func (c producerHeaders) Get(key string) string {
+	for _, header := range *c.headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (c producerHeaders) Set(key, value string) {
+	for i, header := range *c.headers {
+		if string(header.Key) == key {
+			(*c.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.headers = append(*c.headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+func (c producerHeaders) Keys() []string {
+	keys := make([]string, 0, len(*c.headers))
+	for _, header := range *c.headers {
+		keys = append(keys, string(header.Key))
+	}
+	return keys
+}
+
+// consumerHeaders adapts the headers of a consumed Kafka message to
+// propagation.TextMapCarrier. It is read-only.
+type consumerHeaders []*sarama.RecordHeader
+
+func (c consumerHeaders) Get(key string) string {
+	for _, header := range c {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (c consumerHeaders) Set(string, string) {}
+
+func (c consumerHeaders) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for _, header := range c {
+		keys = append(keys, string(header.Key))
+	}
+	return keys
+}
@@ -2,23 +2,35 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"query-service/internal/auth"
 	"query-service/internal/cache"
 	"query-service/internal/config"
+	"query-service/internal/graphqlapi"
 	"query-service/internal/handlers"
 	"query-service/internal/kafka"
+	"query-service/internal/probe"
+	"query-service/internal/schemacheck"
+	"query-service/internal/stream"
+	"query-service/internal/valuation"
+	"query-service/pkg/health"
 	"query-service/pkg/logger"
+	"query-service/pkg/metrics"
 	"query-service/pkg/middleware"
+	"query-service/pkg/tracing"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.uber.org/zap"
 
 	_ "query-service/docs" // Import docs for Swagger
@@ -26,7 +38,7 @@ import (
 
 // @title           Query Service API
 // @version         1.0
-// @description     API de lectura para el sistema de inventario basado en arquitectura CQRS + EDA. Optimizado para baja latencia y alta escalabilidad.
+// @description     API de lectura para el sistema de inventario basado en arquitectura CQRS + EDA. Optimizado para baja latencia y alta escalabilidad. Los errores responden siempre el mismo envelope (`code`, `message`, `details`, `request_id`, `timestamp`); los clientes deben decidir según `code` (ver ErrorResponse).
 // @termsOfService  http://swagger.io/terms/
 
 // @contact.name   API Support
@@ -51,27 +63,59 @@ func main() {
 	// Load configuration
 	cfg := config.Load()
 
+	checkConfig := flag.Bool("check-config", false, "Validate the configuration, probe the event bus and the SQLite paths, and exit (non-zero if anything failed)")
+	flag.Parse()
+	if *checkConfig {
+		if !cfg.Check(context.Background(), os.Stdout, time.Duration(cfg.HealthCheckTimeoutMs)*time.Millisecond) {
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Initialize logger
 	appLogger := logger.New(cfg.Environment)
 	defer appLogger.Sync()
 
+	// Refuse to start with a configuration that would only fail later
+	if err := cfg.Validate(); err != nil {
+		appLogger.Fatal("❌ Invalid configuration", zap.Error(err))
+	}
+	if err := cfg.RunStartupChecks(context.Background(), appLogger); err != nil {
+		appLogger.Fatal("❌ Startup checks failed (STARTUP_CHECK_MODE=strict)", zap.Error(err))
+	}
+
 	appLogger.Info("🚀 Starting Query Service",
 		zap.String("environment", cfg.Environment),
 		zap.String("port", cfg.Port),
 	)
 
-	appLogger.Info("💾 SQLite Configuration",
-		zap.String("path", cfg.SQLitePath),
+	// Initialize tracing; the cache-invalidation consumer continues the traces of the writes
+	shutdownTracing, err := tracing.Init(context.Background(), appLogger)
+	if err != nil {
+		appLogger.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+
+	if cfg.MockDependencies {
+		appLogger.Warn("🧪 Mock mode: in-memory read model, users, tokens and cache; no SQLite file, Kafka or Redis")
+	}
+
+	appLogger.Info("💾 Read Model Configuration",
+		zap.String("driver", cfg.DBDriver),
+		zap.String("sqlite_path", cfg.SQLitePath),
 		zap.String("note", "Reading from same database as Listener Service"),
 	)
 
 	appLogger.Info("🔐 JWT Configuration",
 		zap.Int("secret_length", len(cfg.JWTSecret)),
-		zap.String("note", "Token expiration: 10 minutes"),
+		zap.Int("access_token_ttl_seconds", cfg.JWTAccessTokenTTLSeconds),
+		zap.String("issuer", cfg.JWTIssuer),
+		zap.String("audience", cfg.JWTAudience),
+		zap.String("jwks_url", cfg.JWTJWKSURL),
 	)
 
 	if cfg.UseCache {
 		appLogger.Info("💾 Cache Configuration (Optional)",
+			zap.String("backend", cfg.CacheBackend),
 			zap.String("redis_host", cfg.RedisHost),
 			zap.String("redis_port", cfg.RedisPort),
 			zap.Int("cache_ttl", cfg.CacheTTL),
@@ -86,9 +130,11 @@ func main() {
 
 	if cfg.UseKafka {
 		appLogger.Info("📡 Kafka Configuration (Optional - for cache invalidation)",
+			zap.String("event_bus", cfg.EventBus),
 			zap.Strings("brokers", cfg.KafkaBrokers),
 			zap.String("topic_items", cfg.KafkaTopicItems),
 			zap.String("topic_stock", cfg.KafkaTopicStock),
+			zap.String("topic_stores", cfg.KafkaTopicStores),
 			zap.String("group_id", cfg.KafkaGroupID),
 			zap.Bool("auto_commit", cfg.KafkaAutoCommit),
 			zap.Bool("enabled", cfg.UseKafka),
@@ -109,7 +155,29 @@ func main() {
 	router := gin.New()
 
 	// CORS middleware (must be first to handle preflight requests)
-	router.Use(middleware.CORSMiddleware())
+	corsConfig, err := middleware.NewCORSConfig(cfg.CORSAllowedOrigins, cfg.CORSAllowedHeaders, cfg.CORSMaxAgeSeconds)
+	if err != nil {
+		appLogger.Fatal("Invalid CORS configuration", zap.Error(err))
+	}
+	router.Use(middleware.CORSMiddleware(corsConfig))
+
+	// SLO tracking (outside the recovery handler so recovered panics count as 5xx)
+	sloTracker := middleware.NewSLOTracker(middleware.SLOConfig{
+		Service:            "query-service",
+		AvailabilityTarget: cfg.SLOAvailabilityTarget,
+		LatencyThreshold:   time.Duration(cfg.SLOLatencyThresholdMs) * time.Millisecond,
+		LatencyTarget:      cfg.SLOLatencyTarget,
+		ExcludePrefixes:    []string{"/api/v1/health", "/api/v1/slo", "/swagger", "/metrics"},
+	})
+	router.Use(sloTracker.Middleware())
+
+	// Prometheus request metrics (also outside the recovery handler)
+	router.Use(metrics.GinMiddleware())
+
+	// Server span per read request
+	router.Use(otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(func(r *http.Request) bool {
+		return r.URL.Path != "/metrics" && !strings.HasPrefix(r.URL.Path, "/api/v1/health")
+	})))
 
 	router.Use(middleware.RecoveryHandler(appLogger))
 	router.Use(logger.GinMiddleware(appLogger))
@@ -117,6 +185,32 @@ func main() {
 	// Request ID middleware (must be early in the chain)
 	router.Use(middleware.RequestIDMiddleware(appLogger))
 
+	// Handler timeouts (HANDLER_TIMEOUT_SECONDS, HANDLER_ROUTE_TIMEOUTS), before any middleware
+	// that wraps the response writer so route timeouts can move the connection deadlines
+	routeTimeouts, err := middleware.ParseRouteTimeouts(cfg.HandlerRouteTimeouts)
+	if err != nil {
+		appLogger.Fatal("Invalid HANDLER_ROUTE_TIMEOUTS", zap.Error(err))
+	}
+	if _, ok := routeTimeouts["GET /api/v1/inventory/stream"]; !ok {
+		// The live stream stays open until the client leaves
+		routeTimeouts["GET /api/v1/inventory/stream"] = 0
+	}
+	router.Use(middleware.RequestTimeout(middleware.RequestTimeoutConfig{
+		Default: time.Duration(cfg.HandlerTimeoutSeconds) * time.Second,
+		Routes:  routeTimeouts,
+	}, appLogger))
+
+	// Request body size limit (MAX_REQUEST_BODY_BYTES)
+	router.Use(middleware.BodyLimit(int64(cfg.MaxRequestBodyBytes)))
+
+	// gzip compression (before the envelope, so the envelope is compressed too)
+	if cfg.GzipEnabled {
+		router.Use(middleware.Gzip(cfg.GzipMinSizeBytes))
+	}
+
+	// Optional {data, meta} response envelope (RESPONSE_ENVELOPE or Accept: ...; envelope=true)
+	router.Use(middleware.ResponseEnvelope(cfg.ResponseEnvelope, cacheLookupsEnvelope))
+
 	// Initialize request ID store for idempotency (optional for read operations)
 	appLogger.Info("🔧 Initializing request ID store for idempotency...")
 	requestIDStore := middleware.NewInMemoryRequestIDStore()
@@ -134,35 +228,148 @@ func main() {
 	// Swagger documentation
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Prometheus metrics (HTTP latency/status, cache hit/miss, invalidation consumer)
+	router.GET("/metrics", metrics.Handler())
+
 	// Initialize JWT manager
 	appLogger.Info("🔧 Initializing JWT manager...")
-	jwtManager := auth.NewJWTManager(cfg.JWTSecret, appLogger)
+	jwtManager := auth.NewJWTManager(cfg.JWTSecret, appLogger).
+		WithClockSkew(time.Duration(cfg.JWTClockSkewSeconds)*time.Second).
+		WithAccessTokenTTL(time.Duration(cfg.JWTAccessTokenTTLSeconds)*time.Second).
+		WithIssuer(cfg.JWTIssuer, cfg.JWTTrustedIssuers).
+		WithAudience(cfg.JWTAudience)
+	if cfg.JWTJWKSURL != "" {
+		jwks := auth.NewJWKSKeySet(cfg.JWTJWKSURL, time.Duration(cfg.JWTJWKSRefreshSeconds)*time.Second, appLogger)
+		// Fetch the keys now so a wrong URL shows at startup; they are fetched again on use
+		jwksCtx, cancelJWKS := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := jwks.Refresh(jwksCtx); err != nil {
+			appLogger.Warn("⚠️  Failed to fetch JWKS, RS256/ES256 tokens will fail until it is reachable",
+				zap.String("url", cfg.JWTJWKSURL),
+				zap.Error(err),
+			)
+		}
+		cancelJWKS()
+		jwtManager.WithJWKS(jwks)
+	}
 	appLogger.Info("✅ JWT manager initialized successfully")
 
+	// Initialize role-based authorization
+	rbac, err := auth.NewRBAC(cfg.RBACRolePermissions)
+	if err != nil {
+		appLogger.Fatal("Invalid RBAC_ROLE_PERMISSIONS", zap.Error(err))
+	}
+	appLogger.Info("✅ RBAC initialized", zap.Strings("roles", rbac.Roles()))
+
+	// Initialize token store (refresh tokens + revocation list)
+	tokenStore := auth.NewTokenStore(cfg.TokenStore, auth.RedisOptions{
+		Addr:     cfg.RedisHost + ":" + cfg.RedisPort,
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	}, appLogger)
+
+	// Initialize user store (seeded with the default users when empty)
+	appLogger.Info("🔧 Initializing user store...", zap.String("kind", cfg.UserStore), zap.String("path", cfg.UserStorePath))
+	userStore, err := auth.NewUserStore(cfg.UserStore, cfg.UserStorePath, appLogger)
+	if err != nil {
+		appLogger.Fatal("Failed to initialize user store", zap.Error(err))
+	}
+	appLogger.Info("✅ User store initialized successfully")
+
+	// Initialize API key store (X-API-Key credentials of machine clients)
+	apiKeyStore, err := auth.NewSQLiteAPIKeyStore(cfg.APIKeyStorePath)
+	if err != nil {
+		appLogger.Fatal("Failed to initialize API key store", zap.Error(err))
+	}
+	defer apiKeyStore.Close()
+
 	// Initialize auth handler
 	appLogger.Info("🔧 Initializing auth handler...")
-	authHandler := auth.NewAuthHandler(jwtManager, appLogger)
+	authHandler := auth.NewAuthHandler(jwtManager, userStore, tokenStore, time.Duration(cfg.RefreshTokenTTLMinutes)*time.Minute, appLogger)
+	apiKeyHandler := auth.NewAPIKeyHandler(apiKeyStore, appLogger)
 	appLogger.Info("✅ Auth handler initialized successfully")
 
-	// Initialize cache (optional)
-	var cacheClient cache.Cache
-	if cfg.UseCache {
-		appLogger.Info("🔧 Initializing cache (Redis)...")
-		cacheClient = cache.NewCache(cfg, appLogger)
-		appLogger.Info("✅ Cache initialized successfully")
-	} else {
-		appLogger.Info("⏭️  Skipping cache initialization (USE_CACHE=false)")
-		cacheClient = nil
-	}
-
 	// Initialize handlers first (needed for Kafka consumer)
 	appLogger.Info("🔧 Initializing handlers...")
-	inventoryHandler, err := handlers.NewInventoryHandler(appLogger, cfg)
+	var driftNotifier schemacheck.Notifiers = []schemacheck.Notifier{schemacheck.LogNotifier{Logger: appLogger}}
+	if cfg.SchemaDriftWebhookURL != "" {
+		driftNotifier = append(driftNotifier, schemacheck.WebhookNotifier{URL: cfg.SchemaDriftWebhookURL})
+	}
+	schemaChecker := schemacheck.NewChecker(cfg.SchemaCheckMode, driftNotifier, appLogger)
+	inventoryHandler, err := handlers.NewInventoryHandler(appLogger, cfg, schemaChecker)
 	if err != nil {
 		appLogger.Fatal("Failed to initialize handlers", zap.Error(err))
 	}
 	appLogger.Info("✅ Handlers initialized successfully")
 
+	// Share the handler's cache (nil if USE_CACHE=false) so Kafka invalidations also
+	// reach its in-process hot-key tier
+	cacheClient := inventoryHandler.GetCache()
+
+	// Initialize valuation handler
+	valuationMethod, err := valuation.ParseMethod(cfg.ValuationMethod)
+	if err != nil {
+		appLogger.Warn("Invalid VALUATION_METHOD, using fifo", zap.Error(err))
+		valuationMethod = valuation.FIFO
+	}
+	valuationHandler := handlers.NewValuationHandler(appLogger, inventoryHandler.GetValuationRepository(), valuationMethod)
+
+	// Cache inspection and flush for operators (DELETE /admin/cache, GET /admin/cache/stats)
+	cacheAdminHandler := handlers.NewCacheAdminHandler(appLogger, cacheClient)
+
+	// Initialize reservation handler (shares the cache client invalidated by the Kafka consumer)
+	reservationHandler := handlers.NewReservationHandler(appLogger, inventoryHandler.GetReservationRepository(), cacheClient, cfg.CacheTTL)
+
+	// Initialize forecast handler
+	forecastHandler := handlers.NewForecastHandler(appLogger, inventoryHandler.GetRepository(), inventoryHandler.GetMovementRepository())
+
+	// Initialize stock movement history handler
+	exportHandler := handlers.NewExportHandler(appLogger, inventoryHandler.GetExportRepository())
+	historyHandler := handlers.NewHistoryHandler(appLogger, inventoryHandler.GetMovementRepository())
+
+	// Initialize waitlist handler
+	waitlistHandler := handlers.NewWaitlistHandler(appLogger, inventoryHandler.GetWaitlistRepository())
+
+	// Initialize store calendar handler
+	storeCalendarHandler := handlers.NewStoreCalendarHandler(appLogger, inventoryHandler.GetStoreCalendarRepository())
+
+	// Initialize purchasing handler (suppliers and purchase orders)
+	purchasingHandler := handlers.NewPurchasingHandler(appLogger, inventoryHandler.GetPurchasingRepository())
+
+	// Initialize activity feed handler
+	activityHandler := handlers.NewActivityHandler(appLogger, inventoryHandler.GetActivityRepository())
+
+	// Initialize GraphQL read API (same repositories as the REST handlers)
+	graphqlHandler, err := graphqlapi.NewHandler(appLogger, inventoryHandler.GetRepository(), inventoryHandler.GetReservationRepository())
+	if err != nil {
+		appLogger.Fatal("Failed to build GraphQL schema", zap.Error(err))
+	}
+
+	// Readiness probe; the Kafka consumer registers itself below once created
+	healthChecker := newHealthChecker(cfg, inventoryHandler, cacheClient, schemaChecker)
+
+	// Start the consistency probe (optional)
+	if cfg.ProbeEnabled {
+		consistencyProbe := probe.New(probe.Config{
+			CommandURL:        cfg.ProbeCommandURL,
+			SKU:               cfg.ProbeSKU,
+			Interval:          time.Duration(cfg.ProbeIntervalSeconds) * time.Second,
+			Timeout:           time.Duration(cfg.ProbeTimeoutSeconds) * time.Second,
+			PollInterval:      100 * time.Millisecond,
+			WarnThreshold:     time.Duration(cfg.ProbeWarnMs) * time.Millisecond,
+			CriticalThreshold: time.Duration(cfg.ProbeCriticalMs) * time.Millisecond,
+		}, inventoryHandler.GetRepository(), cacheClient, jwtManager, cfg.ProbeRole, appLogger)
+
+		probeCtx, stopProbe := context.WithCancel(context.Background())
+		defer stopProbe()
+		go consistencyProbe.Run(probeCtx)
+		appLogger.Info("✅ Consistency probe started",
+			zap.String("command_url", cfg.ProbeCommandURL),
+			zap.Int("interval_seconds", cfg.ProbeIntervalSeconds),
+		)
+	} else {
+		appLogger.Info("⏭️  Skipping consistency probe (PROBE_ENABLED=false)")
+	}
+
 	// Initialize Kafka consumer for cache update/invalidation (optional)
 	if cfg.UseKafka && cfg.UseCache {
 		appLogger.Info("🔧 Initializing Kafka consumer for cache update/invalidation...")
@@ -171,7 +378,10 @@ func main() {
 		kafkaConsumer, err := kafka.NewConsumer(cfg, cacheClient, repo, appLogger)
 		if err != nil {
 			appLogger.Warn("Failed to initialize Kafka consumer, continuing without cache update/invalidation", zap.Error(err))
+			healthChecker.Register(health.Dependency{Name: cfg.EventBus,
+				Check: health.Unavailable("Event consumer could not be created, the cache is only refreshed by its TTL")})
 		} else {
+			healthChecker.Register(health.Dependency{Name: cfg.EventBus, Check: kafkaConsumer.Ping})
 			// Start Kafka consumer in background
 			ctx, cancel := context.WithCancel(context.Background())
 			defer func() {
@@ -193,37 +403,130 @@ func main() {
 		}
 	}
 
+	// Initialize the live inventory stream (optional; relays the Listener Service's
+	// confirmations to SSE clients, independently of the cache consumer)
+	var streamHub *stream.Hub
+	if cfg.UseKafka && cfg.StreamEnabled {
+		hub := stream.NewHub(cfg.StreamClientBuffer)
+		streamRelay, err := kafka.NewStreamRelay(cfg, hub, appLogger)
+		if err != nil {
+			appLogger.Warn("Failed to initialize stream relay, continuing without live stream", zap.Error(err))
+		} else {
+			streamHub = hub
+			ctx, cancel := context.WithCancel(context.Background())
+			defer func() {
+				cancel()
+				streamRelay.Close()
+			}()
+			go func() {
+				if err := streamRelay.Start(ctx); err != nil {
+					appLogger.Error("Stream relay error", zap.Error(err))
+				}
+			}()
+		}
+	} else {
+		appLogger.Info("⏭️  Skipping live inventory stream (needs USE_KAFKA=true and STREAM_ENABLED=true)")
+	}
+	heartbeat := time.Duration(cfg.StreamHeartbeatSeconds) * time.Second
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+	streamHandler := handlers.NewStreamHandler(appLogger, streamHub, heartbeat)
+
+	// JWT or API key authentication
+	authenticate := middleware.AuthMiddleware(jwtManager, rbac, tokenStore, apiKeyStore, appLogger)
+	// Permission check for user management (the auth route group below shadows the auth package)
+	manageUsers := middleware.RequirePermission(rbac, auth.PermissionManageUsers, appLogger)
+	// Permission check for the administrative endpoints
+	overrideStock := middleware.RequirePermission(rbac, auth.PermissionOverrideStock, appLogger)
+
 	// API routes
 	v1 := router.Group("/api/v1")
 	{
-		// Health check endpoint (public)
-		v1.GET("/health", healthCheck)
+		// Health check endpoints (public)
+		v1.GET("/health", healthCheck(schemaChecker))
+		v1.GET("/health/live", healthChecker.Live)
+		v1.GET("/health/ready", healthChecker.Ready)
+
+		// Item image files of IMAGE_STORE=local (public, signed with IMAGE_SIGNED_URLS=true)
+		if cfg.ImageStore == config.ImageStoreLocal {
+			imageHandler := handlers.NewImageHandler(appLogger, cfg.ImageLocalDir, inventoryHandler.GetImageURLSigner())
+			v1.GET("/images/*key", imageHandler.ServeImage)
+		}
 
 		// Auth endpoints (public)
 		auth := v1.Group("/auth")
 		{
 			auth.POST("/login", authHandler.Login)
+			auth.POST("/refresh", authHandler.Refresh)
+			auth.POST("/logout", authHandler.Logout)
+			// User management (admin only)
+			auth.POST("/users", authenticate, manageUsers, authHandler.CreateUser)
+			// API keys for machine clients (admin only)
+			auth.POST("/api-keys", authenticate, manageUsers, apiKeyHandler.CreateAPIKey)
+			auth.GET("/api-keys", authenticate, manageUsers, apiKeyHandler.ListAPIKeys)
+			auth.DELETE("/api-keys/:id", authenticate, manageUsers, apiKeyHandler.RevokeAPIKey)
 		}
 
-		// Protected endpoints (require JWT authentication)
+		// Protected endpoints (require a JWT or an API key)
 		protected := v1.Group("")
-		protected.Use(middleware.AuthMiddleware(jwtManager, appLogger))
+		protected.Use(authenticate)
+		protected.GET("/slo", middleware.SLOReportHandler(sloTracker))
+		protected.GET("/activity", activityHandler.ListActivity)
+		protected.GET("/graphql", graphqlHandler.Serve)
+		protected.POST("/graphql", graphqlHandler.Serve)
 		{
 			inventory := protected.Group("/inventory")
 			{
 				// Query endpoints
 				inventory.GET("/items", inventoryHandler.ListItems)
+				inventory.GET("/stream", streamHandler.StreamInventory)
 				inventory.GET("/items/:id", inventoryHandler.GetItemByID)
 				inventory.GET("/items/sku/:sku", inventoryHandler.GetItemBySKU)
+				inventory.GET("/items/barcode/:code", inventoryHandler.GetItemByBarcode)
 				inventory.GET("/items/:id/stock", inventoryHandler.GetStockStatus)
+				inventory.GET("/items/:id/reservations", reservationHandler.ListItemReservations)
+				inventory.GET("/items/:id/forecast", forecastHandler.GetForecast)
+				inventory.GET("/items/:id/history", historyHandler.GetItemHistory)
+				inventory.GET("/items/:id/related", inventoryHandler.GetRelatedItems)
+				inventory.GET("/items/:id/locations", inventoryHandler.GetItemLocations)
+				inventory.GET("/valuation", valuationHandler.GetValuationReport)
+				inventory.GET("/stats", inventoryHandler.GetInventoryStats)
+				inventory.GET("/export", exportHandler.ExportInventory)
+				inventory.POST("/availability", inventoryHandler.CheckAvailability)
+				inventory.POST("/items/batch", inventoryHandler.GetItemsBatch)
+				inventory.GET("/waitlist/:id", waitlistHandler.GetWaitlistEntry)
+			}
+
+			stores := protected.Group("/stores")
+			{
+				stores.GET("/:id/reservations", reservationHandler.ListStoreReservations)
+				stores.GET("/:id/calendar", storeCalendarHandler.GetStoreCalendar)
+			}
+
+			protected.GET("/suppliers", purchasingHandler.ListSuppliers)
+			purchaseOrders := protected.Group("/purchase-orders")
+			{
+				purchaseOrders.GET("", purchasingHandler.ListPurchaseOrders)
+				purchaseOrders.GET("/:id", purchasingHandler.GetPurchaseOrder)
+			}
+
+			// Cache administration (inventory:override, admin only by default)
+			admin := protected.Group("/admin", overrideStock)
+			{
+				admin.DELETE("/cache", cacheAdminHandler.FlushCache)
+				admin.GET("/cache/stats", cacheAdminHandler.GetCacheStats)
 			}
 		}
 	}
 
 	// Start server
 	srv := &http.Server{
-		Addr:    ":" + cfg.Port,
-		Handler: router,
+		Addr:         ":" + cfg.Port,
+		Handler:      router,
+		ReadTimeout:  time.Duration(cfg.HTTPReadTimeoutSeconds) * time.Second,
+		WriteTimeout: time.Duration(cfg.HTTPWriteTimeoutSeconds) * time.Second,
+		IdleTimeout:  time.Duration(cfg.HTTPIdleTimeoutSeconds) * time.Second,
 	}
 
 	// Start server in a goroutine
@@ -252,16 +555,20 @@ func main() {
 		appLogger.Fatal("Server forced to shutdown", zap.Error(err))
 	}
 
+	if err := shutdownTracing(ctx); err != nil {
+		appLogger.Warn("Failed to flush traces", zap.Error(err))
+	}
+
 	appLogger.Info("Server exited")
 }
 
 // healthCheck godoc
 // @Summary      Health check endpoint
-// @Description  Verifica el estado del servicio. Retorna el estado del servicio y su nombre.
+// @Description  Verifica el estado del servicio. Retorna el estado del servicio y su nombre. Si el esquema del read model no coincide con el esperado (SCHEMA_CHECK_MODE=degraded) retorna "degraded" con las versiones esperada y encontrada.
 // @Tags         health
 // @Accept       json
 // @Produce      json
-// @Success      200  {object}  map[string]string  "Servicio operativo"
+// @Success      200  {object}  map[string]interface{}  "Servicio operativo"
 // @Router       /health [get]
 // @Example      Valid response
 //
@@ -269,9 +576,73 @@ func main() {
 //	  "status": "ok",
 //	  "service": "query-service"
 //	}
-func healthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "ok",
-		"service": "query-service",
+//
+// @Example      Degraded response
+//
+//	{
+//	  "status": "degraded",
+//	  "service": "query-service",
+//	  "schema": {"expected_version": 1, "found_version": 0, "reason": "..."}
+//	}
+func healthCheck(schemaChecker *schemacheck.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if drift := schemaChecker.Drift(); drift != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"status":  "degraded",
+				"service": "query-service",
+				"schema": gin.H{
+					"expected_version": drift.Expected,
+					"found_version":    drift.Found,
+					"reason":           drift.Reason,
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "ok",
+			"service": "query-service",
+		})
+	}
+}
+
+// newHealthChecker registers the dependencies probed by /health/ready. Only the read
+// model is critical: without the cache or Kafka the service still answers, from SQLite
+// and with entries that expire by TTL instead of being invalidated.
+func newHealthChecker(cfg *config.Config, inventoryHandler *handlers.InventoryHandler, cacheClient cache.Cache, schemaChecker *schemacheck.Checker) *health.Checker {
+	checker := health.NewChecker(health.Config{
+		Service:          "query-service",
+		Timeout:          time.Duration(cfg.HealthCheckTimeoutMs) * time.Millisecond,
+		FailureThreshold: cfg.HealthFailureThreshold,
 	})
+
+	if pinger, ok := inventoryHandler.GetRepository().(health.Pinger); ok {
+		checker.Register(health.Dependency{Name: "read_model", Critical: true, Check: pinger.Ping})
+	}
+
+	checker.Register(health.Dependency{Name: "read_model_schema", Check: func(ctx context.Context) error {
+		if drift := schemaChecker.Drift(); drift != nil {
+			return fmt.Errorf("schema version %d, expected %d: %s", drift.Found, drift.Expected, drift.Reason)
+		}
+		return nil
+	}})
+
+	if cacheClient != nil && !cfg.MockDependencies {
+		checker.Register(health.Dependency{Name: "cache", Check: func(ctx context.Context) error {
+			return cache.Probe(ctx, cacheClient, cfg.CacheBackend)
+		}})
+	}
+
+	return checker
+}
+
+// cacheLookupsEnvelope reports in the response envelope whether the request was served
+// from the cache, and warns when the cache could not be reached
+func cacheLookupsEnvelope(ctx context.Context) (context.Context, func(*middleware.EnvelopeMeta)) {
+	ctx, lookups := cache.TrackLookups(ctx)
+	return ctx, func(meta *middleware.EnvelopeMeta) {
+		meta.Cache = lookups.Result()
+		if lookups.Errors() > 0 {
+			meta.Warnings = append(meta.Warnings, "cache unavailable, served from the read model")
+		}
+	}
 }
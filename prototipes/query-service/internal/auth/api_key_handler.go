@@ -0,0 +1,184 @@
+package auth
+
+import (
+	stderrors "errors"
+	"net/http"
+	"time"
+
+	"query-service/internal/tenant"
+	"query-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// APIKeyHandler manages the API keys of machine clients
+type APIKeyHandler struct {
+	store  APIKeyStore
+	logger *zap.Logger
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(store APIKeyStore, logger *zap.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// CreateAPIKeyRequest represents the create API key request
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=64" example:"pos-store-12"`
+	Scopes    []string   `json:"scopes" binding:"required" example:"inventory:read,inventory:write"`
+	ExpiresAt *time.Time `json:"expires_at" example:"2025-01-15T00:00:00Z"`
+}
+
+// APIKeyResponse represents an API key (without the key itself)
+type APIKeyResponse struct {
+	ID         string     `json:"id" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	Name       string     `json:"name" example:"pos-store-12"`
+	Prefix     string     `json:"prefix" example:"crk_Xq3vB9kL"`
+	Scopes     []string   `json:"scopes" example:"inventory:read,inventory:write"`
+	TenantID   string     `json:"tenant_id" example:"brand-a"`
+	CreatedBy  string     `json:"created_by" example:"admin"`
+	CreatedAt  time.Time  `json:"created_at" example:"2024-01-15T12:00:00Z"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" example:"2025-01-15T00:00:00Z"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" example:"2024-01-16T08:30:00Z"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" example:"2024-02-01T10:00:00Z"`
+}
+
+// CreatedAPIKeyResponse is the response of a new API key: the only time the key is shown
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key" example:"crk_Xq3vB9kLw2..."`
+}
+
+// CreateAPIKey handles POST /api/v1/auth/api-keys
+// @Summary      Create an API key
+// @Description  Crea una API key para un cliente máquina a máquina (terminales POS, jobs batch), que se envía en el header `X-API-Key` en lugar de un token JWT. La key se muestra solo en esta respuesta: el servicio guarda únicamente su hash SHA-256. Los `scopes` son los permisos que otorga la key (`inventory:read`, `inventory:write`, `inventory:delete`, `inventory:override`) y reemplazan al rol. La key actúa en el tenant del admin que la crea. Requiere un token con el permiso `users:manage` (rol admin por defecto).
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      CreateAPIKeyRequest    true  "API key a crear"
+// @Success      201      {object}  CreatedAPIKeyResponse  "API key creada (incluye la key)"
+// @Failure      400      {object}  errors.StandardError  "Request inválido - nombre faltante, scopes desconocidos o expiración en el pasado"
+// @Failure      401      {object}  errors.StandardError  "No autenticado"
+// @Failure      403      {object}  errors.StandardError  "Sin permiso users:manage"
+// @Router       /auth/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid create api key request", zap.Error(err))
+		c.Error(errors.NewValidationError("invalid request", "name (max 64 chars), scopes or expires_at (RFC3339)"))
+		c.Abort()
+		return
+	}
+	if err := ValidateAPIKeyScopes(req.Scopes); err != nil {
+		c.Error(errors.NewValidationError(err.Error(), "scopes"))
+		c.Abort()
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.Error(errors.NewValidationError("expires_at must be in the future", "expires_at"))
+		c.Abort()
+		return
+	}
+
+	key, record, err := NewAPIKey(req.Name, req.Scopes, tenant.FromContext(c.Request.Context()), c.GetString("username"), req.ExpiresAt)
+	if err == nil {
+		err = h.store.CreateAPIKey(c.Request.Context(), record)
+	}
+	if err != nil {
+		h.logger.Error("Failed to create api key", zap.Error(err))
+		c.Error(errors.NewInternalError("failed to create api key", err))
+		c.Abort()
+		return
+	}
+
+	h.logger.Info("API key created",
+		zap.String("api_key_id", record.ID),
+		zap.String("name", record.Name),
+		zap.Strings("scopes", record.Scopes),
+		zap.String("tenant_id", record.TenantID),
+		zap.String("created_by", record.CreatedBy),
+	)
+
+	c.JSON(http.StatusCreated, CreatedAPIKeyResponse{APIKeyResponse: newAPIKeyResponse(record), Key: key})
+}
+
+// ListAPIKeys handles GET /api/v1/auth/api-keys
+// @Summary      List API keys
+// @Description  Lista las API keys (también las revocadas, con `revoked_at`), de la más nueva a la más antigua, con su prefijo, scopes, tenant y último uso. Nunca incluye la key. Los admins de un tenant solo ven las keys de su tenant; los del tenant `default` ven todas. Requiere el permiso `users:manage`.
+// @Tags         auth
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200      {array}   APIKeyResponse     "API keys"
+// @Failure      401      {object}  errors.StandardError  "No autenticado"
+// @Failure      403      {object}  errors.StandardError  "Sin permiso users:manage"
+// @Router       /auth/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.store.ListAPIKeys(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list api keys", zap.Error(err))
+		c.Error(errors.NewInternalError("failed to list api keys", err))
+		c.Abort()
+		return
+	}
+
+	response := make([]APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, newAPIKeyResponse(key))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeAPIKey handles DELETE /api/v1/auth/api-keys/:id
+// @Summary      Revoke an API key
+// @Description  Revoca una API key: deja de autenticar de inmediato en los servicios que comparten el store. La key sigue listada con `revoked_at`. Solo se pueden revocar keys del propio tenant (salvo desde el tenant `default`). Requiere el permiso `users:manage`.
+// @Tags         auth
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string             true  "API key ID"
+// @Success      200  {object}  APIKeyResponse     "API key revocada"
+// @Failure      401  {object}  errors.StandardError  "No autenticado"
+// @Failure      403  {object}  errors.StandardError  "Sin permiso users:manage"
+// @Failure      404  {object}  errors.StandardError  "API key no encontrada"
+// @Router       /auth/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	key, err := h.store.RevokeAPIKey(c.Request.Context(), c.Param("id"), time.Now().UTC())
+	if err != nil {
+		if stderrors.Is(err, ErrAPIKeyNotFound) {
+			c.Error(errors.NewNotFound("api key not found", "ID: "+c.Param("id")))
+			c.Abort()
+			return
+		}
+		h.logger.Error("Failed to revoke api key", zap.Error(err))
+		c.Error(errors.NewInternalError("failed to revoke api key", err))
+		c.Abort()
+		return
+	}
+
+	h.logger.Info("API key revoked",
+		zap.String("api_key_id", key.ID),
+		zap.String("name", key.Name),
+		zap.String("revoked_by", c.GetString("username")),
+	)
+
+	c.JSON(http.StatusOK, newAPIKeyResponse(key))
+}
+
+func newAPIKeyResponse(key *APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		TenantID:   key.TenantID,
+		CreatedBy:  key.CreatedBy,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"query-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupAPIKeyTestRouter(t *testing.T) (*gin.Engine, *SQLiteAPIKeyStore) {
+	store, err := NewSQLiteAPIKeyStore(filepath.Join(t.TempDir(), "api_keys.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	handler := NewAPIKeyHandler(store, zap.NewNop())
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("username", "admin")
+		c.Next()
+		if len(c.Errors) > 0 {
+			if stdErr, ok := c.Errors.Last().Err.(*errors.StandardError); ok {
+				c.JSON(stdErr.HTTPStatus(), stdErr)
+			}
+		}
+	})
+	router.POST("/api/v1/auth/api-keys", handler.CreateAPIKey)
+	router.GET("/api/v1/auth/api-keys", handler.ListAPIKeys)
+	router.DELETE("/api/v1/auth/api-keys/:id", handler.RevokeAPIKey)
+	return router, store
+}
+
+func TestAPIKeyHandler_Lifecycle(t *testing.T) {
+	router, store := setupAPIKeyTestRouter(t)
+
+	body, _ := json.Marshal(CreateAPIKeyRequest{Name: "pos-store-12", Scopes: []string{PermissionRead, PermissionWrite}})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/auth/api-keys", bytes.NewBuffer(body)))
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created CreatedAPIKeyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "admin", created.CreatedBy)
+	assert.Equal(t, created.Prefix, created.Key[:len(created.Prefix)])
+
+	key, err := Authenticate(context.Background(), store, created.Key)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, key.ID)
+
+	// Listings never include the key
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/auth/api-keys", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), created.Key)
+	assert.NotContains(t, w.Body.String(), key.Hash)
+	var listed []APIKeyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed, 1)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/v1/auth/api-keys/"+created.ID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var revoked APIKeyResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &revoked))
+	assert.NotNil(t, revoked.RevokedAt)
+	_, err = Authenticate(context.Background(), store, created.Key)
+	assert.Equal(t, ErrAPIKeyRevoked, err)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/v1/auth/api-keys/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAPIKeyHandler_InvalidRequests(t *testing.T) {
+	router, _ := setupAPIKeyTestRouter(t)
+	past := time.Now().Add(-time.Hour)
+
+	for name, req := range map[string]CreateAPIKeyRequest{
+		"missing name":    {Scopes: []string{PermissionRead}},
+		"no scopes":       {Name: "job"},
+		"unknown scope":   {Name: "job", Scopes: []string{"inventory:everything"}},
+		"user management": {Name: "job", Scopes: []string{PermissionManageUsers}},
+		"already expired": {Name: "job", Scopes: []string{PermissionRead}, ExpiresAt: &past},
+	} {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/auth/api-keys", bytes.NewBuffer(body)))
+		assert.Equal(t, http.StatusBadRequest, w.Code, name)
+	}
+}
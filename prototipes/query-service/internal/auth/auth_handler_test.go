@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"query-service/pkg/errors"
 
@@ -51,16 +53,26 @@ func setupAuthTestRouter(handler *AuthHandler) *gin.Engine {
 		auth := v1.Group("/auth")
 		{
 			auth.POST("/login", handler.Login)
+			auth.POST("/refresh", handler.Refresh)
+			auth.POST("/logout", handler.Logout)
+			auth.POST("/users", handler.CreateUser)
 		}
 	}
 	return router
 }
 
+// newTestUserStore returns a SQLite user store seeded with the default users
+func newTestUserStore(t *testing.T) UserStore {
+	store, err := NewUserStore("sqlite", filepath.Join(t.TempDir(), "users.db"), zap.NewNop())
+	require.NoError(t, err)
+	return store
+}
+
 func TestLogin_Success(t *testing.T) {
 	// Setup
 	logger := zap.NewNop()
 	jwtManager := NewJWTManager("test-secret-key-min-32-chars-for-testing", logger)
-	handler := NewAuthHandler(jwtManager, logger)
+	handler := NewAuthHandler(jwtManager, newTestUserStore(t), NewInMemoryTokenStore(), time.Hour, logger)
 	router := setupAuthTestRouter(handler)
 
 	// Test data
@@ -85,14 +97,39 @@ func TestLogin_Success(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotEmpty(t, response.Token)
 	assert.Equal(t, "Bearer", response.Type)
+	assert.Equal(t, RoleAdmin, response.Role)
 	assert.Equal(t, 600, response.ExpiresIn) // 10 minutes in seconds
+	assert.NotEmpty(t, response.RefreshToken)
+	assert.Equal(t, 3600, response.RefreshExpiresIn)
+}
+
+func TestLogin_ExpiresInFollowsTokenTTL(t *testing.T) {
+	jwtManager := NewJWTManager("test-secret-key-min-32-chars-for-testing", zap.NewNop()).
+		WithAccessTokenTTL(30 * time.Minute)
+	handler := NewAuthHandler(jwtManager, newTestUserStore(t), NewInMemoryTokenStore(), time.Hour, zap.NewNop())
+	router := setupAuthTestRouter(handler)
+
+	body, _ := json.Marshal(LoginRequest{Username: "admin", Password: "admin123"})
+	req := httptest.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response LoginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 1800, response.ExpiresIn)
+
+	claims, err := jwtManager.ValidateToken(response.Token)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(30*time.Minute), claims.ExpiresAt.Time, 5*time.Second)
 }
 
 func TestLogin_InvalidCredentials(t *testing.T) {
 	// Setup
 	logger := zap.NewNop()
 	jwtManager := NewJWTManager("test-secret-key-min-32-chars-for-testing", logger)
-	handler := NewAuthHandler(jwtManager, logger)
+	handler := NewAuthHandler(jwtManager, newTestUserStore(t), NewInMemoryTokenStore(), time.Hour, logger)
 	router := setupAuthTestRouter(handler)
 
 	testCases := []struct {
@@ -131,7 +168,7 @@ func TestLogin_ValidUsers(t *testing.T) {
 	// Setup
 	logger := zap.NewNop()
 	jwtManager := NewJWTManager("test-secret-key-min-32-chars-for-testing", logger)
-	handler := NewAuthHandler(jwtManager, logger)
+	handler := NewAuthHandler(jwtManager, newTestUserStore(t), NewInMemoryTokenStore(), time.Hour, logger)
 	router := setupAuthTestRouter(handler)
 
 	validUsers := []struct {
@@ -171,7 +208,7 @@ func TestLogin_InvalidRequest(t *testing.T) {
 	// Setup
 	logger := zap.NewNop()
 	jwtManager := NewJWTManager("test-secret-key-min-32-chars-for-testing", logger)
-	handler := NewAuthHandler(jwtManager, logger)
+	handler := NewAuthHandler(jwtManager, newTestUserStore(t), NewInMemoryTokenStore(), time.Hour, logger)
 	router := setupAuthTestRouter(handler)
 
 	testCases := []struct {
@@ -204,7 +241,7 @@ func TestJWTManager_GenerateAndValidateToken(t *testing.T) {
 	jwtManager := NewJWTManager("test-secret-key-min-32-chars-for-testing", logger)
 
 	// Generate token
-	token, err := jwtManager.GenerateToken("admin")
+	token, err := jwtManager.GenerateToken("admin", RoleAdmin, "")
 	require.NoError(t, err)
 	assert.NotEmpty(t, token)
 
@@ -212,6 +249,7 @@ func TestJWTManager_GenerateAndValidateToken(t *testing.T) {
 	claims, err := jwtManager.ValidateToken(token)
 	require.NoError(t, err)
 	assert.Equal(t, "admin", claims.Username)
+	assert.Equal(t, RoleAdmin, claims.Role)
 	assert.Equal(t, "admin", claims.Subject)
 	assert.Equal(t, "query-service", claims.Issuer)
 }
@@ -246,7 +284,7 @@ func TestJWTManager_TokenWithDifferentSecret(t *testing.T) {
 	jwtManager2 := NewJWTManager("secret-key-2-min-32-chars-for-testing", logger)
 
 	// Generate token with manager 1
-	token, err := jwtManager1.GenerateToken("admin")
+	token, err := jwtManager1.GenerateToken("admin", RoleAdmin, "")
 	require.NoError(t, err)
 
 	// Try to validate with manager 2 (different secret)
@@ -254,3 +292,160 @@ func TestJWTManager_TokenWithDifferentSecret(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, ErrInvalidToken, err)
 }
+
+func loginForTest(t *testing.T, router *gin.Engine) LoginResponse {
+	body, _ := json.Marshal(LoginRequest{Username: "operator", Password: "operator123"})
+	req := httptest.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response LoginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+func postRefresh(router *gin.Engine, refreshToken string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(RefreshRequest{RefreshToken: refreshToken})
+	req := httptest.NewRequest("POST", "/api/v1/auth/refresh", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRefresh_RotatesRefreshToken(t *testing.T) {
+	// Setup
+	logger := zap.NewNop()
+	jwtManager := NewJWTManager("test-secret-key-min-32-chars-for-testing", logger)
+	handler := NewAuthHandler(jwtManager, newTestUserStore(t), NewInMemoryTokenStore(), time.Hour, logger)
+	router := setupAuthTestRouter(handler)
+	login := loginForTest(t, router)
+
+	// Execute
+	w := postRefresh(router, login.RefreshToken)
+
+	// Assert: new tokens with the same identity
+	require.Equal(t, http.StatusOK, w.Code)
+	var refreshed LoginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &refreshed))
+	assert.NotEqual(t, login.RefreshToken, refreshed.RefreshToken)
+	assert.Equal(t, RoleOperator, refreshed.Role)
+
+	claims, err := jwtManager.ValidateToken(refreshed.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "operator", claims.Username)
+
+	// A refresh token can only be used once
+	assert.Equal(t, http.StatusUnauthorized, postRefresh(router, login.RefreshToken).Code)
+	assert.Equal(t, http.StatusUnauthorized, postRefresh(router, "unknown-token").Code)
+}
+
+func TestLogout_RevokesTokens(t *testing.T) {
+	// Setup
+	logger := zap.NewNop()
+	jwtManager := NewJWTManager("test-secret-key-min-32-chars-for-testing", logger)
+	tokenStore := NewInMemoryTokenStore()
+	handler := NewAuthHandler(jwtManager, newTestUserStore(t), tokenStore, time.Hour, logger)
+	router := setupAuthTestRouter(handler)
+	login := loginForTest(t, router)
+
+	// Execute
+	body, _ := json.Marshal(LogoutRequest{RefreshToken: login.RefreshToken})
+	req := httptest.NewRequest("POST", "/api/v1/auth/logout", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+login.Token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	claims, err := jwtManager.ValidateToken(login.Token)
+	require.NoError(t, err)
+	revoked, err := tokenStore.IsAccessTokenRevoked(req.Context(), claims.ID)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	assert.Equal(t, http.StatusUnauthorized, postRefresh(router, login.RefreshToken).Code)
+}
+
+func TestLogout_NothingToRevoke(t *testing.T) {
+	// Setup
+	logger := zap.NewNop()
+	jwtManager := NewJWTManager("test-secret-key-min-32-chars-for-testing", logger)
+	handler := NewAuthHandler(jwtManager, newTestUserStore(t), NewInMemoryTokenStore(), time.Hour, logger)
+	router := setupAuthTestRouter(handler)
+
+	// Execute
+	req := httptest.NewRequest("POST", "/api/v1/auth/logout", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func postCreateUser(router *gin.Engine, req CreateUserRequest) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(req)
+	httpReq := httptest.NewRequest("POST", "/api/v1/auth/users", bytes.NewBuffer(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httpReq)
+	return w
+}
+
+func TestCreateUser_CanLogin(t *testing.T) {
+	// Setup
+	logger := zap.NewNop()
+	jwtManager := NewJWTManager("test-secret-key-min-32-chars-for-testing", logger)
+	handler := NewAuthHandler(jwtManager, newTestUserStore(t), NewInMemoryTokenStore(), time.Hour, logger)
+	router := setupAuthTestRouter(handler)
+
+	// Execute
+	w := postCreateUser(router, CreateUserRequest{Username: "jdoe", Password: "s3cret-pass", Role: RoleOperator})
+
+	// Assert
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created UserResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "jdoe", created.Username)
+	assert.Equal(t, RoleOperator, created.Role)
+	assert.NotContains(t, w.Body.String(), "s3cret-pass")
+
+	body, _ := json.Marshal(LoginRequest{Username: "jdoe", Password: "s3cret-pass"})
+	req := httptest.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	loginW := httptest.NewRecorder()
+	router.ServeHTTP(loginW, req)
+	require.Equal(t, http.StatusOK, loginW.Code)
+	var login LoginResponse
+	require.NoError(t, json.Unmarshal(loginW.Body.Bytes(), &login))
+	assert.Equal(t, RoleOperator, login.Role)
+}
+
+func TestCreateUser_Errors(t *testing.T) {
+	// Setup
+	logger := zap.NewNop()
+	jwtManager := NewJWTManager("test-secret-key-min-32-chars-for-testing", logger)
+	handler := NewAuthHandler(jwtManager, newTestUserStore(t), NewInMemoryTokenStore(), time.Hour, logger)
+	router := setupAuthTestRouter(handler)
+
+	testCases := []struct {
+		name         string
+		req          CreateUserRequest
+		expectedCode int
+	}{
+		{"existing user", CreateUserRequest{Username: "admin", Password: "another-pass", Role: RoleAdmin}, http.StatusConflict},
+		{"short password", CreateUserRequest{Username: "jdoe", Password: "short", Role: RoleViewer}, http.StatusBadRequest},
+		{"unknown role", CreateUserRequest{Username: "jdoe", Password: "s3cret-pass", Role: "root"}, http.StatusBadRequest},
+		{"missing username", CreateUserRequest{Password: "s3cret-pass", Role: RoleViewer}, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedCode, postCreateUser(router, tc.req).Code)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Roles carried in the token claims
+const (
+	RoleAdmin    = "admin"
+	RoleOperator = "operator"
+	RoleViewer   = "viewer"
+)
+
+// Permissions granted to roles
+const (
+	PermissionRead   = "inventory:read"
+	PermissionWrite  = "inventory:write"
+	PermissionDelete = "inventory:delete"
+	// PermissionManageUsers allows creating users (POST /api/v1/auth/users)
+	PermissionManageUsers = "users:manage"
+	// PermissionOverrideStock allows overwriting stock counters in the Command Service
+	// (POST /api/v1/admin/items/:id/force-set-stock) and the cache administration here (/api/v1/admin/cache)
+	PermissionOverrideStock = "inventory:override"
+)
+
+// DefaultRolePermissions is the role→permission mapping used when none is configured.
+// Format: "role=perm,perm;role=perm".
+const DefaultRolePermissions = "admin=inventory:read,inventory:write,inventory:delete,inventory:override,users:manage;" +
+	"operator=inventory:read,inventory:write;" +
+	"viewer=inventory:read"
+
+// RBAC maps roles to the permissions they are granted
+type RBAC struct {
+	permissions map[string]map[string]bool
+}
+
+// NewRBAC parses a role→permission mapping (see DefaultRolePermissions for the format).
+// An empty spec uses DefaultRolePermissions.
+func NewRBAC(spec string) (*RBAC, error) {
+	if strings.TrimSpace(spec) == "" {
+		spec = DefaultRolePermissions
+	}
+
+	rbac := &RBAC{permissions: make(map[string]map[string]bool)}
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		role := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || role == "" {
+			return nil, fmt.Errorf("invalid role mapping %q: expected role=perm,perm", entry)
+		}
+		granted := make(map[string]bool)
+		for _, permission := range strings.Split(parts[1], ",") {
+			if permission = strings.TrimSpace(permission); permission != "" {
+				granted[permission] = true
+			}
+		}
+		rbac.permissions[role] = granted
+	}
+
+	if len(rbac.permissions) == 0 {
+		return nil, fmt.Errorf("role mapping defines no roles")
+	}
+	return rbac, nil
+}
+
+// HasPermission reports whether role is granted permission. Unknown roles have no permissions.
+func (r *RBAC) HasPermission(role, permission string) bool {
+	return r.permissions[role][permission]
+}
+
+// Roles returns the configured roles, sorted
+func (r *RBAC) Roles() []string {
+	roles := make([]string, 0, len(r.permissions))
+	for role := range r.permissions {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// RequiredPermission returns the permission a request to the Query Service needs.
+// The Query Service only serves reads, so every role with read access may use it.
+func RequiredPermission(method string) string {
+	return PermissionRead
+}
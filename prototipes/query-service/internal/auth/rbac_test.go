@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRBAC_Defaults(t *testing.T) {
+	rbac, err := NewRBAC("")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{RoleAdmin, RoleOperator, RoleViewer}, rbac.Roles())
+	for _, role := range rbac.Roles() {
+		assert.True(t, rbac.HasPermission(role, RequiredPermission("GET")), "role %s should read", role)
+	}
+	assert.False(t, rbac.HasPermission("unknown", PermissionRead))
+}
+
+func TestNewRBAC_CustomMapping(t *testing.T) {
+	rbac, err := NewRBAC(" auditor = inventory:read ; viewer= ")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"auditor", RoleViewer}, rbac.Roles())
+	assert.True(t, rbac.HasPermission("auditor", PermissionRead))
+	assert.False(t, rbac.HasPermission(RoleViewer, PermissionRead))
+	assert.False(t, rbac.HasPermission(RoleAdmin, PermissionRead))
+}
+
+func TestNewRBAC_InvalidMapping(t *testing.T) {
+	testCases := []string{
+		"admin",
+		"=inventory:read",
+		";;",
+	}
+
+	for _, spec := range testCases {
+		t.Run(spec, func(t *testing.T) {
+			_, err := NewRBAC(spec)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"query-service/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+// MemoryProbe reports how much memory the cache server uses and its limit (0 = none)
+type MemoryProbe interface {
+	MemoryUsage(ctx context.Context) (used, limit int64, err error)
+}
+
+// PressurePolicy configures how the cache reacts to memory pressure
+type PressurePolicy struct {
+	SoftQuotaBytes   int64         // Quota to measure against; 0 uses the server limit (Redis maxmemory)
+	HighWatermark    float64       // Usage ratio of the quota that turns adaptive mode on
+	LowWatermark     float64       // Usage ratio below which adaptive mode turns off again
+	LowValueTTLRatio float64       // TTL multiplier for low-value keys in adaptive mode
+	LowValuePrefixes []string      // Keys cheap to rebuild (list pages)
+	SkipPrefixes     []string      // Keys not cached at all in adaptive mode (exports)
+	CheckInterval    time.Duration // How often the memory usage is read
+}
+
+// AdaptiveCache shortens the TTL of low-value keys and stops caching the skipped ones
+// while the cache server is over its soft quota, so memory goes to the item and stock
+// keys that save the most reads. A high and a low watermark keep it from flapping;
+// normal TTLs return once usage is below the low watermark.
+type AdaptiveCache struct {
+	Cache
+	probe  MemoryProbe
+	policy PressurePolicy
+	logger *zap.Logger
+
+	mu     sync.RWMutex
+	active bool
+}
+
+// NewAdaptiveCache wraps cache, reading its memory usage from probe
+func NewAdaptiveCache(cache Cache, probe MemoryProbe, policy PressurePolicy, logger *zap.Logger) *AdaptiveCache {
+	return &AdaptiveCache{Cache: cache, probe: probe, policy: policy, logger: logger}
+}
+
+// Set stores the value, with a shorter TTL (or not at all) in adaptive mode
+func (c *AdaptiveCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if c.Active() {
+		switch {
+		case hasAnyPrefix(key, c.policy.SkipPrefixes):
+			metrics.CacheAdaptiveSets.WithLabelValues(keyspace(key), "skipped").Inc()
+			return nil
+		case hasAnyPrefix(key, c.policy.LowValuePrefixes):
+			ttl = c.shorten(ttl)
+			metrics.CacheAdaptiveSets.WithLabelValues(keyspace(key), "shortened").Inc()
+		}
+	}
+	return c.Cache.Set(ctx, key, value, ttl)
+}
+
+func (c *AdaptiveCache) shorten(ttl time.Duration) time.Duration {
+	shortened := time.Duration(float64(ttl) * c.policy.LowValueTTLRatio)
+	if shortened < time.Second {
+		return time.Second
+	}
+	return shortened
+}
+
+// Active reports whether adaptive mode is on
+func (c *AdaptiveCache) Active() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.active
+}
+
+// Run checks the memory usage every CheckInterval until ctx is done
+func (c *AdaptiveCache) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.policy.CheckInterval)
+	defer ticker.Stop()
+	for {
+		c.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check reads the memory usage once and turns adaptive mode on or off
+func (c *AdaptiveCache) Check(ctx context.Context) {
+	used, limit, err := c.probe.MemoryUsage(ctx)
+	if err != nil {
+		c.logger.Warn("Failed to read cache memory usage", zap.Error(err))
+		return
+	}
+	quota := c.policy.SoftQuotaBytes
+	if quota <= 0 {
+		quota = limit
+	}
+	if quota <= 0 {
+		// No quota and no maxmemory: nothing to measure against
+		return
+	}
+
+	ratio := float64(used) / float64(quota)
+	metrics.CacheMemoryUsageRatio.Set(ratio)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case !c.active && ratio >= c.policy.HighWatermark:
+		c.active = true
+		metrics.CacheAdaptiveMode.Set(1)
+		metrics.CacheAdaptiveActivations.Inc()
+		c.logger.Warn("Cache memory pressure, adaptive mode on: shortening TTLs of low-value keys",
+			zap.Int64("used_bytes", used),
+			zap.Int64("quota_bytes", quota),
+			zap.Float64("usage_ratio", ratio),
+			zap.Strings("low_value_prefixes", c.policy.LowValuePrefixes),
+			zap.Strings("skipped_prefixes", c.policy.SkipPrefixes),
+		)
+	case c.active && ratio < c.policy.LowWatermark:
+		c.active = false
+		metrics.CacheAdaptiveMode.Set(0)
+		c.logger.Info("Cache memory pressure subsided, adaptive mode off: normal TTLs restored",
+			zap.Int64("used_bytes", used),
+			zap.Int64("quota_bytes", quota),
+			zap.Float64("usage_ratio", ratio),
+		)
+	}
+}
+
+func hasAnyPrefix(key string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// MemoryUsage reads used_memory and maxmemory from INFO memory
+func (c *RedisCache) MemoryUsage(ctx context.Context) (int64, int64, error) {
+	info, err := c.client.Info(ctx, "memory").Result()
+	if err != nil {
+		return 0, 0, fmt.Errorf("redis info error: %w", err)
+	}
+	return parseMemoryInfo(info)
+}
+
+func parseMemoryInfo(info string) (used, limit int64, err error) {
+	found := false
+	scanner := bufio.NewScanner(strings.NewReader(info))
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok {
+			continue
+		}
+		switch name {
+		case "used_memory":
+			if used, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return 0, 0, fmt.Errorf("invalid used_memory %q: %w", value, err)
+			}
+			found = true
+		case "maxmemory":
+			if limit, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return 0, 0, fmt.Errorf("invalid maxmemory %q: %w", value, err)
+			}
+		}
+	}
+	if !found {
+		return 0, 0, fmt.Errorf("used_memory missing from INFO memory")
+	}
+	return used, limit, nil
+}
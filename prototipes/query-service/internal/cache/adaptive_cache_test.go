@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"testsupport"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeMemoryProbe struct {
+	used, limit int64
+}
+
+func (p *fakeMemoryProbe) MemoryUsage(ctx context.Context) (int64, int64, error) {
+	return p.used, p.limit, nil
+}
+
+// ttlRecorder remembers the TTL of the last write of each key
+type ttlRecorder struct {
+	Cache
+	ttls map[string]time.Duration
+}
+
+func (c *ttlRecorder) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.ttls[key] = ttl
+	return c.Cache.Set(ctx, key, value, ttl)
+}
+
+func TestAdaptiveCache_ShortensLowValueTTLsUnderPressure(t *testing.T) {
+	ctx := context.Background()
+	recorder := &ttlRecorder{Cache: NewKVCache(testsupport.NewKV(), zap.NewNop()), ttls: map[string]time.Duration{}}
+	probe := &fakeMemoryProbe{used: 50, limit: 100}
+	c := NewAdaptiveCache(recorder, probe, PressurePolicy{
+		HighWatermark:    0.8,
+		LowWatermark:     0.6,
+		LowValueTTLRatio: 0.1,
+		LowValuePrefixes: []string{"items:list:"},
+		SkipPrefixes:     []string{"export:"},
+	}, zap.NewNop())
+
+	c.Check(ctx)
+	assert.False(t, c.Active())
+
+	// Over the high watermark: list pages get a shorter TTL, exports are not cached
+	probe.used = 85
+	c.Check(ctx)
+	require.True(t, c.Active())
+	require.NoError(t, c.Set(ctx, "items:list:1:10", []byte("page"), 5*time.Minute))
+	require.NoError(t, c.Set(ctx, "item:id:1", []byte("item"), 5*time.Minute))
+	require.NoError(t, c.Set(ctx, "export:csv", []byte("rows"), 5*time.Minute))
+	assert.Equal(t, 30*time.Second, recorder.ttls["items:list:1:10"])
+	assert.Equal(t, 5*time.Minute, recorder.ttls["item:id:1"])
+	_, cached := recorder.ttls["export:csv"]
+	assert.False(t, cached)
+
+	// Between the watermarks adaptive mode stays on
+	probe.used = 70
+	c.Check(ctx)
+	assert.True(t, c.Active())
+
+	// Below the low watermark normal TTLs come back
+	probe.used = 50
+	c.Check(ctx)
+	assert.False(t, c.Active())
+	require.NoError(t, c.Set(ctx, "items:list:1:10", []byte("page"), 5*time.Minute))
+	assert.Equal(t, 5*time.Minute, recorder.ttls["items:list:1:10"])
+}
+
+func TestAdaptiveCache_SoftQuotaWithoutMaxmemory(t *testing.T) {
+	probe := &fakeMemoryProbe{used: 900, limit: 0}
+	c := NewAdaptiveCache(NewKVCache(testsupport.NewKV(), zap.NewNop()), probe,
+		PressurePolicy{HighWatermark: 0.8, LowWatermark: 0.6, LowValueTTLRatio: 0.5}, zap.NewNop())
+
+	// No quota and no maxmemory: never adaptive
+	c.Check(context.Background())
+	assert.False(t, c.Active())
+
+	c.policy.SoftQuotaBytes = 1000
+	c.Check(context.Background())
+	assert.True(t, c.Active())
+	assert.Equal(t, time.Second, c.shorten(time.Second))
+}
+
+func TestParseMemoryInfo(t *testing.T) {
+	used, limit, err := parseMemoryInfo("# Memory\r\nused_memory:1048576\r\nused_memory_human:1.00M\r\nmaxmemory:4194304\r\n")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1048576), used)
+	assert.Equal(t, int64(4194304), limit)
+
+	_, _, err = parseMemoryInfo("# Memory\r\n")
+	assert.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// InvalidationChannel is the Redis Pub/Sub channel local cache tiers listen on
+const InvalidationChannel = "cache:invalidations"
+
+// Invalidation names what a replica dropped from the shared cache: a single key or
+// a Redis-style pattern
+type Invalidation struct {
+	Key     string `json:"key,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// InvalidationBus broadcasts invalidations to every replica. The Kafka consumer group
+// hands each event to one replica only, so without it the others would keep serving
+// their local copies until the hot-tier TTL runs out.
+type InvalidationBus interface {
+	Publish(ctx context.Context, invalidation Invalidation) error
+	// Subscribe calls handle for every invalidation until ctx is done
+	Subscribe(ctx context.Context, handle func(Invalidation)) error
+}
+
+// RedisInvalidationBus implements InvalidationBus on Redis Pub/Sub
+type RedisInvalidationBus struct {
+	client *redis.Client
+	logger *zap.Logger
+}
+
+// NewRedisInvalidationBus creates a bus on InvalidationChannel
+func NewRedisInvalidationBus(client *redis.Client, logger *zap.Logger) *RedisInvalidationBus {
+	return &RedisInvalidationBus{client: client, logger: logger}
+}
+
+func (b *RedisInvalidationBus) Publish(ctx context.Context, invalidation Invalidation) error {
+	payload, err := json.Marshal(invalidation)
+	if err != nil {
+		return fmt.Errorf("failed to marshal invalidation: %w", err)
+	}
+	if err := b.client.Publish(ctx, InvalidationChannel, payload).Err(); err != nil {
+		return fmt.Errorf("redis publish error: %w", err)
+	}
+	return nil
+}
+
+// Subscribe listens on InvalidationChannel; go-redis reconnects the subscription
+// on its own if the connection drops
+func (b *RedisInvalidationBus) Subscribe(ctx context.Context, handle func(Invalidation)) error {
+	pubsub := b.client.Subscribe(ctx, InvalidationChannel)
+	defer pubsub.Close()
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return fmt.Errorf("redis subscribe error: %w", err)
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case message, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			var invalidation Invalidation
+			if err := json.Unmarshal([]byte(message.Payload), &invalidation); err != nil {
+				b.logger.Warn("Ignoring malformed cache invalidation", zap.String("payload", message.Payload), zap.Error(err))
+				continue
+			}
+			handle(invalidation)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"testsupport"
+
+	"go.uber.org/zap"
+)
+
+// mockKV is shared by every cache created in mock mode, as a Redis server would be
+var mockKV = testsupport.NewKV()
+
+// KVCache implements Cache on an in-memory Redis fake (MOCK_DEPENDENCIES mode).
+// Like Redis, and unlike InMemoryCache, it keeps every key until its TTL.
+type KVCache struct {
+	kv     *testsupport.KV
+	logger *zap.Logger
+}
+
+// NewKVCache creates a cache backed by kv
+func NewKVCache(kv *testsupport.KV, logger *zap.Logger) *KVCache {
+	return &KVCache{kv: kv, logger: logger}
+}
+
+func (c *KVCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, ok := c.kv.Get(key)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	return value, nil
+}
+
+func (c *KVCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.kv.Set(key, value, ttl)
+	return nil
+}
+
+func (c *KVCache) Delete(ctx context.Context, key string) error {
+	c.kv.Del(key)
+	return nil
+}
+
+func (c *KVCache) Exists(ctx context.Context, key string) (bool, error) {
+	_, ok := c.kv.Get(key)
+	return ok, nil
+}
+
+// DeleteByPattern deletes all keys matching a pattern (for cache invalidation)
+func (c *KVCache) DeleteByPattern(ctx context.Context, pattern string) error {
+	keys := c.kv.Keys(pattern)
+	c.kv.Del(keys...)
+	c.logger.Debug("Deleted keys by pattern", zap.String("pattern", pattern), zap.Int("count", len(keys)))
+	return nil
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"sync"
+)
+
+type lookupsKey struct{}
+
+// Lookups counts the cache lookups made while serving one request, so the response
+// envelope can report whether the data came from the cache
+type Lookups struct {
+	mu     sync.Mutex
+	hits   int
+	misses int
+	errors int
+}
+
+// TrackLookups returns a context whose cache lookups are counted in the returned Lookups
+func TrackLookups(ctx context.Context) (context.Context, *Lookups) {
+	lookups := &Lookups{}
+	return context.WithValue(ctx, lookupsKey{}, lookups), lookups
+}
+
+// Result is "hit" when every lookup was served from the cache, "miss" when at least one
+// went to the read model and "" when the request made no lookup
+func (l *Lookups) Result() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch {
+	case l.misses > 0 || l.errors > 0:
+		return "miss"
+	case l.hits > 0:
+		return "hit"
+	default:
+		return ""
+	}
+}
+
+// Errors is the number of lookups that failed (cache unavailable)
+func (l *Lookups) Errors() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.errors
+}
+
+// recordLookup adds a lookup result ("hit", "miss" or "error") to the request's Lookups, if tracked
+func recordLookup(ctx context.Context, result string) {
+	lookups, ok := ctx.Value(lookupsKey{}).(*Lookups)
+	if !ok {
+		return
+	}
+	lookups.mu.Lock()
+	defer lookups.mu.Unlock()
+	switch result {
+	case "hit":
+		lookups.hits++
+	case "miss":
+		lookups.misses++
+	default:
+		lookups.errors++
+	}
+}
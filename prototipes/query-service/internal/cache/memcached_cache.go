@@ -0,0 +1,243 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"go.uber.org/zap"
+)
+
+// memcacheClient is the part of *memcache.Client the cache uses
+type memcacheClient interface {
+	Get(key string) (*memcache.Item, error)
+	GetMulti(keys []string) (map[string]*memcache.Item, error)
+	Set(item *memcache.Item) error
+	Add(item *memcache.Item) error
+	Delete(key string) error
+	Increment(key string, delta uint64) (uint64, error)
+}
+
+// memcachedKeyLimit is the longest key memcached accepts
+const memcachedKeyLimit = 250
+
+// MemcachedCache implements Cache on Memcached.
+//
+// Memcached cannot list keys, so DeleteByPattern works with generations instead: every
+// "a:b:" prefix of a key has a counter in Memcached, and the stored key includes the
+// counters of all its prefixes. Invalidating "reservations:item:7:*" increments the
+// counter of "reservations:item:7:", so every key under it is stored under a name no
+// longer read and simply ages out. Reads cost one extra round-trip for the counters.
+type MemcachedCache struct {
+	client memcacheClient
+	logger *zap.Logger
+}
+
+// NewMemcachedCache creates a cache on the given servers ("host:port")
+func NewMemcachedCache(servers []string, logger *zap.Logger) (*MemcachedCache, error) {
+	client := memcache.New(servers...)
+	client.Timeout = 500 * time.Millisecond
+	client.MaxIdleConns = 10
+	if err := client.Ping(); err != nil {
+		return nil, fmt.Errorf("memcached ping error: %w", err)
+	}
+	return &MemcachedCache{client: client, logger: logger}, nil
+}
+
+func (c *MemcachedCache) Get(ctx context.Context, key string) ([]byte, error) {
+	storageKey, err := c.storageKey(key)
+	if err != nil {
+		return nil, err
+	}
+	item, err := c.client.Get(storageKey)
+	if err == memcache.ErrCacheMiss {
+		return nil, ErrCacheMiss
+	}
+	if err != nil {
+		c.logger.Warn("Memcached Get error", zap.String("key", key), zap.Error(err))
+		return nil, fmt.Errorf("memcached get error: %w", err)
+	}
+	return item.Value, nil
+}
+
+func (c *MemcachedCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	storageKey, err := c.storageKey(key)
+	if err != nil {
+		return err
+	}
+	if err := c.client.Set(&memcache.Item{Key: storageKey, Value: value, Expiration: expirationSeconds(ttl)}); err != nil {
+		c.logger.Warn("Memcached Set error", zap.String("key", key), zap.Error(err))
+		return fmt.Errorf("memcached set error: %w", err)
+	}
+	return nil
+}
+
+func (c *MemcachedCache) Delete(ctx context.Context, key string) error {
+	storageKey, err := c.storageKey(key)
+	if err != nil {
+		return err
+	}
+	if err := c.client.Delete(storageKey); err != nil && err != memcache.ErrCacheMiss {
+		c.logger.Warn("Memcached Delete error", zap.String("key", key), zap.Error(err))
+		return fmt.Errorf("memcached delete error: %w", err)
+	}
+	return nil
+}
+
+func (c *MemcachedCache) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := c.Get(ctx, key)
+	if err == ErrCacheMiss {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// DeleteByPattern invalidates the keys under the prefix before the first "*" by moving
+// its generation forward. A pattern without "*" deletes that single key. The prefix is
+// cut back to its last ":" since only those prefixes have generations, which can drop
+// a few more keys than the pattern names but never fewer.
+func (c *MemcachedCache) DeleteByPattern(ctx context.Context, pattern string) error {
+	star := strings.Index(pattern, "*")
+	if star < 0 {
+		return c.Delete(ctx, pattern)
+	}
+	prefix := pattern[:strings.LastIndex(pattern[:star], ":")+1]
+	if err := c.bumpGeneration(prefix); err != nil {
+		c.logger.Warn("Memcached DeleteByPattern error", zap.String("pattern", pattern), zap.Error(err))
+		return fmt.Errorf("memcached delete by pattern error: %w", err)
+	}
+	c.logger.Debug("Invalidated keys by pattern", zap.String("pattern", pattern), zap.String("namespace", prefix))
+	return nil
+}
+
+// storageKey is key followed by the generations of its prefixes, hashed if the
+// result is not a valid memcached key
+func (c *MemcachedCache) storageKey(key string) (string, error) {
+	prefixes := keyPrefixes(key)
+	generations, err := c.generations(prefixes)
+	if err != nil {
+		c.logger.Warn("Memcached generation lookup error", zap.String("key", key), zap.Error(err))
+		return "", fmt.Errorf("memcached get error: %w", err)
+	}
+
+	var b strings.Builder
+	b.WriteString(key)
+	for _, prefix := range prefixes {
+		b.WriteString("#")
+		b.WriteString(generations[prefix])
+	}
+	storageKey := b.String()
+	if !validMemcachedKey(storageKey) {
+		sum := sha1.Sum([]byte(storageKey))
+		storageKey = keyspace(key) + ":sha1:" + hex.EncodeToString(sum[:])
+	}
+	return storageKey, nil
+}
+
+// generations reads the counter of each prefix, creating the missing ones
+func (c *MemcachedCache) generations(prefixes []string) (map[string]string, error) {
+	names := make([]string, len(prefixes))
+	for i, prefix := range prefixes {
+		names[i] = generationKey(prefix)
+	}
+	items, err := c.client.GetMulti(names)
+	if err != nil {
+		return nil, err
+	}
+
+	generations := make(map[string]string, len(prefixes))
+	for i, prefix := range prefixes {
+		if item, ok := items[names[i]]; ok {
+			generations[prefix] = strings.TrimSpace(string(item.Value))
+			continue
+		}
+		generation, err := c.initGeneration(names[i])
+		if err != nil {
+			return nil, err
+		}
+		generations[prefix] = generation
+	}
+	return generations, nil
+}
+
+// initGeneration creates a missing counter. It starts from the clock rather than zero
+// so that a counter evicted by memcached cannot bring back keys invalidated before.
+func (c *MemcachedCache) initGeneration(name string) (string, error) {
+	generation := strconv.FormatInt(time.Now().UnixNano(), 10)
+	err := c.client.Add(&memcache.Item{Key: name, Value: []byte(generation)})
+	if err == nil {
+		return generation, nil
+	}
+	if !errors.Is(err, memcache.ErrNotStored) {
+		return "", err
+	}
+	// Another replica created it first
+	item, err := c.client.Get(name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(item.Value)), nil
+}
+
+func (c *MemcachedCache) bumpGeneration(prefix string) error {
+	name := generationKey(prefix)
+	_, err := c.client.Increment(name, 1)
+	if err == memcache.ErrCacheMiss {
+		// No key was ever stored under it; a fresh counter is enough
+		_, err = c.initGeneration(name)
+	}
+	return err
+}
+
+func generationKey(prefix string) string {
+	if name := "gen:" + prefix; validMemcachedKey(name) {
+		return name
+	}
+	sum := sha1.Sum([]byte(prefix))
+	return "gen:sha1:" + hex.EncodeToString(sum[:])
+}
+
+// keyPrefixes returns the prefixes of key ending in ":" ("" included), shortest first:
+// "item:id:1" has "", "item:" and "item:id:"
+func keyPrefixes(key string) []string {
+	prefixes := []string{""}
+	for i, r := range key {
+		if r == ':' {
+			prefixes = append(prefixes, key[:i+1])
+		}
+	}
+	return prefixes
+}
+
+func validMemcachedKey(key string) bool {
+	if len(key) > memcachedKeyLimit {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// memcachedMaxRelativeTTL is the longest expiration memcached reads as relative;
+// longer values are taken as a Unix timestamp
+const memcachedMaxRelativeTTL = 30 * 24 * time.Hour
+
+// expirationSeconds converts a TTL to memcached's whole seconds (0 would never expire)
+func expirationSeconds(ttl time.Duration) int32 {
+	if ttl > memcachedMaxRelativeTTL {
+		return int32(time.Now().Add(ttl).Unix())
+	}
+	if ttl < time.Second {
+		return 1
+	}
+	return int32(ttl / time.Second)
+}
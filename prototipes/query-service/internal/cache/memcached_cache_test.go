@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeMemcache is an in-memory memcached that ignores expirations
+type fakeMemcache struct {
+	mu    sync.Mutex
+	items map[string][]byte
+}
+
+func newFakeMemcache() *fakeMemcache {
+	return &fakeMemcache{items: map[string][]byte{}}
+}
+
+func (m *fakeMemcache) Get(key string) (*memcache.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.items[key]
+	if !ok {
+		return nil, memcache.ErrCacheMiss
+	}
+	return &memcache.Item{Key: key, Value: value}, nil
+}
+
+func (m *fakeMemcache) GetMulti(keys []string) (map[string]*memcache.Item, error) {
+	items := map[string]*memcache.Item{}
+	for _, key := range keys {
+		if item, err := m.Get(key); err == nil {
+			items[key] = item
+		}
+	}
+	return items, nil
+}
+
+func (m *fakeMemcache) Set(item *memcache.Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items[item.Key] = item.Value
+	return nil
+}
+
+func (m *fakeMemcache) Add(item *memcache.Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.items[item.Key]; ok {
+		return memcache.ErrNotStored
+	}
+	m.items[item.Key] = item.Value
+	return nil
+}
+
+func (m *fakeMemcache) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.items[key]; !ok {
+		return memcache.ErrCacheMiss
+	}
+	delete(m.items, key)
+	return nil
+}
+
+func (m *fakeMemcache) Increment(key string, delta uint64) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.items[key]
+	if !ok {
+		return 0, memcache.ErrCacheMiss
+	}
+	n, err := strconv.ParseUint(string(value), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	n += delta
+	m.items[key] = []byte(strconv.FormatUint(n, 10))
+	return n, nil
+}
+
+func TestMemcachedCache_DeleteByPatternUsesGenerations(t *testing.T) {
+	ctx := context.Background()
+	c := &MemcachedCache{client: newFakeMemcache(), logger: zap.NewNop()}
+
+	keys := []string{"reservations:item:1:all:1:20", "reservations:item:2:all:1:20", "item:id:1"}
+	for _, key := range keys {
+		require.NoError(t, c.Set(ctx, key, []byte(key), time.Minute))
+	}
+	value, err := c.Get(ctx, "item:id:1")
+	require.NoError(t, err)
+	assert.Equal(t, "item:id:1", string(value))
+
+	require.NoError(t, c.DeleteByPattern(ctx, "reservations:item:1:*"))
+	_, err = c.Get(ctx, "reservations:item:1:all:1:20")
+	assert.Equal(t, ErrCacheMiss, err)
+	exists, err := c.Exists(ctx, "reservations:item:2:all:1:20")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// A wider pattern covers every key under it
+	require.NoError(t, c.DeleteByPattern(ctx, "reservations:*"))
+	exists, err = c.Exists(ctx, "reservations:item:2:all:1:20")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	exists, err = c.Exists(ctx, "item:id:1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// Keys written after the invalidation are readable again
+	require.NoError(t, c.Set(ctx, "reservations:item:1:all:1:20", []byte("fresh"), time.Minute))
+	value, err = c.Get(ctx, "reservations:item:1:all:1:20")
+	require.NoError(t, err)
+	assert.Equal(t, "fresh", string(value))
+
+	require.NoError(t, c.Delete(ctx, "item:id:1"))
+	_, err = c.Get(ctx, "item:id:1")
+	assert.Equal(t, ErrCacheMiss, err)
+}
+
+func TestMemcachedCache_LongKeysAreHashed(t *testing.T) {
+	ctx := context.Background()
+	c := &MemcachedCache{client: newFakeMemcache(), logger: zap.NewNop()}
+	key := "items:list:" + strings.Repeat("x", 300) + " with spaces"
+
+	require.NoError(t, c.Set(ctx, key, []byte("page"), time.Minute))
+	value, err := c.Get(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, "page", string(value))
+
+	storageKey, err := c.storageKey(key)
+	require.NoError(t, err)
+	assert.True(t, validMemcachedKey(storageKey))
+	assert.True(t, strings.HasPrefix(storageKey, "items:sha1:"))
+}
+
+func TestExpirationSeconds(t *testing.T) {
+	assert.Equal(t, int32(1), expirationSeconds(0))
+	assert.Equal(t, int32(300), expirationSeconds(5*time.Minute))
+	assert.Greater(t, expirationSeconds(60*24*time.Hour), int32(time.Now().Unix()))
+}
@@ -0,0 +1,196 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"path"
+	"sync"
+	"time"
+
+	"query-service/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+// Defaults of the in-memory cache bounds when the configuration does not set them
+const (
+	DefaultMemoryMaxEntries = 10000
+	DefaultMemoryMaxBytes   = 64 * 1024 * 1024
+)
+
+// memorySweepInterval is how often expired entries are dropped in the background, so
+// keys that are never read again do not hold memory until they are evicted
+const memorySweepInterval = 30 * time.Second
+
+// InMemoryCache is the process-local cache, used with USE_CACHE=memory or when the shared
+// backend is down. It is an LRU bounded by entries and by bytes (keys plus values): a
+// write that goes over either bound evicts the least recently used entries. Entries
+// also expire after their TTL, on read and in the background sweep started by Run.
+type InMemoryCache struct {
+	logger     *zap.Logger
+	maxEntries int
+	maxBytes   int64
+
+	mu      sync.Mutex
+	order   *list.List // front = most recently used
+	entries map[string]*list.Element
+	bytes   int64
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewInMemoryCache creates an in-memory cache holding at most maxEntries entries and
+// maxBytes bytes; non-positive bounds use the defaults
+func NewInMemoryCache(maxEntries int, maxBytes int64, logger *zap.Logger) *InMemoryCache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMemoryMaxEntries
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultMemoryMaxBytes
+	}
+	return &InMemoryCache{
+		logger:     logger,
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// Run drops the expired entries every interval until ctx is done
+func (c *InMemoryCache) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if expired := c.sweep(time.Now()); expired > 0 {
+				c.logger.Debug("Expired in-memory cache entries dropped", zap.Int("count", expired))
+			}
+		}
+	}
+}
+
+func (c *InMemoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	entry := element.Value.(*memoryEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.remove(element)
+		return nil, ErrCacheMiss
+	}
+	c.order.MoveToFront(element)
+	return entry.value, nil
+}
+
+// Set stores the value, evicting the least recently used entries while over a bound. A
+// value larger than the byte bound on its own is not cached.
+func (c *InMemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	size := entrySize(key, value)
+	if size > c.maxBytes {
+		c.logger.Debug("Value larger than the in-memory cache, not cached",
+			zap.String("key", key),
+			zap.Int64("bytes", size),
+		)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(&memoryEntry{key: key, value: value, expiresAt: time.Now().Add(ttl)})
+	c.bytes += size
+	for c.order.Len() > c.maxEntries || c.bytes > c.maxBytes {
+		oldest := c.order.Back()
+		metrics.CacheMemoryEvictions.WithLabelValues(keyspace(oldest.Value.(*memoryEntry).key)).Inc()
+		c.remove(oldest)
+	}
+	return nil
+}
+
+func (c *InMemoryCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	return nil
+}
+
+func (c *InMemoryCache) Exists(ctx context.Context, key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return false, nil
+	}
+	if time.Now().After(element.Value.(*memoryEntry).expiresAt) {
+		c.remove(element)
+		return false, nil
+	}
+	return true, nil
+}
+
+// DeleteByPattern deletes the keys matching a Redis-style glob ("items:list:*")
+func (c *InMemoryCache) DeleteByPattern(ctx context.Context, pattern string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, element := range c.entries {
+		if matched, _ := path.Match(pattern, key); matched {
+			c.remove(element)
+		}
+	}
+	return nil
+}
+
+// sweep drops the entries expired at now and returns how many
+func (c *InMemoryCache) sweep(now time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expired := 0
+	for _, element := range c.entries {
+		if now.After(element.Value.(*memoryEntry).expiresAt) {
+			c.remove(element)
+			expired++
+		}
+	}
+	return expired
+}
+
+// size reports the entries held (expired ones not swept yet included), their bytes and
+// the byte bound
+func (c *InMemoryCache) size(ctx context.Context) (int64, int64, int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return int64(c.order.Len()), c.bytes, c.maxBytes, nil
+}
+
+// remove must be called with mu held
+func (c *InMemoryCache) remove(element *list.Element) {
+	entry := element.Value.(*memoryEntry)
+	c.order.Remove(element)
+	delete(c.entries, entry.key)
+	c.bytes -= entrySize(entry.key, entry.value)
+}
+
+func entrySize(key string, value []byte) int64 {
+	return int64(len(key) + len(value))
+}
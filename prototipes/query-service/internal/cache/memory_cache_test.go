@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestInMemoryCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := NewInMemoryCache(2, 1024, zap.NewNop())
+
+	require.NoError(t, c.Set(ctx, "item:id:1", []byte("a"), time.Minute))
+	require.NoError(t, c.Set(ctx, "item:id:2", []byte("b"), time.Minute))
+	_, err := c.Get(ctx, "item:id:1") // 2 is now the least recently used
+	require.NoError(t, err)
+	require.NoError(t, c.Set(ctx, "item:id:3", []byte("c"), time.Minute))
+
+	_, err = c.Get(ctx, "item:id:2")
+	assert.Equal(t, ErrCacheMiss, err)
+	for _, key := range []string{"item:id:1", "item:id:3"} {
+		exists, _ := c.Exists(ctx, key)
+		assert.True(t, exists, key)
+	}
+}
+
+func TestInMemoryCache_BoundsBytes(t *testing.T) {
+	ctx := context.Background()
+	c := NewInMemoryCache(100, 30, zap.NewNop())
+
+	require.NoError(t, c.Set(ctx, "k1", make([]byte, 10), time.Minute)) // 12 bytes
+	require.NoError(t, c.Set(ctx, "k2", make([]byte, 10), time.Minute)) // 24 bytes
+	require.NoError(t, c.Set(ctx, "k3", make([]byte, 10), time.Minute)) // 36 bytes: evicts k1
+
+	keys, used, limit, err := c.size(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), keys)
+	assert.Equal(t, int64(24), used)
+	assert.Equal(t, int64(30), limit)
+
+	// Replacing a value accounts for the new size only
+	require.NoError(t, c.Set(ctx, "k2", make([]byte, 4), time.Minute))
+	_, used, _, _ = c.size(ctx)
+	assert.Equal(t, int64(18), used)
+
+	// A value over the whole bound is not cached and evicts nothing
+	require.NoError(t, c.Set(ctx, "big", make([]byte, 64), time.Minute))
+	exists, _ := c.Exists(ctx, "big")
+	assert.False(t, exists)
+	keys, _, _, _ = c.size(ctx)
+	assert.Equal(t, int64(2), keys)
+}
+
+func TestInMemoryCache_SweepsExpiredEntries(t *testing.T) {
+	ctx := context.Background()
+	c := NewInMemoryCache(0, 0, zap.NewNop())
+	require.NoError(t, c.Set(ctx, "stock:1", []byte("a"), time.Millisecond))
+	require.NoError(t, c.Set(ctx, "stock:2", []byte("b"), time.Minute))
+
+	assert.Equal(t, 1, c.sweep(time.Now().Add(time.Second)))
+	keys, used, _, _ := c.size(ctx)
+	assert.Equal(t, int64(1), keys)
+	assert.Equal(t, int64(len("stock:2b")), used)
+}
+
+func TestInMemoryCache_DeleteByPattern(t *testing.T) {
+	ctx := context.Background()
+	c := NewInMemoryCache(0, 0, zap.NewNop())
+	for _, key := range []string{"items:list:1:10", "items:list:2:10", "item:id:1"} {
+		require.NoError(t, c.Set(ctx, key, []byte("x"), time.Minute))
+	}
+
+	require.NoError(t, c.DeleteByPattern(ctx, "items:list:*"))
+	keys, _, _, _ := c.size(ctx)
+	assert.Equal(t, int64(1), keys)
+
+	require.NoError(t, c.DeleteByPattern(ctx, "*"))
+	keys, used, _, _ := c.size(ctx)
+	assert.Equal(t, int64(0), keys)
+	assert.Equal(t, int64(0), used)
+}
+
+func TestInMemoryCache_ConcurrentUse(t *testing.T) {
+	ctx := context.Background()
+	c := NewInMemoryCache(50, 4096, zap.NewNop())
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				key := fmt.Sprintf("item:id:%d", (g*200+i)%120)
+				c.Set(ctx, key, []byte(key), time.Minute)
+				c.Get(ctx, key)
+				if i%50 == 0 {
+					c.DeleteByPattern(ctx, "item:id:1*")
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	keys, used, _, _ := c.size(ctx)
+	assert.LessOrEqual(t, keys, int64(50))
+	assert.LessOrEqual(t, used, int64(4096))
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"query-service/pkg/metrics"
+)
+
+// meteredCache counts Get hits and misses of the wrapped cache for the /metrics endpoint
+// and for the response envelope of the request (see TrackLookups)
+type meteredCache struct {
+	Cache
+	backend string
+
+	// Totals since startup for the admin stats (the Prometheus counters are per keyspace)
+	hits, misses, errors atomic.Uint64
+}
+
+// withMetrics wraps a cache so its lookups are counted under the given backend name
+func withMetrics(cache Cache, backend string) Cache {
+	return &meteredCache{Cache: cache, backend: backend}
+}
+
+// Get looks the key up in the wrapped cache and records the result
+func (c *meteredCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.Cache.Get(ctx, key)
+
+	result := "hit"
+	switch {
+	case err == ErrCacheMiss:
+		result = "miss"
+		c.misses.Add(1)
+	case err != nil:
+		result = "error"
+		c.errors.Add(1)
+	default:
+		c.hits.Add(1)
+	}
+	metrics.CacheRequests.WithLabelValues(c.backend, keyspace(key), result).Inc()
+	recordLookup(ctx, result)
+
+	return value, err
+}
+
+// keyspace returns the key prefix ("item", "stock", "items", "reservations") so the
+// label stays bounded no matter how many keys (or tenants) are cached
+func keyspace(key string) string {
+	key = unscoped(key)
+	if i := strings.Index(key, ":"); i > 0 {
+		return key[:i]
+	}
+	return key
+}
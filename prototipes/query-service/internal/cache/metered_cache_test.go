@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"query-service/pkg/metrics"
+
+	"testsupport"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMeteredCache_CountsHitsAndMissesByKeyspace(t *testing.T) {
+	c := withMetrics(NewKVCache(testsupport.NewKV(), zap.NewNop()), "test")
+	ctx := context.Background()
+	hits := metrics.CacheRequests.WithLabelValues("test", "item", "hit")
+	misses := metrics.CacheRequests.WithLabelValues("test", "item", "miss")
+	hitsBefore, missesBefore := testutil.ToFloat64(hits), testutil.ToFloat64(misses)
+
+	_, err := c.Get(ctx, "item:id:1")
+	assert.Equal(t, ErrCacheMiss, err)
+
+	require.NoError(t, c.Set(ctx, "item:id:1", []byte(`{}`), time.Minute))
+	value, err := c.Get(ctx, "item:id:1")
+	require.NoError(t, err)
+	assert.Equal(t, `{}`, string(value))
+
+	assert.Equal(t, hitsBefore+1, testutil.ToFloat64(hits))
+	assert.Equal(t, missesBefore+1, testutil.ToFloat64(misses))
+}
+
+func TestMeteredCache_TracksLookupsOfTheRequest(t *testing.T) {
+	c := withMetrics(NewKVCache(testsupport.NewKV(), zap.NewNop()), "test")
+	require.NoError(t, c.Set(context.Background(), "item:id:1", []byte(`{}`), time.Minute))
+
+	ctx, lookups := TrackLookups(context.Background())
+	assert.Equal(t, "", lookups.Result())
+
+	_, err := c.Get(ctx, "item:id:1")
+	require.NoError(t, err)
+	assert.Equal(t, "hit", lookups.Result())
+
+	_, err = c.Get(ctx, "item:id:2")
+	assert.Equal(t, ErrCacheMiss, err)
+	assert.Equal(t, "miss", lookups.Result())
+	assert.Equal(t, 0, lookups.Errors())
+}
+
+func TestKeyspace(t *testing.T) {
+	assert.Equal(t, "reservations", keyspace("reservations:item:1:all:1:20"))
+	assert.Equal(t, "stock", keyspace("stock:1"))
+	assert.Equal(t, "plain", keyspace("plain"))
+}
@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+)
+
+// probeKey is never written, so reading it goes through every tier to the shared backend
+const probeKey = "health:probe"
+
+// Backend returns the name of the backend behind c ("redis", "memcached", "memory" or
+// "mock"), or "" for a cache not built by NewCache
+func Backend(c Cache) string {
+	switch c := c.(type) {
+	case *meteredCache:
+		return c.backend
+	case *TieredCache:
+		return Backend(c.remote)
+	case *tenantCache:
+		return Backend(c.Cache)
+	}
+	return ""
+}
+
+// Probe checks that the cache answers (readiness probe). A cache that fell back to the
+// process memory because the configured backend was down at startup is reported as
+// unavailable: it still works, but the replicas no longer share it.
+func Probe(ctx context.Context, c Cache, configured string) error {
+	if Backend(c) == BackendMemory && configured != BackendMemory {
+		return fmt.Errorf("%s unreachable at startup, using in-memory cache", configured)
+	}
+	if _, err := c.Get(ctx, probeKey); err != nil && err != ErrCacheMiss {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"query-service/internal/config"
+	"testsupport"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestProbe(t *testing.T) {
+	ctx := context.Background()
+	fallback := newMemoryFallback(&config.Config{}, zap.NewNop())
+
+	assert.Equal(t, BackendMemory, Backend(fallback))
+	assert.NoError(t, Probe(ctx, fallback, BackendMemory))
+	assert.EqualError(t, Probe(ctx, fallback, BackendRedis), "redis unreachable at startup, using in-memory cache")
+
+	tiered := NewTieredCache(withMetrics(NewKVCache(testsupport.NewKV(), zap.NewNop()), "mock"), 10, time.Second)
+	assert.Equal(t, "mock", Backend(tiered))
+	assert.NoError(t, Probe(ctx, tiered, BackendRedis))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/rand"
 	"time"
 
 	"query-service/internal/config"
@@ -28,19 +29,48 @@ type RedisCache struct {
 	logger *zap.Logger
 }
 
-// InMemoryCache is a fallback implementation when Redis is not available
-type InMemoryCache struct {
-	logger *zap.Logger
-	data   map[string]cacheEntry
-}
+// Cache backends selected by USE_CACHE
+const (
+	BackendRedis     = "redis"
+	BackendMemcached = "memcached"
+	BackendMemory    = "memory"
+	BackendTiered    = "tiered" // local LRU over Redis, invalidated on every replica
+)
 
-type cacheEntry struct {
-	value     []byte
-	expiresAt time.Time
-}
+// defaultTieredSize is the local tier size of the tiered backend when HOT_CACHE_SIZE is not set
+const defaultTieredSize = 1000
 
-// NewCache creates a new cache instance (Redis or InMemory fallback)
+// NewCache creates the cache selected by cfg.CacheBackend. A shared backend that
+// cannot be reached falls back to the in-memory cache. Keys are scoped to the tenant of
+// the request (see withTenants).
 func NewCache(cfg *config.Config, logger *zap.Logger) Cache {
+	return withTenants(newCache(cfg, logger))
+}
+
+// newCache builds the backend and tiers of NewCache
+func newCache(cfg *config.Config, logger *zap.Logger) Cache {
+	if cfg.MockDependencies {
+		logger.Info("Mock mode: using in-memory Redis fake for the cache")
+		return withHotTier(cfg, logger, withMetrics(NewKVCache(mockKV, logger), "mock"))
+	}
+
+	switch cfg.CacheBackend {
+	case BackendMemory:
+		logger.Info("In-memory cache initialized")
+		return newMemoryFallback(cfg, logger)
+	case BackendMemcached:
+		memcached, err := NewMemcachedCache(cfg.MemcachedServers, logger)
+		if err != nil {
+			logger.Warn("Failed to connect to Memcached, using in-memory cache",
+				zap.Strings("servers", cfg.MemcachedServers),
+				zap.Error(err),
+			)
+			return newMemoryFallback(cfg, logger)
+		}
+		logger.Info("Memcached cache initialized successfully", zap.Strings("servers", cfg.MemcachedServers))
+		return withHotTier(cfg, logger, withMetrics(memcached, "memcached"))
+	}
+
 	// Try to initialize Redis client
 	rdb := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort),
@@ -70,11 +100,7 @@ func NewCache(cfg *config.Config, logger *zap.Logger) Cache {
 			zap.Error(err),
 		)
 		rdb.Close()
-		// Fallback to in-memory cache
-		return &InMemoryCache{
-			logger: logger,
-			data:   make(map[string]cacheEntry),
-		}
+		return newMemoryFallback(cfg, logger)
 	}
 
 	logger.Info("Redis cache initialized successfully",
@@ -83,68 +109,83 @@ func NewCache(cfg *config.Config, logger *zap.Logger) Cache {
 		zap.Int("db", cfg.RedisDB),
 	)
 
-	return &RedisCache{
+	redisCache := &RedisCache{
 		client: rdb,
 		logger: logger,
 	}
-}
-
-func (c *InMemoryCache) Get(ctx context.Context, key string) ([]byte, error) {
-	entry, exists := c.data[key]
-	if !exists {
-		return nil, ErrCacheMiss
-	}
-
-	if time.Now().After(entry.expiresAt) {
-		delete(c.data, key)
-		return nil, ErrCacheMiss
+	remote := withMetrics(withMemoryPressure(cfg, logger, redisCache), "redis")
+	if cfg.CacheBackend == BackendTiered {
+		return newTiered(cfg, logger, remote, rdb)
 	}
-
-	return entry.value, nil
+	return withHotTier(cfg, logger, remote)
 }
 
-func (c *InMemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
-	c.data[key] = cacheEntry{
-		value:     value,
-		expiresAt: time.Now().Add(ttl),
-	}
-	return nil
+// newMemoryFallback is the process-local cache, used on request or when the shared
+// backend is down. Its expired entries are swept for the life of the process.
+func newMemoryFallback(cfg *config.Config, logger *zap.Logger) Cache {
+	memory := NewInMemoryCache(cfg.CacheMemoryMaxEntries, int64(cfg.CacheMemoryMaxMB)*1024*1024, logger)
+	go memory.Run(context.Background(), memorySweepInterval)
+	return withMetrics(memory, "memory")
 }
 
-func (c *InMemoryCache) Delete(ctx context.Context, key string) error {
-	delete(c.data, key)
-	return nil
+// newTiered puts the local LRU in front of Redis and relays invalidations between
+// replicas over Redis Pub/Sub, so every replica drops its local copy as soon as the
+// one that consumed the Kafka event does
+func newTiered(cfg *config.Config, logger *zap.Logger, remote Cache, rdb *redis.Client) Cache {
+	size := cfg.HotCacheSize
+	if size <= 0 {
+		size = defaultTieredSize
+	}
+	ttl := TTL(cfg.HotCacheTTLSeconds)
+	if ttl <= 0 {
+		ttl = 5 * time.Second
+	}
+	logger.Info("Tiered cache enabled (local LRU over Redis)",
+		zap.Int("local_size", size),
+		zap.Duration("local_ttl", ttl),
+		zap.String("invalidation_channel", InvalidationChannel),
+	)
+	tiered := NewTieredCache(remote, size, ttl).WithInvalidationBus(NewRedisInvalidationBus(rdb, logger), logger)
+	go tiered.Listen(context.Background())
+	return tiered
 }
 
-func (c *InMemoryCache) Exists(ctx context.Context, key string) (bool, error) {
-	entry, exists := c.data[key]
-	if !exists {
-		return false, nil
+// withMemoryPressure shortens low-value TTLs while Redis is over its soft quota
+// (CACHE_PRESSURE_ENABLED). The check runs for the life of the process.
+func withMemoryPressure(cfg *config.Config, logger *zap.Logger, redisCache *RedisCache) Cache {
+	if !cfg.CachePressureEnabled || cfg.CachePressureCheckSeconds <= 0 {
+		return redisCache
 	}
-
-	if time.Now().After(entry.expiresAt) {
-		delete(c.data, key)
-		return false, nil
+	policy := PressurePolicy{
+		SoftQuotaBytes:   int64(cfg.CacheSoftQuotaMB) * 1024 * 1024,
+		HighWatermark:    float64(cfg.CachePressureHighPercent) / 100,
+		LowWatermark:     float64(cfg.CachePressureLowPercent) / 100,
+		LowValueTTLRatio: float64(cfg.CachePressureTTLPercent) / 100,
+		LowValuePrefixes: cfg.CachePressureLowValuePrefixes,
+		SkipPrefixes:     cfg.CachePressureSkipPrefixes,
+		CheckInterval:    time.Duration(cfg.CachePressureCheckSeconds) * time.Second,
 	}
-
-	return true, nil
+	logger.Info("Adaptive cache TTLs enabled",
+		zap.Int("soft_quota_mb", cfg.CacheSoftQuotaMB),
+		zap.Int("high_percent", cfg.CachePressureHighPercent),
+		zap.Int("low_percent", cfg.CachePressureLowPercent),
+	)
+	adaptive := NewAdaptiveCache(redisCache, redisCache, policy, logger)
+	go adaptive.Run(context.Background())
+	return adaptive
 }
 
-func (c *InMemoryCache) DeleteByPattern(ctx context.Context, pattern string) error {
-	// Simple pattern matching for in-memory cache
-	// In production, this would use proper pattern matching
-	for key := range c.data {
-		// Simple prefix matching
-		if len(pattern) > 0 && pattern[len(pattern)-1] == '*' {
-			prefix := pattern[:len(pattern)-1]
-			if len(key) >= len(prefix) && key[:len(prefix)] == prefix {
-				delete(c.data, key)
-			}
-		} else if key == pattern {
-			delete(c.data, key)
-		}
+// withHotTier puts the in-process hot-key LRU in front of a shared cache when
+// HOT_CACHE_SIZE is set. The in-memory fallback is already local and is not tiered.
+func withHotTier(cfg *config.Config, logger *zap.Logger, remote Cache) Cache {
+	if cfg.HotCacheSize <= 0 || cfg.HotCacheTTLSeconds <= 0 {
+		return remote
 	}
-	return nil
+	logger.Info("Hot-key cache tier enabled",
+		zap.Int("size", cfg.HotCacheSize),
+		zap.Int("ttl_seconds", cfg.HotCacheTTLSeconds),
+	)
+	return NewTieredCache(remote, cfg.HotCacheSize, TTL(cfg.HotCacheTTLSeconds))
 }
 
 // RedisCache implementation
@@ -236,6 +277,21 @@ func TTL(seconds int) time.Duration {
 	return time.Duration(seconds) * time.Second
 }
 
+// JitteredTTL returns TTL(seconds) shortened by a random 0 to jitterPercent %, so keys
+// cached at the same moment (the list pages after an invalidation) expire spread out
+// instead of all missing at once. The result never exceeds TTL(seconds).
+func JitteredTTL(seconds, jitterPercent int) time.Duration {
+	ttl := TTL(seconds)
+	if jitterPercent <= 0 || ttl <= 0 {
+		return ttl
+	}
+	maxJitter := int64(ttl) * int64(jitterPercent) / 100
+	if maxJitter <= 0 {
+		return ttl
+	}
+	return ttl - time.Duration(rand.Int63n(maxJitter+1))
+}
+
 var (
 	ErrCacheMiss = fmt.Errorf("cache miss")
 )
@@ -0,0 +1,22 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJitteredTTL(t *testing.T) {
+	assert.Equal(t, 5*time.Minute, JitteredTTL(300, 0))
+	assert.Equal(t, time.Duration(0), JitteredTTL(0, 10))
+
+	spread := map[time.Duration]bool{}
+	for i := 0; i < 100; i++ {
+		ttl := JitteredTTL(300, 10)
+		assert.LessOrEqual(t, ttl, 300*time.Second)
+		assert.GreaterOrEqual(t, ttl, 270*time.Second)
+		spread[ttl] = true
+	}
+	assert.Greater(t, len(spread), 1, "keys cached together must not all get the same TTL")
+}
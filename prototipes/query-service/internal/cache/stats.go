@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+)
+
+// Stats is a snapshot of the cache for GET /api/v1/admin/cache/stats. Hits, Misses and
+// Errors count the lookups that reached the shared backend since startup; the ones
+// answered by the local tier are in HotTier.
+type Stats struct {
+	Backend          string        `json:"backend" example:"redis"`
+	Keys             *int64        `json:"keys,omitempty" example:"1342"`                 // nil when the backend cannot count them (memcached)
+	MemoryUsedBytes  *int64        `json:"memory_used_bytes,omitempty" example:"2097152"` // nil when unknown
+	MemoryLimitBytes *int64        `json:"memory_limit_bytes,omitempty" example:"0"`      // maxmemory of Redis; 0 is unlimited
+	Hits             uint64        `json:"hits" example:"9120"`
+	Misses           uint64        `json:"misses" example:"311"`
+	Errors           uint64        `json:"errors" example:"0"`
+	HotTier          *HotTierStats `json:"hot_tier,omitempty"`
+	PressureActive   *bool         `json:"pressure_active,omitempty"` // adaptive TTLs on (CACHE_PRESSURE_ENABLED)
+}
+
+// HotTierStats describes the in-process LRU of a tiered cache
+type HotTierStats struct {
+	Keys     int    `json:"keys" example:"212"`
+	Capacity int    `json:"capacity" example:"1000"`
+	Hits     uint64 `json:"hits" example:"40211"`
+	Misses   uint64 `json:"misses" example:"9431"`
+}
+
+// sizer is implemented by the backends that can report how many keys and bytes they hold
+type sizer interface {
+	size(ctx context.Context) (keys, usedBytes, limitBytes int64, err error)
+}
+
+// CollectStats walks the layers of a cache built by NewCache and gathers their counters
+// and the size of the backend
+func CollectStats(ctx context.Context, c Cache) (Stats, error) {
+	stats := Stats{Backend: Backend(c)}
+	err := collectStats(ctx, c, &stats)
+	return stats, err
+}
+
+func collectStats(ctx context.Context, c Cache, stats *Stats) error {
+	switch c := c.(type) {
+	case *TieredCache:
+		stats.HotTier = &HotTierStats{
+			Keys:     c.local.len(),
+			Capacity: c.local.size,
+			Hits:     c.localHits.Load(),
+			Misses:   c.localMisses.Load(),
+		}
+		return collectStats(ctx, c.remote, stats)
+	case *meteredCache:
+		stats.Hits = c.hits.Load()
+		stats.Misses = c.misses.Load()
+		stats.Errors = c.errors.Load()
+		return collectStats(ctx, c.Cache, stats)
+	case *tenantCache:
+		return collectStats(ctx, c.Cache, stats)
+	case *AdaptiveCache:
+		active := c.Active()
+		stats.PressureActive = &active
+		return collectStats(ctx, c.Cache, stats)
+	case sizer:
+		keys, used, limit, err := c.size(ctx)
+		if err != nil {
+			return err
+		}
+		stats.Keys, stats.MemoryUsedBytes = &keys, &used
+		if limit >= 0 {
+			stats.MemoryLimitBytes = &limit
+		}
+	}
+	return nil
+}
+
+// size counts the keys of the Redis DB (not only the cache ones, if it is shared) and
+// reads its memory usage
+func (c *RedisCache) size(ctx context.Context) (int64, int64, int64, error) {
+	keys, err := c.client.DBSize(ctx).Result()
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("redis dbsize error: %w", err)
+	}
+	used, limit, err := c.MemoryUsage(ctx)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return keys, used, limit, nil
+}
+
+// size counts the live keys of the fake and the bytes of their keys and values
+func (c *KVCache) size(ctx context.Context) (int64, int64, int64, error) {
+	var keys, used int64
+	for _, key := range c.kv.Keys("*") {
+		if value, ok := c.kv.Get(key); ok {
+			keys++
+			used += int64(len(key) + len(value))
+		}
+	}
+	return keys, used, -1, nil
+}
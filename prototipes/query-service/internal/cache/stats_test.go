@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"testsupport"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCollectStats_WalksEveryLayer(t *testing.T) {
+	ctx := context.Background()
+	c := NewTieredCache(withMetrics(NewKVCache(testsupport.NewKV(), zap.NewNop()), "mock"), 10, time.Minute)
+
+	require.NoError(t, c.Set(ctx, "item:id:1", []byte("abc"), time.Minute))
+	require.NoError(t, c.Set(ctx, "item:id:2", []byte("de"), time.Minute))
+	_, err := c.Get(ctx, "item:id:1") // shared backend hit, now held locally
+	require.NoError(t, err)
+	_, err = c.Get(ctx, "item:id:1") // local hit
+	require.NoError(t, err)
+	_, err = c.Get(ctx, "item:id:3") // miss in both tiers
+	assert.ErrorIs(t, err, ErrCacheMiss)
+
+	stats, err := CollectStats(ctx, c)
+	require.NoError(t, err)
+	assert.Equal(t, "mock", stats.Backend)
+	require.NotNil(t, stats.Keys)
+	assert.Equal(t, int64(2), *stats.Keys)
+	assert.Equal(t, int64(len("item:id:1abc")+len("item:id:2de")), *stats.MemoryUsedBytes)
+	assert.Nil(t, stats.MemoryLimitBytes)
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, &HotTierStats{Keys: 1, Capacity: 10, Hits: 1, Misses: 2}, stats.HotTier)
+	assert.Nil(t, stats.PressureActive)
+}
+
+func TestCollectStats_MemcachedHasNoSize(t *testing.T) {
+	stats, err := CollectStats(context.Background(), withMetrics(&MemcachedCache{}, "memcached"))
+	require.NoError(t, err)
+	assert.Equal(t, "memcached", stats.Backend)
+	assert.Nil(t, stats.Keys)
+	assert.Nil(t, stats.MemoryUsedBytes)
+}
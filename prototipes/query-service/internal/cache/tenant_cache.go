@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"query-service/internal/tenant"
+)
+
+// tenantKeyPrefix starts the keys of the tenants other than the default one, followed
+// by the tenant and ":" (t:acme:item:id:...). The default tenant keeps the bare keys, so
+// the entries cached before tenants existed stay valid.
+const tenantKeyPrefix = "t:"
+
+// tenantCache scopes the keys of the wrapped cache to the tenant of the request, so two
+// brands with the same SKU never read each other's entries
+type tenantCache struct {
+	Cache
+}
+
+// withTenants wraps a cache so every key is scoped to the tenant in ctx
+func withTenants(cache Cache) Cache {
+	return &tenantCache{Cache: cache}
+}
+
+// tenantKey returns key scoped to the tenant of ctx
+func tenantKey(ctx context.Context, key string) string {
+	tenantID := tenant.FromContext(ctx)
+	if tenantID == tenant.Default {
+		return key
+	}
+	return tenantKeyPrefix + tenantID + ":" + key
+}
+
+// unscoped returns key without its tenant prefix
+func unscoped(key string) string {
+	if !strings.HasPrefix(key, tenantKeyPrefix) {
+		return key
+	}
+	if i := strings.Index(key[len(tenantKeyPrefix):], ":"); i >= 0 {
+		return key[len(tenantKeyPrefix)+i+1:]
+	}
+	return key
+}
+
+func (c *tenantCache) Get(ctx context.Context, key string) ([]byte, error) {
+	return c.Cache.Get(ctx, tenantKey(ctx, key))
+}
+
+func (c *tenantCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.Cache.Set(ctx, tenantKey(ctx, key), value, ttl)
+}
+
+func (c *tenantCache) Delete(ctx context.Context, key string) error {
+	return c.Cache.Delete(ctx, tenantKey(ctx, key))
+}
+
+func (c *tenantCache) Exists(ctx context.Context, key string) (bool, error) {
+	return c.Cache.Exists(ctx, tenantKey(ctx, key))
+}
+
+// DeleteByPattern deletes the matching keys of the tenant in ctx. For the default tenant
+// the pattern is not prefixed, so "*" still matches every key of every tenant.
+func (c *tenantCache) DeleteByPattern(ctx context.Context, pattern string) error {
+	return c.Cache.DeleteByPattern(ctx, tenantKey(ctx, pattern))
+}
@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"query-service/internal/tenant"
+
+	"testsupport"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTenantCache_ScopesKeysToTheTenant(t *testing.T) {
+	kv := testsupport.NewKV()
+	c := withTenants(NewKVCache(kv, zap.NewNop()))
+	defaultCtx := context.Background()
+	acme := tenant.WithTenant(context.Background(), "acme")
+
+	require.NoError(t, c.Set(defaultCtx, "item:sku:A-1", []byte(`"default"`), time.Minute))
+	require.NoError(t, c.Set(acme, "item:sku:A-1", []byte(`"acme"`), time.Minute))
+
+	value, err := c.Get(acme, "item:sku:A-1")
+	require.NoError(t, err)
+	assert.Equal(t, `"acme"`, string(value))
+	value, err = c.Get(defaultCtx, "item:sku:A-1")
+	require.NoError(t, err)
+	assert.Equal(t, `"default"`, string(value), "the default tenant keeps the bare key")
+	assert.ElementsMatch(t, []string{"item:sku:A-1", "t:acme:item:sku:A-1"}, kv.Keys("*"))
+
+	// Invalidating one tenant leaves the other's entries
+	require.NoError(t, c.DeleteByPattern(acme, "item:*"))
+	_, err = c.Get(acme, "item:sku:A-1")
+	assert.Equal(t, ErrCacheMiss, err)
+	_, err = c.Get(defaultCtx, "item:sku:A-1")
+	assert.NoError(t, err)
+}
+
+func TestKeyspace_IgnoresTheTenantPrefix(t *testing.T) {
+	assert.Equal(t, "item", keyspace("t:acme:item:id:1"))
+	assert.Equal(t, "item", keyspace("item:id:1"))
+}
@@ -0,0 +1,244 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"query-service/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+// TieredCache keeps the hottest keys in a small in-process LRU in front of a shared
+// cache (Redis), saving a round-trip per read of very popular items.
+//
+// Keys enter the local tier only when they are read from the shared cache, so the LRU
+// fills with what is actually being requested. Writes go to the shared cache and refresh
+// a key already held locally. Delete and DeleteByPattern (used by the Kafka invalidation
+// consumer) clear both tiers. Other replicas only see their own invalidations, so local
+// entries also expire after a short TTL that bounds how stale a replica can be, unless
+// an InvalidationBus relays the invalidations to them.
+type TieredCache struct {
+	local  *lruCache
+	remote Cache
+	bus    InvalidationBus
+	logger *zap.Logger
+
+	// Local tier lookups since startup, for the admin stats
+	localHits, localMisses atomic.Uint64
+}
+
+// NewTieredCache puts an LRU of size entries, each kept at most ttl, in front of remote
+func NewTieredCache(remote Cache, size int, ttl time.Duration) *TieredCache {
+	return &TieredCache{
+		local:  newLRUCache(size, ttl),
+		remote: remote,
+		logger: zap.NewNop(),
+	}
+}
+
+// WithInvalidationBus publishes this replica's invalidations on bus; Listen applies
+// the ones published by the others
+func (c *TieredCache) WithInvalidationBus(bus InvalidationBus, logger *zap.Logger) *TieredCache {
+	c.bus = bus
+	c.logger = logger
+	return c
+}
+
+// Listen drops local entries invalidated by any replica until ctx is done
+func (c *TieredCache) Listen(ctx context.Context) {
+	if c.bus == nil {
+		return
+	}
+	for ctx.Err() == nil {
+		err := c.bus.Subscribe(ctx, c.dropLocal)
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+		c.logger.Warn("Cache invalidation subscription failed, retrying", zap.Error(err))
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func (c *TieredCache) dropLocal(invalidation Invalidation) {
+	if invalidation.Key != "" {
+		c.local.delete(invalidation.Key)
+	}
+	if invalidation.Pattern != "" {
+		c.local.deletePattern(invalidation.Pattern)
+	}
+}
+
+// broadcast relays an invalidation to the other replicas. A failure only leaves their
+// local copies to expire on their own, so it is logged rather than returned.
+func (c *TieredCache) broadcast(ctx context.Context, invalidation Invalidation) {
+	if c.bus == nil {
+		return
+	}
+	if err := c.bus.Publish(ctx, invalidation); err != nil {
+		c.logger.Warn("Failed to broadcast cache invalidation",
+			zap.String("key", invalidation.Key),
+			zap.String("pattern", invalidation.Pattern),
+			zap.Error(err),
+		)
+	}
+}
+
+func (c *TieredCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if value, ok := c.local.get(key); ok {
+		metrics.CacheRequests.WithLabelValues("hot", keyspace(key), "hit").Inc()
+		c.localHits.Add(1)
+		recordLookup(ctx, "hit")
+		return value, nil
+	}
+	metrics.CacheRequests.WithLabelValues("hot", keyspace(key), "miss").Inc()
+	c.localMisses.Add(1)
+
+	value, err := c.remote.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	c.local.set(key, value)
+	return value, nil
+}
+
+func (c *TieredCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := c.remote.Set(ctx, key, value, ttl); err != nil {
+		// Drop the local copy so it cannot outlive the value Redis failed to replace
+		c.local.delete(key)
+		return err
+	}
+	c.local.refresh(key, value)
+	return nil
+}
+
+func (c *TieredCache) Delete(ctx context.Context, key string) error {
+	c.local.delete(key)
+	err := c.remote.Delete(ctx, key)
+	c.broadcast(ctx, Invalidation{Key: key})
+	return err
+}
+
+func (c *TieredCache) Exists(ctx context.Context, key string) (bool, error) {
+	if _, ok := c.local.get(key); ok {
+		return true, nil
+	}
+	return c.remote.Exists(ctx, key)
+}
+
+func (c *TieredCache) DeleteByPattern(ctx context.Context, pattern string) error {
+	c.local.deletePattern(pattern)
+	err := c.remote.DeleteByPattern(ctx, pattern)
+	c.broadcast(ctx, Invalidation{Pattern: pattern})
+	return err
+}
+
+// lruCache is a fixed-size, concurrency-safe LRU whose entries expire after ttl
+type lruCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List // front = most recently used
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+func newLRUCache(size int, ttl time.Duration) *lruCache {
+	return &lruCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (l *lruCache) get(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	element, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*lruEntry)
+	if time.Now().After(entry.expiresAt) {
+		l.remove(element)
+		return nil, false
+	}
+	l.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// set adds or replaces a key, evicting the least recently used one when full
+func (l *lruCache) set(key string, value []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if element, ok := l.entries[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.value, entry.expiresAt = value, time.Now().Add(l.ttl)
+		l.order.MoveToFront(element)
+		return
+	}
+
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, value: value, expiresAt: time.Now().Add(l.ttl)})
+	if l.order.Len() > l.size {
+		l.remove(l.order.Back())
+	}
+}
+
+// refresh replaces the value of a key only if it is already cached
+func (l *lruCache) refresh(key string, value []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if element, ok := l.entries[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.value, entry.expiresAt = value, time.Now().Add(l.ttl)
+	}
+}
+
+func (l *lruCache) delete(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if element, ok := l.entries[key]; ok {
+		l.remove(element)
+	}
+}
+
+// deletePattern removes the keys matching a Redis-style glob ("reservations:item:x:*")
+func (l *lruCache) deletePattern(pattern string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, element := range l.entries {
+		if matched, _ := path.Match(pattern, key); matched {
+			l.remove(element)
+		}
+	}
+}
+
+func (l *lruCache) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+// remove must be called with mu held
+func (l *lruCache) remove(element *list.Element) {
+	l.order.Remove(element)
+	delete(l.entries, element.Value.(*lruEntry).key)
+}
@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"testsupport"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTieredCache_ServesHotKeysLocally(t *testing.T) {
+	kv := testsupport.NewKV()
+	c := NewTieredCache(NewKVCache(kv, zap.NewNop()), 10, time.Minute)
+	ctx := context.Background()
+
+	// Writes do not admit keys to the local tier; reads from Redis do
+	require.NoError(t, c.Set(ctx, "item:id:1", []byte("v1"), time.Minute))
+	assert.Equal(t, 0, c.local.len())
+	value, err := c.Get(ctx, "item:id:1")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(value))
+	assert.Equal(t, 1, c.local.len())
+
+	// Served from the local tier even if Redis loses the key
+	kv.Del("item:id:1")
+	value, err = c.Get(ctx, "item:id:1")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(value))
+
+	// A write refreshes the local copy
+	require.NoError(t, c.Set(ctx, "item:id:1", []byte("v2"), time.Minute))
+	value, err = c.Get(ctx, "item:id:1")
+	require.NoError(t, err)
+	assert.Equal(t, "v2", string(value))
+}
+
+func TestTieredCache_InvalidationClearsBothTiers(t *testing.T) {
+	c := NewTieredCache(NewKVCache(testsupport.NewKV(), zap.NewNop()), 10, time.Minute)
+	ctx := context.Background()
+	for _, key := range []string{"item:id:1", "reservations:item:1:all:1:20", "reservations:item:2:all:1:20"} {
+		require.NoError(t, c.Set(ctx, key, []byte("v"), time.Minute))
+		_, err := c.Get(ctx, key)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, c.Delete(ctx, "item:id:1"))
+	require.NoError(t, c.DeleteByPattern(ctx, "reservations:item:1:*"))
+
+	_, err := c.Get(ctx, "item:id:1")
+	assert.Equal(t, ErrCacheMiss, err)
+	_, err = c.Get(ctx, "reservations:item:1:all:1:20")
+	assert.Equal(t, ErrCacheMiss, err)
+	_, err = c.Get(ctx, "reservations:item:2:all:1:20")
+	assert.NoError(t, err)
+}
+
+func TestLRUCache_EvictsLeastRecentlyUsedAndExpires(t *testing.T) {
+	l := newLRUCache(2, time.Minute)
+	l.set("a", []byte("a"))
+	l.set("b", []byte("b"))
+	l.get("a") // "b" is now the least recently used
+	l.set("c", []byte("c"))
+
+	_, ok := l.get("b")
+	assert.False(t, ok)
+	_, ok = l.get("a")
+	assert.True(t, ok)
+	_, ok = l.get("c")
+	assert.True(t, ok)
+
+	expiring := newLRUCache(2, time.Millisecond)
+	expiring.set("a", []byte("a"))
+	time.Sleep(5 * time.Millisecond)
+	_, ok = expiring.get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, expiring.len())
+}
+
+// localBus delivers invalidations to every subscribed replica in-process
+type localBus struct {
+	mu       sync.Mutex
+	handlers []func(Invalidation)
+}
+
+func (b *localBus) Publish(ctx context.Context, invalidation Invalidation) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, handle := range b.handlers {
+		handle(invalidation)
+	}
+	return nil
+}
+
+func (b *localBus) Subscribe(ctx context.Context, handle func(Invalidation)) error {
+	b.mu.Lock()
+	b.handlers = append(b.handlers, handle)
+	b.mu.Unlock()
+	<-ctx.Done()
+	return nil
+}
+
+func TestTieredCache_InvalidationReachesOtherReplicas(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	kv := testsupport.NewKV()
+	bus := &localBus{}
+	consumer := NewTieredCache(NewKVCache(kv, zap.NewNop()), 10, time.Minute).WithInvalidationBus(bus, zap.NewNop())
+	other := NewTieredCache(NewKVCache(kv, zap.NewNop()), 10, time.Minute).WithInvalidationBus(bus, zap.NewNop())
+	go other.Listen(ctx)
+	require.Eventually(t, func() bool {
+		bus.mu.Lock()
+		defer bus.mu.Unlock()
+		return len(bus.handlers) == 1
+	}, time.Second, time.Millisecond)
+
+	for _, key := range []string{"item:id:1", "items:list:1:20"} {
+		require.NoError(t, other.Set(ctx, key, []byte("v"), time.Minute))
+		_, err := other.Get(ctx, key)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, other.local.len())
+
+	// Only the replica that consumed the Kafka event invalidates
+	require.NoError(t, consumer.Delete(ctx, "item:id:1"))
+	require.NoError(t, consumer.DeleteByPattern(ctx, "items:list:*"))
+
+	assert.Equal(t, 0, other.local.len())
+	_, err := other.Get(ctx, "item:id:1")
+	assert.Equal(t, ErrCacheMiss, err)
+}
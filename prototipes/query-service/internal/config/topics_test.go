@@ -0,0 +1,63 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopicFor_DedicatedTenants(t *testing.T) {
+	cfg := &Config{KafkaTopicItems: "inventory.items.{tenant}", KafkaTopicStock: "inventory.stock", KafkaTopicStores: "inventory.stores.{tenant}"}
+
+	// Without dedicated tenants every tenant has its own topic
+	assert.Equal(t, "inventory.items.acme", cfg.TopicFor(cfg.KafkaTopicItems, "acme"))
+	assert.Equal(t, "inventory.stock", cfg.TopicFor(cfg.KafkaTopicStock, "acme"), "a topic without the placeholder is shared")
+
+	cfg.KafkaDedicatedTenants = "acme, brand-b"
+	assert.Equal(t, "inventory.items.acme", cfg.TopicFor(cfg.KafkaTopicItems, "acme"))
+	assert.Equal(t, "inventory.items.shared", cfg.TopicFor(cfg.KafkaTopicItems, "default"))
+	assert.Equal(t, []string{
+		"inventory.items.shared", "inventory.items.acme", "inventory.items.brand-b",
+		"inventory.stock",
+		"inventory.stores.shared", "inventory.stores.acme", "inventory.stores.brand-b",
+	}, cfg.ListedTopics(cfg.EventTopics()...))
+}
+
+func TestMatchTopics(t *testing.T) {
+	available := []string{
+		"inventory.items.shared", "inventory.items.acme", "inventory.items.acme.retry.5m",
+		"inventory.items", "inventory.stock", "inventory_items_x", "other",
+	}
+
+	assert.Equal(t, []string{"inventory.items.acme", "inventory.items.shared", "inventory.stock"},
+		MatchTopics(available, "inventory.items.{tenant}", "inventory.stock"),
+		"the dots of the template are literal and the tenant cannot span a dot")
+}
+
+func TestValidate_TenantTopics(t *testing.T) {
+	t.Setenv("USE_KAFKA", "true")
+	t.Setenv("KAFKA_TOPIC_ITEMS", "inventory.items.{tenant}")
+
+	err := Load().Validate()
+	require.Error(t, err)
+	assert.Equal(t, []string{
+		"KAFKA_TOPIC_SUBSCRIPTION=regex is required when every tenant has its own topics ({tenant} without KAFKA_DEDICATED_TENANTS)",
+	}, err.(*ValidationError).Problems)
+
+	t.Setenv("KAFKA_DEDICATED_TENANTS", "acme")
+	assert.NoError(t, Load().Validate())
+
+	t.Setenv("KAFKA_DEDICATED_TENANTS", "")
+	t.Setenv("KAFKA_TOPIC_SUBSCRIPTION", "regex")
+	assert.NoError(t, Load().Validate())
+
+	t.Setenv("KAFKA_DEDICATED_TENANTS", "shared,Acme")
+	t.Setenv("EVENT_BUS", "rabbitmq")
+	err = Load().Validate()
+	require.Error(t, err)
+	assert.Equal(t, []string{
+		`KAFKA_DEDICATED_TENANTS: "shared" is not a valid tenant`,
+		"KAFKA_TOPIC_SUBSCRIPTION=regex requires EVENT_BUS=kafka",
+	}, err.(*ValidationError).Problems)
+}
@@ -0,0 +1,520 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// MinJWTSecretLength is the shortest JWT_SECRET accepted: HS256 needs at least 256 bits
+const MinJWTSecretLength = 32
+
+// maxPresignSeconds is the longest expiry of an S3 presigned URL
+const maxPresignSeconds = 7 * 24 * 3600
+
+// defaultJWTSecret is the placeholder JWT_SECRET of Load; it is refused in production
+const defaultJWTSecret = "your-secret-key-change-in-production-min-32-chars"
+
+// Startup check modes (STARTUP_CHECK_MODE): what a failed self-check does at startup
+const (
+	StartupCheckStrict = "strict" // refuse to start
+	StartupCheckWarn   = "warn"   // log it and start anyway
+	StartupCheckOff    = "off"    // skip the self-check
+)
+
+// ValidationError lists every problem found by Validate, so a single run shows them all
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks the values that would otherwise only fail later (on the first
+// request, the first read or a reconnect). It does not touch the network or the disk;
+// see SelfCheck.
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if err := validatePort(c.Port); err != nil {
+		add("PORT: %v", err)
+	}
+	if c.JWTSecret == "" {
+		add("JWT_SECRET is required")
+	} else if len(c.JWTSecret) < MinJWTSecretLength {
+		add("JWT_SECRET must be at least %d characters (got %d)", MinJWTSecretLength, len(c.JWTSecret))
+	} else if c.Environment == "production" && c.JWTSecret == defaultJWTSecret {
+		add("JWT_SECRET still has the default value; set a secret of your own in production")
+	}
+
+	switch c.DBDriver {
+	case "sqlite":
+		if c.SQLitePath == "" && !c.MockDependencies {
+			add("SQLITE_PATH is required with DB_DRIVER=sqlite")
+		}
+	case "postgres":
+		if c.PostgresDSN == "" {
+			add("POSTGRES_DSN is required with DB_DRIVER=postgres")
+		}
+	default:
+		add("DB_DRIVER must be sqlite or postgres (got %q)", c.DBDriver)
+	}
+	switch c.UserStore {
+	case "sqlite", "file":
+		if c.UserStorePath == "" {
+			add("USER_STORE_PATH is required")
+		}
+	default:
+		add("USER_STORE must be sqlite or file (got %q)", c.UserStore)
+	}
+	if c.APIKeyStorePath == "" {
+		add("API_KEY_STORE_PATH is required")
+	}
+	if c.TokenStore != "memory" && c.TokenStore != "redis" {
+		add("TOKEN_STORE must be memory or redis (got %q)", c.TokenStore)
+	}
+	if c.RefreshTokenTTLMinutes <= 0 {
+		add("REFRESH_TOKEN_TTL_MINUTES must be positive")
+	}
+	if c.JWTAccessTokenTTLSeconds <= 0 {
+		add("JWT_ACCESS_TOKEN_TTL_SECONDS must be positive")
+	}
+	if c.JWTClockSkewSeconds < 0 {
+		add("JWT_CLOCK_SKEW_SECONDS must not be negative")
+	}
+	if c.JWTIssuer == "" {
+		add("JWT_ISSUER is required")
+	} else if len(c.JWTTrustedIssuers) > 0 && !contains(c.JWTTrustedIssuers, c.JWTIssuer) {
+		// The service would reject its own tokens
+		add("JWT_TRUSTED_ISSUERS must include JWT_ISSUER (%s)", c.JWTIssuer)
+	}
+	if c.JWTJWKSURL != "" {
+		if parsed, err := url.Parse(c.JWTJWKSURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			add("JWT_JWKS_URL: not an http:// or https:// URL")
+		}
+		if c.JWTJWKSRefreshSeconds <= 0 {
+			add("JWT_JWKS_REFRESH_SECONDS must be positive")
+		}
+	}
+
+	if c.UseKafka {
+		switch c.EventBus {
+		case EventBusKafka:
+			for _, err := range validateBrokers(c.KafkaBrokers) {
+				add("KAFKA_BROKERS: %v", err)
+			}
+		case EventBusNATS:
+			if _, err := natsAddresses(c.NATSURL); err != nil {
+				add("NATS_URL: %v", err)
+			}
+			if c.NATSStream == "" {
+				add("NATS_STREAM is required with EVENT_BUS=nats")
+			}
+		case EventBusRabbitMQ:
+			if _, err := rabbitMQAddress(c.RabbitMQURL); err != nil {
+				add("RABBITMQ_URL: %v", err)
+			}
+			if c.RabbitMQExchange == "" {
+				add("RABBITMQ_EXCHANGE is required with EVENT_BUS=rabbitmq")
+			}
+		default:
+			add("EVENT_BUS must be kafka, nats or rabbitmq (got %q)", c.EventBus)
+		}
+		for _, setting := range [][2]string{
+			{"KAFKA_TOPIC_ITEMS", c.KafkaTopicItems},
+			{"KAFKA_TOPIC_STOCK", c.KafkaTopicStock},
+			{"KAFKA_TOPIC_STORES", c.KafkaTopicStores},
+			{"KAFKA_GROUP_ID", c.KafkaGroupID},
+		} {
+			if setting[1] == "" {
+				add("%s is required with USE_KAFKA=true", setting[0])
+			}
+		}
+		c.validateTopics(add)
+		switch c.KafkaSASLMechanism {
+		case "":
+		case "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512":
+			if c.KafkaSASLUsername == "" || c.KafkaSASLPassword == "" {
+				add("KAFKA_SASL_USERNAME and KAFKA_SASL_PASSWORD are required with KAFKA_SASL_MECHANISM=%s", c.KafkaSASLMechanism)
+			}
+		default:
+			add("KAFKA_SASL_MECHANISM must be PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512 (got %q)", c.KafkaSASLMechanism)
+		}
+		if (c.KafkaTLSCertFile == "") != (c.KafkaTLSKeyFile == "") {
+			add("KAFKA_TLS_CERT_FILE and KAFKA_TLS_KEY_FILE must be set together")
+		}
+		if !c.KafkaTLSEnabled && (c.KafkaTLSCAFile != "" || c.KafkaTLSCertFile != "") {
+			add("KAFKA_TLS_CA_FILE and KAFKA_TLS_CERT_FILE need KAFKA_TLS_ENABLED=true")
+		}
+	}
+
+	if c.UseCache && c.CacheTTL <= 0 {
+		add("CACHE_TTL must be positive")
+	}
+	if c.CacheMemoryMaxEntries <= 0 || c.CacheMemoryMaxMB <= 0 {
+		add("CACHE_MEMORY_MAX_ENTRIES and CACHE_MEMORY_MAX_MB must be positive")
+	}
+	if c.CacheTTLJitterPercent < 0 || c.CacheTTLJitterPercent > 50 {
+		add("CACHE_TTL_JITTER_PERCENT must be between 0 and 50 (got %d)", c.CacheTTLJitterPercent)
+	}
+	if c.CacheBackend == "memcached" && len(c.MemcachedServers) == 0 {
+		add("MEMCACHED_SERVERS is required with USE_CACHE=memcached")
+	}
+	if c.CachePressureEnabled {
+		if c.CachePressureLowPercent <= 0 || c.CachePressureHighPercent > 100 || c.CachePressureLowPercent >= c.CachePressureHighPercent {
+			add("CACHE_PRESSURE_LOW_PERCENT and CACHE_PRESSURE_HIGH_PERCENT must satisfy 0 < low < high <= 100 (got %d and %d)",
+				c.CachePressureLowPercent, c.CachePressureHighPercent)
+		}
+		if c.CachePressureTTLPercent <= 0 || c.CachePressureTTLPercent > 100 {
+			add("CACHE_PRESSURE_TTL_PERCENT must be between 1 and 100 (got %d)", c.CachePressureTTLPercent)
+		}
+	}
+	if c.UseCache && c.StatsCacheTTL <= 0 {
+		add("STATS_CACHE_TTL must be positive")
+	}
+	if c.LowStockThreshold < 0 {
+		add("LOW_STOCK_THRESHOLD must not be negative")
+	}
+	if c.GzipMinSizeBytes < 0 {
+		add("GZIP_MIN_SIZE_BYTES must not be negative")
+	}
+	if c.ShadowReadsEnabled {
+		if c.ShadowPostgresDSN == "" {
+			add("SHADOW_POSTGRES_DSN is required with SHADOW_READS_ENABLED=true")
+		}
+		if c.ShadowSamplePct < 0 || c.ShadowSamplePct > 100 {
+			add("SHADOW_SAMPLE_PERCENT must be between 0 and 100 (got %d)", c.ShadowSamplePct)
+		}
+	}
+	if c.ProbeEnabled && c.ProbeCommandURL == "" {
+		add("PROBE_COMMAND_URL is required with PROBE_ENABLED=true")
+	}
+
+	if c.MaxRequestBodyBytes <= 0 {
+		add("MAX_REQUEST_BODY_BYTES must be positive")
+	}
+	if c.HTTPReadTimeoutSeconds < 0 || c.HTTPWriteTimeoutSeconds < 0 || c.HTTPIdleTimeoutSeconds < 0 || c.HandlerTimeoutSeconds < 0 {
+		add("HTTP_READ_TIMEOUT_SECONDS, HTTP_WRITE_TIMEOUT_SECONDS, HTTP_IDLE_TIMEOUT_SECONDS and HANDLER_TIMEOUT_SECONDS cannot be negative")
+	} else if c.HTTPWriteTimeoutSeconds > 0 && (c.HandlerTimeoutSeconds == 0 || c.HandlerTimeoutSeconds >= c.HTTPWriteTimeoutSeconds) {
+		// Otherwise the connection is cut before the handler can answer 504
+		add("HANDLER_TIMEOUT_SECONDS must be shorter than HTTP_WRITE_TIMEOUT_SECONDS (got %d and %d)", c.HandlerTimeoutSeconds, c.HTTPWriteTimeoutSeconds)
+	}
+	if c.HealthCheckTimeoutMs <= 0 {
+		add("HEALTH_CHECK_TIMEOUT_MS must be positive")
+	}
+	if c.HealthFailureThreshold < 1 {
+		add("HEALTH_FAILURE_THRESHOLD must be at least 1")
+	}
+	if c.SLOAvailabilityTarget <= 0 || c.SLOAvailabilityTarget >= 1 {
+		add("SLO_AVAILABILITY_TARGET must be between 0 and 1 (got %v)", c.SLOAvailabilityTarget)
+	}
+	if c.SLOLatencyTarget <= 0 || c.SLOLatencyTarget >= 1 {
+		add("SLO_LATENCY_TARGET must be between 0 and 1 (got %v)", c.SLOLatencyTarget)
+	}
+	switch c.StartupCheckMode {
+	case StartupCheckStrict, StartupCheckWarn, StartupCheckOff:
+	default:
+		add("STARTUP_CHECK_MODE must be strict, warn or off (got %q)", c.StartupCheckMode)
+	}
+	c.validateImages(add)
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// SelfCheck probes what the configuration points at: that a Kafka broker (or the NATS
+// or RabbitMQ server of EVENT_BUS) accepts connections and the Kafka TLS files can be
+// read (with USE_KAFKA), that the SQLite read model exists and that the SQLite user and API key stores can be written. Mock mode
+// has none of them.
+func (c *Config) SelfCheck(ctx context.Context, timeout time.Duration) []CheckResult {
+	if c.MockDependencies {
+		return nil
+	}
+	var results []CheckResult
+	if c.UseKafka {
+		results = append(results, c.probeEventBus(ctx, timeout))
+		if c.EventBus == EventBusKafka && c.KafkaTLSEnabled {
+			for _, file := range []string{c.KafkaTLSCAFile, c.KafkaTLSCertFile, c.KafkaTLSKeyFile} {
+				if file != "" {
+					_, err := os.ReadFile(file)
+					results = append(results, CheckResult{Name: "kafka tls file " + file, Err: err})
+				}
+			}
+		}
+	}
+	if c.DBDriver == "sqlite" {
+		results = append(results, CheckResult{Name: "read model " + c.SQLitePath, Err: probeReadable(c.SQLitePath)})
+	}
+	if c.UserStore == "sqlite" { // a "file" user store is only read
+		results = append(results, CheckResult{Name: "user store " + c.UserStorePath, Err: probeWritable(c.UserStorePath)})
+	}
+	results = append(results, CheckResult{Name: "api key store " + c.APIKeyStorePath, Err: probeWritable(c.APIKeyStorePath)})
+	return results
+}
+
+// CheckResult is the outcome of one self-check probe
+type CheckResult struct {
+	Name string
+	Err  error
+}
+
+// RunStartupChecks runs SelfCheck as set by STARTUP_CHECK_MODE, logging each failed
+// probe. In strict mode it returns an error if any failed.
+func (c *Config) RunStartupChecks(ctx context.Context, logger *zap.Logger) error {
+	if c.StartupCheckMode == StartupCheckOff {
+		return nil
+	}
+	var failed []string
+	for _, result := range c.SelfCheck(ctx, time.Duration(c.HealthCheckTimeoutMs)*time.Millisecond) {
+		if result.Err == nil {
+			continue
+		}
+		failed = append(failed, result.Name)
+		if c.StartupCheckMode == StartupCheckStrict {
+			logger.Error("Startup check failed", zap.String("check", result.Name), zap.Error(result.Err))
+		} else {
+			logger.Warn("Startup check failed, starting anyway (STARTUP_CHECK_MODE=warn)", zap.String("check", result.Name), zap.Error(result.Err))
+		}
+	}
+	if len(failed) > 0 && c.StartupCheckMode == StartupCheckStrict {
+		return fmt.Errorf("startup checks failed: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// Check runs Validate and SelfCheck for --check-config, writing one line per check to
+// w. It returns false if anything failed.
+func (c *Config) Check(ctx context.Context, w io.Writer, timeout time.Duration) bool {
+	if err := c.Validate(); err != nil {
+		fmt.Fprintln(w, "FAIL configuration")
+		for _, problem := range err.(*ValidationError).Problems {
+			fmt.Fprintln(w, "     - "+problem)
+		}
+		return false
+	}
+	fmt.Fprintln(w, "OK   configuration")
+
+	ok := true
+	for _, result := range c.SelfCheck(ctx, timeout) {
+		if result.Err != nil {
+			fmt.Fprintf(w, "FAIL %s: %v\n", result.Name, result.Err)
+			ok = false
+			continue
+		}
+		fmt.Fprintf(w, "OK   %s\n", result.Name)
+	}
+	return ok
+}
+
+// validateImages checks IMAGE_STORE and, with IMAGE_SIGNED_URLS=true, what signing
+// the URLs of that store needs
+func (c *Config) validateImages(add func(format string, args ...interface{})) {
+	switch c.ImageStore {
+	case ImageStoreLocal:
+		if c.ImageLocalDir == "" {
+			add("IMAGE_LOCAL_DIR is required with IMAGE_STORE=local")
+		}
+		if c.ImageSignedURLs && len(c.ImageSigningKey) < MinJWTSecretLength {
+			add("IMAGE_SIGNING_KEY must be at least %d characters with IMAGE_SIGNED_URLS=true", MinJWTSecretLength)
+		}
+	case ImageStoreS3:
+		if !c.ImageSignedURLs {
+			break
+		}
+		if endpoint, err := url.Parse(c.S3Endpoint); err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+			add("S3_ENDPOINT must be an http(s) URL with IMAGE_SIGNED_URLS=true (got %q)", c.S3Endpoint)
+		}
+		if c.S3Bucket == "" || c.S3AccessKeyID == "" || c.S3SecretAccessKey == "" {
+			add("S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required with IMAGE_SIGNED_URLS=true")
+		}
+		if c.ImageURLTTLSeconds > maxPresignSeconds {
+			add("IMAGE_URL_TTL_SECONDS cannot exceed %d (7 days) with IMAGE_STORE=s3", maxPresignSeconds)
+		}
+	default:
+		add("IMAGE_STORE must be local or s3 (got %q)", c.ImageStore)
+	}
+	if c.ImageSignedURLs && c.ImageURLTTLSeconds <= c.CacheTTL {
+		// Cached item responses hold signed URLs, which must outlive them
+		add("IMAGE_URL_TTL_SECONDS must be longer than CACHE_TTL with IMAGE_SIGNED_URLS=true (got %d and %d)", c.ImageURLTTLSeconds, c.CacheTTL)
+	}
+}
+
+func validatePort(port string) error {
+	value, err := strconv.Atoi(port)
+	if err != nil || value < 1 || value > 65535 {
+		return fmt.Errorf("%q is not a port number", port)
+	}
+	return nil
+}
+
+// contains reports whether values has value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func validateBrokers(brokers []string) []error {
+	var errs []error
+	count := 0
+	for _, broker := range brokers {
+		if broker == "" {
+			continue
+		}
+		count++
+		_, port, err := net.SplitHostPort(broker)
+		if err == nil {
+			err = validatePort(port)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%q is not host:port", broker))
+		}
+	}
+	if count == 0 {
+		errs = append(errs, fmt.Errorf("at least one broker is required"))
+	}
+	return errs
+}
+
+// probeBrokers succeeds if any broker accepts a TCP connection: the client only needs
+// one to bootstrap
+func probeBrokers(ctx context.Context, brokers []string, timeout time.Duration) error {
+	dialer := net.Dialer{Timeout: timeout}
+	var failures []string
+	for _, broker := range brokers {
+		conn, err := dialer.DialContext(ctx, "tcp", broker)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		failures = append(failures, err.Error())
+	}
+	return fmt.Errorf("no broker reachable: %s", strings.Join(failures, "; "))
+}
+
+// probeEventBus checks that a server of the event bus accepts a TCP connection
+func (c *Config) probeEventBus(ctx context.Context, timeout time.Duration) CheckResult {
+	switch c.EventBus {
+	case EventBusNATS:
+		addresses, err := natsAddresses(c.NATSURL)
+		if err == nil {
+			err = probeBrokers(ctx, addresses, timeout)
+		}
+		return CheckResult{Name: "nats server", Err: err}
+	case EventBusRabbitMQ:
+		address, err := rabbitMQAddress(c.RabbitMQURL)
+		if err == nil {
+			err = probeBrokers(ctx, []string{address}, timeout)
+		}
+		return CheckResult{Name: "rabbitmq server", Err: err}
+	}
+	return CheckResult{Name: "kafka brokers", Err: probeBrokers(ctx, c.KafkaBrokers, timeout)}
+}
+
+// natsAddresses returns the host:port of every server of a NATS_URL
+// ("nats://host:4222,nats://other:4222"; the port defaults to 4222)
+func natsAddresses(natsURL string) ([]string, error) {
+	var addresses []string
+	for _, server := range strings.Split(natsURL, ",") {
+		server = strings.TrimSpace(server)
+		if server == "" {
+			continue
+		}
+		if !strings.Contains(server, "://") {
+			server = "nats://" + server
+		}
+		parsed, err := url.Parse(server)
+		if err != nil || parsed.Hostname() == "" {
+			return nil, fmt.Errorf("not a NATS server URL") // the URL may hold credentials
+		}
+		port := parsed.Port()
+		if port == "" {
+			port = "4222"
+		}
+		addresses = append(addresses, net.JoinHostPort(parsed.Hostname(), port))
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("at least one server is required")
+	}
+	return addresses, nil
+}
+
+// rabbitMQAddress returns the host:port of an amqp:// or amqps:// RABBITMQ_URL
+func rabbitMQAddress(rabbitURL string) (string, error) {
+	parsed, err := url.Parse(rabbitURL)
+	if err != nil || parsed.Hostname() == "" {
+		return "", fmt.Errorf("not an AMQP URL") // the URL may hold credentials
+	}
+	port := parsed.Port()
+	switch {
+	case parsed.Scheme == "amqp" && port == "":
+		port = "5672"
+	case parsed.Scheme == "amqps" && port == "":
+		port = "5671"
+	case parsed.Scheme != "amqp" && parsed.Scheme != "amqps":
+		return "", fmt.Errorf("not an amqp:// or amqps:// URL")
+	}
+	return net.JoinHostPort(parsed.Hostname(), port), nil
+}
+
+// probeWritable checks that path (a SQLite file, or a DSN like "file:x?mode=memory")
+// can be written: an existing file is opened for writing, otherwise a temporary file is
+// created in its directory, where SQLite also keeps its journal
+func probeWritable(path string) error {
+	if strings.HasPrefix(path, "file:") && strings.Contains(path, "mode=memory") {
+		return nil
+	}
+	path = strings.TrimPrefix(path, "file:")
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory", path)
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return fmt.Errorf("not writable: %w", err)
+		}
+		return f.Close()
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".check-config-*")
+	if err != nil {
+		return fmt.Errorf("directory not writable: %w", err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// probeReadable checks that the read model file exists and can be read: it is opened
+// read-only and created by the Listener Service
+func probeReadable(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%s does not exist (the Listener Service creates it)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("not readable: %w", err)
+	}
+	return f.Close()
+}
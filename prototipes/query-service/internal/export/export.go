@@ -0,0 +1,291 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"query-service/internal/models"
+)
+
+// Format is an inventory export file format
+type Format string
+
+const (
+	CSV  Format = "csv"
+	JSON Format = "json"
+	XLSX Format = "xlsx"
+)
+
+// ParseFormat parses an export format name (case insensitive)
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(strings.ToLower(strings.TrimSpace(value))); format {
+	case CSV, JSON, XLSX:
+		return format, nil
+	}
+	return "", fmt.Errorf("invalid export format %q (use csv, xlsx or json)", value)
+}
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case JSON:
+		return "application/json"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// columns are the exported fields, in file order
+var columns = []string{"id", "sku", "name", "description", "quantity", "reserved", "available", "created_at", "updated_at", "deleted_at"}
+
+// Writer writes an inventory export one item at a time
+type Writer interface {
+	Write(item models.InventoryItem) error
+	// Flush sends the items written so far to the underlying writer
+	Flush() error
+	// Close finishes the document and flushes it; it does not close the underlying writer
+	Close() error
+}
+
+// NewWriter creates a writer of the given format on w
+func NewWriter(format Format, w io.Writer) Writer {
+	buf := bufio.NewWriter(w)
+	switch format {
+	case JSON:
+		return &jsonWriter{buf: buf}
+	case XLSX:
+		return &xlsxWriter{buf: buf, zip: zip.NewWriter(buf)}
+	}
+	return &csvWriter{buf: buf, csv: csv.NewWriter(buf)}
+}
+
+// record returns the item's values in column order
+func record(item models.InventoryItem) []string {
+	deletedAt := ""
+	if item.DeletedAt != nil {
+		deletedAt = item.DeletedAt.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		item.ID,
+		item.SKU,
+		item.Name,
+		item.Description,
+		strconv.Itoa(item.Quantity),
+		strconv.Itoa(item.Reserved),
+		strconv.Itoa(item.Available),
+		item.CreatedAt.UTC().Format(time.RFC3339),
+		item.UpdatedAt.UTC().Format(time.RFC3339),
+		deletedAt,
+	}
+}
+
+type csvWriter struct {
+	buf    *bufio.Writer
+	csv    *csv.Writer
+	header bool
+}
+
+func (w *csvWriter) Write(item models.InventoryItem) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	return w.csv.Write(record(item))
+}
+
+func (w *csvWriter) writeHeader() error {
+	if w.header {
+		return nil
+	}
+	w.header = true
+	return w.csv.Write(columns)
+}
+
+func (w *csvWriter) Flush() error {
+	w.csv.Flush()
+	if err := w.csv.Error(); err != nil {
+		return err
+	}
+	return w.buf.Flush()
+}
+
+func (w *csvWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// jsonItem is the JSON representation of an exported item
+type jsonItem struct {
+	ID          string     `json:"id"`
+	SKU         string     `json:"sku"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Quantity    int        `json:"quantity"`
+	Reserved    int        `json:"reserved"`
+	Available   int        `json:"available"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+// jsonWriter writes a JSON array, one element per item
+type jsonWriter struct {
+	buf   *bufio.Writer
+	count int
+}
+
+func (w *jsonWriter) Write(item models.InventoryItem) error {
+	data, err := json.Marshal(jsonItem{
+		ID:          item.ID,
+		SKU:         item.SKU,
+		Name:        item.Name,
+		Description: item.Description,
+		Quantity:    item.Quantity,
+		Reserved:    item.Reserved,
+		Available:   item.Available,
+		CreatedAt:   item.CreatedAt.UTC(),
+		UpdatedAt:   item.UpdatedAt.UTC(),
+		DeletedAt:   item.DeletedAt,
+	})
+	if err != nil {
+		return err
+	}
+	separator := ",\n"
+	if w.count == 0 {
+		separator = "[\n"
+	}
+	w.count++
+	if _, err := w.buf.WriteString(separator); err != nil {
+		return err
+	}
+	_, err = w.buf.Write(data)
+	return err
+}
+
+func (w *jsonWriter) Flush() error {
+	return w.buf.Flush()
+}
+
+func (w *jsonWriter) Close() error {
+	end := "\n]\n"
+	if w.count == 0 {
+		end = "[]\n"
+	}
+	if _, err := w.buf.WriteString(end); err != nil {
+		return err
+	}
+	return w.buf.Flush()
+}
+
+// The fixed parts of a single-sheet workbook; the sheet itself is streamed
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Inventory" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+// numericColumns are written as numbers instead of text cells
+var numericColumns = map[int]bool{4: true, 5: true, 6: true}
+
+// xlsxWriter writes a workbook with a single sheet. The zip entries are written in
+// order, so the sheet is compressed and sent as the items arrive.
+type xlsxWriter struct {
+	buf   *bufio.Writer
+	zip   *zip.Writer
+	sheet io.Writer
+}
+
+func (w *xlsxWriter) Write(item models.InventoryItem) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	return w.writeRow(record(item), numericColumns)
+}
+
+// start writes the workbook parts and opens the sheet with its header row
+func (w *xlsxWriter) start() error {
+	if w.sheet != nil {
+		return nil
+	}
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range parts {
+		f, err := w.zip.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+	sheet, err := w.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	w.sheet = sheet
+	if _, err := io.WriteString(w.sheet, xlsxSheetStart); err != nil {
+		return err
+	}
+	return w.writeRow(columns, nil)
+}
+
+func (w *xlsxWriter) writeRow(values []string, numeric map[int]bool) error {
+	var row strings.Builder
+	row.WriteString("<row>")
+	for i, value := range values {
+		if numeric[i] {
+			row.WriteString(`<c t="n"><v>` + value + `</v></c>`)
+			continue
+		}
+		row.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+		if err := xml.EscapeText(&row, []byte(value)); err != nil {
+			return err
+		}
+		row.WriteString(`</t></is></c>`)
+	}
+	row.WriteString("</row>")
+	_, err := io.WriteString(w.sheet, row.String())
+	return err
+}
+
+func (w *xlsxWriter) Flush() error {
+	if err := w.zip.Flush(); err != nil {
+		return err
+	}
+	return w.buf.Flush()
+}
+
+func (w *xlsxWriter) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w.sheet, xlsxSheetEnd); err != nil {
+		return err
+	}
+	if err := w.zip.Close(); err != nil {
+		return err
+	}
+	return w.buf.Flush()
+}
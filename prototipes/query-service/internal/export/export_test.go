@@ -0,0 +1,93 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"query-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleItems() []models.InventoryItem {
+	at := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	return []models.InventoryItem{
+		{ID: "item-1", SKU: "SKU-001", Name: "Laptop, 15\"", Quantity: 10, Reserved: 2, Available: 8, CreatedAt: at, UpdatedAt: at},
+		{ID: "item-2", SKU: "SKU-002", Name: "Mouse <USB> & cable", Quantity: 5, Available: 5, CreatedAt: at, UpdatedAt: at},
+	}
+}
+
+func writeAll(t *testing.T, format Format, items []models.InventoryItem) []byte {
+	var out bytes.Buffer
+	w := NewWriter(format, &out)
+	for _, item := range items {
+		require.NoError(t, w.Write(item))
+	}
+	require.NoError(t, w.Close())
+	return out.Bytes()
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat(" XLSX ")
+	require.NoError(t, err)
+	assert.Equal(t, XLSX, format)
+
+	_, err = ParseFormat("pdf")
+	assert.Error(t, err)
+}
+
+func TestWriter_CSV(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewReader(writeAll(t, CSV, sampleItems()))).ReadAll()
+	require.NoError(t, err)
+
+	require.Len(t, records, 3)
+	assert.Equal(t, columns, records[0])
+	assert.Equal(t, "Laptop, 15\"", records[1][2])
+	assert.Equal(t, "8", records[1][6])
+	assert.Equal(t, "2024-01-15T10:30:00Z", records[1][7])
+}
+
+func TestWriter_JSON(t *testing.T) {
+	var items []map[string]interface{}
+	require.NoError(t, json.Unmarshal(writeAll(t, JSON, sampleItems()), &items))
+
+	require.Len(t, items, 2)
+	assert.Equal(t, "SKU-002", items[1]["sku"])
+	assert.Equal(t, float64(5), items[1]["available"])
+	assert.NotContains(t, items[0], "deleted_at")
+
+	var empty []map[string]interface{}
+	require.NoError(t, json.Unmarshal(writeAll(t, JSON, nil), &empty))
+	assert.Empty(t, empty)
+}
+
+func TestWriter_XLSX(t *testing.T) {
+	data := writeAll(t, XLSX, sampleItems())
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	names := make([]string, 0, len(archive.File))
+	var sheet []byte
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, err := f.Open()
+			require.NoError(t, err)
+			sheet, err = io.ReadAll(rc)
+			require.NoError(t, err)
+			rc.Close()
+		}
+	}
+
+	assert.Contains(t, names, "[Content_Types].xml")
+	assert.Contains(t, names, "xl/workbook.xml")
+	assert.Contains(t, string(sheet), `<t xml:space="preserve">Mouse &lt;USB&gt; &amp; cable</t>`)
+	assert.Contains(t, string(sheet), `<c t="n"><v>8</v></c>`)
+	assert.Equal(t, 3, bytes.Count(sheet, []byte("<row>")))
+}
@@ -0,0 +1,138 @@
+package forecast
+
+import (
+	"math"
+	"time"
+
+	"query-service/internal/models"
+)
+
+const day = 24 * time.Hour
+
+// Params controls the forecast
+type Params struct {
+	WindowDays  int     // Days of history used to measure consumption velocity
+	HorizonDays int     // Days projected forward
+	Confidence  float64 // Probability (0.5-1) that stock lasts at least until the conservative date
+}
+
+// DefaultParams returns the parameters used when the request does not set them
+func DefaultParams() Params {
+	return Params{WindowDays: 30, HorizonDays: 30, Confidence: 0.95}
+}
+
+// Compute projects when an item's available stock runs out.
+//
+// Consumption is the stock that left the inventory (negative quantity changes),
+// bucketed per day over the window. Its moving average is the expected daily
+// demand; its standard deviation widens the conservative estimate, treating
+// demand over t days as normal with mean t*avg and deviation sqrt(t)*std.
+func Compute(item models.InventoryItem, movements []models.StockMovement, params Params, now time.Time) models.StockForecast {
+	now = now.UTC()
+	daily := dailyConsumption(movements, params.WindowDays, now)
+	mean, stdDev := meanStdDev(daily)
+	z := zScore(params.Confidence)
+
+	result := models.StockForecast{
+		ItemID:                  item.ID,
+		SKU:                     item.SKU,
+		Available:               item.Available,
+		WindowDays:              params.WindowDays,
+		HorizonDays:             params.HorizonDays,
+		Confidence:              params.Confidence,
+		AverageDailyConsumption: round(mean),
+		ConsumptionStdDev:       round(stdDev),
+		Projection:              make([]models.ForecastPoint, 0, params.HorizonDays),
+		GeneratedAt:             now,
+	}
+
+	available := float64(item.Available)
+	if available <= 0 {
+		zero := 0.0
+		result.DaysUntilStockout = &zero
+		result.DaysUntilStockoutAtConfidence = &zero
+		result.ProjectedStockoutDate = &now
+		result.ConservativeStockoutDate = &now
+		result.StockoutWithinHorizon = true
+	} else if mean > 0 {
+		expected := available / mean
+		// Solve mean*x^2 + z*std*x - available = 0 for x = sqrt(t)
+		x := (-z*stdDev + math.Sqrt(z*z*stdDev*stdDev+4*mean*available)) / (2 * mean)
+		conservative := x * x
+
+		expectedRounded, conservativeRounded := round(expected), round(conservative)
+		expectedDate := now.Add(time.Duration(expected * float64(day)))
+		conservativeDate := now.Add(time.Duration(conservative * float64(day)))
+
+		result.DaysUntilStockout = &expectedRounded
+		result.DaysUntilStockoutAtConfidence = &conservativeRounded
+		result.ProjectedStockoutDate = &expectedDate
+		result.ConservativeStockoutDate = &conservativeDate
+		result.StockoutWithinHorizon = conservative <= float64(params.HorizonDays)
+	}
+
+	for t := 1; t <= params.HorizonDays; t++ {
+		demand := float64(t) * mean
+		upperDemand := demand + z*stdDev*math.Sqrt(float64(t))
+		result.Projection = append(result.Projection, models.ForecastPoint{
+			Date:                now.Add(time.Duration(t) * day).Format("2006-01-02"),
+			ExpectedAvailable:   clampStock(available - demand),
+			LowerBoundAvailable: clampStock(available - upperDemand),
+		})
+	}
+
+	return result
+}
+
+// dailyConsumption returns the units consumed on each of the last windowDays days
+// (oldest first), including days without movements
+func dailyConsumption(movements []models.StockMovement, windowDays int, now time.Time) []float64 {
+	daily := make([]float64, windowDays)
+	start := now.Add(-time.Duration(windowDays) * day)
+	for _, movement := range movements {
+		if movement.QuantityChange >= 0 || movement.OccurredAt.Before(start) || movement.OccurredAt.After(now) {
+			continue
+		}
+		index := int(movement.OccurredAt.Sub(start) / day)
+		if index >= windowDays {
+			index = windowDays - 1
+		}
+		daily[index] += float64(-movement.QuantityChange)
+	}
+	return daily
+}
+
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(values))
+
+	return mean, math.Sqrt(variance)
+}
+
+// zScore returns the one-sided standard normal quantile for the confidence level
+func zScore(confidence float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*confidence-1)
+}
+
+func clampStock(value float64) int {
+	if value <= 0 {
+		return 0
+	}
+	return int(math.Floor(value))
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
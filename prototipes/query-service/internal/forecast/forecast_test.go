@@ -0,0 +1,73 @@
+package forecast
+
+import (
+	"testing"
+	"time"
+
+	"query-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+
+// movementsPerDay creates one outgoing movement per day over the last days
+func movementsPerDay(days int, units func(day int) int) []models.StockMovement {
+	movements := make([]models.StockMovement, 0, days)
+	for d := 0; d < days; d++ {
+		movements = append(movements, models.StockMovement{
+			QuantityChange: -units(d),
+			OccurredAt:     now.Add(-time.Duration(days-d)*24*time.Hour + time.Hour),
+		})
+	}
+	return movements
+}
+
+func TestCompute_ConstantDemand(t *testing.T) {
+	item := models.InventoryItem{ID: "item-1", SKU: "SKU-1", Quantity: 120, Available: 100}
+	movements := movementsPerDay(10, func(int) int { return 5 })
+	// Receipts and reservations are not consumption
+	movements = append(movements, models.StockMovement{QuantityChange: 50, OccurredAt: now.Add(-time.Hour)})
+
+	result := Compute(item, movements, Params{WindowDays: 10, HorizonDays: 30, Confidence: 0.95}, now)
+
+	assert.Equal(t, 5.0, result.AverageDailyConsumption)
+	assert.Equal(t, 0.0, result.ConsumptionStdDev)
+	require.NotNil(t, result.DaysUntilStockout)
+	assert.Equal(t, 20.0, *result.DaysUntilStockout)
+	assert.Equal(t, 20.0, *result.DaysUntilStockoutAtConfidence)
+	assert.True(t, result.StockoutWithinHorizon)
+	require.Len(t, result.Projection, 30)
+	assert.Equal(t, 95, result.Projection[0].ExpectedAvailable)
+	assert.Equal(t, 0, result.Projection[29].ExpectedAvailable)
+}
+
+func TestCompute_ConfidenceIsMoreConservative(t *testing.T) {
+	item := models.InventoryItem{ID: "item-1", Available: 100}
+	movements := movementsPerDay(10, func(day int) int {
+		if day%2 == 0 {
+			return 2
+		}
+		return 8
+	})
+
+	result := Compute(item, movements, Params{WindowDays: 10, HorizonDays: 10, Confidence: 0.95}, now)
+
+	require.NotNil(t, result.DaysUntilStockout)
+	assert.Equal(t, 20.0, *result.DaysUntilStockout)
+	assert.Less(t, *result.DaysUntilStockoutAtConfidence, *result.DaysUntilStockout)
+	assert.False(t, result.StockoutWithinHorizon)
+	assert.LessOrEqual(t, result.Projection[9].LowerBoundAvailable, result.Projection[9].ExpectedAvailable)
+}
+
+func TestCompute_NoConsumption(t *testing.T) {
+	item := models.InventoryItem{ID: "item-1", Available: 10}
+
+	result := Compute(item, nil, DefaultParams(), now)
+
+	assert.Nil(t, result.DaysUntilStockout)
+	assert.Nil(t, result.ProjectedStockoutDate)
+	assert.False(t, result.StockoutWithinHorizon)
+	assert.Equal(t, 10, result.Projection[0].ExpectedAvailable)
+}
@@ -0,0 +1,84 @@
+// Package graphqlapi serves the read model through GraphQL, so a screen that needs an
+// item, its stock and its reservations gets them in one round trip instead of several
+// REST calls.
+package graphqlapi
+
+import (
+	"net/http"
+
+	"query-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"go.uber.org/zap"
+)
+
+// Request is a GraphQL request, as sent in a POST body
+type Request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+}
+
+// Handler executes GraphQL queries against the read model
+type Handler struct {
+	logger *zap.Logger
+	schema graphql.Schema
+	items  repository.ReadRepository
+}
+
+// NewHandler builds the schema on top of the read repositories. reservations may be
+// nil, in which case the reservation fields fail with an error.
+func NewHandler(logger *zap.Logger, items repository.ReadRepository, reservations repository.ReservationRepository) (*Handler, error) {
+	schema, err := newSchema(items, reservations)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{logger: logger, schema: schema, items: items}, nil
+}
+
+// Serve handles GET and POST /api/v1/graphql. A POST carries a JSON Request; a GET the
+// query in the query parameter (variables are not accepted there). Queries that fail to
+// resolve still answer 200 with an "errors" list, as GraphQL clients expect; a request
+// without a query is a 400.
+// @Summary      GraphQL read API
+// @Description  Ejecuta una consulta GraphQL de solo lectura sobre el modelo de lectura: ítems (por ID, SKU o paginados), estado de stock y reservas, en una sola petición.
+// @Tags         graphql
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      Request  true  "Consulta GraphQL"
+// @Success      200      {object}  map[string]interface{}  "Resultado (data y errors)"
+// @Failure      400      {object}  map[string]interface{}  "Request inválido - falta la consulta"
+// @Failure      401      {object}  map[string]interface{}  "No autorizado - token JWT inválido o faltante"
+// @Router       /graphql [post]
+func (h *Handler) Serve(c *gin.Context) {
+	var req Request
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{"message": "invalid request body: " + err.Error()}}})
+		return
+	}
+	if req.Query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{"message": "query is required"}}})
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         h.schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		// One loader per request: its cache never outlives the query
+		Context: withLoader(c.Request.Context(), newItemLoader(h.items)),
+	})
+	if result.HasErrors() {
+		h.logger.Debug("GraphQL query returned errors",
+			zap.String("operation", req.OperationName),
+			zap.Int("errors", len(result.Errors)),
+		)
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,184 @@
+package graphqlapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// countingRepository counts the lookups that reach the read model
+type countingRepository struct {
+	*repository.InMemoryReadRepository
+	skuBatches [][]string
+	idLookups  int
+}
+
+func (r *countingRepository) FindBySKUs(ctx context.Context, skus []string) ([]models.InventoryItem, error) {
+	r.skuBatches = append(r.skuBatches, skus)
+	return r.InMemoryReadRepository.FindBySKUs(ctx, skus)
+}
+
+func (r *countingRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.InventoryItem, error) {
+	r.idLookups++
+	return r.InMemoryReadRepository.FindByID(ctx, id)
+}
+
+func setupRouter(t *testing.T) (*gin.Engine, *countingRepository, map[string]string) {
+	gin.SetMode(gin.TestMode)
+	repo := &countingRepository{InMemoryReadRepository: repository.NewInMemoryReadRepository()}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	ids := map[string]string{}
+	for i, sku := range []string{"SKU-A", "SKU-B", "SKU-C"} {
+		id := uuid.New().String()
+		ids[sku] = id
+		require.NoError(t, repo.SaveItem(models.InventoryItem{
+			ID: id, SKU: sku, Name: "Item " + sku,
+			Quantity: 10 * (i + 1), Reserved: i, Available: 10*(i+1) - i,
+			CreatedAt: now.Add(time.Duration(i) * time.Minute), UpdatedAt: now,
+		}))
+	}
+
+	storeID := uuid.New()
+	ids["store"] = storeID.String()
+	repo.SaveStore(storeID)
+	for i := 0; i < 3; i++ {
+		repo.SaveReservation(models.StoreReservation{
+			ID: uuid.New().String(), StoreID: storeID.String(), ItemID: ids["SKU-A"],
+			Quantity: 1, Status: "active",
+			ReservedAt: now.Add(time.Duration(i) * time.Second), CreatedAt: now, UpdatedAt: now,
+		})
+	}
+
+	handler, err := NewHandler(zap.NewNop(), repo, repo.InMemoryReadRepository)
+	require.NoError(t, err)
+	router := gin.New()
+	router.POST("/graphql", handler.Serve)
+	router.GET("/graphql", handler.Serve)
+	return router, repo, ids
+}
+
+type response struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func post(t *testing.T, router *gin.Engine, req Request) (int, response) {
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body)))
+
+	var resp response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestServe_OneRoundTrip(t *testing.T) {
+	router, _, ids := setupRouter(t)
+
+	code, resp := post(t, router, Request{
+		Query: `query Screen($id: ID!, $store: ID!) {
+			item(id: $id) { sku available stock { quantity reserved } reservations(status: ACTIVE) { total } }
+			items(page: 1, pageSize: 2) { total totalPages items { sku } }
+			storeReservations(storeId: $store) { total reservations { status item { sku } } }
+		}`,
+		Variables: map[string]interface{}{"id": ids["SKU-B"], "store": ids["store"]},
+	})
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, resp.Errors)
+
+	assert.JSONEq(t, `{"sku":"SKU-B","available":19,"stock":{"quantity":20,"reserved":1},"reservations":{"total":0}}`, string(resp.Data["item"]))
+	assert.JSONEq(t, `{"total":3,"totalPages":2,"items":[{"sku":"SKU-C"},{"sku":"SKU-B"}]}`, string(resp.Data["items"]))
+
+	var reservations struct {
+		Total        int
+		Reservations []struct {
+			Status string
+			Item   struct{ SKU string }
+		}
+	}
+	require.NoError(t, json.Unmarshal(resp.Data["storeReservations"], &reservations))
+	assert.Equal(t, 3, reservations.Total)
+	require.Len(t, reservations.Reservations, 3)
+	assert.Equal(t, "ACTIVE", reservations.Reservations[0].Status)
+	assert.Equal(t, "SKU-A", reservations.Reservations[0].Item.SKU)
+}
+
+func TestServe_BatchesSKULookups(t *testing.T) {
+	router, repo, _ := setupRouter(t)
+
+	code, resp := post(t, router, Request{Query: `{
+		a: itemBySku(sku: "SKU-A") { id }
+		c: itemBySku(sku: "SKU-C") { id }
+		list: itemsBySku(skus: ["SKU-B", "MISSING", "SKU-A"]) { sku }
+	}`})
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, resp.Errors)
+
+	assert.JSONEq(t, `[{"sku":"SKU-B"},null,{"sku":"SKU-A"}]`, string(resp.Data["list"]))
+	require.Len(t, repo.skuBatches, 1, "every SKU should be fetched in a single lookup")
+	assert.ElementsMatch(t, []string{"SKU-A", "SKU-C", "SKU-B", "MISSING"}, repo.skuBatches[0])
+}
+
+func TestServe_DeduplicatesIDLookups(t *testing.T) {
+	router, repo, ids := setupRouter(t)
+
+	code, resp := post(t, router, Request{
+		Query:     `query($store: ID!, $id: ID!) { storeReservations(storeId: $store) { reservations { item { sku } } } item(id: $id) { sku } }`,
+		Variables: map[string]interface{}{"store": ids["store"], "id": ids["SKU-A"]},
+	})
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, resp.Errors)
+	assert.Equal(t, 1, repo.idLookups, "an item shared by several fields should be loaded once")
+}
+
+func TestServe_MissingItemIsNull(t *testing.T) {
+	router, _, _ := setupRouter(t)
+
+	code, resp := post(t, router, Request{Query: `{ item(id: "` + uuid.New().String() + `") { sku } stockStatus(id: "` + uuid.New().String() + `") { sku } }`})
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `null`, string(resp.Data["item"]))
+	assert.JSONEq(t, `null`, string(resp.Data["stockStatus"]))
+}
+
+func TestServe_Errors(t *testing.T) {
+	router, _, _ := setupRouter(t)
+
+	code, resp := post(t, router, Request{Query: `{ item(id: "not-a-uuid") { sku } }`})
+	assert.Equal(t, http.StatusOK, code)
+	require.Len(t, resp.Errors, 1)
+	assert.Contains(t, resp.Errors[0].Message, "invalid id")
+
+	code, resp = post(t, router, Request{Query: `{ items { unknownField } }`})
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotEmpty(t, resp.Errors)
+
+	code, _ = post(t, router, Request{})
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestServe_GET(t *testing.T) {
+	router, _, _ := setupRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/graphql?query=%7Bitems%7Btotal%7D%7D", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":{"items":{"total":3}}}`, w.Body.String())
+}
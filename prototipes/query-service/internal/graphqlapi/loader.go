@@ -0,0 +1,139 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"query-service/internal/models"
+	"query-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// loaderKey is the context key of the request's itemLoader
+type loaderKey struct{}
+
+func withLoader(ctx context.Context, loader *itemLoader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, loader)
+}
+
+func loaderFrom(ctx context.Context) *itemLoader {
+	loader, _ := ctx.Value(loaderKey{}).(*itemLoader)
+	return loader
+}
+
+// itemResult is the outcome of one item lookup; a nil item with no error is a miss
+type itemResult struct {
+	item *models.InventoryItem
+	err  error
+	done bool
+}
+
+// itemLoader batches and caches the item lookups of a single GraphQL request. Resolvers
+// only register the key they need and return a thunk; graphql-go resolves the thunks of
+// a level once every field of that level has been visited, so the first thunk fetches
+// every SKU queued so far with one FindBySKUs call. IDs are looked up one at a time:
+// they are deduplicated and cached, and SKU results prime that cache.
+type itemLoader struct {
+	repo repository.ReadRepository
+
+	mu          sync.Mutex
+	bySKU       map[string]*itemResult
+	byID        map[string]*itemResult
+	pendingSKUs []string
+}
+
+func newItemLoader(repo repository.ReadRepository) *itemLoader {
+	return &itemLoader{
+		repo:  repo,
+		bySKU: make(map[string]*itemResult),
+		byID:  make(map[string]*itemResult),
+	}
+}
+
+// loadBySKU queues sku for the next batch and returns a thunk yielding its item
+func (l *itemLoader) loadBySKU(ctx context.Context, sku string) func() (interface{}, error) {
+	l.mu.Lock()
+	result, ok := l.bySKU[sku]
+	if !ok {
+		result = &itemResult{}
+		l.bySKU[sku] = result
+		l.pendingSKUs = append(l.pendingSKUs, sku)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.dispatchSKUs(ctx)
+		return itemValue(result)
+	}
+}
+
+// dispatchSKUs fetches every queued SKU in a single lookup
+func (l *itemLoader) dispatchSKUs(ctx context.Context) {
+	l.mu.Lock()
+	skus := l.pendingSKUs
+	l.pendingSKUs = nil
+	l.mu.Unlock()
+	if len(skus) == 0 {
+		return
+	}
+
+	items, err := l.repo.FindBySKUs(ctx, skus)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range items {
+		item := &items[i]
+		if result, ok := l.bySKU[item.SKU]; ok && !result.done {
+			result.item = item
+		}
+		if _, ok := l.byID[item.ID]; !ok {
+			l.byID[item.ID] = &itemResult{item: item, done: true}
+		}
+	}
+	for _, sku := range skus {
+		result := l.bySKU[sku]
+		result.err = err
+		result.done = true
+	}
+}
+
+// loadByID returns a thunk yielding the item with the given id, fetched at most once
+func (l *itemLoader) loadByID(ctx context.Context, id uuid.UUID) func() (interface{}, error) {
+	key := id.String()
+	l.mu.Lock()
+	result, ok := l.byID[key]
+	if !ok {
+		result = &itemResult{}
+		l.byID[key] = result
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if !result.done {
+			item, err := l.repo.FindByID(ctx, id)
+			if err != nil && !errors.Is(err, repository.ErrItemNotFound) {
+				result.err = err
+			}
+			if err == nil {
+				result.item = item
+			}
+			result.done = true
+		}
+		return itemValue(result)
+	}
+}
+
+// itemValue turns a result into a resolver value: a missing item resolves to null
+func itemValue(result *itemResult) (interface{}, error) {
+	if result.err != nil {
+		return nil, result.err
+	}
+	if result.item == nil {
+		return nil, nil
+	}
+	return result.item, nil
+}
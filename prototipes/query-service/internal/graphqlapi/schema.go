@@ -0,0 +1,426 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+)
+
+// maxPageSize caps page sizes like the REST list endpoints do
+const maxPageSize = 100
+
+// resolvers holds the repositories the schema reads from
+type resolvers struct {
+	items        repository.ReadRepository
+	reservations repository.ReservationRepository // nil when the backend has no reservations
+}
+
+// newSchema builds the read-only schema: items (by id, by SKU, paged), their stock status
+// and store reservations. Field names are camelCase, as GraphQL clients expect.
+func newSchema(items repository.ReadRepository, reservations repository.ReservationRepository) (graphql.Schema, error) {
+	r := &resolvers{items: items, reservations: reservations}
+
+	stockStatusType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "StockStatus",
+		Description: "Stock levels of an item",
+		Fields: graphql.Fields{
+			"id":        {Type: graphql.NewNonNull(graphql.ID), Resolve: stockField(func(s *models.StockStatus) interface{} { return s.ID })},
+			"sku":       {Type: graphql.NewNonNull(graphql.String), Resolve: stockField(func(s *models.StockStatus) interface{} { return s.SKU })},
+			"quantity":  {Type: graphql.NewNonNull(graphql.Int), Resolve: stockField(func(s *models.StockStatus) interface{} { return s.Quantity })},
+			"reserved":  {Type: graphql.NewNonNull(graphql.Int), Resolve: stockField(func(s *models.StockStatus) interface{} { return s.Reserved })},
+			"available": {Type: graphql.NewNonNull(graphql.Int), Resolve: stockField(func(s *models.StockStatus) interface{} { return s.Available })},
+			"updatedAt": {Type: graphql.NewNonNull(graphql.DateTime), Resolve: stockField(func(s *models.StockStatus) interface{} { return s.UpdatedAt })},
+		},
+	})
+
+	reservationStatusType := graphql.NewEnum(graphql.EnumConfig{
+		Name: "ReservationStatus",
+		Values: graphql.EnumValueConfigMap{
+			"ACTIVE":    {Value: "active"},
+			"RELEASED":  {Value: "released"},
+			"EXPIRED":   {Value: "expired"},
+			"FULFILLED": {Value: "fulfilled"},
+		},
+	})
+
+	// Item and Reservation refer to each other: their fields are declared as thunks
+	var itemType, reservationListType *graphql.Object
+
+	reservationType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Reservation",
+		Description: "Inventory reserved by a store",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":         {Type: graphql.NewNonNull(graphql.ID), Resolve: reservationField(func(res *models.StoreReservation) interface{} { return res.ID })},
+				"storeId":    {Type: graphql.NewNonNull(graphql.ID), Resolve: reservationField(func(res *models.StoreReservation) interface{} { return res.StoreID })},
+				"itemId":     {Type: graphql.NewNonNull(graphql.ID), Resolve: reservationField(func(res *models.StoreReservation) interface{} { return res.ItemID })},
+				"quantity":   {Type: graphql.NewNonNull(graphql.Int), Resolve: reservationField(func(res *models.StoreReservation) interface{} { return res.Quantity })},
+				"status":     {Type: graphql.NewNonNull(reservationStatusType), Resolve: reservationField(func(res *models.StoreReservation) interface{} { return res.Status })},
+				"reservedAt": {Type: graphql.NewNonNull(graphql.DateTime), Resolve: reservationField(func(res *models.StoreReservation) interface{} { return res.ReservedAt })},
+				"releasedAt": {Type: graphql.DateTime, Resolve: reservationField(func(res *models.StoreReservation) interface{} { return optionalTime(res.ReleasedAt) })},
+				"expiresAt":  {Type: graphql.DateTime, Resolve: reservationField(func(res *models.StoreReservation) interface{} { return optionalTime(res.ExpiresAt) })},
+				"createdAt":  {Type: graphql.NewNonNull(graphql.DateTime), Resolve: reservationField(func(res *models.StoreReservation) interface{} { return res.CreatedAt })},
+				"updatedAt":  {Type: graphql.NewNonNull(graphql.DateTime), Resolve: reservationField(func(res *models.StoreReservation) interface{} { return res.UpdatedAt })},
+				"item": {
+					Type:        itemType,
+					Description: "The reserved item, loaded once per request however many reservations share it",
+					Resolve:     r.reservationItem,
+				},
+			}
+		}),
+	})
+
+	pagingArgs := graphql.FieldConfigArgument{
+		"page":     {Type: graphql.Int, DefaultValue: 1},
+		"pageSize": {Type: graphql.Int, DefaultValue: 10, Description: "Max 100"},
+	}
+	reservationArgs := graphql.FieldConfigArgument{
+		"status":   {Type: reservationStatusType},
+		"page":     pagingArgs["page"],
+		"pageSize": pagingArgs["pageSize"],
+	}
+
+	itemType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Item",
+		Description: "An inventory item of the read model",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":          {Type: graphql.NewNonNull(graphql.ID), Resolve: itemField(func(item *models.InventoryItem) interface{} { return item.ID })},
+				"sku":         {Type: graphql.NewNonNull(graphql.String), Resolve: itemField(func(item *models.InventoryItem) interface{} { return item.SKU })},
+				"name":        {Type: graphql.NewNonNull(graphql.String), Resolve: itemField(func(item *models.InventoryItem) interface{} { return item.Name })},
+				"description": {Type: graphql.NewNonNull(graphql.String), Resolve: itemField(func(item *models.InventoryItem) interface{} { return item.Description })},
+				"quantity":    {Type: graphql.NewNonNull(graphql.Int), Resolve: itemField(func(item *models.InventoryItem) interface{} { return item.Quantity })},
+				"reserved":    {Type: graphql.NewNonNull(graphql.Int), Resolve: itemField(func(item *models.InventoryItem) interface{} { return item.Reserved })},
+				"available":   {Type: graphql.NewNonNull(graphql.Int), Resolve: itemField(func(item *models.InventoryItem) interface{} { return item.Available })},
+				"createdAt":   {Type: graphql.NewNonNull(graphql.DateTime), Resolve: itemField(func(item *models.InventoryItem) interface{} { return item.CreatedAt })},
+				"updatedAt":   {Type: graphql.NewNonNull(graphql.DateTime), Resolve: itemField(func(item *models.InventoryItem) interface{} { return item.UpdatedAt })},
+				"stock": {
+					Type:        graphql.NewNonNull(stockStatusType),
+					Description: "Stock status of the item, taken from the item itself (no extra lookup)",
+					Resolve: itemField(func(item *models.InventoryItem) interface{} {
+						return &models.StockStatus{
+							ID:        item.ID,
+							SKU:       item.SKU,
+							Quantity:  item.Quantity,
+							Reserved:  item.Reserved,
+							Available: item.Available,
+							UpdatedAt: item.UpdatedAt,
+						}
+					}),
+				},
+				"reservations": {
+					Type:        reservationListType,
+					Description: "Reservations of the item across all stores, newest first",
+					Args:        reservationArgs,
+					Resolve:     r.itemReservations,
+				},
+			}
+		}),
+	})
+
+	itemListType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ItemList",
+		Fields: graphql.Fields{
+			"items":      {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(itemType))), Resolve: itemListField(func(l *models.ListItemsResponse) interface{} { return itemPointers(l.Items) })},
+			"total":      {Type: graphql.NewNonNull(graphql.Int), Resolve: itemListField(func(l *models.ListItemsResponse) interface{} { return l.Total })},
+			"page":       {Type: graphql.NewNonNull(graphql.Int), Resolve: itemListField(func(l *models.ListItemsResponse) interface{} { return l.Page })},
+			"pageSize":   {Type: graphql.NewNonNull(graphql.Int), Resolve: itemListField(func(l *models.ListItemsResponse) interface{} { return l.PageSize })},
+			"totalPages": {Type: graphql.NewNonNull(graphql.Int), Resolve: itemListField(func(l *models.ListItemsResponse) interface{} { return l.TotalPages })},
+		},
+	})
+
+	reservationListType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "ReservationList",
+		Fields: graphql.Fields{
+			"reservations": {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(reservationType))), Resolve: reservationListField(func(l *models.ListReservationsResponse) interface{} { return reservationPointers(l.Reservations) })},
+			"total":        {Type: graphql.NewNonNull(graphql.Int), Resolve: reservationListField(func(l *models.ListReservationsResponse) interface{} { return l.Total })},
+			"page":         {Type: graphql.NewNonNull(graphql.Int), Resolve: reservationListField(func(l *models.ListReservationsResponse) interface{} { return l.Page })},
+			"pageSize":     {Type: graphql.NewNonNull(graphql.Int), Resolve: reservationListField(func(l *models.ListReservationsResponse) interface{} { return l.PageSize })},
+			"totalPages":   {Type: graphql.NewNonNull(graphql.Int), Resolve: reservationListField(func(l *models.ListReservationsResponse) interface{} { return l.TotalPages })},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"item": {
+				Type:        itemType,
+				Description: "Item by id; null if it does not exist",
+				Args:        graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve:     r.item,
+			},
+			"itemBySku": {
+				Type:        itemType,
+				Description: "Item by SKU; null if it does not exist",
+				Args:        graphql.FieldConfigArgument{"sku": {Type: graphql.NewNonNull(graphql.String)}},
+				Resolve:     r.itemBySKU,
+			},
+			"itemsBySku": {
+				Type:        graphql.NewNonNull(graphql.NewList(itemType)),
+				Description: "Items by SKU, in the order requested; null for the SKUs that do not exist",
+				Args:        graphql.FieldConfigArgument{"skus": {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))}},
+				Resolve:     r.itemsBySKU,
+			},
+			"items": {
+				Type:        graphql.NewNonNull(itemListType),
+				Description: "Page of items, newest first",
+				Args:        pagingArgs,
+				Resolve:     r.listItems,
+			},
+			"stockStatus": {
+				Type:        stockStatusType,
+				Description: "Stock status of an item; null if it does not exist",
+				Args:        graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve:     r.stockStatus,
+			},
+			"storeReservations": {
+				Type:        reservationListType,
+				Description: "Reservations of a store, newest first; null if the store does not exist",
+				Args: graphql.FieldConfigArgument{
+					"storeId":  {Type: graphql.NewNonNull(graphql.ID)},
+					"status":   reservationArgs["status"],
+					"page":     pagingArgs["page"],
+					"pageSize": pagingArgs["pageSize"],
+				},
+				Resolve: r.storeReservations,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+func (r *resolvers) item(p graphql.ResolveParams) (interface{}, error) {
+	id, err := uuidArg(p, "id")
+	if err != nil {
+		return nil, err
+	}
+	return loaderFrom(p.Context).loadByID(p.Context, id), nil
+}
+
+func (r *resolvers) itemBySKU(p graphql.ResolveParams) (interface{}, error) {
+	sku, _ := p.Args["sku"].(string)
+	return loaderFrom(p.Context).loadBySKU(p.Context, sku), nil
+}
+
+func (r *resolvers) itemsBySKU(p graphql.ResolveParams) (interface{}, error) {
+	skus, _ := p.Args["skus"].([]interface{})
+	loader := loaderFrom(p.Context)
+	thunks := make([]func() (interface{}, error), 0, len(skus))
+	for _, sku := range skus {
+		thunks = append(thunks, loader.loadBySKU(p.Context, sku.(string)))
+	}
+	return func() (interface{}, error) {
+		items := make([]interface{}, 0, len(thunks))
+		for _, thunk := range thunks {
+			item, err := thunk()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}, nil
+}
+
+func (r *resolvers) listItems(p graphql.ResolveParams) (interface{}, error) {
+	page, pageSize := pageArgs(p)
+	items, total, err := r.items.ListItems(p.Context, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ListItemsResponse{
+		Items:      items,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages(total, pageSize),
+	}, nil
+}
+
+func (r *resolvers) stockStatus(p graphql.ResolveParams) (interface{}, error) {
+	id, err := uuidArg(p, "id")
+	if err != nil {
+		return nil, err
+	}
+	status, err := r.items.GetStockStatus(p.Context, id)
+	if errors.Is(err, repository.ErrItemNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func (r *resolvers) storeReservations(p graphql.ResolveParams) (interface{}, error) {
+	if r.reservations == nil {
+		return nil, errNoReservations
+	}
+	id, err := uuidArg(p, "storeId")
+	if err != nil {
+		return nil, err
+	}
+	return listReservations(p, id, r.reservations.ListReservationsByStore)
+}
+
+func (r *resolvers) itemReservations(p graphql.ResolveParams) (interface{}, error) {
+	if r.reservations == nil {
+		return nil, errNoReservations
+	}
+	item, ok := p.Source.(*models.InventoryItem)
+	if !ok {
+		return nil, nil
+	}
+	id, err := uuid.Parse(item.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid item id %q", item.ID)
+	}
+	return listReservations(p, id, r.reservations.ListReservationsByItem)
+}
+
+// listReservationsFunc lists the reservations of a store or an item
+type listReservationsFunc func(ctx context.Context, id uuid.UUID, status string, page, pageSize int) ([]models.StoreReservation, int, error)
+
+func listReservations(p graphql.ResolveParams, id uuid.UUID, list listReservationsFunc) (interface{}, error) {
+	status, _ := p.Args["status"].(string)
+	page, pageSize := pageArgs(p)
+	reservations, total, err := list(p.Context, id, status, page, pageSize)
+	if errors.Is(err, repository.ErrStoreNotFound) || errors.Is(err, repository.ErrItemNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &models.ListReservationsResponse{
+		Reservations: reservations,
+		Total:        total,
+		Page:         page,
+		PageSize:     pageSize,
+		TotalPages:   totalPages(total, pageSize),
+	}, nil
+}
+
+// reservationItem resolves the item of a reservation through the request's loader
+func (r *resolvers) reservationItem(p graphql.ResolveParams) (interface{}, error) {
+	res, ok := p.Source.(*models.StoreReservation)
+	if !ok {
+		return nil, nil
+	}
+	id, err := uuid.Parse(res.ItemID)
+	if err != nil {
+		return nil, nil
+	}
+	return loaderFrom(p.Context).loadByID(p.Context, id), nil
+}
+
+var errNoReservations = errors.New("reservations are not available on this read model")
+
+// uuidArg parses the UUID argument name
+func uuidArg(p graphql.ResolveParams, name string) (uuid.UUID, error) {
+	value, _ := p.Args[name].(string)
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid %s: %q is not a UUID", name, value)
+	}
+	return id, nil
+}
+
+// pageArgs returns the page arguments, normalized like the REST query parameters
+func pageArgs(p graphql.ResolveParams) (int, int) {
+	page, _ := p.Args["page"].(int)
+	pageSize, _ := p.Args["pageSize"].(int)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	return page, pageSize
+}
+
+func totalPages(total, pageSize int) int {
+	return (total + pageSize - 1) / pageSize
+}
+
+func optionalTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
+
+// The field helpers below read one field of the source value of a resolver
+
+func itemField(get func(*models.InventoryItem) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		if item, ok := p.Source.(*models.InventoryItem); ok {
+			return get(item), nil
+		}
+		return nil, nil
+	}
+}
+
+func stockField(get func(*models.StockStatus) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		if status, ok := p.Source.(*models.StockStatus); ok {
+			return get(status), nil
+		}
+		return nil, nil
+	}
+}
+
+func reservationField(get func(*models.StoreReservation) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		if res, ok := p.Source.(*models.StoreReservation); ok {
+			return get(res), nil
+		}
+		return nil, nil
+	}
+}
+
+func itemListField(get func(*models.ListItemsResponse) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		if list, ok := p.Source.(*models.ListItemsResponse); ok {
+			return get(list), nil
+		}
+		return nil, nil
+	}
+}
+
+func reservationListField(get func(*models.ListReservationsResponse) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		if list, ok := p.Source.(*models.ListReservationsResponse); ok {
+			return get(list), nil
+		}
+		return nil, nil
+	}
+}
+
+// itemPointers and reservationPointers hand list elements to the field helpers, which
+// expect pointers
+func itemPointers(items []models.InventoryItem) []*models.InventoryItem {
+	pointers := make([]*models.InventoryItem, len(items))
+	for i := range items {
+		pointers[i] = &items[i]
+	}
+	return pointers
+}
+
+func reservationPointers(reservations []models.StoreReservation) []*models.StoreReservation {
+	pointers := make([]*models.StoreReservation, len(reservations))
+	for i := range reservations {
+		pointers[i] = &reservations[i]
+	}
+	return pointers
+}
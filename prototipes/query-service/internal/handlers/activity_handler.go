@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"query-service/internal/models"
+	"query-service/internal/repository"
+	"query-service/pkg/errors"
+	"query-service/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ActivityHandler serves the operator activity feed
+type ActivityHandler struct {
+	logger *zap.Logger
+	repo   repository.ActivityRepository
+}
+
+// NewActivityHandler creates a new activity handler
+func NewActivityHandler(logger *zap.Logger, repo repository.ActivityRepository) *ActivityHandler {
+	return &ActivityHandler{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+// ActivityFeedResponse represents a page of the activity feed
+// @Description Paginated activity feed, most recent first
+type ActivityFeedResponse struct {
+	// Activity entries
+	Entries []models.ActivityEntry `json:"entries"`
+
+	// Total number of matching entries
+	Total int `json:"total" example:"42"`
+
+	// Current page number
+	Page int `json:"page" example:"1"`
+
+	// Number of entries per page
+	PageSize int `json:"page_size" example:"20"`
+
+	// Total number of pages
+	TotalPages int `json:"total_pages" example:"3"`
+}
+
+// ListActivity handles GET /api/v1/activity
+// @Summary      Recent activity feed
+// @Description  Lista los comandos recientes y su resultado (quién hizo qué sobre qué item), del más reciente al más antiguo. Lo alimenta el activity log que escribe el Listener Service al consumir cada evento.
+//
+// **Características:**
+// - `actor`: usuario del token JWT con el que se ejecutó el comando
+// - `outcome`: `applied` (aplicado al modelo de lectura) o `failed` (con el motivo en `error`)
+// - Filtros opcionales por `actor`, `item_id` y `outcome`
+// - Paginación (`page`, `page_size`, máximo 100)
+// - Sin cache: refleja el estado actual del log
+//
+// **Ejemplos válidos:**
+// - `GET /api/v1/activity`
+// - `GET /api/v1/activity?actor=operator&page_size=50`
+// - `GET /api/v1/activity?outcome=failed`
+//
+// **Ejemplos inválidos:**
+// - Outcome desconocido: `GET /api/v1/activity?outcome=pending`
+// - Item ID inválido: `GET /api/v1/activity?item_id=invalid-uuid`
+//
+// @Tags         activity
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page       query     int     false  "Page number (default: 1, min: 1)" example(1)
+// @Param        page_size  query     int     false  "Entries per page (default: 20, min: 1, max: 100)" example(20)
+// @Param        actor      query     string  false  "Filtrar por usuario" example(operator)
+// @Param        item_id    query     string  false  "Filtrar por item (UUID)"
+// @Param        outcome    query     string  false  "Filtrar por resultado (applied, failed)" example(failed)
+// @Success      200  {object}  ActivityFeedResponse  "Página del activity feed"
+// @Failure      400  {object}  ErrorResponse  "Request inválido - filtro inválido"
+// @Failure      401  {object}  ErrorResponse  "No autorizado - token JWT inválido o faltante"
+// @Failure      500  {object}  ErrorResponse  "Error interno del servidor - error de lectura"
+// @Router       /activity [get]
+func (h *ActivityHandler) ListActivity(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		middleware.AddWarning(c, "page_size capped at 100")
+		pageSize = 100
+	}
+
+	filter := models.ActivityFilter{
+		Actor:   c.Query("actor"),
+		ItemID:  c.Query("item_id"),
+		Outcome: c.Query("outcome"),
+	}
+	if filter.ItemID != "" {
+		if _, err := uuid.Parse(filter.ItemID); err != nil {
+			respondError(c, errors.NewInvalidRequest("invalid item_id", ""))
+			return
+		}
+	}
+	if filter.Outcome != "" && filter.Outcome != "applied" && filter.Outcome != "failed" {
+		respondError(c, errors.NewInvalidRequest("invalid outcome, expected applied or failed", ""))
+		return
+	}
+
+	entries, total, err := h.repo.ListActivity(c.Request.Context(), filter, page, pageSize)
+	if err != nil {
+		h.logger.Error("Failed to list activity", zap.Error(err))
+		respondError(c, errors.NewInternalError("failed to list activity", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, ActivityFeedResponse{
+		Entries:    entries,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	})
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"query-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// MockActivityRepository is a mock implementation of repository.ActivityRepository
+type MockActivityRepository struct {
+	mock.Mock
+}
+
+func (m *MockActivityRepository) ListActivity(ctx context.Context, filter models.ActivityFilter, page, pageSize int) ([]models.ActivityEntry, int, error) {
+	args := m.Called(ctx, filter, page, pageSize)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.ActivityEntry), args.Int(1), args.Error(2)
+}
+
+func setupActivityRouter(handler *ActivityHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/activity", handler.ListActivity)
+	return router
+}
+
+func TestListActivity_FiltersAndPagination(t *testing.T) {
+	mockRepo := new(MockActivityRepository)
+	router := setupActivityRouter(NewActivityHandler(zap.NewNop(), mockRepo))
+
+	itemID := uuid.New().String()
+	quantity := 5
+	filter := models.ActivityFilter{Actor: "operator", ItemID: itemID, Outcome: "applied"}
+	mockRepo.On("ListActivity", mock.Anything, filter, 2, 10).Return([]models.ActivityEntry{{
+		ID:          uuid.New().String(),
+		EventType:   "StockAdjusted",
+		ItemID:      itemID,
+		Quantity:    &quantity,
+		Actor:       "operator",
+		Outcome:     "applied",
+		OccurredAt:  time.Now(),
+		ProcessedAt: time.Now(),
+	}}, 11, nil)
+
+	req := httptest.NewRequest("GET", "/api/v1/activity?actor=operator&item_id="+itemID+"&outcome=applied&page=2&page_size=10", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response ActivityFeedResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Entries, 1)
+	assert.Equal(t, "operator", response.Entries[0].Actor)
+	assert.Equal(t, 11, response.Total)
+	assert.Equal(t, 2, response.TotalPages)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListActivity_InvalidFilters(t *testing.T) {
+	router := setupActivityRouter(NewActivityHandler(zap.NewNop(), new(MockActivityRepository)))
+
+	for _, query := range []string{"outcome=pending", "item_id=invalid-uuid"} {
+		req := httptest.NewRequest("GET", "/api/v1/activity?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
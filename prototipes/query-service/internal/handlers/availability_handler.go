@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"net/http"
+
+	"query-service/internal/cache"
+	"query-service/internal/models"
+	"query-service/internal/repository"
+	"query-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// CheckAvailability handles POST /api/v1/inventory/availability
+// @Summary      Check availability of multiple items
+// @Description  Verifica en una sola llamada si un carrito puede ser atendido. Retorna la disponibilidad de cada línea y un indicador global `fulfillable`.
+//
+// **Características:**
+// - Lectura desde cache por SKU; los SKUs no cacheados se leen en una sola consulta al repositorio
+// - Considera las reservas: `available` ya descuenta el stock reservado (reservas de tienda incluidas) y `reserved` indica cuánto está retenido
+// - Líneas repetidas del mismo SKU consumen la disponibilidad en orden
+// - Optimizado para el flujo de checkout (evita N GETs secuenciales)
+// - Las líneas que no se pueden atender traen en `substitutes` los sustitutos del item con stock suficiente
+//
+// **Ejemplos válidos:**
+// - `{"items": [{"sku": "SKU-001", "quantity": 2}, {"sku": "SKU-002", "quantity": 1}]}`
+//
+// **Ejemplos inválidos:**
+// - Lista vacía: `{"items": []}`
+// - Cantidad menor a 1: `{"items": [{"sku": "SKU-001", "quantity": 0}]}`
+// - Más de 100 líneas
+//
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      AvailabilityRequest   true  "Cart lines"
+// @Success      200      {object}  AvailabilityResponse  "Disponibilidad por línea"
+// @Failure      400      {object}  ErrorResponse         "Request inválido - líneas faltantes o cantidades inválidas"
+// @Failure      401      {object}  ErrorResponse         "No autorizado - token JWT inválido o faltante"
+// @Failure      500      {object}  ErrorResponse         "Error interno del servidor - error de lectura"
+// @Router       /inventory/availability [post]
+func (h *InventoryHandler) CheckAvailability(c *gin.Context) {
+	var req AvailabilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, errors.NewBindingError(err))
+		return
+	}
+
+	// Resolve each distinct SKU once: cache first, then a single batched lookup for misses
+	available := make(map[string]int, len(req.Items))
+	reserved := make(map[string]int, len(req.Items))
+	itemIDs := make(map[string]string, len(req.Items))
+	var misses []string
+	seen := make(map[string]bool, len(req.Items))
+	for _, line := range req.Items {
+		if seen[line.SKU] {
+			continue
+		}
+		seen[line.SKU] = true
+
+		if h.cache != nil {
+			var cachedItem models.InventoryItem
+			if err := h.cacheGetJSON(c.Request.Context(), cacheKeyItemBySKU(line.SKU), &cachedItem); err == nil {
+				available[line.SKU] = cachedItem.Available
+				reserved[line.SKU] = cachedItem.Reserved
+				itemIDs[line.SKU] = cachedItem.ID
+				continue
+			}
+		}
+		misses = append(misses, line.SKU)
+	}
+
+	if len(misses) > 0 {
+		items, err := h.repository.FindBySKUs(c.Request.Context(), misses)
+		if err != nil {
+			h.logger.Error("Failed to find items by SKU", zap.Error(err))
+			respondError(c, errors.NewInternalError("failed to check availability", nil))
+			return
+		}
+		for i := range items {
+			available[items[i].SKU] = items[i].Available
+			reserved[items[i].SKU] = items[i].Reserved
+			itemIDs[items[i].SKU] = items[i].ID
+			if h.cache != nil {
+				cache.SetJSON(c.Request.Context(), h.cache, cacheKeyItemBySKU(items[i].SKU), items[i], h.entryTTL(h.cacheTTL))
+			}
+		}
+	}
+
+	response := AvailabilityResponse{
+		Fulfillable: true,
+		Lines:       make([]AvailabilityLineResponse, 0, len(req.Items)),
+	}
+	for _, line := range req.Items {
+		remaining, found := available[line.SKU]
+		result := AvailabilityLineResponse{
+			SKU:       line.SKU,
+			Requested: line.Quantity,
+			Available: remaining,
+			Reserved:  reserved[line.SKU],
+			Found:     found,
+		}
+		if found && remaining >= line.Quantity {
+			result.Fulfillable = true
+			available[line.SKU] = remaining - line.Quantity
+		} else {
+			response.Fulfillable = false
+			if found {
+				result.Substitutes = h.substitutesFor(c, itemIDs[line.SKU], line.Quantity)
+			}
+		}
+		response.Lines = append(response.Lines, result)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// substitutesFor returns the substitutes of an item with at least quantity available.
+// Suggestions are best effort: a failed lookup leaves the line without them.
+func (h *InventoryHandler) substitutesFor(c *gin.Context, itemID string, quantity int) []models.RelatedItem {
+	id, err := uuid.Parse(itemID)
+	if h.relations == nil || err != nil {
+		return nil
+	}
+	substitutes, err := h.relations.FindRelatedItems(c.Request.Context(), id, repository.RelationSubstitute)
+	if err != nil {
+		h.logger.Warn("Failed to find substitutes", zap.String("item_id", itemID), zap.Error(err))
+		return nil
+	}
+	if substitutes = withStock(substitutes, quantity); len(substitutes) == 0 {
+		return nil
+	}
+	return substitutes
+}
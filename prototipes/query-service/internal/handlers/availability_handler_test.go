@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"query-service/internal/cache"
+	"query-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAvailability_CacheHitAndBatchedMiss(t *testing.T) {
+	// Setup
+	mockCache := new(MockCache)
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockCache, mockRepo)
+	router := setupTestRouter(handler)
+
+	cachedItem := createTestItem(uuid.New(), "SKU-001")
+	missedItem := createTestItem(uuid.New(), "SKU-002")
+	missedItem.Available = 5
+
+	cachedData, _ := json.Marshal(cachedItem)
+	mockCache.On("Get", mock.Anything, "item:sku:SKU-001").Return(cachedData, nil)
+	mockCache.On("Get", mock.Anything, "item:sku:SKU-002").Return(nil, cache.ErrCacheMiss)
+	mockRepo.On("FindBySKUs", mock.Anything, []string{"SKU-002"}).Return([]models.InventoryItem{*missedItem}, nil).Once()
+	mockCache.On("Set", mock.Anything, "item:sku:SKU-002", mock.Anything, mock.Anything).Return(nil)
+
+	// Execute
+	body := `{"items":[{"sku":"SKU-001","quantity":10},{"sku":"SKU-002","quantity":3}]}`
+	req := httptest.NewRequest("POST", "/api/v1/inventory/availability", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockCache.AssertExpectations(t)
+	mockRepo.AssertExpectations(t)
+
+	var response AvailabilityResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Fulfillable)
+	require.Len(t, response.Lines, 2)
+	assert.Equal(t, 80, response.Lines[0].Available)
+	assert.Equal(t, 20, response.Lines[0].Reserved)
+	assert.Equal(t, 5, response.Lines[1].Available)
+}
+
+func TestCheckAvailability_NotFulfillable(t *testing.T) {
+	// Setup (no cache)
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(nil, mockRepo)
+	router := setupTestRouter(handler)
+
+	item := createTestItem(uuid.New(), "SKU-001")
+	mockRepo.On("FindBySKUs", mock.Anything, []string{"SKU-001", "SKU-404"}).Return([]models.InventoryItem{*item}, nil).Once()
+
+	// Execute: the repeated SKU consumes what the first line left (80 - 50 = 30 < 40)
+	body := `{"items":[{"sku":"SKU-001","quantity":50},{"sku":"SKU-404","quantity":1},{"sku":"SKU-001","quantity":40}]}`
+	req := httptest.NewRequest("POST", "/api/v1/inventory/availability", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockRepo.AssertExpectations(t)
+
+	var response AvailabilityResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.False(t, response.Fulfillable)
+	require.Len(t, response.Lines, 3)
+	assert.True(t, response.Lines[0].Fulfillable)
+	assert.False(t, response.Lines[1].Found)
+	assert.Zero(t, response.Lines[1].Reserved)
+	assert.False(t, response.Lines[2].Fulfillable)
+	assert.Equal(t, 30, response.Lines[2].Available)
+}
+
+func TestCheckAvailability_InvalidRequest(t *testing.T) {
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(nil, mockRepo)
+	router := setupTestRouter(handler)
+
+	for _, body := range []string{`{"items":[]}`, `{"items":[{"sku":"SKU-001","quantity":0}]}`} {
+		req := httptest.NewRequest("POST", "/api/v1/inventory/availability", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+	mockRepo.AssertNotCalled(t, "FindBySKUs")
+}
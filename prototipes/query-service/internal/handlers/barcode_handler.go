@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"query-service/internal/cache"
+	"query-service/internal/models"
+	"query-service/internal/repository"
+	"query-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// GetItemByBarcode handles GET /api/v1/inventory/items/barcode/:code
+// @Summary      Get inventory item by barcode or alias
+// @Description  Obtiene el item de inventario que tiene un código de barras o alias (agregados con `POST /inventory/items/{id}/identifiers` en el Command Service). Pensado para los escáneres de los puntos de venta.
+//
+// **Características:**
+// - Los códigos de barras se comparan como GTIN-14: el UPC-A `036000291452`, el EAN-13 `0036000291452` y el GTIN-14 `00036000291452` encuentran el mismo item
+// - Un valor que no es un código de barras válido (longitud o dígito verificador) se busca como alias
+// - Cache del código → ID del item; el item sale del mismo cache que `GET /inventory/items/{id}` (timeout `ITEM_BY_ID_TIMEOUT_MS`)
+// - GET condicional: responde `ETag` y `Last-Modified`; con `If-None-Match` (o `If-Modified-Since`) responde `304 Not Modified` sin cuerpo si el cliente ya tiene esta versión
+//
+// **Ejemplos válidos:**
+// - Por EAN-13: `GET /api/v1/inventory/items/barcode/4006381333931`
+// - Por UPC-A: `GET /api/v1/inventory/items/barcode/036000291452`
+// - Por alias: `GET /api/v1/inventory/items/barcode/PROV-4411`
+//
+// **Ejemplos inválidos:**
+// - Código sin item: `GET /api/v1/inventory/items/barcode/4006381333931` (si ningún item lo tiene o su item está eliminado)
+//
+// @Tags         inventory
+// @Accept       json
+// @Produce      json,xml
+// @Security     BearerAuth
+// @Param        X-Request-ID  header    string  false  "Request ID for request tracking (UUID). If not provided, a new one will be generated."
+// @Param        If-None-Match      header  string  false  "ETag de una respuesta anterior; si coincide responde 304"
+// @Param        If-Modified-Since  header  string  false  "Fecha HTTP; si el recurso no cambió desde entonces responde 304 (ignorado con If-None-Match)"
+// @Param        code          path      string  true   "Código de barras (EAN-13, EAN-8, UPC-A o GTIN-14) o alias" example(4006381333931)
+// @Success      200           {object}  InventoryItemResponse  "Item obtenido exitosamente"
+// @Header       200           {string}  ETag           "Validador débil de la representación (W/\"...\")"
+// @Header       200           {string}  Last-Modified  "updated_at más reciente del recurso"
+// @Success      304           "No modificado - la versión del cliente está al día"
+// @Failure      400           {object}  ErrorResponse          "Código vacío"
+// @Failure      401           {object}  ErrorResponse          "No autorizado - token JWT inválido o faltante"
+// @Failure      404           {object}  ErrorResponse          "Ningún item tiene el código"
+// @Failure      500           {object}  ErrorResponse          "Error interno del servidor - error de lectura o conexión a base de datos"
+// @Failure      504           {object}  ErrorResponse          "Timeout - la lectura superó el timeout del endpoint (ITEM_BY_ID_TIMEOUT_MS)"
+// @Router       /inventory/items/barcode/{code} [get]
+func (h *InventoryHandler) GetItemByBarcode(c *gin.Context) {
+	code := lookupCode(c.Param("code"))
+	if code == "" {
+		respondError(c, errors.NewInvalidRequest("code is required", ""))
+		return
+	}
+	if h.identifiers == nil {
+		respondError(c, errors.NewInternalError("item identifiers are not available", nil))
+		return
+	}
+
+	id, err := h.barcodeItemID(c.Request.Context(), code)
+	if err == nil {
+		// The item is read as GET /items/:id reads it, so the cached barcode only maps to
+		// the ID and item changes need not know the barcodes of the item
+		read := h.readItem(c.Request.Context(), endpointItemByID, cacheKeyItemByID(id.String()), h.findWithImages(func(ctx context.Context) (*models.InventoryItem, error) {
+			return h.repository.FindByID(ctx, id)
+		}))
+		if err = read.err; err == nil {
+			h.respondBarcodeItem(c, read)
+			return
+		}
+	}
+	if err == repository.ErrItemNotFound {
+		respondError(c, errors.NewStandardError(errors.CodeItemNotFound, "item not found", "Code: "+code))
+		return
+	}
+	if stderrors.Is(err, context.DeadlineExceeded) {
+		respondError(c, errors.NewTimeout("item read timed out", ""))
+		return
+	}
+	h.logger.Error("Failed to find item by barcode", zap.Error(err))
+	respondError(c, errors.NewInternalError("failed to get item", nil))
+}
+
+// barcodeItemID returns the item holding code, cache first
+func (h *InventoryHandler) barcodeItemID(ctx context.Context, code string) (uuid.UUID, error) {
+	key := cacheKeyItemByBarcode(code)
+	if h.cache != nil {
+		if data, err := h.cacheGet(ctx, key); err == nil {
+			if id, err := uuid.Parse(string(data)); err == nil {
+				return id, nil
+			}
+		}
+	}
+	id, err := h.identifiers.FindItemIDByCode(ctx, code)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if h.cache != nil {
+		if err := h.cache.Set(ctx, key, []byte(id.String()), h.entryTTL(h.cacheTTL)); err != nil {
+			h.logger.Warn("Failed to cache barcode", zap.String("code", code), zap.Error(err))
+		}
+	}
+	return id, nil
+}
+
+// respondBarcodeItem responds with the item found by barcode, caching it by ID
+func (h *InventoryHandler) respondBarcodeItem(c *gin.Context, read itemRead) {
+	item := read.item
+	response := InventoryItemResponse{
+		ID:          item.ID,
+		SKU:         item.SKU,
+		Name:        item.Name,
+		Description: item.Description,
+		Quantity:    item.Quantity,
+		Reserved:    item.Reserved,
+		Available:   item.Available,
+		CreatedAt:   item.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   item.UpdatedAt.Format(time.RFC3339),
+		Images:      h.imageResponses(item.Images),
+	}
+
+	if h.cache != nil && !read.fromCache {
+		cache.SetJSON(c.Request.Context(), h.cache, cacheKeyItemByID(item.ID), item, h.entryTTL(h.cacheTTL))
+	}
+
+	if notModified(c, entityTag(c, response), item.UpdatedAt) {
+		return
+	}
+	respond(c, http.StatusOK, response)
+}
+
+func cacheKeyItemByBarcode(code string) string {
+	return "item:barcode:" + code
+}
+
+// lookupCode returns the code a scanned value is stored under in item_identifiers: its
+// GTIN-14 when it is a valid GS1 barcode (8, 12, 13 or 14 digits with a valid check
+// digit), the value itself otherwise. It must match the Command Service's
+// domain.LookupCode.
+func lookupCode(value string) string {
+	value = strings.TrimSpace(value)
+	switch len(value) {
+	case 8, 12, 13, 14:
+		if validGTIN(value) {
+			return strings.Repeat("0", 14-len(value)) + value
+		}
+	}
+	return value
+}
+
+// validGTIN checks that code is all digits with a valid GS1 mod-10 check digit
+func validGTIN(code string) bool {
+	sum := 0
+	for n := len(code) - 1; n >= 0; n-- {
+		if code[n] < '0' || code[n] > '9' {
+			return false
+		}
+		if n == len(code)-1 {
+			continue
+		}
+		digit := int(code[n] - '0')
+		if (len(code)-2-n)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return (10-sum%10)%10 == int(code[len(code)-1]-'0')
+}
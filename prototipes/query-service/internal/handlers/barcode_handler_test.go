@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"query-service/internal/cache"
+	"query-service/internal/models"
+	"query-service/internal/repository"
+	"query-service/internal/tenant"
+	"testsupport"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestLookupCode(t *testing.T) {
+	// The UPC-A, the same code as an EAN-13 and its GTIN-14 are the same item
+	assert.Equal(t, "00036000291452", lookupCode("036000291452"))
+	assert.Equal(t, "00036000291452", lookupCode("0036000291452"))
+	assert.Equal(t, "00036000291452", lookupCode("00036000291452"))
+	assert.Equal(t, "00000096385074", lookupCode("96385074"))
+	// Anything else is an alias
+	assert.Equal(t, "036000291453", lookupCode("036000291453"))
+	assert.Equal(t, "PROV-4411", lookupCode(" PROV-4411 "))
+}
+
+func TestGetItemByBarcode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := repository.NewInMemoryReadRepository()
+	id := uuid.New()
+	require.NoError(t, repo.SaveItem(models.InventoryItem{ID: id.String(), SKU: "SKU-UPC", Name: "Item", Quantity: 3, Available: 3}))
+	repo.SaveItemIdentifier(id, "00036000291452")
+	repo.SaveItemIdentifier(id, "PROV-4411")
+	other := uuid.New()
+	require.NoError(t, repo.SaveItem(models.InventoryItem{ID: other.String(), TenantID: "acme", SKU: "SKU-ACME", Name: "Item"}))
+	repo.SaveItemIdentifier(other, "04006381333931")
+
+	store := cache.NewKVCache(testsupport.NewKV(), zap.NewNop())
+	handler := &InventoryHandler{logger: zap.NewNop(), repository: repo, identifiers: repo, cache: store, cacheTTL: 300}
+	router := gin.New()
+	router.GET("/api/v1/inventory/items/barcode/:code", handler.GetItemByBarcode)
+
+	for _, code := range []string{"036000291452", "0036000291452", "PROV-4411"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/inventory/items/barcode/"+code, nil))
+		require.Equal(t, http.StatusOK, w.Code, code)
+		var item InventoryItemResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &item))
+		assert.Equal(t, "SKU-UPC", item.SKU)
+	}
+
+	// The code is cached as the ID of its item, the item by ID
+	cached, err := store.Get(context.Background(), cacheKeyItemByBarcode("00036000291452"))
+	require.NoError(t, err)
+	assert.Equal(t, id.String(), string(cached))
+	_, err = store.Get(context.Background(), cacheKeyItemByID(id.String()))
+	assert.NoError(t, err)
+
+	// Unknown code, and a code of another tenant
+	for _, code := range []string{"036000291453", "4006381333931"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/inventory/items/barcode/"+code, nil))
+		assert.Equal(t, http.StatusNotFound, w.Code, code)
+	}
+
+	// The other tenant finds its own
+	found, err := repo.FindItemIDByCode(tenant.WithTenant(context.Background(), "acme"), "04006381333931")
+	require.NoError(t, err)
+	assert.Equal(t, other, found)
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"query-service/internal/cache"
+	"query-service/internal/models"
+	"query-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// maxBatchKeys is the most IDs plus SKUs a batch lookup accepts
+const maxBatchKeys = 500
+
+// GetItemsBatch handles POST /api/v1/inventory/items/batch
+// @Summary      Get many items by ID or SKU
+// @Description  Obtiene en una sola llamada los items de una lista de IDs y/o SKUs, en lugar de un GET por item.
+//
+// **Características:**
+// - Lectura desde cache por clave; las claves no cacheadas se leen con una sola consulta `IN (...)` por tipo (IDs y SKUs)
+// - Los items se devuelven en el orden de su primera clave (IDs primero, luego SKUs), sin repetir
+// - Las claves que no corresponden a ningún item (o a uno eliminado) se listan en `not_found`
+//
+// **Ejemplos válidos:**
+// - `{"ids": ["550e8400-e29b-41d4-a716-446655440000"]}`
+// - `{"skus": ["SKU-001", "SKU-002"]}`
+// - `{"ids": ["550e8400-e29b-41d4-a716-446655440000"], "skus": ["SKU-002"]}`
+//
+// **Ejemplos inválidos:**
+// - Sin claves: `{}` o `{"ids": [], "skus": []}`
+// - ID que no es un UUID: `{"ids": ["abc"]}`
+// - Más de 500 claves entre IDs y SKUs
+//
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      BatchItemsRequest   true  "IDs y/o SKUs"
+// @Success      200      {object}  BatchItemsResponse  "Items encontrados"
+// @Failure      400      {object}  ErrorResponse       "Request inválido - sin claves, más de 500 o ID inválido"
+// @Failure      401      {object}  ErrorResponse       "No autorizado - token JWT inválido o faltante"
+// @Failure      500      {object}  ErrorResponse       "Error interno del servidor - error de lectura"
+// @Router       /inventory/items/batch [post]
+func (h *InventoryHandler) GetItemsBatch(c *gin.Context) {
+	var req BatchItemsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, errors.NewBindingError(err))
+		return
+	}
+	if len(req.IDs)+len(req.SKUs) == 0 {
+		respondError(c, errors.NewInvalidRequest("ids or skus are required", ""))
+		return
+	}
+	if len(req.IDs)+len(req.SKUs) > maxBatchKeys {
+		respondError(c, errors.NewInvalidRequest("too many keys", "at most 500 ids and skus in total"))
+		return
+	}
+	ids := make([]uuid.UUID, 0, len(req.IDs))
+	for _, raw := range req.IDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			respondError(c, errors.NewInvalidRequest("invalid item id", raw))
+			return
+		}
+		ids = append(ids, id)
+	}
+	ctx := c.Request.Context()
+
+	// Cache first, per key; the misses of each kind are read with a single query
+	byID := make(map[string]*models.InventoryItem, len(ids))
+	bySKU := make(map[string]*models.InventoryItem, len(req.SKUs))
+	var missedIDs []uuid.UUID
+	for _, id := range ids {
+		key := id.String()
+		if _, ok := byID[key]; ok {
+			continue
+		}
+		byID[key] = nil
+		if item := h.cachedItem(c, cacheKeyItemByID(key)); item != nil {
+			byID[key] = item
+			continue
+		}
+		missedIDs = append(missedIDs, id)
+	}
+	var missedSKUs []string
+	for _, sku := range req.SKUs {
+		if _, ok := bySKU[sku]; ok {
+			continue
+		}
+		bySKU[sku] = nil
+		if item := h.cachedItem(c, cacheKeyItemBySKU(sku)); item != nil {
+			bySKU[sku] = item
+			continue
+		}
+		missedSKUs = append(missedSKUs, sku)
+	}
+
+	if len(missedIDs) > 0 {
+		items, err := h.repository.FindByIDs(ctx, missedIDs)
+		if err != nil {
+			h.logger.Error("Failed to find items by ID", zap.Error(err))
+			respondError(c, errors.NewInternalError("failed to get items", nil))
+			return
+		}
+		for i := range items {
+			byID[items[i].ID] = &items[i]
+			if h.cache != nil {
+				cache.SetJSON(ctx, h.cache, cacheKeyItemByID(items[i].ID), items[i], h.entryTTL(h.cacheTTL))
+			}
+		}
+	}
+	if len(missedSKUs) > 0 {
+		items, err := h.repository.FindBySKUs(ctx, missedSKUs)
+		if err != nil {
+			h.logger.Error("Failed to find items by SKU", zap.Error(err))
+			respondError(c, errors.NewInternalError("failed to get items", nil))
+			return
+		}
+		for i := range items {
+			bySKU[items[i].SKU] = &items[i]
+			if h.cache != nil {
+				cache.SetJSON(ctx, h.cache, cacheKeyItemBySKU(items[i].SKU), items[i], h.entryTTL(h.cacheTTL))
+			}
+		}
+	}
+
+	response := BatchItemsResponse{
+		Items:    make([]InventoryItemResponse, 0, len(ids)+len(req.SKUs)),
+		NotFound: []string{},
+	}
+	added := make(map[string]bool, len(ids)+len(req.SKUs))
+	add := func(key string, item *models.InventoryItem) {
+		switch {
+		case item == nil:
+			response.NotFound = append(response.NotFound, key)
+		case !added[item.ID]:
+			added[item.ID] = true
+			response.Items = append(response.Items, itemResponse(item))
+		}
+	}
+	for _, id := range ids {
+		key := id.String()
+		if item, ok := byID[key]; ok {
+			delete(byID, key) // once per key
+			add(key, item)
+		}
+	}
+	for _, sku := range req.SKUs {
+		if item, ok := bySKU[sku]; ok {
+			delete(bySKU, sku)
+			add(sku, item)
+		}
+	}
+	response.Count = len(response.Items)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// cachedItem returns the item cached under key; nil on a miss or without cache
+func (h *InventoryHandler) cachedItem(c *gin.Context, key string) *models.InventoryItem {
+	if h.cache == nil {
+		return nil
+	}
+	var item models.InventoryItem
+	if err := h.cacheGetJSON(c.Request.Context(), key, &item); err != nil {
+		return nil
+	}
+	return &item
+}
+
+// itemResponse converts a read model item to its API representation
+func itemResponse(item *models.InventoryItem) InventoryItemResponse {
+	return InventoryItemResponse{
+		ID:          item.ID,
+		SKU:         item.SKU,
+		Name:        item.Name,
+		Description: item.Description,
+		Quantity:    item.Quantity,
+		Reserved:    item.Reserved,
+		Available:   item.Available,
+		CreatedAt:   item.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   item.UpdatedAt.Format(time.RFC3339),
+		DeletedAt:   formatDeletedAt(item.DeletedAt),
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"query-service/internal/cache"
+	"query-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetItemsBatch_CacheHitsAndSingleQueryPerKind(t *testing.T) {
+	// Setup
+	mockCache := new(MockCache)
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockCache, mockRepo)
+	router := setupTestRouter(handler)
+
+	cachedID := uuid.New()
+	missedID := uuid.New()
+	goneID := uuid.New()
+	cachedItem := createTestItem(cachedID, "SKU-001")
+	missedItem := createTestItem(missedID, "SKU-002")
+	skuItem := createTestItem(uuid.New(), "SKU-003")
+
+	cachedData, _ := json.Marshal(cachedItem)
+	mockCache.On("Get", mock.Anything, "item:id:"+cachedID.String()).Return(cachedData, nil)
+	mockCache.On("Get", mock.Anything, mock.Anything).Return(nil, cache.ErrCacheMiss)
+	mockCache.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("FindByIDs", mock.Anything, []uuid.UUID{missedID, goneID}).Return([]models.InventoryItem{*missedItem}, nil).Once()
+	mockRepo.On("FindBySKUs", mock.Anything, []string{"SKU-002", "SKU-003", "SKU-404"}).
+		Return([]models.InventoryItem{*missedItem, *skuItem}, nil).Once()
+
+	// Execute: SKU-002 is the item of missedID, so it is returned once
+	body := `{"ids":["` + cachedID.String() + `","` + missedID.String() + `","` + goneID.String() + `","` + cachedID.String() + `"],` +
+		`"skus":["SKU-002","SKU-003","SKU-404"]}`
+	req := httptest.NewRequest("POST", "/api/v1/inventory/items/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	mockRepo.AssertExpectations(t)
+
+	var response BatchItemsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Equal(t, 3, response.Count)
+	assert.Equal(t, "SKU-001", response.Items[0].SKU)
+	assert.Equal(t, "SKU-002", response.Items[1].SKU)
+	assert.Equal(t, "SKU-003", response.Items[2].SKU)
+	assert.Equal(t, []string{goneID.String(), "SKU-404"}, response.NotFound)
+}
+
+func TestGetItemsBatch_InvalidRequest(t *testing.T) {
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(nil, mockRepo)
+	router := setupTestRouter(handler)
+
+	tooMany := `{"skus":["` + strings.Repeat(`SKU","`, maxBatchKeys) + `SKU"]}`
+	for _, body := range []string{`{}`, `{"ids":[],"skus":[]}`, `{"ids":["abc"]}`, tooMany} {
+		req := httptest.NewRequest("POST", "/api/v1/inventory/items/batch", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+	mockRepo.AssertNotCalled(t, "FindByIDs")
+	mockRepo.AssertNotCalled(t, "FindBySKUs")
+}
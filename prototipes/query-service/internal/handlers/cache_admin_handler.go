@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"query-service/internal/cache"
+	"query-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxFlushPatternLength bounds the pattern of DELETE /admin/cache
+const maxFlushPatternLength = 200
+
+// CacheAdminHandler lets operators inspect and flush the cache, to recover from stale
+// entries without restarting the service or Redis
+type CacheAdminHandler struct {
+	logger *zap.Logger
+	cache  cache.Cache // nil when USE_CACHE=false
+	now    func() time.Time
+}
+
+// NewCacheAdminHandler creates a new cache admin handler
+func NewCacheAdminHandler(logger *zap.Logger, cacheClient cache.Cache) *CacheAdminHandler {
+	return &CacheAdminHandler{
+		logger: logger,
+		cache:  cacheClient,
+		now:    time.Now,
+	}
+}
+
+// FlushCache handles DELETE /api/v1/admin/cache
+// @Summary      Flush the cache
+// @Description  Borra las entradas del cache que coinciden con `pattern` (glob estilo Redis; por defecto `*`, todo el cache), para recuperarse de entradas desactualizadas sin reiniciar el servicio ni Redis. Con el backend `tiered` también se borran las copias locales de las demás réplicas.
+//
+// **Características:**
+// - Requiere el permiso `inventory:override` (rol admin por defecto)
+// - Con Memcached el patrón se aplica al prefijo hasta el último `:` antes del `*`: `items:li*` invalida todo `items:`
+// - Si Redis es compartido con otros datos, `*` también los borra: usar un patrón como `item:*`
+//
+// **Ejemplos válidos:**
+// - Todo el cache: `DELETE /api/v1/admin/cache`
+// - Solo los listados: `DELETE /api/v1/admin/cache?pattern=items:list:*`
+// - Un item: `DELETE /api/v1/admin/cache?pattern=item:id:550e8400-e29b-41d4-a716-446655440000`
+//
+// **Ejemplos inválidos:**
+// - Patrón vacío o con espacios: `DELETE /api/v1/admin/cache?pattern=`
+//
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Param        pattern  query     string  false  "Glob of the keys to delete (default: *)" example(items:list:*)
+// @Success      200      {object}  CacheFlushResponse  "Entradas borradas"
+// @Failure      400      {object}  ErrorResponse  "Request inválido - patrón vacío, demasiado largo o con espacios"
+// @Failure      401      {object}  ErrorResponse  "No autorizado - token JWT inválido o faltante"
+// @Failure      403      {object}  ErrorResponse  "Prohibido - el rol no tiene el permiso inventory:override"
+// @Failure      500      {object}  ErrorResponse  "Error del cache al borrar las entradas"
+// @Failure      503      {object}  ErrorResponse  "Cache deshabilitado (USE_CACHE=false)"
+// @Router       /admin/cache [delete]
+func (h *CacheAdminHandler) FlushCache(c *gin.Context) {
+	if h.cache == nil {
+		respondError(c, errors.NewServiceUnavailable("cache is disabled", "USE_CACHE=false"))
+		return
+	}
+
+	pattern, present := c.GetQuery("pattern")
+	if !present {
+		pattern = "*"
+	}
+	if pattern == "" || len(pattern) > maxFlushPatternLength || strings.IndexFunc(pattern, unicode.IsSpace) >= 0 {
+		respondError(c, errors.NewInvalidRequest("invalid pattern",
+			"Expected a glob of at most 200 characters without spaces, e.g. items:list:*"))
+		return
+	}
+
+	if err := h.cache.DeleteByPattern(c.Request.Context(), pattern); err != nil {
+		h.logger.Error("Failed to flush cache", zap.String("pattern", pattern), zap.Error(err))
+		respondError(c, errors.NewCacheError("flush", err))
+		return
+	}
+
+	h.logger.Warn("Cache flushed by operator",
+		zap.String("pattern", pattern),
+		zap.String("username", c.GetString("username")),
+	)
+	c.JSON(http.StatusOK, CacheFlushResponse{Pattern: pattern, FlushedAt: h.now().UTC()})
+}
+
+// GetCacheStats handles GET /api/v1/admin/cache/stats
+// @Summary      Cache statistics
+// @Description  Devuelve el backend del cache, la cantidad de claves y la memoria usada (cuando el backend los reporta), y los aciertos, fallos y errores de lectura de esta réplica desde que arrancó.
+//
+// **Características:**
+// - Requiere el permiso `inventory:override` (rol admin por defecto)
+// - `keys` y `memory_used_bytes` no se informan con Memcached; con Redis cuentan toda la base (`REDIS_DB`)
+// - `hits`/`misses`/`errors` son las lecturas que llegaron al backend compartido; las resueltas por el tier local (`HOT_CACHE_SIZE` o `tiered`) están en `hot_tier`
+// - `pressure_active` indica si los TTL adaptativos están activos (`CACHE_PRESSURE_ENABLED`)
+//
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  cache.Stats    "Estadísticas del cache"
+// @Failure      401  {object}  ErrorResponse  "No autorizado - token JWT inválido o faltante"
+// @Failure      403  {object}  ErrorResponse  "Prohibido - el rol no tiene el permiso inventory:override"
+// @Failure      500  {object}  ErrorResponse  "Error del cache al leer su tamaño"
+// @Failure      503  {object}  ErrorResponse  "Cache deshabilitado (USE_CACHE=false)"
+// @Router       /admin/cache/stats [get]
+func (h *CacheAdminHandler) GetCacheStats(c *gin.Context) {
+	if h.cache == nil {
+		respondError(c, errors.NewServiceUnavailable("cache is disabled", "USE_CACHE=false"))
+		return
+	}
+
+	stats, err := cache.CollectStats(c.Request.Context(), h.cache)
+	if err != nil {
+		h.logger.Error("Failed to collect cache stats", zap.Error(err))
+		respondError(c, errors.NewCacheError("stats", err))
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
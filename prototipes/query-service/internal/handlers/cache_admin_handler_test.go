@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"query-service/internal/cache"
+	"testsupport"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupCacheAdminRouter(cacheClient cache.Cache) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewCacheAdminHandler(zap.NewNop(), cacheClient)
+	router := gin.New()
+	router.DELETE("/admin/cache", handler.FlushCache)
+	router.GET("/admin/cache/stats", handler.GetCacheStats)
+	return router
+}
+
+func TestFlushCache_ByPattern(t *testing.T) {
+	ctx := context.Background()
+	store := cache.NewKVCache(testsupport.NewKV(), zap.NewNop())
+	require.NoError(t, store.Set(ctx, "items:list:page:1", []byte("[]"), time.Minute))
+	require.NoError(t, store.Set(ctx, "item:id:1", []byte("{}"), time.Minute))
+	router := setupCacheAdminRouter(store)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/admin/cache?pattern=items:list:*", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var response CacheFlushResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "items:list:*", response.Pattern)
+
+	exists, _ := store.Exists(ctx, "items:list:page:1")
+	assert.False(t, exists)
+	exists, _ = store.Exists(ctx, "item:id:1")
+	assert.True(t, exists)
+
+	// Without a pattern the whole cache is flushed
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/admin/cache", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	exists, _ = store.Exists(ctx, "item:id:1")
+	assert.False(t, exists)
+}
+
+func TestFlushCache_InvalidPattern(t *testing.T) {
+	router := setupCacheAdminRouter(cache.NewKVCache(testsupport.NewKV(), zap.NewNop()))
+
+	for _, query := range []string{"?pattern=", "?pattern=items%20*"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("DELETE", "/admin/cache"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestCacheAdmin_CacheDisabled(t *testing.T) {
+	router := setupCacheAdminRouter(nil)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("DELETE", "/admin/cache", nil),
+		httptest.NewRequest("GET", "/admin/cache/stats", nil),
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	}
+}
+
+func TestGetCacheStats(t *testing.T) {
+	store := cache.NewKVCache(testsupport.NewKV(), zap.NewNop())
+	require.NoError(t, store.Set(context.Background(), "item:id:1", []byte("{}"), time.Minute))
+	router := setupCacheAdminRouter(store)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/admin/cache/stats", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var stats cache.Stats
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	require.NotNil(t, stats.Keys)
+	assert.Equal(t, int64(1), *stats.Keys)
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"query-service/internal/cache"
+	"query-service/pkg/metrics"
+)
+
+// cacheKeyClass returns the class of a cache key of the inventory endpoints for the
+// request-level cache metrics: item, sku, barcode, stock, list or stats
+func cacheKeyClass(key string) string {
+	switch {
+	case strings.HasPrefix(key, "item:id:"):
+		return "item"
+	case strings.HasPrefix(key, "item:sku:"):
+		return "sku"
+	case strings.HasPrefix(key, "item:barcode:"):
+		return "barcode"
+	case strings.HasPrefix(key, "stock:"):
+		return "stock"
+	case strings.HasPrefix(key, "items:list:"):
+		return "list"
+	case strings.HasPrefix(key, "stats:"):
+		return "stats"
+	}
+	return "other"
+}
+
+// cacheGet reads key from the cache and records the lookup, with its latency, under the
+// class of the key
+func (h *InventoryHandler) cacheGet(ctx context.Context, key string) ([]byte, error) {
+	start := time.Now()
+	data, err := h.cache.Get(ctx, key)
+
+	result := "hit"
+	if err == cache.ErrCacheMiss {
+		result = "miss"
+	} else if err != nil {
+		result = "error"
+	}
+	class := cacheKeyClass(key)
+	metrics.CacheLookups.WithLabelValues(class, result).Inc()
+	metrics.CacheLookupDuration.WithLabelValues(class, result).Observe(time.Since(start).Seconds())
+
+	return data, err
+}
+
+// cacheGetJSON is cache.GetJSON through cacheGet
+func (h *InventoryHandler) cacheGetJSON(ctx context.Context, key string, dest interface{}) error {
+	data, err := h.cacheGet(ctx, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// entryTTL is the TTL of a new cache entry: seconds, shortened by the TTL jitter
+func (h *InventoryHandler) entryTTL(seconds int) time.Duration {
+	return cache.JitteredTTL(seconds, h.ttlJitter)
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"query-service/internal/cache"
+	"query-service/internal/models"
+	"query-service/pkg/metrics"
+	"testsupport"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+func TestCacheKeyClass(t *testing.T) {
+	assert.Equal(t, "item", cacheKeyClass(cacheKeyItemByID("1")))
+	assert.Equal(t, "sku", cacheKeyClass(cacheKeyItemBySKU("SKU-001")))
+	assert.Equal(t, "barcode", cacheKeyClass(cacheKeyItemByBarcode("04006381333931")))
+	assert.Equal(t, "stock", cacheKeyClass(cacheKeyStockStatus("1")))
+	assert.Equal(t, "list", cacheKeyClass(cacheKeyListItems(1, 10)))
+	assert.Equal(t, "list", cacheKeyClass(cacheKeyListItemsAfter("abc", 10)))
+	assert.Equal(t, "stats", cacheKeyClass(cacheKeyStats(5, 30)))
+	assert.Equal(t, "other", cacheKeyClass("reservations:item:1"))
+}
+
+func TestGetStockStatus_RecordsCacheLookupsByClass(t *testing.T) {
+	store := cache.NewKVCache(testsupport.NewKV(), zap.NewNop())
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(store, mockRepo)
+	handler.ttlJitter = 20
+	router := setupTestRouter(handler)
+
+	id := uuid.New()
+	mockRepo.On("GetStockStatus", mock.Anything, id).Return(&models.StockStatus{
+		ID: id.String(), SKU: "SKU-001", Quantity: 10, Available: 10, UpdatedAt: time.Now(),
+	}, nil).Once()
+
+	hits := metrics.CacheLookups.WithLabelValues("stock", "hit")
+	misses := metrics.CacheLookups.WithLabelValues("stock", "miss")
+	hitsBefore, missesBefore := testutil.ToFloat64(hits), testutil.ToFloat64(misses)
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/inventory/items/"+id.String()+"/stock", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	assert.Equal(t, missesBefore+1, testutil.ToFloat64(misses))
+	assert.Equal(t, hitsBefore+1, testutil.ToFloat64(hits))
+	mockRepo.AssertExpectations(t)
+
+	// The entry was cached with the jittered half TTL
+	exists, _ := store.Exists(context.Background(), cacheKeyStockStatus(id.String()))
+	assert.True(t, exists)
+	ttl := handler.entryTTL(handler.cacheTTL / 2)
+	assert.LessOrEqual(t, ttl, 150*time.Second)
+	assert.GreaterOrEqual(t, ttl, 120*time.Second)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// entityTag returns the weak ETag of response in the format negotiated for c. It hashes
+// the response model rather than the serialized body, so a 304 costs no JSON or XML
+// encoding; the tag is weak because the optional envelope changes the body (its meta)
+// but not the data.
+func entityTag(c *gin.Context, response interface{}) string {
+	return entityTagFor(negotiateFormat(c.GetHeader("Accept")), response)
+}
+
+// entityTagFor returns the weak ETag of response rendered in format
+func entityTagFor(format string, response interface{}) string {
+	sum := sha256.New()
+	fmt.Fprintf(sum, "%s\n%+v", format, response)
+	return `W/"` + hex.EncodeToString(sum.Sum(nil)[:16]) + `"`
+}
+
+// notModified sets the ETag and Last-Modified validators of the response and, when the
+// request's If-None-Match (or, without it, If-Modified-Since) shows the client already
+// has this version, responds 304 Not Modified and returns true. A zero lastModified
+// sends no Last-Modified and ignores If-Modified-Since.
+func notModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	c.Header("ETag", etag)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" {
+		if !etagMatches(ifNoneMatch, etag) {
+			return false
+		}
+	} else {
+		ifModifiedSince, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+		if err != nil || lastModified.IsZero() || lastModified.Truncate(time.Second).After(ifModifiedSince) {
+			return false
+		}
+	}
+
+	c.Writer.Header().Add("Vary", "Accept")
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// etagMatches applies the weak comparison of If-None-Match: "*" or any listed tag equal
+// to etag once the W/ prefixes are dropped
+func etagMatches(ifNoneMatch, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// latestUpdate returns the most recent updated_at of items, zero if none can be parsed
+func latestUpdate(items []InventoryItemResponse) time.Time {
+	var latest time.Time
+	for _, item := range items {
+		if updatedAt, err := time.Parse(time.RFC3339, item.UpdatedAt); err == nil && updatedAt.After(latest) {
+			latest = updatedAt
+		}
+	}
+	return latest
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"query-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetItemByID_ConditionalGet(t *testing.T) {
+	mockRepo := new(MockRepository)
+	router := setupTestRouter(createTestHandler(nil, mockRepo))
+
+	itemID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	testItem := createTestItem(itemID, "SKU-001")
+	testItem.UpdatedAt = time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	mockRepo.On("FindByID", mock.Anything, itemID).Return(testItem, nil)
+
+	get := func(headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/inventory/items/"+itemID.String(), nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := get(nil)
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	assert.Regexp(t, `^W/"[0-9a-f]{32}"$`, etag)
+	assert.Equal(t, "Mon, 15 Jan 2024 10:30:00 GMT", first.Header().Get("Last-Modified"))
+
+	w := get(map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+
+	w = get(map[string]string{"If-None-Match": `W/"other", ` + etag})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = get(map[string]string{"If-Modified-Since": "Mon, 15 Jan 2024 10:30:00 GMT"})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = get(map[string]string{"If-Modified-Since": "Mon, 15 Jan 2024 10:29:59 GMT"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// If-None-Match takes precedence over If-Modified-Since
+	w = get(map[string]string{"If-None-Match": `W/"other"`, "If-Modified-Since": "Mon, 15 Jan 2024 10:30:00 GMT"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Each format has its own tag
+	w = get(map[string]string{"Accept": "application/xml", "If-None-Match": etag})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+
+	// A change of the item changes the tag
+	testItem.Available = 79
+	testItem.Reserved = 21
+	w = get(map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+}
+
+func TestListItems_ConditionalGet(t *testing.T) {
+	mockRepo := new(MockRepository)
+	router := setupTestRouter(createTestHandler(nil, mockRepo))
+
+	older := createTestItem(uuid.New(), "SKU-001")
+	older.UpdatedAt = time.Date(2024, 1, 14, 8, 0, 0, 0, time.UTC)
+	newer := createTestItem(uuid.New(), "SKU-002")
+	newer.UpdatedAt = time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	mockRepo.On("ListItems", mock.Anything, 1, 10).Return([]models.InventoryItem{*older, *newer}, 2, nil)
+
+	req := httptest.NewRequest("GET", "/api/v1/inventory/items", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Mon, 15 Jan 2024 10:30:00 GMT", w.Header().Get("Last-Modified"))
+
+	req = httptest.NewRequest("GET", "/api/v1/inventory/items", nil)
+	req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestETagMatches(t *testing.T) {
+	assert.True(t, etagMatches(`W/"abc"`, `W/"abc"`))
+	assert.True(t, etagMatches(`"abc"`, `W/"abc"`))
+	assert.True(t, etagMatches(`*`, `W/"abc"`))
+	assert.True(t, etagMatches(`"x", W/"abc"`, `W/"abc"`))
+	assert.False(t, etagMatches(`W/"abd"`, `W/"abc"`))
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// encodeItemCursor returns the opaque after cursor of the listing position of item: its
+// created_at and id, base64url-encoded
+func encodeItemCursor(item models.InventoryItem) string {
+	raw := item.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + item.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeItemCursor parses an after cursor returned by encodeItemCursor
+func decodeItemCursor(cursor string) (*repository.ItemCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("not base64url: %w", err)
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, fmt.Errorf("missing id")
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid created_at: %w", err)
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid id: %w", err)
+	}
+	return &repository.ItemCursor{CreatedAt: parsed, ID: id}, nil
+}
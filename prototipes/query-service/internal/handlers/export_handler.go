@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"query-service/internal/export"
+	"query-service/internal/models"
+	"query-service/internal/repository"
+	"query-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// exportFlushRows is the number of items sent in each chunk of an export
+const exportFlushRows = 500
+
+// ExportHandler serves full inventory snapshots as files
+type ExportHandler struct {
+	logger     *zap.Logger
+	repository repository.ExportRepository
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(logger *zap.Logger, repo repository.ExportRepository) *ExportHandler {
+	return &ExportHandler{
+		logger:     logger,
+		repository: repo,
+	}
+}
+
+// ExportInventory handles GET /api/v1/inventory/export
+// @Summary      Export inventory snapshot
+// @Description  Descarga el inventario completo en CSV, XLSX o JSON, leído directamente del read model y enviado en chunks a medida que se leen las filas (sin paginar ni pasar por el cache).
+//
+// **Características:**
+// - `format=csv` (por defecto), `xlsx` o `json` (array de items)
+// - Items ordenados por SKU, con las columnas id, sku, name, description, quantity, reserved, available, created_at, updated_at y deleted_at
+// - Filtros: `sku_prefix`, `in_stock=true` (solo items con stock disponible) e `include_deleted=true` (incluye items eliminados)
+// - Si la lectura falla a mitad del export la respuesta se corta: el archivo queda incompleto
+//
+// **Ejemplos válidos:**
+// - CSV completo: `GET /api/v1/inventory/export`
+// - Excel: `GET /api/v1/inventory/export?format=xlsx`
+// - Solo una familia con stock: `GET /api/v1/inventory/export?format=json&sku_prefix=LAP-&in_stock=true`
+//
+// **Ejemplos inválidos:**
+// - Formato desconocido: `GET /api/v1/inventory/export?format=pdf`
+// - in_stock no booleano: `GET /api/v1/inventory/export?in_stock=yes-please`
+//
+// @Tags         inventory
+// @Produce      text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,json
+// @Security     BearerAuth
+// @Param        format           query     string  false  "File format (csv, xlsx, json; default: csv)"
+// @Param        sku_prefix       query     string  false  "Only items whose SKU starts with this prefix"
+// @Param        in_stock         query     bool    false  "Only items with available stock (default: false)"
+// @Param        include_deleted  query     bool    false  "Include soft-deleted items (default: false)"
+// @Success      200              {file}    file           "Archivo del inventario (Content-Disposition: attachment)"
+// @Failure      400              {object}  ErrorResponse  "Request inválido - formato o filtros inválidos"
+// @Failure      401              {object}  ErrorResponse  "No autorizado - token JWT inválido o faltante"
+// @Failure      500              {object}  ErrorResponse  "Error interno del servidor - error de lectura"
+// @Router       /inventory/export [get]
+func (h *ExportHandler) ExportInventory(c *gin.Context) {
+	format, err := export.ParseFormat(c.DefaultQuery("format", string(export.CSV)))
+	if err != nil {
+		respondError(c, errors.NewInvalidRequest(err.Error(), ""))
+		return
+	}
+
+	filter := repository.ExportFilter{SKUPrefix: c.Query("sku_prefix")}
+	for name, target := range map[string]*bool{"in_stock": &filter.InStock, "include_deleted": &filter.IncludeDeleted} {
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		if *target, err = strconv.ParseBool(raw); err != nil {
+			respondError(c, errors.NewInvalidRequest(name+" must be true or false", ""))
+			return
+		}
+	}
+
+	if h.repository == nil {
+		respondError(c, errors.NewInternalError("inventory export is not available", nil))
+		return
+	}
+
+	// The response starts with the first row, so a query that fails up front still gets a 500
+	writer := export.NewWriter(format, c.Writer)
+	started := false
+	start := func() {
+		started = true
+		filename := fmt.Sprintf("inventory-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+		c.Header("Content-Type", format.ContentType())
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		c.Status(http.StatusOK)
+		// Sending the headers now switches the response envelope to pass-through
+		c.Writer.Flush()
+	}
+
+	rows := 0
+	err = h.repository.ExportItems(c.Request.Context(), filter, func(item models.InventoryItem) error {
+		if !started {
+			start()
+		}
+		if err := writer.Write(item); err != nil {
+			return err
+		}
+		rows++
+		if rows%exportFlushRows == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if !started {
+			h.logger.Error("Failed to export inventory", zap.Error(err))
+			respondError(c, errors.NewInternalError("failed to export inventory", nil))
+			return
+		}
+		// Headers are already sent: the truncated body is the only signal left to the client
+		h.logger.Error("Inventory export interrupted", zap.Int("rows", rows), zap.Error(err))
+		return
+	}
+
+	if !started {
+		start()
+	}
+	if err := writer.Close(); err != nil {
+		h.logger.Error("Failed to finish inventory export", zap.Int("rows", rows), zap.Error(err))
+		return
+	}
+	h.logger.Info("Inventory exported", zap.String("format", string(format)), zap.Int("rows", rows))
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/repository"
+	"query-service/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupExportRouter(t *testing.T, repo repository.ExportRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := NewExportHandler(zap.NewNop(), repo)
+
+	router := gin.New()
+	router.Use(middleware.ResponseEnvelope(true))
+	router.GET("/api/v1/inventory/export", handler.ExportInventory)
+	return router
+}
+
+func seedExportItems(t *testing.T, count int) *repository.InMemoryReadRepository {
+	repo := repository.NewInMemoryReadRepository()
+	now := time.Now().UTC()
+	for i := 0; i < count; i++ {
+		require.NoError(t, repo.SaveItem(models.InventoryItem{
+			ID: uuid.NewString(), SKU: fmt.Sprintf("SKU-%04d", i), Name: "Item",
+			Quantity: i % 3, Available: i % 3, CreatedAt: now, UpdatedAt: now,
+		}))
+	}
+	return repo
+}
+
+func TestExportInventory_CSV(t *testing.T) {
+	// More rows than a chunk, so the response is flushed mid-way
+	router := setupExportRouter(t, seedExportItems(t, exportFlushRows+10))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/inventory/export", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), `attachment; filename="inventory-`)
+	records, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, exportFlushRows+11)
+	assert.Equal(t, "sku", records[0][1])
+	assert.Equal(t, "SKU-0000", records[1][1])
+}
+
+func TestExportInventory_JSONFilters(t *testing.T) {
+	router := setupExportRouter(t, seedExportItems(t, 12))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/inventory/export?format=json&sku_prefix=SKU-000&in_stock=true", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	// The export is not wrapped in the response envelope
+	var items []map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &items))
+	skus := make([]string, 0, len(items))
+	for _, item := range items {
+		skus = append(skus, item["sku"].(string))
+	}
+	assert.Equal(t, []string{"SKU-0001", "SKU-0002", "SKU-0004", "SKU-0005", "SKU-0007", "SKU-0008"}, skus)
+}
+
+func TestExportInventory_XLSX(t *testing.T) {
+	router := setupExportRouter(t, seedExportItems(t, 3))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/inventory/export?format=xlsx", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	_, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	require.NoError(t, err)
+}
+
+func TestExportInventory_InvalidParameters(t *testing.T) {
+	router := setupExportRouter(t, seedExportItems(t, 1))
+
+	for _, query := range []string{"format=pdf", "in_stock=yes-please", "include_deleted=2"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/inventory/export?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+type failingExportRepository struct{}
+
+func (failingExportRepository) ExportItems(ctx context.Context, filter repository.ExportFilter, fn func(models.InventoryItem) error) error {
+	return errors.New("database is locked")
+}
+
+func TestExportInventory_ReadError(t *testing.T) {
+	router := setupExportRouter(t, failingExportRepository{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/inventory/export", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Disposition"))
+}
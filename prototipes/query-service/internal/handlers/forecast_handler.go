@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"query-service/internal/forecast"
+	"query-service/internal/repository"
+	"query-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ForecastHandler serves stock-out forecasts for replenishment planning
+type ForecastHandler struct {
+	logger    *zap.Logger
+	items     repository.ReadRepository
+	movements repository.MovementRepository
+}
+
+// NewForecastHandler creates a new forecast handler
+func NewForecastHandler(logger *zap.Logger, items repository.ReadRepository, movements repository.MovementRepository) *ForecastHandler {
+	return &ForecastHandler{
+		logger:    logger,
+		items:     items,
+		movements: movements,
+	}
+}
+
+// GetForecast handles GET /api/v1/inventory/items/:id/forecast
+// @Summary      Stock-out forecast
+// @Description  Proyecta la fecha de quiebre de stock a partir de la velocidad de consumo reciente (promedio móvil diario de los movimientos de salida).
+//
+// **Características:**
+// - `window_days`: días de historial usados para medir el consumo (default 30)
+// - `horizon_days`: días proyectados hacia adelante (default 30)
+// - `confidence`: probabilidad de que el stock dure al menos hasta la fecha conservadora (default 0.95)
+// - Sin consumo en la ventana no se proyecta quiebre (`days_until_stockout` = null)
+//
+// **Ejemplos válidos:**
+// - Por defecto: `GET /api/v1/inventory/items/{id}/forecast`
+// - Ventana de 14 días y horizonte de 60: `GET /api/v1/inventory/items/{id}/forecast?window_days=14&horizon_days=60`
+// - Confianza 90%: `GET /api/v1/inventory/items/{id}/forecast?confidence=0.9`
+//
+// **Ejemplos inválidos:**
+// - Ventana fuera de rango: `GET /api/v1/inventory/items/{id}/forecast?window_days=0`
+// - Confianza fuera de rango: `GET /api/v1/inventory/items/{id}/forecast?confidence=1.5`
+//
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id            path      string   true   "Item ID (UUID)"
+// @Param        window_days   query     int      false  "History window in days (1-365, default: 30)"
+// @Param        horizon_days  query     int      false  "Projection horizon in days (1-365, default: 30)"
+// @Param        confidence    query     number   false  "Confidence level (0.5-0.999, default: 0.95)"
+// @Success      200           {object}  models.StockForecast  "Pronóstico de stock"
+// @Failure      400           {object}  ErrorResponse  "Request inválido - ID o parámetros fuera de rango"
+// @Failure      401           {object}  ErrorResponse  "No autorizado - token JWT inválido o faltante"
+// @Failure      404           {object}  ErrorResponse  "Item no encontrado"
+// @Failure      500           {object}  ErrorResponse  "Error interno del servidor - error de lectura"
+// @Router       /inventory/items/{id}/forecast [get]
+func (h *ForecastHandler) GetForecast(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, errors.NewInvalidRequest("invalid item id", ""))
+		return
+	}
+
+	params := forecast.DefaultParams()
+	if value := c.Query("window_days"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 1 || days > 365 {
+			respondError(c, errors.NewInvalidRequest("window_days must be between 1 and 365", ""))
+			return
+		}
+		params.WindowDays = days
+	}
+	if value := c.Query("horizon_days"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 1 || days > 365 {
+			respondError(c, errors.NewInvalidRequest("horizon_days must be between 1 and 365", ""))
+			return
+		}
+		params.HorizonDays = days
+	}
+	if value := c.Query("confidence"); value != "" {
+		confidence, err := strconv.ParseFloat(value, 64)
+		if err != nil || confidence < 0.5 || confidence > 0.999 {
+			respondError(c, errors.NewInvalidRequest("confidence must be between 0.5 and 0.999", ""))
+			return
+		}
+		params.Confidence = confidence
+	}
+
+	item, err := h.items.FindByID(c.Request.Context(), id)
+	if err != nil {
+		if err == repository.ErrItemNotFound {
+			respondError(c, errors.NewItemNotFound(id.String()))
+			return
+		}
+		h.logger.Error("Failed to find item", zap.Error(err))
+		respondError(c, errors.NewInternalError("failed to compute forecast", nil))
+		return
+	}
+
+	now := time.Now().UTC()
+	since := now.Add(-time.Duration(params.WindowDays) * 24 * time.Hour)
+	movements, err := h.movements.ListMovements(c.Request.Context(), id, since)
+	if err != nil {
+		h.logger.Error("Failed to list stock movements", zap.Error(err))
+		respondError(c, errors.NewInternalError("failed to compute forecast", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, forecast.Compute(*item, movements, params, now))
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"query-service/internal/config"
+	"query-service/internal/models"
+	"query-service/internal/repository"
+	"query-service/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+// Item endpoints with a read policy, also used as the endpoint label of the metrics
+const (
+	endpointItemByID  = "item_by_id"
+	endpointItemBySKU = "item_by_sku"
+)
+
+// readPolicy bounds the latency of an item endpoint's read
+type readPolicy struct {
+	timeout    time.Duration // SLA of the whole read (cache and read model); 0 = none
+	hedgeAfter time.Duration // latency budget of the primary read before hedging; 0 = no hedging
+}
+
+// itemReadPolicies builds the read policy of each item endpoint from the configuration
+func itemReadPolicies(cfg *config.Config) map[string]readPolicy {
+	var hedgeAfter time.Duration
+	if cfg.HedgedReadsEnabled {
+		hedgeAfter = time.Duration(cfg.HedgeBudgetMs) * time.Millisecond
+	}
+	return map[string]readPolicy{
+		endpointItemByID:  {timeout: time.Duration(cfg.ItemByIDTimeoutMs) * time.Millisecond, hedgeAfter: hedgeAfter},
+		endpointItemBySKU: {timeout: time.Duration(cfg.ItemBySKUTimeoutMs) * time.Millisecond, hedgeAfter: hedgeAfter},
+	}
+}
+
+// itemRead is the outcome of an item lookup
+type itemRead struct {
+	item      *models.InventoryItem
+	fromCache bool
+	hedge     bool // served by the hedged read
+	err       error
+}
+
+// readItem reads an item cache first, then from the read model, within the endpoint's
+// read policy. When the read has not finished after the hedge budget (a slow cache or
+// a slow query) a second read goes straight to the read model and the first of the two
+// to answer wins; the other one is cancelled. Past the timeout the read fails with
+// context.DeadlineExceeded even if the backends ignore cancellation.
+func (h *InventoryHandler) readItem(ctx context.Context, endpoint, cacheKey string, find func(ctx context.Context) (*models.InventoryItem, error)) itemRead {
+	policy := h.readPolicies[endpoint]
+	if policy.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.timeout)
+		defer cancel()
+	}
+
+	primary := func(ctx context.Context) itemRead {
+		if h.cache != nil {
+			var cachedItem models.InventoryItem
+			if err := h.cacheGetJSON(ctx, cacheKey, &cachedItem); err == nil {
+				h.logger.Debug("Cache hit", zap.String("key", cacheKey))
+				return itemRead{item: &cachedItem, fromCache: true}
+			}
+		}
+		item, err := find(ctx)
+		return itemRead{item: item, err: err}
+	}
+	fallback := func(ctx context.Context) itemRead {
+		item, err := find(ctx)
+		return itemRead{item: item, hedge: true, err: err}
+	}
+
+	var read itemRead
+	switch {
+	case policy.hedgeAfter > 0:
+		read = hedgedRead(ctx, endpoint, policy.hedgeAfter, primary, fallback)
+	case policy.timeout > 0:
+		read = boundedRead(ctx, primary)
+	default:
+		read = primary(ctx)
+	}
+	if errors.Is(read.err, context.DeadlineExceeded) {
+		metrics.ReadTimeouts.WithLabelValues(endpoint).Inc()
+	}
+	return read
+}
+
+// boundedRead runs read and gives up when ctx is done, whether or not read returns
+func boundedRead(ctx context.Context, read func(ctx context.Context) itemRead) itemRead {
+	results := make(chan itemRead, 1)
+	go func() { results <- read(ctx) }()
+	select {
+	case result := <-results:
+		return result
+	case <-ctx.Done():
+		return itemRead{err: ctx.Err()}
+	}
+}
+
+// hedgedRead runs primary and, if it has not answered after hedgeAfter, fallback as
+// well. The first definitive answer (an item or "not found") is returned; an error
+// only once both reads have failed. A primary that fails before the budget is not
+// hedged: hedging trades load for latency, it is not a retry.
+func hedgedRead(ctx context.Context, endpoint string, hedgeAfter time.Duration, primary, fallback func(ctx context.Context) itemRead) itemRead {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // cancels the read that lost
+
+	results := make(chan itemRead, 2)
+	go func() { results <- primary(ctx) }()
+	timer := time.NewTimer(hedgeAfter)
+	defer timer.Stop()
+
+	pending, hedged := 1, false
+	var failed itemRead
+	for {
+		select {
+		case <-timer.C:
+			hedged = true
+			pending++
+			metrics.HedgedReads.WithLabelValues(endpoint).Inc()
+			go func() { results <- fallback(ctx) }()
+
+		case result := <-results:
+			pending--
+			if result.err == nil || errors.Is(result.err, repository.ErrItemNotFound) || !hedged {
+				if hedged {
+					winner := "primary"
+					if result.hedge {
+						winner = "hedge"
+					}
+					metrics.HedgedReadWins.WithLabelValues(endpoint, winner).Inc()
+				}
+				return result
+			}
+			failed = result
+			if pending == 0 {
+				return failed
+			}
+
+		case <-ctx.Done():
+			return itemRead{err: ctx.Err()}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"query-service/internal/cache"
+	"query-service/internal/models"
+	"query-service/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetItemByID_HedgesSlowCache(t *testing.T) {
+	mockCache := new(MockCache)
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(mockCache, mockRepo)
+	handler.readPolicies = map[string]readPolicy{endpointItemByID: {hedgeAfter: 10 * time.Millisecond}}
+	router := setupTestRouter(handler)
+
+	itemID := uuid.New()
+	testItem := createTestItem(itemID, "SKU-001")
+
+	// The cache hangs; the hedged read goes to the repository and wins
+	mockCache.On("Get", mock.Anything, "item:id:"+itemID.String()).After(time.Second).Return(nil, cache.ErrCacheMiss)
+	mockRepo.On("FindByID", mock.Anything, itemID).Return(testItem, nil)
+	mockCache.On("Set", mock.Anything, "item:id:"+itemID.String(), mock.Anything, mock.Anything).Return(nil)
+
+	start := time.Now()
+	req := httptest.NewRequest("GET", "/api/v1/inventory/items/"+itemID.String(), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	mockRepo.AssertCalled(t, "FindByID", mock.Anything, itemID)
+}
+
+func TestGetItemBySKU_Timeout(t *testing.T) {
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(nil, mockRepo)
+	handler.readPolicies = map[string]readPolicy{endpointItemBySKU: {timeout: 20 * time.Millisecond}}
+	router := setupTestRouter(handler)
+
+	mockRepo.On("FindBySKU", mock.Anything, "SKU-SLOW").After(time.Second).Return(createTestItem(uuid.New(), "SKU-SLOW"), nil)
+
+	req := httptest.NewRequest("GET", "/api/v1/inventory/items/sku/SKU-SLOW", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+}
+
+func TestHedgedRead(t *testing.T) {
+	item := &models.InventoryItem{ID: uuid.New().String()}
+	slow := func(result itemRead) func(ctx context.Context) itemRead {
+		return func(ctx context.Context) itemRead {
+			select {
+			case <-time.After(time.Second):
+				return result
+			case <-ctx.Done():
+				return itemRead{err: ctx.Err()}
+			}
+		}
+	}
+	fast := func(result itemRead) func(ctx context.Context) itemRead {
+		return func(ctx context.Context) itemRead { return result }
+	}
+
+	t.Run("fast primary is not hedged", func(t *testing.T) {
+		var hedged int32
+		fallback := func(ctx context.Context) itemRead {
+			atomic.AddInt32(&hedged, 1)
+			return itemRead{item: item, hedge: true}
+		}
+		result := hedgedRead(context.Background(), "test", 50*time.Millisecond, fast(itemRead{item: item}), fallback)
+		assert.NoError(t, result.err)
+		assert.False(t, result.hedge)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&hedged))
+	})
+
+	t.Run("slow primary loses to the hedge", func(t *testing.T) {
+		result := hedgedRead(context.Background(), "test", 5*time.Millisecond, slow(itemRead{item: item}), fast(itemRead{item: item, hedge: true}))
+		assert.NoError(t, result.err)
+		assert.True(t, result.hedge)
+	})
+
+	t.Run("not found from the hedge is an answer", func(t *testing.T) {
+		result := hedgedRead(context.Background(), "test", 5*time.Millisecond, slow(itemRead{item: item}), fast(itemRead{hedge: true, err: repository.ErrItemNotFound}))
+		assert.Equal(t, repository.ErrItemNotFound, result.err)
+	})
+
+	t.Run("a failed hedge waits for the primary", func(t *testing.T) {
+		primary := func(ctx context.Context) itemRead {
+			time.Sleep(30 * time.Millisecond)
+			return itemRead{item: item}
+		}
+		result := hedgedRead(context.Background(), "test", 5*time.Millisecond, primary, fast(itemRead{hedge: true, err: errors.New("connection reset")}))
+		assert.NoError(t, result.err)
+		assert.False(t, result.hedge)
+	})
+
+	t.Run("a primary failing before the budget is not retried", func(t *testing.T) {
+		fallback := func(ctx context.Context) itemRead {
+			t.Error("fallback should not run")
+			return itemRead{}
+		}
+		result := hedgedRead(context.Background(), "test", 50*time.Millisecond, fast(itemRead{err: errors.New("boom")}), fallback)
+		assert.EqualError(t, result.err, "boom")
+	})
+
+	t.Run("deadline stops both reads", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		result := hedgedRead(ctx, "test", 5*time.Millisecond, slow(itemRead{item: item}), slow(itemRead{item: item, hedge: true}))
+		assert.ErrorIs(t, result.err, context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/repository"
+	"query-service/pkg/errors"
+	"query-service/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// HistoryHandler serves the stock movement history (audit trail) of an item
+type HistoryHandler struct {
+	logger *zap.Logger
+	repo   repository.MovementRepository
+}
+
+// NewHistoryHandler creates a new history handler
+func NewHistoryHandler(logger *zap.Logger, repo repository.MovementRepository) *HistoryHandler {
+	return &HistoryHandler{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+// ItemHistoryResponse represents a page of an item's stock movements
+// @Description Paginated stock movement history of an item, most recent first
+type ItemHistoryResponse struct {
+	// Item ID
+	ItemID string `json:"item_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+
+	// Stock movements (adjustments, reservations, releases)
+	Movements []models.StockMovement `json:"movements"`
+
+	// Total number of matching movements
+	Total int `json:"total" example:"42"`
+
+	// Current page number
+	Page int `json:"page" example:"1"`
+
+	// Number of movements per page
+	PageSize int `json:"page_size" example:"20"`
+
+	// Total number of pages
+	TotalPages int `json:"total_pages" example:"3"`
+}
+
+// GetItemHistory handles GET /api/v1/inventory/items/:id/history
+// @Summary      Stock movement history
+// @Description  Lista los movimientos de stock de un item (ajustes, reservas y liberaciones), del más reciente al más antiguo, con el usuario y el request que los originaron. Lo alimenta la tabla `stock_movements` que escribe el Listener Service por cada evento procesado.
+//
+// **Características:**
+// - `actor`: usuario del token JWT con el que se ejecutó el comando (vacío en eventos anteriores a la atribución)
+// - `request_id`: ID del request HTTP que originó el movimiento
+// - `reason` y `reference`: código de motivo y referencia externa (p. ej. el ID del pedido) enviados con el comando
+// - Filtro por rango de fechas: `from` (inclusive) y `to` (exclusivo), en RFC3339 o `YYYY-MM-DD`
+// - Paginación (`page`, `page_size`, máximo 100)
+// - El historial se conserva aunque el item haya sido eliminado
+//
+// **Ejemplos válidos:**
+// - `GET /api/v1/inventory/items/550e8400-e29b-41d4-a716-446655440000/history`
+// - `GET /api/v1/inventory/items/550e8400-e29b-41d4-a716-446655440000/history?from=2024-01-01&to=2024-02-01`
+// - `GET /api/v1/inventory/items/550e8400-e29b-41d4-a716-446655440000/history?from=2024-01-15T10:00:00Z&page_size=50`
+//
+// **Ejemplos inválidos:**
+// - ID inválido: `GET /api/v1/inventory/items/invalid-uuid/history`
+// - Fecha inválida: `GET /api/v1/inventory/items/{id}/history?from=ayer`
+// - Rango invertido: `GET /api/v1/inventory/items/{id}/history?from=2024-02-01&to=2024-01-01`
+//
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id         path      string  true   "Item ID (UUID)" example(550e8400-e29b-41d4-a716-446655440000)
+// @Param        from       query     string  false  "Desde (RFC3339 o YYYY-MM-DD, inclusive)" example(2024-01-01)
+// @Param        to         query     string  false  "Hasta (RFC3339 o YYYY-MM-DD, exclusivo)" example(2024-02-01)
+// @Param        page       query     int     false  "Page number (default: 1, min: 1)" example(1)
+// @Param        page_size  query     int     false  "Movements per page (default: 20, min: 1, max: 100)" example(20)
+// @Success      200  {object}  ItemHistoryResponse  "Página del historial de movimientos"
+// @Failure      400  {object}  ErrorResponse  "Request inválido - ID o rango de fechas inválido"
+// @Failure      401  {object}  ErrorResponse  "No autorizado - token JWT inválido o faltante"
+// @Failure      500  {object}  ErrorResponse  "Error interno del servidor - error de lectura"
+// @Router       /inventory/items/{id}/history [get]
+func (h *HistoryHandler) GetItemHistory(c *gin.Context) {
+	itemID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, errors.NewInvalidRequest("invalid item ID", ""))
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		middleware.AddWarning(c, "page_size capped at 100")
+		pageSize = 100
+	}
+
+	var filter models.MovementFilter
+	if raw := c.Query("from"); raw != "" {
+		from, err := parseHistoryTime(raw)
+		if err != nil {
+			respondError(c, errors.NewInvalidRequest("invalid from, expected RFC3339 or YYYY-MM-DD", ""))
+			return
+		}
+		filter.From = &from
+	}
+	if raw := c.Query("to"); raw != "" {
+		to, err := parseHistoryTime(raw)
+		if err != nil {
+			respondError(c, errors.NewInvalidRequest("invalid to, expected RFC3339 or YYYY-MM-DD", ""))
+			return
+		}
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		respondError(c, errors.NewInvalidRequest("from must not be after to", ""))
+		return
+	}
+
+	movements, total, err := h.repo.ListItemHistory(c.Request.Context(), itemID, filter, page, pageSize)
+	if err != nil {
+		h.logger.Error("Failed to list item history", zap.String("item_id", itemID.String()), zap.Error(err))
+		respondError(c, errors.NewInternalError("failed to list item history", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, ItemHistoryResponse{
+		ItemID:     itemID.String(),
+		Movements:  movements,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	})
+}
+
+// parseHistoryTime accepts a full RFC3339 timestamp or a bare date (midnight UTC)
+func parseHistoryTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", raw)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupHistoryRouter(repo repository.MovementRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/inventory/items/:id/history", NewHistoryHandler(zap.NewNop(), repo).GetItemHistory)
+	return router
+}
+
+func TestGetItemHistory_DateRangeAndPagination(t *testing.T) {
+	repo := repository.NewInMemoryReadRepository()
+	itemID := uuid.New()
+	base := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	for i, movementType := range []string{"adjusted", "reserved", "released", "adjusted"} {
+		repo.AddMovement(models.StockMovement{
+			ID:           uuid.New().String(),
+			ItemID:       itemID.String(),
+			MovementType: movementType,
+			OccurredAt:   base.AddDate(0, 0, i),
+			Actor:        "operator",
+			RequestID:    "req-" + movementType,
+		})
+	}
+	repo.AddMovement(models.StockMovement{ID: uuid.New().String(), ItemID: uuid.New().String(), OccurredAt: base})
+
+	router := setupHistoryRouter(repo)
+	req := httptest.NewRequest("GET", "/api/v1/inventory/items/"+itemID.String()+"/history?from=2024-01-11&to=2024-01-13T12:00:00Z&page_size=1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response ItemHistoryResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, itemID.String(), response.ItemID)
+	assert.Equal(t, 2, response.Total)
+	assert.Equal(t, 2, response.TotalPages)
+	require.Len(t, response.Movements, 1)
+	// Newest first: the release on Jan 12 comes before the reservation on Jan 11
+	assert.Equal(t, "released", response.Movements[0].MovementType)
+	assert.Equal(t, "operator", response.Movements[0].Actor)
+	assert.Equal(t, "req-released", response.Movements[0].RequestID)
+}
+
+func TestGetItemHistory_InvalidRequests(t *testing.T) {
+	router := setupHistoryRouter(repository.NewInMemoryReadRepository())
+	itemID := uuid.New().String()
+
+	for _, path := range []string{
+		"/api/v1/inventory/items/invalid-uuid/history",
+		"/api/v1/inventory/items/" + itemID + "/history?from=yesterday",
+		"/api/v1/inventory/items/" + itemID + "/history?to=2024-13-01",
+		"/api/v1/inventory/items/" + itemID + "/history?from=2024-02-01&to=2024-01-01",
+	} {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+	}
+}
+
+func TestGetItemHistory_EmptyForUnknownItem(t *testing.T) {
+	router := setupHistoryRouter(repository.NewInMemoryReadRepository())
+
+	req := httptest.NewRequest("GET", "/api/v1/inventory/items/"+uuid.New().String()+"/history", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response ItemHistoryResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 0, response.Total)
+	assert.Empty(t, response.Movements)
+}
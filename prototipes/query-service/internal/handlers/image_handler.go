@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"query-service/internal/images"
+	"query-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ImageHandler serves the item image files of IMAGE_STORE=local
+type ImageHandler struct {
+	logger *zap.Logger
+	dir    string         // IMAGE_LOCAL_DIR, shared with the Command Service
+	signer *images.Signer // nil serves any file without a signature
+}
+
+// NewImageHandler creates the handler of the image files under dir
+func NewImageHandler(logger *zap.Logger, dir string, signer *images.Signer) *ImageHandler {
+	return &ImageHandler{logger: logger, dir: dir, signer: signer}
+}
+
+// ServeImage handles GET /api/v1/images/*key
+// @Summary      Item image file
+// @Description  Sirve el archivo de una imagen de item guardada por el Command Service con `IMAGE_STORE=local` (el directorio `IMAGE_LOCAL_DIR` compartido entre ambos servicios). Es la URL que llevan las respuestas de items en `images[].url`; no requiere token para que se pueda usar en un `<img>`.
+//
+// **URLs firmadas:** con `IMAGE_SIGNED_URLS=true` la URL debe traer `expires` y `signature` (HMAC-SHA256 con `IMAGE_SIGNING_KEY`) tal como la devuelve el Query Service; sin ellos, con una firma inválida o vencida responde 403. Sin URLs firmadas cualquiera que conozca la key puede descargar la imagen (las keys llevan dos UUID y no son adivinables).
+//
+// **Ejemplos válidos:**
+// - `GET /api/v1/images/default/550e8400-e29b-41d4-a716-446655440000/7c9e6679-7425-40de-944b-e07fc1f90ae7.png`
+// - `GET /api/v1/images/default/550e8400-.../7c9e6679-....png?expires=1705316400&signature=...`
+//
+// **Ejemplos inválidos:**
+// - Ruta fuera del directorio: `GET /api/v1/images/../config.yaml`
+// - Firma vencida o de otra key (con `IMAGE_SIGNED_URLS=true`)
+//
+// @Tags         images
+// @Produce      image/jpeg,image/png,image/gif,image/webp
+// @Param        key        path      string  true   "Key de la imagen ({tenant}/{item_id}/{image_id}.{ext})"
+// @Param        expires    query     int     false  "Vencimiento de la URL firmada (Unix)"
+// @Param        signature  query     string  false  "Firma de la URL"
+// @Success      200        {file}    file           "Archivo de la imagen"
+// @Failure      400        {object}  ErrorResponse  "Key inválida"
+// @Failure      403        {object}  ErrorResponse  "Firma faltante, inválida o vencida"
+// @Failure      404        {object}  ErrorResponse  "Imagen no encontrada"
+// @Router       /images/{key} [get]
+func (h *ImageHandler) ServeImage(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if key == "" || path.Clean(key) != key || strings.HasPrefix(key, "../") || strings.Contains(key, "\\") {
+		respondError(c, errors.NewInvalidRequest("invalid image key", ""))
+		return
+	}
+
+	if h.signer != nil {
+		if err := h.signer.Verify(key, c.Query("expires"), c.Query("signature")); err != nil {
+			respondError(c, errors.NewForbidden(err.Error(), ""))
+			return
+		}
+		c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", int(h.signer.ExpiresIn().Seconds())))
+	} else {
+		// Image IDs are never reused, so a file never changes under its key
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	}
+
+	file := filepath.Join(h.dir, filepath.FromSlash(key))
+	info, err := os.Stat(file)
+	if err != nil || info.IsDir() {
+		if err != nil && !stderrors.Is(err, os.ErrNotExist) {
+			h.logger.Error("Failed to read image file", zap.String("key", key), zap.Error(err))
+		}
+		c.Header("Cache-Control", "no-store")
+		respondError(c, errors.NewNotFound("image not found", "Key: "+key))
+		return
+	}
+	if contentType := mime.TypeByExtension(filepath.Ext(file)); contentType != "" {
+		c.Header("Content-Type", contentType)
+	}
+	c.Header("X-Content-Type-Options", "nosniff")
+	http.ServeFile(c.Writer, c.Request, file)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"query-service/internal/config"
+	"query-service/internal/images"
+	"query-service/internal/models"
+	"query-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestImageSigner(t *testing.T) *images.Signer {
+	signer, err := images.NewSigner(&config.Config{
+		ImageStore:         config.ImageStoreLocal,
+		ImagePublicBaseURL: "http://query/api/v1/images",
+		ImageSignedURLs:    true,
+		ImageURLTTLSeconds: 3600,
+		ImageSigningKey:    "image-signing-key-of-at-least-32-chars",
+	})
+	require.NoError(t, err)
+	return signer
+}
+
+func TestGetItem_WithImages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := repository.NewInMemoryReadRepository()
+	id := uuid.New()
+	require.NoError(t, repo.SaveItem(models.InventoryItem{ID: id.String(), SKU: "SKU-IMG", Name: "Item", Quantity: 1, Available: 1}))
+	require.NoError(t, repo.SaveItem(models.InventoryItem{ID: uuid.NewString(), SKU: "SKU-NONE", Name: "Item", Quantity: 1, Available: 1}))
+	now := time.Now().UTC()
+	for n, name := range []string{"back", "front"} {
+		repo.SaveItemImage(id, models.ItemImage{
+			ID: uuid.NewString(), Key: "default/" + id.String() + "/" + name + ".png",
+			URL:         "http://query/api/v1/images/default/" + id.String() + "/" + name + ".png",
+			ContentType: "image/png", Size: 100, CreatedAt: now.Add(time.Duration(n) * time.Second),
+		})
+	}
+
+	for _, signer := range []*images.Signer{nil, newTestImageSigner(t)} {
+		handler := &InventoryHandler{logger: zap.NewNop(), repository: repo, images: repo, imageURLs: signer}
+		router := gin.New()
+		router.GET("/api/v1/inventory/items", handler.ListItems)
+		router.GET("/api/v1/inventory/items/:id", handler.GetItemByID)
+		router.GET("/api/v1/inventory/items/sku/:sku", handler.GetItemBySKU)
+
+		for _, path := range []string{"/api/v1/inventory/items/" + id.String(), "/api/v1/inventory/items/sku/SKU-IMG"} {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			require.Equal(t, http.StatusOK, w.Code)
+			var item InventoryItemResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &item))
+			require.Len(t, item.Images, 2)
+			assert.True(t, strings.HasSuffix(strings.Split(item.Images[0].URL, "?")[0], "/back.png"))
+			assert.Equal(t, "image/png", item.Images[0].ContentType)
+			if signer == nil {
+				assert.NotContains(t, item.Images[0].URL, "signature=")
+			} else {
+				assert.Contains(t, item.Images[0].URL, "signature=")
+			}
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/inventory/items?page_size=10", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		var list ListItemsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		require.Len(t, list.Items, 2)
+		for _, item := range list.Items {
+			if item.SKU == "SKU-IMG" {
+				assert.Len(t, item.Images, 2)
+			} else {
+				assert.Empty(t, item.Images)
+			}
+		}
+	}
+}
+
+func TestServeImage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	key := "default/item-1/image-1.png"
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "default", "item-1"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, filepath.FromSlash(key)), []byte("\x89PNG\r\n\x1a\n"), 0o644))
+
+	serve := func(signer *images.Signer, path string) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.GET("/api/v1/images/*key", NewImageHandler(zap.NewNop(), dir, signer).ServeImage)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := serve(nil, "/api/v1/images/"+key)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Cache-Control"), "immutable")
+
+	assert.Equal(t, http.StatusNotFound, serve(nil, "/api/v1/images/default/item-1/missing.png").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(nil, "/api/v1/images/default/../../etc/passwd").Code)
+
+	signer := newTestImageSigner(t)
+	assert.Equal(t, http.StatusForbidden, serve(signer, "/api/v1/images/"+key).Code)
+	signed, err := url.Parse(signer.URL(models.ItemImage{Key: key}))
+	require.NoError(t, err)
+	w = serve(signer, signed.RequestURI())
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Cache-Control"), "private")
+
+	// The signature of one key does not open another
+	other := strings.Replace(signed.RequestURI(), "image-1.png", "missing.png", 1)
+	assert.Equal(t, http.StatusForbidden, serve(signer, other).Code)
+}
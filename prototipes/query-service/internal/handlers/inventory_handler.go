@@ -1,15 +1,23 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
 
 	"query-service/internal/cache"
 	"query-service/internal/config"
+	"query-service/internal/images"
 	"query-service/internal/models"
 	"query-service/internal/repository"
+	"query-service/internal/schemacheck"
+	"query-service/pkg/errors"
+	"query-service/pkg/middleware"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -17,10 +25,31 @@ import (
 )
 
 type InventoryHandler struct {
-	logger     *zap.Logger
-	repository repository.ReadRepository
-	cache      cache.Cache
-	cacheTTL   int
+	logger        *zap.Logger
+	repository    repository.ReadRepository
+	deleted       repository.DeletedItemsRepository // Soft-deleted items (include_deleted=true), nil if unsupported
+	cursor        repository.CursorItemsRepository  // Keyset listing (after), nil if unsupported
+	valuation     repository.ValuationRepository
+	export        repository.ExportRepository
+	reservations  repository.ReservationRepository
+	movements     repository.MovementRepository
+	waitlist      repository.WaitlistRepository
+	activity      repository.ActivityRepository
+	calendars     repository.StoreCalendarRepository
+	relations     repository.RelationRepository
+	locations     repository.LocationRepository
+	images        repository.ImageRepository
+	identifiers   repository.IdentifierRepository
+	purchasing    repository.PurchasingRepository
+	imageURLs     *images.Signer // nil serves the published image URLs
+	stats         repository.StatsRepository
+	cache         cache.Cache
+	cacheTTL      int
+	statsTTL      int                   // STATS_CACHE_TTL
+	lowStock      int                   // LOW_STOCK_THRESHOLD
+	ttlJitter     int                   // CACHE_TTL_JITTER_PERCENT
+	compressCache bool                  // Store the serialized list responses gzip-compressed
+	readPolicies  map[string]readPolicy // Timeout and hedging of the item endpoints
 }
 
 // GetRepository returns the repository instance (for Kafka consumer)
@@ -28,29 +57,142 @@ func (h *InventoryHandler) GetRepository() repository.ReadRepository {
 	return h.repository
 }
 
-func NewInventoryHandler(logger *zap.Logger, cfg *config.Config) (*InventoryHandler, error) {
-	// Create repository (SQLite or InMemory)
+// GetMovementRepository returns the stock movement repository
+func (h *InventoryHandler) GetMovementRepository() repository.MovementRepository {
+	return h.movements
+}
+
+// GetWaitlistRepository returns the reservation waitlist repository
+func (h *InventoryHandler) GetWaitlistRepository() repository.WaitlistRepository {
+	return h.waitlist
+}
+
+// GetActivityRepository returns the activity log repository
+func (h *InventoryHandler) GetActivityRepository() repository.ActivityRepository {
+	return h.activity
+}
+
+// GetReservationRepository returns the store reservation repository
+func (h *InventoryHandler) GetReservationRepository() repository.ReservationRepository {
+	return h.reservations
+}
+
+// GetStoreCalendarRepository returns the store calendar repository
+func (h *InventoryHandler) GetStoreCalendarRepository() repository.StoreCalendarRepository {
+	return h.calendars
+}
+
+// GetPurchasingRepository returns the supplier and purchase order repository
+func (h *InventoryHandler) GetPurchasingRepository() repository.PurchasingRepository {
+	return h.purchasing
+}
+
+// GetImageURLSigner returns the signer of the image URLs (nil when IMAGE_SIGNED_URLS=false)
+func (h *InventoryHandler) GetImageURLSigner() *images.Signer {
+	return h.imageURLs
+}
+
+// GetCache returns the handler's cache client (nil when the cache is disabled)
+func (h *InventoryHandler) GetCache() cache.Cache {
+	return h.cache
+}
+
+// GetValuationRepository returns the cost layer repository (for the valuation report)
+func (h *InventoryHandler) GetValuationRepository() repository.ValuationRepository {
+	return h.valuation
+}
+
+// GetExportRepository returns the repository that streams inventory exports
+func (h *InventoryHandler) GetExportRepository() repository.ExportRepository {
+	return h.export
+}
+
+func NewInventoryHandler(logger *zap.Logger, cfg *config.Config, schemaChecker *schemacheck.Checker) (*InventoryHandler, error) {
+	// Create repository (SQLite, PostgreSQL or InMemory)
 	var repo repository.ReadRepository
-	var err error
 
-	if cfg.SQLitePath != "" {
-		// Use SQLite repository (reads from same database as Listener Service)
-		logger.Info("Initializing SQLite repository", zap.String("path", cfg.SQLitePath))
-		repo, err = repository.NewSQLiteReadRepository(cfg.SQLitePath)
+	if cfg.DBDriver == repository.DriverPostgres || cfg.SQLitePath != "" {
+		// Read the same database the Listener Service writes
+		var primary repository.ReadRepository
+		var err error
+		switch cfg.DBDriver {
+		case repository.DriverPostgres:
+			logger.Info("Initializing PostgreSQL repository")
+			primary, err = repository.NewPostgresListenerReadRepository(cfg.PostgresDSN)
+		case repository.DriverSQLite:
+			logger.Info("Initializing SQLite repository", zap.String("path", cfg.SQLitePath))
+			primary, err = repository.NewSQLiteReadRepository(cfg.SQLitePath)
+		default:
+			err = fmt.Errorf("unsupported DB_DRIVER %q", cfg.DBDriver)
+		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to create SQLite repository: %w", err)
+			return nil, fmt.Errorf("failed to create %s repository: %w", cfg.DBDriver, err)
 		}
-		logger.Info("SQLite repository initialized successfully")
+		logger.Info("Read model repository initialized successfully", zap.String("driver", cfg.DBDriver))
+
+		// Fail now, not on the first query, if the Listener Service wrote another schema
+		if versioned, ok := primary.(repository.SchemaVersioned); ok {
+			if err := schemaChecker.Check(context.Background(), versioned); err != nil {
+				if closer, ok := primary.(io.Closer); ok {
+					closer.Close()
+				}
+				return nil, err
+			}
+		}
+		repo = primary
 	} else {
 		// Fallback to in-memory repository (for testing)
 		logger.Warn("Using in-memory repository (SQLite not configured)")
 		repo = repository.NewReadRepository()
 	}
 
+	// Cost layers, store reservations and calendars, item relations, locations, images and identifiers, movements, the waitlist, the activity log, the statistics and the purchase orders are always read from the primary read model
+	deletedRepo, _ := repo.(repository.DeletedItemsRepository)
+	cursorRepo, _ := repo.(repository.CursorItemsRepository)
+	valuationRepo, _ := repo.(repository.ValuationRepository)
+	exportRepo, _ := repo.(repository.ExportRepository)
+	reservationRepo, _ := repo.(repository.ReservationRepository)
+	movementRepo, _ := repo.(repository.MovementRepository)
+	waitlistRepo, _ := repo.(repository.WaitlistRepository)
+	activityRepo, _ := repo.(repository.ActivityRepository)
+	calendarRepo, _ := repo.(repository.StoreCalendarRepository)
+	relationRepo, _ := repo.(repository.RelationRepository)
+	locationRepo, _ := repo.(repository.LocationRepository)
+	imageRepo, _ := repo.(repository.ImageRepository)
+	identifierRepo, _ := repo.(repository.IdentifierRepository)
+	purchasingRepo, _ := repo.(repository.PurchasingRepository)
+	statsRepo, _ := repo.(repository.StatsRepository)
+
+	// Shadow reads: serve from the primary, compare against the candidate in background
+	if cfg.ShadowReadsEnabled {
+		if cfg.ShadowPostgresDSN == "" {
+			logger.Warn("Shadow reads enabled but SHADOW_POSTGRES_DSN is empty, shadow mode disabled")
+		} else {
+			logger.Info("Initializing shadow read repository (PostgreSQL candidate)",
+				zap.Int("sample_percent", cfg.ShadowSamplePct),
+				zap.Int("timeout_ms", cfg.ShadowTimeoutMs),
+			)
+			candidate, err := repository.NewPostgresReadRepository(cfg.ShadowPostgresDSN)
+			if err != nil {
+				// The candidate must never prevent the service from serving reads
+				logger.Warn("Failed to connect to shadow candidate, shadow mode disabled", zap.Error(err))
+			} else {
+				repo = repository.NewShadowReadRepository(repo, candidate, logger,
+					time.Duration(cfg.ShadowTimeoutMs)*time.Millisecond, cfg.ShadowSamplePct)
+				logger.Info("Shadow read repository initialized successfully")
+			}
+		}
+	}
+
+	imageURLs, err := images.NewSigner(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create image URL signer: %w", err)
+	}
+
 	// Create cache client (optional)
 	var cacheClient cache.Cache
 	if cfg.UseCache {
-		logger.Info("Initializing cache (Redis)", zap.String("host", cfg.RedisHost), zap.String("port", cfg.RedisPort))
+		logger.Info("Initializing cache", zap.String("backend", cfg.CacheBackend))
 		cacheClient = cache.NewCache(cfg, logger)
 		logger.Info("Cache initialized successfully")
 	} else {
@@ -59,10 +201,31 @@ func NewInventoryHandler(logger *zap.Logger, cfg *config.Config) (*InventoryHand
 	}
 
 	return &InventoryHandler{
-		logger:     logger,
-		repository: repo,
-		cache:      cacheClient,
-		cacheTTL:   cfg.CacheTTL,
+		logger:        logger,
+		repository:    repo,
+		deleted:       deletedRepo,
+		cursor:        cursorRepo,
+		valuation:     valuationRepo,
+		export:        exportRepo,
+		reservations:  reservationRepo,
+		movements:     movementRepo,
+		waitlist:      waitlistRepo,
+		activity:      activityRepo,
+		calendars:     calendarRepo,
+		relations:     relationRepo,
+		locations:     locationRepo,
+		images:        imageRepo,
+		identifiers:   identifierRepo,
+		purchasing:    purchasingRepo,
+		imageURLs:     imageURLs,
+		stats:         statsRepo,
+		cache:         cacheClient,
+		cacheTTL:      cfg.CacheTTL,
+		statsTTL:      cfg.StatsCacheTTL,
+		lowStock:      cfg.LowStockThreshold,
+		ttlJitter:     cfg.CacheTTLJitterPercent,
+		compressCache: cfg.CacheCompressResponses,
+		readPolicies:  itemReadPolicies(cfg),
 	}, nil
 }
 
@@ -75,26 +238,41 @@ func NewInventoryHandler(logger *zap.Logger, cfg *config.Config) (*InventoryHand
 // - Cache de resultados para mejor rendimiento
 // - Respuestas rápidas y escalables
 // - Cache-first strategy para baja latencia
+// - GET condicional: responde `ETag` y `Last-Modified`; con `If-None-Match` (o `If-Modified-Since`) responde `304 Not Modified` sin cuerpo si el cliente ya tiene esta versión
+// - Los items eliminados (soft delete) se excluyen salvo con `include_deleted=true`, que no usa el cache
+// - Paginación por cursor: cada respuesta con más items trae `next_cursor`; pasado como `after` devuelve los `page_size` items siguientes sin recorrer las páginas anteriores, y sin saltear ni repetir items aunque se agreguen nuevos mientras tanto. Con `after` se ignora `page` y la respuesta no trae `page`
 //
 // **Ejemplos válidos:**
 // - Lista con paginación por defecto: `GET /api/v1/inventory/items`
 // - Lista con paginación personalizada: `GET /api/v1/inventory/items?page=1&page_size=20`
 // - Primera página: `GET /api/v1/inventory/items?page=1&page_size=10`
+// - Incluir items eliminados: `GET /api/v1/inventory/items?include_deleted=true`
+// - Página siguiente por cursor: `GET /api/v1/inventory/items?page_size=20&after=MjAyNC0wMS0xNVQxMDozMDowMFp8NTUwZTg0MDAtZTI5Yi00MWQ0LWE3MTYtNDQ2NjU1NDQwMDAw`
 //
 // **Ejemplos inválidos:**
 // - Página negativa: `GET /api/v1/inventory/items?page=-1`
 // - Page size mayor a 100: `GET /api/v1/inventory/items?page_size=200`
 // - Page size negativo: `GET /api/v1/inventory/items?page_size=-10`
+// - include_deleted no booleano: `GET /api/v1/inventory/items?include_deleted=maybe`
+// - Cursor inválido: `GET /api/v1/inventory/items?after=abc`
+// - Cursor con items eliminados: `GET /api/v1/inventory/items?after=...&include_deleted=true`
 //
 // @Tags         inventory
 // @Accept       json
-// @Produce      json
+// @Produce      json,xml
 // @Security     BearerAuth
 // @Param        X-Request-ID  header    string  false  "Request ID for request tracking (UUID). If not provided, a new one will be generated."
+// @Param        If-None-Match      header  string  false  "ETag de una respuesta anterior; si coincide responde 304"
+// @Param        If-Modified-Since  header  string  false  "Fecha HTTP; si el recurso no cambió desde entonces responde 304 (ignorado con If-None-Match)"
 // @Param        page          query     int     false  "Page number (default: 1, min: 1)" example(1)
 // @Param        page_size     query     int     false  "Items per page (default: 10, min: 1, max: 100)" example(10)
+// @Param        include_deleted  query  bool    false  "Include soft-deleted items (default: false)"
+// @Param        after         query     string  false  "Cursor (next_cursor de una respuesta anterior); devuelve los items siguientes"
 // @Success      200           {object}  ListItemsResponse  "Lista de items obtenida exitosamente"
-// @Failure      400           {object}  ErrorResponse      "Request inválido - parámetros de paginación inválidos"
+// @Header       200           {string}  ETag           "Validador débil de la representación (W/\"...\")"
+// @Header       200           {string}  Last-Modified  "updated_at más reciente del recurso"
+// @Success      304           "No modificado - la versión del cliente está al día"
+// @Failure      400           {object}  ErrorResponse      "Request inválido - parámetros de paginación, cursor o include_deleted inválidos"
 // @Failure      401           {object}  ErrorResponse      "No autorizado - token JWT inválido o faltante"
 // @Failure      500           {object}  ErrorResponse      "Error interno del servidor - error de lectura o conexión a base de datos"
 // @Failure      503           {object}  ErrorResponse      "Servicio no disponible - error de conexión al cache"
@@ -110,25 +288,81 @@ func (h *InventoryHandler) ListItems(c *gin.Context) {
 		pageSize = 10
 	}
 	if pageSize > 100 {
+		middleware.AddWarning(c, "page_size capped at 100")
 		pageSize = 100
 	}
-
-	// Try cache first (if enabled)
-	if h.cache != nil {
-		cacheKey := cacheKeyListItems(page, pageSize)
-		var cachedResponse ListItemsResponse
-		if err := cache.GetJSON(c.Request.Context(), h.cache, cacheKey, &cachedResponse); err == nil {
-			h.logger.Debug("Cache hit", zap.String("key", cacheKey))
-			c.JSON(http.StatusOK, cachedResponse)
+	includeDeleted, ok := h.includeDeleted(c)
+	if !ok {
+		return
+	}
+	after := c.Query("after")
+	var cursor *repository.ItemCursor
+	if after != "" {
+		if includeDeleted {
+			respondError(c, errors.NewInvalidRequest("after cannot be combined with include_deleted", ""))
 			return
 		}
+		if h.cursor == nil {
+			respondError(c, errors.NewInternalError("cursor pagination is not available", nil))
+			return
+		}
+		var err error
+		if cursor, err = decodeItemCursor(after); err != nil {
+			respondError(c, errors.NewInvalidRequest("invalid after cursor", err.Error()))
+			return
+		}
+		page = 0
+	}
+	cacheKey := cacheKeyListItems(page, pageSize)
+	if cursor != nil {
+		cacheKey = cacheKeyListItemsAfter(after, pageSize)
+	}
+
+	// Try cache first (if enabled); the cache only holds live items, as the serialized response
+	if h.cache != nil && !includeDeleted {
+		if data, err := h.cacheGet(c.Request.Context(), cacheKey); err == nil {
+			if entry, ok := parseSerializedResponse(data); ok {
+				h.logger.Debug("Cache hit", zap.String("key", cacheKey))
+				err := respondSerialized(c, entry, func() (interface{}, error) {
+					var cachedResponse ListItemsResponse
+					body, err := entry.plain()
+					if err == nil {
+						err = json.Unmarshal(body, &cachedResponse)
+					}
+					return cachedResponse, err
+				})
+				if err == nil {
+					return
+				}
+				h.logger.Warn("Invalid cached response, reading the repository", zap.String("key", cacheKey), zap.Error(err))
+			}
+		}
 	}
 
 	// Cache miss - fetch from repository
-	items, total, err := h.repository.ListItems(c.Request.Context(), page, pageSize)
+	var items []models.InventoryItem
+	var total int
+	var more bool
+	var err error
+	switch {
+	case includeDeleted:
+		items, total, err = h.deleted.ListItemsIncludingDeleted(c.Request.Context(), page, pageSize)
+	case cursor != nil:
+		// One item more than the page tells whether there is a next one
+		items, total, err = h.cursor.ListItemsAfter(c.Request.Context(), cursor, pageSize+1)
+		if len(items) > pageSize {
+			items, more = items[:pageSize], true
+		}
+	default:
+		items, total, err = h.repository.ListItems(c.Request.Context(), page, pageSize)
+		more = page*pageSize < total
+	}
+	if err == nil {
+		err = h.loadImages(c.Request.Context(), items)
+	}
 	if err != nil {
 		h.logger.Error("Failed to list items", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list items"})
+		respondError(c, errors.NewInternalError("failed to list items", nil))
 		return
 	}
 
@@ -145,6 +379,8 @@ func (h *InventoryHandler) ListItems(c *gin.Context) {
 			Available:   item.Available,
 			CreatedAt:   item.CreatedAt.Format(time.RFC3339),
 			UpdatedAt:   item.UpdatedAt.Format(time.RFC3339),
+			DeletedAt:   formatDeletedAt(item.DeletedAt),
+			Images:      h.imageResponses(item.Images),
 		}
 	}
 
@@ -156,14 +392,26 @@ func (h *InventoryHandler) ListItems(c *gin.Context) {
 		PageSize:   pageSize,
 		TotalPages: totalPages,
 	}
+	// The cursor continues the live listing, so it is not offered with include_deleted
+	if more && len(items) > 0 && h.cursor != nil && !includeDeleted {
+		response.NextCursor = encodeItemCursor(items[len(items)-1])
+	}
 
-	// Cache the response (if enabled)
-	if h.cache != nil {
-		cacheKey := cacheKeyListItems(page, pageSize)
-		cache.SetJSON(c.Request.Context(), h.cache, cacheKey, response, cache.TTL(h.cacheTTL))
+	// Cache the serialized response (if enabled) and send the same bytes
+	if h.cache != nil && !includeDeleted {
+		entry, err := newSerializedResponse(response, latestUpdate(response.Items), h.compressCache)
+		if err == nil {
+			h.cache.Set(c.Request.Context(), cacheKey, entry.marshal(), h.entryTTL(h.cacheTTL))
+			if err := respondSerialized(c, entry, func() (interface{}, error) { return response, nil }); err == nil {
+				return
+			}
+		}
 	}
 
-	c.JSON(http.StatusOK, response)
+	if notModified(c, entityTag(c, response), latestUpdate(response.Items)) {
+		return
+	}
+	respond(c, http.StatusOK, response)
 }
 
 // GetItemByID handles GET /api/v1/inventory/items/:id
@@ -175,9 +423,12 @@ func (h *InventoryHandler) ListItems(c *gin.Context) {
 // - Respuestas ultra-rápidas (cache hit)
 // - Escalable horizontalmente
 // - Cache-first strategy para baja latencia
+// - GET condicional: responde `ETag` y `Last-Modified`; con `If-None-Match` (o `If-Modified-Since`) responde `304 Not Modified` sin cuerpo si el cliente ya tiene esta versión
+// - Un item eliminado (soft delete) responde 404 salvo con `include_deleted=true`, que no usa el cache
 //
 // **Ejemplos válidos:**
 // - Obtener item por ID válido: `GET /api/v1/inventory/items/550e8400-e29b-41d4-a716-446655440000`
+// - Obtener un item eliminado: `GET /api/v1/inventory/items/550e8400-e29b-41d4-a716-446655440000?include_deleted=true`
 //
 // **Ejemplos inválidos:**
 // - ID inválido (UUID malformado): `GET /api/v1/inventory/items/invalid-id`
@@ -185,55 +436,59 @@ func (h *InventoryHandler) ListItems(c *gin.Context) {
 //
 // @Tags         inventory
 // @Accept       json
-// @Produce      json
+// @Produce      json,xml
 // @Security     BearerAuth
 // @Param        X-Request-ID  header    string  false  "Request ID for request tracking (UUID). If not provided, a new one will be generated."
+// @Param        If-None-Match      header  string  false  "ETag de una respuesta anterior; si coincide responde 304"
+// @Param        If-Modified-Since  header  string  false  "Fecha HTTP; si el recurso no cambió desde entonces responde 304 (ignorado con If-None-Match)"
 // @Param        id            path      string  true   "Item ID (UUID)" example(550e8400-e29b-41d4-a716-446655440000)
+// @Param        include_deleted  query  bool    false  "Also return the item if it is soft-deleted (default: false)"
 // @Success      200           {object}  InventoryItemResponse  "Item obtenido exitosamente"
-// @Failure      400           {object}  ErrorResponse          "ID inválido - UUID malformado"
+// @Header       200           {string}  ETag           "Validador débil de la representación (W/\"...\")"
+// @Header       200           {string}  Last-Modified  "updated_at más reciente del recurso"
+// @Success      304           "No modificado - la versión del cliente está al día"
+// @Failure      400           {object}  ErrorResponse          "ID o include_deleted inválido"
 // @Failure      401           {object}  ErrorResponse          "No autorizado - token JWT inválido o faltante"
 // @Failure      404           {object}  ErrorResponse          "Item no encontrado"
 // @Failure      500           {object}  ErrorResponse          "Error interno del servidor - error de lectura o conexión a base de datos"
 // @Failure      503           {object}  ErrorResponse          "Servicio no disponible - error de conexión al cache"
+// @Failure      504           {object}  ErrorResponse          "Timeout - la lectura superó el timeout del endpoint (ITEM_BY_*_TIMEOUT_MS)"
 // @Router       /inventory/items/{id} [get]
 func (h *InventoryHandler) GetItemByID(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item id"})
+		respondError(c, errors.NewInvalidRequest("invalid item id", ""))
 		return
 	}
-
-	// Try cache first (if enabled)
-	if h.cache != nil {
-		cacheKey := cacheKeyItemByID(id.String())
-		var cachedItem models.InventoryItem
-		if err := cache.GetJSON(c.Request.Context(), h.cache, cacheKey, &cachedItem); err == nil {
-			h.logger.Debug("Cache hit", zap.String("key", cacheKey))
-			response := InventoryItemResponse{
-				ID:          cachedItem.ID,
-				SKU:         cachedItem.SKU,
-				Name:        cachedItem.Name,
-				Description: cachedItem.Description,
-				Quantity:    cachedItem.Quantity,
-				Reserved:    cachedItem.Reserved,
-				Available:   cachedItem.Available,
-				CreatedAt:   cachedItem.CreatedAt.Format(time.RFC3339),
-				UpdatedAt:   cachedItem.UpdatedAt.Format(time.RFC3339),
-			}
-			c.JSON(http.StatusOK, response)
-			return
-		}
+	includeDeleted, ok := h.includeDeleted(c)
+	if !ok {
+		return
 	}
 
-	// Cache miss - fetch from repository
-	item, err := h.repository.FindByID(c.Request.Context(), id)
+	// Cache first, then the repository, within the endpoint's timeout and hedge budget;
+	// soft-deleted items are read from the repository only
+	var read itemRead
+	if includeDeleted {
+		read.item, read.err = h.findWithImages(func(ctx context.Context) (*models.InventoryItem, error) {
+			return h.deleted.FindByIDIncludingDeleted(ctx, id)
+		})(c.Request.Context())
+	} else {
+		read = h.readItem(c.Request.Context(), endpointItemByID, cacheKeyItemByID(id.String()), h.findWithImages(func(ctx context.Context) (*models.InventoryItem, error) {
+			return h.repository.FindByID(ctx, id)
+		}))
+	}
+	item, err := read.item, read.err
 	if err != nil {
 		if err == repository.ErrItemNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+			respondError(c, errors.NewItemNotFound(id.String()))
+			return
+		}
+		if stderrors.Is(err, context.DeadlineExceeded) {
+			respondError(c, errors.NewTimeout("item read timed out", ""))
 			return
 		}
 		h.logger.Error("Failed to find item", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get item"})
+		respondError(c, errors.NewInternalError("failed to get item", nil))
 		return
 	}
 
@@ -247,15 +502,47 @@ func (h *InventoryHandler) GetItemByID(c *gin.Context) {
 		Available:   item.Available,
 		CreatedAt:   item.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:   item.UpdatedAt.Format(time.RFC3339),
+		DeletedAt:   formatDeletedAt(item.DeletedAt),
+		Images:      h.imageResponses(item.Images),
 	}
 
-	// Cache the response (if enabled)
-	if h.cache != nil {
+	// Cache the response (if enabled and it did not come from the cache)
+	if h.cache != nil && !read.fromCache && !includeDeleted {
 		cacheKey := cacheKeyItemByID(id.String())
-		cache.SetJSON(c.Request.Context(), h.cache, cacheKey, item, cache.TTL(h.cacheTTL))
+		cache.SetJSON(c.Request.Context(), h.cache, cacheKey, item, h.entryTTL(h.cacheTTL))
 	}
 
-	c.JSON(http.StatusOK, response)
+	if notModified(c, entityTag(c, response), item.UpdatedAt) {
+		return
+	}
+	respond(c, http.StatusOK, response)
+}
+
+// includeDeleted parses the include_deleted query parameter; it responds with an error
+// and returns false when the value is invalid or the read model has no soft-deleted items
+func (h *InventoryHandler) includeDeleted(c *gin.Context) (bool, bool) {
+	raw := c.Query("include_deleted")
+	if raw == "" {
+		return false, true
+	}
+	include, err := strconv.ParseBool(raw)
+	if err != nil {
+		respondError(c, errors.NewInvalidRequest("include_deleted must be true or false", ""))
+		return false, false
+	}
+	if include && h.deleted == nil {
+		respondError(c, errors.NewInternalError("deleted items are not available", nil))
+		return false, false
+	}
+	return include, true
+}
+
+// formatDeletedAt formats the soft-delete time of an item, empty for a live item
+func formatDeletedAt(deletedAt *time.Time) string {
+	if deletedAt == nil {
+		return ""
+	}
+	return deletedAt.Format(time.RFC3339)
 }
 
 // GetItemBySKU handles GET /api/v1/inventory/items/sku/:sku
@@ -267,6 +554,7 @@ func (h *InventoryHandler) GetItemByID(c *gin.Context) {
 // - Respuestas ultra-rápidas (cache hit)
 // - Búsqueda optimizada
 // - Cache-first strategy para baja latencia
+// - GET condicional: responde `ETag` y `Last-Modified`; con `If-None-Match` (o `If-Modified-Since`) responde `304 Not Modified` sin cuerpo si el cliente ya tiene esta versión
 //
 // **Ejemplos válidos:**
 // - Obtener item por SKU válido: `GET /api/v1/inventory/items/sku/SKU-001`
@@ -278,55 +566,46 @@ func (h *InventoryHandler) GetItemByID(c *gin.Context) {
 //
 // @Tags         inventory
 // @Accept       json
-// @Produce      json
+// @Produce      json,xml
 // @Security     BearerAuth
 // @Param        X-Request-ID  header    string  false  "Request ID for request tracking (UUID). If not provided, a new one will be generated."
+// @Param        If-None-Match      header  string  false  "ETag de una respuesta anterior; si coincide responde 304"
+// @Param        If-Modified-Since  header  string  false  "Fecha HTTP; si el recurso no cambió desde entonces responde 304 (ignorado con If-None-Match)"
 // @Param        sku           path      string  true   "SKU (Stock Keeping Unit)" example(SKU-001)
 // @Success      200           {object}  InventoryItemResponse  "Item obtenido exitosamente"
+// @Header       200           {string}  ETag           "Validador débil de la representación (W/\"...\")"
+// @Header       200           {string}  Last-Modified  "updated_at más reciente del recurso"
+// @Success      304           "No modificado - la versión del cliente está al día"
 // @Failure      400           {object}  ErrorResponse          "SKU inválido - SKU vacío"
 // @Failure      401           {object}  ErrorResponse          "No autorizado - token JWT inválido o faltante"
 // @Failure      404           {object}  ErrorResponse          "Item no encontrado"
 // @Failure      500           {object}  ErrorResponse          "Error interno del servidor - error de lectura o conexión a base de datos"
 // @Failure      503           {object}  ErrorResponse          "Servicio no disponible - error de conexión al cache"
+// @Failure      504           {object}  ErrorResponse          "Timeout - la lectura superó el timeout del endpoint (ITEM_BY_*_TIMEOUT_MS)"
 // @Router       /inventory/items/sku/{sku} [get]
 func (h *InventoryHandler) GetItemBySKU(c *gin.Context) {
 	sku := c.Param("sku")
 	if sku == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "sku is required"})
+		respondError(c, errors.NewInvalidRequest("sku is required", ""))
 		return
 	}
 
-	// Try cache first (if enabled)
-	if h.cache != nil {
-		cacheKey := cacheKeyItemBySKU(sku)
-		var cachedItem models.InventoryItem
-		if err := cache.GetJSON(c.Request.Context(), h.cache, cacheKey, &cachedItem); err == nil {
-			h.logger.Debug("Cache hit", zap.String("key", cacheKey))
-			response := InventoryItemResponse{
-				ID:          cachedItem.ID,
-				SKU:         cachedItem.SKU,
-				Name:        cachedItem.Name,
-				Description: cachedItem.Description,
-				Quantity:    cachedItem.Quantity,
-				Reserved:    cachedItem.Reserved,
-				Available:   cachedItem.Available,
-				CreatedAt:   cachedItem.CreatedAt.Format(time.RFC3339),
-				UpdatedAt:   cachedItem.UpdatedAt.Format(time.RFC3339),
-			}
-			c.JSON(http.StatusOK, response)
-			return
-		}
-	}
-
-	// Cache miss - fetch from repository
-	item, err := h.repository.FindBySKU(c.Request.Context(), sku)
+	// Cache first, then the repository, within the endpoint's timeout and hedge budget
+	read := h.readItem(c.Request.Context(), endpointItemBySKU, cacheKeyItemBySKU(sku), h.findWithImages(func(ctx context.Context) (*models.InventoryItem, error) {
+		return h.repository.FindBySKU(ctx, sku)
+	}))
+	item, err := read.item, read.err
 	if err != nil {
 		if err == repository.ErrItemNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+			respondError(c, errors.NewStandardError(errors.CodeItemNotFound, "item not found", "SKU: "+sku))
+			return
+		}
+		if stderrors.Is(err, context.DeadlineExceeded) {
+			respondError(c, errors.NewTimeout("item read timed out", ""))
 			return
 		}
 		h.logger.Error("Failed to find item by SKU", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get item"})
+		respondError(c, errors.NewInternalError("failed to get item", nil))
 		return
 	}
 
@@ -340,15 +619,19 @@ func (h *InventoryHandler) GetItemBySKU(c *gin.Context) {
 		Available:   item.Available,
 		CreatedAt:   item.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:   item.UpdatedAt.Format(time.RFC3339),
+		Images:      h.imageResponses(item.Images),
 	}
 
-	// Cache the response (if enabled)
-	if h.cache != nil {
+	// Cache the response (if enabled and it did not come from the cache)
+	if h.cache != nil && !read.fromCache {
 		cacheKey := cacheKeyItemBySKU(sku)
-		cache.SetJSON(c.Request.Context(), h.cache, cacheKey, item, cache.TTL(h.cacheTTL))
+		cache.SetJSON(c.Request.Context(), h.cache, cacheKey, item, h.entryTTL(h.cacheTTL))
 	}
 
-	c.JSON(http.StatusOK, response)
+	if notModified(c, entityTag(c, response), item.UpdatedAt) {
+		return
+	}
+	respond(c, http.StatusOK, response)
 }
 
 // GetStockStatus handles GET /api/v1/inventory/items/:id/stock
@@ -370,7 +653,7 @@ func (h *InventoryHandler) GetItemBySKU(c *gin.Context) {
 //
 // @Tags         inventory
 // @Accept       json
-// @Produce      json
+// @Produce      json,xml
 // @Security     BearerAuth
 // @Param        X-Request-ID  header    string  false  "Request ID for request tracking (UUID). If not provided, a new one will be generated."
 // @Param        id            path      string  true   "Item ID (UUID)" example(550e8400-e29b-41d4-a716-446655440000)
@@ -384,7 +667,7 @@ func (h *InventoryHandler) GetItemBySKU(c *gin.Context) {
 func (h *InventoryHandler) GetStockStatus(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item id"})
+		respondError(c, errors.NewInvalidRequest("invalid item id", ""))
 		return
 	}
 
@@ -392,7 +675,7 @@ func (h *InventoryHandler) GetStockStatus(c *gin.Context) {
 	if h.cache != nil {
 		cacheKey := cacheKeyStockStatus(id.String())
 		var cachedStatus models.StockStatus
-		if err := cache.GetJSON(c.Request.Context(), h.cache, cacheKey, &cachedStatus); err == nil {
+		if err := h.cacheGetJSON(c.Request.Context(), cacheKey, &cachedStatus); err == nil {
 			h.logger.Debug("Cache hit", zap.String("key", cacheKey))
 			response := StockStatusResponse{
 				ID:        cachedStatus.ID,
@@ -402,7 +685,7 @@ func (h *InventoryHandler) GetStockStatus(c *gin.Context) {
 				Available: cachedStatus.Available,
 				UpdatedAt: cachedStatus.UpdatedAt.Format(time.RFC3339),
 			}
-			c.JSON(http.StatusOK, response)
+			respond(c, http.StatusOK, response)
 			return
 		}
 	}
@@ -411,11 +694,11 @@ func (h *InventoryHandler) GetStockStatus(c *gin.Context) {
 	status, err := h.repository.GetStockStatus(c.Request.Context(), id)
 	if err != nil {
 		if err == repository.ErrItemNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "item not found"})
+			respondError(c, errors.NewItemNotFound(id.String()))
 			return
 		}
 		h.logger.Error("Failed to get stock status", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get stock status"})
+		respondError(c, errors.NewInternalError("failed to get stock status", nil))
 		return
 	}
 
@@ -431,10 +714,10 @@ func (h *InventoryHandler) GetStockStatus(c *gin.Context) {
 	// Cache the response (if enabled, shorter TTL for stock status as it changes frequently)
 	if h.cache != nil {
 		cacheKey := cacheKeyStockStatus(id.String())
-		cache.SetJSON(c.Request.Context(), h.cache, cacheKey, status, cache.TTL(h.cacheTTL/2))
+		cache.SetJSON(c.Request.Context(), h.cache, cacheKey, status, h.entryTTL(h.cacheTTL/2))
 	}
 
-	c.JSON(http.StatusOK, response)
+	respond(c, http.StatusOK, response)
 }
 
 // Cache key helpers
@@ -453,3 +736,9 @@ func cacheKeyStockStatus(id string) string {
 func cacheKeyListItems(page, pageSize int) string {
 	return "items:list:" + strconv.Itoa(page) + ":" + strconv.Itoa(pageSize)
 }
+
+// cacheKeyListItemsAfter keeps the cursor pages under items:list:, invalidated with the
+// other pages
+func cacheKeyListItemsAfter(after string, pageSize int) string {
+	return "items:list:after:" + after + ":" + strconv.Itoa(pageSize)
+}
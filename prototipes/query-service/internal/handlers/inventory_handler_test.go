@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -74,6 +75,22 @@ func (m *MockRepository) FindBySKU(ctx context.Context, sku string) (*models.Inv
 	return args.Get(0).(*models.InventoryItem), args.Error(1)
 }
 
+func (m *MockRepository) FindBySKUs(ctx context.Context, skus []string) ([]models.InventoryItem, error) {
+	args := m.Called(ctx, skus)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.InventoryItem), args.Error(1)
+}
+
+func (m *MockRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]models.InventoryItem, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.InventoryItem), args.Error(1)
+}
+
 func (m *MockRepository) ListItems(ctx context.Context, page, pageSize int) ([]models.InventoryItem, int, error) {
 	args := m.Called(ctx, page, pageSize)
 	if args.Get(0) == nil {
@@ -129,6 +146,8 @@ func setupTestRouter(handler *InventoryHandler) *gin.Engine {
 			inventory.GET("/items/:id", handler.GetItemByID)
 			inventory.GET("/items/sku/:sku", handler.GetItemBySKU)
 			inventory.GET("/items/:id/stock", handler.GetStockStatus)
+			inventory.POST("/availability", handler.CheckAvailability)
+			inventory.POST("/items/batch", handler.GetItemsBatch)
 		}
 	}
 	return router
@@ -160,8 +179,10 @@ func TestListItems_CacheHit(t *testing.T) {
 		TotalPages: 1,
 	}
 
-	cachedData, _ := json.Marshal(cachedResponse)
-	mockCache.On("Get", mock.Anything, "items:list:1:10").Return(cachedData, nil)
+	// Cached as the serialized response
+	entry, err := newSerializedResponse(cachedResponse, time.Time{}, true)
+	require.NoError(t, err)
+	mockCache.On("Get", mock.Anything, "items:list:1:10").Return(entry.marshal(), nil)
 
 	// Execute
 	req := httptest.NewRequest("GET", "/api/v1/inventory/items?page=1&page_size=10", nil)
@@ -174,7 +195,7 @@ func TestListItems_CacheHit(t *testing.T) {
 	mockRepo.AssertNotCalled(t, "ListItems") // Repository should not be called on cache hit
 
 	var response ListItemsResponse
-	err := json.Unmarshal(w.Body.Bytes(), &response)
+	err = json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
 	assert.Equal(t, 1, response.Total)
 	assert.Len(t, response.Items, 1)
@@ -552,3 +573,111 @@ func TestListItems_InvalidPagination(t *testing.T) {
 		})
 	}
 }
+
+func TestItems_IncludeDeleted(t *testing.T) {
+	repo := repository.NewInMemoryReadRepository()
+	live := createTestItem(uuid.New(), "SKU-LIVE")
+	deleted := createTestItem(uuid.New(), "SKU-DELETED")
+	deletedAt := time.Now().UTC().Truncate(time.Second)
+	deleted.DeletedAt = &deletedAt
+	require.NoError(t, repo.SaveItem(*live))
+	require.NoError(t, repo.SaveItem(*deleted))
+
+	handler := createTestHandler(nil, repo)
+	handler.deleted = repo
+	router := setupTestRouter(handler)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	w := get("/api/v1/inventory/items")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list ListItemsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, 1, list.Total)
+	assert.Equal(t, "SKU-LIVE", list.Items[0].SKU)
+	assert.Empty(t, list.Items[0].DeletedAt)
+
+	w = get("/api/v1/inventory/items?include_deleted=true")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, 2, list.Total)
+
+	assert.Equal(t, http.StatusNotFound, get("/api/v1/inventory/items/"+deleted.ID).Code)
+
+	w = get("/api/v1/inventory/items/" + deleted.ID + "?include_deleted=true")
+	require.Equal(t, http.StatusOK, w.Code)
+	var item InventoryItemResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &item))
+	assert.Equal(t, deletedAt.Format(time.RFC3339), item.DeletedAt)
+
+	assert.Equal(t, http.StatusBadRequest, get("/api/v1/inventory/items?include_deleted=maybe").Code)
+
+	// Read models without soft-deleted items cannot serve include_deleted=true
+	handler.deleted = nil
+	assert.Equal(t, http.StatusInternalServerError, get("/api/v1/inventory/items?include_deleted=true").Code)
+}
+
+func TestListItems_AfterCursor(t *testing.T) {
+	repo := repository.NewInMemoryReadRepository()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		item := createTestItem(uuid.New(), fmt.Sprintf("SKU-%d", i))
+		item.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, repo.SaveItem(*item))
+	}
+
+	handler := createTestHandler(nil, repo)
+	handler.cursor = repo
+	handler.deleted = repo
+	router := setupTestRouter(handler)
+
+	list := func(query string) (int, ListItemsResponse) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/inventory/items?"+query, nil))
+		var response ListItemsResponse
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		}
+		return w.Code, response
+	}
+
+	// The first page comes from page/page_size and carries the cursor of the next one
+	code, first := list("page_size=2")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, first.Page)
+	require.NotEmpty(t, first.NextCursor)
+
+	skus := []string{first.Items[0].SKU, first.Items[1].SKU}
+	next := first.NextCursor
+	for next != "" {
+		code, page := list("page_size=2&after=" + next)
+		require.Equal(t, http.StatusOK, code)
+		assert.Zero(t, page.Page)
+		assert.Equal(t, 5, page.Total)
+		for _, item := range page.Items {
+			skus = append(skus, item.SKU)
+		}
+		next = page.NextCursor
+	}
+	assert.Equal(t, []string{"SKU-4", "SKU-3", "SKU-2", "SKU-1", "SKU-0"}, skus)
+
+	// The last page by number has no cursor either
+	_, last := list("page=3&page_size=2")
+	assert.Empty(t, last.NextCursor)
+
+	code, _ = list("after=abc")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = list("include_deleted=true&after=" + first.NextCursor)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// Read models without keyset listing cannot serve after
+	handler.cursor = nil
+	code, _ = list("after=" + first.NextCursor)
+	assert.Equal(t, http.StatusInternalServerError, code)
+	_, first = list("page_size=2")
+	assert.Empty(t, first.NextCursor)
+}
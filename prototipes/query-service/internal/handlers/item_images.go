@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"query-service/internal/models"
+)
+
+// loadImages fills the images of items from the read model. Without an image
+// repository the items have none.
+func (h *InventoryHandler) loadImages(ctx context.Context, items []models.InventoryItem) error {
+	if h.images == nil || len(items) == 0 {
+		return nil
+	}
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	images, err := h.images.ListImages(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].Images = images[items[i].ID]
+	}
+	return nil
+}
+
+// findWithImages wraps a single-item lookup so the item is read with its images (and
+// cached with them)
+func (h *InventoryHandler) findWithImages(find func(ctx context.Context) (*models.InventoryItem, error)) func(ctx context.Context) (*models.InventoryItem, error) {
+	return func(ctx context.Context) (*models.InventoryItem, error) {
+		item, err := find(ctx)
+		if err != nil {
+			return nil, err
+		}
+		items := []models.InventoryItem{*item}
+		if err := h.loadImages(ctx, items); err != nil {
+			return nil, err
+		}
+		return &items[0], nil
+	}
+}
+
+// imageResponses converts the images of an item, signing their URLs when enabled
+func (h *InventoryHandler) imageResponses(images []models.ItemImage) []ItemImageResponse {
+	if len(images) == 0 {
+		return nil
+	}
+	responses := make([]ItemImageResponse, len(images))
+	for i, image := range images {
+		responses[i] = ItemImageResponse{
+			ID:          image.ID,
+			URL:         h.imageURLs.URL(image),
+			ContentType: image.ContentType,
+			Size:        image.Size,
+			CreatedAt:   image.CreatedAt.Format(time.RFC3339),
+		}
+	}
+	return responses
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+
+	"query-service/internal/models"
+	"query-service/internal/repository"
+	"query-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// GetItemLocations handles GET /api/v1/inventory/items/:id/locations
+// @Summary      Stock per location
+// @Description  Obtiene el stock de un item en total y por ubicación (almacén o tienda), tal como lo registra `POST /items/{id}/locations/{loc}/adjust` en el Command Service.
+//
+// **Características:**
+// - `quantity`, `reserved` y `available` son los totales del item, iguales a los de `GET /items/{id}`
+// - `locations` lista las ubicaciones ordenadas por código, con su stock reservado y disponible
+// - `unassigned` es el stock que no está en ninguna ubicación (todo el stock de un item que nunca se gestionó por ubicación)
+// - Sin cache: el desglose es el del modelo de lectura en ese momento
+//
+// **Ejemplos válidos:**
+// - `GET /api/v1/inventory/items/{id}/locations`
+//
+// **Ejemplos inválidos:**
+// - ID inválido: `GET /api/v1/inventory/items/abc/locations`
+//
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Item ID (UUID)"
+// @Success      200  {object}  models.ItemLocationsResponse  "Stock total y por ubicación"
+// @Failure      400  {object}  ErrorResponse  "Request inválido - ID inválido"
+// @Failure      401  {object}  ErrorResponse  "No autorizado - token JWT inválido o faltante"
+// @Failure      404  {object}  ErrorResponse  "Item no encontrado"
+// @Failure      500  {object}  ErrorResponse  "Error interno del servidor - error de lectura"
+// @Router       /inventory/items/{id}/locations [get]
+func (h *InventoryHandler) GetItemLocations(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, errors.NewInvalidRequest("invalid item id", ""))
+		return
+	}
+
+	if h.locations == nil {
+		respondError(c, errors.NewInternalError("stock locations are not available", nil))
+		return
+	}
+
+	item, err := h.repository.FindByID(c.Request.Context(), id)
+	if err != nil {
+		if err == repository.ErrItemNotFound {
+			respondError(c, errors.NewItemNotFound(id.String()))
+			return
+		}
+		h.logger.Error("Failed to find item", zap.Error(err))
+		respondError(c, errors.NewInternalError("failed to get item locations", nil))
+		return
+	}
+
+	locations, err := h.locations.FindItemLocations(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("Failed to find item locations", zap.String("item_id", id.String()), zap.Error(err))
+		respondError(c, errors.NewInternalError("failed to get item locations", nil))
+		return
+	}
+
+	unassigned := models.UnassignedStock{Quantity: item.Quantity, Reserved: item.Reserved}
+	for _, stock := range locations {
+		unassigned.Quantity -= stock.Quantity
+		unassigned.Reserved -= stock.Reserved
+	}
+	unassigned.Available = unassigned.Quantity - unassigned.Reserved
+
+	c.JSON(http.StatusOK, models.ItemLocationsResponse{
+		ItemID:     item.ID,
+		SKU:        item.SKU,
+		Quantity:   item.Quantity,
+		Reserved:   item.Reserved,
+		Available:  item.Available,
+		Unassigned: unassigned,
+		Locations:  locations,
+		Total:      len(locations),
+	})
+}
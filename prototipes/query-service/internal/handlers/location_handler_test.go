@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"query-service/internal/models"
+	"query-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupLocationRouter(t *testing.T) (*gin.Engine, *repository.InMemoryReadRepository) {
+	gin.SetMode(gin.TestMode)
+	repo := repository.NewInMemoryReadRepository()
+	handler := &InventoryHandler{logger: zap.NewNop(), repository: repo, locations: repo}
+
+	router := gin.New()
+	router.GET("/api/v1/inventory/items/:id/locations", handler.GetItemLocations)
+	return router, repo
+}
+
+func TestGetItemLocations(t *testing.T) {
+	router, repo := setupLocationRouter(t)
+	itemID := uuid.New()
+	require.NoError(t, repo.SaveItem(models.InventoryItem{
+		ID: itemID.String(), SKU: "SKU-001", Name: "Laptop", Quantity: 40, Reserved: 9, Available: 31,
+	}))
+	repo.SaveItemLocation(itemID, models.LocationStock{Location: "WH-2", Quantity: 10, Reserved: 2})
+	repo.SaveItemLocation(itemID, models.LocationStock{Location: "WH-1", Quantity: 25, Reserved: 6})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/inventory/items/"+itemID.String()+"/locations", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response models.ItemLocationsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 40, response.Quantity)
+	assert.Equal(t, 31, response.Available)
+	assert.Equal(t, 2, response.Total)
+	assert.Equal(t, "WH-1", response.Locations[0].Location)
+	assert.Equal(t, 19, response.Locations[0].Available)
+	assert.Equal(t, models.UnassignedStock{Quantity: 5, Reserved: 1, Available: 4}, response.Unassigned)
+}
+
+func TestGetItemLocations_NotTrackedPerLocation(t *testing.T) {
+	router, repo := setupLocationRouter(t)
+	itemID := uuid.New()
+	require.NoError(t, repo.SaveItem(models.InventoryItem{
+		ID: itemID.String(), SKU: "SKU-001", Name: "Laptop", Quantity: 8, Reserved: 3, Available: 5,
+	}))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/inventory/items/"+itemID.String()+"/locations", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response models.ItemLocationsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Empty(t, response.Locations)
+	assert.Equal(t, models.UnassignedStock{Quantity: 8, Reserved: 3, Available: 5}, response.Unassigned)
+}
+
+func TestGetItemLocations_InvalidOrMissingItem(t *testing.T) {
+	router, _ := setupLocationRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/inventory/items/abc/locations", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/inventory/items/"+uuid.New().String()+"/locations", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -1,83 +1,205 @@
 package handlers
 
-// ErrorResponse represents an error response
-// @Description Error response with error message
+import (
+	"encoding/xml"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/pkg/errors"
+)
+
+// ErrorResponse is the error envelope returned by every endpoint (errors.StandardError)
+// @Description Error envelope: machine-readable code, message, details, request ID and timestamp
 type ErrorResponse struct {
-	// Error message describing what went wrong
-	// @Example "item not found"
-	// @Example "invalid page number"
-	Error string `json:"error" example:"item not found"`
+	errors.StandardError
 }
 
 // InventoryItemResponse represents an inventory item response
 // @Description Response with inventory item details
 type InventoryItemResponse struct {
+	XMLName xml.Name `json:"-" xml:"item" swaggerignore:"true"`
+
 	// Unique item identifier (UUID)
-	ID string `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ID string `json:"id" xml:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
 	
 	// SKU (Stock Keeping Unit)
-	SKU string `json:"sku" example:"SKU-001"`
+	SKU string `json:"sku" xml:"sku" example:"SKU-001"`
 	
 	// Product name
-	Name string `json:"name" example:"Laptop Dell XPS 15"`
+	Name string `json:"name" xml:"name" example:"Laptop Dell XPS 15"`
 	
 	// Product description
-	Description string `json:"description" example:"High-performance laptop with 16GB RAM and 512GB SSD"`
+	Description string `json:"description" xml:"description" example:"High-performance laptop with 16GB RAM and 512GB SSD"`
 	
 	// Total stock quantity
-	Quantity int `json:"quantity" example:"100"`
+	Quantity int `json:"quantity" xml:"quantity" example:"100"`
 	
 	// Reserved stock quantity
-	Reserved int `json:"reserved" example:"20"`
+	Reserved int `json:"reserved" xml:"reserved" example:"20"`
 	
 	// Available stock (total - reserved)
-	Available int `json:"available" example:"80"`
+	Available int `json:"available" xml:"available" example:"80"`
 	
 	// Creation timestamp (ISO 8601 format)
-	CreatedAt string `json:"created_at" example:"2024-01-15T10:30:00Z"`
+	CreatedAt string `json:"created_at" xml:"created_at" example:"2024-01-15T10:30:00Z"`
 	
 	// Last update timestamp (ISO 8601 format)
-	UpdatedAt string `json:"updated_at" example:"2024-01-15T11:45:00Z"`
+	UpdatedAt string `json:"updated_at" xml:"updated_at" example:"2024-01-15T11:45:00Z"`
+
+	// Soft-delete timestamp (ISO 8601 format), only present on deleted items returned with include_deleted=true
+	DeletedAt string `json:"deleted_at,omitempty" xml:"deleted_at,omitempty" example:"2024-01-16T09:00:00Z"`
+
+	// Images of the item, in upload order (signed URLs with IMAGE_SIGNED_URLS=true)
+	Images []ItemImageResponse `json:"images,omitempty" xml:"images>image,omitempty"`
+}
+
+// ItemImageResponse represents an image of an item
+type ItemImageResponse struct {
+	// Unique image identifier (UUID)
+	ID string `json:"id" xml:"id" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+
+	// URL of the image file; expires after IMAGE_URL_TTL_SECONDS when signed
+	URL string `json:"url" xml:"url" example:"http://localhost:8081/api/v1/images/default/550e8400-e29b-41d4-a716-446655440000/7c9e6679-7425-40de-944b-e07fc1f90ae7.png"`
+
+	// Image/jpeg, image/png, image/gif or image/webp
+	ContentType string `json:"content_type" xml:"content_type" example:"image/png"`
+
+	// File size in bytes
+	Size int64 `json:"size" xml:"size" example:"48213"`
+
+	// Upload timestamp (ISO 8601 format)
+	CreatedAt string `json:"created_at" xml:"created_at" example:"2024-01-15T10:30:00Z"`
 }
 
 // StockStatusResponse represents stock status response
 // @Description Response with stock status information
 type StockStatusResponse struct {
+	XMLName xml.Name `json:"-" xml:"stock_status" swaggerignore:"true"`
+
 	// Unique item identifier (UUID)
-	ID string `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ID string `json:"id" xml:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
 	
 	// SKU (Stock Keeping Unit)
-	SKU string `json:"sku" example:"SKU-001"`
+	SKU string `json:"sku" xml:"sku" example:"SKU-001"`
 	
 	// Total stock quantity
-	Quantity int `json:"quantity" example:"100"`
+	Quantity int `json:"quantity" xml:"quantity" example:"100"`
 	
 	// Reserved stock quantity
-	Reserved int `json:"reserved" example:"20"`
+	Reserved int `json:"reserved" xml:"reserved" example:"20"`
 	
 	// Available stock (total - reserved)
-	Available int `json:"available" example:"80"`
+	Available int `json:"available" xml:"available" example:"80"`
 	
 	// Last update timestamp (ISO 8601 format)
-	UpdatedAt string `json:"updated_at" example:"2024-01-15T12:00:00Z"`
+	UpdatedAt string `json:"updated_at" xml:"updated_at" example:"2024-01-15T12:00:00Z"`
 }
 
 // ListItemsResponse represents the response for listing items
 // @Description Response with paginated list of inventory items
 type ListItemsResponse struct {
+	XMLName xml.Name `json:"-" xml:"item_list" swaggerignore:"true"`
+
 	// List of inventory items
-	Items []InventoryItemResponse `json:"items"`
+	Items []InventoryItemResponse `json:"items" xml:"items>item"`
 	
 	// Total number of items
-	Total int `json:"total" example:"100"`
+	Total int `json:"total" xml:"total" example:"100"`
 	
-	// Current page number
-	Page int `json:"page" example:"1"`
+	// Current page number (omitted when paging with after)
+	Page int `json:"page,omitempty" xml:"page,omitempty" example:"1"`
 	
 	// Number of items per page
-	PageSize int `json:"page_size" example:"10"`
+	PageSize int `json:"page_size" xml:"page_size" example:"10"`
 	
 	// Total number of pages
-	TotalPages int `json:"total_pages" example:"10"`
+	TotalPages int `json:"total_pages" xml:"total_pages" example:"10"`
+
+	// Cursor of the next items (the after parameter), omitted on the last page
+	NextCursor string `json:"next_cursor,omitempty" xml:"next_cursor,omitempty" example:"MjAyNC0wMS0xNVQxMDozMDowMFp8NTUwZTg0MDAtZTI5Yi00MWQ0LWE3MTYtNDQ2NjU1NDQwMDAw"`
 }
 
+
+// BatchItemsRequest represents a lookup of many items by ID and/or SKU
+// @Description Request with the IDs and SKUs to look up (500 keys at most)
+type BatchItemsRequest struct {
+	// Item IDs (UUID)
+	IDs []string `json:"ids" example:"550e8400-e29b-41d4-a716-446655440000"`
+
+	// SKUs (Stock Keeping Units)
+	SKUs []string `json:"skus" example:"SKU-001"`
+}
+
+// BatchItemsResponse represents the items found by a batch lookup
+// @Description Items found, in request order, and the keys that matched no item
+type BatchItemsResponse struct {
+	// Items found, in the order of their first key (IDs first, then SKUs)
+	Items []InventoryItemResponse `json:"items"`
+
+	// Requested IDs and SKUs that matched no item
+	NotFound []string `json:"not_found" example:"SKU-404"`
+
+	// Number of items found
+	Count int `json:"count" example:"1"`
+}
+
+// AvailabilityRequest represents a multi-item availability check (e.g. a checkout cart)
+// @Description Request with the cart lines to check
+type AvailabilityRequest struct {
+	// Cart lines (1-100)
+	Items []AvailabilityLineRequest `json:"items" binding:"required,min=1,max=100,dive"`
+}
+
+// AvailabilityLineRequest represents one cart line
+type AvailabilityLineRequest struct {
+	// SKU (Stock Keeping Unit)
+	SKU string `json:"sku" binding:"required" example:"SKU-001"`
+
+	// Requested quantity (must be >= 1)
+	Quantity int `json:"quantity" binding:"required,min=1" example:"2"`
+}
+
+// AvailabilityResponse represents the result of an availability check
+// @Description Per-line availability plus an overall fulfillable flag
+type AvailabilityResponse struct {
+	// True when every line can be fulfilled
+	Fulfillable bool `json:"fulfillable" example:"true"`
+
+	// Availability of each requested line, in request order
+	Lines []AvailabilityLineResponse `json:"lines"`
+}
+
+// AvailabilityLineResponse represents the availability of one cart line
+type AvailabilityLineResponse struct {
+	// SKU (Stock Keeping Unit)
+	SKU string `json:"sku" example:"SKU-001"`
+
+	// Requested quantity
+	Requested int `json:"requested" example:"2"`
+
+	// Available stock left for this line (after earlier lines with the same SKU);
+	// reserved stock is not available
+	Available int `json:"available" example:"80"`
+
+	// Stock of the item held by active reservations, store reservations included
+	Reserved int `json:"reserved" example:"20"`
+
+	// Whether the SKU exists
+	Found bool `json:"found" example:"true"`
+
+	// Whether this line can be fulfilled
+	Fulfillable bool `json:"fulfillable" example:"true"`
+
+	// Substitutes of the item with enough stock for the line, when it cannot be fulfilled
+	Substitutes []models.RelatedItem `json:"substitutes,omitempty"`
+}
+
+// CacheFlushResponse represents the result of DELETE /admin/cache
+// @Description Pattern of the deleted keys
+type CacheFlushResponse struct {
+	// Pattern of the deleted keys ("*" flushes the whole cache)
+	Pattern string `json:"pattern" example:"items:list:*"`
+
+	// When the keys were deleted
+	FlushedAt time.Time `json:"flushed_at" example:"2024-01-15T10:30:00Z"`
+}
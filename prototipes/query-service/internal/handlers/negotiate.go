@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"query-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Response formats offered by the read endpoints, the first one being the default
+var responseFormats = []string{gin.MIMEJSON, gin.MIMEXML, gin.MIMEXML2}
+
+// respond writes obj in the format the Accept header prefers: JSON unless the client
+// asks for application/xml or text/xml. XML element names come from the xml struct
+// tags of the response models.
+func respond(c *gin.Context, status int, obj interface{}) {
+	c.Writer.Header().Add("Vary", "Accept")
+	switch negotiateFormat(c.GetHeader("Accept")) {
+	case gin.MIMEXML, gin.MIMEXML2:
+		c.XML(status, obj)
+	default:
+		c.JSON(status, obj)
+	}
+}
+
+// respondError writes the error envelope in the negotiated format and aborts the chain
+func respondError(c *gin.Context, err *errors.StandardError) {
+	respond(c, err.HTTPStatus(), err.ForRequest(c))
+	c.Abort()
+}
+
+// negotiateFormat picks the offered format with the highest q-value in accept; ties go
+// to the media type listed first. Wildcards and an empty or unknown Accept get JSON.
+func negotiateFormat(accept string) string {
+	type candidate struct {
+		format string
+		q      float64
+		order  int
+	}
+	var candidates []candidate
+	for order, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		for _, format := range responseFormats {
+			if mediaType == format {
+				candidates = append(candidates, candidate{format: format, q: q, order: order})
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return responseFormats[0]
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].q != candidates[j].q {
+			return candidates[i].q > candidates[j].q
+		}
+		return candidates[i].order < candidates[j].order
+	})
+	return candidates[0].format
+}
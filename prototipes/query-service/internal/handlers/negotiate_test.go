@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"query-service/internal/models"
+	"query-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", gin.MIMEJSON},
+		{"*/*", gin.MIMEJSON},
+		{"application/json", gin.MIMEJSON},
+		{"application/json; envelope=true", gin.MIMEJSON},
+		{"application/xml", gin.MIMEXML},
+		{"text/xml", gin.MIMEXML2},
+		{"application/xml, application/json", gin.MIMEXML},
+		{"application/json;q=0.5, application/xml", gin.MIMEXML},
+		{"application/xml;q=0, application/json", gin.MIMEJSON},
+		{"text/html", gin.MIMEJSON},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, negotiateFormat(tt.accept), "Accept: %q", tt.accept)
+	}
+}
+
+func TestListItems_XML(t *testing.T) {
+	mockRepo := new(MockRepository)
+	router := setupTestRouter(createTestHandler(nil, mockRepo))
+
+	testItem := createTestItem(uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"), "SKU-001")
+	mockRepo.On("ListItems", mock.Anything, 1, 10).Return([]models.InventoryItem{*testItem}, 1, nil)
+
+	req := httptest.NewRequest("GET", "/api/v1/inventory/items", nil)
+	req.Header.Set("Accept", "application/xml")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/xml")
+	assert.Contains(t, w.Body.String(), "<item_list><items><item><id>550e8400-e29b-41d4-a716-446655440000</id><sku>SKU-001</sku>")
+
+	var response ListItemsResponse
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.Total)
+	require.Len(t, response.Items, 1)
+	assert.Equal(t, "SKU-001", response.Items[0].SKU)
+	assert.Equal(t, 80, response.Items[0].Available)
+}
+
+func TestListItems_JSONByDefault(t *testing.T) {
+	mockRepo := new(MockRepository)
+	router := setupTestRouter(createTestHandler(nil, mockRepo))
+
+	testItem := createTestItem(uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"), "SKU-001")
+	mockRepo.On("ListItems", mock.Anything, 1, 10).Return([]models.InventoryItem{*testItem}, 1, nil)
+
+	req := httptest.NewRequest("GET", "/api/v1/inventory/items", nil)
+	req.Header.Set("Accept", "*/*")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+
+	var response ListItemsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "SKU-001", response.Items[0].SKU)
+	assert.NotContains(t, w.Body.String(), "XMLName")
+}
+
+func TestGetItemByID_NotFoundXML(t *testing.T) {
+	mockRepo := new(MockRepository)
+	router := setupTestRouter(createTestHandler(nil, mockRepo))
+
+	itemID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	mockRepo.On("FindByID", mock.Anything, itemID).Return(nil, repository.ErrItemNotFound)
+
+	req := httptest.NewRequest("GET", "/api/v1/inventory/items/"+itemID.String(), nil)
+	req.Header.Set("Accept", "text/xml")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "<error><code>ItemNotFound</code><message>item not found</message><details>Item ID: 550e8400-e29b-41d4-a716-446655440000</details><timestamp>")
+
+	var response ErrorResponse
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &response.StandardError))
+	assert.Equal(t, "ItemNotFound", response.Code)
+	assert.False(t, response.Timestamp.IsZero())
+}
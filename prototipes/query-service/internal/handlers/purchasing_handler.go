@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"query-service/internal/models"
+	"query-service/internal/repository"
+	"query-service/pkg/errors"
+	"query-service/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// PurchasingHandler serves suppliers and purchase orders from the read model
+type PurchasingHandler struct {
+	logger *zap.Logger
+	repo   repository.PurchasingRepository
+}
+
+// NewPurchasingHandler creates a new purchasing handler
+func NewPurchasingHandler(logger *zap.Logger, repo repository.PurchasingRepository) *PurchasingHandler {
+	return &PurchasingHandler{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+// ListSuppliers handles GET /api/v1/suppliers
+// @Summary      List suppliers
+// @Description  Obtiene los proveedores (creados con `POST /suppliers` en el Command Service), paginados y ordenados por código. Incluye los inactivos.
+//
+// **Ejemplos válidos:**
+// - `GET /api/v1/suppliers`
+// - Paginación: `GET /api/v1/suppliers?page=2&page_size=20`
+//
+// @Tags         purchasing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        page       query     int     false  "Page number (default: 1, min: 1)" example(1)
+// @Param        page_size  query     int     false  "Items per page (default: 10, min: 1, max: 100)" example(10)
+// @Success      200        {object}  models.ListSuppliersResponse  "Proveedores"
+// @Failure      401        {object}  ErrorResponse  "No autorizado - token JWT inválido o faltante"
+// @Failure      500        {object}  ErrorResponse  "Error interno del servidor - error de lectura"
+// @Router       /suppliers [get]
+func (h *PurchasingHandler) ListSuppliers(c *gin.Context) {
+	if h.repo == nil {
+		respondError(c, errors.NewInternalError("suppliers are not available", nil))
+		return
+	}
+
+	page, pageSize := pageParams(c)
+	suppliers, total, err := h.repo.ListSuppliers(c.Request.Context(), page, pageSize)
+	if err != nil {
+		h.logger.Error("Failed to list suppliers", zap.Error(err))
+		respondError(c, errors.NewInternalError("failed to list suppliers", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ListSuppliersResponse{
+		Suppliers:  suppliers,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	})
+}
+
+// ListPurchaseOrders handles GET /api/v1/purchase-orders
+// @Summary      List purchase orders
+// @Description  Obtiene las órdenes de compra, paginadas y ordenadas de la más reciente a la más antigua, sin sus líneas (ver `GET /purchase-orders/{id}`).
+//
+// **Ejemplos válidos:**
+// - Todas las órdenes: `GET /api/v1/purchase-orders`
+// - Pendientes de recibir: `GET /api/v1/purchase-orders?status=open`
+// - De un proveedor: `GET /api/v1/purchase-orders?supplier_id={id}&status=partially_received`
+//
+// **Ejemplos inválidos:**
+// - Estado desconocido: `GET /api/v1/purchase-orders?status=pending`
+// - supplier_id inválido: `GET /api/v1/purchase-orders?supplier_id=abc`
+//
+// @Tags         purchasing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status       query     string  false  "Purchase order status (open, partially_received, received, cancelled)"
+// @Param        supplier_id  query     string  false  "Supplier ID (UUID)"
+// @Param        page         query     int     false  "Page number (default: 1, min: 1)" example(1)
+// @Param        page_size    query     int     false  "Items per page (default: 10, min: 1, max: 100)" example(10)
+// @Success      200          {object}  models.ListPurchaseOrdersResponse  "Órdenes de compra"
+// @Failure      400          {object}  ErrorResponse  "Request inválido - estado o supplier_id inválido"
+// @Failure      401          {object}  ErrorResponse  "No autorizado - token JWT inválido o faltante"
+// @Failure      500          {object}  ErrorResponse  "Error interno del servidor - error de lectura"
+// @Router       /purchase-orders [get]
+func (h *PurchasingHandler) ListPurchaseOrders(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && !isPurchaseOrderStatus(status) {
+		respondError(c, errors.NewInvalidRequest("invalid status, must be one of: open, partially_received, received, cancelled", ""))
+		return
+	}
+	supplierID := c.Query("supplier_id")
+	if supplierID != "" {
+		if _, err := uuid.Parse(supplierID); err != nil {
+			respondError(c, errors.NewInvalidRequest("invalid supplier_id", ""))
+			return
+		}
+	}
+
+	if h.repo == nil {
+		respondError(c, errors.NewInternalError("purchase orders are not available", nil))
+		return
+	}
+
+	page, pageSize := pageParams(c)
+	orders, total, err := h.repo.ListPurchaseOrders(c.Request.Context(), status, supplierID, page, pageSize)
+	if err != nil {
+		h.logger.Error("Failed to list purchase orders", zap.Error(err))
+		respondError(c, errors.NewInternalError("failed to list purchase orders", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ListPurchaseOrdersResponse{
+		PurchaseOrders: orders,
+		Total:          total,
+		Page:           page,
+		PageSize:       pageSize,
+		TotalPages:     (total + pageSize - 1) / pageSize,
+	})
+}
+
+// GetPurchaseOrder handles GET /api/v1/purchase-orders/:id
+// @Summary      Get a purchase order
+// @Description  Obtiene una orden de compra con su estado y, por cada línea, la cantidad pedida (`ordered`), la recibida hasta ahora (`received`) y la pendiente (`remaining`).
+//
+// **Ejemplos válidos:**
+// - `GET /api/v1/purchase-orders/{id}`
+//
+// **Ejemplos inválidos:**
+// - ID inválido: `GET /api/v1/purchase-orders/abc`
+//
+// @Tags         purchasing
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Purchase order ID (UUID)"
+// @Success      200  {object}  models.PurchaseOrder  "Orden de compra con sus líneas"
+// @Failure      400  {object}  ErrorResponse  "ID inválido"
+// @Failure      401  {object}  ErrorResponse  "No autorizado - token JWT inválido o faltante"
+// @Failure      404  {object}  ErrorResponse  "Orden de compra no encontrada"
+// @Failure      500  {object}  ErrorResponse  "Error interno del servidor - error de lectura"
+// @Router       /purchase-orders/{id} [get]
+func (h *PurchasingHandler) GetPurchaseOrder(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, errors.NewInvalidRequest("invalid purchase order id", ""))
+		return
+	}
+
+	if h.repo == nil {
+		respondError(c, errors.NewInternalError("purchase orders are not available", nil))
+		return
+	}
+
+	order, err := h.repo.FindPurchaseOrder(c.Request.Context(), id)
+	if err != nil {
+		if err == repository.ErrPurchaseOrderNotFound {
+			respondError(c, errors.NewNotFound("purchase order not found", "Purchase order ID: "+id.String()))
+			return
+		}
+		h.logger.Error("Failed to get purchase order", zap.Error(err))
+		respondError(c, errors.NewInternalError("failed to get purchase order", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// pageParams reads page and page_size, capping page_size at 100 like the other listings
+func pageParams(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		middleware.AddWarning(c, "page_size capped at 100")
+		pageSize = 100
+	}
+	return page, pageSize
+}
+
+func isPurchaseOrderStatus(status string) bool {
+	for _, s := range repository.PurchaseOrderStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupPurchasingRouter(t *testing.T) (*gin.Engine, *repository.InMemoryReadRepository) {
+	gin.SetMode(gin.TestMode)
+	repo := repository.NewInMemoryReadRepository()
+	handler := NewPurchasingHandler(zap.NewNop(), repo)
+
+	router := gin.New()
+	router.GET("/api/v1/suppliers", handler.ListSuppliers)
+	router.GET("/api/v1/purchase-orders", handler.ListPurchaseOrders)
+	router.GET("/api/v1/purchase-orders/:id", handler.GetPurchaseOrder)
+	return router, repo
+}
+
+func TestListSuppliers(t *testing.T) {
+	router, repo := setupPurchasingRouter(t)
+	repo.SaveSupplier(models.Supplier{ID: uuid.New().String(), Code: "SUP-002", Name: "Sur", Active: false})
+	repo.SaveSupplier(models.Supplier{ID: uuid.New().String(), Code: "SUP-001", Name: "Norte", Active: true})
+	repo.SaveSupplier(models.Supplier{ID: uuid.New().String(), TenantID: "acme", Code: "SUP-000", Name: "Otro tenant"})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/suppliers?page_size=1", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response models.ListSuppliersResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 2, response.Total)
+	assert.Equal(t, 2, response.TotalPages)
+	require.Len(t, response.Suppliers, 1)
+	assert.Equal(t, "SUP-001", response.Suppliers[0].Code)
+}
+
+func TestListPurchaseOrders(t *testing.T) {
+	router, repo := setupPurchasingRouter(t)
+	supplierID := uuid.New().String()
+	now := time.Now().UTC()
+	repo.SavePurchaseOrder(models.PurchaseOrder{ID: uuid.New().String(), Number: "PO-1", SupplierID: supplierID, Status: "received", CreatedAt: now.Add(-2 * time.Hour)})
+	repo.SavePurchaseOrder(models.PurchaseOrder{ID: uuid.New().String(), Number: "PO-2", SupplierID: supplierID, Status: "open", CreatedAt: now.Add(-time.Hour),
+		Lines: []models.PurchaseOrderLine{{ItemID: uuid.New().String(), SKU: "SKU-A", Ordered: 5}}})
+	repo.SavePurchaseOrder(models.PurchaseOrder{ID: uuid.New().String(), Number: "PO-3", SupplierID: uuid.New().String(), Status: "open", CreatedAt: now})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/purchase-orders", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var response models.ListPurchaseOrdersResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.PurchaseOrders, 3)
+	assert.Equal(t, "PO-3", response.PurchaseOrders[0].Number, "newest first")
+	assert.Empty(t, response.PurchaseOrders[1].Lines, "listings carry no lines")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/purchase-orders?status=open&supplier_id="+supplierID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.PurchaseOrders, 1)
+	assert.Equal(t, "PO-2", response.PurchaseOrders[0].Number)
+
+	for _, query := range []string{"status=pending", "supplier_id=abc"} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/purchase-orders?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestGetPurchaseOrder(t *testing.T) {
+	router, repo := setupPurchasingRouter(t)
+	id := uuid.New()
+	cost := 2.5
+	repo.SavePurchaseOrder(models.PurchaseOrder{ID: id.String(), Number: "PO-1", SupplierID: uuid.New().String(), Status: "partially_received",
+		Lines: []models.PurchaseOrderLine{
+			{ItemID: uuid.New().String(), SKU: "SKU-A", Ordered: 10, Received: 4, UnitCost: &cost},
+			{ItemID: uuid.New().String(), SKU: "SKU-B", Ordered: 2, Received: 2},
+		}})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/purchase-orders/"+id.String(), nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var order models.PurchaseOrder
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+	assert.Equal(t, "partially_received", order.Status)
+	require.Len(t, order.Lines, 2)
+	assert.Equal(t, 6, order.Lines[0].Remaining)
+	assert.Equal(t, 0, order.Lines[1].Remaining)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/purchase-orders/"+uuid.New().String(), nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestPurchasing_Unavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/suppliers", NewPurchasingHandler(zap.NewNop(), nil).ListSuppliers)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/suppliers", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"query-service/internal/models"
+	"query-service/internal/repository"
+	"query-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// GetRelatedItems handles GET /api/v1/inventory/items/:id/related
+// @Summary      Related items
+// @Description  Obtiene los items vinculados a un item con `POST /items/{id}/related` en el Command Service: sustitutos (`substitute`, se pueden vender en su lugar) y accesorios (`accessory`), con su stock actual.
+//
+// **Características:**
+// - Sustitutos primero, luego los que tienen más stock disponible
+// - `out_of_stock` indica si el item pedido está sin stock disponible, para sugerir sustitutos
+// - `in_stock=true` devuelve solo los vinculados con stock disponible
+// - Sin cache: la disponibilidad es la del modelo de lectura en ese momento
+//
+// **Ejemplos válidos:**
+// - `GET /api/v1/inventory/items/{id}/related`
+// - Sustitutos con stock: `GET /api/v1/inventory/items/{id}/related?relation=substitute&in_stock=true`
+//
+// **Ejemplos inválidos:**
+// - ID inválido: `GET /api/v1/inventory/items/abc/related`
+// - Relación desconocida: `GET /api/v1/inventory/items/{id}/related?relation=bundle`
+//
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      string  true   "Item ID (UUID)"
+// @Param        relation  query     string  false  "Only links of this relation (substitute, accessory)"
+// @Param        in_stock  query     bool    false  "Only related items with available stock"
+// @Success      200       {object}  models.RelatedItemsResponse  "Items vinculados"
+// @Failure      400       {object}  ErrorResponse  "Request inválido - ID o relación inválidos"
+// @Failure      401       {object}  ErrorResponse  "No autorizado - token JWT inválido o faltante"
+// @Failure      404       {object}  ErrorResponse  "Item no encontrado"
+// @Failure      500       {object}  ErrorResponse  "Error interno del servidor - error de lectura"
+// @Router       /inventory/items/{id}/related [get]
+func (h *InventoryHandler) GetRelatedItems(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, errors.NewInvalidRequest("invalid item id", ""))
+		return
+	}
+
+	relation := c.Query("relation")
+	if relation != "" && relation != repository.RelationSubstitute && relation != repository.RelationAccessory {
+		respondError(c, errors.NewInvalidRequest("relation must be substitute or accessory", ""))
+		return
+	}
+	inStock := false
+	if raw := c.Query("in_stock"); raw != "" {
+		inStock, err = strconv.ParseBool(raw)
+		if err != nil {
+			respondError(c, errors.NewInvalidRequest("in_stock must be true or false", ""))
+			return
+		}
+	}
+
+	if h.relations == nil {
+		respondError(c, errors.NewInternalError("item relations are not available", nil))
+		return
+	}
+
+	item, err := h.repository.FindByID(c.Request.Context(), id)
+	if err != nil {
+		if err == repository.ErrItemNotFound {
+			respondError(c, errors.NewItemNotFound(id.String()))
+			return
+		}
+		h.logger.Error("Failed to find item", zap.Error(err))
+		respondError(c, errors.NewInternalError("failed to get related items", nil))
+		return
+	}
+
+	related, err := h.relations.FindRelatedItems(c.Request.Context(), id, relation)
+	if err != nil {
+		h.logger.Error("Failed to find related items", zap.String("item_id", id.String()), zap.Error(err))
+		respondError(c, errors.NewInternalError("failed to get related items", nil))
+		return
+	}
+	if inStock {
+		related = withStock(related, 1)
+	}
+
+	c.JSON(http.StatusOK, models.RelatedItemsResponse{
+		ItemID:     item.ID,
+		SKU:        item.SKU,
+		Available:  item.Available,
+		OutOfStock: item.Available <= 0,
+		Related:    related,
+		Total:      len(related),
+	})
+}
+
+// withStock keeps the related items with at least quantity available
+func withStock(related []models.RelatedItem, quantity int) []models.RelatedItem {
+	kept := make([]models.RelatedItem, 0, len(related))
+	for _, item := range related {
+		if item.Available >= quantity {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
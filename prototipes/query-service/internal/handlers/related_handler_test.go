@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// setupRelatedRouter seeds an out-of-stock item with two substitutes (one of them out
+// of stock too) and an accessory
+func setupRelatedRouter(t *testing.T) (*gin.Engine, map[string]uuid.UUID) {
+	gin.SetMode(gin.TestMode)
+	repo := repository.NewInMemoryReadRepository()
+	handler := &InventoryHandler{logger: zap.NewNop(), repository: repo, relations: repo}
+
+	ids := map[string]uuid.UUID{}
+	for sku, available := range map[string]int{"SKU-MAIN": 0, "SKU-SUB-A": 3, "SKU-SUB-B": 0, "SKU-ACC": 7} {
+		ids[sku] = uuid.New()
+		require.NoError(t, repo.SaveItem(models.InventoryItem{
+			ID: ids[sku].String(), SKU: sku, Name: "Item " + sku,
+			Quantity: available + 1, Reserved: 1, Available: available,
+		}))
+	}
+	now := time.Now().UTC()
+	repo.SaveItemRelation(ids["SKU-MAIN"], ids["SKU-SUB-B"], repository.RelationSubstitute, now)
+	repo.SaveItemRelation(ids["SKU-MAIN"], ids["SKU-SUB-A"], repository.RelationSubstitute, now)
+	repo.SaveItemRelation(ids["SKU-MAIN"], ids["SKU-ACC"], repository.RelationAccessory, now)
+
+	router := gin.New()
+	router.GET("/api/v1/inventory/items/:id/related", handler.GetRelatedItems)
+	router.POST("/api/v1/inventory/availability", handler.CheckAvailability)
+	return router, ids
+}
+
+func getRelated(t *testing.T, router *gin.Engine, path string) (int, models.RelatedItemsResponse) {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	var response models.RelatedItemsResponse
+	if w.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	}
+	return w.Code, response
+}
+
+func relatedSKUs(related []models.RelatedItem) []string {
+	skus := make([]string, 0, len(related))
+	for _, item := range related {
+		skus = append(skus, item.SKU)
+	}
+	return skus
+}
+
+func TestGetRelatedItems(t *testing.T) {
+	router, ids := setupRelatedRouter(t)
+	base := "/api/v1/inventory/items/" + ids["SKU-MAIN"].String() + "/related"
+
+	code, response := getRelated(t, router, base)
+	require.Equal(t, http.StatusOK, code)
+	assert.True(t, response.OutOfStock)
+	assert.Equal(t, 3, response.Total)
+	// Substitutes first, the one with stock ahead
+	assert.Equal(t, []string{"SKU-SUB-A", "SKU-SUB-B", "SKU-ACC"}, relatedSKUs(response.Related))
+	assert.Equal(t, repository.RelationSubstitute, response.Related[0].Relation)
+	assert.Equal(t, 3, response.Related[0].Available)
+
+	code, response = getRelated(t, router, base+"?relation=substitute&in_stock=true")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"SKU-SUB-A"}, relatedSKUs(response.Related))
+
+	code, response = getRelated(t, router, "/api/v1/inventory/items/"+ids["SKU-ACC"].String()+"/related")
+	require.Equal(t, http.StatusOK, code)
+	assert.False(t, response.OutOfStock)
+	assert.Empty(t, response.Related)
+}
+
+func TestGetRelatedItems_Invalid(t *testing.T) {
+	router, ids := setupRelatedRouter(t)
+
+	code, _ := getRelated(t, router, "/api/v1/inventory/items/abc/related")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = getRelated(t, router, "/api/v1/inventory/items/"+ids["SKU-MAIN"].String()+"/related?relation=bundle")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = getRelated(t, router, "/api/v1/inventory/items/"+uuid.New().String()+"/related")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestCheckAvailability_SuggestsSubstitutes(t *testing.T) {
+	router, _ := setupRelatedRouter(t)
+
+	body := `{"items":[{"sku":"SKU-MAIN","quantity":2},{"sku":"SKU-ACC","quantity":1}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/inventory/availability", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response AvailabilityResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.False(t, response.Fulfillable)
+	require.Len(t, response.Lines, 2)
+	assert.Equal(t, []string{"SKU-SUB-A"}, relatedSKUs(response.Lines[0].Substitutes))
+	assert.True(t, response.Lines[1].Fulfillable)
+	assert.Empty(t, response.Lines[1].Substitutes)
+}
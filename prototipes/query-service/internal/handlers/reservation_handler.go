@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"query-service/internal/cache"
+	"query-service/internal/models"
+	"query-service/internal/repository"
+	"query-service/pkg/errors"
+	"query-service/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ReservationHandler serves store reservations from the read model
+type ReservationHandler struct {
+	logger     *zap.Logger
+	repository repository.ReservationRepository
+	cache      cache.Cache
+	cacheTTL   int
+}
+
+// NewReservationHandler creates a new reservation handler. cacheClient may be nil.
+func NewReservationHandler(logger *zap.Logger, repo repository.ReservationRepository, cacheClient cache.Cache, cacheTTL int) *ReservationHandler {
+	return &ReservationHandler{
+		logger:     logger,
+		repository: repo,
+		cache:      cacheClient,
+		cacheTTL:   cacheTTL,
+	}
+}
+
+// ListStoreReservations handles GET /api/v1/stores/:id/reservations
+// @Summary      List reservations of a store
+// @Description  Obtiene las reservas de inventario de una tienda, paginadas y ordenadas de la más reciente a la más antigua.
+//
+// **Ejemplos válidos:**
+// - Todas las reservas: `GET /api/v1/stores/{id}/reservations`
+// - Solo activas: `GET /api/v1/stores/{id}/reservations?status=active`
+// - Paginación: `GET /api/v1/stores/{id}/reservations?page=2&page_size=20`
+//
+// **Ejemplos inválidos:**
+// - ID inválido: `GET /api/v1/stores/abc/reservations`
+// - Estado desconocido: `GET /api/v1/stores/{id}/reservations?status=pending`
+//
+// @Tags         reservations
+// @Accept       json
+// @Produce      json,xml
+// @Security     BearerAuth
+// @Param        id         path      string  true   "Store ID (UUID)"
+// @Param        status     query     string  false  "Reservation status (active, released, expired, fulfilled)"
+// @Param        page       query     int     false  "Page number (default: 1, min: 1)" example(1)
+// @Param        page_size  query     int     false  "Items per page (default: 10, min: 1, max: 100)" example(10)
+// @Success      200        {object}  models.ListReservationsResponse  "Reservas de la tienda"
+// @Failure      400        {object}  ErrorResponse  "Request inválido - ID o estado inválido"
+// @Failure      401        {object}  ErrorResponse  "No autorizado - token JWT inválido o faltante"
+// @Failure      404        {object}  ErrorResponse  "Tienda no encontrada"
+// @Failure      500        {object}  ErrorResponse  "Error interno del servidor - error de lectura"
+// @Router       /stores/{id}/reservations [get]
+func (h *ReservationHandler) ListStoreReservations(c *gin.Context) {
+	h.listReservations(c, "store", h.repository.ListReservationsByStore)
+}
+
+// ListItemReservations handles GET /api/v1/inventory/items/:id/reservations
+// @Summary      List reservations of an item
+// @Description  Obtiene las reservas de un item en todas las tiendas, paginadas y ordenadas de la más reciente a la más antigua.
+//
+// **Ejemplos válidos:**
+// - Todas las reservas: `GET /api/v1/inventory/items/{id}/reservations`
+// - Solo liberadas: `GET /api/v1/inventory/items/{id}/reservations?status=released`
+//
+// **Ejemplos inválidos:**
+// - ID inválido: `GET /api/v1/inventory/items/abc/reservations`
+// - Estado desconocido: `GET /api/v1/inventory/items/{id}/reservations?status=pending`
+//
+// @Tags         reservations
+// @Accept       json
+// @Produce      json,xml
+// @Security     BearerAuth
+// @Param        id         path      string  true   "Item ID (UUID)"
+// @Param        status     query     string  false  "Reservation status (active, released, expired, fulfilled)"
+// @Param        page       query     int     false  "Page number (default: 1, min: 1)" example(1)
+// @Param        page_size  query     int     false  "Items per page (default: 10, min: 1, max: 100)" example(10)
+// @Success      200        {object}  models.ListReservationsResponse  "Reservas del item"
+// @Failure      400        {object}  ErrorResponse  "Request inválido - ID o estado inválido"
+// @Failure      401        {object}  ErrorResponse  "No autorizado - token JWT inválido o faltante"
+// @Failure      404        {object}  ErrorResponse  "Item no encontrado"
+// @Failure      500        {object}  ErrorResponse  "Error interno del servidor - error de lectura"
+// @Router       /inventory/items/{id}/reservations [get]
+func (h *ReservationHandler) ListItemReservations(c *gin.Context) {
+	h.listReservations(c, "item", h.repository.ListReservationsByItem)
+}
+
+// listReservationsFunc lists the reservations of a store or an item
+type listReservationsFunc func(ctx context.Context, id uuid.UUID, status string, page, pageSize int) ([]models.StoreReservation, int, error)
+
+func (h *ReservationHandler) listReservations(c *gin.Context, scope string, list listReservationsFunc) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, errors.NewInvalidRequest("invalid "+scope+" id", ""))
+		return
+	}
+
+	status := c.Query("status")
+	if status != "" && !isReservationStatus(status) {
+		respondError(c, errors.NewInvalidRequest("invalid status, must be one of: active, released, expired, fulfilled", ""))
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		middleware.AddWarning(c, "page_size capped at 100")
+		pageSize = 100
+	}
+
+	cacheKey := cacheKeyReservations(scope, id.String(), status, page, pageSize)
+
+	// Try cache first (if enabled)
+	if h.cache != nil {
+		var cachedResponse models.ListReservationsResponse
+		if err := cache.GetJSON(c.Request.Context(), h.cache, cacheKey, &cachedResponse); err == nil {
+			h.logger.Debug("Cache hit", zap.String("key", cacheKey))
+			respond(c, http.StatusOK, cachedResponse)
+			return
+		}
+	}
+
+	// Cache miss - fetch from repository
+	reservations, total, err := list(c.Request.Context(), id, status, page, pageSize)
+	if err != nil {
+		switch err {
+		case repository.ErrStoreNotFound:
+			respondError(c, errors.NewNotFound("store not found", "Store ID: "+id.String()))
+		case repository.ErrItemNotFound:
+			respondError(c, errors.NewItemNotFound(id.String()))
+		default:
+			h.logger.Error("Failed to list reservations", zap.String("scope", scope), zap.Error(err))
+			respondError(c, errors.NewInternalError("failed to list reservations", nil))
+		}
+		return
+	}
+
+	totalPages := (total + pageSize - 1) / pageSize
+	response := models.ListReservationsResponse{
+		Reservations: reservations,
+		Total:        total,
+		Page:         page,
+		PageSize:     pageSize,
+		TotalPages:   totalPages,
+	}
+
+	// Cache the response (if enabled, shorter TTL as reservations change frequently)
+	if h.cache != nil {
+		cache.SetJSON(c.Request.Context(), h.cache, cacheKey, response, cache.TTL(h.cacheTTL/2))
+	}
+
+	respond(c, http.StatusOK, response)
+}
+
+func isReservationStatus(status string) bool {
+	for _, s := range repository.ReservationStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheKeyReservations builds the cache key for a reservation listing.
+// All keys of a store or item share the "reservations:<scope>:<id>:" prefix
+// so the Kafka consumer can invalidate them by pattern.
+func cacheKeyReservations(scope, id, status string, page, pageSize int) string {
+	if status == "" {
+		status = "all"
+	}
+	return "reservations:" + scope + ":" + id + ":" + status + ":" + strconv.Itoa(page) + ":" + strconv.Itoa(pageSize)
+}
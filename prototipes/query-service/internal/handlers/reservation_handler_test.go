@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"query-service/internal/cache"
+	"query-service/internal/models"
+	"query-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// MockReservationRepository is a mock implementation of repository.ReservationRepository
+type MockReservationRepository struct {
+	mock.Mock
+}
+
+func (m *MockReservationRepository) ListReservationsByStore(ctx context.Context, storeID uuid.UUID, status string, page, pageSize int) ([]models.StoreReservation, int, error) {
+	args := m.Called(ctx, storeID, status, page, pageSize)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]models.StoreReservation), args.Int(1), args.Error(2)
+}
+
+func (m *MockReservationRepository) ListReservationsByItem(ctx context.Context, itemID uuid.UUID, status string, page, pageSize int) ([]models.StoreReservation, int, error) {
+	args := m.Called(ctx, itemID, status, page, pageSize)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]models.StoreReservation), args.Int(1), args.Error(2)
+}
+
+func setupReservationRouter(handler *ReservationHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	{
+		v1.GET("/stores/:id/reservations", handler.ListStoreReservations)
+		v1.GET("/inventory/items/:id/reservations", handler.ListItemReservations)
+	}
+	return router
+}
+
+func TestListStoreReservations_CacheMiss(t *testing.T) {
+	mockCache := new(MockCache)
+	mockRepo := new(MockReservationRepository)
+	handler := NewReservationHandler(zap.NewNop(), mockRepo, mockCache, 300)
+	router := setupReservationRouter(handler)
+
+	storeID := uuid.New()
+	key := "reservations:store:" + storeID.String() + ":active:2:5"
+	reservation := models.StoreReservation{
+		ID:         uuid.New().String(),
+		StoreID:    storeID.String(),
+		ItemID:     uuid.New().String(),
+		Quantity:   3,
+		Status:     "active",
+		ReservedAt: time.Now(),
+	}
+
+	mockCache.On("Get", mock.Anything, key).Return(nil, cache.ErrCacheMiss)
+	mockRepo.On("ListReservationsByStore", mock.Anything, storeID, "active", 2, 5).Return([]models.StoreReservation{reservation}, 6, nil)
+	mockCache.On("Set", mock.Anything, key, mock.Anything, mock.Anything).Return(nil)
+
+	req := httptest.NewRequest("GET", "/api/v1/stores/"+storeID.String()+"/reservations?status=active&page=2&page_size=5", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response models.ListReservationsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Reservations, 1)
+	assert.Equal(t, 6, response.Total)
+	assert.Equal(t, 2, response.TotalPages)
+	mockCache.AssertExpectations(t)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListStoreReservations_StoreNotFound(t *testing.T) {
+	mockRepo := new(MockReservationRepository)
+	handler := NewReservationHandler(zap.NewNop(), mockRepo, nil, 300)
+	router := setupReservationRouter(handler)
+
+	storeID := uuid.New()
+	mockRepo.On("ListReservationsByStore", mock.Anything, storeID, "", 1, 10).Return(nil, 0, repository.ErrStoreNotFound)
+
+	req := httptest.NewRequest("GET", "/api/v1/stores/"+storeID.String()+"/reservations", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestListItemReservations_InvalidStatus(t *testing.T) {
+	mockRepo := new(MockReservationRepository)
+	handler := NewReservationHandler(zap.NewNop(), mockRepo, nil, 300)
+	router := setupReservationRouter(handler)
+
+	req := httptest.NewRequest("GET", "/api/v1/inventory/items/"+uuid.New().String()+"/reservations?status=pending", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRepo.AssertNotCalled(t, "ListReservationsByItem")
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"query-service/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serializedFormat tags the cache entries written by serializedResponse.marshal; other
+// values under the same key (an older format) are treated as a cache miss
+const serializedFormat = "resp1"
+
+const jsonContentType = "application/json; charset=utf-8"
+
+// serializedResponse is a JSON response kept in the cache as the bytes sent to the
+// client (optionally gzip-compressed) with its validators, so a cache hit is a byte copy
+// instead of an unmarshal and a marshal of the whole page
+type serializedResponse struct {
+	etag         string // ETag of the JSON representation
+	lastModified time.Time
+	gzipped      bool
+	body         []byte
+}
+
+// newSerializedResponse serializes response as JSON, compressing it when compress is set
+func newSerializedResponse(response interface{}, lastModified time.Time, compress bool) (serializedResponse, error) {
+	body, err := json.Marshal(response)
+	if err != nil {
+		return serializedResponse{}, fmt.Errorf("failed to marshal response: %w", err)
+	}
+	entry := serializedResponse{
+		etag:         entityTagFor(gin.MIMEJSON, response),
+		lastModified: lastModified,
+		body:         body,
+	}
+	if compress {
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		if _, err := gz.Write(body); err != nil {
+			return serializedResponse{}, fmt.Errorf("failed to compress response: %w", err)
+		}
+		if err := gz.Close(); err != nil {
+			return serializedResponse{}, fmt.Errorf("failed to compress response: %w", err)
+		}
+		entry.body = compressed.Bytes()
+		entry.gzipped = true
+	}
+	return entry, nil
+}
+
+// marshal encodes the entry as a header line ("resp1 <gzipped> <unix last-modified> <etag>")
+// followed by the body
+func (r serializedResponse) marshal() []byte {
+	var lastModified int64
+	if !r.lastModified.IsZero() {
+		lastModified = r.lastModified.Unix()
+	}
+	header := fmt.Sprintf("%s %t %d %s\n", serializedFormat, r.gzipped, lastModified, r.etag)
+	data := make([]byte, 0, len(header)+len(r.body))
+	return append(append(data, header...), r.body...)
+}
+
+// parseSerializedResponse decodes a cache entry written by marshal
+func parseSerializedResponse(data []byte) (serializedResponse, bool) {
+	newline := bytes.IndexByte(data, '\n')
+	if newline < 0 {
+		return serializedResponse{}, false
+	}
+	fields := strings.Fields(string(data[:newline]))
+	if len(fields) != 4 || fields[0] != serializedFormat {
+		return serializedResponse{}, false
+	}
+	gzipped, err := strconv.ParseBool(fields[1])
+	if err != nil {
+		return serializedResponse{}, false
+	}
+	lastModified, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return serializedResponse{}, false
+	}
+	entry := serializedResponse{etag: fields[3], gzipped: gzipped, body: data[newline+1:]}
+	if lastModified != 0 {
+		entry.lastModified = time.Unix(lastModified, 0).UTC()
+	}
+	return entry, true
+}
+
+// plain returns the uncompressed JSON body
+func (r serializedResponse) plain() ([]byte, error) {
+	if !r.gzipped {
+		return r.body, nil
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(r.body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress response: %w", err)
+	}
+	defer gz.Close()
+	return io.ReadAll(gz)
+}
+
+// respondSerialized writes entry with the conditional GET handling of respond. JSON
+// requests get the stored bytes, still compressed when the client accepts gzip and no
+// envelope has to wrap them; other formats get the value returned by decode, rendered
+// like respond does.
+func respondSerialized(c *gin.Context, entry serializedResponse, decode func() (interface{}, error)) error {
+	if negotiateFormat(c.GetHeader("Accept")) != gin.MIMEJSON {
+		response, err := decode()
+		if err != nil {
+			return err
+		}
+		if notModified(c, entityTag(c, response), entry.lastModified) {
+			return nil
+		}
+		respond(c, http.StatusOK, response)
+		return nil
+	}
+
+	if notModified(c, entry.etag, entry.lastModified) {
+		return nil
+	}
+	c.Writer.Header().Add("Vary", "Accept")
+	if entry.gzipped && middleware.AcceptsGzip(c.GetHeader("Accept-Encoding")) && !middleware.EnvelopeActive(c) {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		c.Header("Content-Encoding", "gzip")
+		c.Data(http.StatusOK, jsonContentType, entry.body)
+		return nil
+	}
+	body, err := entry.plain()
+	if err != nil {
+		return err
+	}
+	c.Data(http.StatusOK, jsonContentType, body)
+	return nil
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"query-service/internal/cache"
+	"query-service/internal/models"
+	"query-service/pkg/middleware"
+	"testsupport"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSerializedResponse_RoundTrip(t *testing.T) {
+	response := ListItemsResponse{Items: []InventoryItemResponse{{ID: "1", SKU: "SKU-001"}}, Total: 1, Page: 1, PageSize: 10, TotalPages: 1}
+	lastModified := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	for _, compress := range []bool{false, true} {
+		entry, err := newSerializedResponse(response, lastModified, compress)
+		require.NoError(t, err)
+
+		parsed, ok := parseSerializedResponse(entry.marshal())
+		require.True(t, ok)
+		assert.Equal(t, compress, parsed.gzipped)
+		assert.Equal(t, lastModified, parsed.lastModified)
+		assert.Equal(t, entityTagFor(gin.MIMEJSON, response), parsed.etag)
+
+		body, err := parsed.plain()
+		require.NoError(t, err)
+		expected, _ := json.Marshal(response)
+		assert.Equal(t, expected, body)
+	}
+
+	// Entries of the previous format (plain JSON) are a miss
+	legacy, _ := json.Marshal(response)
+	_, ok := parseSerializedResponse(legacy)
+	assert.False(t, ok)
+}
+
+func TestListItems_SerializedCache(t *testing.T) {
+	store := cache.NewKVCache(testsupport.NewKV(), zap.NewNop())
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(store, mockRepo)
+	handler.compressCache = true
+	router := setupTestRouter(handler)
+
+	testItem := createTestItem(uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"), "SKU-001")
+	mockRepo.On("ListItems", mock.Anything, 1, 10).Return([]models.InventoryItem{*testItem}, 1, nil).Once()
+
+	get := func(headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/inventory/items?page=1&page_size=10", nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Miss: read from the repository, stored serialized
+	miss := get(nil)
+	require.Equal(t, http.StatusOK, miss.Code)
+	plain := miss.Body.Bytes()
+
+	// Hit, gzip client: the stored compressed bytes
+	hit := get(map[string]string{"Accept-Encoding": "gzip"})
+	require.Equal(t, http.StatusOK, hit.Code)
+	assert.Equal(t, "gzip", hit.Header().Get("Content-Encoding"))
+	gz, err := gzip.NewReader(bytes.NewReader(hit.Body.Bytes()))
+	require.NoError(t, err)
+	body, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, plain, body)
+	assert.Equal(t, miss.Header().Get("ETag"), hit.Header().Get("ETag"))
+
+	// Hit, client without gzip: decompressed
+	hit = get(nil)
+	assert.Empty(t, hit.Header().Get("Content-Encoding"))
+	assert.Equal(t, plain, hit.Body.Bytes())
+
+	// Hit, XML: decoded and rendered
+	hit = get(map[string]string{"Accept": "application/xml"})
+	require.Equal(t, http.StatusOK, hit.Code)
+	assert.Contains(t, hit.Body.String(), "<sku>SKU-001</sku>")
+
+	// Hit, conditional
+	hit = get(map[string]string{"If-None-Match": miss.Header().Get("ETag")})
+	assert.Equal(t, http.StatusNotModified, hit.Code)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListItems_SerializedCacheWithEnvelope(t *testing.T) {
+	store := cache.NewKVCache(testsupport.NewKV(), zap.NewNop())
+	mockRepo := new(MockRepository)
+	handler := createTestHandler(store, mockRepo)
+	handler.compressCache = true
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RequestIDMiddleware(zap.NewNop()))
+	router.Use(middleware.ResponseEnvelope(true))
+	router.GET("/api/v1/inventory/items", handler.ListItems)
+
+	testItem := createTestItem(uuid.New(), "SKU-001")
+	mockRepo.On("ListItems", mock.Anything, 1, 10).Return([]models.InventoryItem{*testItem}, 1, nil).Once()
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "/api/v1/inventory/items", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		var envelope struct {
+			Data ListItemsResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &envelope))
+		assert.Equal(t, 1, envelope.Data.Total)
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"query-service/internal/cache"
+	"query-service/internal/models"
+	"query-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Items ranked and days of reservation activity of GET /inventory/stats
+const (
+	defaultStatsTop  = 5
+	maxStatsTop      = 50
+	defaultStatsDays = 30
+	maxStatsDays     = 365
+)
+
+// GetInventoryStats handles GET /api/v1/inventory/stats
+// @Summary      Inventory statistics
+// @Description  Obtiene el resumen del inventario para las tarjetas del dashboard: cantidad de items, unidades totales, reservadas y disponibles, items con poco stock y los items con más reservas.
+//
+// **Características:**
+// - Los totales se calculan con agregados SQL sobre los items no eliminados
+// - `low_stock_count` cuenta los items con `available` menor o igual a `low_stock_threshold` (LOW_STOCK_THRESHOLD)
+// - `top_reserved` ordena los items por la cantidad de movimientos que reservaron unidades en los últimos `days` días (desempate: unidades reservadas)
+// - Cache de STATS_CACHE_TTL segundos (default 30): los eventos no lo invalidan, así que el resumen puede tener hasta ese atraso; `generated_at` indica cuándo se calculó
+//
+// **Ejemplos válidos:**
+// - `GET /api/v1/inventory/stats`
+// - Top 10 de los últimos 7 días: `GET /api/v1/inventory/stats?top=10&days=7`
+//
+// **Ejemplos inválidos:**
+// - top fuera de rango: `GET /api/v1/inventory/stats?top=0`
+// - days fuera de rango: `GET /api/v1/inventory/stats?days=400`
+//
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        top   query     int  false  "Items in top_reserved (default: 5, max: 50)"
+// @Param        days  query     int  false  "Days of reservation activity ranked (default: 30, max: 365)"
+// @Success      200   {object}  models.InventoryStats  "Resumen del inventario"
+// @Failure      400   {object}  ErrorResponse  "Request inválido - top o days fuera de rango"
+// @Failure      401   {object}  ErrorResponse  "No autorizado - token JWT inválido o faltante"
+// @Failure      500   {object}  ErrorResponse  "Error interno del servidor - error de lectura"
+// @Router       /inventory/stats [get]
+func (h *InventoryHandler) GetInventoryStats(c *gin.Context) {
+	var err error
+	top := defaultStatsTop
+	if raw := c.Query("top"); raw != "" {
+		top, err = strconv.Atoi(raw)
+		if err != nil || top < 1 || top > maxStatsTop {
+			respondError(c, errors.NewInvalidRequest("top must be between 1 and "+strconv.Itoa(maxStatsTop), ""))
+			return
+		}
+	}
+	days := defaultStatsDays
+	if raw := c.Query("days"); raw != "" {
+		days, err = strconv.Atoi(raw)
+		if err != nil || days < 1 || days > maxStatsDays {
+			respondError(c, errors.NewInvalidRequest("days must be between 1 and "+strconv.Itoa(maxStatsDays), ""))
+			return
+		}
+	}
+
+	if h.stats == nil {
+		respondError(c, errors.NewInternalError("inventory statistics are not available", nil))
+		return
+	}
+
+	cacheKey := cacheKeyStats(top, days)
+	if h.cache != nil {
+		var cached models.InventoryStats
+		if err := h.cacheGetJSON(c.Request.Context(), cacheKey, &cached); err == nil {
+			h.logger.Debug("Cache hit", zap.String("key", cacheKey))
+			c.JSON(http.StatusOK, cached)
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	stats, err := h.stats.InventoryStats(c.Request.Context(), models.StatsQuery{
+		LowStockThreshold: h.lowStock,
+		Top:               top,
+		Since:             now.AddDate(0, 0, -days),
+	})
+	if err != nil {
+		h.logger.Error("Failed to compute inventory stats", zap.Error(err))
+		respondError(c, errors.NewInternalError("failed to get inventory stats", nil))
+		return
+	}
+	stats.ActivityDays = days
+	stats.GeneratedAt = now
+
+	if h.cache != nil {
+		cache.SetJSON(c.Request.Context(), h.cache, cacheKey, stats, h.entryTTL(h.statsTTL))
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// cacheKeyStats keys a summary by its parameters (the low-stock threshold is fixed by the
+// configuration)
+func cacheKeyStats(top, days int) string {
+	return "stats:inventory:" + strconv.Itoa(top) + ":" + strconv.Itoa(days)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"query-service/internal/cache"
+	"query-service/internal/models"
+	"query-service/internal/repository"
+	"testsupport"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupStatsRouter(t *testing.T, store cache.Cache) (*gin.Engine, *repository.InMemoryReadRepository) {
+	gin.SetMode(gin.TestMode)
+	repo := repository.NewInMemoryReadRepository()
+	handler := &InventoryHandler{logger: zap.NewNop(), repository: repo, stats: repo, cache: store, statsTTL: 30, lowStock: 5}
+
+	router := gin.New()
+	router.GET("/api/v1/inventory/stats", handler.GetInventoryStats)
+	return router, repo
+}
+
+func getStats(t *testing.T, router *gin.Engine, query string) models.InventoryStats {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/inventory/stats"+query, nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var stats models.InventoryStats
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	return stats
+}
+
+func TestGetInventoryStats(t *testing.T) {
+	router, repo := setupStatsRouter(t, nil)
+	laptop, mouse, cable, gone := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	deletedAt := time.Now()
+	for _, item := range []models.InventoryItem{
+		{ID: laptop.String(), SKU: "SKU-001", Name: "Laptop", Quantity: 40, Reserved: 9, Available: 31},
+		{ID: mouse.String(), SKU: "SKU-002", Name: "Mouse", Quantity: 6, Reserved: 3, Available: 3},
+		{ID: cable.String(), SKU: "SKU-003", Name: "Cable", Quantity: 0},
+		{ID: gone.String(), SKU: "SKU-004", Name: "Gone", Quantity: 100, DeletedAt: &deletedAt},
+	} {
+		require.NoError(t, repo.SaveItem(item))
+	}
+	now := time.Now()
+	for _, movement := range []models.StockMovement{
+		{ItemID: laptop.String(), ReservedChange: 4, OccurredAt: now.Add(-time.Hour)},
+		{ItemID: laptop.String(), ReservedChange: 5, OccurredAt: now.Add(-2 * time.Hour)},
+		{ItemID: mouse.String(), ReservedChange: 3, OccurredAt: now.Add(-time.Hour)},
+		{ItemID: mouse.String(), ReservedChange: -2, OccurredAt: now.Add(-time.Hour)},         // a release
+		{ItemID: mouse.String(), ReservedChange: 2, OccurredAt: now.AddDate(0, 0, -10)},       // outside days=7
+		{ItemID: gone.String(), ReservedChange: 9, OccurredAt: now.Add(-time.Hour)},           // deleted item
+		{ItemID: cable.String(), QuantityChange: 10, OccurredAt: now.Add(-time.Hour)},         // not a reservation
+		{ItemID: cable.String(), QuantityChange: -10, OccurredAt: now.Add(-30 * time.Minute)}, // not a reservation
+	} {
+		repo.AddMovement(movement)
+	}
+
+	stats := getStats(t, router, "?days=7")
+	assert.Equal(t, 3, stats.ItemCount)
+	assert.Equal(t, 46, stats.TotalUnits)
+	assert.Equal(t, 12, stats.TotalReserved)
+	assert.Equal(t, 34, stats.TotalAvailable)
+	assert.Equal(t, 2, stats.LowStockCount, "mouse and cable have at most 5 available")
+	assert.Equal(t, 5, stats.LowStockThreshold)
+	assert.Equal(t, 7, stats.ActivityDays)
+	assert.Equal(t, []models.ReservationActivity{
+		{ID: laptop.String(), SKU: "SKU-001", Name: "Laptop", Reservations: 2, UnitsReserved: 9, Reserved: 9},
+		{ID: mouse.String(), SKU: "SKU-002", Name: "Mouse", Reservations: 1, UnitsReserved: 3, Reserved: 3},
+	}, stats.TopReserved)
+
+	stats = getStats(t, router, "?top=1")
+	require.Len(t, stats.TopReserved, 1)
+	assert.Equal(t, "SKU-001", stats.TopReserved[0].SKU)
+	assert.Equal(t, 30, stats.ActivityDays)
+}
+
+func TestGetInventoryStats_Cached(t *testing.T) {
+	store := cache.NewKVCache(testsupport.NewKV(), zap.NewNop())
+	router, repo := setupStatsRouter(t, store)
+	require.NoError(t, repo.SaveItem(models.InventoryItem{ID: uuid.NewString(), SKU: "SKU-001", Name: "Laptop", Quantity: 10, Available: 10}))
+
+	first := getStats(t, router, "")
+	require.NoError(t, repo.SaveItem(models.InventoryItem{ID: uuid.NewString(), SKU: "SKU-002", Name: "Mouse", Quantity: 4, Available: 4}))
+
+	cached := getStats(t, router, "")
+	assert.Equal(t, 1, cached.ItemCount, "served from the cache until STATS_CACHE_TTL")
+	assert.True(t, first.GeneratedAt.Equal(cached.GeneratedAt))
+	assert.Equal(t, 2, getStats(t, router, "?top=10").ItemCount, "other parameters are cached apart")
+}
+
+func TestGetInventoryStats_InvalidParameters(t *testing.T) {
+	router, _ := setupStatsRouter(t, nil)
+	for _, query := range []string{"?top=0", "?top=51", "?top=abc", "?days=0", "?days=366"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/inventory/stats"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/repository"
+	"query-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Days of availability returned by GET /stores/:id/calendar
+const (
+	defaultCalendarDays = 7
+	maxCalendarDays     = 31
+)
+
+// StoreCalendarHandler serves the opening hours of the stores
+type StoreCalendarHandler struct {
+	logger *zap.Logger
+	repo   repository.StoreCalendarRepository
+	now    func() time.Time
+}
+
+// NewStoreCalendarHandler creates a new store calendar handler
+func NewStoreCalendarHandler(logger *zap.Logger, repo repository.StoreCalendarRepository) *StoreCalendarHandler {
+	return &StoreCalendarHandler{
+		logger: logger,
+		repo:   repo,
+		now:    time.Now,
+	}
+}
+
+// GetStoreCalendar handles GET /api/v1/stores/:id/calendar
+// @Summary      Store opening hours
+// @Description  Obtiene el horario de apertura y los feriados de una tienda (definidos con `PUT /stores/{id}/calendar` en el Command Service) y su disponibilidad para los próximos días.
+//
+// **Características:**
+// - `open_now`: si la tienda está abierta en este momento
+// - `days`: un día por fecha a partir de hoy, con los tramos de apertura (`periods`) o el feriado que la cierra
+// - Las fechas y horas están en la zona horaria de la tienda (`timezone`)
+// - Sin cache: refleja el estado actual del modelo de lectura
+//
+// **Ejemplos válidos:**
+// - `GET /api/v1/stores/{id}/calendar`
+// - Dos semanas: `GET /api/v1/stores/{id}/calendar?days=14`
+//
+// **Ejemplos inválidos:**
+// - ID inválido: `GET /api/v1/stores/abc/calendar`
+// - Días fuera de rango: `GET /api/v1/stores/{id}/calendar?days=90` (máximo 31)
+//
+// @Tags         stores
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      string  true   "Store ID (UUID)"
+// @Param        days  query     int     false  "Days of availability from today (default: 7, max: 31)" example(7)
+// @Success      200   {object}  models.StoreCalendarResponse  "Horario y disponibilidad de la tienda"
+// @Failure      400   {object}  ErrorResponse  "Request inválido - ID o días inválidos"
+// @Failure      401   {object}  ErrorResponse  "No autorizado - token JWT inválido o faltante"
+// @Failure      404   {object}  ErrorResponse  "Tienda no encontrada o sin horario (siempre abierta)"
+// @Failure      500   {object}  ErrorResponse  "Error interno del servidor - error de lectura"
+// @Router       /stores/{id}/calendar [get]
+func (h *StoreCalendarHandler) GetStoreCalendar(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, errors.NewInvalidRequest("invalid store id", ""))
+		return
+	}
+
+	days := defaultCalendarDays
+	if raw := c.Query("days"); raw != "" {
+		days, err = strconv.Atoi(raw)
+		if err != nil || days < 1 || days > maxCalendarDays {
+			respondError(c, errors.NewInvalidRequest("days must be between 1 and "+strconv.Itoa(maxCalendarDays), ""))
+			return
+		}
+	}
+
+	if h.repo == nil {
+		respondError(c, errors.NewInternalError("store calendars are not available", nil))
+		return
+	}
+
+	calendar, err := h.repo.GetStoreCalendar(c.Request.Context(), id)
+	if err != nil {
+		switch err {
+		case repository.ErrStoreNotFound:
+			respondError(c, errors.NewNotFound("store not found", "Store ID: "+id.String()))
+		case repository.ErrStoreCalendarNotFound:
+			respondError(c, errors.NewNotFound("store has no calendar", ""))
+		default:
+			h.logger.Error("Failed to get store calendar", zap.Error(err))
+			respondError(c, errors.NewInternalError("failed to get store calendar", nil))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, resolveCalendar(*calendar, h.now(), days))
+}
+
+// resolveCalendar works out, in the store's time zone, whether the store is open at now
+// and its opening periods on each of the following days (today included)
+func resolveCalendar(calendar models.StoreCalendar, now time.Time, days int) models.StoreCalendarResponse {
+	location, err := time.LoadLocation(calendar.Timezone)
+	if err != nil {
+		// The Command Service validates the time zone; an unknown one means the tz
+		// database of this host is older than the writer's
+		location = time.UTC
+	}
+	local := now.In(location)
+
+	holidays := make(map[string]models.Holiday, len(calendar.Holidays))
+	for _, holiday := range calendar.Holidays {
+		holidays[holiday.Date] = holiday
+	}
+
+	response := models.StoreCalendarResponse{StoreCalendar: calendar, Days: make([]models.CalendarDay, 0, days)}
+	for i := 0; i < days; i++ {
+		date := time.Date(local.Year(), local.Month(), local.Day()+i, 12, 0, 0, 0, location)
+		day := models.CalendarDay{
+			Date:    date.Format("2006-01-02"),
+			Weekday: strings.ToLower(date.Weekday().String()),
+			Periods: make([]models.OpeningHours, 0),
+		}
+		if holiday, closed := holidays[day.Date]; closed {
+			day.Holiday = &holiday
+		} else {
+			for _, hours := range calendar.Hours {
+				if strings.EqualFold(hours.Day, day.Weekday) {
+					day.Periods = append(day.Periods, hours)
+				}
+			}
+			sort.Slice(day.Periods, func(a, b int) bool { return day.Periods[a].Opens < day.Periods[b].Opens })
+			day.Open = len(day.Periods) > 0
+		}
+
+		if i == 0 {
+			clock := local.Format("15:04")
+			for _, period := range day.Periods {
+				if clock >= period.Opens && clock < period.Closes {
+					response.OpenNow = true
+				}
+			}
+		}
+		response.Days = append(response.Days, day)
+	}
+	return response
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupStoreCalendarRouter(t *testing.T, now time.Time) (*gin.Engine, *repository.InMemoryReadRepository) {
+	gin.SetMode(gin.TestMode)
+	repo := repository.NewInMemoryReadRepository()
+	handler := NewStoreCalendarHandler(zap.NewNop(), repo)
+	handler.now = func() time.Time { return now }
+
+	router := gin.New()
+	router.GET("/api/v1/stores/:id/calendar", handler.GetStoreCalendar)
+	return router, repo
+}
+
+func TestGetStoreCalendar(t *testing.T) {
+	// Monday 2024-12-23, 10:30 in Bogota (UTC-5)
+	router, repo := setupStoreCalendarRouter(t, time.Date(2024, 12, 23, 15, 30, 0, 0, time.UTC))
+
+	storeID := uuid.New()
+	require.NoError(t, repo.SaveStoreCalendar(models.StoreCalendar{
+		StoreID:  storeID.String(),
+		Timezone: "America/Bogota",
+		Hours: []models.OpeningHours{
+			{Day: "monday", Opens: "14:00", Closes: "19:00"},
+			{Day: "monday", Opens: "09:00", Closes: "13:00"},
+			{Day: "tuesday", Opens: "09:00", Closes: "19:00"},
+			{Day: "wednesday", Opens: "09:00", Closes: "19:00"},
+		},
+		Holidays: []models.Holiday{{Date: "2024-12-25", Name: "Navidad"}},
+	}))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/stores/"+storeID.String()+"/calendar?days=3", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response models.StoreCalendarResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.OpenNow)
+	assert.Equal(t, "America/Bogota", response.Timezone)
+	require.Len(t, response.Days, 3)
+
+	assert.Equal(t, "2024-12-23", response.Days[0].Date)
+	assert.Equal(t, "monday", response.Days[0].Weekday)
+	assert.True(t, response.Days[0].Open)
+	require.Len(t, response.Days[0].Periods, 2)
+	assert.Equal(t, "09:00", response.Days[0].Periods[0].Opens, "periods are sorted by opening time")
+
+	assert.True(t, response.Days[1].Open)
+
+	assert.Equal(t, "2024-12-25", response.Days[2].Date)
+	assert.False(t, response.Days[2].Open)
+	require.NotNil(t, response.Days[2].Holiday)
+	assert.Equal(t, "Navidad", response.Days[2].Holiday.Name)
+	assert.Empty(t, response.Days[2].Periods)
+}
+
+func TestGetStoreCalendar_ClosedNow(t *testing.T) {
+	// 13:30 in Bogota, between the two Monday periods
+	router, repo := setupStoreCalendarRouter(t, time.Date(2024, 12, 23, 18, 30, 0, 0, time.UTC))
+
+	storeID := uuid.New()
+	require.NoError(t, repo.SaveStoreCalendar(models.StoreCalendar{
+		StoreID:  storeID.String(),
+		Timezone: "America/Bogota",
+		Hours: []models.OpeningHours{
+			{Day: "monday", Opens: "09:00", Closes: "13:00"},
+			{Day: "monday", Opens: "14:00", Closes: "19:00"},
+		},
+	}))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/stores/"+storeID.String()+"/calendar", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response models.StoreCalendarResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.False(t, response.OpenNow)
+	assert.Len(t, response.Days, defaultCalendarDays)
+}
+
+func TestGetStoreCalendar_Errors(t *testing.T) {
+	router, repo := setupStoreCalendarRouter(t, time.Now())
+
+	withoutCalendar := uuid.New()
+	repo.SaveStore(withoutCalendar)
+
+	tests := []struct {
+		name string
+		path string
+		code int
+	}{
+		{"invalid id", "/api/v1/stores/abc/calendar", http.StatusBadRequest},
+		{"days out of range", "/api/v1/stores/" + withoutCalendar.String() + "/calendar?days=90", http.StatusBadRequest},
+		{"unknown store", "/api/v1/stores/" + uuid.New().String() + "/calendar", http.StatusNotFound},
+		{"store without calendar", "/api/v1/stores/" + withoutCalendar.String() + "/calendar", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"query-service/internal/stream"
+	"query-service/internal/tenant"
+	"query-service/pkg/errors"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// StreamHandler serves the live inventory stream
+type StreamHandler struct {
+	logger    *zap.Logger
+	hub       *stream.Hub // nil when the stream is disabled
+	heartbeat time.Duration
+}
+
+// NewStreamHandler creates a new stream handler
+func NewStreamHandler(logger *zap.Logger, hub *stream.Hub, heartbeat time.Duration) *StreamHandler {
+	return &StreamHandler{
+		logger:    logger,
+		hub:       hub,
+		heartbeat: heartbeat,
+	}
+}
+
+// StreamInventory handles GET /api/v1/inventory/stream
+// @Summary      Live inventory stream (SSE)
+// @Description  Abre un stream Server-Sent Events con los cambios de inventario confirmados por el Listener Service (eventos `...Confirmed` de Kafka), para no tener que consultar el read model periódicamente.
+//
+// **Eventos:**
+// - `ready`: suscripción activa, con los filtros aplicados
+// - `inventory`: un cambio confirmado (`type`, `item_id`, `sku`, `occurred_at`, `data`); el `id` SSE es el `event_id` del evento
+// - `heartbeat`: cada `STREAM_HEARTBEAT_SECONDS`, para mantener viva la conexión a través de proxies
+// - `overflow`: el cliente no consumió los eventos a tiempo y se cierra el stream; recargar los datos y reconectar
+//
+// **Filtros (opcionales, repetibles o separados por comas):**
+// - `item_id`: solo los eventos de esos items
+// - `sku`: solo los eventos de esos SKUs
+// - Con ambos, basta con que el evento coincida con uno; sin filtros se reciben todos (incluidos los de tiendas)
+//
+// **Ejemplos válidos:**
+// - `GET /api/v1/inventory/stream`
+// - `GET /api/v1/inventory/stream?sku=SKU-001,SKU-002`
+// - `GET /api/v1/inventory/stream?item_id=550e8400-e29b-41d4-a716-446655440000`
+//
+// **Ejemplos inválidos:**
+// - Item ID inválido: `GET /api/v1/inventory/stream?item_id=invalid-uuid`
+//
+// @Tags         inventory
+// @Produce      text/event-stream
+// @Security     BearerAuth
+// @Param        item_id  query     string  false  "Item IDs (UUID) a seguir"
+// @Param        sku      query     string  false  "SKUs a seguir" example(SKU-001)
+// @Success      200      {string}  string         "Stream de eventos SSE"
+// @Failure      400      {object}  ErrorResponse  "Request inválido - item_id inválido"
+// @Failure      401      {object}  ErrorResponse  "No autorizado - token JWT inválido o faltante"
+// @Failure      503      {object}  ErrorResponse  "Stream deshabilitado (USE_KAFKA=false o STREAM_ENABLED=false)"
+// @Router       /inventory/stream [get]
+func (h *StreamHandler) StreamInventory(c *gin.Context) {
+	if h.hub == nil {
+		respondError(c, errors.NewServiceUnavailable("live stream is disabled", ""))
+		return
+	}
+
+	itemIDs := queryList(c, "item_id")
+	for _, id := range itemIDs {
+		if _, err := uuid.Parse(id); err != nil {
+			respondError(c, errors.NewInvalidRequest("invalid item_id: "+id, ""))
+			return
+		}
+	}
+	skus := queryList(c, "sku")
+
+	filter := stream.NewFilter(itemIDs, skus)
+	filter.Tenant = tenant.FromContext(c.Request.Context())
+	subscription := h.hub.Subscribe(filter)
+	defer subscription.Close()
+
+	h.logger.Debug("Stream client connected",
+		zap.String("username", c.GetString("username")),
+		zap.Strings("item_ids", itemIDs),
+		zap.Strings("skus", skus),
+	)
+
+	header := c.Writer.Header()
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // keep nginx from buffering the stream
+	c.Status(http.StatusOK)
+	h.send(c, sse.Event{Event: "ready", Data: gin.H{"item_ids": itemIDs, "skus": skus}})
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				if subscription.Dropped() {
+					h.logger.Warn("Stream client dropped, too slow", zap.String("username", c.GetString("username")))
+					h.send(c, sse.Event{Event: "overflow", Data: gin.H{"error": "client too slow, reload and reconnect"}})
+				}
+				return
+			}
+			h.send(c, sse.Event{Id: event.ID, Event: "inventory", Data: event})
+		case now := <-heartbeat.C:
+			h.send(c, sse.Event{Event: "heartbeat", Data: gin.H{"time": now.UTC()}})
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// send writes one SSE event and flushes it to the client
+func (h *StreamHandler) send(c *gin.Context, event sse.Event) {
+	c.Render(-1, event)
+	c.Writer.Flush()
+}
+
+// queryList returns the values of a repeatable, comma-separated query parameter
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, value := range c.QueryArray(key) {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+	}
+	return values
+}
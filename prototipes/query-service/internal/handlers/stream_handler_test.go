@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"query-service/internal/stream"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// sseEvent is one event read from the stream
+type sseEvent struct {
+	id, name, data string
+}
+
+// openStream connects to GET /api/v1/inventory/stream?query and waits until the hub
+// has registered the client. The stream is closed at the end of the test.
+func openStream(t *testing.T, hub *stream.Hub, heartbeat time.Duration, query string) func() sseEvent {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/inventory/stream", NewStreamHandler(zap.NewNop(), hub, heartbeat).StreamInventory)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	clients := hub.Clients()
+	resp, err := http.Get(server.URL + "/api/v1/inventory/stream?" + query)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	require.Eventually(t, func() bool { return hub.Clients() > clients }, time.Second, time.Millisecond)
+
+	reader := bufio.NewReader(resp.Body)
+	return func() sseEvent {
+		var event sseEvent
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			line = strings.TrimSuffix(line, "\n")
+			switch {
+			case line == "":
+				return event
+			case strings.HasPrefix(line, "id:"):
+				event.id = strings.TrimPrefix(line, "id:")
+			case strings.HasPrefix(line, "event:"):
+				event.name = strings.TrimPrefix(line, "event:")
+			case strings.HasPrefix(line, "data:"):
+				event.data = strings.TrimPrefix(line, "data:")
+			}
+		}
+	}
+}
+
+func TestStreamInventory_Filters(t *testing.T) {
+	hub := stream.NewHub(16)
+	next := openStream(t, hub, time.Minute, "sku=SKU-001&item_id=550e8400-e29b-41d4-a716-446655440000")
+
+	ready := next()
+	assert.Equal(t, "ready", ready.name)
+	assert.JSONEq(t, `{"item_ids":["550e8400-e29b-41d4-a716-446655440000"],"skus":["SKU-001"]}`, ready.data)
+
+	hub.Publish(stream.Event{ID: "e-1", Type: "StockReservedConfirmed", SKU: "SKU-001", Data: []byte(`{"available":7}`)})
+	hub.Publish(stream.Event{ID: "e-2", Type: "StockReservedConfirmed", SKU: "SKU-002", Data: []byte(`{}`)})
+	hub.Publish(stream.Event{ID: "e-3", Type: "StockAdjustedConfirmed", ItemID: "550e8400-e29b-41d4-a716-446655440000", Data: []byte(`{}`)})
+	hub.Publish(stream.Event{ID: "e-4", Type: "StoreCreatedConfirmed", Data: []byte(`{}`)})
+
+	first := next()
+	assert.Equal(t, sseEvent{id: "e-1", name: "inventory"}, sseEvent{id: first.id, name: first.name})
+	assert.Contains(t, first.data, `"type":"StockReservedConfirmed"`)
+	assert.Contains(t, first.data, `"data":{"available":7}`)
+	assert.Equal(t, "e-3", next().id, "events of other SKUs and of stores are filtered out")
+}
+
+func TestStreamInventory_Heartbeat(t *testing.T) {
+	hub := stream.NewHub(16)
+	next := openStream(t, hub, 10*time.Millisecond, "")
+
+	assert.Equal(t, "ready", next().name)
+	assert.Equal(t, "heartbeat", next().name)
+}
+
+func TestStreamInventory_Disconnect(t *testing.T) {
+	hub := stream.NewHub(16)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/stream", NewStreamHandler(zap.NewNop(), hub, time.Minute).StreamInventory)
+
+	ctx, disconnect := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/stream", nil).WithContext(ctx))
+		close(done)
+	}()
+	require.Eventually(t, func() bool { return hub.Clients() == 1 }, time.Second, time.Millisecond)
+
+	disconnect()
+	<-done
+	assert.Equal(t, 0, hub.Clients(), "the subscription ends with the request")
+}
+
+func TestStreamInventory_Invalid(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/stream", NewStreamHandler(zap.NewNop(), stream.NewHub(1), time.Minute).StreamInventory)
+	router.GET("/disabled", NewStreamHandler(zap.NewNop(), nil, time.Minute).StreamInventory)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/stream?item_id=invalid-uuid", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/disabled", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"query-service/internal/repository"
+	"query-service/internal/valuation"
+	"query-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ValuationHandler serves the inventory valuation report
+type ValuationHandler struct {
+	logger        *zap.Logger
+	repository    repository.ValuationRepository
+	defaultMethod valuation.Method
+}
+
+// NewValuationHandler creates a new valuation handler
+func NewValuationHandler(logger *zap.Logger, repo repository.ValuationRepository, defaultMethod valuation.Method) *ValuationHandler {
+	return &ValuationHandler{
+		logger:        logger,
+		repository:    repo,
+		defaultMethod: defaultMethod,
+	}
+}
+
+// GetValuationReport handles GET /api/v1/inventory/valuation
+// @Summary      Inventory valuation report
+// @Description  Calcula la valorización del inventario a partir de las capas de costo registradas en cada recepción de stock. Soporta FIFO y costo promedio ponderado.
+//
+// **Características:**
+// - `method=fifo`: el stock restante se valoriza con el costo de las recepciones más recientes (las capas más antiguas se consumen primero)
+// - `method=weighted_average`: el stock restante se valoriza con el costo promedio de todas las recepciones
+// - Sin `method` se usa el método configurado (VALUATION_METHOD)
+// - `uncosted_quantity` indica el stock sin costo conocido
+//
+// **Ejemplos válidos:**
+// - Reporte completo: `GET /api/v1/inventory/valuation`
+// - Promedio ponderado: `GET /api/v1/inventory/valuation?method=weighted_average`
+// - Un solo item: `GET /api/v1/inventory/valuation?item_id=550e8400-e29b-41d4-a716-446655440000`
+//
+// **Ejemplos inválidos:**
+// - Método desconocido: `GET /api/v1/inventory/valuation?method=lifo`
+// - item_id inválido: `GET /api/v1/inventory/valuation?item_id=abc`
+//
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        method   query     string  false  "Valuation method (fifo, weighted_average)"
+// @Param        item_id  query     string  false  "Item ID (UUID) to value a single item"
+// @Success      200      {object}  models.ValuationReport  "Reporte de valorización"
+// @Failure      400      {object}  ErrorResponse           "Request inválido - método o item_id inválido"
+// @Failure      401      {object}  ErrorResponse           "No autorizado - token JWT inválido o faltante"
+// @Failure      404      {object}  ErrorResponse           "Item no encontrado"
+// @Failure      500      {object}  ErrorResponse           "Error interno del servidor - error de lectura"
+// @Router       /inventory/valuation [get]
+func (h *ValuationHandler) GetValuationReport(c *gin.Context) {
+	method := h.defaultMethod
+	if value := c.Query("method"); value != "" {
+		parsed, err := valuation.ParseMethod(value)
+		if err != nil {
+			respondError(c, errors.NewInvalidRequest(err.Error(), ""))
+			return
+		}
+		method = parsed
+	}
+
+	var itemID *uuid.UUID
+	if value := c.Query("item_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			respondError(c, errors.NewInvalidRequest("invalid item id", ""))
+			return
+		}
+		itemID = &id
+	}
+
+	items, err := h.repository.ListCostLayers(c.Request.Context(), itemID)
+	if err != nil {
+		if err == repository.ErrItemNotFound {
+			respondError(c, errors.NewItemNotFound(itemID.String()))
+			return
+		}
+		h.logger.Error("Failed to load cost layers", zap.Error(err))
+		respondError(c, errors.NewInternalError("failed to build valuation report", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, valuation.BuildReport(items, method, time.Now().UTC()))
+}
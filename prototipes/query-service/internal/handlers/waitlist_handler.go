@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+
+	"query-service/internal/repository"
+	"query-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// WaitlistHandler serves the status of waitlisted reservations
+type WaitlistHandler struct {
+	logger *zap.Logger
+	repo   repository.WaitlistRepository
+}
+
+// NewWaitlistHandler creates a new waitlist handler
+func NewWaitlistHandler(logger *zap.Logger, repo repository.WaitlistRepository) *WaitlistHandler {
+	return &WaitlistHandler{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+// GetWaitlistEntry handles GET /api/v1/inventory/waitlist/:id
+// @Summary      Waitlisted reservation status
+// @Description  Obtiene el estado de una reserva encolada en la lista de espera (creada con `POST /inventory/items/{id}/reserve` y `"waitlist": true`).
+//
+// **Características:**
+// - `status`: `waiting`, `fulfilled` o `cancelled`
+// - `position`: lugar en la cola del item (1 = siguiente en ser atendida), solo mientras está en `waiting`
+// - Sin cache: refleja el estado actual del modelo de lectura
+//
+// **Ejemplos válidos:**
+// - `GET /api/v1/inventory/waitlist/7c9e6679-7425-40de-944b-e07fc1f90ae7`
+//
+// **Ejemplos inválidos:**
+// - ID inválido: `GET /api/v1/inventory/waitlist/invalid-uuid`
+//
+// @Tags         inventory
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Waitlist ID (UUID)"
+// @Success      200  {object}  models.WaitlistEntry  "Estado de la reserva en espera"
+// @Failure      400  {object}  ErrorResponse  "Request inválido - ID inválido"
+// @Failure      401  {object}  ErrorResponse  "No autorizado - token JWT inválido o faltante"
+// @Failure      404  {object}  ErrorResponse  "Reserva en espera no encontrada"
+// @Failure      500  {object}  ErrorResponse  "Error interno del servidor - error de lectura"
+// @Router       /inventory/waitlist/{id} [get]
+func (h *WaitlistHandler) GetWaitlistEntry(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondError(c, errors.NewInvalidRequest("invalid waitlist id", ""))
+		return
+	}
+
+	entry, err := h.repo.FindWaitlistEntry(c.Request.Context(), id)
+	if err != nil {
+		if err == repository.ErrWaitlistEntryNotFound {
+			respondError(c, errors.NewNotFound("waitlist entry not found", ""))
+			return
+		}
+		h.logger.Error("Failed to find waitlist entry", zap.Error(err))
+		respondError(c, errors.NewInternalError("failed to get waitlist entry", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// MockWaitlistRepository is a mock implementation of repository.WaitlistRepository
+type MockWaitlistRepository struct {
+	mock.Mock
+}
+
+func (m *MockWaitlistRepository) FindWaitlistEntry(ctx context.Context, id uuid.UUID) (*models.WaitlistEntry, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WaitlistEntry), args.Error(1)
+}
+
+func setupWaitlistRouter(handler *WaitlistHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/inventory/waitlist/:id", handler.GetWaitlistEntry)
+	return router
+}
+
+func TestGetWaitlistEntry_Waiting(t *testing.T) {
+	mockRepo := new(MockWaitlistRepository)
+	router := setupWaitlistRouter(NewWaitlistHandler(zap.NewNop(), mockRepo))
+
+	id := uuid.New()
+	mockRepo.On("FindWaitlistEntry", mock.Anything, id).Return(&models.WaitlistEntry{
+		ID:          id.String(),
+		ItemID:      uuid.New().String(),
+		Quantity:    20,
+		Status:      "waiting",
+		Position:    2,
+		RequestedAt: time.Now(),
+	}, nil)
+
+	req := httptest.NewRequest("GET", "/api/v1/inventory/waitlist/"+id.String(), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response models.WaitlistEntry
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "waiting", response.Status)
+	assert.Equal(t, 2, response.Position)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetWaitlistEntry_NotFound(t *testing.T) {
+	mockRepo := new(MockWaitlistRepository)
+	router := setupWaitlistRouter(NewWaitlistHandler(zap.NewNop(), mockRepo))
+
+	id := uuid.New()
+	mockRepo.On("FindWaitlistEntry", mock.Anything, id).Return(nil, repository.ErrWaitlistEntryNotFound)
+
+	req := httptest.NewRequest("GET", "/api/v1/inventory/waitlist/"+id.String(), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
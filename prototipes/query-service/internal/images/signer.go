@@ -0,0 +1,196 @@
+// Package images builds the URLs of the item images in the Query Service responses.
+// The files are stored by the Command Service (IMAGE_STORE): in a local directory,
+// served by GET /api/v1/images/*key, or in an S3 or MinIO bucket. With
+// IMAGE_SIGNED_URLS=true the responses carry URLs that expire instead of the public
+// ones published in the events.
+package images
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"query-service/internal/config"
+	"query-service/internal/models"
+)
+
+// Errors of Verify
+var (
+	ErrInvalidSignature = errors.New("invalid image URL signature")
+	ErrExpiredURL       = errors.New("image URL has expired")
+)
+
+// Signer signs the URLs of the images. A nil *Signer leaves the published URLs as they are.
+type Signer struct {
+	store   string
+	ttl     time.Duration
+	window  time.Duration // URLs signed within the same window are identical
+	baseURL string        // local: base URL of GET /api/v1/images
+	key     []byte        // local: HMAC key
+	// s3: URL of the bucket and credentials of the presigned URLs
+	bucket          *url.URL
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	now             func() time.Time
+}
+
+// NewSigner returns the signer of IMAGE_STORE, or nil when IMAGE_SIGNED_URLS=false
+func NewSigner(cfg *config.Config) (*Signer, error) {
+	if !cfg.ImageSignedURLs {
+		return nil, nil
+	}
+	ttl := time.Duration(cfg.ImageURLTTLSeconds) * time.Second
+	signer := &Signer{store: cfg.ImageStore, ttl: ttl, window: ttl / 4, now: time.Now}
+	switch cfg.ImageStore {
+	case config.ImageStoreLocal:
+		signer.baseURL = cfg.ImagePublicBaseURL
+		signer.key = []byte(cfg.ImageSigningKey)
+	case config.ImageStoreS3:
+		endpoint, err := url.Parse(cfg.S3Endpoint)
+		if err != nil || endpoint.Host == "" {
+			return nil, fmt.Errorf("invalid S3 endpoint %q", cfg.S3Endpoint)
+		}
+		bucket := *endpoint
+		if cfg.S3PathStyle {
+			bucket.Path = strings.TrimSuffix(endpoint.Path, "/") + "/" + cfg.S3Bucket
+		} else {
+			bucket.Host = cfg.S3Bucket + "." + endpoint.Host
+		}
+		signer.bucket = &bucket
+		signer.region = cfg.S3Region
+		signer.accessKeyID = cfg.S3AccessKeyID
+		signer.secretAccessKey = cfg.S3SecretAccessKey
+	default:
+		return nil, fmt.Errorf("unknown IMAGE_STORE %q", cfg.ImageStore)
+	}
+	return signer, nil
+}
+
+// URL returns the URL of an image for a response: signed when signing is enabled, the
+// published one otherwise
+func (s *Signer) URL(image models.ItemImage) string {
+	if s == nil {
+		return image.URL
+	}
+	// Signing from the start of the current window keeps the URL (and the ETag of the
+	// responses that carry it) stable for a while; it stays valid for at least 3/4 of the TTL
+	signedAt := s.now().UTC().Truncate(s.window)
+	if s.store == config.ImageStoreS3 {
+		return s.presign(image.Key, signedAt)
+	}
+	expires := strconv.FormatInt(signedAt.Add(s.ttl).Unix(), 10)
+	return s.baseURL + "/" + image.Key + "?expires=" + expires + "&signature=" + s.localSignature(image.Key, expires)
+}
+
+// Verify checks the expires and signature query parameters of a signed local URL
+func (s *Signer) Verify(key, expires, signature string) error {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.localSignature(key, expires))) {
+		return ErrInvalidSignature
+	}
+	if s.now().Unix() > expiresAt {
+		return ErrExpiredURL
+	}
+	return nil
+}
+
+// ExpiresIn is how long a URL signed now stays valid, at least
+func (s *Signer) ExpiresIn() time.Duration {
+	return s.ttl - s.window
+}
+
+func (s *Signer) localSignature(key, expires string) string {
+	return hex.EncodeToString(hmacSHA256(s.key, key+"\n"+expires))
+}
+
+// presign returns a presigned GET URL of the object (AWS Signature Version 4, query
+// string authentication), valid for the TTL from signedAt
+func (s *Signer) presign(key string, signedAt time.Time) string {
+	object := *s.bucket
+	object.Path = strings.TrimSuffix(object.Path, "/") + "/" + key
+	object.RawPath = ""
+
+	amzDate := signedAt.Format("20060102T150405Z")
+	scope := amzDate[:8] + "/" + s.region + "/s3/aws4_request"
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.accessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(s.ttl/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalQueryString := canonicalQuery(query)
+	canonicalRequest := strings.Join([]string{
+		"GET",
+		canonicalPath(&object),
+		canonicalQueryString,
+		"host:" + object.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	object.RawQuery = canonicalQueryString + "&X-Amz-Signature=" + signature(s.secretAccessKey, amzDate, s.region, scope, canonicalRequest)
+	return object.String()
+}
+
+// signature signs the canonical request with the key derived for the date and region
+func signature(secretAccessKey, amzDate, region, scope, canonicalRequest string) string {
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), amzDate[:8])
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// canonicalPath is the URI-encoded path; S3 does not normalize it
+func canonicalPath(u *url.URL) string {
+	if path := u.EscapedPath(); path != "" {
+		return path
+	}
+	return "/"
+}
+
+// canonicalQuery sorts the query by name and URI-encodes it
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var pairs []string
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(name)+"="+uriEncode(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode encodes everything but the unreserved characters, as SigV4 requires
+func uriEncode(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
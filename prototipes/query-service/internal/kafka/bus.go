@@ -0,0 +1,80 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"query-service/internal/config"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// busKeyHeader carries the partition key of a message on NATS and RabbitMQ, which
+// have no message key
+const busKeyHeader = "message-key"
+
+// busPrefetch is how many messages a bus delivers ahead of the one being handled
+const busPrefetch = 64
+
+// busTimeout bounds a request to the NATS or RabbitMQ server
+const busTimeout = 5 * time.Second
+
+// busRetryDelay is how long a consumer waits before consuming the bus again after it
+// failed
+const busRetryDelay = 5 * time.Second
+
+// EventConsumer reads the messages of a set of topics from the NATS or RabbitMQ bus of
+// EVENT_BUS. Messages are handed over in the shape of Kafka messages, so they go
+// through the same path as those of the Kafka consumers.
+type EventConsumer interface {
+	// Consume calls handle for each message of topics, one at a time, until ctx is
+	// cancelled (it then returns nil) or the consumer fails. A message is acknowledged
+	// once handle returns.
+	Consume(ctx context.Context, topics []string, handle func(*sarama.ConsumerMessage)) error
+	// Ping fails when the bus does not answer. Used by the readiness probe.
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// NewEventConsumer connects to the NATS or RabbitMQ bus of cfg. The consumers of a
+// group share its messages, which are kept while they are down (like a Kafka consumer
+// group); without a group each consumer gets every message from the newest.
+func NewEventConsumer(cfg *config.Config, group string) (EventConsumer, error) {
+	switch cfg.EventBus {
+	case config.EventBusNATS:
+		return dialNATS(cfg, group)
+	case config.EventBusRabbitMQ:
+		return dialRabbitMQ(cfg, group)
+	}
+	return nil, fmt.Errorf("EVENT_BUS %q is not a NATS or RabbitMQ bus", cfg.EventBus)
+}
+
+// busTopics are the topics the service consumes (those of the shared and the dedicated
+// tenants)
+func busTopics(cfg *config.Config) []string {
+	return cfg.ListedTopics(cfg.EventTopics()...)
+}
+
+// withBusTimeout bounds ctx by busTimeout; the NATS client needs a deadline
+func withBusTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, busTimeout)
+}
+
+// consumeBus calls handle for the messages of the bus until ctx is cancelled,
+// consuming it again after busRetryDelay when it fails
+func consumeBus(ctx context.Context, bus EventConsumer, topics []string, handle func(*sarama.ConsumerMessage), logger *zap.Logger) {
+	for {
+		err := bus.Consume(ctx, topics, handle)
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Warn("Event consumer stopped, retrying", zap.Duration("delay", busRetryDelay), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(busRetryDelay):
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/nats-io/nats.go"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumerMessageFromNATS(t *testing.T) {
+	msg := nats.NewMsg("inventory.stock")
+	msg.Data = []byte(`{"event_type":"StockReservedConfirmed"}`)
+	msg.Header.Set(busKeyHeader, "item-1")
+	msg.Header.Set(nats.MsgIdHdr, "event-1")
+	msg.Header.Set("event-type", "StockReservedConfirmed")
+
+	message := consumerMessageFromNATS(msg)
+	assert.Equal(t, "inventory.stock", message.Topic)
+	assert.Equal(t, []byte("item-1"), message.Key)
+	require.Len(t, message.Headers, 1, "the key and the JetStream message ID are not event headers")
+	assert.Equal(t, "StockReservedConfirmed", busHeader(message.Headers, "event-type"))
+	assert.False(t, message.Timestamp.IsZero())
+}
+
+func TestConsumerMessageFromAMQP(t *testing.T) {
+	message := consumerMessageFromAMQP(amqp.Delivery{
+		RoutingKey:  "inventory.items",
+		DeliveryTag: 3,
+		Headers:     amqp.Table{busKeyHeader: "item-1", "event-type": "InventoryItemCreatedConfirmed", "schema-version": []byte("2")},
+		Body:        []byte(`{}`),
+	})
+	assert.Equal(t, "inventory.items", message.Topic)
+	assert.Equal(t, int64(3), message.Offset)
+	assert.Equal(t, []byte("item-1"), message.Key)
+	assert.Equal(t, "InventoryItemCreatedConfirmed", busHeader(message.Headers, "event-type"))
+	assert.Equal(t, "2", busHeader(message.Headers, "schema-version"))
+	assert.False(t, message.Timestamp.IsZero(), "a delivery without timestamp is stamped on arrival")
+}
+
+func TestNATSDurableName(t *testing.T) {
+	assert.Equal(t, "query-service_inventory_items", natsDurableName("query-service", "inventory.items"))
+}
+
+func busHeader(headers []*sarama.RecordHeader, key string) string {
+	for _, h := range headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
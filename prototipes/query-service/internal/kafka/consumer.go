@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -12,25 +13,60 @@ import (
 	"query-service/internal/config"
 	"query-service/internal/models"
 	"query-service/internal/repository"
+	"query-service/internal/tenant"
+	"query-service/pkg/metrics"
+	"query-service/pkg/tracing"
 
 	"github.com/IBM/sarama"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 // Consumer represents a Kafka consumer for cache invalidation and update
 type Consumer struct {
+	client        sarama.Client // owns the broker connections of consumerGroup
 	consumerGroup sarama.ConsumerGroup
+	bus           EventConsumer // set instead of consumerGroup on NATS and RabbitMQ
 	cache         cache.Cache
 	repository    repository.ReadRepository
+	cipher        *PayloadCipher // nil when payload decryption is disabled
 	logger        *zap.Logger
 	config        *config.Config
+	topicsMu      sync.RWMutex // topics change with KAFKA_TOPIC_SUBSCRIPTION=regex
 	topics        []string
 	cacheTTL      time.Duration
 }
 
-// NewConsumer creates a new Kafka consumer for cache invalidation and update
+// NewConsumer creates a new Kafka consumer for cache invalidation and update, or a
+// consumer of the NATS or RabbitMQ bus of EVENT_BUS
 func NewConsumer(cfg *config.Config, cacheClient cache.Cache, repo repository.ReadRepository, logger *zap.Logger) (*Consumer, error) {
+	payloadCipher, err := NewPayloadCipher(cfg.EventEncryptionKeys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event encryption config: %w", err)
+	}
+	topics := cfg.ListedTopics(cfg.EventTopics()...)
+
+	if cfg.EventBus != config.EventBusKafka {
+		bus, err := NewEventConsumer(cfg, cfg.KafkaGroupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create consumer: %w", err)
+		}
+		return &Consumer{
+			bus:        bus,
+			cache:      cacheClient,
+			repository: repo,
+			cipher:     payloadCipher,
+			logger:     logger,
+			config:     cfg,
+			topics:     topics,
+			cacheTTL:   time.Duration(cfg.CacheTTL) * time.Second,
+		}, nil
+	}
+
 	logger.Info("🔌 Creating Kafka consumer",
 		zap.Strings("brokers", cfg.KafkaBrokers),
 		zap.String("group_id", cfg.KafkaGroupID),
@@ -61,7 +97,18 @@ func NewConsumer(cfg *config.Config, cacheClient cache.Cache, repo repository.Re
 	saramaConfig.Metadata.Retry.Max = 3
 	saramaConfig.Metadata.Retry.Backoff = 250 * time.Millisecond
 
-	consumerGroup, err := sarama.NewConsumerGroup(cfg.KafkaBrokers, cfg.KafkaGroupID, saramaConfig)
+	// TLS and SASL for managed clusters
+	if err := configureSecurity(saramaConfig, cfg); err != nil {
+		return nil, fmt.Errorf("invalid Kafka security config: %w", err)
+	}
+
+	client, err := sarama.NewClient(cfg.KafkaBrokers, saramaConfig)
+	var consumerGroup sarama.ConsumerGroup
+	if err == nil {
+		if consumerGroup, err = sarama.NewConsumerGroupFromClient(cfg.KafkaGroupID, client); err != nil {
+			client.Close()
+		}
+	}
 	if err != nil {
 		logger.Error("❌ Failed to create Kafka consumer group",
 			zap.Strings("brokers", cfg.KafkaBrokers),
@@ -69,18 +116,23 @@ func NewConsumer(cfg *config.Config, cacheClient cache.Cache, repo repository.Re
 		)
 		return nil, fmt.Errorf("failed to create consumer group: %w", err)
 	}
+	if topics, err = eventTopics(client, cfg); err != nil {
+		consumerGroup.Close()
+		client.Close()
+		return nil, fmt.Errorf("failed to resolve event topics: %w", err)
+	}
 
 	logger.Info("✅ Kafka consumer group created successfully",
 		zap.Strings("brokers", cfg.KafkaBrokers),
 		zap.String("group_id", cfg.KafkaGroupID),
 	)
 
-	topics := []string{cfg.KafkaTopicItems, cfg.KafkaTopicStock}
-
 	return &Consumer{
+		client:        client,
 		consumerGroup: consumerGroup,
 		cache:         cacheClient,
 		repository:    repo,
+		cipher:        payloadCipher,
 		logger:        logger,
 		config:        cfg,
 		topics:        topics,
@@ -93,17 +145,46 @@ func (c *Consumer) Start(ctx context.Context) error {
 	handler := &cacheInvalidationHandler{
 		cache:      c.cache,
 		repository: c.repository,
+		cipher:     c.cipher,
 		logger:     c.logger,
 		cacheTTL:   c.cacheTTL,
 	}
 
+	if c.bus != nil {
+		c.logger.Info("✅ Event consumer started for cache invalidation",
+			zap.String("event_bus", c.config.EventBus),
+			zap.Strings("topics", c.topics),
+			zap.String("group_id", c.config.KafkaGroupID),
+		)
+		consumeBus(ctx, c.bus, c.topics, handler.handleMessage, c.logger)
+		return nil
+	}
+
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
 
 	go func() {
 		defer wg.Done()
 		for {
-			if err := c.consumerGroup.Consume(ctx, c.topics, handler); err != nil {
+			topics := c.subscription()
+			// With a regex subscription, a change of the topics ends the session so the
+			// group rejoins with the new ones
+			session, endSession := context.WithCancel(ctx)
+			if c.config.KafkaTopicSubscription == config.TopicSubscriptionRegex {
+				go watchTopics(session, c.client, c.config, topics, c.logger, func(topics []string) {
+					c.setTopics(topics)
+					endSession()
+				})
+			}
+			var err error
+			if len(topics) == 0 {
+				c.logger.Warn("No event topic matches the topic templates yet", zap.Strings("templates", c.config.EventTopics()))
+				<-session.Done()
+			} else {
+				err = c.consumerGroup.Consume(session, topics, handler)
+			}
+			endSession()
+			if err != nil {
 				c.logger.Error("Error from consumer",
 					zap.Error(err),
 					zap.String("error_type", fmt.Sprintf("%T", err)),
@@ -141,7 +222,7 @@ func (c *Consumer) Start(ctx context.Context) error {
 	}()
 
 	c.logger.Info("✅ Kafka consumer started for cache invalidation",
-		zap.Strings("topics", c.topics),
+		zap.Strings("topics", c.subscription()),
 		zap.String("group_id", c.config.KafkaGroupID),
 	)
 
@@ -149,15 +230,48 @@ func (c *Consumer) Start(ctx context.Context) error {
 	return nil
 }
 
+// subscription returns the event topics consumed
+func (c *Consumer) subscription() []string {
+	c.topicsMu.RLock()
+	defer c.topicsMu.RUnlock()
+	return c.topics
+}
+
+// setTopics replaces the event topics consumed from the next session on
+func (c *Consumer) setTopics(topics []string) {
+	c.topicsMu.Lock()
+	defer c.topicsMu.Unlock()
+	c.topics = topics
+}
+
 // Close closes the consumer
 func (c *Consumer) Close() error {
-	return c.consumerGroup.Close()
+	if c.bus != nil {
+		return c.bus.Close()
+	}
+	if err := c.consumerGroup.Close(); err != nil {
+		return err
+	}
+	return c.client.Close()
+}
+
+// Ping refreshes the metadata of the consumed topics, which fails when no broker
+// answers; the other buses are pinged themselves. Used by the readiness probe.
+func (c *Consumer) Ping(ctx context.Context) error {
+	if c.bus != nil {
+		return c.bus.Ping(ctx)
+	}
+	if err := c.client.RefreshMetadata(c.subscription()...); err != nil {
+		return fmt.Errorf("kafka metadata refresh failed: %w", err)
+	}
+	return nil
 }
 
 // cacheInvalidationHandler handles Kafka messages for cache invalidation and update
 type cacheInvalidationHandler struct {
 	cache      cache.Cache
 	repository repository.ReadRepository
+	cipher     *PayloadCipher
 	logger     *zap.Logger
 	cacheTTL   time.Duration
 }
@@ -180,35 +294,12 @@ func (h *cacheInvalidationHandler) ConsumeClaim(session sarama.ConsumerGroupSess
 			if message == nil {
 				return nil
 			}
-
-			// Extract event type from headers
-			eventType := h.extractEventType(message.Headers)
-			if eventType == "" {
-				h.logger.Warn("Message without event type, skipping",
-					zap.String("topic", message.Topic),
-					zap.Int("partition", int(message.Partition)),
-					zap.Int64("offset", message.Offset),
-				)
-				session.MarkMessage(message, "")
-				continue
-			}
-
-			// Update or invalidate cache based on event type
-			if err := h.updateOrInvalidateCache(context.Background(), eventType, message.Value); err != nil {
-				h.logger.Error("Failed to update/invalidate cache",
-					zap.String("event_type", eventType),
-					zap.String("topic", message.Topic),
-					zap.Error(err),
-				)
-			} else {
-				h.logger.Debug("Cache updated/invalidated",
-					zap.String("event_type", eventType),
-					zap.String("topic", message.Topic),
-				)
-			}
+			h.handleMessage(message)
 
 			// Mark message as processed
 			session.MarkMessage(message, "")
+			metrics.KafkaConsumerLag.WithLabelValues(message.Topic, strconv.Itoa(int(message.Partition))).
+				Set(float64(claim.HighWaterMarkOffset() - message.Offset - 1))
 
 		case <-session.Context().Done():
 			return nil
@@ -216,6 +307,71 @@ func (h *cacheInvalidationHandler) ConsumeClaim(session sarama.ConsumerGroupSess
 	}
 }
 
+// handleMessage updates or invalidates the cache for one message. Messages that cannot
+// be read are skipped.
+func (h *cacheInvalidationHandler) handleMessage(message *sarama.ConsumerMessage) {
+	// Extract event type from headers
+	eventType := h.extractEventType(message.Headers)
+	if eventType == "" {
+		h.logger.Warn("Message without event type, skipping",
+			zap.String("topic", message.Topic),
+			zap.Int("partition", int(message.Partition)),
+			zap.Int64("offset", message.Offset),
+		)
+		metrics.KafkaMessagesConsumed.WithLabelValues(message.Topic, "unknown", "skipped").Inc()
+		return
+	}
+
+	// Decrypt the payload if the publisher encrypted it and unwrap it from its envelope
+	eventData, err := decryptMessage(h.cipher, message, eventType)
+	schemaVersion := 0
+	tenantID := ""
+	if err == nil {
+		var envelope Envelope
+		envelope, eventData, err = decodeEnvelope(eventData)
+		schemaVersion, tenantID = envelope.SchemaVersion, envelope.TenantID
+	}
+	if err != nil {
+		h.logger.Error("Failed to read event, skipping",
+			zap.String("event_type", eventType),
+			zap.String("topic", message.Topic),
+			zap.Int64("offset", message.Offset),
+			zap.Error(err),
+		)
+		metrics.KafkaMessagesConsumed.WithLabelValues(message.Topic, eventType, "failed").Inc()
+		return
+	}
+
+	// Update or invalidate cache based on event type, continuing the trace of the write.
+	// The cache keys and the read model lookups are those of the event's tenant.
+	ctx := tenant.WithTenant(context.Background(), tenant.OrDefault(tenantID))
+	ctx, span := tracing.Tracer().Start(tracing.ExtractKafka(ctx, message.Headers), "invalidate "+eventType,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String("kafka"),
+			semconv.MessagingDestinationName(message.Topic),
+			attribute.String("event.type", eventType),
+		),
+	)
+	if err := h.updateOrInvalidateCache(ctx, eventType, schemaVersion, eventData); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		h.logger.Error("Failed to update/invalidate cache",
+			zap.String("event_type", eventType),
+			zap.String("topic", message.Topic),
+			zap.Error(err),
+		)
+		metrics.KafkaMessagesConsumed.WithLabelValues(message.Topic, eventType, "failed").Inc()
+	} else {
+		h.logger.Debug("Cache updated/invalidated",
+			zap.String("event_type", eventType),
+			zap.String("topic", message.Topic),
+		)
+		metrics.KafkaMessagesConsumed.WithLabelValues(message.Topic, eventType, "applied").Inc()
+	}
+	span.End()
+}
+
 // extractEventType extracts event type from Kafka message headers
 func (h *cacheInvalidationHandler) extractEventType(headers []*sarama.RecordHeader) string {
 	for _, header := range headers {
@@ -229,38 +385,65 @@ func (h *cacheInvalidationHandler) extractEventType(headers []*sarama.RecordHead
 // updateOrInvalidateCache updates or invalidates cache based on event type
 // For confirmation events (ending with "Confirmed"), it updates Redis with new data
 // For regular events, it invalidates cache
-func (h *cacheInvalidationHandler) updateOrInvalidateCache(ctx context.Context, eventType string, eventData []byte) error {
+// eventData is the payload already unwrapped from its envelope (schemaVersion 2) or the
+// whole message (schemaVersion 1)
+func (h *cacheInvalidationHandler) updateOrInvalidateCache(ctx context.Context, eventType string, schemaVersion int, eventData []byte) error {
 	// Check if this is a confirmation event (from listener-service)
 	isConfirmationEvent := strings.HasSuffix(eventType, "Confirmed")
 
 	// Parse event data to extract item ID or SKU
 	var itemID, sku string
 	var confirmationData map[string]interface{}
+	var eventFields map[string]interface{}
 
 	if len(eventData) > 0 {
 		var eventDataMap map[string]interface{}
 		if err := json.Unmarshal(eventData, &eventDataMap); err == nil {
-			// For confirmation events, data is in the "data" field
+			// Version 1 confirmation events carried their data in the "data" field
+			if isConfirmationEvent && schemaVersion < SchemaVersion {
+				eventDataMap, _ = eventDataMap["data"].(map[string]interface{})
+			}
 			if isConfirmationEvent {
-				if data, ok := eventDataMap["data"].(map[string]interface{}); ok {
-					confirmationData = data
-					if id, ok := data["itemId"].(string); ok {
-						itemID = id
-					}
-					if s, ok := data["sku"].(string); ok {
-						sku = s
-					}
-				}
-			} else {
-				// For regular events, data is directly in the event
-				if id, ok := eventDataMap["itemId"].(string); ok {
-					itemID = id
-				}
-				if s, ok := eventDataMap["sku"].(string); ok {
-					sku = s
-				}
+				confirmationData = eventDataMap
 			}
+			eventFields = eventDataMap
+			// Version 1 command events are PascalCase ("ItemID"), everything else camelCase
+			itemID = lookupString(eventFields, "itemId")
+			sku = lookupString(eventFields, "sku")
+		}
+	}
+
+	// Reservation listings are cached per store and per item
+	baseEventType := strings.TrimSuffix(eventType, "Confirmed")
+	switch baseEventType {
+	case "StoreReservationCreated", "StoreReservationReleased", "StoreDeleted", "InventoryItemDeleted":
+		reservationItemID := itemID
+		if reservationItemID == "" {
+			reservationItemID = lookupString(eventFields, "itemId")
 		}
+		h.invalidateReservationCache(ctx, lookupString(eventFields, "storeId"), reservationItemID)
+	}
+
+	// A code that moved between items must not keep resolving to the previous one; the
+	// item itself is refreshed below like any other versioned change
+	switch baseEventType {
+	case "ItemIdentifierAdded", "ItemIdentifierRemoved":
+		h.invalidateBarcodeCache(ctx, lookupString(eventFields, "code"))
+	}
+
+	// Store events, item relations, suppliers and purchase orders don't affect cached items;
+	// received stock arrives as StockAdjusted
+	switch baseEventType {
+	case "StoreCreated", "StoreUpdated", "StoreDeleted", "StoreCalendarUpdated", "ItemRelationAdded", "ItemRelationRemoved",
+		"SupplierCreated", "SupplierUpdated", "SupplierDeleted",
+		"PurchaseOrderCreated", "PurchaseOrderReceived", "PurchaseOrderCancelled":
+		return nil
+	}
+
+	// A soft-deleted item is no longer returned by the repository: drop it from the cache
+	// rather than refreshing it from the confirmation
+	if isConfirmationEvent && baseEventType == "InventoryItemDeleted" {
+		return h.invalidateCache(ctx, baseEventType, itemID, sku)
 	}
 
 	// Handle confirmation events: Update Redis with new data
@@ -306,6 +489,16 @@ func (h *cacheInvalidationHandler) updateCacheWithData(ctx context.Context, even
 		return h.updateCacheFromEventData(ctx, itemID, sku, data)
 	}
 
+	// The cached item carries its images, as the handlers cache it
+	if images, ok := h.repository.(repository.ImageRepository); ok {
+		byItem, err := images.ListImages(ctx, []string{item.ID})
+		if err != nil {
+			h.logger.Warn("Failed to read item images, invalidating the item", zap.String("item_id", itemID), zap.Error(err))
+			return h.invalidateCache(ctx, eventType, itemID, sku)
+		}
+		item.Images = byItem[item.ID]
+	}
+
 	// Update cache with item data
 	if err := h.updateItemCache(ctx, item); err != nil {
 		h.logger.Warn("Failed to update item cache", zap.String("item_id", itemID), zap.Error(err))
@@ -400,8 +593,10 @@ func (h *cacheInvalidationHandler) updateItemCache(ctx context.Context, item *mo
 // invalidateCache invalidates cache based on event type
 func (h *cacheInvalidationHandler) invalidateCache(ctx context.Context, eventType string, itemID, sku string) error {
 	switch eventType {
-	case "InventoryItemCreated", "InventoryItemUpdated", "InventoryItemDeleted",
-		"StockAdjusted", "StockReserved", "StockReleased":
+	case "InventoryItemCreated", "InventoryItemUpdated", "InventoryItemPatched", "InventoryItemDeleted", "InventoryItemRestored",
+		"StockAdjusted", "StockReserved", "StockReleased", "StockCommitted",
+		"StoreReservationCreated", "StoreReservationReleased", "ManualCorrection",
+		"ItemImageAdded", "ItemImageRemoved", "ItemIdentifierAdded", "ItemIdentifierRemoved":
 		// Fast cache invalidation strategy:
 		// 1. Invalidate specific item cache keys (if item ID/SKU available)
 		// 2. Invalidate related cache keys (list, stock status)
@@ -468,3 +663,59 @@ func (h *cacheInvalidationHandler) invalidateCache(ctx context.Context, eventTyp
 		return fmt.Errorf("unknown event type: %s", eventType)
 	}
 }
+
+// invalidateBarcodeCache drops the cached item ID of a barcode or alias code. Without a
+// code every cached code is dropped.
+func (h *cacheInvalidationHandler) invalidateBarcodeCache(ctx context.Context, code string) {
+	if h.cache == nil {
+		return
+	}
+	if code == "" {
+		if err := h.cache.DeleteByPattern(ctx, "item:barcode:*"); err != nil {
+			h.logger.Warn("Failed to delete barcode cache by pattern", zap.Error(err))
+		}
+		return
+	}
+	if err := h.cache.Delete(ctx, fmt.Sprintf("item:barcode:%s", code)); err != nil {
+		h.logger.Warn("Failed to delete barcode cache", zap.String("code", code), zap.Error(err))
+	}
+}
+
+// invalidateReservationCache drops cached reservation listings for a store and/or item.
+// Without either ID every reservation listing is dropped.
+func (h *cacheInvalidationHandler) invalidateReservationCache(ctx context.Context, storeID, itemID string) {
+	if h.cache == nil {
+		return
+	}
+
+	var patterns []string
+	if storeID != "" {
+		patterns = append(patterns, fmt.Sprintf("reservations:store:%s:*", storeID))
+	}
+	if itemID != "" {
+		patterns = append(patterns, fmt.Sprintf("reservations:item:%s:*", itemID))
+	}
+	if len(patterns) == 0 {
+		patterns = append(patterns, "reservations:*")
+	}
+
+	for _, pattern := range patterns {
+		if err := h.cache.DeleteByPattern(ctx, pattern); err != nil {
+			h.logger.Warn("Failed to delete reservation cache by pattern", zap.String("pattern", pattern), zap.Error(err))
+		}
+	}
+}
+
+// lookupString returns a string field matching key case-insensitively.
+// Version 1 command events were serialized without JSON tags ("StoreID"), while
+// version 2 payloads and confirmation events use camelCase ("storeId").
+func lookupString(fields map[string]interface{}, key string) string {
+	for k, v := range fields {
+		if strings.EqualFold(k, key) {
+			if s, ok := v.(string); ok {
+				return s
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,41 @@
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SchemaVersion is the newest event format this service reads. Version 1 messages
+// are the bare event (or, for confirmations, {eventType, ..., data}) with no envelope.
+const SchemaVersion = 2
+
+// Envelope wraps the events of the Command Service and the confirmations of the
+// Listener Service from schema version 2 on
+type Envelope struct {
+	EventID       string          `json:"event_id"`
+	EventType     string          `json:"event_type"`
+	SchemaVersion int             `json:"schema_version"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	TenantID      string          `json:"tenant_id,omitempty"` // empty on events from before multi-tenancy
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// decodeEnvelope returns the envelope of a message and the event payload it carries.
+// A message without an envelope is a version 1 event: the whole body is the payload.
+func decodeEnvelope(data []byte) (Envelope, []byte, error) {
+	var envelope struct {
+		Envelope
+		SchemaVersion *int `json:"schema_version"`
+	}
+	if json.Unmarshal(data, &envelope) != nil || envelope.SchemaVersion == nil || len(envelope.Payload) == 0 {
+		return Envelope{SchemaVersion: 1}, data, nil
+	}
+
+	envelope.Envelope.SchemaVersion = *envelope.SchemaVersion
+	if envelope.Envelope.SchemaVersion > SchemaVersion {
+		return envelope.Envelope, nil, fmt.Errorf("unsupported event schema version %d (newest supported is %d)",
+			envelope.Envelope.SchemaVersion, SchemaVersion)
+	}
+	return envelope.Envelope, envelope.Payload, nil
+}
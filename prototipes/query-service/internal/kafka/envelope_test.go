@@ -0,0 +1,36 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeEnvelope(t *testing.T) {
+	message := []byte(`{"event_id":"e-1","event_type":"StockAdjusted","schema_version":2,` +
+		`"occurred_at":"2024-01-15T10:30:00Z","payload":{"itemId":"abc","newTotal":15}}`)
+
+	envelope, payload, err := decodeEnvelope(message)
+	require.NoError(t, err)
+	assert.Equal(t, 2, envelope.SchemaVersion)
+	assert.Equal(t, "e-1", envelope.EventID)
+	assert.Equal(t, "StockAdjusted", envelope.EventType)
+	assert.JSONEq(t, `{"itemId":"abc","newTotal":15}`, string(payload))
+}
+
+func TestDecodeEnvelope_Version1(t *testing.T) {
+	// Published before the envelope: the body is the PascalCase event
+	message := []byte(`{"ItemID":"abc","NewTotal":15}`)
+
+	envelope, payload, err := decodeEnvelope(message)
+	require.NoError(t, err)
+	assert.Equal(t, 1, envelope.SchemaVersion)
+	assert.Equal(t, message, payload)
+	assert.Equal(t, "abc", lookupString(map[string]interface{}{"ItemID": "abc"}, "itemId"))
+}
+
+func TestDecodeEnvelope_UnsupportedVersion(t *testing.T) {
+	_, _, err := decodeEnvelope([]byte(`{"event_id":"e-1","schema_version":3,"payload":{}}`))
+	assert.Error(t, err)
+}
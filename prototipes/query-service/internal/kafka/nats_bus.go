@@ -0,0 +1,182 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"query-service/internal/config"
+
+	"github.com/IBM/sarama"
+	"github.com/nats-io/nats.go"
+)
+
+// natsAckWait is how long a delivered message may take to be handled before JetStream
+// delivers it again
+const natsAckWait = 2 * time.Minute
+
+// natsBus carries the events on NATS JetStream. Each topic is a subject of the stream
+// NATS_STREAM, which keeps the messages like a Kafka topic does.
+type natsBus struct {
+	conn   *nats.Conn
+	js     nats.JetStreamContext
+	stream string
+	group  string
+}
+
+// dialNATS connects to NATS_URL and creates the stream, or adds the subjects it lacks
+func dialNATS(cfg *config.Config, group string) (*natsBus, error) {
+	conn, err := nats.Connect(cfg.NATSURL, nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open JetStream: %w", err)
+	}
+	bus := &natsBus{conn: conn, js: js, stream: cfg.NATSStream, group: group}
+	if err := bus.ensureStream(busTopics(cfg)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return bus, nil
+}
+
+// ensureStream creates the stream with subjects, or adds those it lacks: every service
+// adds the topics it uses
+func (b *natsBus) ensureStream(subjects []string) error {
+	info, err := b.js.StreamInfo(b.stream)
+	if errors.Is(err, nats.ErrStreamNotFound) {
+		_, err = b.js.AddStream(&nats.StreamConfig{Name: b.stream, Subjects: subjects, Storage: nats.FileStorage})
+		if err != nil {
+			return fmt.Errorf("failed to create JetStream stream %s: %w", b.stream, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read JetStream stream %s: %w", b.stream, err)
+	}
+
+	streamConfig := info.Config
+	missing := false
+	for _, subject := range subjects {
+		if !containsString(streamConfig.Subjects, subject) {
+			streamConfig.Subjects = append(streamConfig.Subjects, subject)
+			missing = true
+		}
+	}
+	if !missing {
+		return nil
+	}
+	if _, err := b.js.UpdateStream(&streamConfig); err != nil {
+		return fmt.Errorf("failed to add subjects to JetStream stream %s: %w", b.stream, err)
+	}
+	return nil
+}
+
+// Consume subscribes to every topic. A group has a durable consumer per topic, shared
+// by its subscribers and starting from the first message kept; without a group the
+// consumers are ephemeral and start from the newest message.
+func (b *natsBus) Consume(ctx context.Context, topics []string, handle func(*sarama.ConsumerMessage)) error {
+	messages := make(chan *nats.Msg, busPrefetch)
+	var subscriptions []*nats.Subscription
+	defer func() {
+		// Unsubscribing deletes the consumer: durable ones are kept for the next run
+		if b.group == "" {
+			for _, subscription := range subscriptions {
+				subscription.Unsubscribe()
+			}
+		}
+	}()
+
+	for _, topic := range topics {
+		options := []nats.SubOpt{
+			nats.BindStream(b.stream),
+			nats.ManualAck(),
+			nats.AckWait(natsAckWait),
+			nats.MaxAckPending(busPrefetch),
+		}
+		var subscription *nats.Subscription
+		var err error
+		if b.group == "" {
+			subscription, err = b.js.ChanSubscribe(topic, messages, append(options, nats.DeliverNew())...)
+		} else {
+			durable := natsDurableName(b.group, topic)
+			subscription, err = b.js.ChanQueueSubscribe(topic, durable, messages,
+				append(options, nats.Durable(durable), nats.DeliverAll())...)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	for {
+		select {
+		case msg := <-messages:
+			handle(consumerMessageFromNATS(msg))
+			// A message whose ack is lost is delivered again after natsAckWait
+			msg.Ack()
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Ping round-trips to the server. Used by the readiness probe.
+func (b *natsBus) Ping(ctx context.Context) error {
+	if !b.conn.IsConnected() {
+		return fmt.Errorf("nats not connected (%s)", b.conn.Status())
+	}
+	ctx, cancel := withBusTimeout(ctx)
+	defer cancel()
+	if err := b.conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("nats flush failed: %w", err)
+	}
+	return nil
+}
+
+// Close closes the connection
+func (b *natsBus) Close() error {
+	b.conn.Close()
+	return nil
+}
+
+// consumerMessageFromNATS converts a JetStream message to the shape the Kafka path
+// works with; the stream sequence is its offset
+func consumerMessageFromNATS(msg *nats.Msg) *sarama.ConsumerMessage {
+	message := &sarama.ConsumerMessage{Topic: msg.Subject, Value: msg.Data, Timestamp: time.Now()}
+	if metadata, err := msg.Metadata(); err == nil {
+		message.Offset = int64(metadata.Sequence.Stream)
+		message.Timestamp = metadata.Timestamp
+	}
+	for key, values := range msg.Header {
+		if len(values) == 0 || key == nats.MsgIdHdr {
+			continue
+		}
+		if key == busKeyHeader {
+			message.Key = []byte(values[0])
+			continue
+		}
+		message.Headers = append(message.Headers, &sarama.RecordHeader{Key: []byte(key), Value: []byte(values[0])})
+	}
+	return message
+}
+
+// natsDurableName names the durable consumer of group on topic; durable names cannot
+// contain dots
+func natsDurableName(group, topic string) string {
+	return strings.NewReplacer(".", "_", "*", "_", ">", "_").Replace(group + "_" + topic)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
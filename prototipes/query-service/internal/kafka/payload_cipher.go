@@ -0,0 +1,116 @@
+package kafka
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/IBM/sarama"
+)
+
+// Kafka headers that mark an encrypted payload. The key id lets consumers pick the
+// right key while keys are being rotated.
+const (
+	EncryptionHeader      = "encryption"
+	EncryptionKeyIDHeader = "encryption-key-id"
+	EncryptionAlgorithm   = "AES-GCM"
+)
+
+// ErrUnknownEncryptionKey is returned when a payload was encrypted with a key that is not configured
+var ErrUnknownEncryptionKey = errors.New("unknown encryption key id")
+
+// PayloadCipher decrypts event payloads encrypted by the Command Service with AES-GCM.
+// Every key still referenced by events on the topic must be configured.
+type PayloadCipher struct {
+	keys map[string]cipher.AEAD
+}
+
+// NewPayloadCipher parses keySpec ("id:base64key,id:base64key", 16/24/32 byte keys).
+// It returns nil, nil when keySpec is empty (decryption disabled).
+func NewPayloadCipher(keySpec string) (*PayloadCipher, error) {
+	if strings.TrimSpace(keySpec) == "" {
+		return nil, nil
+	}
+
+	pc := &PayloadCipher{keys: make(map[string]cipher.AEAD)}
+	for _, entry := range strings.Split(keySpec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid encryption key %q: expected id:base64key", entry)
+		}
+		keyID := parts[0]
+
+		key, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %q: %w", keyID, err)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %q: %w", keyID, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %q: %w", keyID, err)
+		}
+
+		if _, exists := pc.keys[keyID]; exists {
+			return nil, fmt.Errorf("duplicate encryption key id %q", keyID)
+		}
+		pc.keys[keyID] = aead
+	}
+
+	if len(pc.keys) == 0 {
+		return nil, fmt.Errorf("no encryption keys configured")
+	}
+
+	return pc, nil
+}
+
+// Decrypt opens a nonce||ciphertext payload with the key it was encrypted with
+func (pc *PayloadCipher) Decrypt(keyID string, ciphertext, aad []byte) ([]byte, error) {
+	aead, ok := pc.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownEncryptionKey, keyID)
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted payload too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, aad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt payload: %w", err)
+	}
+	return plaintext, nil
+}
+
+// decryptMessage returns the plaintext payload of a message. Messages without the
+// encryption header are returned as is, so plaintext and encrypted events can coexist.
+func decryptMessage(pc *PayloadCipher, message *sarama.ConsumerMessage, eventType string) ([]byte, error) {
+	var algorithm, keyID string
+	for _, header := range message.Headers {
+		switch string(header.Key) {
+		case EncryptionHeader:
+			algorithm = string(header.Value)
+		case EncryptionKeyIDHeader:
+			keyID = string(header.Value)
+		}
+	}
+
+	if algorithm == "" {
+		return message.Value, nil
+	}
+	if algorithm != EncryptionAlgorithm {
+		return nil, fmt.Errorf("unsupported payload encryption %q", algorithm)
+	}
+	if pc == nil {
+		return nil, fmt.Errorf("payload is encrypted but EVENT_ENCRYPTION_KEYS is not configured")
+	}
+	return pc.Decrypt(keyID, message.Value, []byte(eventType))
+}
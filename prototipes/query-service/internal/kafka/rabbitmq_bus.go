@@ -0,0 +1,166 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"query-service/internal/config"
+
+	"github.com/IBM/sarama"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// rabbitMQBus carries the events on a RabbitMQ topic exchange (RABBITMQ_EXCHANGE); the
+// topic is the routing key. RabbitMQ only keeps a message in the queues bound when it
+// is published: a group's queue must exist (its consumer started once) before the
+// events it should get are published.
+type rabbitMQBus struct {
+	url      string
+	exchange string
+	group    string
+
+	mu   sync.Mutex // guards conn, which is replaced on reconnect
+	conn *amqp.Connection
+}
+
+// dialRabbitMQ connects to RABBITMQ_URL and declares the exchange
+func dialRabbitMQ(cfg *config.Config, group string) (*rabbitMQBus, error) {
+	bus := &rabbitMQBus{url: cfg.RabbitMQURL, exchange: cfg.RabbitMQExchange, group: group}
+	if err := bus.connect(); err != nil {
+		return nil, err
+	}
+	return bus, nil
+}
+
+// connect opens the connection and declares the exchange; callers hold mu
+func (b *rabbitMQBus) connect() error {
+	conn, err := amqp.Dial(b.url)
+	if err != nil {
+		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	channel, err := conn.Channel()
+	if err == nil {
+		err = channel.ExchangeDeclare(b.exchange, amqp.ExchangeTopic, true, false, false, false, nil)
+	}
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to set up RabbitMQ exchange %s: %w", b.exchange, err)
+	}
+	channel.Close()
+	b.conn = conn
+	return nil
+}
+
+// connection returns the connection, reconnecting if it was closed (the client does
+// not reconnect by itself)
+func (b *rabbitMQBus) connection() (*amqp.Connection, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.conn.IsClosed() {
+		if err := b.connect(); err != nil {
+			return nil, err
+		}
+	}
+	return b.conn, nil
+}
+
+// Consume binds a queue to every topic and handles its messages. A group shares one
+// durable queue named after it; without a group the queue is exclusive and deleted
+// when the consumer stops.
+func (b *rabbitMQBus) Consume(ctx context.Context, topics []string, handle func(*sarama.ConsumerMessage)) error {
+	conn, err := b.connection()
+	if err != nil {
+		return err
+	}
+	channel, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open RabbitMQ channel: %w", err)
+	}
+	defer channel.Close()
+
+	if err := channel.Qos(busPrefetch, 0, false); err != nil {
+		return fmt.Errorf("failed to set RabbitMQ prefetch: %w", err)
+	}
+	durable := b.group != ""
+	queue, err := channel.QueueDeclare(b.group, durable, !durable, !durable, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to declare RabbitMQ queue %q: %w", b.group, err)
+	}
+	for _, topic := range topics {
+		if err := channel.QueueBind(queue.Name, topic, b.exchange, false, nil); err != nil {
+			return fmt.Errorf("failed to bind %s to queue %s: %w", topic, queue.Name, err)
+		}
+	}
+	deliveries, err := channel.Consume(queue.Name, "", false, !durable, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to consume queue %s: %w", queue.Name, err)
+	}
+
+	for {
+		select {
+		case delivery, ok := <-deliveries:
+			if !ok {
+				return fmt.Errorf("RabbitMQ channel closed")
+			}
+			handle(consumerMessageFromAMQP(delivery))
+			// An unacknowledged message is delivered again when the channel closes
+			delivery.Ack(false)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Ping opens and closes a channel, which fails when the broker does not answer. Used
+// by the readiness probe.
+func (b *rabbitMQBus) Ping(ctx context.Context) error {
+	conn, err := b.connection()
+	if err != nil {
+		return err
+	}
+	channel, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("rabbitmq channel failed: %w", err)
+	}
+	return channel.Close()
+}
+
+// Close closes the connection
+func (b *rabbitMQBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.conn.Close()
+}
+
+// consumerMessageFromAMQP converts a delivery to the shape the Kafka path works with;
+// the delivery tag is its offset
+func consumerMessageFromAMQP(delivery amqp.Delivery) *sarama.ConsumerMessage {
+	message := &sarama.ConsumerMessage{
+		Topic:     delivery.RoutingKey,
+		Value:     delivery.Body,
+		Offset:    int64(delivery.DeliveryTag),
+		Timestamp: delivery.Timestamp,
+	}
+	if message.Timestamp.IsZero() {
+		message.Timestamp = time.Now()
+	}
+	for key, value := range delivery.Headers {
+		var text string
+		switch v := value.(type) {
+		case string:
+			text = v
+		case []byte:
+			text = string(v)
+		default:
+			text = fmt.Sprint(v)
+		}
+		if key == busKeyHeader {
+			message.Key = []byte(text)
+			continue
+		}
+		message.Headers = append(message.Headers, &sarama.RecordHeader{Key: []byte(key), Value: []byte(text)})
+	}
+	return message
+}
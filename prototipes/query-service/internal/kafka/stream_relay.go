@@ -0,0 +1,249 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"query-service/internal/config"
+	"query-service/internal/stream"
+	"query-service/internal/tenant"
+	"query-service/pkg/metrics"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// StreamRelay forwards the Listener Service's confirmation events to the live stream.
+// Unlike the cache consumer it does not join a consumer group: every replica reads
+// every partition from the newest offset, so a client sees all the changes whichever
+// replica it is connected to, and nothing is replayed after a restart. On NATS and
+// RabbitMQ it reads the bus the same way, without a group.
+type StreamRelay struct {
+	client   sarama.Client // owns the broker connections of consumer
+	consumer sarama.Consumer
+	bus      EventConsumer // set instead of consumer on NATS and RabbitMQ
+	config   *config.Config
+	hub      *stream.Hub
+	cipher   *PayloadCipher
+	logger   *zap.Logger
+	topics   []string
+}
+
+// NewStreamRelay connects to the brokers; Start begins relaying
+func NewStreamRelay(cfg *config.Config, hub *stream.Hub, logger *zap.Logger) (*StreamRelay, error) {
+	payloadCipher, err := NewPayloadCipher(cfg.EventEncryptionKeys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event encryption config: %w", err)
+	}
+	topics := cfg.ListedTopics(cfg.EventTopics()...)
+
+	if cfg.EventBus != config.EventBusKafka {
+		bus, err := NewEventConsumer(cfg, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stream consumer: %w", err)
+		}
+		return &StreamRelay{bus: bus, config: cfg, hub: hub, cipher: payloadCipher, logger: logger, topics: topics}, nil
+	}
+
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Consumer.Return.Errors = true
+	saramaConfig.Version = sarama.V2_8_0_0
+	saramaConfig.Net.DialTimeout = 10 * time.Second
+	saramaConfig.Net.ReadTimeout = 10 * time.Second
+	saramaConfig.Net.WriteTimeout = 10 * time.Second
+	if err := configureSecurity(saramaConfig, cfg); err != nil {
+		return nil, fmt.Errorf("invalid Kafka security config: %w", err)
+	}
+
+	client, err := sarama.NewClient(cfg.KafkaBrokers, saramaConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stream consumer: %w", err)
+	}
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to create stream consumer: %w", err)
+	}
+
+	return &StreamRelay{
+		client:   client,
+		consumer: consumer,
+		config:   cfg,
+		hub:      hub,
+		cipher:   payloadCipher,
+		logger:   logger,
+		topics:   topics,
+	}, nil
+}
+
+// Start relays the events of every partition until ctx is done. With
+// KAFKA_TOPIC_SUBSCRIPTION=regex it starts over whenever the matching topics change.
+func (r *StreamRelay) Start(ctx context.Context) error {
+	if r.bus != nil {
+		r.logger.Info("✅ Stream relay started", zap.Strings("topics", r.topics))
+		consumeBus(ctx, r.bus, r.topics, r.relay, r.logger)
+		return nil
+	}
+
+	topics, err := eventTopics(r.client, r.config)
+	if err != nil {
+		return err
+	}
+	for {
+		if r.config.KafkaTopicSubscription != config.TopicSubscriptionRegex {
+			return r.relayTopics(ctx, topics)
+		}
+		session, endSession := context.WithCancel(ctx)
+		changed := make(chan []string, 1)
+		go watchTopics(session, r.client, r.config, topics, r.logger, func(topics []string) {
+			changed <- topics
+			endSession()
+		})
+		err := r.relayTopics(session, topics)
+		endSession()
+		if err != nil || ctx.Err() != nil {
+			return err
+		}
+		select {
+		case topics = <-changed:
+		default:
+			return nil // the partitions closed on their own
+		}
+	}
+}
+
+// relayTopics relays the events of every partition of topics until ctx is done
+func (r *StreamRelay) relayTopics(ctx context.Context, topics []string) error {
+	var partitions []sarama.PartitionConsumer
+	for _, topic := range topics {
+		ids, err := r.consumer.Partitions(topic)
+		if err != nil {
+			return fmt.Errorf("failed to list partitions of %s: %w", topic, err)
+		}
+		for _, id := range ids {
+			partition, err := r.consumer.ConsumePartition(topic, id, sarama.OffsetNewest)
+			if err != nil {
+				return fmt.Errorf("failed to consume %s/%d: %w", topic, id, err)
+			}
+			partitions = append(partitions, partition)
+		}
+	}
+
+	r.logger.Info("✅ Stream relay started", zap.Strings("topics", topics), zap.Int("partitions", len(partitions)))
+	if len(partitions) == 0 {
+		r.logger.Warn("No event topic matches the topic templates yet", zap.Strings("templates", r.config.EventTopics()))
+		<-ctx.Done()
+		return nil
+	}
+
+	wg := &sync.WaitGroup{}
+	for _, partition := range partitions {
+		wg.Add(1)
+		go func(partition sarama.PartitionConsumer) {
+			defer wg.Done()
+			defer partition.AsyncClose()
+			for {
+				select {
+				case message, ok := <-partition.Messages():
+					if !ok {
+						return
+					}
+					r.relay(message)
+				case err, ok := <-partition.Errors():
+					if !ok {
+						return
+					}
+					r.logger.Warn("Stream relay consumer error", zap.Error(err))
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(partition)
+	}
+	wg.Wait()
+	return nil
+}
+
+// Close closes the consumer
+func (r *StreamRelay) Close() error {
+	if r.bus != nil {
+		return r.bus.Close()
+	}
+	if err := r.consumer.Close(); err != nil {
+		return err
+	}
+	return r.client.Close()
+}
+
+// relay publishes a confirmation event to the hub; command events are not relayed
+// because they may still be rejected by the Listener Service
+func (r *StreamRelay) relay(message *sarama.ConsumerMessage) {
+	event, err := streamEvent(r.cipher, message)
+	if err != nil {
+		r.logger.Warn("Failed to read event for the stream, skipping",
+			zap.String("topic", message.Topic),
+			zap.Int64("offset", message.Offset),
+			zap.Error(err),
+		)
+		return
+	}
+	if event == nil {
+		return
+	}
+	r.hub.Publish(*event)
+	metrics.StreamEventsRelayed.WithLabelValues(event.Type).Inc()
+}
+
+// streamEvent decodes a confirmation message into a stream event. It returns nil
+// for messages that are not confirmations.
+func streamEvent(cipher *PayloadCipher, message *sarama.ConsumerMessage) (*stream.Event, error) {
+	var eventType string
+	for _, header := range message.Headers {
+		if string(header.Key) == "event-type" {
+			eventType = string(header.Value)
+		}
+	}
+	if !strings.HasSuffix(eventType, "Confirmed") {
+		return nil, nil
+	}
+
+	eventData, err := decryptMessage(cipher, message, eventType)
+	if err != nil {
+		return nil, err
+	}
+	envelope, payload, err := decodeEnvelope(eventData)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, fmt.Errorf("invalid event payload: %w", err)
+	}
+	// Version 1 confirmations carried their data in the "data" field
+	if envelope.SchemaVersion < SchemaVersion {
+		data, _ := fields["data"].(map[string]interface{})
+		if payload, err = json.Marshal(data); err != nil {
+			return nil, err
+		}
+		fields = data
+	}
+
+	occurredAt := envelope.OccurredAt
+	if occurredAt.IsZero() {
+		occurredAt = message.Timestamp
+	}
+	return &stream.Event{
+		ID:         envelope.EventID,
+		Type:       eventType,
+		ItemID:     lookupString(fields, "itemId"),
+		SKU:        lookupString(fields, "sku"),
+		TenantID:   tenant.OrDefault(envelope.TenantID),
+		OccurredAt: occurredAt.UTC(),
+		Data:       payload,
+	}, nil
+}
@@ -0,0 +1,51 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func confirmationMessage(eventType, value string) *sarama.ConsumerMessage {
+	return &sarama.ConsumerMessage{
+		Topic:     "inventory.stock",
+		Headers:   []*sarama.RecordHeader{{Key: []byte("event-type"), Value: []byte(eventType)}},
+		Value:     []byte(value),
+		Timestamp: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestStreamEvent(t *testing.T) {
+	event, err := streamEvent(nil, confirmationMessage("StockReservedConfirmed",
+		`{"event_id":"e-1","event_type":"StockReservedConfirmed","schema_version":2,`+
+			`"occurred_at":"2024-01-15T10:30:00Z","payload":{"itemId":"abc","sku":"SKU-001","available":7}}`))
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	assert.Equal(t, "e-1", event.ID)
+	assert.Equal(t, "StockReservedConfirmed", event.Type)
+	assert.Equal(t, "abc", event.ItemID)
+	assert.Equal(t, "SKU-001", event.SKU)
+	assert.Equal(t, time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC), event.OccurredAt)
+	assert.JSONEq(t, `{"itemId":"abc","sku":"SKU-001","available":7}`, string(event.Data))
+}
+
+func TestStreamEvent_Version1(t *testing.T) {
+	// Version 1 confirmations wrap their data and carry no occurred_at
+	event, err := streamEvent(nil, confirmationMessage("StockAdjustedConfirmed",
+		`{"eventType":"StockAdjustedConfirmed","data":{"itemId":"abc","sku":"SKU-001"}}`))
+	require.NoError(t, err)
+	require.NotNil(t, event)
+	assert.Equal(t, "abc", event.ItemID)
+	assert.JSONEq(t, `{"itemId":"abc","sku":"SKU-001"}`, string(event.Data))
+	assert.Equal(t, time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC), event.OccurredAt)
+}
+
+func TestStreamEvent_SkipsCommands(t *testing.T) {
+	// A command may still be rejected by the listener: only confirmations are relayed
+	event, err := streamEvent(nil, confirmationMessage("StockReserved", `{"ItemID":"abc"}`))
+	require.NoError(t, err)
+	assert.Nil(t, event)
+}
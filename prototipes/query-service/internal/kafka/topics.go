@@ -0,0 +1,70 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"query-service/internal/config"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// eventTopics returns the event topics to consume: the listed ones or, with
+// KAFKA_TOPIC_SUBSCRIPTION=regex, the topics of the cluster that the templates match
+// (client is only used then)
+func eventTopics(client sarama.Client, cfg *config.Config) ([]string, error) {
+	if cfg.KafkaTopicSubscription != config.TopicSubscriptionRegex || client == nil {
+		return cfg.ListedTopics(cfg.EventTopics()...), nil
+	}
+	if err := client.RefreshMetadata(); err != nil {
+		return nil, fmt.Errorf("failed to refresh the topic list: %w", err)
+	}
+	available, err := client.Topics()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list topics: %w", err)
+	}
+	return config.MatchTopics(available, cfg.EventTopics()...), nil
+}
+
+// watchTopics looks the event topics up every KAFKA_TOPIC_REFRESH_SECONDS until they
+// differ from current (a tenant got its own topics), then calls changed with them and
+// returns. It also returns when ctx is done.
+func watchTopics(ctx context.Context, client sarama.Client, cfg *config.Config, current []string, logger *zap.Logger, changed func([]string)) {
+	ticker := time.NewTicker(time.Duration(cfg.KafkaTopicRefreshSeconds) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		topics, err := eventTopics(client, cfg)
+		if err != nil {
+			logger.Warn("Failed to refresh the event topics", zap.Error(err))
+			continue
+		}
+		if !sameTopics(topics, current) {
+			logger.Info("Event topics changed, resubscribing",
+				zap.Strings("previous", current),
+				zap.Strings("topics", topics),
+			)
+			changed(topics)
+			return
+		}
+	}
+}
+
+// sameTopics compares two sorted topic lists
+func sameTopics(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
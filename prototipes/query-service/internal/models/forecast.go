@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// StockForecast represents the projected stock-out of an item based on recent consumption
+type StockForecast struct {
+	ItemID      string  `json:"item_id"`
+	SKU         string  `json:"sku"`
+	Available   int     `json:"available"`
+	WindowDays  int     `json:"window_days"`
+	HorizonDays int     `json:"horizon_days"`
+	Confidence  float64 `json:"confidence"`
+
+	// Consumption velocity over the window (units per day)
+	AverageDailyConsumption float64 `json:"average_daily_consumption"`
+	ConsumptionStdDev       float64 `json:"consumption_std_dev"`
+
+	// Days until available stock runs out: expected, and at the requested confidence.
+	// Nil when there is no consumption in the window.
+	DaysUntilStockout             *float64   `json:"days_until_stockout"`
+	DaysUntilStockoutAtConfidence *float64   `json:"days_until_stockout_at_confidence"`
+	ProjectedStockoutDate         *time.Time `json:"projected_stockout_date"`
+	ConservativeStockoutDate      *time.Time `json:"conservative_stockout_date"`
+	StockoutWithinHorizon         bool       `json:"stockout_within_horizon"`
+
+	Projection  []ForecastPoint `json:"projection"`
+	GeneratedAt time.Time       `json:"generated_at"`
+}
+
+// ForecastPoint is the projected available stock at the end of a day
+type ForecastPoint struct {
+	Date                string `json:"date"`
+	ExpectedAvailable   int    `json:"expected_available"`
+	LowerBoundAvailable int    `json:"lower_bound_available"`
+}
@@ -1,10 +1,14 @@
 package models
 
-import "time"
+import (
+	"encoding/xml"
+	"time"
+)
 
 // InventoryItem represents a read model for inventory items
 type InventoryItem struct {
 	ID          string    `json:"id"`
+	TenantID    string    `json:"-"` // Empty for the default tenant; responses only hold the caller's
 	SKU         string    `json:"sku"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
@@ -13,6 +17,22 @@ type InventoryItem struct {
 	Available   int       `json:"available"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	// Set on soft-deleted items, which are only returned with include_deleted=true
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Images of the item, in upload order; filled by the handlers, not by ReadRepository
+	Images []ItemImage `json:"images,omitempty"`
+}
+
+// ItemImage is an image of an item. Key locates the file in the image store of the
+// Command Service; URL is the public URL it published, replaced by a signed one when
+// IMAGE_SIGNED_URLS=true.
+type ItemImage struct {
+	ID          string    `json:"id"`
+	Key         string    `json:"key"`
+	URL         string    `json:"url"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 // StockStatus represents the stock status of an item
@@ -34,3 +54,138 @@ type ListItemsResponse struct {
 	TotalPages int             `json:"total_pages"`
 }
 
+
+// StoreReservation represents a reservation of inventory by a store
+type StoreReservation struct {
+	XMLName    xml.Name   `json:"-" xml:"reservation" swaggerignore:"true"`
+	ID         string     `json:"id" xml:"id"`
+	StoreID    string     `json:"store_id" xml:"store_id"`
+	ItemID     string     `json:"item_id" xml:"item_id"`
+	Quantity   int        `json:"quantity" xml:"quantity"`
+	Status     string     `json:"status" xml:"status"`
+	ReservedAt time.Time  `json:"reserved_at" xml:"reserved_at"`
+	ReleasedAt *time.Time `json:"released_at,omitempty" xml:"released_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" xml:"expires_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" xml:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" xml:"updated_at"`
+}
+
+// ListReservationsResponse represents the response for listing reservations
+type ListReservationsResponse struct {
+	XMLName      xml.Name           `json:"-" xml:"reservation_list" swaggerignore:"true"`
+	Reservations []StoreReservation `json:"reservations" xml:"reservations>reservation"`
+	Total        int                `json:"total" xml:"total"`
+	Page         int                `json:"page" xml:"page"`
+	PageSize     int                `json:"page_size" xml:"page_size"`
+	TotalPages   int                `json:"total_pages" xml:"total_pages"`
+}
+
+// StockMovement represents a stock change applied to an item (written by the Listener Service)
+type StockMovement struct {
+	ID             string    `json:"id"`
+	ItemID         string    `json:"item_id"`
+	StoreID        string    `json:"store_id,omitempty"`
+	MovementType   string    `json:"movement_type"`
+	QuantityChange int       `json:"quantity_change"`
+	ReservedChange int       `json:"reserved_change"`
+	QuantityAfter  int       `json:"quantity_after"`
+	ReservedAfter  int       `json:"reserved_after"`
+	AvailableAfter int       `json:"available_after"`
+	OccurredAt     time.Time `json:"occurred_at"`
+	Actor          string    `json:"actor,omitempty"` // Username that issued the command
+	RequestID      string    `json:"request_id,omitempty"`
+	Reason         string    `json:"reason,omitempty"`    // Reason code of the operation, or the justification of a ManualCorrection
+	Reference      string    `json:"reference,omitempty"` // External reference given with the command, e.g. an order ID
+}
+
+// MovementFilter narrows an item's movement history; nil bounds are open
+type MovementFilter struct {
+	From *time.Time // occurred_at >= From
+	To   *time.Time // occurred_at < To
+}
+
+// WaitlistEntry represents a reservation waiting for stock
+type WaitlistEntry struct {
+	ID          string     `json:"id"`
+	ItemID      string     `json:"item_id"`
+	Quantity    int        `json:"quantity"`
+	Status      string     `json:"status"`             // waiting, fulfilled, cancelled
+	Position    int        `json:"position,omitempty"` // 1-based place in the item's queue while waiting
+	RequestedAt time.Time  `json:"requested_at"`
+	FulfilledAt *time.Time `json:"fulfilled_at,omitempty"`
+}
+
+// ActivityEntry is a command event and its outcome, as recorded by the Listener Service
+type ActivityEntry struct {
+	ID          string    `json:"id"`
+	TenantID    string    `json:"-"` // Empty for the default tenant
+	EventType   string    `json:"event_type"`
+	ItemID      string    `json:"item_id,omitempty"`
+	StoreID     string    `json:"store_id,omitempty"`
+	SKU         string    `json:"sku,omitempty"`
+	Quantity    *int      `json:"quantity,omitempty"`
+	Actor       string    `json:"actor,omitempty"` // Username that issued the command
+	RequestID   string    `json:"request_id,omitempty"`
+	Outcome     string    `json:"outcome"`         // applied, failed
+	Error       string    `json:"error,omitempty"` // Why the event failed
+	OccurredAt  time.Time `json:"occurred_at"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+// ActivityFilter narrows the activity feed; empty fields match everything
+type ActivityFilter struct {
+	Actor   string
+	ItemID  string
+	Outcome string
+}
+
+// RelatedItem is an item linked to another one (a substitute for it, or an accessory
+// of it) with its live stock
+type RelatedItem struct {
+	Relation  string    `json:"relation"` // substitute, accessory
+	ID        string    `json:"id"`
+	SKU       string    `json:"sku"`
+	Name      string    `json:"name"`
+	Quantity  int       `json:"quantity"`
+	Reserved  int       `json:"reserved"`
+	Available int       `json:"available"`
+	LinkedAt  time.Time `json:"linked_at"`
+}
+
+// LocationStock is the stock of an item at one location (warehouse or store)
+type LocationStock struct {
+	Location  string    `json:"location"`
+	Quantity  int       `json:"quantity"`
+	Reserved  int       `json:"reserved"`
+	Available int       `json:"available"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UnassignedStock is the stock of an item not held at any location
+type UnassignedStock struct {
+	Quantity  int `json:"quantity"`
+	Reserved  int `json:"reserved"`
+	Available int `json:"available"`
+}
+
+// ItemLocationsResponse is the stock of an item in total and per location
+type ItemLocationsResponse struct {
+	ItemID     string          `json:"item_id"`
+	SKU        string          `json:"sku"`
+	Quantity   int             `json:"quantity"`
+	Reserved   int             `json:"reserved"`
+	Available  int             `json:"available"`
+	Unassigned UnassignedStock `json:"unassigned"`
+	Locations  []LocationStock `json:"locations"`
+	Total      int             `json:"total"`
+}
+
+// RelatedItemsResponse lists the items related to an item
+type RelatedItemsResponse struct {
+	ItemID     string        `json:"item_id"`
+	SKU        string        `json:"sku"`
+	Available  int           `json:"available"`
+	OutOfStock bool          `json:"out_of_stock"`
+	Related    []RelatedItem `json:"related"`
+	Total      int           `json:"total"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Supplier is a vendor the purchase orders are placed with, as written by the Listener
+// Service from the Supplier events
+type Supplier struct {
+	ID        string    `json:"id"`
+	TenantID  string    `json:"-"` // Empty for the default tenant
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email,omitempty"`
+	Phone     string    `json:"phone,omitempty"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PurchaseOrder is stock ordered from a supplier. Lines are only filled when a single
+// order is read.
+type PurchaseOrder struct {
+	ID         string              `json:"id"`
+	TenantID   string              `json:"-"` // Empty for the default tenant
+	Number     string              `json:"number"`
+	SupplierID string              `json:"supplier_id"`
+	Status     string              `json:"status"` // open, partially_received, received or cancelled
+	ExpectedAt *time.Time          `json:"expected_at,omitempty"`
+	Notes      string              `json:"notes,omitempty"`
+	Lines      []PurchaseOrderLine `json:"lines,omitempty"`
+	CreatedAt  time.Time           `json:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at"`
+}
+
+// PurchaseOrderLine is the quantity of an item ordered and received on a purchase order
+type PurchaseOrderLine struct {
+	ItemID    string   `json:"item_id"`
+	SKU       string   `json:"sku"`
+	Ordered   int      `json:"ordered"`
+	Received  int      `json:"received"`
+	Remaining int      `json:"remaining"` // Ordered - Received
+	UnitCost  *float64 `json:"unit_cost,omitempty"`
+}
+
+// ListSuppliersResponse represents the response for listing suppliers
+type ListSuppliersResponse struct {
+	Suppliers  []Supplier `json:"suppliers"`
+	Total      int        `json:"total"`
+	Page       int        `json:"page"`
+	PageSize   int        `json:"page_size"`
+	TotalPages int        `json:"total_pages"`
+}
+
+// ListPurchaseOrdersResponse represents the response for listing purchase orders
+type ListPurchaseOrdersResponse struct {
+	PurchaseOrders []PurchaseOrder `json:"purchase_orders"`
+	Total          int             `json:"total"`
+	Page           int             `json:"page"`
+	PageSize       int             `json:"page_size"`
+	TotalPages     int             `json:"total_pages"`
+}
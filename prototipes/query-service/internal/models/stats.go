@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// StatsQuery selects the aggregates of an inventory statistics report
+type StatsQuery struct {
+	LowStockThreshold int       // Items with this many units available or fewer are low on stock
+	Top               int       // Number of items in TopReserved
+	Since             time.Time // Reservations counted in TopReserved occurred at or after Since
+}
+
+// ReservationActivity is an item ranked by the reservations it received
+type ReservationActivity struct {
+	ID            string `json:"id"`
+	SKU           string `json:"sku"`
+	Name          string `json:"name"`
+	Reservations  int    `json:"reservations"`   // Movements that reserved units of the item
+	UnitsReserved int    `json:"units_reserved"` // Units reserved by those movements
+	Reserved      int    `json:"reserved"`       // Units reserved now
+}
+
+// InventoryStats is the summary of the inventory shown by the dashboard; soft-deleted
+// items are not counted
+type InventoryStats struct {
+	ItemCount         int                   `json:"item_count"`
+	TotalUnits        int                   `json:"total_units"`
+	TotalReserved     int                   `json:"total_reserved"`
+	TotalAvailable    int                   `json:"total_available"`
+	LowStockCount     int                   `json:"low_stock_count"`
+	LowStockThreshold int                   `json:"low_stock_threshold"`
+	TopReserved       []ReservationActivity `json:"top_reserved"`
+	ActivityDays      int                   `json:"activity_days"` // Window of TopReserved
+	GeneratedAt       time.Time             `json:"generated_at"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// StoreCalendar is the opening hours and holidays of a store, as written by the
+// Listener Service from StoreCalendarUpdated events
+type StoreCalendar struct {
+	StoreID   string         `json:"store_id"`
+	Timezone  string         `json:"timezone"` // IANA name; hours and holidays are in this time zone
+	Hours     []OpeningHours `json:"hours"`
+	Holidays  []Holiday      `json:"holidays"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// OpeningHours is one opening period of a day of the week
+type OpeningHours struct {
+	Day    string `json:"day"`    // monday ... sunday
+	Opens  string `json:"opens"`  // HH:MM
+	Closes string `json:"closes"` // HH:MM, "24:00" for midnight
+}
+
+// Holiday is a date on which the store is closed all day
+type Holiday struct {
+	Date string `json:"date"` // YYYY-MM-DD
+	Name string `json:"name,omitempty"`
+}
+
+// StoreCalendarResponse is a store's calendar together with its availability over the
+// coming days, resolved in the store's time zone
+type StoreCalendarResponse struct {
+	StoreCalendar
+	OpenNow bool          `json:"open_now"`
+	Days    []CalendarDay `json:"days"`
+}
+
+// CalendarDay is whether a store opens on a date, and when
+type CalendarDay struct {
+	Date    string         `json:"date"`    // YYYY-MM-DD in the store's time zone
+	Weekday string         `json:"weekday"` // monday ... sunday
+	Open    bool           `json:"open"`
+	Periods []OpeningHours `json:"periods"`
+	Holiday *Holiday       `json:"holiday,omitempty"` // Holiday closing the store, if any
+}
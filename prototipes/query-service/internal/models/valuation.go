@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// CostLayer represents a stock receipt with its unit cost (written by the Listener Service)
+type CostLayer struct {
+	ItemID            string
+	QuantityReceived  int
+	QuantityRemaining int
+	UnitCost          *float64 // nil when the receipt was recorded without a cost
+	ReceivedAt        time.Time
+}
+
+// ItemCostLayers groups an item with its cost layers, oldest first
+type ItemCostLayers struct {
+	Item   InventoryItem
+	Layers []CostLayer
+}
+
+// ItemValuation represents the valuation of the stock remaining for an item
+type ItemValuation struct {
+	ID               string  `json:"id"`
+	SKU              string  `json:"sku"`
+	Name             string  `json:"name"`
+	Quantity         int     `json:"quantity"`
+	UnitCost         float64 `json:"unit_cost"`
+	TotalValue       float64 `json:"total_value"`
+	UncostedQuantity int     `json:"uncosted_quantity"`
+}
+
+// ValuationReport represents the inventory valuation report
+type ValuationReport struct {
+	Method           string          `json:"method"`
+	Items            []ItemValuation `json:"items"`
+	TotalQuantity    int             `json:"total_quantity"`
+	TotalValue       float64         `json:"total_value"`
+	UncostedQuantity int             `json:"uncosted_quantity"`
+	GeneratedAt      time.Time       `json:"generated_at"`
+}
@@ -0,0 +1,360 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"query-service/internal/cache"
+	"query-service/internal/models"
+	"query-service/internal/repository"
+	"query-service/internal/tenant"
+	"query-service/pkg/metrics"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Stages in which a probe write is expected to become visible
+const (
+	StageReadModel = "read_model"
+	StageCache     = "cache"
+)
+
+// Probe failure causes, reported as the reason label of probe_failures_total
+var (
+	errTimeout = errors.New("probe write not visible before timeout")
+	errRead    = errors.New("probe read failed")
+)
+
+// Config configures the consistency probe
+type Config struct {
+	CommandURL        string        // Base URL of the Command Service
+	SKU               string        // SKU of the dedicated probe item
+	Interval          time.Duration // Time between probe runs
+	Timeout           time.Duration // Maximum time to wait for a write to propagate
+	PollInterval      time.Duration // Time between read-side checks while waiting
+	WarnThreshold     time.Duration // Propagation slower than this raises a warning
+	CriticalThreshold time.Duration // Propagation slower than this (or a timeout) is critical
+}
+
+// TokenIssuer mints the token the probe uses to call the Command Service
+type TokenIssuer interface {
+	GenerateToken(username, role, tenantID string) (string, error)
+}
+
+// Probe periodically writes to a dedicated item through the Command Service and measures
+// how long the change takes to reach the read model and the cache.
+//
+// Each run adjusts the probe item's stock by one unit, alternating up and down, so its
+// quantity stays at 1 or 2. The quantity returned by the Command Service is the value
+// the read side must converge to.
+type Probe struct {
+	cfg    Config
+	repo   repository.ReadRepository
+	cache  cache.Cache // nil when the cache is disabled
+	tokens TokenIssuer
+	role   string
+	client *http.Client
+	logger *zap.Logger
+	itemID string
+}
+
+// Username identifies the probe's writes in the activity log and stock history
+const Username = "consistency-probe"
+
+// New creates a consistency probe. cacheClient may be nil; role must grant inventory:write
+// in the Command Service.
+func New(cfg Config, repo repository.ReadRepository, cacheClient cache.Cache, tokens TokenIssuer, role string, logger *zap.Logger) *Probe {
+	metrics.ProbeThresholdSeconds.WithLabelValues("warning").Set(cfg.WarnThreshold.Seconds())
+	metrics.ProbeThresholdSeconds.WithLabelValues("critical").Set(cfg.CriticalThreshold.Seconds())
+
+	return &Probe{
+		cfg:    cfg,
+		repo:   repo,
+		cache:  cacheClient,
+		tokens: tokens,
+		role:   role,
+		client: &http.Client{Timeout: 10 * time.Second},
+		logger: logger,
+	}
+}
+
+// Run probes every Config.Interval until ctx is cancelled
+func (p *Probe) Run(ctx context.Context) {
+	p.logger.Info("Consistency probe started",
+		zap.String("command_url", p.cfg.CommandURL),
+		zap.String("sku", p.cfg.SKU),
+		zap.Duration("interval", p.cfg.Interval),
+	)
+
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce performs one probe write, waits for it to propagate and records the result
+func (p *Probe) RunOnce(ctx context.Context) {
+	latencies, err := p.probe(ctx)
+	if err != nil {
+		reason := "write"
+		switch {
+		case errors.Is(err, errTimeout):
+			reason = "timeout"
+		case ctx.Err() != nil:
+			return // shutting down
+		case errors.Is(err, errRead):
+			reason = "read"
+		}
+		metrics.ProbeFailures.WithLabelValues(reason).Inc()
+		if reason == "timeout" {
+			metrics.ProbeAlertLevel.Set(2)
+		}
+		p.logger.Error("Consistency probe failed", zap.String("reason", reason), zap.Error(err))
+		return
+	}
+
+	slowest := time.Duration(0)
+	for stage, latency := range latencies {
+		metrics.ProbePropagationSeconds.WithLabelValues(stage).Observe(latency.Seconds())
+		metrics.ProbeLastPropagationSeconds.WithLabelValues(stage).Set(latency.Seconds())
+		if latency > slowest {
+			slowest = latency
+		}
+	}
+
+	fields := []zap.Field{zap.Duration("slowest", slowest)}
+	for stage, latency := range latencies {
+		fields = append(fields, zap.Duration(stage, latency))
+	}
+	switch {
+	case p.cfg.CriticalThreshold > 0 && slowest > p.cfg.CriticalThreshold:
+		metrics.ProbeAlertLevel.Set(2)
+		p.logger.Error("Write-to-read propagation above critical threshold", fields...)
+	case p.cfg.WarnThreshold > 0 && slowest > p.cfg.WarnThreshold:
+		metrics.ProbeAlertLevel.Set(1)
+		p.logger.Warn("Write-to-read propagation above warning threshold", fields...)
+	default:
+		metrics.ProbeAlertLevel.Set(0)
+		p.logger.Debug("Write-to-read propagation measured", fields...)
+	}
+}
+
+// probe performs the write and returns how long each stage took to reflect it
+func (p *Probe) probe(ctx context.Context) (map[string]time.Duration, error) {
+	// The probe item belongs to the default tenant, the one the read model is read as
+	token, err := p.tokens.GenerateToken(Username, p.role, tenant.Default)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate probe token: %w", err)
+	}
+
+	var expected int
+	var start time.Time
+	if p.itemID == "" {
+		item, err := p.repo.FindBySKU(ctx, p.cfg.SKU)
+		if err != nil && err != repository.ErrItemNotFound {
+			return nil, fmt.Errorf("%w: %v", errRead, err)
+		}
+		if item != nil {
+			p.itemID = item.ID
+		} else {
+			// First run against this read model: creating the item is the probe write
+			start = time.Now()
+			id, quantity, err := p.createItem(ctx, token)
+			if err != nil {
+				return nil, err
+			}
+			p.itemID, expected = id, quantity
+		}
+	}
+
+	if start.IsZero() {
+		item, err := p.findItem(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errRead, err)
+		}
+		delta := 1
+		if item != nil && item.Quantity > 1 {
+			delta = -1
+		}
+		start = time.Now()
+		quantity, err := p.adjustStock(ctx, token, delta)
+		if err != nil {
+			return nil, err
+		}
+		expected = quantity
+	}
+
+	return p.waitForPropagation(ctx, start, expected)
+}
+
+// waitForPropagation polls each stage until it shows the expected quantity
+func (p *Probe) waitForPropagation(ctx context.Context, start time.Time, expected int) (map[string]time.Duration, error) {
+	pending := map[string]bool{StageReadModel: true}
+	if p.cache != nil {
+		pending[StageCache] = true
+	}
+	latencies := make(map[string]time.Duration, len(pending))
+
+	deadline := time.NewTimer(p.cfg.Timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(p.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for stage := range pending {
+			visible, err := p.visible(ctx, stage, expected)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", errRead, stage, err)
+			}
+			if visible {
+				latencies[stage] = time.Since(start)
+				delete(pending, stage)
+			}
+		}
+		if len(pending) == 0 {
+			return latencies, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline.C:
+			stages := make([]string, 0, len(pending))
+			for stage := range pending {
+				stages = append(stages, stage)
+			}
+			return nil, fmt.Errorf("%w (item %s, expected quantity %d, pending: %s)",
+				errTimeout, p.itemID, expected, strings.Join(stages, ","))
+		case <-ticker.C:
+		}
+	}
+}
+
+// visible reports whether a stage reflects the expected quantity. The cache stage follows
+// the cache-first read path of the API: a cached item or stock status must hold the new
+// quantity, and a miss falls through to the read model.
+func (p *Probe) visible(ctx context.Context, stage string, expected int) (bool, error) {
+	switch stage {
+	case StageReadModel:
+		item, err := p.findItem(ctx)
+		if err != nil {
+			return false, err
+		}
+		return item != nil && item.Quantity == expected, nil
+	case StageCache:
+		for _, key := range []string{"item:id:" + p.itemID, "stock:" + p.itemID} {
+			var cached struct {
+				Quantity int `json:"quantity"`
+			}
+			err := cache.GetJSON(ctx, p.cache, key, &cached)
+			if err == cache.ErrCacheMiss {
+				if ok, err := p.visible(ctx, StageReadModel, expected); !ok || err != nil {
+					return false, err
+				}
+				continue
+			}
+			if err != nil {
+				return false, err
+			}
+			if cached.Quantity != expected {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	return false, fmt.Errorf("unknown stage %q", stage)
+}
+
+// findItem returns the probe item from the read model, or nil if it is not there yet
+func (p *Probe) findItem(ctx context.Context) (*models.InventoryItem, error) {
+	id, err := uuid.Parse(p.itemID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid probe item id %q: %w", p.itemID, err)
+	}
+	item, err := p.repo.FindByID(ctx, id)
+	if err == repository.ErrItemNotFound {
+		return nil, nil
+	}
+	return item, err
+}
+
+// createItem creates the probe item and returns its ID and quantity
+func (p *Probe) createItem(ctx context.Context, token string) (string, int, error) {
+	var response struct {
+		ID       string `json:"id"`
+		Quantity int    `json:"quantity"`
+	}
+	err := p.post(ctx, token, "/api/v1/inventory/items", map[string]interface{}{
+		"sku":         p.cfg.SKU,
+		"name":        "Consistency probe",
+		"description": "Synthetic item written by the query-service consistency probe",
+		"quantity":    1,
+	}, &response)
+	if err != nil {
+		return "", 0, err
+	}
+	return response.ID, response.Quantity, nil
+}
+
+// adjustStock adjusts the probe item's stock and returns the resulting quantity
+func (p *Probe) adjustStock(ctx context.Context, token string, delta int) (int, error) {
+	var response struct {
+		Quantity int `json:"quantity"`
+	}
+	err := p.post(ctx, token, "/api/v1/inventory/items/"+p.itemID+"/adjust", map[string]int{"quantity": delta}, &response)
+	if err != nil {
+		return 0, err
+	}
+	return response.Quantity, nil
+}
+
+// post sends a JSON command to the Command Service and decodes a 2xx response into out
+func (p *Probe) post(ctx context.Context, token, path string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal probe command: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(p.cfg.CommandURL, "/")+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build probe request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json; envelope=false") // decoded below, even with RESPONSE_ENVELOPE=true
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-Request-ID", uuid.New().String())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("probe write failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("probe write %s returned %d: %s: %s", path, resp.StatusCode, apiErr.Code, apiErr.Message)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode probe write response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"query-service/internal/cache"
+	"query-service/internal/models"
+	"query-service/internal/repository"
+	"query-service/pkg/metrics"
+
+	"testsupport"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type staticTokens struct{}
+
+func (staticTokens) GenerateToken(username, role, tenantID string) (string, error) {
+	return username + ":" + role, nil
+}
+
+// fakeCommandService applies probe writes to the read model after a delay, like the
+// Kafka -> listener pipeline would. With apply=false writes are accepted but never applied.
+type fakeCommandService struct {
+	mu       sync.Mutex
+	repo     *repository.InMemoryReadRepository
+	delay    time.Duration
+	apply    bool
+	quantity int
+	tokens   []string
+}
+
+func (f *fakeCommandService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tokens = append(f.tokens, r.Header.Get("Authorization"))
+
+	var body struct {
+		SKU      string `json:"sku"`
+		Quantity int    `json:"quantity"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	var item models.InventoryItem
+	switch {
+	case r.URL.Path == "/api/v1/inventory/items":
+		f.quantity = body.Quantity
+		item = models.InventoryItem{ID: uuid.New().String(), SKU: body.SKU, Quantity: f.quantity, CreatedAt: time.Now()}
+		w.WriteHeader(http.StatusCreated)
+	case strings.HasSuffix(r.URL.Path, "/adjust"):
+		f.quantity += body.Quantity
+		id := strings.Split(r.URL.Path, "/")[5]
+		existing, _ := f.repo.FindByID(context.Background(), uuid.MustParse(id))
+		item = *existing
+		item.Quantity = f.quantity
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if f.apply {
+		applied := item
+		time.AfterFunc(f.delay, func() { _ = f.repo.SaveItem(applied) })
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"id": item.ID, "quantity": item.Quantity})
+}
+
+func newTestProbe(t *testing.T, command *fakeCommandService, cacheClient cache.Cache, timeout time.Duration) *Probe {
+	server := httptest.NewServer(command)
+	t.Cleanup(server.Close)
+
+	return New(Config{
+		CommandURL:        server.URL,
+		SKU:               "PROBE-TEST",
+		Interval:          time.Minute,
+		Timeout:           timeout,
+		PollInterval:      5 * time.Millisecond,
+		WarnThreshold:     time.Second,
+		CriticalThreshold: 5 * time.Second,
+	}, command.repo, cacheClient, staticTokens{}, "operator", zap.NewNop())
+}
+
+func TestProbe_MeasuresPropagationAndAlternatesAdjustments(t *testing.T) {
+	repo := repository.NewInMemoryReadRepository()
+	command := &fakeCommandService{repo: repo, delay: 20 * time.Millisecond, apply: true}
+	kv := cache.NewKVCache(testsupport.NewKV(), zap.NewNop())
+	p := newTestProbe(t, command, kv, 2*time.Second)
+	ctx := context.Background()
+
+	// First run creates the probe item
+	p.RunOnce(ctx)
+	require.NotEmpty(t, p.itemID)
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.ProbeAlertLevel))
+	readModel := testutil.ToFloat64(metrics.ProbeLastPropagationSeconds.WithLabelValues(StageReadModel))
+	assert.GreaterOrEqual(t, readModel, 0.02)
+
+	// A stale cached copy holds the cache stage back until it is refreshed
+	require.NoError(t, cache.SetJSON(ctx, kv, "stock:"+p.itemID, map[string]int{"quantity": 1}, time.Minute))
+	time.AfterFunc(100*time.Millisecond, func() {
+		_ = cache.SetJSON(ctx, kv, "stock:"+p.itemID, map[string]int{"quantity": 2}, time.Minute)
+	})
+	p.RunOnce(ctx)
+	assert.GreaterOrEqual(t, testutil.ToFloat64(metrics.ProbeLastPropagationSeconds.WithLabelValues(StageCache)), 0.1)
+
+	// Stock goes 1 -> 2 -> 1: the probe never drifts the item away
+	require.NoError(t, kv.Delete(ctx, "stock:"+p.itemID)) // as the Kafka invalidation would
+	p.RunOnce(ctx)
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.ProbeAlertLevel))
+	item, err := repo.FindBySKU(ctx, "PROBE-TEST")
+	require.NoError(t, err)
+	assert.Equal(t, 1, item.Quantity)
+	assert.Equal(t, "Bearer "+Username+":operator", command.tokens[0])
+}
+
+func TestProbe_TimeoutIsCritical(t *testing.T) {
+	repo := repository.NewInMemoryReadRepository()
+	command := &fakeCommandService{repo: repo, apply: false}
+	p := newTestProbe(t, command, nil, 50*time.Millisecond)
+	timeouts := metrics.ProbeFailures.WithLabelValues("timeout")
+	before := testutil.ToFloat64(timeouts)
+
+	p.RunOnce(context.Background())
+
+	assert.Equal(t, before+1, testutil.ToFloat64(timeouts))
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.ProbeAlertLevel))
+}
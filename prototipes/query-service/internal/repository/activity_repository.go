@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/tenant"
+)
+
+// ActivityRepository reads the activity log (written by the Listener Service)
+type ActivityRepository interface {
+	// ListActivity returns a page of the activity log, most recent first, and the total number of matching entries
+	ListActivity(ctx context.Context, filter models.ActivityFilter, page, pageSize int) ([]models.ActivityEntry, int, error)
+}
+
+// ListActivity returns a page of the activity log of the ctx tenant
+func (r *SQLiteReadRepository) ListActivity(ctx context.Context, filter models.ActivityFilter, page, pageSize int) ([]models.ActivityEntry, int, error) {
+	conditions := []string{"tenant_id = ?"}
+	args := []interface{}{tenant.FromContext(ctx)}
+	if filter.Actor != "" {
+		conditions = append(conditions, "actor = ?")
+		args = append(args, filter.Actor)
+	}
+	if filter.ItemID != "" {
+		conditions = append(conditions, "item_id = ?")
+		args = append(args, filter.ItemID)
+	}
+	if filter.Outcome != "" {
+		conditions = append(conditions, "outcome = ?")
+		args = append(args, filter.Outcome)
+	}
+	where := "WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM activity_log "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count activity: %w", err)
+	}
+
+	// processed_at has one-second resolution; rowid keeps insertion order within a second
+	query := `
+		SELECT id, event_type, item_id, store_id, sku, quantity, actor, request_id,
+		       outcome, error, occurred_at, processed_at
+		FROM activity_log ` + where + `
+		ORDER BY processed_at DESC, rowid DESC
+		LIMIT ? OFFSET ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, append(args, pageSize, (page-1)*pageSize)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list activity: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]models.ActivityEntry, 0)
+	for rows.Next() {
+		var entry models.ActivityEntry
+		var itemID, storeID, sku, actor, requestID, errMsg sql.NullString
+		var quantity sql.NullInt64
+		var occurredAtStr, processedAtStr string
+
+		if err := rows.Scan(
+			&entry.ID, &entry.EventType, &itemID, &storeID, &sku, &quantity, &actor, &requestID,
+			&entry.Outcome, &errMsg, &occurredAtStr, &processedAtStr,
+		); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan activity: %w", err)
+		}
+
+		entry.ItemID = itemID.String
+		entry.StoreID = storeID.String
+		entry.SKU = sku.String
+		entry.Actor = actor.String
+		entry.RequestID = requestID.String
+		entry.Error = errMsg.String
+		if quantity.Valid {
+			q := int(quantity.Int64)
+			entry.Quantity = &q
+		}
+		entry.OccurredAt, _ = time.Parse(time.RFC3339, occurredAtStr)
+		entry.ProcessedAt, _ = time.Parse(time.RFC3339, processedAtStr)
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating activity: %w", err)
+	}
+
+	return entries, total, nil
+}
+
+// AddActivity appends an entry to the activity log
+func (r *InMemoryReadRepository) AddActivity(entry models.ActivityEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.activity = append(r.activity, entry)
+}
+
+// ListActivity returns a page of the activity log, most recent first
+// (entries processed at the same time are returned newest insertion first)
+func (r *InMemoryReadRepository) ListActivity(ctx context.Context, filter models.ActivityFilter, page, pageSize int) ([]models.ActivityEntry, int, error) {
+	r.mu.RLock()
+	entries := make([]models.ActivityEntry, 0)
+	for i := len(r.activity) - 1; i >= 0; i-- {
+		entry := r.activity[i]
+		if tenant.OrDefault(entry.TenantID) == tenant.FromContext(ctx) &&
+			(filter.Actor == "" || entry.Actor == filter.Actor) &&
+			(filter.ItemID == "" || entry.ItemID == filter.ItemID) &&
+			(filter.Outcome == "" || entry.Outcome == filter.Outcome) {
+			entries = append(entries, entry)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].ProcessedAt.After(entries[j].ProcessedAt)
+	})
+
+	start, end := pageBounds(len(entries), page, pageSize)
+	return entries[start:end], len(entries), nil
+}
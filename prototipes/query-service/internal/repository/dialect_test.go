@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialect_Rebind(t *testing.T) {
+	query := `SELECT id FROM stock_movements WHERE item_id = ? AND reason <> '?' LIMIT ? OFFSET ?`
+
+	assert.Equal(t, query, dialect(DriverSQLite).rebind(query))
+	assert.Equal(t,
+		`SELECT id FROM stock_movements WHERE item_id = $1 AND reason <> '?' LIMIT $2 OFFSET $3`,
+		dialect(DriverPostgres).rebind(query),
+	)
+}
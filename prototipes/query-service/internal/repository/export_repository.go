@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"query-service/internal/models"
+	"query-service/internal/tenant"
+)
+
+// ExportFilter selects the items of an inventory export
+type ExportFilter struct {
+	SKUPrefix      string // Only items whose SKU starts with this prefix
+	InStock        bool   // Only items with available stock
+	IncludeDeleted bool   // Also export soft-deleted items
+}
+
+// ExportRepository streams the whole inventory for exports
+type ExportRepository interface {
+	// ExportItems calls fn for every item matching the filter, ordered by SKU, without
+	// loading the inventory in memory. It stops at the first error returned by fn.
+	ExportItems(ctx context.Context, filter ExportFilter, fn func(models.InventoryItem) error) error
+}
+
+// ExportItems reads the items of the ctx tenant with a single query and hands them to fn
+// as the rows are read
+func (r *SQLiteReadRepository) ExportItems(ctx context.Context, filter ExportFilter, fn func(models.InventoryItem) error) error {
+	conditions := []string{`tenant_id = ?`}
+	args := []interface{}{tenant.FromContext(ctx)}
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, `deleted_at IS NULL`)
+	}
+	if filter.SKUPrefix != "" {
+		conditions = append(conditions, `substr(sku, 1, ?) = ?`)
+		args = append(args, utf8.RuneCountInString(filter.SKUPrefix), filter.SKUPrefix)
+	}
+	if filter.InStock {
+		conditions = append(conditions, `available > 0`)
+	}
+
+	query := `
+		SELECT id, tenant_id, sku, name, description, quantity, reserved, available, created_at, updated_at, deleted_at
+		FROM inventory_items
+		WHERE ` + strings.Join(conditions, ` AND `) + `
+		ORDER BY sku`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to export items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.InventoryItem
+		var createdAtStr, updatedAtStr string
+		var deletedAt sql.NullString
+		if err := rows.Scan(
+			&item.ID, &item.TenantID, &item.SKU, &item.Name, &item.Description,
+			&item.Quantity, &item.Reserved, &item.Available,
+			&createdAtStr, &updatedAtStr, &deletedAt,
+		); err != nil {
+			return fmt.Errorf("failed to scan item: %w", err)
+		}
+		item.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
+		item.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAtStr)
+		item.DeletedAt = parseDeletedAt(deletedAt)
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating items: %w", err)
+	}
+	return nil
+}
+
+// ExportItems hands a snapshot of the matching in-memory items to fn, ordered by SKU
+func (r *InMemoryReadRepository) ExportItems(ctx context.Context, filter ExportFilter, fn func(models.InventoryItem) error) error {
+	r.mu.RLock()
+	items := make([]models.InventoryItem, 0, len(r.items))
+	for _, item := range r.items {
+		if (item.DeletedAt != nil && !filter.IncludeDeleted) || !inTenant(ctx, item) {
+			continue
+		}
+		if !strings.HasPrefix(item.SKU, filter.SKUPrefix) {
+			continue
+		}
+		if filter.InStock && item.Available <= 0 {
+			continue
+		}
+		items = append(items, *item)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(items, func(i, j int) bool { return items[i].SKU < items[j].SKU })
+	for _, item := range items {
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
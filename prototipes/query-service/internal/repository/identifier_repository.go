@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"query-service/internal/tenant"
+
+	"github.com/google/uuid"
+)
+
+// IdentifierRepository reads the barcodes and aliases of the items (written by the
+// Listener Service)
+type IdentifierRepository interface {
+	// FindItemIDByCode returns the item of the ctx tenant holding code (the GTIN-14 of
+	// a barcode or an alias), soft-deleted or not, or ErrItemNotFound
+	FindItemIDByCode(ctx context.Context, code string) (uuid.UUID, error)
+}
+
+// FindItemIDByCode looks a code up in item_identifiers
+func (r *SQLiteReadRepository) FindItemIDByCode(ctx context.Context, code string) (uuid.UUID, error) {
+	var itemID string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT item_id FROM item_identifiers WHERE tenant_id = ? AND code = ?
+	`, tenant.FromContext(ctx), code).Scan(&itemID)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, ErrItemNotFound
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to find item identifier: %w", err)
+	}
+	return uuid.Parse(itemID)
+}
+
+// SaveItemIdentifier assigns a code to an item, in the tenant of the item
+func (r *InMemoryReadRepository) SaveItemIdentifier(itemID uuid.UUID, code string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID := tenant.Default
+	if item, ok := r.items[itemID]; ok {
+		tenantID = tenant.OrDefault(item.TenantID)
+	}
+	if r.identifiers == nil {
+		r.identifiers = make(map[string]map[string]uuid.UUID)
+	}
+	if r.identifiers[tenantID] == nil {
+		r.identifiers[tenantID] = make(map[string]uuid.UUID)
+	}
+	r.identifiers[tenantID][code] = itemID
+}
+
+// FindItemIDByCode returns the item of the ctx tenant holding code
+func (r *InMemoryReadRepository) FindItemIDByCode(ctx context.Context, code string) (uuid.UUID, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	itemID, ok := r.identifiers[tenant.FromContext(ctx)][code]
+	if !ok {
+		return uuid.Nil, ErrItemNotFound
+	}
+	return itemID, nil
+}
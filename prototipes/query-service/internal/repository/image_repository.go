@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/tenant"
+
+	"github.com/google/uuid"
+)
+
+// ImageRepository reads the images of the items (written by the Listener Service)
+type ImageRepository interface {
+	// ListImages returns the images of the items of the ctx tenant among itemIDs, by
+	// item ID and in upload order. Items without images are not in the map.
+	ListImages(ctx context.Context, itemIDs []string) (map[string][]models.ItemImage, error)
+}
+
+// ListImages returns the images of a batch of items in one query
+func (r *SQLiteReadRepository) ListImages(ctx context.Context, itemIDs []string) (map[string][]models.ItemImage, error) {
+	images := make(map[string][]models.ItemImage)
+	if len(itemIDs) == 0 {
+		return images, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(itemIDs)), ",")
+	args := make([]interface{}, 0, len(itemIDs)+1)
+	for _, id := range itemIDs {
+		args = append(args, id)
+	}
+	args = append(args, tenant.FromContext(ctx))
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, item_id, storage_key, url, content_type, size, created_at
+		FROM item_images
+		WHERE item_id IN (`+placeholders+`) AND `+tenantItemIDs+`
+		ORDER BY item_id, created_at, id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list item images: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var image models.ItemImage
+		var itemID, createdAtStr string
+		if err := rows.Scan(&image.ID, &itemID, &image.Key, &image.URL, &image.ContentType, &image.Size, &createdAtStr); err != nil {
+			return nil, fmt.Errorf("failed to scan item image: %w", err)
+		}
+		image.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
+		images[itemID] = append(images[itemID], image)
+	}
+	return images, rows.Err()
+}
+
+// SaveItemImage adds an image to an item
+func (r *InMemoryReadRepository) SaveItemImage(itemID uuid.UUID, image models.ItemImage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.images == nil {
+		r.images = make(map[uuid.UUID][]models.ItemImage)
+	}
+	r.images[itemID] = append(r.images[itemID], image)
+}
+
+// ListImages returns the images of a batch of items
+func (r *InMemoryReadRepository) ListImages(ctx context.Context, itemIDs []string) (map[string][]models.ItemImage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	images := make(map[string][]models.ItemImage)
+	for _, id := range itemIDs {
+		itemID, err := uuid.Parse(id)
+		if err != nil || len(r.images[itemID]) == 0 || r.otherTenantItem(ctx, itemID) {
+			continue
+		}
+		itemImages := append([]models.ItemImage(nil), r.images[itemID]...)
+		sort.SliceStable(itemImages, func(a, b int) bool { return itemImages[a].CreatedAt.Before(itemImages[b].CreatedAt) })
+		images[id] = itemImages
+	}
+	return images, nil
+}
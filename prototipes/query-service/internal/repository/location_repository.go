@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/tenant"
+
+	"github.com/google/uuid"
+)
+
+// LocationRepository reads the stock of the items per location (written by the
+// Listener Service)
+type LocationRepository interface {
+	// FindItemLocations returns the locations an item has stock rows at, sorted by
+	// location. An item without them has none; a missing item is not checked.
+	FindItemLocations(ctx context.Context, itemID uuid.UUID) ([]models.LocationStock, error)
+}
+
+// FindItemLocations returns the stock of an item per location
+func (r *SQLiteReadRepository) FindItemLocations(ctx context.Context, itemID uuid.UUID) ([]models.LocationStock, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT location, quantity, reserved, updated_at
+		FROM stock_locations
+		WHERE item_id = ? AND `+tenantItemIDs+`
+		ORDER BY location
+	`, itemID.String(), tenant.FromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find item locations: %w", err)
+	}
+	defer rows.Close()
+
+	locations := make([]models.LocationStock, 0)
+	for rows.Next() {
+		var stock models.LocationStock
+		var updatedAtStr string
+		if err := rows.Scan(&stock.Location, &stock.Quantity, &stock.Reserved, &updatedAtStr); err != nil {
+			return nil, fmt.Errorf("failed to scan item location: %w", err)
+		}
+		stock.Available = stock.Quantity - stock.Reserved
+		stock.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAtStr)
+		locations = append(locations, stock)
+	}
+	return locations, rows.Err()
+}
+
+// SaveItemLocation sets the stock of an item at a location
+func (r *InMemoryReadRepository) SaveItemLocation(itemID uuid.UUID, stock models.LocationStock) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.locations == nil {
+		r.locations = make(map[uuid.UUID]map[string]models.LocationStock)
+	}
+	if r.locations[itemID] == nil {
+		r.locations[itemID] = make(map[string]models.LocationStock)
+	}
+	stock.Available = stock.Quantity - stock.Reserved
+	r.locations[itemID][stock.Location] = stock
+}
+
+// FindItemLocations returns the stock of an item per location
+func (r *InMemoryReadRepository) FindItemLocations(ctx context.Context, itemID uuid.UUID) ([]models.LocationStock, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.otherTenantItem(ctx, itemID) {
+		return []models.LocationStock{}, nil
+	}
+	locations := make([]models.LocationStock, 0, len(r.locations[itemID]))
+	for _, stock := range r.locations[itemID] {
+		locations = append(locations, stock)
+	}
+	sort.Slice(locations, func(a, b int) bool { return locations[a].Location < locations[b].Location })
+	return locations, nil
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/tenant"
+
+	"github.com/google/uuid"
+)
+
+// MovementRepository reads the stock movement history (written by the Listener Service)
+type MovementRepository interface {
+	// ListMovements returns the movements of an item that occurred at or after since, oldest first
+	ListMovements(ctx context.Context, itemID uuid.UUID, since time.Time) ([]models.StockMovement, error)
+	// ListItemHistory returns a page of an item's movements within the filter's date range,
+	// newest first, and the total number of matching movements
+	ListItemHistory(ctx context.Context, itemID uuid.UUID, filter models.MovementFilter, page, pageSize int) ([]models.StockMovement, int, error)
+}
+
+// movementColumns is the column list scanned by scanMovement
+const movementColumns = `id, item_id, store_id, movement_type, quantity_change, reserved_change,
+		       quantity_after, reserved_after, available_after, occurred_at, actor, request_id, reason, reference`
+
+// ListMovements returns the movements of an item since the given time
+func (r *SQLiteReadRepository) ListMovements(ctx context.Context, itemID uuid.UUID, since time.Time) ([]models.StockMovement, error) {
+	query := `
+		SELECT ` + movementColumns + `
+		FROM stock_movements
+		WHERE item_id = ? AND ` + tenantItemIDs + ` AND occurred_at >= ?
+		ORDER BY occurred_at ASC, created_at ASC
+	`
+
+	// occurred_at is stored as RFC3339 UTC, so string comparison preserves time order
+	rows, err := r.db.QueryContext(ctx, query, itemID.String(), tenant.FromContext(ctx), since.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stock movements: %w", err)
+	}
+	defer rows.Close()
+
+	movements := make([]models.StockMovement, 0)
+	for rows.Next() {
+		movement, err := scanMovement(rows)
+		if err != nil {
+			return nil, err
+		}
+		movements = append(movements, movement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stock movements: %w", err)
+	}
+
+	return movements, nil
+}
+
+// ListItemHistory returns a page of an item's movements, newest first
+func (r *SQLiteReadRepository) ListItemHistory(ctx context.Context, itemID uuid.UUID, filter models.MovementFilter, page, pageSize int) ([]models.StockMovement, int, error) {
+	where := `item_id = ? AND ` + tenantItemIDs
+	args := []interface{}{itemID.String(), tenant.FromContext(ctx)}
+	// occurred_at is stored as RFC3339 UTC, so string comparison preserves time order
+	if filter.From != nil {
+		where += ` AND occurred_at >= ?`
+		args = append(args, filter.From.UTC().Format(time.RFC3339))
+	}
+	if filter.To != nil {
+		where += ` AND occurred_at < ?`
+		args = append(args, filter.To.UTC().Format(time.RFC3339))
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM stock_movements WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count stock movements: %w", err)
+	}
+
+	query := `
+		SELECT ` + movementColumns + `
+		FROM stock_movements
+		WHERE ` + where + `
+		ORDER BY occurred_at DESC, created_at DESC, rowid DESC
+		LIMIT ? OFFSET ?
+	`
+	rows, err := r.db.QueryContext(ctx, query, append(args, pageSize, (page-1)*pageSize)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list stock movements: %w", err)
+	}
+	defer rows.Close()
+
+	movements := make([]models.StockMovement, 0)
+	for rows.Next() {
+		movement, err := scanMovement(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		movements = append(movements, movement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating stock movements: %w", err)
+	}
+
+	return movements, total, nil
+}
+
+// scanMovement scans a row selected with movementColumns
+func scanMovement(rows *sql.Rows) (models.StockMovement, error) {
+	var movement models.StockMovement
+	var storeID, actor, requestID, reason, reference sql.NullString
+	var occurredAtStr string
+
+	if err := rows.Scan(
+		&movement.ID, &movement.ItemID, &storeID, &movement.MovementType,
+		&movement.QuantityChange, &movement.ReservedChange,
+		&movement.QuantityAfter, &movement.ReservedAfter, &movement.AvailableAfter,
+		&occurredAtStr, &actor, &requestID, &reason, &reference,
+	); err != nil {
+		return movement, fmt.Errorf("failed to scan stock movement: %w", err)
+	}
+
+	movement.StoreID = storeID.String
+	movement.Actor = actor.String
+	movement.RequestID = requestID.String
+	movement.Reason = reason.String
+	movement.Reference = reference.String
+	movement.OccurredAt, _ = time.Parse(time.RFC3339, occurredAtStr)
+	return movement, nil
+}
+
+// AddMovement appends a stock movement to the history
+func (r *InMemoryReadRepository) AddMovement(movement models.StockMovement) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.movements = append(r.movements, movement)
+}
+
+// ListMovements returns the movements of an item since the given time, oldest first
+// (movements added at the same time keep their insertion order)
+func (r *InMemoryReadRepository) ListMovements(ctx context.Context, itemID uuid.UUID, since time.Time) ([]models.StockMovement, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	movements := make([]models.StockMovement, 0)
+	if r.otherTenantItem(ctx, itemID) {
+		return movements, nil
+	}
+	for _, movement := range r.movements {
+		if movement.ItemID == itemID.String() && !movement.OccurredAt.Before(since) {
+			movements = append(movements, movement)
+		}
+	}
+	sort.SliceStable(movements, func(i, j int) bool {
+		return movements[i].OccurredAt.Before(movements[j].OccurredAt)
+	})
+	return movements, nil
+}
+
+// ListItemHistory returns a page of an item's movements, newest first
+// (movements at the same time are returned newest insertion first)
+func (r *InMemoryReadRepository) ListItemHistory(ctx context.Context, itemID uuid.UUID, filter models.MovementFilter, page, pageSize int) ([]models.StockMovement, int, error) {
+	r.mu.RLock()
+	movements := make([]models.StockMovement, 0)
+	for i := len(r.movements) - 1; i >= 0; i-- {
+		movement := r.movements[i]
+		if movement.ItemID != itemID.String() || r.otherTenantItem(ctx, itemID) ||
+			(filter.From != nil && movement.OccurredAt.Before(*filter.From)) ||
+			(filter.To != nil && !movement.OccurredAt.Before(*filter.To)) {
+			continue
+		}
+		movements = append(movements, movement)
+	}
+	r.mu.RUnlock()
+
+	sort.SliceStable(movements, func(i, j int) bool {
+		return movements[i].OccurredAt.After(movements[j].OccurredAt)
+	})
+
+	start, end := pageBounds(len(movements), page, pageSize)
+	return movements[start:end], len(movements), nil
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/tenant"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PostgresReadRepository reads from a PostgreSQL database (Read Model)
+// It expects the same inventory_items schema as the SQLite read model, with
+// created_at/updated_at stored as TIMESTAMPTZ columns.
+type PostgresReadRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresReadRepository creates a new PostgreSQL read repository
+func NewPostgresReadRepository(dsn string) (*PostgresReadRepository, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Configure connection pool for read operations
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(time.Hour)
+
+	// Test connection
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return &PostgresReadRepository{
+		db: db,
+	}, nil
+}
+
+// Close closes the database connection
+func (r *PostgresReadRepository) Close() error {
+	if r.db != nil {
+		return r.db.Close()
+	}
+	return nil
+}
+
+// FindByID finds an item of the ctx tenant by ID; soft-deleted items are not found
+func (r *PostgresReadRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.InventoryItem, error) {
+	query := `
+		SELECT id, tenant_id, sku, name, description, quantity, reserved, available, created_at, updated_at
+		FROM inventory_items
+		WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NULL
+	`
+
+	item, err := scanPostgresItem(r.db.QueryRowContext(ctx, query, tenant.FromContext(ctx), id.String()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrItemNotFound
+		}
+		return nil, fmt.Errorf("failed to find item by ID: %w", err)
+	}
+
+	return item, nil
+}
+
+// FindBySKU finds an item of the ctx tenant by SKU
+func (r *PostgresReadRepository) FindBySKU(ctx context.Context, sku string) (*models.InventoryItem, error) {
+	query := `
+		SELECT id, tenant_id, sku, name, description, quantity, reserved, available, created_at, updated_at
+		FROM inventory_items
+		WHERE tenant_id = $1 AND sku = $2 AND deleted_at IS NULL
+	`
+
+	item, err := scanPostgresItem(r.db.QueryRowContext(ctx, query, tenant.FromContext(ctx), sku))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrItemNotFound
+		}
+		return nil, fmt.Errorf("failed to find item by SKU: %w", err)
+	}
+
+	return item, nil
+}
+
+// FindBySKUs finds all items matching the given SKUs with a single query
+func (r *PostgresReadRepository) FindBySKUs(ctx context.Context, skus []string) ([]models.InventoryItem, error) {
+	return r.findItemsIn(ctx, "sku", skus)
+}
+
+// FindByIDs finds all items with the given IDs with a single query
+func (r *PostgresReadRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]models.InventoryItem, error) {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = id.String()
+	}
+	return r.findItemsIn(ctx, "id", keys)
+}
+
+// findItemsIn returns the live items of the ctx tenant whose column (id or sku) is one of keys
+func (r *PostgresReadRepository) findItemsIn(ctx context.Context, column string, keys []string) ([]models.InventoryItem, error) {
+	query := `
+		SELECT id, tenant_id, sku, name, description, quantity, reserved, available, created_at, updated_at
+		FROM inventory_items
+		WHERE tenant_id = $1 AND ` + column + ` = ANY($2) AND deleted_at IS NULL
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenant.FromContext(ctx), pq.Array(keys))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find items by %s: %w", column, err)
+	}
+	defer rows.Close()
+
+	items := make([]models.InventoryItem, 0, len(keys))
+	for rows.Next() {
+		item, err := scanPostgresItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan item: %w", err)
+		}
+		items = append(items, *item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating items: %w", err)
+	}
+
+	return items, nil
+}
+
+// ListItems lists the items of the ctx tenant with pagination, leaving out soft-deleted items
+func (r *PostgresReadRepository) ListItems(ctx context.Context, page, pageSize int) ([]models.InventoryItem, int, error) {
+	tenantID := tenant.FromContext(ctx)
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM inventory_items WHERE tenant_id = $1 AND deleted_at IS NULL`,
+		tenantID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count items: %w", err)
+	}
+
+	offset := (page - 1) * pageSize
+
+	query := `
+		SELECT id, tenant_id, sku, name, description, quantity, reserved, available, created_at, updated_at
+		FROM inventory_items
+		WHERE tenant_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenantID, pageSize, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list items: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.InventoryItem, 0)
+	for rows.Next() {
+		item, err := scanPostgresItem(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan item: %w", err)
+		}
+		items = append(items, *item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating items: %w", err)
+	}
+
+	return items, total, nil
+}
+
+// GetStockStatus gets stock status for an item of the ctx tenant
+func (r *PostgresReadRepository) GetStockStatus(ctx context.Context, id uuid.UUID) (*models.StockStatus, error) {
+	query := `
+		SELECT id, sku, quantity, reserved, available, updated_at
+		FROM inventory_items
+		WHERE tenant_id = $1 AND id = $2 AND deleted_at IS NULL
+	`
+
+	var status models.StockStatus
+	err := r.db.QueryRowContext(ctx, query, tenant.FromContext(ctx), id.String()).Scan(
+		&status.ID,
+		&status.SKU,
+		&status.Quantity,
+		&status.Reserved,
+		&status.Available,
+		&status.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrItemNotFound
+		}
+		return nil, fmt.Errorf("failed to get stock status: %w", err)
+	}
+
+	return &status, nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPostgresItem(row rowScanner) (*models.InventoryItem, error) {
+	var item models.InventoryItem
+	err := row.Scan(
+		&item.ID,
+		&item.TenantID,
+		&item.SKU,
+		&item.Name,
+		&item.Description,
+		&item.Quantity,
+		&item.Reserved,
+		&item.Available,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/tenant"
+
+	"github.com/google/uuid"
+)
+
+// PurchaseOrderStatuses are the statuses a purchase order can have
+var PurchaseOrderStatuses = []string{"open", "partially_received", "received", "cancelled"}
+
+// PurchasingRepository reads suppliers and purchase orders (written by the Listener Service)
+type PurchasingRepository interface {
+	// ListSuppliers lists the suppliers ordered by code
+	ListSuppliers(ctx context.Context, page, pageSize int) ([]models.Supplier, int, error)
+	// ListPurchaseOrders lists purchase orders without their lines, newest first. An empty
+	// status or supplierID does not filter.
+	ListPurchaseOrders(ctx context.Context, status, supplierID string, page, pageSize int) ([]models.PurchaseOrder, int, error)
+	// FindPurchaseOrder returns a purchase order with its lines
+	FindPurchaseOrder(ctx context.Context, id uuid.UUID) (*models.PurchaseOrder, error)
+}
+
+// ListSuppliers lists the suppliers of the ctx tenant
+func (r *SQLiteReadRepository) ListSuppliers(ctx context.Context, page, pageSize int) ([]models.Supplier, int, error) {
+	tenantID := tenant.FromContext(ctx)
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM suppliers WHERE tenant_id = ?`, tenantID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count suppliers: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, code, name, email, phone, active, created_at, updated_at
+		FROM suppliers
+		WHERE tenant_id = ?
+		ORDER BY code, id
+		LIMIT ? OFFSET ?
+	`, tenantID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list suppliers: %w", err)
+	}
+	defer rows.Close()
+
+	suppliers := make([]models.Supplier, 0)
+	for rows.Next() {
+		var supplier models.Supplier
+		var email, phone sql.NullString
+		var active int
+		var createdAtStr, updatedAtStr string
+
+		if err := rows.Scan(
+			&supplier.ID, &supplier.Code, &supplier.Name, &email, &phone, &active,
+			&createdAtStr, &updatedAtStr,
+		); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan supplier: %w", err)
+		}
+
+		supplier.Email = email.String
+		supplier.Phone = phone.String
+		supplier.Active = active == 1
+		supplier.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
+		supplier.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAtStr)
+
+		suppliers = append(suppliers, supplier)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating suppliers: %w", err)
+	}
+
+	return suppliers, total, nil
+}
+
+// ListPurchaseOrders lists purchase orders of the ctx tenant
+func (r *SQLiteReadRepository) ListPurchaseOrders(ctx context.Context, status, supplierID string, page, pageSize int) ([]models.PurchaseOrder, int, error) {
+	where := `tenant_id = ?`
+	args := []interface{}{tenant.FromContext(ctx)}
+	if status != "" {
+		where += ` AND status = ?`
+		args = append(args, status)
+	}
+	if supplierID != "" {
+		where += ` AND supplier_id = ?`
+		args = append(args, supplierID)
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM purchase_orders WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count purchase orders: %w", err)
+	}
+
+	query := `
+		SELECT id, number, supplier_id, status, expected_at, notes, created_at, updated_at
+		FROM purchase_orders
+		WHERE ` + where + `
+		ORDER BY created_at DESC, id
+		LIMIT ? OFFSET ?
+	`
+	args = append(args, pageSize, (page-1)*pageSize)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list purchase orders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := make([]models.PurchaseOrder, 0)
+	for rows.Next() {
+		order, err := scanPurchaseOrder(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		orders = append(orders, *order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating purchase orders: %w", err)
+	}
+
+	return orders, total, nil
+}
+
+// FindPurchaseOrder returns a purchase order of the ctx tenant by ID
+func (r *SQLiteReadRepository) FindPurchaseOrder(ctx context.Context, id uuid.UUID) (*models.PurchaseOrder, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT id, number, supplier_id, status, expected_at, notes, created_at, updated_at
+		FROM purchase_orders
+		WHERE id = ? AND tenant_id = ?
+	`, id.String(), tenant.FromContext(ctx))
+	order, err := scanPurchaseOrder(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPurchaseOrderNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT item_id, sku, ordered, received, unit_cost
+		FROM purchase_order_lines
+		WHERE purchase_order_id = ?
+		ORDER BY sku, item_id
+	`, id.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get purchase order lines: %w", err)
+	}
+	defer rows.Close()
+
+	order.Lines = make([]models.PurchaseOrderLine, 0)
+	for rows.Next() {
+		var line models.PurchaseOrderLine
+		var unitCost sql.NullFloat64
+		if err := rows.Scan(&line.ItemID, &line.SKU, &line.Ordered, &line.Received, &unitCost); err != nil {
+			return nil, fmt.Errorf("failed to scan purchase order line: %w", err)
+		}
+		line.Remaining = line.Ordered - line.Received
+		if unitCost.Valid {
+			line.UnitCost = &unitCost.Float64
+		}
+		order.Lines = append(order.Lines, line)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating purchase order lines: %w", err)
+	}
+
+	return order, nil
+}
+
+// scanPurchaseOrder scans the columns selected by ListPurchaseOrders and FindPurchaseOrder.
+// sql.ErrNoRows is returned as is.
+func scanPurchaseOrder(row rowScanner) (*models.PurchaseOrder, error) {
+	var order models.PurchaseOrder
+	var expectedAtStr, notes sql.NullString
+	var createdAtStr, updatedAtStr string
+
+	if err := row.Scan(
+		&order.ID, &order.Number, &order.SupplierID, &order.Status, &expectedAtStr, &notes,
+		&createdAtStr, &updatedAtStr,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan purchase order: %w", err)
+	}
+
+	order.ExpectedAt = parseOptionalTime(expectedAtStr)
+	order.Notes = notes.String
+	order.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
+	order.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAtStr)
+
+	return &order, nil
+}
+
+// SaveSupplier inserts or replaces a supplier (matched by id)
+func (r *InMemoryReadRepository) SaveSupplier(supplier models.Supplier) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.suppliers {
+		if r.suppliers[i].ID == supplier.ID {
+			r.suppliers[i] = supplier
+			return
+		}
+	}
+	r.suppliers = append(r.suppliers, supplier)
+}
+
+// SavePurchaseOrder inserts or replaces a purchase order with its lines (matched by id)
+func (r *InMemoryReadRepository) SavePurchaseOrder(order models.PurchaseOrder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.purchaseOrders {
+		if r.purchaseOrders[i].ID == order.ID {
+			r.purchaseOrders[i] = order
+			return
+		}
+	}
+	r.purchaseOrders = append(r.purchaseOrders, order)
+}
+
+// ListSuppliers lists the suppliers of the ctx tenant in the SQLite order
+func (r *InMemoryReadRepository) ListSuppliers(ctx context.Context, page, pageSize int) ([]models.Supplier, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	suppliers := make([]models.Supplier, 0)
+	for _, supplier := range r.suppliers {
+		if tenant.OrDefault(supplier.TenantID) == tenant.FromContext(ctx) {
+			suppliers = append(suppliers, supplier)
+		}
+	}
+	sort.SliceStable(suppliers, func(i, j int) bool {
+		if suppliers[i].Code != suppliers[j].Code {
+			return suppliers[i].Code < suppliers[j].Code
+		}
+		return suppliers[i].ID < suppliers[j].ID
+	})
+
+	start, end := pageBounds(len(suppliers), page, pageSize)
+	return suppliers[start:end], len(suppliers), nil
+}
+
+// ListPurchaseOrders lists purchase orders of the ctx tenant in the SQLite order
+func (r *InMemoryReadRepository) ListPurchaseOrders(ctx context.Context, status, supplierID string, page, pageSize int) ([]models.PurchaseOrder, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	orders := make([]models.PurchaseOrder, 0)
+	for _, order := range r.purchaseOrders {
+		if tenant.OrDefault(order.TenantID) != tenant.FromContext(ctx) ||
+			(status != "" && order.Status != status) || (supplierID != "" && order.SupplierID != supplierID) {
+			continue
+		}
+		order.Lines = nil
+		orders = append(orders, order)
+	}
+	sort.SliceStable(orders, func(i, j int) bool {
+		if !orders[i].CreatedAt.Equal(orders[j].CreatedAt) {
+			return orders[i].CreatedAt.After(orders[j].CreatedAt)
+		}
+		return orders[i].ID < orders[j].ID
+	})
+
+	start, end := pageBounds(len(orders), page, pageSize)
+	return orders[start:end], len(orders), nil
+}
+
+// FindPurchaseOrder returns a purchase order of the ctx tenant by ID
+func (r *InMemoryReadRepository) FindPurchaseOrder(ctx context.Context, id uuid.UUID) (*models.PurchaseOrder, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, order := range r.purchaseOrders {
+		if order.ID != id.String() || tenant.OrDefault(order.TenantID) != tenant.FromContext(ctx) {
+			continue
+		}
+		order.Lines = append([]models.PurchaseOrderLine{}, order.Lines...)
+		for i := range order.Lines {
+			order.Lines[i].Remaining = order.Lines[i].Ordered - order.Lines[i].Received
+		}
+		return &order, nil
+	}
+	return nil, ErrPurchaseOrderNotFound
+}
@@ -2,78 +2,252 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
 
 	"query-service/internal/models"
+	"query-service/internal/tenant"
 
 	"github.com/google/uuid"
 )
 
-// ReadRepository defines the interface for read operations
+// ReadRepository defines the interface for read operations. Every read only sees the
+// items of the tenant of ctx (tenant.FromContext); other tenants' items are not found.
 type ReadRepository interface {
 	FindByID(ctx context.Context, id uuid.UUID) (*models.InventoryItem, error)
 	FindBySKU(ctx context.Context, sku string) (*models.InventoryItem, error)
+	// FindBySKUs returns the items matching any of the SKUs in a single lookup; missing SKUs are omitted
+	FindBySKUs(ctx context.Context, skus []string) ([]models.InventoryItem, error)
+	// FindByIDs returns the items with any of the IDs in a single lookup; missing IDs are omitted
+	FindByIDs(ctx context.Context, ids []uuid.UUID) ([]models.InventoryItem, error)
 	ListItems(ctx context.Context, page, pageSize int) ([]models.InventoryItem, int, error)
 	GetStockStatus(ctx context.Context, id uuid.UUID) (*models.StockStatus, error)
 }
 
-// InMemoryReadRepository is a placeholder implementation
-// TODO: Replace with actual database implementation that reads from Read Model
+// DeletedItemsRepository is implemented by read models that can also return soft-deleted
+// items, which ReadRepository leaves out
+type DeletedItemsRepository interface {
+	FindByIDIncludingDeleted(ctx context.Context, id uuid.UUID) (*models.InventoryItem, error)
+	ListItemsIncludingDeleted(ctx context.Context, page, pageSize int) ([]models.InventoryItem, int, error)
+}
+
+// ItemCursor is the position of an item in the newest-first listing (created_at, then id)
+type ItemCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// CursorItemsRepository is implemented by read models that can list items after a cursor
+// (keyset pagination), which does not skip rows like page/page_size on deep pages
+type CursorItemsRepository interface {
+	// ListItemsAfter returns up to limit live items listed after the cursor (from the
+	// first one when after is nil) and the total of live items
+	ListItemsAfter(ctx context.Context, after *ItemCursor, limit int) ([]models.InventoryItem, int, error)
+}
+
+// InMemoryReadRepository is a read model kept in memory, used when SQLite is not configured
+// (tests, demos and MOCK_DEPENDENCIES). It follows the SQLite repository's ordering and
+// filtering so results do not depend on the backend. It is safe for concurrent use;
+// data is loaded with the Save/Add methods.
 type InMemoryReadRepository struct {
-	items map[uuid.UUID]*models.InventoryItem
+	mu             sync.RWMutex
+	items          map[uuid.UUID]*models.InventoryItem
+	stores         map[uuid.UUID]string // tenant of each store
+	reservations   []models.StoreReservation
+	movements      []models.StockMovement
+	activity       []models.ActivityEntry // in insertion order
+	calendars      map[uuid.UUID]models.StoreCalendar
+	relations      []itemRelation
+	locations      map[uuid.UUID]map[string]models.LocationStock
+	images         map[uuid.UUID][]models.ItemImage
+	identifiers    map[string]map[string]uuid.UUID // item of each barcode or alias code, per tenant
+	suppliers      []models.Supplier
+	purchaseOrders []models.PurchaseOrder
 }
 
 func NewReadRepository() ReadRepository {
+	return NewInMemoryReadRepository()
+}
+
+// NewInMemoryReadRepository creates an empty in-memory read model
+func NewInMemoryReadRepository() *InMemoryReadRepository {
 	return &InMemoryReadRepository{
-		items: make(map[uuid.UUID]*models.InventoryItem),
+		items:  make(map[uuid.UUID]*models.InventoryItem),
+		stores: make(map[uuid.UUID]string),
 	}
 }
 
+// SaveItem inserts or replaces an item
+func (r *InMemoryReadRepository) SaveItem(item models.InventoryItem) error {
+	id, err := uuid.Parse(item.ID)
+	if err != nil {
+		return fmt.Errorf("invalid item id %q: %w", item.ID, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.items == nil {
+		r.items = make(map[uuid.UUID]*models.InventoryItem)
+	}
+	r.items[id] = &item
+	return nil
+}
+
+// DeleteItem removes an item
+func (r *InMemoryReadRepository) DeleteItem(id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.items, id)
+}
+
 func (r *InMemoryReadRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.InventoryItem, error) {
-	item, exists := r.items[id]
-	if !exists {
+	item, err := r.FindByIDIncludingDeleted(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if item.DeletedAt != nil {
 		return nil, ErrItemNotFound
 	}
 	return item, nil
 }
 
+// FindByIDIncludingDeleted finds an item by ID, soft-deleted or not
+func (r *InMemoryReadRepository) FindByIDIncludingDeleted(ctx context.Context, id uuid.UUID) (*models.InventoryItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	item, exists := r.items[id]
+	if !exists || !inTenant(ctx, item) {
+		return nil, ErrItemNotFound
+	}
+	copied := *item
+	return &copied, nil
+}
+
+// inTenant reports whether item belongs to the tenant of ctx; items saved without a
+// tenant belong to the default one
+func inTenant(ctx context.Context, item *models.InventoryItem) bool {
+	return tenant.OrDefault(item.TenantID) == tenant.FromContext(ctx)
+}
+
+// otherTenantItem reports whether id is a known item of another tenant than ctx's.
+// Rows saved for items the repository does not hold are visible to every tenant.
+func (r *InMemoryReadRepository) otherTenantItem(ctx context.Context, id uuid.UUID) bool {
+	item, exists := r.items[id]
+	return exists && !inTenant(ctx, item)
+}
+
 func (r *InMemoryReadRepository) FindBySKU(ctx context.Context, sku string) (*models.InventoryItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	for _, item := range r.items {
-		if item.SKU == sku {
-			return item, nil
+		if item.SKU == sku && item.DeletedAt == nil && inTenant(ctx, item) {
+			copied := *item
+			return &copied, nil
 		}
 	}
 	return nil, ErrItemNotFound
 }
 
-func (r *InMemoryReadRepository) ListItems(ctx context.Context, page, pageSize int) ([]models.InventoryItem, int, error) {
-	items := make([]models.InventoryItem, 0)
+func (r *InMemoryReadRepository) FindBySKUs(ctx context.Context, skus []string) ([]models.InventoryItem, error) {
+	wanted := make(map[string]bool, len(skus))
+	for _, sku := range skus {
+		wanted[sku] = true
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := make([]models.InventoryItem, 0, len(skus))
 	for _, item := range r.items {
-		items = append(items, *item)
+		if wanted[item.SKU] && item.DeletedAt == nil && inTenant(ctx, item) {
+			items = append(items, *item)
+		}
 	}
+	sort.Slice(items, func(i, j int) bool { return items[i].SKU < items[j].SKU })
+	return items, nil
+}
 
-	total := len(items)
+func (r *InMemoryReadRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]models.InventoryItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	// Simple pagination
-	start := (page - 1) * pageSize
-	if start > total {
-		return []models.InventoryItem{}, total, nil
+	items := make([]models.InventoryItem, 0, len(ids))
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		if item, ok := r.items[id]; ok && !seen[id] && item.DeletedAt == nil && inTenant(ctx, item) {
+			seen[id] = true
+			items = append(items, *item)
+		}
 	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items, nil
+}
 
-	end := start + pageSize
-	if end > total {
-		end = total
+// ListItems lists items newest first, like the SQLite repository (ties broken by id),
+// leaving out soft-deleted items
+func (r *InMemoryReadRepository) ListItems(ctx context.Context, page, pageSize int) ([]models.InventoryItem, int, error) {
+	return r.listItems(ctx, page, pageSize, false)
+}
+
+// ListItemsIncludingDeleted lists live and soft-deleted items, newest first
+func (r *InMemoryReadRepository) ListItemsIncludingDeleted(ctx context.Context, page, pageSize int) ([]models.InventoryItem, int, error) {
+	return r.listItems(ctx, page, pageSize, true)
+}
+
+func (r *InMemoryReadRepository) listItems(ctx context.Context, page, pageSize int, includeDeleted bool) ([]models.InventoryItem, int, error) {
+	items := r.sortedItems(ctx, includeDeleted)
+	start, end := pageBounds(len(items), page, pageSize)
+	return items[start:end], len(items), nil
+}
+
+// ListItemsAfter lists up to limit live items after the cursor, in the ListItems order
+func (r *InMemoryReadRepository) ListItemsAfter(ctx context.Context, after *ItemCursor, limit int) ([]models.InventoryItem, int, error) {
+	items := r.sortedItems(ctx, false)
+	start := 0
+	if after != nil {
+		start = sort.Search(len(items), func(i int) bool {
+			createdAt := items[i].CreatedAt
+			return createdAt.Before(after.CreatedAt) || createdAt.Equal(after.CreatedAt) && items[i].ID > after.ID
+		})
 	}
+	end := len(items)
+	if limit >= 0 && start+limit < end {
+		end = start + limit
+	}
+	return items[start:end], len(items), nil
+}
 
-	if start >= total {
-		return []models.InventoryItem{}, total, nil
+// sortedItems returns copies of the items of the ctx tenant newest first, ties broken by id
+func (r *InMemoryReadRepository) sortedItems(ctx context.Context, includeDeleted bool) []models.InventoryItem {
+	r.mu.RLock()
+	items := make([]models.InventoryItem, 0, len(r.items))
+	for _, item := range r.items {
+		if (item.DeletedAt != nil && !includeDeleted) || !inTenant(ctx, item) {
+			continue
+		}
+		items = append(items, *item)
 	}
+	r.mu.RUnlock()
 
-	return items[start:end], total, nil
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].CreatedAt.Equal(items[j].CreatedAt) {
+			return items[i].CreatedAt.After(items[j].CreatedAt)
+		}
+		return items[i].ID < items[j].ID
+	})
+	return items
 }
 
 func (r *InMemoryReadRepository) GetStockStatus(ctx context.Context, id uuid.UUID) (*models.StockStatus, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	item, exists := r.items[id]
-	if !exists {
+	if !exists || item.DeletedAt != nil || !inTenant(ctx, item) {
 		return nil, ErrItemNotFound
 	}
 
@@ -87,8 +261,29 @@ func (r *InMemoryReadRepository) GetStockStatus(ctx context.Context, id uuid.UUI
 	}, nil
 }
 
+// pageBounds returns the slice bounds of a 1-based page over total elements,
+// matching LIMIT/OFFSET: out-of-range pages are empty
+func pageBounds(total, page, pageSize int) (int, int) {
+	if page < 1 || pageSize < 1 {
+		return 0, 0
+	}
+	start := (page - 1) * pageSize
+	if start >= total {
+		return total, total
+	}
+	end := start + pageSize
+	if end > total {
+		end = total
+	}
+	return start, end
+}
+
 var (
-	ErrItemNotFound = &RepositoryError{Message: "item not found"}
+	ErrItemNotFound          = &RepositoryError{Message: "item not found"}
+	ErrStoreNotFound         = &RepositoryError{Message: "store not found"}
+	ErrWaitlistEntryNotFound = &RepositoryError{Message: "waitlist entry not found"}
+	ErrStoreCalendarNotFound = &RepositoryError{Message: "store has no calendar"}
+	ErrPurchaseOrderNotFound = &RepositoryError{Message: "purchase order not found"}
 )
 
 type RepositoryError struct {
@@ -98,4 +293,3 @@ type RepositoryError struct {
 func (e *RepositoryError) Error() string {
 	return e.Message
 }
-
@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/tenant"
+
+	"github.com/google/uuid"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryReadRepository_ListItemsIsDeterministic(t *testing.T) {
+	repo := NewInMemoryReadRepository()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Two items share a created_at, so the id breaks the tie
+	ids := []string{
+		"00000000-0000-0000-0000-000000000003",
+		"00000000-0000-0000-0000-000000000001",
+		"00000000-0000-0000-0000-000000000002",
+	}
+	require.NoError(t, repo.SaveItem(models.InventoryItem{ID: ids[0], SKU: "A", CreatedAt: base}))
+	require.NoError(t, repo.SaveItem(models.InventoryItem{ID: ids[1], SKU: "B", CreatedAt: base.Add(time.Hour)}))
+	require.NoError(t, repo.SaveItem(models.InventoryItem{ID: ids[2], SKU: "C", CreatedAt: base}))
+
+	page1, total, err := repo.ListItems(context.Background(), 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, page1, 2)
+	assert.Equal(t, "B", page1[0].SKU)
+	assert.Equal(t, "C", page1[1].SKU)
+
+	page2, _, err := repo.ListItems(context.Background(), 2, 2)
+	require.NoError(t, err)
+	require.Len(t, page2, 1)
+	assert.Equal(t, "A", page2[0].SKU)
+
+	page3, total, err := repo.ListItems(context.Background(), 3, 2)
+	require.NoError(t, err)
+	assert.Empty(t, page3)
+	assert.Equal(t, 3, total)
+}
+
+func TestListItemsAfter_WalksEveryItemOnce(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// B is newest; A, C and D share a created_at so the id breaks the tie; E is deleted
+	items := []models.InventoryItem{
+		{ID: "00000000-0000-0000-0000-000000000003", SKU: "A", CreatedAt: base},
+		{ID: "00000000-0000-0000-0000-000000000005", SKU: "B", CreatedAt: base.Add(time.Hour)},
+		{ID: "00000000-0000-0000-0000-000000000001", SKU: "C", CreatedAt: base},
+		{ID: "00000000-0000-0000-0000-000000000004", SKU: "D", CreatedAt: base},
+		{ID: "00000000-0000-0000-0000-000000000002", SKU: "E", CreatedAt: base, DeletedAt: &base},
+	}
+
+	inMemory := NewInMemoryReadRepository()
+	for _, item := range items {
+		require.NoError(t, inMemory.SaveItem(item))
+	}
+
+	path := filepath.Join(t.TempDir(), "inventory.db")
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE inventory_items (
+		id TEXT PRIMARY KEY, sku TEXT, name TEXT, description TEXT, quantity INTEGER, reserved INTEGER,
+		available INTEGER, created_at TEXT, updated_at TEXT, deleted_at TEXT, tenant_id TEXT NOT NULL DEFAULT 'default')`)
+	require.NoError(t, err)
+	for _, item := range items {
+		var deletedAt interface{}
+		if item.DeletedAt != nil {
+			deletedAt = item.DeletedAt.Format(time.RFC3339)
+		}
+		_, err = db.Exec(`INSERT INTO inventory_items VALUES (?, ?, '', '', 0, 0, 0, ?, ?, ?, 'default')`,
+			item.ID, item.SKU, item.CreatedAt.Format(time.RFC3339), item.CreatedAt.Format(time.RFC3339), deletedAt)
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Close())
+	sqlite, err := NewSQLiteReadRepository(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlite.(*SQLiteReadRepository).Close() })
+
+	for name, repo := range map[string]CursorItemsRepository{"in-memory": inMemory, "sqlite": sqlite.(CursorItemsRepository)} {
+		t.Run(name, func(t *testing.T) {
+			var skus []string
+			var after *ItemCursor
+			for pages := 0; pages < 5; pages++ {
+				page, total, err := repo.ListItemsAfter(context.Background(), after, 2)
+				require.NoError(t, err)
+				assert.Equal(t, 4, total)
+				if len(page) == 0 {
+					break
+				}
+				for _, item := range page {
+					skus = append(skus, item.SKU)
+				}
+				last := page[len(page)-1]
+				after = &ItemCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+			}
+			// The same order as ListItems
+			assert.Equal(t, []string{"B", "C", "A", "D"}, skus)
+		})
+	}
+}
+
+func TestReadRepositories_OnlySeeTheTenantOfTheContext(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	items := []models.InventoryItem{
+		{ID: uuid.NewString(), TenantID: "default", SKU: "SKU-001", Name: "default", CreatedAt: base},
+		{ID: uuid.NewString(), TenantID: "acme", SKU: "SKU-001", Name: "acme", CreatedAt: base},
+	}
+
+	inMemory := NewInMemoryReadRepository()
+	for _, item := range items {
+		require.NoError(t, inMemory.SaveItem(item))
+	}
+
+	path := filepath.Join(t.TempDir(), "inventory.db")
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE inventory_items (
+		id TEXT PRIMARY KEY, tenant_id TEXT NOT NULL, sku TEXT, name TEXT, description TEXT, quantity INTEGER,
+		reserved INTEGER, available INTEGER, created_at TEXT, updated_at TEXT, deleted_at TEXT)`)
+	require.NoError(t, err)
+	for _, item := range items {
+		_, err = db.Exec(`INSERT INTO inventory_items VALUES (?, ?, ?, ?, '', 0, 0, 0, ?, ?, NULL)`,
+			item.ID, item.TenantID, item.SKU, item.Name, base.Format(time.RFC3339), base.Format(time.RFC3339))
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Close())
+	sqlite, err := NewSQLiteReadRepository(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlite.(*SQLiteReadRepository).Close() })
+
+	for name, repo := range map[string]ReadRepository{"in-memory": inMemory, "sqlite": sqlite} {
+		t.Run(name, func(t *testing.T) {
+			acme := tenant.WithTenant(context.Background(), "acme")
+
+			item, err := repo.FindBySKU(acme, "SKU-001")
+			require.NoError(t, err)
+			assert.Equal(t, "acme", item.Name)
+			item, err = repo.FindBySKU(context.Background(), "SKU-001")
+			require.NoError(t, err)
+			assert.Equal(t, "default", item.Name)
+
+			// The item of another tenant is not found, even by ID
+			_, err = repo.FindByID(acme, uuid.MustParse(items[0].ID))
+			assert.ErrorIs(t, err, ErrItemNotFound)
+
+			list, total, err := repo.ListItems(acme, 1, 10)
+			require.NoError(t, err)
+			assert.Equal(t, 1, total)
+			require.Len(t, list, 1)
+			assert.Equal(t, items[1].ID, list[0].ID)
+		})
+	}
+}
+
+func TestInMemoryReadRepository_FindByIDs(t *testing.T) {
+	repo := NewInMemoryReadRepository()
+	live, deleted, missing := uuid.New(), uuid.New(), uuid.New()
+	deletedAt := time.Now()
+	require.NoError(t, repo.SaveItem(models.InventoryItem{ID: live.String(), SKU: "LIVE"}))
+	require.NoError(t, repo.SaveItem(models.InventoryItem{ID: deleted.String(), SKU: "GONE", DeletedAt: &deletedAt}))
+
+	items, err := repo.FindByIDs(context.Background(), []uuid.UUID{live, deleted, missing, live})
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "LIVE", items[0].SKU)
+}
+
+func TestInMemoryReadRepository_ReturnsCopies(t *testing.T) {
+	repo := NewInMemoryReadRepository()
+	id := uuid.New()
+	require.NoError(t, repo.SaveItem(models.InventoryItem{ID: id.String(), SKU: "SKU-1", Quantity: 5, Available: 5}))
+
+	item, err := repo.FindByID(context.Background(), id)
+	require.NoError(t, err)
+	item.Quantity = 0
+
+	status, err := repo.GetStockStatus(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, 5, status.Quantity)
+	assert.Equal(t, 5, status.Available)
+}
+
+func TestInMemoryReadRepository_ReservationFilters(t *testing.T) {
+	repo := NewInMemoryReadRepository()
+	itemID, storeID := uuid.New(), uuid.New()
+	require.NoError(t, repo.SaveItem(models.InventoryItem{ID: itemID.String(), SKU: "SKU-1"}))
+	repo.SaveStore(storeID)
+
+	base := time.Now().UTC()
+	for i, status := range []string{"active", "released", "active"} {
+		repo.SaveReservation(models.StoreReservation{
+			ID:         fmt.Sprintf("res-%d", i),
+			StoreID:    storeID.String(),
+			ItemID:     itemID.String(),
+			Status:     status,
+			ReservedAt: base.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	active, total, err := repo.ListReservationsByStore(context.Background(), storeID, "active", 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, active, 2)
+	assert.Equal(t, "res-2", active[0].ID, "newest first")
+
+	all, total, err := repo.ListReservationsByItem(context.Background(), itemID, "", 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Len(t, all, 3)
+
+	_, _, err = repo.ListReservationsByStore(context.Background(), uuid.New(), "", 1, 10)
+	assert.Equal(t, ErrStoreNotFound, err)
+	_, _, err = repo.ListReservationsByItem(context.Background(), uuid.New(), "", 1, 10)
+	assert.Equal(t, ErrItemNotFound, err)
+}
+
+func TestInMemoryReadRepository_ActivityFilters(t *testing.T) {
+	repo := NewInMemoryReadRepository()
+	processedAt := time.Now().UTC().Truncate(time.Second)
+	repo.AddActivity(models.ActivityEntry{ID: "1", Actor: "alice", Outcome: "applied", ProcessedAt: processedAt})
+	repo.AddActivity(models.ActivityEntry{ID: "2", Actor: "bob", Outcome: "failed", ProcessedAt: processedAt})
+	repo.AddActivity(models.ActivityEntry{ID: "3", Actor: "alice", Outcome: "applied", ProcessedAt: processedAt})
+
+	entries, total, err := repo.ListActivity(context.Background(), models.ActivityFilter{Actor: "alice"}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "3", entries[0].ID, "same second: latest insertion first")
+
+	entries, total, err = repo.ListActivity(context.Background(), models.ActivityFilter{Outcome: "failed"}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, "bob", entries[0].Actor)
+}
+
+func TestInMemoryReadRepository_ConcurrentAccess(t *testing.T) {
+	repo := NewInMemoryReadRepository()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				id := uuid.New()
+				_ = repo.SaveItem(models.InventoryItem{ID: id.String(), SKU: fmt.Sprintf("SKU-%d-%d", i, j)})
+				_, _ = repo.FindByID(ctx, id)
+				_, _, _ = repo.ListItems(ctx, 1, 10)
+				_, _ = repo.FindBySKUs(ctx, []string{"SKU-0-0"})
+				repo.AddActivity(models.ActivityEntry{ID: id.String()})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	_, total, err := repo.ListItems(ctx, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 400, total)
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/tenant"
+
+	"github.com/google/uuid"
+)
+
+// Item relation types
+const (
+	RelationSubstitute = "substitute"
+	RelationAccessory  = "accessory"
+)
+
+// RelationRepository reads the links between items (written by the Listener Service)
+type RelationRepository interface {
+	// FindRelatedItems returns the items linked to an item, optionally only those of one
+	// relation, with their current stock: substitutes first, then the ones with the most
+	// stock available. An item without links has none; a missing item is not checked.
+	FindRelatedItems(ctx context.Context, itemID uuid.UUID, relation string) ([]models.RelatedItem, error)
+}
+
+// FindRelatedItems returns the items of the ctx tenant linked to an item
+func (r *SQLiteReadRepository) FindRelatedItems(ctx context.Context, itemID uuid.UUID, relation string) ([]models.RelatedItem, error) {
+	query := `
+		SELECT r.relation_type, i.id, i.sku, i.name, i.quantity, i.reserved, i.available, r.created_at
+		FROM item_relations r
+		JOIN inventory_items i ON i.id = r.related_item_id AND i.deleted_at IS NULL AND i.tenant_id = ?
+		WHERE r.item_id = ?
+	`
+	args := []interface{}{tenant.FromContext(ctx), itemID.String()}
+	if relation != "" {
+		query += ` AND r.relation_type = ?`
+		args = append(args, relation)
+	}
+	query += ` ORDER BY r.relation_type DESC, i.available DESC, i.sku`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find related items: %w", err)
+	}
+	defer rows.Close()
+
+	related := make([]models.RelatedItem, 0)
+	for rows.Next() {
+		var item models.RelatedItem
+		var linkedAtStr string
+		if err := rows.Scan(&item.Relation, &item.ID, &item.SKU, &item.Name,
+			&item.Quantity, &item.Reserved, &item.Available, &linkedAtStr); err != nil {
+			return nil, fmt.Errorf("failed to scan related item: %w", err)
+		}
+		item.LinkedAt, _ = time.Parse(time.RFC3339, linkedAtStr)
+		related = append(related, item)
+	}
+	return related, rows.Err()
+}
+
+// itemRelation is a link kept by InMemoryReadRepository
+type itemRelation struct {
+	itemID, relatedItemID uuid.UUID
+	relation              string
+	linkedAt              time.Time
+}
+
+// SaveItemRelation links relatedItemID to itemID
+func (r *InMemoryReadRepository) SaveItemRelation(itemID, relatedItemID uuid.UUID, relation string, linkedAt time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.relations {
+		if existing.itemID == itemID && existing.relatedItemID == relatedItemID && existing.relation == relation {
+			return
+		}
+	}
+	r.relations = append(r.relations, itemRelation{itemID: itemID, relatedItemID: relatedItemID, relation: relation, linkedAt: linkedAt})
+}
+
+// FindRelatedItems returns the items linked to an item
+func (r *InMemoryReadRepository) FindRelatedItems(ctx context.Context, itemID uuid.UUID, relation string) ([]models.RelatedItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	related := make([]models.RelatedItem, 0)
+	for _, link := range r.relations {
+		if link.itemID != itemID || (relation != "" && link.relation != relation) {
+			continue
+		}
+		item, ok := r.items[link.relatedItemID]
+		if !ok || item.DeletedAt != nil || !inTenant(ctx, item) {
+			continue
+		}
+		related = append(related, models.RelatedItem{
+			Relation:  link.relation,
+			ID:        item.ID,
+			SKU:       item.SKU,
+			Name:      item.Name,
+			Quantity:  item.Quantity,
+			Reserved:  item.Reserved,
+			Available: item.Available,
+			LinkedAt:  link.linkedAt,
+		})
+	}
+	sort.Slice(related, func(a, b int) bool {
+		if related[a].Relation != related[b].Relation {
+			return related[a].Relation > related[b].Relation
+		}
+		if related[a].Available != related[b].Available {
+			return related[a].Available > related[b].Available
+		}
+		return related[a].SKU < related[b].SKU
+	})
+	return related, nil
+}
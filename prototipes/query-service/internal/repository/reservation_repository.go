@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/tenant"
+
+	"github.com/google/uuid"
+)
+
+// ReservationStatuses are the statuses a store reservation can have
+var ReservationStatuses = []string{"active", "released", "expired", "fulfilled"}
+
+// ReservationRepository reads store reservations (written by the Listener Service)
+type ReservationRepository interface {
+	// ListReservationsByStore lists reservations of a store, newest first.
+	// An empty status returns reservations in any status.
+	ListReservationsByStore(ctx context.Context, storeID uuid.UUID, status string, page, pageSize int) ([]models.StoreReservation, int, error)
+	// ListReservationsByItem lists reservations of an item across all stores, newest first
+	ListReservationsByItem(ctx context.Context, itemID uuid.UUID, status string, page, pageSize int) ([]models.StoreReservation, int, error)
+}
+
+// ListReservationsByStore lists reservations of a store of the ctx tenant
+func (r *SQLiteReadRepository) ListReservationsByStore(ctx context.Context, storeID uuid.UUID, status string, page, pageSize int) ([]models.StoreReservation, int, error) {
+	var exists int
+	err := r.db.QueryRowContext(ctx, `SELECT 1 FROM stores WHERE id = ? AND tenant_id = ?`,
+		storeID.String(), tenant.FromContext(ctx)).Scan(&exists)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, 0, ErrStoreNotFound
+		}
+		return nil, 0, fmt.Errorf("failed to find store: %w", err)
+	}
+
+	return r.listReservations(ctx, "store_id", storeID.String(), status, page, pageSize)
+}
+
+// ListReservationsByItem lists reservations of an item of the ctx tenant
+func (r *SQLiteReadRepository) ListReservationsByItem(ctx context.Context, itemID uuid.UUID, status string, page, pageSize int) ([]models.StoreReservation, int, error) {
+	var exists int
+	err := r.db.QueryRowContext(ctx, `SELECT 1 FROM inventory_items WHERE id = ? AND tenant_id = ?`,
+		itemID.String(), tenant.FromContext(ctx)).Scan(&exists)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, 0, ErrItemNotFound
+		}
+		return nil, 0, fmt.Errorf("failed to find item: %w", err)
+	}
+
+	return r.listReservations(ctx, "item_id", itemID.String(), status, page, pageSize)
+}
+
+// listReservations lists reservations filtered by column (store_id or item_id) and optional status
+func (r *SQLiteReadRepository) listReservations(ctx context.Context, column, id, status string, page, pageSize int) ([]models.StoreReservation, int, error) {
+	where := column + ` = ?`
+	args := []interface{}{id}
+	if status != "" {
+		where += ` AND status = ?`
+		args = append(args, status)
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM store_reservations WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count reservations: %w", err)
+	}
+
+	query := `
+		SELECT id, store_id, item_id, quantity, status, reserved_at, released_at, expires_at, created_at, updated_at
+		FROM store_reservations
+		WHERE ` + where + `
+		ORDER BY reserved_at DESC, created_at DESC
+		LIMIT ? OFFSET ?
+	`
+	args = append(args, pageSize, (page-1)*pageSize)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list reservations: %w", err)
+	}
+	defer rows.Close()
+
+	reservations := make([]models.StoreReservation, 0)
+	for rows.Next() {
+		var res models.StoreReservation
+		var reservedAtStr, createdAtStr, updatedAtStr string
+		var releasedAtStr, expiresAtStr sql.NullString
+
+		if err := rows.Scan(
+			&res.ID, &res.StoreID, &res.ItemID, &res.Quantity, &res.Status,
+			&reservedAtStr, &releasedAtStr, &expiresAtStr,
+			&createdAtStr, &updatedAtStr,
+		); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan reservation: %w", err)
+		}
+
+		res.ReservedAt, _ = time.Parse(time.RFC3339, reservedAtStr)
+		res.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
+		res.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAtStr)
+		res.ReleasedAt = parseOptionalTime(releasedAtStr)
+		res.ExpiresAt = parseOptionalTime(expiresAtStr)
+
+		reservations = append(reservations, res)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating reservations: %w", err)
+	}
+
+	return reservations, total, nil
+}
+
+// parseOptionalTime parses a nullable RFC3339 column; empty strings are treated as NULL
+func parseOptionalTime(value sql.NullString) *time.Time {
+	if !value.Valid || value.String == "" {
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value.String)
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
+
+// SaveStore registers a store of the default tenant, so its (possibly empty)
+// reservation list can be read
+func (r *InMemoryReadRepository) SaveStore(storeID uuid.UUID) {
+	r.SaveTenantStore(storeID, tenant.Default)
+}
+
+// SaveTenantStore registers a store of tenantID
+func (r *InMemoryReadRepository) SaveTenantStore(storeID uuid.UUID, tenantID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stores == nil {
+		r.stores = make(map[uuid.UUID]string)
+	}
+	r.stores[storeID] = tenantID
+}
+
+// hasStore reports whether storeID is a registered store of the ctx tenant. Callers hold r.mu.
+func (r *InMemoryReadRepository) hasStore(ctx context.Context, storeID uuid.UUID) bool {
+	tenantID, exists := r.stores[storeID]
+	return exists && tenantID == tenant.FromContext(ctx)
+}
+
+// SaveReservation inserts or replaces a reservation (matched by id)
+func (r *InMemoryReadRepository) SaveReservation(res models.StoreReservation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.reservations {
+		if r.reservations[i].ID == res.ID {
+			r.reservations[i] = res
+			return
+		}
+	}
+	r.reservations = append(r.reservations, res)
+}
+
+// ListReservationsByStore lists reservations of a registered store
+func (r *InMemoryReadRepository) ListReservationsByStore(ctx context.Context, storeID uuid.UUID, status string, page, pageSize int) ([]models.StoreReservation, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if !r.hasStore(ctx, storeID) {
+		return nil, 0, ErrStoreNotFound
+	}
+	return r.listReservations(func(res *models.StoreReservation) bool { return res.StoreID == storeID.String() }, status, page, pageSize)
+}
+
+// ListReservationsByItem lists reservations of an existing item
+func (r *InMemoryReadRepository) ListReservationsByItem(ctx context.Context, itemID uuid.UUID, status string, page, pageSize int) ([]models.StoreReservation, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if item, exists := r.items[itemID]; !exists || !inTenant(ctx, item) {
+		return nil, 0, ErrItemNotFound
+	}
+	return r.listReservations(func(res *models.StoreReservation) bool { return res.ItemID == itemID.String() }, status, page, pageSize)
+}
+
+// listReservations filters and pages reservations in the SQLite order. Callers hold r.mu.
+func (r *InMemoryReadRepository) listReservations(match func(*models.StoreReservation) bool, status string, page, pageSize int) ([]models.StoreReservation, int, error) {
+	reservations := make([]models.StoreReservation, 0)
+	for i := range r.reservations {
+		res := &r.reservations[i]
+		if match(res) && (status == "" || res.Status == status) {
+			reservations = append(reservations, *res)
+		}
+	}
+
+	sort.SliceStable(reservations, func(i, j int) bool {
+		a, b := reservations[i], reservations[j]
+		if !a.ReservedAt.Equal(b.ReservedAt) {
+			return a.ReservedAt.After(b.ReservedAt)
+		}
+		return a.CreatedAt.After(b.CreatedAt)
+	})
+
+	start, end := pageBounds(len(reservations), page, pageSize)
+	return reservations[start:end], len(reservations), nil
+}
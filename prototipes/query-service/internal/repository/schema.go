@@ -0,0 +1,13 @@
+package repository
+
+import "context"
+
+// ExpectedSchemaVersion is the read model schema version the queries of this package
+// are written against. It must match the Listener Service's database.SchemaVersion.
+const ExpectedSchemaVersion = 11
+
+// SchemaVersioned is implemented by read models whose schema is created by the
+// Listener Service and versioned in its schema_migrations table
+type SchemaVersioned interface {
+	SchemaVersion(ctx context.Context) (int, error)
+}
@@ -0,0 +1,315 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/tenant"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ShadowReadRepository serves every read from the primary repository and,
+// in the background, replays the same read against a candidate implementation.
+// Results are compared asynchronously and mismatches are logged, so a new read
+// model can be validated with production traffic without affecting responses.
+type ShadowReadRepository struct {
+	primary    ReadRepository
+	candidate  ReadRepository
+	logger     *zap.Logger
+	timeout    time.Duration
+	sampleRate float64 // Fraction of reads (0..1) mirrored to the candidate
+
+	compared   uint64
+	mismatches uint64
+	failures   uint64
+}
+
+// ShadowStats is a snapshot of the shadow comparison counters
+type ShadowStats struct {
+	Compared   uint64 `json:"compared"`
+	Mismatches uint64 `json:"mismatches"`
+	Failures   uint64 `json:"candidate_failures"`
+}
+
+// NewShadowReadRepository wraps primary so that reads are mirrored to candidate.
+// samplePercent (0-100) controls how many reads are mirrored.
+func NewShadowReadRepository(primary, candidate ReadRepository, logger *zap.Logger, timeout time.Duration, samplePercent int) *ShadowReadRepository {
+	if samplePercent < 0 {
+		samplePercent = 0
+	}
+	if samplePercent > 100 {
+		samplePercent = 100
+	}
+	return &ShadowReadRepository{
+		primary:    primary,
+		candidate:  candidate,
+		logger:     logger,
+		timeout:    timeout,
+		sampleRate: float64(samplePercent) / 100,
+	}
+}
+
+// Stats returns the comparison counters
+func (r *ShadowReadRepository) Stats() ShadowStats {
+	return ShadowStats{
+		Compared:   atomic.LoadUint64(&r.compared),
+		Mismatches: atomic.LoadUint64(&r.mismatches),
+		Failures:   atomic.LoadUint64(&r.failures),
+	}
+}
+
+// Ping checks the primary read model; the candidate is not needed to serve reads
+func (r *ShadowReadRepository) Ping(ctx context.Context) error {
+	if pinger, ok := r.primary.(interface{ Ping(context.Context) error }); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// FindByID finds an item by ID
+func (r *ShadowReadRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.InventoryItem, error) {
+	item, err := r.primary.FindByID(ctx, id)
+	r.shadow(ctx, "FindByID", id.String(), func(sctx context.Context) string {
+		candidate, cerr := r.candidate.FindByID(sctx, id)
+		return diffItemResults(item, err, candidate, cerr)
+	})
+	return item, err
+}
+
+// FindBySKU finds an item by SKU
+func (r *ShadowReadRepository) FindBySKU(ctx context.Context, sku string) (*models.InventoryItem, error) {
+	item, err := r.primary.FindBySKU(ctx, sku)
+	r.shadow(ctx, "FindBySKU", sku, func(sctx context.Context) string {
+		candidate, cerr := r.candidate.FindBySKU(sctx, sku)
+		return diffItemResults(item, err, candidate, cerr)
+	})
+	return item, err
+}
+
+// FindBySKUs finds all items matching the given SKUs
+func (r *ShadowReadRepository) FindBySKUs(ctx context.Context, skus []string) ([]models.InventoryItem, error) {
+	items, err := r.primary.FindBySKUs(ctx, skus)
+	r.shadow(ctx, "FindBySKUs", fmt.Sprintf("%d skus", len(skus)), func(sctx context.Context) string {
+		candidate, cerr := r.candidate.FindBySKUs(sctx, skus)
+		if diff := diffErrors(err, cerr); diff != "" {
+			return diff
+		}
+		if err != nil {
+			return ""
+		}
+		if len(items) != len(candidate) {
+			return fmt.Sprintf("found: primary=%d candidate=%d", len(items), len(candidate))
+		}
+		// Neither side guarantees an order, so match by SKU
+		bySKU := make(map[string]*models.InventoryItem, len(candidate))
+		for i := range candidate {
+			bySKU[candidate[i].SKU] = &candidate[i]
+		}
+		for i := range items {
+			other, ok := bySKU[items[i].SKU]
+			if !ok {
+				return fmt.Sprintf("sku %s: missing in candidate", items[i].SKU)
+			}
+			if diff := diffItems(&items[i], other); diff != "" {
+				return fmt.Sprintf("sku %s: %s", items[i].SKU, diff)
+			}
+		}
+		return ""
+	})
+	return items, err
+}
+
+// FindByIDs finds all items with the given IDs
+func (r *ShadowReadRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]models.InventoryItem, error) {
+	items, err := r.primary.FindByIDs(ctx, ids)
+	r.shadow(ctx, "FindByIDs", fmt.Sprintf("%d ids", len(ids)), func(sctx context.Context) string {
+		candidate, cerr := r.candidate.FindByIDs(sctx, ids)
+		if diff := diffErrors(err, cerr); diff != "" {
+			return diff
+		}
+		if err != nil {
+			return ""
+		}
+		if len(items) != len(candidate) {
+			return fmt.Sprintf("found: primary=%d candidate=%d", len(items), len(candidate))
+		}
+		// Neither side guarantees an order, so match by ID
+		byID := make(map[string]*models.InventoryItem, len(candidate))
+		for i := range candidate {
+			byID[candidate[i].ID] = &candidate[i]
+		}
+		for i := range items {
+			other, ok := byID[items[i].ID]
+			if !ok {
+				return fmt.Sprintf("id %s: missing in candidate", items[i].ID)
+			}
+			if diff := diffItems(&items[i], other); diff != "" {
+				return fmt.Sprintf("id %s: %s", items[i].ID, diff)
+			}
+		}
+		return ""
+	})
+	return items, err
+}
+
+// ListItems lists items with pagination
+func (r *ShadowReadRepository) ListItems(ctx context.Context, page, pageSize int) ([]models.InventoryItem, int, error) {
+	items, total, err := r.primary.ListItems(ctx, page, pageSize)
+	r.shadow(ctx, "ListItems", fmt.Sprintf("page=%d,page_size=%d", page, pageSize), func(sctx context.Context) string {
+		candidate, ctotal, cerr := r.candidate.ListItems(sctx, page, pageSize)
+		if diff := diffErrors(err, cerr); diff != "" {
+			return diff
+		}
+		if err != nil {
+			return ""
+		}
+		if total != ctotal {
+			return fmt.Sprintf("total: primary=%d candidate=%d", total, ctotal)
+		}
+		if len(items) != len(candidate) {
+			return fmt.Sprintf("page length: primary=%d candidate=%d", len(items), len(candidate))
+		}
+		for i := range items {
+			if diff := diffItems(&items[i], &candidate[i]); diff != "" {
+				return fmt.Sprintf("item[%d]: %s", i, diff)
+			}
+		}
+		return ""
+	})
+	return items, total, err
+}
+
+// GetStockStatus gets stock status for an item
+func (r *ShadowReadRepository) GetStockStatus(ctx context.Context, id uuid.UUID) (*models.StockStatus, error) {
+	status, err := r.primary.GetStockStatus(ctx, id)
+	r.shadow(ctx, "GetStockStatus", id.String(), func(sctx context.Context) string {
+		candidate, cerr := r.candidate.GetStockStatus(sctx, id)
+		if diff := diffErrors(err, cerr); diff != "" {
+			return diff
+		}
+		if err != nil {
+			return ""
+		}
+		switch {
+		case status.ID != candidate.ID:
+			return fmt.Sprintf("id: primary=%s candidate=%s", status.ID, candidate.ID)
+		case status.Quantity != candidate.Quantity, status.Reserved != candidate.Reserved, status.Available != candidate.Available:
+			return fmt.Sprintf("stock: primary=%d/%d/%d candidate=%d/%d/%d",
+				status.Quantity, status.Reserved, status.Available,
+				candidate.Quantity, candidate.Reserved, candidate.Available)
+		case !sameInstant(status.UpdatedAt, candidate.UpdatedAt):
+			return fmt.Sprintf("updated_at: primary=%s candidate=%s", status.UpdatedAt, candidate.UpdatedAt)
+		}
+		return ""
+	})
+	return status, err
+}
+
+// ListImages reads the images from the primary only; the candidate is compared on the
+// items themselves
+func (r *ShadowReadRepository) ListImages(ctx context.Context, itemIDs []string) (map[string][]models.ItemImage, error) {
+	if images, ok := r.primary.(ImageRepository); ok {
+		return images.ListImages(ctx, itemIDs)
+	}
+	return map[string][]models.ItemImage{}, nil
+}
+
+// shadow runs compare in the background with its own deadline, detached from
+// the request context so that the candidate never delays the response. Only the
+// tenant of the request is carried over.
+func (r *ShadowReadRepository) shadow(requestCtx context.Context, operation, key string, compare func(ctx context.Context) string) {
+	if r.sampleRate <= 0 || (r.sampleRate < 1 && rand.Float64() >= r.sampleRate) {
+		return
+	}
+
+	tenantID := tenant.FromContext(requestCtx)
+	go func() {
+		ctx, cancel := context.WithTimeout(tenant.WithTenant(context.Background(), tenantID), r.timeout)
+		defer cancel()
+
+		diff := compare(ctx)
+		atomic.AddUint64(&r.compared, 1)
+		if diff == "" {
+			return
+		}
+
+		if ctx.Err() != nil {
+			atomic.AddUint64(&r.failures, 1)
+			r.logger.Warn("Shadow read timed out",
+				zap.String("operation", operation),
+				zap.String("key", key),
+				zap.Duration("timeout", r.timeout),
+			)
+			return
+		}
+
+		atomic.AddUint64(&r.mismatches, 1)
+		r.logger.Warn("Shadow read mismatch",
+			zap.String("operation", operation),
+			zap.String("key", key),
+			zap.String("diff", diff),
+		)
+	}()
+}
+
+func diffItemResults(primary *models.InventoryItem, perr error, candidate *models.InventoryItem, cerr error) string {
+	if diff := diffErrors(perr, cerr); diff != "" {
+		return diff
+	}
+	if perr != nil {
+		return ""
+	}
+	return diffItems(primary, candidate)
+}
+
+// diffErrors compares the outcome of both reads. Not-found on both sides is a match.
+func diffErrors(perr, cerr error) string {
+	switch {
+	case perr == nil && cerr == nil:
+		return ""
+	case perr == ErrItemNotFound && cerr == ErrItemNotFound:
+		return ""
+	case perr == nil:
+		return fmt.Sprintf("error: primary=<nil> candidate=%v", cerr)
+	case cerr == nil:
+		return fmt.Sprintf("error: primary=%v candidate=<nil>", perr)
+	case perr == ErrItemNotFound || cerr == ErrItemNotFound:
+		return fmt.Sprintf("error: primary=%v candidate=%v", perr, cerr)
+	}
+	// Both failed with infrastructure errors, nothing meaningful to compare
+	return ""
+}
+
+func diffItems(primary, candidate *models.InventoryItem) string {
+	switch {
+	case primary.ID != candidate.ID:
+		return fmt.Sprintf("id: primary=%s candidate=%s", primary.ID, candidate.ID)
+	case primary.SKU != candidate.SKU:
+		return fmt.Sprintf("sku: primary=%s candidate=%s", primary.SKU, candidate.SKU)
+	case primary.Name != candidate.Name:
+		return fmt.Sprintf("name: primary=%q candidate=%q", primary.Name, candidate.Name)
+	case primary.Description != candidate.Description:
+		return fmt.Sprintf("description: primary=%q candidate=%q", primary.Description, candidate.Description)
+	case primary.Quantity != candidate.Quantity, primary.Reserved != candidate.Reserved, primary.Available != candidate.Available:
+		return fmt.Sprintf("stock: primary=%d/%d/%d candidate=%d/%d/%d",
+			primary.Quantity, primary.Reserved, primary.Available,
+			candidate.Quantity, candidate.Reserved, candidate.Available)
+	case !sameInstant(primary.CreatedAt, candidate.CreatedAt):
+		return fmt.Sprintf("created_at: primary=%s candidate=%s", primary.CreatedAt, candidate.CreatedAt)
+	case !sameInstant(primary.UpdatedAt, candidate.UpdatedAt):
+		return fmt.Sprintf("updated_at: primary=%s candidate=%s", primary.UpdatedAt, candidate.UpdatedAt)
+	}
+	return ""
+}
+
+// sameInstant compares timestamps at second precision, which is what the
+// SQLite read model stores (RFC3339)
+func sameInstant(a, b time.Time) bool {
+	return a.Truncate(time.Second).Equal(b.Truncate(time.Second))
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"query-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newRepoWithItem(item models.InventoryItem) *InMemoryReadRepository {
+	id := uuid.MustParse(item.ID)
+	return &InMemoryReadRepository{items: map[uuid.UUID]*models.InventoryItem{id: &item}}
+}
+
+func TestShadowReadRepository_ServesPrimaryAndDetectsMismatch(t *testing.T) {
+	now := time.Now()
+	id := uuid.New()
+	primaryItem := models.InventoryItem{ID: id.String(), SKU: "SKU-1", Name: "Item", Quantity: 10, Available: 10, CreatedAt: now, UpdatedAt: now}
+	candidateItem := primaryItem
+	candidateItem.Quantity = 9
+
+	shadow := NewShadowReadRepository(newRepoWithItem(primaryItem), newRepoWithItem(candidateItem), zap.NewNop(), time.Second, 100)
+
+	item, err := shadow.FindByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, 10, item.Quantity)
+
+	assert.Eventually(t, func() bool { return shadow.Stats().Compared == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, uint64(1), shadow.Stats().Mismatches)
+}
+
+func TestShadowReadRepository_MatchingResults(t *testing.T) {
+	now := time.Now()
+	id := uuid.New()
+	item := models.InventoryItem{ID: id.String(), SKU: "SKU-1", Name: "Item", Quantity: 10, Available: 10, CreatedAt: now, UpdatedAt: now}
+
+	shadow := NewShadowReadRepository(newRepoWithItem(item), newRepoWithItem(item), zap.NewNop(), time.Second, 100)
+
+	_, err := shadow.FindBySKU(context.Background(), "SKU-1")
+	require.NoError(t, err)
+	_, err = shadow.FindByID(context.Background(), uuid.New())
+	assert.Equal(t, ErrItemNotFound, err)
+
+	assert.Eventually(t, func() bool { return shadow.Stats().Compared == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, uint64(0), shadow.Stats().Mismatches)
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"query-service/internal/models"
+	"query-service/internal/tenant"
 
 	"github.com/google/uuid"
 	_ "github.com/mattn/go-sqlite3"
@@ -14,8 +16,11 @@ import (
 
 // SQLiteReadRepository reads from SQLite database (Read Model)
 // This database is updated by the Listener Service
+//
+// With DB_DRIVER=postgres the Listener Service writes the same schema to PostgreSQL,
+// and NewPostgresListenerReadRepository runs these queries there.
 type SQLiteReadRepository struct {
-	db *sql.DB
+	db *sqlDB
 }
 
 // NewSQLiteReadRepository creates a new SQLite read repository
@@ -39,10 +44,63 @@ func NewSQLiteReadRepository(dbPath string) (ReadRepository, error) {
 	}
 
 	return &SQLiteReadRepository{
-		db: db,
+		db: &sqlDB{DB: db, dialect: DriverSQLite},
 	}, nil
 }
 
+// NewPostgresListenerReadRepository reads the read model the Listener Service writes to
+// PostgreSQL (DB_DRIVER=postgres). Unlike PostgresReadRepository, the candidate of
+// shadow reads, it serves every query of the SQLite repository.
+func NewPostgresListenerReadRepository(dsn string) (ReadRepository, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("POSTGRES_DSN is required with DB_DRIVER=postgres")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(time.Hour)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return &SQLiteReadRepository{
+		db: &sqlDB{DB: db, dialect: DriverPostgres},
+	}, nil
+}
+
+// SchemaVersion returns the newest read model schema version the Listener Service
+// recorded in schema_migrations, or 0 if the database predates schema versioning
+func (r *SQLiteReadRepository) SchemaVersion(ctx context.Context) (int, error) {
+	tablesQuery := `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`
+	if r.db.dialect == DriverPostgres {
+		tablesQuery = `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = 'schema_migrations'`
+	}
+	var tables int
+	if err := r.db.QueryRowContext(ctx, tablesQuery).Scan(&tables); err != nil {
+		return 0, fmt.Errorf("failed to inspect schema: %w", err)
+	}
+	if tables == 0 {
+		return 0, nil
+	}
+
+	var version int
+	if err := r.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// Ping checks that the read model database can be reached (readiness probe)
+func (r *SQLiteReadRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
 // Close closes the database connection
 func (r *SQLiteReadRepository) Close() error {
 	if r.db != nil {
@@ -51,19 +109,30 @@ func (r *SQLiteReadRepository) Close() error {
 	return nil
 }
 
-// FindByID finds an item by ID
+// FindByID finds an item of the ctx tenant by ID; soft-deleted items are not found
 func (r *SQLiteReadRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.InventoryItem, error) {
+	return r.findItem(ctx, `AND id = ? AND deleted_at IS NULL`, id.String())
+}
+
+// FindByIDIncludingDeleted finds an item of the ctx tenant by ID, soft-deleted or not
+func (r *SQLiteReadRepository) FindByIDIncludingDeleted(ctx context.Context, id uuid.UUID) (*models.InventoryItem, error) {
+	return r.findItem(ctx, `AND id = ?`, id.String())
+}
+
+// findItem returns the item of the ctx tenant matching condition
+func (r *SQLiteReadRepository) findItem(ctx context.Context, condition string, arg interface{}) (*models.InventoryItem, error) {
 	query := `
-		SELECT id, sku, name, description, quantity, reserved, available, created_at, updated_at
+		SELECT id, tenant_id, sku, name, description, quantity, reserved, available, created_at, updated_at, deleted_at
 		FROM inventory_items
-		WHERE id = ?
-	`
+		WHERE tenant_id = ? ` + condition
 
 	var item models.InventoryItem
 	var createdAtStr, updatedAtStr string
+	var deletedAt sql.NullString
 
-	err := r.db.QueryRowContext(ctx, query, id.String()).Scan(
+	err := r.db.QueryRowContext(ctx, query, tenant.FromContext(ctx), arg).Scan(
 		&item.ID,
+		&item.TenantID,
 		&item.SKU,
 		&item.Name,
 		&item.Description,
@@ -72,6 +141,7 @@ func (r *SQLiteReadRepository) FindByID(ctx context.Context, id uuid.UUID) (*mod
 		&item.Available,
 		&createdAtStr,
 		&updatedAtStr,
+		&deletedAt,
 	)
 
 	if err != nil {
@@ -88,23 +158,41 @@ func (r *SQLiteReadRepository) FindByID(ctx context.Context, id uuid.UUID) (*mod
 	if updatedAt, err := time.Parse(time.RFC3339, updatedAtStr); err == nil {
 		item.UpdatedAt = updatedAt
 	}
+	item.DeletedAt = parseDeletedAt(deletedAt)
 
 	return &item, nil
 }
 
-// FindBySKU finds an item by SKU
+// tenantItemIDs restricts a query on a table keyed by item_id to the items of a tenant,
+// given as its argument (tenant.FromContext)
+const tenantItemIDs = `item_id IN (SELECT id FROM inventory_items WHERE tenant_id = ?)`
+
+// parseDeletedAt returns the soft-delete time of a row, nil for a live item
+func parseDeletedAt(deletedAt sql.NullString) *time.Time {
+	if !deletedAt.Valid {
+		return nil
+	}
+	at, err := time.Parse(time.RFC3339, deletedAt.String)
+	if err != nil {
+		return nil
+	}
+	return &at
+}
+
+// FindBySKU finds an item of the ctx tenant by SKU
 func (r *SQLiteReadRepository) FindBySKU(ctx context.Context, sku string) (*models.InventoryItem, error) {
 	query := `
-		SELECT id, sku, name, description, quantity, reserved, available, created_at, updated_at
+		SELECT id, tenant_id, sku, name, description, quantity, reserved, available, created_at, updated_at
 		FROM inventory_items
-		WHERE sku = ?
+		WHERE tenant_id = ? AND sku = ? AND deleted_at IS NULL
 	`
 
 	var item models.InventoryItem
 	var createdAtStr, updatedAtStr string
 
-	err := r.db.QueryRowContext(ctx, query, sku).Scan(
+	err := r.db.QueryRowContext(ctx, query, tenant.FromContext(ctx), sku).Scan(
 		&item.ID,
+		&item.TenantID,
 		&item.SKU,
 		&item.Name,
 		&item.Description,
@@ -133,30 +221,153 @@ func (r *SQLiteReadRepository) FindBySKU(ctx context.Context, sku string) (*mode
 	return &item, nil
 }
 
-// ListItems lists items with pagination
+// FindBySKUs finds all items matching the given SKUs with a single IN query
+func (r *SQLiteReadRepository) FindBySKUs(ctx context.Context, skus []string) ([]models.InventoryItem, error) {
+	return r.findItemsIn(ctx, "sku", skus)
+}
+
+// FindByIDs finds all items with the given IDs with a single IN query
+func (r *SQLiteReadRepository) FindByIDs(ctx context.Context, ids []uuid.UUID) ([]models.InventoryItem, error) {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = id.String()
+	}
+	return r.findItemsIn(ctx, "id", keys)
+}
+
+// findItemsIn returns the live items of the ctx tenant whose column (id or sku) is one of keys
+func (r *SQLiteReadRepository) findItemsIn(ctx context.Context, column string, keys []string) ([]models.InventoryItem, error) {
+	items := make([]models.InventoryItem, 0, len(keys))
+	if len(keys) == 0 {
+		return items, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(keys)), ",")
+	args := make([]interface{}, 0, len(keys)+1)
+	args = append(args, tenant.FromContext(ctx))
+	for _, key := range keys {
+		args = append(args, key)
+	}
+
+	query := `
+		SELECT id, tenant_id, sku, name, description, quantity, reserved, available, created_at, updated_at
+		FROM inventory_items
+		WHERE tenant_id = ? AND ` + column + ` IN (` + placeholders + `) AND deleted_at IS NULL
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find items by %s: %w", column, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.InventoryItem
+		var createdAtStr, updatedAtStr string
+
+		if err := rows.Scan(
+			&item.ID,
+			&item.TenantID,
+			&item.SKU,
+			&item.Name,
+			&item.Description,
+			&item.Quantity,
+			&item.Reserved,
+			&item.Available,
+			&createdAtStr,
+			&updatedAtStr,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan item: %w", err)
+		}
+
+		// Parse timestamps
+		if createdAt, err := time.Parse(time.RFC3339, createdAtStr); err == nil {
+			item.CreatedAt = createdAt
+		}
+		if updatedAt, err := time.Parse(time.RFC3339, updatedAtStr); err == nil {
+			item.UpdatedAt = updatedAt
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating items: %w", err)
+	}
+
+	return items, nil
+}
+
+// ListItems lists the items of the ctx tenant with pagination, leaving out soft-deleted items
 func (r *SQLiteReadRepository) ListItems(ctx context.Context, page, pageSize int) ([]models.InventoryItem, int, error) {
-	// Get total count
-	var total int
-	countQuery := `SELECT COUNT(*) FROM inventory_items`
-	err := r.db.QueryRowContext(ctx, countQuery).Scan(&total)
+	return r.listItems(ctx, page, pageSize, `AND deleted_at IS NULL`)
+}
+
+// ListItemsIncludingDeleted lists the live and soft-deleted items of the ctx tenant with pagination
+func (r *SQLiteReadRepository) ListItemsIncludingDeleted(ctx context.Context, page, pageSize int) ([]models.InventoryItem, int, error) {
+	return r.listItems(ctx, page, pageSize, ``)
+}
+
+func (r *SQLiteReadRepository) listItems(ctx context.Context, page, pageSize int, condition string) ([]models.InventoryItem, int, error) {
+	total, err := r.countItems(ctx, condition)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count items: %w", err)
+		return nil, 0, err
 	}
 
-	// Calculate offset
 	offset := (page - 1) * pageSize
+	items, err := r.queryItems(ctx, condition, `LIMIT ? OFFSET ?`, pageSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
 
-	// Get items with pagination
+// ListItemsAfter lists up to limit live items after the cursor, in the ListItems order.
+// The keyset condition follows the (created_at, id) order, so no row is skipped over.
+func (r *SQLiteReadRepository) ListItemsAfter(ctx context.Context, after *ItemCursor, limit int) ([]models.InventoryItem, int, error) {
+	condition := `AND deleted_at IS NULL`
+	total, err := r.countItems(ctx, condition)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var args []interface{}
+	if after != nil {
+		// created_at is stored as RFC3339 text in UTC, which sorts like the time
+		createdAt := after.CreatedAt.UTC().Format(time.RFC3339)
+		condition += ` AND (created_at < ? OR (created_at = ? AND id > ?))`
+		args = append(args, createdAt, createdAt, after.ID)
+	}
+	items, err := r.queryItems(ctx, condition, `LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// countItems counts the items of the ctx tenant matching condition
+func (r *SQLiteReadRepository) countItems(ctx context.Context, condition string) (int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM inventory_items WHERE tenant_id = ? `+condition,
+		tenant.FromContext(ctx)).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count items: %w", err)
+	}
+	return total, nil
+}
+
+// queryItems returns the items of the ctx tenant matching condition, newest first (ties
+// broken by id), limited by limit (a LIMIT clause whose placeholders come last in args)
+func (r *SQLiteReadRepository) queryItems(ctx context.Context, condition, limit string, args ...interface{}) ([]models.InventoryItem, error) {
 	query := `
-		SELECT id, sku, name, description, quantity, reserved, available, created_at, updated_at
+		SELECT id, tenant_id, sku, name, description, quantity, reserved, available, created_at, updated_at, deleted_at
 		FROM inventory_items
-		ORDER BY created_at DESC
-		LIMIT ? OFFSET ?
-	`
+		WHERE tenant_id = ? ` + condition + `
+		ORDER BY created_at DESC, id ASC
+	` + limit
 
-	rows, err := r.db.QueryContext(ctx, query, pageSize, offset)
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{tenant.FromContext(ctx)}, args...)...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list items: %w", err)
+		return nil, fmt.Errorf("failed to list items: %w", err)
 	}
 	defer rows.Close()
 
@@ -164,9 +375,11 @@ func (r *SQLiteReadRepository) ListItems(ctx context.Context, page, pageSize int
 	for rows.Next() {
 		var item models.InventoryItem
 		var createdAtStr, updatedAtStr string
+		var deletedAt sql.NullString
 
 		err := rows.Scan(
 			&item.ID,
+			&item.TenantID,
 			&item.SKU,
 			&item.Name,
 			&item.Description,
@@ -175,9 +388,10 @@ func (r *SQLiteReadRepository) ListItems(ctx context.Context, page, pageSize int
 			&item.Available,
 			&createdAtStr,
 			&updatedAtStr,
+			&deletedAt,
 		)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan item: %w", err)
+			return nil, fmt.Errorf("failed to scan item: %w", err)
 		}
 
 		// Parse timestamps
@@ -187,29 +401,30 @@ func (r *SQLiteReadRepository) ListItems(ctx context.Context, page, pageSize int
 		if updatedAt, err := time.Parse(time.RFC3339, updatedAtStr); err == nil {
 			item.UpdatedAt = updatedAt
 		}
+		item.DeletedAt = parseDeletedAt(deletedAt)
 
 		items = append(items, item)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("error iterating items: %w", err)
+		return nil, fmt.Errorf("error iterating items: %w", err)
 	}
 
-	return items, total, nil
+	return items, nil
 }
 
-// GetStockStatus gets stock status for an item
+// GetStockStatus gets stock status for an item of the ctx tenant
 func (r *SQLiteReadRepository) GetStockStatus(ctx context.Context, id uuid.UUID) (*models.StockStatus, error) {
 	query := `
 		SELECT id, sku, quantity, reserved, available, updated_at
 		FROM inventory_items
-		WHERE id = ?
+		WHERE tenant_id = ? AND id = ? AND deleted_at IS NULL
 	`
 
 	var status models.StockStatus
 	var updatedAtStr string
 
-	err := r.db.QueryRowContext(ctx, query, id.String()).Scan(
+	err := r.db.QueryRowContext(ctx, query, tenant.FromContext(ctx), id.String()).Scan(
 		&status.ID,
 		&status.SKU,
 		&status.Quantity,
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/tenant"
+
+	"github.com/google/uuid"
+)
+
+// StatsRepository computes the inventory summary of the dashboard
+type StatsRepository interface {
+	// InventoryStats aggregates the items that are not soft-deleted. GeneratedAt and
+	// ActivityDays are left to the caller.
+	InventoryStats(ctx context.Context, query models.StatsQuery) (*models.InventoryStats, error)
+}
+
+// InventoryStats computes the totals of the ctx tenant with SQL aggregates and ranks its
+// items by the stock movements that reserved units of them
+func (r *SQLiteReadRepository) InventoryStats(ctx context.Context, query models.StatsQuery) (*models.InventoryStats, error) {
+	tenantID := tenant.FromContext(ctx)
+	stats := &models.InventoryStats{LowStockThreshold: query.LowStockThreshold}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*),
+		       COALESCE(SUM(quantity), 0),
+		       COALESCE(SUM(reserved), 0),
+		       COALESCE(SUM(available), 0),
+		       COALESCE(SUM(CASE WHEN available <= ? THEN 1 ELSE 0 END), 0)
+		FROM inventory_items
+		WHERE tenant_id = ? AND deleted_at IS NULL
+	`, query.LowStockThreshold, tenantID).Scan(
+		&stats.ItemCount, &stats.TotalUnits, &stats.TotalReserved, &stats.TotalAvailable, &stats.LowStockCount,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate items: %w", err)
+	}
+
+	// occurred_at is stored as RFC3339 UTC, so string comparison preserves time order
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT i.id, i.sku, i.name, COUNT(*) AS reservations, SUM(m.reserved_change) AS units, i.reserved
+		FROM stock_movements m
+		JOIN inventory_items i ON i.id = m.item_id
+		WHERE m.reserved_change > 0 AND m.occurred_at >= ? AND i.tenant_id = ? AND i.deleted_at IS NULL
+		GROUP BY i.id, i.sku, i.name, i.reserved
+		ORDER BY reservations DESC, units DESC, i.sku
+		LIMIT ?
+	`, query.Since.UTC().Format(time.RFC3339), tenantID, query.Top)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank reservation activity: %w", err)
+	}
+	defer rows.Close()
+
+	stats.TopReserved = make([]models.ReservationActivity, 0, query.Top)
+	for rows.Next() {
+		var activity models.ReservationActivity
+		if err := rows.Scan(
+			&activity.ID, &activity.SKU, &activity.Name,
+			&activity.Reservations, &activity.UnitsReserved, &activity.Reserved,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan reservation activity: %w", err)
+		}
+		stats.TopReserved = append(stats.TopReserved, activity)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reservation activity: %w", err)
+	}
+
+	return stats, nil
+}
+
+// InventoryStats computes the summary from the in-memory items and movements
+func (r *InMemoryReadRepository) InventoryStats(ctx context.Context, query models.StatsQuery) (*models.InventoryStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := &models.InventoryStats{LowStockThreshold: query.LowStockThreshold}
+	for _, item := range r.items {
+		if item.DeletedAt != nil || !inTenant(ctx, item) {
+			continue
+		}
+		stats.ItemCount++
+		stats.TotalUnits += item.Quantity
+		stats.TotalReserved += item.Reserved
+		stats.TotalAvailable += item.Available
+		if item.Available <= query.LowStockThreshold {
+			stats.LowStockCount++
+		}
+	}
+
+	activity := make(map[string]*models.ReservationActivity)
+	for _, movement := range r.movements {
+		if movement.ReservedChange <= 0 || movement.OccurredAt.Before(query.Since) {
+			continue
+		}
+		entry, ok := activity[movement.ItemID]
+		if !ok {
+			id, err := uuid.Parse(movement.ItemID)
+			if err != nil {
+				continue
+			}
+			item, found := r.items[id]
+			if !found || item.DeletedAt != nil || !inTenant(ctx, item) {
+				continue
+			}
+			entry = &models.ReservationActivity{ID: item.ID, SKU: item.SKU, Name: item.Name, Reserved: item.Reserved}
+			activity[movement.ItemID] = entry
+		}
+		entry.Reservations++
+		entry.UnitsReserved += movement.ReservedChange
+	}
+
+	stats.TopReserved = make([]models.ReservationActivity, 0, len(activity))
+	for _, entry := range activity {
+		stats.TopReserved = append(stats.TopReserved, *entry)
+	}
+	sort.Slice(stats.TopReserved, func(a, b int) bool {
+		x, y := stats.TopReserved[a], stats.TopReserved[b]
+		if x.Reservations != y.Reservations {
+			return x.Reservations > y.Reservations
+		}
+		if x.UnitsReserved != y.UnitsReserved {
+			return x.UnitsReserved > y.UnitsReserved
+		}
+		return x.SKU < y.SKU
+	})
+	if len(stats.TopReserved) > query.Top {
+		stats.TopReserved = stats.TopReserved[:query.Top]
+	}
+	return stats, nil
+}
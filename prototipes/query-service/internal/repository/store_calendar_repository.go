@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/tenant"
+
+	"github.com/google/uuid"
+)
+
+// StoreCalendarRepository reads store calendars (written by the Listener Service)
+type StoreCalendarRepository interface {
+	// GetStoreCalendar returns the calendar of a store: ErrStoreNotFound when the
+	// store does not exist, ErrStoreCalendarNotFound when it has no calendar
+	GetStoreCalendar(ctx context.Context, storeID uuid.UUID) (*models.StoreCalendar, error)
+}
+
+// GetStoreCalendar returns the calendar of a store of the ctx tenant
+func (r *SQLiteReadRepository) GetStoreCalendar(ctx context.Context, storeID uuid.UUID) (*models.StoreCalendar, error) {
+	var exists int
+	err := r.db.QueryRowContext(ctx, `SELECT 1 FROM stores WHERE id = ? AND tenant_id = ?`,
+		storeID.String(), tenant.FromContext(ctx)).Scan(&exists)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrStoreNotFound
+		}
+		return nil, fmt.Errorf("failed to find store: %w", err)
+	}
+
+	calendar := models.StoreCalendar{StoreID: storeID.String()}
+	var hours, holidays, updatedAtStr string
+	err = r.db.QueryRowContext(ctx, `
+		SELECT timezone, hours, holidays, updated_at
+		FROM store_calendars
+		WHERE store_id = ?
+	`, storeID.String()).Scan(&calendar.Timezone, &hours, &holidays, &updatedAtStr)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrStoreCalendarNotFound
+		}
+		return nil, fmt.Errorf("failed to get store calendar: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(hours), &calendar.Hours); err != nil {
+		return nil, fmt.Errorf("invalid opening hours of store %s: %w", storeID, err)
+	}
+	if err := json.Unmarshal([]byte(holidays), &calendar.Holidays); err != nil {
+		return nil, fmt.Errorf("invalid holidays of store %s: %w", storeID, err)
+	}
+	calendar.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAtStr)
+
+	return &calendar, nil
+}
+
+// SaveStoreCalendar registers the store (in the default tenant unless it is registered
+// already) and sets its calendar
+func (r *InMemoryReadRepository) SaveStoreCalendar(calendar models.StoreCalendar) error {
+	storeID, err := uuid.Parse(calendar.StoreID)
+	if err != nil {
+		return fmt.Errorf("invalid store id %q: %w", calendar.StoreID, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.calendars == nil {
+		r.calendars = make(map[uuid.UUID]models.StoreCalendar)
+	}
+	if _, exists := r.stores[storeID]; !exists {
+		r.stores[storeID] = tenant.Default
+	}
+	r.calendars[storeID] = calendar
+	return nil
+}
+
+// GetStoreCalendar returns the calendar of a registered store
+func (r *InMemoryReadRepository) GetStoreCalendar(ctx context.Context, storeID uuid.UUID) (*models.StoreCalendar, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if !r.hasStore(ctx, storeID) {
+		return nil, ErrStoreNotFound
+	}
+	calendar, ok := r.calendars[storeID]
+	if !ok {
+		return nil, ErrStoreCalendarNotFound
+	}
+	return &calendar, nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/tenant"
+
+	"github.com/google/uuid"
+)
+
+// ValuationRepository reads the cost layers used for inventory valuation
+type ValuationRepository interface {
+	// ListCostLayers returns every item with its cost layers (oldest first).
+	// When itemID is not nil only that item is returned.
+	ListCostLayers(ctx context.Context, itemID *uuid.UUID) ([]models.ItemCostLayers, error)
+}
+
+// ListCostLayers returns items of the ctx tenant with their cost layers from the
+// cost_layers table
+func (r *SQLiteReadRepository) ListCostLayers(ctx context.Context, itemID *uuid.UUID) ([]models.ItemCostLayers, error) {
+	var result []models.ItemCostLayers
+	if itemID != nil {
+		item, err := r.FindByID(ctx, *itemID)
+		if err != nil {
+			return nil, err
+		}
+		result = []models.ItemCostLayers{{Item: *item}}
+	} else {
+		rows, err := r.db.QueryContext(ctx, `
+			SELECT id, tenant_id, sku, name, description, quantity, reserved, available, created_at, updated_at
+			FROM inventory_items
+			WHERE tenant_id = ? AND deleted_at IS NULL
+			ORDER BY sku
+		`, tenant.FromContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list items: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var item models.InventoryItem
+			var createdAtStr, updatedAtStr string
+			if err := rows.Scan(
+				&item.ID, &item.TenantID, &item.SKU, &item.Name, &item.Description,
+				&item.Quantity, &item.Reserved, &item.Available,
+				&createdAtStr, &updatedAtStr,
+			); err != nil {
+				return nil, fmt.Errorf("failed to scan item: %w", err)
+			}
+			item.CreatedAt, _ = time.Parse(time.RFC3339, createdAtStr)
+			item.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAtStr)
+			result = append(result, models.ItemCostLayers{Item: item})
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("error iterating items: %w", err)
+		}
+	}
+
+	query := `
+		SELECT item_id, quantity_received, quantity_remaining, unit_cost, received_at
+		FROM cost_layers
+		WHERE ` + tenantItemIDs + `
+		ORDER BY item_id, received_at ASC, created_at ASC
+	`
+	args := []interface{}{tenant.FromContext(ctx)}
+	if itemID != nil {
+		query = `
+			SELECT item_id, quantity_received, quantity_remaining, unit_cost, received_at
+			FROM cost_layers
+			WHERE item_id = ?
+			ORDER BY received_at ASC, created_at ASC
+		`
+		args = append(args, itemID.String())
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cost layers: %w", err)
+	}
+	defer rows.Close()
+
+	layersByItem := make(map[string][]models.CostLayer)
+	for rows.Next() {
+		var layer models.CostLayer
+		var unitCost sql.NullFloat64
+		var receivedAtStr string
+		if err := rows.Scan(&layer.ItemID, &layer.QuantityReceived, &layer.QuantityRemaining, &unitCost, &receivedAtStr); err != nil {
+			return nil, fmt.Errorf("failed to scan cost layer: %w", err)
+		}
+		if unitCost.Valid {
+			cost := unitCost.Float64
+			layer.UnitCost = &cost
+		}
+		layer.ReceivedAt, _ = time.Parse(time.RFC3339, receivedAtStr)
+		layersByItem[layer.ItemID] = append(layersByItem[layer.ItemID], layer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cost layers: %w", err)
+	}
+
+	for i := range result {
+		result[i].Layers = layersByItem[result[i].Item.ID]
+	}
+
+	return result, nil
+}
+
+// ListCostLayers returns the in-memory items without cost layers; the in-memory repository tracks no costs
+func (r *InMemoryReadRepository) ListCostLayers(ctx context.Context, itemID *uuid.UUID) ([]models.ItemCostLayers, error) {
+	if itemID != nil {
+		item, err := r.FindByID(ctx, *itemID)
+		if err != nil {
+			return nil, err
+		}
+		return []models.ItemCostLayers{{Item: *item}}, nil
+	}
+
+	r.mu.RLock()
+	result := make([]models.ItemCostLayers, 0, len(r.items))
+	for _, item := range r.items {
+		if item.DeletedAt != nil || !inTenant(ctx, item) {
+			continue
+		}
+		result = append(result, models.ItemCostLayers{Item: *item})
+	}
+	r.mu.RUnlock()
+	sort.Slice(result, func(i, j int) bool { return result[i].Item.SKU < result[j].Item.SKU })
+	return result, nil
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"query-service/internal/models"
+	"query-service/internal/tenant"
+
+	"github.com/google/uuid"
+)
+
+// WaitlistRepository reads the reservation waitlist (written by the Listener Service)
+type WaitlistRepository interface {
+	// FindWaitlistEntry returns a waitlist entry with its queue position
+	FindWaitlistEntry(ctx context.Context, id uuid.UUID) (*models.WaitlistEntry, error)
+}
+
+// FindWaitlistEntry returns a waitlist entry of an item of the ctx tenant by ID
+func (r *SQLiteReadRepository) FindWaitlistEntry(ctx context.Context, id uuid.UUID) (*models.WaitlistEntry, error) {
+	query := `
+		SELECT id, item_id, quantity, status, requested_at, fulfilled_at, created_at
+		FROM reservation_waitlist
+		WHERE id = ? AND ` + tenantItemIDs + `
+	`
+
+	var entry models.WaitlistEntry
+	var requestedAtStr, createdAtStr string
+	var fulfilledAtStr sql.NullString
+
+	err := r.db.QueryRowContext(ctx, query, id.String(), tenant.FromContext(ctx)).Scan(
+		&entry.ID, &entry.ItemID, &entry.Quantity, &entry.Status,
+		&requestedAtStr, &fulfilledAtStr, &createdAtStr,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrWaitlistEntryNotFound
+		}
+		return nil, fmt.Errorf("failed to find waitlist entry: %w", err)
+	}
+
+	entry.RequestedAt, _ = time.Parse(time.RFC3339, requestedAtStr)
+	if fulfilledAtStr.Valid {
+		fulfilledAt, _ := time.Parse(time.RFC3339, fulfilledAtStr.String)
+		entry.FulfilledAt = &fulfilledAt
+	}
+
+	if entry.Status == "waiting" {
+		// Same ordering the listener uses to fulfill the queue
+		var ahead int
+		err := r.db.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM reservation_waitlist
+			WHERE item_id = ? AND status = 'waiting'
+			  AND (requested_at < ? OR (requested_at = ? AND created_at < ?))
+		`, entry.ItemID, requestedAtStr, requestedAtStr, createdAtStr).Scan(&ahead)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute waitlist position: %w", err)
+		}
+		entry.Position = ahead + 1
+	}
+
+	return &entry, nil
+}
+
+// FindWaitlistEntry always reports not found; the in-memory repository keeps no waitlist
+func (r *InMemoryReadRepository) FindWaitlistEntry(ctx context.Context, id uuid.UUID) (*models.WaitlistEntry, error) {
+	return nil, ErrWaitlistEntryNotFound
+}
@@ -0,0 +1,195 @@
+package schemacheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"query-service/internal/repository"
+	"query-service/pkg/metrics"
+
+	"go.uber.org/zap"
+)
+
+// What to do when the read model schema is not the expected one (SCHEMA_CHECK_MODE)
+const (
+	ModeStrict   = "strict"   // refuse to start
+	ModeDegraded = "degraded" // start, report the drift in /health and keep notifying
+	ModeOff      = "off"      // skip the check
+)
+
+// Drift describes a read model whose schema version differs from the expected one
+type Drift struct {
+	Service    string    `json:"service"`
+	Expected   int       `json:"expected_version"`
+	Found      int       `json:"found_version"` // 0: the database predates schema versioning
+	Reason     string    `json:"reason"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+func (d *Drift) Error() string {
+	return fmt.Sprintf("read model schema drift: %s (expected version %d, found %d)", d.Reason, d.Expected, d.Found)
+}
+
+// Notifier is told about a schema drift. Implementations must not block for long:
+// they run during startup.
+type Notifier interface {
+	NotifySchemaDrift(ctx context.Context, drift *Drift) error
+}
+
+// Notifiers sends a drift to every notifier, returning the first error
+type Notifiers []Notifier
+
+// NotifySchemaDrift implements Notifier
+func (n Notifiers) NotifySchemaDrift(ctx context.Context, drift *Drift) error {
+	var firstErr error
+	for _, notifier := range n {
+		if err := notifier.NotifySchemaDrift(ctx, drift); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// LogNotifier logs the drift as an error
+type LogNotifier struct {
+	Logger *zap.Logger
+}
+
+// NotifySchemaDrift implements Notifier
+func (n LogNotifier) NotifySchemaDrift(ctx context.Context, drift *Drift) error {
+	n.Logger.Error("Read model schema drift detected",
+		zap.Int("expected_version", drift.Expected),
+		zap.Int("found_version", drift.Found),
+		zap.String("reason", drift.Reason),
+	)
+	return nil
+}
+
+// WebhookNotifier POSTs the drift as JSON to a URL (e.g. an alerting or chat webhook)
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// NotifySchemaDrift implements Notifier
+func (n WebhookNotifier) NotifySchemaDrift(ctx context.Context, drift *Drift) error {
+	body, err := json.Marshal(drift)
+	if err != nil {
+		return fmt.Errorf("failed to encode schema drift: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build schema drift notification: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := n.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send schema drift notification: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("schema drift webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Checker compares the read model schema against the version the repositories expect
+type Checker struct {
+	mode     string
+	notifier Notifier
+	logger   *zap.Logger
+
+	mu    sync.RWMutex
+	drift *Drift
+}
+
+// NewChecker creates a checker. An unknown mode is treated as strict.
+func NewChecker(mode string, notifier Notifier, logger *zap.Logger) *Checker {
+	switch mode {
+	case ModeStrict, ModeDegraded, ModeOff:
+	default:
+		logger.Warn("Unknown SCHEMA_CHECK_MODE, using strict", zap.String("mode", mode))
+		mode = ModeStrict
+	}
+	return &Checker{mode: mode, notifier: notifier, logger: logger}
+}
+
+// Check reads the schema version of source and notifies a drift. It returns an
+// error when the service must not start: a drift in strict mode, or a version that
+// cannot be read in strict mode.
+func (c *Checker) Check(ctx context.Context, source repository.SchemaVersioned) error {
+	if c.mode == ModeOff {
+		return nil
+	}
+
+	found, err := source.SchemaVersion(ctx)
+	if err != nil {
+		if c.mode == ModeStrict {
+			return fmt.Errorf("failed to check read model schema: %w", err)
+		}
+		c.logger.Warn("Failed to check read model schema, continuing in degraded mode", zap.Error(err))
+		return nil
+	}
+
+	expected := repository.ExpectedSchemaVersion
+	if found == expected {
+		metrics.ReadModelSchemaDrift.Set(0)
+		c.logger.Info("Read model schema version matches", zap.Int("version", found))
+		return nil
+	}
+
+	drift := &Drift{
+		Service:    "query-service",
+		Expected:   expected,
+		Found:      found,
+		DetectedAt: time.Now().UTC(),
+	}
+	switch {
+	case found == 0:
+		drift.Reason = "the database has no schema version; it was created by a Listener Service older than schema versioning"
+	case found < expected:
+		drift.Reason = "the Listener Service that owns the database is older than this Query Service; upgrade it first"
+	default:
+		drift.Reason = "the database was migrated by a newer Listener Service; upgrade this Query Service"
+	}
+
+	c.mu.Lock()
+	c.drift = drift
+	c.mu.Unlock()
+	metrics.ReadModelSchemaDrift.Set(1)
+
+	if c.notifier != nil {
+		if err := c.notifier.NotifySchemaDrift(ctx, drift); err != nil {
+			c.logger.Warn("Failed to notify schema drift", zap.Error(err))
+		}
+	}
+
+	if c.mode == ModeStrict {
+		return drift
+	}
+	c.logger.Warn("Starting in degraded mode despite the schema drift; queries may fail",
+		zap.Int("expected_version", expected),
+		zap.Int("found_version", found),
+	)
+	return nil
+}
+
+// Drift returns the drift found by the last check, or nil
+func (c *Checker) Drift() *Drift {
+	if c == nil {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.drift
+}
@@ -0,0 +1,115 @@
+package schemacheck
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"query-service/internal/repository"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newReadModel creates a database like the Listener Service's; version 0 leaves out
+// the schema_migrations table
+func newReadModel(t *testing.T, version int) repository.SchemaVersioned {
+	path := filepath.Join(t.TempDir(), "inventory.db")
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE inventory_items (id TEXT PRIMARY KEY)`)
+	require.NoError(t, err)
+	if version > 0 {
+		_, err = db.Exec(`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, applied_at TEXT NOT NULL)`)
+		require.NoError(t, err)
+		_, err = db.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, '2024-01-01T00:00:00Z')`, version)
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Close())
+
+	repo, err := repository.NewSQLiteReadRepository(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.(*repository.SQLiteReadRepository).Close() })
+	return repo.(repository.SchemaVersioned)
+}
+
+type recordingNotifier struct {
+	drifts []*Drift
+}
+
+func (n *recordingNotifier) NotifySchemaDrift(ctx context.Context, drift *Drift) error {
+	n.drifts = append(n.drifts, drift)
+	return nil
+}
+
+func TestChecker_MatchingVersion(t *testing.T) {
+	notifier := &recordingNotifier{}
+	checker := NewChecker(ModeStrict, notifier, zap.NewNop())
+
+	require.NoError(t, checker.Check(context.Background(), newReadModel(t, repository.ExpectedSchemaVersion)))
+	assert.Nil(t, checker.Drift())
+	assert.Empty(t, notifier.drifts)
+}
+
+func TestChecker_StrictRefusesDrift(t *testing.T) {
+	notifier := &recordingNotifier{}
+	checker := NewChecker(ModeStrict, notifier, zap.NewNop())
+
+	err := checker.Check(context.Background(), newReadModel(t, repository.ExpectedSchemaVersion+1))
+	require.Error(t, err)
+	var drift *Drift
+	require.ErrorAs(t, err, &drift)
+	assert.Equal(t, repository.ExpectedSchemaVersion, drift.Expected)
+	assert.Equal(t, repository.ExpectedSchemaVersion+1, drift.Found)
+	assert.Contains(t, err.Error(), "upgrade this Query Service")
+	assert.Len(t, notifier.drifts, 1)
+}
+
+func TestChecker_DegradedStartsWithUnversionedDatabase(t *testing.T) {
+	checker := NewChecker(ModeDegraded, &recordingNotifier{}, zap.NewNop())
+
+	require.NoError(t, checker.Check(context.Background(), newReadModel(t, 0)))
+	require.NotNil(t, checker.Drift())
+	assert.Equal(t, 0, checker.Drift().Found)
+}
+
+func TestChecker_OffSkipsCheck(t *testing.T) {
+	notifier := &recordingNotifier{}
+	checker := NewChecker(ModeOff, notifier, zap.NewNop())
+
+	require.NoError(t, checker.Check(context.Background(), newReadModel(t, 0)))
+	assert.Nil(t, checker.Drift())
+	assert.Empty(t, notifier.drifts)
+}
+
+func TestWebhookNotifier_PostsDrift(t *testing.T) {
+	var received Drift
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	drift := &Drift{Service: "query-service", Expected: 2, Found: 1, Reason: "older listener"}
+	require.NoError(t, WebhookNotifier{URL: server.URL}.NotifySchemaDrift(context.Background(), drift))
+	assert.Equal(t, 2, received.Expected)
+	assert.Equal(t, 1, received.Found)
+	assert.Equal(t, "older listener", received.Reason)
+}
+
+func TestWebhookNotifier_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := WebhookNotifier{URL: server.URL}.NotifySchemaDrift(context.Background(), &Drift{})
+	assert.Error(t, err)
+}
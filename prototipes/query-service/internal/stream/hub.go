@@ -0,0 +1,158 @@
+// Package stream fans inventory changes out to the clients of the live stream
+// (GET /api/v1/inventory/stream), so dashboards see confirmed writes as they happen
+// instead of polling the read model.
+package stream
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"query-service/internal/tenant"
+	"query-service/pkg/metrics"
+)
+
+// Event is a confirmed change relayed to the stream clients
+type Event struct {
+	ID         string          `json:"id,omitempty"` // event_id of the envelope (empty for version 1 events)
+	Type       string          `json:"type"`         // confirmation event type, e.g. "StockReservedConfirmed"
+	ItemID     string          `json:"item_id,omitempty"`
+	SKU        string          `json:"sku,omitempty"`
+	TenantID   string          `json:"-"` // only delivered to the clients of this tenant (empty is the default one)
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Filter selects the events a client receives. An empty filter receives every event;
+// otherwise an event matches when its item ID or its SKU is listed, so events that
+// name no item (stores) only reach unfiltered clients. Events of another tenant never
+// match.
+type Filter struct {
+	Tenant  string
+	ItemIDs map[string]bool
+	SKUs    map[string]bool
+}
+
+// NewFilter builds a filter from the requested item IDs and SKUs
+func NewFilter(itemIDs, skus []string) Filter {
+	filter := Filter{ItemIDs: map[string]bool{}, SKUs: map[string]bool{}}
+	for _, id := range itemIDs {
+		filter.ItemIDs[id] = true
+	}
+	for _, sku := range skus {
+		filter.SKUs[sku] = true
+	}
+	return filter
+}
+
+// Matches reports whether event passes the filter
+func (f Filter) Matches(event Event) bool {
+	if tenant.OrDefault(event.TenantID) != tenant.OrDefault(f.Tenant) {
+		return false
+	}
+	if len(f.ItemIDs) == 0 && len(f.SKUs) == 0 {
+		return true
+	}
+	return (event.ItemID != "" && f.ItemIDs[event.ItemID]) || (event.SKU != "" && f.SKUs[event.SKU])
+}
+
+// Hub delivers published events to the subscriptions whose filter they match.
+// Publishing never blocks on a client: a subscription whose buffer is full is closed
+// as dropped, and the client is expected to reload its data and reconnect.
+type Hub struct {
+	mu            sync.RWMutex
+	subscriptions map[*Subscription]struct{}
+	buffer        int
+}
+
+// NewHub creates a hub that queues up to buffer events per subscription
+func NewHub(buffer int) *Hub {
+	if buffer <= 0 {
+		buffer = 1
+	}
+	return &Hub{subscriptions: map[*Subscription]struct{}{}, buffer: buffer}
+}
+
+// Subscribe registers a client; it must call Close when it goes away
+func (h *Hub) Subscribe(filter Filter) *Subscription {
+	subscription := &Subscription{
+		hub:    h,
+		filter: filter,
+		events: make(chan Event, h.buffer),
+	}
+	h.mu.Lock()
+	h.subscriptions[subscription] = struct{}{}
+	h.mu.Unlock()
+	metrics.StreamClients.Inc()
+	return subscription
+}
+
+// Publish hands event to every matching subscription
+func (h *Hub) Publish(event Event) {
+	h.mu.RLock()
+	var slow []*Subscription
+	for subscription := range h.subscriptions {
+		if !subscription.filter.Matches(event) {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+		default:
+			slow = append(slow, subscription)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, subscription := range slow {
+		if h.remove(subscription, true) {
+			metrics.StreamClientsDropped.Inc()
+		}
+	}
+}
+
+// Clients returns the number of open subscriptions
+func (h *Hub) Clients() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subscriptions)
+}
+
+// remove unregisters subscription and closes its channel; it reports false if it
+// was already removed
+func (h *Hub) remove(subscription *Subscription, dropped bool) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscriptions[subscription]; !ok {
+		return false
+	}
+	subscription.dropped = dropped
+	delete(h.subscriptions, subscription)
+	close(subscription.events)
+	metrics.StreamClients.Dec()
+	return true
+}
+
+// Subscription is one client of the hub
+type Subscription struct {
+	hub     *Hub
+	filter  Filter
+	events  chan Event
+	dropped bool // written before events is closed
+}
+
+// Events returns the client's events; the channel is closed when the subscription
+// is closed or dropped
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Dropped reports whether the hub closed the subscription because the client did
+// not keep up. Only meaningful once Events is closed.
+func (s *Subscription) Dropped() bool {
+	return s.dropped
+}
+
+// Close unregisters the subscription
+func (s *Subscription) Close() {
+	s.hub.remove(s, false)
+}
@@ -0,0 +1,53 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter_Matches(t *testing.T) {
+	event := Event{ItemID: "item-1", SKU: "SKU-001"}
+
+	assert.True(t, NewFilter(nil, nil).Matches(event))
+	assert.True(t, NewFilter(nil, nil).Matches(Event{Type: "StoreCreatedConfirmed"}))
+	assert.True(t, NewFilter([]string{"item-1"}, nil).Matches(event))
+	assert.True(t, NewFilter([]string{"item-2"}, []string{"SKU-001"}).Matches(event))
+	assert.False(t, NewFilter([]string{"item-2"}, []string{"SKU-002"}).Matches(event))
+	assert.False(t, NewFilter(nil, []string{"SKU-001"}).Matches(Event{Type: "StoreCreatedConfirmed"}))
+
+	// Events only reach the clients of their tenant; empty is the default tenant
+	acme := NewFilter(nil, nil)
+	acme.Tenant = "acme"
+	assert.False(t, acme.Matches(event))
+	assert.True(t, acme.Matches(Event{SKU: "SKU-001", TenantID: "acme"}))
+	defaultFilter := NewFilter(nil, nil)
+	defaultFilter.Tenant = "default"
+	assert.True(t, defaultFilter.Matches(event))
+}
+
+func TestHub_DropsSlowSubscription(t *testing.T) {
+	hub := NewHub(1)
+	slow := hub.Subscribe(Filter{})
+	fast := hub.Subscribe(NewFilter(nil, []string{"SKU-001"}))
+
+	hub.Publish(Event{Type: "StockAdjustedConfirmed", SKU: "SKU-002"})
+	hub.Publish(Event{Type: "StockAdjustedConfirmed", SKU: "SKU-001"}) // slow's buffer is full
+
+	_, open := <-slow.Events()
+	assert.True(t, open, "the queued event is still delivered")
+	_, open = <-slow.Events()
+	assert.False(t, open)
+	assert.True(t, slow.Dropped())
+	slow.Close() // closing a dropped subscription is a no-op
+
+	event := <-fast.Events()
+	assert.Equal(t, "SKU-001", event.SKU)
+	assert.Equal(t, 1, hub.Clients())
+
+	fast.Close()
+	_, open = <-fast.Events()
+	assert.False(t, open)
+	assert.False(t, fast.Dropped())
+	assert.Equal(t, 0, hub.Clients())
+}
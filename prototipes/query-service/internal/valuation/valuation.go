@@ -0,0 +1,113 @@
+package valuation
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"query-service/internal/models"
+)
+
+// Method is an inventory valuation method
+type Method string
+
+const (
+	// FIFO values the remaining stock at the cost of the most recent receipts,
+	// since the oldest layers are consumed first
+	FIFO Method = "fifo"
+	// WeightedAverage values the remaining stock at the average cost of all receipts
+	WeightedAverage Method = "weighted_average"
+)
+
+// ParseMethod parses a valuation method name (case insensitive)
+func ParseMethod(value string) (Method, error) {
+	switch Method(strings.ToLower(strings.TrimSpace(value))) {
+	case FIFO:
+		return FIFO, nil
+	case WeightedAverage, "wavg", "average":
+		return WeightedAverage, nil
+	}
+	return "", fmt.Errorf("invalid valuation method %q (use fifo or weighted_average)", value)
+}
+
+// ValueItem computes the valuation of the stock on hand for one item.
+// Layers must be ordered oldest first. Stock on hand that is not covered by a
+// costed layer (receipts without cost, or stock that predates cost tracking)
+// is reported as uncosted instead of being valued at zero silently.
+func ValueItem(item models.InventoryItem, layers []models.CostLayer, method Method) models.ItemValuation {
+	result := models.ItemValuation{
+		ID:       item.ID,
+		SKU:      item.SKU,
+		Name:     item.Name,
+		Quantity: item.Quantity,
+	}
+
+	switch method {
+	case WeightedAverage:
+		var receivedQty int
+		var receivedCost float64
+		for _, layer := range layers {
+			if layer.UnitCost == nil {
+				continue
+			}
+			receivedQty += layer.QuantityReceived
+			receivedCost += float64(layer.QuantityReceived) * *layer.UnitCost
+		}
+		if receivedQty == 0 {
+			result.UncostedQuantity = item.Quantity
+			break
+		}
+		result.UnitCost = round(receivedCost / float64(receivedQty))
+		result.TotalValue = round(float64(item.Quantity) * receivedCost / float64(receivedQty))
+
+	default:
+		var costedQty int
+		var value float64
+		for _, layer := range layers {
+			if layer.UnitCost == nil || layer.QuantityRemaining == 0 {
+				continue
+			}
+			costedQty += layer.QuantityRemaining
+			value += float64(layer.QuantityRemaining) * *layer.UnitCost
+		}
+		// Layers can only overstate stock if events were lost; never value more than is on hand
+		if costedQty > item.Quantity {
+			value = value * float64(item.Quantity) / float64(costedQty)
+			costedQty = item.Quantity
+		}
+		result.UncostedQuantity = item.Quantity - costedQty
+		result.TotalValue = round(value)
+		if costedQty > 0 {
+			result.UnitCost = round(value / float64(costedQty))
+		}
+	}
+
+	return result
+}
+
+// BuildReport values every item and aggregates the totals
+func BuildReport(items []models.ItemCostLayers, method Method, now time.Time) models.ValuationReport {
+	report := models.ValuationReport{
+		Method:      string(method),
+		Items:       make([]models.ItemValuation, 0, len(items)),
+		GeneratedAt: now,
+	}
+
+	var total float64
+	for _, entry := range items {
+		itemValuation := ValueItem(entry.Item, entry.Layers, method)
+		report.Items = append(report.Items, itemValuation)
+		report.TotalQuantity += itemValuation.Quantity
+		report.UncostedQuantity += itemValuation.UncostedQuantity
+		total += itemValuation.TotalValue
+	}
+	report.TotalValue = round(total)
+
+	return report
+}
+
+// round rounds to cents
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,84 @@
+package valuation
+
+import (
+	"testing"
+	"time"
+
+	"query-service/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cost(value float64) *float64 {
+	return &value
+}
+
+// Received 10 @ 5.00 then 10 @ 8.00, and 12 units have been shipped since
+func sampleLayers() []models.CostLayer {
+	return []models.CostLayer{
+		{ItemID: "item-1", QuantityReceived: 10, QuantityRemaining: 0, UnitCost: cost(5)},
+		{ItemID: "item-1", QuantityReceived: 10, QuantityRemaining: 8, UnitCost: cost(8)},
+	}
+}
+
+func TestValueItem_FIFO(t *testing.T) {
+	item := models.InventoryItem{ID: "item-1", SKU: "SKU-1", Quantity: 8}
+
+	result := ValueItem(item, sampleLayers(), FIFO)
+
+	assert.Equal(t, 64.0, result.TotalValue)
+	assert.Equal(t, 8.0, result.UnitCost)
+	assert.Equal(t, 0, result.UncostedQuantity)
+}
+
+func TestValueItem_WeightedAverage(t *testing.T) {
+	item := models.InventoryItem{ID: "item-1", SKU: "SKU-1", Quantity: 8}
+
+	result := ValueItem(item, sampleLayers(), WeightedAverage)
+
+	assert.Equal(t, 6.5, result.UnitCost)
+	assert.Equal(t, 52.0, result.TotalValue)
+	assert.Equal(t, 0, result.UncostedQuantity)
+}
+
+func TestValueItem_UncostedStock(t *testing.T) {
+	item := models.InventoryItem{ID: "item-1", SKU: "SKU-1", Quantity: 15}
+	layers := []models.CostLayer{
+		{ItemID: "item-1", QuantityReceived: 5, QuantityRemaining: 5},
+		{ItemID: "item-1", QuantityReceived: 4, QuantityRemaining: 4, UnitCost: cost(2.5)},
+	}
+
+	result := ValueItem(item, layers, FIFO)
+
+	assert.Equal(t, 10.0, result.TotalValue)
+	assert.Equal(t, 11, result.UncostedQuantity)
+}
+
+func TestBuildReport_Totals(t *testing.T) {
+	items := []models.ItemCostLayers{
+		{Item: models.InventoryItem{ID: "item-1", SKU: "SKU-1", Quantity: 8}, Layers: sampleLayers()},
+		{Item: models.InventoryItem{ID: "item-2", SKU: "SKU-2", Quantity: 3}},
+	}
+
+	report := BuildReport(items, FIFO, time.Now())
+
+	require.Len(t, report.Items, 2)
+	assert.Equal(t, "fifo", report.Method)
+	assert.Equal(t, 11, report.TotalQuantity)
+	assert.Equal(t, 64.0, report.TotalValue)
+	assert.Equal(t, 3, report.UncostedQuantity)
+}
+
+func TestParseMethod(t *testing.T) {
+	method, err := ParseMethod("FIFO")
+	require.NoError(t, err)
+	assert.Equal(t, FIFO, method)
+
+	method, err = ParseMethod("weighted_average")
+	require.NoError(t, err)
+	assert.Equal(t, WeightedAverage, method)
+
+	_, err = ParseMethod("lifo")
+	assert.Error(t, err)
+}
@@ -1,15 +1,76 @@
 package errors
 
 import (
+	"encoding/xml"
+	stderrors "errors"
 	"fmt"
 	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Machine-readable error codes sent in the code field of every error response.
+// Clients should branch on the code, never on the message.
+const (
+	CodeInvalidRequest        = "InvalidRequest"        // 400: malformed ID, query parameter or body
+	CodeValidationError       = "ValidationError"       // 400: body failed validation (details or fields name the fields)
+	CodeInsufficientStock     = "InsufficientStock"     // 400: not enough available stock
+	CodeInvalidOperation      = "InvalidOperation"      // 400: the operation does not apply to the item in its current state
+	CodeUnauthorized          = "Unauthorized"          // 401: missing, invalid, expired or revoked credentials
+	CodeForbidden             = "Forbidden"             // 403: the role or API key lacks the permission
+	CodeItemNotFound          = "ItemNotFound"          // 404: no item with that ID or SKU
+	CodeResourceNotFound      = "ResourceNotFound"      // 404: any other missing resource (store, waitlist entry, API key)
+	CodeDuplicateSKU          = "DuplicateSKU"          // 409: an item with the SKU already exists
+	CodeVersionConflict       = "VersionConflict"       // 409: the item is no longer at the expected version
+	CodeConflict              = "Conflict"              // 409: any other conflict with the current state
+	CodePayloadTooLarge       = "PayloadTooLarge"       // 413: the request body exceeds the size limit
+	CodeRateLimited           = "RateLimited"           // 429: too many requests, retry after Retry-After
+	CodeSerializationError    = "SerializationError"    // 500: failed to encode an event or response
+	CodeDatabaseError         = "DatabaseError"         // 500: the database failed
+	CodeCacheError            = "CacheError"            // 500: the cache failed
+	CodeInternalError         = "InternalError"         // 500: unexpected failure
+	CodeServiceUnavailable    = "ServiceUnavailable"    // 503: a dependency or feature is unavailable, or the service is busy
+	CodeBrokerConnectionError = "BrokerConnectionError" // 503: the event broker cannot be reached
+	CodeTimeout               = "Timeout"               // 504: a backend did not answer in time
 )
 
-// StandardError represents a standardized error response
+// requestIDKey is the gin context key RequestIDMiddleware stores the request ID under
+const requestIDKey = "request_id"
+
+// StandardError is the error envelope returned by every endpoint
 type StandardError struct {
-	Code    string `json:"error"`   // Error code/type (e.g., "InvalidRequest", "ItemNotFound")
-	Message string `json:"message"` // Human-readable error message
-	Details string `json:"details"` // Additional details (field name, validation info, etc.)
+	XMLName xml.Name `json:"-" xml:"error" swaggerignore:"true"`
+
+	// Machine-readable error code
+	Code string `json:"code" xml:"code" example:"ItemNotFound" enums:"InvalidRequest,ValidationError,InsufficientStock,InvalidOperation,Unauthorized,Forbidden,ItemNotFound,ResourceNotFound,DuplicateSKU,VersionConflict,Conflict,PayloadTooLarge,RateLimited,SerializationError,DatabaseError,CacheError,InternalError,ServiceUnavailable,BrokerConnectionError,Timeout"`
+
+	// Human-readable error message
+	Message string `json:"message" xml:"message" example:"item not found"`
+
+	// Additional details (field name, IDs, quantities), when there are any
+	Details string `json:"details,omitempty" xml:"details,omitempty" example:"Item ID: 550e8400-e29b-41d4-a716-446655440000"`
+
+	// Field-level validation errors, one per invalid field (ValidationError only)
+	Fields []FieldError `json:"fields,omitempty" xml:"field,omitempty"`
+
+	// X-Request-ID of the request, to quote when reporting the error
+	RequestID string `json:"request_id,omitempty" xml:"request_id,omitempty" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+
+	// When the error was returned (UTC)
+	Timestamp time.Time `json:"timestamp" xml:"timestamp" example:"2024-01-15T10:30:00Z"`
+}
+
+// FieldError describes why a single request field failed validation
+type FieldError struct {
+	// JSON path of the field
+	Field string `json:"field" xml:"name,attr" example:"sku"`
+
+	// Validation rule the value broke
+	Rule string `json:"rule" xml:"rule,attr" example:"sku"`
+
+	// Human-readable explanation
+	Message string `json:"message" xml:",chardata" example:"must start with a letter or digit and contain only letters, digits, '.', '_' or '-'"`
 }
 
 // Error implements the error interface
@@ -20,19 +81,42 @@ func (e *StandardError) Error() string {
 // HTTPStatus returns the appropriate HTTP status code for the error
 func (e *StandardError) HTTPStatus() int {
 	switch e.Code {
-	case "InvalidRequest", "ValidationError":
+	case CodeInvalidRequest, CodeValidationError, CodeInsufficientStock, CodeInvalidOperation:
 		return http.StatusBadRequest
-	case "ItemNotFound", "ResourceNotFound":
+	case CodeUnauthorized:
+		return http.StatusUnauthorized
+	case CodeForbidden:
+		return http.StatusForbidden
+	case CodeItemNotFound, CodeResourceNotFound:
 		return http.StatusNotFound
-	case "DatabaseError", "InternalError":
-		return http.StatusInternalServerError
-	case "CacheError":
-		return http.StatusInternalServerError
+	case CodeDuplicateSKU, CodeVersionConflict, CodeConflict:
+		return http.StatusConflict
+	case CodePayloadTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case CodeRateLimited:
+		return http.StatusTooManyRequests
+	case CodeServiceUnavailable, CodeBrokerConnectionError:
+		return http.StatusServiceUnavailable
+	case CodeTimeout:
+		return http.StatusGatewayTimeout
 	default:
 		return http.StatusInternalServerError
 	}
 }
 
+// ForRequest returns a copy of the error stamped with the request ID and the current time
+func (e *StandardError) ForRequest(c *gin.Context) *StandardError {
+	stamped := *e
+	stamped.RequestID = c.GetString(requestIDKey)
+	stamped.Timestamp = time.Now().UTC()
+	return &stamped
+}
+
+// Respond sends err as the JSON error response of the request and aborts the chain
+func Respond(c *gin.Context, err *StandardError) {
+	c.AbortWithStatusJSON(err.HTTPStatus(), err.ForRequest(c))
+}
+
 // NewStandardError creates a new StandardError
 func NewStandardError(errorCode, message, details string) *StandardError {
 	return &StandardError{
@@ -44,23 +128,98 @@ func NewStandardError(errorCode, message, details string) *StandardError {
 
 // Common error constructors
 func NewInvalidRequest(message, details string) *StandardError {
-	return NewStandardError("InvalidRequest", message, details)
+	return NewStandardError(CodeInvalidRequest, message, details)
 }
 
 func NewValidationError(message, field string) *StandardError {
-	return NewStandardError("ValidationError", message, fmt.Sprintf("Field: %s", field))
+	return NewStandardError(CodeValidationError, message, fmt.Sprintf("Field: %s", field))
+}
+
+// NewBindingError reports a request body that could not be decoded or failed validation;
+// a body cut at the BodyLimit size is reported as PayloadTooLarge
+func NewBindingError(err error) *StandardError {
+	var tooLarge *http.MaxBytesError
+	if stderrors.As(err, &tooLarge) {
+		return NewPayloadTooLarge(tooLarge.Limit)
+	}
+	return NewStandardError(CodeValidationError, "invalid request body", err.Error())
+}
+
+// NewFieldErrors reports a request body whose fields failed validation
+func NewFieldErrors(fields []FieldError) *StandardError {
+	err := NewStandardError(CodeValidationError, "request validation failed", "")
+	err.Fields = fields
+	return err
+}
+
+func NewPayloadTooLarge(limit int64) *StandardError {
+	return NewStandardError(CodePayloadTooLarge, "request body too large", fmt.Sprintf("Limit: %d bytes", limit))
+}
+
+func NewUnauthorized(message, details string) *StandardError {
+	return NewStandardError(CodeUnauthorized, message, details)
+}
+
+func NewForbidden(message, details string) *StandardError {
+	return NewStandardError(CodeForbidden, message, details)
 }
 
 func NewItemNotFound(itemID string) *StandardError {
-	return NewStandardError("ItemNotFound", "item not found", fmt.Sprintf("Item ID: %s", itemID))
+	return NewStandardError(CodeItemNotFound, "item not found", fmt.Sprintf("Item ID: %s", itemID))
+}
+
+func NewNotFound(message, details string) *StandardError {
+	return NewStandardError(CodeResourceNotFound, message, details)
+}
+
+func NewDuplicateSKU(sku string) *StandardError {
+	return NewStandardError(CodeDuplicateSKU, "sku already exists", fmt.Sprintf("SKU: %s", sku))
+}
+
+func NewConflict(message, details string) *StandardError {
+	return NewStandardError(CodeConflict, message, details)
+}
+
+func NewInsufficientStock(available, requested int) *StandardError {
+	return NewStandardError(CodeInsufficientStock, "insufficient stock available",
+		fmt.Sprintf("Available: %d, Requested: %d", available, requested))
+}
+
+func NewInvalidOperation(message, details string) *StandardError {
+	return NewStandardError(CodeInvalidOperation, message, details)
+}
+
+func NewInvalidReleaseQuantity(reserved, requested int) *StandardError {
+	return NewStandardError(CodeInvalidOperation, "invalid release quantity",
+		fmt.Sprintf("Reserved: %d, Requested: %d", reserved, requested))
+}
+
+func NewSerializationError(err error) *StandardError {
+	return NewStandardError(CodeSerializationError, "failed to serialize data", err.Error())
 }
 
 func NewDatabaseError(operation string, err error) *StandardError {
-	return NewStandardError("DatabaseError", fmt.Sprintf("database operation failed: %s", operation), err.Error())
+	return NewStandardError(CodeDatabaseError, fmt.Sprintf("database operation failed: %s", operation), err.Error())
 }
 
 func NewCacheError(operation string, err error) *StandardError {
-	return NewStandardError("CacheError", fmt.Sprintf("cache operation failed: %s", operation), err.Error())
+	return NewStandardError(CodeCacheError, fmt.Sprintf("cache operation failed: %s", operation), err.Error())
+}
+
+func NewBrokerConnectionError(err error) *StandardError {
+	return NewStandardError(CodeBrokerConnectionError, "failed to connect to event broker", err.Error())
+}
+
+func NewServiceUnavailable(message, details string) *StandardError {
+	return NewStandardError(CodeServiceUnavailable, message, details)
+}
+
+func NewRateLimited(message, details string) *StandardError {
+	return NewStandardError(CodeRateLimited, message, details)
+}
+
+func NewTimeout(message, details string) *StandardError {
+	return NewStandardError(CodeTimeout, message, details)
 }
 
 func NewInternalError(message string, err error) *StandardError {
@@ -68,5 +227,5 @@ func NewInternalError(message string, err error) *StandardError {
 	if err != nil {
 		details = err.Error()
 	}
-	return NewStandardError("InternalError", message, details)
+	return NewStandardError(CodeInternalError, message, details)
 }
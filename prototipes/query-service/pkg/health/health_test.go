@@ -0,0 +1,83 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func up(ctx context.Context) error { return nil }
+
+func TestChecker_Statuses(t *testing.T) {
+	var brokerErr error
+	checker := NewChecker(Config{Service: "test", Timeout: time.Second, FailureThreshold: 2})
+	checker.Register(Dependency{Name: "database", Critical: true, Check: up})
+	checker.Register(Dependency{Name: "broker", Critical: true, Check: func(ctx context.Context) error { return brokerErr }})
+	checker.Register(Dependency{Name: "cache", Check: Unavailable("using in-memory fallback")})
+
+	report := checker.Check(context.Background())
+	assert.Equal(t, StatusDegraded, report.Status, "a failing non-critical dependency degrades")
+	require.Len(t, report.Dependencies, 3)
+	assert.Equal(t, StatusUp, report.Dependencies[0].Status)
+	assert.Equal(t, StatusFailing, report.Dependencies[2].Status)
+	assert.Equal(t, "using in-memory fallback", report.Dependencies[2].Error)
+
+	brokerErr = errors.New("connection refused")
+	report = checker.Check(context.Background())
+	assert.Equal(t, StatusDegraded, report.Status, "one failure is under the threshold")
+	assert.Equal(t, StatusFailing, report.Dependencies[1].Status)
+	assert.Equal(t, StatusDown, report.Dependencies[2].Status)
+
+	report = checker.Check(context.Background())
+	assert.Equal(t, StatusNotReady, report.Status)
+	assert.Equal(t, StatusDown, report.Dependencies[1].Status)
+	assert.Equal(t, 2, report.Dependencies[1].ConsecutiveFailures)
+
+	brokerErr = nil
+	report = checker.Check(context.Background())
+	assert.Equal(t, StatusUp, report.Dependencies[1].Status, "a success resets the failures")
+	assert.Zero(t, report.Dependencies[1].ConsecutiveFailures)
+}
+
+func TestChecker_Timeout(t *testing.T) {
+	checker := NewChecker(Config{Service: "test", Timeout: 20 * time.Millisecond})
+	checker.Register(Dependency{Name: "stuck", Critical: true, Check: func(ctx context.Context) error {
+		time.Sleep(time.Second) // ignores ctx
+		return nil
+	}})
+
+	start := time.Now()
+	report := checker.Check(context.Background())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, StatusNotReady, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Dependencies[0].Error)
+}
+
+func TestChecker_Handlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	checker := NewChecker(Config{Service: "test"})
+	checker.Register(Dependency{Name: "database", Critical: true, Check: Unavailable("closed")})
+	router := gin.New()
+	router.GET("/health/live", checker.Live)
+	router.GET("/health/ready", checker.Ready)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/health/live", nil))
+	assert.Equal(t, http.StatusOK, w.Code, "liveness does not depend on the dependencies")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/health/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var report Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, StatusNotReady, report.Status)
+	assert.Equal(t, "database", report.Dependencies[0].Name)
+}
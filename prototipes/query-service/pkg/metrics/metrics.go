@@ -0,0 +1,194 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// HTTP metrics
+var (
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method, route and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// Cache metrics
+var (
+	// CacheRequests counts cache lookups by backend, keyspace (key prefix) and result (hit, miss, error)
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_requests_total",
+		Help: "Cache lookups by backend, keyspace and result; hit ratio = hit / (hit + miss).",
+	}, []string{"backend", "keyspace", "result"})
+
+	// CacheLookups counts the cache reads of the inventory endpoints by key class (item,
+	// sku, stock, list) and result, for tuning CACHE_TTL per class
+	CacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "inventory_cache_lookups_total",
+		Help: "Cache reads of the inventory endpoints by key class (item, sku, barcode, stock, list) and result (hit, miss, error).",
+	}, []string{"class", "result"})
+
+	// CacheLookupDuration is the latency of those reads, tiers included
+	CacheLookupDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "inventory_cache_lookup_duration_seconds",
+		Help:    "Latency of the cache reads of the inventory endpoints by key class and result.",
+		Buckets: []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25},
+	}, []string{"class", "result"})
+
+	// CacheMemoryEvictions counts the entries the in-memory cache evicted to stay within
+	// its bounds, by keyspace
+	CacheMemoryEvictions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_memory_evictions_total",
+		Help: "Entries evicted by the in-memory cache to stay within CACHE_MEMORY_MAX_ENTRIES / CACHE_MEMORY_MAX_MB, by keyspace.",
+	}, []string{"keyspace"})
+
+	// CacheAdaptiveMode is 1 while the cache is over its soft memory quota and shortens TTLs
+	CacheAdaptiveMode = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "cache_adaptive_mode",
+		Help: "1 while cache memory pressure has adaptive TTL mode on, 0 otherwise.",
+	})
+
+	// CacheAdaptiveActivations counts the times adaptive mode turned on
+	CacheAdaptiveActivations = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cache_adaptive_mode_activations_total",
+		Help: "Times cache memory pressure turned adaptive TTL mode on.",
+	})
+
+	// CacheAdaptiveSets counts writes changed by adaptive mode (shortened, skipped)
+	CacheAdaptiveSets = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_adaptive_sets_total",
+		Help: "Cache writes whose TTL was shortened or that were skipped in adaptive mode, by keyspace.",
+	}, []string{"keyspace", "action"})
+
+	// CacheMemoryUsageRatio is the cache server memory usage over its soft quota
+	CacheMemoryUsageRatio = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "cache_memory_usage_ratio",
+		Help: "Cache server used memory divided by the soft quota (or Redis maxmemory).",
+	})
+)
+
+// Read latency policy metrics (item endpoints)
+var (
+	// HedgedReads counts the reads that exceeded the hedge budget and started a second read
+	HedgedReads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "hedged_reads_total",
+		Help: "Item reads that exceeded the hedge budget and fired a parallel read model read, by endpoint.",
+	}, []string{"endpoint"})
+
+	// HedgedReadWins counts which read answered first once a hedge was fired (primary, hedge)
+	HedgedReadWins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "hedged_read_wins_total",
+		Help: "Hedged item reads by endpoint and the read that answered first (primary or hedge).",
+	}, []string{"endpoint", "winner"})
+
+	// ReadTimeouts counts item reads that failed their endpoint's SLA timeout
+	ReadTimeouts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "read_timeouts_total",
+		Help: "Item reads that did not finish within the endpoint's timeout, by endpoint.",
+	}, []string{"endpoint"})
+)
+
+// Kafka metrics (cache update/invalidation consumer)
+var (
+	// KafkaMessagesConsumed counts consumed events by outcome (applied, failed, skipped)
+	KafkaMessagesConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_messages_consumed_total",
+		Help: "Kafka messages consumed by topic, event type and outcome.",
+	}, []string{"topic", "event_type", "outcome"})
+
+	// KafkaConsumerLag is the number of messages behind the partition's high-water mark
+	KafkaConsumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kafka_consumer_lag",
+		Help: "Messages between the last consumed offset and the partition high-water mark.",
+	}, []string{"topic", "partition"})
+)
+
+// Live stream metrics (GET /inventory/stream)
+var (
+	// StreamClients is the number of clients connected to the inventory stream
+	StreamClients = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "stream_clients",
+		Help: "Clients connected to the live inventory stream.",
+	})
+
+	// StreamClientsDropped counts clients disconnected because they fell behind
+	StreamClientsDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "stream_clients_dropped_total",
+		Help: "Stream clients disconnected because their event buffer filled up.",
+	})
+
+	// StreamEventsRelayed counts confirmation events handed to the stream, by event type
+	StreamEventsRelayed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "stream_events_relayed_total",
+		Help: "Confirmation events relayed from Kafka to the live inventory stream, by event type.",
+	}, []string{"event_type"})
+)
+
+// Consistency probe metrics (write-to-read propagation)
+var (
+	// ProbePropagationSeconds is the time from an accepted probe write until it is visible
+	// in each stage: read_model (SQLite) and cache (no stale entry left)
+	ProbePropagationSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "probe_propagation_seconds",
+		Help:    "Write-to-read propagation latency measured by the consistency probe, by stage.",
+		Buckets: []float64{.05, .1, .25, .5, 1, 2, 5, 10, 30, 60},
+	}, []string{"stage"})
+
+	// ProbeLastPropagationSeconds is the latency of the latest successful probe, by stage
+	ProbeLastPropagationSeconds = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "probe_propagation_last_seconds",
+		Help: "Propagation latency of the latest successful probe, by stage.",
+	}, []string{"stage"})
+
+	// ProbeFailures counts probe runs that failed (write, read, timeout)
+	ProbeFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "probe_failures_total",
+		Help: "Consistency probe runs that failed, by reason.",
+	}, []string{"reason"})
+
+	// ProbeAlertLevel is 0 (ok), 1 (warning) or 2 (critical) for the latest probe run
+	ProbeAlertLevel = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_alert_level",
+		Help: "Alert level of the latest probe run: 0 ok, 1 warning, 2 critical (threshold exceeded or timeout).",
+	})
+
+	// ReadModelSchemaDrift is 1 when the read model schema version differs from the expected one
+	ReadModelSchemaDrift = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "read_model_schema_drift",
+		Help: "1 when the read model schema version written by the Listener Service differs from the one this service expects, 0 otherwise.",
+	})
+
+	// ProbeThresholdSeconds exports the configured thresholds so alert rules can use them
+	ProbeThresholdSeconds = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "probe_threshold_seconds",
+		Help: "Configured propagation thresholds of the consistency probe, by level.",
+	}, []string{"level"})
+)
+
+// GinMiddleware records the latency and status of every request. Requests that
+// match no route are grouped under "unmatched" to keep label cardinality bounded.
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequestDuration.WithLabelValues(
+			c.Request.Method, route, strconv.Itoa(c.Writer.Status()),
+		).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
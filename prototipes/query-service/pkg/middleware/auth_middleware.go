@@ -1,28 +1,38 @@
 package middleware
 
 import (
-	"net/http"
+	"fmt"
 	"strings"
 
 	"query-service/internal/auth"
+	"query-service/internal/tenant"
 	"query-service/pkg/errors"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-// AuthMiddleware validates JWT tokens
-func AuthMiddleware(jwtManager *auth.JWTManager, logger *zap.Logger) gin.HandlerFunc {
+// APIKeyHeader carries the API key of machine clients, as an alternative to a JWT
+const APIKeyHeader = "X-API-Key"
+
+// AuthMiddleware validates JWT tokens, rejects tokens revoked through logout and
+// checks that the token's role grants the permission the request needs (see auth.RequiredPermission).
+// Without an Authorization header, an X-API-Key from apiKeys (nil disables them) is
+// accepted instead and its scopes take the place of the role.
+func AuthMiddleware(jwtManager *auth.JWTManager, rbac *auth.RBAC, tokenStore auth.TokenStore, apiKeys auth.APIKeyStore, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get token from Authorization header
 		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" && apiKeys != nil && c.GetHeader(APIKeyHeader) != "" {
+			authenticateAPIKey(c, apiKeys, logger)
+			return
+		}
 		if authHeader == "" {
 			logger.Warn("Missing authorization header",
 				zap.String("path", c.Request.URL.Path),
 				zap.String("method", c.Request.Method),
 			)
-			c.JSON(http.StatusUnauthorized, errors.NewStandardError("Unauthorized", "missing authorization header", "Header: Authorization"))
-			c.Abort()
+			errors.Respond(c, errors.NewUnauthorized("missing authorization header", "Header: Authorization"))
 			return
 		}
 
@@ -33,8 +43,7 @@ func AuthMiddleware(jwtManager *auth.JWTManager, logger *zap.Logger) gin.Handler
 				zap.String("path", c.Request.URL.Path),
 				zap.String("method", c.Request.Method),
 			)
-			c.JSON(http.StatusUnauthorized, errors.NewStandardError("Unauthorized", "invalid authorization header format", "Expected: Bearer <token>"))
-			c.Abort()
+			errors.Respond(c, errors.NewUnauthorized("invalid authorization header format", "Expected: Bearer <token>"))
 			return
 		}
 
@@ -48,8 +57,7 @@ func AuthMiddleware(jwtManager *auth.JWTManager, logger *zap.Logger) gin.Handler
 					zap.String("path", c.Request.URL.Path),
 					zap.String("method", c.Request.Method),
 				)
-				c.JSON(http.StatusUnauthorized, errors.NewStandardError("Unauthorized", "token expired", "Token has expired, please login again"))
-				c.Abort()
+				errors.Respond(c, errors.NewUnauthorized("token expired", "Token has expired, please login again"))
 				return
 			}
 
@@ -58,17 +66,70 @@ func AuthMiddleware(jwtManager *auth.JWTManager, logger *zap.Logger) gin.Handler
 				zap.String("method", c.Request.Method),
 				zap.Error(err),
 			)
-			c.JSON(http.StatusUnauthorized, errors.NewStandardError("Unauthorized", "invalid token", err.Error()))
-			c.Abort()
+			errors.Respond(c, errors.NewUnauthorized("invalid token", err.Error()))
+			return
+		}
+
+		// Reject tokens revoked by logout
+		if claims.ID != "" {
+			revoked, err := tokenStore.IsAccessTokenRevoked(c.Request.Context(), claims.ID)
+			if err != nil {
+				logger.Error("Failed to check token revocation", zap.Error(err))
+				errors.Respond(c, errors.NewServiceUnavailable("failed to check token revocation", "Token store unavailable"))
+				return
+			}
+			if revoked {
+				logger.Warn("Revoked token",
+					zap.String("username", claims.Username),
+					zap.String("path", c.Request.URL.Path),
+					zap.String("method", c.Request.Method),
+				)
+				errors.Respond(c, errors.NewUnauthorized("token revoked", "Token was revoked by logout, please login again"))
+				return
+			}
+		}
+
+		// Tokens without a tenant act for the default one
+		tenantID := tenant.OrDefault(claims.TenantID)
+		if !tenant.Valid(tenantID) {
+			logger.Warn("Invalid tenant claim",
+				zap.String("username", claims.Username),
+				zap.String("tenant_id", tenantID),
+				zap.String("path", c.Request.URL.Path),
+			)
+			errors.Respond(c, errors.NewUnauthorized("invalid token", "Claim tenant_id: "+tenantID))
+			return
+		}
+
+		// Tokens without a role get the least privileged one
+		role := claims.Role
+		if role == "" {
+			role = auth.RoleViewer
+		}
+
+		permission := auth.RequiredPermission(c.Request.Method)
+		if !rbac.HasPermission(role, permission) {
+			logger.Warn("Insufficient permissions",
+				zap.String("username", claims.Username),
+				zap.String("role", role),
+				zap.String("permission", permission),
+				zap.String("path", c.Request.URL.Path),
+				zap.String("method", c.Request.Method),
+			)
+			errors.Respond(c, errors.NewForbidden("insufficient permissions", fmt.Sprintf("Role %s lacks permission %s", role, permission)))
 			return
 		}
 
 		// Set user information in context
 		c.Set("username", claims.Username)
 		c.Set("user_id", claims.Subject)
+		c.Set("role", role)
+		setTenant(c, tenantID)
 
 		logger.Debug("Token validated",
 			zap.String("username", claims.Username),
+			zap.String("role", role),
+			zap.String("tenant_id", tenantID),
 			zap.String("path", c.Request.URL.Path),
 			zap.String("method", c.Request.Method),
 		)
@@ -77,3 +138,81 @@ func AuthMiddleware(jwtManager *auth.JWTManager, logger *zap.Logger) gin.Handler
 	}
 }
 
+// authenticateAPIKey authenticates the request with its X-API-Key and checks that the
+// key's scopes grant the permission the request needs
+func authenticateAPIKey(c *gin.Context, apiKeys auth.APIKeyStore, logger *zap.Logger) {
+	key, err := auth.Authenticate(c.Request.Context(), apiKeys, c.GetHeader(APIKeyHeader))
+	if err != nil {
+		switch err {
+		case auth.ErrAPIKeyNotFound:
+			logger.Warn("Invalid api key",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("method", c.Request.Method),
+			)
+			errors.Respond(c, errors.NewUnauthorized("invalid api key", "Header: "+APIKeyHeader))
+		case auth.ErrAPIKeyRevoked, auth.ErrAPIKeyExpired:
+			logger.Warn("Unusable api key",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("method", c.Request.Method),
+				zap.Error(err),
+			)
+			errors.Respond(c, errors.NewUnauthorized(err.Error(), "Ask an administrator for a new key"))
+		default:
+			logger.Error("Failed to check api key", zap.Error(err))
+			errors.Respond(c, errors.NewServiceUnavailable("failed to check api key", "API key store unavailable"))
+		}
+		return
+	}
+
+	permission := auth.RequiredPermission(c.Request.Method)
+	if !key.HasScope(permission) {
+		logger.Warn("Insufficient api key scopes",
+			zap.String("api_key", key.Name),
+			zap.String("permission", permission),
+			zap.String("path", c.Request.URL.Path),
+			zap.String("method", c.Request.Method),
+		)
+		errors.Respond(c, errors.NewForbidden("insufficient permissions", fmt.Sprintf("API key %s lacks scope %s", key.Name, permission)))
+		return
+	}
+
+	// The key stands in for a user
+	c.Set("username", key.Actor())
+	c.Set("user_id", key.ID)
+	c.Set("api_key", key)
+	setTenant(c, tenant.OrDefault(key.TenantID))
+
+	c.Next()
+}
+
+// setTenant makes the request act for tenantID: repositories and cache keys are scoped
+// to the tenant of the request context
+func setTenant(c *gin.Context, tenantID string) {
+	c.Set(tenant.ContextKey, tenantID)
+	c.Request = c.Request.WithContext(tenant.WithTenant(c.Request.Context(), tenantID))
+}
+
+// RequirePermission rejects requests whose role (set by AuthMiddleware) lacks permission;
+// for API keys, their scopes are checked instead.
+// Use it after AuthMiddleware for endpoints that need more than the method-based permission.
+func RequirePermission(rbac *auth.RBAC, permission string, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		granted := rbac.HasPermission(role, permission)
+		if key, ok := c.Get("api_key"); ok {
+			granted = key.(*auth.APIKey).HasScope(permission)
+		}
+		if !granted {
+			logger.Warn("Insufficient permissions",
+				zap.String("username", c.GetString("username")),
+				zap.String("role", role),
+				zap.String("permission", permission),
+				zap.String("path", c.Request.URL.Path),
+				zap.String("method", c.Request.Method),
+			)
+			errors.Respond(c, errors.NewForbidden("insufficient permissions", fmt.Sprintf("Role %s lacks permission %s", role, permission)))
+			return
+		}
+		c.Next()
+	}
+}
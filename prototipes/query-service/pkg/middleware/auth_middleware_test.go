@@ -1,24 +1,40 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"query-service/internal/auth"
+	"query-service/internal/tenant"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 )
 
-func setupAuthMiddlewareTestRouter(jwtManager *auth.JWTManager) *gin.Engine {
+func newTestRBAC(t *testing.T) *auth.RBAC {
+	rbac, err := auth.NewRBAC("")
+	if err != nil {
+		t.Fatalf("failed to create RBAC: %v", err)
+	}
+	return rbac
+}
+
+func setupAuthMiddlewareTestRouter(jwtManager *auth.JWTManager, rbac *auth.RBAC) *gin.Engine {
+	return setupAuthMiddlewareTestRouterWithStore(jwtManager, rbac, auth.NewInMemoryTokenStore())
+}
+
+func setupAuthMiddlewareTestRouterWithStore(jwtManager *auth.JWTManager, rbac *auth.RBAC, tokenStore auth.TokenStore) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 
 	// Protected route
 	protected := router.Group("/api/v1")
-	protected.Use(AuthMiddleware(jwtManager, zap.NewNop()))
+	protected.Use(AuthMiddleware(jwtManager, rbac, tokenStore, nil, zap.NewNop()))
 	{
 		protected.GET("/test", func(c *gin.Context) {
 			username, _ := c.Get("username")
@@ -36,10 +52,10 @@ func TestAuthMiddleware_ValidToken(t *testing.T) {
 	// Setup
 	logger := zap.NewNop()
 	jwtManager := auth.NewJWTManager("test-secret-key-min-32-chars-for-testing", logger)
-	router := setupAuthMiddlewareTestRouter(jwtManager)
+	router := setupAuthMiddlewareTestRouter(jwtManager, newTestRBAC(t))
 
 	// Generate token
-	token, err := jwtManager.GenerateToken("admin")
+	token, err := jwtManager.GenerateToken("admin", auth.RoleAdmin, tenant.Default)
 	assert.NoError(t, err)
 
 	// Execute
@@ -56,7 +72,7 @@ func TestAuthMiddleware_MissingToken(t *testing.T) {
 	// Setup
 	logger := zap.NewNop()
 	jwtManager := auth.NewJWTManager("test-secret-key-min-32-chars-for-testing", logger)
-	router := setupAuthMiddlewareTestRouter(jwtManager)
+	router := setupAuthMiddlewareTestRouter(jwtManager, newTestRBAC(t))
 
 	// Execute
 	req := httptest.NewRequest("GET", "/api/v1/test", nil)
@@ -71,7 +87,7 @@ func TestAuthMiddleware_InvalidTokenFormat(t *testing.T) {
 	// Setup
 	logger := zap.NewNop()
 	jwtManager := auth.NewJWTManager("test-secret-key-min-32-chars-for-testing", logger)
-	router := setupAuthMiddlewareTestRouter(jwtManager)
+	router := setupAuthMiddlewareTestRouter(jwtManager, newTestRBAC(t))
 
 	testCases := []struct {
 		name   string
@@ -99,7 +115,7 @@ func TestAuthMiddleware_InvalidToken(t *testing.T) {
 	// Setup
 	logger := zap.NewNop()
 	jwtManager := auth.NewJWTManager("test-secret-key-min-32-chars-for-testing", logger)
-	router := setupAuthMiddlewareTestRouter(jwtManager)
+	router := setupAuthMiddlewareTestRouter(jwtManager, newTestRBAC(t))
 
 	testCases := []struct {
 		name  string
@@ -131,7 +147,7 @@ func TestAuthMiddleware_ContextValues(t *testing.T) {
 	router := gin.New()
 
 	protected := router.Group("/api/v1")
-	protected.Use(AuthMiddleware(jwtManager, logger))
+	protected.Use(AuthMiddleware(jwtManager, newTestRBAC(t), auth.NewInMemoryTokenStore(), nil, logger))
 	{
 		protected.GET("/test", func(c *gin.Context) {
 			username, exists := c.Get("username")
@@ -148,7 +164,7 @@ func TestAuthMiddleware_ContextValues(t *testing.T) {
 	}
 
 	// Generate token
-	token, err := jwtManager.GenerateToken("admin")
+	token, err := jwtManager.GenerateToken("admin", auth.RoleAdmin, tenant.Default)
 	assert.NoError(t, err)
 
 	// Execute
@@ -160,3 +176,160 @@ func TestAuthMiddleware_ContextValues(t *testing.T) {
 	// Assert
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+func TestAuthMiddleware_RolePermissions(t *testing.T) {
+	// Setup: a mapping where viewers have no read access to this service
+	logger := zap.NewNop()
+	jwtManager := auth.NewJWTManager("test-secret-key-min-32-chars-for-testing", logger)
+	rbac, err := auth.NewRBAC("admin=inventory:read;viewer=")
+	assert.NoError(t, err)
+	router := setupAuthMiddlewareTestRouter(jwtManager, rbac)
+
+	testCases := []struct {
+		name         string
+		role         string
+		expectedCode int
+	}{
+		{"role with read permission", auth.RoleAdmin, http.StatusOK},
+		{"role without read permission", auth.RoleViewer, http.StatusForbidden},
+		{"role not in the mapping", auth.RoleOperator, http.StatusForbidden},
+		{"token without role is a viewer", "", http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := jwtManager.GenerateToken("someone", tc.role, tenant.Default)
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest("GET", "/api/v1/test", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+		})
+	}
+}
+
+func TestAuthMiddleware_RevokedToken(t *testing.T) {
+	// Setup
+	logger := zap.NewNop()
+	jwtManager := auth.NewJWTManager("test-secret-key-min-32-chars-for-testing", logger)
+	tokenStore := auth.NewInMemoryTokenStore()
+	router := setupAuthMiddlewareTestRouterWithStore(jwtManager, newTestRBAC(t), tokenStore)
+
+	token, err := jwtManager.GenerateToken("admin", auth.RoleAdmin, tenant.Default)
+	assert.NoError(t, err)
+	claims, err := jwtManager.ValidateToken(token)
+	assert.NoError(t, err)
+
+	// Revoke (as logout does)
+	assert.NoError(t, tokenStore.RevokeAccessToken(context.Background(), claims.ID, time.Minute))
+
+	// Execute
+	req := httptest.NewRequest("GET", "/api/v1/test", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "token revoked")
+}
+
+func TestRequirePermission(t *testing.T) {
+	// Setup
+	logger := zap.NewNop()
+	jwtManager := auth.NewJWTManager("test-secret-key-min-32-chars-for-testing", logger)
+	rbac := newTestRBAC(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/v1/auth/users",
+		AuthMiddleware(jwtManager, rbac, auth.NewInMemoryTokenStore(), nil, logger),
+		RequirePermission(rbac, auth.PermissionManageUsers, logger),
+		func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	testCases := []struct {
+		name         string
+		role         string
+		expectedCode int
+	}{
+		{"admin manages users", auth.RoleAdmin, http.StatusCreated},
+		{"operator cannot", auth.RoleOperator, http.StatusForbidden},
+		{"viewer cannot", auth.RoleViewer, http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := jwtManager.GenerateToken("someone", tc.role, tenant.Default)
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest("POST", "/api/v1/auth/users", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+		})
+	}
+}
+
+func TestAuthMiddleware_APIKey(t *testing.T) {
+	// Setup
+	logger := zap.NewNop()
+	jwtManager := auth.NewJWTManager("test-secret-key-min-32-chars-for-testing", logger)
+	rbac := newTestRBAC(t)
+	store, err := auth.NewSQLiteAPIKeyStore(filepath.Join(t.TempDir(), "api_keys.db"))
+	assert.NoError(t, err)
+	defer store.Close()
+
+	ctx := context.Background()
+	readKey, record, err := auth.NewAPIKey("pos-store-12", []string{auth.PermissionRead}, tenant.Default, "admin", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, store.CreateAPIKey(ctx, record))
+	noReadKey, record, err := auth.NewAPIKey("writer", []string{auth.PermissionWrite}, tenant.Default, "admin", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, store.CreateAPIKey(ctx, record))
+	revokedKey, record, err := auth.NewAPIKey("old-terminal", []string{auth.PermissionRead}, tenant.Default, "admin", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, store.CreateAPIKey(ctx, record))
+	_, err = store.RevokeAPIKey(ctx, record.ID, time.Now())
+	assert.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	authenticate := AuthMiddleware(jwtManager, rbac, auth.NewInMemoryTokenStore(), store, logger)
+	router.GET("/api/v1/test", authenticate, func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"username": c.GetString("username")})
+	})
+	router.POST("/api/v1/auth/users", authenticate, RequirePermission(rbac, auth.PermissionManageUsers, logger),
+		func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	testCases := []struct {
+		name         string
+		method, path string
+		key          string
+		expectedCode int
+	}{
+		{"read key", "GET", "/api/v1/test", readKey, http.StatusOK},
+		{"key without read scope", "GET", "/api/v1/test", noReadKey, http.StatusForbidden},
+		{"revoked key", "GET", "/api/v1/test", revokedKey, http.StatusUnauthorized},
+		{"unknown key", "GET", "/api/v1/test", auth.APIKeyPrefix + "unknown", http.StatusUnauthorized},
+		{"keys cannot manage users", "POST", "/api/v1/auth/users", readKey, http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			req.Header.Set(APIKeyHeader, tc.key)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			if tc.expectedCode == http.StatusOK {
+				assert.Contains(t, w.Body.String(), `"username":"apikey:pos-store-12"`)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+
+	"query-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultMaxBodyBytes is the request body limit used when none is configured (1 MiB)
+const DefaultMaxBodyBytes int64 = 1 << 20
+
+// BodyLimit caps request bodies at maxBytes. Requests that declare a larger Content-Length are
+// rejected with 413 before the body is read; streamed bodies fail when the limit is crossed,
+// which errors.NewBindingError reports as PayloadTooLarge.
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBodyBytes
+	}
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > maxBytes {
+			errors.Respond(c, errors.NewPayloadTooLarge(maxBytes))
+			return
+		}
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"query-service/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(BodyLimit(32))
+	router.POST("/availability", func(c *gin.Context) {
+		var req struct {
+			SKU string `json:"sku"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			errors.Respond(c, errors.NewBindingError(err))
+			return
+		}
+		c.JSON(http.StatusOK, req)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/availability", bytes.NewBufferString(`{"sku":"SKU-001"}`)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	large := `{"sku":"` + string(bytes.Repeat([]byte("x"), 64)) + `"}`
+	// Declared Content-Length over the limit: rejected before the handler runs
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/availability", bytes.NewBufferString(large)))
+	assertPayloadTooLarge(t, w)
+
+	// Unknown length (chunked): rejected when the handler reads past the limit
+	req := httptest.NewRequest("POST", "/availability", io.NopCloser(bytes.NewBufferString(large)))
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assertPayloadTooLarge(t, w)
+}
+
+func assertPayloadTooLarge(t *testing.T, w *httptest.ResponseRecorder) {
+	t.Helper()
+	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	var body errors.StandardError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, errors.CodePayloadTooLarge, body.Code)
+	assert.Equal(t, "Limit: 32 bytes", body.Details)
+}
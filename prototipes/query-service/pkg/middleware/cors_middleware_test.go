@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupCORSRouter(t *testing.T, origins ...string) *gin.Engine {
+	cfg, err := NewCORSConfig(origins, []string{"Content-Type", "Authorization"}, 600)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CORSMiddleware(cfg))
+	router.GET("/items", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"total": 1})
+	})
+	return router
+}
+
+func corsRequest(router *gin.Engine, method, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/items", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if method == http.MethodOptions {
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSMiddleware_AllowedOrigin(t *testing.T) {
+	router := setupCORSRouter(t, "http://localhost:8000")
+
+	w := corsRequest(router, http.MethodGet, "http://localhost:8000")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "http://localhost:8000", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "Content-Type, Authorization", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "Origin", w.Header().Get("Vary"))
+
+	w = corsRequest(router, http.MethodOptions, "http://localhost:8000")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+}
+
+func TestCORSMiddleware_RejectedOrigin(t *testing.T) {
+	router := setupCORSRouter(t, "http://localhost:8000")
+
+	// The request is served, but the browser gets no CORS headers and blocks it
+	w := corsRequest(router, http.MethodGet, "https://evil.example.com")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = corsRequest(router, http.MethodOptions, "https://evil.example.com")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSMiddleware_WildcardWithoutCredentials(t *testing.T) {
+	router := setupCORSRouter(t, "*")
+
+	w := corsRequest(router, http.MethodGet, "https://any.example.com")
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORSMiddleware_NoOrigin(t *testing.T) {
+	router := setupCORSRouter(t)
+
+	w := corsRequest(router, http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestNewCORSConfig_ValidatesOrigins(t *testing.T) {
+	cfg, err := NewCORSConfig([]string{"HTTP://Localhost:8000/", "*"}, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://localhost:8000", "*"}, cfg.AllowedOrigins)
+
+	for _, origin := range []string{"localhost:8000", "http://localhost:8000/app", "not an origin"} {
+		_, err := NewCORSConfig([]string{origin}, nil, 0)
+		assert.Error(t, err, origin)
+	}
+}
@@ -0,0 +1,235 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// EnvelopeParam is the Accept media-type parameter that turns the envelope on or
+	// off for one request: "Accept: application/json; envelope=true"
+	EnvelopeParam = "envelope"
+
+	envelopeWarningsKey = "envelope_warnings"
+	envelopeActiveKey   = "envelope_active"
+)
+
+// Envelope is the body sent instead of the plain JSON response when the envelope is on
+type Envelope struct {
+	Data json.RawMessage `json:"data"`
+	Meta EnvelopeMeta    `json:"meta"`
+}
+
+// EnvelopeMeta describes how the request was served
+type EnvelopeMeta struct {
+	RequestID  string   `json:"request_id,omitempty"`
+	DurationMs float64  `json:"duration_ms"`
+	Cache      string   `json:"cache,omitempty"` // "hit" or "miss"; omitted when no cache lookup was made
+	Warnings   []string `json:"warnings"`
+}
+
+// EnvelopeAnnotator prepares the request context before the handler runs and returns
+// a function that fills in the metadata it collected (e.g. the cache lookups made)
+type EnvelopeAnnotator func(ctx context.Context) (context.Context, func(meta *EnvelopeMeta))
+
+// ResponseEnvelope wraps JSON responses as {data, meta} so clients and support can see
+// the request ID, server time, cache result and warnings without reading the logs.
+//
+// enabledByDefault applies the envelope to every response (RESPONSE_ENVELOPE); a client
+// can still opt in or out per request with the envelope parameter of the Accept header.
+// Non-JSON and streamed responses are passed through unchanged. Must run after
+// RequestIDMiddleware.
+func ResponseEnvelope(enabledByDefault bool, annotators ...EnvelopeAnnotator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !envelopeRequested(c.GetHeader("Accept"), enabledByDefault) {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		fills := make([]func(*EnvelopeMeta), 0, len(annotators))
+		ctx := c.Request.Context()
+		for _, annotate := range annotators {
+			var fill func(*EnvelopeMeta)
+			ctx, fill = annotate(ctx)
+			fills = append(fills, fill)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Set(envelopeActiveKey, true)
+
+		writer := &envelopeWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		c.Writer = writer.ResponseWriter
+		if writer.streaming {
+			return
+		}
+
+		body := writer.body.Bytes()
+		contentType := writer.Header().Get("Content-Type")
+		if !strings.HasPrefix(contentType, "application/json") || !json.Valid(body) {
+			writer.flushBuffered()
+			return
+		}
+
+		meta := EnvelopeMeta{
+			RequestID:  GetRequestID(c),
+			DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+			Warnings:   Warnings(c),
+		}
+		for _, fill := range fills {
+			fill(&meta)
+		}
+		if meta.Warnings == nil {
+			meta.Warnings = []string{}
+		}
+
+		wrapped, err := json.Marshal(Envelope{Data: body, Meta: meta})
+		if err != nil {
+			writer.flushBuffered()
+			return
+		}
+		writer.Header().Del("Content-Length")
+		writer.ResponseWriter.WriteHeader(writer.status())
+		_, _ = writer.ResponseWriter.Write(wrapped)
+	}
+}
+
+// AddWarning attaches a warning to the envelope of the current request (e.g. a
+// parameter that was adjusted). Without the envelope the warning is dropped.
+func AddWarning(c *gin.Context, warning string) {
+	c.Set(envelopeWarningsKey, append(Warnings(c), warning))
+}
+
+// EnvelopeActive reports whether the JSON response of the current request will be
+// wrapped in the envelope, so handlers do not write bytes it cannot wrap (e.g. gzip)
+func EnvelopeActive(c *gin.Context) bool {
+	return c.GetBool(envelopeActiveKey)
+}
+
+// Warnings returns the warnings added to the current request
+func Warnings(c *gin.Context) []string {
+	if value, exists := c.Get(envelopeWarningsKey); exists {
+		if warnings, ok := value.([]string); ok {
+			return warnings
+		}
+	}
+	return nil
+}
+
+// envelopeRequested reads the envelope parameter of the Accept header, falling back to
+// the configured default
+func envelopeRequested(accept string, enabledByDefault bool) bool {
+	for _, part := range strings.Split(accept, ",") {
+		_, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if value, ok := params[EnvelopeParam]; ok {
+			if enabled, err := strconv.ParseBool(value); err == nil {
+				return enabled
+			}
+		}
+	}
+	return enabledByDefault
+}
+
+// envelopeWriter holds the response back until the handler chain has finished so it
+// can be wrapped. A Flush (server-sent events) switches it to pass-through.
+type envelopeWriter struct {
+	gin.ResponseWriter
+	body       bytes.Buffer
+	statusCode int
+	streaming  bool
+}
+
+func (w *envelopeWriter) WriteHeader(code int) {
+	if w.streaming {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code > 0 {
+		w.statusCode = code
+	}
+}
+
+// WriteHeaderNow is deferred to the end of the request like the body
+func (w *envelopeWriter) WriteHeaderNow() {
+	if w.streaming {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *envelopeWriter) Write(b []byte) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.body.Write(b)
+}
+
+func (w *envelopeWriter) WriteString(s string) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.WriteString(s)
+	}
+	return w.body.WriteString(s)
+}
+
+func (w *envelopeWriter) Status() int {
+	if w.streaming {
+		return w.ResponseWriter.Status()
+	}
+	return w.status()
+}
+
+func (w *envelopeWriter) Size() int {
+	if w.streaming {
+		return w.ResponseWriter.Size()
+	}
+	if w.body.Len() == 0 {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *envelopeWriter) Written() bool {
+	if w.streaming {
+		return w.ResponseWriter.Written()
+	}
+	return w.body.Len() > 0
+}
+
+func (w *envelopeWriter) Flush() {
+	if !w.streaming {
+		w.flushBuffered()
+		w.streaming = true
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *envelopeWriter) status() int {
+	if w.statusCode == 0 {
+		return http.StatusOK
+	}
+	return w.statusCode
+}
+
+// flushBuffered sends what was held back without wrapping it
+func (w *envelopeWriter) flushBuffered() {
+	w.ResponseWriter.WriteHeader(w.status())
+	if w.body.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.body.Bytes())
+	} else {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+	w.body.Reset()
+}
@@ -27,7 +27,7 @@ func ErrorHandler(logger *zap.Logger) gin.HandlerFunc {
 					zap.String("path", c.Request.URL.Path),
 					zap.String("method", c.Request.Method),
 				)
-				c.JSON(stdErr.HTTPStatus(), stdErr)
+				c.JSON(stdErr.HTTPStatus(), stdErr.ForRequest(c))
 				return
 			}
 
@@ -37,7 +37,7 @@ func ErrorHandler(logger *zap.Logger) gin.HandlerFunc {
 				zap.String("path", c.Request.URL.Path),
 				zap.String("method", c.Request.Method),
 			)
-			c.JSON(http.StatusInternalServerError, errors.NewInternalError("internal server error", err))
+			c.JSON(http.StatusInternalServerError, errors.NewInternalError("internal server error", err).ForRequest(c))
 		}
 	}
 }
@@ -50,7 +50,6 @@ func RecoveryHandler(logger *zap.Logger) gin.HandlerFunc {
 			zap.String("path", c.Request.URL.Path),
 			zap.String("method", c.Request.Method),
 		)
-		c.JSON(http.StatusInternalServerError, errors.NewInternalError("internal server error", nil))
+		errors.Respond(c, errors.NewInternalError("internal server error", nil))
 	})
 }
-
@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultGzipMinSize is the smallest body worth compressing: below it the gzip header
+// and the CPU cost outweigh the bytes saved
+const DefaultGzipMinSize = 1024
+
+var gzipWriters = sync.Pool{New: func() interface{} {
+	w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+	return w
+}}
+
+// Gzip compresses the responses of clients that send Accept-Encoding: gzip once the
+// body reaches minSize bytes. Responses that already have a Content-Encoding (e.g. a
+// pre-compressed cache entry) and server-sent events are passed through. Must run
+// before ResponseEnvelope so the envelope is compressed too.
+func Gzip(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		if !AcceptsGzip(c.GetHeader("Accept-Encoding")) || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		writer := &gzipWriter{ResponseWriter: c.Writer, minSize: minSize}
+		c.Writer = writer
+		defer func() {
+			writer.finish()
+			c.Writer = writer.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// AcceptsGzip reports whether an Accept-Encoding header allows gzip (q > 0, or "*")
+func AcceptsGzip(acceptEncoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if value, err := strconv.ParseFloat(q, 64); err == nil && value <= 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipWriter holds the body back until it reaches minSize, then decides once whether
+// to compress it. Bodies that end smaller are sent as they are.
+type gzipWriter struct {
+	gin.ResponseWriter
+	minSize    int
+	buffer     bytes.Buffer
+	statusCode int
+	decided    bool
+	gz         *gzip.Writer
+}
+
+func (w *gzipWriter) WriteHeader(code int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code > 0 {
+		w.statusCode = code
+	}
+}
+
+// WriteHeaderNow is deferred until the compression is decided
+func (w *gzipWriter) WriteHeaderNow() {
+	if w.decided {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *gzipWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buffer.Write(b)
+		if w.buffer.Len() < w.minSize {
+			return len(b), nil
+		}
+		w.decide(true)
+		return len(b), w.flushBuffered()
+	}
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *gzipWriter) Status() int {
+	if !w.decided {
+		return w.status()
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *gzipWriter) Size() int {
+	if !w.decided {
+		if w.buffer.Len() == 0 {
+			return -1
+		}
+		return w.buffer.Len()
+	}
+	return w.ResponseWriter.Size()
+}
+
+func (w *gzipWriter) Written() bool {
+	if !w.decided {
+		return w.buffer.Len() > 0
+	}
+	return w.ResponseWriter.Written()
+}
+
+// Flush sends what was written so far: a streamed response cannot wait for minSize
+func (w *gzipWriter) Flush() {
+	if !w.decided {
+		w.decide(w.buffer.Len() > 0)
+		_ = w.flushBuffered()
+	}
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide writes the status and headers, compressing when the body is big enough (large)
+// and not already encoded
+func (w *gzipWriter) decide(large bool) {
+	w.decided = true
+	header := w.ResponseWriter.Header()
+	if large && header.Get("Content-Encoding") == "" &&
+		!strings.HasPrefix(header.Get("Content-Type"), "text/event-stream") {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		w.gz = gzipWriters.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status())
+}
+
+func (w *gzipWriter) flushBuffered() error {
+	if w.buffer.Len() == 0 {
+		w.ResponseWriter.WriteHeaderNow()
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(w.buffer.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buffer.Bytes())
+	}
+	w.buffer.Reset()
+	return err
+}
+
+// finish sends a body that stayed below minSize and closes the gzip stream
+func (w *gzipWriter) finish() {
+	if !w.decided {
+		w.decide(false)
+		_ = w.flushBuffered()
+	}
+	if w.gz != nil {
+		_ = w.gz.Close()
+		w.gz.Reset(nil)
+		gzipWriters.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+func (w *gzipWriter) status() int {
+	if w.statusCode == 0 {
+		return http.StatusOK
+	}
+	return w.statusCode
+}
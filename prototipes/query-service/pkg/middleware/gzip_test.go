@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupGzipRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestIDMiddleware(zap.NewNop()))
+	router.Use(Gzip(DefaultGzipMinSize))
+	router.Use(ResponseEnvelope(false))
+	router.GET("/large", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"payload": strings.Repeat("SKU-001 ", 500)})
+	})
+	router.GET("/small", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"total": 1})
+	})
+	router.GET("/encoded", func(c *gin.Context) {
+		c.Header("Content-Encoding", "br")
+		c.Data(http.StatusOK, "application/json", []byte(strings.Repeat("x", 2048)))
+	})
+	router.GET("/not-modified", func(c *gin.Context) {
+		c.Status(http.StatusNotModified)
+	})
+	return router
+}
+
+func gunzip(t *testing.T, body io.Reader) []byte {
+	gz, err := gzip.NewReader(body)
+	require.NoError(t, err)
+	defer gz.Close()
+	data, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	return data
+}
+
+func TestGzip_CompressesLargeResponses(t *testing.T) {
+	router := setupGzipRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/large", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Contains(t, w.Header().Values("Vary"), "Accept-Encoding")
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(gunzip(t, w.Body), &body))
+	assert.Equal(t, strings.Repeat("SKU-001 ", 500), body["payload"])
+}
+
+func TestGzip_CompressesTheEnvelope(t *testing.T) {
+	router := setupGzipRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/large", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("Accept", "application/json; envelope=true")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	var envelope Envelope
+	require.NoError(t, json.Unmarshal(gunzip(t, w.Body), &envelope))
+	assert.NotEmpty(t, envelope.Meta.RequestID)
+}
+
+func TestGzip_PassesThrough(t *testing.T) {
+	router := setupGzipRouter()
+
+	for _, tc := range []struct {
+		path           string
+		acceptEncoding string
+		status         int
+	}{
+		{"/small", "gzip", http.StatusOK},
+		{"/large", "", http.StatusOK},
+		{"/large", "gzip;q=0, identity", http.StatusOK},
+		{"/encoded", "gzip", http.StatusOK},
+		{"/not-modified", "gzip", http.StatusNotModified},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tc.status, w.Code, tc.path)
+		assert.NotEqual(t, "gzip", w.Header().Get("Content-Encoding"), tc.path)
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	assert.True(t, AcceptsGzip("gzip"))
+	assert.True(t, AcceptsGzip("deflate, GZIP;q=0.5"))
+	assert.True(t, AcceptsGzip("*"))
+	assert.False(t, AcceptsGzip(""))
+	assert.False(t, AcceptsGzip("br, deflate"))
+	assert.False(t, AcceptsGzip("gzip;q=0"))
+}
@@ -0,0 +1,98 @@
+package testsupport
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Message is a record published to a Broker topic, shaped like a Kafka message
+type Message struct {
+	Topic     string
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+	Offset    int64
+	Timestamp time.Time
+}
+
+// Broker is an in-memory stand-in for Kafka. Topics are append-only logs that
+// subscriptions read from the oldest message, like a new consumer group.
+type Broker struct {
+	mu     sync.Mutex
+	topics map[string][]Message
+	// notify is closed (and replaced) on every publish to wake up waiting subscriptions
+	notify chan struct{}
+}
+
+// NewBroker creates an empty broker
+func NewBroker() *Broker {
+	return &Broker{
+		topics: make(map[string][]Message),
+		notify: make(chan struct{}),
+	}
+}
+
+// Publish appends msg to its topic and returns it with its offset and timestamp set
+func (b *Broker) Publish(msg Message) Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	msg.Offset = int64(len(b.topics[msg.Topic]))
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+	b.topics[msg.Topic] = append(b.topics[msg.Topic], msg)
+
+	close(b.notify)
+	b.notify = make(chan struct{})
+	return msg
+}
+
+// Messages returns a copy of the messages published to topic
+func (b *Broker) Messages(topic string) []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Message(nil), b.topics[topic]...)
+}
+
+// Subscribe returns a subscription to topics, starting at the oldest message
+func (b *Broker) Subscribe(topics ...string) *Subscription {
+	return &Subscription{
+		broker:  b,
+		topics:  topics,
+		offsets: make(map[string]int, len(topics)),
+	}
+}
+
+// Subscription reads messages from one or more topics. It is not safe for concurrent use.
+type Subscription struct {
+	broker  *Broker
+	topics  []string
+	offsets map[string]int
+}
+
+// Next returns the next unread message, waiting for one to be published if needed.
+// Messages of a topic are returned in order; topics are read in subscription order.
+func (s *Subscription) Next(ctx context.Context) (Message, error) {
+	for {
+		s.broker.mu.Lock()
+		for _, topic := range s.topics {
+			log := s.broker.topics[topic]
+			if offset := s.offsets[topic]; offset < len(log) {
+				s.offsets[topic] = offset + 1
+				msg := log[offset]
+				s.broker.mu.Unlock()
+				return msg, nil
+			}
+		}
+		notify := s.broker.notify
+		s.broker.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return Message{}, ctx.Err()
+		case <-notify:
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package testsupport
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBroker_SubscribeReadsFromOldest(t *testing.T) {
+	broker := NewBroker()
+	broker.Publish(Message{Topic: "items", Value: []byte("1")})
+	broker.Publish(Message{Topic: "stock", Value: []byte("2")})
+	broker.Publish(Message{Topic: "items", Value: []byte("3")})
+
+	sub := broker.Subscribe("items")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for i, want := range []string{"1", "3"} {
+		msg, err := sub.Next(ctx)
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		if string(msg.Value) != want || msg.Offset != int64(i) {
+			t.Fatalf("message %d = %q (offset %d), want %q (offset %d)", i, msg.Value, msg.Offset, want, i)
+		}
+	}
+}
+
+func TestBroker_NextWaitsForPublish(t *testing.T) {
+	broker := NewBroker()
+	sub := broker.Subscribe("items")
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		broker.Publish(Message{Topic: "items", Key: []byte("k"), Headers: map[string]string{"event-type": "X"}})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	msg, err := sub.Next(ctx)
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if string(msg.Key) != "k" || msg.Headers["event-type"] != "X" || msg.Timestamp.IsZero() {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+}
+
+func TestBroker_NextReturnsOnCancel(t *testing.T) {
+	sub := NewBroker().Subscribe("items")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := sub.Next(ctx); err != context.Canceled {
+		t.Fatalf("Next() error = %v, want context.Canceled", err)
+	}
+}
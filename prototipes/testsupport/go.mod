@@ -0,0 +1,3 @@
+module testsupport
+
+go 1.20
@@ -0,0 +1,89 @@
+package testsupport
+
+import (
+	"path"
+	"sync"
+	"time"
+)
+
+// KV is an in-memory stand-in for Redis: string keys, byte values and per-key TTLs
+type KV struct {
+	mu   sync.Mutex
+	data map[string]kvEntry
+}
+
+type kvEntry struct {
+	value     []byte
+	expiresAt time.Time // zero = no expiry
+}
+
+// NewKV creates an empty store
+func NewKV() *KV {
+	return &KV{data: make(map[string]kvEntry)}
+}
+
+// Get returns the value of key and whether it exists
+func (kv *KV) Get(key string) ([]byte, bool) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	entry, ok := kv.live(key)
+	return entry.value, ok
+}
+
+// Set stores value under key; ttl <= 0 means no expiry
+func (kv *KV) Set(key string, value []byte, ttl time.Duration) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	entry := kvEntry{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	kv.data[key] = entry
+}
+
+// GetDel returns the value of key and removes it, like Redis GETDEL
+func (kv *KV) GetDel(key string) ([]byte, bool) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	entry, ok := kv.live(key)
+	delete(kv.data, key)
+	return entry.value, ok
+}
+
+// Del removes keys
+func (kv *KV) Del(keys ...string) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	for _, key := range keys {
+		delete(kv.data, key)
+	}
+}
+
+// Keys returns the live keys matching a Redis-style glob pattern ("items:*")
+func (kv *KV) Keys(pattern string) []string {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	var keys []string
+	for key := range kv.data {
+		if _, ok := kv.live(key); !ok {
+			continue
+		}
+		if matched, _ := path.Match(pattern, key); matched {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// live returns the entry for key, dropping it if it has expired. Callers hold kv.mu.
+func (kv *KV) live(key string) (kvEntry, bool) {
+	entry, ok := kv.data[key]
+	if !ok {
+		return kvEntry{}, false
+	}
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		delete(kv.data, key)
+		return kvEntry{}, false
+	}
+	return entry, true
+}
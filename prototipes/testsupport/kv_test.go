@@ -0,0 +1,32 @@
+package testsupport
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestKV_TTLAndPatterns(t *testing.T) {
+	kv := NewKV()
+	kv.Set("items:1", []byte("a"), 0)
+	kv.Set("items:2", []byte("b"), time.Millisecond)
+	kv.Set("stores:1", []byte("c"), 0)
+
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := kv.Get("items:2"); ok {
+		t.Fatal("expired key is still readable")
+	}
+
+	keys := kv.Keys("items:*")
+	sort.Strings(keys)
+	if len(keys) != 1 || keys[0] != "items:1" {
+		t.Fatalf("Keys(items:*) = %v, want [items:1]", keys)
+	}
+
+	if value, ok := kv.GetDel("stores:1"); !ok || string(value) != "c" {
+		t.Fatalf("GetDel() = %q, %v", value, ok)
+	}
+	if _, ok := kv.Get("stores:1"); ok {
+		t.Fatal("GetDel did not remove the key")
+	}
+}
@@ -0,0 +1,8 @@
+package testsupport
+
+// SQLiteMemoryDSN returns a DSN for a named in-memory SQLite database. Connections
+// opened with the same name share the database, which lives while at least one of
+// them is open. Append further parameters with "&".
+func SQLiteMemoryDSN(name string) string {
+	return "file:" + name + "?mode=memory&cache=shared"
+}
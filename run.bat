@@ -179,7 +179,7 @@ if not exist "html\server.go" (
 
 REM Iniciar Dashboard Server
 echo Iniciando Dashboard Server (puerto 8000)...
-start "Dashboard Server (8000)" cmd /k "cd /d %SCRIPT_DIR%html && echo [Dashboard Server] Iniciando en puerto 8000... && go run server.go"
+start "Dashboard Server (8000)" cmd /k "cd /d %SCRIPT_DIR%html && echo [Dashboard Server] Iniciando en puerto 8000... && go run ."
 echo ✅ Dashboard Server iniciado (ventana separada)
 timeout /t 3 /nobreak >nul
 
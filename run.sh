@@ -253,7 +253,7 @@ cat > "$dashboard_script" << EOF
 cd "$PROJECT_ROOT/html"
 echo "[Dashboard Server] Iniciando en puerto 8000..."
 echo "Directorio: \$(pwd)"
-go run server.go
+go run .
 EOF
 chmod +x "$dashboard_script"
 